@@ -66,6 +66,7 @@ const (
 	NotPermError          FsError = FsError(int(unix.EPERM))        // Operation not permitted
 	NotFoundError         FsError = FsError(int(unix.ENOENT))       // No such file or directory
 	IOError               FsError = FsError(int(unix.EIO))          // I/O error
+	NoDeviceOrAddrError   FsError = FsError(int(unix.ENXIO))        // No such device or address
 	ReadOnlyError         FsError = FsError(int(unix.EROFS))        // Read-only file system
 	TooBigError           FsError = FsError(int(unix.E2BIG))        // Argument list too long
 	TooManyArgsError      FsError = FsError(int(unix.E2BIG))        // Arg list too long
@@ -76,6 +77,7 @@ const (
 	BadAddressError       FsError = FsError(int(unix.EFAULT))       // Bad address
 	DevBusyError          FsError = FsError(int(unix.EBUSY))        // Device or resource busy
 	FileExistsError       FsError = FsError(int(unix.EEXIST))       // File exists
+	CrossDeviceError      FsError = FsError(int(unix.EXDEV))        // Cross-device link
 	NoDeviceError         FsError = FsError(int(unix.ENODEV))       // No such device
 	NotDirError           FsError = FsError(int(unix.ENOTDIR))      // Not a directory
 	IsDirError            FsError = FsError(int(unix.EISDIR))       // Is a directory
@@ -84,6 +86,7 @@ const (
 	TooManyOpenFilesError FsError = FsError(int(unix.EMFILE))       // Too many open files
 	FileTooLargeError     FsError = FsError(int(unix.EFBIG))        // File too large
 	NoSpaceError          FsError = FsError(int(unix.ENOSPC))       // No space left on device
+	QuotaExceededError    FsError = FsError(int(unix.EDQUOT))       // Quota exceeded
 	BadSeekError          FsError = FsError(int(unix.ESPIPE))       // Illegal seek
 	TooManyLinksError     FsError = FsError(int(unix.EMLINK))       // Too many links
 	OutOfRangeError       FsError = FsError(int(unix.ERANGE))       // Math result not representable
@@ -107,6 +110,7 @@ const (
 	SegNumNotIntError     FsError = IOError
 	SegNotFoundError      FsError = IOError
 	SegReadError          FsError = IOError
+	ChecksumError         FsError = IOError
 	InodeFlushError       FsError = IOError
 	BtreeDeleteError      FsError = IOError
 	BtreePutError         FsError = IOError
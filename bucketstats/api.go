@@ -87,6 +87,8 @@ type Bucketer interface {
 //
 // statsStruct is a pointer to a structure which has one or more fields holding
 // statistics.  It may also contain other fields that are not bucketstats types.
+// The statistics of an embedded structure are treated as if they were fields of
+// statsStruct itself.
 //
 // The combination of pkgName and statsGroupName must be unique.  pkgName is
 // typically the name of a pacakge and statsGroupName is the name for the group
@@ -95,7 +97,33 @@ type Bucketer interface {
 // not allowed in either name.
 //
 func Register(pkgName string, statsGroupName string, statsStruct interface{}) {
-	register(pkgName, statsGroupName, statsStruct)
+	register(pkgName, statsGroupName, nil, statsStruct, nil)
+}
+
+// Register and initialize a set of statistics that carry additional labels.
+//
+// This is identical to Register() except that labels (e.g. {"volume": "CommonVolume"})
+// will accompany each of the statistics when they are exported via
+// SprintPrometheusStats(). Label names must match [a-zA-Z_][a-zA-Z0-9_]* and
+// may not be "pkg", "group", or "le" as those labels are supplied by
+// SprintPrometheusStats() itself.
+//
+func RegisterWithLabels(pkgName string, statsGroupName string, labels map[string]string, statsStruct interface{}) {
+	register(pkgName, statsGroupName, labels, statsStruct, nil)
+}
+
+// Register and initialize a set of statistics that carry additional labels and
+// that are also accumulated in an aggregate set of statistics.
+//
+// This is identical to RegisterWithLabels() except that each value added to a
+// statistic in statsStruct is also added to the statistic of the same field name
+// in aggregateStruct (e.g. the statistics of each volume may be accumulated in
+// those of the package as a whole). aggregateStruct must be a pointer to a
+// structure with a field of the same name and type as each statistic in
+// statsStruct. It is typically registered itself, but need not be.
+//
+func RegisterWithLabelsAndAggregate(pkgName string, statsGroupName string, labels map[string]string, statsStruct interface{}, aggregateStruct interface{}) {
+	register(pkgName, statsGroupName, labels, statsStruct, aggregateStruct)
 }
 
 // UnRegister a set of statistics.
@@ -120,22 +148,41 @@ func SprintStats(stringFmt StatStringFormat, pkgName string, statsGroupName stri
 	return sprintStats(stringFmt, pkgName, statsGroupName)
 }
 
+// Print one or more groups of statistics in the Prometheus text exposition format.
+//
+// The selection of statistics is as for SprintStats(). Each statistic is named
+// by its pkgName and Name (with characters not legal in a Prometheus metric name
+// replaced by '_') and labeled with "pkg", "group", and any labels supplied to
+// RegisterWithLabels(). A Total is reported as a counter, an Average as a
+// summary (without quantiles), and the bucketized statistics as histograms whose
+// "le" bucket boundaries are the RangeHigh values of their BucketInfo (omitting
+// buckets beyond the last one to have been added to). As with TotalGet(), the
+// "_sum" of a histogram is estimated from the MeanVal of each bucket.
+//
+func SprintPrometheusStats(pkgName string, statsGroupName string) (values string) {
+	return sprintPrometheusStats(pkgName, statsGroupName)
+}
+
 // Total is a simple totaler. It supports the Totaler interface.
 //
 // Name must be unique within statistics in the structure.  If it is "" then
 // Register() will assign a name based on the name of the field.
 //
 type Total struct {
-	total uint64 // Ensure 64-bit alignment
-	Name  string
+	total     uint64 // Ensure 64-bit alignment
+	Name      string
+	aggregate *Total // See RegisterWithLabelsAndAggregate()
 }
 
 func (this *Total) Add(value uint64) {
 	atomicAddUint64(&this.total, value)
+	if this.aggregate != nil {
+		this.aggregate.Add(value)
+	}
 }
 
 func (this *Total) Increment() {
-	atomicAddUint64(&this.total, 1)
+	this.Add(1)
 }
 
 func (this *Total) TotalGet() uint64 {
@@ -155,9 +202,10 @@ func (this *Total) Sprint(stringFmt StatStringFormat, pkgName string, statsGroup
 // Register() will assign a name based on the name of the field.
 //
 type Average struct {
-	count uint64 // Ensure 64-bit alignment
-	total uint64 // Ensure 64-bit alignment
-	Name  string
+	count     uint64 // Ensure 64-bit alignment
+	total     uint64 // Ensure 64-bit alignment
+	Name      string
+	aggregate *Average // See RegisterWithLabelsAndAggregate()
 }
 
 // Add a value to the mean statistics.
@@ -165,6 +213,9 @@ type Average struct {
 func (this *Average) Add(value uint64) {
 	atomicAddUint64(&this.total, value)
 	atomicAddUint64(&this.count, 1)
+	if this.aggregate != nil {
+		this.aggregate.Add(value)
+	}
 }
 
 // Add a value of 1 to the mean statistics.
@@ -222,9 +273,14 @@ type BucketLog2Round struct {
 	Name        string
 	NBucket     uint
 	statBuckets [65]uint32
+	aggregate   *BucketLog2Round // See RegisterWithLabelsAndAggregate()
 }
 
 func (this *BucketLog2Round) Add(value uint64) {
+	if this.aggregate != nil {
+		this.aggregate.Add(value)
+	}
+
 	if value < 256 {
 		idx := log2RoundIdxTable[value]
 		atomic.AddUint32(&this.statBuckets[idx], 1)
@@ -311,9 +367,14 @@ type BucketLogRoot2Round struct {
 	Name        string
 	NBucket     uint
 	statBuckets [128]uint32
+	aggregate   *BucketLogRoot2Round // See RegisterWithLabelsAndAggregate()
 }
 
 func (this *BucketLogRoot2Round) Add(value uint64) {
+	if this.aggregate != nil {
+		this.aggregate.Add(value)
+	}
+
 	if value < 256 {
 		idx := logRoot2RoundIdxTable[value]
 		atomic.AddUint32(&this.statBuckets[idx], 1)
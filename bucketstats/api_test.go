@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math/rand"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -548,6 +549,179 @@ func TestSprintStats(t *testing.T) {
 	}
 }
 
+func TestSprintPrometheusStats(t *testing.T) {
+
+	var (
+		testFunc func()
+		panicStr string
+	)
+
+	// sprint'ing unregistered stats group should panic
+	testFunc = func() {
+		fmt.Print(SprintPrometheusStats("main", "no-such-stats"))
+	}
+	panicStr = catchAPanic(testFunc)
+	if panicStr == "" {
+		t.Errorf("SprintPrometheusStats() of unregistered statistic group did not panic")
+	}
+
+	// labels that are illegal or collide with the supplied ones should panic
+	for _, labelName := range []string{"pkg", "group", "le", "1volume", "vol-ume"} {
+		testFunc = func() {
+			RegisterWithLabels("main", "badLabels", map[string]string{labelName: "x"}, &allStatTypes{})
+		}
+		panicStr = catchAPanic(testFunc)
+		if panicStr == "" {
+			t.Errorf("RegisterWithLabels() with label name '%s' did not panic", labelName)
+			UnRegister("main", "badLabels")
+		}
+	}
+
+	var myStats6 allStatTypes = allStatTypes{
+		Total1:         Total{Name: "Total.1"},
+		Average1:       Average{Name: "Average1"},
+		BucketLog2:     BucketLog2Round{Name: "BucketLog2"},
+		BucketLogRoot2: BucketLogRoot2Round{Name: "BucketLogRoot2"},
+	}
+	RegisterWithLabels("prom.test", "myStats6", map[string]string{"volume": "Common\"Volume\""}, &myStats6)
+	defer UnRegister("prom.test", "myStats6")
+
+	myStats6.Total1.Add(5)
+	myStats6.Average1.Add(2)
+	myStats6.Average1.Add(4)
+	myStats6.BucketLog2.Add(1)
+	myStats6.BucketLog2.Add(3)
+	myStats6.BucketLog2.Add(100)
+
+	labels := `pkg="prom.test",group="myStats6",volume="Common\"Volume\""`
+	values := SprintPrometheusStats("prom.test", "*")
+
+	for _, expected := range []string{
+		"# TYPE prom_test_Total_1 counter\n",
+		"prom_test_Total_1{" + labels + "} 5\n",
+		"# TYPE prom_test_Average1 summary\n",
+		"prom_test_Average1_sum{" + labels + "} 6\n",
+		"prom_test_Average1_count{" + labels + "} 2\n",
+		"# TYPE prom_test_BucketLog2 histogram\n",
+		"prom_test_BucketLog2_bucket{" + labels + `,le="+Inf"} 3` + "\n",
+		"prom_test_BucketLog2_sum{" + labels + "} ",
+		"prom_test_BucketLog2_count{" + labels + "} 3\n",
+		"# TYPE prom_test_BucketLogRoot2 histogram\n",
+		"prom_test_BucketLogRoot2_bucket{" + labels + `,le="+Inf"} 0` + "\n",
+		"prom_test_BucketLogRoot2_count{" + labels + "} 0\n",
+	} {
+		if !strings.Contains(values, expected) {
+			t.Errorf("SprintPrometheusStats() output missing \"%s\":\n%s", strings.TrimSuffix(expected, "\n"), values)
+		}
+	}
+
+	// the finite buckets must be cumulative, end with the bucket holding 100,
+	// and be followed by the "+Inf" bucket
+	bucketRe := regexp.MustCompile(`^prom_test_BucketLog2_bucket\{.*,le="([^"]+)"\} ([0-9]+)$`)
+	var (
+		lastCount uint64
+		lastLe    string
+	)
+	for _, row := range strings.Split(values, "\n") {
+		matches := bucketRe.FindStringSubmatch(row)
+		if matches == nil {
+			continue
+		}
+		var count uint64
+		fmt.Sscanf(matches[2], "%d", &count)
+		if count < lastCount {
+			t.Errorf("SprintPrometheusStats() histogram buckets not cumulative at '%s'", row)
+		}
+		if matches[1] == "+Inf" {
+			if lastLe == "" || lastCount != 3 {
+				t.Errorf("SprintPrometheusStats() finite buckets did not end with all values (le '%s' count %d)",
+					lastLe, lastCount)
+			}
+		}
+		lastCount = count
+		lastLe = matches[1]
+	}
+}
+
+func TestRegisterWithLabelsAndAggregate(t *testing.T) {
+
+	var (
+		testFunc func()
+		panicStr string
+	)
+
+	// an aggregate embedding the statistics of each member (alongside its own)
+	type aggregateStatTypes struct {
+		allStatTypes
+		Other Total
+	}
+
+	var (
+		aggregateStats aggregateStatTypes
+		memberStats1   allStatTypes
+		memberStats2   allStatTypes
+	)
+	Register("agg.test", "", &aggregateStats)
+	defer UnRegister("agg.test", "")
+	RegisterWithLabelsAndAggregate("agg.test", "member1", map[string]string{"volume": "member1"}, &memberStats1, &aggregateStats)
+	defer UnRegister("agg.test", "member1")
+	RegisterWithLabelsAndAggregate("agg.test", "member2", map[string]string{"volume": "member2"}, &memberStats2, &aggregateStats)
+	defer UnRegister("agg.test", "member2")
+
+	memberStats1.Total1.Add(5)
+	memberStats2.Total1.Increment()
+	memberStats1.Average1.Add(2)
+	memberStats2.Average1.Add(4)
+	memberStats1.BucketLog2.Add(1)
+	memberStats2.BucketLog2.Add(1000)
+	memberStats2.BucketLogRoot2.Add(3)
+	aggregateStats.Other.Add(7)
+
+	if memberStats1.Total1.TotalGet() != 5 || memberStats2.Total1.TotalGet() != 1 || aggregateStats.Total1.TotalGet() != 6 {
+		t.Errorf("Total1 not aggregated: %d + %d != %d", memberStats1.Total1.TotalGet(),
+			memberStats2.Total1.TotalGet(), aggregateStats.Total1.TotalGet())
+	}
+	if aggregateStats.Average1.CountGet() != 2 || aggregateStats.Average1.AverageGet() != 3 {
+		t.Errorf("Average1 not aggregated: count %d average %d", aggregateStats.Average1.CountGet(),
+			aggregateStats.Average1.AverageGet())
+	}
+	if aggregateStats.BucketLog2.CountGet() != 2 || memberStats1.BucketLog2.CountGet() != 1 {
+		t.Errorf("BucketLog2 not aggregated: count %d", aggregateStats.BucketLog2.CountGet())
+	}
+	if aggregateStats.BucketLogRoot2.CountGet() != 1 || aggregateStats.BucketLogRoot2.TotalGet() != 3 {
+		t.Errorf("BucketLogRoot2 not aggregated: count %d total %d", aggregateStats.BucketLogRoot2.CountGet(),
+			aggregateStats.BucketLogRoot2.TotalGet())
+	}
+
+	// the statistics of the embedded struct are reported as if declared in the aggregate
+	values := SprintStats(StatFormatParsable1, "agg.test", "")
+	for _, expected := range []string{"agg.test.Total1 total:6\n", "agg.test.Other total:7\n"} {
+		if !strings.Contains(values, expected) {
+			t.Errorf("SprintStats() output missing \"%s\":\n%s", strings.TrimSuffix(expected, "\n"), values)
+		}
+	}
+	values = SprintPrometheusStats("agg.test", "*")
+	for _, expected := range []string{
+		`agg_test_Total1{pkg="agg.test",group=""} 6` + "\n",
+		`agg_test_Total1{pkg="agg.test",group="member1",volume="member1"} 5` + "\n",
+		`agg_test_Total1{pkg="agg.test",group="member2",volume="member2"} 1` + "\n",
+	} {
+		if !strings.Contains(values, expected) {
+			t.Errorf("SprintPrometheusStats() output missing \"%s\":\n%s", strings.TrimSuffix(expected, "\n"), values)
+		}
+	}
+
+	// an aggregate lacking a statistic of the same name and type should panic
+	testFunc = func() {
+		RegisterWithLabelsAndAggregate("agg.test", "badAggregate", nil, &memberStats1, &struct{ Total1 Average }{})
+	}
+	panicStr = catchAPanic(testFunc)
+	if panicStr == "" {
+		t.Errorf("RegisterWithLabelsAndAggregate() with mismatched aggregate did not panic")
+		UnRegister("agg.test", "badAggregate")
+	}
+}
+
 // Invoke function aFunc, which is expected to panic.  If it does, return the
 // value returned by recover() as a string, otherwise return the empty string.
 //
@@ -11,20 +11,29 @@ import (
 	"math/big"
 	"math/bits"
 	"reflect"
+	"regexp"
 	"strings"
 	"sync"
 	"unicode"
 )
 
+// a registered group of statistics along with the labels (if any) that
+// accompany them when they are exported (e.g. to Prometheus)
+type statsGroup struct {
+	statsStruct interface{}
+	labels      map[string]string
+}
+
 var (
-	pkgNameToGroupName map[string]map[string]interface{}
+	pkgNameToGroupName map[string]map[string]*statsGroup
 	statsNameMapLock   sync.Mutex
+	labelNameRegexp    = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
 )
 
 // Register a set of statistics, where the statistics are one or more fields in
 // the passed structure.
 //
-func register(pkgName string, statsGroupName string, statsStruct interface{}) {
+func register(pkgName string, statsGroupName string, labels map[string]string, statsStruct interface{}, aggregateStruct interface{}) {
 
 	var ok bool
 
@@ -32,6 +41,18 @@ func register(pkgName string, statsGroupName string, statsStruct interface{}) {
 		panic(fmt.Sprintf("statistics group must have non-empty pkgName or statsGroupName"))
 	}
 
+	// verify the label names are legal (and don't collide with the labels
+	// that are always supplied) then make a private copy of them
+	labelsCopy := make(map[string]string)
+	for labelName, labelValue := range labels {
+		if !labelNameRegexp.MatchString(labelName) || labelName == promPkgLabelName ||
+			labelName == promGroupLabelName || labelName == promBucketLabelName {
+			panic(fmt.Sprintf("statistics group '%s' label name '%s' is not allowed",
+				statsGroupName, labelName))
+		}
+		labelsCopy[labelName] = labelValue
+	}
+
 	// let us reflect upon any statistics fields in statsStruct ...
 	//
 	// but first verify this is a pointer to a struct
@@ -41,18 +62,23 @@ func register(pkgName string, statsGroupName string, statsStruct interface{}) {
 			statsGroupName, reflect.TypeOf(statsStruct)))
 	}
 
-	structAsValue := reflect.ValueOf(statsStruct).Elem()
-	structAsType := structAsValue.Type()
+	if aggregateStruct != nil && (reflect.TypeOf(aggregateStruct).Kind() != reflect.Ptr ||
+		reflect.ValueOf(aggregateStruct).Elem().Type().Kind() != reflect.Struct) {
+		panic(fmt.Sprintf("aggregateStruct for statistics group '%s' is (%s), should be (*struct)",
+			statsGroupName, reflect.TypeOf(aggregateStruct)))
+	}
+
+	fields, fieldValues := statsStructFields(reflect.ValueOf(statsStruct).Elem())
 
 	// find all the statistics fields and init them;
 	// assign them a name if they don't have one;
 	// verify each name is only used once
 	names := make(map[string]struct{})
 
-	for i := 0; i < structAsType.NumField(); i++ {
-		fieldName := structAsType.Field(i).Name
-		fieldAsType := structAsType.Field(i).Type
-		fieldAsValue := structAsValue.Field(i)
+	for i := range fields {
+		fieldName := fields[i].Name
+		fieldAsType := fields[i].Type
+		fieldAsValue := fieldValues[i]
 
 		// ignore fields that are not a BucketStats type
 		var (
@@ -114,6 +140,25 @@ func register(pkgName string, statsGroupName string, statsStruct interface{}) {
 				statsGroupName, fieldName, fieldAsType))
 		}
 
+		// link the statistic to its aggregate (if any)
+		if aggregateStruct == nil {
+			continue
+		}
+		aggregateAsValue := reflect.ValueOf(aggregateStruct).Elem().FieldByName(fieldName)
+		if !aggregateAsValue.IsValid() || aggregateAsValue.Type() != fieldAsType {
+			panic(fmt.Sprintf("statistics group '%s' field %s has no aggregate of type '%v'",
+				statsGroupName, fieldName, fieldAsType))
+		}
+		switch v := (fieldAsValue.Addr().Interface()).(type) {
+		case *Total:
+			v.aggregate = aggregateAsValue.Addr().Interface().(*Total)
+		case *Average:
+			v.aggregate = aggregateAsValue.Addr().Interface().(*Average)
+		case *BucketLog2Round:
+			v.aggregate = aggregateAsValue.Addr().Interface().(*BucketLog2Round)
+		case *BucketLogRoot2Round:
+			v.aggregate = aggregateAsValue.Addr().Interface().(*BucketLogRoot2Round)
+		}
 	}
 
 	// add statsGroupName to the list of statistics
@@ -121,10 +166,10 @@ func register(pkgName string, statsGroupName string, statsStruct interface{}) {
 	defer statsNameMapLock.Unlock()
 
 	if pkgNameToGroupName == nil {
-		pkgNameToGroupName = make(map[string]map[string]interface{})
+		pkgNameToGroupName = make(map[string]map[string]*statsGroup)
 	}
 	if pkgNameToGroupName[pkgName] == nil {
-		pkgNameToGroupName[pkgName] = make(map[string]interface{})
+		pkgNameToGroupName[pkgName] = make(map[string]*statsGroup)
 	}
 
 	// check for pre-existence
@@ -133,7 +178,7 @@ func register(pkgName string, statsGroupName string, statsStruct interface{}) {
 			pkgName, statsGroupName))
 	}
 
-	pkgNameToGroupName[pkgName][statsGroupName] = statsStruct
+	pkgNameToGroupName[pkgName][statsGroupName] = &statsGroup{statsStruct: statsStruct, labels: labelsCopy}
 
 	return
 }
@@ -164,14 +209,14 @@ func sprintStats(statFmt StatStringFormat, pkgName string, statsGroupName string
 	defer statsNameMapLock.Unlock()
 
 	var (
-		pkgNameMap   map[string]map[string]interface{}
-		groupNameMap map[string]interface{}
+		pkgNameMap   map[string]map[string]*statsGroup
+		groupNameMap map[string]*statsGroup
 	)
 	if pkgName == "*" {
 		pkgNameMap = pkgNameToGroupName
 	} else {
 		// make a map with a single entry for the pkgName
-		pkgNameMap = map[string]map[string]interface{}{pkgName: nil}
+		pkgNameMap = map[string]map[string]*statsGroup{pkgName: nil}
 	}
 
 	for pkg := range pkgNameMap {
@@ -179,7 +224,7 @@ func sprintStats(statFmt StatStringFormat, pkgName string, statsGroupName string
 			groupNameMap = pkgNameToGroupName[pkg]
 		} else {
 			// make a map with a single entry for the statsGroupName
-			groupNameMap = map[string]interface{}{statsGroupName: nil}
+			groupNameMap = map[string]*statsGroup{statsGroupName: nil}
 		}
 
 		for group := range groupNameMap {
@@ -189,7 +234,7 @@ func sprintStats(statFmt StatStringFormat, pkgName string, statsGroupName string
 					"bucketstats.sprintStats(): statistics group '%s.%s' is not registered",
 					pkg, group))
 			}
-			statValues += sprintStatsStruct(statFmt, pkg, group, pkgNameToGroupName[pkg][group])
+			statValues += sprintStatsStruct(statFmt, pkg, group, pkgNameToGroupName[pkg][group].statsStruct)
 		}
 	}
 	return
@@ -207,13 +252,12 @@ func sprintStatsStruct(statFmt StatStringFormat, pkgName string, statsGroupName
 			statsGroupName, reflect.TypeOf(statsStruct)))
 	}
 
-	structAsValue := reflect.ValueOf(statsStruct).Elem()
-	structAsType := structAsValue.Type()
+	fields, fieldValues := statsStructFields(reflect.ValueOf(statsStruct).Elem())
 
 	// find all the statistics fields and sprint them
-	for i := 0; i < structAsType.NumField(); i++ {
-		fieldAsType := structAsType.Field(i).Type
-		fieldAsValue := structAsValue.Field(i)
+	for i := range fields {
+		fieldAsType := fields[i].Type
+		fieldAsValue := fieldValues[i]
 
 		// ignore fields that are not a BucketStats type
 		var (
@@ -245,6 +289,27 @@ func sprintStatsStruct(statFmt StatStringFormat, pkgName string, statsGroupName
 	return
 }
 
+// Return the fields of the struct structAsValue (and their values), with the fields
+// of any embedded struct in place of the embedded struct itself.
+//
+func statsStructFields(structAsValue reflect.Value) (fields []reflect.StructField, fieldValues []reflect.Value) {
+
+	structAsType := structAsValue.Type()
+
+	for i := 0; i < structAsType.NumField(); i++ {
+		field := structAsType.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			embeddedFields, embeddedFieldValues := statsStructFields(structAsValue.Field(i))
+			fields = append(fields, embeddedFields...)
+			fieldValues = append(fieldValues, embeddedFieldValues...)
+			continue
+		}
+		fields = append(fields, field)
+		fieldValues = append(fieldValues, structAsValue.Field(i))
+	}
+	return
+}
+
 // Construct and return a statistics name (fully qualified field name) in the specified format.
 //
 func statisticName(statFmt StatStringFormat, pkgName string, statsGroupName string, fieldName string) string {
@@ -0,0 +1,244 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package bucketstats
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	promPkgLabelName    = "pkg"
+	promGroupLabelName  = "group"
+	promBucketLabelName = "le"
+
+	promTypeCounter   = "counter"
+	promTypeSummary   = "summary"
+	promTypeHistogram = "histogram"
+)
+
+// a Prometheus metric family: all of the samples sharing a metric name (which
+// must also share a type)
+type promFamily struct {
+	metricType string
+	samples    []string
+}
+
+// Return the selected group(s) of statistics in the Prometheus text exposition
+// format.
+//
+func sprintPrometheusStats(pkgName string, statsGroupName string) (statValues string) {
+
+	statsNameMapLock.Lock()
+	defer statsNameMapLock.Unlock()
+
+	families := make(map[string]*promFamily)
+
+	// visit the selected groups in sorted order so the samples within each
+	// family are consistently ordered
+	pkgNames := make([]string, 0, len(pkgNameToGroupName))
+	for pkg := range pkgNameToGroupName {
+		if pkgName == "*" || pkg == pkgName {
+			pkgNames = append(pkgNames, pkg)
+		}
+	}
+	sort.Strings(pkgNames)
+
+	for _, pkg := range pkgNames {
+		groupNames := make([]string, 0, len(pkgNameToGroupName[pkg]))
+		for group := range pkgNameToGroupName[pkg] {
+			if statsGroupName == "*" || group == statsGroupName {
+				groupNames = append(groupNames, group)
+			}
+		}
+		sort.Strings(groupNames)
+
+		for _, group := range groupNames {
+			promStatsGroup(families, pkg, group, pkgNameToGroupName[pkg][group])
+		}
+	}
+
+	if pkgName != "*" && statsGroupName != "*" {
+		_, ok := pkgNameToGroupName[pkgName][statsGroupName]
+		if !ok {
+			panic(fmt.Sprintf(
+				"bucketstats.sprintPrometheusStats(): statistics group '%s.%s' is not registered",
+				pkgName, statsGroupName))
+		}
+	}
+
+	familyNames := make([]string, 0, len(families))
+	for familyName := range families {
+		familyNames = append(familyNames, familyName)
+	}
+	sort.Strings(familyNames)
+
+	var sb strings.Builder
+	for _, familyName := range familyNames {
+		family := families[familyName]
+		fmt.Fprintf(&sb, "# TYPE %s %s\n", familyName, family.metricType)
+		for _, sample := range family.samples {
+			sb.WriteString(sample)
+		}
+	}
+
+	return sb.String()
+}
+
+// Add the samples for each of the statistics in a group to families.
+//
+func promStatsGroup(families map[string]*promFamily, pkgName string, statsGroupName string, group *statsGroup) {
+
+	labels := promLabels(pkgName, statsGroupName, group.labels)
+
+	_, fieldValues := statsStructFields(reflect.ValueOf(group.statsStruct).Elem())
+
+	for _, fieldAsValue := range fieldValues {
+		if !fieldAsValue.CanAddr() || !fieldAsValue.Addr().CanInterface() {
+			continue
+		}
+
+		switch v := (fieldAsValue.Addr().Interface()).(type) {
+		case *Total:
+			family, name := promFetchFamily(families, pkgName, v.Name, promTypeCounter)
+			family.samples = append(family.samples,
+				fmt.Sprintf("%s%s %d\n", name, labels, v.TotalGet()))
+		case *Average:
+			family, name := promFetchFamily(families, pkgName, v.Name, promTypeSummary)
+			family.samples = append(family.samples,
+				fmt.Sprintf("%s_sum%s %d\n", name, labels, v.TotalGet()),
+				fmt.Sprintf("%s_count%s %d\n", name, labels, v.CountGet()))
+		case *BucketLog2Round:
+			family, name := promFetchFamily(families, pkgName, v.Name, promTypeHistogram)
+			family.samples = append(family.samples,
+				promHistogramSamples(name, labels, v.DistGet(), v.TotalGet())...)
+		case *BucketLogRoot2Round:
+			family, name := promFetchFamily(families, pkgName, v.Name, promTypeHistogram)
+			family.samples = append(family.samples,
+				promHistogramSamples(name, labels, v.DistGet(), v.TotalGet())...)
+		}
+	}
+}
+
+// Return the family for the named statistic, creating it if necessary.  In the
+// unlikely event that the same name is used by statistics of different types
+// in the same package, the type is appended to the name of the later family.
+//
+func promFetchFamily(families map[string]*promFamily, pkgName string, statName string,
+	metricType string) (family *promFamily, familyName string) {
+
+	familyName = promMetricName(pkgName, statName)
+
+	family, ok := families[familyName]
+	if ok && family.metricType != metricType {
+		familyName += "_" + metricType
+		family, ok = families[familyName]
+	}
+	if !ok {
+		family = &promFamily{metricType: metricType}
+		families[familyName] = family
+	}
+	return
+}
+
+// Return the samples for a bucketized statistic as a (cumulative) histogram.
+//
+func promHistogramSamples(name string, labels string, bucketInfo []BucketInfo, sum uint64) (samples []string) {
+
+	// buckets beyond the last one with a non-zero count are omitted
+	_, maxIdx, _, _, _ := bucketCalcStat(bucketInfo)
+
+	var cumulativeCount uint64
+	for i := 0; i < maxIdx; i += 1 {
+		cumulativeCount += bucketInfo[i].Count
+		if bucketInfo[i].RangeHigh == math.MaxUint64 {
+			// covered by the "+Inf" bucket
+			break
+		}
+		samples = append(samples, fmt.Sprintf("%s_bucket%s %d\n",
+			name, promAddLabel(labels, promBucketLabelName, fmt.Sprintf("%d", bucketInfo[i].RangeHigh)), cumulativeCount))
+	}
+
+	// the count is computed from the buckets (rather than CountGet()) so it
+	// is consistent with them even if values are being concurrently added
+	cumulativeCount = 0
+	for i := 0; i < len(bucketInfo); i += 1 {
+		cumulativeCount += bucketInfo[i].Count
+	}
+
+	samples = append(samples,
+		fmt.Sprintf("%s_bucket%s %d\n", name, promAddLabel(labels, promBucketLabelName, "+Inf"), cumulativeCount),
+		fmt.Sprintf("%s_sum%s %d\n", name, labels, sum),
+		fmt.Sprintf("%s_count%s %d\n", name, labels, cumulativeCount))
+	return
+}
+
+// Return the metric name for a statistic, replacing characters that are not
+// legal in a Prometheus metric name with underbar ('_').
+//
+func promMetricName(pkgName string, statName string) string {
+
+	var name string
+	if pkgName == "" {
+		name = statName
+	} else {
+		name = pkgName + "_" + statName
+	}
+
+	replaceChar := func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r
+		case r >= '0' && r <= '9':
+			return r
+		case r == '_' || r == ':':
+			return r
+		}
+		return '_'
+	}
+
+	name = strings.Map(replaceChar, name)
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// Return the label set (in braces) for a group of statistics.
+//
+func promLabels(pkgName string, statsGroupName string, groupLabels map[string]string) string {
+
+	labelNames := make([]string, 0, len(groupLabels))
+	for labelName := range groupLabels {
+		labelNames = append(labelNames, labelName)
+	}
+	sort.Strings(labelNames)
+
+	labels := fmt.Sprintf("{%s=\"%s\",%s=\"%s\"", promPkgLabelName, promEscape(pkgName),
+		promGroupLabelName, promEscape(statsGroupName))
+	for _, labelName := range labelNames {
+		labels += fmt.Sprintf(",%s=\"%s\"", labelName, promEscape(groupLabels[labelName]))
+	}
+	return labels + "}"
+}
+
+// Return labels (in braces) with one more label appended.
+//
+func promAddLabel(labels string, labelName string, labelValue string) string {
+	return fmt.Sprintf("%s,%s=\"%s\"}", strings.TrimSuffix(labels, "}"), labelName, promEscape(labelValue))
+}
+
+// Escape a label value as required by the Prometheus text exposition format.
+//
+func promEscape(labelValue string) string {
+	labelValue = strings.Replace(labelValue, "\\", "\\\\", -1)
+	labelValue = strings.Replace(labelValue, "\"", "\\\"", -1)
+	labelValue = strings.Replace(labelValue, "\n", "\\n", -1)
+	return labelValue
+}
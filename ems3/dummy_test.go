@@ -1,12 +1,11 @@
 // Copyright (c) 2015-2021, NVIDIA CORPORATION.
 // SPDX-License-Identifier: Apache-2.0
 
-package iclientpkg
+package main
 
 import (
 	"testing"
 )
 
-func TestAPI(t *testing.T) {
-	t.Logf("TODO")
+func TestDummy(t *testing.T) {
 }
@@ -0,0 +1,38 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ems3pkg implements an in-memory emulation of an S3-compatible object
+// store responding to the minimal set of (path-style addressed) S3 operations
+// used by package s3client: bucket PUT, HEAD, DELETE, and GET (ListObjectsV2),
+// object PUT, GET (with an optional single byte Range), HEAD, DELETE, and COPY,
+// as well as multipart uploads. Request signatures are not verified.
+//
+// To configure an ems3pkg instance, Start() is called passing, as the sole
+// argument, a package conf ConfMap. Here is a sample .conf file:
+//
+//  [EMS3]
+//  IPAddr:       127.0.0.1
+//  TCPPort:      9000
+//
+//  MinPartSize:  5242880 # All but the last part of a multipart upload must be at least this large
+//  ListingLimit: 1000    # Maximum keys returned by each ListObjectsV2 response
+//
+package ems3pkg
+
+import (
+	"github.com/NVIDIA/proxyfs/conf"
+)
+
+// Start is called to start serving.
+//
+func Start(confMap conf.ConfMap) (err error) {
+	err = start(confMap)
+	return
+}
+
+// Stop is called to stop serving.
+//
+func Stop() (err error) {
+	err = stop()
+	return
+}
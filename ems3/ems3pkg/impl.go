@@ -0,0 +1,1002 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package ems3pkg
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/conf"
+)
+
+type objectStruct struct {
+	key          string
+	metadata     http.Header // only X-Amz-Meta-* headers
+	contents     []byte
+	eTag         string
+	lastModified time.Time
+}
+
+type partStruct struct {
+	contents []byte
+	eTag     string
+}
+
+type uploadStruct struct {
+	uploadID string
+	key      string
+	metadata http.Header
+	partMap  map[uint32]*partStruct // key is PartNumber
+}
+
+type bucketStruct struct {
+	name      string
+	objectMap map[string]*objectStruct // key is objectStruct.key
+	uploadMap map[string]*uploadStruct // key is uploadStruct.uploadID
+}
+
+type configStruct struct {
+	IPAddr  string
+	TCPPort uint16
+
+	MinPartSize  uint64
+	ListingLimit uint64
+}
+
+type globalsStruct struct {
+	sync.Mutex
+	config             configStruct
+	emulatorHTTPServer *http.Server
+	emulatorWG         sync.WaitGroup
+	uploadNonce        uint64
+	bucketMap          map[string]*bucketStruct // key is bucketStruct.name
+}
+
+var globals globalsStruct
+
+const (
+	startGETRetryLimit = 10
+	startGETRetryDelay = 100 * time.Millisecond
+)
+
+const (
+	metadataPrefix = "X-Amz-Meta-"
+	xmlNamespace   = "http://s3.amazonaws.com/doc/2006-03-01/"
+)
+
+type errorResponseStruct struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+type listAllMyBucketsResultStruct struct {
+	XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Buckets []struct {
+		Name string `xml:"Name"`
+	} `xml:"Buckets>Bucket"`
+}
+
+type listBucketResultContentsStruct struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int    `xml:"Size"`
+}
+
+type listBucketResultCommonPrefixesStruct struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type listBucketResultStruct struct {
+	XMLName               xml.Name                               `xml:"ListBucketResult"`
+	XMLNS                 string                                 `xml:"xmlns,attr"`
+	Name                  string                                 `xml:"Name"`
+	Prefix                string                                 `xml:"Prefix"`
+	Delimiter             string                                 `xml:"Delimiter,omitempty"`
+	MaxKeys               uint64                                 `xml:"MaxKeys"`
+	KeyCount              int                                    `xml:"KeyCount"`
+	IsTruncated           bool                                   `xml:"IsTruncated"`
+	ContinuationToken     string                                 `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string                                 `xml:"NextContinuationToken,omitempty"`
+	Contents              []listBucketResultContentsStruct       `xml:"Contents"`
+	CommonPrefixes        []listBucketResultCommonPrefixesStruct `xml:"CommonPrefixes"`
+}
+
+type copyObjectResultStruct struct {
+	XMLName      xml.Name `xml:"CopyObjectResult"`
+	LastModified string   `xml:"LastModified"`
+	ETag         string   `xml:"ETag"`
+}
+
+type initiateMultipartUploadResultStruct struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+type completeMultipartUploadStruct struct {
+	XMLName xml.Name `xml:"CompleteMultipartUpload"`
+	Parts   []struct {
+		PartNumber uint32 `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	} `xml:"Part"`
+}
+
+type completeMultipartUploadResultStruct struct {
+	XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Bucket  string   `xml:"Bucket"`
+	Key     string   `xml:"Key"`
+	ETag    string   `xml:"ETag"`
+}
+
+func start(confMap conf.ConfMap) (err error) {
+	err = initializeGlobals(confMap)
+	if nil != err {
+		return
+	}
+
+	err = startEmulator()
+	if nil != err {
+		return
+	}
+
+	return
+}
+
+func stop() (err error) {
+	err = stopEmulator()
+	if nil != err {
+		return
+	}
+
+	uninitializeGlobals()
+
+	err = nil
+	return
+}
+
+func initializeGlobals(confMap conf.ConfMap) (err error) {
+	globals.config.IPAddr, err = confMap.FetchOptionValueString("EMS3", "IPAddr")
+	if nil != err {
+		return
+	}
+	globals.config.TCPPort, err = confMap.FetchOptionValueUint16("EMS3", "TCPPort")
+	if nil != err {
+		return
+	}
+
+	globals.config.MinPartSize, err = confMap.FetchOptionValueUint64("EMS3", "MinPartSize")
+	if nil != err {
+		return
+	}
+	globals.config.ListingLimit, err = confMap.FetchOptionValueUint64("EMS3", "ListingLimit")
+	if nil != err {
+		return
+	}
+	if 0 == globals.config.ListingLimit {
+		err = fmt.Errorf("[EMS3]ListingLimit must be non-zero")
+		return
+	}
+
+	globals.uploadNonce = 0
+
+	globals.bucketMap = make(map[string]*bucketStruct)
+
+	return
+}
+
+func uninitializeGlobals() {
+	globals.config.IPAddr = ""
+	globals.config.TCPPort = 0
+
+	globals.config.MinPartSize = 0
+	globals.config.ListingLimit = 0
+
+	globals.bucketMap = make(map[string]*bucketStruct)
+}
+
+func startEmulator() (err error) {
+	var (
+		startGETRetryCount int
+	)
+
+	globals.emulatorHTTPServer = &http.Server{
+		Addr:    net.JoinHostPort(globals.config.IPAddr, fmt.Sprintf("%d", globals.config.TCPPort)),
+		Handler: &globals,
+	}
+
+	globals.emulatorWG.Add(1)
+
+	go func() {
+		_ = globals.emulatorHTTPServer.ListenAndServe()
+		globals.emulatorWG.Done()
+	}()
+
+	startGETRetryCount = 0
+
+	for {
+		_, err = http.Get("http://" + globals.emulatorHTTPServer.Addr + "/")
+		if nil == err {
+			break
+		}
+		startGETRetryCount++
+		if startGETRetryCount > startGETRetryLimit {
+			_ = stopEmulator()
+			err = fmt.Errorf("startEmulator() failed to establish that emulator is up")
+			return
+		}
+		time.Sleep(startGETRetryDelay)
+	}
+
+	err = nil
+	return
+}
+
+func stopEmulator() (err error) {
+	err = globals.emulatorHTTPServer.Close()
+	if nil != err {
+		return
+	}
+
+	globals.emulatorWG.Wait()
+
+	err = nil
+	return
+}
+
+func (dummy *globalsStruct) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	var (
+		bucketName string
+		err        error
+		key        string
+		pathSplit  []string
+		query      url.Values
+		requestBuf []byte
+	)
+
+	requestBuf, err = ioutil.ReadAll(request.Body)
+	_ = request.Body.Close()
+	if nil != err {
+		writeError(responseWriter, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+
+	pathSplit = strings.SplitN(strings.TrimPrefix(request.URL.Path, "/"), "/", 2)
+
+	bucketName = pathSplit[0]
+	if 2 == len(pathSplit) {
+		key = pathSplit[1]
+	} else {
+		key = ""
+	}
+
+	query = request.URL.Query()
+
+	globals.Lock()
+	defer globals.Unlock()
+
+	if "" == bucketName {
+		if http.MethodGet == request.Method {
+			doListBuckets(responseWriter)
+		} else {
+			writeError(responseWriter, http.StatusMethodNotAllowed, "MethodNotAllowed", "")
+		}
+		return
+	}
+
+	if "" == key {
+		switch request.Method {
+		case http.MethodDelete:
+			doDeleteBucket(responseWriter, bucketName)
+		case http.MethodGet:
+			doListObjects(responseWriter, bucketName, query)
+		case http.MethodHead:
+			doHeadBucket(responseWriter, bucketName)
+		case http.MethodPut:
+			doCreateBucket(responseWriter, bucketName)
+		default:
+			writeError(responseWriter, http.StatusMethodNotAllowed, "MethodNotAllowed", "")
+		}
+		return
+	}
+
+	switch request.Method {
+	case http.MethodDelete:
+		if _, ok := query["uploadId"]; ok {
+			doAbortMultipartUpload(responseWriter, bucketName, key, query.Get("uploadId"))
+		} else {
+			doDeleteObject(responseWriter, bucketName, key)
+		}
+	case http.MethodGet:
+		doGetObject(responseWriter, request, bucketName, key, true)
+	case http.MethodHead:
+		doGetObject(responseWriter, request, bucketName, key, false)
+	case http.MethodPost:
+		if _, ok := query["uploads"]; ok {
+			doCreateMultipartUpload(responseWriter, request, bucketName, key)
+		} else if _, ok := query["uploadId"]; ok {
+			doCompleteMultipartUpload(responseWriter, bucketName, key, query.Get("uploadId"), requestBuf)
+		} else {
+			writeError(responseWriter, http.StatusMethodNotAllowed, "MethodNotAllowed", "")
+		}
+	case http.MethodPut:
+		if _, ok := query["uploadId"]; ok {
+			doUploadPart(responseWriter, bucketName, key, query.Get("uploadId"), query.Get("partNumber"), requestBuf)
+		} else if "" != request.Header.Get("X-Amz-Copy-Source") {
+			doCopyObject(responseWriter, request, bucketName, key)
+		} else {
+			doPutObject(responseWriter, request, bucketName, key, requestBuf)
+		}
+	default:
+		writeError(responseWriter, http.StatusMethodNotAllowed, "MethodNotAllowed", "")
+	}
+}
+
+func writeError(responseWriter http.ResponseWriter, statusCode int, code string, message string) {
+	var (
+		responseBuf []byte
+	)
+
+	responseBuf, _ = xml.Marshal(&errorResponseStruct{Code: code, Message: message})
+
+	responseWriter.Header().Set("Content-Type", "application/xml")
+	responseWriter.Header().Set("Content-Length", fmt.Sprintf("%d", len(xml.Header)+len(responseBuf)))
+	responseWriter.WriteHeader(statusCode)
+	_, _ = responseWriter.Write([]byte(xml.Header))
+	_, _ = responseWriter.Write(responseBuf)
+}
+
+func writeXML(responseWriter http.ResponseWriter, v interface{}) {
+	var (
+		err         error
+		responseBuf []byte
+	)
+
+	responseBuf, err = xml.Marshal(v)
+	if nil != err {
+		writeError(responseWriter, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/xml")
+	responseWriter.Header().Set("Content-Length", fmt.Sprintf("%d", len(xml.Header)+len(responseBuf)))
+	responseWriter.WriteHeader(http.StatusOK)
+	_, _ = responseWriter.Write([]byte(xml.Header))
+	_, _ = responseWriter.Write(responseBuf)
+}
+
+func computeETag(contents []byte) (eTag string) {
+	var (
+		sum [md5.Size]byte
+	)
+
+	sum = md5.Sum(contents)
+	eTag = "\"" + hex.EncodeToString(sum[:]) + "\""
+
+	return
+}
+
+func metadataFromRequest(request *http.Request) (metadata http.Header) {
+	var (
+		headerName   string
+		headerValues []string
+	)
+
+	metadata = make(http.Header)
+
+	for headerName, headerValues = range request.Header {
+		if strings.HasPrefix(http.CanonicalHeaderKey(headerName), metadataPrefix) {
+			metadata[http.CanonicalHeaderKey(headerName)] = headerValues
+		}
+	}
+
+	return
+}
+
+func locateBucket(responseWriter http.ResponseWriter, bucketName string) (bucket *bucketStruct, ok bool) {
+	bucket, ok = globals.bucketMap[bucketName]
+	if !ok {
+		writeError(responseWriter, http.StatusNotFound, "NoSuchBucket", bucketName)
+	}
+
+	return
+}
+
+func doListBuckets(responseWriter http.ResponseWriter) {
+	var (
+		bucketName  string
+		bucketNames []string
+		result      listAllMyBucketsResultStruct
+	)
+
+	bucketNames = make([]string, 0, len(globals.bucketMap))
+	for bucketName = range globals.bucketMap {
+		bucketNames = append(bucketNames, bucketName)
+	}
+	sort.Strings(bucketNames)
+
+	result.XMLNS = xmlNamespace
+
+	for _, bucketName = range bucketNames {
+		result.Buckets = append(result.Buckets, struct {
+			Name string `xml:"Name"`
+		}{Name: bucketName})
+	}
+
+	writeXML(responseWriter, &result)
+}
+
+func doCreateBucket(responseWriter http.ResponseWriter, bucketName string) {
+	var (
+		ok bool
+	)
+
+	_, ok = globals.bucketMap[bucketName]
+	if ok {
+		writeError(responseWriter, http.StatusConflict, "BucketAlreadyOwnedByYou", bucketName)
+		return
+	}
+
+	globals.bucketMap[bucketName] = &bucketStruct{
+		name:      bucketName,
+		objectMap: make(map[string]*objectStruct),
+		uploadMap: make(map[string]*uploadStruct),
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+}
+
+func doDeleteBucket(responseWriter http.ResponseWriter, bucketName string) {
+	var (
+		bucket *bucketStruct
+		ok     bool
+	)
+
+	bucket, ok = locateBucket(responseWriter, bucketName)
+	if !ok {
+		return
+	}
+
+	if 0 < len(bucket.objectMap) {
+		writeError(responseWriter, http.StatusConflict, "BucketNotEmpty", bucketName)
+		return
+	}
+
+	delete(globals.bucketMap, bucketName)
+
+	responseWriter.WriteHeader(http.StatusNoContent)
+}
+
+func doHeadBucket(responseWriter http.ResponseWriter, bucketName string) {
+	var (
+		ok bool
+	)
+
+	_, ok = globals.bucketMap[bucketName]
+	if ok {
+		responseWriter.WriteHeader(http.StatusOK)
+	} else {
+		responseWriter.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func doListObjects(responseWriter http.ResponseWriter, bucketName string, query url.Values) {
+	var (
+		bucket           *bucketStruct
+		commonPrefix     string
+		commonPrefixSeen map[string]bool
+		delimiterIndex   int
+		err              error
+		key              string
+		keys             []string
+		maxKeys          uint64
+		nextStartAfter   string
+		ok               bool
+		result           listBucketResultStruct
+		startAfter       string
+		startAfterAsBuf  []byte
+	)
+
+	bucket, ok = locateBucket(responseWriter, bucketName)
+	if !ok {
+		return
+	}
+
+	result.XMLNS = xmlNamespace
+	result.Name = bucketName
+	result.Prefix = query.Get("prefix")
+	result.Delimiter = query.Get("delimiter")
+	result.ContinuationToken = query.Get("continuation-token")
+	result.Contents = make([]listBucketResultContentsStruct, 0)
+	result.CommonPrefixes = make([]listBucketResultCommonPrefixesStruct, 0)
+
+	maxKeys = globals.config.ListingLimit
+	if "" != query.Get("max-keys") {
+		maxKeys, err = strconv.ParseUint(query.Get("max-keys"), 10, 64)
+		if nil != err {
+			writeError(responseWriter, http.StatusBadRequest, "InvalidArgument", "max-keys")
+			return
+		}
+		if maxKeys > globals.config.ListingLimit {
+			maxKeys = globals.config.ListingLimit
+		}
+	}
+	result.MaxKeys = maxKeys
+
+	// The ContinuationToken is simply the (hex encoded) key after which the listing resumes
+
+	if "" == result.ContinuationToken {
+		startAfter = query.Get("start-after")
+	} else {
+		startAfterAsBuf, err = hex.DecodeString(result.ContinuationToken)
+		if nil != err {
+			writeError(responseWriter, http.StatusBadRequest, "InvalidArgument", "continuation-token")
+			return
+		}
+		startAfter = string(startAfterAsBuf)
+	}
+
+	keys = make([]string, 0, len(bucket.objectMap))
+	for key = range bucket.objectMap {
+		if strings.HasPrefix(key, result.Prefix) && (key > startAfter) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	commonPrefixSeen = make(map[string]bool)
+
+	for _, key = range keys {
+		if "" != result.Delimiter {
+			delimiterIndex = strings.Index(key[len(result.Prefix):], result.Delimiter)
+			if 0 <= delimiterIndex {
+				commonPrefix = key[:len(result.Prefix)+delimiterIndex+len(result.Delimiter)]
+				if commonPrefixSeen[commonPrefix] || (commonPrefix <= startAfter) {
+					continue
+				}
+				if uint64(result.KeyCount) == maxKeys {
+					result.IsTruncated = true
+					break
+				}
+				commonPrefixSeen[commonPrefix] = true
+				result.CommonPrefixes = append(result.CommonPrefixes, listBucketResultCommonPrefixesStruct{Prefix: commonPrefix})
+				result.KeyCount++
+				nextStartAfter = commonPrefix + "\xff" // skips remaining keys rolled up into commonPrefix
+				continue
+			}
+		}
+
+		if uint64(result.KeyCount) == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+
+		result.Contents = append(result.Contents, listBucketResultContentsStruct{
+			Key:          key,
+			LastModified: bucket.objectMap[key].lastModified.UTC().Format(time.RFC3339),
+			ETag:         bucket.objectMap[key].eTag,
+			Size:         len(bucket.objectMap[key].contents),
+		})
+		result.KeyCount++
+		nextStartAfter = key
+	}
+
+	if result.IsTruncated {
+		result.NextContinuationToken = hex.EncodeToString([]byte(nextStartAfter))
+	}
+
+	writeXML(responseWriter, &result)
+}
+
+func doPutObject(responseWriter http.ResponseWriter, request *http.Request, bucketName string, key string, contents []byte) {
+	var (
+		bucket *bucketStruct
+		object *objectStruct
+		ok     bool
+	)
+
+	bucket, ok = locateBucket(responseWriter, bucketName)
+	if !ok {
+		return
+	}
+
+	object = &objectStruct{
+		key:          key,
+		metadata:     metadataFromRequest(request),
+		contents:     contents,
+		eTag:         computeETag(contents),
+		lastModified: time.Now(),
+	}
+
+	bucket.objectMap[key] = object
+
+	responseWriter.Header().Set("ETag", object.eTag)
+	responseWriter.WriteHeader(http.StatusOK)
+}
+
+func doCopyObject(responseWriter http.ResponseWriter, request *http.Request, bucketName string, key string) {
+	var (
+		bucket        *bucketStruct
+		copySource    string
+		dstMetadata   http.Header
+		dstObject     *objectStruct
+		err           error
+		metadataName  string
+		metadataValue []string
+		ok            bool
+		result        copyObjectResultStruct
+		srcBucket     *bucketStruct
+		srcObject     *objectStruct
+		srcPathSplit  []string
+	)
+
+	bucket, ok = locateBucket(responseWriter, bucketName)
+	if !ok {
+		return
+	}
+
+	copySource, err = url.PathUnescape(request.Header.Get("X-Amz-Copy-Source"))
+	if nil != err {
+		writeError(responseWriter, http.StatusBadRequest, "InvalidArgument", "x-amz-copy-source")
+		return
+	}
+
+	srcPathSplit = strings.SplitN(strings.TrimPrefix(copySource, "/"), "/", 2)
+	if 2 != len(srcPathSplit) {
+		writeError(responseWriter, http.StatusBadRequest, "InvalidArgument", "x-amz-copy-source")
+		return
+	}
+
+	srcBucket, ok = locateBucket(responseWriter, srcPathSplit[0])
+	if !ok {
+		return
+	}
+
+	srcObject, ok = srcBucket.objectMap[srcPathSplit[1]]
+	if !ok {
+		writeError(responseWriter, http.StatusNotFound, "NoSuchKey", copySource)
+		return
+	}
+
+	if "REPLACE" == request.Header.Get("X-Amz-Metadata-Directive") {
+		dstMetadata = metadataFromRequest(request)
+	} else {
+		dstMetadata = make(http.Header)
+		for metadataName, metadataValue = range srcObject.metadata {
+			dstMetadata[metadataName] = metadataValue
+		}
+	}
+
+	dstObject = &objectStruct{
+		key:          key,
+		metadata:     dstMetadata,
+		contents:     srcObject.contents, // contents are never modified in place
+		eTag:         srcObject.eTag,
+		lastModified: time.Now(),
+	}
+
+	bucket.objectMap[key] = dstObject
+
+	result.LastModified = dstObject.lastModified.UTC().Format(time.RFC3339)
+	result.ETag = dstObject.eTag
+
+	writeXML(responseWriter, &result)
+}
+
+func doGetObject(responseWriter http.ResponseWriter, request *http.Request, bucketName string, key string, includeBody bool) {
+	var (
+		bucket        *bucketStruct
+		contents      []byte
+		err           error
+		metadataName  string
+		metadataValue []string
+		object        *objectStruct
+		ok            bool
+		startOffset   int64
+		stopOffset    int64
+	)
+
+	bucket, ok = globals.bucketMap[bucketName]
+	if !ok {
+		if includeBody {
+			writeError(responseWriter, http.StatusNotFound, "NoSuchBucket", bucketName)
+		} else {
+			responseWriter.WriteHeader(http.StatusNotFound)
+		}
+		return
+	}
+
+	object, ok = bucket.objectMap[key]
+	if !ok {
+		if includeBody {
+			writeError(responseWriter, http.StatusNotFound, "NoSuchKey", key)
+		} else {
+			responseWriter.WriteHeader(http.StatusNotFound)
+		}
+		return
+	}
+
+	for metadataName, metadataValue = range object.metadata {
+		responseWriter.Header()[metadataName] = metadataValue
+	}
+
+	responseWriter.Header().Set("ETag", object.eTag)
+	responseWriter.Header().Set("Last-Modified", object.lastModified.UTC().Format(http.TimeFormat))
+
+	if !includeBody {
+		responseWriter.Header().Set("Content-Length", fmt.Sprintf("%d", len(object.contents)))
+		responseWriter.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if "" == request.Header.Get("Range") {
+		responseWriter.Header().Set("Content-Length", fmt.Sprintf("%d", len(object.contents)))
+		responseWriter.WriteHeader(http.StatusOK)
+		_, _ = responseWriter.Write(object.contents)
+		return
+	}
+
+	startOffset, stopOffset, err = parseRangeHeader(request.Header.Get("Range"), int64(len(object.contents)))
+	if nil != err {
+		writeError(responseWriter, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", err.Error())
+		return
+	}
+
+	contents = object.contents[startOffset : stopOffset+1]
+
+	responseWriter.Header().Set("Content-Length", fmt.Sprintf("%d", len(contents)))
+	responseWriter.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", startOffset, stopOffset, len(object.contents)))
+	responseWriter.WriteHeader(http.StatusPartialContent)
+	_, _ = responseWriter.Write(contents)
+}
+
+// parseRangeHeader supports only a single range of the forms "bytes=<first>-<last>",
+// "bytes=<first>-", and "bytes=-<suffix-length>".
+//
+func parseRangeHeader(rangeHeaderValue string, objectLen int64) (startOffset int64, stopOffset int64, err error) {
+	var (
+		rangeStringSlice []string
+	)
+
+	if !strings.HasPrefix(rangeHeaderValue, "bytes=") {
+		err = fmt.Errorf("Range (%v) does not start with expected \"bytes=\"", rangeHeaderValue)
+		return
+	}
+
+	rangeStringSlice = strings.SplitN(rangeHeaderValue[len("bytes="):], "-", 2)
+	if (2 != len(rangeStringSlice)) || (("" == rangeStringSlice[0]) && ("" == rangeStringSlice[1])) {
+		err = fmt.Errorf("Range (%v) malformed", rangeHeaderValue)
+		return
+	}
+
+	if "" == rangeStringSlice[0] {
+		stopOffset, err = strconv.ParseInt(rangeStringSlice[1], 10, 64)
+		if nil != err {
+			return
+		}
+		startOffset = objectLen - stopOffset
+		if startOffset < 0 {
+			startOffset = 0
+		}
+		stopOffset = objectLen - 1
+	} else {
+		startOffset, err = strconv.ParseInt(rangeStringSlice[0], 10, 64)
+		if nil != err {
+			return
+		}
+		if "" == rangeStringSlice[1] {
+			stopOffset = objectLen - 1
+		} else {
+			stopOffset, err = strconv.ParseInt(rangeStringSlice[1], 10, 64)
+			if nil != err {
+				return
+			}
+			if stopOffset > objectLen-1 {
+				stopOffset = objectLen - 1
+			}
+		}
+	}
+
+	if (startOffset >= objectLen) || (startOffset > stopOffset) {
+		err = fmt.Errorf("Range (%v) not satisfiable for object of length %d", rangeHeaderValue, objectLen)
+		return
+	}
+
+	err = nil
+	return
+}
+
+func doDeleteObject(responseWriter http.ResponseWriter, bucketName string, key string) {
+	var (
+		bucket *bucketStruct
+		ok     bool
+	)
+
+	bucket, ok = locateBucket(responseWriter, bucketName)
+	if !ok {
+		return
+	}
+
+	delete(bucket.objectMap, key)
+
+	responseWriter.WriteHeader(http.StatusNoContent)
+}
+
+func doCreateMultipartUpload(responseWriter http.ResponseWriter, request *http.Request, bucketName string, key string) {
+	var (
+		bucket *bucketStruct
+		ok     bool
+		upload *uploadStruct
+	)
+
+	bucket, ok = locateBucket(responseWriter, bucketName)
+	if !ok {
+		return
+	}
+
+	globals.uploadNonce++
+
+	upload = &uploadStruct{
+		uploadID: fmt.Sprintf("%016X", globals.uploadNonce),
+		key:      key,
+		metadata: metadataFromRequest(request),
+		partMap:  make(map[uint32]*partStruct),
+	}
+
+	bucket.uploadMap[upload.uploadID] = upload
+
+	writeXML(responseWriter, &initiateMultipartUploadResultStruct{
+		XMLNS:    xmlNamespace,
+		Bucket:   bucketName,
+		Key:      key,
+		UploadID: upload.uploadID,
+	})
+}
+
+func locateUpload(responseWriter http.ResponseWriter, bucketName string, key string, uploadID string) (bucket *bucketStruct, upload *uploadStruct, ok bool) {
+	bucket, ok = locateBucket(responseWriter, bucketName)
+	if !ok {
+		return
+	}
+
+	upload, ok = bucket.uploadMap[uploadID]
+	if !ok || (key != upload.key) {
+		writeError(responseWriter, http.StatusNotFound, "NoSuchUpload", uploadID)
+		ok = false
+	}
+
+	return
+}
+
+func doUploadPart(responseWriter http.ResponseWriter, bucketName string, key string, uploadID string, partNumberAsString string, contents []byte) {
+	var (
+		err        error
+		ok         bool
+		part       *partStruct
+		partNumber uint64
+		upload     *uploadStruct
+	)
+
+	_, upload, ok = locateUpload(responseWriter, bucketName, key, uploadID)
+	if !ok {
+		return
+	}
+
+	partNumber, err = strconv.ParseUint(partNumberAsString, 10, 32)
+	if (nil != err) || (1 > partNumber) || (10000 < partNumber) {
+		writeError(responseWriter, http.StatusBadRequest, "InvalidArgument", "partNumber")
+		return
+	}
+
+	part = &partStruct{
+		contents: contents,
+		eTag:     computeETag(contents),
+	}
+
+	upload.partMap[uint32(partNumber)] = part
+
+	responseWriter.Header().Set("ETag", part.eTag)
+	responseWriter.WriteHeader(http.StatusOK)
+}
+
+func doCompleteMultipartUpload(responseWriter http.ResponseWriter, bucketName string, key string, uploadID string, requestBuf []byte) {
+	var (
+		bucket                  *bucketStruct
+		completeMultipartUpload completeMultipartUploadStruct
+		contents                []byte
+		err                     error
+		object                  *objectStruct
+		ok                      bool
+		part                    *partStruct
+		partIndex               int
+		prevPartNumber          uint32
+		upload                  *uploadStruct
+	)
+
+	bucket, upload, ok = locateUpload(responseWriter, bucketName, key, uploadID)
+	if !ok {
+		return
+	}
+
+	err = xml.Unmarshal(requestBuf, &completeMultipartUpload)
+	if (nil != err) || (0 == len(completeMultipartUpload.Parts)) {
+		writeError(responseWriter, http.StatusBadRequest, "MalformedXML", "")
+		return
+	}
+
+	contents = make([]byte, 0)
+	prevPartNumber = 0
+
+	for partIndex = range completeMultipartUpload.Parts {
+		if completeMultipartUpload.Parts[partIndex].PartNumber <= prevPartNumber {
+			writeError(responseWriter, http.StatusBadRequest, "InvalidPartOrder", "")
+			return
+		}
+		prevPartNumber = completeMultipartUpload.Parts[partIndex].PartNumber
+
+		part, ok = upload.partMap[completeMultipartUpload.Parts[partIndex].PartNumber]
+		if !ok || (part.eTag != completeMultipartUpload.Parts[partIndex].ETag) {
+			writeError(responseWriter, http.StatusBadRequest, "InvalidPart", fmt.Sprintf("%d", completeMultipartUpload.Parts[partIndex].PartNumber))
+			return
+		}
+
+		if (partIndex < len(completeMultipartUpload.Parts)-1) && (uint64(len(part.contents)) < globals.config.MinPartSize) {
+			writeError(responseWriter, http.StatusBadRequest, "EntityTooSmall", fmt.Sprintf("%d", completeMultipartUpload.Parts[partIndex].PartNumber))
+			return
+		}
+
+		contents = append(contents, part.contents...)
+	}
+
+	object = &objectStruct{
+		key:          key,
+		metadata:     upload.metadata,
+		contents:     contents,
+		eTag:         fmt.Sprintf("\"%s-%d\"", strings.Trim(computeETag(contents), "\""), len(completeMultipartUpload.Parts)),
+		lastModified: time.Now(),
+	}
+
+	bucket.objectMap[key] = object
+
+	delete(bucket.uploadMap, uploadID)
+
+	writeXML(responseWriter, &completeMultipartUploadResultStruct{
+		XMLNS:  xmlNamespace,
+		Bucket: bucketName,
+		Key:    key,
+		ETag:   object.eTag,
+	})
+}
+
+func doAbortMultipartUpload(responseWriter http.ResponseWriter, bucketName string, key string, uploadID string) {
+	var (
+		bucket *bucketStruct
+		ok     bool
+	)
+
+	bucket, _, ok = locateUpload(responseWriter, bucketName, key, uploadID)
+	if !ok {
+		return
+	}
+
+	delete(bucket.uploadMap, uploadID)
+
+	responseWriter.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package ems3pkg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/NVIDIA/proxyfs/conf"
+)
+
+func TestEmulator(t *testing.T) {
+	var (
+		completeBody []byte
+		confMap      conf.ConfMap
+		confStrings  = []string{
+			"EMS3.IPAddr=127.0.0.1",
+			"EMS3.TCPPort=9000",
+			"EMS3.MinPartSize=4",
+			"EMS3.ListingLimit=2",
+		}
+		err                     error
+		initiateMultipartUpload initiateMultipartUploadResultStruct
+		listBucketResult        listBucketResultStruct
+		partETag1               string
+		partETag2               string
+		responseBuf             []byte
+		responseHeader          http.Header
+		statusCode              int
+		urlPrefix               string
+	)
+
+	doRequest := func(method string, url string, headers map[string]string, body []byte) (statusCode int, responseHeader http.Header, responseBuf []byte) {
+		var (
+			err          error
+			headerName   string
+			headerValue  string
+			httpRequest  *http.Request
+			httpResponse *http.Response
+		)
+
+		httpRequest, err = http.NewRequest(method, url, bytes.NewReader(body))
+		if nil != err {
+			t.Fatalf("http.NewRequest() returned unexpected error: %v", err)
+		}
+		for headerName, headerValue = range headers {
+			httpRequest.Header.Set(headerName, headerValue)
+		}
+		httpResponse, err = http.DefaultClient.Do(httpRequest)
+		if nil != err {
+			t.Fatalf("http.DefaultClient.Do() returned unexpected error: %v", err)
+		}
+		responseBuf, err = ioutil.ReadAll(httpResponse.Body)
+		if nil != err {
+			t.Fatalf("ioutil.ReadAll() returned unexpected error: %v", err)
+		}
+		err = httpResponse.Body.Close()
+		if nil != err {
+			t.Fatalf("httpResponse.Body.Close() returned unexpected error: %v", err)
+		}
+
+		statusCode = httpResponse.StatusCode
+		responseHeader = httpResponse.Header
+
+		return
+	}
+
+	confMap, err = conf.MakeConfMapFromStrings(confStrings)
+	if nil != err {
+		t.Fatalf("conf.MakeConfMapFromStrings(confStrings) returned unexpected error: %v", err)
+	}
+
+	err = Start(confMap)
+	if nil != err {
+		t.Fatalf("Start(confMap) returned unexpected error: %v", err)
+	}
+
+	urlPrefix = "http://" + globals.emulatorHTTPServer.Addr + "/"
+
+	// Create a bucket and verify it exists
+
+	statusCode, _, _ = doRequest(http.MethodHead, urlPrefix+"bucket", nil, nil)
+	if http.StatusNotFound != statusCode {
+		t.Fatalf("HEAD of missing bucket returned %d", statusCode)
+	}
+	statusCode, _, _ = doRequest(http.MethodPut, urlPrefix+"bucket", nil, nil)
+	if http.StatusOK != statusCode {
+		t.Fatalf("PUT of bucket returned %d", statusCode)
+	}
+	statusCode, _, _ = doRequest(http.MethodHead, urlPrefix+"bucket", nil, nil)
+	if http.StatusOK != statusCode {
+		t.Fatalf("HEAD of bucket returned %d", statusCode)
+	}
+
+	// PUT some objects and GET them (in whole and in part) back
+
+	statusCode, _, _ = doRequest(http.MethodPut, urlPrefix+"bucket/a", map[string]string{"X-Amz-Meta-Cat": "Dinah"}, []byte("0123456789"))
+	if http.StatusOK != statusCode {
+		t.Fatalf("PUT of object returned %d", statusCode)
+	}
+	statusCode, _, _ = doRequest(http.MethodPut, urlPrefix+"bucket/b/1", nil, []byte("b1"))
+	if http.StatusOK != statusCode {
+		t.Fatalf("PUT of object returned %d", statusCode)
+	}
+	statusCode, _, _ = doRequest(http.MethodPut, urlPrefix+"bucket/b/2", nil, []byte("b2"))
+	if http.StatusOK != statusCode {
+		t.Fatalf("PUT of object returned %d", statusCode)
+	}
+	statusCode, _, _ = doRequest(http.MethodPut, urlPrefix+"bucket/c", nil, []byte("c"))
+	if http.StatusOK != statusCode {
+		t.Fatalf("PUT of object returned %d", statusCode)
+	}
+
+	statusCode, _, responseBuf = doRequest(http.MethodGet, urlPrefix+"bucket/a", map[string]string{"Range": "bytes=2-4"}, nil)
+	if (http.StatusPartialContent != statusCode) || ("234" != string(responseBuf)) {
+		t.Fatalf("GET of object range returned %d \"%s\"", statusCode, string(responseBuf))
+	}
+	statusCode, _, responseBuf = doRequest(http.MethodGet, urlPrefix+"bucket/a", map[string]string{"Range": "bytes=-3"}, nil)
+	if (http.StatusPartialContent != statusCode) || ("789" != string(responseBuf)) {
+		t.Fatalf("GET of object tail returned %d \"%s\"", statusCode, string(responseBuf))
+	}
+	statusCode, _, _ = doRequest(http.MethodGet, urlPrefix+"bucket/a", map[string]string{"Range": "bytes=10-"}, nil)
+	if http.StatusRequestedRangeNotSatisfiable != statusCode {
+		t.Fatalf("GET of object beyond EOF returned %d", statusCode)
+	}
+
+	// Verify COPY preserves metadata
+
+	statusCode, _, _ = doRequest(http.MethodPut, urlPrefix+"bucket/d", map[string]string{"X-Amz-Copy-Source": "/bucket/a"}, nil)
+	if http.StatusOK != statusCode {
+		t.Fatalf("PUT-COPY of object returned %d", statusCode)
+	}
+	statusCode, responseHeader, responseBuf = doRequest(http.MethodGet, urlPrefix+"bucket/d", nil, nil)
+	if (http.StatusOK != statusCode) || ("0123456789" != string(responseBuf)) || ("Dinah" != responseHeader.Get("X-Amz-Meta-Cat")) {
+		t.Fatalf("GET of copied object returned %d \"%s\" %v", statusCode, string(responseBuf), responseHeader)
+	}
+
+	// List with a delimiter paginated by ListingLimit
+
+	statusCode, _, responseBuf = doRequest(http.MethodGet, urlPrefix+"bucket?list-type=2&delimiter=/", nil, nil)
+	if http.StatusOK != statusCode {
+		t.Fatalf("GET of bucket returned %d", statusCode)
+	}
+	err = xml.Unmarshal(responseBuf, &listBucketResult)
+	if nil != err {
+		t.Fatalf("xml.Unmarshal() returned unexpected error: %v", err)
+	}
+	if !listBucketResult.IsTruncated || (1 != len(listBucketResult.Contents)) || ("a" != listBucketResult.Contents[0].Key) || (1 != len(listBucketResult.CommonPrefixes)) || ("b/" != listBucketResult.CommonPrefixes[0].Prefix) {
+		t.Fatalf("GET of bucket returned unexpected first page: %s", string(responseBuf))
+	}
+
+	statusCode, _, responseBuf = doRequest(http.MethodGet, urlPrefix+"bucket?list-type=2&delimiter=/&continuation-token="+listBucketResult.NextContinuationToken, nil, nil)
+	if http.StatusOK != statusCode {
+		t.Fatalf("GET of bucket returned %d", statusCode)
+	}
+	listBucketResult = listBucketResultStruct{}
+	err = xml.Unmarshal(responseBuf, &listBucketResult)
+	if nil != err {
+		t.Fatalf("xml.Unmarshal() returned unexpected error: %v", err)
+	}
+	if listBucketResult.IsTruncated || (2 != len(listBucketResult.Contents)) || ("c" != listBucketResult.Contents[0].Key) || ("d" != listBucketResult.Contents[1].Key) || (0 != len(listBucketResult.CommonPrefixes)) {
+		t.Fatalf("GET of bucket returned unexpected second page: %s", string(responseBuf))
+	}
+
+	// Perform a multipart upload... first with a too small non-final part
+
+	statusCode, _, responseBuf = doRequest(http.MethodPost, urlPrefix+"bucket/e?uploads", nil, nil)
+	if http.StatusOK != statusCode {
+		t.Fatalf("POST of uploads returned %d", statusCode)
+	}
+	err = xml.Unmarshal(responseBuf, &initiateMultipartUpload)
+	if nil != err {
+		t.Fatalf("xml.Unmarshal() returned unexpected error: %v", err)
+	}
+
+	statusCode, responseHeader, _ = doRequest(http.MethodPut, urlPrefix+"bucket/e?partNumber=1&uploadId="+initiateMultipartUpload.UploadID, nil, []byte("abc"))
+	if http.StatusOK != statusCode {
+		t.Fatalf("PUT of part 1 returned %d", statusCode)
+	}
+	partETag1 = responseHeader.Get("ETag")
+	statusCode, responseHeader, _ = doRequest(http.MethodPut, urlPrefix+"bucket/e?partNumber=2&uploadId="+initiateMultipartUpload.UploadID, nil, []byte("de"))
+	if http.StatusOK != statusCode {
+		t.Fatalf("PUT of part 2 returned %d", statusCode)
+	}
+	partETag2 = responseHeader.Get("ETag")
+
+	completeBody = []byte(fmt.Sprintf("<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>%s</ETag></Part><Part><PartNumber>2</PartNumber><ETag>%s</ETag></Part></CompleteMultipartUpload>", partETag1, partETag2))
+
+	statusCode, _, _ = doRequest(http.MethodPost, urlPrefix+"bucket/e?uploadId="+initiateMultipartUpload.UploadID, nil, completeBody)
+	if http.StatusBadRequest != statusCode {
+		t.Fatalf("POST of complete with too small part returned %d", statusCode)
+	}
+
+	statusCode, responseHeader, _ = doRequest(http.MethodPut, urlPrefix+"bucket/e?partNumber=1&uploadId="+initiateMultipartUpload.UploadID, nil, []byte("abcd"))
+	if http.StatusOK != statusCode {
+		t.Fatalf("PUT of part 1 returned %d", statusCode)
+	}
+	partETag1 = responseHeader.Get("ETag")
+
+	completeBody = []byte(fmt.Sprintf("<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>%s</ETag></Part><Part><PartNumber>2</PartNumber><ETag>%s</ETag></Part></CompleteMultipartUpload>", partETag1, partETag2))
+
+	statusCode, _, _ = doRequest(http.MethodPost, urlPrefix+"bucket/e?uploadId="+initiateMultipartUpload.UploadID, nil, completeBody)
+	if http.StatusOK != statusCode {
+		t.Fatalf("POST of complete returned %d", statusCode)
+	}
+	statusCode, _, responseBuf = doRequest(http.MethodGet, urlPrefix+"bucket/e", nil, nil)
+	if (http.StatusOK != statusCode) || ("abcdde" != string(responseBuf)) {
+		t.Fatalf("GET of multipart uploaded object returned %d \"%s\"", statusCode, string(responseBuf))
+	}
+
+	// Verify a non-empty bucket cannot be deleted
+
+	statusCode, _, _ = doRequest(http.MethodDelete, urlPrefix+"bucket", nil, nil)
+	if http.StatusConflict != statusCode {
+		t.Fatalf("DELETE of non-empty bucket returned %d", statusCode)
+	}
+
+	for _, key := range []string{"a", "b/1", "b/2", "c", "d", "e", "missing"} {
+		statusCode, _, _ = doRequest(http.MethodDelete, urlPrefix+"bucket/"+key, nil, nil)
+		if http.StatusNoContent != statusCode {
+			t.Fatalf("DELETE of object %s returned %d", key, statusCode)
+		}
+	}
+
+	statusCode, _, _ = doRequest(http.MethodDelete, urlPrefix+"bucket", nil, nil)
+	if http.StatusNoContent != statusCode {
+		t.Fatalf("DELETE of bucket returned %d", statusCode)
+	}
+
+	err = Stop()
+	if nil != err {
+		t.Fatalf("Stop() returned unexpected error: %v", err)
+	}
+}
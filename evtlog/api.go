@@ -55,6 +55,8 @@ const (
 	FormatHeadhunterRecordTransactionDeleteLogSegmentRec
 	FormatHeadhunterRecordTransactionPutBPlusTreeObject
 	FormatHeadhunterRecordTransactionDeleteBPlusTreeObject
+	FormatHeadhunterRecordTransactionShareLogSegmentRec
+	FormatHeadhunterRecordTransactionShareBPlusTreeObject
 	FormatHeadhunterMissingInodeRec
 	FormatHeadhunterMissingLogSegmentRec
 	FormatHeadhunterMissingBPlusTreeObject
@@ -68,6 +70,8 @@ const (
 	FormatLeaseRequest
 	FormatLeaseReply
 	FormatLeaseInterrupt
+	FormatSnapShotPolicyRetain
+	FormatSnapShotPolicyDelete
 	//
 	formatTypeCount // Used to quickly check upper limit of FormatType values
 )
@@ -297,6 +301,14 @@ var (
 			patternType:  patternS016X,
 			formatString: "%s Headhunter recording DeleteBPlusTreeObject for Volume '%s' Virtual Object# 0x%016X",
 		},
+		eventType{ // FormatHeadhunterRecordTransactionShareLogSegmentRec
+			patternType:  patternS016X,
+			formatString: "%s Headhunter recording ShareLogSegmentRec for Volume '%s' LogSegment# 0x%016X",
+		},
+		eventType{ // FormatHeadhunterRecordTransactionShareBPlusTreeObject
+			patternType:  patternS016X,
+			formatString: "%s Headhunter recording ShareBPlusTreeObject for Volume '%s' Virtual Object# 0x%016X",
+		},
 		eventType{ // FormatHeadhunterMissingInodeRec
 			patternType:  patternS016X,
 			formatString: "%s Headhunter recording DeleteBPlusTreeObject for Volume '%s' Inode# 0x%016X",
@@ -349,6 +361,14 @@ var (
 			patternType:  patternSS016X1X,
 			formatString: "%s jrpcfs.RpcLease() entered for Volume '%s' MountID '%s' Inode# 0x%016X InterruptType %1X",
 		},
+		eventType{ // FormatSnapShotPolicyRetain
+			patternType:  patternSSS,
+			formatString: "%s inode.snapShotPolicy.prune() for Volume '%s' retained SnapShot '%s' (%s)",
+		},
+		eventType{ // FormatSnapShotPolicyDelete
+			patternType:  patternSSS,
+			formatString: "%s inode.snapShotPolicy.prune() for Volume '%s' deleting SnapShot '%s' (%s)",
+		},
 	}
 )
 
@@ -91,11 +91,13 @@ const (
 )
 
 type FlockStruct struct {
-	Type   int32
-	Whence int32
-	Start  uint64
-	Len    uint64
-	Pid    uint64
+	Type    int32
+	Whence  int32
+	Start   uint64
+	Len     uint64
+	Pid     uint64
+	MountID string // If non-empty, lock is owned by {MountID,Owner} rather than Pid
+	Owner   uint64
 }
 
 type StatKey uint64
@@ -136,10 +138,38 @@ const (
 	StatVFSFilesystemID                         // statvfs.f_fsid  - Our filesystem ID
 	StatVFSMountFlags                           // statvfs.f_flag  - mount flags
 	StatVFSMaxFilenameLen                       // statvfs.f_namemax - maximum filename length
+	StatVFSLogicalBytes                         // (not in statvfs) uncompressed bytes of compressed LogSegments
+	StatVFSPhysicalBytes                        // (not in statvfs) bytes actually stored for compressed LogSegments
 )
 
 type StatVFS map[StatVFSKey]uint64 // key is one of StatVFSKey consts
 
+// SnapShotDiffType identifies how an inode differs between two SnapShots
+//
+type SnapShotDiffType string
+
+const (
+	SnapShotDiffCreated  SnapShotDiffType = "created"
+	SnapShotDiffDeleted  SnapShotDiffType = "deleted"
+	SnapShotDiffModified SnapShotDiffType = "modified"
+	SnapShotDiffRenamed  SnapShotDiffType = "renamed" // contents may also have been modified
+)
+
+// SnapShotDiffEntryStruct is reported by SnapShotDiff for each inode that differs.
+// InodeNumber is not adorned with a SnapShotID. Path is in the "to" SnapShot other
+// than for SnapShotDiffDeleted where it is in the "from" SnapShot. Should an inode
+// have several links (i.e. hard links), Path is just one of them.
+//
+type SnapShotDiffEntryStruct struct {
+	Type        SnapShotDiffType
+	InodeNumber uint64
+	InodeType   inode.InodeType
+	Path        string
+	FromPath    string // Only set for SnapShotDiffRenamed
+}
+
+type SnapShotDiffCallback func(entry *SnapShotDiffEntryStruct) (err error)
+
 type JobHandle interface {
 	Active() (active bool)
 	Wait()
@@ -166,12 +196,15 @@ type VolumeHandle interface {
 	Create(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, dirInodeNumber inode.InodeNumber, basename string, filePerm inode.InodeMode) (fileInodeNumber inode.InodeNumber, err error)
 	DefragmentFile(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, fileInodeNumber inode.InodeNumber) (err error)
 	Destroy(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (err error)
+	Fallocate(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, mode uint32, offset uint64, length uint64) (err error)
 	FetchExtentMapChunk(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, fileInodeNumber inode.InodeNumber, fileOffset uint64, maxEntriesFromFileOffset int64, maxEntriesBeforeFileOffset int64) (extentMapChunk *inode.ExtentMapChunkStruct, err error)
 	Flush(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (err error)
 	Flock(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, lockCmd int32, inFlockStruct *FlockStruct) (outFlockStruct *FlockStruct, err error)
+	FlockReleaseAll(mountID string)
 	Getstat(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (stat Stat, err error)
 	GetType(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (inodeType inode.InodeType, err error)
 	GetXAttr(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, streamName string) (value []byte, err error)
+	IsEncrypted() (encrypted bool)
 	IsDir(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (inodeIsDir bool, err error)
 	IsFile(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (inodeIsFile bool, err error)
 	IsSymlink(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (inodeIsSymlink bool, err error)
@@ -179,6 +212,7 @@ type VolumeHandle interface {
 	ListXAttr(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (streamNames []string, err error)
 	Lookup(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, dirInodeNumber inode.InodeNumber, basename string) (inodeNumber inode.InodeNumber, err error)
 	LookupPath(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, fullpath string) (inodeNumber inode.InodeNumber, err error)
+	Lseek(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, offset uint64, whence int) (newOffset uint64, err error)
 	MiddlewareCoalesce(destPath string, metaData []byte, elementPaths []string) (ino uint64, numWrites uint64, attrChangeTime uint64, modificationTime uint64, err error)
 	MiddlewareDelete(parentDir string, baseName string) (err error)
 	MiddlewareGetAccount(maxEntries uint64, marker string, endmarker string) (accountEnts []AccountEntry, mtime uint64, ctime uint64, err error)
@@ -191,6 +225,7 @@ type VolumeHandle interface {
 	MiddlewarePutContainer(containerName string, oldMetadata []byte, newMetadata []byte) (err error)
 	Mkdir(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, basename string, filePerm inode.InodeMode) (newDirInodeNumber inode.InodeNumber, err error)
 	Move(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, srcDirInodeNumber inode.InodeNumber, srcBasename string, dstDirInodeNumber inode.InodeNumber, dstBasename string) (toDestroyInodeNumber inode.InodeNumber, err error)
+	ObjectStoreBackend() (backendName string)
 	RemoveXAttr(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, streamName string) (err error)
 	Rename(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, srcDirInodeNumber inode.InodeNumber, srcBasename string, dstDirInodeNumber inode.InodeNumber, dstBasename string) (err error)
 	Read(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, offset uint64, length uint64, profiler *utils.Profiler) (buf []byte, err error)
@@ -199,14 +234,19 @@ type VolumeHandle interface {
 	Readsymlink(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (target string, err error)
 	Resize(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, newSize uint64) (err error)
 	Rmdir(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, basename string) (err error)
+	SetProjectID(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, projectID uint64) (err error)
 	Setstat(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, stat Stat) (err error)
 	SetXAttr(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, streamName string, value []byte, flags int) (err error)
-	StatVfs() (statVFS StatVFS, err error)
+	SnapShotClone(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, snapShotInodeNumber inode.InodeNumber, dstDirInodeNumber inode.InodeNumber, dstBasename string) (cloneInodeNumber inode.InodeNumber, err error)
+	SnapShotDiff(fromSnapShotID uint64, toSnapShotID uint64, callback SnapShotDiffCallback) (err error) // SnapShotID == 0 means the live view
+	SnapShotRestore(snapShotID uint64, fullpath string) (restoredInodeNumber inode.InodeNumber, err error)
+	SnapShotRollback(snapShotID uint64) (err error)
+	StatVfs(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (statVFS StatVFS, err error)
 	Symlink(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, basename string, target string) (symlinkInodeNumber inode.InodeNumber, err error)
 	Unlink(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, basename string) (err error)
 	VolumeName() (volumeName string)
 	Write(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, offset uint64, buf []byte, profiler *utils.Profiler) (size uint64, err error)
-	Wrote(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, containerName string, objectName string, fileOffset []uint64, objectOffset []uint64, length []uint64, wroteTime uint64) (err error)
+	Wrote(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, containerName string, objectName string, fileOffset []uint64, objectOffset []uint64, length []uint64, checksums *inode.LogSegmentChecksumsStruct, compression *inode.LogSegmentCompressionStruct, wroteTime uint64) (err error)
 }
 
 // ValidateVolume performs an "FSCK" on the specified volumeName.
@@ -14,10 +14,13 @@ import (
 	"syscall"
 	"time"
 
+	"golang.org/x/sys/unix"
+
 	"github.com/NVIDIA/proxyfs/blunder"
 	"github.com/NVIDIA/proxyfs/dlm"
 	"github.com/NVIDIA/proxyfs/inode"
 	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/tracing"
 	"github.com/NVIDIA/proxyfs/utils"
 )
 
@@ -272,8 +275,10 @@ func fetchVolumeHandleByVolumeName(volumeName string) (volumeHandle VolumeHandle
 
 func (vS *volumeStruct) Access(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, accessMode inode.InodeMode) (accessReturn bool) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Access", tracing.SpanKindInternal)
 	defer func() {
-		globals.AccessUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.AccessUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		span.End()
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -286,11 +291,13 @@ func (vS *volumeStruct) Access(userID inode.InodeUserID, groupID inode.InodeGrou
 
 func (vS *volumeStruct) CallInodeToProvisionObject() (pPath string, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.CallInodeToProvisionObject", tracing.SpanKindInternal)
 	defer func() {
-		globals.CallInodeToProvisionObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.CallInodeToProvisionObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.CallInodeToProvisionObjectErrors.Add(1)
+			vS.stats.CallInodeToProvisionObjectErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -302,11 +309,13 @@ func (vS *volumeStruct) CallInodeToProvisionObject() (pPath string, err error) {
 
 func (vS *volumeStruct) Create(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, dirInodeNumber inode.InodeNumber, basename string, filePerm inode.InodeMode) (fileInodeNumber inode.InodeNumber, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Create", tracing.SpanKindInternal)
 	defer func() {
-		globals.CreateUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.CreateUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.CreateErrors.Add(1)
+			vS.stats.CreateErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -343,6 +352,24 @@ func (vS *volumeStruct) Create(userID inode.InodeUserID, groupID inode.InodeGrou
 		return 0, err
 	}
 
+	err = vS.inheritProjectID(dirInodeNumber, fileInodeNumber)
+	if err != nil {
+		destroyErr := vS.inodeVolumeHandle.Destroy(fileInodeNumber)
+		if destroyErr != nil {
+			logger.WarnfWithError(destroyErr, "couldn't destroy inode %v after failed inheritProjectID() in fs.Create", fileInodeNumber)
+		}
+		return 0, err
+	}
+
+	err = vS.inodeVolumeHandle.InheritPosixACL(dirInodeNumber, fileInodeNumber)
+	if err != nil {
+		destroyErr := vS.inodeVolumeHandle.Destroy(fileInodeNumber)
+		if destroyErr != nil {
+			logger.WarnfWithError(destroyErr, "couldn't destroy inode %v after failed InheritPosixACL() in fs.Create", fileInodeNumber)
+		}
+		return 0, err
+	}
+
 	err = vS.inodeVolumeHandle.Link(dirInodeNumber, basename, fileInodeNumber, false)
 	if err != nil {
 		destroyErr := vS.inodeVolumeHandle.Destroy(fileInodeNumber)
@@ -364,11 +391,13 @@ func (vS *volumeStruct) DefragmentFile(userID inode.InodeUserID, groupID inode.I
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.DefragmentFile", tracing.SpanKindInternal)
 	defer func() {
-		globals.DefragmentFileUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.DefragmentFileUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.DefragmentFileErrors.Add(1)
+			vS.stats.DefragmentFileErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -438,6 +467,115 @@ func (vS *volumeStruct) DefragmentFile(userID inode.InodeUserID, groupID inode.I
 	}
 }
 
+func (vS *volumeStruct) Fallocate(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, mode uint32, offset uint64, length uint64) (err error) {
+	var (
+		inodeLock *dlm.RWLockStruct
+		inodeType inode.InodeType
+		metadata  *inode.MetadataStruct
+	)
+
+	startTime := time.Now()
+	span := tracing.StartSpan("fs.Fallocate", tracing.SpanKindInternal)
+	defer func() {
+		vS.stats.FallocateUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			vS.stats.FallocateErrors.Add(1)
+		}
+		span.EndWithError(err)
+	}()
+
+	// Validate args
+
+	switch mode {
+	case 0:
+	case unix.FALLOC_FL_KEEP_SIZE:
+	case unix.FALLOC_FL_PUNCH_HOLE | unix.FALLOC_FL_KEEP_SIZE:
+	case unix.FALLOC_FL_ZERO_RANGE:
+	case unix.FALLOC_FL_ZERO_RANGE | unix.FALLOC_FL_KEEP_SIZE:
+	default:
+		err = blunder.NewError(blunder.NotSupportedError, "Fallocate() mode (0x%X) not supported", mode)
+		return
+	}
+
+	if 0 == length {
+		err = blunder.NewError(blunder.InvalidArgError, "Fallocate() requires length > 0")
+		return
+	}
+	if (offset > math.MaxInt64) || (length > (math.MaxInt64 - offset)) {
+		err = blunder.NewError(blunder.FileTooLargeError, "Fallocate() offset (0x%016X) + length (0x%016X) too large", offset, length)
+		return
+	}
+
+	vS.jobRWMutex.RLock()
+	defer vS.jobRWMutex.RUnlock()
+
+	inodeLock, err = vS.inodeVolumeHandle.InitInodeLock(inodeNumber, nil)
+	if nil != err {
+		return
+	}
+	err = inodeLock.WriteLock()
+	if nil != err {
+		return
+	}
+	defer inodeLock.Unlock()
+
+	if !vS.inodeVolumeHandle.Access(inodeNumber, userID, groupID, otherGroupIDs, inode.F_OK,
+		inode.NoOverride) {
+		err = blunder.NewError(blunder.NotFoundError, "ENOENT")
+		return
+	}
+	if !vS.inodeVolumeHandle.Access(inodeNumber, userID, groupID, otherGroupIDs, inode.W_OK,
+		inode.OwnerOverride) {
+		err = blunder.NewError(blunder.PermDeniedError, "EACCES")
+		return
+	}
+
+	inodeType, err = vS.inodeVolumeHandle.GetType(inodeNumber)
+	if nil != err {
+		logger.ErrorfWithError(err, "couldn't get type for inode %v", inodeNumber)
+		return
+	}
+	// Make sure the inode number is for a file inode
+	if inodeType != inode.FileType {
+		err = fmt.Errorf("%s: expected inode %v to be a file inode, got %v", utils.GetFnName(), inodeNumber, inodeType)
+		logger.ErrorWithError(err)
+		err = blunder.AddError(err, blunder.NotFileError)
+		return
+	}
+
+	// Since holes already "read-as-zero", there is no need to actually allocate anything...
+	// but PUNCH_HOLE and ZERO_RANGE must discard any extents in the range. As quota is only
+	// charged for the bytes referenced by extents, PunchHole() credits any discarded bytes
+	// while a KEEP_SIZE (or size-extending) allocation charges nothing.
+
+	if 0 != (mode & (unix.FALLOC_FL_PUNCH_HOLE | unix.FALLOC_FL_ZERO_RANGE)) {
+		err = vS.inodeVolumeHandle.PunchHole(inodeNumber, offset, length)
+		if nil != err {
+			return
+		}
+	}
+
+	// Absent KEEP_SIZE, extend the file if the range goes beyond end-of-file
+
+	if 0 == (mode & unix.FALLOC_FL_KEEP_SIZE) {
+		metadata, err = vS.inodeVolumeHandle.GetMetadata(inodeNumber)
+		if nil != err {
+			return
+		}
+
+		if (offset + length) > metadata.Size {
+			err = vS.inodeVolumeHandle.SetSize(inodeNumber, offset+length)
+			if nil != err {
+				return
+			}
+		}
+	}
+
+	vS.untrackInFlightFileInodeData(inodeNumber, false)
+
+	return
+}
+
 func (vS *volumeStruct) FetchExtentMapChunk(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, fileInodeNumber inode.InodeNumber, fileOffset uint64, maxEntriesFromFileOffset int64, maxEntriesBeforeFileOffset int64) (extentMapChunk *inode.ExtentMapChunkStruct, err error) {
 	var (
 		inodeLock *dlm.RWLockStruct
@@ -445,11 +583,13 @@ func (vS *volumeStruct) FetchExtentMapChunk(userID inode.InodeUserID, groupID in
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.FetchExtentMapChunk", tracing.SpanKindInternal)
 	defer func() {
-		globals.FetchExtentMapChunkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.FetchExtentMapChunkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.FetchExtentMapChunkErrors.Add(1)
+			vS.stats.FetchExtentMapChunkErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -520,11 +660,13 @@ func (vS *volumeStruct) doInlineCheckpointIfEnabled() {
 
 func (vS *volumeStruct) Flush(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Flush", tracing.SpanKindInternal)
 	defer func() {
-		globals.FlushUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.FlushUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.FlushErrors.Add(1)
+			vS.stats.FlushErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -559,10 +701,9 @@ func (vS *volumeStruct) Flush(userID inode.InodeUserID, groupID inode.InodeGroup
 	return
 }
 
+// getFileLockList returns the list of locks held on inodeNumber (creating it if missing).
+// Caller must hold vS.flockMutex.
 func (vS *volumeStruct) getFileLockList(inodeNumber inode.InodeNumber) (flockList *list.List) {
-	vS.dataMutex.Lock()
-	defer vS.dataMutex.Unlock()
-
 	flockList, ok := vS.FLockMap[inodeNumber]
 	if !ok {
 		flockList = new(list.List)
@@ -572,14 +713,43 @@ func (vS *volumeStruct) getFileLockList(inodeNumber inode.InodeNumber) (flockLis
 	return
 }
 
-// Check for lock conflict with other Pids, if there is a conflict then it will return the first occurance of conflicting range.
+// dropFileLockListIfEmpty removes inodeNumber's list of locks if it no longer holds any.
+// Caller must hold vS.flockMutex.
+func (vS *volumeStruct) dropFileLockListIfEmpty(inodeNumber inode.InodeNumber) {
+	flockList, ok := vS.FLockMap[inodeNumber]
+	if ok && (0 == flockList.Len()) {
+		delete(vS.FLockMap, inodeNumber)
+	}
+}
+
+// end returns the offset just beyond the range covered by flock (saturating rather than wrapping).
+func (flock *FlockStruct) end() uint64 {
+	if flock.Len > (^uint64(0) - flock.Start) {
+		return ^uint64(0)
+	}
+
+	return flock.Start + flock.Len
+}
+
+// sameOwner reports whether flock and other are held by the same lock owner. Locks taken on
+// behalf of a mount (i.e. with a non-empty MountID) are owned by their {MountID,Owner} tuple
+// while all others are owned by their Pid.
+func (flock *FlockStruct) sameOwner(other *FlockStruct) bool {
+	if ("" != flock.MountID) || ("" != other.MountID) {
+		return (flock.MountID == other.MountID) && (flock.Owner == other.Owner)
+	}
+
+	return flock.Pid == other.Pid
+}
+
+// Check for lock conflict with other owners, if there is a conflict then it will return the first occurance of conflicting range.
 func checkConflict(elm *FlockStruct, flock *FlockStruct) bool {
 
-	if flock.Pid == elm.Pid {
+	if flock.sameOwner(elm) {
 		return false
 	}
 
-	if (elm.Start+elm.Len) <= flock.Start || (flock.Start+flock.Len) <= elm.Start {
+	if elm.end() <= flock.Start || flock.end() <= elm.Start {
 		return false
 	}
 
@@ -604,225 +774,154 @@ func (vS *volumeStruct) verifyLock(inodeNumber inode.InodeNumber, flock *FlockSt
 	return nil
 }
 
-// Insert a file lock range to corresponding lock list for the pid.
-// Assumption: There is no lock conflict and the range that is being inserted has no conflict and is free.
-func (vS *volumeStruct) fileLockInsert(inodeNumber inode.InodeNumber, inFlock *FlockStruct) (err error) {
-	err = nil
-	flockList := vS.getFileLockList(inodeNumber)
-
-	overlapList := new(list.List)
-	var beforeElm *list.Element // Refers to the immediate element that starts before the start of the range.
-	var afterElm *list.Element  // Refers to the immediate element that starts after the end of the range.
-
-	// flockList is sorted by starting offset of the range.
-	// Inserting a range happens in two steps. 1) Check if there is any conflict and also identify the
-	// point in the list where the entry will be added (before and after elements) 2) Then check if
-	// the range can extend the before element, if so adjust it. 3) Simillarly, check if the after
-	// element can be collapsed if it forms a contiguous range.
-
+// insertFileLock inserts flock into flockList maintaining the list sorted by starting offset.
+func insertFileLock(flockList *list.List, flock *FlockStruct) {
 	for e := flockList.Front(); e != nil; e = e.Next() {
 		elm := e.Value.(*FlockStruct)
 
-		if (elm.Start + elm.Len) <= inFlock.Start {
-			beforeElm = e
-			continue
+		if elm.Start > flock.Start {
+			flockList.InsertBefore(flock, e)
+			return
 		}
+	}
 
-		if elm.Start > (inFlock.Start + inFlock.Len) {
-			afterElm = e
-			if overlapList.Len() == 0 {
-				flockList.InsertBefore(inFlock, e)
-				return
-			}
+	flockList.PushBack(flock)
+}
 
-			break
-		}
+// Insert a file lock range to corresponding lock list for the owner.
+// Any locks already held by the owner in the range are replaced (e.g. converting a read lock to a write lock).
+func (vS *volumeStruct) fileLockInsert(inodeNumber inode.InodeNumber, inFlock *FlockStruct) (err error) {
+	flockList := vS.getFileLockList(inodeNumber)
+
+	for e := flockList.Front(); e != nil; e = e.Next() {
+		elm := e.Value.(*FlockStruct)
 
 		if checkConflict(elm, inFlock) {
 			err = blunder.AddError(nil, blunder.TryAgainError)
 			return
 		}
-
-		if elm.Pid == inFlock.Pid {
-			overlapList.PushBack(e)
-		}
 	}
 
-	if overlapList.Len() == 0 {
-		if beforeElm != nil {
-			elm := beforeElm.Value.(*FlockStruct)
-			if elm.Pid == inFlock.Pid && elm.Type == inFlock.Type && (elm.Start+elm.Len) == inFlock.Start {
-				elm.Len = inFlock.Start + inFlock.Len - elm.Len
-			} else {
-				flockList.InsertAfter(inFlock, beforeElm)
-			}
-		} else {
-			flockList.PushBack(inFlock)
-		}
+	// There is no conflict, so first remove whatever the owner currently holds in the range
 
+	err = vS.fileUnlock(inodeNumber, inFlock)
+	if nil != err {
 		return
 	}
 
-	// Look at the last element in the overlapping list
-	lastEnt := overlapList.Back()
-	e := lastEnt.Value.(*list.Element)
-	elm := e.Value.(*FlockStruct)
-	if (elm.Start + elm.Len) > (inFlock.Start + inFlock.Len) {
-		inFlock.Len = (elm.Start + elm.Len) - inFlock.Start
-	}
+	newFlock := *inFlock
 
-	// We can delete all the entries in the overlapping list. These entries are replaced by
-	// the range we are inserting.
-	for e := overlapList.Front(); e != nil; e = e.Next() {
-		entry := e.Value.(*list.Element)
-		flockList.Remove(entry)
-	}
+	// Now collapse any of the owner's locks of the same type that are contiguous with the range
 
-	// Now adjust the before and after entries:
-	// First adjust the after:
-	if afterElm != nil {
-		elm := afterElm.Value.(*FlockStruct)
-		if elm.Pid == inFlock.Pid && elm.Type == inFlock.Type && (inFlock.Start+inFlock.Len) == elm.Start {
-			// We can collapse the entry:
-			elm.Len = elm.Start + elm.Len - inFlock.Start
-			elm.Start = inFlock.Start
+	for e := flockList.Front(); e != nil; {
+		next := e.Next()
+		elm := e.Value.(*FlockStruct)
 
-			if beforeElm != nil {
-				belm := beforeElm.Value.(*FlockStruct)
-				if belm.Pid == elm.Pid && belm.Type == elm.Type && (belm.Start+belm.Len) == elm.Start {
-					belm.Len = elm.Start + elm.Len - belm.Start
-					flockList.Remove(afterElm)
-				}
+		if elm.sameOwner(&newFlock) && (elm.Type == newFlock.Type) && ((elm.end() == newFlock.Start) || (newFlock.end() == elm.Start)) {
+			newEnd := newFlock.end()
+			if elm.end() > newEnd {
+				newEnd = elm.end()
 			}
+			if elm.Start < newFlock.Start {
+				newFlock.Start = elm.Start
+			}
+			newFlock.Len = newEnd - newFlock.Start
 
-			return
-		}
-	}
-
-	if beforeElm != nil {
-		belm := beforeElm.Value.(*FlockStruct)
-		if belm.Pid == inFlock.Pid && belm.Type == inFlock.Type && (belm.Start+belm.Len) == inFlock.Start {
-			belm.Len = inFlock.Start + inFlock.Len - belm.Start
+			flockList.Remove(e)
 		}
 
-		flockList.InsertAfter(inFlock, beforeElm)
-		return
+		e = next
 	}
 
-	if afterElm != nil {
-		flockList.InsertBefore(inFlock, afterElm)
-	} else {
-		flockList.PushBack(inFlock)
-	}
+	insertFileLock(flockList, &newFlock)
 
 	return
-
 }
 
-// Unlock a given range. All locks held in this range by the process (identified by Pid) are removed.
+// Unlock a given range. All locks held in this range by the owner are removed.
 func (vS *volumeStruct) fileUnlock(inodeNumber inode.InodeNumber, inFlock *FlockStruct) (err error) {
-
 	flockList := vS.getFileLockList(inodeNumber)
-	if flockList == nil {
-		logger.Warnf("Unlock of a region not already locked - %+v", inFlock)
-		return
-	}
 
-	start := inFlock.Start
-	len := inFlock.Len
+	unlockStart := inFlock.Start
+	unlockEnd := inFlock.end()
 
-	removeList := new(list.List)
+	reinsertList := make([]*FlockStruct, 0)
 
-	for e := flockList.Front(); e != nil; e = e.Next() {
+	for e := flockList.Front(); e != nil; {
+		next := e.Next()
 		elm := e.Value.(*FlockStruct)
 
-		if elm.Pid != inFlock.Pid {
+		if !elm.sameOwner(inFlock) || (elm.end() <= unlockStart) || (elm.Start >= unlockEnd) {
+			e = next
 			continue
 		}
 
-		if (elm.Start + elm.Len) < start {
-			continue
-		}
-
-		if elm.Start >= (start + len) {
-			break
-		}
-
-		// If the lock falls completely in the range, delete it.
-		if elm.Start >= start && (elm.Start+elm.Len) <= (start+len) {
-			removeList.PushBack(e)
-			continue
-		}
+		elmEnd := elm.end()
 
-		// This lock overlapps with the range - three possibalities 1) lock starts before the range, 2) end after range and 3) both.
+		// This lock overlaps with the range - four possibilities 1) lock falls completely in the range,
+		// 2) lock starts before the range, 3) lock ends after the range, and 4) both.
 
-		elmLen := elm.Start + elm.Len // Save the original length, it is required in case of #3 (both)
+		flockList.Remove(e)
 
-		if elm.Start < start { // Handle the first part - lock starts before the range.
-			elm.Len = start - elm.Start
+		if elm.Start < unlockStart {
+			elmHead := *elm
+			elmHead.Len = unlockStart - elm.Start
+			reinsertList = append(reinsertList, &elmHead)
 		}
 
-		if elmLen > (start + len) { // Lock extends beyond the unlock range.
-			if elm.Start > start { // case #2
-				// use the existing record
-				elm.Start = start + len
-				elm.Len = elmLen - elm.Start
-				break
-			}
-
-			// Create a new record - handle case #3 both (starts before the range and extends beyond the range)
-			elmTail := new(FlockStruct)
-			elmTail.Start = start + len
-			elmTail.Len = elmLen - elm.Start
-			elmTail.Pid = elm.Pid
-			elmTail.Type = elm.Type
-			elmTail.Whence = elm.Whence
-			flockList.InsertAfter(elmTail, e)
-			break
+		if elmEnd > unlockEnd {
+			elmTail := *elm
+			elmTail.Start = unlockEnd
+			elmTail.Len = elmEnd - unlockEnd
+			reinsertList = append(reinsertList, &elmTail)
 		}
+
+		e = next
 	}
 
-	for e := removeList.Front(); e != nil; e = e.Next() {
-		elm := e.Value.(*list.Element)
-		flockList.Remove(elm)
+	for _, elm := range reinsertList {
+		insertFileLock(flockList, elm)
 	}
 
 	return
 }
 
-// Implements file locking conforming to fcntl(2) locking description. F_SETLKW is not implemented. Supports F_SETLW and F_GETLW.
+// Implements file locking conforming to fcntl(2) locking description. F_SETLKW is not implemented (callers are expected
+// to retry F_SETLK instead). Supports F_SETLK and F_GETLK.
 // whence: FS supports only SEEK_SET - starting from 0, since it does not manage file handles, caller is expected to supply the start and length relative to offset ZERO.
 func (vS *volumeStruct) Flock(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, lockCmd int32, inFlock *FlockStruct) (outFlock *FlockStruct, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Flock", tracing.SpanKindInternal)
 	defer func() {
 		switch lockCmd {
 
 		case syscall.F_GETLK:
-			globals.FlockGetUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+			vS.stats.FlockGetUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 			if err != nil {
-				globals.FlockGetErrors.Add(1)
+				vS.stats.FlockGetErrors.Add(1)
 			}
 
 		case syscall.F_SETLK:
 			if inFlock.Type == syscall.F_UNLCK {
-				globals.FlockUnlockUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+				vS.stats.FlockUnlockUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 				if err != nil {
-					globals.FlockUnlockErrors.Add(1)
+					vS.stats.FlockUnlockErrors.Add(1)
 				}
 
 			} else if inFlock.Type == syscall.F_WRLCK || inFlock.Type == syscall.F_RDLCK {
-				globals.FlockLockUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+				vS.stats.FlockLockUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 				if err != nil {
-					globals.FlockLockErrors.Add(1)
+					vS.stats.FlockLockErrors.Add(1)
 				}
 			} else {
-				globals.FlockOtherErrors.Add(1)
+				vS.stats.FlockOtherErrors.Add(1)
 			}
 
 		default:
-			globals.FlockOtherErrors.Add(1)
+			vS.stats.FlockOtherErrors.Add(1)
 		}
 
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -859,11 +958,17 @@ func (vS *volumeStruct) Flock(userID inode.InodeUserID, groupID inode.InodeGroup
 		inFlock.Len = ^uint64(0)
 	}
 
+	vS.flockMutex.Lock()
+	defer vS.flockMutex.Unlock()
+
+	defer vS.dropFileLockListIfEmpty(inodeNumber)
+
 	switch lockCmd {
 	case syscall.F_GETLK:
 		conflictLock := vS.verifyLock(inodeNumber, inFlock)
 		if conflictLock != nil {
-			outFlock = conflictLock
+			conflictLockCopy := *conflictLock
+			outFlock = &conflictLockCopy
 			err = blunder.AddError(nil, blunder.TryAgainError)
 		} else {
 			outFlock = inFlock
@@ -892,6 +997,30 @@ func (vS *volumeStruct) Flock(userID inode.InodeUserID, groupID inode.InodeGroup
 	return
 }
 
+// FlockReleaseAll removes every lock held on behalf of the mount identified by mountID.
+func (vS *volumeStruct) FlockReleaseAll(mountID string) {
+	vS.jobRWMutex.RLock()
+	defer vS.jobRWMutex.RUnlock()
+
+	vS.flockMutex.Lock()
+	defer vS.flockMutex.Unlock()
+
+	for inodeNumber, flockList := range vS.FLockMap {
+		for e := flockList.Front(); e != nil; {
+			next := e.Next()
+			elm := e.Value.(*FlockStruct)
+
+			if elm.MountID == mountID {
+				flockList.Remove(e)
+			}
+
+			e = next
+		}
+
+		vS.dropFileLockListIfEmpty(inodeNumber)
+	}
+}
+
 func (vS *volumeStruct) getstatHelper(inodeNumber inode.InodeNumber, callerID dlm.CallerID) (stat Stat, err error) {
 
 	lockID, err := vS.inodeVolumeHandle.MakeLockID(inodeNumber)
@@ -938,11 +1067,13 @@ func (vS *volumeStruct) getstatHelperWhileLocked(inodeNumber inode.InodeNumber)
 
 func (vS *volumeStruct) Getstat(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (stat Stat, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Getstat", tracing.SpanKindInternal)
 	defer func() {
-		globals.GetstatUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.GetstatUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.GetstatErrors.Add(1)
+			vS.stats.GetstatErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -984,11 +1115,13 @@ func (vS *volumeStruct) getTypeHelper(inodeNumber inode.InodeNumber, callerID dl
 
 func (vS *volumeStruct) GetType(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (inodeType inode.InodeType, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.GetType", tracing.SpanKindInternal)
 	defer func() {
-		globals.GetTypeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.GetTypeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.GetTypeErrors.Add(1)
+			vS.stats.GetTypeErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -1009,11 +1142,13 @@ func (vS *volumeStruct) GetType(userID inode.InodeUserID, groupID inode.InodeGro
 
 func (vS *volumeStruct) GetXAttr(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, streamName string) (value []byte, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.GetXAttr", tracing.SpanKindInternal)
 	defer func() {
-		globals.GetXAttrUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.GetXAttrUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.GetXAttrErrors.Add(1)
+			vS.stats.GetXAttrErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -1052,11 +1187,13 @@ func (vS *volumeStruct) GetXAttr(userID inode.InodeUserID, groupID inode.InodeGr
 
 func (vS *volumeStruct) IsDir(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (inodeIsDir bool, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.IsDir", tracing.SpanKindInternal)
 	defer func() {
-		globals.IsDirUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.IsDirUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.IsDirErrors.Add(1)
+			vS.stats.IsDirErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -1088,13 +1225,23 @@ func (vS *volumeStruct) IsDir(userID inode.InodeUserID, groupID inode.InodeGroup
 	return inodeType == inode.DirType, nil
 }
 
+func (vS *volumeStruct) IsEncrypted() (encrypted bool) {
+	startTime := time.Now()
+
+	encrypted = vS.inodeVolumeHandle.IsEncrypted()
+	vS.stats.IsEncryptedUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+	return
+}
+
 func (vS *volumeStruct) IsFile(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (inodeIsFile bool, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.IsFile", tracing.SpanKindInternal)
 	defer func() {
-		globals.IsFileUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.IsFileUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.IsFileErrors.Add(1)
+			vS.stats.IsFileErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -1120,11 +1267,13 @@ func (vS *volumeStruct) IsFile(userID inode.InodeUserID, groupID inode.InodeGrou
 
 func (vS *volumeStruct) IsSymlink(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (inodeIsSymlink bool, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.IsSymlink", tracing.SpanKindInternal)
 	defer func() {
-		globals.IsSymlinkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.IsSymlinkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.IsSymlinkErrors.Add(1)
+			vS.stats.IsSymlinkErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -1150,11 +1299,13 @@ func (vS *volumeStruct) IsSymlink(userID inode.InodeUserID, groupID inode.InodeG
 
 func (vS *volumeStruct) Link(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, dirInodeNumber inode.InodeNumber, basename string, targetInodeNumber inode.InodeNumber) (err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Link", tracing.SpanKindInternal)
 	defer func() {
-		globals.LinkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.LinkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.LinkErrors.Add(1)
+			vS.stats.LinkErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -1236,6 +1387,15 @@ func (vS *volumeStruct) Link(userID inode.InodeUserID, groupID inode.InodeGroupI
 		return
 	}
 
+	dirProjectID, targetProjectID, _, err := vS.fetchProjectIDs(dirInodeNumber, targetInodeNumber)
+	if err != nil {
+		return
+	}
+	if dirProjectID != targetProjectID {
+		err = blunder.NewError(blunder.CrossDeviceError, "EXDEV")
+		return
+	}
+
 	err = vS.inodeVolumeHandle.Link(dirInodeNumber, basename, targetInodeNumber, false)
 
 	// if the link was successful and this is a regular file then any
@@ -1249,11 +1409,13 @@ func (vS *volumeStruct) Link(userID inode.InodeUserID, groupID inode.InodeGroupI
 
 func (vS *volumeStruct) ListXAttr(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (streamNames []string, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.ListXAttr", tracing.SpanKindInternal)
 	defer func() {
-		globals.ListXAttrUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.ListXAttrUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.ListXAttrErrors.Add(1)
+			vS.stats.ListXAttrErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -1295,11 +1457,13 @@ func (vS *volumeStruct) ListXAttr(userID inode.InodeUserID, groupID inode.InodeG
 
 func (vS *volumeStruct) Lookup(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, dirInodeNumber inode.InodeNumber, basename string) (inodeNumber inode.InodeNumber, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Lookup", tracing.SpanKindInternal)
 	defer func() {
-		globals.LookupUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.LookupUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.LookupErrors.Add(1)
+			vS.stats.LookupErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -1329,11 +1493,13 @@ func (vS *volumeStruct) Lookup(userID inode.InodeUserID, groupID inode.InodeGrou
 
 func (vS *volumeStruct) LookupPath(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, fullpath string) (inodeNumber inode.InodeNumber, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.LookupPath", tracing.SpanKindInternal)
 	defer func() {
-		globals.LookupPathUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.LookupPathUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.LookupPathErrors.Add(1)
+			vS.stats.LookupPathErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -1380,6 +1546,66 @@ func (vS *volumeStruct) LookupPath(userID inode.InodeUserID, groupID inode.Inode
 	return cursorInodeNumber, nil
 }
 
+func (vS *volumeStruct) Lseek(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, offset uint64, whence int) (newOffset uint64, err error) {
+	var (
+		inodeLock *dlm.RWLockStruct
+		inodeType inode.InodeType
+	)
+
+	startTime := time.Now()
+	span := tracing.StartSpan("fs.Lseek", tracing.SpanKindInternal)
+	defer func() {
+		vS.stats.LseekUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			vS.stats.LseekErrors.Add(1)
+		}
+		span.EndWithError(err)
+	}()
+
+	vS.jobRWMutex.RLock()
+	defer vS.jobRWMutex.RUnlock()
+
+	inodeLock, err = vS.inodeVolumeHandle.InitInodeLock(inodeNumber, nil)
+	if nil != err {
+		return
+	}
+	err = inodeLock.ReadLock()
+	if nil != err {
+		return
+	}
+	defer inodeLock.Unlock()
+
+	if !vS.inodeVolumeHandle.Access(inodeNumber, userID, groupID, otherGroupIDs, inode.F_OK,
+		inode.NoOverride) {
+		err = blunder.NewError(blunder.NotFoundError, "ENOENT")
+		return
+	}
+
+	inodeType, err = vS.inodeVolumeHandle.GetType(inodeNumber)
+	if nil != err {
+		logger.ErrorfWithError(err, "couldn't get type for inode %v", inodeNumber)
+		return
+	}
+	// Make sure the inode number is for a file inode
+	if inodeType != inode.FileType {
+		err = fmt.Errorf("%s: expected inode %v to be a file inode, got %v", utils.GetFnName(), inodeNumber, inodeType)
+		logger.ErrorWithError(err)
+		err = blunder.AddError(err, blunder.NotFileError)
+		return
+	}
+
+	switch whence {
+	case unix.SEEK_DATA:
+		newOffset, err = vS.inodeVolumeHandle.SeekData(inodeNumber, offset)
+	case unix.SEEK_HOLE:
+		newOffset, err = vS.inodeVolumeHandle.SeekHole(inodeNumber, offset)
+	default:
+		err = blunder.NewError(blunder.InvalidArgError, "Lseek() whence (%d) not supported", whence)
+	}
+
+	return
+}
+
 func (vS *volumeStruct) MiddlewareCoalesce(destPath string, metaData []byte, elementPaths []string) (
 	ino uint64, numWrites uint64, attrChangeTime uint64, modificationTime uint64, err error) {
 
@@ -1401,12 +1627,14 @@ func (vS *volumeStruct) MiddlewareCoalesce(destPath string, metaData []byte, ele
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.MiddlewareCoalesce", tracing.SpanKindInternal)
 	defer func() {
-		globals.MiddlewareCoalesceUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.MiddlewareCoalesceBytes.Add(coalesceSize)
+		vS.stats.MiddlewareCoalesceUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.MiddlewareCoalesceBytes.Add(coalesceSize)
 		if err != nil {
-			globals.MiddlewareCoalesceErrors.Add(1)
+			vS.stats.MiddlewareCoalesceErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -1554,11 +1782,13 @@ func (vS *volumeStruct) MiddlewareDelete(parentDir string, basename string) (err
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.MiddlewareDelete", tracing.SpanKindInternal)
 	defer func() {
-		globals.MiddlewareDeleteUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.MiddlewareDeleteUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.MiddlewareDeleteErrors.Add(1)
+			vS.stats.MiddlewareDeleteErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	// Retry until done or failure (starting with ZERO backoff)
@@ -2420,17 +2650,19 @@ func (vS *volumeStruct) MiddlewareGetObject(containerObjectPath string,
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.MiddlewareGetObject", tracing.SpanKindInternal)
 	defer func() {
 		var totalReadBytes uint64
 		for _, step := range *readRangeOut {
 			totalReadBytes += step.Length
 		}
 
-		globals.MiddlewareGetObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.MiddlewareGetObjectBytes.Add(totalReadBytes)
+		vS.stats.MiddlewareGetObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.MiddlewareGetObjectBytes.Add(totalReadBytes)
 		if err != nil {
-			globals.MiddlewareGetObjectErrors.Add(1)
+			vS.stats.MiddlewareGetObjectErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	// Retry until done or failure (starting with ZERO backoff)
@@ -2547,11 +2779,13 @@ func (vS *volumeStruct) MiddlewareHeadResponse(entityPath string) (response Head
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.MiddlewareHeadResponse", tracing.SpanKindInternal)
 	defer func() {
-		globals.MiddlewareHeadResponseUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.MiddlewareHeadResponseUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.MiddlewareHeadResponseErrors.Add(1)
+			vS.stats.MiddlewareHeadResponseErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	// Retry until done or failure (starting with ZERO backoff)
@@ -2636,12 +2870,14 @@ func (vS *volumeStruct) MiddlewarePost(parentDir string, baseName string, newMet
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.MiddlewarePost", tracing.SpanKindInternal)
 	defer func() {
-		globals.MiddlewarePostUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.MiddlewarePostBytes.Add(uint64(len(newMetaData)))
+		vS.stats.MiddlewarePostUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.MiddlewarePostBytes.Add(uint64(len(newMetaData)))
 		if err != nil {
-			globals.MiddlewarePostErrors.Add(1)
+			vS.stats.MiddlewarePostErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	// Retry until done or failure (starting with ZERO backoff)
@@ -2738,11 +2974,13 @@ func (vS *volumeStruct) MiddlewarePutComplete(vContainerName string, vObjectPath
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.MiddlewarePutComplete", tracing.SpanKindInternal)
 	defer func() {
-		globals.MiddlewarePutCompleteUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.MiddlewarePutCompleteUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.MiddlewarePutCompleteErrors.Add(1)
+			vS.stats.MiddlewarePutCompleteErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	// Validate (pObjectPaths,pObjectLengths) args
@@ -2859,6 +3097,8 @@ Restart:
 			[]uint64{fileOffset},
 			[]uint64{0},
 			[]uint64{pObjectLengths[pObjectIndex]},
+			nil, // The middleware does not (yet) supply LogSegment checksums
+			nil, // ...nor does it seal LogSegments (so RpcPutLocation() refuses encrypted volumes)
 			inodeWroteTime,
 			pObjectIndex > 0) // Initial pObjectIndex == 0 case will implicitly SetSize(,0)
 		if nil != err {
@@ -2907,11 +3147,13 @@ func (vS *volumeStruct) MiddlewareMkdir(vContainerName string, vObjectPath strin
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.MiddlewareMkdir", tracing.SpanKindInternal)
 	defer func() {
-		globals.MiddlewareMkdirUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.MiddlewareMkdirUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.MiddlewareMkdirErrors.Add(1)
+			vS.stats.MiddlewareMkdirErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	// Retry until done or failure (starting with ZERO backoff)
@@ -3017,12 +3259,14 @@ func (vS *volumeStruct) MiddlewarePutContainer(containerName string, oldMetadata
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.MiddlewarePutContainer", tracing.SpanKindInternal)
 	defer func() {
-		globals.MiddlewarePutContainerUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.MiddlewarePutContainerBytes.Add(uint64(len(newMetadata)))
+		vS.stats.MiddlewarePutContainerUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.MiddlewarePutContainerBytes.Add(uint64(len(newMetadata)))
 		if err != nil {
-			globals.MiddlewarePutContainerErrors.Add(1)
+			vS.stats.MiddlewarePutContainerErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -3095,11 +3339,13 @@ func (vS *volumeStruct) MiddlewarePutContainer(containerName string, oldMetadata
 
 func (vS *volumeStruct) Mkdir(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, basename string, filePerm inode.InodeMode) (newDirInodeNumber inode.InodeNumber, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Mkdir", tracing.SpanKindInternal)
 	defer func() {
-		globals.MkdirUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.MkdirUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.MkdirErrors.Add(1)
+			vS.stats.MkdirErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -3148,6 +3394,24 @@ func (vS *volumeStruct) Mkdir(userID inode.InodeUserID, groupID inode.InodeGroup
 		return 0, err
 	}
 
+	err = vS.inheritProjectID(inodeNumber, newDirInodeNumber)
+	if err != nil {
+		destroyErr := vS.inodeVolumeHandle.Destroy(newDirInodeNumber)
+		if destroyErr != nil {
+			logger.WarnfWithError(destroyErr, "couldn't destroy inode %v after failed inheritProjectID() in fs.Mkdir", newDirInodeNumber)
+		}
+		return 0, err
+	}
+
+	err = vS.inodeVolumeHandle.InheritPosixACL(inodeNumber, newDirInodeNumber)
+	if err != nil {
+		destroyErr := vS.inodeVolumeHandle.Destroy(newDirInodeNumber)
+		if destroyErr != nil {
+			logger.WarnfWithError(destroyErr, "couldn't destroy inode %v after failed InheritPosixACL() in fs.Mkdir", newDirInodeNumber)
+		}
+		return 0, err
+	}
+
 	err = vS.inodeVolumeHandle.Link(inodeNumber, basename, newDirInodeNumber, false)
 	if err != nil {
 		destroyErr := vS.inodeVolumeHandle.Destroy(newDirInodeNumber)
@@ -3162,11 +3426,13 @@ func (vS *volumeStruct) Mkdir(userID inode.InodeUserID, groupID inode.InodeGroup
 
 func (vS *volumeStruct) RemoveXAttr(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, streamName string) (err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.RemoveXAttr", tracing.SpanKindInternal)
 	defer func() {
-		globals.RemoveXAttrUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.RemoveXAttrUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.RemoveXAttrErrors.Add(1)
+			vS.stats.RemoveXAttrErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -3187,7 +3453,14 @@ func (vS *volumeStruct) RemoveXAttr(userID inode.InodeUserID, groupID inode.Inod
 		err = blunder.NewError(blunder.NotFoundError, "ENOENT")
 		return
 	}
-	if !vS.inodeVolumeHandle.Access(inodeNumber, userID, groupID, otherGroupIDs, inode.W_OK,
+	if inode.IsPosixACLStreamName(streamName) {
+		// Only the owner (or root) may change the POSIX ACLs of an inode
+		if !vS.inodeVolumeHandle.Access(inodeNumber, userID, groupID, otherGroupIDs, inode.P_OK,
+			inode.NoOverride) {
+			err = blunder.NewError(blunder.NotPermError, "EPERM")
+			return
+		}
+	} else if !vS.inodeVolumeHandle.Access(inodeNumber, userID, groupID, otherGroupIDs, inode.W_OK,
 		inode.OwnerOverride) {
 		err = blunder.NewError(blunder.PermDeniedError, "EACCES")
 		return
@@ -3208,8 +3481,12 @@ func (vS *volumeStruct) workerForMoveAndRename(userID inode.InodeUserID, groupID
 		dirEntryBasename      string
 		dirEntryInodeNumber   inode.InodeNumber
 		dirInodeNumber        inode.InodeNumber
+		priorProjectID        uint64
+		projectIDAdopted      bool
 		retryRequired         bool
+		srcInodeNumber        inode.InodeNumber
 		tryLockBackoffContext *tryLockBackoffContextStruct
+		undoErr               error
 	)
 
 	err = validateBaseName(srcBasename)
@@ -3240,7 +3517,7 @@ Restart:
 
 	// Acquire WriteLock on {srcDirInodeNumber,srcBasename} & perform Access Check
 
-	dirInodeNumber, _, dirEntryBasename, _, retryRequired, err =
+	dirInodeNumber, srcInodeNumber, dirEntryBasename, _, retryRequired, err =
 		vS.resolvePath(
 			srcDirInodeNumber,
 			srcBasename,
@@ -3335,10 +3612,30 @@ Restart:
 		// This is actually OK... it means the target path of the Rename() isn't being potentially replaced
 	}
 
+	// Moving between directories may also move srcInodeNumber between projects
+
+	if srcDirInodeNumber != dstDirInodeNumber {
+		projectIDAdopted, priorProjectID, err = vS.adoptProjectID(dstDirInodeNumber, srcInodeNumber)
+		if nil != err {
+			heldLocks.free()
+			heldLocks = nil
+			return
+		}
+	}
+
 	// Locks held & Access Checks succeeded... time to do the Move
 
 	toDestroyInodeNumber, err = vS.inodeVolumeHandle.Move(srcDirInodeNumber, srcBasename, dstDirInodeNumber, dstBasename)
 
+	if (nil != err) && projectIDAdopted {
+		// srcInodeNumber stays put... so must its ProjectID
+
+		undoErr = vS.inodeVolumeHandle.SetProjectID(srcInodeNumber, priorProjectID)
+		if nil != undoErr {
+			logger.ErrorfWithError(undoErr, "failed to restore ProjectID %v of inode 0x%016X following failed Move", priorProjectID, srcInodeNumber)
+		}
+	}
+
 	return // err returned from inode.Move() suffices here
 }
 
@@ -3350,11 +3647,13 @@ func (vS *volumeStruct) Rename(userID inode.InodeUserID, groupID inode.InodeGrou
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Rename", tracing.SpanKindInternal)
 	defer func() {
-		globals.RenameUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.RenameUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.RenameErrors.Add(1)
+			vS.stats.RenameErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -3382,11 +3681,13 @@ func (vS *volumeStruct) Move(userID inode.InodeUserID, groupID inode.InodeGroupI
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Move", tracing.SpanKindInternal)
 	defer func() {
-		globals.MoveUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.MoveUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.MoveErrors.Add(1)
+			vS.stats.MoveErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -3407,11 +3708,13 @@ func (vS *volumeStruct) Destroy(userID inode.InodeUserID, groupID inode.InodeGro
 	)
 
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Destroy", tracing.SpanKindInternal)
 	defer func() {
-		globals.DestroyUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.DestroyUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.DestroyErrors.Add(1)
+			vS.stats.DestroyErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -3450,14 +3753,24 @@ func (vS *volumeStruct) Destroy(userID inode.InodeUserID, groupID inode.InodeGro
 	return
 }
 
+func (vS *volumeStruct) ObjectStoreBackend() (backendName string) {
+	startTime := time.Now()
+
+	backendName = vS.inodeVolumeHandle.ObjectStoreBackend()
+	vS.stats.ObjectStoreBackendUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+	return
+}
+
 func (vS *volumeStruct) Read(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, offset uint64, length uint64, profiler *utils.Profiler) (buf []byte, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Read", tracing.SpanKindInternal)
 	defer func() {
-		globals.ReadUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.ReadBytes.Add(uint64(len(buf)))
+		vS.stats.ReadUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.ReadBytes.Add(uint64(len(buf)))
 		if err != nil {
-			globals.ReadErrors.Add(1)
+			vS.stats.ReadErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -3596,12 +3909,14 @@ Restart:
 
 func (vS *volumeStruct) Readdir(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, maxEntries uint64, prevReturned ...interface{}) (entries []inode.DirEntry, numEntries uint64, areMoreEntries bool, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Readdir", tracing.SpanKindInternal)
 	defer func() {
-		globals.ReaddirUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.ReaddirEntries.Add(uint64(len(entries)))
+		vS.stats.ReaddirUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.ReaddirEntries.Add(uint64(len(entries)))
 		if err != nil {
-			globals.ReaddirErrors.Add(1)
+			vS.stats.ReaddirErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	entries, _, numEntries, areMoreEntries, err = vS.readdirHelper(userID, groupID, otherGroupIDs, inodeNumber, maxEntries, prevReturned...)
@@ -3611,12 +3926,14 @@ func (vS *volumeStruct) Readdir(userID inode.InodeUserID, groupID inode.InodeGro
 
 func (vS *volumeStruct) ReaddirPlus(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, maxEntries uint64, prevReturned ...interface{}) (dirEntries []inode.DirEntry, statEntries []Stat, numEntries uint64, areMoreEntries bool, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.ReaddirPlus", tracing.SpanKindInternal)
 	defer func() {
-		globals.ReaddirPlusUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.ReaddirPlusBytes.Add(uint64(len(dirEntries)))
+		vS.stats.ReaddirPlusUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.ReaddirPlusBytes.Add(uint64(len(dirEntries)))
 		if err != nil {
-			globals.ReaddirPlusErrors.Add(1)
+			vS.stats.ReaddirPlusErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	dirEntries, statEntries, numEntries, areMoreEntries, err = vS.readdirHelper(userID, groupID, otherGroupIDs, inodeNumber, maxEntries, prevReturned...)
@@ -3626,11 +3943,13 @@ func (vS *volumeStruct) ReaddirPlus(userID inode.InodeUserID, groupID inode.Inod
 
 func (vS *volumeStruct) Readsymlink(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (target string, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Readsymlink", tracing.SpanKindInternal)
 	defer func() {
-		globals.ReadsymlinkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.ReadsymlinkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.ReadsymlinkErrors.Add(1)
+			vS.stats.ReadsymlinkErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -3666,11 +3985,13 @@ func (vS *volumeStruct) Readsymlink(userID inode.InodeUserID, groupID inode.Inod
 
 func (vS *volumeStruct) Resize(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, newSize uint64) (err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Resize", tracing.SpanKindInternal)
 	defer func() {
-		globals.ResizeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.ResizeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.ResizeErrors.Add(1)
+			vS.stats.ResizeErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -3707,11 +4028,13 @@ func (vS *volumeStruct) Resize(userID inode.InodeUserID, groupID inode.InodeGrou
 
 func (vS *volumeStruct) Rmdir(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, basename string) (err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Rmdir", tracing.SpanKindInternal)
 	defer func() {
-		globals.RmdirUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.RmdirUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.RmdirErrors.Add(1)
+			vS.stats.RmdirErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -3806,11 +4129,13 @@ func (vS *volumeStruct) rmdirActual(inodeNumber inode.InodeNumber, basename stri
 
 func (vS *volumeStruct) Setstat(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, stat Stat) (err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Setstat", tracing.SpanKindInternal)
 	defer func() {
-		globals.SetstatUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.SetstatUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.SetstatErrors.Add(1)
+			vS.stats.SetstatErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -4000,11 +4325,13 @@ func (vS *volumeStruct) Setstat(userID inode.InodeUserID, groupID inode.InodeGro
 
 func (vS *volumeStruct) SetXAttr(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, streamName string, value []byte, flags int) (err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.SetXAttr", tracing.SpanKindInternal)
 	defer func() {
-		globals.SetXAttrUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.SetXAttrUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.SetXAttrErrors.Add(1)
+			vS.stats.SetXAttrErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -4025,7 +4352,14 @@ func (vS *volumeStruct) SetXAttr(userID inode.InodeUserID, groupID inode.InodeGr
 		err = blunder.NewError(blunder.NotFoundError, "ENOENT")
 		return
 	}
-	if !vS.inodeVolumeHandle.Access(inodeNumber, userID, groupID, otherGroupIDs, inode.W_OK,
+	if inode.IsPosixACLStreamName(streamName) {
+		// Only the owner (or root) may change the POSIX ACLs of an inode
+		if !vS.inodeVolumeHandle.Access(inodeNumber, userID, groupID, otherGroupIDs, inode.P_OK,
+			inode.NoOverride) {
+			err = blunder.NewError(blunder.NotPermError, "EPERM")
+			return
+		}
+	} else if !vS.inodeVolumeHandle.Access(inodeNumber, userID, groupID, otherGroupIDs, inode.W_OK,
 		inode.OwnerOverride) {
 		err = blunder.NewError(blunder.PermDeniedError, "EACCES")
 		return
@@ -4058,18 +4392,41 @@ func (vS *volumeStruct) SetXAttr(userID inode.InodeUserID, groupID inode.InodeGr
 	return
 }
 
-func (vS *volumeStruct) StatVfs() (statVFS StatVFS, err error) {
+func (vS *volumeStruct) StatVfs(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber) (statVFS StatVFS, err error) {
+	var (
+		compressionStats inode.CompressionStatsStruct
+		inodeLock        *dlm.RWLockStruct
+		metadata         *inode.MetadataStruct
+		quota            inode.QuotaStruct
+	)
+
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.StatVfs", tracing.SpanKindInternal)
 	defer func() {
-		globals.StatVfsUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.StatVfsUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.StatVfsErrors.Add(1)
+			vS.stats.StatVfsErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
 	defer vS.jobRWMutex.RUnlock()
 
+	inodeLock, err = vS.inodeVolumeHandle.InitInodeLock(inodeNumber, nil)
+	if nil != err {
+		return
+	}
+	err = inodeLock.ReadLock()
+	if nil != err {
+		return
+	}
+	metadata, err = vS.inodeVolumeHandle.GetMetadata(inodeNumber)
+	_ = inodeLock.Unlock()
+	if nil != err {
+		return
+	}
+
 	statVFS = make(map[StatVFSKey]uint64)
 
 	statVFS[StatVFSFilesystemID] = vS.inodeVolumeHandle.GetFSID()
@@ -4084,16 +4441,47 @@ func (vS *volumeStruct) StatVfs() (statVFS StatVFS, err error) {
 	statVFS[StatVFSMountFlags] = 0
 	statVFS[StatVFSMaxFilenameLen] = FileNameMax
 
+	// Report the compression ratio achieved (if any) as StatVFSLogicalBytes / StatVFSPhysicalBytes
+
+	compressionStats = vS.inodeVolumeHandle.FetchCompressionStats()
+
+	statVFS[StatVFSLogicalBytes] = compressionStats.LogicalBytes
+	statVFS[StatVFSPhysicalBytes] = compressionStats.PhysicalBytes
+
+	// Report the tightest of any applicable quotas as the capacity of the volume
+
+	quota, err = vS.inodeVolumeHandle.FetchQuota(inode.QuotaTypeUser, uint64(userID))
+	if nil != err {
+		return
+	}
+	vS.applyQuotaToStatVFS(statVFS, &quota)
+
+	quota, err = vS.inodeVolumeHandle.FetchQuota(inode.QuotaTypeGroup, uint64(groupID))
+	if nil != err {
+		return
+	}
+	vS.applyQuotaToStatVFS(statVFS, &quota)
+
+	if 0 != metadata.ProjectID {
+		quota, err = vS.inodeVolumeHandle.FetchQuota(inode.QuotaTypeProject, metadata.ProjectID)
+		if nil != err {
+			return
+		}
+		vS.applyQuotaToStatVFS(statVFS, &quota)
+	}
+
 	return statVFS, nil
 }
 
 func (vS *volumeStruct) Symlink(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, basename string, target string) (symlinkInodeNumber inode.InodeNumber, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Symlink", tracing.SpanKindInternal)
 	defer func() {
-		globals.SymlinkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.SymlinkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.SymlinkErrors.Add(1)
+			vS.stats.SymlinkErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -4146,6 +4534,15 @@ func (vS *volumeStruct) Symlink(userID inode.InodeUserID, groupID inode.InodeGro
 		return
 	}
 
+	err = vS.inheritProjectID(inodeNumber, symlinkInodeNumber)
+	if err != nil {
+		destroyErr := vS.inodeVolumeHandle.Destroy(symlinkInodeNumber)
+		if destroyErr != nil {
+			logger.WarnfWithError(destroyErr, "couldn't destroy inode %v after failed inheritProjectID() in fs.Symlink", symlinkInodeNumber)
+		}
+		return
+	}
+
 	err = vS.inodeVolumeHandle.Link(inodeNumber, basename, symlinkInodeNumber, false)
 	if err != nil {
 		destroyErr := vS.inodeVolumeHandle.Destroy(symlinkInodeNumber)
@@ -4160,11 +4557,13 @@ func (vS *volumeStruct) Symlink(userID inode.InodeUserID, groupID inode.InodeGro
 
 func (vS *volumeStruct) Unlink(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, basename string) (err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Unlink", tracing.SpanKindInternal)
 	defer func() {
-		globals.UnlinkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.UnlinkUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.UnlinkErrors.Add(1)
+			vS.stats.UnlinkErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -4245,18 +4644,20 @@ func (vS *volumeStruct) VolumeName() (volumeName string) {
 	startTime := time.Now()
 
 	volumeName = vS.volumeName
-	globals.VolumeNameUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+	vS.stats.VolumeNameUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 	return
 }
 
 func (vS *volumeStruct) Write(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, offset uint64, buf []byte, profiler *utils.Profiler) (size uint64, err error) {
 	startTime := time.Now()
+	span := tracing.StartSpan("fs.Write", tracing.SpanKindInternal)
 	defer func() {
-		globals.WriteUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.WriteBytes.Add(size)
+		vS.stats.WriteUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		vS.stats.WriteBytes.Add(size)
 		if err != nil {
-			globals.WriteErrors.Add(1)
+			vS.stats.WriteErrors.Add(1)
 		}
+		span.EndWithError(err)
 	}()
 
 	vS.jobRWMutex.RLock()
@@ -4301,7 +4702,7 @@ func (vS *volumeStruct) Write(userID inode.InodeUserID, groupID inode.InodeGroup
 	return
 }
 
-func (vS *volumeStruct) Wrote(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, containerName string, objectName string, fileOffset []uint64, objectOffset []uint64, length []uint64, wroteTime uint64) (err error) {
+func (vS *volumeStruct) Wrote(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, containerName string, objectName string, fileOffset []uint64, objectOffset []uint64, length []uint64, checksums *inode.LogSegmentChecksumsStruct, compression *inode.LogSegmentCompressionStruct, wroteTime uint64) (err error) {
 	vS.jobRWMutex.RLock()
 	defer vS.jobRWMutex.RUnlock()
 
@@ -4331,7 +4732,7 @@ func (vS *volumeStruct) Wrote(userID inode.InodeUserID, groupID inode.InodeGroup
 
 	inodeWroteTime := time.Unix(0, int64(wroteTime))
 
-	err = vS.inodeVolumeHandle.Wrote(inodeNumber, containerName, objectName, fileOffset, objectOffset, length, inodeWroteTime, true)
+	err = vS.inodeVolumeHandle.Wrote(inodeNumber, containerName, objectName, fileOffset, objectOffset, length, checksums, compression, inodeWroteTime, true)
 
 	return // err, as set by inode.Wrote(), is sufficient
 }
@@ -4441,7 +4842,7 @@ func (vS *volumeStruct) removeObstacleToObjectPut(callerID dlm.CallerID, dirInod
 // Utility function to append entries to reply
 func appendReadPlanEntries(readPlan []inode.ReadPlanStep, readRangeOut *[]inode.ReadPlanStep) (numEntries uint64) {
 	for i := range readPlan {
-		entry := inode.ReadPlanStep{ObjectPath: readPlan[i].ObjectPath, Offset: readPlan[i].Offset, Length: readPlan[i].Length}
+		entry := inode.ReadPlanStep{ObjectPath: readPlan[i].ObjectPath, Offset: readPlan[i].Offset, Length: readPlan[i].Length, Checksums: readPlan[i].Checksums}
 		*readRangeOut = append(*readRangeOut, entry)
 		numEntries++
 	}
@@ -5,7 +5,10 @@ package fs
 
 import (
 	"bytes"
+	"encoding/binary"
+	"io/ioutil"
 	"math"
+	"strconv"
 	"strings"
 	"syscall"
 	"testing"
@@ -15,6 +18,10 @@ import (
 
 	"github.com/NVIDIA/proxyfs/blunder"
 	"github.com/NVIDIA/proxyfs/inode"
+	"github.com/NVIDIA/proxyfs/keyring"
+	"github.com/NVIDIA/proxyfs/swiftclient"
+	"github.com/NVIDIA/proxyfs/transitions"
+	"github.com/NVIDIA/proxyfs/utils"
 )
 
 // TODO: Enhance this to do a stat() as well and check number of files
@@ -847,6 +854,1702 @@ func TestFlock(t *testing.T) {
 	testTeardown(t)
 }
 
+func TestFlockMountOwners(t *testing.T) {
+	var (
+		err error
+	)
+
+	testSetup(t, false)
+
+	rootDirInodeNumber := inode.RootDirInodeNumber
+
+	basename := "TestMountLockFile"
+	lockFileInodeNumber, err := testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, rootDirInodeNumber, basename, inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Create() %v returned error: %v", basename, err)
+	}
+
+	// Write lock range 0 - 100 from MountA's owner 1 (with a Pid matching that used by MountB below):
+	lockA1 := FlockStruct{Type: syscall.F_WRLCK, Start: 0, Len: 100, Pid: 7, MountID: "MountA", Owner: 1}
+	_, err = testVolumeStruct.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lockFileInodeNumber, syscall.F_SETLK, &lockA1)
+	if err != nil {
+		t.Fatalf("Write lock of range 0 - 100 by MountA owner 1 failed: %v", err)
+	}
+
+	// Same Owner (and Pid) but different MountID must conflict:
+	lockB1 := FlockStruct{Type: syscall.F_WRLCK, Start: 50, Len: 10, Pid: 7, MountID: "MountB", Owner: 1}
+	_, err = testVolumeStruct.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lockFileInodeNumber, syscall.F_SETLK, &lockB1)
+	if blunder.Errno(err) != int(blunder.TryAgainError) {
+		t.Fatalf("Write lock by MountB owner 1 should fail with EAGAIN instead got: %v", err)
+	}
+
+	// Same MountID but different Owner must conflict:
+	lockA2 := FlockStruct{Type: syscall.F_RDLCK, Start: 50, Len: 10, Pid: 8, MountID: "MountA", Owner: 2}
+	_, err = testVolumeStruct.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lockFileInodeNumber, syscall.F_SETLK, &lockA2)
+	if blunder.Errno(err) != int(blunder.TryAgainError) {
+		t.Fatalf("Read lock by MountA owner 2 should fail with EAGAIN instead got: %v", err)
+	}
+
+	// GETLK should report the conflicting lock:
+	lockB1Get := lockB1
+	lockHeld, err := testVolumeStruct.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lockFileInodeNumber, syscall.F_GETLK, &lockB1Get)
+	if blunder.Errno(err) != int(blunder.TryAgainError) {
+		t.Fatalf("GETLK by MountB owner 1 should fail with EAGAIN instead got: %v", err)
+	}
+	if lockHeld.MountID != "MountA" || lockHeld.Owner != 1 || lockHeld.Start != 0 || lockHeld.Len != 100 || lockHeld.Type != syscall.F_WRLCK {
+		t.Fatalf("GETLK by MountB owner 1 returned unexpected lockHeld: %+v", lockHeld)
+	}
+
+	// Unlocking range 40 - 60 by MountA owner 1 (using a Pid of 0 as FUSE does) should split the lock in two:
+	unlockA1 := FlockStruct{Type: syscall.F_UNLCK, Start: 40, Len: 20, Pid: 0, MountID: "MountA", Owner: 1}
+	_, err = testVolumeStruct.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lockFileInodeNumber, syscall.F_SETLK, &unlockA1)
+	if err != nil {
+		t.Fatalf("Unlock of range 40 - 60 by MountA owner 1 failed: %v", err)
+	}
+
+	_, err = testVolumeStruct.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lockFileInodeNumber, syscall.F_SETLK, &lockB1)
+	if err != nil {
+		t.Fatalf("Write lock of range 50 - 60 by MountB owner 1 should have succeeded, err: %v", err)
+	}
+
+	lockB2 := FlockStruct{Type: syscall.F_RDLCK, Start: 30, Len: 20, Pid: 9, MountID: "MountB", Owner: 2}
+	_, err = testVolumeStruct.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lockFileInodeNumber, syscall.F_SETLK, &lockB2)
+	if blunder.Errno(err) != int(blunder.TryAgainError) {
+		t.Fatalf("Read lock of range 30 - 50 by MountB owner 2 should fail with EAGAIN instead got: %v", err)
+	}
+
+	lockB3 := FlockStruct{Type: syscall.F_RDLCK, Start: 60, Len: 40, Pid: 9, MountID: "MountB", Owner: 2}
+	_, err = testVolumeStruct.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lockFileInodeNumber, syscall.F_SETLK, &lockB3)
+	if blunder.Errno(err) != int(blunder.TryAgainError) {
+		t.Fatalf("Read lock of range 60 - 100 by MountB owner 2 should fail with EAGAIN instead got: %v", err)
+	}
+
+	// Once MountA goes away, all of its locks should be released:
+	testVolumeStruct.FlockReleaseAll("MountA")
+
+	_, err = testVolumeStruct.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lockFileInodeNumber, syscall.F_SETLK, &lockB2)
+	if err != nil {
+		t.Fatalf("Read lock of range 30 - 50 by MountB owner 2 should have succeeded, err: %v", err)
+	}
+
+	_, err = testVolumeStruct.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lockFileInodeNumber, syscall.F_SETLK, &lockB3)
+	if err != nil {
+		t.Fatalf("Read lock of range 60 - 100 by MountB owner 2 should have succeeded, err: %v", err)
+	}
+
+	testVolumeStruct.FlockReleaseAll("MountB")
+
+	lockA1.Len = 0
+	_, err = testVolumeStruct.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lockFileInodeNumber, syscall.F_SETLK, &lockA1)
+	if err != nil {
+		t.Fatalf("Write lock of whole file by MountA owner 1 should have succeeded, err: %v", err)
+	}
+
+	testVolumeStruct.FlockReleaseAll("MountA")
+
+	err = testVolumeStruct.Unlink(inode.InodeRootUserID, inode.InodeGroupID(0), nil, rootDirInodeNumber, basename)
+	if err != nil {
+		t.Fatalf("Unlink() %v returned error: %v", basename, err)
+	}
+
+	testTeardown(t)
+}
+
+func TestFallocateAndLseek(t *testing.T) {
+	var (
+		err error
+	)
+
+	testSetup(t, false)
+
+	rootDirInodeNumber := inode.RootDirInodeNumber
+
+	basename := "TestFallocateFile"
+	fileInodeNumber, err := testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, rootDirInodeNumber, basename, inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Create() %v returned error: %v", basename, err)
+	}
+
+	// Lay out data in [0:4096) & [16384:20480) leaving a hole in between
+
+	_, err = testVolumeStruct.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, bytes.Repeat([]byte{0x11}, 4096), nil)
+	if err != nil {
+		t.Fatalf("Write() of [0:4096) returned error: %v", err)
+	}
+	_, err = testVolumeStruct.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 16384, bytes.Repeat([]byte{0x22}, 4096), nil)
+	if err != nil {
+		t.Fatalf("Write() of [16384:20480) returned error: %v", err)
+	}
+
+	checkLseek := func(offset uint64, whence int, expectedOffset uint64) {
+		newOffset, lseekErr := testVolumeStruct.Lseek(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, offset, whence)
+		if lseekErr != nil {
+			t.Fatalf("Lseek(%v,%v) returned error: %v", offset, whence, lseekErr)
+		}
+		if newOffset != expectedOffset {
+			t.Fatalf("Lseek(%v,%v) returned %v... expected %v", offset, whence, newOffset, expectedOffset)
+		}
+	}
+	checkLseekENXIO := func(offset uint64, whence int) {
+		_, lseekErr := testVolumeStruct.Lseek(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, offset, whence)
+		if blunder.IsNot(lseekErr, blunder.NoDeviceOrAddrError) {
+			t.Fatalf("Lseek(%v,%v) should have failed with ENXIO, err: %v", offset, whence, lseekErr)
+		}
+	}
+	checkSize := func(expectedSize uint64) {
+		stat, getstatErr := testVolumeStruct.Getstat(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber)
+		if getstatErr != nil {
+			t.Fatalf("Getstat() returned error: %v", getstatErr)
+		}
+		if stat[StatSize] != expectedSize {
+			t.Fatalf("Getstat() returned size %v... expected %v", stat[StatSize], expectedSize)
+		}
+	}
+
+	checkSize(20480)
+	checkLseek(0, unix.SEEK_DATA, 0)
+	checkLseek(0, unix.SEEK_HOLE, 4096)
+	checkLseek(100, unix.SEEK_HOLE, 4096)
+	checkLseek(4096, unix.SEEK_DATA, 16384)
+	checkLseek(8192, unix.SEEK_HOLE, 8192)
+	checkLseek(16384, unix.SEEK_HOLE, 20480)
+	checkLseekENXIO(20480, unix.SEEK_DATA)
+	checkLseekENXIO(20480, unix.SEEK_HOLE)
+
+	// Preallocating beyond end-of-file extends it (with a hole) unless KEEP_SIZE is specified
+
+	err = testVolumeStruct.Fallocate(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, 20480, 4096)
+	if err != nil {
+		t.Fatalf("Fallocate(0,20480,4096) returned error: %v", err)
+	}
+	checkSize(24576)
+	checkLseek(20480, unix.SEEK_HOLE, 20480)
+	checkLseekENXIO(20480, unix.SEEK_DATA)
+
+	err = testVolumeStruct.Fallocate(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, unix.FALLOC_FL_KEEP_SIZE, 24576, 4096)
+	if err != nil {
+		t.Fatalf("Fallocate(KEEP_SIZE,24576,4096) returned error: %v", err)
+	}
+	checkSize(24576)
+
+	// Punching a hole must discard the data in the range without changing the size
+
+	err = testVolumeStruct.Fallocate(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, unix.FALLOC_FL_PUNCH_HOLE, 1024, 1024)
+	if blunder.IsNot(err, blunder.NotSupportedError) {
+		t.Fatalf("Fallocate(PUNCH_HOLE,1024,1024) without KEEP_SIZE should have failed with ENOTSUP, err: %v", err)
+	}
+
+	err = testVolumeStruct.Fallocate(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, 1024, 1024)
+	if err != nil {
+		t.Fatalf("Fallocate(PUNCH_HOLE|KEEP_SIZE,1024,1024) returned error: %v", err)
+	}
+	checkSize(24576)
+	checkLseek(0, unix.SEEK_HOLE, 1024)
+	checkLseek(1024, unix.SEEK_DATA, 2048)
+
+	readBuf, err := testVolumeStruct.Read(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, 4096, nil)
+	if err != nil {
+		t.Fatalf("Read() of [0:4096) returned error: %v", err)
+	}
+	expectedBuf := bytes.Repeat([]byte{0x11}, 4096)
+	copy(expectedBuf[1024:2048], make([]byte, 1024))
+	if !bytes.Equal(readBuf, expectedBuf) {
+		t.Fatalf("Read() of [0:4096) after PUNCH_HOLE returned unexpected data")
+	}
+
+	// Zeroing a range discards its data and, absent KEEP_SIZE, may extend the file
+
+	err = testVolumeStruct.Fallocate(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, unix.FALLOC_FL_ZERO_RANGE, 16384, 12288)
+	if err != nil {
+		t.Fatalf("Fallocate(ZERO_RANGE,16384,12288) returned error: %v", err)
+	}
+	checkSize(28672)
+	checkLseekENXIO(4096, unix.SEEK_DATA)
+	checkLseek(2048, unix.SEEK_HOLE, 4096)
+
+	err = testVolumeStruct.Fallocate(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, 0, 0)
+	if blunder.IsNot(err, blunder.InvalidArgError) {
+		t.Fatalf("Fallocate() with length == 0 should have failed with EINVAL, err: %v", err)
+	}
+
+	err = testVolumeStruct.Unlink(inode.InodeRootUserID, inode.InodeGroupID(0), nil, rootDirInodeNumber, basename)
+	if err != nil {
+		t.Fatalf("Unlink() %v returned error: %v", basename, err)
+	}
+
+	testTeardown(t)
+}
+
+func TestQuota(t *testing.T) {
+	var (
+		err error
+	)
+
+	testSetup(t, false)
+
+	rootDirInodeNumber := inode.RootDirInodeNumber
+
+	userID := inode.InodeUserID(1000)
+	groupID := inode.InodeGroupID(1000)
+
+	testDirInodeNumber, err := testVolumeStruct.Mkdir(inode.InodeRootUserID, inode.InodeGroupID(0), nil, rootDirInodeNumber, "TestQuotaDir", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Mkdir() returned error: %v", err)
+	}
+
+	checkUsage := func(quotaType inode.QuotaType, quotaID uint64, expectedBytes uint64, expectedInodes uint64) {
+		quota, fetchErr := testVolumeStruct.inodeVolumeHandle.FetchQuota(quotaType, quotaID)
+		if fetchErr != nil {
+			t.Fatalf("FetchQuota(%v,%v) returned error: %v", quotaType, quotaID, fetchErr)
+		}
+		if (quota.Bytes != expectedBytes) || (quota.Inodes != expectedInodes) {
+			t.Fatalf("FetchQuota(%v,%v) returned usage %+v... expected {Bytes:%v Inodes:%v}", quotaType, quotaID, quota.QuotaUsageStruct, expectedBytes, expectedInodes)
+		}
+	}
+
+	// Enforce a user quota of 8192 bytes & 2 inodes
+
+	err = testVolumeStruct.inodeVolumeHandle.SetQuotaLimits(inode.QuotaTypeUser, uint64(userID), inode.QuotaLimitsStruct{MaxBytes: 8192, MaxInodes: 2})
+	if err != nil {
+		t.Fatalf("SetQuotaLimits() returned error: %v", err)
+	}
+
+	fileInodeNumber, err := testVolumeStruct.Create(userID, groupID, nil, testDirInodeNumber, "file1", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Create() of file1 returned error: %v", err)
+	}
+	_, err = testVolumeStruct.Write(userID, groupID, nil, fileInodeNumber, 0, bytes.Repeat([]byte{0x11}, 8192), nil)
+	if err != nil {
+		t.Fatalf("Write() of [0:8192) returned error: %v", err)
+	}
+	checkUsage(inode.QuotaTypeUser, uint64(userID), 8192, 1)
+	checkUsage(inode.QuotaTypeGroup, uint64(groupID), 8192, 1)
+
+	_, err = testVolumeStruct.Write(userID, groupID, nil, fileInodeNumber, 8192, []byte{0x22}, nil)
+	if blunder.IsNot(err, blunder.QuotaExceededError) {
+		t.Fatalf("Write() beyond quota should have failed with EDQUOT, err: %v", err)
+	}
+
+	_, err = testVolumeStruct.Create(userID, groupID, nil, testDirInodeNumber, "file2", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Create() of file2 returned error: %v", err)
+	}
+	_, err = testVolumeStruct.Create(userID, groupID, nil, testDirInodeNumber, "file3", inode.PosixModePerm)
+	if blunder.IsNot(err, blunder.QuotaExceededError) {
+		t.Fatalf("Create() of file3 should have failed with EDQUOT, err: %v", err)
+	}
+
+	// StatVfs() should report capacity relative to the quota
+
+	statVFS, err := testVolumeStruct.StatVfs(userID, groupID, nil, testDirInodeNumber)
+	if err != nil {
+		t.Fatalf("StatVfs() returned error: %v", err)
+	}
+	if statVFS[StatVFSTotalBlocks] != (8192 / statVFS[StatVFSFragmentSize]) {
+		t.Fatalf("StatVfs() returned TotalBlocks %v... expected %v", statVFS[StatVFSTotalBlocks], 8192/statVFS[StatVFSFragmentSize])
+	}
+	if (statVFS[StatVFSFreeBlocks] != 0) || (statVFS[StatVFSAvailBlocks] != 0) {
+		t.Fatalf("StatVfs() returned FreeBlocks %v & AvailBlocks %v... expected 0", statVFS[StatVFSFreeBlocks], statVFS[StatVFSAvailBlocks])
+	}
+	if (statVFS[StatVFSTotalInodes] != 2) || (statVFS[StatVFSFreeInodes] != 0) {
+		t.Fatalf("StatVfs() returned TotalInodes %v & FreeInodes %v... expected 2 & 0", statVFS[StatVFSTotalInodes], statVFS[StatVFSFreeInodes])
+	}
+
+	statVFS, err = testVolumeStruct.StatVfs(inode.InodeRootUserID, inode.InodeGroupID(0), nil, testDirInodeNumber)
+	if err != nil {
+		t.Fatalf("StatVfs() returned error: %v", err)
+	}
+	if statVFS[StatVFSTotalInodes] != testVolumeStruct.reportedNumInodes {
+		t.Fatalf("StatVfs() for root returned TotalInodes %v... expected %v", statVFS[StatVFSTotalInodes], testVolumeStruct.reportedNumInodes)
+	}
+
+	// Only bytes referenced by the file's extents are charged... not holes
+
+	err = testVolumeStruct.Resize(userID, groupID, nil, fileInodeNumber, 16384)
+	if err != nil {
+		t.Fatalf("Resize() creating a hole returned error: %v", err)
+	}
+	err = testVolumeStruct.Fallocate(userID, groupID, nil, fileInodeNumber, unix.FALLOC_FL_KEEP_SIZE, 16384, 4096)
+	if err != nil {
+		t.Fatalf("Fallocate(KEEP_SIZE,16384,4096) returned error: %v", err)
+	}
+	_, err = testVolumeStruct.Write(userID, groupID, nil, fileInodeNumber, 0, bytes.Repeat([]byte{0x33}, 4096), nil)
+	if err != nil {
+		t.Fatalf("Write() overwriting [0:4096) returned error: %v", err)
+	}
+	checkUsage(inode.QuotaTypeUser, uint64(userID), 8192, 2)
+
+	err = testVolumeStruct.Fallocate(userID, groupID, nil, fileInodeNumber, unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, 2048, 4096)
+	if err != nil {
+		t.Fatalf("Fallocate(PUNCH_HOLE|KEEP_SIZE,2048,4096) returned error: %v", err)
+	}
+	checkUsage(inode.QuotaTypeUser, uint64(userID), 4096, 2)
+
+	_, err = testVolumeStruct.Write(userID, groupID, nil, fileInodeNumber, 12288, bytes.Repeat([]byte{0x44}, 4096), nil)
+	if err != nil {
+		t.Fatalf("Write() of [12288:16384) returned error: %v", err)
+	}
+	checkUsage(inode.QuotaTypeUser, uint64(userID), 8192, 2)
+
+	err = testVolumeStruct.Resize(userID, groupID, nil, fileInodeNumber, 14336)
+	if err != nil {
+		t.Fatalf("Resize() truncating the file returned error: %v", err)
+	}
+	checkUsage(inode.QuotaTypeUser, uint64(userID), 6144, 2)
+	checkUsage(inode.QuotaTypeGroup, uint64(groupID), 6144, 2)
+
+	// Removing a file should credit its usage back
+
+	err = testVolumeStruct.Unlink(userID, groupID, nil, testDirInodeNumber, "file1")
+	if err != nil {
+		t.Fatalf("Unlink() of file1 returned error: %v", err)
+	}
+	checkUsage(inode.QuotaTypeUser, uint64(userID), 0, 1)
+
+	// Enforce a project quota of 2 inodes on a directory tree
+
+	projectID := uint64(7)
+
+	projectDirInodeNumber, err := testVolumeStruct.Mkdir(inode.InodeRootUserID, inode.InodeGroupID(0), nil, testDirInodeNumber, "project", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Mkdir() of project returned error: %v", err)
+	}
+	err = testVolumeStruct.SetProjectID(userID, groupID, nil, projectDirInodeNumber, projectID)
+	if blunder.IsNot(err, blunder.NotPermError) {
+		t.Fatalf("SetProjectID() by non-root should have failed with EPERM, err: %v", err)
+	}
+	err = testVolumeStruct.SetProjectID(inode.InodeRootUserID, inode.InodeGroupID(0), nil, projectDirInodeNumber, projectID)
+	if err != nil {
+		t.Fatalf("SetProjectID() returned error: %v", err)
+	}
+	err = testVolumeStruct.inodeVolumeHandle.SetQuotaLimits(inode.QuotaTypeProject, projectID, inode.QuotaLimitsStruct{MaxInodes: 2})
+	if err != nil {
+		t.Fatalf("SetQuotaLimits() returned error: %v", err)
+	}
+	checkUsage(inode.QuotaTypeProject, projectID, 0, 1)
+
+	fileAInodeNumber, err := testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, projectDirInodeNumber, "fileA", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Create() of fileA returned error: %v", err)
+	}
+	checkUsage(inode.QuotaTypeProject, projectID, 0, 2)
+	_, err = testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, projectDirInodeNumber, "fileB", inode.PosixModePerm)
+	if blunder.IsNot(err, blunder.QuotaExceededError) {
+		t.Fatalf("Create() of fileB should have failed with EDQUOT, err: %v", err)
+	}
+
+	// Files may be moved out of a project (taking their usage with them)...
+
+	err = testVolumeStruct.Rename(inode.InodeRootUserID, inode.InodeGroupID(0), nil, projectDirInodeNumber, "fileA", testDirInodeNumber, "fileA")
+	if err != nil {
+		t.Fatalf("Rename() of fileA out of project returned error: %v", err)
+	}
+	checkUsage(inode.QuotaTypeProject, projectID, 0, 1)
+
+	// ...but neither hard linked across nor have directories moved across a project boundary
+
+	err = testVolumeStruct.Link(inode.InodeRootUserID, inode.InodeGroupID(0), nil, projectDirInodeNumber, "fileA", fileAInodeNumber)
+	if blunder.IsNot(err, blunder.CrossDeviceError) {
+		t.Fatalf("Link() across project boundary should have failed with EXDEV, err: %v", err)
+	}
+
+	_, err = testVolumeStruct.Mkdir(inode.InodeRootUserID, inode.InodeGroupID(0), nil, projectDirInodeNumber, "subdir", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Mkdir() of subdir returned error: %v", err)
+	}
+	checkUsage(inode.QuotaTypeProject, projectID, 0, 2)
+	err = testVolumeStruct.Rename(inode.InodeRootUserID, inode.InodeGroupID(0), nil, projectDirInodeNumber, "subdir", testDirInodeNumber, "subdir")
+	if blunder.IsNot(err, blunder.CrossDeviceError) {
+		t.Fatalf("Rename() of subdir out of project should have failed with EXDEV, err: %v", err)
+	}
+
+	// A failed move into a project leaves the file (and its usage) outside of it
+
+	err = testVolumeStruct.inodeVolumeHandle.SetQuotaLimits(inode.QuotaTypeProject, projectID, inode.QuotaLimitsStruct{MaxInodes: 3})
+	if err != nil {
+		t.Fatalf("SetQuotaLimits() returned error: %v", err)
+	}
+	err = testVolumeStruct.Rename(inode.InodeRootUserID, inode.InodeGroupID(0), nil, testDirInodeNumber, "fileA", projectDirInodeNumber, "subdir")
+	if err == nil {
+		t.Fatalf("Rename() of fileA over subdir should have failed")
+	}
+	checkUsage(inode.QuotaTypeProject, projectID, 0, 2)
+	metadata, err := testVolumeStruct.inodeVolumeHandle.GetMetadata(fileAInodeNumber)
+	if err != nil {
+		t.Fatalf("GetMetadata() of fileA returned error: %v", err)
+	}
+	if metadata.ProjectID != 0 {
+		t.Fatalf("Rename() of fileA over subdir left fileA with ProjectID %v", metadata.ProjectID)
+	}
+
+	testTeardown(t)
+}
+
+// testPosixACL encodes tag, perm, id triples in "system.posix_acl_*" xattr format
+func testPosixACL(entries ...uint32) (buf []byte) {
+	buf = make([]byte, 4+(len(entries)/3)*8)
+	binary.LittleEndian.PutUint32(buf, 2)
+	for i := 0; i < len(entries)/3; i++ {
+		binary.LittleEndian.PutUint16(buf[4+(i*8):], uint16(entries[(i*3)+0]))
+		binary.LittleEndian.PutUint16(buf[4+(i*8)+2:], uint16(entries[(i*3)+1]))
+		binary.LittleEndian.PutUint32(buf[4+(i*8)+4:], entries[(i*3)+2])
+	}
+	return
+}
+
+func TestPosixACL(t *testing.T) {
+	const (
+		aclUserObj  = 0x01
+		aclUser     = 0x02
+		aclGroupObj = 0x04
+		aclGroup    = 0x08
+		aclMask     = 0x10
+		aclOther    = 0x20
+		aclNoID     = 0xFFFFFFFF
+	)
+
+	var (
+		err error
+	)
+
+	testSetup(t, false)
+
+	rootDirInodeNumber := inode.RootDirInodeNumber
+
+	userID := inode.InodeUserID(1000)
+	groupID := inode.InodeGroupID(1000)
+	otherUserID := inode.InodeUserID(1001)
+	otherGroupID := inode.InodeGroupID(1001)
+
+	dirInodeNumber, err := testVolumeStruct.Mkdir(inode.InodeRootUserID, inode.InodeGroupID(0), nil, rootDirInodeNumber, "TestPosixACLDir", inode.InodeMode(0750))
+	if err != nil {
+		t.Fatalf("Mkdir() returned error: %v", err)
+	}
+
+	checkMode := func(inodeNumber inode.InodeNumber, expectedFilePerm uint64) {
+		stat, getstatErr := testVolumeStruct.Getstat(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inodeNumber)
+		if getstatErr != nil {
+			t.Fatalf("Getstat() returned error: %v", getstatErr)
+		}
+		if (stat[StatMode] & uint64(inode.PosixModePerm)) != expectedFilePerm {
+			t.Fatalf("Getstat() returned mode %o... expected %o", stat[StatMode]&uint64(inode.PosixModePerm), expectedFilePerm)
+		}
+	}
+
+	_, err = testVolumeStruct.Create(userID, groupID, nil, dirInodeNumber, "file1", inode.PosixModePerm)
+	if blunder.IsNot(err, blunder.PermDeniedError) {
+		t.Fatalf("Create() without an ACL should have failed with EACCES, err: %v", err)
+	}
+
+	// Only the owner may set an ACL
+
+	accessACL := testPosixACL(
+		aclUserObj, 7, aclNoID,
+		aclUser, 7, uint32(userID),
+		aclGroupObj, 5, aclNoID,
+		aclMask, 7, aclNoID,
+		aclOther, 0, aclNoID)
+
+	err = testVolumeStruct.SetXAttr(userID, groupID, nil, dirInodeNumber, inode.PosixACLAccessStreamName, accessACL, SetXAttrCreateOrReplace)
+	if blunder.IsNot(err, blunder.NotPermError) {
+		t.Fatalf("SetXAttr() of ACL by non-owner should have failed with EPERM, err: %v", err)
+	}
+	err = testVolumeStruct.SetXAttr(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, inode.PosixACLAccessStreamName, testPosixACL(aclUserObj, 7, aclNoID, aclUser, 7, uint32(userID), aclGroupObj, 5, aclNoID, aclOther, 0, aclNoID), SetXAttrCreateOrReplace)
+	if blunder.IsNot(err, blunder.InvalidArgError) {
+		t.Fatalf("SetXAttr() of ACL lacking ACL_MASK should have failed with EINVAL, err: %v", err)
+	}
+	err = testVolumeStruct.SetXAttr(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, inode.PosixACLAccessStreamName, accessACL, SetXAttrCreateOrReplace)
+	if err != nil {
+		t.Fatalf("SetXAttr() of access ACL returned error: %v", err)
+	}
+	checkMode(dirInodeNumber, 0770)
+
+	// The ACL_USER entry grants access that the Mode alone would not
+
+	_, err = testVolumeStruct.Create(userID, groupID, nil, dirInodeNumber, "file1", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Create() with an ACL returned error: %v", err)
+	}
+	_, err = testVolumeStruct.Create(otherUserID, otherGroupID, nil, dirInodeNumber, "file2", inode.PosixModePerm)
+	if blunder.IsNot(err, blunder.PermDeniedError) {
+		t.Fatalf("Create() by user not in ACL should have failed with EACCES, err: %v", err)
+	}
+
+	// A chmod() restricts the ACL_MASK entry
+
+	stat := make(Stat)
+	stat[StatMode] = 0750
+	err = testVolumeStruct.Setstat(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, stat)
+	if err != nil {
+		t.Fatalf("Setstat() returned error: %v", err)
+	}
+	_, err = testVolumeStruct.Create(userID, groupID, nil, dirInodeNumber, "file3", inode.PosixModePerm)
+	if blunder.IsNot(err, blunder.PermDeniedError) {
+		t.Fatalf("Create() after chmod() should have failed with EACCES, err: %v", err)
+	}
+	value, err := testVolumeStruct.GetXAttr(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, inode.PosixACLAccessStreamName)
+	if err != nil {
+		t.Fatalf("GetXAttr() of access ACL returned error: %v", err)
+	}
+	expectedACL := testPosixACL(
+		aclUserObj, 7, aclNoID,
+		aclUser, 7, uint32(userID),
+		aclGroupObj, 5, aclNoID,
+		aclMask, 5, aclNoID,
+		aclOther, 0, aclNoID)
+	if !bytes.Equal(value, expectedACL) {
+		t.Fatalf("GetXAttr() of access ACL returned %v... expected %v", value, expectedACL)
+	}
+
+	// Default ACLs are inherited by new files and directories
+
+	fileInodeNumber, err := testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, "file4", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Create() returned error: %v", err)
+	}
+	err = testVolumeStruct.SetXAttr(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, inode.PosixACLDefaultStreamName, accessACL, SetXAttrCreateOrReplace)
+	if blunder.IsNot(err, blunder.PermDeniedError) {
+		t.Fatalf("SetXAttr() of default ACL on a file should have failed with EACCES, err: %v", err)
+	}
+
+	defaultACL := testPosixACL(
+		aclUserObj, 7, aclNoID,
+		aclUser, 6, uint32(userID),
+		aclGroupObj, 4, aclNoID,
+		aclMask, 6, aclNoID,
+		aclOther, 0, aclNoID)
+
+	err = testVolumeStruct.SetXAttr(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, inode.PosixACLDefaultStreamName, defaultACL, SetXAttrCreateOrReplace)
+	if err != nil {
+		t.Fatalf("SetXAttr() of default ACL returned error: %v", err)
+	}
+
+	fileInodeNumber, err = testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, "file5", inode.InodeMode(0644))
+	if err != nil {
+		t.Fatalf("Create() returned error: %v", err)
+	}
+	checkMode(fileInodeNumber, 0640)
+	value, err = testVolumeStruct.GetXAttr(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, inode.PosixACLAccessStreamName)
+	if err != nil {
+		t.Fatalf("GetXAttr() of inherited access ACL returned error: %v", err)
+	}
+	expectedACL = testPosixACL(
+		aclUserObj, 6, aclNoID,
+		aclUser, 6, uint32(userID),
+		aclGroupObj, 4, aclNoID,
+		aclMask, 4, aclNoID,
+		aclOther, 0, aclNoID)
+	if !bytes.Equal(value, expectedACL) {
+		t.Fatalf("GetXAttr() of inherited access ACL returned %v... expected %v", value, expectedACL)
+	}
+	if !testVolumeStruct.Access(userID, groupID, nil, fileInodeNumber, inode.R_OK) {
+		t.Fatalf("Access(R_OK) via inherited ACL should have succeeded")
+	}
+	if testVolumeStruct.Access(userID, groupID, nil, fileInodeNumber, inode.W_OK) {
+		t.Fatalf("Access(W_OK) via inherited ACL should have been masked")
+	}
+
+	subDirInodeNumber, err := testVolumeStruct.Mkdir(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, "subdir", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Mkdir() returned error: %v", err)
+	}
+	value, err = testVolumeStruct.GetXAttr(inode.InodeRootUserID, inode.InodeGroupID(0), nil, subDirInodeNumber, inode.PosixACLDefaultStreamName)
+	if err != nil {
+		t.Fatalf("GetXAttr() of inherited default ACL returned error: %v", err)
+	}
+	if !bytes.Equal(value, defaultACL) {
+		t.Fatalf("GetXAttr() of inherited default ACL returned %v... expected %v", value, defaultACL)
+	}
+	checkMode(subDirInodeNumber, 0760)
+
+	// Removing the access ACL reverts to Mode-only checking
+
+	err = testVolumeStruct.RemoveXAttr(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, inode.PosixACLAccessStreamName)
+	if err != nil {
+		t.Fatalf("RemoveXAttr() of access ACL returned error: %v", err)
+	}
+	if testVolumeStruct.Access(userID, groupID, nil, fileInodeNumber, inode.R_OK) {
+		t.Fatalf("Access(R_OK) after removing ACL should have failed")
+	}
+
+	testTeardown(t)
+}
+
+func TestChecksums(t *testing.T) {
+	var (
+		err error
+	)
+
+	testSetup(t, false)
+	defer testTeardown(t)
+
+	// ReadCacheLineSize (and, hence, checksum BlockSize) is 1000000... so write 3 blocks
+
+	bufToWrite := make([]byte, 2500000)
+	for i := range bufToWrite {
+		bufToWrite[i] = byte(i % 251)
+	}
+
+	fileInodeNumber, err := testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.RootDirInodeNumber, "TestChecksumsFile", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Create() returned error: %v", err)
+	}
+	_, err = testVolumeStruct.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, bufToWrite, nil)
+	if err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	err = testVolumeStruct.Flush(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber)
+	if err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	corruptExtents, err := testVolumeStruct.inodeVolumeHandle.VerifyFileData(fileInodeNumber)
+	if err != nil {
+		t.Fatalf("VerifyFileData() returned error: %v", err)
+	}
+	if len(corruptExtents) != 0 {
+		t.Fatalf("VerifyFileData() of intact file returned %v", corruptExtents)
+	}
+
+	// Flip a byte in the middle block of the LogSegment behind our back
+
+	readPlanOffset := uint64(0)
+	readPlanLength := uint64(len(bufToWrite))
+	readPlan, err := testVolumeStruct.inodeVolumeHandle.GetReadPlan(fileInodeNumber, &readPlanOffset, &readPlanLength)
+	if err != nil {
+		t.Fatalf("GetReadPlan() returned error: %v", err)
+	}
+	if len(readPlan) != 1 {
+		t.Fatalf("GetReadPlan() returned %d steps... expected 1", len(readPlan))
+	}
+
+	if readPlan[0].Checksums == nil {
+		t.Fatalf("GetReadPlan() returned a step lacking Checksums")
+	}
+
+	objectBuf, err := swiftclient.ObjectLoad(readPlan[0].AccountName, readPlan[0].ContainerName, readPlan[0].ObjectName)
+	if err != nil {
+		t.Fatalf("swiftclient.ObjectLoad() returned error: %v", err)
+	}
+	objectBuf[readPlan[0].Offset+1500000] ^= 0xFF
+
+	// A GetReadPlan() consumer verifying what it reads should detect the corruption as well
+
+	_, err = readPlan[0].Checksums.VerifyCovered(readPlan[0].Offset, objectBuf[readPlan[0].Offset:readPlan[0].Offset+1500000])
+	if err != nil {
+		t.Fatalf("VerifyCovered() of intact block returned error: %v", err)
+	}
+	corruptOffset, err := readPlan[0].Checksums.VerifyCovered(readPlan[0].Offset+500000, objectBuf[readPlan[0].Offset+500000:])
+	if blunder.IsNot(err, blunder.ChecksumError) || (corruptOffset != readPlan[0].Offset+1000000) {
+		t.Fatalf("VerifyCovered() of corrupted block returned corruptOffset 0x%016X err: %v", corruptOffset, err)
+	}
+	chunkedPutContext, err := swiftclient.ObjectFetchChunkedPutContext(readPlan[0].AccountName, readPlan[0].ContainerName, readPlan[0].ObjectName, "")
+	if err != nil {
+		t.Fatalf("swiftclient.ObjectFetchChunkedPutContext() returned error: %v", err)
+	}
+	err = chunkedPutContext.SendChunk(objectBuf)
+	if err != nil {
+		t.Fatalf("chunkedPutContext.SendChunk() returned error: %v", err)
+	}
+	err = chunkedPutContext.Close()
+	if err != nil {
+		t.Fatalf("chunkedPutContext.Close() returned error: %v", err)
+	}
+
+	// Reads of the intact blocks succeed while those of the corrupted block fail
+
+	readBuf, err := testVolumeStruct.Read(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, 10, nil)
+	if err != nil {
+		t.Fatalf("Read() of intact block returned error: %v", err)
+	}
+	if !bytes.Equal(readBuf, bufToWrite[:10]) {
+		t.Fatalf("Read() of intact block returned unexpected data")
+	}
+	_, err = testVolumeStruct.Read(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 1200000, 10, nil)
+	if blunder.IsNot(err, blunder.ChecksumError) {
+		t.Fatalf("Read() of corrupted block should have failed with EIO, err: %v", err)
+	}
+
+	corruptExtents, err = testVolumeStruct.inodeVolumeHandle.VerifyFileData(fileInodeNumber)
+	if err != nil {
+		t.Fatalf("VerifyFileData() returned error: %v", err)
+	}
+	expectedCorruptExtent := inode.CorruptExtentStruct{
+		FileOffset:       1000000,
+		Length:           1000000,
+		LogSegmentNumber: readPlan[0].LogSegmentNumber,
+		LogSegmentOffset: readPlan[0].Offset + 1000000,
+	}
+	if (len(corruptExtents) != 1) || (corruptExtents[0] != expectedCorruptExtent) {
+		t.Fatalf("VerifyFileData() of corrupted file returned %v... expected [%v]", corruptExtents, expectedCorruptExtent)
+	}
+
+	// A SCRUB should report the corruption
+
+	scrubJob := ScrubVolume(testVolumeStruct.volumeName)
+	scrubJob.Wait()
+
+	scrubErrs := scrubJob.Error()
+	if (len(scrubErrs) != 1) || !strings.Contains(scrubErrs[0], "has corrupt data at FileOffset 0x00000000000F4240") {
+		t.Fatalf("ScrubVolume() returned errors %v", scrubErrs)
+	}
+
+}
+
+func TestCompression(t *testing.T) {
+	var (
+		err error
+	)
+
+	testSetup(t, false)
+	defer testTeardown(t)
+
+	// Restart TestVolume with Compression enabled
+
+	testCompressionRestart := func() {
+		err = transitions.Down(testConfMap)
+		if err != nil {
+			t.Fatalf("transitions.Down() returned error: %v", err)
+		}
+		err = testConfMap.UpdateFromStrings([]string{"Volume:TestVolume.Compression=flate"})
+		if err != nil {
+			t.Fatalf("testConfMap.UpdateFromStrings() returned error: %v", err)
+		}
+		err = transitions.Up(testConfMap)
+		if err != nil {
+			t.Fatalf("transitions.Up() returned error: %v", err)
+		}
+		testVolumeHandle, err := FetchVolumeHandleByVolumeName("TestVolume")
+		if err != nil {
+			t.Fatalf("FetchVolumeHandleByVolumeName() returned error: %v", err)
+		}
+		testVolumeStruct = testVolumeHandle.(*volumeStruct)
+	}
+
+	testCompressionRestart()
+
+	bufToWrite := make([]byte, 2500000)
+	for i := range bufToWrite {
+		bufToWrite[i] = byte(i % 251)
+	}
+
+	fileInodeNumber, err := testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.RootDirInodeNumber, "TestCompressionFile", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Create() returned error: %v", err)
+	}
+	for fileOffset := 0; fileOffset < len(bufToWrite); fileOffset += 500000 {
+		_, err = testVolumeStruct.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, uint64(fileOffset), bufToWrite[fileOffset:fileOffset+500000], nil)
+		if err != nil {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+	}
+
+	// Read back (spanning chunks) from the still open LogSegment
+
+	readBuf, err := testVolumeStruct.Read(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 400000, 200000, nil)
+	if err != nil {
+		t.Fatalf("Read() of unflushed data returned error: %v", err)
+	}
+	if !bytes.Equal(readBuf, bufToWrite[400000:600000]) {
+		t.Fatalf("Read() of unflushed data returned unexpected data")
+	}
+
+	err = testVolumeStruct.Flush(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber)
+	if err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	readBuf, err = testVolumeStruct.Read(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, uint64(len(bufToWrite)), nil)
+	if err != nil {
+		t.Fatalf("Read() of flushed data returned error: %v", err)
+	}
+	if !bytes.Equal(readBuf, bufToWrite) {
+		t.Fatalf("Read() of flushed data returned unexpected data")
+	}
+
+	corruptExtents, err := testVolumeStruct.inodeVolumeHandle.VerifyFileData(fileInodeNumber)
+	if err != nil {
+		t.Fatalf("VerifyFileData() returned error: %v", err)
+	}
+	if len(corruptExtents) != 0 {
+		t.Fatalf("VerifyFileData() of compressed file returned %v", corruptExtents)
+	}
+
+	// Clients reading directly from LogSegments cannot handle compressed ones
+
+	readPlanOffset := uint64(0)
+	readPlanLength := uint64(len(bufToWrite))
+	_, err = testVolumeStruct.inodeVolumeHandle.GetReadPlan(fileInodeNumber, &readPlanOffset, &readPlanLength)
+	if blunder.IsNot(err, blunder.NotSupportedError) {
+		t.Fatalf("GetReadPlan() of compressed file should have failed with ENOTSUP, err: %v", err)
+	}
+
+	// The compression ratio should be reported (and survive a restart)
+
+	testCompressionRestart()
+
+	statVFS, err := testVolumeStruct.StatVfs(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.RootDirInodeNumber)
+	if err != nil {
+		t.Fatalf("StatVfs() returned error: %v", err)
+	}
+	if (statVFS[StatVFSLogicalBytes] != uint64(len(bufToWrite))) || (statVFS[StatVFSPhysicalBytes] >= statVFS[StatVFSLogicalBytes]) {
+		t.Fatalf("StatVfs() reported CompressedLogicalBytes %v CompressedPhysicalBytes %v", statVFS[StatVFSLogicalBytes], statVFS[StatVFSPhysicalBytes])
+	}
+
+	readBuf, err = testVolumeStruct.Read(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 1200000, 10, nil)
+	if err != nil {
+		t.Fatalf("Read() after restart returned error: %v", err)
+	}
+	if !bytes.Equal(readBuf, bufToWrite[1200000:1200010]) {
+		t.Fatalf("Read() after restart returned unexpected data")
+	}
+
+	// Removing the file should discount its LogSegment
+
+	err = testVolumeStruct.Unlink(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.RootDirInodeNumber, "TestCompressionFile")
+	if err != nil {
+		t.Fatalf("Unlink() returned error: %v", err)
+	}
+
+	statVFS, err = testVolumeStruct.StatVfs(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.RootDirInodeNumber)
+	if err != nil {
+		t.Fatalf("StatVfs() returned error: %v", err)
+	}
+	if (statVFS[StatVFSLogicalBytes] != 0) || (statVFS[StatVFSPhysicalBytes] != 0) {
+		t.Fatalf("StatVfs() after Unlink() reported CompressedLogicalBytes %v CompressedPhysicalBytes %v", statVFS[StatVFSLogicalBytes], statVFS[StatVFSPhysicalBytes])
+	}
+
+}
+
+func TestEncryption(t *testing.T) {
+	var (
+		err error
+	)
+
+	testSetup(t, false)
+
+	// Restart TestVolume encrypted (in a fresh account so that it is formatted as such)
+
+	err = swiftclient.AccountPut("AUTH_encrypted", make(map[string][]string))
+	if err != nil {
+		t.Fatalf("swiftclient.AccountPut() returned error: %v", err)
+	}
+
+	err = ioutil.WriteFile("TestVolume.keys", []byte("1 000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f\n"), 0600)
+	if err != nil {
+		t.Fatalf("ioutil.WriteFile() returned error: %v", err)
+	}
+
+	testEncryptionRestart := func() {
+		err = transitions.Down(testConfMap)
+		if err != nil {
+			t.Fatalf("transitions.Down() returned error: %v", err)
+		}
+		err = testConfMap.UpdateFromStrings([]string{
+			"Volume:TestVolume.AccountName=AUTH_encrypted",
+			"Volume:TestVolume.EncryptionKeyProvider=keyfile",
+			"Volume:TestVolume.EncryptionKeyFile=TestVolume.keys",
+		})
+		if err != nil {
+			t.Fatalf("testConfMap.UpdateFromStrings() returned error: %v", err)
+		}
+		err = transitions.Up(testConfMap)
+		if err != nil {
+			t.Fatalf("transitions.Up() returned error: %v", err)
+		}
+		testVolumeHandle, err := FetchVolumeHandleByVolumeName("TestVolume")
+		if err != nil {
+			t.Fatalf("FetchVolumeHandleByVolumeName() returned error: %v", err)
+		}
+		testVolumeStruct = testVolumeHandle.(*volumeStruct)
+	}
+
+	testEncryptionRestart()
+
+	if !testVolumeStruct.IsEncrypted() {
+		t.Fatalf("IsEncrypted() should have returned true")
+	}
+
+	bufToWrite := make([]byte, 1000000)
+	for i := range bufToWrite {
+		bufToWrite[i] = byte(i % 251)
+	}
+
+	fileInodeNumber, err := testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.RootDirInodeNumber, "TestEncryptionFile", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Create() returned error: %v", err)
+	}
+	_, err = testVolumeStruct.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, bufToWrite[:500000], nil)
+	if err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+
+	readBuf, err := testVolumeStruct.Read(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 100000, 200000, nil)
+	if err != nil {
+		t.Fatalf("Read() of unflushed data returned error: %v", err)
+	}
+	if !bytes.Equal(readBuf, bufToWrite[100000:300000]) {
+		t.Fatalf("Read() of unflushed data returned unexpected data")
+	}
+
+	err = testVolumeStruct.Flush(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber)
+	if err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	// Rotate to a new key... what was written with the prior key must remain readable
+
+	err = ioutil.WriteFile("TestVolume.keys", []byte("1 000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f\n2 202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f\n"), 0600)
+	if err != nil {
+		t.Fatalf("ioutil.WriteFile() returned error: %v", err)
+	}
+	err = transitions.Signaled(testConfMap)
+	if err != nil {
+		t.Fatalf("transitions.Signaled() returned error: %v", err)
+	}
+
+	_, err = testVolumeStruct.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 500000, bufToWrite[500000:], nil)
+	if err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	err = testVolumeStruct.Flush(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber)
+	if err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	testEncryptionRestart()
+
+	fileInodeNumber, err = testVolumeStruct.Lookup(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.RootDirInodeNumber, "TestEncryptionFile")
+	if err != nil {
+		t.Fatalf("Lookup() after restart returned error: %v", err)
+	}
+	readBuf, err = testVolumeStruct.Read(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, uint64(len(bufToWrite)), nil)
+	if err != nil {
+		t.Fatalf("Read() after restart returned error: %v", err)
+	}
+	if !bytes.Equal(readBuf, bufToWrite) {
+		t.Fatalf("Read() after restart returned unexpected data")
+	}
+
+	corruptExtents, err := testVolumeStruct.inodeVolumeHandle.VerifyFileData(fileInodeNumber)
+	if err != nil {
+		t.Fatalf("VerifyFileData() returned error: %v", err)
+	}
+	if len(corruptExtents) != 0 {
+		t.Fatalf("VerifyFileData() of encrypted file returned %v", corruptExtents)
+	}
+
+	// Clients reading directly from LogSegments cannot unseal them
+
+	readPlanOffset := uint64(0)
+	readPlanLength := uint64(len(bufToWrite))
+	_, err = testVolumeStruct.inodeVolumeHandle.GetReadPlan(fileInodeNumber, &readPlanOffset, &readPlanLength)
+	if blunder.IsNot(err, blunder.NotSupportedError) {
+		t.Fatalf("GetReadPlan() of encrypted file should have failed with ENOTSUP, err: %v", err)
+	}
+
+	// Clients writing directly to Swift (e.g. PFSAgent) must seal each chunk with their own keyring
+
+	clientKeyring, err := keyring.NewKeyring(testConfMap, "TestVolume")
+	if err != nil {
+		t.Fatalf("keyring.NewKeyring() returned error: %v", err)
+	}
+
+	clientFileInodeNumber, err := testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.RootDirInodeNumber, "TestEncryptionClientFile", inode.PosixModePerm)
+	if err != nil {
+		t.Fatalf("Create() returned error: %v", err)
+	}
+
+	objectPath, err := testVolumeStruct.CallInodeToProvisionObject()
+	if err != nil {
+		t.Fatalf("CallInodeToProvisionObject() returned error: %v", err)
+	}
+	_, containerName, objectName, err := utils.PathToAcctContObj(objectPath)
+	if err != nil {
+		t.Fatalf("utils.PathToAcctContObj() returned error: %v", err)
+	}
+	objectNumber, err := strconv.ParseUint(objectName, 16, 64)
+	if err != nil {
+		t.Fatalf("strconv.ParseUint() returned error: %v", err)
+	}
+
+	clientChunkedPutContext, err := swiftclient.ObjectFetchChunkedPutContext("AUTH_encrypted", containerName, objectName, "")
+	if err != nil {
+		t.Fatalf("swiftclient.ObjectFetchChunkedPutContext() returned error: %v", err)
+	}
+
+	clientChecksums := inode.NewLogSegmentChecksums(65536)
+	clientCompression := &inode.LogSegmentCompressionStruct{Algorithm: inode.CompressionNone, Encrypted: true}
+	clientPhysicalOffset := uint64(0)
+
+	for chunkOffset := 0; chunkOffset < len(bufToWrite); chunkOffset += 300000 {
+		chunkEnd := chunkOffset + 300000
+		if chunkEnd > len(bufToWrite) {
+			chunkEnd = len(bufToWrite)
+		}
+		sealedChunk, err := clientKeyring.Seal(bufToWrite[chunkOffset:chunkEnd], keyring.ObjectAdditionalData(objectNumber, clientPhysicalOffset))
+		if err != nil {
+			t.Fatalf("clientKeyring.Seal() returned error: %v", err)
+		}
+		err = clientChunkedPutContext.SendChunk(sealedChunk)
+		if err != nil {
+			t.Fatalf("SendChunk() returned error: %v", err)
+		}
+		clientChecksums.Append(bufToWrite[chunkOffset:chunkEnd])
+		clientCompression.Append(uint64(chunkEnd-chunkOffset), uint64(len(sealedChunk)))
+		clientPhysicalOffset += uint64(len(sealedChunk))
+	}
+
+	err = clientChunkedPutContext.Close()
+	if err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	err = testVolumeStruct.Wrote(inode.InodeRootUserID, inode.InodeGroupID(0), nil, clientFileInodeNumber, containerName, objectName, []uint64{0}, []uint64{0}, []uint64{uint64(len(bufToWrite))}, clientChecksums, nil, uint64(time.Now().UnixNano()))
+	if !blunder.Is(err, blunder.NotSupportedError) {
+		t.Fatalf("Wrote() of unsealed LogSegment should have failed with NotSupportedError, got: %v", err)
+	}
+	err = testVolumeStruct.Wrote(inode.InodeRootUserID, inode.InodeGroupID(0), nil, clientFileInodeNumber, containerName, objectName, []uint64{0}, []uint64{0}, []uint64{uint64(len(bufToWrite))}, clientChecksums, clientCompression, uint64(time.Now().UnixNano()))
+	if err != nil {
+		t.Fatalf("Wrote() of sealed LogSegment returned error: %v", err)
+	}
+
+	readBuf, err = testVolumeStruct.Read(inode.InodeRootUserID, inode.InodeGroupID(0), nil, clientFileInodeNumber, 0, uint64(len(bufToWrite)), nil)
+	if err != nil {
+		t.Fatalf("Read() of client sealed file returned error: %v", err)
+	}
+	if !bytes.Equal(readBuf, bufToWrite) {
+		t.Fatalf("Read() of client sealed file returned unexpected data")
+	}
+
+	// ...and can open what they read directly from Swift via the ExtentMap
+
+	extentMapChunk, err := testVolumeStruct.FetchExtentMapChunk(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, 64, 0)
+	if err != nil {
+		t.Fatalf("FetchExtentMapChunk() returned error: %v", err)
+	}
+	readBuf = make([]byte, 0, len(bufToWrite))
+	for _, extentMapEntry := range extentMapChunk.ExtentMapEntry {
+		if (nil == extentMapEntry.Compression) || !extentMapEntry.Compression.Encrypted {
+			t.Fatalf("FetchExtentMapChunk() returned ExtentMapEntry without sealed Compression")
+		}
+		entryObjectNumber, err := strconv.ParseUint(extentMapEntry.ObjectName, 16, 64)
+		if err != nil {
+			t.Fatalf("strconv.ParseUint() returned error: %v", err)
+		}
+		entryBuf, err := extentMapEntry.Compression.ReadSealed(extentMapEntry.LogSegmentOffset, extentMapEntry.Length,
+			func(physicalOffset uint64, physicalLength uint64) (physicalBuf []byte, err error) {
+				return swiftclient.ObjectGet("AUTH_encrypted", extentMapEntry.ContainerName, extentMapEntry.ObjectName, physicalOffset, physicalLength)
+			},
+			func(physicalOffset uint64, sealedBuf []byte) (buf []byte, err error) {
+				return clientKeyring.Open(sealedBuf, keyring.ObjectAdditionalData(entryObjectNumber, physicalOffset))
+			})
+		if err != nil {
+			t.Fatalf("ReadSealed() returned error: %v", err)
+		}
+		readBuf = append(readBuf, entryBuf...)
+	}
+	if !bytes.Equal(readBuf, bufToWrite) {
+		t.Fatalf("ReadSealed() of ExtentMap returned unexpected data")
+	}
+
+	// Neither file names nor file contents should be visible in Swift
+
+	err = testVolumeStruct.headhunterVolumeHandle.DoCheckpoint()
+	if err != nil {
+		t.Fatalf("DoCheckpoint() returned error: %v", err)
+	}
+
+	_, containerList, err := swiftclient.AccountGet("AUTH_encrypted")
+	if err != nil {
+		t.Fatalf("swiftclient.AccountGet() returned error: %v", err)
+	}
+	for _, containerName := range containerList {
+		_, objectList, err := swiftclient.ContainerGet("AUTH_encrypted", containerName)
+		if err != nil {
+			t.Fatalf("swiftclient.ContainerGet() returned error: %v", err)
+		}
+		for _, objectName := range objectList {
+			objectBuf, err := swiftclient.ObjectLoad("AUTH_encrypted", containerName, objectName)
+			if err != nil {
+				t.Fatalf("swiftclient.ObjectLoad() returned error: %v", err)
+			}
+			if bytes.Contains(objectBuf, []byte("TestEncryptionFile")) || bytes.Contains(objectBuf, []byte("TestEncryptionClientFile")) || bytes.Contains(objectBuf, bufToWrite[1000:1064]) {
+				t.Fatalf("Object %s/%s contains plaintext", containerName, objectName)
+			}
+		}
+	}
+
+	testTeardown(t)
+}
+
+func TestSnapShotDiff(t *testing.T) {
+	var (
+		err error
+	)
+
+	testSetup(t, false)
+
+	dirInodeNumber := createTestDirectory(t, "TestSnapShotDiffDir")
+
+	nestedDirInodeNumber, err := testVolumeStruct.Mkdir(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, "Nested", inode.PosixModePerm)
+	if nil != err {
+		t.Fatalf("Mkdir(,,,,\"Nested\",) returned error: %v", err)
+	}
+	deeperDirInodeNumber, err := testVolumeStruct.Mkdir(inode.InodeRootUserID, inode.InodeGroupID(0), nil, nestedDirInodeNumber, "Deeper", inode.PosixModePerm)
+	if nil != err {
+		t.Fatalf("Mkdir(,,,,\"Deeper\",) returned error: %v", err)
+	}
+
+	inodeNumberMap := make(map[string]inode.InodeNumber)
+
+	for _, testFile := range []struct {
+		dirInodeNumber inode.InodeNumber
+		basename       string
+	}{
+		{dirInodeNumber, "Unchanged"},
+		{dirInodeNumber, "Modified"},
+		{dirInodeNumber, "Renamed"},
+		{inode.RootDirInodeNumber, "Deleted"},
+		{nestedDirInodeNumber, "ModifiedInPlace"},
+	} {
+		inodeNumberMap[testFile.basename], err = testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, testFile.dirInodeNumber, testFile.basename, inode.PosixModePerm)
+		if nil != err {
+			t.Fatalf("Create(,,,,\"%s\",) returned error: %v", testFile.basename, err)
+		}
+	}
+
+	fromSnapShotID, err := testVolumeStruct.inodeVolumeHandle.SnapShotCreate("TestSnapShotDiffFrom")
+	if nil != err {
+		t.Fatalf("SnapShotCreate() [from] returned error: %v", err)
+	}
+
+	for _, basename := range []string{"Modified", "ModifiedInPlace"} {
+		_, err = testVolumeStruct.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inodeNumberMap[basename], 0, []byte(basename), nil)
+		if nil != err {
+			t.Fatalf("Write() to \"%s\" returned error: %v", basename, err)
+		}
+		err = testVolumeStruct.Flush(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inodeNumberMap[basename])
+		if nil != err {
+			t.Fatalf("Flush() of \"%s\" returned error: %v", basename, err)
+		}
+	}
+	err = testVolumeStruct.Rename(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, "Renamed", inode.RootDirInodeNumber, "RenamedTo")
+	if nil != err {
+		t.Fatalf("Rename() returned error: %v", err)
+	}
+	err = testVolumeStruct.Unlink(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.RootDirInodeNumber, "Deleted")
+	if nil != err {
+		t.Fatalf("Unlink() returned error: %v", err)
+	}
+	inodeNumberMap["Created"], err = testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, "Created", inode.PosixModePerm)
+	if nil != err {
+		t.Fatalf("Create(,,,,\"Created\",) returned error: %v", err)
+	}
+	inodeNumberMap["CreatedDeeper"], err = testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, deeperDirInodeNumber, "CreatedDeeper", inode.PosixModePerm)
+	if nil != err {
+		t.Fatalf("Create(,,,,\"CreatedDeeper\",) returned error: %v", err)
+	}
+
+	toSnapShotID, err := testVolumeStruct.inodeVolumeHandle.SnapShotCreate("TestSnapShotDiffTo")
+	if nil != err {
+		t.Fatalf("SnapShotCreate() [to] returned error: %v", err)
+	}
+
+	// A changed directory whose parent is unchanged is located via "..", while an inode
+	// modified in place in an unchanged directory is reported last (once searched for)
+
+	expectedEntries := []SnapShotDiffEntryStruct{
+		{SnapShotDiffModified, uint64(inode.RootDirInodeNumber), inode.DirType, "/", ""},
+		{SnapShotDiffModified, uint64(dirInodeNumber), inode.DirType, "/TestSnapShotDiffDir", ""},
+		{SnapShotDiffModified, uint64(deeperDirInodeNumber), inode.DirType, "/TestSnapShotDiffDir/Nested/Deeper", ""},
+		{SnapShotDiffModified, uint64(inodeNumberMap["Modified"]), inode.FileType, "/TestSnapShotDiffDir/Modified", ""},
+		{SnapShotDiffRenamed, uint64(inodeNumberMap["Renamed"]), inode.FileType, "/RenamedTo", "/TestSnapShotDiffDir/Renamed"},
+		{SnapShotDiffDeleted, uint64(inodeNumberMap["Deleted"]), inode.FileType, "/Deleted", ""},
+		{SnapShotDiffCreated, uint64(inodeNumberMap["Created"]), inode.FileType, "/TestSnapShotDiffDir/Created", ""},
+		{SnapShotDiffCreated, uint64(inodeNumberMap["CreatedDeeper"]), inode.FileType, "/TestSnapShotDiffDir/Nested/Deeper/CreatedDeeper", ""},
+		{SnapShotDiffModified, uint64(inodeNumberMap["ModifiedInPlace"]), inode.FileType, "/TestSnapShotDiffDir/Nested/ModifiedInPlace", ""},
+	}
+
+	// The live view should (at this point) match the "to" SnapShot
+
+	for _, toID := range []uint64{toSnapShotID, 0} {
+		entries := make([]SnapShotDiffEntryStruct, 0)
+
+		err = testVolumeStruct.SnapShotDiff(fromSnapShotID, toID, func(entry *SnapShotDiffEntryStruct) (err error) {
+			entries = append(entries, *entry)
+			return nil
+		})
+		if nil != err {
+			t.Fatalf("SnapShotDiff(,%d,) returned error: %v", toID, err)
+		}
+
+		if len(expectedEntries) != len(entries) {
+			t.Fatalf("SnapShotDiff(,%d,) returned %v... expected %v", toID, entries, expectedEntries)
+		}
+		for i := range entries {
+			if expectedEntries[i] != entries[i] {
+				t.Fatalf("SnapShotDiff(,%d,) returned %v... expected %v", toID, entries, expectedEntries)
+			}
+		}
+	}
+
+	// An error returned by callback stops SnapShotDiff after the entry it was given
+
+	callbackCount := 0
+
+	err = testVolumeStruct.SnapShotDiff(fromSnapShotID, toSnapShotID, func(entry *SnapShotDiffEntryStruct) (err error) {
+		callbackCount++
+		return blunder.NewError(blunder.IOError, "callback failed")
+	})
+	if blunder.IsNot(err, blunder.IOError) || (1 != callbackCount) {
+		t.Fatalf("SnapShotDiff() should have stopped at callback's error, err: %v callbackCount: %d", err, callbackCount)
+	}
+
+	// Diffing a SnapShot against itself should report nothing
+
+	err = testVolumeStruct.SnapShotDiff(toSnapShotID, toSnapShotID, func(entry *SnapShotDiffEntryStruct) (err error) {
+		t.Fatalf("SnapShotDiff() of SnapShot against itself returned %v", *entry)
+		return nil
+	})
+	if nil != err {
+		t.Fatalf("SnapShotDiff() of SnapShot against itself returned error: %v", err)
+	}
+
+	err = testVolumeStruct.SnapShotDiff(fromSnapShotID, toSnapShotID+1, func(entry *SnapShotDiffEntryStruct) (err error) { return nil })
+	if blunder.IsNot(err, blunder.NotFoundError) {
+		t.Fatalf("SnapShotDiff() to unknown SnapShotID should have failed with ENOENT, err: %v", err)
+	}
+
+	testTeardown(t)
+}
+
+func TestSnapShotClone(t *testing.T) {
+	var (
+		err error
+	)
+
+	testSetup(t, false)
+
+	lookup := func(dirInodeNumber inode.InodeNumber, basename string) (inodeNumber inode.InodeNumber) {
+		inodeNumber, err = testVolumeStruct.Lookup(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, basename)
+		if nil != err {
+			t.Fatalf("Lookup(,,,,\"%s\") returned error: %v", basename, err)
+		}
+		return
+	}
+
+	read := func(inodeNumber inode.InodeNumber) (buf []byte) {
+		buf, err = testVolumeStruct.Read(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inodeNumber, 0, 4096, nil)
+		if nil != err {
+			t.Fatalf("Read() returned error: %v", err)
+		}
+		return
+	}
+
+	write := func(inodeNumber inode.InodeNumber, buf []byte) {
+		_, err = testVolumeStruct.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inodeNumber, 0, buf, nil)
+		if nil != err {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+		err = testVolumeStruct.Flush(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inodeNumber)
+		if nil != err {
+			t.Fatalf("Flush() returned error: %v", err)
+		}
+	}
+
+	srcDirInodeNumber := createTestDirectory(t, "TestSnapShotCloneSrc")
+
+	fileInodeNumber, err := testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, srcDirInodeNumber, "File", inode.PosixModePerm)
+	if nil != err {
+		t.Fatalf("Create() returned error: %v", err)
+	}
+	write(fileInodeNumber, []byte("Original"))
+	err = testVolumeStruct.Link(inode.InodeRootUserID, inode.InodeGroupID(0), nil, srcDirInodeNumber, "Link", fileInodeNumber)
+	if nil != err {
+		t.Fatalf("Link() returned error: %v", err)
+	}
+	_, err = testVolumeStruct.Symlink(inode.InodeRootUserID, inode.InodeGroupID(0), nil, srcDirInodeNumber, "Symlink", "File")
+	if nil != err {
+		t.Fatalf("Symlink() returned error: %v", err)
+	}
+	_, err = testVolumeStruct.Mkdir(inode.InodeRootUserID, inode.InodeGroupID(0), nil, srcDirInodeNumber, "SubDir", inode.PosixModePerm)
+	if nil != err {
+		t.Fatalf("Mkdir() returned error: %v", err)
+	}
+
+	snapShotID, err := testVolumeStruct.inodeVolumeHandle.SnapShotCreate("TestSnapShotClone")
+	if nil != err {
+		t.Fatalf("SnapShotCreate() returned error: %v", err)
+	}
+
+	// Overwrite then remove the live file so that the clone must resurrect what it shares
+
+	write(fileInodeNumber, []byte("Replaced"))
+	for _, basename := range []string{"File", "Link"} {
+		err = testVolumeStruct.Unlink(inode.InodeRootUserID, inode.InodeGroupID(0), nil, srcDirInodeNumber, basename)
+		if nil != err {
+			t.Fatalf("Unlink(,,,,\"%s\") returned error: %v", basename, err)
+		}
+	}
+
+	snapShotSrcDirInodeNumber := lookup(lookup(lookup(inode.RootDirInodeNumber, inode.SnapShotDirName), "TestSnapShotClone"), "TestSnapShotCloneSrc")
+	snapShotFileInodeNumber := lookup(snapShotSrcDirInodeNumber, "File")
+
+	_, err = testVolumeStruct.SnapShotClone(inode.InodeUserID(1), inode.InodeGroupID(0), nil, snapShotSrcDirInodeNumber, inode.RootDirInodeNumber, "TestSnapShotCloneDst")
+	if blunder.IsNot(err, blunder.NotPermError) {
+		t.Fatalf("SnapShotClone() by non-root should have failed with EPERM, err: %v", err)
+	}
+	_, err = testVolumeStruct.SnapShotClone(inode.InodeRootUserID, inode.InodeGroupID(0), nil, srcDirInodeNumber, inode.RootDirInodeNumber, "TestSnapShotCloneDst")
+	if blunder.IsNot(err, blunder.InvalidArgError) {
+		t.Fatalf("SnapShotClone() of live inode should have failed with EINVAL, err: %v", err)
+	}
+
+	dstDirInodeNumber, err := testVolumeStruct.SnapShotClone(inode.InodeRootUserID, inode.InodeGroupID(0), nil, snapShotSrcDirInodeNumber, inode.RootDirInodeNumber, "TestSnapShotCloneDst")
+	if nil != err {
+		t.Fatalf("SnapShotClone() returned error: %v", err)
+	}
+	if dstDirInodeNumber != lookup(inode.RootDirInodeNumber, "TestSnapShotCloneDst") {
+		t.Fatalf("SnapShotClone() returned inodeNumber not found at TestSnapShotCloneDst")
+	}
+
+	cloneFileInodeNumber := lookup(dstDirInodeNumber, "File")
+	if cloneFileInodeNumber != lookup(dstDirInodeNumber, "Link") {
+		t.Fatalf("SnapShotClone() failed to preserve hard link")
+	}
+	target, err := testVolumeStruct.Readsymlink(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lookup(dstDirInodeNumber, "Symlink"))
+	if nil != err {
+		t.Fatalf("Readsymlink() returned error: %v", err)
+	}
+	if "File" != target {
+		t.Fatalf("Readsymlink() of cloned Symlink returned \"%s\"", target)
+	}
+	inodeType, err := testVolumeStruct.inodeVolumeHandle.GetType(lookup(dstDirInodeNumber, "SubDir"))
+	if nil != err {
+		t.Fatalf("GetType() returned error: %v", err)
+	}
+	if inode.DirType != inodeType {
+		t.Fatalf("GetType() of cloned SubDir returned %v", inodeType)
+	}
+
+	if "Original" != string(read(cloneFileInodeNumber)) {
+		t.Fatalf("Read() of cloned File returned \"%s\"", read(cloneFileInodeNumber))
+	}
+
+	// Modifying the clone must not modify the SnapShot
+
+	write(cloneFileInodeNumber, []byte("Clone"))
+	if "Clonenal" != string(read(cloneFileInodeNumber)) {
+		t.Fatalf("Read() of modified cloned File returned \"%s\"", read(cloneFileInodeNumber))
+	}
+	if "Original" != string(read(snapShotFileInodeNumber)) {
+		t.Fatalf("Read() of SnapShot File returned \"%s\" after modifying clone", read(snapShotFileInodeNumber))
+	}
+
+	// Deleting the SnapShot must not reclaim what the clone still shares
+
+	err = testVolumeStruct.inodeVolumeHandle.SnapShotDelete(snapShotID)
+	if nil != err {
+		t.Fatalf("SnapShotDelete() returned error: %v", err)
+	}
+	err = testVolumeStruct.headhunterVolumeHandle.DoCheckpoint()
+	if nil != err {
+		t.Fatalf("DoCheckpoint() returned error: %v", err)
+	}
+	if "Clonenal" != string(read(cloneFileInodeNumber)) {
+		t.Fatalf("Read() of cloned File returned \"%s\" after SnapShotDelete()", read(cloneFileInodeNumber))
+	}
+	err = testVolumeStruct.inodeVolumeHandle.Validate(cloneFileInodeNumber, true)
+	if nil != err {
+		t.Fatalf("Validate() of cloned File returned error: %v", err)
+	}
+
+	testTeardown(t)
+}
+
+func TestSnapShotRollbackAndRestore(t *testing.T) {
+	var (
+		err error
+	)
+
+	testSetup(t, false)
+
+	lookupPath := func(fullpath string) (inodeNumber inode.InodeNumber) {
+		inodeNumber, err = testVolumeStruct.LookupPath(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fullpath)
+		if nil != err {
+			t.Fatalf("LookupPath(,,,\"%s\") returned error: %v", fullpath, err)
+		}
+		return
+	}
+
+	read := func(fullpath string) (contents string) {
+		buf, err := testVolumeStruct.Read(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lookupPath(fullpath), 0, 4096, nil)
+		if nil != err {
+			t.Fatalf("Read() of \"%s\" returned error: %v", fullpath, err)
+		}
+		contents = string(buf)
+		return
+	}
+
+	create := func(dirInodeNumber inode.InodeNumber, basename string, contents string) {
+		fileInodeNumber, err := testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, basename, inode.PosixModePerm)
+		if nil != err {
+			t.Fatalf("Create(,,,,\"%s\",) returned error: %v", basename, err)
+		}
+		_, err = testVolumeStruct.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, []byte(contents), nil)
+		if nil != err {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+		err = testVolumeStruct.Flush(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber)
+		if nil != err {
+			t.Fatalf("Flush() returned error: %v", err)
+		}
+	}
+
+	dirInodeNumber := createTestDirectory(t, "TestSnapShotRollback")
+
+	create(dirInodeNumber, "Kept", "Kept")
+	create(dirInodeNumber, "Removed", "Removed")
+
+	treeInodeNumber, err := testVolumeStruct.Mkdir(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, "Tree", inode.PosixModePerm)
+	if nil != err {
+		t.Fatalf("Mkdir() returned error: %v", err)
+	}
+	create(treeInodeNumber, "Leaf", "Leaf")
+
+	fromSnapShotID, err := testVolumeStruct.inodeVolumeHandle.SnapShotCreate("TestSnapShotRollbackFrom")
+	if nil != err {
+		t.Fatalf("SnapShotCreate() [from] returned error: %v", err)
+	}
+
+	_, err = testVolumeStruct.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lookupPath("/TestSnapShotRollback/Kept"), 0, []byte("Changed"), nil)
+	if nil != err {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	err = testVolumeStruct.Flush(inode.InodeRootUserID, inode.InodeGroupID(0), nil, lookupPath("/TestSnapShotRollback/Kept"))
+	if nil != err {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+	err = testVolumeStruct.Unlink(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, "Removed")
+	if nil != err {
+		t.Fatalf("Unlink() returned error: %v", err)
+	}
+	err = testVolumeStruct.Unlink(inode.InodeRootUserID, inode.InodeGroupID(0), nil, treeInodeNumber, "Leaf")
+	if nil != err {
+		t.Fatalf("Unlink() returned error: %v", err)
+	}
+	err = testVolumeStruct.Rmdir(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, "Tree")
+	if nil != err {
+		t.Fatalf("Rmdir() returned error: %v", err)
+	}
+	create(dirInodeNumber, "Added", "Added")
+
+	_, err = testVolumeStruct.inodeVolumeHandle.SnapShotCreate("TestSnapShotRollbackTo")
+	if nil != err {
+		t.Fatalf("SnapShotCreate() [to] returned error: %v", err)
+	}
+
+	// Restore just the removed subtree
+
+	_, err = testVolumeStruct.SnapShotRestore(fromSnapShotID, "/TestSnapShotRollback/Tree")
+	if nil != err {
+		t.Fatalf("SnapShotRestore() returned error: %v", err)
+	}
+	if "Leaf" != read("/TestSnapShotRollback/Tree/Leaf") {
+		t.Fatalf("Read() of restored Leaf returned \"%s\"", read("/TestSnapShotRollback/Tree/Leaf"))
+	}
+	if "Changed" != read("/TestSnapShotRollback/Kept") {
+		t.Fatalf("SnapShotRestore() of Tree should not have modified Kept")
+	}
+
+	_, err = testVolumeStruct.SnapShotRestore(fromSnapShotID, "/TestSnapShotRollback/Tree")
+	if blunder.IsNot(err, blunder.FileExistsError) {
+		t.Fatalf("SnapShotRestore() over existing Tree should have failed with EEXIST, err: %v", err)
+	}
+	_, err = testVolumeStruct.SnapShotRestore(fromSnapShotID, "/")
+	if blunder.IsNot(err, blunder.InvalidArgError) {
+		t.Fatalf("SnapShotRestore() of \"/\" should have failed with EINVAL, err: %v", err)
+	}
+	_, err = testVolumeStruct.SnapShotRestore(fromSnapShotID+100, "/TestSnapShotRollback/Tree")
+	if blunder.IsNot(err, blunder.NotFoundError) {
+		t.Fatalf("SnapShotRestore() from unknown SnapShotID should have failed with ENOENT, err: %v", err)
+	}
+
+	// Now roll back the entire volume (despite the later SnapShot)
+
+	err = testVolumeStruct.SnapShotRollback(fromSnapShotID)
+	if nil != err {
+		t.Fatalf("SnapShotRollback() returned error: %v", err)
+	}
+
+	if "Kept" != read("/TestSnapShotRollback/Kept") {
+		t.Fatalf("Read() of Kept returned \"%s\" after SnapShotRollback()", read("/TestSnapShotRollback/Kept"))
+	}
+	if "Removed" != read("/TestSnapShotRollback/Removed") {
+		t.Fatalf("Read() of Removed returned \"%s\" after SnapShotRollback()", read("/TestSnapShotRollback/Removed"))
+	}
+	if "Leaf" != read("/TestSnapShotRollback/Tree/Leaf") {
+		t.Fatalf("Read() of Leaf returned \"%s\" after SnapShotRollback()", read("/TestSnapShotRollback/Tree/Leaf"))
+	}
+	_, err = testVolumeStruct.LookupPath(inode.InodeRootUserID, inode.InodeGroupID(0), nil, "/TestSnapShotRollback/Added")
+	if blunder.IsNot(err, blunder.NotFoundError) {
+		t.Fatalf("LookupPath() of Added should have failed with ENOENT after SnapShotRollback(), err: %v", err)
+	}
+
+	// The later SnapShot must remain intact
+
+	if "Added" != read("/"+inode.SnapShotDirName+"/TestSnapShotRollbackTo/TestSnapShotRollback/Added") {
+		t.Fatalf("Read() of Added in later SnapShot failed after SnapShotRollback()")
+	}
+
+	// Deleting both SnapShots must not reclaim anything the live view still references
+
+	for _, snapShot := range testVolumeStruct.headhunterVolumeHandle.SnapShotListByID(false) {
+		err = testVolumeStruct.inodeVolumeHandle.SnapShotDelete(snapShot.ID)
+		if nil != err {
+			t.Fatalf("SnapShotDelete() returned error: %v", err)
+		}
+	}
+	err = testVolumeStruct.headhunterVolumeHandle.DoCheckpoint()
+	if nil != err {
+		t.Fatalf("DoCheckpoint() returned error: %v", err)
+	}
+
+	for _, fullpath := range []string{"/TestSnapShotRollback/Kept", "/TestSnapShotRollback/Removed", "/TestSnapShotRollback/Tree/Leaf"} {
+		err = testVolumeStruct.inodeVolumeHandle.Validate(lookupPath(fullpath), true)
+		if nil != err {
+			t.Fatalf("Validate() of \"%s\" returned error: %v", fullpath, err)
+		}
+	}
+
+	err = testVolumeStruct.SnapShotRollback(fromSnapShotID)
+	if blunder.IsNot(err, blunder.NotFoundError) {
+		t.Fatalf("SnapShotRollback() to deleted SnapShot should have failed with ENOENT, err: %v", err)
+	}
+
+	testTeardown(t)
+}
+
+func TestSnapShotPin(t *testing.T) {
+	var (
+		err error
+	)
+
+	testSetup(t, false)
+
+	isPinned := func(snapShotID uint64) (pinned bool) {
+		for _, snapShot := range testVolumeStruct.headhunterVolumeHandle.SnapShotListByID(false) {
+			if snapShotID == snapShot.ID {
+				pinned = snapShot.Pinned
+				return
+			}
+		}
+		t.Fatalf("SnapShotID %v not found", snapShotID)
+		return
+	}
+
+	dirInodeNumber := createTestDirectory(t, "TestSnapShotPin")
+
+	fileInodeNumber, err := testVolumeStruct.Create(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, "Trapped", inode.PosixModePerm)
+	if nil != err {
+		t.Fatalf("Create() returned error: %v", err)
+	}
+	_, err = testVolumeStruct.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber, 0, []byte("Trapped"), nil)
+	if nil != err {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	err = testVolumeStruct.Flush(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInodeNumber)
+	if nil != err {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	pinnedSnapShotID, err := testVolumeStruct.inodeVolumeHandle.SnapShotCreate("TestSnapShotPinned")
+	if nil != err {
+		t.Fatalf("SnapShotCreate() [pinned] returned error: %v", err)
+	}
+	otherSnapShotID, err := testVolumeStruct.inodeVolumeHandle.SnapShotCreate("TestSnapShotNotPinned")
+	if nil != err {
+		t.Fatalf("SnapShotCreate() [not pinned] returned error: %v", err)
+	}
+
+	err = testVolumeStruct.inodeVolumeHandle.SnapShotPin(pinnedSnapShotID, true)
+	if nil != err {
+		t.Fatalf("SnapShotPin() returned error: %v", err)
+	}
+	if !isPinned(pinnedSnapShotID) || isPinned(otherSnapShotID) {
+		t.Fatalf("SnapShotListByID() reported wrong Pinned state after SnapShotPin()")
+	}
+
+	err = testVolumeStruct.inodeVolumeHandle.SnapShotPin(otherSnapShotID+100, true)
+	if nil == err {
+		t.Fatalf("SnapShotPin() of unknown SnapShotID should have failed")
+	}
+
+	// Unlinking the file leaves its LogSegment trapped by the SnapShots
+
+	err = testVolumeStruct.Unlink(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dirInodeNumber, "Trapped")
+	if nil != err {
+		t.Fatalf("Unlink() returned error: %v", err)
+	}
+	err = testVolumeStruct.headhunterVolumeHandle.DoCheckpoint()
+	if nil != err {
+		t.Fatalf("DoCheckpoint() returned error: %v", err)
+	}
+
+	// ...attributed to the older SnapShot only if it is also deletable
+
+	trappedLogSegmentRecs, err := testVolumeStruct.headhunterVolumeHandle.SnapShotTrappedLogSegmentRecs(map[uint64]struct{}{pinnedSnapShotID: {}, otherSnapShotID: {}})
+	if nil != err {
+		t.Fatalf("SnapShotTrappedLogSegmentRecs() [both deletable] returned error: %v", err)
+	}
+	if (0 != len(trappedLogSegmentRecs[pinnedSnapShotID])) || (0 == len(trappedLogSegmentRecs[otherSnapShotID])) {
+		t.Fatalf("SnapShotTrappedLogSegmentRecs() [both deletable] should have attributed the unlinked file's LogSegment to the newer SnapShot")
+	}
+
+	trappedLogSegmentRecs, err = testVolumeStruct.headhunterVolumeHandle.SnapShotTrappedLogSegmentRecs(map[uint64]struct{}{otherSnapShotID: {}})
+	if nil != err {
+		t.Fatalf("SnapShotTrappedLogSegmentRecs() [newer deletable] returned error: %v", err)
+	}
+	if (0 == len(trappedLogSegmentRecs[pinnedSnapShotID])) || (0 != len(trappedLogSegmentRecs[otherSnapShotID])) {
+		t.Fatalf("SnapShotTrappedLogSegmentRecs() [newer deletable] should have attributed the unlinked file's LogSegment to the older SnapShot")
+	}
+
+	// Pins must survive a SnapShotRollback
+
+	err = testVolumeStruct.SnapShotRollback(otherSnapShotID)
+	if nil != err {
+		t.Fatalf("SnapShotRollback() returned error: %v", err)
+	}
+	if !isPinned(pinnedSnapShotID) || isPinned(otherSnapShotID) {
+		t.Fatalf("SnapShotListByID() reported wrong Pinned state after SnapShotRollback()")
+	}
+
+	// A pinned SnapShot may still be deleted explicitly (taking its pin with it)
+
+	err = testVolumeStruct.inodeVolumeHandle.SnapShotDelete(pinnedSnapShotID)
+	if nil != err {
+		t.Fatalf("SnapShotDelete() [pinned] returned error: %v", err)
+	}
+	err = testVolumeStruct.inodeVolumeHandle.SnapShotDelete(otherSnapShotID)
+	if nil != err {
+		t.Fatalf("SnapShotDelete() [not pinned] returned error: %v", err)
+	}
+
+	reusedSnapShotID, err := testVolumeStruct.inodeVolumeHandle.SnapShotCreate("TestSnapShotReused")
+	if nil != err {
+		t.Fatalf("SnapShotCreate() [reused] returned error: %v", err)
+	}
+	if isPinned(reusedSnapShotID) {
+		t.Fatalf("SnapShotCreate() returned a pinned SnapShot")
+	}
+	err = testVolumeStruct.inodeVolumeHandle.SnapShotDelete(reusedSnapShotID)
+	if nil != err {
+		t.Fatalf("SnapShotDelete() [reused] returned error: %v", err)
+	}
+
+	testTeardown(t)
+}
+
 // Verify that the file system API works correctly with stale inode numbers,
 // as can happen if an NFS client cache gets out of sync because another NFS
 // client as removed a file or directory.
@@ -39,13 +39,15 @@ type volumeStruct struct {
 	fileDefragmentChunkDelay time.Duration
 	reportedBlockSize        uint64
 	reportedFragmentSize     uint64
-	reportedNumBlocks        uint64 // Used for Total, Free, and Avail
-	reportedNumInodes        uint64 // Used for Total, Free, and Avail
+	reportedNumBlocks        uint64            // Used for Total, Free, and Avail
+	reportedNumInodes        uint64            // Used for Total, Free, and Avail
+	flockMutex               trackedlock.Mutex // protects FLockMap as well as each of its *list.List's
 	FLockMap                 map[inode.InodeNumber]*list.List
 	inFlightFileInodeDataMap map[inode.InodeNumber]*inFlightFileInodeDataStruct
 	jobRWMutex               trackedlock.RWMutex
 	inodeVolumeHandle        inode.VolumeHandle
 	headhunterVolumeHandle   headhunter.VolumeHandle
+	stats                    *volumeStatsStruct
 }
 
 type tryLockBackoffContextStruct struct {
@@ -67,50 +69,78 @@ type globalsStruct struct {
 	inFlightFileInodeDataList *list.List
 	serializedBackoffList     *list.List
 
-	AccessUsec         bucketstats.BucketLog2Round
-	CreateUsec         bucketstats.BucketLog2Round
-	DestroyUsec        bucketstats.BucketLog2Round
-	FlushUsec          bucketstats.BucketLog2Round
-	FlockGetUsec       bucketstats.BucketLog2Round
-	FlockLockUsec      bucketstats.BucketLog2Round
-	FlockUnlockUsec    bucketstats.BucketLog2Round
-	GetstatUsec        bucketstats.BucketLog2Round
-	GetTypeUsec        bucketstats.BucketLog2Round
-	GetXAttrUsec       bucketstats.BucketLog2Round
-	IsDirUsec          bucketstats.BucketLog2Round
-	IsFileUsec         bucketstats.BucketLog2Round
-	IsSymlinkUsec      bucketstats.BucketLog2Round
-	LinkUsec           bucketstats.BucketLog2Round
-	ListXAttrUsec      bucketstats.BucketLog2Round
-	LookupUsec         bucketstats.BucketLog2Round
-	LookupPathUsec     bucketstats.BucketLog2Round
-	MkdirUsec          bucketstats.BucketLog2Round
-	MoveUsec           bucketstats.BucketLog2Round
-	RemoveXAttrUsec    bucketstats.BucketLog2Round
-	RenameUsec         bucketstats.BucketLog2Round
-	ReadUsec           bucketstats.BucketLog2Round
-	ReadBytes          bucketstats.BucketLog2Round
-	ReaddirUsec        bucketstats.BucketLog2Round
-	ReaddirEntries     bucketstats.BucketLog2Round
-	ReaddirOneUsec     bucketstats.BucketLog2Round
-	ReaddirOnePlusUsec bucketstats.BucketLog2Round
-	ReaddirPlusUsec    bucketstats.BucketLog2Round
-	ReaddirPlusBytes   bucketstats.BucketLog2Round
-	ReadsymlinkUsec    bucketstats.BucketLog2Round
-	ResizeUsec         bucketstats.BucketLog2Round
-	RmdirUsec          bucketstats.BucketLog2Round
-	SetstatUsec        bucketstats.BucketLog2Round
-	SetXAttrUsec       bucketstats.BucketLog2Round
-	StatVfsUsec        bucketstats.BucketLog2Round
-	SymlinkUsec        bucketstats.BucketLog2Round
-	UnlinkUsec         bucketstats.BucketLog2Round
-	VolumeNameUsec     bucketstats.BucketLog2Round
-	WriteUsec          bucketstats.BucketLog2Round
-	WriteBytes         bucketstats.BucketLog2Round
+	volumeStatsStruct // aggregate of the statistics of every served volume
+
+	FetchVolumeHandleUsec                   bucketstats.BucketLog2Round
+	FetchVolumeHandleErrors                 bucketstats.BucketLog2Round
+	ValidateVolumeUsec                      bucketstats.BucketLog2Round
+	ScrubVolumeUsec                         bucketstats.BucketLog2Round
+	ValidateBaseNameUsec                    bucketstats.BucketLog2Round
+	ValidateBaseNameErrors                  bucketstats.Total
+	ValidateFullPathUsec                    bucketstats.BucketLog2Round
+	ValidateFullPathErrors                  bucketstats.Total
+	AccountNameToVolumeNameUsec             bucketstats.BucketLog2Round
+	VolumeNameToActivePeerPrivateIPAddrUsec bucketstats.BucketLog2Round
+}
+
+// volumeStatsStruct holds the statistics of operations on a volume. Each served volume's
+// are registered with package bucketstats as group volumeName with a "volume" label and
+// are also accumulated in those of globals (registered, as before, as group "").
+type volumeStatsStruct struct {
+	AccessUsec             bucketstats.BucketLog2Round
+	CreateUsec             bucketstats.BucketLog2Round
+	DestroyUsec            bucketstats.BucketLog2Round
+	FallocateUsec          bucketstats.BucketLog2Round
+	FlushUsec              bucketstats.BucketLog2Round
+	FlockGetUsec           bucketstats.BucketLog2Round
+	FlockLockUsec          bucketstats.BucketLog2Round
+	FlockUnlockUsec        bucketstats.BucketLog2Round
+	GetstatUsec            bucketstats.BucketLog2Round
+	GetTypeUsec            bucketstats.BucketLog2Round
+	GetXAttrUsec           bucketstats.BucketLog2Round
+	IsDirUsec              bucketstats.BucketLog2Round
+	IsEncryptedUsec        bucketstats.BucketLog2Round
+	IsFileUsec             bucketstats.BucketLog2Round
+	IsSymlinkUsec          bucketstats.BucketLog2Round
+	LinkUsec               bucketstats.BucketLog2Round
+	ListXAttrUsec          bucketstats.BucketLog2Round
+	LookupUsec             bucketstats.BucketLog2Round
+	LookupPathUsec         bucketstats.BucketLog2Round
+	LseekUsec              bucketstats.BucketLog2Round
+	MkdirUsec              bucketstats.BucketLog2Round
+	MoveUsec               bucketstats.BucketLog2Round
+	ObjectStoreBackendUsec bucketstats.BucketLog2Round
+	RemoveXAttrUsec        bucketstats.BucketLog2Round
+	RenameUsec             bucketstats.BucketLog2Round
+	ReadUsec               bucketstats.BucketLog2Round
+	ReadBytes              bucketstats.BucketLog2Round
+	ReaddirUsec            bucketstats.BucketLog2Round
+	ReaddirEntries         bucketstats.BucketLog2Round
+	ReaddirOneUsec         bucketstats.BucketLog2Round
+	ReaddirOnePlusUsec     bucketstats.BucketLog2Round
+	ReaddirPlusUsec        bucketstats.BucketLog2Round
+	ReaddirPlusBytes       bucketstats.BucketLog2Round
+	ReadsymlinkUsec        bucketstats.BucketLog2Round
+	ResizeUsec             bucketstats.BucketLog2Round
+	RmdirUsec              bucketstats.BucketLog2Round
+	SetProjectIDUsec       bucketstats.BucketLog2Round
+	SetstatUsec            bucketstats.BucketLog2Round
+	SetXAttrUsec           bucketstats.BucketLog2Round
+	SnapShotCloneUsec      bucketstats.BucketLog2Round
+	SnapShotDiffUsec       bucketstats.BucketLog2Round
+	SnapShotRestoreUsec    bucketstats.BucketLog2Round
+	SnapShotRollbackUsec   bucketstats.BucketLog2Round
+	StatVfsUsec            bucketstats.BucketLog2Round
+	SymlinkUsec            bucketstats.BucketLog2Round
+	UnlinkUsec             bucketstats.BucketLog2Round
+	VolumeNameUsec         bucketstats.BucketLog2Round
+	WriteUsec              bucketstats.BucketLog2Round
+	WriteBytes             bucketstats.BucketLog2Round
 
 	CreateErrors              bucketstats.Total
 	DefragmentFileErrors      bucketstats.Total
 	DestroyErrors             bucketstats.Total
+	FallocateErrors           bucketstats.Total
 	FetchExtentMapChunkErrors bucketstats.Total
 	FlushErrors               bucketstats.Total
 	FlockOtherErrors          bucketstats.Total
@@ -127,6 +157,7 @@ type globalsStruct struct {
 	ListXAttrErrors           bucketstats.Total
 	LookupErrors              bucketstats.Total
 	LookupPathErrors          bucketstats.Total
+	LseekErrors               bucketstats.Total
 	MkdirErrors               bucketstats.Total
 	MoveErrors                bucketstats.Total
 	RemoveXAttrErrors         bucketstats.Total
@@ -139,8 +170,13 @@ type globalsStruct struct {
 	ReadsymlinkErrors         bucketstats.Total
 	ResizeErrors              bucketstats.Total
 	RmdirErrors               bucketstats.Total
+	SetProjectIDErrors        bucketstats.Total
 	SetstatErrors             bucketstats.Total
 	SetXAttrErrors            bucketstats.Total
+	SnapShotCloneErrors       bucketstats.Total
+	SnapShotDiffErrors        bucketstats.Total
+	SnapShotRestoreErrors     bucketstats.Total
+	SnapShotRollbackErrors    bucketstats.Total
 	StatVfsErrors             bucketstats.Total
 	SymlinkErrors             bucketstats.Total
 	UnlinkErrors              bucketstats.Total
@@ -176,17 +212,6 @@ type globalsStruct struct {
 	MiddlewarePostErrors             bucketstats.Total
 	MiddlewarePutCompleteErrors      bucketstats.Total
 	MiddlewarePutContainerErrors     bucketstats.Total
-
-	FetchVolumeHandleUsec                   bucketstats.BucketLog2Round
-	FetchVolumeHandleErrors                 bucketstats.BucketLog2Round
-	ValidateVolumeUsec                      bucketstats.BucketLog2Round
-	ScrubVolumeUsec                         bucketstats.BucketLog2Round
-	ValidateBaseNameUsec                    bucketstats.BucketLog2Round
-	ValidateBaseNameErrors                  bucketstats.Total
-	ValidateFullPathUsec                    bucketstats.BucketLog2Round
-	ValidateFullPathErrors                  bucketstats.Total
-	AccountNameToVolumeNameUsec             bucketstats.BucketLog2Round
-	VolumeNameToActivePeerPrivateIPAddrUsec bucketstats.BucketLog2Round
 }
 
 var globals globalsStruct
@@ -311,6 +336,10 @@ func (dummy *globalsStruct) ServeVolume(confMap conf.ConfMap, volumeName string)
 		return
 	}
 
+	volume.stats = &volumeStatsStruct{}
+
+	bucketstats.RegisterWithLabelsAndAggregate("proxyfs.fs", volumeName, map[string]string{"volume": volumeName}, volume.stats, &globals)
+
 	globals.volumeMap[volumeName] = volume
 
 	err = nil
@@ -332,6 +361,8 @@ func (dummy *globalsStruct) UnserveVolume(confMap conf.ConfMap, volumeName strin
 
 	volume.untrackInFlightFileInodeDataAll()
 
+	bucketstats.UnRegister("proxyfs.fs", volumeName)
+
 	delete(globals.volumeMap, volumeName)
 
 	err = nil
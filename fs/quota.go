@@ -0,0 +1,275 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package fs
+
+import (
+	"time"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/dlm"
+	"github.com/NVIDIA/proxyfs/headhunter"
+	"github.com/NVIDIA/proxyfs/inode"
+	"github.com/NVIDIA/proxyfs/logger"
+)
+
+// Project (i.e. directory tree) quotas are implemented by tagging each inode with
+// the ProjectID of the directory it was created in. The inode package charges the
+// usage of each inode against its ProjectID (if non-zero). Moving a directory
+// between projects would require re-tagging the entire subtree, so (much like
+// XFS) such Rename/Move requests are failed with EXDEV. Files (and Symlinks), on
+// the other hand, are simply re-tagged with the ProjectID of their new directory.
+// As a hard link would leave a file visible in two projects, Link requests that
+// cross a project boundary are also failed with EXDEV.
+
+const setProjectIDReadDirMaxEntries = uint64(256)
+
+// inheritProjectID tags a newly created inode with the ProjectID of the directory
+// it is being created in. Caller must hold a WriteLock on dirInodeNumber.
+//
+func (vS *volumeStruct) inheritProjectID(dirInodeNumber inode.InodeNumber, inodeNumber inode.InodeNumber) (err error) {
+	var (
+		dirMetadata *inode.MetadataStruct
+	)
+
+	dirMetadata, err = vS.inodeVolumeHandle.GetMetadata(dirInodeNumber)
+	if nil != err {
+		return
+	}
+
+	if 0 == dirMetadata.ProjectID {
+		err = nil
+		return
+	}
+
+	err = vS.inodeVolumeHandle.SetProjectID(inodeNumber, dirMetadata.ProjectID)
+
+	return
+}
+
+// fetchProjectIDs returns the ProjectIDs of dirInodeNumber and inodeNumber (along
+// with the InodeType of the latter). Caller must hold locks on both inodes.
+//
+func (vS *volumeStruct) fetchProjectIDs(dirInodeNumber inode.InodeNumber, inodeNumber inode.InodeNumber) (dirProjectID uint64, projectID uint64, inodeType inode.InodeType, err error) {
+	var (
+		dirMetadata *inode.MetadataStruct
+		metadata    *inode.MetadataStruct
+	)
+
+	dirMetadata, err = vS.inodeVolumeHandle.GetMetadata(dirInodeNumber)
+	if nil != err {
+		return
+	}
+	metadata, err = vS.inodeVolumeHandle.GetMetadata(inodeNumber)
+	if nil != err {
+		return
+	}
+
+	dirProjectID = dirMetadata.ProjectID
+	projectID = metadata.ProjectID
+	inodeType = metadata.InodeType
+
+	return
+}
+
+// adoptProjectID re-tags an existing inode being moved into dirInodeNumber with
+// the ProjectID of that directory. Caller must hold WriteLocks on both inodes. If
+// the inode was re-tagged, priorProjectID (to be restored should the move fail)
+// is returned with adopted == true.
+//
+func (vS *volumeStruct) adoptProjectID(dirInodeNumber inode.InodeNumber, inodeNumber inode.InodeNumber) (adopted bool, priorProjectID uint64, err error) {
+	var (
+		dirProjectID uint64
+		inodeType    inode.InodeType
+	)
+
+	dirProjectID, priorProjectID, inodeType, err = vS.fetchProjectIDs(dirInodeNumber, inodeNumber)
+	if nil != err {
+		return
+	}
+
+	if dirProjectID == priorProjectID {
+		adopted = false
+		err = nil
+		return
+	}
+
+	if inode.DirType == inodeType {
+		err = blunder.NewError(blunder.CrossDeviceError, "EXDEV")
+		return
+	}
+
+	err = vS.inodeVolumeHandle.SetProjectID(inodeNumber, dirProjectID)
+	if nil != err {
+		return
+	}
+
+	adopted = true
+
+	return
+}
+
+// SetProjectID tags inodeNumber (and, if it is a directory, everything beneath it)
+// with projectID. Only root may do this. Note that should the walk fail part way
+// through (e.g. due to the project's quota being exceeded), those inodes already
+// visited remain tagged with projectID.
+//
+func (vS *volumeStruct) SetProjectID(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, inodeNumber inode.InodeNumber, projectID uint64) (err error) {
+	startTime := time.Now()
+	defer func() {
+		vS.stats.SetProjectIDUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			vS.stats.SetProjectIDErrors.Add(1)
+		}
+	}()
+
+	vS.jobRWMutex.RLock()
+	defer vS.jobRWMutex.RUnlock()
+
+	if inode.InodeRootUserID != userID {
+		err = blunder.NewError(blunder.NotPermError, "EPERM")
+		return
+	}
+
+	err = vS.setProjectIDWalk(inodeNumber, projectID)
+
+	return
+}
+
+func (vS *volumeStruct) setProjectIDWalk(inodeNumber inode.InodeNumber, projectID uint64) (err error) {
+	var (
+		areMoreEntries bool
+		dirEntry       inode.DirEntry
+		dirEntrySlice  []inode.DirEntry
+		inodeLock      *dlm.RWLockStruct
+		inodeType      inode.InodeType
+		prevBasename   string
+		snapShotIDType headhunter.SnapShotIDType
+	)
+
+	inodeLock, err = vS.inodeVolumeHandle.InitInodeLock(inodeNumber, nil)
+	if nil != err {
+		return
+	}
+	err = inodeLock.WriteLock()
+	if nil != err {
+		return
+	}
+
+	inodeType, err = vS.inodeVolumeHandle.GetType(inodeNumber)
+	if nil != err {
+		_ = inodeLock.Unlock()
+		return
+	}
+
+	err = vS.inodeVolumeHandle.SetProjectID(inodeNumber, projectID)
+	if nil != err {
+		_ = inodeLock.Unlock()
+		return
+	}
+
+	err = inodeLock.Unlock()
+	if nil != err {
+		return
+	}
+
+	if inode.DirType != inodeType {
+		err = nil
+		return
+	}
+
+	prevBasename = ""
+
+	for {
+		err = inodeLock.ReadLock()
+		if nil != err {
+			return
+		}
+
+		dirEntrySlice, areMoreEntries, err = vS.inodeVolumeHandle.ReadDir(inodeNumber, setProjectIDReadDirMaxEntries, 0, prevBasename)
+
+		_ = inodeLock.Unlock()
+
+		if nil != err {
+			return
+		}
+
+		for _, dirEntry = range dirEntrySlice {
+			prevBasename = dirEntry.Basename
+
+			if ("." == dirEntry.Basename) || (".." == dirEntry.Basename) {
+				continue
+			}
+
+			snapShotIDType, _, _ = vS.headhunterVolumeHandle.SnapShotU64Decode(uint64(dirEntry.InodeNumber))
+			if headhunter.SnapShotIDTypeLive != snapShotIDType {
+				continue
+			}
+
+			err = vS.setProjectIDWalk(dirEntry.InodeNumber, projectID)
+			if nil != err {
+				logger.WarnfWithError(err, "fs.SetProjectID() failed for inode %v", dirEntry.InodeNumber)
+				return
+			}
+		}
+
+		if !areMoreEntries || (0 == len(dirEntrySlice)) {
+			err = nil
+			return
+		}
+	}
+}
+
+// applyQuotaToStatVFS clamps the block and inode counts in statVFS to those
+// remaining within quota's limits (if any).
+//
+func (vS *volumeStruct) applyQuotaToStatVFS(statVFS StatVFS, quota *inode.QuotaStruct) {
+	var (
+		freeBlocks  uint64
+		freeInodes  uint64
+		totalBlocks uint64
+		totalInodes uint64
+		usedBlocks  uint64
+	)
+
+	if 0 != quota.MaxBytes {
+		totalBlocks = quota.MaxBytes / vS.reportedFragmentSize
+		usedBlocks = (quota.Bytes + vS.reportedFragmentSize - 1) / vS.reportedFragmentSize
+
+		if totalBlocks > usedBlocks {
+			freeBlocks = totalBlocks - usedBlocks
+		} else {
+			freeBlocks = 0
+		}
+
+		if totalBlocks < statVFS[StatVFSTotalBlocks] {
+			statVFS[StatVFSTotalBlocks] = totalBlocks
+		}
+		if freeBlocks < statVFS[StatVFSFreeBlocks] {
+			statVFS[StatVFSFreeBlocks] = freeBlocks
+		}
+		if freeBlocks < statVFS[StatVFSAvailBlocks] {
+			statVFS[StatVFSAvailBlocks] = freeBlocks
+		}
+	}
+
+	if 0 != quota.MaxInodes {
+		totalInodes = quota.MaxInodes
+
+		if totalInodes > quota.Inodes {
+			freeInodes = totalInodes - quota.Inodes
+		} else {
+			freeInodes = 0
+		}
+
+		if totalInodes < statVFS[StatVFSTotalInodes] {
+			statVFS[StatVFSTotalInodes] = totalInodes
+		}
+		if freeInodes < statVFS[StatVFSFreeInodes] {
+			statVFS[StatVFSFreeInodes] = freeInodes
+		}
+		if freeInodes < statVFS[StatVFSAvailInodes] {
+			statVFS[StatVFSAvailInodes] = freeInodes
+		}
+	}
+}
@@ -718,6 +718,18 @@ RestartAfterFollowingSymlink:
 				dirEntryInodeLockAlreadyHeld = true
 				dirEntryInodeLockAlreadyShared = false
 
+				// Tag created {Dir|File}Inode with the ProjectID (if any) of its parent
+
+				internalErr = vS.inheritProjectID(dirInodeNumber, dirEntryInodeNumber)
+				if nil != internalErr {
+					err = internalErr
+					internalErr = inodeVolumeHandle.Destroy(dirEntryInodeNumber)
+					if nil != internalErr {
+						logger.Errorf("resolvePath(): failed to Destroy() created {Dir|File}Inode 0x%016X: %v", dirEntryInodeNumber, internalErr)
+					}
+					return
+				}
+
 				// Now insert created {Dir|File}Inode
 
 				internalErr = inodeVolumeHandle.Link(dirInodeNumber, pathSplitPart, dirEntryInodeNumber, false)
@@ -0,0 +1,273 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package fs
+
+import (
+	"time"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/dlm"
+	"github.com/NVIDIA/proxyfs/headhunter"
+	"github.com/NVIDIA/proxyfs/inode"
+	"github.com/NVIDIA/proxyfs/logger"
+)
+
+// A SnapShotClone is built top down: each clone (see inode.CloneInode) is linked
+// into its (already cloned) parent directory before any entries of its own are
+// cloned. File contents are not copied but rather shared, copy-on-write, with the
+// SnapShot. Hard links among the cloned files are preserved.
+//
+// Should a SnapShotClone fail part way, the partially populated clone is left in
+// place (e.g. for the caller to remove).
+
+const snapShotCloneReadDirMaxEntries = uint64(256)
+
+// SnapShotClone creates, at dstBasename in dstDirInodeNumber, a writable clone of
+// snapShotInodeNumber (e.g. as returned by a Lookup of "/.snapshot/<name>/<path>").
+// Directories are cloned recursively. As the clone retains the ownership and mode
+// of each inode in the SnapShot, only root may do so.
+//
+func (vS *volumeStruct) SnapShotClone(userID inode.InodeUserID, groupID inode.InodeGroupID, otherGroupIDs []inode.InodeGroupID, snapShotInodeNumber inode.InodeNumber, dstDirInodeNumber inode.InodeNumber, dstBasename string) (cloneInodeNumber inode.InodeNumber, err error) {
+	var (
+		cloneMap       map[inode.InodeNumber]inode.InodeNumber
+		inodeType      inode.InodeType
+		snapShotIDType headhunter.SnapShotIDType
+	)
+
+	startTime := time.Now()
+	defer func() {
+		vS.stats.SnapShotCloneUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			vS.stats.SnapShotCloneErrors.Add(1)
+		}
+	}()
+
+	vS.jobRWMutex.RLock()
+	defer vS.jobRWMutex.RUnlock()
+
+	if inode.InodeRootUserID != userID {
+		err = blunder.NewError(blunder.NotPermError, "EPERM")
+		return
+	}
+
+	err = validateBaseName(dstBasename)
+	if nil != err {
+		return
+	}
+
+	snapShotIDType, _, _ = vS.headhunterVolumeHandle.SnapShotU64Decode(uint64(snapShotInodeNumber))
+	if headhunter.SnapShotIDTypeSnapShot != snapShotIDType {
+		err = blunder.NewError(blunder.InvalidArgError, "SnapShotClone() requires an inode in a SnapShot")
+		return
+	}
+
+	cloneMap = make(map[inode.InodeNumber]inode.InodeNumber)
+
+	cloneInodeNumber, inodeType, _, err = vS.snapShotCloneInode(cloneMap, snapShotInodeNumber)
+	if nil != err {
+		return
+	}
+
+	err = vS.snapShotCloneLink(dstDirInodeNumber, dstBasename, cloneInodeNumber, true)
+	if nil != err {
+		destroyErr := vS.inodeVolumeHandle.Destroy(cloneInodeNumber)
+		if nil != destroyErr {
+			logger.WarnfWithError(destroyErr, "couldn't destroy inode %v after failed Link() in fs.SnapShotClone", cloneInodeNumber)
+		}
+		return
+	}
+
+	if inode.DirType == inodeType {
+		err = vS.snapShotCloneDir(cloneMap, snapShotInodeNumber, cloneInodeNumber)
+	}
+
+	return
+}
+
+// snapShotCloneInode returns the clone of snapShotInodeNumber. If snapShotInodeNumber
+// is a hard link to an already cloned (non-directory) inode, that clone is returned
+// (with alreadyCloned set) instead.
+//
+func (vS *volumeStruct) snapShotCloneInode(cloneMap map[inode.InodeNumber]inode.InodeNumber, snapShotInodeNumber inode.InodeNumber) (cloneInodeNumber inode.InodeNumber, inodeType inode.InodeType, alreadyCloned bool, err error) {
+	var (
+		linkCount uint64
+	)
+
+	inodeType, err = vS.inodeVolumeHandle.GetType(snapShotInodeNumber)
+	if nil != err {
+		return
+	}
+
+	if inode.DirType != inodeType {
+		cloneInodeNumber, alreadyCloned = cloneMap[snapShotInodeNumber]
+		if alreadyCloned {
+			err = nil
+			return
+		}
+	}
+
+	cloneInodeNumber, err = vS.inodeVolumeHandle.CloneInode(snapShotInodeNumber)
+	if nil != err {
+		return
+	}
+
+	if inode.DirType != inodeType {
+		linkCount, err = vS.inodeVolumeHandle.GetLinkCount(snapShotInodeNumber)
+		if nil != err {
+			return
+		}
+		if 1 < linkCount {
+			cloneMap[snapShotInodeNumber] = cloneInodeNumber
+		}
+	}
+
+	err = nil
+	return
+}
+
+// snapShotCloneLink links cloneInodeNumber into dirInodeNumber. A newly created
+// clone first inherits the ProjectID (if any) of dirInodeNumber.
+//
+func (vS *volumeStruct) snapShotCloneLink(dirInodeNumber inode.InodeNumber, basename string, cloneInodeNumber inode.InodeNumber, newlyCreated bool) (err error) {
+	var (
+		callerID       dlm.CallerID
+		cloneInodeLock *dlm.RWLockStruct
+		dirInodeLock   *dlm.RWLockStruct
+	)
+
+	callerID = dlm.GenerateCallerID()
+
+	dirInodeLock, err = vS.inodeVolumeHandle.InitInodeLock(dirInodeNumber, callerID)
+	if nil != err {
+		return
+	}
+	err = dirInodeLock.WriteLock()
+	if nil != err {
+		return
+	}
+	defer dirInodeLock.Unlock()
+
+	cloneInodeLock, err = vS.inodeVolumeHandle.InitInodeLock(cloneInodeNumber, callerID)
+	if nil != err {
+		return
+	}
+	err = cloneInodeLock.WriteLock()
+	if nil != err {
+		return
+	}
+	defer cloneInodeLock.Unlock()
+
+	if newlyCreated {
+		err = vS.inheritProjectID(dirInodeNumber, cloneInodeNumber)
+		if nil != err {
+			return
+		}
+	}
+
+	err = vS.inodeVolumeHandle.Link(dirInodeNumber, basename, cloneInodeNumber, false)
+
+	return
+}
+
+// snapShotCloneDir populates cloneDirInodeNumber with clones of the entries of
+// snapShotDirInodeNumber (recursively) and then sets its ModificationTime to
+// match that of snapShotDirInodeNumber.
+//
+func (vS *volumeStruct) snapShotCloneDir(cloneMap map[inode.InodeNumber]inode.InodeNumber, snapShotDirInodeNumber inode.InodeNumber, cloneDirInodeNumber inode.InodeNumber) (err error) {
+	var (
+		alreadyCloned       bool
+		areMoreEntries      bool
+		cloneDirInodeLock   *dlm.RWLockStruct
+		cloneInodeNumber    inode.InodeNumber
+		dirEntry            inode.DirEntry
+		dirEntrySlice       []inode.DirEntry
+		inodeType           inode.InodeType
+		prevBasename        string
+		snapShotDirLock     *dlm.RWLockStruct
+		snapShotDirMetadata *inode.MetadataStruct
+		snapShotIDType      headhunter.SnapShotIDType
+	)
+
+	snapShotDirLock, err = vS.inodeVolumeHandle.InitInodeLock(snapShotDirInodeNumber, nil)
+	if nil != err {
+		return
+	}
+
+	prevBasename = ""
+
+	for {
+		err = snapShotDirLock.ReadLock()
+		if nil != err {
+			return
+		}
+
+		dirEntrySlice, areMoreEntries, err = vS.inodeVolumeHandle.ReadDir(snapShotDirInodeNumber, snapShotCloneReadDirMaxEntries, 0, prevBasename)
+
+		_ = snapShotDirLock.Unlock()
+
+		if nil != err {
+			return
+		}
+
+		for _, dirEntry = range dirEntrySlice {
+			prevBasename = dirEntry.Basename
+
+			if ("." == dirEntry.Basename) || (".." == dirEntry.Basename) {
+				continue
+			}
+
+			snapShotIDType, _, _ = vS.headhunterVolumeHandle.SnapShotU64Decode(uint64(dirEntry.InodeNumber))
+			if headhunter.SnapShotIDTypeDotSnapShot == snapShotIDType {
+				continue
+			}
+
+			cloneInodeNumber, inodeType, alreadyCloned, err = vS.snapShotCloneInode(cloneMap, dirEntry.InodeNumber)
+			if nil != err {
+				return
+			}
+
+			err = vS.snapShotCloneLink(cloneDirInodeNumber, dirEntry.Basename, cloneInodeNumber, !alreadyCloned)
+			if nil != err {
+				if !alreadyCloned {
+					destroyErr := vS.inodeVolumeHandle.Destroy(cloneInodeNumber)
+					if nil != destroyErr {
+						logger.WarnfWithError(destroyErr, "couldn't destroy inode %v after failed Link() in fs.SnapShotClone", cloneInodeNumber)
+					}
+				}
+				return
+			}
+
+			if inode.DirType == inodeType {
+				err = vS.snapShotCloneDir(cloneMap, dirEntry.InodeNumber, cloneInodeNumber)
+				if nil != err {
+					return
+				}
+			}
+		}
+
+		if !areMoreEntries || (0 == len(dirEntrySlice)) {
+			break
+		}
+	}
+
+	snapShotDirMetadata, err = vS.inodeVolumeHandle.GetMetadata(snapShotDirInodeNumber)
+	if nil != err {
+		return
+	}
+
+	cloneDirInodeLock, err = vS.inodeVolumeHandle.InitInodeLock(cloneDirInodeNumber, nil)
+	if nil != err {
+		return
+	}
+	err = cloneDirInodeLock.WriteLock()
+	if nil != err {
+		return
+	}
+
+	err = vS.inodeVolumeHandle.SetModificationTime(cloneDirInodeNumber, snapShotDirMetadata.ModificationTime)
+
+	_ = cloneDirInodeLock.Unlock()
+
+	return
+}
@@ -0,0 +1,789 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package fs
+
+import (
+	"sort"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/dlm"
+	"github.com/NVIDIA/proxyfs/headhunter"
+	"github.com/NVIDIA/proxyfs/inode"
+)
+
+// A SnapShotDiff is computed in three steps. First, headhunter compares the
+// inodeRec B+Trees of the two views yielding the InodeNumbers created, deleted,
+// or modified. Any change to a link (e.g. Create, Unlink, Link, or Rename)
+// modifies the directory holding it, so reading just the changed directories (in
+// each view) finds every link that differs (including those of renamed inodes
+// that are otherwise untouched). Second, paths are formed from those links by
+// following ".." (the only reverse link an inode records) up to the root, reading
+// only enough of each parent directory to find the child's basename. Each entry
+// is reported as soon as it is resolved, so cost scales with the change set.
+//
+// The exception is a file or symlink modified in place in an unchanged directory.
+// While a directory's path follows from "..", a file does not record the
+// directory (or directories) referencing it. So, third, should any such inodes
+// remain, the namespace of the "to" view is walked until the last of them is
+// found.
+//
+// An inode present in both views whose links differ is reported as renamed if a
+// link was both removed and added (e.g. by Rename) or as modified if links were
+// only added or removed (e.g. by Link or Unlink of one of several hard links).
+// A renamed directory is reported once... the entries beneath it, though now at
+// different paths, are not. As with zfs diff, a directory whose entries changed
+// is itself reported as modified.
+//
+// Note that diffing against the live view (SnapShotID 0) reads a namespace that
+// may be changing concurrently. Take a SnapShot first when that matters.
+
+const snapShotDiffReadDirMaxEntries = uint64(256)
+
+type snapShotDiffLinkStruct struct {
+	dirInodeNumber uint64 // un-adorned
+	basename       string
+}
+
+var snapShotDiffRootLink = &snapShotDiffLinkStruct{dirInodeNumber: 0, basename: ""}
+
+type snapShotDiffViewStruct struct {
+	snapShotID uint64
+	linkMap    map[uint64][]*snapShotDiffLinkStruct // key == un-adorned InodeNumber; links found in changed directories
+	dirLinkMap map[uint64]*snapShotDiffLinkStruct   // key == un-adorned InodeNumber; links of other directories found via ".."
+	dirPathMap map[uint64]string                    // key == un-adorned InodeNumber; "" if not reachable
+}
+
+type snapShotDiffStruct struct {
+	vS           *volumeStruct
+	fromView     *snapShotDiffViewStruct
+	toView       *snapShotDiffViewStruct
+	changedMap   map[uint64]struct{}        // key == un-adorned InodeNumber of created, deleted, or modified inodes
+	createdMap   map[uint64]struct{}        // key == un-adorned InodeNumber
+	deletedMap   map[uint64]struct{}        // key == un-adorned InodeNumber
+	inodeTypeMap map[uint64]inode.InodeType // key == un-adorned InodeNumber
+	callback     SnapShotDiffCallback
+}
+
+// SnapShotDiff reports (via callback) each inode created, deleted, modified, or
+// renamed between fromSnapShotID and toSnapShotID (either of which may be 0 to
+// specify the live view). Entries are reported as they are resolved... in
+// InodeNumber order save for those inodes modified in place whose paths must be
+// searched for (see above). Should callback return an error, SnapShotDiff stops
+// and returns it.
+//
+func (vS *volumeStruct) SnapShotDiff(fromSnapShotID uint64, toSnapShotID uint64, callback SnapShotDiffCallback) (err error) {
+	var (
+		created      []uint64
+		deleted      []uint64
+		diff         *snapShotDiffStruct
+		entry        *SnapShotDiffEntryStruct
+		inodeNumber  uint64
+		inodeNumbers []uint64
+		inodeType    inode.InodeType
+		modified     []uint64
+		ok           bool
+		pendingMap   map[uint64]struct{}
+		seenMap      map[uint64]struct{}
+	)
+
+	startTime := time.Now()
+	defer func() {
+		vS.stats.SnapShotDiffUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			vS.stats.SnapShotDiffErrors.Add(1)
+		}
+	}()
+
+	vS.jobRWMutex.RLock()
+	defer vS.jobRWMutex.RUnlock()
+
+	err = vS.snapShotDiffCheckID(fromSnapShotID)
+	if nil != err {
+		return
+	}
+	err = vS.snapShotDiffCheckID(toSnapShotID)
+	if nil != err {
+		return
+	}
+
+	created, deleted, modified, err = vS.headhunterVolumeHandle.SnapShotInodeRecDiff(fromSnapShotID, toSnapShotID)
+	if nil != err {
+		err = blunder.AddError(err, blunder.IOError)
+		return
+	}
+
+	if (0 == len(created)) && (0 == len(deleted)) && (0 == len(modified)) {
+		err = nil
+		return
+	}
+
+	diff = &snapShotDiffStruct{
+		vS:           vS,
+		fromView:     newSnapShotDiffView(fromSnapShotID),
+		toView:       newSnapShotDiffView(toSnapShotID),
+		changedMap:   make(map[uint64]struct{}),
+		createdMap:   make(map[uint64]struct{}),
+		deletedMap:   make(map[uint64]struct{}),
+		inodeTypeMap: make(map[uint64]inode.InodeType),
+		callback:     callback,
+	}
+
+	for _, inodeNumber = range created {
+		diff.changedMap[inodeNumber] = struct{}{}
+		diff.createdMap[inodeNumber] = struct{}{}
+	}
+	for _, inodeNumber = range deleted {
+		diff.changedMap[inodeNumber] = struct{}{}
+		diff.deletedMap[inodeNumber] = struct{}{}
+	}
+	for _, inodeNumber = range modified {
+		diff.changedMap[inodeNumber] = struct{}{}
+	}
+
+	// Read the entries of each changed directory in each view it is present in
+
+	inodeNumbers = make([]uint64, 0, len(diff.changedMap))
+
+	for inodeNumber = range diff.changedMap {
+		inodeNumbers = append(inodeNumbers, inodeNumber)
+	}
+
+	sort.Slice(inodeNumbers, func(i int, j int) bool { return inodeNumbers[i] < inodeNumbers[j] })
+
+	for _, inodeNumber = range inodeNumbers {
+		inodeType, err = diff.inodeType(inodeNumber)
+		if nil != err {
+			return
+		}
+		if inode.DirType != inodeType {
+			continue
+		}
+
+		_, ok = diff.createdMap[inodeNumber]
+		if !ok {
+			err = diff.recordDirLinks(diff.fromView, inodeNumber)
+			if nil != err {
+				return
+			}
+		}
+		_, ok = diff.deletedMap[inodeNumber]
+		if !ok {
+			err = diff.recordDirLinks(diff.toView, inodeNumber)
+			if nil != err {
+				return
+			}
+		}
+	}
+
+	// Every inode of interest is either in changedMap or was found in a changed directory
+
+	seenMap = make(map[uint64]struct{})
+
+	for _, inodeNumber = range inodeNumbers {
+		seenMap[inodeNumber] = struct{}{}
+	}
+	for _, view := range []*snapShotDiffViewStruct{diff.fromView, diff.toView} {
+		for inodeNumber = range view.linkMap {
+			_, ok = seenMap[inodeNumber]
+			if !ok {
+				seenMap[inodeNumber] = struct{}{}
+				inodeNumbers = append(inodeNumbers, inodeNumber)
+			}
+		}
+	}
+
+	sort.Slice(inodeNumbers, func(i int, j int) bool { return inodeNumbers[i] < inodeNumbers[j] })
+
+	pendingMap = make(map[uint64]struct{})
+
+	for _, inodeNumber = range inodeNumbers {
+		entry, ok, err = diff.resolve(inodeNumber)
+		if nil != err {
+			return
+		}
+		if !ok {
+			pendingMap[inodeNumber] = struct{}{}
+			continue
+		}
+
+		if nil != entry {
+			err = callback(entry)
+			if nil != err {
+				return
+			}
+		}
+	}
+
+	if 0 == len(pendingMap) {
+		err = nil
+		return
+	}
+
+	// Finally, search the "to" view for those inodes modified in place
+
+	_, err = diff.search(pendingMap, uint64(inode.RootDirInodeNumber), "")
+	if nil != err {
+		return
+	}
+
+	for _, inodeNumber = range inodeNumbers {
+		_, ok = pendingMap[inodeNumber]
+		if ok {
+			// Not reachable in the "to" view's namespace
+
+			err = diff.report(SnapShotDiffModified, inodeNumber, "", "")
+			if nil != err {
+				return
+			}
+		}
+	}
+
+	err = nil
+	return
+}
+
+func newSnapShotDiffView(snapShotID uint64) (view *snapShotDiffViewStruct) {
+	view = &snapShotDiffViewStruct{
+		snapShotID: snapShotID,
+		linkMap:    make(map[uint64][]*snapShotDiffLinkStruct),
+		dirLinkMap: make(map[uint64]*snapShotDiffLinkStruct),
+		dirPathMap: make(map[uint64]string),
+	}
+
+	view.dirPathMap[uint64(inode.RootDirInodeNumber)] = "/"
+
+	return
+}
+
+func (vS *volumeStruct) snapShotDiffCheckID(snapShotID uint64) (err error) {
+	var (
+		snapShot headhunter.SnapShotStruct
+	)
+
+	if 0 == snapShotID {
+		err = nil
+		return
+	}
+
+	for _, snapShot = range vS.headhunterVolumeHandle.SnapShotListByID(false) {
+		if snapShotID == snapShot.ID {
+			err = nil
+			return
+		}
+	}
+
+	err = blunder.NewError(blunder.NotFoundError, "SnapShotID %v not found in volume %v", snapShotID, vS.volumeName)
+	return
+}
+
+// inodeType returns the InodeType of inodeNumber (which is the same in any view
+// it is present in).
+//
+func (diff *snapShotDiffStruct) inodeType(inodeNumber uint64) (inodeType inode.InodeType, err error) {
+	var (
+		ok   bool
+		view *snapShotDiffViewStruct
+	)
+
+	inodeType, ok = diff.inodeTypeMap[inodeNumber]
+	if ok {
+		err = nil
+		return
+	}
+
+	_, ok = diff.createdMap[inodeNumber]
+	if ok {
+		view = diff.toView
+	} else {
+		view = diff.fromView
+	}
+
+	inodeType, err = diff.vS.inodeVolumeHandle.GetType(diff.adorn(view, inodeNumber))
+	if nil != err {
+		return
+	}
+
+	diff.inodeTypeMap[inodeNumber] = inodeType
+
+	return
+}
+
+func (diff *snapShotDiffStruct) adorn(view *snapShotDiffViewStruct, inodeNumber uint64) (adornedInodeNumber inode.InodeNumber) {
+	adornedInodeNumber = inode.InodeNumber(diff.vS.headhunterVolumeHandle.SnapShotIDAndNonceEncode(view.snapShotID, inodeNumber))
+	return
+}
+
+// readDir invokes entryFunc for each entry (other than ".", "..", and ".snapshot")
+// of directory dirInodeNumber as found in view until entryFunc returns done == true.
+//
+func (diff *snapShotDiffStruct) readDir(view *snapShotDiffViewStruct, dirInodeNumber uint64, entryFunc func(basename string, entryInodeNumber uint64) (done bool, err error)) (done bool, err error) {
+	var (
+		adornedDirInodeNumber inode.InodeNumber
+		areMoreEntries        bool
+		dirEntry              inode.DirEntry
+		dirEntrySlice         []inode.DirEntry
+		entryInodeNumber      uint64
+		inodeLock             *dlm.RWLockStruct
+		prevBasename          string
+		snapShotIDType        headhunter.SnapShotIDType
+	)
+
+	adornedDirInodeNumber = diff.adorn(view, dirInodeNumber)
+
+	inodeLock, err = diff.vS.inodeVolumeHandle.InitInodeLock(adornedDirInodeNumber, nil)
+	if nil != err {
+		return
+	}
+
+	prevBasename = ""
+
+	for {
+		err = inodeLock.ReadLock()
+		if nil != err {
+			return
+		}
+
+		dirEntrySlice, areMoreEntries, err = diff.vS.inodeVolumeHandle.ReadDir(adornedDirInodeNumber, snapShotDiffReadDirMaxEntries, 0, prevBasename)
+
+		_ = inodeLock.Unlock()
+
+		if nil != err {
+			return
+		}
+
+		for _, dirEntry = range dirEntrySlice {
+			prevBasename = dirEntry.Basename
+
+			if ("." == dirEntry.Basename) || (".." == dirEntry.Basename) {
+				continue
+			}
+
+			snapShotIDType, _, entryInodeNumber = diff.vS.headhunterVolumeHandle.SnapShotU64Decode(uint64(dirEntry.InodeNumber))
+			if headhunter.SnapShotIDTypeDotSnapShot == snapShotIDType {
+				continue
+			}
+
+			done, err = entryFunc(dirEntry.Basename, entryInodeNumber)
+			if (nil != err) || done {
+				return
+			}
+		}
+
+		if !areMoreEntries || (0 == len(dirEntrySlice)) {
+			done = false
+			err = nil
+			return
+		}
+	}
+}
+
+// recordDirLinks records, in view, the link of each entry of (changed) directory
+// dirInodeNumber.
+//
+func (diff *snapShotDiffStruct) recordDirLinks(view *snapShotDiffViewStruct, dirInodeNumber uint64) (err error) {
+	_, err = diff.readDir(view, dirInodeNumber, func(basename string, entryInodeNumber uint64) (done bool, err error) {
+		view.linkMap[entryInodeNumber] = append(view.linkMap[entryInodeNumber], &snapShotDiffLinkStruct{
+			dirInodeNumber: dirInodeNumber,
+			basename:       basename,
+		})
+		done = false
+		err = nil
+		return
+	})
+
+	return
+}
+
+// dirLink returns the (sole) link to directory dirInodeNumber in view (or nil if
+// it is not reachable). If its parent did not change, the parent is found via
+// ".." and read only until the link is found.
+//
+func (diff *snapShotDiffStruct) dirLink(view *snapShotDiffViewStruct, dirInodeNumber uint64) (link *snapShotDiffLinkStruct, err error) {
+	var (
+		adornedDirInodeNumber    inode.InodeNumber
+		adornedParentInodeNumber inode.InodeNumber
+		inodeLock                *dlm.RWLockStruct
+		links                    []*snapShotDiffLinkStruct
+		ok                       bool
+		parentInodeNumber        uint64
+	)
+
+	if uint64(inode.RootDirInodeNumber) == dirInodeNumber {
+		link = snapShotDiffRootLink
+		err = nil
+		return
+	}
+
+	links = view.linkMap[dirInodeNumber]
+	if 0 < len(links) {
+		link = links[0]
+		err = nil
+		return
+	}
+
+	link, ok = view.dirLinkMap[dirInodeNumber]
+	if ok {
+		err = nil
+		return
+	}
+
+	adornedDirInodeNumber = diff.adorn(view, dirInodeNumber)
+
+	inodeLock, err = diff.vS.inodeVolumeHandle.InitInodeLock(adornedDirInodeNumber, nil)
+	if nil != err {
+		return
+	}
+	err = inodeLock.ReadLock()
+	if nil != err {
+		return
+	}
+
+	adornedParentInodeNumber, err = diff.vS.inodeVolumeHandle.Lookup(adornedDirInodeNumber, "..")
+
+	_ = inodeLock.Unlock()
+
+	if nil != err {
+		return
+	}
+
+	_, _, parentInodeNumber = diff.vS.headhunterVolumeHandle.SnapShotU64Decode(uint64(adornedParentInodeNumber))
+
+	link = nil
+
+	_, err = diff.readDir(view, parentInodeNumber, func(basename string, entryInodeNumber uint64) (done bool, err error) {
+		if dirInodeNumber == entryInodeNumber {
+			link = &snapShotDiffLinkStruct{
+				dirInodeNumber: parentInodeNumber,
+				basename:       basename,
+			}
+			done = true
+		} else {
+			done = false
+		}
+		err = nil
+		return
+	})
+	if nil != err {
+		return
+	}
+
+	view.dirLinkMap[dirInodeNumber] = link
+
+	return
+}
+
+// dirPath returns the path of directory dirInodeNumber in view (or "" if it is
+// not reachable).
+//
+func (diff *snapShotDiffStruct) dirPath(view *snapShotDiffViewStruct, dirInodeNumber uint64) (path string, err error) {
+	var (
+		link *snapShotDiffLinkStruct
+		ok   bool
+	)
+
+	path, ok = view.dirPathMap[dirInodeNumber]
+	if ok {
+		err = nil
+		return
+	}
+
+	link, err = diff.dirLink(view, dirInodeNumber)
+	if nil != err {
+		return
+	}
+
+	path, err = diff.linkPath(view, link)
+	if nil != err {
+		return
+	}
+
+	view.dirPathMap[dirInodeNumber] = path
+
+	return
+}
+
+// linkPath returns the path of link in view (or "" if it is not reachable).
+//
+func (diff *snapShotDiffStruct) linkPath(view *snapShotDiffViewStruct, link *snapShotDiffLinkStruct) (path string, err error) {
+	var (
+		dirPath string
+	)
+
+	if nil == link {
+		path = ""
+		err = nil
+		return
+	}
+	if snapShotDiffRootLink == link {
+		path = "/"
+		err = nil
+		return
+	}
+
+	dirPath, err = diff.dirPath(view, link.dirInodeNumber)
+	if nil != err {
+		return
+	}
+
+	switch dirPath {
+	case "":
+		path = ""
+	case "/":
+		path = "/" + link.basename
+	default:
+		path = dirPath + "/" + link.basename
+	}
+
+	return
+}
+
+// links returns the links to inodeNumber in view known without a search.
+//
+func (diff *snapShotDiffStruct) links(view *snapShotDiffViewStruct, inodeNumber uint64) (links []*snapShotDiffLinkStruct, err error) {
+	var (
+		inodeType inode.InodeType
+		link      *snapShotDiffLinkStruct
+	)
+
+	inodeType, err = diff.inodeType(inodeNumber)
+	if nil != err {
+		return
+	}
+
+	if inode.DirType != inodeType {
+		links = view.linkMap[inodeNumber]
+		return
+	}
+
+	link, err = diff.dirLink(view, inodeNumber)
+	if (nil != err) || (nil == link) {
+		return
+	}
+
+	links = []*snapShotDiffLinkStruct{link}
+
+	return
+}
+
+// resolve returns the entry (if any) for inodeNumber. Should that require a
+// search for the inode's path, ok == false is returned instead.
+//
+func (diff *snapShotDiffStruct) resolve(inodeNumber uint64) (entry *SnapShotDiffEntryStruct, ok bool, err error) {
+	var (
+		addedLink   *snapShotDiffLinkStruct
+		found       bool
+		fromLinks   []*snapShotDiffLinkStruct
+		fromPath    string
+		modified    bool
+		path        string
+		removedLink *snapShotDiffLinkStruct
+		toLinks     []*snapShotDiffLinkStruct
+	)
+
+	ok = true
+
+	_, found = diff.createdMap[inodeNumber]
+	if found {
+		toLinks, err = diff.links(diff.toView, inodeNumber)
+		if nil != err {
+			return
+		}
+		path, err = diff.firstPath(diff.toView, toLinks)
+		if nil != err {
+			return
+		}
+		entry, err = diff.entry(SnapShotDiffCreated, inodeNumber, path, "")
+		return
+	}
+
+	_, found = diff.deletedMap[inodeNumber]
+	if found {
+		fromLinks, err = diff.links(diff.fromView, inodeNumber)
+		if nil != err {
+			return
+		}
+		path, err = diff.firstPath(diff.fromView, fromLinks)
+		if nil != err {
+			return
+		}
+		entry, err = diff.entry(SnapShotDiffDeleted, inodeNumber, path, "")
+		return
+	}
+
+	fromLinks, err = diff.links(diff.fromView, inodeNumber)
+	if nil != err {
+		return
+	}
+	toLinks, err = diff.links(diff.toView, inodeNumber)
+	if nil != err {
+		return
+	}
+
+	removedLink = snapShotDiffFirstMissingLink(fromLinks, toLinks)
+	addedLink = snapShotDiffFirstMissingLink(toLinks, fromLinks)
+
+	if (nil != removedLink) && (nil != addedLink) {
+		path, err = diff.linkPath(diff.toView, addedLink)
+		if nil != err {
+			return
+		}
+		fromPath, err = diff.linkPath(diff.fromView, removedLink)
+		if nil != err {
+			return
+		}
+		entry, err = diff.entry(SnapShotDiffRenamed, inodeNumber, path, fromPath)
+		return
+	}
+
+	_, modified = diff.changedMap[inodeNumber]
+
+	if !modified && (nil == removedLink) && (nil == addedLink) {
+		entry = nil
+		err = nil
+		return
+	}
+
+	if 0 == len(toLinks) {
+		// Modified in place (links unchanged) in an unchanged directory
+
+		entry = nil
+		ok = false
+		err = nil
+		return
+	}
+
+	path, err = diff.firstPath(diff.toView, toLinks)
+	if nil != err {
+		return
+	}
+	entry, err = diff.entry(SnapShotDiffModified, inodeNumber, path, "")
+
+	return
+}
+
+func (diff *snapShotDiffStruct) firstPath(view *snapShotDiffViewStruct, links []*snapShotDiffLinkStruct) (path string, err error) {
+	if 0 == len(links) {
+		path = "" // Inode not reachable in view's namespace
+		err = nil
+		return
+	}
+
+	path, err = diff.linkPath(view, links[0])
+
+	return
+}
+
+// search walks the "to" view from directory dirInodeNumber (at dirPath) reporting
+// (and removing from pendingMap) each inode in pendingMap found along the way.
+// It returns done == true once pendingMap is empty.
+//
+func (diff *snapShotDiffStruct) search(pendingMap map[uint64]struct{}, dirInodeNumber uint64, dirPath string) (done bool, err error) {
+	done, err = diff.readDir(diff.toView, dirInodeNumber, func(basename string, entryInodeNumber uint64) (done bool, err error) {
+		var (
+			entryPath string
+			inodeType inode.InodeType
+			ok        bool
+		)
+
+		entryPath = dirPath + "/" + basename
+
+		_, ok = pendingMap[entryInodeNumber]
+		if ok {
+			delete(pendingMap, entryInodeNumber)
+
+			err = diff.report(SnapShotDiffModified, entryInodeNumber, entryPath, "")
+			if nil != err {
+				return
+			}
+
+			if 0 == len(pendingMap) {
+				done = true
+				return
+			}
+		}
+
+		inodeType, err = diff.vS.inodeVolumeHandle.GetType(diff.adorn(diff.toView, entryInodeNumber))
+		if nil != err {
+			return
+		}
+
+		if inode.DirType == inodeType {
+			done, err = diff.search(pendingMap, entryInodeNumber, entryPath)
+			return
+		}
+
+		done = false
+		err = nil
+		return
+	})
+
+	return
+}
+
+// entry returns an entry of diffType for inodeNumber.
+//
+func (diff *snapShotDiffStruct) entry(diffType SnapShotDiffType, inodeNumber uint64, path string, fromPath string) (entry *SnapShotDiffEntryStruct, err error) {
+	var (
+		inodeType inode.InodeType
+	)
+
+	inodeType, err = diff.inodeType(inodeNumber)
+	if nil != err {
+		return
+	}
+
+	entry = &SnapShotDiffEntryStruct{
+		Type:        diffType,
+		InodeNumber: inodeNumber,
+		InodeType:   inodeType,
+		Path:        path,
+		FromPath:    fromPath,
+	}
+
+	err = nil
+	return
+}
+
+func (diff *snapShotDiffStruct) report(diffType SnapShotDiffType, inodeNumber uint64, path string, fromPath string) (err error) {
+	var (
+		entry *SnapShotDiffEntryStruct
+	)
+
+	entry, err = diff.entry(diffType, inodeNumber, path, fromPath)
+	if nil != err {
+		return
+	}
+
+	err = diff.callback(entry)
+
+	return
+}
+
+// snapShotDiffFirstMissingLink returns the first of links not also in otherLinks.
+//
+func snapShotDiffFirstMissingLink(links []*snapShotDiffLinkStruct, otherLinks []*snapShotDiffLinkStruct) (missingLink *snapShotDiffLinkStruct) {
+	var (
+		found     bool
+		link      *snapShotDiffLinkStruct
+		otherLink *snapShotDiffLinkStruct
+	)
+
+	for _, link = range links {
+		found = false
+		for _, otherLink = range otherLinks {
+			if (link.dirInodeNumber == otherLink.dirInodeNumber) && (link.basename == otherLink.basename) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missingLink = link
+			return
+		}
+	}
+
+	missingLink = nil
+	return
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package fs
+
+import (
+	"path"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/headhunter"
+	"github.com/NVIDIA/proxyfs/inode"
+)
+
+// A SnapShot may be used to recover either the entire volume or a single subtree.
+// SnapShotRollback discards every change made to the live view since the SnapShot
+// was taken (see headhunter's SnapShotRollbackByInodeLayer). SnapShotRestore instead
+// recreates a single (e.g. accidentally removed) file or directory subtree from the
+// SnapShot as a SnapShotClone of it placed at its original path. In both cases, file
+// contents are not copied but rather the SnapShot's LogSegments are reused.
+
+// snapShotLookupByID returns the SnapShot identified by snapShotID.
+//
+func (vS *volumeStruct) snapShotLookupByID(snapShotID uint64) (snapShot headhunter.SnapShotStruct, err error) {
+	for _, snapShot = range vS.headhunterVolumeHandle.SnapShotListByID(false) {
+		if snapShotID == snapShot.ID {
+			err = nil
+			return
+		}
+	}
+
+	err = blunder.NewError(blunder.NotFoundError, "SnapShotID %v not found in volume %v", snapShotID, vS.volumeName)
+	return
+}
+
+// SnapShotRestore recreates, at fullpath in the live view, the file or directory
+// subtree found at fullpath in the SnapShot identified by snapShotID. The parent
+// directory of fullpath must exist in the live view while fullpath itself must not.
+//
+func (vS *volumeStruct) SnapShotRestore(snapShotID uint64, fullpath string) (restoredInodeNumber inode.InodeNumber, err error) {
+	var (
+		dstBasename         string
+		dstDirInodeNumber   inode.InodeNumber
+		dstDirPath          string
+		snapShot            headhunter.SnapShotStruct
+		snapShotInodeNumber inode.InodeNumber
+	)
+
+	startTime := time.Now()
+	defer func() {
+		vS.stats.SnapShotRestoreUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			vS.stats.SnapShotRestoreErrors.Add(1)
+		}
+	}()
+
+	snapShot, err = vS.snapShotLookupByID(snapShotID)
+	if nil != err {
+		return
+	}
+
+	fullpath = path.Clean("/" + fullpath)
+	if "/" == fullpath {
+		err = blunder.NewError(blunder.InvalidArgError, "SnapShotRestore() cannot restore \"/\"... use SnapShotRollback() instead")
+		return
+	}
+
+	dstDirPath, dstBasename = path.Split(fullpath)
+
+	snapShotInodeNumber, err = vS.LookupPath(inode.InodeRootUserID, inode.InodeGroupID(0), nil, path.Join("/", inode.SnapShotDirName, snapShot.Name, fullpath))
+	if nil != err {
+		return
+	}
+
+	dstDirInodeNumber, err = vS.LookupPath(inode.InodeRootUserID, inode.InodeGroupID(0), nil, dstDirPath)
+	if nil != err {
+		return
+	}
+
+	restoredInodeNumber, err = vS.SnapShotClone(inode.InodeRootUserID, inode.InodeGroupID(0), nil, snapShotInodeNumber, dstDirInodeNumber, dstBasename)
+
+	return
+}
+
+// SnapShotRollback rolls the entire live view back to the SnapShot identified by
+// snapShotID. All other activity on the volume is quiesced for the duration.
+//
+// Note that clients caching state under a Lease are not consulted here. Callers
+// should instead use jrpcfs.SnapShotRollback() which first ensures there are none.
+//
+func (vS *volumeStruct) SnapShotRollback(snapShotID uint64) (err error) {
+	startTime := time.Now()
+	defer func() {
+		vS.stats.SnapShotRollbackUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			vS.stats.SnapShotRollbackErrors.Add(1)
+		}
+	}()
+
+	vS.jobRWMutex.Lock()
+	defer vS.jobRWMutex.Unlock()
+
+	_, err = vS.snapShotLookupByID(snapShotID)
+	if nil != err {
+		return
+	}
+
+	// Flush all File Inodes currently in flight
+
+	vS.untrackInFlightFileInodeDataAll()
+
+	err = vS.inodeVolumeHandle.SnapShotRollback(snapShotID)
+
+	return
+}
@@ -1244,8 +1244,11 @@ func (sVS *scrubVolumeStruct) Info() (info []string) {
 
 func (sVS *scrubVolumeStruct) scrubVolumeInode(inodeNumber uint64) {
 	var (
-		err       error
-		inodeLock *dlm.RWLockStruct
+		corruptExtent  inode.CorruptExtentStruct
+		corruptExtents []inode.CorruptExtentStruct
+		err            error
+		inodeLock      *dlm.RWLockStruct
+		inodeType      inode.InodeType
 	)
 
 	defer sVS.childrenWaitGroup.Done()
@@ -1276,6 +1279,30 @@ func (sVS *scrubVolumeStruct) scrubVolumeInode(inodeNumber uint64) {
 		// Note: We could identify removal of an inode as an error here, but we don't
 		//       know if it is actually referenced. A subsequent validateVolume() call
 		//       would catch that condition.
+
+		return
+	}
+
+	inodeType, err = sVS.inodeVolumeHandle.GetType(inode.InodeNumber(inodeNumber))
+	if nil != err {
+		sVS.jobLogErr("Got inode.GetType(0x%016X) failure: %v", inodeNumber, err)
+		return
+	}
+
+	if inode.FileType != inodeType {
+		return
+	}
+
+	// Re-read every byte of file data verifying it against its LogSegment checksums (if any)
+
+	corruptExtents, err = sVS.inodeVolumeHandle.VerifyFileData(inode.InodeNumber(inodeNumber))
+	if nil != err {
+		sVS.jobLogErr("Got inode.VerifyFileData(0x%016X) failure: %v", inodeNumber, err)
+		return
+	}
+
+	for _, corruptExtent = range corruptExtents {
+		sVS.jobLogErr("Inode# 0x%016X has corrupt data at FileOffset 0x%016X Length 0x%016X (LogSegment# 0x%016X Offset 0x%016X)", inodeNumber, corruptExtent.FileOffset, corruptExtent.Length, corruptExtent.LogSegmentNumber, corruptExtent.LogSegmentOffset)
 	}
 }
 
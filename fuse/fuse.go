@@ -35,7 +35,7 @@ func (pfs *ProxyFUSE) Statfs(ctx context.Context, req *fuselib.StatfsRequest, re
 	enterGate()
 	defer leaveGate()
 
-	statvfs, err := pfs.volumeHandle.StatVfs()
+	statvfs, err := pfs.volumeHandle.StatVfs(inode.InodeUserID(req.Header.Uid), inode.InodeGroupID(req.Header.Gid), nil, inode.RootDirInodeNumber)
 	if err != nil {
 		return newFuseError(err)
 	}
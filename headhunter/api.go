@@ -31,9 +31,28 @@ const (
 )
 
 type SnapShotStruct struct {
-	ID   uint64
-	Time time.Time
-	Name string
+	ID     uint64
+	Time   time.Time
+	Name   string
+	Pinned bool // if true, never deleted by a SnapShotPolicy
+}
+
+// ReplicationStatusStruct reports the progress of replicating a volume's checkpoints
+// (see [Volume]ReplicationAccountName). Lag is the age of the oldest checkpoint not
+// yet fully replicated (zero if all have been).
+type ReplicationStatusStruct struct {
+	Enabled                    bool
+	ReplicaAccountURL          string
+	ReplicatedCheckpointHeader string    // the CheckpointHeaderName value last POST'd to the replica
+	ReplicatedCheckpointTime   time.Time // when that checkpoint completed
+	Lag                        time.Duration
+	BacklogCheckpoints         uint64
+	BacklogObjectCopies        uint64
+	BacklogObjectDeletes       uint64
+	CopiedObjects              uint64
+	CopiedBytes                uint64
+	DeletedObjects             uint64
+	LastError                  string // "" if the most recent attempt to replicate succeeded
 }
 
 type VolumeEventListener interface {
@@ -46,30 +65,45 @@ type VolumeHandle interface {
 	UnregisterForEvents(listener VolumeEventListener)
 	FetchAccountAndCheckpointContainerNames() (accountName string, checkpointContainerName string)
 	FetchNonce() (nonce uint64)
+	IsEncrypted() (encrypted bool)
+	Seal(objectNumber uint64, objectOffset uint64, buf []byte) (sealedBuf []byte, err error) // only if IsEncrypted()
+	Unseal(objectNumber uint64, objectOffset uint64, sealedBuf []byte) (buf []byte, err error)
 	GetInodeRec(inodeNumber uint64) (value []byte, ok bool, err error)
 	PutInodeRec(inodeNumber uint64, value []byte) (err error)
 	PutInodeRecs(inodeNumbers []uint64, values [][]byte) (err error)
 	DeleteInodeRec(inodeNumber uint64) (err error)
 	IndexedInodeNumber(index uint64) (inodeNumber uint64, ok bool, err error)
 	NextInodeNumber(lastInodeNumber uint64) (nextInodeNumber uint64, ok bool, err error)
-	GetLogSegmentRec(logSegmentNumber uint64) (value []byte, err error)
+	GetQuotaRec() (value []byte, ok bool, err error)
+	PutQuotaRec(value []byte) (err error)
+	GetCompressionStatsRec() (value []byte, ok bool, err error)
+	PutCompressionStatsRec(value []byte) (err error)
+	GetLogSegmentRec(logSegmentNumber uint64) (value []byte, err error) // value is ContainerName optionally followed by NUL and caller-defined data
 	PutLogSegmentRec(logSegmentNumber uint64, value []byte) (err error)
 	DeleteLogSegmentRec(logSegmentNumber uint64) (err error)
 	IndexedLogSegmentNumber(index uint64) (logSegmentNumber uint64, ok bool, err error)
+	ShareLogSegmentRec(logSegmentNumber uint64) (err error)                              // logSegmentNumber adorned with the SnapShotID of a view referencing it
+	FetchLogSegmentRecShareCount(logSegmentNumber uint64) (shareCount uint64, err error) // additional live view references made by ShareLogSegmentRec()
 	GetBPlusTreeObject(objectNumber uint64) (value []byte, err error)
 	PutBPlusTreeObject(objectNumber uint64, value []byte) (err error)
 	DeleteBPlusTreeObject(objectNumber uint64) (err error)
 	IndexedBPlusTreeObjectNumber(index uint64) (objectNumber uint64, ok bool, err error)
+	ShareBPlusTreeObject(objectNumber uint64) (err error) // objectNumber adorned with the SnapShotID of a view referencing it
 	DoCheckpoint() (err error)
+	FetchReplicationStatus() (replicationStatus *ReplicationStatusStruct)
 	FetchLayoutReport(treeType BPlusTreeType, validate bool) (layoutReport sortedmap.LayoutReport, discrepencies uint64, err error)
 	DefragmentMetadata(treeType BPlusTreeType, thisStartPercentage float64, thisStopPercentage float64) (err error)
 	SnapShotCreateByInodeLayer(name string) (id uint64, err error)
 	SnapShotDeleteByInodeLayer(id uint64) (err error)
+	SnapShotRollbackByInodeLayer(id uint64) (err error)
+	SnapShotPin(id uint64, pinned bool) (err error)
+	SnapShotTrappedLogSegmentRecs(deletableIDs map[uint64]struct{}) (logSegmentRecs map[uint64][][]byte, err error) // Key: SnapShotID whose deletion frees them
 	SnapShotCount() (snapShotCount uint64)
 	SnapShotLookupByName(name string) (snapShot SnapShotStruct, ok bool)
 	SnapShotListByID(reversed bool) (list []SnapShotStruct)
 	SnapShotListByTime(reversed bool) (list []SnapShotStruct)
 	SnapShotListByName(reversed bool) (list []SnapShotStruct)
+	SnapShotInodeRecDiff(fromSnapShotID uint64, toSnapShotID uint64) (created []uint64, deleted []uint64, modified []uint64, err error) // SnapShotID == 0 means the live view
 	SnapShotU64Decode(snapShotU64 uint64) (snapShotIDType SnapShotIDType, snapShotID uint64, nonce uint64)
 	SnapShotIDAndNonceEncode(snapShotID uint64, nonce uint64) (snapShotU64 uint64)
 	SnapShotTypeDotSnapShotAndNonceEncode(nonce uint64) (snapShotU64 uint64)
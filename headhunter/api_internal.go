@@ -4,6 +4,7 @@
 package headhunter
 
 import (
+	"bytes"
 	"container/list"
 	"fmt"
 	"math/big"
@@ -79,6 +80,7 @@ func (volume *volumeStruct) fetchNonceWhileLocked() (nonce uint64) {
 			CheckpointObjectTrailerStructObjectNumber: volume.checkpointHeader.CheckpointObjectTrailerStructObjectNumber,
 			CheckpointObjectTrailerStructObjectLength: volume.checkpointHeader.CheckpointObjectTrailerStructObjectLength,
 			ReservedToNonce:                           volume.checkpointHeader.ReservedToNonce + uint64(volume.nonceValuesToReserve),
+			EncryptionKeyID:                           volume.checkpointHeader.EncryptionKeyID,
 		}
 
 		volume.checkpointHeader = newCheckpointHeader
@@ -93,12 +95,7 @@ func (volume *volumeStruct) fetchNonceWhileLocked() (nonce uint64) {
 			}
 		}
 
-		checkpointHeaderValue = fmt.Sprintf("%016X %016X %016X %016X",
-			volume.checkpointHeader.CheckpointVersion,
-			volume.checkpointHeader.CheckpointObjectTrailerStructObjectNumber,
-			volume.checkpointHeader.CheckpointObjectTrailerStructObjectLength,
-			volume.checkpointHeader.ReservedToNonce,
-		)
+		checkpointHeaderValue = formatCheckpointHeaderValue(volume.checkpointHeader)
 
 		checkpointHeaderValues = []string{checkpointHeaderValue}
 
@@ -124,7 +121,7 @@ func (volume *volumeStruct) fetchNonceWhileLocked() (nonce uint64) {
 func (volume *volumeStruct) FetchNonce() (nonce uint64) {
 	startTime := time.Now()
 	defer func() {
-		globals.FetchNonceUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.FetchNonceUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 	}()
 
 	volume.Lock()
@@ -169,10 +166,10 @@ func (volume *volumeStruct) GetInodeRec(inodeNumber uint64) (value []byte, ok bo
 
 	startTime := time.Now()
 	defer func() {
-		globals.GetInodeRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.GetInodeRecBytes.Add(uint64(len(value)))
+		volume.stats.GetInodeRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.GetInodeRecBytes.Add(uint64(len(value)))
 		if err != nil {
-			globals.GetInodeRecErrors.Add(1)
+			volume.stats.GetInodeRecErrors.Add(1)
 		}
 	}()
 
@@ -215,10 +212,10 @@ func (volume *volumeStruct) PutInodeRec(inodeNumber uint64, value []byte) (err e
 
 	startTime := time.Now()
 	defer func() {
-		globals.PutInodeRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.PutInodeRecBytes.Add(uint64(len(value)))
+		volume.stats.PutInodeRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.PutInodeRecBytes.Add(uint64(len(value)))
 		if err != nil {
-			globals.PutInodeRecErrors.Add(1)
+			volume.stats.PutInodeRecErrors.Add(1)
 		}
 	}()
 
@@ -259,10 +256,10 @@ func (volume *volumeStruct) PutInodeRecs(inodeNumbers []uint64, values [][]byte)
 			totalBytes += len(inodeValue)
 		}
 
-		globals.PutInodeRecsUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.PutInodeRecsBytes.Add(uint64(totalBytes))
+		volume.stats.PutInodeRecsUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.PutInodeRecsBytes.Add(uint64(totalBytes))
 		if err != nil {
-			globals.PutInodeRecsErrors.Add(1)
+			volume.stats.PutInodeRecsErrors.Add(1)
 		}
 	}()
 
@@ -310,9 +307,9 @@ func (volume *volumeStruct) DeleteInodeRec(inodeNumber uint64) (err error) {
 
 	startTime := time.Now()
 	defer func() {
-		globals.DeleteInodeRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.DeleteInodeRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.DeleteInodeRecErrors.Add(1)
+			volume.stats.DeleteInodeRecErrors.Add(1)
 		}
 	}()
 
@@ -333,13 +330,21 @@ func (volume *volumeStruct) IndexedInodeNumber(index uint64) (inodeNumber uint64
 
 	startTime := time.Now()
 	defer func() {
-		globals.IndexedInodeNumberUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.IndexedInodeNumberUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.IndexedInodeNumberErrors.Add(1)
+			volume.stats.IndexedInodeNumberErrors.Add(1)
 		}
 	}()
 
 	volume.Lock()
+	_, volumeRecsPresent, err := volume.liveView.inodeRecWrapper.bPlusTree.GetByKey(volumeRecsKey)
+	if nil != err {
+		volume.Unlock()
+		return
+	}
+	if volumeRecsPresent {
+		index++ // Skip over VolumeRecs since it sorts before all InodeRecs
+	}
 	key, _, ok, err := volume.liveView.inodeRecWrapper.bPlusTree.GetByIndex(int(index))
 	if nil != err {
 		volume.Unlock()
@@ -353,6 +358,10 @@ func (volume *volumeStruct) IndexedInodeNumber(index uint64) (inodeNumber uint64
 
 	inodeNumber = key.(uint64)
 
+	if volume.isShareCountRecKey(inodeNumber) {
+		ok = false // ShareCountRecs sort after all InodeRecs
+	}
+
 	return
 }
 
@@ -360,9 +369,9 @@ func (volume *volumeStruct) NextInodeNumber(lastInodeNumber uint64) (nextInodeNu
 
 	startTime := time.Now()
 	defer func() {
-		globals.NextInodeNumberUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.NextInodeNumberUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.NextInodeNumberErrors.Add(1)
+			volume.stats.NextInodeNumberErrors.Add(1)
 		}
 	}()
 
@@ -386,6 +395,9 @@ func (volume *volumeStruct) NextInodeNumber(lastInodeNumber uint64) (nextInodeNu
 
 	if ok {
 		nextInodeNumber = key.(uint64)
+		if volume.isShareCountRecKey(nextInodeNumber) {
+			ok = false // ShareCountRecs sort after all InodeRecs
+		}
 	}
 
 	return
@@ -395,9 +407,9 @@ func (volume *volumeStruct) GetLogSegmentRec(logSegmentNumber uint64) (value []b
 
 	startTime := time.Now()
 	defer func() {
-		globals.GetLogSegmentRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.GetLogSegmentRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.GetLogSegmentRecErrors.Add(1)
+			volume.stats.GetLogSegmentRecErrors.Add(1)
 		}
 	}()
 
@@ -440,9 +452,9 @@ func (volume *volumeStruct) PutLogSegmentRec(logSegmentNumber uint64, value []by
 
 	startTime := time.Now()
 	defer func() {
-		globals.PutLogSegmentRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.PutLogSegmentRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.PutLogSegmentRecErrors.Add(1)
+			volume.stats.PutLogSegmentRecErrors.Add(1)
 		}
 	}()
 
@@ -466,14 +478,18 @@ func (volume *volumeStruct) PutLogSegmentRec(logSegmentNumber uint64, value []by
 		}
 	}
 
+	containerName := logSegmentRecContainerName(valueToTree)
+
 	if nil != volume.priorView {
-		_, err = volume.priorView.createdObjectsWrapper.bPlusTree.Put(logSegmentNumber, valueToTree)
+		_, err = volume.priorView.createdObjectsWrapper.bPlusTree.Put(logSegmentNumber, []byte(containerName))
 		if nil != err {
 			volume.Unlock()
 			return
 		}
 	}
 
+	volume.replicationNoteCreatedObjectWhileLocked(containerName, logSegmentNumber)
+
 	volume.recordTransaction(transactionPutLogSegmentRec, logSegmentNumber, value)
 
 	volume.Unlock()
@@ -482,17 +498,34 @@ func (volume *volumeStruct) PutLogSegmentRec(logSegmentNumber uint64, value []by
 	return
 }
 
+// logSegmentRecContainerName returns the ContainerName held in a LogSegmentRec
+// value (i.e. ignoring the NUL and anything following it).
+func logSegmentRecContainerName(value []byte) (containerName string) {
+	var (
+		nulIndex int
+	)
+
+	nulIndex = bytes.IndexByte(value, 0)
+	if 0 <= nulIndex {
+		value = value[:nulIndex]
+	}
+
+	containerName = string(value)
+	return
+}
+
 func (volume *volumeStruct) DeleteLogSegmentRec(logSegmentNumber uint64) (err error) {
 	var (
 		containerNameAsValue sortedmap.Value
 		ok                   bool
+		released             bool
 	)
 
 	startTime := time.Now()
 	defer func() {
-		globals.DeleteLogSegmentRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.DeleteLogSegmentRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.DeleteLogSegmentRecErrors.Add(1)
+			volume.stats.DeleteLogSegmentRecErrors.Add(1)
 		}
 	}()
 
@@ -501,6 +534,15 @@ func (volume *volumeStruct) DeleteLogSegmentRec(logSegmentNumber uint64) (err er
 
 	volume.checkpointTriggeringEvents++
 
+	released, err = volume.releaseShareWhileLocked(logSegmentNumber)
+	if nil != err {
+		return
+	}
+	if released {
+		volume.recordTransaction(transactionDeleteLogSegmentRec, logSegmentNumber, nil)
+		return
+	}
+
 	containerNameAsValue, ok, err = volume.liveView.logSegmentRecWrapper.bPlusTree.GetByKey(logSegmentNumber)
 	if nil != err {
 		return
@@ -515,6 +557,10 @@ func (volume *volumeStruct) DeleteLogSegmentRec(logSegmentNumber uint64) (err er
 		return
 	}
 
+	// Only the ContainerName (i.e. not anything following a NUL) is needed to delete the Object
+
+	containerNameAsValue = []byte(logSegmentRecContainerName(containerNameAsValue.([]byte)))
+
 	if nil == volume.priorView {
 		_, err = volume.liveView.deletedObjectsWrapper.bPlusTree.Put(logSegmentNumber, containerNameAsValue)
 		if nil != err {
@@ -547,9 +593,9 @@ func (volume *volumeStruct) IndexedLogSegmentNumber(index uint64) (logSegmentNum
 
 	startTime := time.Now()
 	defer func() {
-		globals.IndexedLogSegmentNumberUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.IndexedLogSegmentNumberUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.IndexedLogSegmentNumberErrors.Add(1)
+			volume.stats.IndexedLogSegmentNumberErrors.Add(1)
 		}
 	}()
 
@@ -574,10 +620,10 @@ func (volume *volumeStruct) GetBPlusTreeObject(objectNumber uint64) (value []byt
 
 	startTime := time.Now()
 	defer func() {
-		globals.GetBPlusTreeObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.GetBPlusTreeObjectBytes.Add(uint64(len(value)))
+		volume.stats.GetBPlusTreeObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.GetBPlusTreeObjectBytes.Add(uint64(len(value)))
 		if err != nil {
-			globals.GetBPlusTreeObjectErrors.Add(1)
+			volume.stats.GetBPlusTreeObjectErrors.Add(1)
 		}
 	}()
 
@@ -620,10 +666,10 @@ func (volume *volumeStruct) PutBPlusTreeObject(objectNumber uint64, value []byte
 
 	startTime := time.Now()
 	defer func() {
-		globals.PutBPlusTreeObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
-		globals.PutBPlusTreeObjectBytes.Add(uint64(len(value)))
+		volume.stats.PutBPlusTreeObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.PutBPlusTreeObjectBytes.Add(uint64(len(value)))
 		if err != nil {
-			globals.PutBPlusTreeObjectErrors.Add(1)
+			volume.stats.PutBPlusTreeObjectErrors.Add(1)
 		}
 	}()
 
@@ -659,9 +705,9 @@ func (volume *volumeStruct) DeleteBPlusTreeObject(objectNumber uint64) (err erro
 
 	startTime := time.Now()
 	defer func() {
-		globals.DeleteBPlusTreeObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.DeleteBPlusTreeObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.DeleteBPlusTreeObjectErrors.Add(1)
+			volume.stats.DeleteBPlusTreeObjectErrors.Add(1)
 		}
 	}()
 
@@ -669,7 +715,14 @@ func (volume *volumeStruct) DeleteBPlusTreeObject(objectNumber uint64) (err erro
 
 	volume.checkpointTriggeringEvents++
 
-	_, err = volume.liveView.bPlusTreeObjectWrapper.bPlusTree.DeleteByKey(objectNumber)
+	released, err := volume.releaseShareWhileLocked(objectNumber)
+	if nil != err {
+		volume.Unlock()
+		return
+	}
+	if !released {
+		_, err = volume.liveView.bPlusTreeObjectWrapper.bPlusTree.DeleteByKey(objectNumber)
+	}
 
 	volume.recordTransaction(transactionDeleteBPlusTreeObject, objectNumber, nil)
 
@@ -682,9 +735,9 @@ func (volume *volumeStruct) IndexedBPlusTreeObjectNumber(index uint64) (objectNu
 
 	startTime := time.Now()
 	defer func() {
-		globals.IndexedBPlusTreeObjectNumberUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.IndexedBPlusTreeObjectNumberUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.IndexedBPlusTreeObjectNumberErrors.Add(1)
+			volume.stats.IndexedBPlusTreeObjectNumberErrors.Add(1)
 		}
 	}()
 
@@ -712,9 +765,9 @@ func (volume *volumeStruct) DoCheckpoint() (err error) {
 
 	startTime := time.Now()
 	defer func() {
-		globals.DoCheckpointUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.DoCheckpointUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.DoCheckpointErrors.Add(1)
+			volume.stats.DoCheckpointErrors.Add(1)
 		}
 	}()
 
@@ -843,9 +896,9 @@ func (volume *volumeStruct) FetchLayoutReport(treeType BPlusTreeType, validate b
 
 	startTime := time.Now()
 	defer func() {
-		globals.FetchLayoutReportUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.FetchLayoutReportUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.FetchLayoutReportErrors.Add(1)
+			volume.stats.FetchLayoutReportErrors.Add(1)
 		}
 	}()
 
@@ -1086,9 +1139,9 @@ func (volume *volumeStruct) SnapShotCreateByInodeLayer(name string) (id uint64,
 
 	startTime := time.Now()
 	defer func() {
-		globals.SnapShotCreateByInodeLayerUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.SnapShotCreateByInodeLayerUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.SnapShotCreateByInodeLayerErrors.Add(1)
+			volume.stats.SnapShotCreateByInodeLayerErrors.Add(1)
 		}
 	}()
 
@@ -1327,9 +1380,9 @@ func (volume *volumeStruct) SnapShotDeleteByInodeLayer(id uint64) (err error) {
 
 	startTime := time.Now()
 	defer func() {
-		globals.SnapShotDeleteByInodeLayerUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.SnapShotDeleteByInodeLayerUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.SnapShotDeleteByInodeLayerErrors.Add(1)
+			volume.stats.SnapShotDeleteByInodeLayerErrors.Add(1)
 		}
 	}()
 
@@ -1582,9 +1635,9 @@ func (volume *volumeStruct) SnapShotCount() (snapShotCount uint64) {
 
 	startTime := time.Now()
 	defer func() {
-		globals.SnapShotCountUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.SnapShotCountUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.SnapShotCountErrors.Add(1)
+			volume.stats.SnapShotCountErrors.Add(1)
 		}
 	}()
 
@@ -1605,9 +1658,9 @@ func (volume *volumeStruct) SnapShotLookupByName(name string) (snapShot SnapShot
 
 	startTime := time.Now()
 	defer func() {
-		globals.SnapShotLookupByNameUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.SnapShotLookupByNameUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.SnapShotLookupByNameErrors.Add(1)
+			volume.stats.SnapShotLookupByNameErrors.Add(1)
 		}
 	}()
 
@@ -1630,6 +1683,10 @@ func (volume *volumeStruct) SnapShotLookupByName(name string) (snapShot SnapShot
 		Name: volumeView.snapShotName, // == name
 	}
 
+	volume.Lock()
+	snapShot.Pinned = volumeView.pinned
+	volume.Unlock()
+
 	return
 }
 
@@ -1674,6 +1731,7 @@ func (volume *volumeStruct) snapShotList(viewTree sortedmap.LLRBTree, reversed b
 		list[listIndex].ID = volumeView.snapShotID
 		list[listIndex].Time = volumeView.snapShotTime
 		list[listIndex].Name = volumeView.snapShotName
+		list[listIndex].Pinned = volumeView.pinned
 	}
 
 	return
@@ -1683,7 +1741,7 @@ func (volume *volumeStruct) SnapShotListByID(reversed bool) (list []SnapShotStru
 
 	startTime := time.Now()
 	defer func() {
-		globals.SnapShotListByIDUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.SnapShotListByIDUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 	}()
 
 	volume.Lock()
@@ -1697,7 +1755,7 @@ func (volume *volumeStruct) SnapShotListByTime(reversed bool) (list []SnapShotSt
 
 	startTime := time.Now()
 	defer func() {
-		globals.SnapShotListByTimeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.SnapShotListByTimeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 	}()
 
 	volume.Lock()
@@ -1711,7 +1769,7 @@ func (volume *volumeStruct) SnapShotListByName(reversed bool) (list []SnapShotSt
 
 	startTime := time.Now()
 	defer func() {
-		globals.SnapShotListByNameUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.SnapShotListByNameUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 	}()
 
 	volume.Lock()
@@ -1721,11 +1779,155 @@ func (volume *volumeStruct) SnapShotListByName(reversed bool) (list []SnapShotSt
 	return
 }
 
+// fetchVolumeViewByIDWhileLocked returns the volumeView for snapShotID (where
+// 0 selects the live view).
+//
+func (volume *volumeStruct) fetchVolumeViewByIDWhileLocked(snapShotID uint64) (volumeView *volumeViewStruct, err error) {
+	var (
+		ok    bool
+		value sortedmap.Value
+	)
+
+	if liveSnapShotID == snapShotID {
+		volumeView = volume.liveView
+		err = nil
+		return
+	}
+
+	value, ok, err = volume.viewTreeByID.GetByKey(snapShotID)
+	if nil != err {
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("SnapShotID %v not found in volume \"%v\"", snapShotID, volume.volumeName)
+		return
+	}
+
+	volumeView = value.(*volumeViewStruct)
+
+	err = nil
+	return
+}
+
+// SnapShotInodeRecDiff compares the InodeRecs of two views returning the (un-adorned)
+// InodeNumbers only present in toSnapShotID (created), only present in fromSnapShotID
+// (deleted), and present in both but with differing InodeRecs (modified). As the two
+// inodeRec B+Trees are walked in parallel, the volume is only locked while examining
+// each batch of snapShotInodeRecDiffBatchSize InodeRecs.
+//
+func (volume *volumeStruct) SnapShotInodeRecDiff(fromSnapShotID uint64, toSnapShotID uint64) (created []uint64, deleted []uint64, modified []uint64, err error) {
+	var (
+		batchCount     int
+		fromIndex      int
+		fromKey        uint64
+		fromKeyAsKey   sortedmap.Key
+		fromOK         bool
+		fromValue      sortedmap.Value
+		fromVolumeView *volumeViewStruct
+		nextKey        uint64
+		toIndex        int
+		toKey          uint64
+		toKeyAsKey     sortedmap.Key
+		toOK           bool
+		toValue        sortedmap.Value
+		toVolumeView   *volumeViewStruct
+	)
+
+	startTime := time.Now()
+	defer func() {
+		volume.stats.SnapShotInodeRecDiffUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			volume.stats.SnapShotInodeRecDiffErrors.Add(1)
+		}
+	}()
+
+	created = make([]uint64, 0)
+	deleted = make([]uint64, 0)
+	modified = make([]uint64, 0)
+
+	nextKey = volumeRecsKey + 1 // Skip over VolumeRecs since it sorts before all InodeRecs
+
+	for {
+		volume.Lock()
+
+		// Views are re-fetched for each batch in case one was deleted while unlocked
+
+		fromVolumeView, err = volume.fetchVolumeViewByIDWhileLocked(fromSnapShotID)
+		if nil != err {
+			volume.Unlock()
+			return
+		}
+		toVolumeView, err = volume.fetchVolumeViewByIDWhileLocked(toSnapShotID)
+		if nil != err {
+			volume.Unlock()
+			return
+		}
+
+		fromIndex, _, err = fromVolumeView.inodeRecWrapper.bPlusTree.BisectRight(nextKey)
+		if nil != err {
+			volume.Unlock()
+			return
+		}
+		toIndex, _, err = toVolumeView.inodeRecWrapper.bPlusTree.BisectRight(nextKey)
+		if nil != err {
+			volume.Unlock()
+			return
+		}
+
+		for batchCount = 0; batchCount < snapShotInodeRecDiffBatchSize; batchCount++ {
+			fromKeyAsKey, fromValue, fromOK, err = fromVolumeView.inodeRecWrapper.bPlusTree.GetByIndex(fromIndex)
+			if nil != err {
+				volume.Unlock()
+				return
+			}
+			toKeyAsKey, toValue, toOK, err = toVolumeView.inodeRecWrapper.bPlusTree.GetByIndex(toIndex)
+			if nil != err {
+				volume.Unlock()
+				return
+			}
+
+			if fromOK {
+				fromKey = fromKeyAsKey.(uint64)
+				fromOK = !volume.isShareCountRecKey(fromKey) // ShareCountRecs sort after all InodeRecs
+			}
+			if toOK {
+				toKey = toKeyAsKey.(uint64)
+				toOK = !volume.isShareCountRecKey(toKey)
+			}
+
+			if !fromOK && !toOK {
+				volume.Unlock()
+				err = nil
+				return
+			}
+
+			if !toOK || (fromOK && (fromKey < toKey)) {
+				deleted = append(deleted, fromKey)
+				nextKey = fromKey + 1
+				fromIndex++
+			} else if !fromOK || (toKey < fromKey) {
+				created = append(created, toKey)
+				nextKey = toKey + 1
+				toIndex++
+			} else {
+				if !bytes.Equal(fromValue.([]byte), toValue.([]byte)) {
+					modified = append(modified, toKey)
+				}
+				nextKey = toKey + 1
+				fromIndex++
+				toIndex++
+			}
+		}
+
+		volume.Unlock()
+	}
+}
+
 func (volume *volumeStruct) SnapShotU64Decode(snapShotU64 uint64) (snapShotIDType SnapShotIDType, snapShotID uint64, nonce uint64) {
 
 	startTime := time.Now()
 	defer func() {
-		globals.SnapShotU64DecodeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.SnapShotU64DecodeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 	}()
 
 	snapShotID = snapShotU64 >> volume.snapShotIDShift
@@ -1746,7 +1948,7 @@ func (volume *volumeStruct) SnapShotIDAndNonceEncode(snapShotID uint64, nonce ui
 
 	startTime := time.Now()
 	defer func() {
-		globals.SnapShotIDAndNonceEncodeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.SnapShotIDAndNonceEncodeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 	}()
 
 	snapShotU64 = snapShotID
@@ -1760,7 +1962,7 @@ func (volume *volumeStruct) SnapShotTypeDotSnapShotAndNonceEncode(nonce uint64)
 
 	startTime := time.Now()
 	defer func() {
-		globals.SnapShotTypeDotSnapShotAndNonceEncodeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.SnapShotTypeDotSnapShotAndNonceEncodeUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 	}()
 
 	snapShotU64 = volume.dotSnapShotDirSnapShotID
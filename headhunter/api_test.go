@@ -101,6 +101,9 @@ func TestHeadHunterAPI(t *testing.T) {
 		*/
 		secondUpNonce          uint64
 		signalHandlerIsArmedWG sync.WaitGroup
+		snapShot               SnapShotStruct
+		snapShotID             uint64
+		snapShotOK             bool
 		value                  []byte
 		volume                 VolumeHandle
 	)
@@ -237,6 +240,15 @@ func TestHeadHunterAPI(t *testing.T) {
 
 	firstUpNonce = volume.FetchNonce()
 
+	snapShotID, err = volume.SnapShotCreateByInodeLayer("TestSnapShot")
+	if nil != err {
+		t.Fatalf("SnapShotCreateByInodeLayer() [case 1] returned error: %v", err)
+	}
+	err = volume.SnapShotPin(snapShotID, true)
+	if nil != err {
+		t.Fatalf("SnapShotPin() [case 1] returned error: %v", err)
+	}
+
 	err = transitions.Down(confMap)
 	if nil != err {
 		t.Fatalf("transitions.Down() [case 1] returned error: %v", err)
@@ -257,6 +269,17 @@ func TestHeadHunterAPI(t *testing.T) {
 		t.Fatalf("FetchNonce() [case 2] returned unexpected nonce: %v (should have been > %v)", secondUpNonce, firstUpNonce)
 	}
 
+	// The pin must have been persisted in the checkpoint
+
+	snapShot, snapShotOK = volume.SnapShotLookupByName("TestSnapShot")
+	if !snapShotOK || (snapShotID != snapShot.ID) || !snapShot.Pinned {
+		t.Fatalf("SnapShotLookupByName() [case 2] returned unexpected result: %+v (ok == %v)", snapShot, snapShotOK)
+	}
+	err = volume.SnapShotDeleteByInodeLayer(snapShotID)
+	if nil != err {
+		t.Fatalf("SnapShotDeleteByInodeLayer() [case 2] returned error: %v", err)
+	}
+
 	key = 1234
 	value = []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 
@@ -40,20 +40,25 @@ type uint64Struct struct {
 
 const (
 	CheckpointVersion3 uint64 = iota + 3
-	// uint64 in %016X indicating checkpointVersion2 or CheckpointVersion3
+	CheckpointVersion4        // as CheckpointVersion3 but each ElementOfSnapShotListStruct includes Pinned
+	// uint64 in %016X indicating CheckpointVersion3 or CheckpointVersion4
 	// ' '
 	// uint64 in %016X indicating objectNumber containing checkpoint record at tail of object
 	// ' '
 	// uint64 in %016X indicating length of               checkpoint record at tail of object
 	// ' '
 	// uint64 in %016X indicating reservedToNonce
+	// if volume is encrypted:
+	//   ' '
+	//   uint64 in %016X indicating KeyID used to seal checkpoint record at tail of object
 )
 
 type CheckpointHeaderStruct struct {
-	CheckpointVersion                         uint64 // either checkpointVersion2 or CheckpointVersion3
+	CheckpointVersion                         uint64 // either CheckpointVersion3 or CheckpointVersion4
 	CheckpointObjectTrailerStructObjectNumber uint64 // checkpointObjectTrailerV?Struct found at "tail" of object
 	CheckpointObjectTrailerStructObjectLength uint64 // this length includes appended non-fixed sized arrays
 	ReservedToNonce                           uint64 // highest nonce value reserved
+	EncryptionKeyID                           uint64 // if != 0, KeyID used to seal checkpointObjectTrailerV?Struct
 }
 
 type CheckpointObjectTrailerV3Struct struct {
@@ -113,6 +118,7 @@ type ElementOfSnapShotListStruct struct { // Note: for illustrative purposes...
 	DeletedObjectsBPlusTreeObjectNumber  uint64
 	DeletedObjectsBPlusTreeObjectOffset  uint64
 	DeletedObjectsBPlusTreeObjectLength  uint64
+	Pinned                               uint64 // only present if CheckpointVersion4... if != 0, never deleted by a SnapShotPolicy
 }
 
 type checkpointRequestStruct struct {
@@ -134,6 +140,8 @@ const (
 	transactionDeleteLogSegmentRec
 	transactionPutBPlusTreeObject
 	transactionDeleteBPlusTreeObject
+	transactionShareLogSegmentRec
+	transactionShareBPlusTreeObject
 )
 
 type replayLogTransactionFixedPartStruct struct { // transactions begin on a replayLogWriteBufferAlignment boundary
@@ -211,6 +219,10 @@ func (volume *volumeStruct) recordTransaction(transactionType uint64, keys inter
 		evtlog.Record(evtlog.FormatHeadhunterRecordTransactionPutBPlusTreeObject, volume.volumeName, keys.(uint64))
 	case transactionDeleteBPlusTreeObject:
 		evtlog.Record(evtlog.FormatHeadhunterRecordTransactionDeleteBPlusTreeObject, volume.volumeName, keys.(uint64))
+	case transactionShareLogSegmentRec:
+		evtlog.Record(evtlog.FormatHeadhunterRecordTransactionShareLogSegmentRec, volume.volumeName, keys.(uint64))
+	case transactionShareBPlusTreeObject:
+		evtlog.Record(evtlog.FormatHeadhunterRecordTransactionShareBPlusTreeObject, volume.volumeName, keys.(uint64))
 	default:
 		logger.Fatalf("headhunter.recordTransaction(transactionType==%v,,) invalid", transactionType)
 	}
@@ -306,6 +318,28 @@ func (volume *volumeStruct) recordTransaction(transactionType uint64, keys inter
 				globals.uint64Size + //               last CheckpointHeaderStruct.checkpointObjectTrailerStructObjectNumber
 				globals.uint64Size + //               transactionType == transactionDeleteBPlusTreeObject
 				globals.uint64Size //                 objectNumber
+	case transactionShareLogSegmentRec:
+		singleKey = keys.(uint64)
+		if nil != values {
+			logger.Fatalf("headhunter.recordTransaction(transactionType==transactionShareLogSegmentRec,,) passed non-nil values")
+		}
+		bytesNeeded = //                              transactions begin on a replayLogWriteBufferAlignment boundary
+			globals.uint64Size + //                   checksum of everything after this field
+				globals.uint64Size + //               bytes following in this transaction
+				globals.uint64Size + //               last CheckpointHeaderStruct.checkpointObjectTrailerStructObjectNumber
+				globals.uint64Size + //               transactionType == transactionShareLogSegmentRec
+				globals.uint64Size //                 logSegmentNumber (adorned)
+	case transactionShareBPlusTreeObject:
+		singleKey = keys.(uint64)
+		if nil != values {
+			logger.Fatalf("headhunter.recordTransaction(transactionType==transactionShareBPlusTreeObject,,) passed non-nil values")
+		}
+		bytesNeeded = //                              transactions begin on a replayLogWriteBufferAlignment boundary
+			globals.uint64Size + //                   checksum of everything after this field
+				globals.uint64Size + //               bytes following in this transaction
+				globals.uint64Size + //               last CheckpointHeaderStruct.checkpointObjectTrailerStructObjectNumber
+				globals.uint64Size + //               transactionType == transactionShareBPlusTreeObject
+				globals.uint64Size //                 objectNumber (adorned)
 	default:
 		logger.Fatalf("headhunter.recordTransaction(transactionType==%v,,) invalid", transactionType)
 	}
@@ -468,6 +502,24 @@ func (volume *volumeStruct) recordTransaction(transactionType uint64, keys inter
 	case transactionDeleteBPlusTreeObject:
 		// Fill in objectNumber
 
+		packedUint64, err = cstruct.Pack(singleKey, LittleEndian)
+		if nil != err {
+			logger.Fatalf("cstruct.Pack() unexpectedly returned error: %v", err)
+		}
+		_ = copy(replayLogWriteBuffer[replayLogWriteBufferPosition:], packedUint64)
+		replayLogWriteBufferPosition += globals.uint64Size
+	case transactionShareLogSegmentRec:
+		// Fill in (adorned) logSegmentNumber
+
+		packedUint64, err = cstruct.Pack(singleKey, LittleEndian)
+		if nil != err {
+			logger.Fatalf("cstruct.Pack() unexpectedly returned error: %v", err)
+		}
+		_ = copy(replayLogWriteBuffer[replayLogWriteBufferPosition:], packedUint64)
+		replayLogWriteBufferPosition += globals.uint64Size
+	case transactionShareBPlusTreeObject:
+		// Fill in (adorned) objectNumber
+
 		packedUint64, err = cstruct.Pack(singleKey, LittleEndian)
 		if nil != err {
 			logger.Fatalf("cstruct.Pack() unexpectedly returned error: %v", err)
@@ -724,8 +776,8 @@ func (volume *volumeStruct) fetchCheckpointContainerHeaderAsynchronously(checkpo
 
 	checkpointHeaderValue = checkpointHeaderValues[0]
 	checkpointHeaderValueSplit = strings.Split(checkpointHeaderValue, " ")
-	if 4 != len(checkpointHeaderValueSplit) {
-		logger.Warnf("%s.fetchCheckpointContainerHeader() ContainerHead Header %s should have four fields (had %d)", volume.volumeName, CheckpointHeaderName, len(checkpointHeaderValueSplit))
+	if (4 != len(checkpointHeaderValueSplit)) && (5 != len(checkpointHeaderValueSplit)) {
+		logger.Warnf("%s.fetchCheckpointContainerHeader() ContainerHead Header %s should have four or five fields (had %d)", volume.volumeName, CheckpointHeaderName, len(checkpointHeaderValueSplit))
 		*checkpointHeaderPtr = nil
 		wg.Done()
 		return
@@ -740,7 +792,7 @@ func (volume *volumeStruct) fetchCheckpointContainerHeaderAsynchronously(checkpo
 		wg.Done()
 		return
 	}
-	if CheckpointVersion3 != thisCheckpointHeader.CheckpointVersion {
+	if (CheckpointVersion3 != thisCheckpointHeader.CheckpointVersion) && (CheckpointVersion4 != thisCheckpointHeader.CheckpointVersion) {
 		logger.Warnf("%s.fetchCheckpointContainerHeader() ContainerHead Header %s contained unsupported CheckpointVersion (%s)", volume.volumeName, CheckpointHeaderName, checkpointHeaderValueSplit[0])
 		*checkpointHeaderPtr = nil
 		wg.Done()
@@ -768,11 +820,38 @@ func (volume *volumeStruct) fetchCheckpointContainerHeaderAsynchronously(checkpo
 		wg.Done()
 		return
 	}
+	if 5 == len(checkpointHeaderValueSplit) {
+		thisCheckpointHeader.EncryptionKeyID, err = strconv.ParseUint(checkpointHeaderValueSplit[4], 16, 64)
+		if nil != err {
+			logger.Warnf("%s.fetchCheckpointContainerHeader() ContainerHead Header %s contained unparseable EncryptionKeyID (%s)", volume.volumeName, CheckpointHeaderName, checkpointHeaderValueSplit[4])
+			*checkpointHeaderPtr = nil
+			wg.Done()
+			return
+		}
+	}
 
 	*checkpointHeaderPtr = thisCheckpointHeader
 	wg.Done()
 }
 
+// formatCheckpointHeaderValue returns checkpointHeader formatted as the value of
+// the Checkpoint Container's CheckpointHeaderName header.
+//
+func formatCheckpointHeaderValue(checkpointHeader *CheckpointHeaderStruct) (checkpointHeaderValue string) {
+	checkpointHeaderValue = fmt.Sprintf("%016X %016X %016X %016X",
+		checkpointHeader.CheckpointVersion,
+		checkpointHeader.CheckpointObjectTrailerStructObjectNumber,
+		checkpointHeader.CheckpointObjectTrailerStructObjectLength,
+		checkpointHeader.ReservedToNonce,
+	)
+
+	if 0 != checkpointHeader.EncryptionKeyID {
+		checkpointHeaderValue += fmt.Sprintf(" %016X", checkpointHeader.EncryptionKeyID)
+	}
+
+	return
+}
+
 func (volume *volumeStruct) fetchCheckpointLayoutReport() (layoutReport sortedmap.LayoutReport, err error) {
 	var (
 		checkpointHeader *CheckpointHeaderStruct
@@ -837,6 +916,7 @@ func (volume *volumeStruct) getCheckpoint(autoFormat bool) (err error) {
 		ok                                                 bool
 		replayLogReadBuffer                                []byte
 		replayLogReadBufferPosition                        uint64
+		released                                           bool
 		replayLogPosition                                  int64
 		replayLogSize                                      int64
 		replayLogTransactionFixedPart                      replayLogTransactionFixedPartStruct
@@ -861,6 +941,7 @@ func (volume *volumeStruct) getCheckpoint(autoFormat bool) (err error) {
 		snapShotNameBuf                                    []byte
 		snapShotNameBufLenStruct                           uint64Struct
 		snapShotNonceStruct                                uint64Struct
+		snapShotPinnedStruct                               uint64Struct
 		snapShotTimeStampBuf                               []byte
 		snapShotTimeStampBufLenStruct                      uint64Struct
 		storagePolicyHeaderValues                          []string
@@ -889,7 +970,7 @@ func (volume *volumeStruct) getCheckpoint(autoFormat bool) (err error) {
 				}
 
 				volume.checkpointHeader = &CheckpointHeaderStruct{
-					CheckpointVersion:                         CheckpointVersion3,
+					CheckpointVersion:                         CheckpointVersion4,
 					CheckpointObjectTrailerStructObjectNumber: 0,
 					CheckpointObjectTrailerStructObjectLength: 0,
 					ReservedToNonce:                           firstNonceToProvide, // First FetchNonce() will trigger a reserve step
@@ -952,7 +1033,7 @@ func (volume *volumeStruct) getCheckpoint(autoFormat bool) (err error) {
 			}
 
 			volume.checkpointHeader = &CheckpointHeaderStruct{
-				CheckpointVersion:                         CheckpointVersion3,
+				CheckpointVersion:                         CheckpointVersion4,
 				CheckpointObjectTrailerStructObjectNumber: 0,
 				CheckpointObjectTrailerStructObjectLength: 0,
 				ReservedToNonce:                           firstNonceToProvide, // First FetchNonce() will trigger a reserve step
@@ -995,9 +1076,14 @@ func (volume *volumeStruct) getCheckpoint(autoFormat bool) (err error) {
 		}
 	}
 
+	err = volume.checkCheckpointHeaderEncryption()
+	if nil != err {
+		return
+	}
+
 	volume.liveView = &volumeViewStruct{volume: volume}
 
-	if CheckpointVersion3 == volume.checkpointHeader.CheckpointVersion {
+	if (CheckpointVersion3 == volume.checkpointHeader.CheckpointVersion) || (CheckpointVersion4 == volume.checkpointHeader.CheckpointVersion) {
 		if 0 == volume.checkpointHeader.CheckpointObjectTrailerStructObjectNumber {
 			// Initialize based on zero-filled CheckpointObjectTrailerV3Struct
 
@@ -1126,6 +1212,17 @@ func (volume *volumeStruct) getCheckpoint(autoFormat bool) (err error) {
 				return
 			}
 
+			if nil != volume.keyring {
+				checkpointObjectTrailerBuf, err =
+					volume.Unseal(
+						volume.checkpointHeader.CheckpointObjectTrailerStructObjectNumber,
+						checkpointTrailerSealOffset,
+						checkpointObjectTrailerBuf)
+				if nil != err {
+					return
+				}
+			}
+
 			checkpointObjectTrailerV3 = &CheckpointObjectTrailerV3Struct{}
 
 			bytesConsumed, err = cstruct.Unpack(checkpointObjectTrailerBuf, checkpointObjectTrailerV3, LittleEndian)
@@ -1723,6 +1820,21 @@ func (volume *volumeStruct) getCheckpoint(autoFormat bool) (err error) {
 				}
 				checkpointObjectTrailerBuf = checkpointObjectTrailerBuf[bytesConsumed:]
 
+				// ElementOfSnapShotListStruct.Pinned (absent if CheckpointVersion3)
+
+				if CheckpointVersion4 == volume.checkpointHeader.CheckpointVersion {
+					if uint64(len(checkpointObjectTrailerBuf)) < globals.uint64Size {
+						err = fmt.Errorf("Cannot parse volume %v's checkpointObjectTrailer's SnapShotList element %v...no room for the pinned", volume.volumeName, snapShotIndex)
+						return
+					}
+					bytesConsumed, err = cstruct.Unpack(checkpointObjectTrailerBuf, &snapShotPinnedStruct, LittleEndian)
+					if nil != err {
+						return
+					}
+					checkpointObjectTrailerBuf = checkpointObjectTrailerBuf[bytesConsumed:]
+					volumeView.pinned = (0 != snapShotPinnedStruct.U64)
+				}
+
 				volumeView.deletedObjectsWrapper = &bPlusTreeWrapperStruct{
 					volumeView:       volumeView,
 					bPlusTreeTracker: volumeView.volume.liveView.deletedObjectsWrapper.bPlusTreeTracker,
@@ -1998,7 +2110,7 @@ func (volume *volumeStruct) getCheckpoint(autoFormat bool) (err error) {
 				}
 			}
 			if nil != volume.priorView {
-				_, err = volume.priorView.createdObjectsWrapper.bPlusTree.Put(logSegmentNumber, value)
+				_, err = volume.priorView.createdObjectsWrapper.bPlusTree.Put(logSegmentNumber, []byte(logSegmentRecContainerName(value)))
 				if nil != err {
 					logger.Fatalf("Reply Log for Volume %s hit unexpected volume.priorView.createdObjectsWrapper.bPlusTree.Put() failure: %v", volume.volumeName, err)
 				}
@@ -2008,6 +2120,13 @@ func (volume *volumeStruct) getCheckpoint(autoFormat bool) (err error) {
 			if nil != err {
 				logger.Fatalf("Reply Log for Volume %s hit unexpected cstruct.Unpack() failure: %v", volume.volumeName, err)
 			}
+			released, err = volume.releaseShareWhileLocked(logSegmentNumber)
+			if nil != err {
+				logger.Fatalf("Reply Log for Volume %s hit unexpected volume.releaseShareWhileLocked() failure: %v", volume.volumeName, err)
+			}
+			if released {
+				break
+			}
 			containerNameAsValue, ok, err = volume.liveView.logSegmentRecWrapper.bPlusTree.GetByKey(logSegmentNumber)
 			if nil != err {
 				logger.Fatalf("Reply Log for Volume %s hit unexpected volume.liveView.logSegmentRecWrapper.bPlusTree.GetByKey() failure: %v", volume.volumeName, err)
@@ -2019,6 +2138,8 @@ func (volume *volumeStruct) getCheckpoint(autoFormat bool) (err error) {
 			if nil != err {
 				logger.Fatalf("Reply Log for Volume %s hit unexpected volume.liveView.logSegmentRecWrapper.bPlusTree.DeleteByKey() failure: %v", volume.volumeName, err)
 			}
+			// As in DeleteLogSegmentRec(), only the ContainerName (i.e. not anything following a NUL) is needed
+			containerNameAsValue = []byte(logSegmentRecContainerName(containerNameAsValue.([]byte)))
 			if nil == volume.priorView {
 				_, err = volume.liveView.deletedObjectsWrapper.bPlusTree.Put(logSegmentNumber, containerNameAsValue)
 				if nil != err {
@@ -2069,10 +2190,35 @@ func (volume *volumeStruct) getCheckpoint(autoFormat bool) (err error) {
 			if nil != err {
 				logger.Fatalf("Reply Log for Volume %s hit unexpected cstruct.Unpack() failure: %v", volume.volumeName, err)
 			}
+			released, err = volume.releaseShareWhileLocked(objectNumber)
+			if nil != err {
+				logger.Fatalf("Reply Log for Volume %s hit unexpected volume.releaseShareWhileLocked() failure: %v", volume.volumeName, err)
+			}
+			if released {
+				break
+			}
 			_, err = volume.liveView.bPlusTreeObjectWrapper.bPlusTree.DeleteByKey(objectNumber)
 			if nil != err {
 				logger.Fatalf("Reply Log for Volume %s hit unexpected volume.liveView.bPlusTreeObjectWrapper.bPlusTree.DeleteByKey() failure: %v", volume.volumeName, err)
 			}
+		case transactionShareLogSegmentRec:
+			_, err = cstruct.Unpack(replayLogReadBuffer[replayLogReadBufferPosition:replayLogReadBufferPosition+globals.uint64Size], &logSegmentNumber, LittleEndian)
+			if nil != err {
+				logger.Fatalf("Reply Log for Volume %s hit unexpected cstruct.Unpack() failure: %v", volume.volumeName, err)
+			}
+			err = volume.shareLogSegmentRecWhileLocked(logSegmentNumber)
+			if nil != err {
+				logger.Fatalf("Reply Log for Volume %s hit unexpected volume.shareLogSegmentRecWhileLocked() failure: %v", volume.volumeName, err)
+			}
+		case transactionShareBPlusTreeObject:
+			_, err = cstruct.Unpack(replayLogReadBuffer[replayLogReadBufferPosition:replayLogReadBufferPosition+globals.uint64Size], &objectNumber, LittleEndian)
+			if nil != err {
+				logger.Fatalf("Reply Log for Volume %s hit unexpected cstruct.Unpack() failure: %v", volume.volumeName, err)
+			}
+			err = volume.shareBPlusTreeObjectWhileLocked(objectNumber)
+			if nil != err {
+				logger.Fatalf("Reply Log for Volume %s hit unexpected volume.shareBPlusTreeObjectWhileLocked() failure: %v", volume.volumeName, err)
+			}
 		default:
 			// Corruption in replayLogTransactionFixedPart - so exit as if Replay Log ended here
 
@@ -2112,12 +2258,12 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 	startTime := time.Now()
 	var chunkedPutBytes uint64
 	defer func() {
-		globals.PutCheckpointUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.PutCheckpointUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 		if err != nil {
-			globals.PutCheckpointErrors.Add(1)
+			volume.stats.PutCheckpointErrors.Add(1)
 			return
 		}
-		globals.PutCheckpointBytes.Add(chunkedPutBytes)
+		volume.stats.PutCheckpointBytes.Add(chunkedPutBytes)
 	}()
 
 	var (
@@ -2137,12 +2283,14 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 		elementOfBPlusTreeLayout                           ElementOfBPlusTreeLayoutStruct
 		elementOfBPlusTreeLayoutBuf                        []byte
 		elementOfSnapShotListBuf                           []byte
+		encryptionKeyID                                    uint64
 		logSegmentObjectsToDelete                          int
 		objectNumber                                       uint64
 		objectNumberAsKey                                  sortedmap.Key
 		ok                                                 bool
 		postponedCreatedObjectNumber                       uint64
 		postponedCreatedObjectsFound                       bool
+		sealedCheckpointTrailerBuf                         []byte
 		snapShotBPlusTreeObjectBPlusTreeObjectLengthBuf    []byte
 		snapShotBPlusTreeObjectBPlusTreeObjectLengthStruct uint64Struct
 		snapShotBPlusTreeObjectBPlusTreeObjectNumberBuf    []byte
@@ -2181,6 +2329,8 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 		snapShotNameBufLenStruct                           uint64Struct
 		snapShotNonceBuf                                   []byte
 		snapShotNonceStruct                                uint64Struct
+		snapShotPinnedBuf                                  []byte
+		snapShotPinnedStruct                               uint64Struct
 		snapShotTimeStampBuf                               []byte
 		snapShotTimeStampBufLenBuf                         []byte
 		snapShotTimeStampBufLenStruct                      uint64Struct
@@ -2212,9 +2362,9 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 	if nil != err {
 		return
 	}
-	globals.PutCheckpointInodeRecUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
-	globals.PutCheckpointInodeRecNodes.Add(volume.liveView.inodeRecWrapper.totalPutNodes)
-	globals.PutCheckpointInodeRecBytes.Add(volume.liveView.inodeRecWrapper.totalPutBytes)
+	volume.stats.PutCheckpointInodeRecUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+	volume.stats.PutCheckpointInodeRecNodes.Add(volume.liveView.inodeRecWrapper.totalPutNodes)
+	volume.stats.PutCheckpointInodeRecBytes.Add(volume.liveView.inodeRecWrapper.totalPutBytes)
 	chunkedPutBytes += volume.liveView.inodeRecWrapper.totalPutBytes
 
 	startTime2 = time.Now()
@@ -2227,9 +2377,9 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 	if nil != err {
 		return
 	}
-	globals.PutCheckpointLogSegmentUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
-	globals.PutCheckpointLogSegmentNodes.Add(volume.liveView.logSegmentRecWrapper.totalPutNodes)
-	globals.PutCheckpointLogSegmentBytes.Add(volume.liveView.logSegmentRecWrapper.totalPutBytes)
+	volume.stats.PutCheckpointLogSegmentUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+	volume.stats.PutCheckpointLogSegmentNodes.Add(volume.liveView.logSegmentRecWrapper.totalPutNodes)
+	volume.stats.PutCheckpointLogSegmentBytes.Add(volume.liveView.logSegmentRecWrapper.totalPutBytes)
 	chunkedPutBytes += volume.liveView.logSegmentRecWrapper.totalPutBytes
 
 	startTime2 = time.Now()
@@ -2242,9 +2392,9 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 	if nil != err {
 		return
 	}
-	globals.PutCheckpointbPlusTreeObjectUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
-	globals.PutCheckpointbPlusTreeObjectNodes.Add(volume.liveView.bPlusTreeObjectWrapper.totalPutNodes)
-	globals.PutCheckpointbPlusTreeObjectBytes.Add(volume.liveView.bPlusTreeObjectWrapper.totalPutBytes)
+	volume.stats.PutCheckpointbPlusTreeObjectUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+	volume.stats.PutCheckpointbPlusTreeObjectNodes.Add(volume.liveView.bPlusTreeObjectWrapper.totalPutNodes)
+	volume.stats.PutCheckpointbPlusTreeObjectBytes.Add(volume.liveView.bPlusTreeObjectWrapper.totalPutBytes)
 	chunkedPutBytes += volume.liveView.bPlusTreeObjectWrapper.totalPutBytes
 
 	startTime2 = time.Now()
@@ -2342,7 +2492,7 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 	if nil != err {
 		return
 	}
-	globals.PutCheckpointSnapshotFlushUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+	volume.stats.PutCheckpointSnapshotFlushUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
 
 	startTime2 = time.Now()
 	checkpointObjectTrailer.InodeRecBPlusTreeLayoutNumElements = uint64(len(volume.liveView.inodeRecWrapper.bPlusTreeTracker.bPlusTreeLayout))
@@ -2399,7 +2549,7 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 		}
 		treeLayoutBuf = append(treeLayoutBuf, elementOfBPlusTreeLayoutBuf...)
 	}
-	globals.PutCheckpointTreeLayoutUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+	volume.stats.PutCheckpointTreeLayoutUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
 
 	startTime2 = time.Now()
 	checkpointObjectTrailer.SnapShotIDNumBits = uint64(volume.snapShotIDNumBits)
@@ -2531,7 +2681,17 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 			logger.Fatalf("cstruct.Pack(snapShotDeletedObjectsBPlusTreeObjectLengthStruct, LittleEndian) failed: %v", err)
 		}
 
-		elementOfSnapShotListBuf = make([]byte, 0, 19*globals.uint64Size+snapShotTimeStampBufLenStruct.U64+snapShotNameBufLenStruct.U64)
+		if volumeView.pinned {
+			snapShotPinnedStruct.U64 = 1
+		} else {
+			snapShotPinnedStruct.U64 = 0
+		}
+		snapShotPinnedBuf, err = cstruct.Pack(snapShotPinnedStruct, LittleEndian)
+		if nil != err {
+			logger.Fatalf("cstruct.Pack(snapShotPinnedStruct, LittleEndian) failed: %v", err)
+		}
+
+		elementOfSnapShotListBuf = make([]byte, 0, 20*globals.uint64Size+snapShotTimeStampBufLenStruct.U64+snapShotNameBufLenStruct.U64)
 
 		elementOfSnapShotListBuf = append(elementOfSnapShotListBuf, snapShotNonceBuf...)
 		elementOfSnapShotListBuf = append(elementOfSnapShotListBuf, snapShotIDBuf...)
@@ -2554,12 +2714,13 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 		elementOfSnapShotListBuf = append(elementOfSnapShotListBuf, snapShotDeletedObjectsBPlusTreeObjectNumberBuf...)
 		elementOfSnapShotListBuf = append(elementOfSnapShotListBuf, snapShotDeletedObjectsBPlusTreeObjectOffsetBuf...)
 		elementOfSnapShotListBuf = append(elementOfSnapShotListBuf, snapShotDeletedObjectsBPlusTreeObjectLengthBuf...)
+		elementOfSnapShotListBuf = append(elementOfSnapShotListBuf, snapShotPinnedBuf...)
 
 		snapShotListBuf = append(snapShotListBuf, elementOfSnapShotListBuf...)
 	}
 	checkpointObjectTrailer.SnapShotListTotalSize = uint64(len(snapShotListBuf))
 
-	globals.PutCheckpointSnapshotListUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+	volume.stats.PutCheckpointSnapshotListUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
 
 	startTime2 = time.Now()
 
@@ -2578,27 +2739,41 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 		return
 	}
 
-	err = volume.sendChunkToCheckpointChunkedPutContext(checkpointTrailerBuf)
-	if nil != err {
-		return
-	}
-	globals.PutCheckpointCheckpointTrailerBytes.Add(uint64(len(checkpointTrailerBuf)))
-	chunkedPutBytes += uint64(len(checkpointTrailerBuf))
+	if nil == volume.keyring {
+		err = volume.sendChunkToCheckpointChunkedPutContext(checkpointTrailerBuf)
+		if nil != err {
+			return
+		}
 
-	err = volume.sendChunkToCheckpointChunkedPutContext(treeLayoutBuf)
-	if nil != err {
-		return
-	}
-	globals.PutCheckpointTreeLayoutBytes.Add(uint64(len(treeLayoutBuf)))
-	chunkedPutBytes += uint64(len(treeLayoutBuf))
+		err = volume.sendChunkToCheckpointChunkedPutContext(treeLayoutBuf)
+		if nil != err {
+			return
+		}
 
-	if 0 < len(snapShotListBuf) {
-		err = volume.sendChunkToCheckpointChunkedPutContext(snapShotListBuf)
+		if 0 < len(snapShotListBuf) {
+			err = volume.sendChunkToCheckpointChunkedPutContext(snapShotListBuf)
+			if nil != err {
+				return
+			}
+		}
+
+		encryptionKeyID = 0
+	} else {
+		sealedCheckpointTrailerBuf, encryptionKeyID, err = volume.sealCheckpointTrailer(volume.checkpointChunkedPutContextObjectNumber, checkpointTrailerBuf, treeLayoutBuf, snapShotListBuf)
+		if nil != err {
+			return
+		}
+
+		err = volume.sendChunkToCheckpointChunkedPutContext(sealedCheckpointTrailerBuf)
 		if nil != err {
 			return
 		}
 	}
-	globals.PutCheckpointSnapshotListBytes.Add(uint64(len(snapShotListBuf)))
+	volume.stats.PutCheckpointCheckpointTrailerBytes.Add(uint64(len(checkpointTrailerBuf)))
+	chunkedPutBytes += uint64(len(checkpointTrailerBuf))
+	volume.stats.PutCheckpointTreeLayoutBytes.Add(uint64(len(treeLayoutBuf)))
+	chunkedPutBytes += uint64(len(treeLayoutBuf))
+	volume.stats.PutCheckpointSnapshotListBytes.Add(uint64(len(snapShotListBuf)))
 	chunkedPutBytes += uint64(len(snapShotListBuf))
 
 	checkpointObjectTrailerEndingOffset, err = volume.bytesPutToCheckpointChunkedPutContext()
@@ -2610,7 +2785,7 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 	if nil != err {
 		return
 	}
-	globals.PutCheckpointChunkedPutUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+	volume.stats.PutCheckpointChunkedPutUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
 
 	startTime2 = time.Now()
 
@@ -2624,17 +2799,13 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 
 	// Now update checkpointHeader atomically indicating checkpoint is complete
 
-	volume.checkpointHeader.CheckpointVersion = CheckpointVersion3
+	volume.checkpointHeader.CheckpointVersion = CheckpointVersion4
 
 	volume.checkpointHeader.CheckpointObjectTrailerStructObjectNumber = volume.checkpointChunkedPutContextObjectNumber
 	volume.checkpointHeader.CheckpointObjectTrailerStructObjectLength = checkpointObjectTrailerEndingOffset - checkpointObjectTrailerBeginningOffset
+	volume.checkpointHeader.EncryptionKeyID = encryptionKeyID
 
-	checkpointHeaderValue = fmt.Sprintf("%016X %016X %016X %016X",
-		volume.checkpointHeader.CheckpointVersion,
-		volume.checkpointHeader.CheckpointObjectTrailerStructObjectNumber,
-		volume.checkpointHeader.CheckpointObjectTrailerStructObjectLength,
-		volume.checkpointHeader.ReservedToNonce,
-	)
+	checkpointHeaderValue = formatCheckpointHeaderValue(volume.checkpointHeader)
 
 	if globals.etcdEnabled {
 		volume.checkpointHeaderEtcdRevision, err = volume.putEtcdCheckpointHeader(volume.checkpointHeader, volume.checkpointHeaderEtcdRevision)
@@ -2656,7 +2827,7 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 	if globals.logCheckpointHeaderPosts {
 		logger.Infof("POST'd checkpointHeaderValue %s for volume %s from putCheckpoint()", checkpointHeaderValue, volume.volumeName)
 	}
-	globals.PutCheckpointPostAndEtcdUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+	volume.stats.PutCheckpointPostAndEtcdUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
 
 	volume.checkpointTriggeringEvents = 0
 
@@ -2792,11 +2963,13 @@ func (volume *volumeStruct) putCheckpoint() (err error) {
 		}
 	}
 
+	volume.replicationNoteCheckpointWhileLocked(delayedObjectDeleteList)
+
 	if 0 < len(delayedObjectDeleteList) {
 		volume.backgroundObjectDeleteWG.Add(1)
 		go volume.performDelayedObjectDeletes(delayedObjectDeleteList)
 	}
-	globals.PutCheckpointObjectCleanupUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+	volume.stats.PutCheckpointObjectCleanupUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
 
 	err = nil
 	return
@@ -2866,6 +3039,7 @@ func (volume *volumeStruct) openCheckpointChunkedPutContextIfNecessary() (err er
 		if nil != err {
 			return
 		}
+		volume.replicationNoteCreatedObjectWhileLocked(volume.checkpointContainerName, volume.checkpointChunkedPutContextObjectNumber)
 		if nil != volume.priorView {
 			if volume.postponePriorViewCreatedObjectsPuts {
 				_, ok = volume.postponedPriorViewCreatedObjectsPuts[volume.checkpointChunkedPutContextObjectNumber]
@@ -2976,7 +3150,7 @@ func (volume *volumeStruct) checkpointDaemon() {
 		startTime2 := startTime
 
 		volume.Lock()
-		globals.DaemonPerCheckpointLockWaitUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+		volume.stats.DaemonPerCheckpointLockWaitUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
 
 		// measure the time while the lock is held for the checkpoint
 		startTime2 = time.Now()
@@ -3033,7 +3207,7 @@ func (volume *volumeStruct) checkpointDaemon() {
 		}
 
 		volume.Unlock()
-		globals.DaemonPerCheckpointLockedUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+		volume.stats.DaemonPerCheckpointLockedUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
 
 		// Measure time spent updating statistics (including lock wait
 		// time), time spent waking waiters and calling back listeners
@@ -3109,8 +3283,8 @@ func (volume *volumeStruct) checkpointDaemon() {
 		}
 
 		globals.Unlock()
-		globals.DaemonPerCheckpointStatsUpdateUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
-		globals.DaemonPerCheckpointUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		volume.stats.DaemonPerCheckpointStatsUpdateUsec.Add(uint64(time.Since(startTime2) / time.Microsecond))
+		volume.stats.DaemonPerCheckpointUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
 
 		if exitOnCompletion {
 			return
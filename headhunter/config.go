@@ -20,6 +20,7 @@ import (
 	"github.com/NVIDIA/proxyfs/bucketstats"
 	"github.com/NVIDIA/proxyfs/conf"
 	"github.com/NVIDIA/proxyfs/etcdclient"
+	"github.com/NVIDIA/proxyfs/keyring"
 	"github.com/NVIDIA/proxyfs/logger"
 	"github.com/NVIDIA/proxyfs/swiftclient"
 	"github.com/NVIDIA/proxyfs/trackedlock"
@@ -30,10 +31,18 @@ const (
 	firstNonceToProvide = uint64(2) // Must skip useful values: 0 == unassigned and 1 == RootDirInodeNumber
 )
 
+const (
+	volumeRecsKey = uint64(0) // Never a valid InodeNumber, so VolumeRecs (see volume_recs.go) are kept in inodeRecWrapper.bPlusTree
+)
+
 const (
 	liveSnapShotID = uint64(0)
 )
 
+const (
+	snapShotInodeRecDiffBatchSize = 1024 // InodeRecs examined by SnapShotInodeRecDiff() per volume.Lock()
+)
+
 const (
 	AccountHeaderName           = "X-ProxyFS-BiModal"
 	AccountHeaderNameTranslated = "X-Account-Sysmeta-Proxyfs-Bimodal"
@@ -70,6 +79,7 @@ type volumeViewStruct struct {
 	//                     ID == 2^SnapShotIDNumBits-1 reserved for the .snapshot subdir of a dir
 	snapShotTime           time.Time
 	snapShotName           string
+	pinned                 bool // if true, never deleted by a SnapShotPolicy (persisted in the SnapShotList)
 	inodeRecWrapper        *bPlusTreeWrapperStruct
 	logSegmentRecWrapper   *bPlusTreeWrapperStruct
 	bPlusTreeObjectWrapper *bPlusTreeWrapperStruct
@@ -153,6 +163,8 @@ type volumeStruct struct {
 	checkpointRequestChan                   chan *checkpointRequestStruct
 	checkpointHeader                        *CheckpointHeaderStruct
 	checkpointHeaderEtcdRevision            int64
+	keyring                                 *keyring.KeyringStruct // if != nil, objects written to Swift are sealed
+	replication                             *replicationStruct     // if != nil, completed checkpoints are replicated
 	liveView                                *volumeViewStruct
 	priorView                               *volumeViewStruct
 	postponePriorViewCreatedObjectsPuts     bool
@@ -163,6 +175,7 @@ type volumeStruct struct {
 	viewTreeByName                          sortedmap.LLRBTree // key == volumeViewStruct.Name;  value == *volumeViewStruct
 	availableSnapShotIDList                 *list.List
 	backgroundObjectDeleteWG                sync.WaitGroup
+	stats                                   *volumeStatsStruct
 }
 
 type volumeGroupStruct struct {
@@ -218,6 +231,13 @@ type globalsStruct struct {
 	backgroundObjectDeleteEnabledWG sync.WaitGroup // use to awaken blocked performDelayedObjectDeletes() after EnableObjectDeletions() called
 	backgroundObjectDeleteActiveWG  sync.WaitGroup // used to hold off returning from DisableObjectDeletions() until all deletions cease
 
+	volumeStatsStruct // aggregate of the statistics of every served volume
+}
+
+// volumeStatsStruct holds the statistics of operations on a volume. Each served volume's
+// are registered with package bucketstats as group volumeName with a "volume" label and
+// are also accumulated in those of globals (registered, as before, as group "").
+type volumeStatsStruct struct {
 	FetchNonceUsec                     bucketstats.BucketLog2Round
 	GetInodeRecUsec                    bucketstats.BucketLog2Round
 	GetInodeRecBytes                   bucketstats.BucketLog2Round
@@ -232,12 +252,14 @@ type globalsStruct struct {
 	PutLogSegmentRecUsec               bucketstats.BucketLog2Round
 	DeleteLogSegmentRecUsec            bucketstats.BucketLog2Round
 	IndexedLogSegmentNumberUsec        bucketstats.BucketLog2Round
+	ShareLogSegmentRecUsec             bucketstats.BucketLog2Round
 	GetBPlusTreeObjectUsec             bucketstats.BucketLog2Round
 	GetBPlusTreeObjectBytes            bucketstats.BucketLog2Round
 	PutBPlusTreeObjectUsec             bucketstats.BucketLog2Round
 	PutBPlusTreeObjectBytes            bucketstats.BucketLog2Round
 	DeleteBPlusTreeObjectUsec          bucketstats.BucketLog2Round
 	IndexedBPlusTreeObjectNumberUsec   bucketstats.BucketLog2Round
+	ShareBPlusTreeObjectUsec           bucketstats.BucketLog2Round
 	DoCheckpointUsec                   bucketstats.BucketLog2Round
 	DaemonPerCheckpointUsec            bucketstats.BucketLog2Round
 	DaemonPerCheckpointLockWaitUsec    bucketstats.BucketLog2Round
@@ -265,39 +287,57 @@ type globalsStruct struct {
 	PutCheckpointPostAndEtcdUsec        bucketstats.BucketLog2Round
 	PutCheckpointObjectCleanupUsec      bucketstats.BucketLog2Round
 
+	ReplicationShipUsec        bucketstats.BucketLog2Round
+	ReplicationShipCheckpoints bucketstats.BucketLog2Round
+	ReplicationCopyObjectUsec  bucketstats.BucketLog2Round
+	ReplicationCopyObjectBytes bucketstats.BucketLog2Round
+
 	FetchLayoutReportUsec                     bucketstats.BucketLog2Round
+	FetchReplicationStatusUsec                bucketstats.BucketLog2Round
 	SnapShotCreateByInodeLayerUsec            bucketstats.BucketLog2Round
 	SnapShotDeleteByInodeLayerUsec            bucketstats.BucketLog2Round
+	SnapShotRollbackByInodeLayerUsec          bucketstats.BucketLog2Round
+	SnapShotPinUsec                           bucketstats.BucketLog2Round
+	SnapShotTrappedLogSegmentRecsUsec         bucketstats.BucketLog2Round
 	SnapShotCountUsec                         bucketstats.BucketLog2Round
 	SnapShotLookupByNameUsec                  bucketstats.BucketLog2Round
 	SnapShotListByIDUsec                      bucketstats.BucketLog2Round
 	SnapShotListByTimeUsec                    bucketstats.BucketLog2Round
 	SnapShotListByNameUsec                    bucketstats.BucketLog2Round
+	SnapShotInodeRecDiffUsec                  bucketstats.BucketLog2Round
 	SnapShotU64DecodeUsec                     bucketstats.BucketLog2Round
 	SnapShotIDAndNonceEncodeUsec              bucketstats.BucketLog2Round
 	SnapShotTypeDotSnapShotAndNonceEncodeUsec bucketstats.BucketLog2Round
 
-	GetInodeRecErrors                  bucketstats.Total
-	PutInodeRecErrors                  bucketstats.Total
-	PutInodeRecsErrors                 bucketstats.Total
-	DeleteInodeRecErrors               bucketstats.Total
-	IndexedInodeNumberErrors           bucketstats.Total
-	NextInodeNumberErrors              bucketstats.Total
-	GetLogSegmentRecErrors             bucketstats.Total
-	PutLogSegmentRecErrors             bucketstats.Total
-	DeleteLogSegmentRecErrors          bucketstats.Total
-	IndexedLogSegmentNumberErrors      bucketstats.Total
-	GetBPlusTreeObjectErrors           bucketstats.Total
-	PutBPlusTreeObjectErrors           bucketstats.Total
-	DeleteBPlusTreeObjectErrors        bucketstats.Total
-	IndexedBPlusTreeObjectNumberErrors bucketstats.Total
-	DoCheckpointErrors                 bucketstats.Total
-	PutCheckpointErrors                bucketstats.Total
-	FetchLayoutReportErrors            bucketstats.Total
-	SnapShotCreateByInodeLayerErrors   bucketstats.Total
-	SnapShotDeleteByInodeLayerErrors   bucketstats.Total
-	SnapShotCountErrors                bucketstats.Total
-	SnapShotLookupByNameErrors         bucketstats.Total
+	GetInodeRecErrors                   bucketstats.Total
+	PutInodeRecErrors                   bucketstats.Total
+	PutInodeRecsErrors                  bucketstats.Total
+	DeleteInodeRecErrors                bucketstats.Total
+	IndexedInodeNumberErrors            bucketstats.Total
+	NextInodeNumberErrors               bucketstats.Total
+	GetLogSegmentRecErrors              bucketstats.Total
+	PutLogSegmentRecErrors              bucketstats.Total
+	DeleteLogSegmentRecErrors           bucketstats.Total
+	IndexedLogSegmentNumberErrors       bucketstats.Total
+	ShareLogSegmentRecErrors            bucketstats.Total
+	GetBPlusTreeObjectErrors            bucketstats.Total
+	PutBPlusTreeObjectErrors            bucketstats.Total
+	DeleteBPlusTreeObjectErrors         bucketstats.Total
+	IndexedBPlusTreeObjectNumberErrors  bucketstats.Total
+	ShareBPlusTreeObjectErrors          bucketstats.Total
+	DoCheckpointErrors                  bucketstats.Total
+	PutCheckpointErrors                 bucketstats.Total
+	FetchLayoutReportErrors             bucketstats.Total
+	ReplicationShipErrors               bucketstats.Total
+	ReplicationCopyObjectErrors         bucketstats.Total
+	SnapShotCreateByInodeLayerErrors    bucketstats.Total
+	SnapShotDeleteByInodeLayerErrors    bucketstats.Total
+	SnapShotRollbackByInodeLayerErrors  bucketstats.Total
+	SnapShotPinErrors                   bucketstats.Total
+	SnapShotTrappedLogSegmentRecsErrors bucketstats.Total
+	SnapShotCountErrors                 bucketstats.Total
+	SnapShotLookupByNameErrors          bucketstats.Total
+	SnapShotInodeRecDiffErrors          bucketstats.Total
 }
 
 var globals globalsStruct
@@ -558,7 +598,7 @@ func (dummy *globalsStruct) VolumeGroupDestroyed(confMap conf.ConfMap, volumeGro
 }
 
 func (dummy *globalsStruct) VolumeCreated(confMap conf.ConfMap, volumeName string, volumeGroupName string) (err error) {
-	volume := &volumeStruct{volumeName: volumeName, served: false, checkpointTriggeringEvents: 0}
+	volume := &volumeStruct{volumeName: volumeName, served: false, checkpointTriggeringEvents: 0, stats: &volumeStatsStruct{}}
 	globals.Lock()
 	volumeGroup, ok := globals.volumeGroupMap[volumeGroupName]
 	if !ok {
@@ -655,6 +695,7 @@ func (dummy *globalsStruct) ServeVolume(confMap conf.ConfMap, volumeName string)
 	}
 	volume.served = true
 	globals.Unlock()
+	bucketstats.RegisterWithLabelsAndAggregate("proxyfs.headhunter", volumeName, map[string]string{"volume": volumeName}, volume.stats, &globals)
 	err = volume.up(confMap)
 	if nil != err {
 		err = fmt.Errorf("headhunter.ServeVolume() failed to \"up\" Volume (%s): %v", volumeName, err)
@@ -680,6 +721,7 @@ func (dummy *globalsStruct) UnserveVolume(confMap conf.ConfMap, volumeName strin
 	volume.served = false
 	globals.Unlock()
 	err = volume.down(confMap)
+	bucketstats.UnRegister("proxyfs.headhunter", volumeName)
 	if nil != err {
 		err = fmt.Errorf("headhunter.UnserveVolume() failed to \"down\" Volume (%s): %v", volumeName, err)
 		return
@@ -696,6 +738,23 @@ func (dummy *globalsStruct) SignaledStart(confMap conf.ConfMap) (err error) {
 	return nil
 }
 func (dummy *globalsStruct) SignaledFinish(confMap conf.ConfMap) (err error) {
+	var (
+		volume *volumeStruct
+	)
+
+	// Pick up any rotated keys for encrypted volumes
+
+	globals.Lock()
+	for _, volume = range globals.volumeMap {
+		if volume.served && (nil != volume.keyring) {
+			err = volume.keyring.Reload()
+			if nil != err {
+				logger.WarnfWithError(err, "Continuing to use prior keys for Volume %s", volume.volumeName)
+			}
+		}
+	}
+	globals.Unlock()
+
 	return nil
 }
 
@@ -833,6 +892,11 @@ func (volume *volumeStruct) up(confMap conf.ConfMap) (err error) {
 		autoFormat = false // Default to false if not present
 	}
 
+	volume.keyring, err = keyring.NewKeyring(confMap, volume.volumeName)
+	if nil != err {
+		return
+	}
+
 	err = volume.getCheckpoint(autoFormat)
 	if nil != err {
 		logger.Warnf("Initial attempt to mount Volume %s failed: %v", volume.volumeName, err)
@@ -856,6 +920,11 @@ func (volume *volumeStruct) up(confMap conf.ConfMap) (err error) {
 		}
 	}
 
+	err = volume.replicationUp(confMap, volumeSectionName)
+	if nil != err {
+		return
+	}
+
 	go volume.checkpointDaemon()
 
 	err = nil
@@ -876,6 +945,8 @@ func (volume *volumeStruct) down(confMap conf.ConfMap) (err error) {
 
 	err = checkpointRequest.err
 
+	volume.replicationDown()
+
 	volume.backgroundObjectDeleteWG.Wait()
 
 	return
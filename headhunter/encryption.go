@@ -0,0 +1,114 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package headhunter
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/proxyfs/keyring"
+)
+
+// A volume configured with an EncryptionKeyProvider seals (see package keyring)
+// everything it writes to Swift. Each B+Tree node is sealed individually as it is
+// appended to a checkpoint object. The checkpointObjectTrailerV3Struct, along with
+// the arrays that follow it, is sealed as a single chunk. The KeyID used to seal
+// that trailer is recorded in the CheckpointHeader. LogSegments are sealed by the
+// inode package via Seal() and Unseal() (or by PFSAgent using its own keyring).
+//
+// The additional data authenticated with each sealed chunk is its objectNumber
+// and objectOffset (see keyring.ObjectAdditionalData()), so chunks cannot be
+// swapped undetected. As the trailer is read via ObjectTail() (and hence without
+// knowing its objectOffset), it is sealed with the otherwise impossible
+// objectOffset checkpointTrailerSealOffset.
+//
+// Note that the tracked (and reported) layout of checkpoint objects continues to
+// count the unsealed bytes of each B+Tree node.
+
+const (
+	checkpointTrailerSealOffset = ^uint64(0)
+)
+
+// checkCheckpointHeaderEncryption ensures the volume's encryption configuration
+// matches that of its most recent checkpoint.
+//
+func (volume *volumeStruct) checkCheckpointHeaderEncryption() (err error) {
+	if 0 == volume.checkpointHeader.CheckpointObjectTrailerStructObjectNumber {
+		// No checkpoint yet... so volume may be configured either way
+
+		err = nil
+		return
+	}
+
+	if nil == volume.keyring {
+		if 0 != volume.checkpointHeader.EncryptionKeyID {
+			err = fmt.Errorf("Volume %s is encrypted (KeyID %d) but has no EncryptionKeyProvider", volume.volumeName, volume.checkpointHeader.EncryptionKeyID)
+			return
+		}
+	} else {
+		if 0 == volume.checkpointHeader.EncryptionKeyID {
+			err = fmt.Errorf("Volume %s was not formatted for encryption", volume.volumeName)
+			return
+		}
+
+		err = volume.keyring.CheckKeyID(volume.checkpointHeader.EncryptionKeyID)
+		if nil != err {
+			return
+		}
+	}
+
+	err = nil
+	return
+}
+
+func (volume *volumeStruct) IsEncrypted() (encrypted bool) {
+	encrypted = (nil != volume.keyring)
+	return
+}
+
+func (volume *volumeStruct) Seal(objectNumber uint64, objectOffset uint64, buf []byte) (sealedBuf []byte, err error) {
+	if nil == volume.keyring {
+		err = fmt.Errorf("Volume %s is not encrypted", volume.volumeName)
+		return
+	}
+
+	sealedBuf, err = volume.keyring.Seal(buf, keyring.ObjectAdditionalData(objectNumber, objectOffset))
+
+	return
+}
+
+func (volume *volumeStruct) Unseal(objectNumber uint64, objectOffset uint64, sealedBuf []byte) (buf []byte, err error) {
+	if nil == volume.keyring {
+		err = fmt.Errorf("Volume %s is not encrypted", volume.volumeName)
+		return
+	}
+
+	buf, err = volume.keyring.Open(sealedBuf, keyring.ObjectAdditionalData(objectNumber, objectOffset))
+
+	return
+}
+
+// sealCheckpointTrailer returns the sealed concatenation of checkpointTrailerBuf
+// and the bufs that follow it along with the KeyID used.
+//
+func (volume *volumeStruct) sealCheckpointTrailer(objectNumber uint64, checkpointTrailerBuf []byte, followingBufs ...[]byte) (sealedBuf []byte, keyID uint64, err error) {
+	var (
+		buf          []byte
+		followingBuf []byte
+	)
+
+	buf = make([]byte, 0, len(checkpointTrailerBuf))
+	buf = append(buf, checkpointTrailerBuf...)
+	for _, followingBuf = range followingBufs {
+		buf = append(buf, followingBuf...)
+	}
+
+	sealedBuf, err = volume.Seal(objectNumber, checkpointTrailerSealOffset, buf)
+	if nil != err {
+		return
+	}
+
+	keyID = keyring.SealedKeyID(sealedBuf)
+
+	return
+}
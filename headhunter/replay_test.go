@@ -0,0 +1,222 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package headhunter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/ramswift"
+	"github.com/NVIDIA/proxyfs/swiftclient"
+	"github.com/NVIDIA/proxyfs/transitions"
+	"github.com/NVIDIA/proxyfs/utils"
+)
+
+// TestReplayDeleteLogSegmentRec verifies that replaying a DeleteLogSegmentRec()
+// following a crash queues the deletion of the LogSegment's Object in its
+// ContainerName (i.e. not including the checksums et. al. following the NUL).
+func TestReplayDeleteLogSegmentRec(t *testing.T) {
+	var (
+		chunkedPutContext      swiftclient.ChunkedPutContext
+		confMap                conf.ConfMap
+		confStrings            []string
+		containerNameAsValue   interface{}
+		doneChan               chan bool
+		err                    error
+		logSegmentNumber       uint64
+		ok                     bool
+		replayLogDir           string
+		signalHandlerIsArmedWG sync.WaitGroup
+		volume                 *volumeStruct
+		volumeHandle           VolumeHandle
+	)
+
+	replayLogDir, err = ioutil.TempDir("", "TestVolume_Replay_Log_")
+	if nil != err {
+		t.Fatalf("ioutil.TempDir() returned error: %v", err)
+	}
+	defer os.RemoveAll(replayLogDir)
+
+	confStrings = []string{
+		"Logging.LogFilePath=/dev/null",
+		"Stats.IPAddr=localhost",
+		"Stats.UDPPort=52184",
+		"Stats.BufferLength=100",
+		"Stats.MaxLatency=1s",
+		"SwiftClient.NoAuthIPAddr=127.0.0.1",
+
+		"TrackedLock.LockHoldTimeLimit=0s",
+		"TrackedLock.LockCheckPeriod=0s",
+
+		"SwiftClient.NoAuthTCPPort=9999",
+		"SwiftClient.Timeout=10s",
+		"SwiftClient.RetryLimit=0",
+		"SwiftClient.RetryLimitObject=0",
+		"SwiftClient.RetryDelay=1s",
+		"SwiftClient.RetryDelayObject=1s",
+		"SwiftClient.RetryExpBackoff=1.2",
+		"SwiftClient.RetryExpBackoffObject=2.0",
+		"SwiftClient.ChunkedConnectionPoolSize=64",
+		"SwiftClient.NonChunkedConnectionPoolSize=32",
+		"Cluster.WhoAmI=Peer0",
+		"Peer:Peer0.ReadCacheQuotaFraction=0.20",
+		"Volume:TestVolume.PrimaryPeer=Peer0",
+		"Volume:TestVolume.AccountName=TestAccount",
+		"Volume:TestVolume.CheckpointContainerName=.__checkpoint__",
+		"Volume:TestVolume.CheckpointContainerStoragePolicy=gold",
+		"Volume:TestVolume.CheckpointInterval=10s",
+		"Volume:TestVolume.MaxFlushSize=10000000",
+		"Volume:TestVolume.NonceValuesToReserve=100",
+		"Volume:TestVolume.MaxInodesPerMetadataNode=32",
+		"Volume:TestVolume.MaxLogSegmentsPerMetadataNode=64",
+		"Volume:TestVolume.MaxDirFileNodesPerMetadataNode=16",
+		"Volume:TestVolume.ReplayLogFileName=" + filepath.Join(replayLogDir, "TestVolume.rlog"),
+		"VolumeGroup:TestVolumeGroup.VolumeList=TestVolume",
+		"VolumeGroup:TestVolumeGroup.VirtualIPAddr=",
+		"VolumeGroup:TestVolumeGroup.PrimaryPeer=Peer0",
+		"FSGlobals.VolumeGroupList=TestVolumeGroup",
+		"FSGlobals.CheckpointHeaderConsensusAttempts=5",
+		"FSGlobals.MountRetryLimit=6",
+		"FSGlobals.MountRetryDelay=1s",
+		"FSGlobals.MountRetryExpBackoff=2",
+		"FSGlobals.LogCheckpointHeaderPosts=true",
+		"FSGlobals.TryLockBackoffMin=10ms",
+		"FSGlobals.TryLockBackoffMax=50ms",
+		"FSGlobals.TryLockSerializationThreshhold=5",
+		"FSGlobals.SymlinkMax=32",
+		"FSGlobals.CoalesceElementChunkSize=16",
+		"FSGlobals.InodeRecCacheEvictLowLimit=10000",
+		"FSGlobals.InodeRecCacheEvictHighLimit=10010",
+		"FSGlobals.LogSegmentRecCacheEvictLowLimit=10000",
+		"FSGlobals.LogSegmentRecCacheEvictHighLimit=10010",
+		"FSGlobals.BPlusTreeObjectCacheEvictLowLimit=10000",
+		"FSGlobals.BPlusTreeObjectCacheEvictHighLimit=10010",
+		"FSGlobals.EtcdEnabled=false",
+		"RamSwiftInfo.MaxAccountNameLength=256",
+		"RamSwiftInfo.MaxContainerNameLength=256",
+		"RamSwiftInfo.MaxObjectNameLength=1024",
+		"RamSwiftInfo.AccountListingLimit=10000",
+		"RamSwiftInfo.ContainerListingLimit=10000",
+		"Volume:TestVolume.AutoFormat=true",
+	}
+
+	// Launch a ramswift instance
+
+	signalHandlerIsArmedWG.Add(1)
+	doneChan = make(chan bool, 1) // Must be buffered to avoid race
+
+	go ramswift.Daemon("/dev/null", confStrings, &signalHandlerIsArmedWG, doneChan, unix.SIGTERM)
+
+	signalHandlerIsArmedWG.Wait()
+
+	confMap, err = conf.MakeConfMapFromStrings(confStrings)
+	if nil != err {
+		t.Fatalf("conf.MakeConfMapFromStrings(confStrings) returned error: %v", err)
+	}
+
+	err = transitions.Up(confMap)
+	if nil != err {
+		t.Fatalf("transitions.Up() returned error: %v", err)
+	}
+
+	volumeHandle, err = FetchVolumeHandle("TestVolume")
+	if nil != err {
+		t.Fatalf("FetchVolumeHandle(\"TestVolume\") returned error: %v", err)
+	}
+
+	volume = volumeHandle.(*volumeStruct)
+
+	// Create a LogSegment whose LogSegmentRec carries more than just its ContainerName
+
+	logSegmentNumber = volume.FetchNonce()
+
+	err = swiftclient.ContainerPut("TestAccount", "TestContainer", make(map[string][]string))
+	if nil != err {
+		t.Fatalf("swiftclient.ContainerPut() returned error: %v", err)
+	}
+
+	chunkedPutContext, err = swiftclient.ObjectFetchChunkedPutContext("TestAccount", "TestContainer", utils.Uint64ToHexStr(logSegmentNumber), "")
+	if nil != err {
+		t.Fatalf("swiftclient.ObjectFetchChunkedPutContext() returned error: %v", err)
+	}
+	err = chunkedPutContext.SendChunk([]byte("LogSegment"))
+	if nil != err {
+		t.Fatalf("chunkedPutContext.SendChunk() returned error: %v", err)
+	}
+	err = chunkedPutContext.Close()
+	if nil != err {
+		t.Fatalf("chunkedPutContext.Close() returned error: %v", err)
+	}
+
+	err = volume.PutLogSegmentRec(logSegmentNumber, []byte("TestContainer\x00Checksums"))
+	if nil != err {
+		t.Fatalf("PutLogSegmentRec() returned error: %v", err)
+	}
+
+	err = volume.DoCheckpoint()
+	if nil != err {
+		t.Fatalf("DoCheckpoint() returned error: %v", err)
+	}
+
+	// Delete it such that only the Replay Log records the deletion
+
+	err = volume.DeleteLogSegmentRec(logSegmentNumber)
+	if nil != err {
+		t.Fatalf("DeleteLogSegmentRec() returned error: %v", err)
+	}
+
+	// "Crash" before the next checkpoint by reloading the last checkpoint and replaying the Replay Log
+
+	volume.Lock()
+
+	err = volume.replayLogFile.Close()
+	if nil != err {
+		t.Fatalf("volume.replayLogFile.Close() returned error: %v", err)
+	}
+	volume.replayLogFile = nil
+
+	err = volume.getCheckpoint(false)
+	if nil != err {
+		t.Fatalf("getCheckpoint() returned error: %v", err)
+	}
+
+	containerNameAsValue, ok, err = volume.liveView.deletedObjectsWrapper.bPlusTree.GetByKey(logSegmentNumber)
+	if nil != err {
+		t.Fatalf("deletedObjectsWrapper.bPlusTree.GetByKey() returned error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("Replay of DeleteLogSegmentRec() failed to queue the deletion of its Object")
+	}
+	if !bytes.Equal([]byte("TestContainer"), containerNameAsValue.([]byte)) {
+		t.Fatalf("Replay of DeleteLogSegmentRec() queued the deletion of its Object in container \"%s\"", containerNameAsValue.([]byte))
+	}
+
+	volume.Unlock()
+
+	// The checkpoint performed by transitions.Down() should delete the Object
+
+	err = transitions.Down(confMap)
+	if nil != err {
+		t.Fatalf("transitions.Down() returned error: %v", err)
+	}
+
+	_, err = swiftclient.ObjectHead("TestAccount", "TestContainer", utils.Uint64ToHexStr(logSegmentNumber))
+	if !blunder.Is(err, blunder.NotFoundError) {
+		t.Fatalf("LogSegment Object should have been deleted (err: %v)", err)
+	}
+
+	// Send ourself a SIGTERM to terminate ramswift.Daemon()
+
+	unix.Kill(unix.Getpid(), unix.SIGTERM)
+
+	_ = <-doneChan
+}
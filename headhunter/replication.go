@@ -0,0 +1,755 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package headhunter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/swiftclient"
+	"github.com/NVIDIA/proxyfs/trackedlock"
+	"github.com/NVIDIA/proxyfs/utils"
+)
+
+// A volume configured with a ReplicationAccountName asynchronously replicates each
+// completed checkpoint, along with every LogSegment and checkpoint object it references,
+// to that account (optionally at a second Swift endpoint). Objects are noted as they are
+// created (see PutLogSegmentRec() and openCheckpointChunkedPutContextIfNecessary()) while
+// the objects a checkpoint makes unreferenced are precisely its delayedObjectDeleteList
+// (i.e. those drained from the liveView's deletedObjects). Each completed checkpoint thus
+// appends a replicationBatchStruct to the replication backlog.
+//
+// The replicator ships the backlog in order. Should it fall behind, the pending batches
+// are coalesced... an object both created and deleted by the coalesced checkpoints need
+// never be shipped. A batch is shipped by first copying each created object, then POSTing
+// the checkpoint header to the replica's checkpoint container, and only then deleting each
+// deleted object from the replica. The replica is therefore always mountable (read-only,
+// see [Volume]ReadOnly) at the last fully replicated checkpoint.
+//
+// An object deleted locally before it could be copied (e.g. while the replicator was
+// unreachable) is necessarily in the delayedObjectDeleteList of a later batch, so the
+// in-flight batch is simply coalesced with its successors and retried.
+//
+// As created objects are only noted in memory, the first checkpoint after the volume is
+// served ships every object the volume references (skipping those already replicated).
+
+const (
+	replicationDefaultRetryDelay = 10 * time.Second
+	replicationDefaultTimeout    = 60 * time.Second
+)
+
+type replicationObjectStruct struct {
+	containerName string
+	objectNumber  uint64
+}
+
+type replicationBatchStruct struct {
+	checkpointHeaderValue string
+	firstCheckpointTime   time.Time                        // completion time of the first coalesced checkpoint
+	lastCheckpointTime    time.Time                        // completion time of the last coalesced checkpoint
+	checkpoints           uint64                           // number of coalesced checkpoints
+	verifyCopies          bool                             // if true, objects already present in the replica are not copied
+	copies                map[replicationObjectStruct]bool // value == true once copied
+	deletes               map[replicationObjectStruct]struct{}
+}
+
+type replicationStruct struct {
+	trackedlock.Mutex
+	volume                     *volumeStruct
+	accountName                string
+	accountURL                 string // e.g. "http://127.0.0.1:8080/v1/AUTH_test"
+	retryDelay                 time.Duration
+	httpClient                 *http.Client
+	pendingCopies              map[replicationObjectStruct]struct{} // protected by volume.Lock()
+	pendingVerifyCopies        bool                                 // protected by volume.Lock()
+	knownContainers            map[string]struct{}                  // only accessed by replicator()
+	backlog                    []*replicationBatchStruct
+	inFlight                   *replicationBatchStruct
+	replicatedCheckpointHeader string
+	replicatedCheckpointTime   time.Time
+	copiedObjects              uint64
+	copiedBytes                uint64
+	deletedObjects             uint64
+	lastErr                    error
+	wakeChan                   chan struct{}
+	stopChan                   chan struct{}
+	replicatorWG               sync.WaitGroup
+}
+
+// replicationUp parses the volume's replication configuration. If replication is
+// configured, the objects referenced by the just mounted checkpoint are noted for
+// copying and the replicator is launched.
+func (volume *volumeStruct) replicationUp(confMap conf.ConfMap, volumeSectionName string) (err error) {
+	var (
+		accountName   string
+		noAuthIPAddr  string
+		noAuthTCPPort uint16
+		replication   *replicationStruct
+		retryDelay    time.Duration
+		timeout       time.Duration
+	)
+
+	volume.replication = nil
+
+	accountName, err = confMap.FetchOptionValueString(volumeSectionName, "ReplicationAccountName")
+	if (nil != err) || ("" == accountName) {
+		err = nil // Replication not configured
+		return
+	}
+
+	noAuthIPAddr, err = confMap.FetchOptionValueString(volumeSectionName, "ReplicationNoAuthIPAddr")
+	if nil != err {
+		if accountName == volume.accountName {
+			err = fmt.Errorf("[%v]ReplicationAccountName must differ from AccountName unless ReplicationNoAuthIPAddr is specified", volumeSectionName)
+			return
+		}
+		noAuthIPAddr, err = confMap.FetchOptionValueString("SwiftClient", "NoAuthIPAddr")
+		if nil != err {
+			noAuthIPAddr = "127.0.0.1" // If not present, default to the same (local) Swift endpoint
+		}
+	}
+
+	noAuthTCPPort, err = confMap.FetchOptionValueUint16(volumeSectionName, "ReplicationNoAuthTCPPort")
+	if nil != err {
+		noAuthTCPPort, err = confMap.FetchOptionValueUint16("SwiftClient", "NoAuthTCPPort")
+		if nil != err {
+			return
+		}
+	}
+
+	retryDelay, err = confMap.FetchOptionValueDuration(volumeSectionName, "ReplicationRetryDelay")
+	if nil != err {
+		retryDelay = replicationDefaultRetryDelay // If not present, default to replicationDefaultRetryDelay
+	}
+
+	timeout, err = confMap.FetchOptionValueDuration(volumeSectionName, "ReplicationTimeout")
+	if nil != err {
+		timeout = replicationDefaultTimeout // If not present, default to replicationDefaultTimeout
+	}
+
+	replication = &replicationStruct{
+		volume:              volume,
+		accountName:         accountName,
+		accountURL:          "http://" + noAuthIPAddr + ":" + strconv.Itoa(int(noAuthTCPPort)) + "/v1/" + accountName,
+		retryDelay:          retryDelay,
+		httpClient:          &http.Client{Timeout: timeout},
+		pendingVerifyCopies: true,
+		knownContainers:     make(map[string]struct{}),
+		backlog:             make([]*replicationBatchStruct, 0),
+		wakeChan:            make(chan struct{}, 1),
+		stopChan:            make(chan struct{}),
+	}
+
+	volume.Lock()
+
+	replication.pendingCopies, err = volume.replicationEnumerateObjectsWhileLocked()
+	if nil != err {
+		volume.Unlock()
+		err = fmt.Errorf("Unable to enumerate objects of volume %s to replicate: %v", volume.volumeName, err)
+		return
+	}
+
+	volume.checkpointTriggeringEvents++ // Ensure the enumerated objects are shipped at the next checkpoint
+
+	volume.replication = replication
+
+	volume.Unlock()
+
+	replication.replicatorWG.Add(1)
+	go replication.replicator()
+
+	logger.Infof("Volume %s replicating to %s", volume.volumeName, replication.accountURL)
+
+	err = nil
+	return
+}
+
+// replicationDown stops the replicator (if any). Any backlog is abandoned... it will
+// be recovered by the full enumeration performed when the volume is next served.
+func (volume *volumeStruct) replicationDown() {
+	if nil == volume.replication {
+		return
+	}
+
+	close(volume.replication.stopChan)
+	volume.replication.replicatorWG.Wait()
+
+	volume.Lock()
+	volume.replication = nil
+	volume.Unlock()
+}
+
+// replicationEnumerateObjectsWhileLocked returns every LogSegment and checkpoint
+// object referenced by the live view or any SnapShot.
+func (volume *volumeStruct) replicationEnumerateObjectsWhileLocked() (objects map[replicationObjectStruct]struct{}, err error) {
+	var (
+		bytesUsed         uint64
+		bPlusTreeTracker  *bPlusTreeTrackerStruct
+		objectNumber      uint64
+		ok                bool
+		viewIndex         int
+		viewTreeLen       int
+		volumeView        *volumeViewStruct
+		volumeViewAsValue sortedmap.Value
+	)
+
+	objects = make(map[replicationObjectStruct]struct{})
+
+	err = volume.replicationEnumerateBPlusTreeWhileLocked(objects, volume.liveView.logSegmentRecWrapper.bPlusTree)
+	if nil != err {
+		return
+	}
+
+	viewTreeLen, err = volume.viewTreeByNonce.Len()
+	if nil != err {
+		return
+	}
+
+	for viewIndex = 0; viewIndex < viewTreeLen; viewIndex++ {
+		_, volumeViewAsValue, ok, err = volume.viewTreeByNonce.GetByIndex(viewIndex)
+		if nil != err {
+			return
+		}
+		if !ok {
+			logger.Fatalf("Logic error - viewTreeByNonce.GetByIndex(%v) returned ok == false", viewIndex)
+		}
+
+		volumeView = volumeViewAsValue.(*volumeViewStruct)
+
+		err = volume.replicationEnumerateBPlusTreeWhileLocked(objects, volumeView.logSegmentRecWrapper.bPlusTree)
+		if nil != err {
+			return
+		}
+
+		// Objects no longer referenced by any later view (LogSegments and checkpoint objects alike)
+
+		err = volume.replicationEnumerateBPlusTreeWhileLocked(objects, volumeView.deletedObjectsWrapper.bPlusTree)
+		if nil != err {
+			return
+		}
+	}
+
+	for _, bPlusTreeTracker = range []*bPlusTreeTrackerStruct{
+		volume.liveView.inodeRecWrapper.bPlusTreeTracker,
+		volume.liveView.logSegmentRecWrapper.bPlusTreeTracker,
+		volume.liveView.bPlusTreeObjectWrapper.bPlusTreeTracker,
+		volume.liveView.createdObjectsWrapper.bPlusTreeTracker,
+		volume.liveView.deletedObjectsWrapper.bPlusTreeTracker,
+	} {
+		for objectNumber, bytesUsed = range bPlusTreeTracker.bPlusTreeLayout {
+			if 0 < bytesUsed {
+				objects[replicationObjectStruct{containerName: volume.checkpointContainerName, objectNumber: objectNumber}] = struct{}{}
+			}
+		}
+	}
+
+	err = nil
+	return
+}
+
+// replicationEnumerateBPlusTreeWhileLocked adds to objects each key of bPlusTree
+// (whose values are ContainerNames optionally followed by NUL and other data).
+func (volume *volumeStruct) replicationEnumerateBPlusTreeWhileLocked(objects map[replicationObjectStruct]struct{}, bPlusTree sortedmap.BPlusTree) (err error) {
+	var (
+		keyAsKey     sortedmap.Key
+		ok           bool
+		treeIndex    int
+		treeLen      int
+		valueAsValue sortedmap.Value
+	)
+
+	treeLen, err = bPlusTree.Len()
+	if nil != err {
+		return
+	}
+
+	for treeIndex = 0; treeIndex < treeLen; treeIndex++ {
+		keyAsKey, valueAsValue, ok, err = bPlusTree.GetByIndex(treeIndex)
+		if nil != err {
+			return
+		}
+		if !ok {
+			err = fmt.Errorf("bPlusTree.GetByIndex(%v) returned ok == false", treeIndex)
+			return
+		}
+
+		objects[replicationObjectStruct{containerName: logSegmentRecContainerName(valueAsValue.([]byte)), objectNumber: keyAsKey.(uint64)}] = struct{}{}
+	}
+
+	err = nil
+	return
+}
+
+// replicationNoteCreatedObjectWhileLocked records an object to be copied by the
+// replication batch of the next checkpoint.
+func (volume *volumeStruct) replicationNoteCreatedObjectWhileLocked(containerName string, objectNumber uint64) {
+	if nil == volume.replication {
+		return
+	}
+
+	volume.replication.pendingCopies[replicationObjectStruct{containerName: containerName, objectNumber: objectNumber}] = struct{}{}
+}
+
+// replicationNoteCheckpointWhileLocked appends the replication batch for the
+// just completed checkpoint to the replication backlog.
+func (volume *volumeStruct) replicationNoteCheckpointWhileLocked(delayedObjectDeleteList []delayedObjectDeleteStruct) {
+	var (
+		batch               *replicationBatchStruct
+		delayedObjectDelete delayedObjectDeleteStruct
+		object              replicationObjectStruct
+		ok                  bool
+		replication         *replicationStruct
+	)
+
+	replication = volume.replication
+	if nil == replication {
+		return
+	}
+
+	batch = &replicationBatchStruct{
+		checkpointHeaderValue: formatCheckpointHeaderValue(volume.checkpointHeader),
+		firstCheckpointTime:   time.Now(),
+		checkpoints:           1,
+		verifyCopies:          replication.pendingVerifyCopies,
+		copies:                make(map[replicationObjectStruct]bool, len(replication.pendingCopies)),
+		deletes:               make(map[replicationObjectStruct]struct{}, len(delayedObjectDeleteList)),
+	}
+
+	batch.lastCheckpointTime = batch.firstCheckpointTime
+
+	for object = range replication.pendingCopies {
+		batch.copies[object] = false
+	}
+
+	for _, delayedObjectDelete = range delayedObjectDeleteList {
+		object = replicationObjectStruct{containerName: delayedObjectDelete.containerName, objectNumber: delayedObjectDelete.objectNumber}
+		_, ok = batch.copies[object]
+		if ok {
+			delete(batch.copies, object)
+			if !batch.verifyCopies {
+				continue // Never referenced by any replicated checkpoint
+			}
+		}
+		batch.deletes[object] = struct{}{}
+	}
+
+	replication.pendingCopies = make(map[replicationObjectStruct]struct{})
+	replication.pendingVerifyCopies = false
+
+	replication.Lock()
+	replication.backlog = append(replication.backlog, batch)
+	replication.Unlock()
+
+	select {
+	case replication.wakeChan <- struct{}{}:
+	default:
+		// replicator() already awakened
+	}
+}
+
+// coalesce merges laterBatch into batch. An object copied by batch but deleted by
+// laterBatch need only be deleted from the replica if it was already copied there.
+func (batch *replicationBatchStruct) coalesce(laterBatch *replicationBatchStruct) {
+	var (
+		copied bool
+		object replicationObjectStruct
+		ok     bool
+	)
+
+	for object, copied = range laterBatch.copies {
+		batch.copies[object] = copied
+	}
+
+	for object = range laterBatch.deletes {
+		copied, ok = batch.copies[object]
+		if ok {
+			delete(batch.copies, object)
+			if !copied && !batch.verifyCopies {
+				continue
+			}
+		}
+		batch.deletes[object] = struct{}{}
+	}
+
+	batch.checkpointHeaderValue = laterBatch.checkpointHeaderValue
+	batch.lastCheckpointTime = laterBatch.lastCheckpointTime
+	batch.checkpoints += laterBatch.checkpoints
+	batch.verifyCopies = batch.verifyCopies || laterBatch.verifyCopies
+}
+
+func (replication *replicationStruct) replicator() {
+	var (
+		err error
+	)
+
+	defer replication.replicatorWG.Done()
+
+	for {
+		select {
+		case <-replication.wakeChan:
+			// Ship whatever is in the backlog
+		case <-replication.stopChan:
+			return
+		}
+
+		for {
+			err = replication.shipBacklog()
+			if nil == err {
+				break
+			}
+
+			logger.WarnfWithError(err, "Replication of volume %s to %s failed", replication.volume.volumeName, replication.accountURL)
+
+			replication.Lock()
+			replication.lastErr = err
+			if blunder.Is(err, blunder.NotFoundError) && (0 < len(replication.backlog)) {
+				// Object was deleted by a later checkpoint... so coalesce with it immediately
+				replication.Unlock()
+				continue
+			}
+			replication.Unlock()
+
+			select {
+			case <-time.After(replication.retryDelay):
+				// Retry
+			case <-replication.stopChan:
+				return
+			}
+		}
+	}
+}
+
+// shipBacklog ships (coalesced) batches until the backlog is empty.
+func (replication *replicationStruct) shipBacklog() (err error) {
+	var (
+		batch *replicationBatchStruct
+	)
+
+	for {
+		replication.Lock()
+		batch = replication.inFlight
+		if nil == batch {
+			if 0 == len(replication.backlog) {
+				replication.Unlock()
+				err = nil
+				return
+			}
+			batch = replication.backlog[0]
+			replication.backlog = replication.backlog[1:]
+		}
+		for 0 < len(replication.backlog) {
+			batch.coalesce(replication.backlog[0])
+			replication.backlog = replication.backlog[1:]
+		}
+		replication.inFlight = batch
+		replication.Unlock()
+
+		err = replication.ship(batch)
+		if nil != err {
+			return
+		}
+
+		replication.Lock()
+		replication.inFlight = nil
+		replication.replicatedCheckpointHeader = batch.checkpointHeaderValue
+		replication.replicatedCheckpointTime = batch.lastCheckpointTime
+		replication.lastErr = nil
+		replication.Unlock()
+	}
+}
+
+func (replication *replicationStruct) ship(batch *replicationBatchStruct) (err error) {
+	var (
+		checkpointContainerHeaders map[string][]string
+		copied                     bool
+		object                     replicationObjectStruct
+	)
+
+	startTime := time.Now()
+	defer func() {
+		replication.volume.stats.ReplicationShipUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			replication.volume.stats.ReplicationShipErrors.Add(1)
+		}
+	}()
+
+	if 0 == len(replication.knownContainers) {
+		err = replication.ensureAccount()
+		if nil != err {
+			return
+		}
+	}
+
+	for object, copied = range batch.copies {
+		if copied {
+			continue
+		}
+
+		select {
+		case <-replication.stopChan:
+			err = fmt.Errorf("replication stopped")
+			return
+		default:
+		}
+
+		err = replication.copyObject(object, batch.verifyCopies)
+		if nil != err {
+			return
+		}
+
+		replication.Lock()
+		batch.copies[object] = true
+		replication.Unlock()
+	}
+
+	checkpointContainerHeaders = make(map[string][]string)
+	checkpointContainerHeaders[CheckpointHeaderName] = []string{batch.checkpointHeaderValue}
+
+	err = replication.ensureContainer(replication.volume.checkpointContainerName)
+	if nil != err {
+		return
+	}
+
+	_, err = replication.request(http.MethodPost, replication.volume.checkpointContainerName, "", checkpointContainerHeaders, nil, http.StatusNoContent)
+	if nil != err {
+		return
+	}
+
+	for object = range batch.deletes {
+		replication.deleteObject(object)
+	}
+
+	replication.volume.stats.ReplicationShipCheckpoints.Add(batch.checkpoints)
+
+	err = nil
+	return
+}
+
+func (replication *replicationStruct) ensureAccount() (err error) {
+	var (
+		accountHeaders map[string][]string
+		statusCode     int
+	)
+
+	// Mark Account as bi-modal (as is done by format)
+
+	accountHeaders = make(map[string][]string)
+	accountHeaders[AccountHeaderName] = []string{AccountHeaderValue}
+
+	statusCode, err = replication.request(http.MethodPost, "", "", accountHeaders, nil, http.StatusNoContent, http.StatusNotFound)
+	if (nil == err) && (http.StatusNotFound == statusCode) {
+		_, err = replication.request(http.MethodPut, "", "", accountHeaders, nil, http.StatusCreated, http.StatusAccepted)
+	}
+
+	return
+}
+
+func (replication *replicationStruct) ensureContainer(containerName string) (err error) {
+	var (
+		ok bool
+	)
+
+	_, ok = replication.knownContainers[containerName]
+	if ok {
+		err = nil
+		return
+	}
+
+	_, err = replication.request(http.MethodPut, containerName, "", nil, nil, http.StatusCreated, http.StatusAccepted)
+	if nil != err {
+		return
+	}
+
+	replication.knownContainers[containerName] = struct{}{}
+
+	return
+}
+
+func (replication *replicationStruct) copyObject(object replicationObjectStruct, verifyCopy bool) (err error) {
+	var (
+		buf        []byte
+		objectName string
+		statusCode int
+	)
+
+	startTime := time.Now()
+	defer func() {
+		replication.volume.stats.ReplicationCopyObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			replication.volume.stats.ReplicationCopyObjectErrors.Add(1)
+		}
+	}()
+
+	objectName = utils.Uint64ToHexStr(object.objectNumber)
+
+	err = replication.ensureContainer(object.containerName)
+	if nil != err {
+		return
+	}
+
+	if verifyCopy {
+		statusCode, err = replication.request(http.MethodHead, object.containerName, objectName, nil, nil, http.StatusOK, http.StatusNotFound)
+		if nil != err {
+			return
+		}
+		if http.StatusOK == statusCode {
+			return
+		}
+	}
+
+	buf, err = swiftclient.ObjectLoad(replication.volume.accountName, object.containerName, objectName)
+	if nil != err {
+		return
+	}
+
+	_, err = replication.request(http.MethodPut, object.containerName, objectName, nil, buf, http.StatusCreated)
+	if nil != err {
+		return
+	}
+
+	replication.volume.stats.ReplicationCopyObjectBytes.Add(uint64(len(buf)))
+
+	replication.Lock()
+	replication.copiedObjects++
+	replication.copiedBytes += uint64(len(buf))
+	replication.Unlock()
+
+	return
+}
+
+func (replication *replicationStruct) deleteObject(object replicationObjectStruct) {
+	var (
+		err        error
+		objectName string
+	)
+
+	objectName = utils.Uint64ToHexStr(object.objectNumber)
+
+	_, err = replication.request(http.MethodDelete, object.containerName, objectName, nil, nil, http.StatusNoContent, http.StatusNotFound)
+	if nil != err {
+		logger.Errorf("DELETE %s/%s/%s failed with err: %v", replication.accountURL, object.containerName, objectName, err)
+		return
+	}
+
+	replication.Lock()
+	replication.deletedObjects++
+	replication.Unlock()
+}
+
+// request issues an HTTP request against the replica's account, container, or object
+// returning an error unless the response's StatusCode is one of expectedStatusCodes.
+func (replication *replicationStruct) request(method string, containerName string, objectName string, headers map[string][]string, body []byte, expectedStatusCodes ...int) (statusCode int, err error) {
+	var (
+		expectedStatusCode int
+		httpRequest        *http.Request
+		httpResponse       *http.Response
+		url                string
+	)
+
+	url = replication.accountURL
+	if "" != containerName {
+		url += "/" + containerName
+		if "" != objectName {
+			url += "/" + objectName
+		}
+	}
+
+	httpRequest, err = http.NewRequest(method, url, bytes.NewReader(body))
+	if nil != err {
+		return
+	}
+
+	for headerName, headerValues := range headers {
+		for _, headerValue := range headerValues {
+			httpRequest.Header.Add(headerName, headerValue)
+		}
+	}
+
+	httpResponse, err = replication.httpClient.Do(httpRequest)
+	if nil != err {
+		return
+	}
+
+	_, _ = io.Copy(ioutil.Discard, httpResponse.Body)
+	_ = httpResponse.Body.Close()
+
+	statusCode = httpResponse.StatusCode
+
+	for _, expectedStatusCode = range expectedStatusCodes {
+		if expectedStatusCode == statusCode {
+			err = nil
+			return
+		}
+	}
+
+	err = fmt.Errorf("%s %s returned unexpected StatusCode %d", method, url, statusCode)
+	return
+}
+
+func (volume *volumeStruct) FetchReplicationStatus() (replicationStatus *ReplicationStatusStruct) {
+	var (
+		batch       *replicationBatchStruct
+		copied      bool
+		replication *replicationStruct
+	)
+
+	startTime := time.Now()
+	defer func() {
+		volume.stats.FetchReplicationStatusUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	replicationStatus = &ReplicationStatusStruct{}
+
+	volume.Lock()
+	replication = volume.replication
+	volume.Unlock()
+
+	if nil == replication {
+		return
+	}
+
+	replication.Lock()
+	defer replication.Unlock()
+
+	replicationStatus.Enabled = true
+	replicationStatus.ReplicaAccountURL = replication.accountURL
+	replicationStatus.ReplicatedCheckpointHeader = replication.replicatedCheckpointHeader
+	replicationStatus.ReplicatedCheckpointTime = replication.replicatedCheckpointTime
+	replicationStatus.CopiedObjects = replication.copiedObjects
+	replicationStatus.CopiedBytes = replication.copiedBytes
+	replicationStatus.DeletedObjects = replication.deletedObjects
+
+	if nil != replication.lastErr {
+		replicationStatus.LastError = replication.lastErr.Error()
+	}
+
+	for _, batch = range append([]*replicationBatchStruct{replication.inFlight}, replication.backlog...) {
+		if nil == batch {
+			continue
+		}
+		if 0 == replicationStatus.BacklogCheckpoints {
+			replicationStatus.Lag = time.Since(batch.firstCheckpointTime)
+		}
+		replicationStatus.BacklogCheckpoints += batch.checkpoints
+		for _, copied = range batch.copies {
+			if !copied {
+				replicationStatus.BacklogObjectCopies++
+			}
+		}
+		replicationStatus.BacklogObjectDeletes += uint64(len(batch.deletes))
+	}
+
+	return
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package headhunter
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/ramswift"
+	"github.com/NVIDIA/proxyfs/swiftclient"
+	"github.com/NVIDIA/proxyfs/transitions"
+	"github.com/NVIDIA/proxyfs/utils"
+)
+
+func TestReplication(t *testing.T) {
+	var (
+		confMap                conf.ConfMap
+		confStrings            []string
+		doneChan               chan bool
+		err                    error
+		logSegmentNumbers      []uint64
+		replicationStatus      *ReplicationStatusStruct
+		signalHandlerIsArmedWG sync.WaitGroup
+		volume                 VolumeHandle
+	)
+
+	confStrings = []string{
+		"Logging.LogFilePath=/dev/null",
+		"Stats.IPAddr=localhost",
+		"Stats.UDPPort=52184",
+		"Stats.BufferLength=100",
+		"Stats.MaxLatency=1s",
+		"SwiftClient.NoAuthIPAddr=127.0.0.1",
+
+		"TrackedLock.LockHoldTimeLimit=0s",
+		"TrackedLock.LockCheckPeriod=0s",
+
+		"SwiftClient.NoAuthTCPPort=9999",
+		"SwiftClient.Timeout=10s",
+		"SwiftClient.RetryLimit=0",
+		"SwiftClient.RetryLimitObject=0",
+		"SwiftClient.RetryDelay=1s",
+		"SwiftClient.RetryDelayObject=1s",
+		"SwiftClient.RetryExpBackoff=1.2",
+		"SwiftClient.RetryExpBackoffObject=2.0",
+		"SwiftClient.ChunkedConnectionPoolSize=64",
+		"SwiftClient.NonChunkedConnectionPoolSize=32",
+		"Cluster.WhoAmI=Peer0",
+		"Peer:Peer0.ReadCacheQuotaFraction=0.20",
+		"Volume:TestVolume.PrimaryPeer=Peer0",
+		"Volume:TestVolume.AccountName=TestAccount",
+		"Volume:TestVolume.CheckpointContainerName=.__checkpoint__",
+		"Volume:TestVolume.CheckpointContainerStoragePolicy=gold",
+		"Volume:TestVolume.CheckpointInterval=10s",
+		"Volume:TestVolume.MaxFlushSize=10000000",
+		"Volume:TestVolume.NonceValuesToReserve=100",
+		"Volume:TestVolume.MaxInodesPerMetadataNode=32",
+		"Volume:TestVolume.MaxLogSegmentsPerMetadataNode=64",
+		"Volume:TestVolume.MaxDirFileNodesPerMetadataNode=16",
+		"Volume:TestVolume.ReplicationAccountName=TestReplicaAccount",
+		"Volume:TestVolume.ReplicationRetryDelay=100ms",
+		"VolumeGroup:TestVolumeGroup.VolumeList=TestVolume",
+		"VolumeGroup:TestVolumeGroup.VirtualIPAddr=",
+		"VolumeGroup:TestVolumeGroup.PrimaryPeer=Peer0",
+		"FSGlobals.VolumeGroupList=TestVolumeGroup",
+		"FSGlobals.CheckpointHeaderConsensusAttempts=5",
+		"FSGlobals.MountRetryLimit=6",
+		"FSGlobals.MountRetryDelay=1s",
+		"FSGlobals.MountRetryExpBackoff=2",
+		"FSGlobals.LogCheckpointHeaderPosts=true",
+		"FSGlobals.TryLockBackoffMin=10ms",
+		"FSGlobals.TryLockBackoffMax=50ms",
+		"FSGlobals.TryLockSerializationThreshhold=5",
+		"FSGlobals.SymlinkMax=32",
+		"FSGlobals.CoalesceElementChunkSize=16",
+		"FSGlobals.InodeRecCacheEvictLowLimit=10000",
+		"FSGlobals.InodeRecCacheEvictHighLimit=10010",
+		"FSGlobals.LogSegmentRecCacheEvictLowLimit=10000",
+		"FSGlobals.LogSegmentRecCacheEvictHighLimit=10010",
+		"FSGlobals.BPlusTreeObjectCacheEvictLowLimit=10000",
+		"FSGlobals.BPlusTreeObjectCacheEvictHighLimit=10010",
+		"FSGlobals.EtcdEnabled=false",
+		"RamSwiftInfo.MaxAccountNameLength=256",
+		"RamSwiftInfo.MaxContainerNameLength=256",
+		"RamSwiftInfo.MaxObjectNameLength=1024",
+		"RamSwiftInfo.AccountListingLimit=10000",
+		"RamSwiftInfo.ContainerListingLimit=10000",
+		"Volume:TestVolume.AutoFormat=true",
+	}
+
+	// Launch a ramswift instance
+
+	signalHandlerIsArmedWG.Add(1)
+	doneChan = make(chan bool, 1) // Must be buffered to avoid race
+
+	go ramswift.Daemon("/dev/null", confStrings, &signalHandlerIsArmedWG, doneChan, unix.SIGTERM)
+
+	signalHandlerIsArmedWG.Wait()
+
+	confMap, err = conf.MakeConfMapFromStrings(confStrings)
+	if nil != err {
+		t.Fatalf("conf.MakeConfMapFromStrings(confStrings) returned error: %v", err)
+	}
+
+	err = transitions.Up(confMap)
+	if nil != err {
+		t.Fatalf("transitions.Up() returned error: %v", err)
+	}
+
+	volume, err = FetchVolumeHandle("TestVolume")
+	if nil != err {
+		t.Fatalf("FetchVolumeHandle(\"TestVolume\") returned error: %v", err)
+	}
+
+	// Helper closures
+
+	waitForReplication := func() {
+		for i := 0; i < 100; i++ {
+			replicationStatus = volume.FetchReplicationStatus()
+			if !replicationStatus.Enabled {
+				t.Fatalf("FetchReplicationStatus() reported replication not enabled")
+			}
+			if (0 == replicationStatus.BacklogCheckpoints) && ("" != replicationStatus.ReplicatedCheckpointHeader) {
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		t.Fatalf("Replication failed to catch up (LastError: \"%s\")", replicationStatus.LastError)
+	}
+
+	checkpointObjectList := func(accountName string) (objectList []string) {
+		_, objectList, err = swiftclient.ContainerGet(accountName, ".__checkpoint__")
+		if nil != err {
+			t.Fatalf("swiftclient.ContainerGet(\"%s\",) returned error: %v", accountName, err)
+		}
+		sort.Strings(objectList)
+		return
+	}
+
+	checkReplica := func() {
+		headers, err := swiftclient.ContainerHead("TestAccount", ".__checkpoint__")
+		if nil != err {
+			t.Fatalf("swiftclient.ContainerHead(\"TestAccount\",) returned error: %v", err)
+		}
+		replicaHeaders, err := swiftclient.ContainerHead("TestReplicaAccount", ".__checkpoint__")
+		if nil != err {
+			t.Fatalf("swiftclient.ContainerHead(\"TestReplicaAccount\",) returned error: %v", err)
+		}
+		if (headers[CheckpointHeaderName][0] != replicaHeaders[CheckpointHeaderName][0]) || (replicationStatus.ReplicatedCheckpointHeader != replicaHeaders[CheckpointHeaderName][0]) {
+			t.Fatalf("Replica's %s (%v) mismatched", CheckpointHeaderName, replicaHeaders[CheckpointHeaderName])
+		}
+
+		volume.(*volumeStruct).backgroundObjectDeleteWG.Wait()
+
+		objectList := checkpointObjectList("TestAccount")
+		replicaObjectList := checkpointObjectList("TestReplicaAccount")
+		if len(objectList) != len(replicaObjectList) {
+			t.Fatalf("Replica's checkpoint objects (%v) mismatched (%v)", replicaObjectList, objectList)
+		}
+		for i := range objectList {
+			if objectList[i] != replicaObjectList[i] {
+				t.Fatalf("Replica's checkpoint objects (%v) mismatched (%v)", replicaObjectList, objectList)
+			}
+		}
+	}
+
+	// Write some LogSegments
+
+	err = swiftclient.ContainerPut("TestAccount", "TestLogSegmentContainer", map[string][]string{})
+	if nil != err {
+		t.Fatalf("swiftclient.ContainerPut() returned error: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		logSegmentNumber := volume.FetchNonce()
+
+		chunkedPutContext, err := swiftclient.ObjectFetchChunkedPutContext("TestAccount", "TestLogSegmentContainer", utils.Uint64ToHexStr(logSegmentNumber), "")
+		if nil != err {
+			t.Fatalf("swiftclient.ObjectFetchChunkedPutContext() returned error: %v", err)
+		}
+		err = chunkedPutContext.SendChunk([]byte{byte(i), byte(i), byte(i)})
+		if nil != err {
+			t.Fatalf("chunkedPutContext.SendChunk() returned error: %v", err)
+		}
+		err = chunkedPutContext.Close()
+		if nil != err {
+			t.Fatalf("chunkedPutContext.Close() returned error: %v", err)
+		}
+
+		err = volume.PutLogSegmentRec(logSegmentNumber, []byte("TestLogSegmentContainer"))
+		if nil != err {
+			t.Fatalf("PutLogSegmentRec() returned error: %v", err)
+		}
+
+		logSegmentNumbers = append(logSegmentNumbers, logSegmentNumber)
+	}
+
+	err = volume.DoCheckpoint()
+	if nil != err {
+		t.Fatalf("DoCheckpoint() [case 1] returned error: %v", err)
+	}
+
+	waitForReplication()
+	checkReplica()
+
+	for i, logSegmentNumber := range logSegmentNumbers {
+		buf, err := swiftclient.ObjectLoad("TestReplicaAccount", "TestLogSegmentContainer", utils.Uint64ToHexStr(logSegmentNumber))
+		if nil != err {
+			t.Fatalf("swiftclient.ObjectLoad() of replicated LogSegment returned error: %v", err)
+		}
+		if 0 != bytes.Compare(buf, []byte{byte(i), byte(i), byte(i)}) {
+			t.Fatalf("Replicated LogSegment contents mismatched")
+		}
+	}
+
+	if 0 != replicationStatus.Lag {
+		t.Fatalf("FetchReplicationStatus() reported non-zero Lag (%v) with no backlog", replicationStatus.Lag)
+	}
+
+	// Deleting a LogSegment should delete it from the replica as well
+
+	err = volume.DeleteLogSegmentRec(logSegmentNumbers[0])
+	if nil != err {
+		t.Fatalf("DeleteLogSegmentRec() returned error: %v", err)
+	}
+
+	err = volume.DoCheckpoint()
+	if nil != err {
+		t.Fatalf("DoCheckpoint() [case 2] returned error: %v", err)
+	}
+
+	waitForReplication()
+	checkReplica()
+
+	_, err = swiftclient.ObjectHead("TestReplicaAccount", "TestLogSegmentContainer", utils.Uint64ToHexStr(logSegmentNumbers[0]))
+	if blunder.IsNot(err, blunder.NotFoundError) {
+		t.Fatalf("Deleted LogSegment should have been deleted from the replica (err: %v)", err)
+	}
+
+	// Upon re-serving the volume, everything should be found already replicated
+
+	err = transitions.Down(confMap)
+	if nil != err {
+		t.Fatalf("transitions.Down() [case 1] returned error: %v", err)
+	}
+
+	err = confMap.UpdateFromString("Volume:TestVolume.AutoFormat=false")
+	if nil != err {
+		t.Fatalf("conf.UpdateFromString(\"Volume:TestVolume.AutoFormat=false\") returned error: %v", err)
+	}
+
+	err = transitions.Up(confMap)
+	if nil != err {
+		t.Fatalf("transitions.Up() [case 2] returned error: %v", err)
+	}
+
+	volume, err = FetchVolumeHandle("TestVolume")
+	if nil != err {
+		t.Fatalf("FetchVolumeHandle(\"TestVolume\") [case 2] returned error: %v", err)
+	}
+
+	err = volume.DoCheckpoint()
+	if nil != err {
+		t.Fatalf("DoCheckpoint() [case 3] returned error: %v", err)
+	}
+
+	waitForReplication()
+	checkReplica()
+
+	if replicationStatus.CopiedObjects > uint64(len(checkpointObjectList("TestAccount"))) {
+		t.Fatalf("Re-served volume should only have replicated new checkpoint objects (CopiedObjects: %v)", replicationStatus.CopiedObjects)
+	}
+
+	err = transitions.Down(confMap)
+	if nil != err {
+		t.Fatalf("transitions.Down() [case 2] returned error: %v", err)
+	}
+
+	// Send ourself a SIGTERM to terminate ramswift.Daemon()
+
+	unix.Kill(unix.Getpid(), unix.SIGTERM)
+
+	_ = <-doneChan
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package headhunter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/evtlog"
+	"github.com/NVIDIA/proxyfs/logger"
+)
+
+// SnapShot retention (see inode's SnapShotPolicy) is supported by headhunter in two ways.
+//
+// A pinned SnapShot is never deleted by a SnapShotPolicy (though it may still be
+// explicitly deleted). Whether or not a SnapShot is pinned is recorded, along with the
+// rest of its metadata, in its ElementOfSnapShotListStruct in the checkpoint (hence the
+// advance to CheckpointVersion4). As such, pins are unaffected by a SnapShot rollback
+// and vanish with the SnapShot they pin.
+//
+// The space "trapped" by SnapShots is that of the LogSegments they alone reference
+// (i.e. those deleted from the live view yet held in the deletedObjects of a SnapShot).
+// SnapShotTrappedLogSegmentRecs reports the LogSegmentRec of each such LogSegment in a
+// single pass over the SnapShots. Each is attributed to the SnapShot whose deletion would
+// free it were those in deletableIDs deleted from oldest to newest. LogSegments never
+// freed that way are attributed to the newest SnapShot not in deletableIDs that
+// references them.
+
+func (volume *volumeStruct) SnapShotPin(id uint64, pinned bool) (err error) {
+	var (
+		volumeView *volumeViewStruct
+	)
+
+	startTime := time.Now()
+	defer func() {
+		volume.stats.SnapShotPinUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			volume.stats.SnapShotPinErrors.Add(1)
+		}
+	}()
+
+	volume.Lock()
+	defer volume.Unlock()
+
+	if liveSnapShotID == id {
+		err = fmt.Errorf("Cannot pin volume \"%v\"'s live view", volume.volumeName)
+		return
+	}
+
+	volumeView, err = volume.fetchVolumeViewByIDWhileLocked(id)
+	if nil != err {
+		return
+	}
+
+	if volumeView.pinned == pinned {
+		return
+	}
+
+	volumeView.pinned = pinned
+
+	// Like SnapShotCreateByInodeLayer() & SnapShotDeleteByInodeLayer(), persist the change immediately
+
+	volume.checkpointTriggeringEvents++
+
+	evtlog.Record(evtlog.FormatHeadhunterCheckpointStart, volume.volumeName)
+	err = volume.putCheckpoint()
+	if nil != err {
+		evtlog.Record(evtlog.FormatHeadhunterCheckpointEndFailure, volume.volumeName, err.Error())
+		logger.FatalfWithError(err, "Shutting down to prevent subsequent checkpoints from corrupting Swift")
+	}
+	evtlog.Record(evtlog.FormatHeadhunterCheckpointEndSuccess, volume.volumeName)
+
+	return
+}
+
+func (volume *volumeStruct) SnapShotTrappedLogSegmentRecs(deletableIDs map[uint64]struct{}) (logSegmentRecs map[uint64][][]byte, err error) {
+	var (
+		holderID          uint64
+		nonce             uint64
+		nonceAsKey        sortedmap.Key
+		nonceIndex        int
+		ok                bool
+		retainedView      *volumeViewStruct // newest SnapShot so far not in deletableIDs
+		value             []byte
+		valueAsValue      sortedmap.Value
+		viewIndex         int
+		viewTreeLen       int
+		volumeView        *volumeViewStruct
+		volumeViewAsValue sortedmap.Value
+	)
+
+	startTime := time.Now()
+	defer func() {
+		volume.stats.SnapShotTrappedLogSegmentRecsUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			volume.stats.SnapShotTrappedLogSegmentRecsErrors.Add(1)
+		}
+	}()
+
+	volume.Lock()
+	defer volume.Unlock()
+
+	logSegmentRecs = make(map[uint64][][]byte)
+
+	viewTreeLen, err = volume.viewTreeByNonce.Len()
+	if nil != err {
+		return
+	}
+
+	// Walk the SnapShots from oldest to newest (once)
+
+	for viewIndex = 0; viewIndex < viewTreeLen; viewIndex++ {
+		_, volumeViewAsValue, ok, err = volume.viewTreeByNonce.GetByIndex(viewIndex)
+		if nil != err {
+			return
+		}
+		if !ok {
+			logger.Fatalf("Logic error - viewTreeByNonce.GetByIndex(%v) returned ok == false", viewIndex)
+		}
+
+		volumeView = volumeViewAsValue.(*volumeViewStruct)
+
+		_, ok = deletableIDs[volumeView.snapShotID]
+		if !ok {
+			retainedView = volumeView
+		}
+
+		for nonceIndex = 0; ; nonceIndex++ {
+			nonceAsKey, _, ok, err = volumeView.deletedObjectsWrapper.bPlusTree.GetByIndex(nonceIndex)
+			if nil != err {
+				return
+			}
+			if !ok {
+				break
+			}
+
+			nonce = nonceAsKey.(uint64)
+
+			// Objects other than LogSegments (i.e. checkpoint objects) are not in logSegmentRecWrapper
+
+			valueAsValue, ok, err = volumeView.logSegmentRecWrapper.bPlusTree.GetByKey(nonce)
+			if nil != err {
+				return
+			}
+			if !ok {
+				continue
+			}
+
+			// The LogSegment is referenced by this and a contiguous run of older SnapShots
+			// so it is freed by deleting this one unless retainedView is among them
+
+			holderID = volumeView.snapShotID
+
+			if (nil != retainedView) && (volumeView != retainedView) {
+				_, ok, err = retainedView.logSegmentRecWrapper.bPlusTree.GetByKey(nonce)
+				if nil != err {
+					return
+				}
+				if ok {
+					holderID = retainedView.snapShotID
+				}
+			}
+
+			value = make([]byte, len(valueAsValue.([]byte)))
+			copy(value, valueAsValue.([]byte))
+			logSegmentRecs[holderID] = append(logSegmentRecs[holderID], value)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package headhunter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/evtlog"
+	"github.com/NVIDIA/proxyfs/logger"
+)
+
+// Rolling the live view back to a SnapShot replaces each of the live view's InodeRec,
+// LogSegmentRec, and BPlusTreeObject B+Trees with one rooted where the SnapShot's is
+// rooted. The swap is bracketed by checkpoints so that, following a crash, the volume
+// recovers to either its pre- or its post-rollback state. Nothing else is recorded in
+// the replay log.
+//
+// LogSegments only referenced by the discarded live view are deleted just as if by
+// DeleteLogSegmentRec(). LogSegments only referenced by the SnapShot (i.e. deleted
+// from the live view since the SnapShot was taken) are pending deletion in some view's
+// deletedObjects... that pending deletion is cancelled. Similarly, checkpoint objects
+// holding nodes of the SnapShot's B+Trees are once again tracked as referenced by the
+// live view while those only holding nodes of the discarded live B+Trees are reclaimed
+// by the post-rollback checkpoint. All SnapShots, including any taken after the one
+// rolled back to, are retained.
+
+// rollbackLogSegmentRecsWhileLocked walks the live and snapShotView LogSegmentRec
+// B+Trees in parallel returning the LogSegments (with their ContainerNames) only
+// present in the live view (deleted) and only present in snapShotView (restored).
+//
+func (volume *volumeStruct) rollbackLogSegmentRecsWhileLocked(snapShotView *volumeViewStruct) (deleted map[uint64][]byte, restored []uint64, err error) {
+	var (
+		liveIndex        int
+		liveKey          uint64
+		liveKeyAsKey     sortedmap.Key
+		liveOK           bool
+		liveValue        sortedmap.Value
+		snapShotIndex    int
+		snapShotKey      uint64
+		snapShotKeyAsKey sortedmap.Key
+		snapShotOK       bool
+	)
+
+	deleted = make(map[uint64][]byte)
+	restored = make([]uint64, 0)
+
+	liveIndex = 0
+	snapShotIndex = 0
+
+	for {
+		liveKeyAsKey, liveValue, liveOK, err = volume.liveView.logSegmentRecWrapper.bPlusTree.GetByIndex(liveIndex)
+		if nil != err {
+			return
+		}
+		snapShotKeyAsKey, _, snapShotOK, err = snapShotView.logSegmentRecWrapper.bPlusTree.GetByIndex(snapShotIndex)
+		if nil != err {
+			return
+		}
+
+		if !liveOK && !snapShotOK {
+			err = nil
+			return
+		}
+
+		if liveOK {
+			liveKey = liveKeyAsKey.(uint64)
+		}
+		if snapShotOK {
+			snapShotKey = snapShotKeyAsKey.(uint64)
+		}
+
+		if !snapShotOK || (liveOK && (liveKey < snapShotKey)) {
+			// Only the ContainerName (i.e. not anything following a NUL) is needed to delete the Object
+
+			deleted[liveKey] = []byte(logSegmentRecContainerName(liveValue.([]byte)))
+			liveIndex++
+		} else if !liveOK || (snapShotKey < liveKey) {
+			restored = append(restored, snapShotKey)
+			snapShotIndex++
+		} else {
+			liveIndex++
+			snapShotIndex++
+		}
+	}
+}
+
+// rollbackBPlusTree returns a B+Tree, maintained via liveWrapper, rooted where
+// snapShotBPlusTree is rooted along with that B+Tree's layout.
+//
+func (volume *volumeStruct) rollbackBPlusTree(snapShotBPlusTree sortedmap.BPlusTree, liveWrapper *bPlusTreeWrapperStruct, maxKeysPerNode uint64, cache sortedmap.BPlusTreeCache) (bPlusTree sortedmap.BPlusTree, layoutReport sortedmap.LayoutReport, err error) {
+	var (
+		rootObjectLength uint64
+		rootObjectNumber uint64
+		rootObjectOffset uint64
+	)
+
+	rootObjectNumber, rootObjectOffset, rootObjectLength = snapShotBPlusTree.FetchLocation()
+
+	if 0 == rootObjectNumber {
+		bPlusTree = sortedmap.NewBPlusTree(maxKeysPerNode, sortedmap.CompareUint64, liveWrapper, cache)
+		layoutReport = make(sortedmap.LayoutReport)
+		err = nil
+		return
+	}
+
+	bPlusTree, err = sortedmap.OldBPlusTree(rootObjectNumber, rootObjectOffset, rootObjectLength, sortedmap.CompareUint64, liveWrapper, cache)
+	if nil != err {
+		return
+	}
+
+	layoutReport, err = bPlusTree.FetchLayoutReport()
+
+	return
+}
+
+// rollbackTrackerWhileLocked accounts for the live view no longer referencing the
+// nodes of its discarded B+Tree but instead those described by layoutReport.
+//
+func rollbackTrackerWhileLocked(liveWrapper *bPlusTreeWrapperStruct, layoutReport sortedmap.LayoutReport) {
+	var (
+		bytesUsed    uint64
+		objectNumber uint64
+	)
+
+	for objectNumber = range liveWrapper.bPlusTreeTracker.bPlusTreeLayout {
+		liveWrapper.bPlusTreeTracker.bPlusTreeLayout[objectNumber] = 0
+	}
+
+	for objectNumber, bytesUsed = range layoutReport {
+		liveWrapper.bPlusTreeTracker.bPlusTreeLayout[objectNumber] = bytesUsed
+	}
+}
+
+func (volume *volumeStruct) SnapShotRollbackByInodeLayer(id uint64) (err error) {
+	var (
+		bPlusTreeObjectBPlusTree    sortedmap.BPlusTree
+		bPlusTreeObjectLayoutReport sortedmap.LayoutReport
+		containerNameAsBuf          []byte
+		deletedLogSegments          map[uint64][]byte
+		inodeRecBPlusTree           sortedmap.BPlusTree
+		inodeRecLayoutReport        sortedmap.LayoutReport
+		layoutReport                sortedmap.LayoutReport
+		logSegmentNumber            uint64
+		logSegmentRecBPlusTree      sortedmap.BPlusTree
+		logSegmentRecLayoutReport   sortedmap.LayoutReport
+		objectNumber                uint64
+		ok                          bool
+		restoredLogSegments         []uint64
+		snapShotView                *volumeViewStruct
+	)
+
+	startTime := time.Now()
+	defer func() {
+		volume.stats.SnapShotRollbackByInodeLayerUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			volume.stats.SnapShotRollbackByInodeLayerErrors.Add(1)
+		}
+	}()
+
+	if liveSnapShotID == id {
+		err = fmt.Errorf("Cannot rollback volume \"%v\" to its live view", volume.volumeName)
+		return
+	}
+
+	volume.Lock()
+	defer volume.Unlock()
+
+	snapShotView, err = volume.fetchVolumeViewByIDWhileLocked(id)
+	if nil != err {
+		return
+	}
+
+	volume.checkpointTriggeringEvents++
+
+	evtlog.Record(evtlog.FormatHeadhunterCheckpointStart, volume.volumeName)
+	err = volume.putCheckpoint()
+	if nil != err {
+		evtlog.Record(evtlog.FormatHeadhunterCheckpointEndFailure, volume.volumeName, err.Error())
+		logger.FatalfWithError(err, "Shutting down to prevent subsequent checkpoints from corrupting Swift")
+	}
+	evtlog.Record(evtlog.FormatHeadhunterCheckpointEndSuccess, volume.volumeName)
+
+	// Nothing is modified until everything that could fail has succeeded
+
+	deletedLogSegments, restoredLogSegments, err = volume.rollbackLogSegmentRecsWhileLocked(snapShotView)
+	if nil != err {
+		return
+	}
+
+	inodeRecBPlusTree, inodeRecLayoutReport, err = volume.rollbackBPlusTree(snapShotView.inodeRecWrapper.bPlusTree, volume.liveView.inodeRecWrapper, volume.maxInodesPerMetadataNode, globals.inodeRecCache)
+	if nil != err {
+		return
+	}
+	logSegmentRecBPlusTree, logSegmentRecLayoutReport, err = volume.rollbackBPlusTree(snapShotView.logSegmentRecWrapper.bPlusTree, volume.liveView.logSegmentRecWrapper, volume.maxLogSegmentsPerMetadataNode, globals.logSegmentRecCache)
+	if nil != err {
+		return
+	}
+	bPlusTreeObjectBPlusTree, bPlusTreeObjectLayoutReport, err = volume.rollbackBPlusTree(snapShotView.bPlusTreeObjectWrapper.bPlusTree, volume.liveView.bPlusTreeObjectWrapper, volume.maxDirFileNodesPerMetadataNode, globals.bPlusTreeObjectCache)
+	if nil != err {
+		return
+	}
+
+	volume.checkpointTriggeringEvents++
+
+	// As the SnapShot exists, volume.priorView != nil
+
+	for logSegmentNumber, containerNameAsBuf = range deletedLogSegments {
+		ok, err = volume.priorView.createdObjectsWrapper.bPlusTree.DeleteByKey(logSegmentNumber)
+		if nil != err {
+			logger.Fatalf("Logic error - priorView.createdObjectsWrapper.bPlusTree.DeleteByKey() failed with error: %v", err)
+		}
+		if ok {
+			_, err = volume.liveView.deletedObjectsWrapper.bPlusTree.Put(logSegmentNumber, containerNameAsBuf)
+		} else {
+			_, err = volume.priorView.deletedObjectsWrapper.bPlusTree.Put(logSegmentNumber, containerNameAsBuf)
+		}
+		if nil != err {
+			logger.Fatalf("Logic error - deletedObjectsWrapper.bPlusTree.Put() failed with error: %v", err)
+		}
+	}
+
+	for _, logSegmentNumber = range restoredLogSegments {
+		ok, err = volume.cancelObjectDeletionWhileLocked(logSegmentNumber)
+		if nil != err {
+			logger.Fatalf("Logic error - cancelObjectDeletionWhileLocked() failed with error: %v", err)
+		}
+		if !ok {
+			logger.Warnf("Rollback of volume %v to SnapShotID %v found LogSegment 0x%016X not pending deletion", volume.volumeName, id, logSegmentNumber)
+		}
+	}
+
+	for _, layoutReport = range []sortedmap.LayoutReport{inodeRecLayoutReport, logSegmentRecLayoutReport, bPlusTreeObjectLayoutReport} {
+		for objectNumber = range layoutReport {
+			_, err = volume.cancelObjectDeletionWhileLocked(objectNumber)
+			if nil != err {
+				logger.Fatalf("Logic error - cancelObjectDeletionWhileLocked() failed with error: %v", err)
+			}
+		}
+	}
+
+	err = volume.liveView.inodeRecWrapper.bPlusTree.Prune()
+	if nil != err {
+		logger.Fatalf("Logic error - liveView.inodeRecWrapper.bPlusTree.Prune() failed with error: %v", err)
+	}
+	err = volume.liveView.logSegmentRecWrapper.bPlusTree.Prune()
+	if nil != err {
+		logger.Fatalf("Logic error - liveView.logSegmentRecWrapper.bPlusTree.Prune() failed with error: %v", err)
+	}
+	err = volume.liveView.bPlusTreeObjectWrapper.bPlusTree.Prune()
+	if nil != err {
+		logger.Fatalf("Logic error - liveView.bPlusTreeObjectWrapper.bPlusTree.Prune() failed with error: %v", err)
+	}
+
+	volume.liveView.inodeRecWrapper.bPlusTree = inodeRecBPlusTree
+	volume.liveView.logSegmentRecWrapper.bPlusTree = logSegmentRecBPlusTree
+	volume.liveView.bPlusTreeObjectWrapper.bPlusTree = bPlusTreeObjectBPlusTree
+
+	rollbackTrackerWhileLocked(volume.liveView.inodeRecWrapper, inodeRecLayoutReport)
+	rollbackTrackerWhileLocked(volume.liveView.logSegmentRecWrapper, logSegmentRecLayoutReport)
+	rollbackTrackerWhileLocked(volume.liveView.bPlusTreeObjectWrapper, bPlusTreeObjectLayoutReport)
+
+	evtlog.Record(evtlog.FormatHeadhunterCheckpointStart, volume.volumeName)
+	err = volume.putCheckpoint()
+	if nil != err {
+		evtlog.Record(evtlog.FormatHeadhunterCheckpointEndFailure, volume.volumeName, err.Error())
+		logger.FatalfWithError(err, "Shutting down to prevent subsequent checkpoints from corrupting Swift")
+	}
+	evtlog.Record(evtlog.FormatHeadhunterCheckpointEndSuccess, volume.volumeName)
+
+	return
+}
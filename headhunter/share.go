@@ -0,0 +1,315 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package headhunter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/logger"
+)
+
+// A LogSegment or B+Tree object referenced by a SnapShot may be shared with the
+// live view (e.g. by a writable clone of a file in that SnapShot). Sharing ensures
+// the record is present in the live view, resurrecting it (and cancelling its
+// pending deletion) if the live view had already deleted it. If the record was
+// already present, a share count is incremented instead. Each subsequent delete
+// of the record first decrements its share count... only once that count reaches
+// zero is the record actually deleted (and, for a LogSegment, its object queued
+// for deletion as usual).
+//
+// Share counts are kept, like the VolumeRecs, in the live view's inodeRec B+Tree.
+// Each is keyed by the shared nonce adorned with the (otherwise impossible for an
+// InodeRec) .snapshot/ directory SnapShotID, so all ShareCountRecs sort after all
+// InodeRecs. The value is the little endian uint64 count of additional references.
+
+const (
+	shareCountRecSize = 8
+)
+
+func (volume *volumeStruct) shareCountRecKey(nonce uint64) (key uint64) {
+	key = (volume.dotSnapShotDirSnapShotID << volume.snapShotIDShift) | nonce
+	return
+}
+
+func (volume *volumeStruct) isShareCountRecKey(key uint64) (isShareCountRecKey bool) {
+	isShareCountRecKey = ((key >> volume.snapShotIDShift) == volume.dotSnapShotDirSnapShotID)
+	return
+}
+
+// addShareWhileLocked increments the share count of nonce.
+//
+func (volume *volumeStruct) addShareWhileLocked(nonce uint64) (err error) {
+	var (
+		key          uint64
+		ok           bool
+		shareCount   uint64
+		value        []byte
+		valueAsValue sortedmap.Value
+	)
+
+	key = volume.shareCountRecKey(nonce)
+
+	valueAsValue, ok, err = volume.liveView.inodeRecWrapper.bPlusTree.GetByKey(key)
+	if nil != err {
+		return
+	}
+
+	if ok {
+		shareCount = binary.LittleEndian.Uint64(valueAsValue.([]byte))
+	} else {
+		shareCount = 0
+	}
+
+	value = make([]byte, shareCountRecSize)
+	binary.LittleEndian.PutUint64(value, shareCount+1)
+
+	if ok {
+		_, err = volume.liveView.inodeRecWrapper.bPlusTree.PatchByKey(key, value)
+	} else {
+		_, err = volume.liveView.inodeRecWrapper.bPlusTree.Put(key, value)
+	}
+
+	return
+}
+
+// releaseShareWhileLocked decrements the share count of nonce (if any). If released
+// is returned true, the caller must not delete the (still referenced) record.
+//
+func (volume *volumeStruct) releaseShareWhileLocked(nonce uint64) (released bool, err error) {
+	var (
+		key          uint64
+		shareCount   uint64
+		value        []byte
+		valueAsValue sortedmap.Value
+	)
+
+	key = volume.shareCountRecKey(nonce)
+
+	valueAsValue, released, err = volume.liveView.inodeRecWrapper.bPlusTree.GetByKey(key)
+	if (nil != err) || !released {
+		return
+	}
+
+	shareCount = binary.LittleEndian.Uint64(valueAsValue.([]byte))
+
+	if 1 == shareCount {
+		_, err = volume.liveView.inodeRecWrapper.bPlusTree.DeleteByKey(key)
+	} else {
+		value = make([]byte, shareCountRecSize)
+		binary.LittleEndian.PutUint64(value, shareCount-1)
+		_, err = volume.liveView.inodeRecWrapper.bPlusTree.PatchByKey(key, value)
+	}
+
+	return
+}
+
+// cancelObjectDeletionWhileLocked removes nonce from the deletedObjects of whichever
+// view (including the live view) holds it. If no view holds it, cancelled is false.
+//
+func (volume *volumeStruct) cancelObjectDeletionWhileLocked(nonce uint64) (cancelled bool, err error) {
+	var (
+		ok                bool
+		viewIndex         int
+		viewTreeLen       int
+		volumeView        *volumeViewStruct
+		volumeViewAsValue sortedmap.Value
+	)
+
+	cancelled, err = volume.liveView.deletedObjectsWrapper.bPlusTree.DeleteByKey(nonce)
+	if (nil != err) || cancelled {
+		return
+	}
+
+	viewTreeLen, err = volume.viewTreeByNonce.Len()
+	if nil != err {
+		return
+	}
+
+	for viewIndex = 0; viewIndex < viewTreeLen; viewIndex++ {
+		_, volumeViewAsValue, ok, err = volume.viewTreeByNonce.GetByIndex(viewIndex)
+		if nil != err {
+			return
+		}
+		if !ok {
+			logger.Fatalf("Logic error - viewTreeByNonce.GetByIndex(%v) returned ok == false", viewIndex)
+		}
+
+		volumeView = volumeViewAsValue.(*volumeViewStruct)
+
+		cancelled, err = volumeView.deletedObjectsWrapper.bPlusTree.DeleteByKey(nonce)
+		if (nil != err) || cancelled {
+			return
+		}
+	}
+
+	err = nil
+	return
+}
+
+func (volume *volumeStruct) shareLogSegmentRecWhileLocked(logSegmentNumber uint64) (err error) {
+	var (
+		nonce        uint64
+		ok           bool
+		valueAsValue sortedmap.Value
+		volumeView   *volumeViewStruct
+	)
+
+	volumeView, nonce, ok, err = volume.findVolumeViewAndNonceWhileLocked(logSegmentNumber)
+	if nil != err {
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("logSegmentNumber 0x%016X not found in volume \"%v\"", logSegmentNumber, volume.volumeName)
+		return
+	}
+
+	_, ok, err = volume.liveView.logSegmentRecWrapper.bPlusTree.GetByKey(nonce)
+	if nil != err {
+		return
+	}
+	if ok {
+		err = volume.addShareWhileLocked(nonce)
+		return
+	}
+
+	valueAsValue, ok, err = volumeView.logSegmentRecWrapper.bPlusTree.GetByKey(nonce)
+	if nil != err {
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("logSegmentNumber 0x%016X not found in volume \"%v\" logSegmentRecWrapper.bPlusTree", logSegmentNumber, volume.volumeName)
+		return
+	}
+
+	ok, err = volume.cancelObjectDeletionWhileLocked(nonce)
+	if nil != err {
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("logSegmentNumber 0x%016X not pending deletion in volume \"%v\"", logSegmentNumber, volume.volumeName)
+		return
+	}
+
+	_, err = volume.liveView.logSegmentRecWrapper.bPlusTree.Put(nonce, valueAsValue)
+
+	return
+}
+
+func (volume *volumeStruct) shareBPlusTreeObjectWhileLocked(objectNumber uint64) (err error) {
+	var (
+		nonce        uint64
+		ok           bool
+		valueAsValue sortedmap.Value
+		volumeView   *volumeViewStruct
+	)
+
+	volumeView, nonce, ok, err = volume.findVolumeViewAndNonceWhileLocked(objectNumber)
+	if nil != err {
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("objectNumber 0x%016X not found in volume \"%v\"", objectNumber, volume.volumeName)
+		return
+	}
+
+	_, ok, err = volume.liveView.bPlusTreeObjectWrapper.bPlusTree.GetByKey(nonce)
+	if nil != err {
+		return
+	}
+	if ok {
+		err = volume.addShareWhileLocked(nonce)
+		return
+	}
+
+	valueAsValue, ok, err = volumeView.bPlusTreeObjectWrapper.bPlusTree.GetByKey(nonce)
+	if nil != err {
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("objectNumber 0x%016X not found in volume \"%v\" bPlusTreeObjectWrapper.bPlusTree", objectNumber, volume.volumeName)
+		return
+	}
+
+	_, err = volume.liveView.bPlusTreeObjectWrapper.bPlusTree.Put(nonce, valueAsValue)
+
+	return
+}
+
+func (volume *volumeStruct) ShareLogSegmentRec(logSegmentNumber uint64) (err error) {
+
+	startTime := time.Now()
+	defer func() {
+		volume.stats.ShareLogSegmentRecUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			volume.stats.ShareLogSegmentRecErrors.Add(1)
+		}
+	}()
+
+	volume.Lock()
+	defer volume.Unlock()
+
+	volume.checkpointTriggeringEvents++
+
+	err = volume.shareLogSegmentRecWhileLocked(logSegmentNumber)
+	if nil != err {
+		return
+	}
+
+	volume.recordTransaction(transactionShareLogSegmentRec, logSegmentNumber, nil)
+
+	return
+}
+
+// FetchLogSegmentRecShareCount returns the number of additional references to the live
+// view's logSegmentNumber made by ShareLogSegmentRec() (i.e. the number of times it may
+// be deleted before actually being deleted, less one).
+//
+func (volume *volumeStruct) FetchLogSegmentRecShareCount(logSegmentNumber uint64) (shareCount uint64, err error) {
+	var (
+		ok           bool
+		valueAsValue sortedmap.Value
+	)
+
+	volume.Lock()
+	defer volume.Unlock()
+
+	valueAsValue, ok, err = volume.liveView.inodeRecWrapper.bPlusTree.GetByKey(volume.shareCountRecKey(logSegmentNumber))
+	if (nil != err) || !ok {
+		shareCount = 0
+		return
+	}
+
+	shareCount = binary.LittleEndian.Uint64(valueAsValue.([]byte))
+
+	return
+}
+
+func (volume *volumeStruct) ShareBPlusTreeObject(objectNumber uint64) (err error) {
+
+	startTime := time.Now()
+	defer func() {
+		volume.stats.ShareBPlusTreeObjectUsec.Add(uint64(time.Since(startTime) / time.Microsecond))
+		if err != nil {
+			volume.stats.ShareBPlusTreeObjectErrors.Add(1)
+		}
+	}()
+
+	volume.Lock()
+	defer volume.Unlock()
+
+	volume.checkpointTriggeringEvents++
+
+	err = volume.shareBPlusTreeObjectWhileLocked(objectNumber)
+	if nil != err {
+		return
+	}
+
+	volume.recordTransaction(transactionShareBPlusTreeObject, objectNumber, nil)
+
+	return
+}
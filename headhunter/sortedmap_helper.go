@@ -9,6 +9,7 @@ import (
 	"github.com/NVIDIA/sortedmap"
 
 	"github.com/NVIDIA/proxyfs/evtlog"
+	"github.com/NVIDIA/proxyfs/keyring"
 	"github.com/NVIDIA/proxyfs/logger"
 	"github.com/NVIDIA/proxyfs/stats"
 	"github.com/NVIDIA/proxyfs/swiftclient"
@@ -61,24 +62,46 @@ func (bPlusTreeWrapper *bPlusTreeWrapperStruct) DumpValue(value sortedmap.Value)
 }
 
 func (bPlusTreeWrapper *bPlusTreeWrapperStruct) GetNode(objectNumber uint64, objectOffset uint64, objectLength uint64) (nodeByteSlice []byte, err error) {
+	var (
+		sealedNodeByteSlice []byte
+	)
+
 	stats.IncrementOperations(&stats.HeadhunterBPlusTreeNodeFaults)
 	evtlog.Record(evtlog.FormatHeadhunterBPlusTreeNodeFault, bPlusTreeWrapper.volumeView.volume.volumeName, objectNumber, objectOffset, objectLength)
 
-	nodeByteSlice, err =
-		swiftclient.ObjectGet(
-			bPlusTreeWrapper.volumeView.volume.accountName,
-			bPlusTreeWrapper.volumeView.volume.checkpointContainerName,
-			utils.Uint64ToHexStr(objectNumber),
-			objectOffset,
-			objectLength)
+	if nil == bPlusTreeWrapper.volumeView.volume.keyring {
+		nodeByteSlice, err =
+			swiftclient.ObjectGet(
+				bPlusTreeWrapper.volumeView.volume.accountName,
+				bPlusTreeWrapper.volumeView.volume.checkpointContainerName,
+				utils.Uint64ToHexStr(objectNumber),
+				objectOffset,
+				objectLength)
+	} else {
+		// objectLength is that of the unsealed node
+
+		sealedNodeByteSlice, err =
+			swiftclient.ObjectGet(
+				bPlusTreeWrapper.volumeView.volume.accountName,
+				bPlusTreeWrapper.volumeView.volume.checkpointContainerName,
+				utils.Uint64ToHexStr(objectNumber),
+				objectOffset,
+				objectLength+keyring.Overhead)
+		if nil != err {
+			return
+		}
+
+		nodeByteSlice, err = bPlusTreeWrapper.volumeView.volume.Unseal(objectNumber, objectOffset, sealedNodeByteSlice)
+	}
 
 	return
 }
 
 func (bPlusTreeWrapper *bPlusTreeWrapperStruct) PutNode(nodeByteSlice []byte) (objectNumber uint64, objectOffset uint64, err error) {
 	var (
-		bytesUsed uint64
-		ok        bool
+		bytesUsed           uint64
+		ok                  bool
+		sealedNodeByteSlice []byte
 	)
 
 	// track data written
@@ -97,7 +120,16 @@ func (bPlusTreeWrapper *bPlusTreeWrapperStruct) PutNode(nodeByteSlice []byte) (o
 		return
 	}
 
-	err = bPlusTreeWrapper.volumeView.volume.sendChunkToCheckpointChunkedPutContext(nodeByteSlice)
+	if nil == bPlusTreeWrapper.volumeView.volume.keyring {
+		err = bPlusTreeWrapper.volumeView.volume.sendChunkToCheckpointChunkedPutContext(nodeByteSlice)
+	} else {
+		sealedNodeByteSlice, err = bPlusTreeWrapper.volumeView.volume.Seal(objectNumber, objectOffset, nodeByteSlice)
+		if nil != err {
+			return
+		}
+
+		err = bPlusTreeWrapper.volumeView.volume.sendChunkToCheckpointChunkedPutContext(sealedNodeByteSlice)
+	}
 	if nil != err {
 		return
 	}
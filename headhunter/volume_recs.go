@@ -0,0 +1,199 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package headhunter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/NVIDIA/sortedmap"
+)
+
+// Volume-wide records maintained by the inode layer (e.g. the QuotaRec and the
+// CompressionStatsRec) are kept, each under its own name, in the live view's inodeRec
+// B+Tree as a single VolumeRecs value keyed by the (never valid) InodeNumber 0. Being
+// kept in the inodeRec B+Tree, updates are captured by the replay log and checkpoints
+// like any InodeRec. As key 0 sorts before all InodeRecs, enumerators need only skip
+// it once no matter how many volume-wide records are present. The value is a sequence
+// of (little endian):
+//
+//   uint64 len(name)
+//   name
+//   uint64 len(value)
+//   value
+
+const (
+	volumeRecQuotaName            = "QuotaRec"
+	volumeRecCompressionStatsName = "CompressionStatsRec"
+)
+
+func decodeVolumeRecs(volumeName string, buf []byte) (volumeRecs map[string][]byte, err error) {
+	var (
+		name     string
+		nameLen  uint64
+		valueLen uint64
+	)
+
+	volumeRecs = make(map[string][]byte)
+
+	for 0 < len(buf) {
+		if uint64(len(buf)) < globals.uint64Size {
+			err = fmt.Errorf("VolumeRecs for volume \"%v\" truncated before name length", volumeName)
+			return
+		}
+		nameLen = binary.LittleEndian.Uint64(buf)
+		buf = buf[globals.uint64Size:]
+		if uint64(len(buf)) < nameLen {
+			err = fmt.Errorf("VolumeRecs for volume \"%v\" truncated within name", volumeName)
+			return
+		}
+		name = string(buf[:nameLen])
+		buf = buf[nameLen:]
+
+		if uint64(len(buf)) < globals.uint64Size {
+			err = fmt.Errorf("VolumeRecs for volume \"%v\" truncated before %s length", volumeName, name)
+			return
+		}
+		valueLen = binary.LittleEndian.Uint64(buf)
+		buf = buf[globals.uint64Size:]
+		if uint64(len(buf)) < valueLen {
+			err = fmt.Errorf("VolumeRecs for volume \"%v\" truncated within %s", volumeName, name)
+			return
+		}
+		volumeRecs[name] = append([]byte(nil), buf[:valueLen]...)
+		buf = buf[valueLen:]
+	}
+
+	err = nil
+	return
+}
+
+func encodeVolumeRecs(volumeRecs map[string][]byte) (buf []byte) {
+	var (
+		lenBuf []byte
+		name   string
+		names  []string
+		value  []byte
+	)
+
+	names = make([]string, 0, len(volumeRecs))
+	for name = range volumeRecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf = make([]byte, 0)
+	lenBuf = make([]byte, globals.uint64Size)
+
+	for _, name = range names {
+		value = volumeRecs[name]
+		binary.LittleEndian.PutUint64(lenBuf, uint64(len(name)))
+		buf = append(buf, lenBuf...)
+		buf = append(buf, name...)
+		binary.LittleEndian.PutUint64(lenBuf, uint64(len(value)))
+		buf = append(buf, lenBuf...)
+		buf = append(buf, value...)
+	}
+
+	return
+}
+
+func (volume *volumeStruct) fetchVolumeRecsWhileLocked() (volumeRecs map[string][]byte, err error) {
+	var (
+		ok           bool
+		valueAsValue sortedmap.Value
+	)
+
+	valueAsValue, ok, err = volume.liveView.inodeRecWrapper.bPlusTree.GetByKey(volumeRecsKey)
+	if nil != err {
+		return
+	}
+	if !ok {
+		volumeRecs = make(map[string][]byte)
+		return
+	}
+
+	volumeRecs, err = decodeVolumeRecs(volume.volumeName, valueAsValue.([]byte))
+
+	return
+}
+
+func (volume *volumeStruct) getVolumeRec(name string) (value []byte, ok bool, err error) {
+	var (
+		volumeRecs map[string][]byte
+	)
+
+	volume.Lock()
+	defer volume.Unlock()
+
+	volumeRecs, err = volume.fetchVolumeRecsWhileLocked()
+	if nil != err {
+		return
+	}
+
+	value, ok = volumeRecs[name]
+
+	return
+}
+
+func (volume *volumeStruct) putVolumeRec(name string, value []byte) (err error) {
+	var (
+		ok         bool
+		volumeRecs map[string][]byte
+	)
+
+	volume.Lock()
+	defer volume.Unlock()
+
+	volumeRecs, err = volume.fetchVolumeRecsWhileLocked()
+	if nil != err {
+		return
+	}
+
+	volumeRecs[name] = append([]byte(nil), value...)
+
+	value = encodeVolumeRecs(volumeRecs)
+
+	volume.checkpointTriggeringEvents++
+
+	ok, err = volume.liveView.inodeRecWrapper.bPlusTree.PatchByKey(volumeRecsKey, value)
+	if nil != err {
+		return
+	}
+	if !ok {
+		_, err = volume.liveView.inodeRecWrapper.bPlusTree.Put(volumeRecsKey, value)
+		if nil != err {
+			return
+		}
+	}
+
+	volume.recordTransaction(transactionPutInodeRec, volumeRecsKey, value)
+
+	return
+}
+
+// GetQuotaRec returns the serialized quota limits and usage for the volume (if any)
+func (volume *volumeStruct) GetQuotaRec() (value []byte, ok bool, err error) {
+	value, ok, err = volume.getVolumeRec(volumeRecQuotaName)
+	return
+}
+
+// PutQuotaRec records the serialized quota limits and usage for the volume
+func (volume *volumeStruct) PutQuotaRec(value []byte) (err error) {
+	err = volume.putVolumeRec(volumeRecQuotaName, value)
+	return
+}
+
+// GetCompressionStatsRec returns the serialized compression stats for the volume (if any)
+func (volume *volumeStruct) GetCompressionStatsRec() (value []byte, ok bool, err error) {
+	value, ok, err = volume.getVolumeRec(volumeRecCompressionStatsName)
+	return
+}
+
+// PutCompressionStatsRec records the serialized compression stats for the volume
+func (volume *volumeStruct) PutCompressionStatsRec(value []byte) (err error) {
+	err = volume.putVolumeRec(volumeRecCompressionStatsName, value)
+	return
+}
@@ -440,16 +440,18 @@ const snapShotsTopTemplate string = `<!doctype html>
             <th scope="col" id="header-timestamp" class="w-25 clickable">Time</th>
             <th scope="col" id="header-name" class="clickable">Name</th>
             <th class="fit">&nbsp;</th>
+            <th class="fit">&nbsp;</th>
           </tr>
         </thead>
         <tbody>
 `
 
-// To use: fmt.Sprintf(snapShotsPerSnapShotTemplate, id, timeStamp.Format(time.RFC3339), name)
+// To use: fmt.Sprintf(snapShotsPerSnapShotTemplate, id, timeStamp.Format(time.RFC3339), name, {"pin"|"unpin"}, {"btn-outline-secondary"|"btn-secondary"})
 const snapShotsPerSnapShotTemplate string = `          <tr>
             <td>%[1]v</td>
             <td>%[2]v</td>
             <td>%[3]v</td>
+            <td class="fit"><a href="#" class="btn btn-sm %[5]v" onclick="pinSnapShot(%[1]v, '%[4]v');"><span class="oi oi-pin" title="%[4]v" aria-hidden="true"></a></td>
             <td class="fit"><a href="#" class="btn btn-sm btn-danger" onclick="deleteSnapShot(%[1]v);"><span class="oi oi-trash" title="Delete" aria-hidden="true"></a></td>
           </tr>
 `
@@ -480,6 +482,10 @@ const snapShotsBottomTemplate string = `        </tbody>
         var msg = 'Error deleting snapshot with ID <em>' + id + '</em>: ' + jqXHR.status + ' ' + jqXHR.statusText;
         showAlertWithMsg(msg);
       };
+      showPinError = function(id, action, jqXHR, textStatus, errorThrown) {
+        var msg = 'Error attempting to ' + action + ' snapshot with ID <em>' + id + '</em>: ' + jqXHR.status + ' ' + jqXHR.statusText;
+        showAlertWithMsg(msg);
+      };
       showCreateError = function(name, jqXHR, textStatus, errorThrown) {
         var msg = 'Error creating snapshot with name <em>' + name + '</em>: ' + jqXHR.status + ' ' + jqXHR.statusText;
         showAlertWithMsg(msg);
@@ -499,6 +505,20 @@ const snapShotsBottomTemplate string = `        </tbody>
           }
         });
       };
+      pinSnapShot = function(id, action) {
+        hideAlert();
+        var url = '/volume/' + volumeName + '/snapshot/' + id + '/' + action;
+        $.ajax({
+          url: url,
+          method: 'POST',
+          success: function(data, textStatus, jqXHR) {
+            location.reload();
+          },
+          error: function(jqXHR, textStatus, errorThrown) {
+            showPinError(id, action, jqXHR, textStatus, errorThrown);
+          }
+        });
+      };
       createSnapShot = function() {
         hideAlert();
         document.getElementById('new-snapshot-name').select();
@@ -885,6 +905,29 @@ const layoutReportTopTemplate string = `<!doctype html>
       <a id="validate-button" class="btn btn-primary float-right" href="#">Show validated version</a><br><br>
 `
 
+// To use: fmt.Sprintf(layoutReportCompressionTemplate, CompressedLogicalBytes, CompressedPhysicalBytes, CompressionRatio)
+const layoutReportCompressionTemplate string = `      <br>
+      <div class="d-flex justify-content-between">
+        <h3>Compressed Log Segments</h3>
+      </div>
+	    <table class="table table-sm table-striped table-hover">
+        <thead>
+          <tr>
+            <th scope="col" class="w-25">LogicalBytes</th>
+            <th scope="col" class="w-25">PhysicalBytes</th>
+            <th scope="col" class="w-50">Ratio</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr>
+            <td><pre class="no-margin">%[1]v</pre></td>
+            <td><pre class="no-margin">%[2]v</pre></td>
+            <td><pre class="no-margin">%[3]v</pre></td>
+          </tr>
+        </tbody>
+      </table>
+`
+
 // To use: fmt.Sprintf(layoutReportTableTopTemplate, TreeName, NumDiscrepencies, {"success"|"danger"})
 const layoutReportTableTopTemplate string = `      <br>
       <div class="d-flex justify-content-between">
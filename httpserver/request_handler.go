@@ -15,12 +15,14 @@ import (
 	"net/http/pprof"
 	"net/url"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/NVIDIA/sortedmap"
 
+	"github.com/NVIDIA/proxyfs/blunder"
 	"github.com/NVIDIA/proxyfs/bucketstats"
 	"github.com/NVIDIA/proxyfs/fs"
 	"github.com/NVIDIA/proxyfs/halter"
@@ -88,6 +90,7 @@ func doDeleteOfVolume(responseWriter http.ResponseWriter, request *http.Request)
 		numPathParts  int
 		ok            bool
 		pathSplit     []string
+		requestState  *requestStateStruct
 		snapShotID    uint64
 		volume        *volumeStruct
 		volumeAsValue sortedmap.Value
@@ -107,7 +110,12 @@ func doDeleteOfVolume(responseWriter http.ResponseWriter, request *http.Request)
 		return
 	}
 
-	if 4 != numPathParts {
+	switch numPathParts {
+	case 4:
+		// Form: /volume/<volume-name>/snapshot/<snapshot-id>
+	case 5:
+		// Form: /volume/<volume-name>/quota/<quota-type>/<quota-id>
+	default:
 		responseWriter.WriteHeader(http.StatusNotFound)
 		return
 	}
@@ -124,7 +132,18 @@ func doDeleteOfVolume(responseWriter http.ResponseWriter, request *http.Request)
 	}
 	volume = volumeAsValue.(*volumeStruct)
 
-	if "snapshot" != pathSplit[3] {
+	if (5 == numPathParts) && ("quota" == pathSplit[3]) {
+		requestState = &requestStateStruct{
+			pathSplit:    pathSplit,
+			numPathParts: numPathParts,
+			volume:       volume,
+		}
+
+		doDeleteOfQuota(responseWriter, request, requestState)
+		return
+	}
+
+	if (4 != numPathParts) || ("snapshot" != pathSplit[3]) {
 		responseWriter.WriteHeader(http.StatusNotFound)
 		return
 	}
@@ -342,26 +361,33 @@ func doGetOfLiveness(responseWriter http.ResponseWriter, request *http.Request)
 
 func doGetOfMetrics(responseWriter http.ResponseWriter, request *http.Request) {
 	var (
-		acceptHeader         string
-		err                  error
-		formatResponseAsHTML bool
-		formatResponseAsJSON bool
-		i                    int
-		memStats             runtime.MemStats
-		metricKey            string
-		metricValueAsString  string
-		metricValueAsUint64  uint64
-		metricsJSON          bytes.Buffer
-		metricsJSONPacked    []byte
-		metricsLLRB          sortedmap.LLRBTree
-		metricsMap           map[string]uint64
-		ok                   bool
-		paramList            []string
-		pauseNsAccumulator   uint64
-		sendPackedMetrics    bool
-		statKey              string
-		statValue            uint64
-		statsMap             map[string]uint64
+		acceptHeader          string
+		err                   error
+		formatResponseAsHTML  bool
+		formatResponseAsJSON  bool
+		i                     int
+		memStats              runtime.MemStats
+		metricKey             string
+		metricKeySuffix       string
+		metricValueAsUint64   uint64
+		metricsJSON           bytes.Buffer
+		metricsJSONPacked     []byte
+		metricsMap            map[string]uint64
+		ok                    bool
+		paramList             []string
+		pauseNsAccumulator    uint64
+		replicationMetrics    map[string]uint64
+		replicationMetricsMap map[string]map[string]uint64
+		replicationStatus     *headhunter.ReplicationStatusStruct
+		sendPackedMetrics     bool
+		statKey               string
+		statValue             uint64
+		statsMap              map[string]uint64
+		volume                *volumeStruct
+		volumeAsValue         sortedmap.Value
+		volumeListIndex       int
+		volumeListLen         int
+		volumeName            string
 	)
 
 	runtime.ReadMemStats(&memStats)
@@ -429,6 +455,40 @@ func doGetOfMetrics(responseWriter http.ResponseWriter, request *http.Request) {
 		metricsMap[metricKey] = statValue
 	}
 
+	// Per-volume replication statistics.
+	replicationMetricsMap = make(map[string]map[string]uint64) // Key: volume name
+	volumeListLen, err = globals.volumeLLRB.Len()
+	if nil != err {
+		logger.Fatalf("HTTP Server Logic Error: %v", err)
+	}
+	for volumeListIndex = 0; volumeListIndex < volumeListLen; volumeListIndex++ {
+		_, volumeAsValue, ok, err = globals.volumeLLRB.GetByIndex(volumeListIndex)
+		if nil != err {
+			logger.Fatalf("HTTP Server Logic Error: %v", err)
+		}
+		if !ok {
+			err = fmt.Errorf("httpserver.doGetOfMetrics() indexing globals.volumeLLRB failed")
+			logger.Fatalf("HTTP Server Logic Error: %v", err)
+		}
+
+		volume = volumeAsValue.(*volumeStruct)
+
+		replicationStatus = volume.headhunterVolumeHandle.FetchReplicationStatus()
+		if !replicationStatus.Enabled {
+			continue
+		}
+
+		replicationMetricsMap[volume.name] = map[string]uint64{
+			"lag_usec":               uint64(replicationStatus.Lag / time.Microsecond),
+			"backlog_checkpoints":    replicationStatus.BacklogCheckpoints,
+			"backlog_object_copies":  replicationStatus.BacklogObjectCopies,
+			"backlog_object_deletes": replicationStatus.BacklogObjectDeletes,
+			"copied_objects":         replicationStatus.CopiedObjects,
+			"copied_bytes":           replicationStatus.CopiedBytes,
+			"deleted_objects":        replicationStatus.DeletedObjects,
+		}
+	}
+
 	acceptHeader = request.Header.Get("Accept")
 
 	if strings.Contains(acceptHeader, "application/json") {
@@ -452,6 +512,15 @@ func doGetOfMetrics(responseWriter http.ResponseWriter, request *http.Request) {
 	}
 
 	if formatResponseAsJSON {
+		// Flatten the per-volume replication statistics as JSON has no notion of labels
+		for volumeName, replicationMetrics = range replicationMetricsMap {
+			metricKey = "replication_" + strings.Replace(volumeName, ".", "_", -1)
+			metricKey = strings.Replace(metricKey, "-", "_", -1)
+			for metricKeySuffix, metricValueAsUint64 = range replicationMetrics {
+				metricsMap[metricKey+"_"+metricKeySuffix] = metricValueAsUint64
+			}
+		}
+
 		metricsJSONPacked, _ = json.Marshal(metricsMap)
 		if formatResponseAsHTML {
 			responseWriter.Header().Set("Content-Type", "text/html")
@@ -482,23 +551,102 @@ func doGetOfMetrics(responseWriter http.ResponseWriter, request *http.Request) {
 			}
 		}
 	} else {
-		metricsLLRB = sortedmap.NewLLRBTree(sortedmap.CompareString, nil)
+		// Respond in the Prometheus text exposition format
 
-		for metricKey, metricValueAsUint64 = range metricsMap {
-			metricValueAsString = fmt.Sprintf("%v", metricValueAsUint64)
-			ok, err = metricsLLRB.Put(metricKey, metricValueAsString)
-			if nil != err {
-				err = fmt.Errorf("metricsLLRB.Put(%v, %v) failed: %v", metricKey, metricValueAsString, err)
-				logger.Fatalf("HTTP Server Logic Error: %v", err)
-			}
-			if !ok {
-				err = fmt.Errorf("metricsLLRB.Put(%v, %v) returned ok == false", metricKey, metricValueAsString)
-				logger.Fatalf("HTTP Server Logic Error: %v", err)
+		responseWriter.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		responseWriter.WriteHeader(http.StatusOK)
+
+		_, _ = responseWriter.Write([]byte(sprintPrometheusMetrics(metricsMap, replicationMetricsMap)))
+		_, _ = responseWriter.Write([]byte(bucketstats.SprintPrometheusStats("*", "*")))
+	}
+}
+
+// sprintPrometheusMetrics formats metricsMap (the Go runtime and package stats
+// metrics) and replicationMetricsMap (per-volume replication metrics labeled
+// with the volume name) in the Prometheus text exposition format. Package stats
+// metrics are reported without a type as they may be either counters or gauges.
+//
+func sprintPrometheusMetrics(metricsMap map[string]uint64, replicationMetricsMap map[string]map[string]uint64) (metrics string) {
+	var (
+		goRuntimeCounterSet     map[string]struct{}
+		isCounter               bool
+		isGoRuntime             bool
+		metricKey               string
+		metricKeySlice          []string
+		metricsBuf              bytes.Buffer
+		replicationCounterSet   map[string]struct{}
+		replicationMetricKey    string
+		replicationMetricKeySet map[string]struct{}
+		volumeName              string
+		volumeNameSlice         []string
+	)
+
+	goRuntimeCounterSet = map[string]struct{}{
+		"go_runtime_MemStats_TotalAlloc":   {},
+		"go_runtime_MemStats_Lookups":      {},
+		"go_runtime_MemStats_Mallocs":      {},
+		"go_runtime_MemStats_Frees":        {},
+		"go_runtime_MemStats_PauseTotalNs": {},
+		"go_runtime_MemStats_NumGC":        {},
+	}
+
+	replicationCounterSet = map[string]struct{}{
+		"copied_objects":  {},
+		"copied_bytes":    {},
+		"deleted_objects": {},
+	}
+
+	metricKeySlice = make([]string, 0, len(metricsMap))
+	for metricKey = range metricsMap {
+		metricKeySlice = append(metricKeySlice, metricKey)
+	}
+	sort.Strings(metricKeySlice)
+
+	for _, metricKey = range metricKeySlice {
+		isGoRuntime = strings.HasPrefix(metricKey, "go_runtime_")
+		if isGoRuntime {
+			_, isCounter = goRuntimeCounterSet[metricKey]
+			if isCounter {
+				_, _ = fmt.Fprintf(&metricsBuf, "# TYPE %s counter\n", metricKey)
+			} else {
+				_, _ = fmt.Fprintf(&metricsBuf, "# TYPE %s gauge\n", metricKey)
 			}
 		}
+		_, _ = fmt.Fprintf(&metricsBuf, "%s %d\n", metricKey, metricsMap[metricKey])
+	}
 
-		sortedTwoColumnResponseWriter(metricsLLRB, responseWriter)
+	volumeNameSlice = make([]string, 0, len(replicationMetricsMap))
+	replicationMetricKeySet = make(map[string]struct{})
+	for volumeName = range replicationMetricsMap {
+		volumeNameSlice = append(volumeNameSlice, volumeName)
+		for replicationMetricKey = range replicationMetricsMap[volumeName] {
+			replicationMetricKeySet[replicationMetricKey] = struct{}{}
+		}
 	}
+	sort.Strings(volumeNameSlice)
+
+	metricKeySlice = make([]string, 0, len(replicationMetricKeySet))
+	for replicationMetricKey = range replicationMetricKeySet {
+		metricKeySlice = append(metricKeySlice, replicationMetricKey)
+	}
+	sort.Strings(metricKeySlice)
+
+	for _, replicationMetricKey = range metricKeySlice {
+		metricKey = "replication_" + replicationMetricKey
+		_, isCounter = replicationCounterSet[replicationMetricKey]
+		if isCounter {
+			_, _ = fmt.Fprintf(&metricsBuf, "# TYPE %s counter\n", metricKey)
+		} else {
+			_, _ = fmt.Fprintf(&metricsBuf, "# TYPE %s gauge\n", metricKey)
+		}
+		for _, volumeName = range volumeNameSlice {
+			_, _ = fmt.Fprintf(&metricsBuf, "%s{volume=%q} %d\n", metricKey, volumeName, replicationMetricsMap[volumeName][replicationMetricKey])
+		}
+	}
+
+	metrics = metricsBuf.String()
+
+	return
 }
 
 func doGetOfStats(responseWriter http.ResponseWriter, request *http.Request) {
@@ -763,6 +911,8 @@ func doGetOfVolume(responseWriter http.ResponseWriter, request *http.Request) {
 		// Form: /volume/<volume-name>/layout-report
 		// Form: /volume/<volume-name>/lease-report
 		// Form: /volume/<volume-name>/meta-defrag
+		// Form: /volume/<volume-name>/quota
+		// Form: /volume/<volume-name>/replication
 		// Form: /volume/<volume-name>/scrub-job
 		// Form: /volume/<volume-name>/snapshot
 	case 4:
@@ -778,6 +928,8 @@ func doGetOfVolume(responseWriter http.ResponseWriter, request *http.Request) {
 		// Form: /volume/<volume-name>/defrag/<dir>/.../<basename>
 		// Form: /volume/<volume-name>/extent-map/<dir>/.../<basename>
 		// Form: /volume/<volume-name>/find-dir-inode/<dir>/.../<basename>
+		// Form: /volume/<volume-name>/quota/<quota-type>/<quota-id>
+		// Form: /volume/<volume-name>/snapshot/<from-snapshot-id>/diff/<to-snapshot-id>
 	}
 
 	acceptHeader = request.Header.Get("Accept")
@@ -963,11 +1115,21 @@ func doGetOfVolume(responseWriter http.ResponseWriter, request *http.Request) {
 	case "meta-defrag":
 		doMetaDefrag(responseWriter, request, requestState)
 
+	case "quota":
+		doGetOfQuota(responseWriter, request, requestState)
+
+	case "replication":
+		doGetOfReplication(responseWriter, request, requestState)
+
 	case "scrub-job":
 		doJob(scrubJobType, responseWriter, request, requestState)
 
 	case "snapshot":
-		doGetOfSnapShot(responseWriter, request, requestState)
+		if 3 == numPathParts {
+			doGetOfSnapShot(responseWriter, request, requestState)
+		} else {
+			doGetOfSnapShotDiff(responseWriter, request, requestState)
+		}
 
 	default:
 		responseWriter.WriteHeader(http.StatusNotFound)
@@ -1680,6 +1842,8 @@ type layoutReportSetElementWithoutDiscrepenciesStruct struct {
 
 func doLayoutReport(responseWriter http.ResponseWriter, request *http.Request, requestState *requestStateStruct) {
 	var (
+		compressionRatio                    string
+		compressionStats                    inode.CompressionStatsStruct
 		discrepencyFormatClass              string
 		err                                 error
 		layoutReportIndex                   int
@@ -1763,6 +1927,15 @@ func doLayoutReport(responseWriter http.ResponseWriter, request *http.Request, r
 
 		_, _ = responseWriter.Write([]byte(fmt.Sprintf(layoutReportTopTemplate, version.ProxyFSVersion, globals.ipAddrTCPPort, requestState.volume.name)))
 
+		compressionStats = requestState.volume.inodeVolumeHandle.FetchCompressionStats()
+		if 0 == compressionStats.PhysicalBytes {
+			compressionRatio = "n/a"
+		} else {
+			compressionRatio = fmt.Sprintf("%.2f", float64(compressionStats.LogicalBytes)/float64(compressionStats.PhysicalBytes))
+		}
+
+		_, _ = responseWriter.Write([]byte(fmt.Sprintf(layoutReportCompressionTemplate, compressionStats.LogicalBytes, compressionStats.PhysicalBytes, compressionRatio)))
+
 		for _, layoutReportSetElement = range layoutReportSet {
 			if 0 == layoutReportSetElement.Discrepencies {
 				discrepencyFormatClass = "success"
@@ -1816,6 +1989,34 @@ func doLeaseReport(responseWriter http.ResponseWriter, request *http.Request, re
 	}
 }
 
+func doGetOfReplication(responseWriter http.ResponseWriter, request *http.Request, requestState *requestStateStruct) {
+	var (
+		err                         error
+		replicationStatus           *headhunter.ReplicationStatusStruct
+		replicationStatusJSON       bytes.Buffer
+		replicationStatusJSONPacked []byte
+	)
+
+	replicationStatus = requestState.volume.headhunterVolumeHandle.FetchReplicationStatus()
+
+	replicationStatusJSONPacked, err = json.Marshal(replicationStatus)
+	if nil != err {
+		responseWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	if requestState.formatResponseCompactly {
+		_, _ = responseWriter.Write(replicationStatusJSONPacked)
+	} else {
+		json.Indent(&replicationStatusJSON, replicationStatusJSONPacked, "", "\t")
+		_, _ = responseWriter.Write(replicationStatusJSON.Bytes())
+		_, _ = responseWriter.Write([]byte("\n"))
+	}
+}
+
 func doGetOfSnapShot(responseWriter http.ResponseWriter, request *http.Request, requestState *requestStateStruct) {
 	var (
 		directionStringCanonicalized string
@@ -1886,13 +2087,103 @@ func doGetOfSnapShot(responseWriter http.ResponseWriter, request *http.Request,
 		_, _ = responseWriter.Write([]byte(fmt.Sprintf(snapShotsTopTemplate, version.ProxyFSVersion, globals.ipAddrTCPPort, requestState.volume.name)))
 
 		for _, snapShot = range list {
-			_, _ = responseWriter.Write([]byte(fmt.Sprintf(snapShotsPerSnapShotTemplate, snapShot.ID, snapShot.Time.Format(time.RFC3339), snapShot.Name)))
+			if snapShot.Pinned {
+				_, _ = responseWriter.Write([]byte(fmt.Sprintf(snapShotsPerSnapShotTemplate, snapShot.ID, snapShot.Time.Format(time.RFC3339), snapShot.Name, "unpin", "btn-secondary")))
+			} else {
+				_, _ = responseWriter.Write([]byte(fmt.Sprintf(snapShotsPerSnapShotTemplate, snapShot.ID, snapShot.Time.Format(time.RFC3339), snapShot.Name, "pin", "btn-outline-secondary")))
+			}
 		}
 
 		_, _ = responseWriter.Write([]byte(fmt.Sprintf(snapShotsBottomTemplate, requestState.volume.name)))
 	}
 }
 
+// doGetOfSnapShotDiff streams (in JSON form only) the fs.SnapShotDiffEntryStruct's
+// describing what changed between two SnapShots. A SnapShotID of 0 specifies the
+// live view. As entries are written as they are found, an error encountered part
+// way through is indicated only by a truncated response.
+//
+func doGetOfSnapShotDiff(responseWriter http.ResponseWriter, request *http.Request, requestState *requestStateStruct) {
+	var (
+		entryJSON      []byte
+		entriesWritten uint64
+		err            error
+		fromSnapShotID uint64
+		toSnapShotID   uint64
+	)
+
+	// Form: /volume/<volume-name>/snapshot/<from-snapshot-id>/diff/<to-snapshot-id>
+
+	if (6 != requestState.numPathParts) || ("diff" != requestState.pathSplit[5]) {
+		responseWriter.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	fromSnapShotID, err = strconv.ParseUint(requestState.pathSplit[4], 10, 64)
+	if nil != err {
+		responseWriter.WriteHeader(http.StatusNotFound)
+		return
+	}
+	toSnapShotID, err = strconv.ParseUint(requestState.pathSplit[6], 10, 64)
+	if nil != err {
+		responseWriter.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	entriesWritten = 0
+
+	writeHeaderIfNecessary := func() {
+		if 0 == entriesWritten {
+			responseWriter.Header().Set("Content-Type", "application/json")
+			responseWriter.WriteHeader(http.StatusOK)
+			_, _ = responseWriter.Write([]byte("["))
+		}
+	}
+
+	err = requestState.volume.fsVolumeHandle.SnapShotDiff(fromSnapShotID, toSnapShotID, func(entry *fs.SnapShotDiffEntryStruct) (err error) {
+		writeHeaderIfNecessary()
+
+		if requestState.formatResponseCompactly {
+			entryJSON, err = json.Marshal(entry)
+		} else {
+			entryJSON, err = json.MarshalIndent(entry, "\t", "\t")
+		}
+		if nil != err {
+			return
+		}
+
+		if 0 != entriesWritten {
+			_, _ = responseWriter.Write([]byte(","))
+		}
+		if !requestState.formatResponseCompactly {
+			_, _ = responseWriter.Write([]byte("\n\t"))
+		}
+		_, err = responseWriter.Write(entryJSON)
+
+		entriesWritten++
+
+		return
+	})
+	if nil != err {
+		if 0 == entriesWritten {
+			if blunder.Is(err, blunder.NotFoundError) {
+				responseWriter.WriteHeader(http.StatusNotFound)
+			} else {
+				responseWriter.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+		return
+	}
+
+	writeHeaderIfNecessary()
+
+	if requestState.formatResponseCompactly {
+		_, _ = responseWriter.Write([]byte("]"))
+	} else {
+		_, _ = responseWriter.Write([]byte("\n]\n"))
+	}
+}
+
 func doPost(responseWriter http.ResponseWriter, request *http.Request) {
 	switch {
 	case strings.HasPrefix(request.URL.Path, "/deletions"):
@@ -2047,6 +2338,11 @@ func doPostOfVolume(responseWriter http.ResponseWriter, request *http.Request) {
 	case 4:
 		// Form: /volume/<volume-name>/fsck-job/<job-id>
 		// Form: /volume/<volume-name>/scrub-job/<job-id>
+	case 5:
+		// Form: /volume/<volume-name>/snapshot/<snapshot-id>/pin
+		// Form: /volume/<volume-name>/snapshot/<snapshot-id>/restore
+		// Form: /volume/<volume-name>/snapshot/<snapshot-id>/rollback
+		// Form: /volume/<volume-name>/snapshot/<snapshot-id>/unpin
 	default:
 		responseWriter.WriteHeader(http.StatusNotFound)
 		return
@@ -2073,6 +2369,10 @@ func doPostOfVolume(responseWriter http.ResponseWriter, request *http.Request) {
 		doPostOfAddDirEntry(responseWriter, request, volume)
 		return
 	case "fsck-job":
+		if 5 == numPathParts {
+			responseWriter.WriteHeader(http.StatusNotFound)
+			return
+		}
 		jobType = fsckJobType
 	case "find-file-inodes-matching-lengths":
 		if 3 != numPathParts {
@@ -2103,13 +2403,31 @@ func doPostOfVolume(responseWriter http.ResponseWriter, request *http.Request) {
 		doPostOfPatchSymlinkInode(responseWriter, request, volume)
 		return
 	case "scrub-job":
+		if 5 == numPathParts {
+			responseWriter.WriteHeader(http.StatusNotFound)
+			return
+		}
 		jobType = scrubJobType
 	case "snapshot":
-		if 3 != numPathParts {
+		switch numPathParts {
+		case 3:
+			doPostOfSnapShot(responseWriter, request, volume)
+		case 5:
+			switch pathSplit[5] {
+			case "pin":
+				doPostOfSnapShotPin(responseWriter, request, volume, pathSplit[4], true)
+			case "restore":
+				doPostOfSnapShotRestore(responseWriter, request, volume, pathSplit[4])
+			case "rollback":
+				doPostOfSnapShotRollback(responseWriter, request, volume, pathSplit[4])
+			case "unpin":
+				doPostOfSnapShotPin(responseWriter, request, volume, pathSplit[4], false)
+			default:
+				responseWriter.WriteHeader(http.StatusNotFound)
+			}
+		default:
 			responseWriter.WriteHeader(http.StatusNotFound)
-			return
 		}
-		doPostOfSnapShot(responseWriter, request, volume)
 		return
 	default:
 		responseWriter.WriteHeader(http.StatusNotFound)
@@ -2295,6 +2613,81 @@ func doPostOfSnapShot(responseWriter http.ResponseWriter, request *http.Request,
 	}
 }
 
+func doPostOfSnapShotPin(responseWriter http.ResponseWriter, request *http.Request, volume *volumeStruct, snapShotIDAsString string, pinned bool) {
+	var (
+		err        error
+		snapShotID uint64
+	)
+
+	snapShotID, err = strconv.ParseUint(snapShotIDAsString, 10, 64)
+	if nil != err {
+		responseWriter.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = volume.inodeVolumeHandle.SnapShotPin(snapShotID, pinned)
+	if nil == err {
+		responseWriter.WriteHeader(http.StatusNoContent)
+	} else {
+		responseWriter.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func doPostOfSnapShotRestore(responseWriter http.ResponseWriter, request *http.Request, volume *volumeStruct, snapShotIDAsString string) {
+	var (
+		err        error
+		snapShotID uint64
+	)
+
+	snapShotID, err = strconv.ParseUint(snapShotIDAsString, 10, 64)
+	if nil != err {
+		responseWriter.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	_, err = volume.fsVolumeHandle.SnapShotRestore(snapShotID, request.FormValue("path"))
+	if nil == err {
+		responseWriter.WriteHeader(http.StatusCreated)
+	} else {
+		switch {
+		case blunder.Is(err, blunder.NotFoundError):
+			responseWriter.WriteHeader(http.StatusNotFound)
+		case blunder.Is(err, blunder.FileExistsError):
+			responseWriter.WriteHeader(http.StatusConflict)
+		case blunder.Is(err, blunder.InvalidArgError):
+			responseWriter.WriteHeader(http.StatusBadRequest)
+		default:
+			responseWriter.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+func doPostOfSnapShotRollback(responseWriter http.ResponseWriter, request *http.Request, volume *volumeStruct, snapShotIDAsString string) {
+	var (
+		err        error
+		snapShotID uint64
+	)
+
+	snapShotID, err = strconv.ParseUint(snapShotIDAsString, 10, 64)
+	if nil != err {
+		responseWriter.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = jrpcfs.SnapShotRollback(volume.name, snapShotID)
+	if nil == err {
+		responseWriter.WriteHeader(http.StatusNoContent)
+	} else {
+		if blunder.Is(err, blunder.NotFoundError) {
+			responseWriter.WriteHeader(http.StatusNotFound)
+		} else if blunder.Is(err, blunder.DevBusyError) {
+			responseWriter.WriteHeader(http.StatusConflict)
+		} else {
+			responseWriter.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
 func doPostOfAddDirEntry(responseWriter http.ResponseWriter, request *http.Request, volume *volumeStruct) {
 	var (
 		dirEntryInodeNumberAsString                         string
@@ -2934,6 +3327,8 @@ func doPutOfVolume(responseWriter http.ResponseWriter, request *http.Request) {
 	switch numPathParts {
 	case 3:
 		// Form: /volume/<volume-name>/replace-dir-entries
+	case 5:
+		// Form: /volume/<volume-name>/quota/<quota-type>/<quota-id>
 	default:
 		responseWriter.WriteHeader(http.StatusNotFound)
 		return
@@ -2951,11 +3346,19 @@ func doPutOfVolume(responseWriter http.ResponseWriter, request *http.Request) {
 	}
 
 	requestState = &requestStateStruct{
-		volume: volumeAsValue.(*volumeStruct),
+		pathSplit:    pathSplit,
+		numPathParts: numPathParts,
+		volume:       volumeAsValue.(*volumeStruct),
 	}
 
 	switch pathSplit[3] {
+	case "quota":
+		doPutOfQuota(responseWriter, request, requestState)
 	case "replace-dir-entries":
+		if 3 != numPathParts {
+			responseWriter.WriteHeader(http.StatusNotFound)
+			return
+		}
 		doReplaceDirEntries(responseWriter, request, requestState)
 	default:
 		responseWriter.WriteHeader(http.StatusNotFound)
@@ -3080,3 +3483,198 @@ func doReplaceDirEntries(responseWriter http.ResponseWriter, request *http.Reque
 		responseWriter.WriteHeader(http.StatusBadRequest)
 	}
 }
+
+// parseQuotaTypeAndID converts the <quota-type>/<quota-id> portion of a
+// /volume/<volume-name>/quota/<quota-type>/<quota-id> path.
+//
+func parseQuotaTypeAndID(requestState *requestStateStruct) (quotaType inode.QuotaType, quotaID uint64, ok bool) {
+	var (
+		err error
+	)
+
+	if 5 != requestState.numPathParts {
+		ok = false
+		return
+	}
+
+	quotaType = inode.QuotaType(requestState.pathSplit[4])
+
+	switch quotaType {
+	case inode.QuotaTypeUser:
+	case inode.QuotaTypeGroup:
+	case inode.QuotaTypeProject:
+	default:
+		ok = false
+		return
+	}
+
+	quotaID, err = strconv.ParseUint(requestState.pathSplit[5], 10, 64)
+	if nil != err {
+		ok = false
+		return
+	}
+
+	ok = true
+	return
+}
+
+func doGetOfQuota(responseWriter http.ResponseWriter, request *http.Request, requestState *requestStateStruct) {
+	var (
+		err             error
+		ok              bool
+		quota           inode.QuotaStruct
+		quotaID         uint64
+		quotaJSON       bytes.Buffer
+		quotaJSONPacked []byte
+		quotaType       inode.QuotaType
+	)
+
+	switch requestState.numPathParts {
+	case 3:
+		// Form: /volume/<volume-name>/quota
+
+		quotaJSONPacked, err = json.Marshal(requestState.volume.inodeVolumeHandle.FetchQuotaList())
+	case 5:
+		// Form: /volume/<volume-name>/quota/<quota-type>/<quota-id>
+
+		quotaType, quotaID, ok = parseQuotaTypeAndID(requestState)
+		if !ok {
+			responseWriter.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		quota, err = requestState.volume.inodeVolumeHandle.FetchQuota(quotaType, quotaID)
+		if nil != err {
+			responseWriter.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		quotaJSONPacked, err = json.Marshal(quota)
+	default:
+		responseWriter.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if nil != err {
+		responseWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Quotas are only reported in JSON form
+
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	if requestState.formatResponseCompactly {
+		_, _ = responseWriter.Write(quotaJSONPacked)
+	} else {
+		json.Indent(&quotaJSON, quotaJSONPacked, "", "\t")
+		_, _ = responseWriter.Write(quotaJSON.Bytes())
+		_, _ = responseWriter.Write([]byte("\n"))
+	}
+}
+
+// doPutOfQuota sets the limits for the specified quota. The following query
+// parameters are supported:
+//
+//   maxbytes=<N>   - limit on bytes (0 == unlimited; absent == unchanged)
+//   maxinodes=<N>  - limit on inodes (0 == unlimited; absent == unchanged)
+//   path=<dirpath> - (project quotas only) directory tree to tag with <quota-id>
+//
+func doPutOfQuota(responseWriter http.ResponseWriter, request *http.Request, requestState *requestStateStruct) {
+	var (
+		err         error
+		inodeNumber inode.InodeNumber
+		ok          bool
+		paramList   []string
+		quota       inode.QuotaStruct
+		quotaID     uint64
+		quotaLimits inode.QuotaLimitsStruct
+		quotaType   inode.QuotaType
+	)
+
+	// Form: /volume/<volume-name>/quota/<quota-type>/<quota-id>
+
+	quotaType, quotaID, ok = parseQuotaTypeAndID(requestState)
+	if !ok {
+		responseWriter.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	quota, err = requestState.volume.inodeVolumeHandle.FetchQuota(quotaType, quotaID)
+	if nil != err {
+		responseWriter.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	quotaLimits = quota.QuotaLimitsStruct
+
+	paramList, ok = request.URL.Query()["maxbytes"]
+	if ok && (0 < len(paramList)) {
+		quotaLimits.MaxBytes, err = strconv.ParseUint(paramList[0], 10, 64)
+		if nil != err {
+			responseWriter.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	paramList, ok = request.URL.Query()["maxinodes"]
+	if ok && (0 < len(paramList)) {
+		quotaLimits.MaxInodes, err = strconv.ParseUint(paramList[0], 10, 64)
+		if nil != err {
+			responseWriter.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	paramList, ok = request.URL.Query()["path"]
+	if ok && (0 < len(paramList)) {
+		if inode.QuotaTypeProject != quotaType {
+			responseWriter.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		inodeNumber, err = requestState.volume.fsVolumeHandle.LookupPath(inode.InodeRootUserID, inode.InodeGroupID(0), nil, paramList[0])
+		if nil != err {
+			responseWriter.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		err = requestState.volume.fsVolumeHandle.SetProjectID(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inodeNumber, quotaID)
+		if nil != err {
+			responseWriter.WriteHeader(http.StatusConflict)
+			return
+		}
+	}
+
+	err = requestState.volume.inodeVolumeHandle.SetQuotaLimits(quotaType, quotaID, quotaLimits)
+	if nil == err {
+		responseWriter.WriteHeader(http.StatusNoContent)
+	} else {
+		responseWriter.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func doDeleteOfQuota(responseWriter http.ResponseWriter, request *http.Request, requestState *requestStateStruct) {
+	var (
+		err       error
+		ok        bool
+		quotaID   uint64
+		quotaType inode.QuotaType
+	)
+
+	// Form: /volume/<volume-name>/quota/<quota-type>/<quota-id>
+
+	quotaType, quotaID, ok = parseQuotaTypeAndID(requestState)
+	if !ok {
+		responseWriter.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = requestState.volume.inodeVolumeHandle.SetQuotaLimits(quotaType, quotaID, inode.QuotaLimitsStruct{})
+	if nil == err {
+		responseWriter.WriteHeader(http.StatusNoContent)
+	} else {
+		responseWriter.WriteHeader(http.StatusBadRequest)
+	}
+}
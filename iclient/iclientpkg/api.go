@@ -1,4 +1,103 @@
 // Copyright (c) 2015-2021, NVIDIA CORPORATION.
 // SPDX-License-Identifier: Apache-2.0
 
+// Package iclientpkg implements the client side of a ProxyFS volume served by
+// package imgrpkg. The volume is presented via FUSE at a local mount point.
+// Inode metadata is obtained via package retryrpc-exposed imgrpkg RPCs (Mount,
+// RenewMount, Lease, GetInodeTableEntry, PutInodeTableEntries, etc.) while the
+// contents of each Inode (formatted as specified in package ilayout) are read
+// and written directly from/to the Container in Swift holding the volume.
+//
+// Coherency among multiple clients is achieved via Shared and Exclusive Leases
+// granted by imgrpkg on each Inode. Modifications to an Inode are only made while
+// holding an Exclusive Lease. Upon receipt of an RPCInterrupt upcall, any pending
+// modifications are flushed before the Lease is demoted or released.
+//
+// To configure an iclientpkg instance, Start() is called passing, as the sole
+// argument, a package conf ConfMap. Here is a sample .conf file:
+//
+//  [ICLIENT]
+//  VolumeName:                       testvol
+//  MountPointDirPath:                /mnt
+//  FUSEAllowOther:                   true
+//  FUSEMaxBackground:                1000
+//  FUSECongestionThreshhold:         0
+//  FUSEMaxWrite:                     131076
+//  FUSEEntryValidDuration:           250ms
+//  FUSEAttrValidDuration:            250ms
+//  AuthPlugInPath:                   iauth-swift.so
+//  AuthPlugInEnvName:                                 # Only used if not defined or is empty...
+//  AuthPlugInEnvValue:               {"AuthURL":"http://swift:8080/auth/v1.0"\u002C"AuthUser":"test:tester"\u002C"AuthKey":"testing"\u002C"Account":"AUTH_test"\u002C"Container":"con"}
+//  SwiftTimeout:                     10m
+//  SwiftRetryLimit:                  4
+//  SwiftRetryDelay:                  100ms
+//  SwiftRetryDelayVariance:          25               # Percentage (1-100) of SwiftRetryDelay
+//  SwiftRetryExpBackoff:             1.4
+//  SwiftConnectionPoolSize:          128
+//  RetryRPCPublicIPAddr:             imgr
+//  RetryRPCPort:                     32356
+//  RetryRPCDeadlineIO:               60s
+//  RetryRPCKeepAlivePeriod:          60s
+//  RetryRPCCACertFilePath:                            # Defaults to /dev/null (i.e. TCP)
+//  InodePayloadEvictLowLimit:        100000
+//  InodePayloadEvictHighLimit:       100010
+//  DirInodeMaxKeysPerBPlusTreePage:  1024
+//  FileInodeMaxKeysPerBPlusTreePage: 2048
+//  FileFlushTriggerSize:             10485760
+//  LogFilePath:                                       # iclient.log
+//  LogToConsole:                     true             # false
+//  TraceEnabled:                     false
+//
+// Most of the config keys are required and must have values. One exception
+// is LogFilePath that will default to "" and, hence, cause logging to not
+// go to a file. This might typically be used when LogToConsole is set to true.
+//
+// The AuthPlugInPath identifies the package iauth plug-in used to obtain both
+// the AuthToken presented to imgrpkg (and Swift) and the StorageURL identifying
+// the Container holding the volume. The string passed to the plug-in is taken
+// from the environment variable named by AuthPlugInEnvName if it is specified
+// or, if not, the value of AuthPlugInEnvValue.
+//
+// The RetryRPCCACertFilePath key is optional and, if provided, may be empty.
+// In such cases, the retryrpc package will be configured to use TCP. If, however,
+// it is present and provides a path to a valid CA Certificate file, the retryrpc
+// package will be configured to use TLS.
+//
 package iclientpkg
+
+import (
+	"github.com/NVIDIA/proxyfs/conf"
+)
+
+// Start is called to start serving.
+//
+func Start(confMap conf.ConfMap, fissionErrChan chan error) (err error) {
+	err = start(confMap, fissionErrChan)
+	return
+}
+
+// Stop is called to stop serving.
+//
+func Stop() (err error) {
+	err = stop()
+	return
+}
+
+// Signal is called to interrupt the server for performing operations such as log rotation.
+//
+func Signal() (err error) {
+	err = signal()
+	return
+}
+
+// LogWarnf is a wrapper around the internal logWarnf() func called by iclient/main.go::main().
+//
+func LogWarnf(format string, args ...interface{}) {
+	logWarnf(format, args...)
+}
+
+// LogInfof is a wrapper around the internal logInfof() func called by iclient/main.go::main().
+//
+func LogInfof(format string, args ...interface{}) {
+	logInfof(format, args...)
+}
@@ -0,0 +1,1787 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package iclientpkg
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/NVIDIA/fission"
+	"golang.org/x/sys/unix"
+
+	"github.com/NVIDIA/proxyfs/ilayout"
+	"github.com/NVIDIA/proxyfs/imgr/imgrpkg"
+)
+
+const (
+	attrBlockSize = uint32(512)
+
+	initOutFlagsMask = uint32(0) |
+		fission.InitFlagsAsyncRead |
+		fission.InitFlagsFileOps |
+		fission.InitFlagsAtomicOTrunc |
+		fission.InitFlagsBigWrites |
+		fission.InitFlagsAutoInvalData |
+		fission.InitFlagsParallelDirops |
+		fission.InitFlagsMaxPages |
+		fission.InitFlagsExplicitInvalData
+
+	iclientFuseSubtype = "ProxyFS"
+
+	statFSBlockSize   = uint32(4096)
+	statFSMaxNameLen  = uint32(4096)
+	statFSTotalBlocks = uint64(1) << 40
+	statFSTotalInodes = uint64(1) << 40
+)
+
+func performMountFUSE() (err error) {
+	globals.fissionVolume = fission.NewVolume(
+		globals.config.VolumeName,        // volumeName        string
+		globals.config.MountPointDirPath, // mountpointDirPath string
+		iclientFuseSubtype,               // fuseSubtype       string
+		globals.config.FUSEMaxWrite,      // initOutMaxWrite   uint32
+		globals.config.FUSEAllowOther,    // allowOther        bool
+		&globals,                         // callbacks         fission.Callbacks
+		newLogger(),                      // logger            *log.Logger
+		globals.fissionErrChan)           // errChan           chan error
+
+	err = globals.fissionVolume.DoMount()
+	if nil != err {
+		globals.fissionVolume = nil
+		err = fmt.Errorf("fissionVolume.DoMount() failed: %v", err)
+		return
+	}
+
+	err = nil
+	return
+}
+
+func performUnmountFUSE() (err error) {
+	if nil == globals.fissionVolume {
+		err = nil
+		return
+	}
+
+	err = globals.fissionVolume.DoUnmount()
+	if nil != err {
+		err = fmt.Errorf("fissionVolume.DoUnmount() failed: %v", err)
+		return
+	}
+
+	globals.fissionVolume = nil
+
+	err = nil
+	return
+}
+
+// convertErrToErrno maps an error returned by an imgrpkg RPC or Swift access to
+// the syscall.Errno to be returned to FUSE. Unexpected errors are logged.
+//
+func convertErrToErrno(err error, defaultErrno syscall.Errno) (errno syscall.Errno) {
+	if strings.HasPrefix(err.Error(), imgrpkg.EUnknownInodeNumber) {
+		errno = syscall.ENOENT
+	} else {
+		logWarn(err)
+		errno = defaultErrno
+	}
+
+	return
+}
+
+func fixAttrSizes(attr *fission.Attr) {
+	if syscall.S_IFREG == (attr.Mode & syscall.S_IFMT) {
+		attr.Blocks = attr.Size + (uint64(attrBlockSize) - 1)
+		attr.Blocks /= uint64(attrBlockSize)
+		attr.BlkSize = attrBlockSize
+	} else {
+		attr.Size = 0
+		attr.Blocks = 0
+		attr.BlkSize = 0
+	}
+}
+
+func timeToUnixTime(t time.Time) (sec uint64, nsec uint32) {
+	sec = uint64(t.Unix())
+	nsec = uint32(t.Nanosecond())
+	return
+}
+
+func unixTimeToTime(sec uint64, nsec uint32) (t time.Time) {
+	t = time.Unix(int64(sec), int64(nsec))
+	return
+}
+
+func durationToUnixTime(d time.Duration) (sec uint64, nsec uint32) {
+	sec = uint64(d / time.Second)
+	nsec = uint32(d % time.Second)
+	return
+}
+
+// fillAttr populates attr from the locked (and Leased) inode.
+//
+func (inode *inodeStruct) fillAttr(attr *fission.Attr) {
+	attr.Ino = inode.inodeNumber
+	attr.MTimeSec, attr.MTimeNSec = timeToUnixTime(inode.inodeHeadV1.ModificationTime)
+	attr.ATimeSec, attr.ATimeNSec = attr.MTimeSec, attr.MTimeNSec
+	attr.CTimeSec, attr.CTimeNSec = timeToUnixTime(inode.inodeHeadV1.StatusChangeTime)
+	attr.NLink = uint32(len(inode.inodeHeadV1.LinkTable))
+	attr.UID = uint32(inode.inodeHeadV1.UserID)
+	attr.GID = uint32(inode.inodeHeadV1.GroupID)
+	attr.RDev = 0
+	attr.Padding = 0
+
+	switch inode.inodeHeadV1.InodeType {
+	case ilayout.InodeTypeDir:
+		attr.Mode = syscall.S_IFDIR
+		attr.Size = 0
+	case ilayout.InodeTypeFile:
+		attr.Mode = syscall.S_IFREG
+		attr.Size = inode.inodeHeadV1.Size
+	case ilayout.InodeTypeSymLink:
+		attr.Mode = syscall.S_IFLNK
+		attr.Size = uint64(len(inode.inodeHeadV1.SymLinkTarget))
+	default:
+		logFatalf("InodeNumber %016X has unknown InodeType %v", inode.inodeNumber, inode.inodeHeadV1.InodeType)
+	}
+
+	attr.Mode |= uint32(inode.inodeHeadV1.Mode)
+
+	fixAttrSizes(attr)
+}
+
+// fillEntryOut populates entryOut from the locked (and Leased) inode.
+//
+func (inode *inodeStruct) fillEntryOut(entryOut *fission.EntryOut) {
+	entryOut.NodeID = inode.inodeNumber
+	entryOut.Generation = 0
+	entryOut.EntryValidSec, entryOut.EntryValidNSec = durationToUnixTime(globals.config.FUSEEntryValidDuration)
+	entryOut.AttrValidSec, entryOut.AttrValidNSec = durationToUnixTime(globals.config.FUSEAttrValidDuration)
+
+	inode.fillAttr(&entryOut.Attr)
+}
+
+// findStream returns the index of the StreamTable entry of the locked (and
+// Leased) inode for the specified name or -1 if not found.
+//
+func (inode *inodeStruct) findStream(name string) (streamTableIndex int) {
+	var (
+		streamTableEntry ilayout.InodeStreamTableEntryStruct
+	)
+
+	for streamTableIndex, streamTableEntry = range inode.inodeHeadV1.StreamTable {
+		if streamTableEntry.Name == name {
+			return
+		}
+	}
+
+	streamTableIndex = -1
+	return
+}
+
+func allocateFH(inodeNumber uint64) (fh uint64) {
+	globals.Lock()
+
+	globals.lastFH++
+
+	fh = globals.lastFH
+
+	globals.fhToInodeNumber[fh] = inodeNumber
+
+	globals.Unlock()
+
+	return
+}
+
+func fetchFH(fh uint64, inodeNumber uint64) (ok bool) {
+	var (
+		fhInodeNumber uint64
+	)
+
+	globals.Lock()
+	fhInodeNumber, ok = globals.fhToInodeNumber[fh]
+	globals.Unlock()
+
+	ok = ok && (fhInodeNumber == inodeNumber)
+
+	return
+}
+
+func releaseFH(fh uint64, inodeNumber uint64) (ok bool) {
+	var (
+		fhInodeNumber uint64
+	)
+
+	globals.Lock()
+
+	fhInodeNumber, ok = globals.fhToInodeNumber[fh]
+	ok = ok && (fhInodeNumber == inodeNumber)
+	if ok {
+		delete(globals.fhToInodeNumber, fh)
+	}
+
+	globals.Unlock()
+
+	return
+}
+
+func (dummy *globalsStruct) DoLookup(inHeader *fission.InHeader, lookupIn *fission.LookupIn) (lookupOut *fission.LookupOut, errno syscall.Errno) {
+	var (
+		dirEntry  ilayout.DirectoryEntryValueV1Struct
+		dirInode  *inodeStruct
+		err       error
+		inode     *inodeStruct
+		ok        bool
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoLookupUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	dirInode, err = lockInodeWithLease(inHeader.NodeID, false)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if ilayout.InodeTypeDir != dirInode.inodeHeadV1.InodeType {
+		dirInode.unlock()
+		errno = syscall.ENOTDIR
+		return
+	}
+
+	dirEntry, ok, err = dirInode.lookupDirEntry(string(lookupIn.Name))
+
+	dirInode.unlock()
+
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	if !ok {
+		errno = syscall.ENOENT
+		return
+	}
+
+	inode, err = lockInodeWithLease(dirEntry.InodeNumber, false)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	lookupOut = &fission.LookupOut{}
+
+	inode.fillEntryOut(&lookupOut.EntryOut)
+
+	inode.unlock()
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoForget(inHeader *fission.InHeader, forgetIn *fission.ForgetIn) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoForgetUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+}
+
+func (dummy *globalsStruct) DoGetAttr(inHeader *fission.InHeader, getAttrIn *fission.GetAttrIn) (getAttrOut *fission.GetAttrOut, errno syscall.Errno) {
+	var (
+		err       error
+		inode     *inodeStruct
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoGetAttrUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, false)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	getAttrOut = &fission.GetAttrOut{}
+
+	getAttrOut.AttrValidSec, getAttrOut.AttrValidNSec = durationToUnixTime(globals.config.FUSEAttrValidDuration)
+
+	inode.fillAttr(&getAttrOut.Attr)
+
+	inode.unlock()
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoSetAttr(inHeader *fission.InHeader, setAttrIn *fission.SetAttrIn) (setAttrOut *fission.SetAttrOut, errno syscall.Errno) {
+	var (
+		err       error
+		inode     *inodeStruct
+		modified  bool
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoSetAttrUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, true)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	modified = false
+
+	if 0 != (setAttrIn.Valid & fission.SetAttrInValidSize) {
+		if ilayout.InodeTypeFile != inode.inodeHeadV1.InodeType {
+			inode.unlock()
+			if ilayout.InodeTypeDir == inode.inodeHeadV1.InodeType {
+				errno = syscall.EISDIR
+			} else {
+				errno = syscall.EINVAL
+			}
+			return
+		}
+
+		err = inode.truncateFile(setAttrIn.Size)
+		if nil != err {
+			inode.unlock()
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+
+		modified = true
+	}
+
+	if 0 != (setAttrIn.Valid & fission.SetAttrInValidMode) {
+		inode.inodeHeadV1.Mode = uint16(setAttrIn.Mode) & ilayout.InodeModeMask
+		modified = true
+	}
+	if 0 != (setAttrIn.Valid & fission.SetAttrInValidUID) {
+		inode.inodeHeadV1.UserID = uint64(setAttrIn.UID)
+		modified = true
+	}
+	if 0 != (setAttrIn.Valid & fission.SetAttrInValidGID) {
+		inode.inodeHeadV1.GroupID = uint64(setAttrIn.GID)
+		modified = true
+	}
+
+	if modified {
+		inode.markModified(false)
+	}
+
+	// Note: As no ATime is maintained, only an explicit MTime update is honored
+
+	if 0 != (setAttrIn.Valid & fission.SetAttrInValidMTime) {
+		inode.markModified(false)
+		inode.inodeHeadV1.ModificationTime = unixTimeToTime(setAttrIn.MTimeSec, setAttrIn.MTimeNSec)
+	} else if 0 != (setAttrIn.Valid & fission.SetAttrInValidMTimeNow) {
+		inode.markModified(true)
+	}
+
+	setAttrOut = &fission.SetAttrOut{}
+
+	setAttrOut.AttrValidSec, setAttrOut.AttrValidNSec = durationToUnixTime(globals.config.FUSEAttrValidDuration)
+
+	inode.fillAttr(&setAttrOut.Attr)
+
+	inode.unlock()
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoReadLink(inHeader *fission.InHeader) (readLinkOut *fission.ReadLinkOut, errno syscall.Errno) {
+	var (
+		err       error
+		inode     *inodeStruct
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoReadLinkUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, false)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if ilayout.InodeTypeSymLink != inode.inodeHeadV1.InodeType {
+		inode.unlock()
+		errno = syscall.EINVAL
+		return
+	}
+
+	readLinkOut = &fission.ReadLinkOut{
+		Data: []byte(inode.inodeHeadV1.SymLinkTarget),
+	}
+
+	inode.unlock()
+
+	errno = 0
+	return
+}
+
+// doCreate implements the portion of DoSymLink, DoMkNod, DoMkDir, and DoCreate
+// that creates a new inode and inserts it in the directory identified by
+// inHeader.NodeID. Upon success, the new inode is returned still locked by the
+// returned namespaceOp that the caller must end().
+//
+func doCreate(inHeader *fission.InHeader, name []byte, inodeType uint8, mode uint32, symLinkTarget string) (namespaceOp *namespaceOpStruct, inode *inodeStruct, errno syscall.Errno) {
+	var (
+		dirInode *inodeStruct
+		err      error
+		ok       bool
+	)
+
+	namespaceOp = beginNamespaceOp()
+
+	dirInode, err = namespaceOp.lockInode(inHeader.NodeID)
+	if nil != err {
+		namespaceOp.end()
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if ilayout.InodeTypeDir != dirInode.inodeHeadV1.InodeType {
+		namespaceOp.end()
+		errno = syscall.ENOTDIR
+		return
+	}
+
+	_, ok, err = dirInode.lookupDirEntry(string(name))
+	if nil != err {
+		namespaceOp.end()
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	if ok {
+		namespaceOp.end()
+		errno = syscall.EEXIST
+		return
+	}
+
+	inode, err = namespaceOp.createInode(inodeType, uint16(mode), uint64(inHeader.UID), uint64(inHeader.GID))
+	if nil != err {
+		namespaceOp.end()
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	inode.inodeHeadV1.SymLinkTarget = symLinkTarget
+
+	inode.addLink(dirInode.inodeNumber, string(name))
+
+	if ilayout.InodeTypeDir == inodeType {
+		_, err = inode.payload.Put(".", ilayout.DirectoryEntryValueV1Struct{InodeNumber: inode.inodeNumber, InodeType: ilayout.InodeTypeDir})
+		if nil == err {
+			_, err = inode.payload.Put("..", ilayout.DirectoryEntryValueV1Struct{InodeNumber: dirInode.inodeNumber, InodeType: ilayout.InodeTypeDir})
+		}
+		if nil != err {
+			namespaceOp.abort()
+			namespaceOp.end()
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+
+		inode.addLink(inode.inodeNumber, ".")
+		dirInode.addLink(inode.inodeNumber, "..")
+	}
+
+	_, err = dirInode.payload.Put(string(name), ilayout.DirectoryEntryValueV1Struct{InodeNumber: inode.inodeNumber, InodeType: inodeType})
+	if nil != err {
+		namespaceOp.abort()
+		namespaceOp.end()
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	dirInode.markModified(true)
+
+	err = namespaceOp.flush()
+	if nil != err {
+		namespaceOp.end()
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoSymLink(inHeader *fission.InHeader, symLinkIn *fission.SymLinkIn) (symLinkOut *fission.SymLinkOut, errno syscall.Errno) {
+	var (
+		inode       *inodeStruct
+		namespaceOp *namespaceOpStruct
+		startTime   time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoSymLinkUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	namespaceOp, inode, errno = doCreate(inHeader, symLinkIn.Name, ilayout.InodeTypeSymLink, uint32(ilayout.InodeModeMask), string(symLinkIn.Data))
+	if 0 != errno {
+		return
+	}
+
+	symLinkOut = &fission.SymLinkOut{}
+
+	inode.fillEntryOut(&symLinkOut.EntryOut)
+
+	namespaceOp.end()
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoMkNod(inHeader *fission.InHeader, mkNodIn *fission.MkNodIn) (mkNodOut *fission.MkNodOut, errno syscall.Errno) {
+	var (
+		inode       *inodeStruct
+		namespaceOp *namespaceOpStruct
+		startTime   time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoMkNodUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	if syscall.S_IFREG != (mkNodIn.Mode & syscall.S_IFMT) {
+		errno = syscall.EPERM
+		return
+	}
+
+	namespaceOp, inode, errno = doCreate(inHeader, mkNodIn.Name, ilayout.InodeTypeFile, mkNodIn.Mode, "")
+	if 0 != errno {
+		return
+	}
+
+	mkNodOut = &fission.MkNodOut{}
+
+	inode.fillEntryOut(&mkNodOut.EntryOut)
+
+	namespaceOp.end()
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoMkDir(inHeader *fission.InHeader, mkDirIn *fission.MkDirIn) (mkDirOut *fission.MkDirOut, errno syscall.Errno) {
+	var (
+		inode       *inodeStruct
+		namespaceOp *namespaceOpStruct
+		startTime   time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoMkDirUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	namespaceOp, inode, errno = doCreate(inHeader, mkDirIn.Name, ilayout.InodeTypeDir, mkDirIn.Mode, "")
+	if 0 != errno {
+		return
+	}
+
+	mkDirOut = &fission.MkDirOut{}
+
+	inode.fillEntryOut(&mkDirOut.EntryOut)
+
+	namespaceOp.end()
+
+	errno = 0
+	return
+}
+
+// doRemove implements DoUnlink (if isDir is false) and DoRmDir (if isDir is true).
+//
+func doRemove(inHeader *fission.InHeader, name []byte, isDir bool) (errno syscall.Errno) {
+	var (
+		dirEntry      ilayout.DirectoryEntryValueV1Struct
+		dirInode      *inodeStruct
+		err           error
+		inode         *inodeStruct
+		namespaceOp   *namespaceOpStruct
+		numDirEntries int
+		ok            bool
+	)
+
+	if ("." == string(name)) || (".." == string(name)) {
+		errno = syscall.EINVAL
+		return
+	}
+
+	namespaceOp = beginNamespaceOp()
+	defer namespaceOp.end()
+
+	dirInode, err = namespaceOp.lockInode(inHeader.NodeID)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if ilayout.InodeTypeDir != dirInode.inodeHeadV1.InodeType {
+		errno = syscall.ENOTDIR
+		return
+	}
+
+	dirEntry, ok, err = dirInode.lookupDirEntry(string(name))
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	if !ok {
+		errno = syscall.ENOENT
+		return
+	}
+
+	if isDir {
+		if ilayout.InodeTypeDir != dirEntry.InodeType {
+			errno = syscall.ENOTDIR
+			return
+		}
+	} else {
+		if ilayout.InodeTypeDir == dirEntry.InodeType {
+			errno = syscall.EISDIR
+			return
+		}
+	}
+
+	inode, err = namespaceOp.lockInode(dirEntry.InodeNumber)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if isDir {
+		numDirEntries, err = inode.payload.Len()
+		if nil != err {
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+		if 2 < numDirEntries {
+			errno = syscall.ENOTEMPTY
+			return
+		}
+	}
+
+	_, err = dirInode.payload.DeleteByKey(string(name))
+	if nil != err {
+		namespaceOp.abort()
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	dirInode.markModified(true)
+
+	inode.removeLink(dirInode.inodeNumber, string(name))
+
+	if isDir {
+		inode.removeLink(inode.inodeNumber, ".")
+		dirInode.removeLink(inode.inodeNumber, "..")
+	}
+
+	err = namespaceOp.flush()
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	err = inode.deleteIfUnlinked()
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoUnlink(inHeader *fission.InHeader, unlinkIn *fission.UnlinkIn) (errno syscall.Errno) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoUnlinkUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	errno = doRemove(inHeader, unlinkIn.Name, false)
+	return
+}
+
+func (dummy *globalsStruct) DoRmDir(inHeader *fission.InHeader, rmDirIn *fission.RmDirIn) (errno syscall.Errno) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoRmDirUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	errno = doRemove(inHeader, rmDirIn.Name, true)
+	return
+}
+
+// doRename implements DoRename and (absent any flags) DoRename2.
+//
+func doRename(inHeader *fission.InHeader, oldName []byte, newDirInodeNumber uint64, newName []byte) (errno syscall.Errno) {
+	var (
+		ancestorDirEntry ilayout.DirectoryEntryValueV1Struct
+		ancestorInode    *inodeStruct
+		dirEntry         ilayout.DirectoryEntryValueV1Struct
+		err              error
+		inode            *inodeStruct
+		namespaceOp      *namespaceOpStruct
+		newDirInode      *inodeStruct
+		numDirEntries    int
+		ok               bool
+		oldDirInode      *inodeStruct
+		targetDirEntry   ilayout.DirectoryEntryValueV1Struct
+		targetInode      *inodeStruct
+	)
+
+	if ("." == string(oldName)) || (".." == string(oldName)) || ("." == string(newName)) || (".." == string(newName)) {
+		errno = syscall.EINVAL
+		return
+	}
+
+	namespaceOp = beginNamespaceOp()
+	defer namespaceOp.end()
+
+	oldDirInode, err = namespaceOp.lockInode(inHeader.NodeID)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	if ilayout.InodeTypeDir != oldDirInode.inodeHeadV1.InodeType {
+		errno = syscall.ENOTDIR
+		return
+	}
+
+	newDirInode, err = namespaceOp.lockInode(newDirInodeNumber)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	if ilayout.InodeTypeDir != newDirInode.inodeHeadV1.InodeType {
+		errno = syscall.ENOTDIR
+		return
+	}
+
+	dirEntry, ok, err = oldDirInode.lookupDirEntry(string(oldName))
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	if !ok {
+		errno = syscall.ENOENT
+		return
+	}
+
+	inode, err = namespaceOp.lockInode(dirEntry.InodeNumber)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if (ilayout.InodeTypeDir == dirEntry.InodeType) && (oldDirInode != newDirInode) {
+		// Ensure a directory is not being moved beneath itself
+
+		ancestorInode = newDirInode
+
+		for ilayout.RootDirInodeNumber != ancestorInode.inodeNumber {
+			if ancestorInode == inode {
+				errno = syscall.EINVAL
+				return
+			}
+
+			ancestorDirEntry, ok, err = ancestorInode.lookupDirEntry("..")
+			if nil != err {
+				errno = convertErrToErrno(err, syscall.EIO)
+				return
+			}
+			if !ok {
+				logFatalf("InodeNumber %016X missing \"..\" DirEntry", ancestorInode.inodeNumber)
+			}
+
+			ancestorInode, err = namespaceOp.lockInode(ancestorDirEntry.InodeNumber)
+			if nil != err {
+				errno = convertErrToErrno(err, syscall.EIO)
+				return
+			}
+		}
+	}
+
+	targetDirEntry, ok, err = newDirInode.lookupDirEntry(string(newName))
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if ok {
+		if targetDirEntry.InodeNumber == dirEntry.InodeNumber {
+			errno = 0
+			return
+		}
+
+		targetInode, err = namespaceOp.lockInode(targetDirEntry.InodeNumber)
+		if nil != err {
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+
+		if ilayout.InodeTypeDir == dirEntry.InodeType {
+			if ilayout.InodeTypeDir != targetDirEntry.InodeType {
+				errno = syscall.ENOTDIR
+				return
+			}
+
+			numDirEntries, err = targetInode.payload.Len()
+			if nil != err {
+				errno = convertErrToErrno(err, syscall.EIO)
+				return
+			}
+			if 2 < numDirEntries {
+				errno = syscall.ENOTEMPTY
+				return
+			}
+		} else {
+			if ilayout.InodeTypeDir == targetDirEntry.InodeType {
+				errno = syscall.EISDIR
+				return
+			}
+		}
+
+		_, err = newDirInode.payload.DeleteByKey(string(newName))
+		if nil != err {
+			namespaceOp.abort()
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+
+		targetInode.removeLink(newDirInode.inodeNumber, string(newName))
+
+		if ilayout.InodeTypeDir == targetDirEntry.InodeType {
+			targetInode.removeLink(targetInode.inodeNumber, ".")
+			newDirInode.removeLink(targetInode.inodeNumber, "..")
+		}
+	} else {
+		targetInode = nil
+	}
+
+	_, err = oldDirInode.payload.DeleteByKey(string(oldName))
+	if nil == err {
+		_, err = newDirInode.payload.Put(string(newName), dirEntry)
+	}
+	if nil != err {
+		namespaceOp.abort()
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	oldDirInode.markModified(true)
+	newDirInode.markModified(true)
+
+	inode.removeLink(oldDirInode.inodeNumber, string(oldName))
+	inode.addLink(newDirInode.inodeNumber, string(newName))
+
+	if (ilayout.InodeTypeDir == dirEntry.InodeType) && (oldDirInode != newDirInode) {
+		_, err = inode.payload.PatchByKey("..", ilayout.DirectoryEntryValueV1Struct{InodeNumber: newDirInode.inodeNumber, InodeType: ilayout.InodeTypeDir})
+		if nil != err {
+			namespaceOp.abort()
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+
+		oldDirInode.removeLink(inode.inodeNumber, "..")
+		newDirInode.addLink(inode.inodeNumber, "..")
+	}
+
+	err = namespaceOp.flush()
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if nil != targetInode {
+		err = targetInode.deleteIfUnlinked()
+		if nil != err {
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoRename(inHeader *fission.InHeader, renameIn *fission.RenameIn) (errno syscall.Errno) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoRenameUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	errno = doRename(inHeader, renameIn.OldName, renameIn.NewDir, renameIn.NewName)
+	return
+}
+
+func (dummy *globalsStruct) DoLink(inHeader *fission.InHeader, linkIn *fission.LinkIn) (linkOut *fission.LinkOut, errno syscall.Errno) {
+	var (
+		dirInode    *inodeStruct
+		err         error
+		inode       *inodeStruct
+		namespaceOp *namespaceOpStruct
+		ok          bool
+		startTime   time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoLinkUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	namespaceOp = beginNamespaceOp()
+	defer namespaceOp.end()
+
+	inode, err = namespaceOp.lockInode(linkIn.OldNodeID)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	if ilayout.InodeTypeDir == inode.inodeHeadV1.InodeType {
+		errno = syscall.EPERM
+		return
+	}
+
+	dirInode, err = namespaceOp.lockInode(inHeader.NodeID)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	if ilayout.InodeTypeDir != dirInode.inodeHeadV1.InodeType {
+		errno = syscall.ENOTDIR
+		return
+	}
+
+	_, ok, err = dirInode.lookupDirEntry(string(linkIn.Name))
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	if ok {
+		errno = syscall.EEXIST
+		return
+	}
+
+	_, err = dirInode.payload.Put(string(linkIn.Name), ilayout.DirectoryEntryValueV1Struct{InodeNumber: inode.inodeNumber, InodeType: inode.inodeHeadV1.InodeType})
+	if nil != err {
+		namespaceOp.abort()
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	dirInode.markModified(true)
+
+	inode.addLink(dirInode.inodeNumber, string(linkIn.Name))
+
+	err = namespaceOp.flush()
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	linkOut = &fission.LinkOut{}
+
+	inode.fillEntryOut(&linkOut.EntryOut)
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoOpen(inHeader *fission.InHeader, openIn *fission.OpenIn) (openOut *fission.OpenOut, errno syscall.Errno) {
+	var (
+		err       error
+		inode     *inodeStruct
+		startTime time.Time = time.Now()
+		truncate  bool
+	)
+
+	defer func() {
+		globals.stats.DoOpenUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	truncate = (0 != (openIn.Flags & syscall.O_TRUNC))
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, truncate)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if ilayout.InodeTypeFile != inode.inodeHeadV1.InodeType {
+		inode.unlock()
+		if ilayout.InodeTypeDir == inode.inodeHeadV1.InodeType {
+			errno = syscall.EISDIR
+		} else {
+			errno = syscall.EINVAL
+		}
+		return
+	}
+
+	if truncate {
+		err = inode.truncateFile(0)
+		if nil != err {
+			inode.unlock()
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+	}
+
+	err = inode.adjustOpenCount(1)
+	if nil != err {
+		inode.unlock()
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	inode.openCount++
+
+	openOut = &fission.OpenOut{
+		FH:        allocateFH(inode.inodeNumber),
+		OpenFlags: 0,
+		Padding:   0,
+	}
+
+	inode.unlock()
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.ReadIn) (readOut *fission.ReadOut, errno syscall.Errno) {
+	var (
+		buf       []byte
+		err       error
+		inode     *inodeStruct
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoReadUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	if !fetchFH(readIn.FH, inHeader.NodeID) {
+		errno = syscall.EBADF
+		return
+	}
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, false)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	buf, err = inode.readFile(readIn.Offset, uint64(readIn.Size))
+
+	inode.unlock()
+
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	readOut = &fission.ReadOut{
+		Data: buf,
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoWrite(inHeader *fission.InHeader, writeIn *fission.WriteIn) (writeOut *fission.WriteOut, errno syscall.Errno) {
+	var (
+		err       error
+		inode     *inodeStruct
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoWriteUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	if !fetchFH(writeIn.FH, inHeader.NodeID) {
+		errno = syscall.EBADF
+		return
+	}
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, true)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	err = inode.writeFile(writeIn.Offset, writeIn.Data)
+
+	inode.unlock()
+
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	writeOut = &fission.WriteOut{
+		Size:    uint32(len(writeIn.Data)),
+		Padding: 0,
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoStatFS(inHeader *fission.InHeader) (statFSOut *fission.StatFSOut, errno syscall.Errno) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoStatFSUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	// Note: imgrpkg does not (yet) report volume capacity, so fixed values are returned
+
+	statFSOut = &fission.StatFSOut{
+		KStatFS: fission.KStatFS{
+			Blocks:  statFSTotalBlocks,
+			BFree:   statFSTotalBlocks,
+			BAvail:  statFSTotalBlocks,
+			Files:   statFSTotalInodes,
+			FFree:   statFSTotalInodes,
+			BSize:   statFSBlockSize,
+			NameLen: statFSMaxNameLen,
+			FRSize:  statFSBlockSize,
+			Padding: 0,
+			Spare:   [6]uint32{0, 0, 0, 0, 0, 0},
+		},
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoRelease(inHeader *fission.InHeader, releaseIn *fission.ReleaseIn) (errno syscall.Errno) {
+	var (
+		err       error
+		inode     *inodeStruct
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoReleaseUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	if !releaseFH(releaseIn.FH, inHeader.NodeID) {
+		errno = syscall.EBADF
+		return
+	}
+
+	inode = lockInode(inHeader.NodeID)
+	defer inode.unlock()
+
+	err = flushInodes([]*inodeStruct{inode})
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	err = inode.ensureLease(false)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	err = inode.adjustOpenCount(-1)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	inode.openCount--
+
+	if inode.markedForDelete && (0 == inode.openCount) {
+		err = inode.releaseLease()
+		if nil != err {
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+	}
+
+	errno = 0
+	return
+}
+
+// doFSync flushes any pending modifications to the specified inode and, if
+// persist is true, requests that imgrpkg persist them.
+//
+func doFSync(inodeNumber uint64, persist bool) (errno syscall.Errno) {
+	var (
+		err   error
+		inode *inodeStruct
+	)
+
+	inode = lockInode(inodeNumber)
+
+	err = flushInodes([]*inodeStruct{inode})
+
+	inode.unlock()
+
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if persist {
+		err = flushVolume()
+		if nil != err {
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoFSync(inHeader *fission.InHeader, fSyncIn *fission.FSyncIn) (errno syscall.Errno) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoFSyncUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	errno = doFSync(inHeader.NodeID, true)
+	return
+}
+
+func (dummy *globalsStruct) DoSetXAttr(inHeader *fission.InHeader, setXAttrIn *fission.SetXAttrIn) (errno syscall.Errno) {
+	var (
+		err              error
+		inode            *inodeStruct
+		startTime        time.Time = time.Now()
+		streamTableIndex int
+	)
+
+	defer func() {
+		globals.stats.DoSetXAttrUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, true)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	defer inode.unlock()
+
+	streamTableIndex = inode.findStream(string(setXAttrIn.Name))
+
+	if 0 > streamTableIndex {
+		if 0 != (setXAttrIn.Flags & unix.XATTR_REPLACE) {
+			errno = syscall.ENODATA
+			return
+		}
+
+		inode.inodeHeadV1.StreamTable = append(inode.inodeHeadV1.StreamTable, ilayout.InodeStreamTableEntryStruct{
+			Name:  string(setXAttrIn.Name),
+			Value: append([]byte{}, setXAttrIn.Data...),
+		})
+	} else {
+		if 0 != (setXAttrIn.Flags & unix.XATTR_CREATE) {
+			errno = syscall.EEXIST
+			return
+		}
+
+		inode.inodeHeadV1.StreamTable[streamTableIndex].Value = append([]byte{}, setXAttrIn.Data...)
+	}
+
+	inode.markModified(false)
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoGetXAttr(inHeader *fission.InHeader, getXAttrIn *fission.GetXAttrIn) (getXAttrOut *fission.GetXAttrOut, errno syscall.Errno) {
+	var (
+		err              error
+		inode            *inodeStruct
+		startTime        time.Time = time.Now()
+		streamTableIndex int
+		value            []byte
+	)
+
+	defer func() {
+		globals.stats.DoGetXAttrUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, false)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	streamTableIndex = inode.findStream(string(getXAttrIn.Name))
+	if 0 <= streamTableIndex {
+		value = append([]byte{}, inode.inodeHeadV1.StreamTable[streamTableIndex].Value...)
+	}
+
+	inode.unlock()
+
+	if 0 > streamTableIndex {
+		errno = syscall.ENODATA
+		return
+	}
+
+	if 0 == getXAttrIn.Size {
+		getXAttrOut = &fission.GetXAttrOut{
+			Size:    uint32(len(value)),
+			Padding: 0,
+			Data:    make([]byte, 0),
+		}
+		errno = 0
+		return
+	}
+
+	if uint32(len(value)) > getXAttrIn.Size {
+		errno = syscall.ERANGE
+		return
+	}
+
+	getXAttrOut = &fission.GetXAttrOut{
+		Size:    uint32(len(value)),
+		Padding: 0,
+		Data:    value,
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoListXAttr(inHeader *fission.InHeader, listXAttrIn *fission.ListXAttrIn) (listXAttrOut *fission.ListXAttrOut, errno syscall.Errno) {
+	var (
+		err              error
+		inode            *inodeStruct
+		names            [][]byte
+		startTime        time.Time = time.Now()
+		streamTableEntry ilayout.InodeStreamTableEntryStruct
+		totalSize        uint32
+	)
+
+	defer func() {
+		globals.stats.DoListXAttrUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, false)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	names = make([][]byte, 0, len(inode.inodeHeadV1.StreamTable))
+	totalSize = 0
+
+	for _, streamTableEntry = range inode.inodeHeadV1.StreamTable {
+		names = append(names, []byte(streamTableEntry.Name))
+		totalSize += uint32(len(streamTableEntry.Name) + 1)
+	}
+
+	inode.unlock()
+
+	if 0 == listXAttrIn.Size {
+		listXAttrOut = &fission.ListXAttrOut{
+			Size:    totalSize,
+			Padding: 0,
+			Name:    make([][]byte, 0),
+		}
+		errno = 0
+		return
+	}
+
+	if totalSize > listXAttrIn.Size {
+		errno = syscall.ERANGE
+		return
+	}
+
+	listXAttrOut = &fission.ListXAttrOut{
+		Size:    totalSize,
+		Padding: 0,
+		Name:    names,
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoRemoveXAttr(inHeader *fission.InHeader, removeXAttrIn *fission.RemoveXAttrIn) (errno syscall.Errno) {
+	var (
+		err              error
+		inode            *inodeStruct
+		startTime        time.Time = time.Now()
+		streamTableIndex int
+	)
+
+	defer func() {
+		globals.stats.DoRemoveXAttrUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, true)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	defer inode.unlock()
+
+	streamTableIndex = inode.findStream(string(removeXAttrIn.Name))
+	if 0 > streamTableIndex {
+		errno = syscall.ENODATA
+		return
+	}
+
+	inode.inodeHeadV1.StreamTable = append(inode.inodeHeadV1.StreamTable[:streamTableIndex], inode.inodeHeadV1.StreamTable[streamTableIndex+1:]...)
+
+	inode.markModified(false)
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoFlush(inHeader *fission.InHeader, flushIn *fission.FlushIn) (errno syscall.Errno) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoFlushUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	errno = doFSync(inHeader.NodeID, false)
+	return
+}
+
+func (dummy *globalsStruct) DoInit(inHeader *fission.InHeader, initIn *fission.InitIn) (initOut *fission.InitOut, errno syscall.Errno) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoInitUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	initOut = &fission.InitOut{
+		Major:                initIn.Major,
+		Minor:                initIn.Minor,
+		MaxReadAhead:         initIn.MaxReadAhead,
+		Flags:                initOutFlagsMask,
+		MaxBackground:        globals.config.FUSEMaxBackground,
+		CongestionThreshhold: globals.config.FUSECongestionThreshhold,
+		MaxWrite:             globals.config.FUSEMaxWrite,
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoOpenDir(inHeader *fission.InHeader, openDirIn *fission.OpenDirIn) (openDirOut *fission.OpenDirOut, errno syscall.Errno) {
+	var (
+		err       error
+		inode     *inodeStruct
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoOpenDirUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, false)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if ilayout.InodeTypeDir != inode.inodeHeadV1.InodeType {
+		inode.unlock()
+		errno = syscall.ENOTDIR
+		return
+	}
+
+	openDirOut = &fission.OpenDirOut{
+		FH:        allocateFH(inode.inodeNumber),
+		OpenFlags: 0,
+		Padding:   0,
+	}
+
+	inode.unlock()
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoReadDir(inHeader *fission.InHeader, readDirIn *fission.ReadDirIn) (readDirOut *fission.ReadDirOut, errno syscall.Errno) {
+	var (
+		curSize              uint32
+		dirEnt               fission.DirEnt
+		dirEntIndex          int
+		dirEntNameLenAligned uint32
+		dirEntSize           uint32
+		dirEntry             ilayout.DirectoryEntryValueV1Struct
+		dirEntryKeyRaw       interface{}
+		dirEntryName         string
+		dirEntryValueRaw     interface{}
+		err                  error
+		inode                *inodeStruct
+		ok                   bool
+		startTime            time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoReadDirUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	if !fetchFH(readDirIn.FH, inHeader.NodeID) {
+		errno = syscall.EBADF
+		return
+	}
+
+	inode, err = lockInodeWithLease(inHeader.NodeID, false)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+	defer inode.unlock()
+
+	readDirOut = &fission.ReadDirOut{
+		DirEnt: make([]fission.DirEnt, 0),
+	}
+
+	curSize = 0
+
+	// Note: DirEnt.Off is the index (plus one) of the next DirEnt to be returned
+
+	for dirEntIndex = int(readDirIn.Offset); ; dirEntIndex++ {
+		dirEntryKeyRaw, dirEntryValueRaw, ok, err = inode.payload.GetByIndex(dirEntIndex)
+		if nil != err {
+			readDirOut = nil
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+		if !ok {
+			break
+		}
+
+		dirEntryName, ok = dirEntryKeyRaw.(string)
+		if !ok {
+			logFatalf("dirEntryKeyRaw.(string) returned !ok")
+		}
+		dirEntry, ok = dirEntryValueRaw.(ilayout.DirectoryEntryValueV1Struct)
+		if !ok {
+			logFatalf("dirEntryValueRaw.(ilayout.DirectoryEntryValueV1Struct) returned !ok")
+		}
+
+		dirEntNameLenAligned = (uint32(len(dirEntryName)) + (fission.DirEntAlignment - 1)) & ^uint32(fission.DirEntAlignment-1)
+		dirEntSize = fission.DirEntFixedPortionSize + dirEntNameLenAligned
+
+		if (curSize + dirEntSize) > readDirIn.Size {
+			break
+		}
+
+		dirEnt = fission.DirEnt{
+			Ino:     dirEntry.InodeNumber,
+			Off:     uint64(dirEntIndex) + 1,
+			NameLen: uint32(len(dirEntryName)), // unnecessary
+			Name:    []byte(dirEntryName),
+		}
+
+		switch dirEntry.InodeType {
+		case ilayout.InodeTypeDir:
+			dirEnt.Type = syscall.S_IFDIR >> 12
+		case ilayout.InodeTypeFile:
+			dirEnt.Type = syscall.S_IFREG >> 12
+		case ilayout.InodeTypeSymLink:
+			dirEnt.Type = syscall.S_IFLNK >> 12
+		default:
+			logFatalf("DirEntry \"%s\" has unknown InodeType %v", dirEntryName, dirEntry.InodeType)
+		}
+
+		readDirOut.DirEnt = append(readDirOut.DirEnt, dirEnt)
+
+		curSize += dirEntSize
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoReleaseDir(inHeader *fission.InHeader, releaseDirIn *fission.ReleaseDirIn) (errno syscall.Errno) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoReleaseDirUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	if !releaseFH(releaseDirIn.FH, inHeader.NodeID) {
+		errno = syscall.EBADF
+		return
+	}
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoFSyncDir(inHeader *fission.InHeader, fSyncDirIn *fission.FSyncDirIn) (errno syscall.Errno) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoFSyncDirUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	errno = doFSync(inHeader.NodeID, true)
+	return
+}
+
+func (dummy *globalsStruct) DoGetLK(inHeader *fission.InHeader, getLKIn *fission.GetLKIn) (getLKOut *fission.GetLKOut, errno syscall.Errno) {
+	errno = syscall.ENOSYS
+	return
+}
+
+func (dummy *globalsStruct) DoSetLK(inHeader *fission.InHeader, setLKIn *fission.SetLKIn) (errno syscall.Errno) {
+	errno = syscall.ENOSYS
+	return
+}
+
+func (dummy *globalsStruct) DoSetLKW(inHeader *fission.InHeader, setLKWIn *fission.SetLKWIn) (errno syscall.Errno) {
+	errno = syscall.ENOSYS
+	return
+}
+
+func (dummy *globalsStruct) DoAccess(inHeader *fission.InHeader, accessIn *fission.AccessIn) (errno syscall.Errno) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoAccessUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	// Note: Permission checking is left to the kernel
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoCreate(inHeader *fission.InHeader, createIn *fission.CreateIn) (createOut *fission.CreateOut, errno syscall.Errno) {
+	var (
+		err         error
+		inode       *inodeStruct
+		namespaceOp *namespaceOpStruct
+		startTime   time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoCreateUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	namespaceOp, inode, errno = doCreate(inHeader, createIn.Name, ilayout.InodeTypeFile, createIn.Mode, "")
+	if 0 != errno {
+		return
+	}
+	defer namespaceOp.end()
+
+	err = inode.adjustOpenCount(1)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	inode.openCount++
+
+	createOut = &fission.CreateOut{
+		FH:        allocateFH(inode.inodeNumber),
+		OpenFlags: 0,
+		Padding:   0,
+	}
+
+	inode.fillEntryOut(&createOut.EntryOut)
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoInterrupt(inHeader *fission.InHeader, interruptIn *fission.InterruptIn) {
+}
+
+func (dummy *globalsStruct) DoBMap(inHeader *fission.InHeader, bMapIn *fission.BMapIn) (bMapOut *fission.BMapOut, errno syscall.Errno) {
+	errno = syscall.ENOSYS
+	return
+}
+
+func (dummy *globalsStruct) DoDestroy(inHeader *fission.InHeader) (errno syscall.Errno) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DoDestroyUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	errno = 0
+	return
+}
+
+func (dummy *globalsStruct) DoPoll(inHeader *fission.InHeader, pollIn *fission.PollIn) (pollOut *fission.PollOut, errno syscall.Errno) {
+	errno = syscall.ENOSYS
+	return
+}
+
+func (dummy *globalsStruct) DoBatchForget(inHeader *fission.InHeader, batchForgetIn *fission.BatchForgetIn) {
+}
+
+func (dummy *globalsStruct) DoFAllocate(inHeader *fission.InHeader, fAllocateIn *fission.FAllocateIn) (errno syscall.Errno) {
+	errno = syscall.ENOSYS
+	return
+}
+
+func (dummy *globalsStruct) DoReadDirPlus(inHeader *fission.InHeader, readDirPlusIn *fission.ReadDirPlusIn) (readDirPlusOut *fission.ReadDirPlusOut, errno syscall.Errno) {
+	errno = syscall.ENOSYS
+	return
+}
+
+func (dummy *globalsStruct) DoRename2(inHeader *fission.InHeader, rename2In *fission.Rename2In) (errno syscall.Errno) {
+	if 0 != rename2In.Flags {
+		errno = syscall.EINVAL
+		return
+	}
+
+	errno = doRename(inHeader, rename2In.OldName, rename2In.NewDir, rename2In.NewName)
+	return
+}
+
+func (dummy *globalsStruct) DoLSeek(inHeader *fission.InHeader, lSeekIn *fission.LSeekIn) (lSeekOut *fission.LSeekOut, errno syscall.Errno) {
+	errno = syscall.ENOSYS
+	return
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package iclientpkg
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+
+	"github.com/NVIDIA/fission"
+
+	"github.com/NVIDIA/proxyfs/ilayout"
+)
+
+func testDoLookup(t *testing.T, dirInodeNumber uint64, name string) (lookupOut *fission.LookupOut, errno syscall.Errno) {
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: dirInodeNumber}, &fission.LookupIn{Name: []byte(name)})
+	return
+}
+
+func testDoReadDir(t *testing.T, dirInodeNumber uint64) (names []string) {
+	var (
+		dirEnt     fission.DirEnt
+		errno      syscall.Errno
+		openDirOut *fission.OpenDirOut
+		readDirOut *fission.ReadDirOut
+	)
+
+	openDirOut, errno = globals.DoOpenDir(&fission.InHeader{NodeID: dirInodeNumber}, &fission.OpenDirIn{})
+	if 0 != errno {
+		t.Fatalf("DoOpenDir() returned errno: %v", errno)
+	}
+
+	readDirOut, errno = globals.DoReadDir(&fission.InHeader{NodeID: dirInodeNumber}, &fission.ReadDirIn{FH: openDirOut.FH, Offset: 0, Size: 4096})
+	if 0 != errno {
+		t.Fatalf("DoReadDir() returned errno: %v", errno)
+	}
+
+	names = make([]string, 0, len(readDirOut.DirEnt))
+
+	for _, dirEnt = range readDirOut.DirEnt {
+		names = append(names, string(dirEnt.Name))
+	}
+
+	errno = globals.DoReleaseDir(&fission.InHeader{NodeID: dirInodeNumber}, &fission.ReleaseDirIn{FH: openDirOut.FH})
+	if 0 != errno {
+		t.Fatalf("DoReleaseDir() returned errno: %v", errno)
+	}
+
+	return
+}
+
+func TestFission(t *testing.T) {
+	var (
+		createOut      *fission.CreateOut
+		dirInodeNumber uint64
+		errno          syscall.Errno
+		expectedData   []byte
+		fileInode      *inodeStruct
+		getXAttrOut    *fission.GetXAttrOut
+		linkOut        *fission.LinkOut
+		listXAttrOut   *fission.ListXAttrOut
+		lookupOut      *fission.LookupOut
+		mkDirOut       *fission.MkDirOut
+		names          []string
+		ok             bool
+		openOut        *fission.OpenOut
+		readLinkOut    *fission.ReadLinkOut
+		readOut        *fission.ReadOut
+		setAttrOut     *fission.SetAttrOut
+		symLinkOut     *fission.SymLinkOut
+		writeOut       *fission.WriteOut
+	)
+
+	testSetup(t)
+
+	rootInHeader := &fission.InHeader{NodeID: ilayout.RootDirInodeNumber, UID: 1, GID: 2}
+
+	// Create /dir and /dir/file
+
+	mkDirOut, errno = globals.DoMkDir(rootInHeader, &fission.MkDirIn{Mode: syscall.S_IFDIR | 0o755, Name: []byte("dir")})
+	if 0 != errno {
+		t.Fatalf("DoMkDir(,\"dir\") returned errno: %v", errno)
+	}
+	if (syscall.S_IFDIR|0o755) != mkDirOut.Attr.Mode || 2 != mkDirOut.Attr.NLink || 1 != mkDirOut.Attr.UID || 2 != mkDirOut.Attr.GID {
+		t.Fatalf("DoMkDir(,\"dir\") returned unexpected Attr: %#v", mkDirOut.Attr)
+	}
+
+	dirInodeNumber = mkDirOut.NodeID
+
+	_, errno = globals.DoMkDir(rootInHeader, &fission.MkDirIn{Mode: syscall.S_IFDIR | 0o755, Name: []byte("dir")})
+	if syscall.EEXIST != errno {
+		t.Fatalf("DoMkDir(,\"dir\") of existing \"dir\" should have returned EEXIST, got errno: %v", errno)
+	}
+
+	createOut, errno = globals.DoCreate(&fission.InHeader{NodeID: dirInodeNumber}, &fission.CreateIn{Mode: syscall.S_IFREG | 0o644, Name: []byte("file")})
+	if 0 != errno {
+		t.Fatalf("DoCreate(,\"file\") returned errno: %v", errno)
+	}
+
+	// Write "Hello" at 0 and "World" at 10 leaving a hole in between
+
+	writeOut, errno = globals.DoWrite(&fission.InHeader{NodeID: createOut.NodeID}, &fission.WriteIn{FH: createOut.FH, Offset: 0, Data: []byte("Hello")})
+	if (0 != errno) || (5 != writeOut.Size) {
+		t.Fatalf("DoWrite(,\"Hello\") returned errno: %v", errno)
+	}
+	_, errno = globals.DoWrite(&fission.InHeader{NodeID: createOut.NodeID}, &fission.WriteIn{FH: createOut.FH, Offset: 10, Data: []byte("World")})
+	if 0 != errno {
+		t.Fatalf("DoWrite(,\"World\") returned errno: %v", errno)
+	}
+
+	expectedData = []byte("Hello\x00\x00\x00\x00\x00World")
+
+	readOut, errno = globals.DoRead(&fission.InHeader{NodeID: createOut.NodeID}, &fission.ReadIn{FH: createOut.FH, Offset: 0, Size: 100})
+	if (0 != errno) || !bytes.Equal(expectedData, readOut.Data) {
+		t.Fatalf("DoRead() [case 1] returned unexpected errno: %v or Data: %v", errno, readOut)
+	}
+
+	errno = globals.DoRelease(&fission.InHeader{NodeID: createOut.NodeID}, &fission.ReleaseIn{FH: createOut.FH})
+	if 0 != errno {
+		t.Fatalf("DoRelease() returned errno: %v", errno)
+	}
+
+	// Drop all cached state such that the following must be fetched from imgrpkg & Swift
+
+	err := releaseAllLeases()
+	if nil != err {
+		t.Fatalf("releaseAllLeases() failed: %v", err)
+	}
+
+	lookupOut, errno = testDoLookup(t, ilayout.RootDirInodeNumber, "dir")
+	if (0 != errno) || (dirInodeNumber != lookupOut.NodeID) {
+		t.Fatalf("DoLookup(,\"dir\") returned unexpected errno: %v or LookupOut: %#v", errno, lookupOut)
+	}
+	lookupOut, errno = testDoLookup(t, dirInodeNumber, "file")
+	if (0 != errno) || (createOut.NodeID != lookupOut.NodeID) || (uint64(len(expectedData)) != lookupOut.Attr.Size) {
+		t.Fatalf("DoLookup(,\"file\") returned unexpected errno: %v or LookupOut: %#v", errno, lookupOut)
+	}
+
+	openOut, errno = globals.DoOpen(&fission.InHeader{NodeID: createOut.NodeID}, &fission.OpenIn{Flags: syscall.O_RDWR})
+	if 0 != errno {
+		t.Fatalf("DoOpen() returned errno: %v", errno)
+	}
+
+	readOut, errno = globals.DoRead(&fission.InHeader{NodeID: createOut.NodeID}, &fission.ReadIn{FH: openOut.FH, Offset: 3, Size: 9})
+	if (0 != errno) || !bytes.Equal(expectedData[3:12], readOut.Data) {
+		t.Fatalf("DoRead() [case 2] returned unexpected errno: %v or Data: %v", errno, readOut)
+	}
+
+	// Truncate to "Hello" and overwrite "ll" with "LL"
+
+	setAttrOut, errno = globals.DoSetAttr(&fission.InHeader{NodeID: createOut.NodeID}, &fission.SetAttrIn{Valid: fission.SetAttrInValidSize, Size: 5})
+	if (0 != errno) || (5 != setAttrOut.Attr.Size) {
+		t.Fatalf("DoSetAttr() returned unexpected errno: %v or SetAttrOut: %#v", errno, setAttrOut)
+	}
+	_, errno = globals.DoWrite(&fission.InHeader{NodeID: createOut.NodeID}, &fission.WriteIn{FH: openOut.FH, Offset: 2, Data: []byte("LL")})
+	if 0 != errno {
+		t.Fatalf("DoWrite(,\"LL\") returned errno: %v", errno)
+	}
+
+	errno = globals.DoFSync(&fission.InHeader{NodeID: createOut.NodeID}, &fission.FSyncIn{FH: openOut.FH})
+	if 0 != errno {
+		t.Fatalf("DoFSync() returned errno: %v", errno)
+	}
+
+	fileInode = lockInode(createOut.NodeID)
+	fileInode.discardCachedState()
+	fileInode.unlock()
+
+	readOut, errno = globals.DoRead(&fission.InHeader{NodeID: createOut.NodeID}, &fission.ReadIn{FH: openOut.FH, Offset: 0, Size: 100})
+	if (0 != errno) || ("HeLLo" != string(readOut.Data)) {
+		t.Fatalf("DoRead() [case 3] returned unexpected errno: %v or Data: %v", errno, readOut)
+	}
+
+	errno = globals.DoRelease(&fission.InHeader{NodeID: createOut.NodeID}, &fission.ReleaseIn{FH: openOut.FH})
+	if 0 != errno {
+		t.Fatalf("DoRelease() returned errno: %v", errno)
+	}
+
+	// Exercise XAttrs
+
+	errno = globals.DoSetXAttr(&fission.InHeader{NodeID: createOut.NodeID}, &fission.SetXAttrIn{Name: []byte("user.a"), Data: []byte("A")})
+	if 0 != errno {
+		t.Fatalf("DoSetXAttr() returned errno: %v", errno)
+	}
+	getXAttrOut, errno = globals.DoGetXAttr(&fission.InHeader{NodeID: createOut.NodeID}, &fission.GetXAttrIn{Name: []byte("user.a"), Size: 100})
+	if (0 != errno) || ("A" != string(getXAttrOut.Data)) {
+		t.Fatalf("DoGetXAttr() returned unexpected errno: %v or GetXAttrOut: %#v", errno, getXAttrOut)
+	}
+	listXAttrOut, errno = globals.DoListXAttr(&fission.InHeader{NodeID: createOut.NodeID}, &fission.ListXAttrIn{Size: 0})
+	if (0 != errno) || (uint32(len("user.a")+1) != listXAttrOut.Size) {
+		t.Fatalf("DoListXAttr() returned unexpected errno: %v or ListXAttrOut: %#v", errno, listXAttrOut)
+	}
+	errno = globals.DoRemoveXAttr(&fission.InHeader{NodeID: createOut.NodeID}, &fission.RemoveXAttrIn{Name: []byte("user.a")})
+	if 0 != errno {
+		t.Fatalf("DoRemoveXAttr() returned errno: %v", errno)
+	}
+	_, errno = globals.DoGetXAttr(&fission.InHeader{NodeID: createOut.NodeID}, &fission.GetXAttrIn{Name: []byte("user.a"), Size: 100})
+	if syscall.ENODATA != errno {
+		t.Fatalf("DoGetXAttr() of removed XAttr should have returned ENODATA, got errno: %v", errno)
+	}
+
+	// Create /dir/link -> file, /dir/hardlink, and rename /dir/hardlink to /hardlink
+
+	symLinkOut, errno = globals.DoSymLink(&fission.InHeader{NodeID: dirInodeNumber}, &fission.SymLinkIn{Name: []byte("link"), Data: []byte("file")})
+	if 0 != errno {
+		t.Fatalf("DoSymLink() returned errno: %v", errno)
+	}
+	readLinkOut, errno = globals.DoReadLink(&fission.InHeader{NodeID: symLinkOut.NodeID})
+	if (0 != errno) || ("file" != string(readLinkOut.Data)) {
+		t.Fatalf("DoReadLink() returned unexpected errno: %v or ReadLinkOut: %#v", errno, readLinkOut)
+	}
+
+	linkOut, errno = globals.DoLink(&fission.InHeader{NodeID: dirInodeNumber}, &fission.LinkIn{OldNodeID: createOut.NodeID, Name: []byte("hardlink")})
+	if (0 != errno) || (2 != linkOut.Attr.NLink) {
+		t.Fatalf("DoLink() returned unexpected errno: %v or LinkOut: %#v", errno, linkOut)
+	}
+
+	errno = globals.DoRename(&fission.InHeader{NodeID: dirInodeNumber}, &fission.RenameIn{NewDir: ilayout.RootDirInodeNumber, OldName: []byte("hardlink"), NewName: []byte("hardlink")})
+	if 0 != errno {
+		t.Fatalf("DoRename() returned errno: %v", errno)
+	}
+
+	names = testDoReadDir(t, dirInodeNumber)
+	if (4 != len(names)) || ("." != names[0]) || (".." != names[1]) || ("file" != names[2]) || ("link" != names[3]) {
+		t.Fatalf("testDoReadDir(,dirInodeNumber) returned unexpected names: %v", names)
+	}
+	names = testDoReadDir(t, ilayout.RootDirInodeNumber)
+	if (4 != len(names)) || ("." != names[0]) || (".." != names[1]) || ("dir" != names[2]) || ("hardlink" != names[3]) {
+		t.Fatalf("testDoReadDir(,ilayout.RootDirInodeNumber) returned unexpected names: %v", names)
+	}
+
+	// Attempt to move /dir beneath itself
+
+	errno = globals.DoRename(rootInHeader, &fission.RenameIn{NewDir: dirInodeNumber, OldName: []byte("dir"), NewName: []byte("subdir")})
+	if syscall.EINVAL != errno {
+		t.Fatalf("DoRename() of \"dir\" beneath itself should have returned EINVAL, got errno: %v", errno)
+	}
+
+	// Remove everything
+
+	errno = globals.DoRmDir(rootInHeader, &fission.RmDirIn{Name: []byte("dir")})
+	if syscall.ENOTEMPTY != errno {
+		t.Fatalf("DoRmDir() of non-empty \"dir\" should have returned ENOTEMPTY, got errno: %v", errno)
+	}
+
+	errno = globals.DoUnlink(&fission.InHeader{NodeID: dirInodeNumber}, &fission.UnlinkIn{Name: []byte("file")})
+	if 0 != errno {
+		t.Fatalf("DoUnlink(,\"file\") returned errno: %v", errno)
+	}
+	errno = globals.DoUnlink(&fission.InHeader{NodeID: dirInodeNumber}, &fission.UnlinkIn{Name: []byte("link")})
+	if 0 != errno {
+		t.Fatalf("DoUnlink(,\"link\") returned errno: %v", errno)
+	}
+	errno = globals.DoUnlink(rootInHeader, &fission.UnlinkIn{Name: []byte("hardlink")})
+	if 0 != errno {
+		t.Fatalf("DoUnlink(,\"hardlink\") returned errno: %v", errno)
+	}
+	errno = globals.DoRmDir(rootInHeader, &fission.RmDirIn{Name: []byte("dir")})
+	if 0 != errno {
+		t.Fatalf("DoRmDir(,\"dir\") returned errno: %v", errno)
+	}
+
+	_, errno = testDoLookup(t, ilayout.RootDirInodeNumber, "dir")
+	if syscall.ENOENT != errno {
+		t.Fatalf("DoLookup(,\"dir\") after DoRmDir() should have returned ENOENT, got errno: %v", errno)
+	}
+
+	globals.Lock()
+	_, ok = globals.inodeMap[createOut.NodeID]
+	globals.Unlock()
+	if ok {
+		t.Fatalf("globals.inodeMap should no longer contain deleted file inode")
+	}
+
+	testTeardown(t)
+}
@@ -0,0 +1,455 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package iclientpkg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/fission"
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/bucketstats"
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/iauth"
+	"github.com/NVIDIA/proxyfs/ilayout"
+	"github.com/NVIDIA/proxyfs/retryrpc"
+	"github.com/NVIDIA/proxyfs/utils"
+)
+
+type configStruct struct {
+	VolumeName               string
+	MountPointDirPath        string
+	FUSEAllowOther           bool
+	FUSEMaxBackground        uint16
+	FUSECongestionThreshhold uint16
+	FUSEMaxWrite             uint32
+	FUSEEntryValidDuration   time.Duration
+	FUSEAttrValidDuration    time.Duration
+	AuthPlugInPath           string
+	AuthPlugInEnvName        string
+	AuthPlugInEnvValue       string
+	SwiftTimeout             time.Duration
+	SwiftRetryLimit          uint32
+	SwiftRetryDelay          time.Duration
+	SwiftRetryDelayVariance  uint8
+	SwiftRetryExpBackoff     float64
+	SwiftConnectionPoolSize  uint32
+	RetryRPCPublicIPAddr     string
+	RetryRPCPort             uint16
+	RetryRPCDeadlineIO       time.Duration
+	RetryRPCKeepAlivePeriod  time.Duration
+	RetryRPCCACertFilePath   string // Defaults to /dev/null
+
+	InodePayloadEvictLowLimit        uint64
+	InodePayloadEvictHighLimit       uint64
+	DirInodeMaxKeysPerBPlusTreePage  uint64
+	FileInodeMaxKeysPerBPlusTreePage uint64
+	FileFlushTriggerSize             uint64
+
+	LogFilePath  string // Unless starting with '/', relative to $CWD; == "" means disabled
+	LogToConsole bool
+	TraceEnabled bool
+}
+
+type retryDelayElementStruct struct {
+	nominal  time.Duration
+	variance time.Duration
+}
+
+type inodeLeaseStateType uint32
+
+const (
+	inodeLeaseStateNone inodeLeaseStateType = iota
+	inodeLeaseStateSharedGranted
+	inodeLeaseStateExclusiveGranted
+)
+
+type layoutMapEntryStruct struct {
+	objectSize      uint64 // matches ilayout.InodeHeadLayoutEntryV1Struct.ObjectSize
+	bytesReferenced uint64 // matches ilayout.InodeHeadLayoutEntryV1Struct.BytesReferenced
+}
+
+type inodeStruct struct {
+	sync.Mutex                                       // held for the duration of any operation upon the inode
+	inodeNumber           uint64                     //
+	leaseState            inodeLeaseStateType        //
+	inodeHeadV1           *ilayout.InodeHeadV1Struct // == nil if not yet fetched (or created) while holding a Lease
+	inodeHeadObjectNumber uint64                     // == 0 if inodeHeadV1 has never been written
+	inodeHeadLength       uint64                     // == 0 if inodeHeadV1 has never been written
+	payload               sortedmap.BPlusTree        // == nil if not yet fetched (or created); for Dir: key == string; value == ilayout.DirectoryEntryValueV1Struct
+	//                                                       for File: key == uint64 (FileOffset); value == ilayout.ExtentMapEntryValueV1Struct
+	layoutMap              map[uint64]*layoutMapEntryStruct // == nil if inodeHeadV1 == nil; key == objectNumber (matching ilayout.InodeHeadLayoutEntryV1Struct.ObjectNumber)
+	dirty                  bool                             // if true, inodeHeadV1 and/or payload modified since last flush()
+	putObjectNumber        uint64                           // if putObjectBuffer != nil, the objectNumber to which it will be PUT
+	putObjectBuffer        []byte                           // if != nil, accumulates data and B+Tree pages yet to be PUT
+	dereferencedObjectList []uint64                         // objectNumbers no longer referenced once the next flush() completes
+	objectCountAdjustment  int64                            // pending adjustment to SuperBlock.InodeObjectCount
+	objectSizeAdjustment   int64                            // pending adjustment to SuperBlock.InodeObjectSize
+	bytesRefAdjustment     int64                            // pending adjustment to SuperBlock.InodeBytesReferenced
+	openCount              uint64                           // number of FH's referencing this inode
+	markedForDelete        bool                             // if true, DeleteInodeTableEntry issued once openCount drops to zero
+}
+
+type statsStruct struct {
+	AdjustInodeTableEntryOpenCountUsecs bucketstats.BucketLog2Round // (*imgrpkg.RetryRPCServerStruct).AdjustInodeTableEntryOpenCount()
+	DeleteInodeTableEntryUsecs          bucketstats.BucketLog2Round // (*imgrpkg.RetryRPCServerStruct).DeleteInodeTableEntry()
+	FetchNonceRangeUsecs                bucketstats.BucketLog2Round // (*imgrpkg.RetryRPCServerStruct).FetchNonceRange()
+	FlushUsecs                          bucketstats.BucketLog2Round // (*imgrpkg.RetryRPCServerStruct).Flush()
+	GetInodeTableEntryUsecs             bucketstats.BucketLog2Round // (*imgrpkg.RetryRPCServerStruct).GetInodeTableEntry()
+	LeaseUsecs                          bucketstats.BucketLog2Round // (*imgrpkg.RetryRPCServerStruct).Lease()
+	MountUsecs                          bucketstats.BucketLog2Round // (*imgrpkg.RetryRPCServerStruct).Mount()
+	PutInodeTableEntriesUsecs           bucketstats.BucketLog2Round // (*imgrpkg.RetryRPCServerStruct).PutInodeTableEntries()
+	RenewMountUsecs                     bucketstats.BucketLog2Round // (*imgrpkg.RetryRPCServerStruct).RenewMount()
+	UnmountUsecs                        bucketstats.BucketLog2Round // (*imgrpkg.RetryRPCServerStruct).Unmount()
+
+	UnmountInterrupts     bucketstats.Total
+	DemoteLeaseInterrupts bucketstats.Total
+	RevokeLeaseInterrupts bucketstats.Total
+
+	InodeFlushUsecs bucketstats.BucketLog2Round
+
+	SwiftObjectGetRangeUsecs bucketstats.BucketLog2Round
+	SwiftObjectGetTailUsecs  bucketstats.BucketLog2Round
+	SwiftObjectPutUsecs      bucketstats.BucketLog2Round
+
+	DoLookupUsecs      bucketstats.BucketLog2Round // (*globalsStruct)DoLookup()
+	DoForgetUsecs      bucketstats.BucketLog2Round // (*globalsStruct)DoForget()
+	DoGetAttrUsecs     bucketstats.BucketLog2Round // (*globalsStruct)DoGetAttr()
+	DoSetAttrUsecs     bucketstats.BucketLog2Round // (*globalsStruct)DoSetAttr()
+	DoReadLinkUsecs    bucketstats.BucketLog2Round // (*globalsStruct)DoReadLink()
+	DoSymLinkUsecs     bucketstats.BucketLog2Round // (*globalsStruct)DoSymLink()
+	DoMkNodUsecs       bucketstats.BucketLog2Round // (*globalsStruct)DoMkNod()
+	DoMkDirUsecs       bucketstats.BucketLog2Round // (*globalsStruct)DoMkDir()
+	DoUnlinkUsecs      bucketstats.BucketLog2Round // (*globalsStruct)DoUnlink()
+	DoRmDirUsecs       bucketstats.BucketLog2Round // (*globalsStruct)DoRmDir()
+	DoRenameUsecs      bucketstats.BucketLog2Round // (*globalsStruct)DoRename()
+	DoLinkUsecs        bucketstats.BucketLog2Round // (*globalsStruct)DoLink()
+	DoOpenUsecs        bucketstats.BucketLog2Round // (*globalsStruct)DoOpen()
+	DoReadUsecs        bucketstats.BucketLog2Round // (*globalsStruct)DoRead()
+	DoWriteUsecs       bucketstats.BucketLog2Round // (*globalsStruct)DoWrite()
+	DoStatFSUsecs      bucketstats.BucketLog2Round // (*globalsStruct)DoStatFS()
+	DoReleaseUsecs     bucketstats.BucketLog2Round // (*globalsStruct)DoRelease()
+	DoFSyncUsecs       bucketstats.BucketLog2Round // (*globalsStruct)DoFSync()
+	DoSetXAttrUsecs    bucketstats.BucketLog2Round // (*globalsStruct)DoSetXAttr()
+	DoGetXAttrUsecs    bucketstats.BucketLog2Round // (*globalsStruct)DoGetXAttr()
+	DoListXAttrUsecs   bucketstats.BucketLog2Round // (*globalsStruct)DoListXAttr()
+	DoRemoveXAttrUsecs bucketstats.BucketLog2Round // (*globalsStruct)DoRemoveXAttr()
+	DoFlushUsecs       bucketstats.BucketLog2Round // (*globalsStruct)DoFlush()
+	DoInitUsecs        bucketstats.BucketLog2Round // (*globalsStruct)DoInit()
+	DoOpenDirUsecs     bucketstats.BucketLog2Round // (*globalsStruct)DoOpenDir()
+	DoReadDirUsecs     bucketstats.BucketLog2Round // (*globalsStruct)DoReadDir()
+	DoReleaseDirUsecs  bucketstats.BucketLog2Round // (*globalsStruct)DoReleaseDir()
+	DoFSyncDirUsecs    bucketstats.BucketLog2Round // (*globalsStruct)DoFSyncDir()
+	DoAccessUsecs      bucketstats.BucketLog2Round // (*globalsStruct)DoAccess()
+	DoCreateUsecs      bucketstats.BucketLog2Round // (*globalsStruct)DoCreate()
+	DoDestroyUsecs     bucketstats.BucketLog2Round // (*globalsStruct)DoDestroy()
+}
+
+type globalsStruct struct {
+	sync.Mutex                                  // protects all but config, stats, & the contents of each inodeStruct
+	config            configStruct              //
+	logFile           *os.File                  // == nil if config.LogFilePath == ""
+	retryDelay        []retryDelayElementStruct //
+	authPlugInInput   string                    // passed to iauth.PerformAuth() (via performAuth)
+	swiftAuthToken    string                    // protected by swiftAuthLock
+	swiftStorageURL   string                    // protected by swiftAuthLock
+	swiftAuthLock     sync.RWMutex              //
+	httpClient        *http.Client              //
+	retryRPCCACertPEM []byte                    // == nil if RetryRPCCACertFilePath == ""
+	retryRPCClient    *retryrpc.Client          //
+	mountID           string                    //
+	nonceLock         sync.Mutex                // protects nextNonce & numNoncesReserved
+	nextNonce         uint64                    //
+	numNoncesReserved uint64                    //
+	namespaceLock     sync.Mutex                // serializes operations that lock more than one inode
+	inodeMap          map[uint64]*inodeStruct   // key == inodeStruct.inodeNumber
+	inodePayloadCache sortedmap.BPlusTreeCache  //
+	fhToInodeNumber   map[uint64]uint64         // key == FH; value == inodeNumber
+	lastFH            uint64                    // valid FH's start at 1
+	interruptWG       sync.WaitGroup            // tracks RPCInterrupt handling goroutines
+	fissionErrChan    chan error                //
+	fissionVolume     fission.Volume            //
+	stats             *statsStruct              //
+}
+
+var globals globalsStruct
+
+// performAuth is the func used to obtain the AuthToken and StorageURL for the volume.
+// It is a variable to enable testing without an actual iauth plug-in.
+//
+var performAuth = iauth.PerformAuth
+
+func initializeGlobals(confMap conf.ConfMap, fissionErrChan chan error) (err error) {
+	var (
+		configJSONified string
+		nextRetryDelay  time.Duration
+		retryIndex      uint32
+	)
+
+	// Default logging related globals
+
+	globals.config.LogFilePath = ""
+	globals.config.LogToConsole = true
+	globals.logFile = nil
+
+	// Process resultant confMap
+
+	globals.config.VolumeName, err = confMap.FetchOptionValueString("ICLIENT", "VolumeName")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.MountPointDirPath, err = confMap.FetchOptionValueString("ICLIENT", "MountPointDirPath")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.FUSEAllowOther, err = confMap.FetchOptionValueBool("ICLIENT", "FUSEAllowOther")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.FUSEMaxBackground, err = confMap.FetchOptionValueUint16("ICLIENT", "FUSEMaxBackground")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.FUSECongestionThreshhold, err = confMap.FetchOptionValueUint16("ICLIENT", "FUSECongestionThreshhold")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.FUSEMaxWrite, err = confMap.FetchOptionValueUint32("ICLIENT", "FUSEMaxWrite")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.FUSEEntryValidDuration, err = confMap.FetchOptionValueDuration("ICLIENT", "FUSEEntryValidDuration")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.FUSEAttrValidDuration, err = confMap.FetchOptionValueDuration("ICLIENT", "FUSEAttrValidDuration")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.AuthPlugInPath, err = confMap.FetchOptionValueString("ICLIENT", "AuthPlugInPath")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.AuthPlugInEnvName, err = confMap.FetchOptionValueString("ICLIENT", "AuthPlugInEnvName")
+	if nil != err {
+		globals.config.AuthPlugInEnvName = ""
+	}
+	if "" == globals.config.AuthPlugInEnvName {
+		globals.config.AuthPlugInEnvValue, err = confMap.FetchOptionValueString("ICLIENT", "AuthPlugInEnvValue")
+		if nil != err {
+			logFatal(err)
+		}
+		globals.authPlugInInput = globals.config.AuthPlugInEnvValue
+	} else {
+		globals.config.AuthPlugInEnvValue = ""
+		globals.authPlugInInput = os.Getenv(globals.config.AuthPlugInEnvName)
+	}
+	globals.config.SwiftTimeout, err = confMap.FetchOptionValueDuration("ICLIENT", "SwiftTimeout")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.SwiftRetryLimit, err = confMap.FetchOptionValueUint32("ICLIENT", "SwiftRetryLimit")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.SwiftRetryDelay, err = confMap.FetchOptionValueDuration("ICLIENT", "SwiftRetryDelay")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.SwiftRetryDelayVariance, err = confMap.FetchOptionValueUint8("ICLIENT", "SwiftRetryDelayVariance")
+	if nil != err {
+		logFatal(err)
+	}
+	if 0 == globals.config.SwiftRetryDelayVariance {
+		logFatalf("[ICLIENT]SwiftRetryDelayVariance must be > 0")
+	}
+	if 100 < globals.config.SwiftRetryDelayVariance {
+		logFatalf("[ICLIENT]SwiftRetryDelayVariance (%v) must be <= 100", globals.config.SwiftRetryDelayVariance)
+	}
+	globals.config.SwiftRetryExpBackoff, err = confMap.FetchOptionValueFloat64("ICLIENT", "SwiftRetryExpBackoff")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.SwiftConnectionPoolSize, err = confMap.FetchOptionValueUint32("ICLIENT", "SwiftConnectionPoolSize")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.RetryRPCPublicIPAddr, err = confMap.FetchOptionValueString("ICLIENT", "RetryRPCPublicIPAddr")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.RetryRPCPort, err = confMap.FetchOptionValueUint16("ICLIENT", "RetryRPCPort")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.RetryRPCDeadlineIO, err = confMap.FetchOptionValueDuration("ICLIENT", "RetryRPCDeadlineIO")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.RetryRPCKeepAlivePeriod, err = confMap.FetchOptionValueDuration("ICLIENT", "RetryRPCKeepAlivePeriod")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.RetryRPCCACertFilePath, err = confMap.FetchOptionValueString("ICLIENT", "RetryRPCCACertFilePath")
+	if nil != err {
+		globals.config.RetryRPCCACertFilePath = ""
+	}
+
+	globals.config.InodePayloadEvictLowLimit, err = confMap.FetchOptionValueUint64("ICLIENT", "InodePayloadEvictLowLimit")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.InodePayloadEvictHighLimit, err = confMap.FetchOptionValueUint64("ICLIENT", "InodePayloadEvictHighLimit")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.DirInodeMaxKeysPerBPlusTreePage, err = confMap.FetchOptionValueUint64("ICLIENT", "DirInodeMaxKeysPerBPlusTreePage")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.FileInodeMaxKeysPerBPlusTreePage, err = confMap.FetchOptionValueUint64("ICLIENT", "FileInodeMaxKeysPerBPlusTreePage")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.FileFlushTriggerSize, err = confMap.FetchOptionValueUint64("ICLIENT", "FileFlushTriggerSize")
+	if nil != err {
+		logFatal(err)
+	}
+
+	globals.config.LogFilePath, err = confMap.FetchOptionValueString("ICLIENT", "LogFilePath")
+	if nil != err {
+		err = confMap.VerifyOptionValueIsEmpty("ICLIENT", "LogFilePath")
+		if nil == err {
+			globals.config.LogFilePath = ""
+		} else {
+			logFatalf("[ICLIENT]LogFilePath must either be a valid string or empty]")
+		}
+	}
+	globals.config.LogToConsole, err = confMap.FetchOptionValueBool("ICLIENT", "LogToConsole")
+	if nil != err {
+		logFatal(err)
+	}
+	globals.config.TraceEnabled, err = confMap.FetchOptionValueBool("ICLIENT", "TraceEnabled")
+	if nil != err {
+		logFatal(err)
+	}
+
+	configJSONified = utils.JSONify(globals.config, true)
+
+	logInfof("globals.config:\n%s", configJSONified)
+
+	globals.retryDelay = make([]retryDelayElementStruct, globals.config.SwiftRetryLimit)
+
+	nextRetryDelay = globals.config.SwiftRetryDelay
+
+	for retryIndex = 0; retryIndex < globals.config.SwiftRetryLimit; retryIndex++ {
+		globals.retryDelay[retryIndex].nominal = nextRetryDelay
+		globals.retryDelay[retryIndex].variance = nextRetryDelay * time.Duration(globals.config.SwiftRetryDelayVariance) / time.Duration(100)
+		nextRetryDelay = time.Duration(float64(nextRetryDelay) * globals.config.SwiftRetryExpBackoff)
+	}
+
+	if "" == globals.config.RetryRPCCACertFilePath {
+		globals.retryRPCCACertPEM = nil
+	} else {
+		globals.retryRPCCACertPEM, err = ioutil.ReadFile(globals.config.RetryRPCCACertFilePath)
+		if nil != err {
+			err = fmt.Errorf("ioutil.ReadFile(\"%s\") failed: %v", globals.config.RetryRPCCACertFilePath, err)
+			return
+		}
+	}
+
+	globals.swiftAuthToken = ""
+	globals.swiftStorageURL = ""
+
+	globals.httpClient = nil
+	globals.retryRPCClient = nil
+	globals.mountID = ""
+
+	globals.nextNonce = 0
+	globals.numNoncesReserved = 0
+
+	globals.inodeMap = make(map[uint64]*inodeStruct)
+	globals.inodePayloadCache = sortedmap.NewBPlusTreeCache(globals.config.InodePayloadEvictLowLimit, globals.config.InodePayloadEvictHighLimit)
+
+	globals.fhToInodeNumber = make(map[uint64]uint64)
+	globals.lastFH = 0
+
+	globals.fissionErrChan = fissionErrChan
+	globals.fissionVolume = nil
+
+	globals.stats = &statsStruct{}
+
+	bucketstats.Register("ICLIENT", "", globals.stats)
+
+	err = nil
+	return
+}
+
+func uninitializeGlobals() (err error) {
+	globals.config.VolumeName = ""
+	globals.config.MountPointDirPath = ""
+	globals.config.FUSEAllowOther = false
+	globals.config.FUSEMaxBackground = 0
+	globals.config.FUSECongestionThreshhold = 0
+	globals.config.FUSEMaxWrite = 0
+	globals.config.FUSEEntryValidDuration = time.Duration(0)
+	globals.config.FUSEAttrValidDuration = time.Duration(0)
+	globals.config.AuthPlugInPath = ""
+	globals.config.AuthPlugInEnvName = ""
+	globals.config.AuthPlugInEnvValue = ""
+	globals.config.SwiftTimeout = time.Duration(0)
+	globals.config.SwiftRetryLimit = 0
+	globals.config.SwiftRetryDelay = time.Duration(0)
+	globals.config.SwiftRetryDelayVariance = 0
+	globals.config.SwiftRetryExpBackoff = 0.0
+	globals.config.SwiftConnectionPoolSize = 0
+	globals.config.RetryRPCPublicIPAddr = ""
+	globals.config.RetryRPCPort = 0
+	globals.config.RetryRPCDeadlineIO = time.Duration(0)
+	globals.config.RetryRPCKeepAlivePeriod = time.Duration(0)
+	globals.config.RetryRPCCACertFilePath = ""
+
+	globals.config.InodePayloadEvictLowLimit = 0
+	globals.config.InodePayloadEvictHighLimit = 0
+	globals.config.DirInodeMaxKeysPerBPlusTreePage = 0
+	globals.config.FileInodeMaxKeysPerBPlusTreePage = 0
+	globals.config.FileFlushTriggerSize = 0
+
+	globals.config.LogFilePath = ""
+	globals.config.LogToConsole = false
+	globals.config.TraceEnabled = false
+
+	globals.retryDelay = nil
+	globals.authPlugInInput = ""
+
+	globals.swiftAuthToken = ""
+	globals.swiftStorageURL = ""
+
+	globals.httpClient = nil
+	globals.retryRPCCACertPEM = nil
+	globals.retryRPCClient = nil
+	globals.mountID = ""
+
+	globals.nextNonce = 0
+	globals.numNoncesReserved = 0
+
+	globals.inodeMap = nil
+	globals.inodePayloadCache = nil
+
+	globals.fhToInodeNumber = nil
+	globals.lastFH = 0
+
+	globals.fissionErrChan = nil
+	globals.fissionVolume = nil
+
+	bucketstats.UnRegister("ICLIENT", "")
+
+	err = nil
+	return
+}
@@ -2,3 +2,64 @@
 // SPDX-License-Identifier: Apache-2.0
 
 package iclientpkg
+
+import (
+	"github.com/NVIDIA/proxyfs/conf"
+)
+
+func start(confMap conf.ConfMap, fissionErrChan chan error) (err error) {
+	err = initializeGlobals(confMap, fissionErrChan)
+	if nil != err {
+		return
+	}
+
+	err = startSwiftClient()
+	if nil != err {
+		return
+	}
+
+	err = startRetryRPCClient()
+	if nil != err {
+		return
+	}
+
+	err = performMountFUSE()
+	if nil != err {
+		return
+	}
+
+	return
+}
+
+func stop() (err error) {
+	err = performUnmountFUSE()
+	if nil != err {
+		return
+	}
+
+	err = releaseAllLeases()
+	if nil != err {
+		return
+	}
+
+	err = stopRetryRPCClient()
+	if nil != err {
+		return
+	}
+
+	err = stopSwiftClient()
+	if nil != err {
+		return
+	}
+
+	err = uninitializeGlobals()
+
+	return
+}
+
+func signal() (err error) {
+	logSIGHUP()
+
+	err = nil
+	return
+}
@@ -0,0 +1,1071 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package iclientpkg
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/ilayout"
+	"github.com/NVIDIA/proxyfs/imgr/imgrpkg"
+)
+
+// fetchInodeHead ensures the locked (and Leased) inode's InodeHead, Layout, and
+// Payload B+Tree (if any) have been fetched.
+//
+func (inode *inodeStruct) fetchInodeHead() (err error) {
+	var (
+		getInodeTableEntryRequest  *imgrpkg.GetInodeTableEntryRequestStruct
+		getInodeTableEntryResponse *imgrpkg.GetInodeTableEntryResponseStruct
+		inodeHeadLayoutEntry       ilayout.InodeHeadLayoutEntryV1Struct
+		inodeHeadV1                *ilayout.InodeHeadV1Struct
+		inodeHeadV1Buf             []byte
+		startTime                  time.Time
+	)
+
+	if nil != inode.inodeHeadV1 {
+		err = nil
+		return
+	}
+
+	getInodeTableEntryRequest = &imgrpkg.GetInodeTableEntryRequestStruct{
+		MountID:     fetchMountID(),
+		InodeNumber: inode.inodeNumber,
+	}
+
+	getInodeTableEntryResponse = &imgrpkg.GetInodeTableEntryResponseStruct{}
+
+	startTime = time.Now()
+
+	err = rpcSend("GetInodeTableEntry", getInodeTableEntryRequest, getInodeTableEntryResponse)
+
+	globals.stats.GetInodeTableEntryUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+
+	if nil != err {
+		return
+	}
+
+	inodeHeadV1Buf, err = swiftObjectGetTail(getInodeTableEntryResponse.InodeHeadObjectNumber, getInodeTableEntryResponse.InodeHeadLength)
+	if nil != err {
+		return
+	}
+
+	inodeHeadV1, err = ilayout.UnmarshalInodeHeadV1(inodeHeadV1Buf)
+	if nil != err {
+		return
+	}
+	if inodeHeadV1.InodeNumber != inode.inodeNumber {
+		err = fmt.Errorf("InodeHead for InodeNumber %016X found to contain InodeNumber %016X", inode.inodeNumber, inodeHeadV1.InodeNumber)
+		return
+	}
+
+	inode.layoutMap = make(map[uint64]*layoutMapEntryStruct)
+
+	for _, inodeHeadLayoutEntry = range inodeHeadV1.Layout {
+		inode.layoutMap[inodeHeadLayoutEntry.ObjectNumber] = &layoutMapEntryStruct{
+			objectSize:      inodeHeadLayoutEntry.ObjectSize,
+			bytesReferenced: inodeHeadLayoutEntry.BytesReferenced,
+		}
+	}
+
+	inode.inodeHeadV1 = inodeHeadV1
+	inode.inodeHeadObjectNumber = getInodeTableEntryResponse.InodeHeadObjectNumber
+	inode.inodeHeadLength = getInodeTableEntryResponse.InodeHeadLength
+
+	switch inodeHeadV1.InodeType {
+	case ilayout.InodeTypeDir:
+		inode.payload, err = sortedmap.OldBPlusTree(inodeHeadV1.PayloadObjectNumber, inodeHeadV1.PayloadObjectOffset, inodeHeadV1.PayloadObjectLength, sortedmap.CompareString, inode, globals.inodePayloadCache)
+	case ilayout.InodeTypeFile:
+		inode.payload, err = sortedmap.OldBPlusTree(inodeHeadV1.PayloadObjectNumber, inodeHeadV1.PayloadObjectOffset, inodeHeadV1.PayloadObjectLength, sortedmap.CompareUint64, inode, globals.inodePayloadCache)
+	case ilayout.InodeTypeSymLink:
+		inode.payload = nil
+	default:
+		err = fmt.Errorf("InodeHead for InodeNumber %016X contains unknown InodeType %v", inode.inodeNumber, inodeHeadV1.InodeType)
+	}
+	if nil != err {
+		inode.discardCachedState()
+		return
+	}
+
+	err = nil
+	return
+}
+
+// discardCachedState drops all cached state for the locked inode.
+//
+func (inode *inodeStruct) discardCachedState() {
+	if nil != inode.payload {
+		_ = inode.payload.Purge(true)
+	}
+
+	inode.inodeHeadV1 = nil
+	inode.inodeHeadObjectNumber = 0
+	inode.inodeHeadLength = 0
+	inode.payload = nil
+	inode.layoutMap = nil
+	inode.dirty = false
+	inode.putObjectNumber = 0
+	inode.putObjectBuffer = nil
+	inode.dereferencedObjectList = nil
+	inode.objectCountAdjustment = 0
+	inode.objectSizeAdjustment = 0
+	inode.bytesRefAdjustment = 0
+}
+
+// createInode allocates a new InodeNumber, obtains an ExclusiveLease for it, and
+// returns it locked. The caller is responsible for linking the new inode into
+// its parent directory and flushing both.
+//
+func createInode(inodeType uint8, mode uint16, userID uint64, groupID uint64) (inode *inodeStruct, err error) {
+	var (
+		inodeNumber uint64
+		timeNow     time.Time = time.Now()
+	)
+
+	inodeNumber, err = fetchNonce()
+	if nil != err {
+		return
+	}
+
+	inode = lockInode(inodeNumber)
+
+	err = inode.ensureLease(true)
+	if nil != err {
+		inode.unlock()
+		inode = nil
+		return
+	}
+
+	inode.inodeHeadV1 = &ilayout.InodeHeadV1Struct{
+		InodeNumber:      inodeNumber,
+		InodeType:        inodeType,
+		LinkTable:        []ilayout.InodeLinkTableEntryStruct{},
+		Size:             0,
+		ModificationTime: timeNow,
+		StatusChangeTime: timeNow,
+		Mode:             mode & ilayout.InodeModeMask,
+		UserID:           userID,
+		GroupID:          groupID,
+		StreamTable:      []ilayout.InodeStreamTableEntryStruct{},
+		SymLinkTarget:    "",
+		Layout:           []ilayout.InodeHeadLayoutEntryV1Struct{},
+	}
+
+	inode.inodeHeadObjectNumber = 0
+	inode.inodeHeadLength = 0
+
+	inode.layoutMap = make(map[uint64]*layoutMapEntryStruct)
+
+	switch inodeType {
+	case ilayout.InodeTypeDir:
+		inode.payload = sortedmap.NewBPlusTree(globals.config.DirInodeMaxKeysPerBPlusTreePage, sortedmap.CompareString, inode, globals.inodePayloadCache)
+	case ilayout.InodeTypeFile:
+		inode.payload = sortedmap.NewBPlusTree(globals.config.FileInodeMaxKeysPerBPlusTreePage, sortedmap.CompareUint64, inode, globals.inodePayloadCache)
+	default:
+		inode.payload = nil
+	}
+
+	inode.dirty = true
+
+	err = nil
+	return
+}
+
+// markModified records that the locked inode (which must hold an ExclusiveLease)
+// has been modified. If updateMTime is true, its ModificationTime is updated as
+// well as its StatusChangeTime.
+//
+func (inode *inodeStruct) markModified(updateMTime bool) {
+	var (
+		timeNow time.Time = time.Now()
+	)
+
+	if updateMTime {
+		inode.inodeHeadV1.ModificationTime = timeNow
+	}
+
+	inode.inodeHeadV1.StatusChangeTime = timeNow
+
+	inode.dirty = true
+}
+
+// ensurePutObject ensures inode.putObjectBuffer is ready to accumulate data and
+// B+Tree pages to be written to a new Object.
+//
+func (inode *inodeStruct) ensurePutObject() (err error) {
+	if nil != inode.putObjectBuffer {
+		err = nil
+		return
+	}
+
+	inode.putObjectNumber, err = fetchNonce()
+	if nil != err {
+		return
+	}
+
+	inode.putObjectBuffer = make([]byte, 0)
+
+	err = nil
+	return
+}
+
+// appendToPutObject appends buf to inode.putObjectBuffer returning the location
+// where it will reside.
+//
+func (inode *inodeStruct) appendToPutObject(buf []byte) (objectNumber uint64, objectOffset uint64, err error) {
+	var (
+		layoutMapEntry *layoutMapEntryStruct
+		ok             bool
+	)
+
+	err = inode.ensurePutObject()
+	if nil != err {
+		return
+	}
+
+	objectNumber = inode.putObjectNumber
+	objectOffset = uint64(len(inode.putObjectBuffer))
+
+	inode.putObjectBuffer = append(inode.putObjectBuffer, buf...)
+
+	layoutMapEntry, ok = inode.layoutMap[objectNumber]
+	if !ok {
+		layoutMapEntry = &layoutMapEntryStruct{}
+		inode.layoutMap[objectNumber] = layoutMapEntry
+	}
+
+	layoutMapEntry.objectSize += uint64(len(buf))
+	layoutMapEntry.bytesReferenced += uint64(len(buf))
+
+	inode.bytesRefAdjustment += int64(len(buf))
+
+	err = nil
+	return
+}
+
+// dereference records that objectLength bytes of objectNumber are no longer
+// referenced by the locked inode. Once no bytes are referenced, objectNumber
+// is scheduled to be dereferenced at the next flush.
+//
+func (inode *inodeStruct) dereference(objectNumber uint64, objectLength uint64) (err error) {
+	var (
+		layoutMapEntry *layoutMapEntryStruct
+		ok             bool
+	)
+
+	layoutMapEntry, ok = inode.layoutMap[objectNumber]
+	if !ok {
+		err = fmt.Errorf("inode.layoutMap[%016X] not found for InodeNumber %016X", objectNumber, inode.inodeNumber)
+		return
+	}
+	if layoutMapEntry.bytesReferenced < objectLength {
+		err = fmt.Errorf("inode.layoutMap[%016X].bytesReferenced (%v) < objectLength (%v) for InodeNumber %016X", objectNumber, layoutMapEntry.bytesReferenced, objectLength, inode.inodeNumber)
+		return
+	}
+
+	layoutMapEntry.bytesReferenced -= objectLength
+
+	inode.bytesRefAdjustment -= int64(objectLength)
+
+	if (0 == layoutMapEntry.bytesReferenced) && ((nil == inode.putObjectBuffer) || (objectNumber != inode.putObjectNumber)) {
+		delete(inode.layoutMap, objectNumber)
+
+		inode.dereferencedObjectList = append(inode.dereferencedObjectList, objectNumber)
+		inode.objectCountAdjustment--
+		inode.objectSizeAdjustment -= int64(layoutMapEntry.objectSize)
+	}
+
+	err = nil
+	return
+}
+
+// readObject returns the objectLength bytes at objectOffset in objectNumber
+// (which might reside in inode.putObjectBuffer).
+//
+func (inode *inodeStruct) readObject(objectNumber uint64, objectOffset uint64, objectLength uint64) (buf []byte, err error) {
+	if (nil != inode.putObjectBuffer) && (objectNumber == inode.putObjectNumber) {
+		if (objectOffset + objectLength) > uint64(len(inode.putObjectBuffer)) {
+			err = fmt.Errorf("read of putObjectBuffer [%v:%v] exceeds len() == %v", objectOffset, objectOffset+objectLength, len(inode.putObjectBuffer))
+			return
+		}
+		buf = inode.putObjectBuffer[objectOffset:(objectOffset + objectLength)]
+		err = nil
+		return
+	}
+
+	buf, err = swiftObjectGetRange(objectNumber, objectOffset, objectLength)
+
+	return
+}
+
+// prepareFlush writes all pending modifications of the locked inode to a new
+// Object and appends the resultant InodeTableEntry update (and SuperBlock
+// adjustments) to putInodeTableEntriesRequest. Until completeFlush() is called,
+// the inode remains marked dirty.
+//
+// Note that the Layout of an Inode (as is the case in the format established
+// by imgrpkg when the RootDirInode is created) only accounts for the bytes of
+// B+Tree pages and File data. The InodeHead appended to each Object is only
+// accounted for in the SuperBlock adjustments.
+//
+func (inode *inodeStruct) prepareFlush(putInodeTableEntriesRequest *imgrpkg.PutInodeTableEntriesRequestStruct) (putInodeTableEntry *imgrpkg.PutInodeTableEntryStruct, err error) {
+	var (
+		inodeHeadDereferenced bool
+		inodeHeadInLayout     bool
+		inodeHeadV1Buf        []byte
+		layoutMapEntry        *layoutMapEntryStruct
+		objectBuf             []byte
+		objectNumber          uint64
+		objectNumbers         []uint64
+		payloadLength         uint64
+		payloadObjectNum      uint64
+		payloadOffset         uint64
+	)
+
+	if nil != inode.payload {
+		payloadObjectNum, payloadOffset, payloadLength, err = inode.payload.Flush(false)
+		if nil != err {
+			return
+		}
+
+		inode.inodeHeadV1.PayloadObjectNumber = payloadObjectNum
+		inode.inodeHeadV1.PayloadObjectOffset = payloadOffset
+		inode.inodeHeadV1.PayloadObjectLength = payloadLength
+	}
+
+	err = inode.ensurePutObject()
+	if nil != err {
+		return
+	}
+
+	objectNumbers = make([]uint64, 0, len(inode.layoutMap))
+
+	for objectNumber, layoutMapEntry = range inode.layoutMap {
+		if 0 == layoutMapEntry.bytesReferenced {
+			// Only possible for inode.putObjectNumber... which will still hold the InodeHead
+
+			delete(inode.layoutMap, objectNumber)
+		} else {
+			objectNumbers = append(objectNumbers, objectNumber)
+		}
+	}
+
+	sort.Slice(objectNumbers, func(i, j int) bool { return objectNumbers[i] < objectNumbers[j] })
+
+	inode.inodeHeadV1.Layout = make([]ilayout.InodeHeadLayoutEntryV1Struct, 0, len(objectNumbers))
+
+	for _, objectNumber = range objectNumbers {
+		layoutMapEntry = inode.layoutMap[objectNumber]
+		inode.inodeHeadV1.Layout = append(inode.inodeHeadV1.Layout, ilayout.InodeHeadLayoutEntryV1Struct{
+			ObjectNumber:    objectNumber,
+			ObjectSize:      layoutMapEntry.objectSize,
+			BytesReferenced: layoutMapEntry.bytesReferenced,
+		})
+	}
+
+	inodeHeadV1Buf, err = inode.inodeHeadV1.MarshalInodeHeadV1()
+	if nil != err {
+		return
+	}
+
+	objectBuf = make([]byte, 0, len(inode.putObjectBuffer)+len(inodeHeadV1Buf))
+	objectBuf = append(objectBuf, inode.putObjectBuffer...)
+	objectBuf = append(objectBuf, inodeHeadV1Buf...)
+
+	err = swiftObjectPut(inode.putObjectNumber, objectBuf)
+	if nil != err {
+		return
+	}
+
+	putInodeTableEntry = &imgrpkg.PutInodeTableEntryStruct{
+		InodeNumber:           inode.inodeNumber,
+		InodeHeadObjectNumber: inode.putObjectNumber,
+		InodeHeadLength:       uint64(len(inodeHeadV1Buf)),
+	}
+
+	putInodeTableEntriesRequest.UpdatedInodeTableEntryArray = append(putInodeTableEntriesRequest.UpdatedInodeTableEntryArray, *putInodeTableEntry)
+
+	putInodeTableEntriesRequest.SuperBlockInodeObjectCountAdjustment += inode.objectCountAdjustment + 1
+	putInodeTableEntriesRequest.SuperBlockInodeObjectSizeAdjustment += inode.objectSizeAdjustment + int64(len(objectBuf))
+	putInodeTableEntriesRequest.SuperBlockInodeBytesReferencedAdjustment += inode.bytesRefAdjustment + int64(len(inodeHeadV1Buf))
+	putInodeTableEntriesRequest.DereferencedObjectNumberArray = append(putInodeTableEntriesRequest.DereferencedObjectNumberArray, inode.dereferencedObjectList...)
+
+	if 0 != inode.inodeHeadObjectNumber {
+		putInodeTableEntriesRequest.SuperBlockInodeBytesReferencedAdjustment -= int64(inode.inodeHeadLength)
+
+		_, inodeHeadInLayout = inode.layoutMap[inode.inodeHeadObjectNumber]
+		if !inodeHeadInLayout {
+			putInodeTableEntriesRequest.SuperBlockInodeObjectSizeAdjustment -= int64(inode.inodeHeadLength)
+
+			inodeHeadDereferenced = false
+
+			for _, objectNumber = range inode.dereferencedObjectList {
+				if objectNumber == inode.inodeHeadObjectNumber {
+					inodeHeadDereferenced = true
+					break
+				}
+			}
+
+			if !inodeHeadDereferenced {
+				putInodeTableEntriesRequest.SuperBlockInodeObjectCountAdjustment--
+				putInodeTableEntriesRequest.DereferencedObjectNumberArray = append(putInodeTableEntriesRequest.DereferencedObjectNumberArray, inode.inodeHeadObjectNumber)
+			}
+		}
+	}
+
+	err = nil
+	return
+}
+
+// completeFlush records that the InodeTableEntry returned by prepareFlush()
+// has been successfully applied.
+//
+func (inode *inodeStruct) completeFlush(putInodeTableEntry *imgrpkg.PutInodeTableEntryStruct) {
+	inode.inodeHeadObjectNumber = putInodeTableEntry.InodeHeadObjectNumber
+	inode.inodeHeadLength = putInodeTableEntry.InodeHeadLength
+
+	inode.dirty = false
+	inode.putObjectNumber = 0
+	inode.putObjectBuffer = nil
+	inode.dereferencedObjectList = nil
+	inode.objectCountAdjustment = 0
+	inode.objectSizeAdjustment = 0
+	inode.bytesRefAdjustment = 0
+}
+
+// flushInodes atomically applies all pending modifications to the supplied
+// locked inodes (each holding an ExclusiveLease) via a single PutInodeTableEntries
+// request.
+//
+func flushInodes(inodes []*inodeStruct) (err error) {
+	var (
+		inode                        *inodeStruct
+		putInodeTableEntries         []*imgrpkg.PutInodeTableEntryStruct
+		putInodeTableEntriesRequest  *imgrpkg.PutInodeTableEntriesRequestStruct
+		putInodeTableEntriesResponse *imgrpkg.PutInodeTableEntriesResponseStruct
+		putInodeTableEntry           *imgrpkg.PutInodeTableEntryStruct
+		putInodeTableEntryIndex      int
+		rpcStartTime                 time.Time
+		startTime                    time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.InodeFlushUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	putInodeTableEntriesRequest = &imgrpkg.PutInodeTableEntriesRequestStruct{
+		MountID:                       fetchMountID(),
+		UpdatedInodeTableEntryArray:   make([]imgrpkg.PutInodeTableEntryStruct, 0, len(inodes)),
+		DereferencedObjectNumberArray: make([]uint64, 0),
+	}
+
+	putInodeTableEntries = make([]*imgrpkg.PutInodeTableEntryStruct, len(inodes))
+
+	for putInodeTableEntryIndex, inode = range inodes {
+		if !inode.dirty || inode.markedForDelete {
+			// Note: Any modifications to an inode marked for delete are simply discarded
+			continue
+		}
+
+		if inodeLeaseStateExclusiveGranted != inode.leaseState {
+			logFatalf("flushInodes() called for dirty InodeNumber %016X without an ExclusiveLease", inode.inodeNumber)
+		}
+
+		putInodeTableEntry, err = inode.prepareFlush(putInodeTableEntriesRequest)
+		if nil != err {
+			return
+		}
+
+		putInodeTableEntries[putInodeTableEntryIndex] = putInodeTableEntry
+	}
+
+	if 0 == len(putInodeTableEntriesRequest.UpdatedInodeTableEntryArray) {
+		err = nil
+		return
+	}
+
+	putInodeTableEntriesResponse = &imgrpkg.PutInodeTableEntriesResponseStruct{}
+
+	rpcStartTime = time.Now()
+
+	err = rpcSend("PutInodeTableEntries", putInodeTableEntriesRequest, putInodeTableEntriesResponse)
+
+	globals.stats.PutInodeTableEntriesUsecs.Add(uint64(time.Since(rpcStartTime) / time.Microsecond))
+
+	if nil != err {
+		return
+	}
+
+	for putInodeTableEntryIndex, inode = range inodes {
+		putInodeTableEntry = putInodeTableEntries[putInodeTableEntryIndex]
+		if nil != putInodeTableEntry {
+			inode.completeFlush(putInodeTableEntry)
+		}
+	}
+
+	err = nil
+	return
+}
+
+// deleteInode requests that the locked inode (holding an ExclusiveLease and
+// having no remaining LinkTable entries) be removed from the InodeTable. Should
+// the inode still be open, removal is deferred until it is no longer open.
+//
+func (inode *inodeStruct) deleteInode() (err error) {
+	var (
+		deleteInodeTableEntryRequest  *imgrpkg.DeleteInodeTableEntryRequestStruct
+		deleteInodeTableEntryResponse *imgrpkg.DeleteInodeTableEntryResponseStruct
+		startTime                     time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.DeleteInodeTableEntryUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	deleteInodeTableEntryRequest = &imgrpkg.DeleteInodeTableEntryRequestStruct{
+		MountID:     fetchMountID(),
+		InodeNumber: inode.inodeNumber,
+	}
+
+	deleteInodeTableEntryResponse = &imgrpkg.DeleteInodeTableEntryResponseStruct{}
+
+	err = rpcSend("DeleteInodeTableEntry", deleteInodeTableEntryRequest, deleteInodeTableEntryResponse)
+	if nil != err {
+		return
+	}
+
+	inode.markedForDelete = true
+
+	err = nil
+	return
+}
+
+// deleteIfUnlinked requests that the locked inode (holding an ExclusiveLease) be
+// deleted if its last link has been removed. If the inode is not currently open,
+// its Lease is also released.
+//
+func (inode *inodeStruct) deleteIfUnlinked() (err error) {
+	if 0 < inode.linkCount() {
+		err = nil
+		return
+	}
+
+	err = inode.deleteInode()
+	if nil != err {
+		return
+	}
+
+	if 0 == inode.openCount {
+		err = inode.releaseLease()
+	}
+
+	return
+}
+
+// adjustOpenCount informs imgrpkg of a change in the number of opens of the
+// locked (and Leased) inode by this mount.
+//
+func (inode *inodeStruct) adjustOpenCount(adjustment int64) (err error) {
+	var (
+		adjustInodeTableEntryOpenCountRequest  *imgrpkg.AdjustInodeTableEntryOpenCountRequestStruct
+		adjustInodeTableEntryOpenCountResponse *imgrpkg.AdjustInodeTableEntryOpenCountResponseStruct
+		startTime                              time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.AdjustInodeTableEntryOpenCountUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	adjustInodeTableEntryOpenCountRequest = &imgrpkg.AdjustInodeTableEntryOpenCountRequestStruct{
+		MountID:     fetchMountID(),
+		InodeNumber: inode.inodeNumber,
+		Adjustment:  adjustment,
+	}
+
+	adjustInodeTableEntryOpenCountResponse = &imgrpkg.AdjustInodeTableEntryOpenCountResponseStruct{}
+
+	err = rpcSend("AdjustInodeTableEntryOpenCount", adjustInodeTableEntryOpenCountRequest, adjustInodeTableEntryOpenCountResponse)
+
+	return
+}
+
+// lookupDirEntry returns the DirectoryEntryValueV1Struct for name in the locked
+// (and Leased) directory inode.
+//
+func (inode *inodeStruct) lookupDirEntry(name string) (dirEntry ilayout.DirectoryEntryValueV1Struct, ok bool, err error) {
+	var (
+		dirEntryRaw sortedmap.Value
+	)
+
+	dirEntryRaw, ok, err = inode.payload.GetByKey(name)
+	if (nil != err) || !ok {
+		return
+	}
+
+	dirEntry, ok = dirEntryRaw.(ilayout.DirectoryEntryValueV1Struct)
+	if !ok {
+		err = fmt.Errorf("dirEntryRaw.(ilayout.DirectoryEntryValueV1Struct) returned !ok")
+	}
+
+	return
+}
+
+// addLink records, in the locked inode's LinkTable, that it is referenced by
+// name in the directory identified by parentDirInodeNumber.
+//
+func (inode *inodeStruct) addLink(parentDirInodeNumber uint64, name string) {
+	inode.inodeHeadV1.LinkTable = append(inode.inodeHeadV1.LinkTable, ilayout.InodeLinkTableEntryStruct{
+		ParentDirInodeNumber: parentDirInodeNumber,
+		ParentDirEntryName:   name,
+	})
+
+	inode.markModified(false)
+}
+
+// removeLink removes, from the locked inode's LinkTable, the reference by
+// name in the directory identified by parentDirInodeNumber.
+//
+func (inode *inodeStruct) removeLink(parentDirInodeNumber uint64, name string) {
+	var (
+		linkTableEntry      ilayout.InodeLinkTableEntryStruct
+		linkTableEntryIndex int
+	)
+
+	for linkTableEntryIndex, linkTableEntry = range inode.inodeHeadV1.LinkTable {
+		if (linkTableEntry.ParentDirInodeNumber == parentDirInodeNumber) && (linkTableEntry.ParentDirEntryName == name) {
+			inode.inodeHeadV1.LinkTable = append(inode.inodeHeadV1.LinkTable[:linkTableEntryIndex], inode.inodeHeadV1.LinkTable[linkTableEntryIndex+1:]...)
+			inode.markModified(false)
+			return
+		}
+	}
+}
+
+// linkCount returns the number of LinkTable entries for a non-directory inode
+// or, for a directory inode, the number of LinkTable entries that are not
+// the "." and ".." references of the directory itself or its subdirectories.
+// In either case, a linkCount of zero indicates the inode may be deleted.
+//
+func (inode *inodeStruct) linkCount() (linkCount int) {
+	var (
+		linkTableEntry ilayout.InodeLinkTableEntryStruct
+	)
+
+	linkCount = 0
+
+	for _, linkTableEntry = range inode.inodeHeadV1.LinkTable {
+		if (ilayout.InodeTypeDir == inode.inodeHeadV1.InodeType) && (("." == linkTableEntry.ParentDirEntryName) || (".." == linkTableEntry.ParentDirEntryName)) {
+			continue
+		}
+		linkCount++
+	}
+
+	return
+}
+
+// readFile returns up to size bytes starting at offset of the locked (and
+// Leased) file inode. Holes are returned as zeroes.
+//
+func (inode *inodeStruct) readFile(offset uint64, size uint64) (buf []byte, err error) {
+	var (
+		curOffset      uint64
+		endOffset      uint64
+		extentBuf      []byte
+		extentEnd      uint64
+		extentIndex    int
+		extentKeyRaw   sortedmap.Key
+		extentStart    uint64
+		extentValue    ilayout.ExtentMapEntryValueV1Struct
+		extentValueRaw sortedmap.Value
+		ok             bool
+		overlapEnd     uint64
+	)
+
+	if offset >= inode.inodeHeadV1.Size {
+		buf = make([]byte, 0)
+		err = nil
+		return
+	}
+
+	endOffset = offset + size
+	if endOffset > inode.inodeHeadV1.Size {
+		endOffset = inode.inodeHeadV1.Size
+	}
+
+	buf = make([]byte, endOffset-offset)
+
+	extentIndex, _, err = inode.payload.BisectLeft(offset)
+	if nil != err {
+		return
+	}
+	if extentIndex < 0 {
+		extentIndex = 0
+	}
+
+	for {
+		extentKeyRaw, extentValueRaw, ok, err = inode.payload.GetByIndex(extentIndex)
+		if nil != err {
+			return
+		}
+		if !ok {
+			break
+		}
+
+		extentStart, ok = extentKeyRaw.(uint64)
+		if !ok {
+			err = fmt.Errorf("extentKeyRaw.(uint64) returned !ok")
+			return
+		}
+		extentValue, ok = extentValueRaw.(ilayout.ExtentMapEntryValueV1Struct)
+		if !ok {
+			err = fmt.Errorf("extentValueRaw.(ilayout.ExtentMapEntryValueV1Struct) returned !ok")
+			return
+		}
+
+		if extentStart >= endOffset {
+			break
+		}
+
+		extentEnd = extentStart + extentValue.Length
+
+		if extentEnd > offset {
+			curOffset = extentStart
+			if curOffset < offset {
+				curOffset = offset
+			}
+			overlapEnd = extentEnd
+			if overlapEnd > endOffset {
+				overlapEnd = endOffset
+			}
+
+			extentBuf, err = inode.readObject(extentValue.ObjectNumber, extentValue.ObjectOffset+(curOffset-extentStart), overlapEnd-curOffset)
+			if nil != err {
+				return
+			}
+
+			_ = copy(buf[(curOffset-offset):], extentBuf)
+		}
+
+		extentIndex++
+	}
+
+	err = nil
+	return
+}
+
+// punchHole removes any extents (or portions thereof) of the locked file inode
+// (holding an ExclusiveLease) in the range [offset:offset+length).
+//
+func (inode *inodeStruct) punchHole(offset uint64, length uint64) (err error) {
+	var (
+		endOffset      uint64
+		extentEnd      uint64
+		extentIndex    int
+		extentKeyRaw   sortedmap.Key
+		extentStart    uint64
+		extentValue    ilayout.ExtentMapEntryValueV1Struct
+		extentValueRaw sortedmap.Value
+		ok             bool
+		overlapEnd     uint64
+		overlapStart   uint64
+	)
+
+	endOffset = offset + length
+
+	extentIndex, _, err = inode.payload.BisectLeft(offset)
+	if nil != err {
+		return
+	}
+	if extentIndex < 0 {
+		extentIndex = 0
+	}
+
+	for {
+		extentKeyRaw, extentValueRaw, ok, err = inode.payload.GetByIndex(extentIndex)
+		if nil != err {
+			return
+		}
+		if !ok {
+			break
+		}
+
+		extentStart, ok = extentKeyRaw.(uint64)
+		if !ok {
+			err = fmt.Errorf("extentKeyRaw.(uint64) returned !ok")
+			return
+		}
+		extentValue, ok = extentValueRaw.(ilayout.ExtentMapEntryValueV1Struct)
+		if !ok {
+			err = fmt.Errorf("extentValueRaw.(ilayout.ExtentMapEntryValueV1Struct) returned !ok")
+			return
+		}
+
+		if extentStart >= endOffset {
+			break
+		}
+
+		extentEnd = extentStart + extentValue.Length
+
+		if extentEnd <= offset {
+			extentIndex++
+			continue
+		}
+
+		_, err = inode.payload.DeleteByIndex(extentIndex)
+		if nil != err {
+			return
+		}
+
+		overlapStart = extentStart
+		if overlapStart < offset {
+			overlapStart = offset
+		}
+		overlapEnd = extentEnd
+		if overlapEnd > endOffset {
+			overlapEnd = endOffset
+		}
+
+		err = inode.dereference(extentValue.ObjectNumber, overlapEnd-overlapStart)
+		if nil != err {
+			return
+		}
+
+		if extentStart < offset {
+			_, err = inode.payload.Put(extentStart, ilayout.ExtentMapEntryValueV1Struct{
+				Length:       offset - extentStart,
+				ObjectNumber: extentValue.ObjectNumber,
+				ObjectOffset: extentValue.ObjectOffset,
+			})
+			if nil != err {
+				return
+			}
+
+			extentIndex++
+		}
+
+		if extentEnd > endOffset {
+			_, err = inode.payload.Put(endOffset, ilayout.ExtentMapEntryValueV1Struct{
+				Length:       extentEnd - endOffset,
+				ObjectNumber: extentValue.ObjectNumber,
+				ObjectOffset: extentValue.ObjectOffset + (endOffset - extentStart),
+			})
+			if nil != err {
+				return
+			}
+		}
+	}
+
+	err = nil
+	return
+}
+
+// writeFile writes buf at offset of the locked file inode (holding an ExclusiveLease).
+// The data is accumulated in inode.putObjectBuffer that is flushed once it reaches
+// globals.config.FileFlushTriggerSize.
+//
+func (inode *inodeStruct) writeFile(offset uint64, buf []byte) (err error) {
+	var (
+		objectNumber uint64
+		objectOffset uint64
+	)
+
+	if 0 == len(buf) {
+		err = nil
+		return
+	}
+
+	err = inode.punchHole(offset, uint64(len(buf)))
+	if nil != err {
+		return
+	}
+
+	objectNumber, objectOffset, err = inode.appendToPutObject(buf)
+	if nil != err {
+		return
+	}
+
+	_, err = inode.payload.Put(offset, ilayout.ExtentMapEntryValueV1Struct{
+		Length:       uint64(len(buf)),
+		ObjectNumber: objectNumber,
+		ObjectOffset: objectOffset,
+	})
+	if nil != err {
+		return
+	}
+
+	if (offset + uint64(len(buf))) > inode.inodeHeadV1.Size {
+		inode.inodeHeadV1.Size = offset + uint64(len(buf))
+	}
+
+	inode.markModified(true)
+
+	if uint64(len(inode.putObjectBuffer)) >= globals.config.FileFlushTriggerSize {
+		err = flushInodes([]*inodeStruct{inode})
+	}
+
+	return
+}
+
+// truncateFile sets the Size of the locked file inode (holding an ExclusiveLease)
+// discarding any extents beyond the new size.
+//
+func (inode *inodeStruct) truncateFile(size uint64) (err error) {
+	if size < inode.inodeHeadV1.Size {
+		err = inode.punchHole(size, inode.inodeHeadV1.Size-size)
+		if nil != err {
+			return
+		}
+	}
+
+	inode.inodeHeadV1.Size = size
+
+	inode.markModified(true)
+
+	err = nil
+	return
+}
+
+func (inode *inodeStruct) DumpKey(key sortedmap.Key) (keyAsString string, err error) {
+	switch keyAsType := key.(type) {
+	case string:
+		keyAsString = keyAsType
+	case uint64:
+		keyAsString = fmt.Sprintf("%016X", keyAsType)
+	default:
+		err = fmt.Errorf("(*inodeStruct).DumpKey(key:%v) called with unexpected Key type", key)
+		return
+	}
+
+	err = nil
+	return
+}
+
+func (inode *inodeStruct) DumpValue(value sortedmap.Value) (valueAsString string, err error) {
+	switch valueAsType := value.(type) {
+	case ilayout.DirectoryEntryValueV1Struct:
+		valueAsString = fmt.Sprintf("[%016X %v]", valueAsType.InodeNumber, valueAsType.InodeType)
+	case ilayout.ExtentMapEntryValueV1Struct:
+		valueAsString = fmt.Sprintf("[%016X %016X %016X]", valueAsType.Length, valueAsType.ObjectNumber, valueAsType.ObjectOffset)
+	default:
+		err = fmt.Errorf("(*inodeStruct).DumpValue(value:%v) called with unexpected Value type", value)
+		return
+	}
+
+	err = nil
+	return
+}
+
+func (inode *inodeStruct) GetNode(objectNumber uint64, objectOffset uint64, objectLength uint64) (nodeByteSlice []byte, err error) {
+	nodeByteSlice, err = inode.readObject(objectNumber, objectOffset, objectLength)
+	return
+}
+
+func (inode *inodeStruct) PutNode(nodeByteSlice []byte) (objectNumber uint64, objectOffset uint64, err error) {
+	objectNumber, objectOffset, err = inode.appendToPutObject(nodeByteSlice)
+	return
+}
+
+func (inode *inodeStruct) DiscardNode(objectNumber uint64, objectOffset uint64, objectLength uint64) (err error) {
+	err = inode.dereference(objectNumber, objectLength)
+	return
+}
+
+func (inode *inodeStruct) PackKey(key sortedmap.Key) (packedKey []byte, err error) {
+	var (
+		nextPos int
+	)
+
+	switch keyAsType := key.(type) {
+	case string:
+		packedKey = make([]byte, 8+len(keyAsType))
+		nextPos, err = ilayout.PutLEStringToBuf(packedKey, 0, keyAsType)
+	case uint64:
+		packedKey = make([]byte, 8)
+		nextPos, err = ilayout.PutLEUint64ToBuf(packedKey, 0, keyAsType)
+	default:
+		err = fmt.Errorf("(*inodeStruct).PackKey(key:%v) called with unexpected Key type", key)
+		return
+	}
+	if nil != err {
+		return
+	}
+
+	if len(packedKey) != nextPos {
+		err = fmt.Errorf("(*inodeStruct).PackKey(key:%v) logic error", key)
+		return
+	}
+
+	err = nil
+	return
+}
+
+func (inode *inodeStruct) UnpackKey(payloadData []byte) (key sortedmap.Key, bytesConsumed uint64, err error) {
+	var (
+		nextPos int
+	)
+
+	switch inode.inodeHeadV1.InodeType {
+	case ilayout.InodeTypeDir:
+		key, nextPos, err = ilayout.GetLEStringFromBuf(payloadData, 0)
+	case ilayout.InodeTypeFile:
+		key, nextPos, err = ilayout.GetLEUint64FromBuf(payloadData, 0)
+	default:
+		err = fmt.Errorf("(*inodeStruct).UnpackKey() called for unexpected InodeType %v", inode.inodeHeadV1.InodeType)
+		return
+	}
+	if nil != err {
+		return
+	}
+
+	bytesConsumed = uint64(nextPos)
+
+	err = nil
+	return
+}
+
+func (inode *inodeStruct) PackValue(value sortedmap.Value) (packedValue []byte, err error) {
+	switch valueAsType := value.(type) {
+	case ilayout.DirectoryEntryValueV1Struct:
+		packedValue, err = valueAsType.MarshalDirectoryEntryValueV1()
+	case ilayout.ExtentMapEntryValueV1Struct:
+		packedValue, err = valueAsType.MarshalExtentMapEntryValueV1()
+	default:
+		err = fmt.Errorf("(*inodeStruct).PackValue(value:%v) called with unexpected Value type", value)
+	}
+
+	return
+}
+
+func (inode *inodeStruct) UnpackValue(payloadData []byte) (value sortedmap.Value, bytesConsumed uint64, err error) {
+	var (
+		bytesConsumedAsInt    int
+		directoryEntryValueV1 *ilayout.DirectoryEntryValueV1Struct
+		extentMapEntryValueV1 *ilayout.ExtentMapEntryValueV1Struct
+	)
+
+	switch inode.inodeHeadV1.InodeType {
+	case ilayout.InodeTypeDir:
+		directoryEntryValueV1, bytesConsumedAsInt, err = ilayout.UnmarshalDirectoryEntryValueV1(payloadData)
+		if nil != err {
+			return
+		}
+		value = *directoryEntryValueV1
+	case ilayout.InodeTypeFile:
+		extentMapEntryValueV1, bytesConsumedAsInt, err = ilayout.UnmarshalExtentMapEntryValueV1(payloadData)
+		if nil != err {
+			return
+		}
+		value = *extentMapEntryValueV1
+	default:
+		err = fmt.Errorf("(*inodeStruct).UnpackValue() called for unexpected InodeType %v", inode.inodeHeadV1.InodeType)
+		return
+	}
+
+	bytesConsumed = uint64(bytesConsumedAsInt)
+
+	err = nil
+	return
+}
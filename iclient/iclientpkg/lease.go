@@ -0,0 +1,441 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package iclientpkg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/imgr/imgrpkg"
+)
+
+// lockInode returns the inodeStruct for the specified inodeNumber (creating
+// it if necessary) after having obtained its lock. No Lease is obtained.
+//
+func lockInode(inodeNumber uint64) (inode *inodeStruct) {
+	var (
+		currentInode *inodeStruct
+		ok           bool
+	)
+
+	for {
+		globals.Lock()
+
+		inode, ok = globals.inodeMap[inodeNumber]
+		if !ok {
+			inode = &inodeStruct{
+				inodeNumber: inodeNumber,
+				leaseState:  inodeLeaseStateNone,
+			}
+
+			globals.inodeMap[inodeNumber] = inode
+		}
+
+		globals.Unlock()
+
+		inode.Lock()
+
+		// While we were waiting, inode may have been removed from globals.inodeMap
+
+		globals.Lock()
+		currentInode, ok = globals.inodeMap[inodeNumber]
+		globals.Unlock()
+
+		if ok && (currentInode == inode) {
+			return
+		}
+
+		inode.Unlock()
+	}
+}
+
+// lockInodeWithLease locks the specified inode, ensures the required Lease has
+// been granted, and fetches the inode's InodeHead (if not already cached).
+//
+func lockInodeWithLease(inodeNumber uint64, exclusive bool) (inode *inodeStruct, err error) {
+	inode = lockInode(inodeNumber)
+
+	err = inode.ensureLease(exclusive)
+	if nil != err {
+		inode.unlock()
+		inode = nil
+		return
+	}
+
+	err = inode.fetchInodeHead()
+	if nil != err {
+		inode.unlock()
+		inode = nil
+		return
+	}
+
+	return
+}
+
+// unlock releases the lock on inode. If no Lease is held and inode is not
+// currently open, it is also removed from globals.inodeMap.
+//
+func (inode *inodeStruct) unlock() {
+	if (inodeLeaseStateNone == inode.leaseState) && (0 == inode.openCount) {
+		globals.Lock()
+		delete(globals.inodeMap, inode.inodeNumber)
+		globals.Unlock()
+	}
+
+	inode.Unlock()
+}
+
+func (inode *inodeStruct) leaseRequest(leaseRequestType imgrpkg.LeaseRequestType) (leaseResponseType imgrpkg.LeaseResponseType, err error) {
+	var (
+		leaseRequest  *imgrpkg.LeaseRequestStruct
+		leaseResponse *imgrpkg.LeaseResponseStruct
+		startTime     time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.LeaseUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	leaseRequest = &imgrpkg.LeaseRequestStruct{
+		MountID:          fetchMountID(),
+		InodeNumber:      inode.inodeNumber,
+		LeaseRequestType: leaseRequestType,
+	}
+
+	leaseResponse = &imgrpkg.LeaseResponseStruct{}
+
+	err = rpcSend("Lease", leaseRequest, leaseResponse)
+	if nil != err {
+		return
+	}
+
+	leaseResponseType = leaseResponse.LeaseResponseType
+
+	return
+}
+
+// ensureLease obtains at least a SharedLease (or, if exclusive is true, an
+// ExclusiveLease) on the locked inode.
+//
+// Rather than request a Promotion (that imgrpkg may deny), a held SharedLease
+// is first released (discarding any cached state) before an ExclusiveLease
+// is requested.
+//
+func (inode *inodeStruct) ensureLease(exclusive bool) (err error) {
+	var (
+		leaseResponseType imgrpkg.LeaseResponseType
+	)
+
+	switch inode.leaseState {
+	case inodeLeaseStateNone:
+		// Fall through to request below
+	case inodeLeaseStateSharedGranted:
+		if !exclusive {
+			err = nil
+			return
+		}
+		err = inode.releaseLease()
+		if nil != err {
+			return
+		}
+	case inodeLeaseStateExclusiveGranted:
+		err = nil
+		return
+	default:
+		logFatalf("inode.leaseState (%v) unknown", inode.leaseState)
+	}
+
+	if exclusive {
+		leaseResponseType, err = inode.leaseRequest(imgrpkg.LeaseRequestTypeExclusive)
+		if nil != err {
+			return
+		}
+		if imgrpkg.LeaseResponseTypeExclusive != leaseResponseType {
+			err = fmt.Errorf("Lease(InodeNumber: %016X, LeaseRequestTypeExclusive) returned unexpected LeaseResponseType: %v", inode.inodeNumber, leaseResponseType)
+			return
+		}
+		inode.leaseState = inodeLeaseStateExclusiveGranted
+	} else {
+		leaseResponseType, err = inode.leaseRequest(imgrpkg.LeaseRequestTypeShared)
+		if nil != err {
+			return
+		}
+		if imgrpkg.LeaseResponseTypeShared != leaseResponseType {
+			err = fmt.Errorf("Lease(InodeNumber: %016X, LeaseRequestTypeShared) returned unexpected LeaseResponseType: %v", inode.inodeNumber, leaseResponseType)
+			return
+		}
+		inode.leaseState = inodeLeaseStateSharedGranted
+	}
+
+	err = nil
+	return
+}
+
+// demoteLease flushes any pending modifications to the locked inode and then
+// demotes its ExclusiveLease to a SharedLease. Cached state remains valid.
+//
+func (inode *inodeStruct) demoteLease() (err error) {
+	var (
+		leaseResponseType imgrpkg.LeaseResponseType
+	)
+
+	if inodeLeaseStateExclusiveGranted != inode.leaseState {
+		err = nil
+		return
+	}
+
+	err = flushInodes([]*inodeStruct{inode})
+	if nil != err {
+		return
+	}
+
+	leaseResponseType, err = inode.leaseRequest(imgrpkg.LeaseRequestTypeDemote)
+	if nil != err {
+		return
+	}
+	if imgrpkg.LeaseResponseTypeDemoted != leaseResponseType {
+		err = fmt.Errorf("Lease(InodeNumber: %016X, LeaseRequestTypeDemote) returned unexpected LeaseResponseType: %v", inode.inodeNumber, leaseResponseType)
+		return
+	}
+
+	inode.leaseState = inodeLeaseStateSharedGranted
+
+	err = nil
+	return
+}
+
+// releaseLease flushes any pending modifications to the locked inode, releases
+// its Lease, and discards all cached state.
+//
+func (inode *inodeStruct) releaseLease() (err error) {
+	var (
+		leaseResponseType imgrpkg.LeaseResponseType
+	)
+
+	if inodeLeaseStateNone == inode.leaseState {
+		err = nil
+		return
+	}
+
+	if inodeLeaseStateExclusiveGranted == inode.leaseState {
+		err = flushInodes([]*inodeStruct{inode})
+		if nil != err {
+			return
+		}
+	}
+
+	leaseResponseType, err = inode.leaseRequest(imgrpkg.LeaseRequestTypeRelease)
+	if nil != err {
+		return
+	}
+	if imgrpkg.LeaseResponseTypeReleased != leaseResponseType {
+		err = fmt.Errorf("Lease(InodeNumber: %016X, LeaseRequestTypeRelease) returned unexpected LeaseResponseType: %v", inode.inodeNumber, leaseResponseType)
+		return
+	}
+
+	inode.leaseState = inodeLeaseStateNone
+
+	inode.discardCachedState()
+
+	err = nil
+	return
+}
+
+// handleRPCInterrupt services an RPCInterrupt "upcall" received from imgrpkg.
+// As it may need to wait for the affected inode(s) to be unlocked, it is run
+// in its own goroutine.
+//
+func handleRPCInterrupt(rpcInterrupt *imgrpkg.RPCInterrupt) {
+	var (
+		err          error
+		inode        *inodeStruct
+		inodeNumber  uint64
+		inodeNumbers []uint64
+		ok           bool
+	)
+
+	defer globals.interruptWG.Done()
+
+	if imgrpkg.RPCInterruptTypeUnmount == rpcInterrupt.RPCInterruptType {
+		globals.Lock()
+		inodeNumbers = make([]uint64, 0, len(globals.inodeMap))
+		for inodeNumber = range globals.inodeMap {
+			inodeNumbers = append(inodeNumbers, inodeNumber)
+		}
+		globals.Unlock()
+	} else {
+		inodeNumbers = []uint64{rpcInterrupt.InodeNumber}
+	}
+
+	for _, inodeNumber = range inodeNumbers {
+		globals.Lock()
+		_, ok = globals.inodeMap[inodeNumber]
+		globals.Unlock()
+
+		if !ok {
+			// Lease must have already been released
+
+			continue
+		}
+
+		inode = lockInode(inodeNumber)
+
+		if imgrpkg.RPCInterruptTypeDemote == rpcInterrupt.RPCInterruptType {
+			err = inode.demoteLease()
+		} else {
+			err = inode.releaseLease()
+		}
+		if nil != err {
+			logErrorf("handleRPCInterrupt(%#v) failed for InodeNumber %016X: %v", rpcInterrupt, inodeNumber, err)
+		}
+
+		inode.unlock()
+	}
+
+	if imgrpkg.RPCInterruptTypeUnmount == rpcInterrupt.RPCInterruptType {
+		logWarnf("RPCInterruptTypeUnmount received... all Leases released")
+
+		select {
+		case globals.fissionErrChan <- fmt.Errorf("RPCInterruptTypeUnmount received"):
+		default:
+		}
+	}
+}
+
+// namespaceOpStruct tracks the inodes locked (each holding an ExclusiveLease)
+// during an operation that modifies more than one inode (e.g. DoMkDir). Such
+// operations are serialized by globals.namespaceLock to avoid deadlocks among
+// themselves.
+//
+type namespaceOpStruct struct {
+	inodes []*inodeStruct
+}
+
+func beginNamespaceOp() (namespaceOp *namespaceOpStruct) {
+	globals.namespaceLock.Lock()
+
+	namespaceOp = &namespaceOpStruct{
+		inodes: make([]*inodeStruct, 0, 4),
+	}
+
+	return
+}
+
+// lockInode locks the specified inode (if not already locked by namespaceOp)
+// ensuring it holds an ExclusiveLease.
+//
+func (namespaceOp *namespaceOpStruct) lockInode(inodeNumber uint64) (inode *inodeStruct, err error) {
+	for _, inode = range namespaceOp.inodes {
+		if inode.inodeNumber == inodeNumber {
+			err = nil
+			return
+		}
+	}
+
+	inode, err = lockInodeWithLease(inodeNumber, true)
+	if nil != err {
+		return
+	}
+
+	namespaceOp.inodes = append(namespaceOp.inodes, inode)
+
+	err = nil
+	return
+}
+
+// createInode is a wrapper around createInode() that tracks the new inode.
+//
+func (namespaceOp *namespaceOpStruct) createInode(inodeType uint8, mode uint16, userID uint64, groupID uint64) (inode *inodeStruct, err error) {
+	inode, err = createInode(inodeType, mode, userID, groupID)
+	if nil != err {
+		return
+	}
+
+	namespaceOp.inodes = append(namespaceOp.inodes, inode)
+
+	err = nil
+	return
+}
+
+// flush atomically applies all modifications made to the inodes locked by
+// namespaceOp. Should that fail, all cached state of those inodes is discarded
+// (and their Leases released) such that it will subsequently be refetched.
+//
+func (namespaceOp *namespaceOpStruct) flush() (err error) {
+	err = flushInodes(namespaceOp.inodes)
+	if nil != err {
+		namespaceOp.abort()
+	}
+
+	return
+}
+
+// abort discards all cached state (including any modifications) of the inodes
+// locked by namespaceOp and releases their Leases.
+//
+func (namespaceOp *namespaceOpStruct) abort() {
+	var (
+		err   error
+		inode *inodeStruct
+	)
+
+	for _, inode = range namespaceOp.inodes {
+		inode.discardCachedState()
+
+		err = inode.releaseLease()
+		if nil != err {
+			logWarnf("inode.releaseLease() of InodeNumber %016X failed: %v", inode.inodeNumber, err)
+		}
+	}
+}
+
+// end unlocks the inodes locked by namespaceOp and allows the next operation
+// modifying more than one inode to proceed.
+//
+func (namespaceOp *namespaceOpStruct) end() {
+	var (
+		inodeIndex int
+	)
+
+	for inodeIndex = len(namespaceOp.inodes) - 1; inodeIndex >= 0; inodeIndex-- {
+		namespaceOp.inodes[inodeIndex].unlock()
+	}
+
+	globals.namespaceLock.Unlock()
+}
+
+// releaseAllLeases flushes any pending modifications and releases the Leases
+// of all inodes in globals.inodeMap.
+//
+func releaseAllLeases() (err error) {
+	var (
+		inode        *inodeStruct
+		inodeNumber  uint64
+		inodeNumbers []uint64
+	)
+
+	globals.Lock()
+	inodeNumbers = make([]uint64, 0, len(globals.inodeMap))
+	for inodeNumber = range globals.inodeMap {
+		inodeNumbers = append(inodeNumbers, inodeNumber)
+	}
+	globals.Unlock()
+
+	for _, inodeNumber = range inodeNumbers {
+		inode = lockInode(inodeNumber)
+
+		err = inode.releaseLease()
+
+		inode.openCount = 0
+
+		inode.unlock()
+
+		if nil != err {
+			return
+		}
+	}
+
+	err = nil
+	return
+}
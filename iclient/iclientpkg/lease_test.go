@@ -0,0 +1,105 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package iclientpkg
+
+import (
+	"encoding/json"
+	"syscall"
+	"testing"
+
+	"github.com/NVIDIA/fission"
+
+	"github.com/NVIDIA/proxyfs/ilayout"
+	"github.com/NVIDIA/proxyfs/imgr/imgrpkg"
+)
+
+func testInterrupt(t *testing.T, rpcInterruptType imgrpkg.RPCInterruptType, inodeNumber uint64) {
+	var (
+		err              error
+		rpcInterruptJSON []byte
+	)
+
+	rpcInterruptJSON, err = json.Marshal(&imgrpkg.RPCInterrupt{
+		RPCInterruptType: rpcInterruptType,
+		InodeNumber:      inodeNumber,
+	})
+	if nil != err {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+
+	globals.Interrupt(rpcInterruptJSON)
+
+	globals.interruptWG.Wait()
+}
+
+func TestRPCInterrupt(t *testing.T) {
+	var (
+		createOut *fission.CreateOut
+		errno     syscall.Errno
+		inode     *inodeStruct
+		ok        bool
+		readOut   *fission.ReadOut
+	)
+
+	testSetup(t)
+
+	createOut, errno = globals.DoCreate(&fission.InHeader{NodeID: ilayout.RootDirInodeNumber}, &fission.CreateIn{Mode: syscall.S_IFREG | 0o644, Name: []byte("file")})
+	if 0 != errno {
+		t.Fatalf("DoCreate() returned errno: %v", errno)
+	}
+
+	_, errno = globals.DoWrite(&fission.InHeader{NodeID: createOut.NodeID}, &fission.WriteIn{FH: createOut.FH, Offset: 0, Data: []byte("data")})
+	if 0 != errno {
+		t.Fatalf("DoWrite() returned errno: %v", errno)
+	}
+
+	// A Demote should flush the pending write but retain cached state
+
+	testInterrupt(t, imgrpkg.RPCInterruptTypeDemote, createOut.NodeID)
+
+	inode = lockInode(createOut.NodeID)
+	if (inodeLeaseStateSharedGranted != inode.leaseState) || inode.dirty || (nil == inode.inodeHeadV1) {
+		t.Fatalf("Demote RPCInterrupt left inode in unexpected state")
+	}
+	inode.unlock()
+
+	// A Release should discard cached state (but inode remains in globals.inodeMap as it is open)
+
+	testInterrupt(t, imgrpkg.RPCInterruptTypeRelease, createOut.NodeID)
+
+	inode = lockInode(createOut.NodeID)
+	if (inodeLeaseStateNone != inode.leaseState) || (nil != inode.inodeHeadV1) {
+		t.Fatalf("Release RPCInterrupt left inode in unexpected state")
+	}
+	inode.unlock()
+
+	readOut, errno = globals.DoRead(&fission.InHeader{NodeID: createOut.NodeID}, &fission.ReadIn{FH: createOut.FH, Offset: 0, Size: 100})
+	if (0 != errno) || ("data" != string(readOut.Data)) {
+		t.Fatalf("DoRead() returned unexpected errno: %v or ReadOut: %#v", errno, readOut)
+	}
+
+	errno = globals.DoRelease(&fission.InHeader{NodeID: createOut.NodeID}, &fission.ReleaseIn{FH: createOut.FH})
+	if 0 != errno {
+		t.Fatalf("DoRelease() returned errno: %v", errno)
+	}
+
+	// An Unmount should release all Leases and notify via fissionErrChan
+
+	testInterrupt(t, imgrpkg.RPCInterruptTypeUnmount, 0)
+
+	globals.Lock()
+	ok = (0 == len(globals.inodeMap))
+	globals.Unlock()
+	if !ok {
+		t.Fatalf("Unmount RPCInterrupt should have emptied globals.inodeMap")
+	}
+
+	select {
+	case <-testGlobals.fissionErrChan:
+	default:
+		t.Fatalf("Unmount RPCInterrupt should have sent to fissionErrChan")
+	}
+
+	testTeardown(t)
+}
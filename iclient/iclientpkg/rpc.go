@@ -0,0 +1,300 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package iclientpkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/imgr/imgrpkg"
+	"github.com/NVIDIA/proxyfs/retryrpc"
+)
+
+func startRetryRPCClient() (err error) {
+	var (
+		mountRequest         *imgrpkg.MountRequestStruct
+		mountResponse        *imgrpkg.MountResponseStruct
+		retryrpcClientConfig *retryrpc.ClientConfig
+		startTime            time.Time
+	)
+
+	err = updateAuthTokenAndStorageURL()
+	if nil != err {
+		return
+	}
+
+	retryrpcClientConfig = &retryrpc.ClientConfig{
+		DNSOrIPAddr:              globals.config.RetryRPCPublicIPAddr,
+		Port:                     int(globals.config.RetryRPCPort),
+		RootCAx509CertificatePEM: globals.retryRPCCACertPEM,
+		Callbacks:                &globals,
+		DeadlineIO:               globals.config.RetryRPCDeadlineIO,
+		KeepAlivePeriod:          globals.config.RetryRPCKeepAlivePeriod,
+	}
+
+	globals.retryRPCClient, err = retryrpc.NewClient(retryrpcClientConfig)
+	if nil != err {
+		err = fmt.Errorf("retryrpc.NewClient() failed: %v", err)
+		return
+	}
+
+	globals.swiftAuthLock.RLock()
+
+	mountRequest = &imgrpkg.MountRequestStruct{
+		VolumeName: globals.config.VolumeName,
+		AuthToken:  globals.swiftAuthToken,
+	}
+
+	globals.swiftAuthLock.RUnlock()
+
+	mountResponse = &imgrpkg.MountResponseStruct{}
+
+	startTime = time.Now()
+
+	err = globals.retryRPCClient.Send("Mount", mountRequest, mountResponse)
+
+	globals.stats.MountUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+
+	if nil != err {
+		globals.retryRPCClient.Close()
+		globals.retryRPCClient = nil
+		err = fmt.Errorf("Mount(VolumeName: \"%s\") failed: %v", globals.config.VolumeName, err)
+		return
+	}
+
+	globals.Lock()
+	globals.mountID = mountResponse.MountID
+	globals.Unlock()
+
+	err = nil
+	return
+}
+
+func stopRetryRPCClient() (err error) {
+	var (
+		startTime       time.Time
+		unmountRequest  *imgrpkg.UnmountRequestStruct
+		unmountResponse *imgrpkg.UnmountResponseStruct
+	)
+
+	if nil == globals.retryRPCClient {
+		err = nil
+		return
+	}
+
+	unmountRequest = &imgrpkg.UnmountRequestStruct{
+		MountID: globals.mountID,
+	}
+
+	unmountResponse = &imgrpkg.UnmountResponseStruct{}
+
+	startTime = time.Now()
+
+	err = globals.retryRPCClient.Send("Unmount", unmountRequest, unmountResponse)
+
+	globals.stats.UnmountUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+
+	if nil != err {
+		logWarnf("Unmount(MountID: \"%s\") failed: %v", globals.mountID, err)
+	}
+
+	globals.interruptWG.Wait()
+
+	globals.retryRPCClient.Close()
+
+	globals.Lock()
+	globals.retryRPCClient = nil
+	globals.mountID = ""
+	globals.Unlock()
+
+	err = nil
+	return
+}
+
+// updateAuthTokenAndStorageURL obtains a fresh AuthToken (and StorageURL) via the
+// configured Auth PlugIn. If the volume is already mounted, imgrpkg is informed
+// of the new AuthToken via a RenewMount request.
+//
+func updateAuthTokenAndStorageURL() (err error) {
+	var (
+		authToken          string
+		mountID            string
+		renewMountRequest  *imgrpkg.RenewMountRequestStruct
+		renewMountResponse *imgrpkg.RenewMountResponseStruct
+		startTime          time.Time
+		storageURL         string
+	)
+
+	authToken, storageURL, err = performAuth(globals.config.AuthPlugInPath, globals.authPlugInInput)
+	if nil != err {
+		err = fmt.Errorf("performAuth(\"%s\",) failed: %v", globals.config.AuthPlugInPath, err)
+		return
+	}
+
+	globals.swiftAuthLock.Lock()
+	globals.swiftAuthToken = authToken
+	globals.swiftStorageURL = storageURL
+	globals.swiftAuthLock.Unlock()
+
+	mountID = fetchMountID()
+
+	if "" == mountID {
+		err = nil
+		return
+	}
+
+	renewMountRequest = &imgrpkg.RenewMountRequestStruct{
+		MountID:   mountID,
+		AuthToken: authToken,
+	}
+
+	renewMountResponse = &imgrpkg.RenewMountResponseStruct{}
+
+	startTime = time.Now()
+
+	err = globals.retryRPCClient.Send("RenewMount", renewMountRequest, renewMountResponse)
+
+	globals.stats.RenewMountUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+
+	if nil != err {
+		err = fmt.Errorf("RenewMount(MountID: \"%s\") failed: %v", mountID, err)
+	}
+
+	return
+}
+
+func fetchMountID() (mountID string) {
+	globals.Lock()
+	mountID = globals.mountID
+	globals.Unlock()
+	return
+}
+
+// rpcSend issues the specified RPC. Should imgrpkg reject the current AuthToken,
+// a fresh one is obtained (and supplied via RenewMount) before retrying the RPC.
+//
+func rpcSend(method string, request interface{}, reply interface{}) (err error) {
+	err = globals.retryRPCClient.Send(method, request, reply)
+	if (nil != err) && strings.HasPrefix(err.Error(), imgrpkg.EAuthTokenRejected) {
+		err = updateAuthTokenAndStorageURL()
+		if nil != err {
+			return
+		}
+
+		err = globals.retryRPCClient.Send(method, request, reply)
+	}
+
+	return
+}
+
+// fetchNonce returns a value that will never be returned again. Nonces are used
+// for both ObjectNumbers and InodeNumbers.
+//
+func fetchNonce() (nonce uint64, err error) {
+	var (
+		fetchNonceRangeRequest  *imgrpkg.FetchNonceRangeRequestStruct
+		fetchNonceRangeResponse *imgrpkg.FetchNonceRangeResponseStruct
+		startTime               time.Time
+	)
+
+	globals.nonceLock.Lock()
+
+	if 0 == globals.numNoncesReserved {
+		fetchNonceRangeRequest = &imgrpkg.FetchNonceRangeRequestStruct{
+			MountID: fetchMountID(),
+		}
+
+		fetchNonceRangeResponse = &imgrpkg.FetchNonceRangeResponseStruct{}
+
+		startTime = time.Now()
+
+		err = rpcSend("FetchNonceRange", fetchNonceRangeRequest, fetchNonceRangeResponse)
+
+		globals.stats.FetchNonceRangeUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+
+		if nil != err {
+			globals.nonceLock.Unlock()
+			return
+		}
+		if 0 == fetchNonceRangeResponse.NumNoncesFetched {
+			globals.nonceLock.Unlock()
+			err = fmt.Errorf("FetchNonceRange() returned NumNoncesFetched == 0")
+			return
+		}
+
+		globals.nextNonce = fetchNonceRangeResponse.NextNonce
+		globals.numNoncesReserved = fetchNonceRangeResponse.NumNoncesFetched
+	}
+
+	nonce = globals.nextNonce
+
+	globals.nextNonce++
+	globals.numNoncesReserved--
+
+	globals.nonceLock.Unlock()
+
+	err = nil
+	return
+}
+
+// Interrupt implements retryrpc.ClientCallbacks. Each RPCInterrupt is handled
+// in a separate goroutine so as to not block the retryrpc.Client.
+//
+func (dummy *globalsStruct) Interrupt(payload []byte) {
+	var (
+		err          error
+		rpcInterrupt *imgrpkg.RPCInterrupt
+	)
+
+	rpcInterrupt = &imgrpkg.RPCInterrupt{}
+
+	err = json.Unmarshal(payload, rpcInterrupt)
+	if nil != err {
+		logErrorf("json.Unmarshal() of RPCInterrupt payload failed: %v", err)
+		return
+	}
+
+	switch rpcInterrupt.RPCInterruptType {
+	case imgrpkg.RPCInterruptTypeUnmount:
+		globals.stats.UnmountInterrupts.Increment()
+	case imgrpkg.RPCInterruptTypeDemote:
+		globals.stats.DemoteLeaseInterrupts.Increment()
+	case imgrpkg.RPCInterruptTypeRelease:
+		globals.stats.RevokeLeaseInterrupts.Increment()
+	default:
+		logErrorf("RPCInterrupt.RPCInterruptType (%v) unknown", rpcInterrupt.RPCInterruptType)
+		return
+	}
+
+	globals.interruptWG.Add(1)
+
+	go handleRPCInterrupt(rpcInterrupt)
+}
+
+// flushVolume requests that imgrpkg persist the results of all prior
+// PutInodeTableEntries requests.
+//
+func flushVolume() (err error) {
+	var (
+		flushRequest  *imgrpkg.FlushRequestStruct
+		flushResponse *imgrpkg.FlushResponseStruct
+		startTime     time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.FlushUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	flushRequest = &imgrpkg.FlushRequestStruct{
+		MountID: fetchMountID(),
+	}
+
+	flushResponse = &imgrpkg.FlushResponseStruct{}
+
+	err = rpcSend("Flush", flushRequest, flushResponse)
+
+	return
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package iclientpkg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/ilayout"
+)
+
+func startSwiftClient() (err error) {
+	var (
+		customTransport  *http.Transport
+		defaultTransport *http.Transport
+		ok               bool
+	)
+
+	defaultTransport, ok = http.DefaultTransport.(*http.Transport)
+	if !ok {
+		err = fmt.Errorf("http.DefaultTransport.(*http.Transport) returned !ok\n")
+		return
+	}
+
+	customTransport = &http.Transport{ // Up-to-date as of Golang 1.11
+		Proxy:                  defaultTransport.Proxy,
+		DialContext:            defaultTransport.DialContext,
+		Dial:                   defaultTransport.Dial,
+		DialTLS:                defaultTransport.DialTLS,
+		TLSClientConfig:        defaultTransport.TLSClientConfig,
+		TLSHandshakeTimeout:    globals.config.SwiftTimeout,
+		DisableKeepAlives:      false,
+		DisableCompression:     defaultTransport.DisableCompression,
+		MaxIdleConns:           int(globals.config.SwiftConnectionPoolSize),
+		MaxIdleConnsPerHost:    int(globals.config.SwiftConnectionPoolSize),
+		MaxConnsPerHost:        int(globals.config.SwiftConnectionPoolSize),
+		IdleConnTimeout:        globals.config.SwiftTimeout,
+		ResponseHeaderTimeout:  globals.config.SwiftTimeout,
+		ExpectContinueTimeout:  globals.config.SwiftTimeout,
+		TLSNextProto:           defaultTransport.TLSNextProto,
+		ProxyConnectHeader:     defaultTransport.ProxyConnectHeader,
+		MaxResponseHeaderBytes: defaultTransport.MaxResponseHeaderBytes,
+	}
+
+	globals.httpClient = &http.Client{
+		Transport: customTransport,
+		Timeout:   globals.config.SwiftTimeout,
+	}
+
+	err = nil
+	return
+}
+
+func stopSwiftClient() (err error) {
+	globals.httpClient = nil
+
+	err = nil
+	return
+}
+
+func swiftObjectOnce(method string, objectNumber uint64, rangeHeaderValue string, body []byte) (buf []byte, authOK bool, err error) {
+	var (
+		authToken    string
+		httpRequest  *http.Request
+		httpResponse *http.Response
+		objectURL    string
+		requestBody  io.Reader
+	)
+
+	globals.swiftAuthLock.RLock()
+	authToken = globals.swiftAuthToken
+	objectURL = globals.swiftStorageURL + "/" + ilayout.GetObjectNameAsString(objectNumber)
+	globals.swiftAuthLock.RUnlock()
+
+	if nil != body {
+		requestBody = bytes.NewReader(body)
+	}
+
+	httpRequest, err = http.NewRequest(method, objectURL, requestBody)
+	if nil != err {
+		return
+	}
+
+	if authToken != "" {
+		httpRequest.Header["X-Auth-Token"] = []string{authToken}
+	}
+	if rangeHeaderValue != "" {
+		httpRequest.Header["Range"] = []string{rangeHeaderValue}
+	}
+
+	httpResponse, err = globals.httpClient.Do(httpRequest)
+	if nil != err {
+		err = fmt.Errorf("globals.httpClient.Do(%s %s) failed: %v", method, objectURL, err)
+		return
+	}
+
+	buf, err = ioutil.ReadAll(httpResponse.Body)
+	if nil != err {
+		err = fmt.Errorf("ioutil.ReadAll(httpResponse.Body) failed: %v", err)
+		return
+	}
+	err = httpResponse.Body.Close()
+	if nil != err {
+		err = fmt.Errorf("httpResponse.Body.Close() failed: %v", err)
+		return
+	}
+
+	if (200 <= httpResponse.StatusCode) && (299 >= httpResponse.StatusCode) {
+		authOK = true
+		err = nil
+	} else if http.StatusUnauthorized == httpResponse.StatusCode {
+		authOK = false // Auth failed,
+		err = nil      //   but we will still indicate the func succeeded
+	} else {
+		err = fmt.Errorf("httpResponse.Status: %s", httpResponse.Status)
+	}
+
+	return
+}
+
+// swiftObject performs the specified Swift Object method retrying both failures
+// and authorization rejections (after first obtaining a fresh AuthToken).
+//
+func swiftObject(method string, objectNumber uint64, rangeHeaderValue string, body []byte) (buf []byte, err error) {
+	var (
+		authOK     bool
+		retryDelay time.Duration
+		retryIndex uint32
+	)
+
+	retryIndex = 0
+
+	for {
+		buf, authOK, err = swiftObjectOnce(method, objectNumber, rangeHeaderValue, body)
+		if nil == err {
+			if authOK {
+				return
+			}
+
+			err = updateAuthTokenAndStorageURL()
+			if nil != err {
+				return
+			}
+		}
+
+		if retryIndex >= globals.config.SwiftRetryLimit {
+			if nil == err {
+				err = fmt.Errorf("httpResponse.Status: http.StatusUnauthorized")
+			}
+			err = fmt.Errorf("globals.config.SwiftRetryLimit exceeded (last err: %v)", err)
+			return
+		}
+
+		retryDelay = globals.retryDelay[retryIndex].nominal - time.Duration(rand.Int63n(int64(globals.retryDelay[retryIndex].variance)+1))
+
+		time.Sleep(retryDelay)
+
+		retryIndex++
+	}
+}
+
+func swiftObjectGetRange(objectNumber uint64, objectOffset uint64, objectLength uint64) (buf []byte, err error) {
+	var (
+		rangeHeaderValue string
+		startTime        time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.SwiftObjectGetRangeUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	rangeHeaderValue = fmt.Sprintf("bytes=%d-%d", objectOffset, (objectOffset + objectLength - 1))
+
+	buf, err = swiftObject("GET", objectNumber, rangeHeaderValue, nil)
+	if (nil == err) && (uint64(len(buf)) != objectLength) {
+		err = fmt.Errorf("swiftObjectGetRange(0x%016X,0x%016X,0x%016X) returned only 0x%016X bytes", objectNumber, objectOffset, objectLength, len(buf))
+	}
+
+	return
+}
+
+func swiftObjectGetTail(objectNumber uint64, objectLength uint64) (buf []byte, err error) {
+	var (
+		rangeHeaderValue string
+		startTime        time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.SwiftObjectGetTailUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	rangeHeaderValue = fmt.Sprintf("bytes=-%d", objectLength)
+
+	buf, err = swiftObject("GET", objectNumber, rangeHeaderValue, nil)
+	if (nil == err) && (uint64(len(buf)) != objectLength) {
+		err = fmt.Errorf("swiftObjectGetTail(0x%016X,0x%016X) returned only 0x%016X bytes", objectNumber, objectLength, len(buf))
+	}
+
+	return
+}
+
+func swiftObjectPut(objectNumber uint64, body []byte) (err error) {
+	var (
+		startTime time.Time = time.Now()
+	)
+
+	defer func() {
+		globals.stats.SwiftObjectPutUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	_, err = swiftObject("PUT", objectNumber, "", body)
+
+	return
+}
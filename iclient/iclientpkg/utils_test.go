@@ -0,0 +1,328 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package iclientpkg
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/imgr/imgrpkg"
+	"github.com/NVIDIA/proxyfs/iswift/iswiftpkg"
+)
+
+const (
+	testIPAddr            = "127.0.0.1"
+	testRetryRPCPort      = 32357
+	testHTTPServerPort    = 15347
+	testSwiftProxyTCPPort = 8081
+	testSwiftAuthUser     = "test"
+	testSwiftAuthKey      = "test"
+	testContainer         = "testContainer"
+	testVolume            = "testVolume"
+)
+
+type testGlobalsStruct struct {
+	confMap        conf.ConfMap
+	httpServerURL  string
+	authURL        string
+	authToken      string
+	accountURL     string
+	containerURL   string
+	fissionErrChan chan error
+}
+
+var testGlobals *testGlobalsStruct
+
+// testSetup launches iswiftpkg & imgrpkg, formats and serves testVolume, and
+// then mounts it (absent FUSE) such that the fission.Callbacks may be invoked
+// directly.
+//
+func testSetup(t *testing.T) {
+	var (
+		confStrings                []string
+		err                        error
+		postRequestBody            string
+		putAccountRequestHeaders   http.Header
+		putContainerRequestHeaders http.Header
+		putRequestBody             string
+	)
+
+	testGlobals = &testGlobalsStruct{
+		httpServerURL:  fmt.Sprintf("http://%s:%d", testIPAddr, testHTTPServerPort),
+		authURL:        fmt.Sprintf("http://%s:%d/auth/v1.0", testIPAddr, testSwiftProxyTCPPort),
+		fissionErrChan: make(chan error, 1),
+	}
+
+	confStrings = []string{
+		"ICLIENT.VolumeName=" + testVolume,
+		"ICLIENT.MountPointDirPath=/dev/null",
+		"ICLIENT.FUSEAllowOther=true",
+		"ICLIENT.FUSEMaxBackground=1000",
+		"ICLIENT.FUSECongestionThreshhold=0",
+		"ICLIENT.FUSEMaxWrite=131076",
+		"ICLIENT.FUSEEntryValidDuration=250ms",
+		"ICLIENT.FUSEAttrValidDuration=250ms",
+		"ICLIENT.AuthPlugInPath=/dev/null",
+		"ICLIENT.AuthPlugInEnvName=",
+		"ICLIENT.AuthPlugInEnvValue={}",
+		"ICLIENT.SwiftTimeout=10m",
+		"ICLIENT.SwiftRetryLimit=4",
+		"ICLIENT.SwiftRetryDelay=100ms",
+		"ICLIENT.SwiftRetryDelayVariance=25",
+		"ICLIENT.SwiftRetryExpBackoff=1.4",
+		"ICLIENT.SwiftConnectionPoolSize=128",
+		"ICLIENT.RetryRPCPublicIPAddr=" + testIPAddr,
+		"ICLIENT.RetryRPCPort=" + fmt.Sprintf("%d", testRetryRPCPort),
+		"ICLIENT.RetryRPCDeadlineIO=60s",
+		"ICLIENT.RetryRPCKeepAlivePeriod=60s",
+		"ICLIENT.RetryRPCCACertFilePath=",
+		"ICLIENT.InodePayloadEvictLowLimit=100000",
+		"ICLIENT.InodePayloadEvictHighLimit=100010",
+		"ICLIENT.DirInodeMaxKeysPerBPlusTreePage=1024",
+		"ICLIENT.FileInodeMaxKeysPerBPlusTreePage=2048",
+		"ICLIENT.FileFlushTriggerSize=10485760",
+		"ICLIENT.LogFilePath=",
+		"ICLIENT.LogToConsole=true",
+		"ICLIENT.TraceEnabled=false",
+
+		"IMGR.PublicIPAddr=" + testIPAddr,
+		"IMGR.PrivateIPAddr=" + testIPAddr,
+		"IMGR.RetryRPCPort=" + fmt.Sprintf("%d", testRetryRPCPort),
+		"IMGR.HTTPServerPort=" + fmt.Sprintf("%d", testHTTPServerPort),
+
+		"IMGR.RetryRPCTTLCompleted=10m",
+		"IMGR.RetryRPCAckTrim=100ms",
+		"IMGR.RetryRPCDeadlineIO=60s",
+		"IMGR.RetryRPCKeepAlivePeriod=60s",
+
+		"IMGR.CheckPointInterval=10s",
+
+		"IMGR.AuthTokenCheckInterval=1m",
+
+		"IMGR.FetchNonceRangeToReturn=100",
+
+		"IMGR.MinLeaseDuration=250ms",
+		"IMGR.LeaseInterruptInterval=250ms",
+		"IMGR.LeaseInterruptLimit=5",
+		"IMGR.LeaseEvictLowLimit=100000",
+		"IMGR.LeaseEvictHighLimit=100010",
+
+		"IMGR.SwiftRetryDelay=100ms",
+		"IMGR.SwiftRetryExpBackoff=2",
+		"IMGR.SwiftRetryLimit=4",
+
+		"IMGR.SwiftTimeout=10m",
+		"IMGR.SwiftConnectionPoolSize=128",
+
+		"IMGR.ParallelObjectDeleteMax=10",
+
+		"IMGR.InodeTableCacheEvictLowLimit=10000",
+		"IMGR.InodeTableCacheEvictHighLimit=10010",
+
+		"IMGR.InodeTableMaxInodesPerBPlusTreePage=2048",
+		"IMGR.RootDirMaxDirEntriesPerBPlusTreePage=1024",
+
+		"IMGR.LogFilePath=",
+		"IMGR.LogToConsole=true",
+		"IMGR.TraceEnabled=false",
+
+		"ISWIFT.SwiftProxyIPAddr=" + testIPAddr,
+		"ISWIFT.SwiftProxyTCPPort=" + fmt.Sprintf("%d", testSwiftProxyTCPPort),
+
+		"ISWIFT.MaxAccountNameLength=256",
+		"ISWIFT.MaxContainerNameLength=256",
+		"ISWIFT.MaxObjectNameLength=1024",
+		"ISWIFT.AccountListingLimit=10000",
+		"ISWIFT.ContainerListingLimit=10000",
+	}
+
+	testGlobals.confMap, err = conf.MakeConfMapFromStrings(confStrings)
+	if nil != err {
+		t.Fatalf("conf.MakeConfMapFromStrings(confStrings) failed: %v", err)
+	}
+
+	err = iswiftpkg.Start(testGlobals.confMap)
+	if nil != err {
+		t.Fatalf("iswifpkg.Start(testGlobals.confMap) failed: %v", err)
+	}
+
+	err = testDoAuth()
+	if nil != err {
+		t.Fatalf("testDoAuth() failed: %v", err)
+	}
+
+	testGlobals.containerURL = testGlobals.accountURL + "/" + testContainer
+
+	putAccountRequestHeaders = make(http.Header)
+
+	putAccountRequestHeaders["X-Auth-Token"] = []string{testGlobals.authToken}
+
+	_, _, err = testDoHTTPRequest("PUT", testGlobals.accountURL, putAccountRequestHeaders, nil)
+	if nil != err {
+		t.Fatalf("testDoHTTPRequest(\"PUT\", testGlobals.accountURL, putAccountRequestHeaders) failed: %v", err)
+	}
+
+	putContainerRequestHeaders = make(http.Header)
+
+	putContainerRequestHeaders["X-Auth-Token"] = []string{testGlobals.authToken}
+
+	_, _, err = testDoHTTPRequest("PUT", testGlobals.containerURL, putContainerRequestHeaders, nil)
+	if nil != err {
+		t.Fatalf("testDoHTTPRequest(\"PUT\", testGlobals.storageURL, putContainerRequestHeaders) failed: %v", err)
+	}
+
+	err = imgrpkg.Start(testGlobals.confMap)
+	if nil != err {
+		t.Fatalf("imgrpkg.Start(testGlobals.confMap) failed: %v", err)
+	}
+
+	postRequestBody = fmt.Sprintf("{\"StorageURL\":\"%s\",\"AuthToken\":\"%s\"}", testGlobals.containerURL, testGlobals.authToken)
+
+	_, _, err = testDoHTTPRequest("POST", testGlobals.httpServerURL+"/volume", nil, strings.NewReader(postRequestBody))
+	if nil != err {
+		t.Fatalf("testDoHTTPRequest(\"POST\", testGlobals.httpServerURL+\"/volume\", nil, strings.NewReader(postRequestBody)) failed: %v", err)
+	}
+
+	putRequestBody = fmt.Sprintf("{\"StorageURL\":\"%s\"}", testGlobals.containerURL)
+
+	_, _, err = testDoHTTPRequest("PUT", testGlobals.httpServerURL+"/volume/"+testVolume, nil, strings.NewReader(putRequestBody))
+	if nil != err {
+		t.Fatalf("testDoHTTPRequest(\"PUT\", testGlobals.httpServerURL+\"/volume\"+testVolume, nil, strings.NewReader(putRequestBody)) failed: %v", err)
+	}
+
+	performAuth = testPerformAuth
+
+	err = initializeGlobals(testGlobals.confMap, testGlobals.fissionErrChan)
+	if nil != err {
+		t.Fatalf("initializeGlobals() failed: %v", err)
+	}
+
+	err = startSwiftClient()
+	if nil != err {
+		t.Fatalf("startSwiftClient() failed: %v", err)
+	}
+
+	err = startRetryRPCClient()
+	if nil != err {
+		t.Fatalf("startRetryRPCClient() failed: %v", err)
+	}
+}
+
+func testTeardown(t *testing.T) {
+	var (
+		err error
+	)
+
+	err = releaseAllLeases()
+	if nil != err {
+		t.Fatalf("releaseAllLeases() failed: %v", err)
+	}
+
+	err = stopRetryRPCClient()
+	if nil != err {
+		t.Fatalf("stopRetryRPCClient() failed: %v", err)
+	}
+
+	err = stopSwiftClient()
+	if nil != err {
+		t.Fatalf("stopSwiftClient() failed: %v", err)
+	}
+
+	err = uninitializeGlobals()
+	if nil != err {
+		t.Fatalf("uninitializeGlobals() failed: %v", err)
+	}
+
+	err = imgrpkg.Stop()
+	if nil != err {
+		t.Fatalf("imgrpkg.Stop() failed: %v", err)
+	}
+
+	err = iswiftpkg.Stop()
+	if nil != err {
+		t.Fatalf("iswiftpkg.Stop() failed: %v", err)
+	}
+
+	testGlobals = nil
+}
+
+func testPerformAuth(plugInPath string, plugInEnvValue string) (authToken string, storageURL string, err error) {
+	authToken = testGlobals.authToken
+	storageURL = testGlobals.containerURL
+	err = nil
+	return
+}
+
+func testDoHTTPRequest(method string, url string, requestHeaders http.Header, requestBody io.Reader) (responseHeaders http.Header, responseBody []byte, err error) {
+	var (
+		headerKey    string
+		headerValues []string
+		httpRequest  *http.Request
+		httpResponse *http.Response
+	)
+
+	httpRequest, err = http.NewRequest(method, url, requestBody)
+	if nil != err {
+		err = fmt.Errorf("http.NewRequest(\"%s\", \"%s\", nil) failed: %v", method, url, err)
+		return
+	}
+
+	if nil != requestHeaders {
+		for headerKey, headerValues = range requestHeaders {
+			httpRequest.Header[headerKey] = headerValues
+		}
+	}
+
+	httpResponse, err = http.DefaultClient.Do(httpRequest)
+	if nil != err {
+		err = fmt.Errorf("http.Do(httpRequest) failed: %v", err)
+		return
+	}
+
+	responseBody, err = ioutil.ReadAll(httpResponse.Body)
+	if nil != err {
+		err = fmt.Errorf("ioutil.ReadAll(httpResponse.Body) failed: %v", err)
+		return
+	}
+	err = httpResponse.Body.Close()
+	if nil != err {
+		err = fmt.Errorf("httpResponse.Body.Close() failed: %v", err)
+		return
+	}
+
+	if (200 > httpResponse.StatusCode) || (299 < httpResponse.StatusCode) {
+		err = fmt.Errorf("httpResponse.StatusCode unexpected: %s", httpResponse.Status)
+		return
+	}
+
+	responseHeaders = httpResponse.Header
+
+	err = nil
+	return
+}
+
+func testDoAuth() (err error) {
+	var (
+		authRequestHeaders  http.Header
+		authResponseHeaders http.Header
+	)
+
+	authRequestHeaders = make(http.Header)
+
+	authRequestHeaders["X-Auth-User"] = []string{testSwiftAuthUser}
+	authRequestHeaders["X-Auth-Key"] = []string{testSwiftAuthKey}
+
+	authResponseHeaders, _, err = testDoHTTPRequest("GET", testGlobals.authURL, authRequestHeaders, nil)
+	if nil == err {
+		testGlobals.authToken = authResponseHeaders.Get("X-Auth-Token")
+		testGlobals.accountURL = authResponseHeaders.Get("X-Storage-Url")
+	}
+
+	return
+}
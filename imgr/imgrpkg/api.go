@@ -28,6 +28,8 @@
 //  RetryRPCCertFilePath:                              # If both RetryRPC{Cert|Key}FilePath are missing or empty,
 //  RetryRPCKeyFilePath:                               #   non-TLS RetryRPC will be selected; otherwise TLS will be used
 //
+//  RetryRPCJournalPath:                               # If missing or empty, completed RetryRPCs are not journaled
+//
 //  CheckPointInterval:                   10s
 //
 //  AuthTokenCheckInterval:               1m
@@ -47,6 +49,11 @@
 //  SwiftTimeout:                         10m
 //  SwiftConnectionPoolSize:              128
 //
+//  S3Endpoint:                                        # If missing or empty, StorageURLs of the form
+//  S3Region:                                          #   s3://<bucket>[/<prefix>] are not supported
+//  S3AccessKeyID:
+//  S3SecretAccessKey:
+//
 //  InodeTableCacheEvictLowLimit:         10000
 //  InodeTableCacheEvictHighLimit:        10010
 //
@@ -67,6 +74,19 @@
 // files, the retryrpc package will be configured to use TLS. In any event,
 // the RPCs will be available via <PublicIPAddr>:<RetryRPCPort>.
 //
+// The RetryRPCJournalPath key is also optional. If provided (and non-empty),
+// the replies to completed RPCs are journaled to that file (bounded by
+// RetryRPCTTLCompleted) so that an RPC retransmitted to a restarted imgr
+// is not performed a second time.
+//
+// The S3* keys are also optional. If S3Endpoint (e.g. http://127.0.0.1:9000)
+// is provided, a volume may be held in a bucket of that S3-compatible object
+// store by specifying a StorageURL of the form s3://<bucket>[/<prefix>] in
+// place of a Swift Container URL. Such volumes are accessed using the
+// S3AccessKeyID and S3SecretAccessKey credentials (signed for S3Region,
+// if provided) rather than any AuthToken. Note that clients (e.g. iclient)
+// still require a Swift StorageURL to access a volume's objects directly.
+//
 // The RESTful API is provided by an embedded HTTP Server
 // (at URL http://<PrivateIPAddr>:<HTTPServerPort>) responsing to the following:
 //
@@ -80,6 +100,11 @@
 // This will return a JSON document that matches the conf.ConfMap used to
 // launch this package.
 //
+//  GET /metrics
+//
+// This will return the bucketstats statistics in the Prometheus text
+// exposition format.
+//
 //  GET /stats
 //
 // This will return a raw bucketstats dump.
@@ -176,13 +201,14 @@ func LogInfof(format string, args ...interface{}) {
 // E* specifies the prefix of an error string returned by any RetryRPC API
 //
 const (
-	EAuthTokenRejected  = "EAuthTokenRejected:"
-	ELeaseRequestDenied = "ELeaseRequestDenied:"
-	EMissingLease       = "EMissingLease:"
-	EVolumeBeingDeleted = "EVolumeBeingDeleted:"
-	EUnknownInodeNumber = "EUnknownInodeNumber:"
-	EUnknownMountID     = "EUnknownMountID:"
-	EUnknownVolumeName  = "EUnknownVolumeName:"
+	EAuthTokenRejected      = "EAuthTokenRejected:"
+	EBadOpenCountAdjustment = "EBadOpenCountAdjustment:"
+	ELeaseRequestDenied     = "ELeaseRequestDenied:"
+	EMissingLease           = "EMissingLease:"
+	EVolumeBeingDeleted     = "EVolumeBeingDeleted:"
+	EUnknownInodeNumber     = "EUnknownInodeNumber:"
+	EUnknownMountID         = "EUnknownMountID:"
+	EUnknownVolumeName      = "EUnknownVolumeName:"
 
 	ETODO = "ETODO:"
 )
@@ -241,7 +267,10 @@ type UnmountRequestStruct struct {
 type UnmountResponseStruct struct{}
 
 // Unmount requests that the given MountID be released (and implicitly releases
-// any Leases held by the MountID).
+// any Leases held by the MountID). Any OpenCount held by the MountID for any Inode
+// is also released.
+//
+// Possible errors: EUnknownMountID
 //
 func (dummy *RetryRPCServerStruct) Unmount(unmountRequest *UnmountRequestStruct, unmountResponse *UnmountResponseStruct) (err error) {
 	return unmount(unmountRequest, unmountResponse)
@@ -346,6 +375,8 @@ type DeleteInodeTableEntryResponseStruct struct{}
 // unless/until the OpenCount for the Inode drops to zero, the Inode will
 // still exist.
 //
+// Possible errors: EAuthTokenRejected EMissingLease EUnknownInodeNumber EUnknownMountID
+//
 func (dummy *RetryRPCServerStruct) DeleteInodeTableEntry(deleteInodeTableEntryRequest *DeleteInodeTableEntryRequestStruct, deleteInodeTableEntryResponse *DeleteInodeTableEntryResponseStruct) (err error) {
 	return deleteInodeTableEntry(deleteInodeTableEntryRequest, deleteInodeTableEntryResponse)
 }
@@ -369,7 +400,10 @@ type AdjustInodeTableEntryOpenCountResponseStruct struct {
 // adjusted. A (Shared or Exclusive) Lease must be granted to the MountID. If
 // the adjustment results in an OpenCount of zero and the Inode has been marked
 // for deletion by a prior call to DeleteInodeTableEntry, the Inode will be
-// deleted.
+// deleted. The resultant OpenCount for both this MountID and all MountIDs are
+// returned.
+//
+// Possible errors: EAuthTokenRejected EBadOpenCountAdjustment EMissingLease EUnknownInodeNumber EUnknownMountID
 //
 func (dummy *RetryRPCServerStruct) AdjustInodeTableEntryOpenCount(adjustInodeTableEntryOpenCountRequest *AdjustInodeTableEntryOpenCountRequestStruct, adjustInodeTableEntryOpenCountResponse *AdjustInodeTableEntryOpenCountResponseStruct) (err error) {
 	return adjustInodeTableEntryOpenCount(adjustInodeTableEntryOpenCountRequest, adjustInodeTableEntryOpenCountResponse)
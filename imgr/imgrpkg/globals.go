@@ -18,6 +18,7 @@ import (
 	"github.com/NVIDIA/proxyfs/conf"
 	"github.com/NVIDIA/proxyfs/ilayout"
 	"github.com/NVIDIA/proxyfs/retryrpc"
+	"github.com/NVIDIA/proxyfs/s3client"
 	"github.com/NVIDIA/proxyfs/utils"
 )
 
@@ -35,6 +36,8 @@ type configStruct struct {
 	RetryRPCCertFilePath string
 	RetryRPCKeyFilePath  string
 
+	RetryRPCJournalPath string // == "" means completed RetryRPCs do not survive an imgr restart
+
 	CheckPointInterval time.Duration
 
 	AuthTokenCheckInterval time.Duration
@@ -54,6 +57,11 @@ type configStruct struct {
 	SwiftTimeout            time.Duration
 	SwiftConnectionPoolSize uint32
 
+	S3Endpoint        string // == "" means StorageURLs of the form "s3://<bucket>[/<prefix>]" are not supported
+	S3Region          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+
 	ParallelObjectDeleteMax uint32
 
 	InodeTableCacheEvictLowLimit  uint64
@@ -86,6 +94,7 @@ type chunkedPutContextStruct struct {
 type statsStruct struct {
 	DeleteVolumeUsecs   bucketstats.BucketLog2Round // DELETE /volume/<VolumeName>
 	GetConfigUsecs      bucketstats.BucketLog2Round // GET /config
+	GetMetricsUsecs     bucketstats.BucketLog2Round // GET /metrics
 	GetStatsUsecs       bucketstats.BucketLog2Round // GET /stats
 	GetVersionUsecs     bucketstats.BucketLog2Round // GET /version
 	GetVolumeInodeUsecs bucketstats.BucketLog2Round // GET /volume/<VolumeName>/inode/<InodeNumber>
@@ -211,12 +220,19 @@ type mountStruct struct {
 	mountID                string                         //
 	retryRPCClientID       uint64                         //
 	acceptingLeaseRequests bool                           //
+	unmounting             bool                           // if true, an unmount() is removing this mountStruct
 	leaseRequestMap        map[uint64]*leaseRequestStruct // key == leaseRequestStruct.inodeLease.inodeNumber
 	leasesExpired          bool                           // if true, leases are being expired prior to auto-deletion of mountStruct
 	authTokenExpired       bool                           // if true, authToken has been rejected... needing a renewMount() to update
 	authToken              string                         //
 	lastAuthTime           time.Time                      // used to periodically check TTL of authToken
 	listElement            *list.Element                  // LRU element on either volumeStruct.{healthy|leasesExpired|authTokenExpired}MountList
+	inodeOpenMap           map[uint64]uint64              // key == inodeNumber; value == open count for this mountStruct for this inodeNumber
+}
+
+type inodeOpenMapElementStruct struct {
+	numOpens        uint64 // sum of each mountStruct's open count for this inodeNumber
+	markedForDelete bool   // if true, remove inodeNumber from volumeStruct.inodeTable once numOpens == 0
 }
 
 type inodeTableLayoutElementStruct struct {
@@ -241,11 +257,13 @@ type volumeStruct struct {
 	numNoncesReserved             uint64                                    // number of nonce's reserved for checkpointing
 	activeDeleteObjectNumberList  *list.List                                // list of objectNumber's to be deleted since last CheckPoint
 	pendingDeleteObjectNumberList *list.List                                // list of objectNumber's pending deletion after next CheckPoint
+	removedInodeList              *list.List                                // list of ilayout.InodeTableEntryValueV1Struct's of removed Inodes whose objects are yet to be added to pendingDeleteObjectNumberList
 	checkPointControlChan         chan chan error                           // send chan error to chan to request a CheckPoint; close it to terminate checkPointDaemon()
 	checkPointControlWG           sync.WaitGroup                            // checkPointDeamon() indicates it is done by calling .Done() on this WG
 	checkPointPutObjectNumber     uint64                                    //
 	checkPointPutObjectBuffer     *bytes.Buffer                             // if nil, no CheckPoint data to PUT has yet accumulated
 	inodeLeaseMap                 map[uint64]*inodeLeaseStruct              // key == inodeLeaseStruct.inodeNumber
+	inodeOpenMap                  map[uint64]*inodeOpenMapElementStruct     // key == inodeNumber
 	leaseHandlerWG                sync.WaitGroup                            // .Add(1) each inodeLease insertion into inodeLeaseMap
 	//                                                                         .Done() each inodeLease after it is removed from inodeLeaseMap
 }
@@ -259,6 +277,7 @@ type globalsStruct struct {
 	volumeMap       sortedmap.LLRBTree       // key == volumeStruct.name; value == *volumeStruct
 	mountMap        map[string]*mountStruct  // key == mountStruct.mountID
 	httpClient      *http.Client             //
+	s3Client        *s3client.Client         // == nil if config.S3Endpoint == ""
 	retryrpcServer  *retryrpc.Server         //
 	httpServer      *http.Server             //
 	httpServerWG    sync.WaitGroup           //
@@ -364,6 +383,11 @@ func initializeGlobals(confMap conf.ConfMap) (err error) {
 		}
 	}
 
+	globals.config.RetryRPCJournalPath, err = confMap.FetchOptionValueString("IMGR", "RetryRPCJournalPath")
+	if nil != err {
+		globals.config.RetryRPCJournalPath = "" // If not present, default to not journaling completed RetryRPCs
+	}
+
 	globals.config.CheckPointInterval, err = confMap.FetchOptionValueDuration("IMGR", "CheckPointInterval")
 	if nil != err {
 		logFatal(err)
@@ -422,6 +446,25 @@ func initializeGlobals(confMap conf.ConfMap) (err error) {
 		logFatal(err)
 	}
 
+	globals.config.S3Endpoint, err = confMap.FetchOptionValueString("IMGR", "S3Endpoint")
+	if nil != err {
+		globals.config.S3Endpoint = "" // If not present, default to not supporting S3 StorageURLs
+	}
+	if "" != globals.config.S3Endpoint {
+		globals.config.S3Region, err = confMap.FetchOptionValueString("IMGR", "S3Region")
+		if nil != err {
+			globals.config.S3Region = "" // If not present, default to s3client's default
+		}
+		globals.config.S3AccessKeyID, err = confMap.FetchOptionValueString("IMGR", "S3AccessKeyID")
+		if nil != err {
+			logFatal(err)
+		}
+		globals.config.S3SecretAccessKey, err = confMap.FetchOptionValueString("IMGR", "S3SecretAccessKey")
+		if nil != err {
+			logFatal(err)
+		}
+	}
+
 	globals.config.ParallelObjectDeleteMax, err = confMap.FetchOptionValueUint32("IMGR", "ParallelObjectDeleteMax")
 	if nil != err {
 		logFatal(err)
@@ -489,6 +532,8 @@ func uninitializeGlobals() (err error) {
 	globals.config.RetryRPCCertFilePath = ""
 	globals.config.RetryRPCKeyFilePath = ""
 
+	globals.config.RetryRPCJournalPath = ""
+
 	globals.config.CheckPointInterval = time.Duration(0)
 
 	globals.config.AuthTokenCheckInterval = time.Duration(0)
@@ -508,6 +553,11 @@ func uninitializeGlobals() (err error) {
 	globals.config.SwiftTimeout = time.Duration(0)
 	globals.config.SwiftConnectionPoolSize = 0
 
+	globals.config.S3Endpoint = ""
+	globals.config.S3Region = ""
+	globals.config.S3AccessKeyID = ""
+	globals.config.S3SecretAccessKey = ""
+
 	globals.config.ParallelObjectDeleteMax = 0
 
 	globals.config.InodeTableCacheEvictLowLimit = 0
@@ -184,6 +184,8 @@ func serveHTTPGet(responseWriter http.ResponseWriter, request *http.Request, req
 		responseWriter.Header().Set("Content-Type", jsontreeDotJSContentType)
 		responseWriter.WriteHeader(http.StatusOK)
 		_, _ = responseWriter.Write([]byte(jsontreeDotJSContent))
+	case "/metrics" == requestPath:
+		serveHTTPGetOfMetrics(responseWriter, request)
 	case "/open-iconic/font/css/open-iconic-bootstrap.min.css" == requestPath:
 		responseWriter.Header().Set("Content-Type", openIconicBootstrapDotCSSContentType)
 		responseWriter.WriteHeader(http.StatusOK)
@@ -268,6 +270,30 @@ func serveHTTPGetOfConfig(responseWriter http.ResponseWriter, request *http.Requ
 	}
 }
 
+func serveHTTPGetOfMetrics(responseWriter http.ResponseWriter, request *http.Request) {
+	var (
+		err             error
+		metricsAsString string
+		startTime       time.Time
+	)
+
+	startTime = time.Now()
+	defer func() {
+		globals.stats.GetMetricsUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
+	}()
+
+	metricsAsString = bucketstats.SprintPrometheusStats("*", "*")
+
+	responseWriter.Header().Set("Content-Length", fmt.Sprintf("%d", len(metricsAsString)))
+	responseWriter.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	_, err = responseWriter.Write([]byte(metricsAsString))
+	if nil != err {
+		logWarnf("responseWriter.Write([]byte(metricsAsString)) failed: %v", err)
+	}
+}
+
 func serveHTTPGetOfStats(responseWriter http.ResponseWriter, request *http.Request) {
 	var (
 		err           error
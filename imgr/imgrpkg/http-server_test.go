@@ -36,6 +36,14 @@ func TestHTTPServer(t *testing.T) {
 		t.Fatalf("GET /config failed: %v", err)
 	}
 
+	_, responseBody, err = testDoHTTPRequest("GET", testGlobals.httpServerURL+"/metrics", nil, nil)
+	if nil != err {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	if !strings.Contains(string(responseBody[:]), "# TYPE IMGR_GetConfigUsecs histogram\n") {
+		t.Fatalf("GET /metrics should have returned a GetConfigUsecs histogram")
+	}
+
 	_, _, err = testDoHTTPRequest("GET", testGlobals.httpServerURL+"/stats", nil, nil)
 	if nil != err {
 		t.Fatalf("GET /stats failed: %v", err)
@@ -18,6 +18,11 @@ func start(confMap conf.ConfMap) (err error) {
 		return
 	}
 
+	err = startS3Client()
+	if nil != err {
+		return
+	}
+
 	err = startVolumeManagement()
 	if nil != err {
 		return
@@ -52,6 +57,11 @@ func stop() (err error) {
 		return
 	}
 
+	err = stopS3Client()
+	if nil != err {
+		return
+	}
+
 	err = stopSwiftClient()
 	if nil != err {
 		return
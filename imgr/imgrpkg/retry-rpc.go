@@ -8,6 +8,7 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/NVIDIA/sortedmap"
@@ -40,6 +41,7 @@ func startRetryRPCServer() (err error) {
 		DeadlineIO:      globals.config.RetryRPCDeadlineIO,
 		KeepAlivePeriod: globals.config.RetryRPCKeepAlivePeriod,
 		TLSCertificate:  tlsCertificate,
+		JournalPath:     globals.config.RetryRPCJournalPath,
 	}
 
 	globals.retryrpcServer = retryrpc.NewServer(retryrpcServerConfig)
@@ -157,6 +159,7 @@ retryGenerateMountID:
 		authTokenExpired:       false,
 		authToken:              mountRequest.AuthToken,
 		lastAuthTime:           startTime,
+		inodeOpenMap:           make(map[uint64]uint64),
 	}
 
 	volume.mountMap[mountIDAsString] = mount
@@ -254,14 +257,98 @@ func renewMount(renewMountRequest *RenewMountRequestStruct, renewMountResponse *
 
 func unmount(unmountRequest *UnmountRequestStruct, unmountResponse *UnmountResponseStruct) (err error) {
 	var (
-		startTime time.Time = time.Now()
+		checkPointControlChan  chan chan error
+		checkPointResponseChan chan error
+		inodeNumber            uint64
+		leaseReleaseFinishedWG sync.WaitGroup
+		leaseReleaseStartWG    sync.WaitGroup
+		mount                  *mountStruct
+		ok                     bool
+		openCount              uint64
+		startTime              time.Time = time.Now()
+		volume                 *volumeStruct
 	)
 
 	defer func() {
 		globals.stats.UnmountUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
 	}()
 
-	return fmt.Errorf(ETODO + " unmount")
+	globals.Lock()
+
+	mount, ok = globals.mountMap[unmountRequest.MountID]
+	if !ok || mount.unmounting {
+		globals.Unlock()
+		err = fmt.Errorf("%s %s", EUnknownMountID, unmountRequest.MountID)
+		return
+	}
+
+	volume = mount.volume
+
+	// Ensure a concurrent unmount() of mount (while globals is unlocked below) is rejected
+
+	mount.unmounting = true
+
+	// Block any new Lease Requests and release all Leases currently held by mount
+
+	mount.acceptingLeaseRequests = false
+
+	leaseReleaseStartWG.Add(1)
+
+	mount.armReleaseOfAllLeasesWhileLocked(&leaseReleaseStartWG, &leaseReleaseFinishedWG)
+
+	globals.Unlock()
+
+	leaseReleaseStartWG.Done()
+	leaseReleaseFinishedWG.Wait()
+
+	globals.Lock()
+
+	// Drop any OpenCount held by mount (possibly triggering deferred Inode deletions)
+
+	for inodeNumber, openCount = range mount.inodeOpenMap {
+		err = volume.adjustInodeOpenCountWhileLocked(mount, inodeNumber, -int64(openCount))
+		if nil != err {
+			logWarnf("volume.adjustInodeOpenCountWhileLocked(mount, inodeNumber: %016X, -%v) failed: %v", inodeNumber, openCount, err)
+		}
+	}
+
+	// If this is the last mount, perform a final CheckPoint while its AuthToken is still available
+
+	if 1 == len(volume.mountMap) {
+		checkPointControlChan = volume.checkPointControlChan
+		checkPointResponseChan = make(chan error)
+
+		globals.Unlock()
+
+		checkPointControlChan <- checkPointResponseChan
+
+		err = <-checkPointResponseChan
+		if nil != err {
+			logWarnf("final CheckPoint for volume %s failed: %v", volume.name, err)
+		}
+
+		globals.Lock()
+	}
+
+	// Now remove mount from volume & globals
+
+	if mount.leasesExpired {
+		_ = volume.leasesExpiredMountList.Remove(mount.listElement)
+	} else if mount.authTokenExpired {
+		_ = volume.authTokenExpiredMountList.Remove(mount.listElement)
+	} else {
+		_ = volume.healthyMountList.Remove(mount.listElement)
+	}
+
+	mount.listElement = nil
+
+	delete(volume.mountMap, mount.mountID)
+	delete(globals.mountMap, mount.mountID)
+
+	globals.Unlock()
+
+	err = nil
+	return
 }
 
 func fetchNonceRange(fetchNonceRangeRequest *FetchNonceRangeRequestStruct, fetchNonceRangeResponse *FetchNonceRangeResponseStruct) (err error) {
@@ -439,26 +526,135 @@ func putInodeTableEntries(putInodeTableEntriesRequest *PutInodeTableEntriesReque
 
 func deleteInodeTableEntry(deleteInodeTableEntryRequest *DeleteInodeTableEntryRequestStruct, deleteInodeTableEntryResponse *DeleteInodeTableEntryResponseStruct) (err error) {
 	var (
-		startTime time.Time = time.Now()
+		inodeOpenMapElement *inodeOpenMapElementStruct
+		leaseRequest        *leaseRequestStruct
+		mount               *mountStruct
+		ok                  bool
+		startTime           time.Time = time.Now()
+		volume              *volumeStruct
 	)
 
 	defer func() {
 		globals.stats.DeleteInodeTableEntryUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
 	}()
 
-	return fmt.Errorf(ETODO + " deleteInodeTableEntry")
+	globals.Lock()
+
+	mount, ok = globals.mountMap[deleteInodeTableEntryRequest.MountID]
+	if !ok {
+		globals.Unlock()
+		err = fmt.Errorf("%s %s", EUnknownMountID, deleteInodeTableEntryRequest.MountID)
+		return
+	}
+
+	if mount.authTokenHasExpired() {
+		globals.Unlock()
+		err = fmt.Errorf("%s %s", EAuthTokenRejected, mount.authToken)
+		return
+	}
+
+	leaseRequest, ok = mount.leaseRequestMap[deleteInodeTableEntryRequest.InodeNumber]
+	if !ok || (leaseRequestStateExclusiveGranted != leaseRequest.requestState) {
+		globals.Unlock()
+		err = fmt.Errorf("%s %016X", EMissingLease, deleteInodeTableEntryRequest.InodeNumber)
+		return
+	}
+
+	volume = mount.volume
+
+	_, ok, err = volume.inodeTable.GetByKey(deleteInodeTableEntryRequest.InodeNumber)
+	if nil != err {
+		logFatalf("volume.inodeTable.GetByKey(deleteInodeTableEntryRequest.InodeNumber) failed: %v", err)
+	}
+	if !ok {
+		globals.Unlock()
+		err = fmt.Errorf("%s %016X", EUnknownInodeNumber, deleteInodeTableEntryRequest.InodeNumber)
+		return
+	}
+
+	inodeOpenMapElement, ok = volume.inodeOpenMap[deleteInodeTableEntryRequest.InodeNumber]
+	if ok && (0 < inodeOpenMapElement.numOpens) {
+		inodeOpenMapElement.markedForDelete = true
+		err = nil
+	} else {
+		err = volume.removeInodeWhileLocked(deleteInodeTableEntryRequest.InodeNumber)
+	}
+
+	globals.Unlock()
+
+	return
 }
 
 func adjustInodeTableEntryOpenCount(adjustInodeTableEntryOpenCountRequest *AdjustInodeTableEntryOpenCountRequestStruct, adjustInodeTableEntryOpenCountResponse *AdjustInodeTableEntryOpenCountResponseStruct) (err error) {
 	var (
-		startTime time.Time = time.Now()
+		inodeOpenMapElement *inodeOpenMapElementStruct
+		leaseRequest        *leaseRequestStruct
+		mount               *mountStruct
+		ok                  bool
+		startTime           time.Time = time.Now()
+		volume              *volumeStruct
 	)
 
 	defer func() {
 		globals.stats.AdjustInodeTableEntryOpenCountUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
 	}()
 
-	return fmt.Errorf(ETODO + " adjustInodeTableEntryOpenCount")
+	globals.Lock()
+
+	mount, ok = globals.mountMap[adjustInodeTableEntryOpenCountRequest.MountID]
+	if !ok {
+		globals.Unlock()
+		err = fmt.Errorf("%s %s", EUnknownMountID, adjustInodeTableEntryOpenCountRequest.MountID)
+		return
+	}
+
+	if mount.authTokenHasExpired() {
+		globals.Unlock()
+		err = fmt.Errorf("%s %s", EAuthTokenRejected, mount.authToken)
+		return
+	}
+
+	leaseRequest, ok = mount.leaseRequestMap[adjustInodeTableEntryOpenCountRequest.InodeNumber]
+	if !ok || ((leaseRequestStateSharedGranted != leaseRequest.requestState) && (leaseRequestStateExclusiveGranted != leaseRequest.requestState)) {
+		globals.Unlock()
+		err = fmt.Errorf("%s %016X", EMissingLease, adjustInodeTableEntryOpenCountRequest.InodeNumber)
+		return
+	}
+
+	volume = mount.volume
+
+	_, ok = volume.inodeOpenMap[adjustInodeTableEntryOpenCountRequest.InodeNumber]
+	if !ok {
+		_, ok, err = volume.inodeTable.GetByKey(adjustInodeTableEntryOpenCountRequest.InodeNumber)
+		if nil != err {
+			logFatalf("volume.inodeTable.GetByKey(adjustInodeTableEntryOpenCountRequest.InodeNumber) failed: %v", err)
+		}
+		if !ok {
+			globals.Unlock()
+			err = fmt.Errorf("%s %016X", EUnknownInodeNumber, adjustInodeTableEntryOpenCountRequest.InodeNumber)
+			return
+		}
+	}
+
+	err = volume.adjustInodeOpenCountWhileLocked(mount, adjustInodeTableEntryOpenCountRequest.InodeNumber, adjustInodeTableEntryOpenCountRequest.Adjustment)
+	if nil != err {
+		globals.Unlock()
+		return
+	}
+
+	adjustInodeTableEntryOpenCountResponse.CurrentOpenCountThisMount = mount.inodeOpenMap[adjustInodeTableEntryOpenCountRequest.InodeNumber]
+
+	inodeOpenMapElement, ok = volume.inodeOpenMap[adjustInodeTableEntryOpenCountRequest.InodeNumber]
+	if ok {
+		adjustInodeTableEntryOpenCountResponse.CurrentOpenCountAllMounts = inodeOpenMapElement.numOpens
+	} else {
+		adjustInodeTableEntryOpenCountResponse.CurrentOpenCountAllMounts = 0
+	}
+
+	globals.Unlock()
+
+	err = nil
+	return
 }
 
 func flush(flushRequest *FlushRequestStruct, flushResponse *FlushResponseStruct) (err error) {
@@ -480,6 +480,23 @@ func (retryrpcClientCallbacks *testRetryRPCClientCallbacksStruct) Interrupt(payl
 	retryrpcClientCallbacks.interruptPayloadChan <- payload
 }
 
+func testObjectExists(objectNumber uint64) (exists bool) {
+	var (
+		err               error
+		getRequestHeaders http.Header
+	)
+
+	getRequestHeaders = make(http.Header)
+
+	getRequestHeaders["X-Auth-Token"] = []string{testGlobals.authToken}
+
+	_, _, err = testDoHTTPRequest("GET", testGlobals.containerURL+"/"+ilayout.GetObjectNameAsString(objectNumber), getRequestHeaders, nil)
+
+	exists = (nil == err)
+
+	return
+}
+
 func TestRetryRPC(t *testing.T) {
 	var (
 		adjustInodeTableEntryOpenCountRequest  *AdjustInodeTableEntryOpenCountRequestStruct
@@ -1137,15 +1154,17 @@ func TestRetryRPC(t *testing.T) {
 		t.Fatalf("retryrpcClient.Send(\"Flush()\",,) failed: %v", err)
 	}
 
-	// TODO: Remove this early exit skipping of following TODOs
+	// Verify that 1st Object for FileInode gets deleted... but not 2nd nor 3rd
 
-	if nil == err {
-		t.Logf("Exiting TestRetryRPC() early to skip following TODOs")
-		return
+	if testObjectExists(fileInodeObjectA) {
+		t.Fatalf("fileInodeObjectA should have been deleted")
+	}
+	if !testObjectExists(fileInodeObjectB) {
+		t.Fatalf("fileInodeObjectB should not have been deleted")
+	}
+	if !testObjectExists(fileInodeObjectC) {
+		t.Fatalf("fileInodeObjectC should not have been deleted")
 	}
-
-	// TODO: Verify that 1st Object for FileInode gets deleted... but not 2nd nor 3rd
-	//       Note that this requires a checkpoint & async deletes to have completed
 
 	// Perform an AdjustInodeTableEntryOpenCount(+1) for FileInode
 
@@ -1160,6 +1179,29 @@ func TestRetryRPC(t *testing.T) {
 	if nil != err {
 		t.Fatalf("retryrpcClient.Send(\"AdjustInodeTableEntryOpenCount(,fileInodeNumber,+1)\",,) failed: %v", err)
 	}
+	if adjustInodeTableEntryOpenCountResponse.CurrentOpenCountThisMount != 1 {
+		t.Fatalf("adjustInodeTableEntryOpenCountResponse.CurrentOpenCountThisMount unexpected: %v", adjustInodeTableEntryOpenCountResponse.CurrentOpenCountThisMount)
+	}
+	if adjustInodeTableEntryOpenCountResponse.CurrentOpenCountAllMounts != 1 {
+		t.Fatalf("adjustInodeTableEntryOpenCountResponse.CurrentOpenCountAllMounts unexpected: %v", adjustInodeTableEntryOpenCountResponse.CurrentOpenCountAllMounts)
+	}
+
+	// Attempt an AdjustInodeTableEntryOpenCount(-2) for FileInode... which should fail (OpenCount would go negative)
+
+	adjustInodeTableEntryOpenCountRequest = &AdjustInodeTableEntryOpenCountRequestStruct{
+		MountID:     mountResponse.MountID,
+		InodeNumber: fileInodeNumber,
+		Adjustment:  -2,
+	}
+	adjustInodeTableEntryOpenCountResponse = &AdjustInodeTableEntryOpenCountResponseStruct{}
+
+	err = retryrpcClient.Send("AdjustInodeTableEntryOpenCount", adjustInodeTableEntryOpenCountRequest, adjustInodeTableEntryOpenCountResponse)
+	if nil == err {
+		t.Fatalf("retryrpcClient.Send(\"AdjustInodeTableEntryOpenCount(,fileInodeNumber,-2)\",,) should have failed")
+	}
+	if !strings.HasPrefix(err.Error(), EBadOpenCountAdjustment) {
+		t.Fatalf("retryrpcClient.Send(\"AdjustInodeTableEntryOpenCount(,fileInodeNumber,-2)\",,) returned unexpected err: %v", err)
+	}
 
 	// Perform a DeleteInodeTableEntry() on FileInode
 
@@ -1186,7 +1228,21 @@ func TestRetryRPC(t *testing.T) {
 		t.Fatalf("retryrpcClient.Send(\"Flush()\",,) failed: %v", err)
 	}
 
-	// TODO: Verify that FileInode is still in InodeTable
+	// Verify that FileInode is still in InodeTable
+
+	getInodeTableEntryRequest = &GetInodeTableEntryRequestStruct{
+		MountID:     mountResponse.MountID,
+		InodeNumber: fileInodeNumber,
+	}
+	getInodeTableEntryResponse = &GetInodeTableEntryResponseStruct{}
+
+	err = retryrpcClient.Send("GetInodeTableEntry", getInodeTableEntryRequest, getInodeTableEntryResponse)
+	if nil != err {
+		t.Fatalf("retryrpcClient.Send(\"GetInodeTableEntry(,fileInodeNumber)\",,) failed: %v", err)
+	}
+	if getInodeTableEntryResponse.InodeHeadObjectNumber != fileInodeObjectC {
+		t.Fatalf("getInodeTableEntryResponse.InodeHeadObjectNumber unexpected: %016X", getInodeTableEntryResponse.InodeHeadObjectNumber)
+	}
 
 	// Perform an AdjustInodeTableEntryOpenCount(-1) for FileInode
 
@@ -1201,6 +1257,12 @@ func TestRetryRPC(t *testing.T) {
 	if nil != err {
 		t.Fatalf("retryrpcClient.Send(\"AdjustInodeTableEntryOpenCount(,fileInodeNumber,-1)\",,) failed: %v", err)
 	}
+	if adjustInodeTableEntryOpenCountResponse.CurrentOpenCountThisMount != 0 {
+		t.Fatalf("adjustInodeTableEntryOpenCountResponse.CurrentOpenCountThisMount unexpected: %v", adjustInodeTableEntryOpenCountResponse.CurrentOpenCountThisMount)
+	}
+	if adjustInodeTableEntryOpenCountResponse.CurrentOpenCountAllMounts != 0 {
+		t.Fatalf("adjustInodeTableEntryOpenCountResponse.CurrentOpenCountAllMounts unexpected: %v", adjustInodeTableEntryOpenCountResponse.CurrentOpenCountAllMounts)
+	}
 
 	// Perform a Flush()
 
@@ -1214,7 +1276,28 @@ func TestRetryRPC(t *testing.T) {
 		t.Fatalf("retryrpcClient.Send(\"Flush()\",,) failed: %v", err)
 	}
 
-	// TODO: Verify that FileInode is no longer in InodeTable and 2nd and 3rd Objects are deleted
+	// Verify that FileInode is no longer in InodeTable and 2nd and 3rd Objects are deleted
+
+	getInodeTableEntryRequest = &GetInodeTableEntryRequestStruct{
+		MountID:     mountResponse.MountID,
+		InodeNumber: fileInodeNumber,
+	}
+	getInodeTableEntryResponse = &GetInodeTableEntryResponseStruct{}
+
+	err = retryrpcClient.Send("GetInodeTableEntry", getInodeTableEntryRequest, getInodeTableEntryResponse)
+	if nil == err {
+		t.Fatalf("retryrpcClient.Send(\"GetInodeTableEntry(,fileInodeNumber)\",,) should have failed")
+	}
+	if !strings.HasPrefix(err.Error(), EUnknownInodeNumber) {
+		t.Fatalf("retryrpcClient.Send(\"GetInodeTableEntry(,fileInodeNumber)\",,) returned unexpected err: %v", err)
+	}
+
+	if testObjectExists(fileInodeObjectB) {
+		t.Fatalf("fileInodeObjectB should have been deleted")
+	}
+	if testObjectExists(fileInodeObjectC) {
+		t.Fatalf("fileInodeObjectC should have been deleted")
+	}
 
 	// Perform a Lease Release on FileInode
 
@@ -1242,7 +1325,73 @@ func TestRetryRPC(t *testing.T) {
 		t.Fatalf("retryrpcClient.Send(\"Unmount()\",,) failed: %v", err)
 	}
 
-	// TODO: Verify that Exclusive Lease on RootDirInode is implicitly released
+	// Verify that the MountID is no longer known
+
+	flushRequest = &FlushRequestStruct{
+		MountID: mountResponse.MountID,
+	}
+	flushResponse = &FlushResponseStruct{}
+
+	err = retryrpcClient.Send("Flush", flushRequest, flushResponse)
+	if nil == err {
+		t.Fatalf("retryrpcClient.Send(\"Flush()\",,) following Unmount() should have failed")
+	}
+	if !strings.HasPrefix(err.Error(), EUnknownMountID) {
+		t.Fatalf("retryrpcClient.Send(\"Flush()\",,) following Unmount() returned unexpected err: %v", err)
+	}
+
+	// Verify that Exclusive Lease on RootDirInode is implicitly released by obtaining it via a new Mount()
+
+	mountRequest = &MountRequestStruct{
+		VolumeName: testVolume,
+		AuthToken:  testGlobals.authToken,
+	}
+	mountResponse = &MountResponseStruct{}
+
+	err = retryrpcClient.Send("Mount", mountRequest, mountResponse)
+	if nil != err {
+		t.Fatalf("retryrpcClient.Send(\"Mount(,)\",,) failed: %v", err)
+	}
+
+	leaseRequest = &LeaseRequestStruct{
+		MountID:          mountResponse.MountID,
+		InodeNumber:      ilayout.RootDirInodeNumber,
+		LeaseRequestType: LeaseRequestTypeExclusive,
+	}
+	leaseResponse = &LeaseResponseStruct{}
+
+	err = retryrpcClient.Send("Lease", leaseRequest, leaseResponse)
+	if nil != err {
+		t.Fatalf("retryrpcClient.Send(\"Lease(,ilayout.RootDirInodeNumber,LeaseRequestTypeExclusive)\",,) failed: %v", err)
+	}
+	if leaseResponse.LeaseResponseType != LeaseResponseTypeExclusive {
+		t.Fatalf("retryrpcClient.Send(\"Lease(,ilayout.RootDirInodeNumber,LeaseRequestTypeExclusive)\",,) returned unexpected LeaseResponseType: %v", leaseResponse.LeaseResponseType)
+	}
+
+	// Perform concurrent Unmount()'s... only one of which should succeed
+
+	unmountErrChan := make(chan error, 2)
+
+	for i := 0; i < 2; i++ {
+		go func() {
+			unmountErrChan <- retryrpcClient.Send("Unmount", &UnmountRequestStruct{MountID: mountResponse.MountID}, &UnmountResponseStruct{})
+		}()
+	}
+
+	unmountSuccesses := 0
+
+	for i := 0; i < 2; i++ {
+		err = <-unmountErrChan
+		if nil == err {
+			unmountSuccesses++
+		} else if !strings.HasPrefix(err.Error(), EUnknownMountID) {
+			t.Fatalf("retryrpcClient.Send(\"Unmount()\",,) returned unexpected err: %v", err)
+		}
+	}
+
+	if 1 != unmountSuccesses {
+		t.Fatalf("concurrent retryrpcClient.Send(\"Unmount()\",,)'s returned %v successes (expected 1)", unmountSuccesses)
+	}
 
 	// Teardown RetryRPC Client
 
@@ -0,0 +1,168 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package imgrpkg
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/NVIDIA/proxyfs/s3client"
+)
+
+// A StorageURL of the form "s3://<bucket>[/<prefix>]" identifies a volume held
+// in an S3-compatible object store rather than a Swift Container. Each object
+// is then stored at key "[<prefix>/]<objectName>" of <bucket>. As the S3
+// credentials come from the [IMGR] section rather than from a Mount's AuthToken,
+// the s3Object*Once() funcs below always report authOK == true.
+
+const s3StorageURLPrefix = "s3://"
+
+func startS3Client() (err error) {
+	if "" == globals.config.S3Endpoint {
+		globals.s3Client = nil
+		err = nil
+		return
+	}
+
+	globals.s3Client, err = s3client.NewClient(&s3client.ClientConfig{
+		Endpoint:        strings.TrimSuffix(globals.config.S3Endpoint, "/"),
+		Region:          globals.config.S3Region,
+		AccessKeyID:     globals.config.S3AccessKeyID,
+		SecretAccessKey: globals.config.S3SecretAccessKey,
+		Timeout:         globals.config.SwiftTimeout,
+	})
+
+	return
+}
+
+func stopS3Client() (err error) {
+	globals.s3Client = nil
+
+	err = nil
+	return
+}
+
+func isS3ObjectURL(objectURL string) bool {
+	return strings.HasPrefix(objectURL, s3StorageURLPrefix)
+}
+
+func parseS3ObjectURL(objectURL string) (bucketName string, key string, err error) {
+	var (
+		bucketAndKey []string
+	)
+
+	if nil == globals.s3Client {
+		err = fmt.Errorf("S3 StorageURLs not supported without [IMGR]S3Endpoint (objectURL: %s)", objectURL)
+		return
+	}
+
+	bucketAndKey = strings.SplitN(strings.TrimPrefix(objectURL, s3StorageURLPrefix), "/", 2)
+	if (2 != len(bucketAndKey)) || ("" == bucketAndKey[0]) || ("" == bucketAndKey[1]) {
+		err = fmt.Errorf("objectURL (%s) not of the form %s<bucket>[/<prefix>]/<objectName>", objectURL, s3StorageURLPrefix)
+		return
+	}
+
+	bucketName = bucketAndKey[0]
+	key = bucketAndKey[1]
+
+	err = nil
+	return
+}
+
+func s3ObjectDeleteOnce(objectURL string) (authOK bool, err error) {
+	var (
+		bucketName string
+		key        string
+	)
+
+	authOK = true
+
+	bucketName, key, err = parseS3ObjectURL(objectURL)
+	if nil != err {
+		return
+	}
+
+	err = globals.s3Client.DeleteObject(bucketName, key) // Succeeds even if the object was already deleted
+	if nil != err {
+		err = fmt.Errorf("globals.s3Client.DeleteObject(%s,%s) failed: %v", bucketName, key, err)
+	}
+
+	return
+}
+
+func s3ObjectGetOnce(objectURL string, rangeHeaderValue string) (buf []byte, authOK bool, err error) {
+	var (
+		bucketName  string
+		key         string
+		rangeFirst  uint64
+		rangeLast   uint64
+		rangeLength uint64
+	)
+
+	authOK = true
+
+	bucketName, key, err = parseS3ObjectURL(objectURL)
+	if nil != err {
+		return
+	}
+
+	if "" == rangeHeaderValue {
+		_, buf, err = globals.s3Client.GetObject(bucketName, key)
+	} else if strings.HasPrefix(rangeHeaderValue, "bytes=-") {
+		_, err = fmt.Sscanf(rangeHeaderValue, "bytes=-%d", &rangeLength)
+		if nil != err {
+			err = fmt.Errorf("rangeHeaderValue (%s) not understood: %v", rangeHeaderValue, err)
+			return
+		}
+		buf, err = globals.s3Client.GetObjectTail(bucketName, key, rangeLength)
+	} else {
+		_, err = fmt.Sscanf(rangeHeaderValue, "bytes=%d-%d", &rangeFirst, &rangeLast)
+		if (nil != err) || (rangeLast < rangeFirst) {
+			err = fmt.Errorf("rangeHeaderValue (%s) not understood: %v", rangeHeaderValue, err)
+			return
+		}
+		buf, err = globals.s3Client.GetObjectRange(bucketName, key, rangeFirst, (rangeLast - rangeFirst + 1))
+	}
+	if nil != err {
+		err = fmt.Errorf("globals.s3Client.GetObject*(%s,%s) [rangeHeaderValue: \"%s\"] failed: %v", bucketName, key, rangeHeaderValue, err)
+	}
+
+	return
+}
+
+func s3ObjectPutOnce(objectURL string, body io.ReadSeeker) (authOK bool, err error) {
+	var (
+		bucketName string
+		bodyBuf    []byte
+		key        string
+	)
+
+	authOK = true
+
+	bucketName, key, err = parseS3ObjectURL(objectURL)
+	if nil != err {
+		return
+	}
+
+	_, err = body.Seek(0, io.SeekStart)
+	if nil != err {
+		err = fmt.Errorf("body.Seek(0, io.SeekStart) failed: %v", err)
+		return
+	}
+
+	bodyBuf, err = ioutil.ReadAll(body)
+	if nil != err {
+		err = fmt.Errorf("ioutil.ReadAll(body) failed: %v", err)
+		return
+	}
+
+	err = globals.s3Client.PutObject(bucketName, key, nil, bodyBuf)
+	if nil != err {
+		err = fmt.Errorf("globals.s3Client.PutObject(%s,%s,,) failed: %v", bucketName, key, err)
+	}
+
+	return
+}
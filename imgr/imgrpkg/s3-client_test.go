@@ -0,0 +1,104 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package imgrpkg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/proxyfs/ilayout"
+)
+
+func TestS3Volume(t *testing.T) {
+	var (
+		buf             []byte
+		err             error
+		keys            []string
+		postRequestBody string
+		putRequestBody  string
+		storageURL      string
+	)
+
+	testSetup(t, nil)
+
+	err = globals.s3Client.CreateBucket(testS3Bucket)
+	if nil != err {
+		t.Fatalf("globals.s3Client.CreateBucket(testS3Bucket) failed: %v", err)
+	}
+
+	storageURL = "s3://" + testS3Bucket + "/" + testContainer
+
+	// Format a volume held in the S3 Bucket
+
+	postRequestBody = fmt.Sprintf("{\"StorageURL\":\"%s\",\"AuthToken\":\"\"}", storageURL)
+
+	_, _, err = testDoHTTPRequest("POST", testGlobals.httpServerURL+"/volume", nil, strings.NewReader(postRequestBody))
+	if nil != err {
+		t.Fatalf("testDoHTTPRequest(\"POST\", testGlobals.httpServerURL+\"/volume\", nil, strings.NewReader(postRequestBody)) failed: %v", err)
+	}
+
+	keys, _, err = globals.s3Client.ListObjects(testS3Bucket, testContainer+"/", "")
+	if nil != err {
+		t.Fatalf("globals.s3Client.ListObjects() failed: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{
+		testContainer + "/" + ilayout.GetObjectNameAsString(ilayout.CheckPointObjectNumber),
+		testContainer + "/" + ilayout.GetObjectNameAsString(ilayout.CheckPointObjectNumber+2),
+		testContainer + "/" + ilayout.GetObjectNameAsString(ilayout.CheckPointObjectNumber+3),
+	}) {
+		t.Fatalf("globals.s3Client.ListObjects() returned unexpected keys: %v", keys)
+	}
+
+	buf, err = swiftObjectGet(storageURL, "", ilayout.CheckPointObjectNumber)
+	if nil != err {
+		t.Fatalf("swiftObjectGet(storageURL,,ilayout.CheckPointObjectNumber) failed: %v", err)
+	}
+	if string(buf) != fmt.Sprintf("%016X %016X %016X %016X", ilayout.CheckPointVersionV1, ilayout.CheckPointObjectNumber+3, 96, ilayout.CheckPointObjectNumber+3) {
+		t.Fatalf("swiftObjectGet(storageURL,,ilayout.CheckPointObjectNumber) returned unexpected CheckPoint: \"%s\"", string(buf))
+	}
+
+	buf, err = swiftObjectGetRange(storageURL, "", ilayout.CheckPointObjectNumber, 17, 16)
+	if (nil != err) || (string(buf) != fmt.Sprintf("%016X", ilayout.CheckPointObjectNumber+3)) {
+		t.Fatalf("swiftObjectGetRange(storageURL,,ilayout.CheckPointObjectNumber,,) returned \"%s\" and %v", string(buf), err)
+	}
+
+	buf, err = swiftObjectGetTail(storageURL, "", ilayout.CheckPointObjectNumber, 16)
+	if (nil != err) || (string(buf) != fmt.Sprintf("%016X", ilayout.CheckPointObjectNumber+3)) {
+		t.Fatalf("swiftObjectGetTail(storageURL,,ilayout.CheckPointObjectNumber,) returned \"%s\" and %v", string(buf), err)
+	}
+
+	// Serve the volume (no AuthToken is needed to access it)
+
+	putRequestBody = fmt.Sprintf("{\"StorageURL\":\"%s\"}", storageURL)
+
+	_, _, err = testDoHTTPRequest("PUT", testGlobals.httpServerURL+"/volume/"+testVolume, nil, strings.NewReader(putRequestBody))
+	if nil != err {
+		t.Fatalf("testDoHTTPRequest(\"PUT\", testGlobals.httpServerURL+\"/volume\"+testVolume, nil, strings.NewReader(putRequestBody)) failed: %v", err)
+	}
+
+	_, _, err = testDoHTTPRequest("DELETE", testGlobals.httpServerURL+"/volume/"+testVolume, nil, nil)
+	if nil != err {
+		t.Fatalf("testDoHTTPRequest(\"DELETE\", testGlobals.httpServerURL+\"/volume/\"+testVolume, nil, nil) failed: %v", err)
+	}
+
+	// Delete (twice) an Object of the volume
+
+	err = swiftObjectDelete(storageURL, "", ilayout.CheckPointObjectNumber+2)
+	if nil != err {
+		t.Fatalf("swiftObjectDelete(storageURL,,ilayout.CheckPointObjectNumber+2) failed: %v", err)
+	}
+	err = swiftObjectDelete(storageURL, "", ilayout.CheckPointObjectNumber+2)
+	if nil != err {
+		t.Fatalf("swiftObjectDelete(storageURL,,ilayout.CheckPointObjectNumber+2) [second time] failed: %v", err)
+	}
+
+	_, err = swiftObjectGet(storageURL, "", ilayout.CheckPointObjectNumber+2)
+	if nil == err {
+		t.Fatalf("swiftObjectGet(storageURL,,ilayout.CheckPointObjectNumber+2) of deleted Object should have failed")
+	}
+
+	testTeardown(t)
+}
@@ -67,6 +67,11 @@ func swiftObjectDeleteOnce(objectURL string, authToken string) (authOK bool, err
 		httpResponse *http.Response
 	)
 
+	if isS3ObjectURL(objectURL) {
+		authOK, err = s3ObjectDeleteOnce(objectURL)
+		return
+	}
+
 	httpRequest, err = http.NewRequest("DELETE", objectURL, nil)
 	if nil != err {
 		return
@@ -96,6 +101,9 @@ func swiftObjectDeleteOnce(objectURL string, authToken string) (authOK bool, err
 	if (200 <= httpResponse.StatusCode) && (299 >= httpResponse.StatusCode) {
 		authOK = true
 		err = nil
+	} else if http.StatusNotFound == httpResponse.StatusCode {
+		authOK = true // Object already deleted (e.g. a PendingDeleteObjectNumberArray replayed after a restart)
+		err = nil
 	} else if http.StatusUnauthorized == httpResponse.StatusCode {
 		authOK = false // Auth failed,
 		err = nil      //   but we will still indicate the func succeeded
@@ -114,6 +122,11 @@ func (volume *volumeStruct) swiftObjectDeleteOnce(objectURL string) (authOK bool
 		toRetryMountList *list.List
 	)
 
+	if isS3ObjectURL(objectURL) {
+		authOK, err = s3ObjectDeleteOnce(objectURL)
+		return
+	}
+
 	toRetryMountList = list.New()
 
 	mountListElement = volume.healthyMountList.Front()
@@ -249,6 +262,11 @@ func swiftObjectGetOnce(objectURL string, authToken string, rangeHeaderValue str
 		httpResponse *http.Response
 	)
 
+	if isS3ObjectURL(objectURL) {
+		buf, authOK, err = s3ObjectGetOnce(objectURL, rangeHeaderValue)
+		return
+	}
+
 	httpRequest, err = http.NewRequest("GET", objectURL, nil)
 	if nil != err {
 		return
@@ -299,6 +317,11 @@ func (volume *volumeStruct) swiftObjectGetOnce(objectURL string, rangeHeaderValu
 		toRetryMountList *list.List
 	)
 
+	if isS3ObjectURL(objectURL) {
+		buf, authOK, err = s3ObjectGetOnce(objectURL, rangeHeaderValue)
+		return
+	}
+
 	toRetryMountList = list.New()
 
 	mountListElement = volume.healthyMountList.Front()
@@ -600,6 +623,11 @@ func swiftObjectPutOnce(objectURL string, authToken string, body io.ReadSeeker)
 		httpResponse *http.Response
 	)
 
+	if isS3ObjectURL(objectURL) {
+		authOK, err = s3ObjectPutOnce(objectURL, body)
+		return
+	}
+
 	body.Seek(0, io.SeekStart)
 
 	httpRequest, err = http.NewRequest("PUT", objectURL, body)
@@ -649,6 +677,11 @@ func (volume *volumeStruct) swiftObjectPutOnce(objectURL string, body io.ReadSee
 		toRetryMountList *list.List
 	)
 
+	if isS3ObjectURL(objectURL) {
+		authOK, err = s3ObjectPutOnce(objectURL, body)
+		return
+	}
+
 	toRetryMountList = list.New()
 
 	mountListElement = volume.healthyMountList.Front()
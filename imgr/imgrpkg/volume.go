@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/NVIDIA/sortedmap"
@@ -627,10 +628,12 @@ func putVolume(name string, storageURL string, authToken string) (err error) {
 		numNoncesReserved:             0,
 		activeDeleteObjectNumberList:  list.New(),
 		pendingDeleteObjectNumberList: list.New(),
+		removedInodeList:              list.New(),
 		checkPointControlChan:         nil,
 		checkPointPutObjectNumber:     0,
 		checkPointPutObjectBuffer:     nil,
 		inodeLeaseMap:                 make(map[uint64]*inodeLeaseStruct),
+		inodeOpenMap:                  make(map[uint64]*inodeOpenMapElementStruct),
 	}
 
 	globals.Lock()
@@ -682,6 +685,7 @@ func (volume *volumeStruct) checkPointDaemon(checkPointControlChan chan chan err
 				checkPointResponseChan <- err
 			} else {
 				volume.checkPointControlWG.Done()
+				return
 			}
 		}
 	}
@@ -689,9 +693,12 @@ func (volume *volumeStruct) checkPointDaemon(checkPointControlChan chan chan err
 
 func (volume *volumeStruct) doCheckPoint() (err error) {
 	var (
+		activeDeleteObjectNumberArray []uint64
 		authOK                        bool
+		authToken                     string
 		checkPointV1String            string
 		deleteObjectNumberListElement *list.Element
+		failedDeleteObjectNumberArray []uint64
 		inodeTableLayoutElement       *inodeTableLayoutElementStruct
 		inodeTableRootObjectLength    uint64
 		inodeTableRootObjectNumber    uint64
@@ -699,6 +706,7 @@ func (volume *volumeStruct) doCheckPoint() (err error) {
 		objectNumber                  uint64
 		ok                            bool
 		startTime                     time.Time = time.Now()
+		storageURL                    string
 		superBlockV1Buf               []byte
 	)
 
@@ -706,6 +714,11 @@ func (volume *volumeStruct) doCheckPoint() (err error) {
 		globals.stats.VolumeCheckPointUsecs.Add(uint64(time.Since(startTime) / time.Microsecond))
 	}()
 
+	err = volume.scheduleRemovedInodeObjects()
+	if nil != err {
+		logWarnf("volume.scheduleRemovedInodeObjects() failed (will retry at next CheckPoint): %v", err)
+	}
+
 	globals.Lock()
 
 	for volume.numNoncesReserved == 0 {
@@ -747,8 +760,8 @@ func (volume *volumeStruct) doCheckPoint() (err error) {
 	}
 
 	volume.superBlock.InodeTableRootObjectNumber = inodeTableRootObjectNumber
-	volume.superBlock.InodeTableRootObjectNumber = inodeTableRootObjectOffset
-	volume.superBlock.InodeTableRootObjectNumber = inodeTableRootObjectLength
+	volume.superBlock.InodeTableRootObjectOffset = inodeTableRootObjectOffset
+	volume.superBlock.InodeTableRootObjectLength = inodeTableRootObjectLength
 
 	volume.superBlock.InodeTableLayout = make([]ilayout.InodeTableLayoutEntryV1Struct, 0, len(volume.inodeTableLayout))
 
@@ -832,12 +845,289 @@ func (volume *volumeStruct) doCheckPoint() (err error) {
 		return
 	}
 
+	// Now that the CheckPoint is persisted, all objects listed in its
+	// PendingDeleteObjectNumberArray may actually be deleted
+
+	volume.activeDeleteObjectNumberList.PushBackList(volume.pendingDeleteObjectNumberList)
+	volume.pendingDeleteObjectNumberList.Init()
+
+	activeDeleteObjectNumberArray = make([]uint64, 0, volume.activeDeleteObjectNumberList.Len())
+
+	deleteObjectNumberListElement = volume.activeDeleteObjectNumberList.Front()
+
+	for nil != deleteObjectNumberListElement {
+		objectNumber, ok = deleteObjectNumberListElement.Value.(uint64)
+		if !ok {
+			err = fmt.Errorf("deleteObjectNumberListElement.Value.(uint64) returned !ok")
+			logFatal(err)
+		}
+		activeDeleteObjectNumberArray = append(activeDeleteObjectNumberArray, objectNumber)
+		deleteObjectNumberListElement = deleteObjectNumberListElement.Next()
+	}
+
+	storageURL = volume.storageURL
+	authToken = volume.fetchAuthTokenWhileLocked()
+
+	globals.Unlock()
+
+	failedDeleteObjectNumberArray = deleteObjects(storageURL, authToken, activeDeleteObjectNumberArray)
+
+	// Only this checkPointDaemon() goroutine modifies volume.activeDeleteObjectNumberList,
+	// so any object that could not be deleted is simply retained for the next CheckPoint
+
+	globals.Lock()
+
+	volume.activeDeleteObjectNumberList.Init()
+
+	for _, objectNumber = range failedDeleteObjectNumberArray {
+		_ = volume.activeDeleteObjectNumberList.PushBack(objectNumber)
+	}
+
 	globals.Unlock()
 
 	err = nil
 	return
 }
 
+// fetchAuthTokenWhileLocked returns the AuthToken of the most recently active
+// healthy mountStruct. If there is none, the volume's AuthToken (if any) is
+// returned.
+func (volume *volumeStruct) fetchAuthTokenWhileLocked() (authToken string) {
+	var (
+		mount *mountStruct
+		ok    bool
+	)
+
+	if 0 == volume.healthyMountList.Len() {
+		authToken = volume.authToken
+	} else {
+		mount, ok = volume.healthyMountList.Back().Value.(*mountStruct)
+		if !ok {
+			logFatalf("volume.healthyMountList.Back().Value.(*mountStruct) returned !ok")
+		}
+		authToken = mount.authToken
+	}
+
+	return
+}
+
+// deleteObjects deletes each object listed in objectNumberArray using up to
+// globals.config.ParallelObjectDeleteMax concurrent requests. Any objects that
+// could not be deleted are returned in failedObjectNumberArray.
+func deleteObjects(storageURL string, authToken string, objectNumberArray []uint64) (failedObjectNumberArray []uint64) {
+	var (
+		failedObjectNumberArrayLock sync.Mutex
+		objectNumber                uint64
+		parallelObjectDeleteChan    chan struct{}
+		parallelObjectDeleteWG      sync.WaitGroup
+	)
+
+	failedObjectNumberArray = make([]uint64, 0)
+
+	if 0 == len(objectNumberArray) {
+		return
+	}
+
+	if 0 == globals.config.ParallelObjectDeleteMax {
+		parallelObjectDeleteChan = make(chan struct{}, 1)
+	} else {
+		parallelObjectDeleteChan = make(chan struct{}, globals.config.ParallelObjectDeleteMax)
+	}
+
+	for _, objectNumber = range objectNumberArray {
+		parallelObjectDeleteChan <- struct{}{}
+		parallelObjectDeleteWG.Add(1)
+
+		go func(objectNumber uint64) {
+			var (
+				err error
+			)
+
+			err = swiftObjectDelete(storageURL, authToken, objectNumber)
+			if nil != err {
+				logWarnf("swiftObjectDelete(storageURL: %s,, objectNumber: %016X) failed: %v", storageURL, objectNumber, err)
+
+				failedObjectNumberArrayLock.Lock()
+				failedObjectNumberArray = append(failedObjectNumberArray, objectNumber)
+				failedObjectNumberArrayLock.Unlock()
+			}
+
+			<-parallelObjectDeleteChan
+			parallelObjectDeleteWG.Done()
+		}(objectNumber)
+	}
+
+	parallelObjectDeleteWG.Wait()
+
+	return
+}
+
+// adjustInodeOpenCountWhileLocked applies adjustment to mount's open count for
+// inodeNumber (and, correspondingly, to the open count across all mounts). If
+// the resultant open count across all mounts is zero and inodeNumber has been
+// marked for deletion, the Inode is removed.
+func (volume *volumeStruct) adjustInodeOpenCountWhileLocked(mount *mountStruct, inodeNumber uint64, adjustment int64) (err error) {
+	var (
+		inodeOpenMapElement     *inodeOpenMapElementStruct
+		mountOpenCount          uint64
+		ok                      bool
+		resultantMountOpenCount int64
+	)
+
+	mountOpenCount = mount.inodeOpenMap[inodeNumber]
+
+	resultantMountOpenCount = int64(mountOpenCount) + adjustment
+	if 0 > resultantMountOpenCount {
+		err = fmt.Errorf("%s %016X adjustment %v would result in negative OpenCount", EBadOpenCountAdjustment, inodeNumber, adjustment)
+		return
+	}
+
+	inodeOpenMapElement, ok = volume.inodeOpenMap[inodeNumber]
+	if !ok {
+		inodeOpenMapElement = &inodeOpenMapElementStruct{
+			numOpens:        0,
+			markedForDelete: false,
+		}
+
+		volume.inodeOpenMap[inodeNumber] = inodeOpenMapElement
+	}
+
+	inodeOpenMapElement.numOpens = uint64(int64(inodeOpenMapElement.numOpens) + adjustment)
+
+	if 0 == resultantMountOpenCount {
+		delete(mount.inodeOpenMap, inodeNumber)
+	} else {
+		mount.inodeOpenMap[inodeNumber] = uint64(resultantMountOpenCount)
+	}
+
+	if 0 == inodeOpenMapElement.numOpens {
+		if inodeOpenMapElement.markedForDelete {
+			err = volume.removeInodeWhileLocked(inodeNumber)
+			if nil != err {
+				// Leave inodeOpenMapElement in place so that a subsequent DeleteInodeTableEntry() may retry
+				return
+			}
+		}
+
+		delete(volume.inodeOpenMap, inodeNumber)
+	}
+
+	err = nil
+	return
+}
+
+// removeInodeWhileLocked removes inodeNumber from the InodeTable. As fetching the
+// Inode's InodeHead must not be done while globals is locked, the objects it references
+// are only scheduled for deletion by the next CheckPoint (see scheduleRemovedInodeObjects()).
+func (volume *volumeStruct) removeInodeWhileLocked(inodeNumber uint64) (err error) {
+	var (
+		inodeTableEntryValue    ilayout.InodeTableEntryValueV1Struct
+		inodeTableEntryValueRaw sortedmap.Value
+		ok                      bool
+	)
+
+	inodeTableEntryValueRaw, ok, err = volume.inodeTable.GetByKey(inodeNumber)
+	if nil != err {
+		logFatalf("volume.inodeTable.GetByKey(inodeNumber) failed: %v", err)
+	}
+	if !ok {
+		err = fmt.Errorf("%s %016X", EUnknownInodeNumber, inodeNumber)
+		return
+	}
+
+	inodeTableEntryValue, ok = inodeTableEntryValueRaw.(ilayout.InodeTableEntryValueV1Struct)
+	if !ok {
+		logFatalf("inodeTableEntryValueRaw.(ilayout.InodeTableEntryValueV1Struct) returned !ok")
+	}
+
+	ok, err = volume.inodeTable.DeleteByKey(inodeNumber)
+	if nil != err {
+		logFatalf("volume.inodeTable.DeleteByKey(inodeNumber) failed: %v", err)
+	}
+	if !ok {
+		logFatalf("volume.inodeTable.DeleteByKey(inodeNumber) returned !ok")
+	}
+
+	_ = volume.removedInodeList.PushBack(inodeTableEntryValue)
+
+	err = nil
+	return
+}
+
+// scheduleRemovedInodeObjects fetches the InodeHead of each Inode on removedInodeList
+// without globals locked and then, with globals locked, schedules each object referenced
+// by those InodeHeads for deletion following the CheckPoint about to be performed. Any
+// Inodes whose InodeHead could not be fetched remain on removedInodeList to be retried.
+func (volume *volumeStruct) scheduleRemovedInodeObjects() (err error) {
+	var (
+		inodeHeadLayoutEntry    ilayout.InodeHeadLayoutEntryV1Struct
+		inodeHeadObjectInLayout bool
+		inodeHeadV1             *ilayout.InodeHeadV1Struct
+		inodeHeadV1Array        []*ilayout.InodeHeadV1Struct
+		inodeTableEntryValue    ilayout.InodeTableEntryValueV1Struct
+		ok                      bool
+		removedInodeList        *list.List
+		removedInodeListElement *list.Element
+	)
+
+	globals.Lock()
+	removedInodeList = volume.removedInodeList
+	volume.removedInodeList = list.New()
+	globals.Unlock()
+
+	inodeHeadV1Array = make([]*ilayout.InodeHeadV1Struct, 0, removedInodeList.Len())
+
+	for removedInodeListElement = removedInodeList.Front(); nil != removedInodeListElement; removedInodeListElement = removedInodeListElement.Next() {
+		inodeTableEntryValue, ok = removedInodeListElement.Value.(ilayout.InodeTableEntryValueV1Struct)
+		if !ok {
+			logFatalf("removedInodeListElement.Value.(ilayout.InodeTableEntryValueV1Struct) returned !ok")
+		}
+
+		inodeHeadV1, err = volume.fetchInodeHead(inodeTableEntryValue.InodeHeadObjectNumber, inodeTableEntryValue.InodeHeadLength)
+		if nil != err {
+			err = fmt.Errorf("volume.fetchInodeHead(inodeHeadObjectNumber: %016X, inodeHeadLength: %v) failed: %v", inodeTableEntryValue.InodeHeadObjectNumber, inodeTableEntryValue.InodeHeadLength, err)
+			break
+		}
+
+		inodeHeadV1Array = append(inodeHeadV1Array, inodeHeadV1)
+	}
+
+	globals.Lock()
+
+	removedInodeListElement = removedInodeList.Front()
+
+	for _, inodeHeadV1 = range inodeHeadV1Array {
+		inodeTableEntryValue = removedInodeList.Remove(removedInodeListElement).(ilayout.InodeTableEntryValueV1Struct)
+		removedInodeListElement = removedInodeList.Front()
+
+		inodeHeadObjectInLayout = false
+
+		for _, inodeHeadLayoutEntry = range inodeHeadV1.Layout {
+			if inodeHeadLayoutEntry.ObjectNumber == inodeTableEntryValue.InodeHeadObjectNumber {
+				inodeHeadObjectInLayout = true
+			}
+
+			volume.superBlock.InodeObjectCount--
+			volume.superBlock.InodeObjectSize -= inodeHeadLayoutEntry.ObjectSize
+			volume.superBlock.InodeBytesReferenced -= inodeHeadLayoutEntry.BytesReferenced
+
+			_ = volume.pendingDeleteObjectNumberList.PushBack(inodeHeadLayoutEntry.ObjectNumber)
+		}
+
+		if !inodeHeadObjectInLayout {
+			_ = volume.pendingDeleteObjectNumberList.PushBack(inodeTableEntryValue.InodeHeadObjectNumber)
+		}
+	}
+
+	// Return any Inodes not yet handled to the front of removedInodeList
+
+	volume.removedInodeList.PushFrontList(removedInodeList)
+
+	globals.Unlock()
+
+	return
+}
+
 func (volume *volumeStruct) DumpKey(key sortedmap.Key) (keyAsString string, err error) {
 	var (
 		keyAsInodeNumber uint64
@@ -951,7 +1241,7 @@ func (volume *volumeStruct) DiscardNode(objectNumber uint64, objectOffset uint64
 
 	if inodeTableLayoutElement.bytesReferenced == 0 {
 		delete(volume.inodeTableLayout, objectNumber)
-		volume.superBlock.PendingDeleteObjectNumberArray = append(volume.superBlock.PendingDeleteObjectNumberArray, objectNumber)
+		_ = volume.pendingDeleteObjectNumberList.PushBack(objectNumber)
 	}
 
 	err = nil
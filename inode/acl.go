@@ -0,0 +1,448 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package inode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/headhunter"
+	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/utils"
+)
+
+// POSIX ACLs are stored in the format of the Linux posix_acl_xattr_header and
+// posix_acl_xattr_entry structs (little endian) so that they may be passed
+// unmodified to/from clients via the "system.posix_acl_*" extended attributes:
+//
+//   uint32 version == 2
+//   { uint16 tag; uint16 perm; uint32 id } ...
+//
+// An access ACL that is equivalent to Mode is never stored (just as Linux does).
+
+const (
+	posixACLVersion     = uint32(2)
+	posixACLHeaderSize  = 4
+	posixACLEntrySize   = 8
+	posixACLUndefinedID = uint32(0xFFFFFFFF)
+
+	posixACLTagUserObj  = uint16(0x01)
+	posixACLTagUser     = uint16(0x02)
+	posixACLTagGroupObj = uint16(0x04)
+	posixACLTagGroup    = uint16(0x08)
+	posixACLTagMask     = uint16(0x10)
+	posixACLTagOther    = uint16(0x20)
+)
+
+type posixACLEntryStruct struct {
+	tag  uint16
+	perm InodeMode // some combination of R_OK, W_OK, and X_OK
+	id   uint32
+}
+
+// unmarshalPosixACL decodes and validates buf. Entries must be sorted by tag (and
+// then by id for ACL_USER and ACL_GROUP entries) as setfacl(1) provides them.
+func unmarshalPosixACL(buf []byte) (posixACL []posixACLEntryStruct, err error) {
+	var (
+		entry      posixACLEntryStruct
+		entryIndex int
+		numEntries int
+		prevEntry  posixACLEntryStruct
+		tagCount   map[uint16]int
+	)
+
+	if (len(buf) < posixACLHeaderSize) || (0 != ((len(buf) - posixACLHeaderSize) % posixACLEntrySize)) {
+		err = blunder.NewError(blunder.InvalidArgError, "POSIX ACL of invalid length %d", len(buf))
+		return
+	}
+	if posixACLVersion != binary.LittleEndian.Uint32(buf[:posixACLHeaderSize]) {
+		err = blunder.NewError(blunder.InvalidArgError, "POSIX ACL of unsupported version %d", binary.LittleEndian.Uint32(buf[:posixACLHeaderSize]))
+		return
+	}
+
+	numEntries = (len(buf) - posixACLHeaderSize) / posixACLEntrySize
+
+	posixACL = make([]posixACLEntryStruct, numEntries)
+	tagCount = make(map[uint16]int)
+
+	for entryIndex = 0; entryIndex < numEntries; entryIndex++ {
+		entry.tag = binary.LittleEndian.Uint16(buf[posixACLHeaderSize+(entryIndex*posixACLEntrySize):])
+		entry.perm = InodeMode(binary.LittleEndian.Uint16(buf[posixACLHeaderSize+(entryIndex*posixACLEntrySize)+2:]))
+		entry.id = binary.LittleEndian.Uint32(buf[posixACLHeaderSize+(entryIndex*posixACLEntrySize)+4:])
+
+		if entry.perm != (entry.perm & (R_OK | W_OK | X_OK)) {
+			err = blunder.NewError(blunder.InvalidArgError, "POSIX ACL entry with invalid perm 0x%X", entry.perm)
+			return
+		}
+
+		switch entry.tag {
+		case posixACLTagUserObj, posixACLTagGroupObj, posixACLTagMask, posixACLTagOther:
+			entry.id = posixACLUndefinedID
+		case posixACLTagUser, posixACLTagGroup:
+			if posixACLUndefinedID == entry.id {
+				err = blunder.NewError(blunder.InvalidArgError, "POSIX ACL entry with tag 0x%X missing id", entry.tag)
+				return
+			}
+		default:
+			err = blunder.NewError(blunder.InvalidArgError, "POSIX ACL entry with invalid tag 0x%X", entry.tag)
+			return
+		}
+
+		if 0 < entryIndex {
+			if (entry.tag < prevEntry.tag) || ((entry.tag == prevEntry.tag) && (entry.id <= prevEntry.id)) {
+				err = blunder.NewError(blunder.InvalidArgError, "POSIX ACL entries not sorted or duplicated")
+				return
+			}
+		}
+
+		tagCount[entry.tag]++
+
+		posixACL[entryIndex] = entry
+		prevEntry = entry
+	}
+
+	if (1 != tagCount[posixACLTagUserObj]) || (1 != tagCount[posixACLTagGroupObj]) || (1 != tagCount[posixACLTagOther]) {
+		err = blunder.NewError(blunder.InvalidArgError, "POSIX ACL missing ACL_USER_OBJ, ACL_GROUP_OBJ, or ACL_OTHER entry")
+		return
+	}
+	if (0 == tagCount[posixACLTagMask]) && ((0 != tagCount[posixACLTagUser]) || (0 != tagCount[posixACLTagGroup])) {
+		err = blunder.NewError(blunder.InvalidArgError, "POSIX ACL with ACL_USER or ACL_GROUP entries missing ACL_MASK entry")
+		return
+	}
+
+	err = nil
+	return
+}
+
+func marshalPosixACL(posixACL []posixACLEntryStruct) (buf []byte) {
+	var (
+		entry      posixACLEntryStruct
+		entryIndex int
+	)
+
+	buf = make([]byte, posixACLHeaderSize+(len(posixACL)*posixACLEntrySize))
+
+	binary.LittleEndian.PutUint32(buf[:posixACLHeaderSize], posixACLVersion)
+
+	for entryIndex, entry = range posixACL {
+		binary.LittleEndian.PutUint16(buf[posixACLHeaderSize+(entryIndex*posixACLEntrySize):], entry.tag)
+		binary.LittleEndian.PutUint16(buf[posixACLHeaderSize+(entryIndex*posixACLEntrySize)+2:], uint16(entry.perm))
+		binary.LittleEndian.PutUint32(buf[posixACLHeaderSize+(entryIndex*posixACLEntrySize)+4:], entry.id)
+	}
+
+	return
+}
+
+// posixACLModeEntry returns the index of the entry whose perm is reflected in the
+// group bits of Mode (i.e. ACL_MASK if present, else ACL_GROUP_OBJ).
+func posixACLModeEntry(posixACL []posixACLEntryStruct) (groupBitsEntryIndex int) {
+	var (
+		entry      posixACLEntryStruct
+		entryIndex int
+	)
+
+	groupBitsEntryIndex = -1
+
+	for entryIndex, entry = range posixACL {
+		switch entry.tag {
+		case posixACLTagGroupObj:
+			if -1 == groupBitsEntryIndex {
+				groupBitsEntryIndex = entryIndex
+			}
+		case posixACLTagMask:
+			groupBitsEntryIndex = entryIndex
+		}
+	}
+
+	return
+}
+
+// posixACLToMode returns the permission bits of Mode implied by posixACL.
+func posixACLToMode(posixACL []posixACLEntryStruct) (filePerm InodeMode) {
+	var (
+		entry               posixACLEntryStruct
+		groupBitsEntryIndex int
+	)
+
+	groupBitsEntryIndex = posixACLModeEntry(posixACL)
+
+	filePerm = posixACL[groupBitsEntryIndex].perm << 3
+
+	for _, entry = range posixACL {
+		switch entry.tag {
+		case posixACLTagUserObj:
+			filePerm |= entry.perm << 6
+		case posixACLTagOther:
+			filePerm |= entry.perm
+		}
+	}
+
+	return
+}
+
+// applyModeToPosixACL sets (if !maskOnly) or restricts (if maskOnly) the ACL_USER_OBJ,
+// ACL_MASK (or ACL_GROUP_OBJ), and ACL_OTHER entries of posixACL by filePerm.
+func applyModeToPosixACL(posixACL []posixACLEntryStruct, filePerm InodeMode, maskOnly bool) {
+	var (
+		entryIndex          int
+		groupBitsEntryIndex int
+		perm                InodeMode
+	)
+
+	groupBitsEntryIndex = posixACLModeEntry(posixACL)
+
+	for entryIndex = range posixACL {
+		switch {
+		case posixACLTagUserObj == posixACL[entryIndex].tag:
+			perm = (filePerm >> 6) & 07
+		case groupBitsEntryIndex == entryIndex:
+			perm = (filePerm >> 3) & 07
+		case posixACLTagOther == posixACL[entryIndex].tag:
+			perm = filePerm & 07
+		default:
+			continue
+		}
+
+		if maskOnly {
+			posixACL[entryIndex].perm &= perm
+		} else {
+			posixACL[entryIndex].perm = perm
+		}
+	}
+}
+
+// posixACLAccess performs the named user and group portion of the POSIX ACL access
+// check algorithm. The ACL_USER_OBJ entry has already been handled by the caller
+// (as it is identical to the owner check of Mode).
+func posixACLAccess(posixACL []posixACLEntryStruct, ourInodeMode InodeMode, ourInodeGroupID InodeGroupID, userID InodeUserID, groupID InodeGroupID, otherGroupIDs []InodeGroupID, accessMode InodeMode) (accessReturn bool) {
+	var (
+		entry        posixACLEntryStruct
+		groupMatched bool
+		mask         InodeMode
+		memberOf     func(entryGroupID InodeGroupID) bool
+		otherGroupID InodeGroupID
+		perm         InodeMode
+	)
+
+	memberOf = func(entryGroupID InodeGroupID) bool {
+		if groupID == entryGroupID {
+			return true
+		}
+		for _, otherGroupID = range otherGroupIDs {
+			if otherGroupID == entryGroupID {
+				return true
+			}
+		}
+		return false
+	}
+
+	if posixACLTagMask == posixACL[posixACLModeEntry(posixACL)].tag {
+		mask = (ourInodeMode >> 3) & 07
+	} else {
+		mask = 07
+	}
+
+	for _, entry = range posixACL {
+		if (posixACLTagUser == entry.tag) && (uint32(userID) == entry.id) {
+			accessReturn = ((entry.perm & mask & accessMode) == accessMode)
+			return
+		}
+	}
+
+	groupMatched = false
+
+	for _, entry = range posixACL {
+		switch entry.tag {
+		case posixACLTagGroupObj:
+			if !memberOf(ourInodeGroupID) {
+				continue
+			}
+			if posixACLTagMask == posixACL[posixACLModeEntry(posixACL)].tag {
+				perm = entry.perm
+			} else {
+				perm = (ourInodeMode >> 3) & 07
+			}
+		case posixACLTagGroup:
+			if !memberOf(InodeGroupID(entry.id)) {
+				continue
+			}
+			perm = entry.perm
+		default:
+			continue
+		}
+
+		groupMatched = true
+
+		if (perm & mask & accessMode) == accessMode {
+			accessReturn = true
+			return
+		}
+	}
+
+	if groupMatched {
+		accessReturn = false
+		return
+	}
+
+	accessReturn = (((ourInodeMode & 07) & accessMode) == accessMode)
+	return
+}
+
+// preparePosixACLStream validates a POSIX ACL about to be stored in inode. For an
+// access ACL, inode.Mode is updated to match and, should the ACL be equivalent to
+// Mode, a nil buf is returned indicating no stream should be kept.
+func (inode *inMemoryInodeStruct) preparePosixACLStream(inodeStreamName string, buf []byte) (preparedBuf []byte, err error) {
+	var (
+		posixACL []posixACLEntryStruct
+	)
+
+	if 0 == len(buf) {
+		preparedBuf = nil
+		err = nil
+		return
+	}
+
+	posixACL, err = unmarshalPosixACL(buf)
+	if nil != err {
+		return
+	}
+
+	if PosixACLDefaultStreamName == inodeStreamName {
+		if DirType != inode.InodeType {
+			err = blunder.NewError(blunder.PermDeniedError, "default POSIX ACL only supported on directories")
+			return
+		}
+
+		preparedBuf = buf
+		err = nil
+		return
+	}
+
+	inode.Mode = (inode.Mode &^ PosixModePerm) | posixACLToMode(posixACL)
+
+	if 3 == len(posixACL) {
+		preparedBuf = nil
+	} else {
+		preparedBuf = buf
+	}
+
+	err = nil
+	return
+}
+
+// syncPosixACLStream returns the access ACL in buf with its ACL_USER_OBJ, ACL_MASK
+// (or ACL_GROUP_OBJ), and ACL_OTHER entries updated to reflect ourInodeMode.
+func syncPosixACLStream(buf []byte, ourInodeMode InodeMode) (syncedBuf []byte) {
+	var (
+		err      error
+		posixACL []posixACLEntryStruct
+	)
+
+	posixACL, err = unmarshalPosixACL(buf)
+	if nil != err {
+		syncedBuf = buf
+		return
+	}
+
+	applyModeToPosixACL(posixACL, ourInodeMode, false)
+
+	syncedBuf = marshalPosixACL(posixACL)
+
+	return
+}
+
+// InheritPosixACL applies the default ACL (if any) of dirInodeNumber to the newly
+// created inodeNumber as POSIX requires. The access ACL of inodeNumber becomes the
+// default ACL of dirInodeNumber restricted by the Mode requested at creation time.
+// Directories also inherit the default ACL itself. Symlinks are left unchanged.
+func (vS *volumeStruct) InheritPosixACL(dirInodeNumber InodeNumber, inodeNumber InodeNumber) (err error) {
+	var (
+		defaultBuf     []byte
+		dirInode       *inMemoryInodeStruct
+		ok             bool
+		ourInode       *inMemoryInodeStruct
+		posixACL       []posixACLEntryStruct
+		snapShotIDType headhunter.SnapShotIDType
+	)
+
+	err = vS.enforceRWMode(false)
+	if nil != err {
+		return
+	}
+
+	snapShotIDType, _, _ = vS.headhunterVolumeHandle.SnapShotU64Decode(uint64(inodeNumber))
+	if headhunter.SnapShotIDTypeLive != snapShotIDType {
+		err = fmt.Errorf("InheritPosixACL() on non-LiveView inodeNumber not allowed")
+		return
+	}
+
+	dirInode, ok, err = vS.fetchInode(dirInodeNumber)
+	if nil != err {
+		logger.ErrorfWithError(err, "%s: fetch of directory inode failed", utils.GetFnName())
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("%s: failing request for inode %d volume '%s' because it is unallocated",
+			utils.GetFnName(), dirInodeNumber, vS.volumeName)
+		logger.ErrorWithError(err)
+		err = blunder.AddError(err, blunder.NotFoundError)
+		return
+	}
+
+	defaultBuf, ok = dirInode.StreamMap[PosixACLDefaultStreamName]
+	if !ok {
+		err = nil
+		return
+	}
+
+	ourInode, ok, err = vS.fetchInode(inodeNumber)
+	if nil != err {
+		logger.ErrorfWithError(err, "%s: fetch of target inode failed", utils.GetFnName())
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("%s: failing request for inode %d volume '%s' because it is unallocated",
+			utils.GetFnName(), inodeNumber, vS.volumeName)
+		logger.ErrorWithError(err)
+		err = blunder.AddError(err, blunder.NotFoundError)
+		return
+	}
+
+	if SymlinkType == ourInode.InodeType {
+		err = nil
+		return
+	}
+
+	posixACL, err = unmarshalPosixACL(defaultBuf)
+	if nil != err {
+		logger.WarnfWithError(err, "%s: ignoring invalid default POSIX ACL of inode %d volume '%s'", utils.GetFnName(), dirInodeNumber, vS.volumeName)
+		err = nil
+		return
+	}
+
+	if DirType == ourInode.InodeType {
+		ourInode.StreamMap[PosixACLDefaultStreamName] = marshalPosixACL(posixACL)
+	}
+
+	applyModeToPosixACL(posixACL, ourInode.Mode, true)
+
+	ourInode.Mode = (ourInode.Mode &^ PosixModePerm) | posixACLToMode(posixACL)
+
+	if 3 != len(posixACL) {
+		ourInode.StreamMap[PosixACLAccessStreamName] = marshalPosixACL(posixACL)
+	}
+
+	ourInode.dirty = true
+	ourInode.AttrChangeTime = time.Now()
+
+	err = vS.flushInode(ourInode)
+	if nil != err {
+		logger.ErrorWithError(err)
+		return
+	}
+
+	return
+}
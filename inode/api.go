@@ -64,6 +64,14 @@ const (
 	OwnerOverride
 )
 
+// POSIX ACLs are kept in the following streams using the Linux "system.posix_acl_*"
+// extended attribute encoding. The permissions of the ACL_USER_OBJ, ACL_MASK (or, if
+// absent, ACL_GROUP_OBJ), and ACL_OTHER entries of an access ACL always track Mode.
+const (
+	PosixACLAccessStreamName  = "system.posix_acl_access"
+	PosixACLDefaultStreamName = "system.posix_acl_default"
+)
+
 // The following line of code is a directive to go generate that tells it to create a
 // file called inodetype_string.go that implements the .String() method for InodeType.
 //go:generate stringer -type=InodeType
@@ -81,6 +89,7 @@ type MetadataStruct struct {
 	Mode                 InodeMode
 	UserID               InodeUserID
 	GroupID              InodeGroupID
+	ProjectID            uint64 // 0 == not part of a project (i.e. directory tree) quota
 }
 
 type FragmentationReport struct {
@@ -89,6 +98,33 @@ type FragmentationReport struct {
 	BytesTrapped      uint64 // unreferenced bytes trapped in referenced log segments
 }
 
+// QuotaType identifies what a quota is charged against. For QuotaTypeProject,
+// the quota ID is the ProjectID assigned to the inodes of a directory tree.
+type QuotaType string
+
+const (
+	QuotaTypeUser    QuotaType = "user"
+	QuotaTypeGroup   QuotaType = "group"
+	QuotaTypeProject QuotaType = "project"
+)
+
+type QuotaLimitsStruct struct {
+	MaxBytes  uint64 // Sum of FileInode Size's; 0 == unlimited
+	MaxInodes uint64 // Count of all Inodes;      0 == unlimited
+}
+
+type QuotaUsageStruct struct {
+	Bytes  uint64
+	Inodes uint64
+}
+
+type QuotaStruct struct {
+	Type QuotaType
+	ID   uint64
+	QuotaLimitsStruct
+	QuotaUsageStruct
+}
+
 type DirEntry struct {
 	InodeNumber
 	Basename        string
@@ -103,21 +139,67 @@ type CoalesceElement struct {
 }
 
 type ReadPlanStep struct {
-	LogSegmentNumber uint64 // If == 0, Length specifies zero-fill size
-	Offset           uint64 // If zero-fill case, == 0
-	Length           uint64 // Must != 0
-	AccountName      string // If == "", Length specifies a zero-fill size
-	ContainerName    string // If == "", Length specifies a zero-fill size
-	ObjectName       string // If == "", Length specifies a zero-fill size
-	ObjectPath       string // If == "", Length specifies a zero-fill size
+	LogSegmentNumber uint64                     // If == 0, Length specifies zero-fill size
+	Offset           uint64                     // If zero-fill case, == 0
+	Length           uint64                     // Must != 0
+	AccountName      string                     // If == "", Length specifies a zero-fill size
+	ContainerName    string                     // If == "", Length specifies a zero-fill size
+	ObjectName       string                     // If == "", Length specifies a zero-fill size
+	ObjectPath       string                     // If == "", Length specifies a zero-fill size
+	Checksums        *LogSegmentChecksumsStruct // If != nil, covers the LogSegment from its start (not just this step)
+}
+
+// LogSegmentChecksumsStruct holds the CRC32C (Castagnoli) of each BlockSize block
+// of the first Length bytes of a LogSegment (the last of which may be short).
+type LogSegmentChecksumsStruct struct {
+	BlockSize uint64
+	Length    uint64
+	CRC32C    []uint32
+}
+
+// CorruptExtentStruct describes a range of a file whose data is missing from its
+// LogSegment or failed verification against its checksums. A failure to fetch the
+// data at all (e.g. a failed GET) is instead returned by VerifyFileData() as an error.
+type CorruptExtentStruct struct {
+	FileOffset       uint64
+	Length           uint64
+	LogSegmentNumber uint64
+	LogSegmentOffset uint64
+}
+
+// LogSegmentCompressedChunkStruct maps a chunk of a LogSegment's uncompressed (logical)
+// bytes to the bytes actually stored (physical). Should PhysicalLength == LogicalLength,
+// the chunk was stored uncompressed.
+type LogSegmentCompressedChunkStruct struct {
+	LogicalOffset  uint64
+	LogicalLength  uint64
+	PhysicalOffset uint64
+	PhysicalLength uint64
+}
+
+// LogSegmentCompressionStruct describes how each chunk of a compressed (and/or
+// encrypted) LogSegment was stored. LogSegmentOffsets of extents referencing it
+// are logical offsets.
+type LogSegmentCompressionStruct struct {
+	Algorithm string
+	Encrypted bool // if true, each chunk was sealed (following any compression)
+	Chunk     []LogSegmentCompressedChunkStruct
+}
+
+// CompressionStatsStruct totals the logical and physical bytes of a volume's compressed LogSegments.
+type CompressionStatsStruct struct {
+	LogicalBytes  uint64
+	PhysicalBytes uint64
 }
 
 type ExtentMapEntryStruct struct {
 	FileOffset       uint64
 	LogSegmentOffset uint64
 	Length           uint64
-	ContainerName    string // While "read-as-zero" entries in ExtentMapShunkStruct
-	ObjectName       string //   are not present, {Container|Object}Name would be == ""
+	ContainerName    string                       // While "read-as-zero" entries in ExtentMapShunkStruct
+	ObjectName       string                       //   are not present, {Container|Object}Name would be == ""
+	Compression      *LogSegmentCompressionStruct // If != nil, LogSegmentOffset must be mapped thru Compression
+	Checksums        *LogSegmentChecksumsStruct   // If != nil, covers the (logical) LogSegment from its start
 }
 
 type ExtentMapChunkStruct struct {
@@ -154,6 +236,11 @@ func AccountNameToVolumeName(accountName string) (volumeName string, ok bool) {
 	return
 }
 
+// IsPosixACLStreamName reports whether inodeStreamName holds a POSIX ACL.
+func IsPosixACLStreamName(inodeStreamName string) bool {
+	return (PosixACLAccessStreamName == inodeStreamName) || (PosixACLDefaultStreamName == inodeStreamName)
+}
+
 // VolumeNameToAccountName returns the corresponding accountName for the supplied volumeName (if any).
 func VolumeNameToAccountName(volumeName string) (accountName string, ok bool) {
 	accountName, ok = volumeNameToAccountName(volumeName)
@@ -179,8 +266,12 @@ type VolumeHandle interface {
 	// Generic methods, implemented volume.go
 
 	GetFSID() (fsid uint64)
+	IsEncrypted() (encrypted bool)            // If true, LogSegments are sealed (see LogSegmentCompressionStruct.ReadSealed())
+	ObjectStoreBackend() (backendName string) // See swiftclient.AccountBackend()
 	SnapShotCreate(name string) (id uint64, err error)
 	SnapShotDelete(id uint64) (err error)
+	SnapShotRollback(id uint64) (err error)
+	SnapShotPin(id uint64, pinned bool) (err error)
 
 	// Wrapper methods around DLM locks.  Implemented in locker.go
 
@@ -219,6 +310,29 @@ type VolumeHandle interface {
 	Optimize(inodeNumber InodeNumber, maxDuration time.Duration) (err error)
 	Validate(inodeNumber InodeNumber, deeply bool) (err error)
 
+	// Quota methods, implemented in quota.go
+
+	FetchQuota(quotaType QuotaType, quotaID uint64) (quota QuotaStruct, err error)
+	FetchQuotaList() (quotaList []QuotaStruct)
+	SetQuotaLimits(quotaType QuotaType, quotaID uint64, quotaLimits QuotaLimitsStruct) (err error)
+	SetProjectID(inodeNumber InodeNumber, projectID uint64) (err error)
+
+	// POSIX ACL methods, implemented in acl.go
+
+	InheritPosixACL(dirInodeNumber InodeNumber, inodeNumber InodeNumber) (err error)
+
+	// LogSegment checksum methods, implemented in checksum.go
+
+	VerifyFileData(fileInodeNumber InodeNumber) (corruptExtents []CorruptExtentStruct, err error)
+
+	// LogSegment compression methods, implemented in compression.go
+
+	FetchCompressionStats() (compressionStats CompressionStatsStruct)
+
+	// SnapShot clone methods, implemented in clone.go
+
+	CloneInode(snapShotInodeNumber InodeNumber) (inodeNumber InodeNumber, err error)
+
 	// Directory Inode specific methods, implemented in dir.go
 
 	CreateDir(filePerm InodeMode, userID InodeUserID, groupID InodeGroupID) (dirInodeNumber InodeNumber, err error)
@@ -239,8 +353,11 @@ type VolumeHandle interface {
 	FetchExtentMapChunk(fileInodeNumber InodeNumber, fileOffset uint64, maxEntriesFromFileOffset int64, maxEntriesBeforeFileOffset int64) (extentMapChunk *ExtentMapChunkStruct, err error)
 	Write(fileInodeNumber InodeNumber, offset uint64, buf []byte, profiler *utils.Profiler) (err error)
 	ProvisionObject() (objectPath string, err error)
-	Wrote(fileInodeNumber InodeNumber, containerName string, objectName string, fileOffset []uint64, objectOffset []uint64, length []uint64, wroteTime time.Time, patchOnly bool) (err error)
+	Wrote(fileInodeNumber InodeNumber, containerName string, objectName string, fileOffset []uint64, objectOffset []uint64, length []uint64, checksums *LogSegmentChecksumsStruct, compression *LogSegmentCompressionStruct, wroteTime time.Time, patchOnly bool) (err error)
 	SetSize(fileInodeNumber InodeNumber, Size uint64) (err error)
+	PunchHole(fileInodeNumber InodeNumber, offset uint64, length uint64) (err error)
+	SeekData(fileInodeNumber InodeNumber, offset uint64) (dataOffset uint64, err error)
+	SeekHole(fileInodeNumber InodeNumber, offset uint64) (holeOffset uint64, err error)
 	Flush(fileInodeNumber InodeNumber, andPurge bool) (err error)
 	Coalesce(destInodeNumber InodeNumber, metaDataName string, metaData []byte, elements []*CoalesceElement) (attrChangeTime time.Time, modificationTime time.Time, numWrites uint64, fileSize uint64, err error)
 	DefragmentFile(fileInodeNumber InodeNumber, startingFileOffset uint64, chunkSize uint64) (nextFileOffset uint64, eofReached bool, err error)
@@ -1066,7 +1066,7 @@ func TestAPI(t *testing.T) {
 		t.Fatalf("GetMetadata(fileInodeNumber) failed: %v", err)
 	}
 	checkMetadata(t, postMetadata, testMetadata, MetadataNumWritesField, "GetMetadata() before Wrote(,,,,,,,true)")
-	err = testVolumeHandle.Wrote(fileInodeNumber, containerName, objectName, []uint64{1}, []uint64{0}, []uint64{3}, time.Now(), true)
+	err = testVolumeHandle.Wrote(fileInodeNumber, containerName, objectName, []uint64{1}, []uint64{0}, []uint64{3}, nil, nil, time.Now(), true)
 	if nil != err {
 		t.Fatalf("Wrote(fileInodeNumber, containerName, objectName, []uint64{1}, []uint64{0}, []uint64{3}, time.Now(), true) failed: %v", err)
 	}
@@ -1253,7 +1253,7 @@ func TestAPI(t *testing.T) {
 		t.Fatalf("GetMetadata(fileInodeNumber) failed: %v", err)
 	}
 	checkMetadata(t, postMetadata, testMetadata, MetadataNumWritesField, "GetMetadata() before Wrote(,,,,,,,false)")
-	err = testVolumeHandle.Wrote(fileInodeNumber, containerName, objectName, []uint64{0}, []uint64{0}, []uint64{2}, time.Now(), false)
+	err = testVolumeHandle.Wrote(fileInodeNumber, containerName, objectName, []uint64{0}, []uint64{0}, []uint64{2}, nil, nil, time.Now(), false)
 	if nil != err {
 		t.Fatalf("Wrote(fileInodeNumber, containerName, objectName, []uint64{0}, []uint64{0}, []uint64{2}, time.Now(), false) failed: %v", err)
 	}
@@ -2220,7 +2220,7 @@ func TestAPI(t *testing.T) {
 	if err != nil {
 		t.Fatalf("couldn't parse %v as object path", objectPath)
 	}
-	err = testVolumeHandle.Wrote(fileInode, containerName, objectName, []uint64{0}, []uint64{0}, []uint64{2}, time.Now(), false)
+	err = testVolumeHandle.Wrote(fileInode, containerName, objectName, []uint64{0}, []uint64{0}, []uint64{2}, nil, nil, time.Now(), false)
 	if nil != err {
 		t.Fatalf("Wrote(fileInode, objectPath, []uint64{0}, []uint64{0}, []uint64{2}, time.Now(), false) failed: %v", err)
 	}
@@ -0,0 +1,431 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package inode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/utils"
+)
+
+// LogSegment checksums are recorded alongside the ContainerName in each LogSegment's
+// headhunter LogSegmentRec (little endian):
+//
+//   ContainerName
+//   NUL
+//   uint64 BlockSize
+//   uint64 Length
+//   { uint32 CRC32C } ...
+//
+// LogSegmentRecs lacking the NUL (e.g. those recorded prior to checksum support or
+// for LogSegments written by a client not supplying them) have nothing to verify.
+// The checksums of a compressed LogSegment are followed by its compression (see
+// compression.go).
+
+const (
+	logSegmentRecChecksumsSeparator = byte(0)
+	logSegmentRecChecksumsFixedSize = 8 + 8
+	logSegmentRecChecksumSize       = 4
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// NewLogSegmentChecksums returns an empty LogSegmentChecksumsStruct that will
+// checksum each blockSize block of data subsequently passed to Append().
+//
+func NewLogSegmentChecksums(blockSize uint64) (checksums *LogSegmentChecksumsStruct) {
+	checksums = &LogSegmentChecksumsStruct{
+		BlockSize: blockSize,
+		Length:    0,
+		CRC32C:    make([]uint32, 0),
+	}
+
+	return
+}
+
+// Append extends checksums to cover buf as if it immediately followed the
+// checksums.Length bytes of the LogSegment already covered.
+//
+func (checksums *LogSegmentChecksumsStruct) Append(buf []byte) {
+	var (
+		blockRemaining uint64
+		chunk          []byte
+	)
+
+	for 0 < len(buf) {
+		blockRemaining = checksums.BlockSize - (checksums.Length % checksums.BlockSize)
+
+		if uint64(len(buf)) > blockRemaining {
+			chunk = buf[:blockRemaining]
+		} else {
+			chunk = buf
+		}
+
+		if blockRemaining == checksums.BlockSize {
+			checksums.CRC32C = append(checksums.CRC32C, crc32.Checksum(chunk, crc32cTable))
+		} else {
+			checksums.CRC32C[len(checksums.CRC32C)-1] = crc32.Update(checksums.CRC32C[len(checksums.CRC32C)-1], crc32cTable, chunk)
+		}
+
+		checksums.Length += uint64(len(chunk))
+		buf = buf[len(chunk):]
+	}
+}
+
+// Verify checks buf, read from the LogSegment at logSegmentOffset, against checksums.
+// The logSegmentOffset must be a multiple of checksums.BlockSize and buf must cover
+// whole blocks (other than the final, possibly short, block of the LogSegment). Any
+// portion of buf beyond checksums.Length is ignored. Should a mismatch be detected,
+// corruptOffset reports the LogSegment offset of the block that failed verification.
+//
+func (checksums *LogSegmentChecksumsStruct) Verify(logSegmentOffset uint64, buf []byte) (corruptOffset uint64, err error) {
+	var (
+		blockIndex  uint64
+		blockLength uint64
+	)
+
+	if 0 != (logSegmentOffset % checksums.BlockSize) {
+		err = blunder.NewError(blunder.InvalidArgError, "logSegmentOffset 0x%016X not a multiple of BlockSize 0x%016X", logSegmentOffset, checksums.BlockSize)
+		return
+	}
+
+	for (0 < len(buf)) && (logSegmentOffset < checksums.Length) {
+		blockIndex = logSegmentOffset / checksums.BlockSize
+
+		if (logSegmentOffset + checksums.BlockSize) > checksums.Length {
+			blockLength = checksums.Length - logSegmentOffset
+		} else {
+			blockLength = checksums.BlockSize
+		}
+
+		if (uint64(len(buf)) < blockLength) || (crc32.Checksum(buf[:blockLength], crc32cTable) != checksums.CRC32C[blockIndex]) {
+			corruptOffset = logSegmentOffset
+			err = blunder.NewError(blunder.ChecksumError, "checksum mismatch at LogSegment offset 0x%016X", logSegmentOffset)
+			return
+		}
+
+		logSegmentOffset += blockLength
+		buf = buf[blockLength:]
+	}
+
+	err = nil
+	return
+}
+
+// VerifyCovered checks those blocks lying wholly within buf, read from the LogSegment
+// at logSegmentOffset, against checksums. Unlike Verify(), neither logSegmentOffset nor
+// the end of buf need be aligned to checksums.BlockSize... any partially covered block
+// at either end is simply not checked. Should a mismatch be detected, corruptOffset
+// reports the LogSegment offset of the block that failed verification.
+//
+func (checksums *LogSegmentChecksumsStruct) VerifyCovered(logSegmentOffset uint64, buf []byte) (corruptOffset uint64, err error) {
+	var (
+		bufEnd     uint64
+		blockStart uint64
+		verifyEnd  uint64
+	)
+
+	blockStart = ((logSegmentOffset + checksums.BlockSize - 1) / checksums.BlockSize) * checksums.BlockSize
+
+	bufEnd = logSegmentOffset + uint64(len(buf))
+
+	if bufEnd >= checksums.Length {
+		verifyEnd = checksums.Length
+	} else {
+		verifyEnd = (bufEnd / checksums.BlockSize) * checksums.BlockSize
+	}
+
+	if verifyEnd <= blockStart {
+		err = nil
+		return
+	}
+
+	corruptOffset, err = checksums.Verify(blockStart, buf[blockStart-logSegmentOffset:verifyEnd-logSegmentOffset])
+
+	return
+}
+
+func encodeLogSegmentRec(containerName string, checksums *LogSegmentChecksumsStruct, compression *LogSegmentCompressionStruct) (value []byte) {
+	var (
+		checksumIndex  int
+		checksumsBuf   []byte
+		compressionBuf []byte
+	)
+
+	if nil == checksums {
+		value = utils.StringToByteSlice(containerName)
+		return
+	}
+
+	checksumsBuf = make([]byte, logSegmentRecChecksumsFixedSize+(len(checksums.CRC32C)*logSegmentRecChecksumSize))
+
+	binary.LittleEndian.PutUint64(checksumsBuf[0:], checksums.BlockSize)
+	binary.LittleEndian.PutUint64(checksumsBuf[8:], checksums.Length)
+
+	for checksumIndex = range checksums.CRC32C {
+		binary.LittleEndian.PutUint32(checksumsBuf[logSegmentRecChecksumsFixedSize+(checksumIndex*logSegmentRecChecksumSize):], checksums.CRC32C[checksumIndex])
+	}
+
+	if nil == compression {
+		compressionBuf = make([]byte, 0)
+	} else {
+		compressionBuf = encodeLogSegmentRecCompression(compression)
+	}
+
+	value = make([]byte, 0, len(containerName)+1+len(checksumsBuf)+len(compressionBuf))
+	value = append(value, containerName...)
+	value = append(value, logSegmentRecChecksumsSeparator)
+	value = append(value, checksumsBuf...)
+	value = append(value, compressionBuf...)
+
+	return
+}
+
+func decodeLogSegmentRec(value []byte) (containerName string, checksums *LogSegmentChecksumsStruct, compression *LogSegmentCompressionStruct, err error) {
+	var (
+		checksumIndex  int
+		checksumsBuf   []byte
+		numChecksums   uint64
+		separatorIndex int
+	)
+
+	separatorIndex = bytes.IndexByte(value, logSegmentRecChecksumsSeparator)
+	if 0 > separatorIndex {
+		containerName = utils.ByteSliceToString(value)
+		checksums = nil
+		compression = nil
+		err = nil
+		return
+	}
+
+	containerName = utils.ByteSliceToString(value[:separatorIndex])
+	checksumsBuf = value[separatorIndex+1:]
+
+	if logSegmentRecChecksumsFixedSize > len(checksumsBuf) {
+		err = fmt.Errorf("LogSegmentRec for container %s has malformed checksums", containerName)
+		return
+	}
+
+	checksums = &LogSegmentChecksumsStruct{
+		BlockSize: binary.LittleEndian.Uint64(checksumsBuf[0:]),
+		Length:    binary.LittleEndian.Uint64(checksumsBuf[8:]),
+	}
+
+	if 0 == checksums.BlockSize {
+		err = fmt.Errorf("LogSegmentRec for container %s has inconsistent checksums", containerName)
+		return
+	}
+
+	numChecksums = (checksums.Length + checksums.BlockSize - 1) / checksums.BlockSize
+
+	if uint64(len(checksumsBuf)) < (logSegmentRecChecksumsFixedSize + (numChecksums * logSegmentRecChecksumSize)) {
+		err = fmt.Errorf("LogSegmentRec for container %s has inconsistent checksums", containerName)
+		return
+	}
+
+	checksums.CRC32C = make([]uint32, numChecksums)
+
+	for checksumIndex = range checksums.CRC32C {
+		checksums.CRC32C[checksumIndex] = binary.LittleEndian.Uint32(checksumsBuf[logSegmentRecChecksumsFixedSize+(checksumIndex*logSegmentRecChecksumSize):])
+	}
+
+	checksumsBuf = checksumsBuf[logSegmentRecChecksumsFixedSize+(numChecksums*logSegmentRecChecksumSize):]
+
+	if 0 == len(checksumsBuf) {
+		compression = nil
+	} else {
+		compression, err = decodeLogSegmentRecCompression(containerName, checksumsBuf, checksums)
+		if nil != err {
+			return
+		}
+	}
+
+	err = nil
+	return
+}
+
+func (vS *volumeStruct) getLogSegmentChecksums(logSegmentNumber uint64) (checksums *LogSegmentChecksumsStruct, err error) {
+	value, err := vS.headhunterVolumeHandle.GetLogSegmentRec(logSegmentNumber)
+	if nil != err {
+		return
+	}
+	_, checksums, _, err = decodeLogSegmentRec(value)
+	return
+}
+
+func (vS *volumeStruct) getLogSegmentChecksumsAndCompression(logSegmentNumber uint64) (checksums *LogSegmentChecksumsStruct, compression *LogSegmentCompressionStruct, err error) {
+	value, err := vS.headhunterVolumeHandle.GetLogSegmentRec(logSegmentNumber)
+	if nil != err {
+		return
+	}
+	_, checksums, compression, err = decodeLogSegmentRec(value)
+	return
+}
+
+// putLogSegmentChecksums records checksums (and compression, if any) for a LogSegment
+// whose LogSegmentRec has already been recorded. Should the LogSegment have since been
+// deleted, there is nothing to record.
+//
+func (vS *volumeStruct) putLogSegmentChecksums(logSegmentNumber uint64, containerName string, checksums *LogSegmentChecksumsStruct, compression *LogSegmentCompressionStruct) (err error) {
+	_, err = vS.headhunterVolumeHandle.GetLogSegmentRec(logSegmentNumber)
+	if nil != err {
+		err = nil
+		return
+	}
+
+	err = vS.headhunterVolumeHandle.PutLogSegmentRec(logSegmentNumber, encodeLogSegmentRec(containerName, checksums, compression))
+
+	return
+}
+
+type verifiedBlockKeyStruct struct {
+	logSegmentNumber uint64
+	blockOffset      uint64
+}
+
+func (vS *volumeStruct) VerifyFileData(fileInodeNumber InodeNumber) (corruptExtents []CorruptExtentStruct, err error) {
+	var (
+		blockEnd         uint64
+		blockOffset      uint64
+		blockSize        uint64
+		buf              []byte
+		checksums        *LogSegmentChecksumsStruct
+		checksumsMap     map[uint64]*LogSegmentChecksumsStruct
+		containerName    string
+		extentEnd        uint64
+		extentIndex      int
+		extentValue      sortedmap.Value
+		extents          sortedmap.BPlusTree
+		fileExtent       *fileExtentStruct
+		fileInode        *inMemoryInodeStruct
+		logSegmentNumber uint64
+		objectName       string
+		ok               bool
+		overlapEnd       uint64
+		overlapStart     uint64
+		snapShotID       uint64
+		verifiedBlockKey verifiedBlockKeyStruct
+		verifiedBlockMap map[verifiedBlockKeyStruct]bool
+		verifiedBlockOK  bool
+	)
+
+	fileInode, err = vS.fetchInodeType(fileInodeNumber, FileType)
+	if nil != err {
+		return
+	}
+
+	if fileInode.dirty {
+		err = flush(fileInode, false)
+		if nil != err {
+			return
+		}
+	}
+
+	_, snapShotID, _ = vS.headhunterVolumeHandle.SnapShotU64Decode(uint64(fileInodeNumber))
+
+	corruptExtents = make([]CorruptExtentStruct, 0)
+
+	checksumsMap = make(map[uint64]*LogSegmentChecksumsStruct)
+	verifiedBlockMap = make(map[verifiedBlockKeyStruct]bool)
+
+	extents = fileInode.payload.(sortedmap.BPlusTree)
+
+	for extentIndex = 0; ; extentIndex++ {
+		_, extentValue, ok, err = extents.GetByIndex(extentIndex)
+		if nil != err {
+			return
+		}
+		if !ok {
+			break
+		}
+
+		fileExtent = extentValue.(*fileExtentStruct)
+		extentEnd = fileExtent.LogSegmentOffset + fileExtent.Length
+
+		logSegmentNumber = vS.headhunterVolumeHandle.SnapShotIDAndNonceEncode(snapShotID, fileExtent.LogSegmentNumber)
+
+		checksums, ok = checksumsMap[logSegmentNumber]
+		if !ok {
+			checksums, err = vS.getLogSegmentChecksums(logSegmentNumber)
+			if nil != err {
+				return
+			}
+			checksumsMap[logSegmentNumber] = checksums
+		}
+
+		containerName, objectName, _, err = vS.getObjectLocationFromLogSegmentNumber(logSegmentNumber)
+		if nil != err {
+			return
+		}
+
+		if nil == checksums {
+			// Without checksums, the best we can do is ensure every referenced byte can be read
+			blockSize = vS.volumeGroup.readCacheLineSize
+		} else {
+			blockSize = checksums.BlockSize
+		}
+
+		verifiedBlockKey.logSegmentNumber = logSegmentNumber
+
+		for blockOffset = fileExtent.LogSegmentOffset - (fileExtent.LogSegmentOffset % blockSize); blockOffset < extentEnd; blockOffset += blockSize {
+			blockEnd = blockOffset + blockSize
+
+			if nil == checksums {
+				if blockEnd > extentEnd {
+					blockEnd = extentEnd
+				}
+				buf, err = vS.readLogSegment(logSegmentNumber, containerName, objectName, blockOffset, blockEnd-blockOffset)
+				if nil != err {
+					return
+				}
+				verifiedBlockOK = (uint64(len(buf)) >= (blockEnd - blockOffset))
+			} else {
+				// Adjacent extents may share a block, so only fetch & verify each block once
+
+				verifiedBlockKey.blockOffset = blockOffset
+
+				verifiedBlockOK, ok = verifiedBlockMap[verifiedBlockKey]
+				if !ok {
+					_, err = vS.readLogSegment(logSegmentNumber, containerName, objectName, blockOffset, blockSize)
+					if nil == err {
+						verifiedBlockOK = true
+					} else if blunder.Is(err, blunder.ChecksumError) {
+						verifiedBlockOK = false
+					} else {
+						// Failing to fetch the block does not mean it is corrupt
+						return
+					}
+					verifiedBlockMap[verifiedBlockKey] = verifiedBlockOK
+				}
+			}
+
+			if !verifiedBlockOK {
+				overlapStart = blockOffset
+				if overlapStart < fileExtent.LogSegmentOffset {
+					overlapStart = fileExtent.LogSegmentOffset
+				}
+				overlapEnd = blockEnd
+				if overlapEnd > extentEnd {
+					overlapEnd = extentEnd
+				}
+
+				corruptExtents = append(corruptExtents, CorruptExtentStruct{
+					FileOffset:       fileExtent.FileOffset + (overlapStart - fileExtent.LogSegmentOffset),
+					Length:           overlapEnd - overlapStart,
+					LogSegmentNumber: logSegmentNumber,
+					LogSegmentOffset: overlapStart,
+				})
+			}
+		}
+	}
+
+	err = nil
+	return
+}
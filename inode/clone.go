@@ -0,0 +1,178 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package inode
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/headhunter"
+)
+
+// A writable clone of a SnapShot's file shares, copy-on-write, the B+Tree pages of
+// the SnapShot file's extent map as well as the LogSegments those extents reference.
+// As B+Tree pages are never modified in place (see treeNodeLoadable.PutNode), the
+// clone's first modification of a page simply writes a new one and discards (i.e.
+// releases its share of) the old one. The same holds for LogSegments once no extent
+// of the clone references them. Headhunter tracks the shares so that neither the
+// SnapShot's deletion nor the clone's modification reclaims what the other still uses.
+//
+// Directories and symlinks are not shared: a cloned directory starts out empty (the
+// caller is expected to populate it with clones of the SnapShot directory's entries)
+// while a cloned symlink simply copies the SnapShot symlink's target.
+
+func (vS *volumeStruct) CloneInode(snapShotInodeNumber InodeNumber) (inodeNumber InodeNumber, err error) {
+	var (
+		byteCount        uint64
+		cloneInode       *inMemoryInodeStruct
+		layoutReport     sortedmap.LayoutReport
+		logSegmentNumber uint64
+		objectNumber     uint64
+		ok               bool
+		snapShotID       uint64
+		snapShotIDType   headhunter.SnapShotIDType
+		snapShotInode    *inMemoryInodeStruct
+		streamBuf        []byte
+		streamName       string
+	)
+
+	err = vS.enforceRWMode(false)
+	if nil != err {
+		return
+	}
+
+	snapShotIDType, snapShotID, _ = vS.headhunterVolumeHandle.SnapShotU64Decode(uint64(snapShotInodeNumber))
+	if headhunter.SnapShotIDTypeSnapShot != snapShotIDType {
+		err = blunder.NewError(blunder.InvalidArgError, "CloneInode(0x%016X) requires an inodeNumber in a SnapShot", snapShotInodeNumber)
+		return
+	}
+
+	snapShotInode, ok, err = vS.fetchInode(snapShotInodeNumber)
+	if nil != err {
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("CloneInode(0x%016X) found SnapShot inode unallocated", snapShotInodeNumber)
+		err = blunder.AddError(err, blunder.NotFoundError)
+		return
+	}
+
+	// Note that, like CreateFile() et al, the clone is not (yet) part of any project
+
+	err = vS.quotaReserve(quotaKeysFor(snapShotInode.UserID, snapShotInode.GroupID, 0), snapShotInode.quotaBytes(), 1)
+	if nil != err {
+		return
+	}
+
+	defer func() {
+		if nil != err {
+			vS.quotaAdjust(quotaKeysFor(snapShotInode.UserID, snapShotInode.GroupID, 0), -int64(snapShotInode.quotaBytes()), -1)
+		}
+	}()
+
+	cloneInode, err = vS.makeInMemoryInode(snapShotInode.InodeType, snapShotInode.Mode, snapShotInode.UserID, snapShotInode.GroupID)
+	if nil != err {
+		return
+	}
+
+	cloneInode.CreationTime = snapShotInode.CreationTime
+	cloneInode.ModificationTime = snapShotInode.ModificationTime
+	cloneInode.AccessTime = snapShotInode.AccessTime
+
+	for streamName, streamBuf = range snapShotInode.StreamMap {
+		cloneInode.StreamMap[streamName] = make([]byte, len(streamBuf))
+		copy(cloneInode.StreamMap[streamName], streamBuf)
+	}
+
+	switch snapShotInode.InodeType {
+	case DirType:
+		cloneInode.payload =
+			sortedmap.NewBPlusTree(
+				vS.maxEntriesPerDirNode,
+				sortedmap.CompareString,
+				&dirInodeCallbacks{treeNodeLoadable{inode: cloneInode}},
+				globals.dirEntryCache)
+
+		ok, err = cloneInode.payload.(sortedmap.BPlusTree).Put(".", cloneInode.InodeNumber)
+		if nil != err {
+			return
+		}
+		if !ok {
+			err = fmt.Errorf("CloneInode(0x%016X) unable to insert \".\" into clone", snapShotInodeNumber)
+			return
+		}
+
+		cloneInode.LinkCount = 1
+	case FileType:
+		// Should sharing fail part way, shares already taken merely delay reclamation
+
+		if 0 == snapShotInode.PayloadObjectNumber {
+			cloneInode.payload =
+				sortedmap.NewBPlusTree(
+					vS.maxExtentsPerFileNode,
+					sortedmap.CompareUint64,
+					&fileInodeCallbacks{treeNodeLoadable{inode: cloneInode}},
+					globals.fileExtentMapCache)
+		} else {
+			layoutReport, err = snapShotInode.payload.(sortedmap.BPlusTree).FetchLayoutReport()
+			if nil != err {
+				return
+			}
+
+			for objectNumber = range layoutReport {
+				err = vS.headhunterVolumeHandle.ShareBPlusTreeObject(vS.headhunterVolumeHandle.SnapShotIDAndNonceEncode(snapShotID, objectNumber))
+				if nil != err {
+					err = blunder.AddError(err, blunder.IOError)
+					return
+				}
+			}
+
+			cloneInode.PayloadObjectNumber = snapShotInode.PayloadObjectNumber
+			cloneInode.PayloadObjectLength = snapShotInode.PayloadObjectLength
+
+			cloneInode.payload, err =
+				sortedmap.OldBPlusTree(
+					cloneInode.PayloadObjectNumber,
+					onDiskInodeV1PayloadObjectOffset,
+					cloneInode.PayloadObjectLength,
+					sortedmap.CompareUint64,
+					&fileInodeCallbacks{treeNodeLoadable{inode: cloneInode}},
+					globals.fileExtentMapCache)
+			if nil != err {
+				return
+			}
+		}
+
+		for logSegmentNumber, byteCount = range snapShotInode.LogSegmentMap {
+			err = vS.shareLogSegmentRec(vS.headhunterVolumeHandle.SnapShotIDAndNonceEncode(snapShotID, logSegmentNumber))
+			if nil != err {
+				err = blunder.AddError(err, blunder.IOError)
+				return
+			}
+
+			cloneInode.LogSegmentMap[logSegmentNumber] = byteCount
+		}
+
+		cloneInode.Size = snapShotInode.Size
+		cloneInode.NumWrites = snapShotInode.NumWrites
+	case SymlinkType:
+		cloneInode.SymlinkTarget = snapShotInode.SymlinkTarget
+	}
+
+	ok, err = vS.inodeCacheInsert(cloneInode)
+	if nil != err {
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("inodeCacheInsert(cloneInode) failed")
+		return
+	}
+
+	inodeNumber = cloneInode.InodeNumber
+
+	err = nil
+	return
+}
@@ -0,0 +1,807 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package inode
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/swiftclient"
+	"github.com/NVIDIA/proxyfs/trackedlock"
+	"github.com/NVIDIA/proxyfs/utils"
+)
+
+// Each chunk sent to a LogSegment may be compressed according to the volume's
+// Compression setting. Extents continue to address the uncompressed ("logical")
+// bytes of a LogSegment. The mapping of each chunk to the compressed ("physical")
+// bytes actually stored is recorded following the checksums in the LogSegment's
+// headhunter LogSegmentRec (little endian):
+//
+//   uint64 Algorithm
+//   { uint64 LogicalLength; uint64 PhysicalLength } ...
+//
+// A chunk that would not shrink is stored as is (i.e. PhysicalLength == LogicalLength).
+// Checksums always cover the logical bytes. Only Go's standard library compression
+// (flate) is available in this build. A volume configured for zstd or lz4 falls
+// back to flate.
+//
+// On an encrypted volume (see headhunter's IsEncrypted()), each (possibly compressed)
+// chunk is additionally sealed using headhunter's Seal() with the LogSegment number
+// and the chunk's PhysicalOffset. Such LogSegments always carry the chunk map (with
+// logSegmentRecCompressionEncrypted set in Algorithm) whether or not compressed.
+// Clients reading or writing LogSegments directly (i.e. PFSAgent) must hold the
+// volume's keys: they open chunks found via FetchExtentMapChunk() with ReadSealed()
+// and pass the chunk map of each (sealed but uncompressed) LogSegment they write
+// to Wrote(). GetReadPlan() remains unsupported on encrypted volumes.
+
+const (
+	CompressionNone  = "none"
+	CompressionFlate = "flate"
+)
+
+const (
+	logSegmentRecCompressionFixedSize = 8
+	logSegmentRecCompressionChunkSize = 8 + 8
+
+	logSegmentRecCompressionNone  = uint64(0) // Only valid with logSegmentRecCompressionEncrypted
+	logSegmentRecCompressionFlate = uint64(1)
+
+	logSegmentRecCompressionEncrypted = uint64(1) << 63
+)
+
+// parseCompression validates a volume's Compression setting.
+func parseCompression(volumeName string, compression string) (algorithm string, err error) {
+	switch compression {
+	case CompressionNone, CompressionFlate:
+		algorithm = compression
+	case "zstd", "lz4":
+		logger.Warnf("Volume %s Compression %s not available... falling back to %s", volumeName, compression, CompressionFlate)
+		algorithm = CompressionFlate
+	default:
+		err = fmt.Errorf("Volume %s Compression %s not supported", volumeName, compression)
+		return
+	}
+
+	err = nil
+	return
+}
+
+// newLogSegmentCompression returns an empty LogSegmentCompressionStruct for a
+// LogSegment about to be written using algorithm (or nil if neither compressing
+// nor encrypting).
+func newLogSegmentCompression(algorithm string, encrypted bool) (compression *LogSegmentCompressionStruct) {
+	if (CompressionNone == algorithm) && !encrypted {
+		compression = nil
+	} else {
+		compression = &LogSegmentCompressionStruct{
+			Algorithm: algorithm,
+			Encrypted: encrypted,
+			Chunk:     make([]LogSegmentCompressedChunkStruct, 0),
+		}
+	}
+
+	return
+}
+
+// compressed indicates whether the chunks of the LogSegment were compressed (as
+// opposed to merely encrypted). Only these are counted in the compression stats.
+func (compression *LogSegmentCompressionStruct) compressed() (compressed bool) {
+	compressed = (nil != compression) && (CompressionNone != compression.Algorithm)
+	return
+}
+
+// compressLogSegmentChunk returns the physical bytes to store for logicalBuf.
+func compressLogSegmentChunk(algorithm string, logicalBuf []byte) (physicalBuf []byte) {
+	var (
+		compressedBuf bytes.Buffer
+		err           error
+		flateWriter   *flate.Writer
+	)
+
+	flateWriter, err = flate.NewWriter(&compressedBuf, flate.BestSpeed)
+	if nil == err {
+		_, err = flateWriter.Write(logicalBuf)
+		if nil == err {
+			err = flateWriter.Close()
+		}
+	}
+
+	if (nil == err) && (compressedBuf.Len() < len(logicalBuf)) {
+		physicalBuf = compressedBuf.Bytes()
+	} else {
+		physicalBuf = logicalBuf
+	}
+
+	return
+}
+
+// Append records the next chunk sent to the LogSegment.
+func (compression *LogSegmentCompressionStruct) Append(logicalLength uint64, physicalLength uint64) {
+	var (
+		chunk LogSegmentCompressedChunkStruct
+	)
+
+	chunk.LogicalOffset, chunk.PhysicalOffset = compression.lengths()
+	chunk.LogicalLength = logicalLength
+	chunk.PhysicalLength = physicalLength
+
+	compression.Chunk = append(compression.Chunk, chunk)
+}
+
+// lengths returns the total logical and physical lengths of the LogSegment.
+func (compression *LogSegmentCompressionStruct) lengths() (logicalLength uint64, physicalLength uint64) {
+	var (
+		lastChunk *LogSegmentCompressedChunkStruct
+	)
+
+	if 0 == len(compression.Chunk) {
+		logicalLength = 0
+		physicalLength = 0
+	} else {
+		lastChunk = &compression.Chunk[len(compression.Chunk)-1]
+		logicalLength = lastChunk.LogicalOffset + lastChunk.LogicalLength
+		physicalLength = lastChunk.PhysicalOffset + lastChunk.PhysicalLength
+	}
+
+	return
+}
+
+// physicalEnd returns the physical offset just past the chunk holding the byte
+// just before logicalEnd.
+func (compression *LogSegmentCompressionStruct) physicalEnd(logicalEnd uint64) (physicalEnd uint64) {
+	var (
+		chunkIndex int
+	)
+
+	if 0 == logicalEnd {
+		physicalEnd = 0
+		return
+	}
+
+	chunkIndex = sort.Search(len(compression.Chunk), func(i int) bool {
+		return (compression.Chunk[i].LogicalOffset + compression.Chunk[i].LogicalLength) >= logicalEnd
+	})
+
+	if chunkIndex == len(compression.Chunk) {
+		_, physicalEnd = compression.lengths()
+	} else {
+		physicalEnd = compression.Chunk[chunkIndex].PhysicalOffset + compression.Chunk[chunkIndex].PhysicalLength
+	}
+
+	return
+}
+
+// Read returns the (up to) length logical bytes of the LogSegment starting at
+// logicalOffset. The fetch func is called once to obtain the physical bytes of
+// every chunk overlapping that range. As with a ranged GET, the returned buf is
+// short should the range extend beyond the end of the LogSegment. Encrypted
+// LogSegments must instead be read via ReadSealed().
+//
+func (compression *LogSegmentCompressionStruct) Read(logicalOffset uint64, length uint64, fetch func(physicalOffset uint64, physicalLength uint64) (physicalBuf []byte, err error)) (buf []byte, err error) {
+	if compression.Encrypted {
+		err = blunder.NewError(blunder.NotSupportedError, "encrypted LogSegment cannot be read directly")
+		return
+	}
+
+	buf, err = compression.read(logicalOffset, length, fetch, nil)
+
+	return
+}
+
+// ReadSealed is Read() for an encrypted LogSegment. The unseal func is called to
+// open each chunk given its PhysicalOffset (see keyring.ObjectAdditionalData()).
+//
+func (compression *LogSegmentCompressionStruct) ReadSealed(logicalOffset uint64, length uint64, fetch func(physicalOffset uint64, physicalLength uint64) (physicalBuf []byte, err error), unseal func(physicalOffset uint64, sealedBuf []byte) (buf []byte, err error)) (buf []byte, err error) {
+	if !compression.Encrypted {
+		err = blunder.NewError(blunder.InvalidArgError, "unencrypted LogSegment must be read via Read()")
+		return
+	}
+
+	buf, err = compression.read(logicalOffset, length, fetch, unseal)
+
+	return
+}
+
+// read is Read() with an additional unseal func used to decrypt each chunk of
+// an encrypted LogSegment.
+func (compression *LogSegmentCompressionStruct) read(logicalOffset uint64, length uint64, fetch func(physicalOffset uint64, physicalLength uint64) (physicalBuf []byte, err error), unseal func(physicalOffset uint64, sealedBuf []byte) (buf []byte, err error)) (buf []byte, err error) {
+	var (
+		chunk              *LogSegmentCompressedChunkStruct
+		chunkIndex         int
+		chunkIndexEnd      int
+		chunkIndexStart    int
+		chunkLogicalBuf    []byte
+		chunkPhysicalBuf   []byte
+		logicalBuf         []byte
+		logicalEnd         uint64
+		logicalLength      uint64
+		physicalBuf        []byte
+		physicalRangeEnd   uint64
+		physicalRangeStart uint64
+	)
+
+	logicalLength, _ = compression.lengths()
+
+	logicalEnd = logicalOffset + length
+	if logicalEnd > logicalLength {
+		logicalEnd = logicalLength
+	}
+
+	if logicalOffset >= logicalEnd {
+		buf = make([]byte, 0)
+		err = nil
+		return
+	}
+
+	chunkIndexStart = sort.Search(len(compression.Chunk), func(i int) bool {
+		return (compression.Chunk[i].LogicalOffset + compression.Chunk[i].LogicalLength) > logicalOffset
+	})
+	chunkIndexEnd = sort.Search(len(compression.Chunk), func(i int) bool {
+		return compression.Chunk[i].LogicalOffset >= logicalEnd
+	})
+
+	physicalRangeStart = compression.Chunk[chunkIndexStart].PhysicalOffset
+	physicalRangeEnd = compression.Chunk[chunkIndexEnd-1].PhysicalOffset + compression.Chunk[chunkIndexEnd-1].PhysicalLength
+
+	physicalBuf, err = fetch(physicalRangeStart, physicalRangeEnd-physicalRangeStart)
+	if nil != err {
+		return
+	}
+	if uint64(len(physicalBuf)) < (physicalRangeEnd - physicalRangeStart) {
+		err = blunder.NewError(blunder.SegReadError, "compressed LogSegment short read at physical offset 0x%016X", physicalRangeStart)
+		return
+	}
+
+	logicalBuf = make([]byte, 0, logicalEnd-compression.Chunk[chunkIndexStart].LogicalOffset)
+
+	for chunkIndex = chunkIndexStart; chunkIndex < chunkIndexEnd; chunkIndex++ {
+		chunk = &compression.Chunk[chunkIndex]
+		chunkPhysicalBuf = physicalBuf[chunk.PhysicalOffset-physicalRangeStart : chunk.PhysicalOffset-physicalRangeStart+chunk.PhysicalLength]
+
+		if compression.Encrypted {
+			chunkPhysicalBuf, err = unseal(chunk.PhysicalOffset, chunkPhysicalBuf)
+			if nil != err {
+				err = blunder.NewError(blunder.SegReadError, "encrypted LogSegment chunk at physical offset 0x%016X failed to unseal: %v", chunk.PhysicalOffset, err)
+				return
+			}
+		}
+
+		// A chunk that did not shrink was stored uncompressed
+
+		if uint64(len(chunkPhysicalBuf)) == chunk.LogicalLength {
+			chunkLogicalBuf = chunkPhysicalBuf
+		} else {
+			chunkLogicalBuf, err = ioutil.ReadAll(flate.NewReader(bytes.NewReader(chunkPhysicalBuf)))
+			if (nil == err) && (uint64(len(chunkLogicalBuf)) != chunk.LogicalLength) {
+				err = fmt.Errorf("decompressed to 0x%016X bytes rather than 0x%016X", len(chunkLogicalBuf), chunk.LogicalLength)
+			}
+			if nil != err {
+				err = blunder.NewError(blunder.SegReadError, "compressed LogSegment chunk at physical offset 0x%016X failed to decompress: %v", chunk.PhysicalOffset, err)
+				return
+			}
+		}
+
+		logicalBuf = append(logicalBuf, chunkLogicalBuf...)
+	}
+
+	buf = logicalBuf[logicalOffset-compression.Chunk[chunkIndexStart].LogicalOffset : logicalEnd-compression.Chunk[chunkIndexStart].LogicalOffset]
+
+	err = nil
+	return
+}
+
+func encodeLogSegmentRecCompression(compression *LogSegmentCompressionStruct) (compressionBuf []byte) {
+	var (
+		algorithm  uint64
+		chunkIndex int
+	)
+
+	compressionBuf = make([]byte, logSegmentRecCompressionFixedSize+(len(compression.Chunk)*logSegmentRecCompressionChunkSize))
+
+	if CompressionNone == compression.Algorithm {
+		algorithm = logSegmentRecCompressionNone
+	} else {
+		algorithm = logSegmentRecCompressionFlate
+	}
+	if compression.Encrypted {
+		algorithm |= logSegmentRecCompressionEncrypted
+	}
+
+	binary.LittleEndian.PutUint64(compressionBuf[0:], algorithm)
+
+	for chunkIndex = range compression.Chunk {
+		binary.LittleEndian.PutUint64(compressionBuf[logSegmentRecCompressionFixedSize+(chunkIndex*logSegmentRecCompressionChunkSize):], compression.Chunk[chunkIndex].LogicalLength)
+		binary.LittleEndian.PutUint64(compressionBuf[logSegmentRecCompressionFixedSize+(chunkIndex*logSegmentRecCompressionChunkSize)+8:], compression.Chunk[chunkIndex].PhysicalLength)
+	}
+
+	return
+}
+
+func decodeLogSegmentRecCompression(containerName string, compressionBuf []byte, checksums *LogSegmentChecksumsStruct) (compression *LogSegmentCompressionStruct, err error) {
+	var (
+		algorithm     uint64
+		algorithmName string
+		chunkIndex    int
+		encrypted     bool
+		logicalLength uint64
+		numChunks     int
+	)
+
+	if (logSegmentRecCompressionFixedSize > len(compressionBuf)) || (0 != ((len(compressionBuf) - logSegmentRecCompressionFixedSize) % logSegmentRecCompressionChunkSize)) {
+		err = fmt.Errorf("LogSegmentRec for container %s has malformed compression", containerName)
+		return
+	}
+
+	algorithm = binary.LittleEndian.Uint64(compressionBuf[0:])
+	encrypted = (0 != (algorithm & logSegmentRecCompressionEncrypted))
+	algorithm &^= logSegmentRecCompressionEncrypted
+
+	switch {
+	case logSegmentRecCompressionFlate == algorithm:
+		algorithmName = CompressionFlate
+	case (logSegmentRecCompressionNone == algorithm) && encrypted:
+		algorithmName = CompressionNone
+	default:
+		err = fmt.Errorf("LogSegmentRec for container %s has unsupported compression algorithm %d", containerName, binary.LittleEndian.Uint64(compressionBuf[0:]))
+		return
+	}
+
+	numChunks = (len(compressionBuf) - logSegmentRecCompressionFixedSize) / logSegmentRecCompressionChunkSize
+
+	compression = &LogSegmentCompressionStruct{
+		Algorithm: algorithmName,
+		Encrypted: encrypted,
+		Chunk:     make([]LogSegmentCompressedChunkStruct, 0, numChunks),
+	}
+
+	for chunkIndex = 0; chunkIndex < numChunks; chunkIndex++ {
+		compression.Append(
+			binary.LittleEndian.Uint64(compressionBuf[logSegmentRecCompressionFixedSize+(chunkIndex*logSegmentRecCompressionChunkSize):]),
+			binary.LittleEndian.Uint64(compressionBuf[logSegmentRecCompressionFixedSize+(chunkIndex*logSegmentRecCompressionChunkSize)+8:]))
+	}
+
+	logicalLength, _ = compression.lengths()
+	if logicalLength != checksums.Length {
+		err = fmt.Errorf("LogSegmentRec for container %s has compression inconsistent with checksums", containerName)
+		return
+	}
+
+	err = nil
+	return
+}
+
+func (vS *volumeStruct) getLogSegmentCompression(logSegmentNumber uint64) (compression *LogSegmentCompressionStruct, err error) {
+	value, err := vS.headhunterVolumeHandle.GetLogSegmentRec(logSegmentNumber)
+	if nil != err {
+		return
+	}
+	_, _, compression, err = decodeLogSegmentRec(value)
+	return
+}
+
+// unsealLogSegmentChunk returns the func used to unseal the chunks of an encrypted LogSegment.
+// Chunks are sealed with the object number of the LogSegment (i.e. as named in Swift) so that
+// PFSAgent, and views of SnapShots sharing the LogSegment, can open them.
+func (vS *volumeStruct) unsealLogSegmentChunk(logSegmentNumber uint64) func(physicalOffset uint64, sealedBuf []byte) (buf []byte, err error) {
+	_, _, objectNumber := vS.headhunterVolumeHandle.SnapShotU64Decode(logSegmentNumber)
+
+	return func(physicalOffset uint64, sealedBuf []byte) (buf []byte, err error) {
+		return vS.headhunterVolumeHandle.Unseal(objectNumber, physicalOffset, sealedBuf)
+	}
+}
+
+// readLogical reads back logical bytes previously sent to inFlightLogSegment.
+func (inFlightLogSegment *inFlightLogSegmentStruct) readLogical(offset uint64, length uint64) (buf []byte, err error) {
+	if nil == inFlightLogSegment.compression {
+		buf, err = inFlightLogSegment.Read(offset, length)
+	} else {
+		buf, err = inFlightLogSegment.compression.read(offset, length, inFlightLogSegment.Read, inFlightLogSegment.fileInode.volume.unsealLogSegmentChunk(inFlightLogSegment.logSegmentNumber))
+	}
+
+	return
+}
+
+// readLogSegment fetches (and, as necessary, decompresses) length logical bytes of a
+// LogSegment starting at offset. Should the LogSegment have checksums, the fetch is
+// widened to cover whole blocks so that the bytes returned are always verified.
+//
+func (vS *volumeStruct) readLogSegment(logSegmentNumber uint64, containerName string, objectName string, offset uint64, length uint64) (buf []byte, err error) {
+	var (
+		checksums     *LogSegmentChecksumsStruct
+		compression   *LogSegmentCompressionStruct
+		corruptOffset uint64
+		fetchEnd      uint64
+		fetchOffset   uint64
+		logSegmentRec []byte
+	)
+
+	logSegmentRec, err = vS.headhunterVolumeHandle.GetLogSegmentRec(logSegmentNumber)
+	if nil != err {
+		err = blunder.AddError(err, blunder.SegReadError)
+		return
+	}
+	_, checksums, compression, err = decodeLogSegmentRec(logSegmentRec)
+	if nil != err {
+		err = blunder.AddError(err, blunder.SegReadError)
+		return
+	}
+
+	fetchOffset = offset
+	fetchEnd = offset + length
+
+	if nil != checksums {
+		fetchOffset -= fetchOffset % checksums.BlockSize
+
+		// Round fetchEnd up to a block boundary (though not past the end of the LogSegment)
+
+		if 0 != (fetchEnd % checksums.BlockSize) {
+			fetchEnd += checksums.BlockSize - (fetchEnd % checksums.BlockSize)
+			if fetchEnd > checksums.Length {
+				if (offset + length) > checksums.Length {
+					fetchEnd = offset + length
+				} else {
+					fetchEnd = checksums.Length
+				}
+			}
+		}
+	}
+
+	if nil == compression {
+		buf, err = swiftclient.ObjectGet(vS.accountName, containerName, objectName, fetchOffset, fetchEnd-fetchOffset)
+	} else {
+		buf, err = compression.read(fetchOffset, fetchEnd-fetchOffset, func(physicalOffset uint64, physicalLength uint64) ([]byte, error) {
+			return swiftclient.ObjectGet(vS.accountName, containerName, objectName, physicalOffset, physicalLength)
+		}, vS.unsealLogSegmentChunk(logSegmentNumber))
+	}
+	if nil != err {
+		err = blunder.AddError(err, blunder.SegReadError)
+		return
+	}
+
+	if nil == checksums {
+		err = nil
+		return
+	}
+
+	corruptOffset, err = checksums.Verify(fetchOffset, buf)
+	if nil != err {
+		logger.ErrorfWithError(err, "volume %s LogSegment 0x%016X failed verification at offset 0x%016X", vS.volumeName, logSegmentNumber, corruptOffset)
+		err = blunder.AddError(err, blunder.ChecksumError)
+		return
+	}
+
+	// Trim buf back to the range requested
+
+	if uint64(len(buf)) <= (offset - fetchOffset) {
+		buf = buf[:0]
+	} else {
+		buf = buf[offset-fetchOffset:]
+		if uint64(len(buf)) > length {
+			buf = buf[:length]
+		}
+	}
+
+	return
+}
+
+// compressionStatsStruct tracks the logical and physical bytes of the volume's compressed
+// LogSegments. Logical bytes are counted for each live view reference to a LogSegment
+// (i.e. once more for each ShareLogSegmentRec()) while physical bytes, as they are only
+// stored once, are counted once. The stats are persisted in headhunter's
+// CompressionStatsRec following each checkpoint and when the volume is unserved. Just
+// as for the QuotaRec (see quota.go), the CompressionStatsRec is only trusted when
+// served if it was persisted by UnserveVolume()... otherwise, the stats are recomputed
+// by compressionScan().
+type compressionStatsStruct struct {
+	trackedlock.Mutex
+	dirty           bool
+	cleanlyUnserved bool // Value of CleanlyUnserved in the persisted CompressionStatsRec
+	CompressionStatsStruct
+}
+
+type onDiskCompressionStatsV1Struct struct {
+	Version Version
+	CompressionStatsStruct
+	CleanlyUnserved bool
+}
+
+// compressionStatsLoad initializes vS.compressionStats from the volume's CompressionStatsRec.
+// If the volume has never recorded one, or it was not CleanlyUnserved, the stats are computed
+// by scanning every LogSegmentRec. Unless the volume is read-only, the CompressionStatsRec is
+// then persisted with CleanlyUnserved cleared.
+func (vS *volumeStruct) compressionStatsLoad() (err error) {
+	var (
+		compressionStatsRec      []byte
+		ok                       bool
+		onDiskCompressionStatsV1 *onDiskCompressionStatsV1Struct
+	)
+
+	vS.compressionStats = &compressionStatsStruct{
+		dirty:           false,
+		cleanlyUnserved: false,
+	}
+
+	compressionStatsRec, ok, err = vS.headhunterVolumeHandle.GetCompressionStatsRec()
+	if nil != err {
+		err = fmt.Errorf("%s: unable to fetch CompressionStatsRec for volume %s: %v", utils.GetFnName(), vS.volumeName, err)
+		return
+	}
+
+	if ok {
+		onDiskCompressionStatsV1 = &onDiskCompressionStatsV1Struct{}
+
+		err = json.Unmarshal(compressionStatsRec, onDiskCompressionStatsV1)
+		if nil != err {
+			err = fmt.Errorf("%s: CompressionStatsRec for volume %s json.Unmarshal() failed: %v", utils.GetFnName(), vS.volumeName, err)
+			return
+		}
+		if V1 != onDiskCompressionStatsV1.Version {
+			err = fmt.Errorf("%s: CompressionStatsRec.Version for volume %s (%v) not supported", utils.GetFnName(), vS.volumeName, onDiskCompressionStatsV1.Version)
+			return
+		}
+
+		vS.compressionStats.CompressionStatsStruct = onDiskCompressionStatsV1.CompressionStatsStruct
+		vS.compressionStats.cleanlyUnserved = onDiskCompressionStatsV1.CleanlyUnserved
+	}
+
+	if !ok || !vS.compressionStats.cleanlyUnserved {
+		if ok {
+			logger.Infof("CompressionStatsRec for volume %s was not cleanly unserved... recomputing compression stats", vS.volumeName)
+		}
+
+		err = vS.compressionScan()
+		if nil != err {
+			return
+		}
+	}
+
+	if vS.readOnly {
+		err = nil
+		return
+	}
+
+	err = vS.compressionStatsPersist(false)
+
+	return
+}
+
+// compressionStatsPersist records the current compression stats in the volume's
+// CompressionStatsRec (if changed). Only UnserveVolume() should pass cleanlyUnserved as true.
+func (vS *volumeStruct) compressionStatsPersist(cleanlyUnserved bool) (err error) {
+	var (
+		compressionStatsRec      []byte
+		onDiskCompressionStatsV1 *onDiskCompressionStatsV1Struct
+	)
+
+	vS.compressionStats.Lock()
+
+	if !vS.compressionStats.dirty && (cleanlyUnserved == vS.compressionStats.cleanlyUnserved) {
+		vS.compressionStats.Unlock()
+		err = nil
+		return
+	}
+
+	onDiskCompressionStatsV1 = &onDiskCompressionStatsV1Struct{
+		Version:                V1,
+		CompressionStatsStruct: vS.compressionStats.CompressionStatsStruct,
+		CleanlyUnserved:        cleanlyUnserved,
+	}
+
+	vS.compressionStats.dirty = false
+	vS.compressionStats.cleanlyUnserved = cleanlyUnserved
+
+	vS.compressionStats.Unlock()
+
+	compressionStatsRec, err = json.Marshal(onDiskCompressionStatsV1)
+	if nil == err {
+		err = vS.headhunterVolumeHandle.PutCompressionStatsRec(compressionStatsRec)
+	}
+	if nil != err {
+		vS.compressionStats.Lock()
+		vS.compressionStats.dirty = true
+		vS.compressionStats.Unlock()
+		err = fmt.Errorf("%s: unable to persist CompressionStatsRec for volume %s: %v", utils.GetFnName(), vS.volumeName, err)
+	}
+
+	return
+}
+
+// compressionStatsAdjust applies the (signed) changes in the logical and physical
+// bytes of compressed LogSegments.
+func (vS *volumeStruct) compressionStatsAdjust(logicalBytesDelta int64, physicalBytesDelta int64) {
+	if (0 == logicalBytesDelta) && (0 == physicalBytesDelta) {
+		return
+	}
+
+	vS.compressionStats.Lock()
+	vS.compressionStats.LogicalBytes = adjustQuotaUsage(vS.compressionStats.LogicalBytes, logicalBytesDelta)
+	vS.compressionStats.PhysicalBytes = adjustQuotaUsage(vS.compressionStats.PhysicalBytes, physicalBytesDelta)
+	vS.compressionStats.dirty = true
+	vS.compressionStats.Unlock()
+}
+
+// compressionScan computes the volume's compression stats from scratch by examining
+// every LiveView LogSegmentRec (e.g. following a crash or a SnapShot rollback). A
+// LogSegment shared (via shareLogSegmentRec) has its logical bytes counted once for
+// each reference (such that each subsequent deleteLogSegmentRec discounts them exactly
+// once) but its physical bytes counted only once.
+func (vS *volumeStruct) compressionScan() (err error) {
+	var (
+		compression       *LogSegmentCompressionStruct
+		compressionStats  CompressionStatsStruct
+		logicalLength     uint64
+		logSegmentIndex   uint64
+		logSegmentNumber  uint64
+		logSegmentRec     []byte
+		numLogSegmentRecs uint64
+		ok                bool
+		physicalLength    uint64
+		shareCount        uint64
+		startTime         time.Time = time.Now()
+	)
+
+	for logSegmentIndex = 0; ; logSegmentIndex++ {
+		logSegmentNumber, ok, err = vS.headhunterVolumeHandle.IndexedLogSegmentNumber(logSegmentIndex)
+		if (nil != err) || !ok {
+			break
+		}
+
+		logSegmentRec, err = vS.headhunterVolumeHandle.GetLogSegmentRec(logSegmentNumber)
+		if nil != err {
+			break
+		}
+
+		numLogSegmentRecs++
+
+		_, _, compression, err = decodeLogSegmentRec(logSegmentRec)
+		if nil != err {
+			logger.WarnfWithError(err, "%s: skipping undecodable LogSegmentRec 0x%016X in volume %s", utils.GetFnName(), logSegmentNumber, vS.volumeName)
+			err = nil
+			continue
+		}
+		if !compression.compressed() {
+			continue
+		}
+
+		shareCount, err = vS.headhunterVolumeHandle.FetchLogSegmentRecShareCount(logSegmentNumber)
+		if nil != err {
+			break
+		}
+
+		logicalLength, physicalLength = compression.lengths()
+
+		compressionStats.LogicalBytes += (1 + shareCount) * logicalLength
+		compressionStats.PhysicalBytes += physicalLength
+	}
+	if nil != err {
+		err = fmt.Errorf("%s: unable to enumerate LogSegmentRecs of volume %s: %v", utils.GetFnName(), vS.volumeName, err)
+		return
+	}
+
+	vS.compressionStats.Lock()
+	vS.compressionStats.CompressionStatsStruct = compressionStats
+	vS.compressionStats.dirty = true
+	vS.compressionStats.Unlock()
+
+	logger.Infof("Computed compression stats for %d LogSegments of volume %s in %v", numLogSegmentRecs, vS.volumeName, time.Since(startTime))
+
+	err = nil
+	return
+}
+
+// deleteLogSegmentRec deletes a LogSegment's LogSegmentRec, first discounting
+// the LogSegment from the volume's compression stats (if compressed). Should the
+// delete merely release a reference made by shareLogSegmentRec, only the logical
+// bytes are discounted as the LogSegment remains stored.
+func (vS *volumeStruct) deleteLogSegmentRec(logSegmentNumber uint64) (err error) {
+	var (
+		compression    *LogSegmentCompressionStruct
+		decodeErr      error
+		logSegmentRec  []byte
+		logicalLength  uint64
+		physicalLength uint64
+		shareCount     uint64
+	)
+
+	logSegmentRec, err = vS.headhunterVolumeHandle.GetLogSegmentRec(logSegmentNumber)
+	if nil == err {
+		_, _, compression, decodeErr = decodeLogSegmentRec(logSegmentRec)
+		if nil != decodeErr {
+			compression = nil
+		}
+	}
+
+	if compression.compressed() {
+		shareCount, err = vS.headhunterVolumeHandle.FetchLogSegmentRecShareCount(logSegmentNumber)
+		if nil != err {
+			return
+		}
+	}
+
+	err = vS.headhunterVolumeHandle.DeleteLogSegmentRec(logSegmentNumber)
+	if nil != err {
+		return
+	}
+
+	if compression.compressed() {
+		logicalLength, physicalLength = compression.lengths()
+		if 0 < shareCount {
+			physicalLength = 0
+		}
+		vS.compressionStatsAdjust(-int64(logicalLength), -int64(physicalLength))
+	}
+
+	return
+}
+
+// shareLogSegmentRec shares a SnapShot's LogSegment with the live view, counting
+// the LogSegment's logical bytes (if compressed) in the volume's compression stats
+// once more so that each subsequent deleteLogSegmentRec discounts them exactly once.
+// Its physical bytes are only counted should the LogSegment not already have been
+// referenced by the live view.
+func (vS *volumeStruct) shareLogSegmentRec(logSegmentNumber uint64) (err error) {
+	var (
+		compression       *LogSegmentCompressionStruct
+		logSegmentRec     []byte
+		logicalLength     uint64
+		nonce             uint64
+		physicalLength    uint64
+		priorShareCount   uint64
+		currentShareCount uint64
+	)
+
+	logSegmentRec, err = vS.headhunterVolumeHandle.GetLogSegmentRec(logSegmentNumber)
+	if nil != err {
+		return
+	}
+
+	_, _, compression, err = decodeLogSegmentRec(logSegmentRec)
+	if nil != err {
+		return
+	}
+
+	_, _, nonce = vS.headhunterVolumeHandle.SnapShotU64Decode(logSegmentNumber)
+
+	if compression.compressed() {
+		priorShareCount, err = vS.headhunterVolumeHandle.FetchLogSegmentRecShareCount(nonce)
+		if nil != err {
+			return
+		}
+	}
+
+	err = vS.headhunterVolumeHandle.ShareLogSegmentRec(logSegmentNumber)
+	if nil != err {
+		return
+	}
+
+	if compression.compressed() {
+		currentShareCount, err = vS.headhunterVolumeHandle.FetchLogSegmentRecShareCount(nonce)
+		if nil != err {
+			return
+		}
+		logicalLength, physicalLength = compression.lengths()
+		if currentShareCount > priorShareCount {
+			physicalLength = 0 // Already referenced (and counted) by the live view
+		}
+		vS.compressionStatsAdjust(int64(logicalLength), int64(physicalLength))
+	}
+
+	return
+}
+
+func (vS *volumeStruct) FetchCompressionStats() (compressionStats CompressionStatsStruct) {
+	vS.compressionStats.Lock()
+	compressionStats = vS.compressionStats.CompressionStatsStruct
+	vS.compressionStats.Unlock()
+
+	return
+}
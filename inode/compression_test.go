@@ -0,0 +1,108 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package inode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressionStatsFollowingRollback(t *testing.T) {
+	testSetup(t, false)
+	defer testTeardown(t)
+
+	assert := assert.New(t)
+
+	testVolumeHandle, err := FetchVolumeHandle("TestVolume")
+	if nil != err {
+		t.Fatalf("FetchVolumeHandle(\"TestVolume\") should have worked - got error: %v", err)
+	}
+	vS := testVolumeHandle.(*volumeStruct)
+
+	priorCompression := vS.compression
+	vS.compression = CompressionFlate
+
+	// writeCompressedFile creates a file holding buf and returns the resultant compression stats
+	writeCompressedFile := func(buf []byte) (fileInodeNumber InodeNumber, compressionStats CompressionStatsStruct) {
+		fileInodeNumber, err := vS.CreateFile(InodeMode(0000), InodeUserID(0), InodeGroupID(0))
+		if nil != err {
+			t.Fatalf("CreateFile() failed: %v", err)
+		}
+		err = vS.Write(fileInodeNumber, 0, buf, nil)
+		assert.Nil(err)
+		err = vS.Flush(fileInodeNumber, false)
+		assert.Nil(err)
+		compressionStats = vS.FetchCompressionStats()
+		return
+	}
+
+	compressionStatsBefore := vS.FetchCompressionStats()
+
+	fileInodeNumber, compressionStatsAtSnapShot := writeCompressedFile(bytes.Repeat([]byte("A"), 65536))
+	assert.Equal(compressionStatsBefore.LogicalBytes+65536, compressionStatsAtSnapShot.LogicalBytes)
+	assert.True(compressionStatsAtSnapShot.PhysicalBytes-compressionStatsBefore.PhysicalBytes < 65536)
+
+	snapShotID, err := vS.SnapShotCreate("TestCompressionStatsFollowingRollback")
+	if nil != err {
+		t.Fatalf("SnapShotCreate() failed: %v", err)
+	}
+
+	_, compressionStatsAfterSnapShot := writeCompressedFile(bytes.Repeat([]byte("0123456789ABCDEF"), 4096))
+	assert.Equal(compressionStatsAtSnapShot.LogicalBytes+65536, compressionStatsAfterSnapShot.LogicalBytes)
+
+	// Following a rollback, the compression stats (and hence ratio) should match those at the SnapShot
+
+	err = vS.SnapShotRollback(snapShotID)
+	assert.Nil(err)
+
+	compressionStats := vS.FetchCompressionStats()
+	assert.Equal(compressionStatsAtSnapShot, compressionStats)
+	assert.Equal(float64(compressionStatsAtSnapShot.LogicalBytes)/float64(compressionStatsAtSnapShot.PhysicalBytes), float64(compressionStats.LogicalBytes)/float64(compressionStats.PhysicalBytes))
+
+	// Similarly, compression stats persisted other than by UnserveVolume() should be recomputed
+
+	vS.compressionStatsAdjust(1000, 5)
+
+	err = vS.compressionStatsPersist(false)
+	assert.Nil(err)
+
+	err = vS.compressionStatsLoad()
+	assert.Nil(err)
+
+	assert.Equal(compressionStatsAtSnapShot, vS.FetchCompressionStats())
+
+	// A clone of the SnapShot's file shares its LogSegment... so only adds its logical bytes
+
+	cloneInodeNumber, err := vS.CloneInode(InodeNumber(vS.headhunterVolumeHandle.SnapShotIDAndNonceEncode(snapShotID, uint64(fileInodeNumber))))
+	if nil != err {
+		t.Fatalf("CloneInode() failed: %v", err)
+	}
+
+	compressionStats = vS.FetchCompressionStats()
+	assert.Equal(compressionStatsAtSnapShot.LogicalBytes+65536, compressionStats.LogicalBytes)
+	assert.Equal(compressionStatsAtSnapShot.PhysicalBytes, compressionStats.PhysicalBytes)
+
+	err = vS.compressionScan()
+	assert.Nil(err)
+	assert.Equal(compressionStats, vS.FetchCompressionStats())
+
+	err = vS.Destroy(cloneInodeNumber)
+	assert.Nil(err)
+
+	assert.Equal(compressionStatsAtSnapShot, vS.FetchCompressionStats())
+
+	// Clean up
+
+	err = vS.SnapShotDelete(snapShotID)
+	assert.Nil(err)
+
+	err = vS.Destroy(fileInodeNumber)
+	assert.Nil(err)
+
+	assert.Equal(compressionStatsBefore, vS.FetchCompressionStats())
+
+	vS.compression = priorCompression
+}
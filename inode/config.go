@@ -4,6 +4,7 @@
 package inode
 
 import (
+	"container/list"
 	"fmt"
 	"sync"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/NVIDIA/sortedmap"
 
 	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/bucketstats"
 	"github.com/NVIDIA/proxyfs/conf"
 	"github.com/NVIDIA/proxyfs/headhunter"
 	"github.com/NVIDIA/proxyfs/logger"
@@ -28,10 +30,12 @@ type readCacheKeyStruct struct {
 }
 
 type readCacheElementStruct struct {
-	readCacheKey readCacheKeyStruct
-	next         *readCacheElementStruct // nil if MRU element of volumeGroupStruct.readCache
-	prev         *readCacheElementStruct // nil if LRU element of volumeGroupStruct.readCache
-	cacheLine    []byte
+	readCacheKey      readCacheKeyStruct
+	policyList        *list.List    // Which of volumeGroupStruct.readCachePolicy's lists holds this element
+	policyListElement *list.Element // Value is this element
+	policyFrequency   uint8         // Used by some volumeGroupStruct.readCachePolicy's to count reads
+	cacheLine         []byte
+	prefetched        bool // Set if inserted by read-ahead and not yet read
 }
 
 type volumeGroupStruct struct {
@@ -45,8 +49,12 @@ type volumeGroupStruct struct {
 	readCacheWeight         uint64
 	readCacheLineCount      uint64
 	readCache               map[readCacheKeyStruct]*readCacheElementStruct
-	readCacheMRU            *readCacheElementStruct
-	readCacheLRU            *readCacheElementStruct
+	readCachePolicyName     string
+	readCachePolicy         readCachePolicyIf
+	readCacheStats          *readCacheStatsStruct
+	readAheadWindowMax      uint64                                 // If == 0, read-ahead is disabled
+	readAheadInFlightMax    uint64                                 // Limits # of concurrent read-ahead cache line fetches
+	readAheadInFlight       map[readCacheKeyStruct]*sync.WaitGroup // Read Cache Lines being fetched by read-ahead
 }
 
 type physicalContainerLayoutStruct struct {
@@ -74,6 +82,8 @@ type volumeStruct struct {
 	maxExtentsPerFileNode          uint64
 	defaultPhysicalContainerLayout *physicalContainerLayoutStruct
 	maxFlushSize                   uint64
+	compression                    string // One of CompressionNone or CompressionFlate
+	readOnly                       bool   // If true, all modifications fail with EROFS (e.g. when serving a replica)
 	headhunterVolumeHandle         headhunter.VolumeHandle
 	inodeCache                     sortedmap.LLRBTree //          key == InodeNumber; value == *inMemoryInodeStruct
 	inodeCacheStopChan             chan struct{}
@@ -85,6 +95,8 @@ type volumeStruct struct {
 	inodeCacheLRUTicker            *time.Ticker
 	inodeCacheLRUTickerInterval    time.Duration
 	snapShotPolicy                 *snapShotPolicyStruct
+	quota                          *quotaStruct
+	compressionStats               *compressionStatsStruct
 }
 
 const (
@@ -258,8 +270,8 @@ func (dummy *globalsStruct) VolumeGroupCreated(confMap conf.ConfMap, volumeGroup
 		numServed:          0,
 		readCacheLineCount: 0,
 		readCache:          make(map[readCacheKeyStruct]*readCacheElementStruct),
-		readCacheMRU:       nil,
-		readCacheLRU:       nil,
+		readCacheStats:     &readCacheStatsStruct{},
+		readAheadInFlight:  make(map[readCacheKeyStruct]*sync.WaitGroup),
 	}
 
 	volumeGroupSectionName = "VolumeGroup:" + volumeGroupName
@@ -302,6 +314,27 @@ func (dummy *globalsStruct) VolumeGroupCreated(confMap conf.ConfMap, volumeGroup
 		volumeGroup.readCacheWeight = 1
 	}
 
+	volumeGroup.readCachePolicyName, err = confMap.FetchOptionValueString(volumeGroupSectionName, "ReadCachePolicy")
+	if nil != err {
+		volumeGroup.readCachePolicyName = ReadCachePolicyLRU // If not present, default to the original LRU policy
+	}
+
+	volumeGroup.readCachePolicy, err = newReadCachePolicy(volumeGroup.readCachePolicyName)
+	if nil != err {
+		err = fmt.Errorf("Section '%s' for VolumeGroup '%s': %v", volumeGroupSectionName, volumeGroupName, err)
+		return
+	}
+
+	volumeGroup.readAheadWindowMax, err = confMap.FetchOptionValueUint64(volumeGroupSectionName, "ReadAheadWindowMax")
+	if nil != err {
+		volumeGroup.readAheadWindowMax = 0 // If not present, default to disabling read-ahead
+	}
+
+	volumeGroup.readAheadInFlightMax, err = confMap.FetchOptionValueUint64(volumeGroupSectionName, "ReadAheadInFlightMax")
+	if nil != err {
+		volumeGroup.readAheadInFlightMax = 8 // If not present, default to 8 concurrent read-ahead cache line fetches
+	}
+
 	globals.Lock()
 
 	_, ok = globals.volumeGroupMap[volumeGroupName]
@@ -315,6 +348,8 @@ func (dummy *globalsStruct) VolumeGroupCreated(confMap conf.ConfMap, volumeGroup
 
 	globals.Unlock()
 
+	bucketstats.Register("proxyfs.inode.readcache."+volumeGroup.readCachePolicyName, volumeGroupName, volumeGroup.readCacheStats)
+
 	err = nil
 	return
 }
@@ -347,7 +382,7 @@ func (dummy *globalsStruct) VolumeGroupMoved(confMap conf.ConfMap, volumeGroupNa
 		}
 	}
 
-	// Note that VirtualIPAddr, ReadCacheLineSize, & ReadCacheWeight are not reloaded
+	// Note that VirtualIPAddr, ReadCacheLineSize, ReadCacheWeight, ReadCachePolicy, & ReadAhead* are not reloaded
 
 	volumeGroup.Unlock()
 	globals.Unlock()
@@ -385,6 +420,8 @@ func (dummy *globalsStruct) VolumeGroupDestroyed(confMap conf.ConfMap, volumeGro
 	volumeGroup.Unlock()
 	globals.Unlock()
 
+	bucketstats.UnRegister("proxyfs.inode.readcache."+volumeGroup.readCachePolicyName, volumeGroupName)
+
 	err = nil
 	return
 }
@@ -549,6 +586,7 @@ func (dummy *globalsStruct) VolumeDestroyed(confMap conf.ConfMap, volumeName str
 
 func (dummy *globalsStruct) ServeVolume(confMap conf.ConfMap, volumeName string) (err error) {
 	var (
+		compression                               string
 		defaultPhysicalContainerLayout            *physicalContainerLayoutStruct
 		defaultPhysicalContainerLayoutName        string
 		defaultPhysicalContainerLayoutSectionName string
@@ -628,12 +666,39 @@ func (dummy *globalsStruct) ServeVolume(confMap conf.ConfMap, volumeName string)
 		return
 	}
 
+	compression, err = confMap.FetchOptionValueString(volumeSectionName, "Compression")
+	if nil != err {
+		compression = CompressionNone // Current default
+	}
+	volume.compression, err = parseCompression(volume.volumeName, compression)
+	if nil != err {
+		globals.Unlock()
+		return
+	}
+
+	volume.readOnly, err = confMap.FetchOptionValueBool(volumeSectionName, "ReadOnly")
+	if nil != err {
+		volume.readOnly = false // If not present, default to read-write
+	}
+
 	volume.headhunterVolumeHandle, err = headhunter.FetchVolumeHandle(volume.volumeName)
 	if nil != err {
 		globals.Unlock()
 		return
 	}
 
+	err = volume.quotaLoad()
+	if nil != err {
+		globals.Unlock()
+		return
+	}
+
+	err = volume.compressionStatsLoad()
+	if nil != err {
+		globals.Unlock()
+		return
+	}
+
 	volume.headhunterVolumeHandle.RegisterForEvents(volume)
 
 	volume.inodeCache = sortedmap.NewLLRBTree(compareInodeNumber, volume)
@@ -693,6 +758,16 @@ func (dummy *globalsStruct) UnserveVolume(confMap conf.ConfMap, volumeName strin
 
 	volume.headhunterVolumeHandle.UnregisterForEvents(volume)
 
+	err = volume.quotaPersist(true)
+	if nil != err {
+		logger.ErrorWithError(err)
+	}
+
+	err = volume.compressionStatsPersist(true)
+	if nil != err {
+		logger.ErrorWithError(err)
+	}
+
 	volume.volumeGroup.Lock()
 
 	volume.served = false
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/evtlog"
 	"github.com/NVIDIA/proxyfs/headhunter"
 	"github.com/NVIDIA/proxyfs/logger"
 )
@@ -32,13 +33,43 @@ type snapShotScheduleStruct struct {
 	count               uint64 // computed by scanning each time daemon() awakes
 }
 
+// In addition to the per snapShotSchedule keep counts, a snapShotPolicy may retain
+// the newest SnapShot in each of the most recent KeepHourly hours, KeepDaily days,
+// KeepWeekly weeks (starting Sunday), KeepMonthly months, and KeepYearly years (i.e.
+// "grandfather-father-son" retention). A SnapShot younger than MinAge is always
+// retained while one older than MaxAge (if non-zero) is deleted even if otherwise
+// retained. Should the bytes of LogSegments referenced only by SnapShots exceed
+// MaxTrappedBytes (if non-zero), the oldest SnapShots not protected by MinAge are
+// deleted until they no longer do. Pinned SnapShots are never deleted. In all cases,
+// only SnapShots named (and scheduled) by the snapShotPolicy are considered. Each
+// retention decision is recorded via evtlog.
+
+type snapShotPeriodStruct struct {
+	name       string // e.g. "KeepDaily"
+	keep       uint64
+	truncate   func(untruncatedTime time.Time, loc *time.Location) (truncatedTime time.Time)
+	count      uint64    // computed by scanning each time daemon() awakes
+	lastPeriod time.Time // computed by scanning each time daemon() awakes
+}
+
 type snapShotPolicyStruct struct {
-	name          string
-	volume        *volumeStruct
-	schedule      []*snapShotScheduleStruct
-	location      *time.Location
-	stopChan      chan struct{}
-	doneWaitGroup sync.WaitGroup
+	name            string
+	volume          *volumeStruct
+	schedule        []*snapShotScheduleStruct
+	period          []*snapShotPeriodStruct // only those with keep != 0
+	minAge          time.Duration
+	maxAge          time.Duration // 0 == no limit
+	maxTrappedBytes uint64        // 0 == no limit
+	location        *time.Location
+	stopChan        chan struct{}
+	doneWaitGroup   sync.WaitGroup
+}
+
+type snapShotVerdictStruct struct {
+	snapShot headhunter.SnapShotStruct
+	delete   bool
+	prunable bool // if true, may be deleted should MaxTrappedBytes be exceeded
+	reason   string
 }
 
 func (vS *volumeStruct) loadSnapShotPolicy(confMap conf.ConfMap) (err error) {
@@ -49,6 +80,7 @@ func (vS *volumeStruct) loadSnapShotPolicy(confMap conf.ConfMap) (err error) {
 		hourAsU64                   uint64
 		minuteAsU64                 uint64
 		monthAsU64                  uint64
+		snapShotPeriod              *snapShotPeriodStruct
 		snapShotPolicy              *snapShotPolicyStruct
 		snapShotPolicyName          string
 		snapShotPolicySectionName   string
@@ -198,6 +230,43 @@ func (vS *volumeStruct) loadSnapShotPolicy(confMap conf.ConfMap) (err error) {
 		snapShotPolicy.schedule = append(snapShotPolicy.schedule, snapShotSchedule)
 	}
 
+	snapShotPolicy.period = make([]*snapShotPeriodStruct, 0, 5)
+	for _, snapShotPeriod = range []*snapShotPeriodStruct{
+		&snapShotPeriodStruct{name: "KeepHourly", truncate: truncateToStartOfHour},
+		&snapShotPeriodStruct{name: "KeepDaily", truncate: truncateToStartOfDay},
+		&snapShotPeriodStruct{name: "KeepWeekly", truncate: truncateToStartOfWeek},
+		&snapShotPeriodStruct{name: "KeepMonthly", truncate: truncateToStartOfMonth},
+		&snapShotPeriodStruct{name: "KeepYearly", truncate: truncateToStartOfYear},
+	} {
+		snapShotPeriod.keep, err = confMap.FetchOptionValueUint64(snapShotPolicySectionName, snapShotPeriod.name)
+		if nil != err {
+			snapShotPeriod.keep = 0 // If not present, default to not retaining by this period
+		}
+		if 0 != snapShotPeriod.keep {
+			snapShotPolicy.period = append(snapShotPolicy.period, snapShotPeriod)
+		}
+	}
+
+	snapShotPolicy.minAge, err = confMap.FetchOptionValueDuration(snapShotPolicySectionName, "MinAge")
+	if nil != err {
+		snapShotPolicy.minAge = 0 // If not present, default to no minimum age
+	}
+
+	snapShotPolicy.maxAge, err = confMap.FetchOptionValueDuration(snapShotPolicySectionName, "MaxAge")
+	if nil != err {
+		snapShotPolicy.maxAge = 0 // If not present, default to no maximum age
+	}
+
+	if (0 != snapShotPolicy.maxAge) && (snapShotPolicy.maxAge < snapShotPolicy.minAge) {
+		err = fmt.Errorf("%v.MaxAge must not be less than %v.MinAge", snapShotPolicySectionName, snapShotPolicySectionName)
+		return
+	}
+
+	snapShotPolicy.maxTrappedBytes, err = confMap.FetchOptionValueUint64(snapShotPolicySectionName, "MaxTrappedBytes")
+	if nil != err {
+		snapShotPolicy.maxTrappedBytes = 0 // If not present, default to no space-based pruning
+	}
+
 	timeZone, err = confMap.FetchOptionValueString(snapShotPolicySectionName, "TimeZone")
 
 	if nil == err {
@@ -275,25 +344,119 @@ func (snapShotPolicy *snapShotPolicyStruct) daemon() {
 
 func (snapShotPolicy *snapShotPolicyStruct) prune() {
 	var (
+		deletableIDs         map[uint64]struct{}
+		err                  error
+		snapShotTrappedBytes uint64
+		trappedBytes         uint64
+		trappedByID          map[uint64]uint64
+		verdict              snapShotVerdictStruct
+		verdictIndex         int
+		verdicts             []snapShotVerdictStruct
+	)
+
+	// First, decide the fate of each snapshot
+
+	verdicts = snapShotPolicy.classify(snapShotPolicy.volume.headhunterVolumeHandle.SnapShotListByTime(true), time.Now().In(snapShotPolicy.location))
+
+	for _, verdict = range verdicts {
+		if verdict.delete {
+			snapShotPolicy.delete(verdict)
+		} else {
+			evtlog.Record(evtlog.FormatSnapShotPolicyRetain, snapShotPolicy.volume.volumeName, verdict.snapShot.Name, verdict.reason)
+		}
+	}
+
+	if 0 == snapShotPolicy.maxTrappedBytes {
+		return
+	}
+
+	// Now delete the oldest prunable snapshots until trappedBytes no longer exceeds maxTrappedBytes
+
+	deletableIDs = make(map[uint64]struct{})
+
+	for _, verdict = range verdicts {
+		if !verdict.delete && verdict.prunable {
+			deletableIDs[verdict.snapShot.ID] = struct{}{}
+		}
+	}
+
+	if 0 == len(deletableIDs) {
+		return
+	}
+
+	trappedByID, err = snapShotPolicy.volume.snapShotTrappedBytes(deletableIDs)
+	if nil != err {
+		logger.WarnWithError(err)
+		return
+	}
+
+	trappedBytes = 0
+
+	for _, snapShotTrappedBytes = range trappedByID {
+		trappedBytes += snapShotTrappedBytes
+	}
+
+	for verdictIndex = len(verdicts) - 1; verdictIndex >= 0; verdictIndex-- {
+		if trappedBytes <= snapShotPolicy.maxTrappedBytes {
+			return
+		}
+
+		verdict = verdicts[verdictIndex]
+		if verdict.delete || !verdict.prunable {
+			continue
+		}
+
+		verdict.delete = true
+		verdict.reason = fmt.Sprintf("%v trapped bytes exceeds MaxTrappedBytes", trappedBytes)
+
+		snapShotPolicy.delete(verdict)
+
+		trappedBytes -= trappedByID[verdict.snapShot.ID]
+	}
+}
+
+func (snapShotPolicy *snapShotPolicyStruct) delete(verdict snapShotVerdictStruct) {
+	var (
+		err error
+	)
+
+	evtlog.Record(evtlog.FormatSnapShotPolicyDelete, snapShotPolicy.volume.volumeName, verdict.snapShot.Name, verdict.reason)
+
+	err = snapShotPolicy.volume.SnapShotDelete(verdict.snapShot.ID)
+	if nil != err {
+		logger.WarnWithError(err)
+	}
+}
+
+// classify returns, in the order of snapShotList (expected to be reverse time-ordered),
+// a verdict for each snapshot named and scheduled by snapShotPolicy. The timeNow is
+// presumably time.Now() localized to snapShotPolicy.location... but provided here
+// primarily to enable easy testing.
+func (snapShotPolicy *snapShotPolicyStruct) classify(snapShotList []headhunter.SnapShotStruct, timeNow time.Time) (verdicts []snapShotVerdictStruct) {
+	var (
+		age               time.Duration
 		err               error
-		keep              bool
+		keepReason        string
 		matches           bool
 		matchesAtLeastOne bool
+		periodStart       time.Time
 		snapShot          headhunter.SnapShotStruct
-		snapShotList      []headhunter.SnapShotStruct
+		snapShotPeriod    *snapShotPeriodStruct
 		snapShotSchedule  *snapShotScheduleStruct
 		snapShotTime      time.Time
+		verdict           snapShotVerdictStruct
 	)
 
-	// First, zero each snapShotSchedule.count
+	// First, zero each snapShotSchedule.count and snapShotPeriod.count
 
 	for _, snapShotSchedule = range snapShotPolicy.schedule {
 		snapShotSchedule.count = 0
 	}
+	for _, snapShotPeriod = range snapShotPolicy.period {
+		snapShotPeriod.count = 0
+	}
 
-	// Now fetch the reverse time-ordered list of snapshots
-
-	snapShotList = snapShotPolicy.volume.headhunterVolumeHandle.SnapShotListByTime(true)
+	verdicts = make([]snapShotVerdictStruct, 0, len(snapShotList))
 
 	// Now walk snapShotList looking for snapshots to prune
 
@@ -306,7 +469,7 @@ func (snapShotPolicy *snapShotPolicyStruct) prune() {
 
 		// Compare against each snapShotSchedule
 
-		keep = false
+		keepReason = ""
 		matchesAtLeastOne = false
 
 		for _, snapShotSchedule = range snapShotPolicy.schedule {
@@ -314,22 +477,105 @@ func (snapShotPolicy *snapShotPolicyStruct) prune() {
 			if matches {
 				matchesAtLeastOne = true
 				snapShotSchedule.count++
-				if snapShotSchedule.count <= snapShotSchedule.keep {
-					keep = true
+				if ("" == keepReason) && (snapShotSchedule.count <= snapShotSchedule.keep) {
+					keepReason = "Keep of SnapShotSchedule " + snapShotSchedule.name
+				}
+			}
+		}
+
+		if !matchesAtLeastOne {
+			// Not taken by snapShotPolicy...so never pruned by it
+			continue
+		}
+
+		// Retain the newest snapshot in each of the most recent snapShotPeriod.keep periods
+
+		for _, snapShotPeriod = range snapShotPolicy.period {
+			if snapShotPeriod.count == snapShotPeriod.keep {
+				continue
+			}
+			periodStart = snapShotPeriod.truncate(snapShotTime, snapShotPolicy.location)
+			if (0 == snapShotPeriod.count) || !periodStart.Equal(snapShotPeriod.lastPeriod) {
+				snapShotPeriod.count++
+				snapShotPeriod.lastPeriod = periodStart
+				if "" == keepReason {
+					keepReason = snapShotPeriod.name
 				}
 			}
 		}
 
-		if matchesAtLeastOne && !keep {
+		age = timeNow.Sub(snapShotTime)
+
+		verdict = snapShotVerdictStruct{
+			snapShot: snapShot,
+			prunable: !snapShot.Pinned && (age >= snapShotPolicy.minAge),
+		}
+
+		switch {
+		case snapShot.Pinned:
+			verdict.delete = false
+			verdict.reason = "pinned"
+		case age < snapShotPolicy.minAge:
+			verdict.delete = false
+			verdict.reason = "younger than MinAge"
+		case (0 != snapShotPolicy.maxAge) && (age > snapShotPolicy.maxAge):
+			verdict.delete = true
+			verdict.reason = "older than MaxAge"
+		case "" != keepReason:
+			verdict.delete = false
+			verdict.reason = keepReason
+		default:
 			// Although this snapshot "matchesAtLeastOne",
-			//   no snapShotSchedule said "keep" it
+			//   no snapShotSchedule nor snapShotPeriod said "keep" it
+
+			verdict.delete = true
+			verdict.reason = "not kept by any SnapShotSchedule or period"
+		}
+
+		verdicts = append(verdicts, verdict)
+	}
 
-			err = snapShotPolicy.volume.SnapShotDelete(snapShot.ID)
+	return
+}
+
+// snapShotTrappedBytes returns the bytes of LogSegments referenced only by SnapShots keyed
+// by the SnapShotID whose deletion frees them (see SnapShotTrappedLogSegmentRecs()).
+// LogSegments lacking checksums (and, hence, a recorded length) are not counted.
+func (vS *volumeStruct) snapShotTrappedBytes(deletableIDs map[uint64]struct{}) (trappedByID map[uint64]uint64, err error) {
+	var (
+		checksums      *LogSegmentChecksumsStruct
+		compression    *LogSegmentCompressionStruct
+		logSegmentRec  []byte
+		logSegmentRecs map[uint64][][]byte
+		physicalLength uint64
+		snapShotID     uint64
+		snapShotRecs   [][]byte
+	)
+
+	logSegmentRecs, err = vS.headhunterVolumeHandle.SnapShotTrappedLogSegmentRecs(deletableIDs)
+	if nil != err {
+		return
+	}
+
+	trappedByID = make(map[uint64]uint64)
+
+	for snapShotID, snapShotRecs = range logSegmentRecs {
+		for _, logSegmentRec = range snapShotRecs {
+			_, checksums, compression, err = decodeLogSegmentRec(logSegmentRec)
 			if nil != err {
-				logger.WarnWithError(err)
+				return
+			}
+
+			if nil != compression {
+				_, physicalLength = compression.lengths()
+				trappedByID[snapShotID] += physicalLength
+			} else if nil != checksums {
+				trappedByID[snapShotID] += checksums.Length
 			}
 		}
 	}
+
+	return
 }
 
 // thisTime is presumably the snapShotSchedule.policy.location-local parsed snapShotStruct.name
@@ -444,6 +690,22 @@ func truncateToStartOfDay(untruncatedTime time.Time, loc *time.Location) (trunca
 	return
 }
 
+func truncateToStartOfWeek(untruncatedTime time.Time, loc *time.Location) (truncatedTime time.Time) {
+	var (
+		day   int
+		month time.Month
+		year  int
+	)
+
+	year, month, day = untruncatedTime.Date()
+
+	// Note that time.Date() normalizes a day before the start of the month
+
+	truncatedTime = time.Date(year, month, day-int(untruncatedTime.Weekday()), 0, 0, 0, 0, loc)
+
+	return
+}
+
 func truncateToStartOfMonth(untruncatedTime time.Time, loc *time.Location) (truncatedTime time.Time) {
 	var (
 		month time.Month
@@ -457,6 +719,18 @@ func truncateToStartOfMonth(untruncatedTime time.Time, loc *time.Location) (trun
 	return
 }
 
+func truncateToStartOfYear(untruncatedTime time.Time, loc *time.Location) (truncatedTime time.Time) {
+	var (
+		year int
+	)
+
+	year = untruncatedTime.Year()
+
+	truncatedTime = time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+
+	return
+}
+
 // timeNow is presumably time.Now() localized to snapShotSchedule.policy.location...
 //   ...but provided here so that each invocation of the per snapShotSchedule
 //      within a snapShotPolicy can use the same value
@@ -4,10 +4,12 @@
 package inode
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/headhunter"
 )
 
 func TestLoadSnapShotPolicy(t *testing.T) {
@@ -726,3 +728,91 @@ func TestSnapShotPolicyNext(t *testing.T) {
 		t.Fatalf("snapShotPolicy.next(timeOneFortyFiveAM) returned unexpected time: %v", timeOneFortyFiveAMNext)
 	}
 }
+
+func TestSnapShotPolicyClassify(t *testing.T) {
+	var (
+		err                error
+		expectedVerdict    snapShotVerdictStruct
+		expectedVerdicts   []snapShotVerdictStruct
+		snapShotList       []headhunter.SnapShotStruct
+		snapShotName       func(year int, month time.Month, day int, hour int, min int) (name string)
+		testConfMap        conf.ConfMap
+		testConfMapStrings []string
+		timeNow            time.Time
+		verdictIndex       int
+		verdicts           []snapShotVerdictStruct
+		volume             *volumeStruct
+	)
+
+	testConfMapStrings = []string{
+		"SnapShotSchedule:HourlySnapShotSchedule.CronTab=0 * * * *", // ==> snapShotPolicy.schedule[0]
+		"SnapShotSchedule:HourlySnapShotSchedule.Keep=0",
+		"SnapShotPolicy:CommonSnapShotPolicy.ScheduleList=HourlySnapShotSchedule",
+		"SnapShotPolicy:CommonSnapShotPolicy.KeepHourly=2",
+		"SnapShotPolicy:CommonSnapShotPolicy.KeepDaily=2",
+		"SnapShotPolicy:CommonSnapShotPolicy.MinAge=30m",
+		"SnapShotPolicy:CommonSnapShotPolicy.MaxAge=72h",
+		"SnapShotPolicy:CommonSnapShotPolicy.MaxTrappedBytes=1000000",
+		"Volume:TestVolume.SnapShotPolicy=CommonSnapShotPolicy",
+	}
+
+	testConfMap, err = conf.MakeConfMapFromStrings(testConfMapStrings)
+	if nil != err {
+		t.Fatalf("conf.MakeConfMapFromStrings() failed: %v", err)
+	}
+
+	volume = &volumeStruct{volumeName: "TestVolume", snapShotPolicy: nil}
+
+	err = volume.loadSnapShotPolicy(testConfMap)
+	if nil != err {
+		t.Fatalf("loadSnapShotPolicy() failed: %v", err)
+	}
+
+	if (2 != len(volume.snapShotPolicy.period)) || ("KeepHourly" != volume.snapShotPolicy.period[0].name) || ("KeepDaily" != volume.snapShotPolicy.period[1].name) {
+		t.Fatalf("loadSnapShotPolicy() returned snapShotPolicy with unexpected .period")
+	}
+	if (30*time.Minute != volume.snapShotPolicy.minAge) || (72*time.Hour != volume.snapShotPolicy.maxAge) || (1000000 != volume.snapShotPolicy.maxTrappedBytes) {
+		t.Fatalf("loadSnapShotPolicy() returned snapShotPolicy with unexpected .minAge, .maxAge, or .maxTrappedBytes")
+	}
+
+	snapShotName = func(year int, month time.Month, day int, hour int, min int) (name string) {
+		name = strings.Replace(time.Date(year, month, day, hour, min, 0, 0, volume.snapShotPolicy.location).Format(time.RFC3339), ":", ".", -1)
+		return
+	}
+
+	snapShotList = []headhunter.SnapShotStruct{ // Reverse time-ordered
+		headhunter.SnapShotStruct{ID: 1, Name: snapShotName(2017, time.January, 3, 12, 0)},
+		headhunter.SnapShotStruct{ID: 2, Name: snapShotName(2017, time.January, 3, 11, 0)},
+		headhunter.SnapShotStruct{ID: 3, Name: snapShotName(2017, time.January, 3, 10, 0)},
+		headhunter.SnapShotStruct{ID: 4, Name: "Manual"},
+		headhunter.SnapShotStruct{ID: 5, Name: snapShotName(2017, time.January, 2, 23, 0)},
+		headhunter.SnapShotStruct{ID: 6, Name: snapShotName(2017, time.January, 2, 22, 0)},
+		headhunter.SnapShotStruct{ID: 7, Name: snapShotName(2017, time.January, 2, 21, 30)},
+		headhunter.SnapShotStruct{ID: 8, Name: snapShotName(2017, time.January, 2, 21, 0), Pinned: true},
+		headhunter.SnapShotStruct{ID: 9, Name: snapShotName(2016, time.December, 30, 0, 0)},
+	}
+
+	timeNow = time.Date(2017, time.January, 3, 12, 10, 0, 0, volume.snapShotPolicy.location)
+
+	verdicts = volume.snapShotPolicy.classify(snapShotList, timeNow)
+
+	expectedVerdicts = []snapShotVerdictStruct{
+		snapShotVerdictStruct{snapShot: snapShotList[0], delete: false, prunable: false, reason: "younger than MinAge"},
+		snapShotVerdictStruct{snapShot: snapShotList[1], delete: false, prunable: true, reason: "KeepHourly"},
+		snapShotVerdictStruct{snapShot: snapShotList[2], delete: true, prunable: true, reason: "not kept by any SnapShotSchedule or period"},
+		snapShotVerdictStruct{snapShot: snapShotList[4], delete: false, prunable: true, reason: "KeepDaily"},
+		snapShotVerdictStruct{snapShot: snapShotList[5], delete: true, prunable: true, reason: "not kept by any SnapShotSchedule or period"},
+		snapShotVerdictStruct{snapShot: snapShotList[7], delete: false, prunable: false, reason: "pinned"},
+		snapShotVerdictStruct{snapShot: snapShotList[8], delete: true, prunable: true, reason: "older than MaxAge"},
+	}
+
+	if len(expectedVerdicts) != len(verdicts) {
+		t.Fatalf("snapShotPolicy.classify() returned %v verdicts (expected %v)", len(verdicts), len(expectedVerdicts))
+	}
+
+	for verdictIndex, expectedVerdict = range expectedVerdicts {
+		if expectedVerdict != verdicts[verdictIndex] {
+			t.Fatalf("snapShotPolicy.classify() returned verdicts[%v] == %+v (expected %+v)", verdictIndex, verdicts[verdictIndex], expectedVerdict)
+		}
+	}
+}
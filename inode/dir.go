@@ -80,7 +80,10 @@ func (vS *volumeStruct) createRootOrSubDir(filePerm InodeMode, userID InodeUserI
 	}
 
 	// If creating RootDir, force an immediate flush to ensure it is atomically created as well
+	// (a SubDir will have already been charged via quotaReserve() by CreateDir())
 	if isRootDir {
+		vS.quotaAdjust(dirInode.quotaKeys(), 0, 1)
+
 		err = vS.flushInode(dirInode)
 		if nil != err {
 			logger.ErrorfWithError(err, "createRootOrSubDir() call to flushInode() failed")
@@ -93,14 +96,22 @@ func (vS *volumeStruct) createRootOrSubDir(filePerm InodeMode, userID InodeUserI
 }
 
 func (vS *volumeStruct) CreateDir(filePerm InodeMode, userID InodeUserID, groupID InodeGroupID) (dirInodeNumber InodeNumber, err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
 
 	stats.IncrementOperations(&stats.DirCreateOps)
 
+	err = vS.quotaReserve(quotaKeysFor(userID, groupID, 0), 0, 1)
+	if nil != err {
+		return
+	}
+
 	dirInodeNumber, err = vS.createRootOrSubDir(filePerm, userID, groupID, false)
+	if nil != err {
+		vS.quotaAdjust(quotaKeysFor(userID, groupID, 0), 0, -1)
+	}
 
 	if err == nil {
 		stats.IncrementOperations(&stats.DirCreateSuccessOps)
@@ -177,7 +188,7 @@ func (vS *volumeStruct) Link(dirInodeNumber InodeNumber, basename string, target
 		targetInode    *inMemoryInodeStruct
 	)
 
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -350,7 +361,7 @@ func (vS *volumeStruct) Unlink(dirInodeNumber InodeNumber, basename string, remo
 		untargetInodeNumber InodeNumber
 	)
 
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -425,7 +436,7 @@ func (vS *volumeStruct) Unlink(dirInodeNumber InodeNumber, basename string, remo
 }
 
 func (vS *volumeStruct) Move(srcDirInodeNumber InodeNumber, srcBasename string, dstDirInodeNumber InodeNumber, dstBasename string) (toDestroyInodeNumber InodeNumber, err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
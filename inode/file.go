@@ -25,13 +25,19 @@ type fileExtentStruct struct {
 }
 
 func (vS *volumeStruct) CreateFile(filePerm InodeMode, userID InodeUserID, groupID InodeGroupID) (fileInodeNumber InodeNumber, err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
+	if nil != err {
+		return
+	}
+
+	err = vS.quotaReserve(quotaKeysFor(userID, groupID, 0), 0, 1)
 	if nil != err {
 		return
 	}
 
 	fileInode, err := vS.createFileInode(filePerm, userID, groupID)
 	if err != nil {
+		vS.quotaAdjust(quotaKeysFor(userID, groupID, 0), 0, -1)
 		return 0, err
 	}
 	return fileInode.InodeNumber, nil
@@ -200,16 +206,29 @@ func (vS *volumeStruct) Read(fileInodeNumber InodeNumber, offset uint64, length
 		return
 	}
 
+	vS.readAheadIfSequential(snapShotID, fileInode, offset, uint64(len(buf)))
+
 	stats.IncrementOperationsAndBucketedBytes(stats.FileRead, uint64(len(buf)))
 
 	err = nil
 	return
 }
 
+// GetReadPlan returns the steps a client must take to read directly from the LogSegments
+// of fileInodeNumber. As the steps address uncompressed LogSegment offsets, a request
+// that would need to read from a compressed (or encrypted) LogSegment fails with
+// NotSupportedError. Each step reading from a LogSegment having checksums carries them
+// so that the client may verify what it reads.
+//
 func (vS *volumeStruct) GetReadPlan(fileInodeNumber InodeNumber, offset *uint64, length *uint64) (readPlan []ReadPlanStep, err error) {
 	var (
+		checksums     *LogSegmentChecksumsStruct
+		checksumsMap  map[uint64]*LogSegmentChecksumsStruct
+		compression   *LogSegmentCompressionStruct
+		ok            bool
 		readPlanBytes uint64
 		snapShotID    uint64
+		stepIndex     int
 	)
 
 	fileInode, err := vS.fetchInodeType(fileInodeNumber, FileType)
@@ -234,6 +253,28 @@ func (vS *volumeStruct) GetReadPlan(fileInodeNumber InodeNumber, offset *uint64,
 		return
 	}
 
+	checksumsMap = make(map[uint64]*LogSegmentChecksumsStruct)
+
+	for stepIndex = range readPlan {
+		if 0 == readPlan[stepIndex].LogSegmentNumber {
+			continue
+		}
+		checksums, ok = checksumsMap[readPlan[stepIndex].LogSegmentNumber]
+		if !ok {
+			checksums, compression, err = vS.getLogSegmentChecksumsAndCompression(readPlan[stepIndex].LogSegmentNumber)
+			if nil != err {
+				logger.ErrorWithError(err)
+				return
+			}
+			if nil != compression {
+				err = blunder.NewError(blunder.NotSupportedError, "GetReadPlan() of inode %d volume '%s' references compressed or encrypted LogSegment 0x%016X", fileInodeNumber, vS.volumeName, readPlan[stepIndex].LogSegmentNumber)
+				return
+			}
+			checksumsMap[readPlan[stepIndex].LogSegmentNumber] = checksums
+		}
+		readPlan[stepIndex].Checksums = checksums
+	}
+
 	stats.IncrementOperationsBucketedEntriesAndBucketedBytes(stats.FileReadplan, uint64(len(readPlan)), readPlanBytes)
 	return
 }
@@ -397,6 +438,8 @@ func (vS *volumeStruct) getReadPlanHelper(snapShotID uint64, fileInode *inMemory
 
 func (vS *volumeStruct) FetchExtentMapChunk(fileInodeNumber InodeNumber, fileOffset uint64, maxEntriesFromFileOffset int64, maxEntriesBeforeFileOffset int64) (extentMapChunk *ExtentMapChunkStruct, err error) {
 	var (
+		checksums                   *LogSegmentChecksumsStruct
+		compression                 *LogSegmentCompressionStruct
 		containerName               string
 		encodedLogSegmentNumber     uint64
 		extentMap                   sortedmap.BPlusTree
@@ -536,12 +579,19 @@ func (vS *volumeStruct) FetchExtentMapChunk(fileInodeNumber InodeNumber, fileOff
 			panic(err)
 		}
 
+		checksums, compression, err = vS.getLogSegmentChecksumsAndCompression(encodedLogSegmentNumber)
+		if nil != err {
+			panic(err)
+		}
+
 		extentMapChunk.ExtentMapEntry = append(extentMapChunk.ExtentMapEntry, ExtentMapEntryStruct{
 			FileOffset:       fileExtent.FileOffset,
 			LogSegmentOffset: fileExtent.LogSegmentOffset,
 			Length:           fileExtent.Length,
 			ContainerName:    containerName,
 			ObjectName:       objectName,
+			Compression:      compression,
+			Checksums:        checksums,
 		})
 	}
 
@@ -588,13 +638,13 @@ func decrementLogSegmentMapFileData(fileInode *inMemoryInodeStruct, logSegmentNu
 	}
 }
 
-// `recordWrite` is called by `Write` and `Wrote` to update the file inode
-// payload's record of the extents that compose the file.
-func recordWrite(fileInode *inMemoryInodeStruct, fileOffset uint64, length uint64, logSegmentNumber uint64, logSegmentOffset uint64) (err error) {
+// `punchHoleInMemory` eliminates extents or portions thereof that overlap the
+// specified range such that it will "read-as-zero". The file's size is unchanged.
+//
+// Doesn't flush anything.
+func punchHoleInMemory(fileInode *inMemoryInodeStruct, fileOffset uint64, length uint64) {
 	extents := fileInode.payload.(sortedmap.BPlusTree)
 
-	// First we need to eliminate extents or portions thereof that overlap the specified write
-
 	extentIndex, found, err := extents.BisectLeft(fileOffset)
 	if nil != err {
 		panic(err)
@@ -698,6 +748,16 @@ func recordWrite(fileInode *inMemoryInodeStruct, fileOffset uint64, length uint6
 			break
 		}
 	}
+}
+
+// `recordWrite` is called by `Write` and `Wrote` to update the file inode
+// payload's record of the extents that compose the file.
+func recordWrite(fileInode *inMemoryInodeStruct, fileOffset uint64, length uint64, logSegmentNumber uint64, logSegmentOffset uint64) (err error) {
+	extents := fileInode.payload.(sortedmap.BPlusTree)
+
+	// First we need to eliminate extents or portions thereof that overlap the specified write
+
+	punchHoleInMemory(fileInode, fileOffset, length)
 
 	// Now that there will be no overlap, see if we can append to the preceding fileExtent
 
@@ -769,7 +829,7 @@ func recordWrite(fileInode *inMemoryInodeStruct, fileOffset uint64, length uint6
 }
 
 func (vS *volumeStruct) Write(fileInodeNumber InodeNumber, offset uint64, buf []byte, profiler *utils.Profiler) (err error) {
-	err = enforceRWMode(true)
+	err = vS.enforceRWMode(true)
 	if nil != err {
 		return
 	}
@@ -791,6 +851,14 @@ func (vS *volumeStruct) Write(fileInodeNumber InodeNumber, offset uint64, buf []
 		return
 	}
 
+	startingSize := fileInode.Size
+
+	priorBytes, reservedBytes, err := vS.quotaReserveFileData(fileInode, uint64(len(buf))-quotaReferencedBytes(fileInode, offset, uint64(len(buf))))
+	if nil != err {
+		return
+	}
+	defer vS.quotaSettleFileData(fileInode, priorBytes, reservedBytes)
+
 	fileInode.dirty = true
 
 	logSegmentNumber, logSegmentOffset, err := vS.doSendChunk(fileInode, buf)
@@ -800,7 +868,6 @@ func (vS *volumeStruct) Write(fileInodeNumber InodeNumber, offset uint64, buf []
 	}
 
 	length := uint64(len(buf))
-	startingSize := fileInode.Size
 
 	err = recordWrite(fileInode, offset, length, logSegmentNumber, logSegmentOffset)
 	if nil != err {
@@ -821,8 +888,13 @@ func (vS *volumeStruct) Write(fileInodeNumber InodeNumber, offset uint64, buf []
 	return
 }
 
-func (vS *volumeStruct) Wrote(fileInodeNumber InodeNumber, containerName string, objectName string, fileOffset []uint64, objectOffset []uint64, length []uint64, wroteTime time.Time, patchOnly bool) (err error) {
-	err = enforceRWMode(false)
+// Wrote records the extents of a LogSegment written directly by the caller. On an
+// encrypted volume, the caller must have sealed each chunk of the LogSegment (see
+// compression.go) and supply the resultant chunk map in compression. Otherwise,
+// compression must be nil.
+//
+func (vS *volumeStruct) Wrote(fileInodeNumber InodeNumber, containerName string, objectName string, fileOffset []uint64, objectOffset []uint64, length []uint64, checksums *LogSegmentChecksumsStruct, compression *LogSegmentCompressionStruct, wroteTime time.Time, patchOnly bool) (err error) {
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -838,6 +910,27 @@ func (vS *volumeStruct) Wrote(fileInodeNumber InodeNumber, containerName string,
 		return
 	}
 
+	if vS.headhunterVolumeHandle.IsEncrypted() {
+		if (nil == compression) || !compression.Encrypted {
+			err = blunder.NewError(blunder.NotSupportedError, "Wrote() of unsealed LogSegment not supported on encrypted volume '%s'", vS.volumeName)
+			return
+		}
+		if compression.compressed() {
+			err = blunder.NewError(blunder.InvalidArgError, "Wrote() of compressed LogSegment not supported")
+			return
+		}
+		if nil != checksums {
+			logicalLength, _ := compression.lengths()
+			if logicalLength != checksums.Length {
+				err = blunder.NewError(blunder.InvalidArgError, "Wrote() of LogSegment with chunk map length (%d) != checksums length (%d)", logicalLength, checksums.Length)
+				return
+			}
+		}
+	} else if nil != compression {
+		err = blunder.NewError(blunder.InvalidArgError, "Wrote() of sealed or compressed LogSegment not supported on unencrypted volume '%s'", vS.volumeName)
+		return
+	}
+
 	fileInode, err := vS.fetchInodeType(fileInodeNumber, FileType)
 	if err != nil {
 		logger.ErrorWithError(err)
@@ -852,12 +945,35 @@ func (vS *volumeStruct) Wrote(fileInodeNumber InodeNumber, containerName string,
 		}
 	}
 
+	// Bound the bytes newly referenced by the extents about to be recorded
+
+	maxGrowth := uint64(0)
+	for i, thisLength := range length {
+		maxGrowth += thisLength
+		if patchOnly {
+			maxGrowth -= quotaReferencedBytes(fileInode, fileOffset[i], thisLength)
+		}
+	}
+	if !patchOnly {
+		if maxGrowth > fileInode.quotaBytes() {
+			maxGrowth -= fileInode.quotaBytes()
+		} else {
+			maxGrowth = 0
+		}
+	}
+
+	priorBytes, reservedBytes, err := vS.quotaReserveFileData(fileInode, maxGrowth)
+	if nil != err {
+		return
+	}
+	defer vS.quotaSettleFileData(fileInode, priorBytes, reservedBytes)
+
 	logSegmentNumber, err := strconv.ParseUint(objectName, 16, 64)
 	if err != nil {
 		return
 	}
 
-	err = fileInode.volume.setLogSegmentContainer(logSegmentNumber, containerName)
+	err = fileInode.volume.setLogSegmentContainer(logSegmentNumber, containerName, checksums, compression)
 	if nil != err {
 		return
 	}
@@ -908,7 +1024,7 @@ func (vS *volumeStruct) Wrote(fileInodeNumber InodeNumber, containerName string,
 }
 
 func (vS *volumeStruct) SetSize(fileInodeNumber InodeNumber, size uint64) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -924,6 +1040,11 @@ func (vS *volumeStruct) SetSize(fileInodeNumber InodeNumber, size uint64) (err e
 		return err
 	}
 
+	// Extending a file only adds a hole, so nothing need be reserved
+
+	priorBytes := fileInode.quotaBytes()
+	defer vS.quotaSettleFileData(fileInode, priorBytes, 0)
+
 	fileInode.dirty = true
 
 	err = setSizeInMemory(fileInode, size)
@@ -946,8 +1067,173 @@ func (vS *volumeStruct) SetSize(fileInodeNumber InodeNumber, size uint64) (err e
 	return
 }
 
+func (vS *volumeStruct) PunchHole(fileInodeNumber InodeNumber, offset uint64, length uint64) (err error) {
+	err = vS.enforceRWMode(false)
+	if nil != err {
+		return
+	}
+
+	snapShotIDType, _, _ := vS.headhunterVolumeHandle.SnapShotU64Decode(uint64(fileInodeNumber))
+	if headhunter.SnapShotIDTypeLive != snapShotIDType {
+		err = fmt.Errorf("PunchHole() on non-LiveView fileInodeNumber not allowed")
+		return
+	}
+
+	fileInode, err := vS.fetchInodeType(fileInodeNumber, FileType)
+	if nil != err {
+		return err
+	}
+
+	priorBytes := fileInode.quotaBytes()
+	defer vS.quotaSettleFileData(fileInode, priorBytes, 0)
+
+	// Only the portion of the range preceeding end-of-file need be considered
+
+	if offset < fileInode.Size {
+		if length > (fileInode.Size - offset) {
+			length = fileInode.Size - offset
+		}
+
+		punchHoleInMemory(fileInode, offset, length)
+	}
+
+	fileInode.dirty = true
+
+	updateTime := time.Now()
+	fileInode.ModificationTime = updateTime
+	fileInode.AttrChangeTime = updateTime
+
+	// punching a hole is just like a write
+	fileInode.NumWrites++
+
+	err = fileInode.volume.flushInode(fileInode)
+	if nil != err {
+		logger.ErrorWithError(err)
+		return err
+	}
+
+	return
+}
+
+func (vS *volumeStruct) SeekData(fileInodeNumber InodeNumber, offset uint64) (dataOffset uint64, err error) {
+	fileInode, err := vS.fetchInodeType(fileInodeNumber, FileType)
+	if nil != err {
+		return
+	}
+
+	if offset >= fileInode.Size {
+		err = blunder.NewError(blunder.NoDeviceOrAddrError, "SeekData() offset (0x%016X) at or beyond end-of-file", offset)
+		return
+	}
+
+	extents := fileInode.payload.(sortedmap.BPlusTree)
+
+	extentIndex, found, err := extents.BisectLeft(offset)
+	if nil != err {
+		panic(err)
+	}
+
+	if found {
+		// An extent starts precisely at offset
+
+		dataOffset = offset
+		return
+	}
+
+	if 0 <= extentIndex {
+		_, extentValue, ok, getByIndexErr := extents.GetByIndex(extentIndex)
+		if nil != getByIndexErr {
+			panic(getByIndexErr)
+		}
+		if !ok {
+			unexpectedErr := fmt.Errorf("unexpected extents indexing problem")
+			panic(unexpectedErr)
+		}
+		extent := extentValue.(*fileExtentStruct)
+		if (extent.FileOffset + extent.Length) > offset {
+			// Preceeding extent covers offset
+
+			dataOffset = offset
+			return
+		}
+	}
+
+	// offset is in a hole... so data (if any) starts at the next extent
+
+	_, extentValue, ok, err := extents.GetByIndex(extentIndex + 1)
+	if nil != err {
+		panic(err)
+	}
+	if !ok {
+		err = blunder.NewError(blunder.NoDeviceOrAddrError, "SeekData() offset (0x%016X) in trailing hole", offset)
+		return
+	}
+
+	dataOffset = extentValue.(*fileExtentStruct).FileOffset
+
+	return
+}
+
+func (vS *volumeStruct) SeekHole(fileInodeNumber InodeNumber, offset uint64) (holeOffset uint64, err error) {
+	fileInode, err := vS.fetchInodeType(fileInodeNumber, FileType)
+	if nil != err {
+		return
+	}
+
+	if offset >= fileInode.Size {
+		err = blunder.NewError(blunder.NoDeviceOrAddrError, "SeekHole() offset (0x%016X) at or beyond end-of-file", offset)
+		return
+	}
+
+	extents := fileInode.payload.(sortedmap.BPlusTree)
+
+	extentIndex, found, err := extents.BisectLeft(offset)
+	if nil != err {
+		panic(err)
+	}
+
+	if !found && (0 > extentIndex) {
+		// offset preceeds the first extent
+
+		holeOffset = offset
+		return
+	}
+
+	// Walk contiguous extents starting with the one at (or preceeding) offset
+
+	holeOffset = offset
+
+	for {
+		_, extentValue, ok, getByIndexErr := extents.GetByIndex(extentIndex)
+		if nil != getByIndexErr {
+			panic(getByIndexErr)
+		}
+		if !ok {
+			// We have reached the end of extents
+			break
+		}
+		extent := extentValue.(*fileExtentStruct)
+		if extent.FileOffset > holeOffset {
+			// We have found a hole
+			break
+		}
+		if (extent.FileOffset + extent.Length) > holeOffset {
+			holeOffset = extent.FileOffset + extent.Length
+		}
+		extentIndex++
+	}
+
+	// There is always an implicit hole at end-of-file
+
+	if holeOffset > fileInode.Size {
+		holeOffset = fileInode.Size
+	}
+
+	return
+}
+
 func (vS *volumeStruct) Flush(fileInodeNumber InodeNumber, andPurge bool) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1026,6 +1312,8 @@ func (vS *volumeStruct) resetFileInodeInMemory(fileInode *inMemoryInodeStruct) (
 		}
 	}
 
+	vS.quotaAdjust(fileInode.quotaKeys(), -int64(fileInode.quotaBytes()), 0)
+
 	fileInode.LogSegmentMap = make(map[uint64]uint64)
 	fileInode.Size = 0
 	fileInode.NumWrites = 0
@@ -1058,7 +1346,7 @@ func (vS *volumeStruct) Coalesce(destInodeNumber InodeNumber, metaDataName strin
 		toDestroyInodeNumber               InodeNumber
 	)
 
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1193,6 +1481,7 @@ func (vS *volumeStruct) Coalesce(destInodeNumber InodeNumber, metaDataName strin
 			} else {
 				destInode.LogSegmentMap[elementInodeExtent.LogSegmentNumber] = elementInodeExtent.Length
 			}
+			vS.quotaAdjust(destInode.quotaKeys(), int64(elementInodeExtent.Length), 0)
 		}
 		destInodeOffsetBeforeElementAppend += elementInode.Size
 		err = setSizeInMemory(destInode, destInodeOffsetBeforeElementAppend)
@@ -1275,7 +1564,7 @@ func (vS *volumeStruct) DefragmentFile(fileInodeNumber InodeNumber, startingFile
 		fileInode       *inMemoryInodeStruct
 	)
 
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1312,18 +1601,17 @@ func (vS *volumeStruct) DefragmentFile(fileInodeNumber InodeNumber, startingFile
 	return // err as returned by Write() is sufficient
 }
 
-func (vS *volumeStruct) setLogSegmentContainer(logSegmentNumber uint64, containerName string) (err error) {
-	containerNameAsByteSlice := utils.StringToByteSlice(containerName)
-	err = vS.headhunterVolumeHandle.PutLogSegmentRec(logSegmentNumber, containerNameAsByteSlice)
+func (vS *volumeStruct) setLogSegmentContainer(logSegmentNumber uint64, containerName string, checksums *LogSegmentChecksumsStruct, compression *LogSegmentCompressionStruct) (err error) {
+	err = vS.headhunterVolumeHandle.PutLogSegmentRec(logSegmentNumber, encodeLogSegmentRec(containerName, checksums, compression))
 	return
 }
 
 func (vS *volumeStruct) getLogSegmentContainer(logSegmentNumber uint64) (containerName string, err error) {
-	containerNameAsByteSlice, err := vS.headhunterVolumeHandle.GetLogSegmentRec(logSegmentNumber)
+	logSegmentRec, err := vS.headhunterVolumeHandle.GetLogSegmentRec(logSegmentNumber)
 	if nil != err {
 		return
 	}
-	containerName = utils.ByteSliceToString(containerNameAsByteSlice)
+	containerName, _, _, err = decodeLogSegmentRec(logSegmentRec)
 	return
 }
 
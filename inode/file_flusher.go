@@ -104,42 +104,49 @@ func openLogSegmentLRURemove(inFlightLogSegment *inFlightLogSegmentStruct) {
 	globals.Unlock()
 }
 
-func (volumeGroup *volumeGroupStruct) capReadCacheWhileLocked() {
-	for uint64(len(volumeGroup.readCache)) > volumeGroup.readCacheLineCount {
+// evictReadCacheElementsWhileLocked evicts Read Cache Lines, as selected by the volumeGroup's
+// readCachePolicy, until at most limit remain. Should the eviction be on behalf of inserting
+// a Read Cache Line, its readCacheKey is passed as incoming (some policies consult it).
+//
+func (volumeGroup *volumeGroupStruct) evictReadCacheElementsWhileLocked(incoming readCacheKeyStruct, limit uint64) {
+	var (
+		victim *readCacheElementStruct
+	)
+
+	for uint64(len(volumeGroup.readCache)) > limit {
+		victim = volumeGroup.readCachePolicy.evict(incoming, volumeGroup.readCacheLineCount)
+
+		if victim.prefetched {
+			stats.IncrementOperations(&stats.FileReadaheadWasteOps)
+		}
 
-		delete(volumeGroup.readCache, volumeGroup.readCacheLRU.readCacheKey)
-		volumeGroup.readCacheLRU = volumeGroup.readCacheLRU.prev
-		volumeGroup.readCacheLRU.next = nil
+		delete(volumeGroup.readCache, victim.readCacheKey)
+
+		volumeGroup.readCacheStats.Evictions.Increment()
 	}
 }
 
+func (volumeGroup *volumeGroupStruct) capReadCacheWhileLocked() {
+	volumeGroup.evictReadCacheElementsWhileLocked(readCacheKeyStruct{}, volumeGroup.readCacheLineCount)
+}
+
 func (volumeGroup *volumeGroupStruct) insertReadCacheElementWhileLocked(readCacheElement *readCacheElementStruct) {
+	_, alreadyCached := volumeGroup.readCache[readCacheElement.readCacheKey]
+	if alreadyCached {
+		// Another reader (or read-ahead) beat us to it
+		return
+	}
+	if 0 < volumeGroup.readCacheLineCount {
+		volumeGroup.evictReadCacheElementsWhileLocked(readCacheElement.readCacheKey, volumeGroup.readCacheLineCount-1)
+	}
 	volumeGroup.readCache[readCacheElement.readCacheKey] = readCacheElement
-	if nil == volumeGroup.readCacheMRU {
-		volumeGroup.readCacheMRU = readCacheElement
-		volumeGroup.readCacheLRU = readCacheElement
-	} else {
-		readCacheElement.next = volumeGroup.readCacheMRU
-		readCacheElement.next.prev = readCacheElement
-		volumeGroup.readCacheMRU = readCacheElement
+	if volumeGroup.readCachePolicy.insert(readCacheElement, volumeGroup.readCacheLineCount) {
+		volumeGroup.readCacheStats.GhostHits.Increment()
 	}
-	volumeGroup.capReadCacheWhileLocked()
 }
 
 func (volumeGroup *volumeGroupStruct) touchReadCacheElementWhileLocked(readCacheElement *readCacheElementStruct) {
-	if volumeGroup.readCacheMRU != readCacheElement {
-		if readCacheElement == volumeGroup.readCacheLRU {
-			volumeGroup.readCacheLRU = readCacheElement.prev
-			volumeGroup.readCacheLRU.next = nil
-		} else {
-			readCacheElement.prev.next = readCacheElement.next
-			readCacheElement.next.prev = readCacheElement.prev
-		}
-		readCacheElement.next = volumeGroup.readCacheMRU
-		readCacheElement.prev = nil
-		volumeGroup.readCacheMRU.prev = readCacheElement
-		volumeGroup.readCacheMRU = readCacheElement
-	}
+	volumeGroup.readCachePolicy.hit(readCacheElement)
 }
 
 func (vS *volumeStruct) doReadPlan(fileInode *inMemoryInodeStruct, readPlan []ReadPlanStep, readPlanBytes uint64) (buf []byte, err error) {
@@ -188,7 +195,7 @@ func (vS *volumeStruct) doReadPlan(fileInode *inMemoryInodeStruct, readPlan []Re
 		if inFlightHit {
 			// Case 2: The lone step is satisfied by reading from an inFlightLogSegment
 			openLogSegmentLRUTouch(inFlightLogSegment)
-			buf, err = inFlightLogSegment.Read(step.Offset, step.Length)
+			buf, err = inFlightLogSegment.readLogical(step.Offset, step.Length)
 			if nil != err {
 				fileInode.Unlock()
 				logger.ErrorfWithError(err, "Reading back inFlightLogSegment failed - optimal case")
@@ -212,31 +219,26 @@ func (vS *volumeStruct) doReadPlan(fileInode *inMemoryInodeStruct, readPlan []Re
 			readCacheKey.logSegmentNumber = step.LogSegmentNumber
 			readCacheKey.cacheLineTag = step.Offset / readCacheLineSize
 
-			volumeGroup.Lock()
-
-			readCacheElement, readCacheHit = volumeGroup.readCache[readCacheKey]
+			readCacheElement, readCacheHit = volumeGroup.fetchReadCacheElement(readCacheKey)
 
 			if readCacheHit {
-				volumeGroup.touchReadCacheElementWhileLocked(readCacheElement)
 				cacheLine = readCacheElement.cacheLine
-				volumeGroup.Unlock()
 				stats.IncrementOperations(&stats.FileReadcacheHitOps)
 			} else {
-				volumeGroup.Unlock()
 				stats.IncrementOperations(&stats.FileReadcacheMissOps)
 				// Make readCacheHit true (at MRU, likely kicking out LRU)
 				cacheLineStartOffset = readCacheKey.cacheLineTag * readCacheLineSize
-				cacheLine, err = swiftclient.ObjectGet(step.AccountName, step.ContainerName, step.ObjectName, cacheLineStartOffset, readCacheLineSize)
+				cacheLine, err = vS.readLogSegment(step.LogSegmentNumber, step.ContainerName, step.ObjectName, cacheLineStartOffset, readCacheLineSize)
 				if nil != err {
 					logger.ErrorfWithError(err, "Reading from LogSegment object failed - optimal case")
-					err = blunder.AddError(err, blunder.SegReadError)
 					return
 				}
 				readCacheElement = &readCacheElementStruct{
-					readCacheKey: readCacheKey,
-					next:         nil,
-					prev:         nil,
-					cacheLine:    cacheLine,
+					readCacheKey:      readCacheKey,
+					policyList:        nil,
+					policyListElement: nil,
+					policyFrequency:   0,
+					cacheLine:         cacheLine,
 				}
 				volumeGroup.Lock()
 				volumeGroup.insertReadCacheElementWhileLocked(readCacheElement)
@@ -273,7 +275,7 @@ func (vS *volumeStruct) doReadPlan(fileInode *inMemoryInodeStruct, readPlan []Re
 			if inFlightHit {
 				// The step is satisfied by reading from an inFlightLogSegment
 				openLogSegmentLRUTouch(inFlightLogSegment)
-				inFlightHitBuf, err = inFlightLogSegment.Read(step.Offset, step.Length)
+				inFlightHitBuf, err = inFlightLogSegment.readLogical(step.Offset, step.Length)
 				if nil != err {
 					fileInode.Unlock()
 					logger.ErrorfWithError(err, "Reading back inFlightLogSegment failed - general case")
@@ -306,29 +308,25 @@ func (vS *volumeStruct) doReadPlan(fileInode *inMemoryInodeStruct, readPlan []Re
 						// When we've got a cache hit, all the data is inside the cache line
 						cacheLineHitLength = remainingLength
 					}
-					volumeGroup.Lock()
-					readCacheElement, readCacheHit = volumeGroup.readCache[readCacheKey]
+					readCacheElement, readCacheHit = volumeGroup.fetchReadCacheElement(readCacheKey)
 					if readCacheHit {
-						volumeGroup.touchReadCacheElementWhileLocked(readCacheElement)
 						cacheLine = readCacheElement.cacheLine
-						volumeGroup.Unlock()
 						stats.IncrementOperations(&stats.FileReadcacheHitOps)
 					} else {
-						volumeGroup.Unlock()
 						stats.IncrementOperations(&stats.FileReadcacheMissOps)
 						// Make readCacheHit true (at MRU, likely kicking out LRU)
 						cacheLineStartOffset = readCacheKey.cacheLineTag * readCacheLineSize
-						cacheLine, err = swiftclient.ObjectGet(step.AccountName, step.ContainerName, step.ObjectName, cacheLineStartOffset, readCacheLineSize)
+						cacheLine, err = vS.readLogSegment(step.LogSegmentNumber, step.ContainerName, step.ObjectName, cacheLineStartOffset, readCacheLineSize)
 						if nil != err {
 							logger.ErrorfWithError(err, "Reading from LogSegment object failed - general case")
-							err = blunder.AddError(err, blunder.SegReadError)
 							return
 						}
 						readCacheElement = &readCacheElementStruct{
-							readCacheKey: readCacheKey,
-							next:         nil,
-							prev:         nil,
-							cacheLine:    cacheLine,
+							readCacheKey:      readCacheKey,
+							policyList:        nil,
+							policyListElement: nil,
+							policyFrequency:   0,
+							cacheLine:         cacheLine,
 						}
 						volumeGroup.Lock()
 						volumeGroup.insertReadCacheElementWhileLocked(readCacheElement)
@@ -359,6 +357,8 @@ func (vS *volumeStruct) doSendChunk(fileInode *inMemoryInodeStruct, buf []byte)
 		inFlightLogSegment          *inFlightLogSegmentStruct
 		openLogSegmentContainerName string
 		openLogSegmentObjectNumber  uint64
+		physicalBuf                 []byte
+		physicalOffset              uint64
 	)
 
 	fileInode.Lock()
@@ -377,7 +377,7 @@ func (vS *volumeStruct) doSendChunk(fileInode *inMemoryInodeStruct, buf []byte)
 			return
 		}
 
-		err = fileInode.volume.setLogSegmentContainer(openLogSegmentObjectNumber, openLogSegmentContainerName)
+		err = fileInode.volume.setLogSegmentContainer(openLogSegmentObjectNumber, openLogSegmentContainerName, nil, nil)
 		if nil != err {
 			logger.ErrorfWithError(err, "Recording LogSegment ContainerName failed")
 			return
@@ -389,6 +389,8 @@ func (vS *volumeStruct) doSendChunk(fileInode *inMemoryInodeStruct, buf []byte)
 			accountName:      fileInode.volume.accountName,
 			containerName:    openLogSegmentContainerName,
 			objectName:       utils.Uint64ToHexStr(openLogSegmentObjectNumber),
+			checksums:        NewLogSegmentChecksums(fileInode.volume.volumeGroup.readCacheLineSize),
+			compression:      newLogSegmentCompression(fileInode.volume.compression, fileInode.volume.headhunterVolumeHandle.IsEncrypted()),
 		}
 
 		inFlightLogSegment.ChunkedPutContext, err = swiftclient.ObjectFetchChunkedPutContext(inFlightLogSegment.accountName, inFlightLogSegment.containerName, inFlightLogSegment.objectName, "")
@@ -412,21 +414,49 @@ func (vS *volumeStruct) doSendChunk(fileInode *inMemoryInodeStruct, buf []byte)
 
 	logSegmentNumber = inFlightLogSegment.logSegmentNumber
 
-	logSegmentOffset, err = inFlightLogSegment.BytesPut()
+	physicalOffset, err = inFlightLogSegment.BytesPut()
 	if nil != err {
 		fileInode.Unlock()
 		logger.ErrorfWithError(err, "Failed to get current LogSegmentOffset")
 		return
 	}
 
-	err = inFlightLogSegment.ChunkedPutContext.SendChunk(buf)
+	// Extents address the logical (i.e. uncompressed and unsealed) bytes of the LogSegment
+
+	if nil == inFlightLogSegment.compression {
+		logSegmentOffset = physicalOffset
+		physicalBuf = buf
+	} else {
+		logSegmentOffset = inFlightLogSegment.checksums.Length
+		if inFlightLogSegment.compression.compressed() {
+			physicalBuf = compressLogSegmentChunk(inFlightLogSegment.compression.Algorithm, buf)
+		} else {
+			physicalBuf = buf
+		}
+		if inFlightLogSegment.compression.Encrypted {
+			physicalBuf, err = vS.headhunterVolumeHandle.Seal(logSegmentNumber, physicalOffset, physicalBuf)
+			if nil != err {
+				fileInode.Unlock()
+				logger.ErrorfWithError(err, "Sealing LogSegment chunk failed")
+				return
+			}
+		}
+	}
+
+	err = inFlightLogSegment.ChunkedPutContext.SendChunk(physicalBuf)
 	if nil != err {
 		fileInode.Unlock()
 		logger.ErrorfWithError(err, "Sending Chunked PUT chunk to LogSegment failed")
 		return
 	}
 
-	if (logSegmentOffset + uint64(len(buf))) >= fileInode.volume.maxFlushSize {
+	inFlightLogSegment.checksums.Append(buf)
+
+	if nil != inFlightLogSegment.compression {
+		inFlightLogSegment.compression.Append(uint64(len(buf)), uint64(len(physicalBuf)))
+	}
+
+	if (physicalOffset + uint64(len(physicalBuf))) >= fileInode.volume.maxFlushSize {
 		fileInode.Add(1)
 		go vS.inFlightLogSegmentFlusher(inFlightLogSegment, true)
 		// No need to wait for it to complete now... that's only in doFileInodeDataFlush()
@@ -470,8 +500,10 @@ func (vS *volumeStruct) doFileInodeDataFlush(fileInode *inMemoryInodeStruct) (er
 
 func (vS *volumeStruct) inFlightLogSegmentFlusher(inFlightLogSegment *inFlightLogSegmentStruct, doDone bool) {
 	var (
-		err       error
-		fileInode *inMemoryInodeStruct
+		err            error
+		fileInode      *inMemoryInodeStruct
+		logicalLength  uint64
+		physicalLength uint64
 	)
 
 	// Handle the race between a DLM-serialized Flush triggering this versus the starvatation condition
@@ -504,6 +536,21 @@ func (vS *volumeStruct) inFlightLogSegmentFlusher(inFlightLogSegment *inFlightLo
 
 	// Finish up... recording error (if any) in the process
 
+	if nil == err {
+		err = vS.putLogSegmentChecksums(inFlightLogSegment.logSegmentNumber, inFlightLogSegment.containerName, inFlightLogSegment.checksums, inFlightLogSegment.compression)
+		if nil == err {
+			if inFlightLogSegment.compression.compressed() {
+				logicalLength, physicalLength = inFlightLogSegment.compression.lengths()
+				vS.compressionStatsAdjust(int64(logicalLength), int64(physicalLength))
+			}
+		} else if nil == inFlightLogSegment.compression {
+			// Checksums are advisory, so a failure to record them alone does not fail the flush
+
+			logger.WarnfWithError(err, "Recording LogSegment checksums failed")
+			err = nil
+		}
+	}
+
 	if nil != err {
 		err = blunder.AddError(err, blunder.InodeFlushError)
 		fileInode.inFlightLogSegmentErrors[inFlightLogSegment.logSegmentNumber] = err
@@ -56,6 +56,7 @@ type onDiskInodeV1Struct struct { // Preceded "on disk" by CorruptionDetected th
 	Mode                InodeMode
 	UserID              InodeUserID
 	GroupID             InodeGroupID
+	ProjectID           uint64
 	StreamMap           map[string][]byte
 	PayloadObjectNumber uint64            // DirInode:     B+Tree Root with Key == dir_entry_name, Value = InodeNumber
 	PayloadObjectLength uint64            // FileInode:    B+Tree Root with Key == fileOffset, Value = fileExtent
@@ -71,6 +72,8 @@ type inFlightLogSegmentStruct struct { //               Used as (by reference) V
 	accountName               string
 	containerName             string
 	objectName                string
+	checksums                 *LogSegmentChecksumsStruct
+	compression               *LogSegmentCompressionStruct // If == nil, chunks are not compressed
 	openLogSegmentListElement list.Element
 	swiftclient.ChunkedPutContext
 }
@@ -88,6 +91,8 @@ type inMemoryInodeStruct struct {
 	openLogSegment           *inFlightLogSegmentStruct            // FileInode only... also in inFlightLogSegmentMap
 	inFlightLogSegmentMap    map[uint64]*inFlightLogSegmentStruct // FileInode: key == logSegmentNumber
 	inFlightLogSegmentErrors map[uint64]error                     // FileInode: key == logSegmentNumber; value == err (if non nil)
+	readAheadNextOffset      uint64                               // FileInode: offset following the most recent Read()
+	readAheadWindow          uint64                               // FileInode: bytes to read-ahead (0 if access not sequential)
 	onDiskInodeV1Struct                                           // Real on-disk inode information embedded here
 }
 
@@ -163,11 +168,16 @@ func setRWMode(rwMode RWModeType) (err error) {
 	return
 }
 
-func enforceRWMode(enforceNoWriteMode bool) (err error) {
+func (vS *volumeStruct) enforceRWMode(enforceNoWriteMode bool) (err error) {
 	var (
 		rwModeCopy RWModeType
 	)
 
+	if vS.readOnly {
+		err = blunder.NewError(blunder.ReadOnlyError, "EROFS")
+		return
+	}
+
 	rwModeCopy = globals.rwMode
 
 	if rwModeCopy == RWModeReadOnly {
@@ -1031,7 +1041,7 @@ func (vS *volumeStruct) flushInodes(inodes []*inMemoryInodeStruct) (err error) {
 	// Now do phase one of garbage collection
 	if 0 < len(emptyLogSegments) {
 		for _, logSegmentNumber = range emptyLogSegments {
-			err = vS.headhunterVolumeHandle.DeleteLogSegmentRec(logSegmentNumber)
+			err = vS.deleteLogSegmentRec(logSegmentNumber)
 			if nil != err {
 				logger.WarnfWithError(err, "couldn't delete garbage log segment")
 			}
@@ -1240,6 +1250,8 @@ func (vS *volumeStruct) Access(inodeNumber InodeNumber, userID InodeUserID, grou
 		ourInodeGroupID     InodeGroupID
 		ourInodeMode        InodeMode
 		ourInodeUserID      InodeUserID
+		posixACL            []posixACLEntryStruct
+		posixACLBuf         []byte
 		snapShotIDType      headhunter.SnapShotIDType
 	)
 
@@ -1361,6 +1373,18 @@ func (vS *volumeStruct) Access(inodeNumber InodeNumber, userID InodeUserID, grou
 		return
 	}
 
+	// If an access ACL is present, its named user and group entries (limited by
+	// its ACL_MASK entry) take the place of the group permission bits
+	posixACLBuf, ok = ourInode.StreamMap[PosixACLAccessStreamName]
+	if ok {
+		posixACL, err = unmarshalPosixACL(posixACLBuf)
+		if nil == err {
+			accessReturn = posixACLAccess(posixACL, ourInodeMode, ourInodeGroupID, userID, groupID, otherGroupIDs, accessMode)
+			return
+		}
+		logger.WarnfWithError(err, "%s: ignoring invalid POSIX ACL of inode %d volume '%s'", utils.GetFnName(), adjustedInodeNumber, vS.volumeName)
+	}
+
 	groupIDCheck = (groupID == ourInodeGroupID)
 	if !groupIDCheck {
 		for _, otherGroupID = range otherGroupIDs {
@@ -1380,7 +1404,7 @@ func (vS *volumeStruct) Access(inodeNumber InodeNumber, userID InodeUserID, grou
 }
 
 func (vS *volumeStruct) ProvisionObject() (objectPath string, err error) {
-	err = enforceRWMode(true)
+	err = vS.enforceRWMode(true)
 	if nil != err {
 		return
 	}
@@ -1402,7 +1426,7 @@ func (vS *volumeStruct) Purge(inodeNumber InodeNumber) (err error) {
 		ok    bool
 	)
 
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1431,7 +1455,7 @@ func (vS *volumeStruct) Purge(inodeNumber InodeNumber) (err error) {
 func (vS *volumeStruct) Destroy(inodeNumber InodeNumber) (err error) {
 	logger.Tracef("inode.Destroy(): volume '%s' inode %d", vS.volumeName, inodeNumber)
 
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1479,6 +1503,8 @@ func (vS *volumeStruct) Destroy(inodeNumber InodeNumber) (err error) {
 		return
 	}
 
+	vS.quotaAdjust(ourInode.quotaKeys(), -int64(ourInode.quotaBytes()), -1)
+
 	if DirType == ourInode.InodeType {
 		logger.Tracef("inode.Destroy(): volume '%s' inode %d: discarding dirmap payload Object %016X  len %d",
 			vS.volumeName, inodeNumber, ourInode.PayloadObjectNumber, ourInode.PayloadObjectLength)
@@ -1506,7 +1532,7 @@ func (vS *volumeStruct) Destroy(inodeNumber InodeNumber) (err error) {
 		}
 
 		for logSegmentNumber := range ourInode.LogSegmentMap {
-			deleteSegmentErr := vS.headhunterVolumeHandle.DeleteLogSegmentRec(logSegmentNumber)
+			deleteSegmentErr := vS.deleteLogSegmentRec(logSegmentNumber)
 			if nil != deleteSegmentErr {
 				logger.WarnfWithError(deleteSegmentErr, "couldn't delete destroy'd log segment")
 				return
@@ -1567,6 +1593,7 @@ func (vS *volumeStruct) GetMetadata(inodeNumber InodeNumber) (metadata *Metadata
 		Mode:                 inode.Mode,
 		UserID:               inode.UserID,
 		GroupID:              inode.GroupID,
+		ProjectID:            inode.ProjectID,
 	}
 
 	if headhunter.SnapShotIDTypeDotSnapShot == snapShotIDType {
@@ -1670,7 +1697,7 @@ func (vS *volumeStruct) GetLinkCount(inodeNumber InodeNumber) (linkCount uint64,
 
 // SetLinkCount is used to adjust the LinkCount property to match current reference count during FSCK TreeWalk.
 func (vS *volumeStruct) SetLinkCount(inodeNumber InodeNumber, linkCount uint64) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1710,7 +1737,7 @@ func (vS *volumeStruct) SetLinkCount(inodeNumber InodeNumber, linkCount uint64)
 }
 
 func (vS *volumeStruct) SetCreationTime(inodeNumber InodeNumber, CreationTime time.Time) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1750,7 +1777,7 @@ func (vS *volumeStruct) SetCreationTime(inodeNumber InodeNumber, CreationTime ti
 }
 
 func (vS *volumeStruct) SetModificationTime(inodeNumber InodeNumber, ModificationTime time.Time) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1791,7 +1818,7 @@ func (vS *volumeStruct) SetModificationTime(inodeNumber InodeNumber, Modificatio
 }
 
 func (vS *volumeStruct) SetAccessTime(inodeNumber InodeNumber, accessTime time.Time) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1861,7 +1888,7 @@ func determineMode(filePerm InodeMode, inodeType InodeType) (fileMode InodeMode,
 }
 
 func (vS *volumeStruct) SetPermMode(inodeNumber InodeNumber, filePerm InodeMode) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1910,7 +1937,7 @@ func (vS *volumeStruct) SetPermMode(inodeNumber InodeNumber, filePerm InodeMode)
 }
 
 func (vS *volumeStruct) SetOwnerUserID(inodeNumber InodeNumber, userID InodeUserID) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1937,6 +1964,8 @@ func (vS *volumeStruct) SetOwnerUserID(inodeNumber InodeNumber, userID InodeUser
 		return err
 	}
 
+	vS.quotaTransfer(userQuotaKeys(inode.UserID), userQuotaKeys(userID), inode.quotaBytes())
+
 	inode.dirty = true
 	inode.UserID = userID
 
@@ -1953,7 +1982,7 @@ func (vS *volumeStruct) SetOwnerUserID(inodeNumber InodeNumber, userID InodeUser
 }
 
 func (vS *volumeStruct) SetOwnerUserIDGroupID(inodeNumber InodeNumber, userID InodeUserID, groupID InodeGroupID) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -1980,6 +2009,9 @@ func (vS *volumeStruct) SetOwnerUserIDGroupID(inodeNumber InodeNumber, userID In
 		return err
 	}
 
+	vS.quotaTransfer(userQuotaKeys(inode.UserID), userQuotaKeys(userID), inode.quotaBytes())
+	vS.quotaTransfer(groupQuotaKeys(inode.GroupID), groupQuotaKeys(groupID), inode.quotaBytes())
+
 	inode.dirty = true
 	inode.UserID = userID
 	inode.GroupID = groupID
@@ -1997,7 +2029,7 @@ func (vS *volumeStruct) SetOwnerUserIDGroupID(inodeNumber InodeNumber, userID In
 }
 
 func (vS *volumeStruct) SetOwnerGroupID(inodeNumber InodeNumber, groupID InodeGroupID) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -2024,6 +2056,8 @@ func (vS *volumeStruct) SetOwnerGroupID(inodeNumber InodeNumber, groupID InodeGr
 		return err
 	}
 
+	vS.quotaTransfer(groupQuotaKeys(inode.GroupID), groupQuotaKeys(groupID), inode.quotaBytes())
+
 	inode.dirty = true
 	inode.GroupID = groupID
 
@@ -2069,6 +2103,12 @@ func (vS *volumeStruct) GetStream(inodeNumber InodeNumber, inodeStreamName strin
 		return buf, blunder.AddError(err, blunder.StreamNotFound)
 	}
 
+	if PosixACLAccessStreamName == inodeStreamName {
+		buf = syncPosixACLStream(inodeStreamBuf, inode.Mode)
+		err = nil
+		return
+	}
+
 	buf = make([]byte, len(inodeStreamBuf))
 
 	copy(buf, inodeStreamBuf)
@@ -2079,7 +2119,7 @@ func (vS *volumeStruct) GetStream(inodeNumber InodeNumber, inodeStreamName strin
 }
 
 func (vS *volumeStruct) PutStream(inodeNumber InodeNumber, inodeStreamName string, buf []byte) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -2106,12 +2146,24 @@ func (vS *volumeStruct) PutStream(inodeNumber InodeNumber, inodeStreamName strin
 		return err
 	}
 
+	if IsPosixACLStreamName(inodeStreamName) {
+		buf, err = inode.preparePosixACLStream(inodeStreamName, buf)
+		if nil != err {
+			return
+		}
+	}
+
 	inodeStreamBuf := make([]byte, len(buf))
 
 	copy(inodeStreamBuf, buf)
 
 	inode.dirty = true
-	inode.StreamMap[inodeStreamName] = inodeStreamBuf
+	if (nil == buf) && IsPosixACLStreamName(inodeStreamName) {
+		// POSIX ACL equivalent to Mode (or empty) so needn't be kept
+		delete(inode.StreamMap, inodeStreamName)
+	} else {
+		inode.StreamMap[inodeStreamName] = inodeStreamBuf
+	}
 
 	updateTime := time.Now()
 	inode.AttrChangeTime = updateTime
@@ -2126,7 +2178,7 @@ func (vS *volumeStruct) PutStream(inodeNumber InodeNumber, inodeStreamName strin
 }
 
 func (vS *volumeStruct) DeleteStream(inodeNumber InodeNumber, inodeStreamName string) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -2208,7 +2260,7 @@ func (vS *volumeStruct) FetchFragmentationReport(inodeNumber InodeNumber) (fragm
 }
 
 func (vS *volumeStruct) Optimize(inodeNumber InodeNumber, maxDuration time.Duration) (err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -2278,6 +2330,7 @@ func validateFileExtents(snapShotID uint64, ourInode *inMemoryInodeStruct) (err
 	// segment objects in Swift. First, we'll construct a map of object paths to
 	// the largest offset we would need read up to in that object.
 	objectPathToEndOffset := make(map[string]uint64)
+	objectPathToLogSegmentNumber := make(map[string]uint64)
 
 	for _, planStep := range readPlan {
 
@@ -2290,6 +2343,18 @@ func validateFileExtents(snapShotID uint64, ourInode *inMemoryInodeStruct) (err
 		if !ok || stepEndOffset > endOffset {
 			objectPathToEndOffset[planStep.ObjectPath] = stepEndOffset
 		}
+		objectPathToLogSegmentNumber[planStep.ObjectPath] = planStep.LogSegmentNumber
+	}
+
+	// Offsets into compressed log segments must be mapped to the bytes actually stored
+	for objectPath, endOffset := range objectPathToEndOffset {
+		compression, err := ourInode.volume.getLogSegmentCompression(objectPathToLogSegmentNumber[objectPath])
+		if err != nil {
+			return blunder.AddError(err, blunder.CorruptInodeError)
+		}
+		if compression != nil {
+			objectPathToEndOffset[objectPath] = compression.physicalEnd(endOffset)
+		}
 	}
 
 	// then, HEAD each object to make sure that it has enough bytes.
@@ -0,0 +1,681 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package inode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/NVIDIA/cstruct"
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/headhunter"
+	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/trackedlock"
+	"github.com/NVIDIA/proxyfs/utils"
+)
+
+// Quota usage is charged for every LiveView inode against the quota of its UserID, its
+// GroupID, and (if non-zero) its ProjectID. Usage is tracked whether or not limits have
+// been set so that limits may be imposed at any time. The limits and usage are persisted
+// in headhunter's QuotaRec following each checkpoint and when the volume is unserved. As
+// the QuotaRec may thus lag the InodeRecs it summarizes, it is only marked CleanlyUnserved
+// when persisted by UnserveVolume(). Upon serving the volume, the QuotaRec is immediately
+// re-persisted with CleanlyUnserved cleared. Should the volume next be served following a
+// crash, usage is recomputed (retaining the limits) by scanning every InodeRec.
+//
+// The bytes charged for a file inode are those referenced by its extents (i.e. the sum
+// of its LogSegmentMap) rather than its Size. Holes, whether left by extending a file
+// via SetSize(), by PunchHole(), or by a Fallocate() that merely reserves its range,
+// consume no quota. Overwriting bytes already referenced by the file is charged only for
+// the bytes newly referenced.
+
+type quotaKeyStruct struct {
+	quotaType QuotaType
+	quotaID   uint64
+}
+
+type quotaEntryStruct struct {
+	QuotaLimitsStruct
+	QuotaUsageStruct
+}
+
+type quotaStruct struct {
+	trackedlock.Mutex
+	dirty           bool
+	cleanlyUnserved bool // Value of CleanlyUnserved in the persisted QuotaRec
+	entryMap        map[quotaKeyStruct]*quotaEntryStruct
+}
+
+type onDiskQuotaV1Struct struct {
+	Version         Version
+	QuotaList       []QuotaStruct
+	CleanlyUnserved bool
+}
+
+// onDiskInodeQuotaViewStruct picks out just the onDiskInodeV1Struct fields needed to
+// compute quota usage when scanning InodeRecs.
+type onDiskInodeQuotaViewStruct struct {
+	InodeType
+	LogSegmentMap map[uint64]uint64
+	UserID        InodeUserID
+	GroupID       InodeGroupID
+	ProjectID     uint64
+}
+
+func quotaKeysFor(userID InodeUserID, groupID InodeGroupID, projectID uint64) (quotaKeys []quotaKeyStruct) {
+	quotaKeys = make([]quotaKeyStruct, 0, 3)
+
+	quotaKeys = append(quotaKeys, userQuotaKeys(userID)...)
+	quotaKeys = append(quotaKeys, groupQuotaKeys(groupID)...)
+	quotaKeys = append(quotaKeys, projectQuotaKeys(projectID)...)
+
+	return
+}
+
+func userQuotaKeys(userID InodeUserID) (quotaKeys []quotaKeyStruct) {
+	quotaKeys = []quotaKeyStruct{{quotaType: QuotaTypeUser, quotaID: uint64(userID)}}
+	return
+}
+
+func groupQuotaKeys(groupID InodeGroupID) (quotaKeys []quotaKeyStruct) {
+	quotaKeys = []quotaKeyStruct{{quotaType: QuotaTypeGroup, quotaID: uint64(groupID)}}
+	return
+}
+
+func projectQuotaKeys(projectID uint64) (quotaKeys []quotaKeyStruct) {
+	if 0 == projectID {
+		quotaKeys = []quotaKeyStruct{}
+	} else {
+		quotaKeys = []quotaKeyStruct{{quotaType: QuotaTypeProject, quotaID: projectID}}
+	}
+	return
+}
+
+func (inode *inMemoryInodeStruct) quotaKeys() (quotaKeys []quotaKeyStruct) {
+	quotaKeys = quotaKeysFor(inode.UserID, inode.GroupID, inode.ProjectID)
+	return
+}
+
+// quotaBytes returns the number of bytes charged for an inode. For a file inode, this
+// is the number of bytes referenced by its extents... so holes are not charged.
+func (inode *inMemoryInodeStruct) quotaBytes() (bytes uint64) {
+	if FileType == inode.InodeType {
+		bytes = logSegmentMapBytes(inode.LogSegmentMap)
+	} else {
+		bytes = 0
+	}
+	return
+}
+
+// logSegmentMapBytes sums the file user data bytes referenced in each LogSegment.
+func logSegmentMapBytes(logSegmentMap map[uint64]uint64) (bytes uint64) {
+	bytes = 0
+	for _, logSegmentBytes := range logSegmentMap {
+		bytes += logSegmentBytes
+	}
+	return
+}
+
+// quotaReferencedBytes returns the number of bytes in [offset, offset+length) of fileInode
+// that are currently referenced by its extents (i.e. are not part of a hole).
+func quotaReferencedBytes(fileInode *inMemoryInodeStruct, offset uint64, length uint64) (bytes uint64) {
+	var (
+		err         error
+		extent      *fileExtentStruct
+		extentEnd   uint64
+		extentIndex int
+		extents     sortedmap.BPlusTree
+		ok          bool
+		overlapEnd  uint64
+		overlapHead uint64
+		value       sortedmap.Value
+	)
+
+	bytes = 0
+
+	extents = fileInode.payload.(sortedmap.BPlusTree)
+
+	extentIndex, _, err = extents.BisectLeft(offset)
+	if nil != err {
+		panic(err)
+	}
+	if 0 > extentIndex {
+		extentIndex = 0
+	}
+
+	for {
+		_, value, ok, err = extents.GetByIndex(extentIndex)
+		if nil != err {
+			panic(err)
+		}
+		if !ok {
+			return
+		}
+		extent = value.(*fileExtentStruct)
+		if extent.FileOffset >= (offset + length) {
+			return
+		}
+		extentEnd = extent.FileOffset + extent.Length
+		if extentEnd > offset {
+			overlapHead = extent.FileOffset
+			if overlapHead < offset {
+				overlapHead = offset
+			}
+			overlapEnd = extentEnd
+			if overlapEnd > (offset + length) {
+				overlapEnd = offset + length
+			}
+			bytes += overlapEnd - overlapHead
+		}
+		extentIndex++
+	}
+}
+
+func validateQuotaTypeAndID(quotaType QuotaType, quotaID uint64) (err error) {
+	switch quotaType {
+	case QuotaTypeUser, QuotaTypeGroup:
+		err = nil
+	case QuotaTypeProject:
+		if 0 == quotaID {
+			err = blunder.NewError(blunder.InvalidArgError, "ProjectID 0 cannot have a quota")
+		} else {
+			err = nil
+		}
+	default:
+		err = blunder.NewError(blunder.InvalidArgError, "QuotaType \"%s\" not supported", quotaType)
+	}
+	return
+}
+
+// quotaReserve charges the specified additional bytes and inodes to each of quotaKeys
+// unless doing so would exceed any of their limits, in which case nothing is charged
+// and blunder.QuotaExceededError is returned. As the check and the charge are made
+// under the same lock, concurrent callers cannot together exceed a limit. A caller
+// that subsequently fails must return the reservation via quotaAdjust().
+func (vS *volumeStruct) quotaReserve(quotaKeys []quotaKeyStruct, bytes uint64, inodes uint64) (err error) {
+	var (
+		entry    *quotaEntryStruct
+		ok       bool
+		quotaKey quotaKeyStruct
+	)
+
+	if (0 == bytes) && (0 == inodes) {
+		err = nil
+		return
+	}
+
+	vS.quota.Lock()
+	defer vS.quota.Unlock()
+
+	for _, quotaKey = range quotaKeys {
+		entry, ok = vS.quota.entryMap[quotaKey]
+		if !ok {
+			continue
+		}
+		if (0 != bytes) && (0 != entry.MaxBytes) && ((entry.Bytes + bytes) > entry.MaxBytes) {
+			err = blunder.NewError(blunder.QuotaExceededError, "%s %d quota of %d bytes exceeded", quotaKey.quotaType, quotaKey.quotaID, entry.MaxBytes)
+			return
+		}
+		if (0 != inodes) && (0 != entry.MaxInodes) && ((entry.Inodes + inodes) > entry.MaxInodes) {
+			err = blunder.NewError(blunder.QuotaExceededError, "%s %d quota of %d inodes exceeded", quotaKey.quotaType, quotaKey.quotaID, entry.MaxInodes)
+			return
+		}
+	}
+
+	for _, quotaKey = range quotaKeys {
+		entry, ok = vS.quota.entryMap[quotaKey]
+		if !ok {
+			entry = &quotaEntryStruct{}
+			vS.quota.entryMap[quotaKey] = entry
+		}
+
+		entry.Bytes += bytes
+		entry.Inodes += inodes
+	}
+
+	vS.quota.dirty = true
+
+	err = nil
+	return
+}
+
+// quotaAdjust applies the (signed) changes in bytes and inodes to the usage of each of quotaKeys.
+func (vS *volumeStruct) quotaAdjust(quotaKeys []quotaKeyStruct, bytesDelta int64, inodesDelta int64) {
+	var (
+		entry    *quotaEntryStruct
+		ok       bool
+		quotaKey quotaKeyStruct
+	)
+
+	if (0 == bytesDelta) && (0 == inodesDelta) {
+		return
+	}
+
+	vS.quota.Lock()
+
+	for _, quotaKey = range quotaKeys {
+		entry, ok = vS.quota.entryMap[quotaKey]
+		if !ok {
+			entry = &quotaEntryStruct{}
+			vS.quota.entryMap[quotaKey] = entry
+		}
+
+		entry.Bytes = adjustQuotaUsage(entry.Bytes, bytesDelta)
+		entry.Inodes = adjustQuotaUsage(entry.Inodes, inodesDelta)
+
+		if (0 == entry.Bytes) && (0 == entry.Inodes) && (0 == entry.MaxBytes) && (0 == entry.MaxInodes) {
+			delete(vS.quota.entryMap, quotaKey)
+		}
+	}
+
+	vS.quota.dirty = true
+
+	vS.quota.Unlock()
+}
+
+func adjustQuotaUsage(usage uint64, delta int64) (adjustedUsage uint64) {
+	if delta >= 0 {
+		adjustedUsage = usage + uint64(delta)
+	} else if uint64(-delta) < usage {
+		adjustedUsage = usage - uint64(-delta)
+	} else {
+		adjustedUsage = 0
+	}
+	return
+}
+
+// quotaReserveFileData reserves (via quotaReserve()) the maxGrowth bytes an operation
+// on fileInode may newly reference. The caller must later pass priorBytes and reservedBytes
+// to quotaSettleFileData().
+func (vS *volumeStruct) quotaReserveFileData(fileInode *inMemoryInodeStruct, maxGrowth uint64) (priorBytes uint64, reservedBytes uint64, err error) {
+	priorBytes = fileInode.quotaBytes()
+
+	if 0 == maxGrowth {
+		reservedBytes = 0
+		err = nil
+		return
+	}
+
+	err = vS.quotaReserve(fileInode.quotaKeys(), maxGrowth, 0)
+	if nil == err {
+		reservedBytes = maxGrowth
+	} else {
+		reservedBytes = 0
+	}
+
+	return
+}
+
+// quotaSettleFileData charges fileInode for its change in referenced bytes since it
+// referenced priorBytes less the reservedBytes already charged by quotaReserveFileData().
+func (vS *volumeStruct) quotaSettleFileData(fileInode *inMemoryInodeStruct, priorBytes uint64, reservedBytes uint64) {
+	vS.quotaAdjust(fileInode.quotaKeys(), int64(fileInode.quotaBytes())-int64(priorBytes)-int64(reservedBytes), 0)
+}
+
+// quotaTransfer moves the charges for an inode from one set of quotas to another.
+func (vS *volumeStruct) quotaTransfer(fromQuotaKeys []quotaKeyStruct, toQuotaKeys []quotaKeyStruct, bytes uint64) {
+	vS.quotaAdjust(fromQuotaKeys, -int64(bytes), -1)
+	vS.quotaAdjust(toQuotaKeys, int64(bytes), 1)
+}
+
+// quotaLoad initializes vS.quota from the volume's QuotaRec. If the volume has never
+// recorded one, or it was not CleanlyUnserved, usage is computed by scanning every InodeRec.
+// Unless the volume is read-only, the QuotaRec is then persisted with CleanlyUnserved cleared.
+func (vS *volumeStruct) quotaLoad() (err error) {
+	var (
+		onDiskQuotaV1 *onDiskQuotaV1Struct
+		ok            bool
+		quota         QuotaStruct
+		quotaRec      []byte
+	)
+
+	vS.quota = &quotaStruct{
+		dirty:           false,
+		cleanlyUnserved: false,
+		entryMap:        make(map[quotaKeyStruct]*quotaEntryStruct),
+	}
+
+	quotaRec, ok, err = vS.headhunterVolumeHandle.GetQuotaRec()
+	if nil != err {
+		err = fmt.Errorf("%s: unable to fetch QuotaRec for volume %s: %v", utils.GetFnName(), vS.volumeName, err)
+		return
+	}
+
+	if !ok {
+		err = vS.quotaScan()
+		if nil == err {
+			err = vS.quotaMarkServed()
+		}
+		return
+	}
+
+	onDiskQuotaV1 = &onDiskQuotaV1Struct{}
+
+	err = json.Unmarshal(quotaRec, onDiskQuotaV1)
+	if nil != err {
+		err = fmt.Errorf("%s: QuotaRec for volume %s json.Unmarshal() failed: %v", utils.GetFnName(), vS.volumeName, err)
+		return
+	}
+	if V1 != onDiskQuotaV1.Version {
+		err = fmt.Errorf("%s: QuotaRec.Version for volume %s (%v) not supported", utils.GetFnName(), vS.volumeName, onDiskQuotaV1.Version)
+		return
+	}
+
+	for _, quota = range onDiskQuotaV1.QuotaList {
+		vS.quota.entryMap[quotaKeyStruct{quotaType: quota.Type, quotaID: quota.ID}] = &quotaEntryStruct{
+			QuotaLimitsStruct: quota.QuotaLimitsStruct,
+			QuotaUsageStruct:  quota.QuotaUsageStruct,
+		}
+	}
+
+	vS.quota.cleanlyUnserved = onDiskQuotaV1.CleanlyUnserved
+
+	if !onDiskQuotaV1.CleanlyUnserved {
+		logger.Infof("QuotaRec for volume %s was not cleanly unserved... recomputing quota usage", vS.volumeName)
+
+		err = vS.quotaRescan()
+		if nil != err {
+			return
+		}
+	}
+
+	err = vS.quotaMarkServed()
+
+	return
+}
+
+// quotaMarkServed clears CleanlyUnserved in the volume's QuotaRec such that, should the
+// volume not be cleanly unserved, quotaLoad() will recompute usage.
+func (vS *volumeStruct) quotaMarkServed() (err error) {
+	if vS.readOnly {
+		err = nil
+		return
+	}
+
+	err = vS.quotaPersist(false)
+
+	return
+}
+
+// quotaScan computes quota usage from scratch by examining every LiveView InodeRec.
+func (vS *volumeStruct) quotaScan() (err error) {
+	var (
+		bytesConsumedByCorruptionDetected uint64
+		bytesConsumedByVersion            uint64
+		corruptionDetected                CorruptionDetected
+		inodeBytes                        uint64
+		inodeNumber                       uint64
+		inodeRec                          []byte
+		inodeView                         onDiskInodeQuotaViewStruct
+		numInodes                         uint64
+		ok                                bool
+		startTime                         time.Time = time.Now()
+		version                           Version
+	)
+
+	inodeNumber, ok, err = vS.headhunterVolumeHandle.IndexedInodeNumber(0)
+
+	for ok && (nil == err) {
+		inodeRec, ok, err = vS.headhunterVolumeHandle.GetInodeRec(inodeNumber)
+		if (nil == err) && ok {
+			bytesConsumedByCorruptionDetected, err = cstruct.Unpack(inodeRec, &corruptionDetected, cstruct.LittleEndian)
+			if (nil == err) && !corruptionDetected {
+				bytesConsumedByVersion, err = cstruct.Unpack(inodeRec[bytesConsumedByCorruptionDetected:], &version, cstruct.LittleEndian)
+				if (nil == err) && (V1 == version) {
+					inodeView = onDiskInodeQuotaViewStruct{}
+					err = json.Unmarshal(inodeRec[bytesConsumedByCorruptionDetected+bytesConsumedByVersion:], &inodeView)
+					if nil == err {
+						if FileType == inodeView.InodeType {
+							inodeBytes = logSegmentMapBytes(inodeView.LogSegmentMap)
+						} else {
+							inodeBytes = 0
+						}
+						vS.quotaAdjust(quotaKeysFor(inodeView.UserID, inodeView.GroupID, inodeView.ProjectID), int64(inodeBytes), 1)
+						numInodes++
+					}
+				}
+			}
+			if nil != err {
+				logger.WarnfWithError(err, "%s: skipping unparseable inode %d in volume %s", utils.GetFnName(), inodeNumber, vS.volumeName)
+			}
+		}
+
+		inodeNumber, ok, err = vS.headhunterVolumeHandle.NextInodeNumber(inodeNumber)
+	}
+	if nil != err {
+		err = fmt.Errorf("%s: unable to enumerate inodes of volume %s: %v", utils.GetFnName(), vS.volumeName, err)
+		return
+	}
+
+	vS.quota.dirty = true
+
+	logger.Infof("Computed quota usage for %d inodes of volume %s in %v", numInodes, vS.volumeName, time.Since(startTime))
+
+	err = nil
+	return
+}
+
+// quotaRescan recomputes quota usage from scratch (e.g. following
+// a SnapShot rollback) retaining the current quota limits.
+func (vS *volumeStruct) quotaRescan() (err error) {
+	var (
+		quotaEntry *quotaEntryStruct
+	)
+
+	vS.quota.Lock()
+	for _, quotaEntry = range vS.quota.entryMap {
+		quotaEntry.QuotaUsageStruct = QuotaUsageStruct{}
+	}
+	vS.quota.Unlock()
+
+	err = vS.quotaScan()
+
+	return
+}
+
+// quotaPersist records the current quota limits and usage in the volume's QuotaRec (if changed).
+// Only UnserveVolume() should pass cleanlyUnserved as true.
+func (vS *volumeStruct) quotaPersist(cleanlyUnserved bool) (err error) {
+	var (
+		onDiskQuotaV1 *onDiskQuotaV1Struct
+		quotaRec      []byte
+	)
+
+	vS.quota.Lock()
+
+	if !vS.quota.dirty && (cleanlyUnserved == vS.quota.cleanlyUnserved) {
+		vS.quota.Unlock()
+		err = nil
+		return
+	}
+
+	onDiskQuotaV1 = &onDiskQuotaV1Struct{
+		Version:         V1,
+		QuotaList:       vS.fetchQuotaListWhileLocked(),
+		CleanlyUnserved: cleanlyUnserved,
+	}
+
+	vS.quota.dirty = false
+	vS.quota.cleanlyUnserved = cleanlyUnserved
+
+	vS.quota.Unlock()
+
+	quotaRec, err = json.Marshal(onDiskQuotaV1)
+	if nil == err {
+		err = vS.headhunterVolumeHandle.PutQuotaRec(quotaRec)
+	}
+	if nil != err {
+		vS.quota.Lock()
+		vS.quota.dirty = true
+		vS.quota.Unlock()
+		err = fmt.Errorf("%s: unable to persist QuotaRec for volume %s: %v", utils.GetFnName(), vS.volumeName, err)
+	}
+
+	return
+}
+
+func (vS *volumeStruct) fetchQuotaListWhileLocked() (quotaList []QuotaStruct) {
+	var (
+		entry    *quotaEntryStruct
+		quotaKey quotaKeyStruct
+	)
+
+	quotaList = make([]QuotaStruct, 0, len(vS.quota.entryMap))
+
+	for quotaKey, entry = range vS.quota.entryMap {
+		quotaList = append(quotaList, QuotaStruct{
+			Type:              quotaKey.quotaType,
+			ID:                quotaKey.quotaID,
+			QuotaLimitsStruct: entry.QuotaLimitsStruct,
+			QuotaUsageStruct:  entry.QuotaUsageStruct,
+		})
+	}
+
+	sort.Slice(quotaList, func(i int, j int) bool {
+		if quotaList[i].Type != quotaList[j].Type {
+			return quotaList[i].Type > quotaList[j].Type // i.e. "user", "project", "group"
+		}
+		return quotaList[i].ID < quotaList[j].ID
+	})
+
+	return
+}
+
+func (vS *volumeStruct) FetchQuota(quotaType QuotaType, quotaID uint64) (quota QuotaStruct, err error) {
+	var (
+		entry *quotaEntryStruct
+		ok    bool
+	)
+
+	err = validateQuotaTypeAndID(quotaType, quotaID)
+	if nil != err {
+		return
+	}
+
+	quota = QuotaStruct{
+		Type: quotaType,
+		ID:   quotaID,
+	}
+
+	vS.quota.Lock()
+	entry, ok = vS.quota.entryMap[quotaKeyStruct{quotaType: quotaType, quotaID: quotaID}]
+	if ok {
+		quota.QuotaLimitsStruct = entry.QuotaLimitsStruct
+		quota.QuotaUsageStruct = entry.QuotaUsageStruct
+	}
+	vS.quota.Unlock()
+
+	err = nil
+	return
+}
+
+func (vS *volumeStruct) FetchQuotaList() (quotaList []QuotaStruct) {
+	vS.quota.Lock()
+	quotaList = vS.fetchQuotaListWhileLocked()
+	vS.quota.Unlock()
+
+	return
+}
+
+func (vS *volumeStruct) SetQuotaLimits(quotaType QuotaType, quotaID uint64, quotaLimits QuotaLimitsStruct) (err error) {
+	var (
+		entry    *quotaEntryStruct
+		ok       bool
+		quotaKey quotaKeyStruct
+	)
+
+	err = vS.enforceRWMode(false)
+	if nil != err {
+		return
+	}
+
+	err = validateQuotaTypeAndID(quotaType, quotaID)
+	if nil != err {
+		return
+	}
+
+	quotaKey = quotaKeyStruct{quotaType: quotaType, quotaID: quotaID}
+
+	vS.quota.Lock()
+
+	entry, ok = vS.quota.entryMap[quotaKey]
+	if !ok {
+		entry = &quotaEntryStruct{}
+		vS.quota.entryMap[quotaKey] = entry
+	}
+
+	entry.QuotaLimitsStruct = quotaLimits
+
+	if (0 == entry.Bytes) && (0 == entry.Inodes) && (0 == entry.MaxBytes) && (0 == entry.MaxInodes) {
+		delete(vS.quota.entryMap, quotaKey)
+	}
+
+	vS.quota.dirty = true
+
+	vS.quota.Unlock()
+
+	err = vS.quotaPersist(false)
+
+	return
+}
+
+func (vS *volumeStruct) SetProjectID(inodeNumber InodeNumber, projectID uint64) (err error) {
+	err = vS.enforceRWMode(false)
+	if nil != err {
+		return
+	}
+
+	snapShotIDType, _, _ := vS.headhunterVolumeHandle.SnapShotU64Decode(uint64(inodeNumber))
+	if headhunter.SnapShotIDTypeLive != snapShotIDType {
+		err = fmt.Errorf("SetProjectID() on non-LiveView inodeNumber not allowed")
+		return
+	}
+
+	inode, ok, err := vS.fetchInode(inodeNumber)
+	if err != nil {
+		// the inode is locked so this should never happen (unless the inode
+		// was evicted from the cache and it was corrupt when read from disk)
+		logger.ErrorfWithError(err, "%s: fetch of target inode failed", utils.GetFnName())
+		return err
+	}
+	if !ok {
+		// this should never happen (see above)
+		err = fmt.Errorf("%s: failing request for inode %d volume '%s' because it is unallocated",
+			utils.GetFnName(), inodeNumber, vS.volumeName)
+		logger.ErrorWithError(err)
+		err = blunder.AddError(err, blunder.NotFoundError)
+		return err
+	}
+
+	if projectID == inode.ProjectID {
+		return
+	}
+
+	// Charge the new project (if any) before releasing the old one's charges
+
+	err = vS.quotaReserve(projectQuotaKeys(projectID), inode.quotaBytes(), 1)
+	if nil != err {
+		return
+	}
+
+	vS.quotaAdjust(projectQuotaKeys(inode.ProjectID), -int64(inode.quotaBytes()), -1)
+
+	inode.dirty = true
+	inode.ProjectID = projectID
+
+	updateTime := time.Now()
+	inode.AttrChangeTime = updateTime
+
+	err = vS.flushInode(inode)
+	if err != nil {
+		logger.ErrorWithError(err)
+		return err
+	}
+
+	return
+}
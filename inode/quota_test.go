@@ -0,0 +1,208 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package inode
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+)
+
+func TestQuotaLoadFollowingCrash(t *testing.T) {
+	testSetup(t, false)
+
+	assert := assert.New(t)
+
+	testVolumeHandle, err := FetchVolumeHandle("TestVolume")
+	if nil != err {
+		t.Fatalf("FetchVolumeHandle(\"TestVolume\") should have worked - got error: %v", err)
+	}
+	vS := testVolumeHandle.(*volumeStruct)
+
+	testUserID := InodeUserID(4321)
+	testQuotaKeys := userQuotaKeys(testUserID)
+
+	// fetchPersistedCleanlyUnserved returns the CleanlyUnserved marker of the QuotaRec
+	fetchPersistedCleanlyUnserved := func() (cleanlyUnserved bool) {
+		quotaRec, ok, err := vS.headhunterVolumeHandle.GetQuotaRec()
+		if !assert.Nil(err) || !assert.True(ok) {
+			return
+		}
+		onDiskQuotaV1 := &onDiskQuotaV1Struct{}
+		err = json.Unmarshal(quotaRec, onDiskQuotaV1)
+		assert.Nil(err)
+		cleanlyUnserved = onDiskQuotaV1.CleanlyUnserved
+		return
+	}
+
+	// A served volume's QuotaRec is never marked CleanlyUnserved
+
+	assert.False(fetchPersistedCleanlyUnserved())
+
+	err = vS.SetQuotaLimits(QuotaTypeUser, uint64(testUserID), QuotaLimitsStruct{MaxBytes: 1 << 20})
+	assert.Nil(err)
+
+	fileInodeNumber, err := vS.CreateFile(InodeMode(0000), testUserID, InodeGroupID(0))
+	if nil != err {
+		t.Fatalf("CreateFile() failed: %v", err)
+	}
+	err = vS.Write(fileInodeNumber, 0, []byte("QuotaUsage"), nil)
+	assert.Nil(err)
+	err = vS.Flush(fileInodeNumber, false)
+	assert.Nil(err)
+
+	quota, err := vS.FetchQuota(QuotaTypeUser, uint64(testUserID))
+	assert.Nil(err)
+	assert.Equal(uint64(10), quota.Bytes)
+	assert.Equal(uint64(1), quota.Inodes)
+
+	// Simulate a QuotaRec persisted by a checkpoint that is inconsistent with the InodeRecs
+	// (as would follow a crash) and verify that the subsequent quotaLoad() recomputes usage
+
+	vS.quotaAdjust(testQuotaKeys, 1000, 5)
+
+	err = vS.quotaPersist(false)
+	assert.Nil(err)
+
+	err = vS.quotaLoad()
+	assert.Nil(err)
+
+	quota, err = vS.FetchQuota(QuotaTypeUser, uint64(testUserID))
+	assert.Nil(err)
+	assert.Equal(uint64(1<<20), quota.MaxBytes)
+	assert.Equal(uint64(10), quota.Bytes)
+	assert.Equal(uint64(1), quota.Inodes)
+
+	// A QuotaRec persisted as the volume is cleanly unserved is trusted by quotaLoad()
+
+	vS.quotaAdjust(testQuotaKeys, 1000, 5)
+
+	err = vS.quotaPersist(true)
+	assert.Nil(err)
+	assert.True(fetchPersistedCleanlyUnserved())
+
+	err = vS.quotaLoad()
+	assert.Nil(err)
+	assert.False(fetchPersistedCleanlyUnserved())
+
+	quota, err = vS.FetchQuota(QuotaTypeUser, uint64(testUserID))
+	assert.Nil(err)
+	assert.Equal(uint64(1010), quota.Bytes)
+	assert.Equal(uint64(6), quota.Inodes)
+
+	// Restore consistent usage before cleaning up
+
+	err = vS.quotaRescan()
+	assert.Nil(err)
+
+	err = vS.Destroy(fileInodeNumber)
+	assert.Nil(err)
+
+	err = vS.SetQuotaLimits(QuotaTypeUser, uint64(testUserID), QuotaLimitsStruct{})
+	assert.Nil(err)
+
+	quota, err = vS.FetchQuota(QuotaTypeUser, uint64(testUserID))
+	assert.Nil(err)
+	assert.Equal(QuotaUsageStruct{}, quota.QuotaUsageStruct)
+
+	testTeardown(t)
+}
+
+func TestQuotaConcurrentReservations(t *testing.T) {
+	testSetup(t, false)
+
+	assert := assert.New(t)
+
+	testVolumeHandle, err := FetchVolumeHandle("TestVolume")
+	if nil != err {
+		t.Fatalf("FetchVolumeHandle(\"TestVolume\") should have worked - got error: %v", err)
+	}
+	vS := testVolumeHandle.(*volumeStruct)
+
+	testUserID := InodeUserID(4321)
+
+	err = vS.SetQuotaLimits(QuotaTypeUser, uint64(testUserID), QuotaLimitsStruct{MaxBytes: 10, MaxInodes: 4})
+	assert.Nil(err)
+
+	// Race more CreateFile()'s than MaxInodes allows and verify only MaxInodes succeed
+
+	var (
+		fileInodeNumbers      []InodeNumber
+		fileInodeNumbersMutex sync.Mutex
+		wg                    sync.WaitGroup
+	)
+
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fileInodeNumber, err := vS.CreateFile(InodeMode(0000), testUserID, InodeGroupID(0))
+			if nil == err {
+				fileInodeNumbersMutex.Lock()
+				fileInodeNumbers = append(fileInodeNumbers, fileInodeNumber)
+				fileInodeNumbersMutex.Unlock()
+			} else {
+				assert.True(blunder.Is(err, blunder.QuotaExceededError))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(4, len(fileInodeNumbers))
+
+	quota, err := vS.FetchQuota(QuotaTypeUser, uint64(testUserID))
+	assert.Nil(err)
+	assert.Equal(uint64(4), quota.Inodes)
+
+	// Race Write()'s (each to a distinct file) that only fit MaxBytes one at a time
+
+	var (
+		successfulWrites      int
+		successfulWritesMutex sync.Mutex
+	)
+
+	for _, fileInodeNumber := range fileInodeNumbers {
+		wg.Add(1)
+		go func(fileInodeNumber InodeNumber) {
+			defer wg.Done()
+			err := vS.Write(fileInodeNumber, 0, []byte("QuotaUse"), nil)
+			if nil == err {
+				successfulWritesMutex.Lock()
+				successfulWrites++
+				successfulWritesMutex.Unlock()
+			} else {
+				assert.True(blunder.Is(err, blunder.QuotaExceededError))
+			}
+		}(fileInodeNumber)
+	}
+
+	wg.Wait()
+
+	assert.Equal(1, successfulWrites)
+
+	quota, err = vS.FetchQuota(QuotaTypeUser, uint64(testUserID))
+	assert.Nil(err)
+	assert.Equal(uint64(8), quota.Bytes)
+
+	// Clean up
+
+	for _, fileInodeNumber := range fileInodeNumbers {
+		err = vS.Destroy(fileInodeNumber)
+		assert.Nil(err)
+	}
+
+	err = vS.SetQuotaLimits(QuotaTypeUser, uint64(testUserID), QuotaLimitsStruct{})
+	assert.Nil(err)
+
+	quota, err = vS.FetchQuota(QuotaTypeUser, uint64(testUserID))
+	assert.Nil(err)
+	assert.Equal(QuotaUsageStruct{}, quota.QuotaUsageStruct)
+
+	testTeardown(t)
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package inode
+
+import (
+	"sync"
+
+	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/stats"
+)
+
+// fetchReadCacheElement looks up readCacheKey in the volumeGroup's Read Cache. Should
+// the Read Cache Line currently be fetched by read-ahead, the caller waits for it to
+// arrive rather than issuing a duplicate GET. The first read of a prefetched Read Cache
+// Line is not reported to the readCachePolicy as a hit since, for scan resistant policies,
+// that would promote every Read Cache Line of a sequential scan as if it were read twice.
+//
+func (volumeGroup *volumeGroupStruct) fetchReadCacheElement(readCacheKey readCacheKeyStruct) (readCacheElement *readCacheElementStruct, readCacheHit bool) {
+	var (
+		readAheadInFlight   bool
+		readAheadInFlightWG *sync.WaitGroup
+	)
+
+	volumeGroup.Lock()
+
+	readCacheElement, readCacheHit = volumeGroup.readCache[readCacheKey]
+
+	if !readCacheHit {
+		readAheadInFlightWG, readAheadInFlight = volumeGroup.readAheadInFlight[readCacheKey]
+		if readAheadInFlight {
+			volumeGroup.Unlock()
+			readAheadInFlightWG.Wait()
+			volumeGroup.Lock()
+
+			readCacheElement, readCacheHit = volumeGroup.readCache[readCacheKey]
+		}
+	}
+
+	if readCacheHit {
+		volumeGroup.readCacheStats.Hits.Increment()
+
+		if readCacheElement.prefetched {
+			readCacheElement.prefetched = false
+			stats.IncrementOperations(&stats.FileReadaheadHitOps)
+		} else {
+			volumeGroup.touchReadCacheElementWhileLocked(readCacheElement)
+		}
+	} else {
+		volumeGroup.readCacheStats.Misses.Increment()
+	}
+
+	volumeGroup.Unlock()
+
+	return
+}
+
+// readAheadIfSequential tracks whether Read()'s of fileInode are sequential. If so, the
+// read-ahead window is grown (doubling up to readAheadWindowMax) and the Read Cache Lines
+// covering that window beyond the just completed Read() are fetched in the background.
+// Any other Read() pattern collapses the read-ahead window.
+//
+func (vS *volumeStruct) readAheadIfSequential(snapShotID uint64, fileInode *inMemoryInodeStruct, offset uint64, length uint64) {
+	var (
+		cacheLineTag        uint64
+		cacheLineTagFirst   uint64
+		cacheLineTagLast    uint64
+		err                 error
+		inFlightHit         bool
+		readAheadInFlight   bool
+		readAheadInFlightWG *sync.WaitGroup
+		readAheadLength     uint64
+		readAheadOffset     uint64
+		readCacheHit        bool
+		readCacheKey        readCacheKeyStruct
+		readCacheLineSize   uint64
+		readPlan            []ReadPlanStep
+		step                ReadPlanStep
+		volumeGroup         *volumeGroupStruct
+	)
+
+	volumeGroup = vS.volumeGroup
+
+	if (0 == volumeGroup.readAheadWindowMax) || (0 == length) {
+		return
+	}
+
+	readCacheLineSize = volumeGroup.readCacheLineSize
+
+	fileInode.Lock()
+
+	if offset == fileInode.readAheadNextOffset {
+		if 0 == fileInode.readAheadWindow {
+			fileInode.readAheadWindow = readCacheLineSize
+		} else {
+			fileInode.readAheadWindow *= 2
+		}
+		if fileInode.readAheadWindow > volumeGroup.readAheadWindowMax {
+			fileInode.readAheadWindow = volumeGroup.readAheadWindowMax
+		}
+	} else {
+		fileInode.readAheadWindow = 0
+	}
+
+	fileInode.readAheadNextOffset = offset + length
+
+	readAheadOffset = fileInode.readAheadNextOffset
+	readAheadLength = fileInode.readAheadWindow
+
+	fileInode.Unlock()
+
+	if 0 == readAheadLength {
+		return
+	}
+
+	readPlan, _, err = vS.getReadPlanHelper(snapShotID, fileInode, &readAheadOffset, &readAheadLength)
+	if nil != err {
+		logger.WarnfWithError(err, "Unable to compute read-ahead plan for inode %v volume '%s'", fileInode.InodeNumber, vS.volumeName)
+		return
+	}
+
+	readCacheKey.volumeName = vS.volumeName
+
+	for _, step = range readPlan {
+		if 0 == step.LogSegmentNumber {
+			// Zero-filled... so nothing to read-ahead
+			continue
+		}
+
+		fileInode.Lock()
+		_, inFlightHit = fileInode.inFlightLogSegmentMap[step.LogSegmentNumber]
+		fileInode.Unlock()
+
+		if inFlightHit {
+			// Will be read from the inFlightLogSegment... so nothing to read-ahead
+			continue
+		}
+
+		readCacheKey.logSegmentNumber = step.LogSegmentNumber
+
+		cacheLineTagFirst = step.Offset / readCacheLineSize
+		cacheLineTagLast = (step.Offset + step.Length - 1) / readCacheLineSize
+
+		for cacheLineTag = cacheLineTagFirst; cacheLineTag <= cacheLineTagLast; cacheLineTag++ {
+			readCacheKey.cacheLineTag = cacheLineTag
+
+			volumeGroup.Lock()
+
+			_, readCacheHit = volumeGroup.readCache[readCacheKey]
+			_, readAheadInFlight = volumeGroup.readAheadInFlight[readCacheKey]
+
+			if readCacheHit || readAheadInFlight {
+				volumeGroup.Unlock()
+				continue
+			}
+
+			if uint64(len(volumeGroup.readAheadInFlight)) >= volumeGroup.readAheadInFlightMax {
+				// At our cap... so abandon the balance of the read-ahead window
+				volumeGroup.Unlock()
+				return
+			}
+
+			readAheadInFlightWG = &sync.WaitGroup{}
+			readAheadInFlightWG.Add(1)
+			volumeGroup.readAheadInFlight[readCacheKey] = readAheadInFlightWG
+
+			volumeGroup.Unlock()
+
+			stats.IncrementOperations(&stats.FileReadaheadPrefetchOps)
+
+			go vS.readAheadCacheLine(readCacheKey, step.ContainerName, step.ObjectName, readAheadInFlightWG)
+		}
+	}
+}
+
+// readAheadCacheLine fetches the Read Cache Line for readCacheKey and inserts it (at
+// the MRU end) into the volumeGroup's Read Cache marked as prefetched.
+//
+func (vS *volumeStruct) readAheadCacheLine(readCacheKey readCacheKeyStruct, containerName string, objectName string, readAheadInFlightWG *sync.WaitGroup) {
+	var (
+		cacheLine         []byte
+		err               error
+		readCacheElement  *readCacheElementStruct
+		readCacheLineSize uint64
+		volumeGroup       *volumeGroupStruct
+	)
+
+	volumeGroup = vS.volumeGroup
+	readCacheLineSize = volumeGroup.readCacheLineSize
+
+	cacheLine, err = vS.readLogSegment(readCacheKey.logSegmentNumber, containerName, objectName, readCacheKey.cacheLineTag*readCacheLineSize, readCacheLineSize)
+
+	volumeGroup.Lock()
+
+	delete(volumeGroup.readAheadInFlight, readCacheKey)
+
+	if nil == err {
+		readCacheElement = &readCacheElementStruct{
+			readCacheKey:      readCacheKey,
+			policyList:        nil,
+			policyListElement: nil,
+			policyFrequency:   0,
+			cacheLine:         cacheLine,
+			prefetched:        true,
+		}
+		volumeGroup.insertReadCacheElementWhileLocked(readCacheElement) // A no-op if a reader beat us to it
+	}
+
+	volumeGroup.Unlock()
+
+	readAheadInFlightWG.Done()
+
+	if nil != err {
+		logger.WarnfWithError(err, "Read-ahead of LogSegment 0x%016X cache line 0x%016X of volume '%s' failed", readCacheKey.logSegmentNumber, readCacheKey.cacheLineTag, vS.volumeName)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package inode
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func testReadAheadDrain(t *testing.T, volumeGroup *volumeGroupStruct) {
+	var (
+		readAheadInFlightLen int
+	)
+
+	for {
+		volumeGroup.Lock()
+		readAheadInFlightLen = len(volumeGroup.readAheadInFlight)
+		volumeGroup.Unlock()
+
+		if 0 == readAheadInFlightLen {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func testReadAheadCached(volumeGroup *volumeGroupStruct, fileInode *inMemoryInodeStruct, offset uint64) (cached bool, prefetched bool) {
+	var (
+		readCacheElement *readCacheElementStruct
+		readPlan         []ReadPlanStep
+		readPlanLength   uint64
+	)
+
+	readPlanLength = 1
+
+	readPlan, _, _ = fileInode.volume.getReadPlanHelper(0, fileInode, &offset, &readPlanLength)
+	if 1 != len(readPlan) {
+		return
+	}
+
+	volumeGroup.Lock()
+	readCacheElement, cached = volumeGroup.readCache[readCacheKeyStruct{
+		volumeName:       fileInode.volume.volumeName,
+		logSegmentNumber: readPlan[0].LogSegmentNumber,
+		cacheLineTag:     readPlan[0].Offset / volumeGroup.readCacheLineSize,
+	}]
+	if cached {
+		prefetched = readCacheElement.prefetched
+	}
+	volumeGroup.Unlock()
+
+	return
+}
+
+func TestReadAhead(t *testing.T) {
+	var (
+		cached            bool
+		err               error
+		fileInode         *inMemoryInodeStruct
+		fileInodeNumber   InodeNumber
+		lineIndex         uint64
+		prefetched        bool
+		readBuf           []byte
+		readCacheLineSize uint64
+		testVolumeHandle  VolumeHandle
+		volumeGroup       *volumeGroupStruct
+		writeBuf          []byte
+	)
+
+	testSetup(t, false)
+
+	testVolumeHandle, err = FetchVolumeHandle("TestVolume")
+	if nil != err {
+		t.Fatalf("FetchVolumeHandle(\"TestVolume\") failed: %v", err)
+	}
+
+	volumeGroup = testVolumeHandle.(*volumeStruct).volumeGroup
+	readCacheLineSize = volumeGroup.readCacheLineSize
+
+	fileInodeNumber, err = testVolumeHandle.CreateFile(PosixModePerm, 0, 0)
+	if nil != err {
+		t.Fatalf("CreateFile() failed: %v", err)
+	}
+
+	// Write 4 Read Cache Lines worth of distinguishable data
+
+	writeBuf = make([]byte, 4*readCacheLineSize)
+	for lineIndex = 0; lineIndex < 4; lineIndex++ {
+		writeBuf[lineIndex*readCacheLineSize] = byte(lineIndex + 1)
+	}
+
+	err = testVolumeHandle.Write(fileInodeNumber, 0, writeBuf, nil)
+	if nil != err {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	err = testVolumeHandle.Flush(fileInodeNumber, false)
+	if nil != err {
+		t.Fatalf("Flush() failed: %v", err)
+	}
+
+	fileInode, err = testVolumeHandle.(*volumeStruct).fetchInodeType(fileInodeNumber, FileType)
+	if nil != err {
+		t.Fatalf("fetchInodeType() failed: %v", err)
+	}
+
+	// Enable read-ahead and start with an empty Read Cache
+
+	volumeGroup.Lock()
+	volumeGroup.readAheadWindowMax = 2 * readCacheLineSize
+	volumeGroup.readCache = make(map[readCacheKeyStruct]*readCacheElementStruct)
+	volumeGroup.readCachePolicy, _ = newReadCachePolicy(volumeGroup.readCachePolicyName)
+	volumeGroup.Unlock()
+
+	// A first sequential Read() should read-ahead the following Read Cache Line
+
+	readBuf, err = testVolumeHandle.Read(fileInodeNumber, 0, readCacheLineSize, nil)
+	if (nil != err) || !bytes.Equal(writeBuf[:readCacheLineSize], readBuf) {
+		t.Fatalf("Read() of first Read Cache Line failed: %v", err)
+	}
+
+	testReadAheadDrain(t, volumeGroup)
+
+	cached, prefetched = testReadAheadCached(volumeGroup, fileInode, readCacheLineSize)
+	if !cached || !prefetched {
+		t.Fatalf("second Read Cache Line should have been prefetched (cached: %v prefetched: %v)", cached, prefetched)
+	}
+	cached, _ = testReadAheadCached(volumeGroup, fileInode, 2*readCacheLineSize)
+	if cached {
+		t.Fatalf("third Read Cache Line should not yet have been prefetched")
+	}
+
+	// A second sequential Read() should hit the prefetched Read Cache Line and double the window
+
+	readBuf, err = testVolumeHandle.Read(fileInodeNumber, readCacheLineSize, readCacheLineSize, nil)
+	if (nil != err) || !bytes.Equal(writeBuf[readCacheLineSize:2*readCacheLineSize], readBuf) {
+		t.Fatalf("Read() of second Read Cache Line failed: %v", err)
+	}
+
+	testReadAheadDrain(t, volumeGroup)
+
+	cached, prefetched = testReadAheadCached(volumeGroup, fileInode, readCacheLineSize)
+	if !cached || prefetched {
+		t.Fatalf("second Read Cache Line should no longer be marked prefetched (cached: %v prefetched: %v)", cached, prefetched)
+	}
+	for lineIndex = 2; lineIndex < 4; lineIndex++ {
+		cached, prefetched = testReadAheadCached(volumeGroup, fileInode, lineIndex*readCacheLineSize)
+		if !cached || !prefetched {
+			t.Fatalf("Read Cache Line %d should have been prefetched (cached: %v prefetched: %v)", lineIndex, cached, prefetched)
+		}
+	}
+
+	// A non-sequential Read() should collapse the read-ahead window
+
+	_, err = testVolumeHandle.Read(fileInodeNumber, 0, 1, nil)
+	if nil != err {
+		t.Fatalf("Read() of first byte failed: %v", err)
+	}
+
+	fileInode.Lock()
+	if 0 != fileInode.readAheadWindow {
+		t.Fatalf("non-sequential Read() should have collapsed readAheadWindow (was %d)", fileInode.readAheadWindow)
+	}
+	fileInode.Unlock()
+
+	volumeGroup.Lock()
+	volumeGroup.readAheadWindowMax = 0
+	volumeGroup.Unlock()
+
+	testTeardown(t)
+}
@@ -0,0 +1,363 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package inode
+
+import (
+	"container/list"
+	"fmt"
+
+	"github.com/NVIDIA/proxyfs/bucketstats"
+)
+
+// Supported values of [VolumeGroup:<VolumeGroupName>]ReadCachePolicy
+//
+const (
+	ReadCachePolicyLRU    = "LRU"
+	ReadCachePolicyARC    = "ARC"
+	ReadCachePolicy2Q     = "2Q"
+	ReadCachePolicyS3FIFO = "S3FIFO"
+)
+
+// readCachePolicyIf is implemented by each Read Cache eviction policy. The volumeGroup's
+// readCache map remains the authority on which Read Cache Lines are resident; the policy
+// merely tracks the ordering (and any "ghost" history of recently evicted readCacheKeys)
+// needed to select a victim. All methods are called while holding the volumeGroup lock.
+//
+type readCachePolicyIf interface {
+	hit(readCacheElement *readCacheElementStruct)                                               // readCacheElement was just read
+	insert(readCacheElement *readCacheElementStruct, readCacheLineCount uint64) (ghostHit bool) // readCacheElement was just made resident
+	evict(incoming readCacheKeyStruct, readCacheLineCount uint64) (victim *readCacheElementStruct)
+}
+
+type readCacheStatsStruct struct {
+	Hits      bucketstats.Total // Read Cache Line found resident
+	Misses    bucketstats.Total // Read Cache Line fetched from Swift
+	Evictions bucketstats.Total // Read Cache Lines evicted to make room
+	GhostHits bucketstats.Total // Misses of Read Cache Lines remembered by the policy's history
+}
+
+func newReadCachePolicy(readCachePolicyName string) (readCachePolicy readCachePolicyIf, err error) {
+	switch readCachePolicyName {
+	case ReadCachePolicyLRU:
+		readCachePolicy = &readCachePolicyLRUStruct{lru: list.New()}
+	case ReadCachePolicyARC:
+		readCachePolicy = &readCachePolicyARCStruct{t1: list.New(), t2: list.New(), b1: newReadCacheGhostList(), b2: newReadCacheGhostList()}
+	case ReadCachePolicy2Q:
+		readCachePolicy = &readCachePolicy2QStruct{a1in: list.New(), am: list.New(), a1out: newReadCacheGhostList()}
+	case ReadCachePolicyS3FIFO:
+		readCachePolicy = &readCachePolicyS3FIFOStruct{small: list.New(), main: list.New(), ghost: newReadCacheGhostList()}
+	default:
+		err = fmt.Errorf("ReadCachePolicy (%s) must be one of %s, %s, %s, or %s", readCachePolicyName, ReadCachePolicyLRU, ReadCachePolicyARC, ReadCachePolicy2Q, ReadCachePolicyS3FIFO)
+		return
+	}
+
+	err = nil
+	return
+}
+
+// readCacheElementPushFront places readCacheElement at the front of policyList.
+//
+func readCacheElementPushFront(policyList *list.List, readCacheElement *readCacheElementStruct) {
+	readCacheElement.policyList = policyList
+	readCacheElement.policyListElement = policyList.PushFront(readCacheElement)
+}
+
+// readCacheElementRemove removes readCacheElement from whichever policyList it is on.
+//
+func readCacheElementRemove(readCacheElement *readCacheElementStruct) {
+	readCacheElement.policyList.Remove(readCacheElement.policyListElement)
+	readCacheElement.policyList = nil
+	readCacheElement.policyListElement = nil
+}
+
+// readCacheElementPopBack removes and returns the element at the back of policyList.
+//
+func readCacheElementPopBack(policyList *list.List) (readCacheElement *readCacheElementStruct) {
+	readCacheElement = policyList.Back().Value.(*readCacheElementStruct)
+	readCacheElementRemove(readCacheElement)
+	return
+}
+
+// readCacheGhostListStruct remembers (in MRU order) the readCacheKeys of recently evicted
+// Read Cache Lines without retaining their contents.
+//
+type readCacheGhostListStruct struct {
+	keyList *list.List                           // Values are readCacheKeyStruct's with MRU at Front()
+	keyMap  map[readCacheKeyStruct]*list.Element // Index into keyList
+}
+
+func newReadCacheGhostList() (ghostList *readCacheGhostListStruct) {
+	ghostList = &readCacheGhostListStruct{
+		keyList: list.New(),
+		keyMap:  make(map[readCacheKeyStruct]*list.Element),
+	}
+	return
+}
+
+func (ghostList *readCacheGhostListStruct) len() uint64 {
+	return uint64(ghostList.keyList.Len())
+}
+
+func (ghostList *readCacheGhostListStruct) contains(readCacheKey readCacheKeyStruct) (ok bool) {
+	_, ok = ghostList.keyMap[readCacheKey]
+	return
+}
+
+func (ghostList *readCacheGhostListStruct) pushFront(readCacheKey readCacheKeyStruct) {
+	ghostList.keyMap[readCacheKey] = ghostList.keyList.PushFront(readCacheKey)
+}
+
+// remove forgets readCacheKey returning whether or not it had been remembered.
+//
+func (ghostList *readCacheGhostListStruct) remove(readCacheKey readCacheKeyStruct) (ok bool) {
+	var (
+		listElement *list.Element
+	)
+
+	listElement, ok = ghostList.keyMap[readCacheKey]
+	if ok {
+		_ = ghostList.keyList.Remove(listElement)
+		delete(ghostList.keyMap, readCacheKey)
+	}
+
+	return
+}
+
+// trim forgets the least recently remembered readCacheKeys until at most limit remain.
+//
+func (ghostList *readCacheGhostListStruct) trim(limit uint64) {
+	for ghostList.len() > limit {
+		delete(ghostList.keyMap, ghostList.keyList.Remove(ghostList.keyList.Back()).(readCacheKeyStruct))
+	}
+}
+
+// readCachePolicyLRUStruct implements the original, simple LRU policy. Note that it is
+// not scan resistant.
+//
+type readCachePolicyLRUStruct struct {
+	lru *list.List // MRU at Front()
+}
+
+func (policy *readCachePolicyLRUStruct) hit(readCacheElement *readCacheElementStruct) {
+	policy.lru.MoveToFront(readCacheElement.policyListElement)
+}
+
+func (policy *readCachePolicyLRUStruct) insert(readCacheElement *readCacheElementStruct, readCacheLineCount uint64) (ghostHit bool) {
+	readCacheElementPushFront(policy.lru, readCacheElement)
+	ghostHit = false
+	return
+}
+
+func (policy *readCachePolicyLRUStruct) evict(incoming readCacheKeyStruct, readCacheLineCount uint64) (victim *readCacheElementStruct) {
+	victim = readCacheElementPopBack(policy.lru)
+	return
+}
+
+// readCachePolicyARCStruct implements Megiddo & Modha's Adaptive Replacement Cache. Read
+// Cache Lines read only once live in t1 while those read again are promoted to t2. The
+// ghost lists b1 & b2 remember what was recently evicted from t1 & t2 (respectively) and
+// a miss found in either adapts p (the target size of t1) in its favor. A scan thus only
+// ever displaces t1.
+//
+type readCachePolicyARCStruct struct {
+	p  uint64                    // Target # of Read Cache Lines in t1
+	t1 *list.List                // Resident, read once (MRU at Front())
+	t2 *list.List                // Resident, read more than once (MRU at Front())
+	b1 *readCacheGhostListStruct // Evicted from t1
+	b2 *readCacheGhostListStruct // Evicted from t2
+}
+
+func (policy *readCachePolicyARCStruct) hit(readCacheElement *readCacheElementStruct) {
+	if policy.t2 == readCacheElement.policyList {
+		policy.t2.MoveToFront(readCacheElement.policyListElement)
+	} else {
+		readCacheElementRemove(readCacheElement)
+		readCacheElementPushFront(policy.t2, readCacheElement)
+	}
+}
+
+func (policy *readCachePolicyARCStruct) insert(readCacheElement *readCacheElementStruct, readCacheLineCount uint64) (ghostHit bool) {
+	var (
+		delta uint64
+	)
+
+	if policy.b1.remove(readCacheElement.readCacheKey) {
+		delta = 1
+		if policy.b2.len() > (policy.b1.len() + 1) {
+			delta = policy.b2.len() / (policy.b1.len() + 1)
+		}
+		policy.p += delta
+		if policy.p > readCacheLineCount {
+			policy.p = readCacheLineCount
+		}
+		readCacheElementPushFront(policy.t2, readCacheElement)
+		ghostHit = true
+	} else if policy.b2.remove(readCacheElement.readCacheKey) {
+		delta = 1
+		if policy.b1.len() > (policy.b2.len() + 1) {
+			delta = policy.b1.len() / (policy.b2.len() + 1)
+		}
+		if policy.p > delta {
+			policy.p -= delta
+		} else {
+			policy.p = 0
+		}
+		readCacheElementPushFront(policy.t2, readCacheElement)
+		ghostHit = true
+	} else {
+		readCacheElementPushFront(policy.t1, readCacheElement)
+		ghostHit = false
+	}
+
+	// Bound the history to readCacheLineCount per ghost list (and 2 * readCacheLineCount overall)
+
+	if (uint64(policy.t1.Len()) + policy.b1.len()) > readCacheLineCount {
+		policy.b1.trim(readCacheLineCount - uint64(policy.t1.Len()))
+	}
+	policy.b2.trim(readCacheLineCount)
+
+	return
+}
+
+func (policy *readCachePolicyARCStruct) evict(incoming readCacheKeyStruct, readCacheLineCount uint64) (victim *readCacheElementStruct) {
+	var (
+		t1Len uint64
+	)
+
+	t1Len = uint64(policy.t1.Len())
+
+	if (0 < t1Len) && ((t1Len > policy.p) || ((t1Len == policy.p) && policy.b2.contains(incoming)) || (0 == policy.t2.Len())) {
+		victim = readCacheElementPopBack(policy.t1)
+		policy.b1.pushFront(victim.readCacheKey)
+	} else {
+		victim = readCacheElementPopBack(policy.t2)
+		policy.b2.pushFront(victim.readCacheKey)
+	}
+
+	return
+}
+
+// readCachePolicy2QStruct implements Johnson & Shasha's (full) 2Q policy. Newly read
+// Read Cache Lines enter the a1in FIFO. Those evicted from a1in are remembered in a1out
+// and only if read again while remembered are they admitted to the am LRU. A scan thus
+// only ever displaces a1in.
+//
+type readCachePolicy2QStruct struct {
+	a1in  *list.List                // Resident, FIFO of newly read (newest at Front())
+	am    *list.List                // Resident, LRU of frequently read (MRU at Front())
+	a1out *readCacheGhostListStruct // Evicted from a1in
+}
+
+func readCachePolicy2QKin(readCacheLineCount uint64) (kin uint64) {
+	kin = readCacheLineCount / 4
+	if 0 == kin {
+		kin = 1
+	}
+	return
+}
+
+func readCachePolicy2QKout(readCacheLineCount uint64) (kout uint64) {
+	kout = readCacheLineCount / 2
+	if 0 == kout {
+		kout = 1
+	}
+	return
+}
+
+func (policy *readCachePolicy2QStruct) hit(readCacheElement *readCacheElementStruct) {
+	if policy.am == readCacheElement.policyList {
+		policy.am.MoveToFront(readCacheElement.policyListElement)
+	}
+	// Hits while in a1in are deliberately ignored (they are likely correlated references)
+}
+
+func (policy *readCachePolicy2QStruct) insert(readCacheElement *readCacheElementStruct, readCacheLineCount uint64) (ghostHit bool) {
+	if policy.a1out.remove(readCacheElement.readCacheKey) {
+		readCacheElementPushFront(policy.am, readCacheElement)
+		ghostHit = true
+	} else {
+		readCacheElementPushFront(policy.a1in, readCacheElement)
+		ghostHit = false
+	}
+
+	return
+}
+
+func (policy *readCachePolicy2QStruct) evict(incoming readCacheKeyStruct, readCacheLineCount uint64) (victim *readCacheElementStruct) {
+	if (uint64(policy.a1in.Len()) > readCachePolicy2QKin(readCacheLineCount)) || (0 == policy.am.Len()) {
+		victim = readCacheElementPopBack(policy.a1in)
+		policy.a1out.pushFront(victim.readCacheKey)
+		policy.a1out.trim(readCachePolicy2QKout(readCacheLineCount))
+	} else {
+		victim = readCacheElementPopBack(policy.am)
+	}
+
+	return
+}
+
+// readCachePolicyS3FIFOStruct implements Yang et al's S3-FIFO policy. Newly read Read
+// Cache Lines enter the small FIFO (targeting 10% of the Read Cache). Upon reaching its
+// tail, a Read Cache Line read again since insertion moves to the main FIFO while the
+// rest are evicted (and remembered in ghost so that, if read again soon, they enter main
+// directly). The main FIFO gives Read Cache Lines read again since last reaching its tail
+// another trip around. A scan thus only ever displaces small.
+//
+type readCachePolicyS3FIFOStruct struct {
+	small *list.List                // Resident, FIFO of newly read (newest at Front())
+	main  *list.List                // Resident, FIFO of frequently read (newest at Front())
+	ghost *readCacheGhostListStruct // Evicted from small
+}
+
+const readCachePolicyS3FIFOFrequencyMax = 3
+
+func (policy *readCachePolicyS3FIFOStruct) hit(readCacheElement *readCacheElementStruct) {
+	if readCacheElement.policyFrequency < readCachePolicyS3FIFOFrequencyMax {
+		readCacheElement.policyFrequency++
+	}
+}
+
+func (policy *readCachePolicyS3FIFOStruct) insert(readCacheElement *readCacheElementStruct, readCacheLineCount uint64) (ghostHit bool) {
+	readCacheElement.policyFrequency = 0
+
+	if policy.ghost.remove(readCacheElement.readCacheKey) {
+		readCacheElementPushFront(policy.main, readCacheElement)
+		ghostHit = true
+	} else {
+		readCacheElementPushFront(policy.small, readCacheElement)
+		ghostHit = false
+	}
+
+	return
+}
+
+func (policy *readCachePolicyS3FIFOStruct) evict(incoming readCacheKeyStruct, readCacheLineCount uint64) (victim *readCacheElementStruct) {
+	var (
+		smallTarget uint64
+	)
+
+	smallTarget = readCacheLineCount / 10
+	if 0 == smallTarget {
+		smallTarget = 1
+	}
+
+	for {
+		if (uint64(policy.small.Len()) >= smallTarget) || (0 == policy.main.Len()) {
+			victim = readCacheElementPopBack(policy.small)
+			if 0 == victim.policyFrequency {
+				policy.ghost.pushFront(victim.readCacheKey)
+				policy.ghost.trim(readCacheLineCount)
+				return
+			}
+			victim.policyFrequency = 0
+			readCacheElementPushFront(policy.main, victim)
+		} else {
+			victim = readCacheElementPopBack(policy.main)
+			if 0 == victim.policyFrequency {
+				return
+			}
+			victim.policyFrequency--
+			readCacheElementPushFront(policy.main, victim)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package inode
+
+import (
+	"sync"
+	"testing"
+)
+
+func testReadCachePolicyAccess(volumeGroup *volumeGroupStruct, logSegmentNumber uint64) {
+	var (
+		readCacheHit bool
+		readCacheKey readCacheKeyStruct
+	)
+
+	readCacheKey = readCacheKeyStruct{
+		volumeName:       "TestVolume",
+		logSegmentNumber: logSegmentNumber,
+		cacheLineTag:     0,
+	}
+
+	_, readCacheHit = volumeGroup.fetchReadCacheElement(readCacheKey)
+	if !readCacheHit {
+		volumeGroup.Lock()
+		volumeGroup.insertReadCacheElementWhileLocked(&readCacheElementStruct{readCacheKey: readCacheKey})
+		volumeGroup.Unlock()
+	}
+}
+
+func testReadCachePolicyResident(volumeGroup *volumeGroupStruct, logSegmentNumber uint64) (resident bool) {
+	volumeGroup.Lock()
+	_, resident = volumeGroup.readCache[readCacheKeyStruct{
+		volumeName:       "TestVolume",
+		logSegmentNumber: logSegmentNumber,
+		cacheLineTag:     0,
+	}]
+	volumeGroup.Unlock()
+
+	return
+}
+
+func TestReadCachePolicy(t *testing.T) {
+	const (
+		readCacheLineCount = 20
+		hotCount           = 6
+		fillerCount        = 10
+		roundCount         = 3
+		scanCount          = 100
+	)
+
+	var (
+		err                  error
+		hotResidentCount     uint64
+		logSegmentNumber     uint64
+		nextLogSegmentNumber uint64
+		readCachePolicyName  string
+		round                int
+		scanResistant        bool
+		volumeGroup          *volumeGroupStruct
+	)
+
+	_, err = newReadCachePolicy("MRU")
+	if nil == err {
+		t.Fatalf("newReadCachePolicy(\"MRU\") should have failed")
+	}
+
+	for readCachePolicyName, scanResistant = range map[string]bool{
+		ReadCachePolicyLRU:    false,
+		ReadCachePolicyARC:    true,
+		ReadCachePolicy2Q:     true,
+		ReadCachePolicyS3FIFO: true,
+	} {
+		volumeGroup = &volumeGroupStruct{
+			readCacheLineCount:  readCacheLineCount,
+			readCache:           make(map[readCacheKeyStruct]*readCacheElementStruct),
+			readCachePolicyName: readCachePolicyName,
+			readCacheStats:      &readCacheStatsStruct{},
+			readAheadInFlight:   make(map[readCacheKeyStruct]*sync.WaitGroup),
+		}
+
+		volumeGroup.readCachePolicy, err = newReadCachePolicy(readCachePolicyName)
+		if nil != err {
+			t.Fatalf("newReadCachePolicy(\"%s\") failed: %v", readCachePolicyName, err)
+		}
+
+		// Repeatedly read the hot set interspersed with some (never reread) filler
+
+		nextLogSegmentNumber = hotCount + 1
+
+		for round = 0; round < roundCount; round++ {
+			for logSegmentNumber = 1; logSegmentNumber <= hotCount; logSegmentNumber++ {
+				testReadCachePolicyAccess(volumeGroup, logSegmentNumber)
+			}
+
+			for logSegmentNumber = nextLogSegmentNumber; logSegmentNumber < nextLogSegmentNumber+fillerCount; logSegmentNumber++ {
+				testReadCachePolicyAccess(volumeGroup, logSegmentNumber)
+			}
+
+			nextLogSegmentNumber += fillerCount
+		}
+
+		// Now perform a large scan
+
+		for logSegmentNumber = nextLogSegmentNumber; logSegmentNumber < nextLogSegmentNumber+scanCount; logSegmentNumber++ {
+			testReadCachePolicyAccess(volumeGroup, logSegmentNumber)
+		}
+
+		if readCacheLineCount != len(volumeGroup.readCache) {
+			t.Fatalf("%s: readCache should hold %d Read Cache Lines (held %d)", readCachePolicyName, readCacheLineCount, len(volumeGroup.readCache))
+		}
+
+		hotResidentCount = 0
+
+		for logSegmentNumber = 1; logSegmentNumber <= hotCount; logSegmentNumber++ {
+			if testReadCachePolicyResident(volumeGroup, logSegmentNumber) {
+				hotResidentCount++
+			}
+		}
+
+		if scanResistant {
+			if hotCount != hotResidentCount {
+				t.Fatalf("%s: scan should not have evicted the hot set (%d of %d remain)", readCachePolicyName, hotResidentCount, hotCount)
+			}
+		} else {
+			if 0 != hotResidentCount {
+				t.Fatalf("%s: scan should have evicted the hot set (%d of %d remain)", readCachePolicyName, hotResidentCount, hotCount)
+			}
+		}
+
+		if (roundCount*(hotCount+fillerCount) + scanCount) != (volumeGroup.readCacheStats.Hits.TotalGet() + volumeGroup.readCacheStats.Misses.TotalGet()) {
+			t.Fatalf("%s: unexpected Hits (%d) + Misses (%d)", readCachePolicyName, volumeGroup.readCacheStats.Hits.TotalGet(), volumeGroup.readCacheStats.Misses.TotalGet())
+		}
+		if (volumeGroup.readCacheStats.Misses.TotalGet() - readCacheLineCount) != volumeGroup.readCacheStats.Evictions.TotalGet() {
+			t.Fatalf("%s: unexpected Evictions (%d) given Misses (%d)", readCachePolicyName, volumeGroup.readCacheStats.Evictions.TotalGet(), volumeGroup.readCacheStats.Misses.TotalGet())
+		}
+	}
+}
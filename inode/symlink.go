@@ -11,7 +11,12 @@ import (
 )
 
 func (vS *volumeStruct) CreateSymlink(target string, filePerm InodeMode, userID InodeUserID, groupID InodeGroupID) (symlinkInodeNumber InodeNumber, err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
+	if nil != err {
+		return
+	}
+
+	err = vS.quotaReserve(quotaKeysFor(userID, groupID, 0), 0, 1)
 	if nil != err {
 		return
 	}
@@ -19,11 +24,13 @@ func (vS *volumeStruct) CreateSymlink(target string, filePerm InodeMode, userID
 	// Create file mode out of file permissions plus inode type
 	fileMode, err := determineMode(filePerm, SymlinkType)
 	if err != nil {
+		vS.quotaAdjust(quotaKeysFor(userID, groupID, 0), 0, -1)
 		return
 	}
 
 	symlinkInode, err := vS.makeInMemoryInode(SymlinkType, fileMode, userID, groupID)
 	if err != nil {
+		vS.quotaAdjust(quotaKeysFor(userID, groupID, 0), 0, -1)
 		return
 	}
 
@@ -34,9 +41,11 @@ func (vS *volumeStruct) CreateSymlink(target string, filePerm InodeMode, userID
 
 	ok, err := vS.inodeCacheInsert(symlinkInode)
 	if nil != err {
+		vS.quotaAdjust(quotaKeysFor(userID, groupID, 0), 0, -1)
 		return
 	}
 	if !ok {
+		vS.quotaAdjust(quotaKeysFor(userID, groupID, 0), 0, -1)
 		err = fmt.Errorf("inodeCacheInsert(symlinkInode) failed")
 		return
 	}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/NVIDIA/proxyfs/logger"
 	"github.com/NVIDIA/proxyfs/stats"
+	"github.com/NVIDIA/proxyfs/swiftclient"
 	"github.com/NVIDIA/sortedmap"
 )
 
@@ -16,8 +17,18 @@ func (vS *volumeStruct) GetFSID() (fsid uint64) {
 	return
 }
 
+func (vS *volumeStruct) IsEncrypted() (encrypted bool) {
+	encrypted = vS.headhunterVolumeHandle.IsEncrypted()
+	return
+}
+
+func (vS *volumeStruct) ObjectStoreBackend() (backendName string) {
+	backendName = swiftclient.AccountBackend(vS.accountName)
+	return
+}
+
 func (vS *volumeStruct) SnapShotCreate(name string) (id uint64, err error) {
-	err = enforceRWMode(false)
+	err = vS.enforceRWMode(false)
 	if nil != err {
 		return
 	}
@@ -34,6 +45,71 @@ func (vS *volumeStruct) SnapShotCreate(name string) (id uint64, err error) {
 }
 
 func (vS *volumeStruct) SnapShotDelete(id uint64) (err error) {
+	err = vS.enforceRWMode(false)
+	if nil != err {
+		return
+	}
+
+	vS.Lock()
+	err = vS.headhunterVolumeHandle.SnapShotDeleteByInodeLayer(id)
+	if nil == err {
+		// Purge elements in inodeCache to avoid aliasing if/when SnapShotID is reused
+
+		err = vS.inodeCachePurgeSnapShotWhileLocked(id)
+	}
+	vS.Unlock()
+	return
+}
+
+// SnapShotRollback rolls the live view back to SnapShot id. The caller must ensure
+// there are no in-flight modifications (e.g. of file data) to the live view as, once
+// rolled back, all live view inodes are purged from inodeCache. Quota usage is then
+// recomputed (retaining the current quota limits).
+//
+func (vS *volumeStruct) SnapShotRollback(id uint64) (err error) {
+	err = vS.enforceRWMode(false)
+	if nil != err {
+		return
+	}
+
+	vS.Lock()
+	err = vS.headhunterVolumeHandle.SnapShotRollbackByInodeLayer(id)
+	if nil == err {
+		err = vS.inodeCachePurgeSnapShotWhileLocked(0)
+	}
+	vS.Unlock()
+	if nil != err {
+		return
+	}
+
+	err = vS.quotaRescan()
+	if nil != err {
+		return
+	}
+
+	err = vS.compressionScan()
+
+	return
+}
+
+// SnapShotPin pins (or unpins) SnapShot id. A pinned SnapShot is never deleted by
+// the volume's SnapShotPolicy.
+//
+func (vS *volumeStruct) SnapShotPin(id uint64, pinned bool) (err error) {
+	err = vS.enforceRWMode(false)
+	if nil != err {
+		return
+	}
+
+	err = vS.headhunterVolumeHandle.SnapShotPin(id, pinned)
+
+	return
+}
+
+// inodeCachePurgeSnapShotWhileLocked drops every inode of SnapShot id (where 0
+// selects the live view) from inodeCache.
+//
+func (vS *volumeStruct) inodeCachePurgeSnapShotWhileLocked(id uint64) (err error) {
 	var (
 		found                               bool
 		keyAsInodeNumber                    InodeNumber
@@ -46,77 +122,62 @@ func (vS *volumeStruct) SnapShotDelete(id uint64) (err error) {
 		valueAsInodeStructPtr               *inMemoryInodeStruct
 	)
 
-	err = enforceRWMode(false)
+	minInodeNumberToPurgeFromInodeCache = InodeNumber(vS.headhunterVolumeHandle.SnapShotIDAndNonceEncode(id, uint64(0)))
+	maxInodeNumberToPurgeFromInodeCache = InodeNumber(vS.headhunterVolumeHandle.SnapShotIDAndNonceEncode(id+1, uint64(0)))
+
+	indexWherePurgesAreToHappen, _, err = vS.inodeCache.BisectRight(minInodeNumberToPurgeFromInodeCache)
 	if nil != err {
+		err = fmt.Errorf("Volume %v InodeCache BisectRight() failed: %v", vS.volumeName, err)
+		logger.Error(err)
 		return
 	}
 
-	vS.Lock()
-	err = vS.headhunterVolumeHandle.SnapShotDeleteByInodeLayer(id)
-	if nil == err {
-		// Purge elements in inodeCache to avoid aliasing if/when SnapShotID is reused
+	// Note that BisectRight() only reports found == TRUE for an exact match... so we
+	// instead start at the returned index and rely on GetByIndex() reporting the end
 
-		minInodeNumberToPurgeFromInodeCache = InodeNumber(vS.headhunterVolumeHandle.SnapShotIDAndNonceEncode(id, uint64(0)))
-		maxInodeNumberToPurgeFromInodeCache = InodeNumber(vS.headhunterVolumeHandle.SnapShotIDAndNonceEncode(id+1, uint64(0)))
+	found = true
 
-		indexWherePurgesAreToHappen, found, err = vS.inodeCache.BisectRight(minInodeNumberToPurgeFromInodeCache)
+	for found {
+		keyAsKey, valueAsValue, ok, err = vS.inodeCache.GetByIndex(indexWherePurgesAreToHappen)
 		if nil != err {
-			vS.Unlock()
-			err = fmt.Errorf("Volume %v InodeCache BisectRight() failed: %v", vS.volumeName, err)
+			err = fmt.Errorf("Volume %v InodeCache GetByIndex() failed: %v", vS.volumeName, err)
 			logger.Error(err)
 			return
 		}
 
-		// If there is some InodeNumber at or beyond minInodeNumberToPurgeFromInodeCache, found == TRUE
+		if !ok {
+			return
+		}
 
-		for found {
-			keyAsKey, valueAsValue, ok, err = vS.inodeCache.GetByIndex(indexWherePurgesAreToHappen)
-			if nil != err {
-				vS.Unlock()
-				err = fmt.Errorf("Volume %v InodeCache GetByIndex() failed: %v", vS.volumeName, err)
-				logger.Error(err)
-				return
-			}
+		keyAsInodeNumber, ok = keyAsKey.(InodeNumber)
+		if !ok {
+			err = fmt.Errorf("Volume %v InodeCache GetByIndex() returned non-InodeNumber", vS.volumeName)
+			return
+		}
+
+		// Redefine found to indicate we've found an InodeCache entry to evict (used next iteration)
 
+		found = (keyAsInodeNumber < maxInodeNumberToPurgeFromInodeCache)
+
+		// func (vS *volumeStruct) inodeCacheDropWhileLocked(inode *inMemoryInodeStruct) (ok bool, err error)
+		if found {
+			valueAsInodeStructPtr, ok = valueAsValue.(*inMemoryInodeStruct)
 			if !ok {
-				vS.Unlock()
+				err = fmt.Errorf("Volume %v InodeCache GetByIndex() returned non-inMemoryInodeStructPtr", vS.volumeName)
 				return
 			}
-
-			keyAsInodeNumber, ok = keyAsKey.(InodeNumber)
-			if !ok {
-				vS.Unlock()
-				err = fmt.Errorf("Volume %v InodeCache GetByIndex() returned non-InodeNumber", vS.volumeName)
+			ok, err = vS.inodeCacheDropWhileLocked(valueAsInodeStructPtr)
+			if nil != err {
+				err = fmt.Errorf("Volume %v inodeCacheDropWhileLocked() failed: %v", vS.volumeName, err)
 				return
 			}
-
-			// Redefine found to indicate we've found an InodeCache entry to evict (used next iteration)
-
-			found = (keyAsInodeNumber < maxInodeNumberToPurgeFromInodeCache)
-
-			// func (vS *volumeStruct) inodeCacheDropWhileLocked(inode *inMemoryInodeStruct) (ok bool, err error)
-			if found {
-				valueAsInodeStructPtr, ok = valueAsValue.(*inMemoryInodeStruct)
-				if !ok {
-					vS.Unlock()
-					err = fmt.Errorf("Volume %v InodeCache GetByIndex() returned non-inMemoryInodeStructPtr", vS.volumeName)
-					return
-				}
-				ok, err = vS.inodeCacheDropWhileLocked(valueAsInodeStructPtr)
-				if nil != err {
-					vS.Unlock()
-					err = fmt.Errorf("Volume %v inodeCacheDropWhileLocked() failed: %v", vS.volumeName, err)
-					return
-				}
-				if !ok {
-					vS.Unlock()
-					err = fmt.Errorf("Volume %v inodeCacheDropWhileLocked() returned !ok", vS.volumeName)
-					return
-				}
+			if !ok {
+				err = fmt.Errorf("Volume %v inodeCacheDropWhileLocked() returned !ok", vS.volumeName)
+				return
 			}
 		}
 	}
-	vS.Unlock()
+
 	return
 }
 
@@ -125,6 +186,7 @@ func (vS *volumeStruct) CheckpointCompleted() {
 		dirEntryCacheHitsDelta        uint64
 		dirEntryCacheMissesDelta      uint64
 		dirEntryCacheStats            *sortedmap.BPlusTreeCacheStats
+		err                           error
 		fileExtentMapCacheHitsDelta   uint64
 		fileExtentMapCacheMissesDelta uint64
 		fileExtentMapCacheStats       *sortedmap.BPlusTreeCacheStats
@@ -160,4 +222,16 @@ func (vS *volumeStruct) CheckpointCompleted() {
 	}
 
 	globals.Unlock()
+
+	// Now persist any quota usage and compression stats changes accumulated since the prior checkpoint
+
+	err = vS.quotaPersist(false)
+	if nil != err {
+		logger.ErrorWithError(err)
+	}
+
+	err = vS.compressionStatsPersist(false)
+	if nil != err {
+		logger.ErrorWithError(err)
+	}
 }
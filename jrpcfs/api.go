@@ -234,6 +234,17 @@ type DirEntry struct {
 	NextDirLocation int64
 }
 
+// FallocateRequest is the request object for RpcFallocate.
+//
+// Mode is a combination of FALLOC_FL_{KEEP_SIZE|PUNCH_HOLE|ZERO_RANGE}.
+//
+type FallocateRequest struct {
+	InodeHandle
+	Mode   uint32
+	Offset uint64
+	Length uint64
+}
+
 // FetchExtentMapChunkRequest is the request object for RpcFetchExtentMapChunk.
 type FetchExtentMapChunkRequest struct {
 	InodeHandle
@@ -242,7 +253,8 @@ type FetchExtentMapChunkRequest struct {
 	MaxEntriesBeforeFileOffset int64
 }
 
-// FetchExtentMapChunkReply is the response object for RpcFetchExtentMapChunk.
+// FetchExtentMapChunkReply is the response object for RpcFetchExtentMapChunk
+// (and each frame of RpcFetchExtentMapChunkStream).
 type FetchExtentMapChunkReply struct {
 	FileOffsetRangeStart uint64                       // Holes in [FileOffsetRangeStart:FileOffsetRangeEnd)
 	FileOffsetRangeEnd   uint64                       //   not covered in ExtentMapEntry slice should "read-as-zero"
@@ -250,6 +262,19 @@ type FetchExtentMapChunkReply struct {
 	ExtentMapEntry       []inode.ExtentMapEntryStruct // All will be in [FileOffsetRangeStart:FileOffsetRangeEnd)
 }
 
+// FetchExtentMapChunkStreamRequest is the request object for RpcFetchExtentMapChunkStream.
+//
+// Each frame is a FetchExtentMapChunkReply with up to MaxEntriesPerFrame
+// ExtentMapEntry's following FileOffset (0 means a default).  Successive
+// frames describe successive ranges of the file.
+type FetchExtentMapChunkStreamRequest struct {
+	InodeHandle
+	FileOffset                 uint64
+	MaxEntriesFromFileOffset   int64
+	MaxEntriesBeforeFileOffset int64
+	MaxEntriesPerFrame         int64
+}
+
 // FlushRequest is the request object for RpcFlush.
 type FlushRequest struct {
 	InodeHandle
@@ -282,6 +307,15 @@ type GetXAttrReply struct {
 	AttrValue     []byte
 }
 
+// FlockRequest is the request object for RpcFlock.
+//
+// If FlockOwner is non-zero, the lock is owned by FlockOwner within the mount
+// identified by MountID (e.g. a FUSE lock_owner) and will be released should that
+// mount go away. Otherwise, the lock is owned by FlockPid.
+//
+// Note that a F_GETLK that finds a conflicting lock succeeds with the conflicting
+// lock described in FlockReply. Otherwise, FlockReply.FlockType == F_UNLCK.
+//
 type FlockRequest struct {
 	InodeHandle
 	FlockCmd    int32
@@ -290,8 +324,10 @@ type FlockRequest struct {
 	FlockStart  uint64
 	FlockLen    uint64
 	FlockPid    uint64
+	FlockOwner  uint64
 }
 
+// FlockReply is the reply object for RpcFlock.
 type FlockReply struct {
 	FlockType   int32
 	FlockWhence int32
@@ -360,6 +396,21 @@ type LookupPlusReply struct {
 	StatStruct
 }
 
+// LseekRequest is the request object for RpcLseek.
+//
+// Only SEEK_DATA and SEEK_HOLE are supported for Whence.
+//
+type LseekRequest struct {
+	InodeHandle
+	Offset uint64
+	Whence int
+}
+
+// LseekReply is the reply object for RpcLseek.
+type LseekReply struct {
+	Offset uint64
+}
+
 // AccessRequest is the request object for RpcAccess.
 type AccessRequest struct {
 	InodeHandle
@@ -393,7 +444,8 @@ type MountByAccountNameRequest struct {
 
 // MountByAccountNameReply is the reply object for RpcMountByAccountName.
 type MountByAccountNameReply struct {
-	MountID MountIDAsString
+	MountID   MountIDAsString
+	Encrypted bool // If true, LogSegments must be sealed & opened with the volume's keys
 }
 
 // MountByVolumeNameRequest is the request object for RpcMountByVolumeName.
@@ -428,7 +480,19 @@ type ReaddirByLocRequest struct {
 	PrevDirEntLocation int64
 }
 
-// ReaddirReply is the reply object for RpcReaddir and RpcReaddirByLoc.
+// ReaddirByLocStreamRequest is the request object for RpcReaddirByLocStream.
+//
+// Each frame is a ReaddirReply with up to MaxEntriesPerFrame DirEnts (0 means
+// a default).  A MaxEntries of 0 means all remaining entries are returned.
+type ReaddirByLocStreamRequest struct {
+	InodeHandle
+	MaxEntries         uint64
+	MaxEntriesPerFrame uint64
+	PrevDirEntLocation int64
+}
+
+// ReaddirReply is the reply object for RpcReaddir and RpcReaddirByLoc (and
+// each frame of RpcReaddirByLocStream).
 type ReaddirReply struct {
 	DirEnts []DirEntry
 }
@@ -447,7 +511,20 @@ type ReaddirPlusByLocRequest struct {
 	PrevDirEntLocation int64
 }
 
-// ReaddirPlusReply is the reply object for RpcReaddirPlus and RpcReaddirPlusByLoc.
+// ReaddirPlusByLocStreamRequest is the request object for RpcReaddirPlusByLocStream.
+//
+// Each frame is a ReaddirPlusReply with up to MaxEntriesPerFrame DirEnts
+// (0 means a default).  A MaxEntries of 0 means all remaining entries are
+// returned.
+type ReaddirPlusByLocStreamRequest struct {
+	InodeHandle
+	MaxEntries         uint64
+	MaxEntriesPerFrame uint64
+	PrevDirEntLocation int64
+}
+
+// ReaddirPlusReply is the reply object for RpcReaddirPlus and RpcReaddirPlusByLoc
+// (and each frame of RpcReaddirPlusByLocStream).
 type ReaddirPlusReply struct {
 	DirEnts  []DirEntry
 	StatEnts []StatStruct
@@ -468,9 +545,13 @@ type ReadSymlinkReply struct {
 	Target string
 }
 
+// RemoveXAttrRequest is the request object for RpcRemoveXAttr. The request is
+// performed on behalf of UserID and GroupID (zero values imply the root user).
 type RemoveXAttrRequest struct {
 	InodeHandle
 	AttrName string
+	UserID   int32
+	GroupID  int32
 }
 
 type RemoveXAttrPathRequest struct {
@@ -550,11 +631,17 @@ type SetTimePathRequest struct {
 	StatStruct
 }
 
+// SetXAttrRequest is the request object for RpcSetXAttr. The request is performed
+// on behalf of UserID and GroupID (zero values imply the root user). Note that only
+// the owner of an inode may set its POSIX ACLs (i.e. "system.posix_acl_access" and
+// "system.posix_acl_default").
 type SetXAttrRequest struct {
 	InodeHandle
 	AttrName  string
 	AttrValue []byte
 	AttrFlags int
+	UserID    int32
+	GroupID   int32
 }
 
 type SetXAttrPathRequest struct {
@@ -564,9 +651,13 @@ type SetXAttrPathRequest struct {
 	AttrFlags int
 }
 
-// StatVFSRequest is the request object for RpcStatVFS.
+// StatVFSRequest is the request object for RpcStatVFS. Any quotas applicable to
+// UserID, GroupID, or the project containing InodeNumber are reflected in the
+// reply. An InodeNumber of zero is taken to mean the root directory.
 type StatVFSRequest struct {
-	MountID MountIDAsString
+	InodeHandle
+	UserID  int32
+	GroupID int32
 }
 
 // StatVFS is used when filesystem stats need to be conveyed. It is used by RpcStatVFS.
@@ -582,6 +673,9 @@ type StatVFS struct {
 	FileSystemID   uint64
 	MountFlags     uint64
 	MaxFilenameLen uint64
+
+	CompressedLogicalBytes  uint64 // Compression ratio of the volume is
+	CompressedPhysicalBytes uint64 //   CompressedLogicalBytes / CompressedPhysicalBytes
 }
 
 // StatStruct is used when stats need to be conveyed. It is used as the response to RpcGetStat and RpcGetStatPath,
@@ -859,7 +953,9 @@ type WroteRequest struct {
 	FileOffset    []uint64
 	ObjectOffset  []uint64
 	Length        []uint64
-	WroteTimeNs   uint64 // New value for File's Stat.CTimeNs & Stat.MTimeNs
+	WroteTimeNs   uint64                             // New value for File's Stat.CTimeNs & Stat.MTimeNs
+	Checksums     *inode.LogSegmentChecksumsStruct   // If non-nil, covers the entire LogSegment Object
+	Compression   *inode.LogSegmentCompressionStruct // Chunk map of the sealed LogSegment Object iff volume is encrypted
 }
 
 type WroteReply struct {
@@ -895,6 +991,42 @@ type SnapShotDeleteRequest struct {
 // SnapShotDeleteReply is the reply object for RpcSnapShotDelete
 type SnapShotDeleteReply struct{}
 
+// SnapShotCloneRequest is the request object for RpcSnapShotClone (replied to with an InodeReply)
+type SnapShotCloneRequest struct {
+	MountID             MountIDAsString
+	SnapShotInodeNumber int64
+	DstDirInodeNumber   int64
+	DstBasename         string
+}
+
+// SnapShotDiffRequest is the request object for RpcSnapShotDiff (a SnapShotID of 0 means the live view)
+type SnapShotDiffRequest struct {
+	MountID        MountIDAsString
+	FromSnapShotID uint64
+	ToSnapShotID   uint64
+}
+
+// SnapShotDiffReply is the reply object for RpcSnapShotDiff
+type SnapShotDiffReply struct {
+	Entries []fs.SnapShotDiffEntryStruct
+}
+
+// SnapShotRestoreRequest is the request object for RpcSnapShotRestore (replied to with an InodeReply)
+type SnapShotRestoreRequest struct {
+	MountID    MountIDAsString
+	SnapShotID uint64
+	Path       string
+}
+
+// SnapShotRollbackRequest is the request object for RpcSnapShotRollback
+type SnapShotRollbackRequest struct {
+	MountID    MountIDAsString
+	SnapShotID uint64
+}
+
+// SnapShotRollbackReply is the reply object for RpcSnapShotRollback
+type SnapShotRollbackReply struct{}
+
 // SnapShotListRequest is the request object for RpcSnapShotListBy{ID|Name|Time}
 type SnapShotListRequest struct {
 	MountID  MountIDAsString
@@ -1008,3 +1140,13 @@ func FetchLeaseReport(volumeName string) (leaseReport []*LeaseReportElementStruc
 	leaseReport, err = fetchLeaseReport(volumeName)
 	return
 }
+
+// SnapShotRollback rolls the specified Volume back to the SnapShot identified by snapShotID.
+// As mounted clients may be caching state (or holding dirty data) under a Lease that would
+// not match the rolled back Volume, the rollback is refused (with blunder.DevBusyError)
+// while any Lease is held or requested. No Lease will be granted until it has completed.
+//
+func SnapShotRollback(volumeName string, snapShotID uint64) (err error) {
+	err = snapShotRollback(volumeName, snapShotID)
+	return
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package jrpcfs
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+)
+
+// Test that clients accessing LogSegments directly via Swift (i.e. PFSAgent) are
+// turned away from a volume held by the posix backend
+func TestPosixBackedVolumeRefused(t *testing.T) {
+	assert := assert.New(t)
+
+	mountByAccountNameReply := &MountByAccountNameReply{}
+	err := jserver.RpcMountByAccountName(testRpcLeaseLocalClientID, &MountByAccountNameRequest{AccountName: testAccountName3}, mountByAccountNameReply)
+	assert.Equal(fmt.Sprintf("errno: %d", blunder.NotSupportedError), err.Error())
+
+	// Other clients may still mount the volume but not provision Objects in it
+
+	mountByVolumeNameReply := &MountByVolumeNameReply{}
+	err = jserver.RpcMountByVolumeName(testRpcLeaseLocalClientID, &MountByVolumeNameRequest{VolumeName: "SomeVolume3"}, mountByVolumeNameReply)
+	if !assert.Nil(err) {
+		return
+	}
+
+	provisionObjectReply := &ProvisionObjectReply{}
+	err = jserver.RpcProvisionObject(&ProvisionObjectRequest{MountID: mountByVolumeNameReply.MountID}, provisionObjectReply)
+	assert.Equal(fmt.Sprintf("errno: %d", blunder.NotSupportedError), err.Error())
+
+	err = jserver.RpcUnmount(&UnmountRequest{MountID: mountByVolumeNameReply.MountID}, &Reply{})
+	assert.Nil(err)
+
+	// ...while the same requests of a Swift backed volume succeed
+
+	err = jserver.RpcMountByAccountName(testRpcLeaseLocalClientID, &MountByAccountNameRequest{AccountName: testAccountName}, mountByAccountNameReply)
+	if !assert.Nil(err) {
+		return
+	}
+
+	err = jserver.RpcProvisionObject(&ProvisionObjectRequest{MountID: mountByAccountNameReply.MountID}, provisionObjectReply)
+	assert.Nil(err)
+
+	err = jserver.RpcUnmount(&UnmountRequest{MountID: mountByAccountNameReply.MountID}, &Reply{})
+	assert.Nil(err)
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package jrpcfs
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/rpc"
+	"sync"
+
+	"github.com/NVIDIA/proxyfs/tracing"
+)
+
+// serverCodec is a JSON-RPC 1.0 rpc.ServerCodec equivalent to the one returned
+// by jsonrpc.NewServerCodec() except that it also honors the optional (W3C)
+// "traceparent" member of the request envelope, e.g.:
+//
+//	{
+//	  "method": "Server.RpcPing",
+//	  "params": [{"Message": "Hi"}],
+//	  "id": 1,
+//	  "traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+//	}
+//
+// Each request is covered by a Span (if tracing is enabled) started when the
+// request is read and ended just before its response is written. Rather than using
+// (*rpc.Server).ServeCodec() (that reads each request on one goroutine and then
+// invokes its method on another), serve() reads each request with a call to
+// (*rpc.Server).ServeRequest() on a goroutine of its own that also invokes the
+// method. As such, the Span is the current Span of the goroutine running the
+// method and Spans started by the method (e.g. in package fs) are its children.
+// Once a request has been read, the next one is read on a new goroutine such
+// that methods may run concurrently as they would under ServeCodec().
+//
+type serverCodec struct {
+	dec     *json.Decoder
+	enc     *json.Encoder
+	c       io.Closer
+	req     serverRequest
+	mutex   sync.Mutex // Protects seq & pending
+	seq     uint64
+	pending map[uint64]*pendingRequest // Key: rpc.Request.Seq
+	sending sync.Mutex                 // Serializes responses as each ServeRequest() call uses its own
+	srv     *rpc.Server
+	wg      sync.WaitGroup // Tracks goroutines calling ServeRequest()
+}
+
+type serverRequest struct {
+	Method      string           `json:"method"`
+	Params      *json.RawMessage `json:"params"`
+	ID          *json.RawMessage `json:"id"`
+	TraceParent string           `json:"traceparent"`
+}
+
+type serverResponse struct {
+	ID     *json.RawMessage `json:"id"`
+	Result interface{}      `json:"result"`
+	Error  interface{}      `json:"error"`
+}
+
+type pendingRequest struct {
+	id   *json.RawMessage // Client supplied request ID (may be any JSON value)
+	span *tracing.SpanStruct
+}
+
+const tracingSpanNamePrefix = "jrpcfs/"
+
+var errMissingParams = errors.New("jsonrpc: request body missing params")
+
+var jsonNull = json.RawMessage([]byte("null"))
+
+func newServerCodec(conn io.ReadWriteCloser) *serverCodec {
+	return &serverCodec{
+		dec:     json.NewDecoder(conn),
+		enc:     json.NewEncoder(conn),
+		c:       conn,
+		pending: make(map[uint64]*pendingRequest),
+	}
+}
+
+// serve is the equivalent of srv.ServeCodec(c) described above. It returns once
+// no further requests can be read and the responses to all prior ones are written.
+//
+func (c *serverCodec) serve(srv *rpc.Server) {
+	c.srv = srv
+
+	c.wg.Add(1)
+	go c.serveRequest()
+
+	c.wg.Wait()
+
+	_ = c.Close()
+}
+
+func (c *serverCodec) serveRequest() {
+	_ = c.srv.ServeRequest(c)
+	c.wg.Done()
+}
+
+func (c *serverCodec) ReadRequestHeader(r *rpc.Request) (err error) {
+	c.req = serverRequest{}
+
+	err = c.dec.Decode(&c.req)
+	if nil != err {
+		return
+	}
+
+	r.ServiceMethod = c.req.Method
+
+	span := tracing.StartSpanFromTraceParent(tracingSpanNamePrefix+c.req.Method, tracing.SpanKindServer, c.req.TraceParent)
+
+	// net/rpc requires uint64 request IDs, so remember the client's on the side
+
+	c.mutex.Lock()
+	c.seq++
+	c.pending[c.seq] = &pendingRequest{id: c.req.ID, span: span}
+	c.req.ID = nil
+	r.Seq = c.seq
+	c.mutex.Unlock()
+
+	return
+}
+
+func (c *serverCodec) ReadRequestBody(x interface{}) (err error) {
+	// net/rpc always follows a successful ReadRequestHeader() with a call here,
+	// so once c.req has been consumed, the next request may be read
+
+	defer func() {
+		c.wg.Add(1)
+		go c.serveRequest()
+	}()
+
+	if nil == x {
+		return
+	}
+
+	if nil == c.req.Params {
+		err = errMissingParams
+		return
+	}
+
+	// JSON-RPC params is an array of (here, just) one struct
+
+	var params [1]interface{}
+	params[0] = x
+
+	err = json.Unmarshal(*c.req.Params, &params)
+
+	return
+}
+
+func (c *serverCodec) WriteResponse(r *rpc.Response, x interface{}) (err error) {
+	c.mutex.Lock()
+	pending, ok := c.pending[r.Seq]
+	if !ok {
+		c.mutex.Unlock()
+		err = errors.New("invalid sequence number in response")
+		return
+	}
+	delete(c.pending, r.Seq)
+	c.mutex.Unlock()
+
+	resp := serverResponse{ID: pending.id}
+
+	if nil == resp.ID {
+		// Invalid request so no id... use JSON null
+		resp.ID = &jsonNull
+	}
+
+	if "" == r.Error {
+		resp.Result = x
+	} else {
+		resp.Error = r.Error
+		pending.span.SetError(errors.New(r.Error))
+	}
+
+	pending.span.End()
+
+	c.sending.Lock()
+	err = c.enc.Encode(resp)
+	c.sending.Unlock()
+
+	return
+}
+
+func (c *serverCodec) Close() (err error) {
+	err = c.c.Close()
+	return
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package jrpcfs
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/proxyfs/inode"
+	"github.com/NVIDIA/proxyfs/tracing"
+)
+
+type testExportedSpanStruct struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+}
+
+type testExportedTracesDataStruct struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []*testExportedSpanStruct `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+// Test that Spans started by a method invoked via the JSON-RPC server are
+// children of the Span covering the request (itself a child of the caller's)
+func TestJSONRPCTracing(t *testing.T) {
+	assert := assert.New(t)
+
+	testDir, err := ioutil.TempDir("", "jrpcfs_codec_test_")
+	if !assert.Nil(err) {
+		return
+	}
+	defer os.RemoveAll(testDir)
+
+	exportFilePath := filepath.Join(testDir, "spans.json")
+
+	err = tracing.Start(&tracing.ConfigStruct{
+		ServiceName:      "jrpcfs.test",
+		SampleRatio:      1.0,
+		ExportFilePath:   exportFilePath,
+		ExportInterval:   time.Second,
+		ExportBatchSize:  100,
+		ExportQueueDepth: 1000,
+	})
+	if !assert.Nil(err) {
+		return
+	}
+
+	mountByAccountNameReply := &MountByAccountNameReply{}
+	err = jserver.RpcMountByAccountName(testRpcLeaseLocalClientID, &MountByAccountNameRequest{AccountName: testAccountName}, mountByAccountNameReply)
+	if !assert.Nil(err) {
+		_ = tracing.Stop()
+		return
+	}
+
+	// Issue RpcGetStat as a child of a (remote) Span of the caller
+
+	netConn, err := net.Dial("tcp", net.JoinHostPort(globals.privateIPAddr, globals.portString))
+	if !assert.Nil(err) {
+		_ = tracing.Stop()
+		return
+	}
+
+	callerTraceID := "0af7651916cd43dd8448eb211c80319c"
+	callerSpanID := "b7ad6b7169203331"
+
+	err = json.NewEncoder(netConn).Encode(map[string]interface{}{
+		"method":      "Server.RpcGetStat",
+		"params":      []interface{}{&GetStatRequest{InodeHandle: InodeHandle{MountID: mountByAccountNameReply.MountID, InodeNumber: int64(inode.RootDirInodeNumber)}}},
+		"id":          1,
+		"traceparent": "00-" + callerTraceID + "-" + callerSpanID + "-01",
+	})
+	assert.Nil(err)
+
+	var getStatResponse struct {
+		ID     int         `json:"id"`
+		Result StatStruct  `json:"result"`
+		Error  interface{} `json:"error"`
+	}
+	err = json.NewDecoder(netConn).Decode(&getStatResponse)
+	assert.Nil(err)
+	assert.Equal(1, getStatResponse.ID)
+	assert.Nil(getStatResponse.Error)
+	assert.Equal(int64(inode.RootDirInodeNumber), getStatResponse.Result.StatInodeNumber)
+
+	_ = netConn.Close()
+
+	err = jserver.RpcUnmount(&UnmountRequest{MountID: mountByAccountNameReply.MountID}, &Reply{})
+	assert.Nil(err)
+
+	err = tracing.Stop()
+	assert.Nil(err)
+
+	// Locate the Span covering the request and the fs Span it parents
+
+	exportFile, err := os.Open(exportFilePath)
+	if !assert.Nil(err) {
+		return
+	}
+	defer exportFile.Close()
+
+	spanMap := make(map[string]*testExportedSpanStruct) // Key: Span.Name
+
+	scanner := bufio.NewScanner(exportFile)
+	for scanner.Scan() {
+		tracesData := &testExportedTracesDataStruct{}
+		err = json.Unmarshal(scanner.Bytes(), tracesData)
+		if !assert.Nil(err) {
+			return
+		}
+		for _, resourceSpans := range tracesData.ResourceSpans {
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				for _, span := range scopeSpans.Spans {
+					if callerTraceID == span.TraceID {
+						spanMap[span.Name] = span
+					}
+				}
+			}
+		}
+	}
+	assert.Nil(scanner.Err())
+
+	rpcSpan, ok := spanMap[tracingSpanNamePrefix+"Server.RpcGetStat"]
+	if !assert.True(ok) {
+		return
+	}
+	assert.Equal(callerSpanID, rpcSpan.ParentSpanID)
+
+	fsSpan, ok := spanMap["fs.Getstat"]
+	if !assert.True(ok) {
+		return
+	}
+	assert.Equal(rpcSpan.SpanID, fsSpan.ParentSpanID)
+}
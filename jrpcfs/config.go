@@ -137,6 +137,8 @@ type globalsStruct struct {
 	retryRPCAckTrim         time.Duration
 	retryRPCDeadlineIO      time.Duration
 	retryRPCKeepAlivePeriod time.Duration
+	retryRPCMaxConcurrent   uint32
+	retryRPCJournalPath     string
 	retryRPCCertFilePath    string
 	retryRPCKeyFilePath     string
 	minLeaseDuration        time.Duration
@@ -229,6 +231,16 @@ func (dummy *globalsStruct) Up(confMap conf.ConfMap) (err error) {
 			logger.Infof("failed to get JSONRPCServer.RetryRPCKeepAlivePeriod from config file - defaulting to 60s")
 			globals.retryRPCKeepAlivePeriod = 60 * time.Second
 		}
+		globals.retryRPCMaxConcurrent, err = confMap.FetchOptionValueUint32("JSONRPCServer", "RetryRPCMaxConcurrentRPCs")
+		if nil != err {
+			logger.Infof("failed to get JSONRPCServer.RetryRPCMaxConcurrentRPCs from config file - defaulting to 64")
+			globals.retryRPCMaxConcurrent = 64
+		}
+		globals.retryRPCJournalPath, err = confMap.FetchOptionValueString("JSONRPCServer", "RetryRPCJournalPath")
+		if nil != err {
+			logger.Infof("failed to get JSONRPCServer.RetryRPCJournalPath from config file - defaulting to \"\" (not journaled)")
+			globals.retryRPCJournalPath = ""
+		}
 		globals.retryRPCCertFilePath, err = confMap.FetchOptionValueString("JSONRPCServer", "RetryRPCCertFilePath")
 		if (nil == err) && ("" != globals.retryRPCCertFilePath) {
 			globals.retryRPCKeyFilePath, err = confMap.FetchOptionValueString("JSONRPCServer", "RetryRPCKeyFilePath")
@@ -271,6 +283,8 @@ func (dummy *globalsStruct) Up(confMap conf.ConfMap) (err error) {
 		globals.retryRPCAckTrim = time.Duration(0)
 		globals.retryRPCDeadlineIO = time.Duration(0)
 		globals.retryRPCKeepAlivePeriod = time.Duration(0)
+		globals.retryRPCMaxConcurrent = 0
+		globals.retryRPCJournalPath = ""
 		globals.retryRPCCertFilePath = ""
 		globals.retryRPCKeyFilePath = ""
 		globals.retryRPCCertPEM = nil
@@ -10,10 +10,10 @@ import (
 	"fmt"
 	"net"
 	"net/rpc"
-	"net/rpc/jsonrpc"
 	"path/filepath"
 	"strconv"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/NVIDIA/proxyfs/blunder"
@@ -21,6 +21,8 @@ import (
 	"github.com/NVIDIA/proxyfs/headhunter"
 	"github.com/NVIDIA/proxyfs/inode"
 	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/retryrpc"
+	"github.com/NVIDIA/proxyfs/swiftclient"
 	"github.com/NVIDIA/proxyfs/utils"
 )
 
@@ -31,6 +33,10 @@ var jrpcListener net.Listener
 // Local server handle, used to track jrpc-related ops
 var jserver *Server
 
+// Number of entries sent in each frame of a streaming RPC whose request
+// leaves MaxEntriesPerFrame as zero
+const streamMaxEntriesPerFrameDefault = 1024
+
 func jsonRpcServerUp(ipAddr string, portString string) {
 	var err error
 
@@ -82,7 +88,7 @@ func jrpcServerLoop() {
 		globals.connLock.Unlock()
 
 		go func(myConn net.Conn, myElm *list.Element) {
-			srv.ServeCodec(jsonrpc.NewServerCodec(myConn))
+			newServerCodec(myConn).serve(srv)
 			globals.connLock.Lock()
 			globals.connections.Remove(myElm)
 
@@ -118,6 +124,8 @@ const (
 	ReaddirByLocOp
 	ReaddirPlusOp
 	ReaddirPlusByLocOp
+	ReaddirByLocStreamOp
+	ReaddirPlusByLocStreamOp
 	InvalidOp // Keep this as the last entry!
 )
 
@@ -139,6 +147,8 @@ var opTypeStrs = []string{
 	"ReaddirByLoc",
 	"ReaddirPlus",
 	"ReaddirPlusByLoc",
+	"ReaddirByLocStream",
+	"ReaddirPlusByLocStream",
 	"InvalidOp",
 }
 
@@ -800,7 +810,15 @@ func (s *Server) RpcFlock(in *FlockRequest, reply *FlockReply) (err error) {
 	flock.Len = in.FlockLen
 	flock.Pid = in.FlockPid
 
+	if 0 != in.FlockOwner {
+		flock.MountID = string(in.MountID)
+		flock.Owner = in.FlockOwner
+	}
+
 	lockStruct, err := volumeHandle.Flock(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.InodeNumber(in.InodeNumber), in.FlockCmd, &flock)
+	if (syscall.F_GETLK == in.FlockCmd) && blunder.Is(err, blunder.TryAgainError) {
+		err = nil // The conflicting lock is returned in reply
+	}
 	if lockStruct != nil {
 		reply.FlockType = lockStruct.Type
 		reply.FlockWhence = lockStruct.Whence
@@ -828,10 +846,17 @@ func (s *Server) RpcProvisionObject(in *ProvisionObjectRequest, reply *Provision
 	defer func() { rpcEncodeError(&err) }() // Encode error for return by RPC
 
 	volumeHandle, err := lookupVolumeHandleByMountIDAsString(in.MountID)
-	if nil == err {
-		reply.PhysPath, err = volumeHandle.CallInodeToProvisionObject()
+	if nil != err {
+		return
+	}
+
+	err = checkSwiftBacked(volumeHandle)
+	if nil != err {
+		return
 	}
 
+	reply.PhysPath, err = volumeHandle.CallInodeToProvisionObject()
+
 	return
 }
 
@@ -845,12 +870,29 @@ func (s *Server) RpcWrote(in *WroteRequest, reply *WroteReply) (err error) {
 
 	volumeHandle, err := lookupVolumeHandleByMountIDAsString(in.MountID)
 	if nil == err {
-		err = volumeHandle.Wrote(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.InodeNumber(in.InodeNumber), in.ContainerName, in.ObjectName, in.FileOffset, in.ObjectOffset, in.Length, in.WroteTimeNs)
+		err = volumeHandle.Wrote(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.InodeNumber(in.InodeNumber), in.ContainerName, in.ObjectName, in.FileOffset, in.ObjectOffset, in.Length, in.Checksums, in.Compression, in.WroteTimeNs)
 	}
 
 	return
 }
 
+func (s *Server) RpcFallocate(in *FallocateRequest, reply *Reply) (err error) {
+	enterGate()
+	defer leaveGate()
+
+	flog := logger.TraceEnter("in.", in)
+	defer func() { flog.TraceExitErr("reply.", err, reply) }()
+	defer func() { rpcEncodeError(&err) }() // Encode error for return by RPC
+
+	volumeHandle, err := lookupVolumeHandleByMountIDAsString(in.MountID)
+	if nil != err {
+		return
+	}
+
+	err = volumeHandle.Fallocate(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.InodeNumber(in.InodeNumber), in.Mode, in.Offset, in.Length)
+	return
+}
+
 func (s *Server) RpcFetchExtentMapChunk(in *FetchExtentMapChunkRequest, reply *FetchExtentMapChunkReply) (err error) {
 	var (
 		extentMapChunk *inode.ExtentMapChunkStruct
@@ -877,6 +919,83 @@ func (s *Server) RpcFetchExtentMapChunk(in *FetchExtentMapChunkRequest, reply *F
 	return
 }
 
+// RpcFetchExtentMapChunkStream is like RpcFetchExtentMapChunk except that the
+// ExtentMapEntry's are sent a frame at a time so that the extent map of a huge
+// file is never held (or marshaled) all at once.
+func (s *Server) RpcFetchExtentMapChunkStream(in *FetchExtentMapChunkStreamRequest, stream *retryrpc.ServerStream) (err error) {
+	var (
+		extentMapChunk             *inode.ExtentMapChunkStruct
+		extentMapEntry             *inode.ExtentMapEntryStruct
+		fileOffset                 uint64
+		maxEntriesBeforeFileOffset int64
+		maxEntriesFromFileOffset   int64
+		maxEntriesPerFrame         int64
+		numFromFileOffset          int64
+		numSent                    int64
+		volumeHandle               fs.VolumeHandle
+	)
+
+	enterGate()
+	defer leaveGate()
+
+	flog := logger.TraceEnter("in.", in)
+	defer func() { flog.TraceExitErr("numSent.", err, numSent) }()
+	defer func() { rpcEncodeError(&err) }() // Encode error for return by RPC
+
+	volumeHandle, err = lookupVolumeHandleByMountIDAsString(in.MountID)
+	if nil != err {
+		return
+	}
+
+	maxEntriesPerFrame = in.MaxEntriesPerFrame
+	if maxEntriesPerFrame < 1 {
+		maxEntriesPerFrame = streamMaxEntriesPerFrameDefault
+	}
+
+	fileOffset = in.FileOffset
+	maxEntriesBeforeFileOffset = in.MaxEntriesBeforeFileOffset
+
+	for {
+		maxEntriesFromFileOffset = in.MaxEntriesFromFileOffset - numSent
+		if maxEntriesFromFileOffset > maxEntriesPerFrame {
+			maxEntriesFromFileOffset = maxEntriesPerFrame
+		}
+
+		extentMapChunk, err = volumeHandle.FetchExtentMapChunk(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.InodeNumber(in.InodeNumber), fileOffset, maxEntriesFromFileOffset, maxEntriesBeforeFileOffset)
+		if nil != err {
+			return
+		}
+
+		err = stream.Send(&FetchExtentMapChunkReply{
+			FileOffsetRangeStart: extentMapChunk.FileOffsetRangeStart,
+			FileOffsetRangeEnd:   extentMapChunk.FileOffsetRangeEnd,
+			FileSize:             extentMapChunk.FileSize,
+			ExtentMapEntry:       extentMapChunk.ExtentMapEntry,
+		})
+		if nil != err {
+			return
+		}
+
+		// Only the ExtentMapEntry's at or after fileOffset count against MaxEntriesFromFileOffset
+
+		numFromFileOffset = 0
+		for i := range extentMapChunk.ExtentMapEntry {
+			extentMapEntry = &extentMapChunk.ExtentMapEntry[i]
+			if (extentMapEntry.FileOffset + extentMapEntry.Length) > fileOffset {
+				numFromFileOffset++
+			}
+		}
+		numSent += numFromFileOffset
+
+		if (numFromFileOffset < maxEntriesFromFileOffset) || (numSent >= in.MaxEntriesFromFileOffset) || (extentMapChunk.FileOffsetRangeEnd >= extentMapChunk.FileSize) {
+			return
+		}
+
+		fileOffset = extentMapChunk.FileOffsetRangeEnd
+		maxEntriesBeforeFileOffset = 0
+	}
+}
+
 func (s *Server) RpcFlush(in *FlushRequest, reply *Reply) (err error) {
 	var profiler = utils.NewProfilerIf(doProfiling, "flush")
 
@@ -1258,6 +1377,23 @@ func (s *Server) RpcLookupPlus(in *LookupPlusRequest, reply *LookupPlusReply) (e
 	return
 }
 
+func (s *Server) RpcLseek(in *LseekRequest, reply *LseekReply) (err error) {
+	enterGate()
+	defer leaveGate()
+
+	flog := logger.TraceEnter("in.", in)
+	defer func() { flog.TraceExitErr("reply.", err, reply) }()
+	defer func() { rpcEncodeError(&err) }() // Encode error for return by RPC
+
+	volumeHandle, err := lookupVolumeHandleByMountIDAsString(in.MountID)
+	if nil != err {
+		return
+	}
+
+	reply.Offset, err = volumeHandle.Lseek(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.InodeNumber(in.InodeNumber), in.Offset, in.Whence)
+	return
+}
+
 func (s *Server) RpcAccess(in *AccessRequest, reply *InodeReply) (err error) {
 	enterGate()
 	defer leaveGate()
@@ -1400,6 +1536,24 @@ func performMount(volumeHandle fs.VolumeHandle, authToken string, clientID uint6
 	return
 }
 
+// checkSwiftBacked fails with NotSupportedError unless volumeHandle's Account is held
+// by the Swift backend. Clients like PFSAgent PUT and GET LogSegments directly via
+// the Swift Proxy, so would not find (or would silently lose) those of volumes held
+// by any other backend (e.g. "s3" or "posix").
+func checkSwiftBacked(volumeHandle fs.VolumeHandle) (err error) {
+	var (
+		backendName string
+	)
+
+	backendName = volumeHandle.ObjectStoreBackend()
+
+	if swiftclient.BackendNameSwift != backendName {
+		err = blunder.NewError(blunder.NotSupportedError, "volume %s with ObjectStoreBackend %s not accessible directly via Swift", volumeHandle.VolumeName(), backendName)
+	}
+
+	return
+}
+
 func (s *Server) RpcMountByAccountName(clientID uint64, in *MountByAccountNameRequest, reply *MountByAccountNameReply) (err error) {
 	enterGate()
 	defer leaveGate()
@@ -1409,10 +1563,24 @@ func (s *Server) RpcMountByAccountName(clientID uint64, in *MountByAccountNameRe
 	defer func() { rpcEncodeError(&err) }() // Encode error for return by RPC
 
 	volumeHandle, err := fs.FetchVolumeHandleByAccountName(in.AccountName)
-	if nil == err {
-		_, reply.MountID, err = performMount(volumeHandle, in.AuthToken, clientID)
+	if nil != err {
+		return
 	}
 
+	err = checkSwiftBacked(volumeHandle)
+	if nil != err {
+		return
+	}
+
+	_, reply.MountID, err = performMount(volumeHandle, in.AuthToken, clientID)
+	if nil != err {
+		return
+	}
+
+	// PFSAgent accesses LogSegments directly in Swift so must seal & open them itself
+
+	reply.Encrypted = volumeHandle.IsEncrypted()
+
 	return
 }
 
@@ -1469,6 +1637,8 @@ func (s *Server) RpcUnmount(in *UnmountRequest, reply *Reply) (err error) {
 	leaseReleaseStartWG.Done()
 	leaseReleaseFinishedWG.Wait()
 
+	volume.volumeHandle.FlockReleaseAll(string(mount.mountIDAsString))
+
 	err = nil
 	return
 }
@@ -1498,6 +1668,15 @@ func (s *Server) RpcReaddirByLoc(in *ReaddirByLocRequest, reply *ReaddirReply) (
 	return
 }
 
+func (s *Server) RpcReaddirByLocStream(in *ReaddirByLocStreamRequest, stream *retryrpc.ServerStream) (err error) {
+	profiler := utils.NewProfilerIf(doProfiling, "readdirByLocStream")
+	err = s.rpcReaddirStreamInternal(in, stream, profiler)
+	// Save profiler with server op stats
+	profiler.Close()
+	SaveProfiler(s, ReaddirByLocStreamOp, profiler)
+	return
+}
+
 func (s *Server) rpcReaddirInternal(in interface{}, reply *ReaddirReply, profiler *utils.Profiler) (err error) {
 	var (
 		dirEnts      []inode.DirEntry
@@ -1551,6 +1730,71 @@ func (s *Server) rpcReaddirInternal(in interface{}, reply *ReaddirReply, profile
 	return
 }
 
+// rpcReaddirStreamInternal sends the directory entries following
+// in.PrevDirEntLocation a frame at a time so that a huge directory is
+// never held (or marshaled) all at once.
+func (s *Server) rpcReaddirStreamInternal(in *ReaddirByLocStreamRequest, stream *retryrpc.ServerStream, profiler *utils.Profiler) (err error) {
+	var (
+		areMoreEntries bool
+		dirEnts        []inode.DirEntry
+		i              int
+		maxEntries     uint64
+		numSent        uint64
+		prevMarker     inode.InodeDirLocation
+		reply          *ReaddirReply
+		volumeHandle   fs.VolumeHandle
+	)
+
+	flog := logger.TraceEnter("in.", in)
+
+	enterGate()
+	defer leaveGate()
+
+	defer func() { flog.TraceExitErr("numSent.", err, numSent) }()
+	defer func() { rpcEncodeError(&err) }() // Encode error for return by RPC
+
+	volumeHandle, err = lookupVolumeHandleByMountIDAsString(in.MountID)
+	if nil != err {
+		return
+	}
+
+	prevMarker = inode.InodeDirLocation(in.PrevDirEntLocation)
+
+	for {
+		maxEntries = in.MaxEntriesPerFrame
+		if 0 == maxEntries {
+			maxEntries = streamMaxEntriesPerFrameDefault
+		}
+		if (0 != in.MaxEntries) && ((in.MaxEntries - numSent) < maxEntries) {
+			maxEntries = in.MaxEntries - numSent
+		}
+
+		profiler.AddEventNow("before fs.Readdir()")
+		dirEnts, _, areMoreEntries, err = volumeHandle.Readdir(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.InodeNumber(in.InodeNumber), maxEntries, prevMarker)
+		profiler.AddEventNow("after fs.Readdir()")
+		if (nil != err) || (0 == len(dirEnts)) {
+			return
+		}
+
+		reply = &ReaddirReply{DirEnts: make([]DirEntry, len(dirEnts))}
+		for i = range dirEnts {
+			reply.DirEnts[i].fsDirentToDirEntryStruct(dirEnts[i])
+		}
+
+		err = stream.Send(reply)
+		if nil != err {
+			return
+		}
+
+		numSent += uint64(len(dirEnts))
+		if !areMoreEntries || ((0 != in.MaxEntries) && (numSent >= in.MaxEntries)) {
+			return
+		}
+
+		prevMarker = dirEnts[len(dirEnts)-1].NextDirLocation - 1
+	}
+}
+
 func (s *Server) RpcReaddirPlus(in *ReaddirPlusRequest, reply *ReaddirPlusReply) (err error) {
 	profiler := utils.NewProfilerIf(doProfiling, "readdir_plus")
 	err = s.rpcReaddirPlusInternal(in, reply, profiler)
@@ -1569,6 +1813,15 @@ func (s *Server) RpcReaddirPlusByLoc(in *ReaddirPlusByLocRequest, reply *Readdir
 	return
 }
 
+func (s *Server) RpcReaddirPlusByLocStream(in *ReaddirPlusByLocStreamRequest, stream *retryrpc.ServerStream) (err error) {
+	profiler := utils.NewProfilerIf(doProfiling, "readdir_plus_by_loc_stream")
+	err = s.rpcReaddirPlusStreamInternal(in, stream, profiler)
+	// Save profiler with server op stats
+	profiler.Close()
+	SaveProfiler(s, ReaddirPlusByLocStreamOp, profiler)
+	return
+}
+
 func (s *Server) rpcReaddirPlusInternal(in interface{}, reply *ReaddirPlusReply, profiler *utils.Profiler) (err error) {
 	var (
 		dirEnts      []inode.DirEntry
@@ -1625,6 +1878,75 @@ func (s *Server) rpcReaddirPlusInternal(in interface{}, reply *ReaddirPlusReply,
 	return
 }
 
+// rpcReaddirPlusStreamInternal is the ReaddirPlus equivalent of
+// rpcReaddirStreamInternal.
+func (s *Server) rpcReaddirPlusStreamInternal(in *ReaddirPlusByLocStreamRequest, stream *retryrpc.ServerStream, profiler *utils.Profiler) (err error) {
+	var (
+		areMoreEntries bool
+		dirEnts        []inode.DirEntry
+		i              int
+		maxEntries     uint64
+		numSent        uint64
+		prevMarker     inode.InodeDirLocation
+		reply          *ReaddirPlusReply
+		statEnts       []fs.Stat
+		volumeHandle   fs.VolumeHandle
+	)
+
+	flog := logger.TraceEnter("in.", in)
+
+	enterGate()
+	defer leaveGate()
+
+	defer func() { flog.TraceExitErr("numSent.", err, numSent) }()
+	defer func() { rpcEncodeError(&err) }() // Encode error for return by RPC
+
+	volumeHandle, err = lookupVolumeHandleByMountIDAsString(in.MountID)
+	if err != nil {
+		return
+	}
+
+	prevMarker = inode.InodeDirLocation(in.PrevDirEntLocation)
+
+	for {
+		maxEntries = in.MaxEntriesPerFrame
+		if 0 == maxEntries {
+			maxEntries = streamMaxEntriesPerFrameDefault
+		}
+		if (0 != in.MaxEntries) && ((in.MaxEntries - numSent) < maxEntries) {
+			maxEntries = in.MaxEntries - numSent
+		}
+
+		profiler.AddEventNow("before fs.ReaddirPlus()")
+		dirEnts, statEnts, _, areMoreEntries, err = volumeHandle.ReaddirPlus(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.InodeNumber(in.InodeNumber), maxEntries, prevMarker)
+		profiler.AddEventNow("after fs.ReaddirPlus()")
+		if (nil != err) || (0 == len(dirEnts)) {
+			return
+		}
+
+		reply = &ReaddirPlusReply{
+			DirEnts:  make([]DirEntry, len(dirEnts)),
+			StatEnts: make([]StatStruct, len(dirEnts)), // Assuming len(dirEnts) == len(statEnts)
+		}
+		for i = range dirEnts {
+			reply.DirEnts[i].fsDirentToDirEntryStruct(dirEnts[i])
+			reply.StatEnts[i].fsStatToStatStruct(statEnts[i])
+		}
+
+		err = stream.Send(reply)
+		if nil != err {
+			return
+		}
+
+		numSent += uint64(len(dirEnts))
+		if !areMoreEntries || ((0 != in.MaxEntries) && (numSent >= in.MaxEntries)) {
+			return
+		}
+
+		prevMarker = dirEnts[len(dirEnts)-1].NextDirLocation - 1
+	}
+}
+
 func (s *Server) RpcReadSymlink(in *ReadSymlinkRequest, reply *ReadSymlinkReply) (err error) {
 	enterGate()
 	defer leaveGate()
@@ -1680,7 +2002,7 @@ func (s *Server) RpcRemoveXAttr(in *RemoveXAttrRequest, reply *Reply) (err error
 		return
 	}
 
-	err = volumeHandle.RemoveXAttr(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.InodeNumber(in.InodeNumber), in.AttrName)
+	err = volumeHandle.RemoveXAttr(inode.InodeUserID(in.UserID), inode.InodeGroupID(in.GroupID), nil, inode.InodeNumber(in.InodeNumber), in.AttrName)
 	return
 }
 
@@ -1957,7 +2279,7 @@ func (s *Server) RpcSetXAttr(in *SetXAttrRequest, reply *Reply) (err error) {
 		return
 	}
 
-	err = volumeHandle.SetXAttr(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.InodeNumber(in.InodeNumber), in.AttrName, in.AttrValue, in.AttrFlags)
+	err = volumeHandle.SetXAttr(inode.InodeUserID(in.UserID), inode.InodeGroupID(in.GroupID), nil, inode.InodeNumber(in.InodeNumber), in.AttrName, in.AttrValue, in.AttrFlags)
 	return
 }
 
@@ -1996,7 +2318,12 @@ func (s *Server) RpcStatVFS(in *StatVFSRequest, reply *StatVFS) (err error) {
 		return
 	}
 
-	statvfs, err := volumeHandle.StatVfs()
+	ino := inode.InodeNumber(in.InodeNumber)
+	if 0 == ino {
+		ino = inode.RootDirInodeNumber
+	}
+
+	statvfs, err := volumeHandle.StatVfs(inode.InodeUserID(in.UserID), inode.InodeGroupID(in.GroupID), nil, ino)
 	if err != nil {
 		return
 	}
@@ -2013,6 +2340,8 @@ func (s *Server) RpcStatVFS(in *StatVFSRequest, reply *StatVFS) (err error) {
 	reply.FileSystemID = statvfs[fs.StatVFSFilesystemID]
 	reply.MountFlags = statvfs[fs.StatVFSMountFlags]
 	reply.MaxFilenameLen = statvfs[fs.StatVFSMaxFilenameLen]
+	reply.CompressedLogicalBytes = statvfs[fs.StatVFSLogicalBytes]
+	reply.CompressedPhysicalBytes = statvfs[fs.StatVFSPhysicalBytes]
 
 	return
 }
@@ -2164,6 +2493,84 @@ func (s *Server) RpcSnapShotDelete(in *SnapShotDeleteRequest, reply *SnapShotDel
 	return
 }
 
+func (s *Server) RpcSnapShotClone(in *SnapShotCloneRequest, reply *InodeReply) (err error) {
+	var (
+		cloneInodeNumber inode.InodeNumber
+		fsVolumeHandle   fs.VolumeHandle
+	)
+
+	fsVolumeHandle, err = lookupVolumeHandleByMountIDAsString(in.MountID)
+	if nil != err {
+		return
+	}
+
+	cloneInodeNumber, err = fsVolumeHandle.SnapShotClone(inode.InodeRootUserID, inode.InodeGroupID(0), nil, inode.InodeNumber(in.SnapShotInodeNumber), inode.InodeNumber(in.DstDirInodeNumber), in.DstBasename)
+	if nil != err {
+		return
+	}
+
+	reply.InodeNumber = int64(cloneInodeNumber)
+
+	return
+}
+
+func (s *Server) RpcSnapShotDiff(in *SnapShotDiffRequest, reply *SnapShotDiffReply) (err error) {
+	var (
+		fsVolumeHandle fs.VolumeHandle
+	)
+
+	fsVolumeHandle, err = lookupVolumeHandleByMountIDAsString(in.MountID)
+	if nil != err {
+		return
+	}
+
+	reply.Entries = make([]fs.SnapShotDiffEntryStruct, 0)
+
+	err = fsVolumeHandle.SnapShotDiff(in.FromSnapShotID, in.ToSnapShotID, func(entry *fs.SnapShotDiffEntryStruct) (err error) {
+		reply.Entries = append(reply.Entries, *entry)
+		err = nil
+		return
+	})
+
+	return
+}
+
+func (s *Server) RpcSnapShotRestore(in *SnapShotRestoreRequest, reply *InodeReply) (err error) {
+	var (
+		fsVolumeHandle      fs.VolumeHandle
+		restoredInodeNumber inode.InodeNumber
+	)
+
+	fsVolumeHandle, err = lookupVolumeHandleByMountIDAsString(in.MountID)
+	if nil != err {
+		return
+	}
+
+	restoredInodeNumber, err = fsVolumeHandle.SnapShotRestore(in.SnapShotID, in.Path)
+	if nil != err {
+		return
+	}
+
+	reply.InodeNumber = int64(restoredInodeNumber)
+
+	return
+}
+
+func (s *Server) RpcSnapShotRollback(in *SnapShotRollbackRequest, reply *SnapShotRollbackReply) (err error) {
+	var (
+		fsVolumeHandle fs.VolumeHandle
+	)
+
+	fsVolumeHandle, err = lookupVolumeHandleByMountIDAsString(in.MountID)
+	if nil != err {
+		return
+	}
+
+	err = snapShotRollback(fsVolumeHandle.VolumeName(), in.SnapShotID)
+
+	return
+}
+
 func (s *Server) RpcSnapShotListByID(in *SnapShotListRequest, reply *SnapShotListReply) (err error) {
 	var (
 		fsVolumeHandle         fs.VolumeHandle
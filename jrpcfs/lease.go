@@ -29,6 +29,8 @@ func (s *Server) RpcLease(in *LeaseRequest, reply *LeaseReply) (err error) {
 		delayedUnmountLeaseReleaseFinishedWG sync.WaitGroup
 		delayedUnmountLeaseReleaseStartWG    sync.WaitGroup
 		delayedUnmountListElement            *list.Element
+		delayedUnmountMountIDAsString        MountIDAsString
+		delayedUnmountMountIDAsStringSlice   []MountIDAsString
 		inodeLease                           *inodeLeaseStruct
 		inodeLeaseFromLRU                    *inodeLeaseStruct
 		inodeLeaseLRUElement                 *list.Element
@@ -160,12 +162,16 @@ func (s *Server) RpcLease(in *LeaseRequest, reply *LeaseReply) (err error) {
 
 	delayedUnmountLeaseReleaseStartWG.Add(1)
 
+	delayedUnmountMountIDAsStringSlice = make([]MountIDAsString, 0, volume.delayedUnmountList.Len())
+
 	delayedUnmountListElement = volume.delayedUnmountList.Front()
 
 	for nil != delayedUnmountListElement {
 		delayedUnmount = delayedUnmountListElement.Value.(*mountStruct)
 		_ = volume.delayedUnmountList.Remove(delayedUnmountListElement)
 
+		delayedUnmountMountIDAsStringSlice = append(delayedUnmountMountIDAsStringSlice, delayedUnmount.mountIDAsString)
+
 		delayedUnmount.armReleaseOfAllLeasesWhileLocked(&delayedUnmountLeaseReleaseStartWG, &delayedUnmountLeaseReleaseFinishedWG)
 
 		delete(volume.mountMapByMountIDAsByteArray, delayedUnmount.mountIDAsByteArray)
@@ -182,6 +188,10 @@ func (s *Server) RpcLease(in *LeaseRequest, reply *LeaseReply) (err error) {
 	delayedUnmountLeaseReleaseStartWG.Done()
 	delayedUnmountLeaseReleaseFinishedWG.Wait()
 
+	for _, delayedUnmountMountIDAsString = range delayedUnmountMountIDAsStringSlice {
+		volume.volumeHandle.FlockReleaseAll(string(delayedUnmountMountIDAsString))
+	}
+
 	err = nil
 	return
 }
@@ -2101,6 +2111,47 @@ func fetchLeaseReport(volumeName string) (leaseReport []*LeaseReportElementStruc
 	return
 }
 
+func snapShotRollback(volumeName string, snapShotID uint64) (err error) {
+	var (
+		inodeLease *inodeLeaseStruct
+		ok         bool
+		volume     *volumeStruct
+	)
+
+	enterGate()
+	defer leaveGate()
+
+	// Holding globals.volumesLock for the duration blocks any RpcLease (or Unmount)
+	// from proceeding until the rollback has completed
+
+	globals.volumesLock.Lock()
+	defer globals.volumesLock.Unlock()
+
+	volume, ok = globals.volumeMap[volumeName]
+	if !ok {
+		err = fmt.Errorf("snapShotRollback(volumeName: \"%s\") could not find Volume", volumeName)
+		err = blunder.AddError(err, blunder.NotFoundError)
+		return
+	}
+
+	for _, inodeLease = range volume.inodeLeaseMap {
+		if (0 != inodeLease.sharedHoldersList.Len()) ||
+			(nil != inodeLease.promotingHolder) ||
+			(nil != inodeLease.exclusiveHolder) ||
+			(nil != inodeLease.demotingHolder) ||
+			(0 != inodeLease.releasingHoldersList.Len()) ||
+			(0 != inodeLease.requestedList.Len()) {
+			err = fmt.Errorf("snapShotRollback(volumeName: \"%s\") not allowed while Leases are held (e.g. on Inode 0x%016X)", volumeName, uint64(inodeLease.InodeNumber))
+			err = blunder.AddError(err, blunder.DevBusyError)
+			return
+		}
+	}
+
+	err = volume.volumeHandle.SnapShotRollback(snapShotID)
+
+	return
+}
+
 func (dummy *globalsStruct) DumpKey(key sortedmap.Key) (keyAsString string, err error) {
 	err = fmt.Errorf("Not implemented")
 	return
@@ -312,6 +312,13 @@ func (s *Server) RpcPutLocation(in *PutLocationReq, reply *PutLocationReply) (er
 		return err
 	}
 
+	// The middleware would write (unsealed) data directly to the provisioned Object
+
+	if volumeHandle.IsEncrypted() {
+		err = blunder.NewError(blunder.NotSupportedError, "RpcPutLocation() not supported on encrypted volume %s", volumeHandle.VolumeName())
+		return
+	}
+
 	// Via fs package, ask inode package to provision object
 	reply.PhysPath, err = volumeHandle.CallInodeToProvisionObject()
 
@@ -19,13 +19,15 @@ func retryRPCServerUp(jserver *Server) {
 
 	// Create a new RetryRPC Server.
 	retryConfig := &retryrpc.ServerConfig{
-		LongTrim:        globals.retryRPCTTLCompleted,
-		ShortTrim:       globals.retryRPCAckTrim,
-		DNSOrIPAddr:     globals.publicIPAddr,
-		Port:            int(globals.retryRPCPort),
-		DeadlineIO:      globals.retryRPCDeadlineIO,
-		KeepAlivePeriod: globals.retryRPCKeepAlivePeriod,
-		TLSCertificate:  globals.retryRPCCertificate,
+		LongTrim:          globals.retryRPCTTLCompleted,
+		ShortTrim:         globals.retryRPCAckTrim,
+		DNSOrIPAddr:       globals.publicIPAddr,
+		Port:              int(globals.retryRPCPort),
+		DeadlineIO:        globals.retryRPCDeadlineIO,
+		KeepAlivePeriod:   globals.retryRPCKeepAlivePeriod,
+		TLSCertificate:    globals.retryRPCCertificate,
+		MaxConcurrentRPCs: int(globals.retryRPCMaxConcurrent),
+		JournalPath:       globals.retryRPCJournalPath,
 	}
 
 	rrSvr := retryrpc.NewServer(retryConfig)
@@ -35,6 +35,7 @@ const testContainerName = "test_container"
 const testVerAccountName = testVer + testAccountName
 const testVerAccountContainerName = testVerAccountName + "/" + testContainerName
 const testAccountName2 = "AN_account2"
+const testAccountName3 = "AN_account3" // held by the posix backend
 
 const testRequireSlashesInPathsToProperlySort = false
 
@@ -235,7 +236,30 @@ func testSetup() []func() {
 		"Volume:SomeVolume2.InodeCacheEvictInterval=1s",
 		"Volume:SomeVolume2.ActiveLeaseEvictLowLimit=5000",
 		"Volume:SomeVolume2.ActiveLeaseEvictHighLimit=5010",
-		"VolumeGroup:JrpcfsTestVolumeGroup.VolumeList=SomeVolume,SomeVolume2",
+		"Volume:SomeVolume3.FSID=3",
+		"Volume:SomeVolume3.AccountName=" + testAccountName3,
+		"Volume:SomeVolume3.AutoFormat=true",
+		"Volume:SomeVolume3.ObjectStoreBackend=posix",
+		"Volume:SomeVolume3.PosixPath=" + tempDir,
+		"Volume:SomeVolume3.CheckpointContainerName=.__checkpoint__",
+		"Volume:SomeVolume3.CheckpointContainerStoragePolicy=gold",
+		"Volume:SomeVolume3.CheckpointInterval=10s",
+		"Volume:SomeVolume3.DefaultPhysicalContainerLayout=SomeContainerLayout2",
+		"Volume:SomeVolume3.MaxFlushSize=10027008",
+		"Volume:SomeVolume3.MaxFlushTime=2s",
+		"Volume:SomeVolume3.FileDefragmentChunkSize=10027008",
+		"Volume:SomeVolume3.FileDefragmentChunkDelay=2ms",
+		"Volume:SomeVolume3.NonceValuesToReserve=100",
+		"Volume:SomeVolume3.MaxEntriesPerDirNode=32",
+		"Volume:SomeVolume3.MaxExtentsPerFileNode=32",
+		"Volume:SomeVolume3.MaxInodesPerMetadataNode=32",
+		"Volume:SomeVolume3.MaxLogSegmentsPerMetadataNode=64",
+		"Volume:SomeVolume3.MaxDirFileNodesPerMetadataNode=16",
+		"Volume:SomeVolume3.MaxBytesInodeCache=100000",
+		"Volume:SomeVolume3.InodeCacheEvictInterval=1s",
+		"Volume:SomeVolume3.ActiveLeaseEvictLowLimit=5000",
+		"Volume:SomeVolume3.ActiveLeaseEvictHighLimit=5010",
+		"VolumeGroup:JrpcfsTestVolumeGroup.VolumeList=SomeVolume,SomeVolume2,SomeVolume3",
 		"VolumeGroup:JrpcfsTestVolumeGroup.VirtualIPAddr=",
 		"VolumeGroup:JrpcfsTestVolumeGroup.PrimaryPeer=Peer0",
 		"VolumeGroup:JrpcfsTestVolumeGroup.ReadCacheLineSize=1000000",
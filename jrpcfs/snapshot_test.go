@@ -0,0 +1,67 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package jrpcfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/inode"
+)
+
+// Test that SnapShotRollback() is refused while any Lease is held on the volume
+func TestSnapShotRollbackWithLeases(t *testing.T) {
+	assert := assert.New(t)
+
+	inodeVolumeHandle, err := inode.FetchVolumeHandle("SomeVolume")
+	if !assert.Nil(err) {
+		return
+	}
+
+	snapShotID, err := inodeVolumeHandle.SnapShotCreate("TestSnapShotRollbackWithLeases")
+	if !assert.Nil(err) {
+		return
+	}
+
+	mountByAccountNameReply := &MountByAccountNameReply{}
+	err = jserver.RpcMountByAccountName(testRpcLeaseLocalClientID, &MountByAccountNameRequest{AccountName: testAccountName}, mountByAccountNameReply)
+	if !assert.Nil(err) {
+		return
+	}
+
+	leaseRequest := &LeaseRequest{
+		InodeHandle: InodeHandle{
+			MountID:     mountByAccountNameReply.MountID,
+			InodeNumber: int64(inode.RootDirInodeNumber),
+		},
+		LeaseRequestType: LeaseRequestTypeShared,
+	}
+	leaseReply := &LeaseReply{}
+	err = jserver.RpcLease(leaseRequest, leaseReply)
+	assert.Nil(err)
+	assert.Equal(LeaseReplyTypeShared, leaseReply.LeaseReplyType)
+
+	err = SnapShotRollback("SomeVolume", snapShotID)
+	assert.True(blunder.Is(err, blunder.DevBusyError))
+
+	leaseRequest.LeaseRequestType = LeaseRequestTypeRelease
+	leaseReply = &LeaseReply{}
+	err = jserver.RpcLease(leaseRequest, leaseReply)
+	assert.Nil(err)
+	assert.Equal(LeaseReplyTypeReleased, leaseReply.LeaseReplyType)
+
+	err = SnapShotRollback("SomeVolume", snapShotID)
+	assert.Nil(err)
+
+	err = SnapShotRollback("NoSuchVolume", snapShotID)
+	assert.True(blunder.Is(err, blunder.NotFoundError))
+
+	err = jserver.RpcUnmount(&UnmountRequest{MountID: mountByAccountNameReply.MountID}, &Reply{})
+	assert.Nil(err)
+
+	err = inodeVolumeHandle.SnapShotDelete(snapShotID)
+	assert.Nil(err)
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package jrpcfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/fs"
+	"github.com/NVIDIA/proxyfs/inode"
+	"github.com/NVIDIA/proxyfs/retryrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// testStreamMount returns a retryrpc.Client on which testAccountName has been mounted
+func testStreamMount(t *testing.T) (retryRPCClient *retryrpc.Client, mountID MountIDAsString) {
+	assert := assert.New(t)
+
+	retryRPCClient, err := retryrpc.NewClient(&retryrpc.ClientConfig{
+		DNSOrIPAddr:              globals.publicIPAddr,
+		Port:                     int(globals.retryRPCPort),
+		RootCAx509CertificatePEM: testTLSCerts.caCertPEMBlock,
+		DeadlineIO:               60 * time.Second,
+		KeepAlivePeriod:          60 * time.Second,
+	})
+	if !assert.Nil(err) {
+		t.FailNow()
+	}
+
+	mountByAccountNameReply := &MountByAccountNameReply{}
+	err = retryRPCClient.Send("RpcMountByAccountName", &MountByAccountNameRequest{AccountName: testAccountName}, mountByAccountNameReply)
+	if !assert.Nil(err) {
+		t.FailNow()
+	}
+
+	mountID = mountByAccountNameReply.MountID
+	return
+}
+
+func testStreamUnmount(t *testing.T, retryRPCClient *retryrpc.Client, mountID MountIDAsString) {
+	assert := assert.New(t)
+
+	err := retryRPCClient.Send("RpcUnmount", &UnmountRequest{MountID: mountID}, &Reply{})
+	assert.Nil(err)
+
+	retryRPCClient.Close()
+}
+
+func TestRpcReaddirByLocStream(t *testing.T) {
+	assert := assert.New(t)
+
+	volumeHandle, err := fs.FetchVolumeHandleByVolumeName("SomeVolume")
+	if nil != err {
+		panic(fmt.Sprintf("failed to mount SomeVolume: %v", err))
+	}
+
+	dirInode := fsMkDir(volumeHandle, inode.RootDirInodeNumber, "TestRpcReaddirByLocStream")
+	for i := 0; i < 100; i++ {
+		_ = fsCreateFile(volumeHandle, dirInode, fmt.Sprintf("file%03d", i))
+	}
+
+	retryRPCClient, mountID := testStreamMount(t)
+	defer testStreamUnmount(t, retryRPCClient, mountID)
+
+	// readdirStream returns the DirEnts from each frame of RpcReaddirByLocStream
+	readdirStream := func(maxEntries uint64, prevDirEntLocation int64) (frames [][]DirEntry) {
+		stream, err := retryRPCClient.SendStream(context.Background(), "RpcReaddirByLocStream", &ReaddirByLocStreamRequest{
+			InodeHandle:        InodeHandle{MountID: mountID, InodeNumber: int64(dirInode)},
+			MaxEntries:         maxEntries,
+			MaxEntriesPerFrame: 7,
+			PrevDirEntLocation: prevDirEntLocation,
+		})
+		if !assert.Nil(err) {
+			return
+		}
+		for {
+			reply := &ReaddirReply{}
+			err = stream.Recv(reply)
+			if io.EOF == err {
+				return
+			}
+			if !assert.Nil(err) {
+				return
+			}
+			frames = append(frames, reply.DirEnts)
+		}
+	}
+
+	// All 102 entries (including "." and "..") in frames of no more than 7 entries each
+
+	frames := readdirStream(0, -1)
+	assert.Equal(15, len(frames))
+	location := int64(0)
+	for _, dirEnts := range frames {
+		assert.True(len(dirEnts) <= 7)
+		for _, dirEnt := range dirEnts {
+			assert.Equal(location+1, dirEnt.NextDirLocation)
+			if location >= 2 {
+				assert.Equal(fmt.Sprintf("file%03d", location-2), dirEnt.Basename)
+			}
+			location++
+		}
+	}
+	assert.Equal(int64(102), location)
+
+	// MaxEntries limits the total number of entries, not those in each frame
+
+	frames = readdirStream(50, 9)
+	location = 10
+	for _, dirEnts := range frames {
+		for _, dirEnt := range dirEnts {
+			assert.Equal(location+1, dirEnt.NextDirLocation)
+			location++
+		}
+	}
+	assert.Equal(int64(60), location)
+
+	// Starting past the end sends no frames at all
+
+	frames = readdirStream(0, 101)
+	assert.Equal(0, len(frames))
+
+	// The ReaddirPlus variant sends a StatStruct with each DirEntry
+
+	stream, err := retryRPCClient.SendStream(context.Background(), "RpcReaddirPlusByLocStream", &ReaddirPlusByLocStreamRequest{
+		InodeHandle:        InodeHandle{MountID: mountID, InodeNumber: int64(dirInode)},
+		MaxEntriesPerFrame: 40,
+		PrevDirEntLocation: -1,
+	})
+	assert.Nil(err)
+	numEntries := 0
+	for {
+		reply := &ReaddirPlusReply{}
+		err = stream.Recv(reply)
+		if io.EOF == err {
+			break
+		}
+		if !assert.Nil(err) {
+			break
+		}
+		assert.Equal(len(reply.DirEnts), len(reply.StatEnts))
+		numEntries += len(reply.DirEnts)
+	}
+	assert.Equal(102, numEntries)
+}
+
+func TestRpcFetchExtentMapChunkStream(t *testing.T) {
+	assert := assert.New(t)
+
+	volumeHandle, err := fs.FetchVolumeHandleByVolumeName("SomeVolume")
+	if nil != err {
+		panic(fmt.Sprintf("failed to mount SomeVolume: %v", err))
+	}
+
+	// Non-contiguous writes to create 20 extents
+
+	fileInode := fsCreateFile(volumeHandle, inode.RootDirInodeNumber, "TestRpcFetchExtentMapChunkStream")
+	for i := 0; i < 20; i++ {
+		_, err = volumeHandle.Write(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInode, uint64(i*10), []byte("x"), nil)
+		assert.Nil(err)
+	}
+	err = volumeHandle.Flush(inode.InodeRootUserID, inode.InodeGroupID(0), nil, fileInode)
+	assert.Nil(err)
+
+	retryRPCClient, mountID := testStreamMount(t)
+	defer testStreamUnmount(t, retryRPCClient, mountID)
+
+	stream, err := retryRPCClient.SendStream(context.Background(), "RpcFetchExtentMapChunkStream", &FetchExtentMapChunkStreamRequest{
+		InodeHandle:              InodeHandle{MountID: mountID, InodeNumber: int64(fileInode)},
+		FileOffset:               0,
+		MaxEntriesFromFileOffset: 100,
+		MaxEntriesPerFrame:       3,
+	})
+	assert.Nil(err)
+
+	numFrames := 0
+	fileOffset := uint64(0)
+	for {
+		reply := &FetchExtentMapChunkReply{}
+		err = stream.Recv(reply)
+		if io.EOF == err {
+			break
+		}
+		if !assert.Nil(err) {
+			break
+		}
+		assert.True(len(reply.ExtentMapEntry) <= 3)
+		assert.Equal(uint64(191), reply.FileSize)
+		for _, extentMapEntry := range reply.ExtentMapEntry {
+			assert.Equal(fileOffset, extentMapEntry.FileOffset)
+			assert.Equal(uint64(1), extentMapEntry.Length)
+			fileOffset += 10
+		}
+		numFrames++
+	}
+	assert.Equal(7, numFrames)
+	assert.Equal(uint64(200), fileOffset)
+}
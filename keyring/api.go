@@ -0,0 +1,149 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package keyring provides the AES-GCM encryption applied to the objects a volume
+// stores in Swift along with the pluggable Providers of the keys used to do so.
+//
+// Each sealed buffer is self-describing (little endian):
+//
+//   uint64   KeyID
+//   [12]byte Nonce
+//   []byte   Ciphertext (same length as the plaintext)
+//   [16]byte Tag
+//
+// New buffers are always sealed with the Provider's current key. Buffers sealed
+// with an earlier key remain readable so long as the Provider can still supply it.
+// Rotating a volume's key is therefore simply a matter of having the Provider
+// return a new (higher) current KeyID.
+//
+// Callers supply additionalData (typically identifying where the sealed buffer
+// is stored) that is authenticated along with the KeyID but not stored.
+package keyring
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/NVIDIA/proxyfs/conf"
+)
+
+const (
+	KeyIDSize = 8
+	NonceSize = 12
+	TagSize   = 16
+
+	Overhead = KeyIDSize + NonceSize + TagSize // Bytes added to each sealed buffer
+)
+
+const (
+	ProviderNone    = "none"
+	ProviderKeyFile = "keyfile"
+)
+
+// Provider supplies the keys for a volume. Each key is identified by a non-zero
+// KeyID and must be 16, 24, or 32 bytes long (selecting AES-128, AES-192, or AES-256).
+//
+type Provider interface {
+	CurrentKeyID() (keyID uint64)
+	FetchKey(keyID uint64) (key []byte, err error)
+	Reload() (err error) // Called upon SIGHUP to pick up a rotated key
+}
+
+// ProviderFactory is called to instantiate a Provider for volumeName. Any
+// Provider-specific settings are expected in the volume's section of confMap.
+//
+type ProviderFactory func(confMap conf.ConfMap, volumeName string) (provider Provider, err error)
+
+// KeyringStruct seals and opens buffers for a volume using the keys of its Provider.
+//
+type KeyringStruct struct {
+	sync.Mutex
+	volumeName string
+	provider   Provider
+	aeadMap    map[uint64]cipher.AEAD // key == KeyID
+}
+
+// RegisterProvider makes a Provider available to volumes configured with an
+// EncryptionKeyProvider of providerName. It is expected to be called from the
+// init() func of the package implementing the Provider.
+//
+func RegisterProvider(providerName string, providerFactory ProviderFactory) {
+	registerProvider(providerName, providerFactory)
+}
+
+// NewKeyring returns the KeyringStruct for volumeName as configured by its
+// EncryptionKeyProvider setting. If the volume is not encrypted, nil is returned.
+//
+func NewKeyring(confMap conf.ConfMap, volumeName string) (keyring *KeyringStruct, err error) {
+	keyring, err = newKeyring(confMap, volumeName)
+	return
+}
+
+// CurrentKeyID returns the KeyID that will be used to seal subsequent buffers.
+//
+func (keyring *KeyringStruct) CurrentKeyID() (keyID uint64) {
+	keyID = keyring.provider.CurrentKeyID()
+	return
+}
+
+// CheckKeyID ensures the key identified by keyID is available (e.g. to verify
+// a volume's CheckpointHeader references a key that can still be fetched).
+//
+func (keyring *KeyringStruct) CheckKeyID(keyID uint64) (err error) {
+	_, err = keyring.fetchAEAD(keyID)
+	return
+}
+
+// Seal returns plaintext encrypted with the current key.
+//
+func (keyring *KeyringStruct) Seal(plaintext []byte, additionalData []byte) (sealed []byte, err error) {
+	sealed, err = keyring.seal(plaintext, additionalData)
+	return
+}
+
+// Open returns the plaintext of a buffer previously returned by Seal() given
+// the same additionalData.
+//
+func (keyring *KeyringStruct) Open(sealed []byte, additionalData []byte) (plaintext []byte, err error) {
+	plaintext, err = keyring.open(sealed, additionalData)
+	return
+}
+
+// ObjectAdditionalData returns the additionalData used to seal the chunk of an
+// object (e.g. a LogSegment or checkpoint object) stored at objectOffset. Anything
+// (e.g. PFSAgent) sealing or opening a volume's objects must use it so that chunks
+// cannot be swapped undetected.
+//
+func ObjectAdditionalData(objectNumber uint64, objectOffset uint64) (additionalData []byte) {
+	additionalData = make([]byte, 16)
+	binary.LittleEndian.PutUint64(additionalData[0:], objectNumber)
+	binary.LittleEndian.PutUint64(additionalData[8:], objectOffset)
+	return
+}
+
+// SealedKeyID returns the KeyID of the key used to seal a buffer.
+//
+func SealedKeyID(sealed []byte) (keyID uint64) {
+	if KeyIDSize > len(sealed) {
+		keyID = 0
+	} else {
+		keyID = binary.LittleEndian.Uint64(sealed)
+	}
+	return
+}
+
+// Reload asks the Provider to refresh its keys (e.g. following a key rotation).
+//
+func (keyring *KeyringStruct) Reload() (err error) {
+	err = keyring.provider.Reload()
+	if nil != err {
+		err = fmt.Errorf("Volume %s EncryptionKeyProvider reload failed: %v", keyring.volumeName, err)
+		return
+	}
+
+	err = keyring.CheckKeyID(keyring.CurrentKeyID())
+
+	return
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/NVIDIA/proxyfs/conf"
+)
+
+type globalsStruct struct {
+	sync.Mutex
+	providerFactoryMap map[string]ProviderFactory // key == providerName
+}
+
+var globals globalsStruct
+
+func init() {
+	globals.providerFactoryMap = make(map[string]ProviderFactory)
+
+	registerProvider(ProviderKeyFile, newKeyFileProvider)
+}
+
+func registerProvider(providerName string, providerFactory ProviderFactory) {
+	globals.Lock()
+	globals.providerFactoryMap[providerName] = providerFactory
+	globals.Unlock()
+}
+
+func newKeyring(confMap conf.ConfMap, volumeName string) (keyring *KeyringStruct, err error) {
+	var (
+		ok              bool
+		provider        Provider
+		providerFactory ProviderFactory
+		providerName    string
+	)
+
+	providerName, err = confMap.FetchOptionValueString("Volume:"+volumeName, "EncryptionKeyProvider")
+	if nil != err {
+		providerName = ProviderNone // Current default
+	}
+
+	if ("" == providerName) || (ProviderNone == providerName) {
+		keyring = nil
+		err = nil
+		return
+	}
+
+	globals.Lock()
+	providerFactory, ok = globals.providerFactoryMap[providerName]
+	globals.Unlock()
+
+	if !ok {
+		err = fmt.Errorf("Volume %s EncryptionKeyProvider %s not registered", volumeName, providerName)
+		return
+	}
+
+	provider, err = providerFactory(confMap, volumeName)
+	if nil != err {
+		err = fmt.Errorf("Volume %s EncryptionKeyProvider %s failed: %v", volumeName, providerName, err)
+		return
+	}
+
+	keyring = &KeyringStruct{
+		volumeName: volumeName,
+		provider:   provider,
+		aeadMap:    make(map[uint64]cipher.AEAD),
+	}
+
+	// Ensure the current key is usable before anything is sealed with it
+
+	err = keyring.CheckKeyID(keyring.CurrentKeyID())
+	if nil != err {
+		keyring = nil
+		return
+	}
+
+	err = nil
+	return
+}
+
+func (keyring *KeyringStruct) fetchAEAD(keyID uint64) (aead cipher.AEAD, err error) {
+	var (
+		block cipher.Block
+		key   []byte
+		ok    bool
+	)
+
+	keyring.Lock()
+	aead, ok = keyring.aeadMap[keyID]
+	keyring.Unlock()
+
+	if ok {
+		err = nil
+		return
+	}
+
+	if 0 == keyID {
+		err = fmt.Errorf("Volume %s has no key with KeyID 0", keyring.volumeName)
+		return
+	}
+
+	key, err = keyring.provider.FetchKey(keyID)
+	if nil != err {
+		err = fmt.Errorf("Volume %s unable to fetch key with KeyID %d: %v", keyring.volumeName, keyID, err)
+		return
+	}
+
+	block, err = aes.NewCipher(key)
+	if nil != err {
+		err = fmt.Errorf("Volume %s key with KeyID %d unusable: %v", keyring.volumeName, keyID, err)
+		return
+	}
+
+	aead, err = cipher.NewGCM(block)
+	if nil != err {
+		err = fmt.Errorf("Volume %s key with KeyID %d unusable: %v", keyring.volumeName, keyID, err)
+		return
+	}
+
+	keyring.Lock()
+	keyring.aeadMap[keyID] = aead
+	keyring.Unlock()
+
+	err = nil
+	return
+}
+
+// authenticatedData prefixes the caller's additionalData with the KeyID so that
+// the KeyID stored in a sealed buffer cannot be altered undetected.
+func authenticatedData(sealedHeader []byte, additionalData []byte) (authenticated []byte) {
+	authenticated = make([]byte, 0, KeyIDSize+len(additionalData))
+	authenticated = append(authenticated, sealedHeader[:KeyIDSize]...)
+	authenticated = append(authenticated, additionalData...)
+	return
+}
+
+func (keyring *KeyringStruct) seal(plaintext []byte, additionalData []byte) (sealed []byte, err error) {
+	var (
+		aead  cipher.AEAD
+		keyID uint64
+	)
+
+	keyID = keyring.provider.CurrentKeyID()
+
+	aead, err = keyring.fetchAEAD(keyID)
+	if nil != err {
+		return
+	}
+
+	sealed = make([]byte, KeyIDSize+NonceSize, Overhead+len(plaintext))
+
+	binary.LittleEndian.PutUint64(sealed, keyID)
+
+	_, err = rand.Read(sealed[KeyIDSize:])
+	if nil != err {
+		err = fmt.Errorf("Volume %s unable to generate nonce: %v", keyring.volumeName, err)
+		return
+	}
+
+	sealed = aead.Seal(sealed, sealed[KeyIDSize:], plaintext, authenticatedData(sealed, additionalData))
+
+	err = nil
+	return
+}
+
+func (keyring *KeyringStruct) open(sealed []byte, additionalData []byte) (plaintext []byte, err error) {
+	var (
+		aead  cipher.AEAD
+		keyID uint64
+	)
+
+	if Overhead > len(sealed) {
+		err = fmt.Errorf("Volume %s sealed buffer too short (%d bytes)", keyring.volumeName, len(sealed))
+		return
+	}
+
+	keyID = binary.LittleEndian.Uint64(sealed)
+
+	aead, err = keyring.fetchAEAD(keyID)
+	if nil != err {
+		return
+	}
+
+	plaintext, err = aead.Open(nil, sealed[KeyIDSize:KeyIDSize+NonceSize], sealed[KeyIDSize+NonceSize:], authenticatedData(sealed, additionalData))
+	if nil != err {
+		err = fmt.Errorf("Volume %s sealed buffer (KeyID %d) failed authentication", keyring.volumeName, keyID)
+		return
+	}
+
+	err = nil
+	return
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package keyring
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/proxyfs/conf"
+)
+
+const (
+	testKey1 = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	testKey2 = "202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f"
+)
+
+func testWriteKeyFile(t *testing.T, keyFileName string, keyFileContents string) {
+	err := ioutil.WriteFile(keyFileName, []byte(keyFileContents), 0600)
+	if nil != err {
+		t.Fatalf("ioutil.WriteFile() failed: %v", err)
+	}
+}
+
+func TestKeyFile(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "keyring")
+	if nil != err {
+		t.Fatalf("ioutil.TempDir() failed: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	keyFileName := filepath.Join(testDir, "keys")
+
+	testWriteKeyFile(t, keyFileName, "# Test keys\n1 "+testKey1+"\n")
+
+	confMap, err := conf.MakeConfMapFromStrings([]string{
+		"Volume:TestVolume.EncryptionKeyProvider=keyfile",
+		"Volume:TestVolume.EncryptionKeyFile=" + keyFileName,
+		"Volume:PlainVolume.AccountName=AUTH_test",
+	})
+	if nil != err {
+		t.Fatalf("conf.MakeConfMapFromStrings() failed: %v", err)
+	}
+
+	plainKeyring, err := NewKeyring(confMap, "PlainVolume")
+	if nil != err {
+		t.Fatalf("NewKeyring(,\"PlainVolume\") failed: %v", err)
+	}
+	if nil != plainKeyring {
+		t.Fatalf("NewKeyring(,\"PlainVolume\") should have returned nil")
+	}
+
+	keyring, err := NewKeyring(confMap, "TestVolume")
+	if nil != err {
+		t.Fatalf("NewKeyring(,\"TestVolume\") failed: %v", err)
+	}
+	if 1 != keyring.CurrentKeyID() {
+		t.Fatalf("CurrentKeyID() should have returned 1 (got %d)", keyring.CurrentKeyID())
+	}
+
+	plaintext := []byte("Some plaintext")
+	additionalData := []byte("Object 1")
+
+	sealed1, err := keyring.Seal(plaintext, additionalData)
+	if nil != err {
+		t.Fatalf("Seal() failed: %v", err)
+	}
+	if (len(plaintext) + Overhead) != len(sealed1) {
+		t.Fatalf("Seal() returned %d bytes (expected %d)", len(sealed1), len(plaintext)+Overhead)
+	}
+	if bytes.Contains(sealed1, plaintext) {
+		t.Fatalf("Seal() returned buffer containing plaintext")
+	}
+
+	opened, err := keyring.Open(sealed1, additionalData)
+	if nil != err {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	if !bytes.Equal(plaintext, opened) {
+		t.Fatalf("Open() returned wrong plaintext")
+	}
+
+	_, err = keyring.Open(sealed1, []byte("Object 2"))
+	if nil == err {
+		t.Fatalf("Open() with wrong additionalData should have failed")
+	}
+
+	sealed1[len(sealed1)-1] ^= 0xFF
+	_, err = keyring.Open(sealed1, additionalData)
+	if nil == err {
+		t.Fatalf("Open() of tampered buffer should have failed")
+	}
+	sealed1[len(sealed1)-1] ^= 0xFF
+
+	// Rotate to a new key... buffers sealed with the prior key remain readable
+
+	testWriteKeyFile(t, keyFileName, "1 "+testKey1+"\n2 "+testKey2+"\n")
+
+	err = keyring.Reload()
+	if nil != err {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if 2 != keyring.CurrentKeyID() {
+		t.Fatalf("CurrentKeyID() should have returned 2 (got %d)", keyring.CurrentKeyID())
+	}
+
+	sealed2, err := keyring.Seal(plaintext, additionalData)
+	if nil != err {
+		t.Fatalf("Seal() failed: %v", err)
+	}
+
+	for _, sealed := range [][]byte{sealed1, sealed2} {
+		opened, err = keyring.Open(sealed, additionalData)
+		if nil != err {
+			t.Fatalf("Open() following rotation failed: %v", err)
+		}
+		if !bytes.Equal(plaintext, opened) {
+			t.Fatalf("Open() following rotation returned wrong plaintext")
+		}
+	}
+
+	err = keyring.CheckKeyID(3)
+	if nil == err {
+		t.Fatalf("CheckKeyID(3) should have failed")
+	}
+
+	// A malformed keyfile should be rejected leaving the prior keys in place
+
+	testWriteKeyFile(t, keyFileName, "1 "+testKey1+"\n1 "+testKey2+"\n")
+
+	err = keyring.Reload()
+	if nil == err {
+		t.Fatalf("Reload() of keyfile with duplicate KeyIDs should have failed")
+	}
+	if 2 != keyring.CurrentKeyID() {
+		t.Fatalf("CurrentKeyID() should have remained 2 (got %d)", keyring.CurrentKeyID())
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package keyring
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/NVIDIA/proxyfs/conf"
+)
+
+// The keyfile Provider reads a volume's keys from the local file named by the
+// volume's EncryptionKeyFile setting. Each non-blank line not starting with '#'
+// is of the form:
+//
+//   <KeyID> <Key>
+//
+// where KeyID is a non-zero decimal integer and Key is hex encoded. The key with
+// the highest KeyID is the current key. To rotate keys, append a line with a
+// higher KeyID and send SIGHUP. Earlier keys must be retained so long as objects
+// sealed with them remain.
+
+type keyFileProviderStruct struct {
+	sync.Mutex
+	keyFileName  string
+	currentKeyID uint64
+	keyMap       map[uint64][]byte // key == KeyID
+}
+
+func newKeyFileProvider(confMap conf.ConfMap, volumeName string) (provider Provider, err error) {
+	var (
+		keyFileProvider *keyFileProviderStruct
+	)
+
+	keyFileProvider = &keyFileProviderStruct{}
+
+	keyFileProvider.keyFileName, err = confMap.FetchOptionValueString("Volume:"+volumeName, "EncryptionKeyFile")
+	if nil != err {
+		return
+	}
+
+	err = keyFileProvider.Reload()
+	if nil != err {
+		return
+	}
+
+	provider = keyFileProvider
+
+	err = nil
+	return
+}
+
+func (keyFileProvider *keyFileProviderStruct) CurrentKeyID() (keyID uint64) {
+	keyFileProvider.Lock()
+	keyID = keyFileProvider.currentKeyID
+	keyFileProvider.Unlock()
+
+	return
+}
+
+func (keyFileProvider *keyFileProviderStruct) FetchKey(keyID uint64) (key []byte, err error) {
+	var (
+		ok bool
+	)
+
+	keyFileProvider.Lock()
+	key, ok = keyFileProvider.keyMap[keyID]
+	keyFileProvider.Unlock()
+
+	if ok {
+		err = nil
+	} else {
+		err = fmt.Errorf("KeyID %d not found in %s", keyID, keyFileProvider.keyFileName)
+	}
+
+	return
+}
+
+func (keyFileProvider *keyFileProviderStruct) Reload() (err error) {
+	var (
+		currentKeyID  uint64
+		fields        []string
+		key           []byte
+		keyFileBuf    []byte
+		keyFileLine   string
+		keyFileLineNo int
+		keyID         uint64
+		keyMap        map[uint64][]byte
+		ok            bool
+	)
+
+	keyFileBuf, err = ioutil.ReadFile(keyFileProvider.keyFileName)
+	if nil != err {
+		return
+	}
+
+	currentKeyID = 0
+	keyMap = make(map[uint64][]byte)
+
+	for keyFileLineNo, keyFileLine = range strings.Split(string(keyFileBuf), "\n") {
+		keyFileLine = strings.TrimSpace(keyFileLine)
+		if ("" == keyFileLine) || strings.HasPrefix(keyFileLine, "#") {
+			continue
+		}
+
+		fields = strings.Fields(keyFileLine)
+		if 2 != len(fields) {
+			err = fmt.Errorf("%s line %d malformed", keyFileProvider.keyFileName, keyFileLineNo+1)
+			return
+		}
+
+		keyID, err = strconv.ParseUint(fields[0], 10, 64)
+		if (nil != err) || (0 == keyID) {
+			err = fmt.Errorf("%s line %d has invalid KeyID", keyFileProvider.keyFileName, keyFileLineNo+1)
+			return
+		}
+
+		_, ok = keyMap[keyID]
+		if ok {
+			err = fmt.Errorf("%s line %d has duplicate KeyID %d", keyFileProvider.keyFileName, keyFileLineNo+1, keyID)
+			return
+		}
+
+		key, err = hex.DecodeString(fields[1])
+		if nil != err {
+			err = fmt.Errorf("%s line %d has invalid Key", keyFileProvider.keyFileName, keyFileLineNo+1)
+			return
+		}
+
+		keyMap[keyID] = key
+
+		if keyID > currentKeyID {
+			currentKeyID = keyID
+		}
+	}
+
+	if 0 == currentKeyID {
+		err = fmt.Errorf("%s contains no keys", keyFileProvider.keyFileName)
+		return
+	}
+
+	keyFileProvider.Lock()
+	keyFileProvider.currentKeyID = currentKeyID
+	keyFileProvider.keyMap = keyMap
+	keyFileProvider.Unlock()
+
+	err = nil
+	return
+}
@@ -448,8 +448,11 @@ func fetchCheckpointHeader() (checkpointHeader *headhunter.CheckpointHeaderStruc
 				log.Fatalf("error unmarshalling %s's Value (%s): %v", globals.checkpointEtcdKeyName, string(etcdGetResponse.Kvs[0].Value[:]), err)
 			}
 
-			if headhunter.CheckpointVersion3 != checkpointHeader.CheckpointVersion {
-				log.Fatalf("unsupported CheckpointVersion (%v)...must be == CheckpointVersion3 (%v)", checkpointHeader.CheckpointVersion, headhunter.CheckpointVersion3)
+			if (headhunter.CheckpointVersion3 != checkpointHeader.CheckpointVersion) && (headhunter.CheckpointVersion4 != checkpointHeader.CheckpointVersion) {
+				log.Fatalf("unsupported CheckpointVersion (%v)...must be == CheckpointVersion3 (%v) or CheckpointVersion4 (%v)", checkpointHeader.CheckpointVersion, headhunter.CheckpointVersion3, headhunter.CheckpointVersion4)
+			}
+			if 0 != checkpointHeader.EncryptionKeyID {
+				log.Fatalf("encrypted volumes not supported")
 			}
 
 			return
@@ -472,6 +475,9 @@ func fetchCheckpointHeader() (checkpointHeader *headhunter.CheckpointHeaderStruc
 	checkpointContainerHeaderString = checkpointContainerHeaderStringSlice[0]
 
 	checkpointContainerHeaderStringSplit = strings.Split(checkpointContainerHeaderString, " ")
+	if 5 == len(checkpointContainerHeaderStringSplit) {
+		log.Fatalf("encrypted volumes not supported")
+	}
 	if 4 != len(checkpointContainerHeaderStringSplit) {
 		log.Fatalf("checkpointContainerHeaderStringSplit must be four-valued")
 	}
@@ -480,8 +486,8 @@ func fetchCheckpointHeader() (checkpointHeader *headhunter.CheckpointHeaderStruc
 	if nil != err {
 		log.Fatalf("error parsing CheckpointVersion: %v", err)
 	}
-	if headhunter.CheckpointVersion3 != checkpointHeader.CheckpointVersion {
-		log.Fatalf("unsupported CheckpointVersion (%v)...must be == CheckpointVersion3 (%v)", checkpointHeader.CheckpointVersion, headhunter.CheckpointVersion3)
+	if (headhunter.CheckpointVersion3 != checkpointHeader.CheckpointVersion) && (headhunter.CheckpointVersion4 != checkpointHeader.CheckpointVersion) {
+		log.Fatalf("unsupported CheckpointVersion (%v)...must be == CheckpointVersion3 (%v) or CheckpointVersion4 (%v)", checkpointHeader.CheckpointVersion, headhunter.CheckpointVersion3, headhunter.CheckpointVersion4)
 	}
 
 	checkpointHeader.CheckpointObjectTrailerStructObjectNumber, err = strconv.ParseUint(checkpointContainerHeaderStringSplit[1], 16, 64)
@@ -680,6 +686,12 @@ func fetchSnapShotList(numElements uint64) (err error) {
 		if nil != err {
 			return
 		}
+		if headhunter.CheckpointVersion4 == globals.initialCheckpointHeader.CheckpointVersion {
+			elementOfSnapShotList.Pinned, err = consumeCheckpointObjectTrailerV3BufUint64()
+			if nil != err {
+				return
+			}
+		}
 
 		_, alreadySeenThisNonce = alreadySeenThisNonceSet[elementOfSnapShotList.Nonce]
 		if alreadySeenThisNonce {
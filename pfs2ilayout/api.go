@@ -0,0 +1,100 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pfs2ilayout converts a volume formatted by package headhunter (as served
+// by proxyfsd) into an equivalent volume in the format specified by package ilayout
+// (as served by imgr).
+//
+// Conversion is performed "online" from a SnapShot of the legacy volume taken at
+// the start of the conversion. As such, the legacy volume may continue to be
+// served while the conversion proceeds, but modifications made after the SnapShot
+// is taken will not be reflected in the converted volume.
+//
+// The converted volume is written to a Container in the same Swift Account as the
+// legacy volume. Every Inode (Directory, File, or SymLink) retains its InodeNumber
+// along with its Mode (protection bits only), UserID, GroupID, ModificationTime,
+// StatusChangeTime (from the legacy AttrChangeTime), and Streams. Legacy fields not
+// supported by ilayout (e.g. CreationTime, AccessTime, and ProjectID) are dropped.
+//
+// Where possible, the legacy LogSegment Objects holding File data are reused in
+// place. This is possible only when the LogSegment resides in the destination
+// Container and its Object Name maps (via ilayout.GetObjectNumberFromString) to
+// an Object Number at or above ReuseObjectNumberFloor (a range imgr will never
+// reach when allocating new Object Numbers). Each such LogSegment may be reused
+// by only a single Inode. All other LogSegments are copied. Data in compressed or
+// encrypted LogSegments is read via package inode and written uncompressed and
+// unencrypted into the Object holding the File's ExtentMap.
+//
+// As the converted volume may reference LogSegments of the legacy volume, the
+// SnapShot taken at the start of the conversion is retained such that the legacy
+// volume will not delete them. When the legacy volume is retired, care must be
+// taken not to delete the destination Container along with the legacy volume's
+// other Containers.
+//
+package pfs2ilayout
+
+import (
+	"github.com/NVIDIA/proxyfs/ilayout"
+)
+
+// ReuseObjectNumberFloor is the lowest Object Number that a reused LogSegment
+// may map to. As imgr allocates Object Numbers sequentially from the CheckPoint's
+// ReservedToNonce, it could only collide with a reused LogSegment after having
+// allocated this many Object Numbers.
+//
+const ReuseObjectNumberFloor uint64 = 1 << 60
+
+// ReportStruct summarizes the result of a call to Convert().
+//
+type ReportStruct struct {
+	SnapShotID           uint64 // SnapShot of the legacy volume from which the conversion was performed
+	SnapShotName         string //
+	DirInodeCount        uint64 //
+	FileInodeCount       uint64 //
+	SymLinkInodeCount    uint64 //
+	ReusedObjectCount    uint64 // Number of legacy LogSegment Objects referenced in place
+	ReusedObjectBytes    uint64 // Sum of sizes of legacy LogSegment Objects referenced in place
+	CopiedObjectCount    uint64 // Number of legacy LogSegment Objects copied
+	CopiedObjectBytes    uint64 // Sum of sizes of legacy LogSegment Objects copied
+	RewrittenExtentBytes uint64 // Bytes of compressed or encrypted File data read and rewritten
+	ReservedToNonce      uint64 // As recorded in the converted volume's CheckPoint
+}
+
+// Convert converts the legacy volumeName into dstContainerName (in the same
+// Swift Account) in the format specified by package ilayout. The legacy volume
+// must be served by this process (i.e. package transitions must have been
+// brought Up with volumeName in an active VolumeGroup). If dstContainerName does
+// not exist, it is created. If dstContainerName already holds an ilayout
+// CheckPoint, the conversion is not performed.
+//
+func Convert(volumeName string, dstContainerName string) (report *ReportStruct, err error) {
+	report, err = convert(volumeName, dstContainerName)
+	return
+}
+
+// Verify checks that the ilayout-formatted volume in dstContainerName matches
+// the SnapShot (identified by snapShotID) of the legacy volumeName from which
+// it was converted. If verifyFileData is true, the contents of every File are
+// also compared.
+//
+func Verify(volumeName string, dstContainerName string, snapShotID uint64, verifyFileData bool) (err error) {
+	err = verify(volumeName, dstContainerName, snapShotID, verifyFileData)
+	return
+}
+
+// FetchCheckPoint returns the CheckPoint of the ilayout-formatted volume in
+// dstContainerName of the Swift Account hosting the legacy volumeName.
+//
+func FetchCheckPoint(volumeName string, dstContainerName string) (checkPointV1 *ilayout.CheckPointV1Struct, err error) {
+	checkPointV1, err = fetchCheckPoint(volumeName, dstContainerName)
+	return
+}
+
+// ConvertVolume is the command line form of Convert() followed by Verify(). It
+// brings up the legacy stack with only volumeName (which must not be served by
+// any proxyfsd at the time) per confFile and confStrings (overrides).
+//
+func ConvertVolume(volumeName string, dstContainerName string, verifyFileData bool, confFile string, confStrings []string, execArgs []string) (report *ReportStruct, err error) {
+	report, err = convertVolume(volumeName, dstContainerName, verifyFileData, confFile, confStrings, execArgs)
+	return
+}
@@ -0,0 +1,361 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package pfs2ilayout
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/ilayout"
+	"github.com/NVIDIA/proxyfs/inode"
+	"github.com/NVIDIA/proxyfs/ramswift"
+	"github.com/NVIDIA/proxyfs/transitions"
+)
+
+var (
+	ramswiftDoneChan chan bool
+	testConfMap      conf.ConfMap
+)
+
+func testSetup(t *testing.T) {
+	var (
+		err                    error
+		signalHandlerIsArmedWG sync.WaitGroup
+		testConfStrings        []string
+		testDir                string
+	)
+
+	testDir, err = ioutil.TempDir(os.TempDir(), "ProxyFS_test_pfs2ilayout_")
+	if nil != err {
+		t.Fatalf("ioutil.TempDir() failed: %v", err)
+	}
+
+	err = os.Chdir(testDir)
+	if nil != err {
+		t.Fatalf("os.Chdir() failed: %v", err)
+	}
+
+	testConfStrings = []string{
+		"Stats.IPAddr=localhost",
+		"Stats.UDPPort=52284",
+		"Stats.BufferLength=100",
+		"Stats.MaxLatency=1s",
+		"Logging.LogFilePath=/dev/null",
+		"Logging.LogToConsole=false",
+		"SwiftClient.NoAuthIPAddr=127.0.0.1",
+		"SwiftClient.NoAuthTCPPort=45262",
+		"SwiftClient.Timeout=10s",
+		"SwiftClient.RetryLimit=3",
+		"SwiftClient.RetryLimitObject=3",
+		"SwiftClient.RetryDelay=10ms",
+		"SwiftClient.RetryDelayObject=10ms",
+		"SwiftClient.RetryExpBackoff=1.2",
+		"SwiftClient.RetryExpBackoffObject=1.0",
+		"SwiftClient.ChunkedConnectionPoolSize=64",
+		"SwiftClient.NonChunkedConnectionPoolSize=32",
+		"PhysicalContainerLayout:PhysicalContainerLayoutReplicated3Way.ContainerStoragePolicy=silver",
+		"PhysicalContainerLayout:PhysicalContainerLayoutReplicated3Way.ContainerNamePrefix=Replicated3Way_",
+		"PhysicalContainerLayout:PhysicalContainerLayoutReplicated3Way.ContainersPerPeer=10",
+		"PhysicalContainerLayout:PhysicalContainerLayoutReplicated3Way.MaxObjectsPerContainer=1000000",
+		"Peer:Peer0.PublicIPAddr=127.0.0.1",
+		"Peer:Peer0.PrivateIPAddr=127.0.0.1",
+		"Peer:Peer0.ReadCacheQuotaFraction=0.20",
+		"Cluster.Peers=Peer0",
+		"Cluster.WhoAmI=Peer0",
+		"Volume:TestVolume.FSID=1",
+		"Volume:TestVolume.AccountName=AUTH_test",
+		"Volume:TestVolume.AutoFormat=true",
+		"Volume:TestVolume.CheckpointContainerName=.__checkpoint__",
+		"Volume:TestVolume.CheckpointContainerStoragePolicy=gold",
+		"Volume:TestVolume.CheckpointInterval=10s",
+		"Volume:TestVolume.DefaultPhysicalContainerLayout=PhysicalContainerLayoutReplicated3Way",
+		"Volume:TestVolume.MaxFlushSize=10485760",
+		"Volume:TestVolume.MaxFlushTime=10s",
+		"Volume:TestVolume.FileDefragmentChunkSize=10485760",
+		"Volume:TestVolume.FileDefragmentChunkDelay=10ms",
+		"Volume:TestVolume.NonceValuesToReserve=100",
+		"Volume:TestVolume.MaxEntriesPerDirNode=32",
+		"Volume:TestVolume.MaxExtentsPerFileNode=32",
+		"Volume:TestVolume.MaxInodesPerMetadataNode=32",
+		"Volume:TestVolume.MaxLogSegmentsPerMetadataNode=64",
+		"Volume:TestVolume.MaxDirFileNodesPerMetadataNode=16",
+		"Volume:TestVolume.MaxBytesInodeCache=100000",
+		"Volume:TestVolume.InodeCacheEvictInterval=1s",
+		"Volume:TestVolume.ActiveLeaseEvictLowLimit=5000",
+		"Volume:TestVolume.ActiveLeaseEvictHighLimit=5010",
+		"VolumeGroup:TestVolumeGroup.VolumeList=TestVolume",
+		"VolumeGroup:TestVolumeGroup.VirtualIPAddr=",
+		"VolumeGroup:TestVolumeGroup.PrimaryPeer=Peer0",
+		"VolumeGroup:TestVolumeGroup.ReadCacheLineSize=1000000",
+		"VolumeGroup:TestVolumeGroup.ReadCacheWeight=100",
+		"FSGlobals.VolumeGroupList=TestVolumeGroup",
+		"FSGlobals.CheckpointHeaderConsensusAttempts=5",
+		"FSGlobals.MountRetryLimit=6",
+		"FSGlobals.MountRetryDelay=1s",
+		"FSGlobals.MountRetryExpBackoff=2",
+		"FSGlobals.LogCheckpointHeaderPosts=true",
+		"FSGlobals.TryLockBackoffMin=10ms",
+		"FSGlobals.TryLockBackoffMax=50ms",
+		"FSGlobals.TryLockSerializationThreshhold=5",
+		"FSGlobals.SymlinkMax=32",
+		"FSGlobals.CoalesceElementChunkSize=16",
+		"FSGlobals.InodeRecCacheEvictLowLimit=10000",
+		"FSGlobals.InodeRecCacheEvictHighLimit=10010",
+		"FSGlobals.LogSegmentRecCacheEvictLowLimit=10000",
+		"FSGlobals.LogSegmentRecCacheEvictHighLimit=10010",
+		"FSGlobals.BPlusTreeObjectCacheEvictLowLimit=10000",
+		"FSGlobals.BPlusTreeObjectCacheEvictHighLimit=10010",
+		"FSGlobals.DirEntryCacheEvictLowLimit=10000",
+		"FSGlobals.DirEntryCacheEvictHighLimit=10010",
+		"FSGlobals.FileExtentMapEvictLowLimit=10000",
+		"FSGlobals.FileExtentMapEvictHighLimit=10010",
+		"FSGlobals.EtcdEnabled=false",
+		"RamSwiftInfo.MaxAccountNameLength=256",
+		"RamSwiftInfo.MaxContainerNameLength=256",
+		"RamSwiftInfo.MaxObjectNameLength=1024",
+		"RamSwiftInfo.AccountListingLimit=10000",
+		"RamSwiftInfo.ContainerListingLimit=10000",
+	}
+
+	testConfMap, err = conf.MakeConfMapFromStrings(testConfStrings)
+	if nil != err {
+		t.Fatalf("conf.MakeConfMapFromStrings() failed: %v", err)
+	}
+
+	signalHandlerIsArmedWG.Add(1)
+	ramswiftDoneChan = make(chan bool, 1)
+	go ramswift.Daemon("/dev/null", testConfStrings, &signalHandlerIsArmedWG, ramswiftDoneChan, unix.SIGTERM)
+
+	signalHandlerIsArmedWG.Wait()
+
+	err = transitions.Up(testConfMap)
+	if nil != err {
+		t.Fatalf("transitions.Up() failed: %v", err)
+	}
+}
+
+func testTeardown(t *testing.T) {
+	var (
+		err     error
+		testDir string
+	)
+
+	err = transitions.Down(testConfMap)
+	if nil != err {
+		t.Fatalf("transitions.Down() failed: %v", err)
+	}
+
+	_ = syscall.Kill(syscall.Getpid(), unix.SIGTERM)
+	_ = <-ramswiftDoneChan
+
+	runtime.GC()
+
+	testDir, err = os.Getwd()
+	if nil != err {
+		t.Fatalf("os.Getwd() failed: %v", err)
+	}
+
+	err = os.Chdir("..")
+	if nil != err {
+		t.Fatalf("os.Chdir() failed: %v", err)
+	}
+
+	err = os.RemoveAll(testDir)
+	if nil != err {
+		t.Fatalf("os.RemoveAll() failed: %v", err)
+	}
+}
+
+func testWriteFile(t *testing.T, volumeHandle inode.VolumeHandle, dirInodeNumber inode.InodeNumber, basename string, offset uint64, buf []byte) (fileInodeNumber inode.InodeNumber) {
+	var (
+		err error
+	)
+
+	fileInodeNumber, err = volumeHandle.CreateFile(inode.PosixModePerm, 1, 2)
+	if nil != err {
+		t.Fatalf("CreateFile() failed: %v", err)
+	}
+
+	err = volumeHandle.Link(dirInodeNumber, basename, fileInodeNumber, false)
+	if nil != err {
+		t.Fatalf("Link(,\"%s\",,) failed: %v", basename, err)
+	}
+
+	err = volumeHandle.Write(fileInodeNumber, offset, buf, nil)
+	if nil != err {
+		t.Fatalf("Write() to \"%s\" failed: %v", basename, err)
+	}
+
+	err = volumeHandle.Flush(fileInodeNumber, false)
+	if nil != err {
+		t.Fatalf("Flush() of \"%s\" failed: %v", basename, err)
+	}
+
+	return
+}
+
+func TestConvert(t *testing.T) {
+	var (
+		checkPointV1        *ilayout.CheckPointV1Struct
+		dirInodeNumber      inode.InodeNumber
+		err                 error
+		expectedReuseCount  uint64
+		extentMapChunk      *inode.ExtentMapChunkStruct
+		extentMapEntry      inode.ExtentMapEntryStruct
+		file1InodeNumber    inode.InodeNumber
+		fileInodeNumber     inode.InodeNumber
+		logSegmentMap       map[string]bool
+		logSegmentContainer string
+		objectNumber        uint64
+		report              *ReportStruct
+		symlinkInodeNumber  inode.InodeNumber
+		volumeHandle        inode.VolumeHandle
+	)
+
+	testSetup(t)
+
+	volumeHandle, err = inode.FetchVolumeHandle("TestVolume")
+	if nil != err {
+		t.Fatalf("inode.FetchVolumeHandle() failed: %v", err)
+	}
+
+	// Populate legacy volume with a subdirectory, sparse file (with hard link & stream),
+	// symlink, and a few other files each in their own LogSegment
+
+	dirInodeNumber, err = volumeHandle.CreateDir(inode.PosixModePerm, 1, 2)
+	if nil != err {
+		t.Fatalf("CreateDir() failed: %v", err)
+	}
+
+	err = volumeHandle.Link(inode.RootDirInodeNumber, "dir", dirInodeNumber, false)
+	if nil != err {
+		t.Fatalf("Link(,\"dir\",,) failed: %v", err)
+	}
+
+	file1InodeNumber = testWriteFile(t, volumeHandle, dirInodeNumber, "file1", 0, []byte("Hello"))
+
+	err = volumeHandle.Write(file1InodeNumber, 1<<20, []byte("World"), nil)
+	if nil != err {
+		t.Fatalf("Write() to \"file1\" failed: %v", err)
+	}
+
+	err = volumeHandle.Flush(file1InodeNumber, false)
+	if nil != err {
+		t.Fatalf("Flush() of \"file1\" failed: %v", err)
+	}
+
+	err = volumeHandle.Link(inode.RootDirInodeNumber, "hardlink", file1InodeNumber, false)
+	if nil != err {
+		t.Fatalf("Link(,\"hardlink\",,) failed: %v", err)
+	}
+
+	err = volumeHandle.PutStream(file1InodeNumber, "stream", []byte{0x00, 0x01, 0x02})
+	if nil != err {
+		t.Fatalf("PutStream() failed: %v", err)
+	}
+
+	symlinkInodeNumber, err = volumeHandle.CreateSymlink("dir/file1", inode.PosixModePerm, 1, 2)
+	if nil != err {
+		t.Fatalf("CreateSymlink() failed: %v", err)
+	}
+
+	err = volumeHandle.Link(inode.RootDirInodeNumber, "symlink", symlinkInodeNumber, false)
+	if nil != err {
+		t.Fatalf("Link(,\"symlink\",,) failed: %v", err)
+	}
+
+	_ = testWriteFile(t, volumeHandle, inode.RootDirInodeNumber, "file2", 0, []byte("file2"))
+	_ = testWriteFile(t, volumeHandle, inode.RootDirInodeNumber, "file3", 3, []byte("file3"))
+	fileInodeNumber = testWriteFile(t, volumeHandle, dirInodeNumber, "file4", 0, make([]byte, 4096))
+
+	err = volumeHandle.SetSize(fileInodeNumber, 8192)
+	if nil != err {
+		t.Fatalf("SetSize() failed: %v", err)
+	}
+
+	// Convert to a fresh Container (where every LogSegment must be copied)
+
+	report, err = Convert("TestVolume", "TestContainer1")
+	if nil != err {
+		t.Fatalf("Convert(,\"TestContainer1\") failed: %v", err)
+	}
+
+	if (2 != report.DirInodeCount) || (4 != report.FileInodeCount) || (1 != report.SymLinkInodeCount) {
+		t.Fatalf("Convert(,\"TestContainer1\") returned unexpected Inode counts: %+v", report)
+	}
+	if (0 != report.ReusedObjectCount) || (0 == report.CopiedObjectCount) {
+		t.Fatalf("Convert(,\"TestContainer1\") should have only copied LogSegments: %+v", report)
+	}
+
+	err = Verify("TestVolume", "TestContainer1", report.SnapShotID, true)
+	if nil != err {
+		t.Fatalf("Verify(,\"TestContainer1\",,) failed: %v", err)
+	}
+
+	checkPointV1, err = FetchCheckPoint("TestVolume", "TestContainer1")
+	if nil != err {
+		t.Fatalf("FetchCheckPoint(,\"TestContainer1\") failed: %v", err)
+	}
+	if checkPointV1.ReservedToNonce != report.ReservedToNonce {
+		t.Fatalf("FetchCheckPoint(,\"TestContainer1\") returned unexpected ReservedToNonce")
+	}
+
+	// A second conversion to the same Container must fail
+
+	_, err = Convert("TestVolume", "TestContainer1")
+	if nil == err {
+		t.Fatalf("Convert(,\"TestContainer1\") should have failed the second time")
+	}
+
+	// Convert to the Container holding the LogSegments (where some may be reused)
+
+	logSegmentContainer = ""
+	logSegmentMap = make(map[string]bool)
+
+	extentMapChunk, err = volumeHandle.FetchExtentMapChunk(file1InodeNumber, 0, 64, 0)
+	if nil != err {
+		t.Fatalf("FetchExtentMapChunk() failed: %v", err)
+	}
+
+	for _, extentMapEntry = range extentMapChunk.ExtentMapEntry {
+		if "" == logSegmentContainer {
+			logSegmentContainer = extentMapEntry.ContainerName
+		}
+		if extentMapEntry.ContainerName == logSegmentContainer {
+			logSegmentMap[extentMapEntry.ObjectName] = true
+		}
+	}
+
+	report, err = Convert("TestVolume", logSegmentContainer)
+	if nil != err {
+		t.Fatalf("Convert(,\"%s\") failed: %v", logSegmentContainer, err)
+	}
+
+	expectedReuseCount = 0
+
+	for objectName := range logSegmentMap {
+		objectNumber, err = ilayout.GetObjectNumberFromString(objectName)
+		if (nil == err) && (ReuseObjectNumberFloor <= objectNumber) {
+			expectedReuseCount++
+		}
+	}
+
+	if (0 == expectedReuseCount) || (report.ReusedObjectCount < expectedReuseCount) {
+		t.Fatalf("Convert(,\"%s\") reused only %d LogSegments (expected at least %d)", logSegmentContainer, report.ReusedObjectCount, expectedReuseCount)
+	}
+
+	err = Verify("TestVolume", logSegmentContainer, report.SnapShotID, true)
+	if nil != err {
+		t.Fatalf("Verify(,\"%s\",,) failed: %v", logSegmentContainer, err)
+	}
+
+	testTeardown(t)
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package pfs2ilayout
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/ilayout"
+	"github.com/NVIDIA/proxyfs/swiftclient"
+)
+
+const (
+	bPlusTreeValueTypeDirectoryEntry = iota
+	bPlusTreeValueTypeExtentMapEntry
+	bPlusTreeValueTypeInodeTableEntry
+)
+
+// objectWriterStruct is used to append to an Object being written via a
+// ChunkedPutContext while tracking the offset of each append.
+//
+type objectWriterStruct struct {
+	objectNumber      uint64
+	chunkedPutContext swiftclient.ChunkedPutContext
+	bytesPut          uint64
+}
+
+// bPlusTreeCallbacksStruct provides the sortedmap.BPlusTreeCallbacks for each of
+// the Directory, ExtentMap, and InodeTable B+Trees. When writing, nodes are appended
+// to objectWriter. When reading, nodes are fetched from containerName.
+//
+type bPlusTreeCallbacksStruct struct {
+	accountName   string
+	containerName string
+	keyIsString   bool // If false, keys are uint64's
+	valueType     int  // One of bPlusTreeValueType*
+	objectWriter  *objectWriterStruct
+}
+
+func newObjectWriter(accountName string, containerName string, objectNumber uint64) (objectWriter *objectWriterStruct, err error) {
+	objectWriter = &objectWriterStruct{
+		objectNumber: objectNumber,
+		bytesPut:     0,
+	}
+
+	objectWriter.chunkedPutContext, err = swiftclient.ObjectFetchChunkedPutContext(accountName, containerName, ilayout.GetObjectNameAsString(objectNumber), "")
+
+	return
+}
+
+func (objectWriter *objectWriterStruct) write(buf []byte) (objectOffset uint64, err error) {
+	objectOffset = objectWriter.bytesPut
+
+	if 0 == len(buf) {
+		err = nil
+		return
+	}
+
+	err = objectWriter.chunkedPutContext.SendChunk(buf)
+	if nil != err {
+		return
+	}
+
+	objectWriter.bytesPut += uint64(len(buf))
+
+	return
+}
+
+func (objectWriter *objectWriterStruct) close() (err error) {
+	err = objectWriter.chunkedPutContext.Close()
+	return
+}
+
+func (bPlusTreeCallbacks *bPlusTreeCallbacksStruct) DumpKey(key sortedmap.Key) (keyAsString string, err error) {
+	if bPlusTreeCallbacks.keyIsString {
+		keyAsString = fmt.Sprintf("%s", key)
+	} else {
+		keyAsString = fmt.Sprintf("%016X", key)
+	}
+	err = nil
+	return
+}
+
+func (bPlusTreeCallbacks *bPlusTreeCallbacksStruct) DumpValue(value sortedmap.Value) (valueAsString string, err error) {
+	valueAsString = fmt.Sprintf("%+v", value)
+	err = nil
+	return
+}
+
+func (bPlusTreeCallbacks *bPlusTreeCallbacksStruct) GetNode(objectNumber uint64, objectOffset uint64, objectLength uint64) (nodeByteSlice []byte, err error) {
+	nodeByteSlice, err = swiftclient.ObjectGet(bPlusTreeCallbacks.accountName, bPlusTreeCallbacks.containerName, ilayout.GetObjectNameAsString(objectNumber), objectOffset, objectLength)
+	return
+}
+
+func (bPlusTreeCallbacks *bPlusTreeCallbacksStruct) PutNode(nodeByteSlice []byte) (objectNumber uint64, objectOffset uint64, err error) {
+	if nil == bPlusTreeCallbacks.objectWriter {
+		err = fmt.Errorf("(*bPlusTreeCallbacksStruct).PutNode() called on read-only B+Tree")
+		return
+	}
+
+	objectNumber = bPlusTreeCallbacks.objectWriter.objectNumber
+
+	objectOffset, err = bPlusTreeCallbacks.objectWriter.write(nodeByteSlice)
+
+	return
+}
+
+func (bPlusTreeCallbacks *bPlusTreeCallbacksStruct) DiscardNode(objectNumber uint64, objectOffset uint64, objectLength uint64) (err error) {
+	err = nil
+	return
+}
+
+func (bPlusTreeCallbacks *bPlusTreeCallbacksStruct) PackKey(key sortedmap.Key) (packedKey []byte, err error) {
+	var (
+		keyAsString string
+		keyAsUint64 uint64
+		nextPos     int
+		ok          bool
+	)
+
+	if bPlusTreeCallbacks.keyIsString {
+		keyAsString, ok = key.(string)
+		if !ok {
+			err = fmt.Errorf("(*bPlusTreeCallbacksStruct).PackKey(key:%v) called with non-string", key)
+			return
+		}
+
+		packedKey = make([]byte, 8+len(keyAsString))
+
+		nextPos, err = ilayout.PutLEStringToBuf(packedKey, 0, keyAsString)
+	} else {
+		keyAsUint64, ok = key.(uint64)
+		if !ok {
+			err = fmt.Errorf("(*bPlusTreeCallbacksStruct).PackKey(key:%v) called with non-uint64", key)
+			return
+		}
+
+		packedKey = make([]byte, 8)
+
+		nextPos, err = ilayout.PutLEUint64ToBuf(packedKey, 0, keyAsUint64)
+	}
+	if nil != err {
+		return
+	}
+
+	if len(packedKey) != nextPos {
+		err = fmt.Errorf("(*bPlusTreeCallbacksStruct).PackKey(key:%v) logic error", key)
+		return
+	}
+
+	err = nil
+	return
+}
+
+func (bPlusTreeCallbacks *bPlusTreeCallbacksStruct) UnpackKey(payloadData []byte) (key sortedmap.Key, bytesConsumed uint64, err error) {
+	var (
+		nextPos int
+	)
+
+	if bPlusTreeCallbacks.keyIsString {
+		key, nextPos, err = ilayout.GetLEStringFromBuf(payloadData, 0)
+	} else {
+		key, nextPos, err = ilayout.GetLEUint64FromBuf(payloadData, 0)
+	}
+	if nil != err {
+		return
+	}
+
+	bytesConsumed = uint64(nextPos)
+
+	err = nil
+	return
+}
+
+func (bPlusTreeCallbacks *bPlusTreeCallbacksStruct) PackValue(value sortedmap.Value) (packedValue []byte, err error) {
+	var (
+		ok                            bool
+		valueAsDirectoryEntryValueV1  ilayout.DirectoryEntryValueV1Struct
+		valueAsExtentMapEntryValueV1  ilayout.ExtentMapEntryValueV1Struct
+		valueAsInodeTableEntryValueV1 ilayout.InodeTableEntryValueV1Struct
+	)
+
+	switch bPlusTreeCallbacks.valueType {
+	case bPlusTreeValueTypeDirectoryEntry:
+		valueAsDirectoryEntryValueV1, ok = value.(ilayout.DirectoryEntryValueV1Struct)
+		if !ok {
+			err = fmt.Errorf("(*bPlusTreeCallbacksStruct).PackValue(value:%v) called with non-DirectoryEntryValueV1Struct", value)
+			return
+		}
+		packedValue, err = valueAsDirectoryEntryValueV1.MarshalDirectoryEntryValueV1()
+	case bPlusTreeValueTypeExtentMapEntry:
+		valueAsExtentMapEntryValueV1, ok = value.(ilayout.ExtentMapEntryValueV1Struct)
+		if !ok {
+			err = fmt.Errorf("(*bPlusTreeCallbacksStruct).PackValue(value:%v) called with non-ExtentMapEntryValueV1Struct", value)
+			return
+		}
+		packedValue, err = valueAsExtentMapEntryValueV1.MarshalExtentMapEntryValueV1()
+	case bPlusTreeValueTypeInodeTableEntry:
+		valueAsInodeTableEntryValueV1, ok = value.(ilayout.InodeTableEntryValueV1Struct)
+		if !ok {
+			err = fmt.Errorf("(*bPlusTreeCallbacksStruct).PackValue(value:%v) called with non-InodeTableEntryValueV1Struct", value)
+			return
+		}
+		packedValue, err = valueAsInodeTableEntryValueV1.MarshalInodeTableEntryValueV1()
+	default:
+		err = fmt.Errorf("(*bPlusTreeCallbacksStruct).PackValue() called with unknown valueType (%d)", bPlusTreeCallbacks.valueType)
+	}
+
+	return
+}
+
+func (bPlusTreeCallbacks *bPlusTreeCallbacksStruct) UnpackValue(payloadData []byte) (value sortedmap.Value, bytesConsumed uint64, err error) {
+	var (
+		bytesConsumedAsInt     int
+		directoryEntryValueV1  *ilayout.DirectoryEntryValueV1Struct
+		extentMapEntryValueV1  *ilayout.ExtentMapEntryValueV1Struct
+		inodeTableEntryValueV1 *ilayout.InodeTableEntryValueV1Struct
+	)
+
+	switch bPlusTreeCallbacks.valueType {
+	case bPlusTreeValueTypeDirectoryEntry:
+		directoryEntryValueV1, bytesConsumedAsInt, err = ilayout.UnmarshalDirectoryEntryValueV1(payloadData)
+		if nil == err {
+			value = *directoryEntryValueV1
+		}
+	case bPlusTreeValueTypeExtentMapEntry:
+		extentMapEntryValueV1, bytesConsumedAsInt, err = ilayout.UnmarshalExtentMapEntryValueV1(payloadData)
+		if nil == err {
+			value = *extentMapEntryValueV1
+		}
+	case bPlusTreeValueTypeInodeTableEntry:
+		inodeTableEntryValueV1, bytesConsumedAsInt, err = ilayout.UnmarshalInodeTableEntryValueV1(payloadData)
+		if nil == err {
+			value = *inodeTableEntryValueV1
+		}
+	default:
+		err = fmt.Errorf("(*bPlusTreeCallbacksStruct).UnpackValue() called with unknown valueType (%d)", bPlusTreeCallbacks.valueType)
+	}
+
+	bytesConsumed = uint64(bytesConsumedAsInt)
+
+	return
+}
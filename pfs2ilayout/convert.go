@@ -0,0 +1,906 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package pfs2ilayout
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/headhunter"
+	"github.com/NVIDIA/proxyfs/ilayout"
+	"github.com/NVIDIA/proxyfs/inode"
+	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/swiftclient"
+)
+
+const (
+	maxDirEntriesPerBPlusTreePage = 1024
+	maxExtentsPerBPlusTreePage    = 1024
+	maxInodesPerBPlusTreePage     = 2048
+
+	readDirMaxEntries        = 1024
+	extentMapChunkMaxEntries = 1024
+	objectCopyChunkSize      = 16 * 1024 * 1024
+	rewriteExtentChunkSize   = 1024 * 1024
+	snapShotNamePrefix       = "pfs2ilayout_"
+	snapShotNameTimeFormat   = "20060102T150405.000000000Z"
+	objectPathSeparator      = "/"
+	initialLinkTableCapacity = 1
+)
+
+type converterInodeStruct struct {
+	inodeType            uint8
+	parentDirInodeNumber uint64 // Only applicable to Dir Inodes
+	linkTable            []ilayout.InodeLinkTableEntryStruct
+}
+
+type converterStruct struct {
+	volumeName             string
+	accountName            string
+	dstContainerName       string
+	inodeVolumeHandle      inode.VolumeHandle
+	headhunterVolumeHandle headhunter.VolumeHandle
+	snapShotID             uint64
+	nextNonce              uint64                           // Next Object Number to be allocated
+	inodeMap               map[uint64]*converterInodeStruct // Key == ilayout InodeNumber (i.e. legacy InodeNumber's nonce)
+	reusedLogSegmentMap    map[string]uint64                // Key == "<containerName>/<objectName>"; Value == ilayout InodeNumber that reused it
+	inodeObjectCount       uint64
+	inodeObjectSize        uint64
+	inodeBytesReferenced   uint64
+	report                 *ReportStruct
+}
+
+func convert(volumeName string, dstContainerName string) (report *ReportStruct, err error) {
+	var (
+		checkPointV1           *ilayout.CheckPointV1Struct
+		checkPointV1String     string
+		checkPointWriter       *objectWriterStruct
+		converter              *converterStruct
+		inodeNumber            uint64
+		inodeNumberSlice       []uint64
+		inodeTable             sortedmap.BPlusTree
+		inodeTableCallbacks    *bPlusTreeCallbacksStruct
+		inodeTableEntryValueV1 ilayout.InodeTableEntryValueV1Struct
+		ok                     bool
+		snapShotCreated        bool
+		superBlockV1           *ilayout.SuperBlockV1Struct
+		superBlockV1Buf        []byte
+		superBlockWriter       *objectWriterStruct
+	)
+
+	converter = &converterStruct{
+		volumeName:          volumeName,
+		dstContainerName:    dstContainerName,
+		inodeMap:            make(map[uint64]*converterInodeStruct),
+		reusedLogSegmentMap: make(map[string]uint64),
+		report:              &ReportStruct{},
+	}
+
+	converter.inodeVolumeHandle, err = inode.FetchVolumeHandle(volumeName)
+	if nil != err {
+		return
+	}
+
+	converter.headhunterVolumeHandle, err = headhunter.FetchVolumeHandle(volumeName)
+	if nil != err {
+		return
+	}
+
+	converter.accountName, _ = converter.headhunterVolumeHandle.FetchAccountAndCheckpointContainerNames()
+
+	// Ensure dstContainerName exists and does not already hold a converted volume
+
+	err = converter.prepareDstContainer()
+	if nil != err {
+		return
+	}
+
+	// Take the SnapShot from which the conversion will be performed (retained only upon success)
+
+	converter.report.SnapShotName = snapShotNamePrefix + time.Now().UTC().Format(snapShotNameTimeFormat)
+
+	converter.snapShotID, err = converter.inodeVolumeHandle.SnapShotCreate(converter.report.SnapShotName)
+	if nil != err {
+		err = fmt.Errorf("SnapShotCreate(\"%s\") of %s failed: %v", converter.report.SnapShotName, volumeName, err)
+		return
+	}
+
+	snapShotCreated = true
+
+	defer func() {
+		if snapShotCreated && (nil != err) {
+			_ = converter.inodeVolumeHandle.SnapShotDelete(converter.snapShotID)
+		}
+	}()
+
+	converter.report.SnapShotID = converter.snapShotID
+
+	// All Object Numbers allocated by the conversion lie beyond any legacy nonce (e.g. InodeNumber)
+
+	converter.nextNonce = converter.headhunterVolumeHandle.FetchNonce()
+
+	logger.Infof("pfs2ilayout converting %s (SnapShot %s) to %s/%s", volumeName, converter.report.SnapShotName, converter.accountName, dstContainerName)
+
+	// Discover all Inodes (and their LinkTables) reachable in the SnapShot
+
+	err = converter.discoverInodes()
+	if nil != err {
+		return
+	}
+
+	// Convert each Inode (in InodeNumber order) populating the InodeTable
+
+	inodeTableCallbacks = &bPlusTreeCallbacksStruct{
+		accountName:   converter.accountName,
+		containerName: dstContainerName,
+		keyIsString:   false,
+		valueType:     bPlusTreeValueTypeInodeTableEntry,
+		objectWriter:  nil, // Set once the SuperBlock's Object is to be written
+	}
+
+	inodeTable = sortedmap.NewBPlusTree(
+		maxInodesPerBPlusTreePage,
+		sortedmap.CompareUint64,
+		inodeTableCallbacks,
+		nil)
+
+	inodeNumberSlice = make([]uint64, 0, len(converter.inodeMap))
+	for inodeNumber = range converter.inodeMap {
+		inodeNumberSlice = append(inodeNumberSlice, inodeNumber)
+	}
+	sort.Slice(inodeNumberSlice, func(i, j int) bool { return inodeNumberSlice[i] < inodeNumberSlice[j] })
+
+	for _, inodeNumber = range inodeNumberSlice {
+		inodeTableEntryValueV1, err = converter.convertInode(inodeNumber)
+		if nil != err {
+			err = fmt.Errorf("conversion of Inode %016X failed: %v", inodeNumber, err)
+			return
+		}
+
+		ok, err = inodeTable.Put(inodeNumber, inodeTableEntryValueV1)
+		if nil != err {
+			return
+		}
+		if !ok {
+			err = fmt.Errorf("inodeTable.Put(%016X,) returned !ok", inodeNumber)
+			return
+		}
+	}
+
+	// Create SuperBlock
+
+	superBlockWriter, err = newObjectWriter(converter.accountName, dstContainerName, converter.fetchNonce())
+	if nil != err {
+		return
+	}
+
+	inodeTableCallbacks.objectWriter = superBlockWriter
+
+	superBlockV1 = &ilayout.SuperBlockV1Struct{
+		InodeObjectCount:               converter.inodeObjectCount,
+		InodeObjectSize:                converter.inodeObjectSize,
+		InodeBytesReferenced:           converter.inodeBytesReferenced,
+		PendingDeleteObjectNumberArray: []uint64{},
+	}
+
+	superBlockV1.InodeTableRootObjectNumber, superBlockV1.InodeTableRootObjectOffset, superBlockV1.InodeTableRootObjectLength, err = inodeTable.Flush(false)
+	if nil != err {
+		_ = superBlockWriter.close()
+		return
+	}
+
+	superBlockV1.InodeTableLayout = []ilayout.InodeTableLayoutEntryV1Struct{
+		{
+			ObjectNumber:    superBlockWriter.objectNumber,
+			ObjectSize:      superBlockWriter.bytesPut,
+			BytesReferenced: superBlockWriter.bytesPut,
+		},
+	}
+
+	superBlockV1Buf, err = superBlockV1.MarshalSuperBlockV1()
+	if nil != err {
+		_ = superBlockWriter.close()
+		return
+	}
+
+	_, err = superBlockWriter.write(superBlockV1Buf)
+	if nil != err {
+		_ = superBlockWriter.close()
+		return
+	}
+
+	err = superBlockWriter.close()
+	if nil != err {
+		return
+	}
+
+	// Create CheckPoint (last... so that a failed conversion leaves no CheckPoint behind)
+
+	converter.report.ReservedToNonce = superBlockWriter.objectNumber
+
+	checkPointV1 = &ilayout.CheckPointV1Struct{
+		Version:                ilayout.CheckPointVersionV1,
+		SuperBlockObjectNumber: superBlockWriter.objectNumber,
+		SuperBlockLength:       uint64(len(superBlockV1Buf)),
+		ReservedToNonce:        converter.report.ReservedToNonce,
+	}
+
+	checkPointV1String, err = checkPointV1.MarshalCheckPointV1()
+	if nil != err {
+		return
+	}
+
+	checkPointWriter, err = newObjectWriter(converter.accountName, dstContainerName, ilayout.CheckPointObjectNumber)
+	if nil != err {
+		return
+	}
+
+	_, err = checkPointWriter.write([]byte(checkPointV1String))
+	if nil != err {
+		_ = checkPointWriter.close()
+		return
+	}
+
+	err = checkPointWriter.close()
+	if nil != err {
+		return
+	}
+
+	logger.Infof("pfs2ilayout converted %s: %+v", volumeName, *converter.report)
+
+	report = converter.report
+
+	err = nil
+	return
+}
+
+func (converter *converterStruct) fetchNonce() (nonce uint64) {
+	nonce = converter.nextNonce
+	converter.nextNonce++
+	return
+}
+
+func (converter *converterStruct) snapShotInodeNumber(inodeNumber uint64) (snapShotInodeNumber inode.InodeNumber) {
+	snapShotInodeNumber = inode.InodeNumber(converter.headhunterVolumeHandle.SnapShotIDAndNonceEncode(converter.snapShotID, inodeNumber))
+	return
+}
+
+func (converter *converterStruct) prepareDstContainer() (err error) {
+	_, err = swiftclient.ContainerHead(converter.accountName, converter.dstContainerName)
+	if nil != err {
+		if http.StatusNotFound != blunder.HTTPCode(err) {
+			err = fmt.Errorf("failed to HEAD %s/%s: %v", converter.accountName, converter.dstContainerName, err)
+			return
+		}
+
+		err = swiftclient.ContainerPut(converter.accountName, converter.dstContainerName, make(map[string][]string))
+		if nil != err {
+			err = fmt.Errorf("failed to PUT %s/%s: %v", converter.accountName, converter.dstContainerName, err)
+			return
+		}
+	}
+
+	_, err = swiftclient.ObjectHead(converter.accountName, converter.dstContainerName, ilayout.GetObjectNameAsString(ilayout.CheckPointObjectNumber))
+	if nil == err {
+		err = fmt.Errorf("%s/%s already contains a CheckPoint", converter.accountName, converter.dstContainerName)
+		return
+	}
+	if http.StatusNotFound != blunder.HTTPCode(err) {
+		err = fmt.Errorf("failed to HEAD %s/%s CheckPoint: %v", converter.accountName, converter.dstContainerName, err)
+		return
+	}
+
+	err = nil
+	return
+}
+
+func convertInodeType(inodeType inode.InodeType) (ilayoutInodeType uint8, err error) {
+	switch inodeType {
+	case inode.DirType:
+		ilayoutInodeType = ilayout.InodeTypeDir
+	case inode.FileType:
+		ilayoutInodeType = ilayout.InodeTypeFile
+	case inode.SymlinkType:
+		ilayoutInodeType = ilayout.InodeTypeSymLink
+	default:
+		err = fmt.Errorf("unsupported InodeType (%v)", inodeType)
+		return
+	}
+
+	err = nil
+	return
+}
+
+// readDir returns the entries (other than "." and ".."), with their Type, of the SnapShot view of dirInodeNumber.
+//
+func (converter *converterStruct) readDir(dirInodeNumber uint64) (dirEntrySlice []inode.DirEntry, err error) {
+	var (
+		dirEntry         inode.DirEntry
+		dirEntrySliceTmp []inode.DirEntry
+		moreEntries      bool
+	)
+
+	dirEntrySlice = make([]inode.DirEntry, 0)
+
+	dirEntrySliceTmp, moreEntries, err = converter.inodeVolumeHandle.ReadDir(converter.snapShotInodeNumber(dirInodeNumber), readDirMaxEntries, 0)
+
+	for {
+		if nil != err {
+			return
+		}
+
+		for _, dirEntry = range dirEntrySliceTmp {
+			if ("." == dirEntry.Basename) || (".." == dirEntry.Basename) {
+				continue
+			}
+			if (ilayout.RootDirInodeNumber == dirInodeNumber) && (inode.SnapShotDirName == dirEntry.Basename) {
+				continue
+			}
+
+			// inode.ReadDir() does not fill in Type
+
+			dirEntry.Type, err = converter.inodeVolumeHandle.GetType(dirEntry.InodeNumber)
+			if nil != err {
+				return
+			}
+
+			dirEntrySlice = append(dirEntrySlice, dirEntry)
+		}
+
+		if !moreEntries || (0 == len(dirEntrySliceTmp)) {
+			break
+		}
+
+		dirEntrySliceTmp, moreEntries, err = converter.inodeVolumeHandle.ReadDir(converter.snapShotInodeNumber(dirInodeNumber), readDirMaxEntries, 0, dirEntrySliceTmp[len(dirEntrySliceTmp)-1].Basename)
+	}
+
+	err = nil
+	return
+}
+
+// discoverInodes walks the SnapShot's directory tree recording each Inode's type and LinkTable.
+//
+func (converter *converterStruct) discoverInodes() (err error) {
+	var (
+		childInode       *converterInodeStruct
+		childInodeNumber uint64
+		childInodeType   uint8
+		dirEntry         inode.DirEntry
+		dirEntrySlice    []inode.DirEntry
+		dirInode         *converterInodeStruct
+		dirInodeNumber   uint64
+		dirQueue         []uint64
+		ok               bool
+	)
+
+	converter.inodeMap[ilayout.RootDirInodeNumber] = &converterInodeStruct{
+		inodeType:            ilayout.InodeTypeDir,
+		parentDirInodeNumber: ilayout.RootDirInodeNumber,
+		linkTable: []ilayout.InodeLinkTableEntryStruct{
+			{
+				ParentDirInodeNumber: ilayout.RootDirInodeNumber,
+				ParentDirEntryName:   ".",
+			},
+			{
+				ParentDirInodeNumber: ilayout.RootDirInodeNumber,
+				ParentDirEntryName:   "..",
+			},
+		},
+	}
+
+	dirQueue = []uint64{ilayout.RootDirInodeNumber}
+
+	for 0 < len(dirQueue) {
+		dirInodeNumber = dirQueue[0]
+		dirQueue = dirQueue[1:]
+
+		dirInode = converter.inodeMap[dirInodeNumber]
+
+		dirEntrySlice, err = converter.readDir(dirInodeNumber)
+		if nil != err {
+			err = fmt.Errorf("ReadDir() of Inode %016X failed: %v", dirInodeNumber, err)
+			return
+		}
+
+		for _, dirEntry = range dirEntrySlice {
+			_, _, childInodeNumber = converter.headhunterVolumeHandle.SnapShotU64Decode(uint64(dirEntry.InodeNumber))
+
+			childInode, ok = converter.inodeMap[childInodeNumber]
+			if !ok {
+				childInodeType, err = convertInodeType(dirEntry.Type)
+				if nil != err {
+					err = fmt.Errorf("Inode %016X: %v", childInodeNumber, err)
+					return
+				}
+
+				childInode = &converterInodeStruct{
+					inodeType: childInodeType,
+					linkTable: make([]ilayout.InodeLinkTableEntryStruct, 0, initialLinkTableCapacity),
+				}
+
+				converter.inodeMap[childInodeNumber] = childInode
+
+				if ilayout.InodeTypeDir == childInodeType {
+					childInode.parentDirInodeNumber = dirInodeNumber
+					childInode.linkTable = append(childInode.linkTable, ilayout.InodeLinkTableEntryStruct{
+						ParentDirInodeNumber: childInodeNumber,
+						ParentDirEntryName:   ".",
+					})
+					dirInode.linkTable = append(dirInode.linkTable, ilayout.InodeLinkTableEntryStruct{
+						ParentDirInodeNumber: childInodeNumber,
+						ParentDirEntryName:   "..",
+					})
+
+					dirQueue = append(dirQueue, childInodeNumber)
+				}
+			} else if ilayout.InodeTypeDir == childInode.inodeType {
+				err = fmt.Errorf("Dir Inode %016X found in more than one directory", childInodeNumber)
+				return
+			}
+
+			childInode.linkTable = append(childInode.linkTable, ilayout.InodeLinkTableEntryStruct{
+				ParentDirInodeNumber: dirInodeNumber,
+				ParentDirEntryName:   dirEntry.Basename,
+			})
+		}
+	}
+
+	err = nil
+	return
+}
+
+// convertInode writes the ilayout equivalent of the SnapShot view of inodeNumber
+// returning the InodeTable entry locating its InodeHead.
+//
+func (converter *converterStruct) convertInode(inodeNumber uint64) (inodeTableEntryValueV1 ilayout.InodeTableEntryValueV1Struct, err error) {
+	var (
+		converterInode      *converterInodeStruct
+		inodeHeadLayout     ilayout.InodeHeadLayoutEntryV1Struct
+		inodeHeadV1         *ilayout.InodeHeadV1Struct
+		inodeHeadV1Buf      []byte
+		inodeStreamName     string
+		inodeStreamValue    []byte
+		metadata            *inode.MetadataStruct
+		objectWriter        *objectWriterStruct
+		snapShotInodeNumber inode.InodeNumber
+	)
+
+	converterInode = converter.inodeMap[inodeNumber]
+	snapShotInodeNumber = converter.snapShotInodeNumber(inodeNumber)
+
+	metadata, err = converter.inodeVolumeHandle.GetMetadata(snapShotInodeNumber)
+	if nil != err {
+		return
+	}
+
+	inodeHeadV1 = &ilayout.InodeHeadV1Struct{
+		InodeNumber:      inodeNumber,
+		InodeType:        converterInode.inodeType,
+		LinkTable:        converterInode.linkTable,
+		Size:             0,
+		ModificationTime: metadata.ModificationTime,
+		StatusChangeTime: metadata.AttrChangeTime,
+		Mode:             uint16(metadata.Mode) & ilayout.InodeModeMask,
+		UserID:           uint64(metadata.UserID),
+		GroupID:          uint64(metadata.GroupID),
+		StreamTable:      make([]ilayout.InodeStreamTableEntryStruct, 0, len(metadata.InodeStreamNameSlice)),
+		SymLinkTarget:    "",
+		Layout:           []ilayout.InodeHeadLayoutEntryV1Struct{},
+	}
+
+	for _, inodeStreamName = range metadata.InodeStreamNameSlice {
+		inodeStreamValue, err = converter.inodeVolumeHandle.GetStream(snapShotInodeNumber, inodeStreamName)
+		if nil != err {
+			return
+		}
+
+		inodeHeadV1.StreamTable = append(inodeHeadV1.StreamTable, ilayout.InodeStreamTableEntryStruct{
+			Name:  inodeStreamName,
+			Value: inodeStreamValue,
+		})
+	}
+
+	objectWriter, err = newObjectWriter(converter.accountName, converter.dstContainerName, converter.fetchNonce())
+	if nil != err {
+		return
+	}
+
+	switch converterInode.inodeType {
+	case ilayout.InodeTypeDir:
+		converter.report.DirInodeCount++
+		err = converter.convertDirInode(inodeNumber, converterInode, objectWriter, inodeHeadV1)
+	case ilayout.InodeTypeFile:
+		converter.report.FileInodeCount++
+		inodeHeadV1.Size = metadata.Size
+		err = converter.convertFileInode(inodeNumber, metadata.Size, objectWriter, inodeHeadV1)
+	case ilayout.InodeTypeSymLink:
+		converter.report.SymLinkInodeCount++
+		inodeHeadV1.SymLinkTarget, err = converter.inodeVolumeHandle.GetSymlink(snapShotInodeNumber)
+	}
+	if nil != err {
+		_ = objectWriter.close()
+		return
+	}
+
+	for _, inodeHeadLayout = range inodeHeadV1.Layout {
+		converter.inodeObjectCount++
+		converter.inodeObjectSize += inodeHeadLayout.ObjectSize
+		converter.inodeBytesReferenced += inodeHeadLayout.BytesReferenced
+	}
+
+	inodeHeadV1Buf, err = inodeHeadV1.MarshalInodeHeadV1()
+	if nil != err {
+		_ = objectWriter.close()
+		return
+	}
+
+	_, err = objectWriter.write(inodeHeadV1Buf)
+	if nil != err {
+		_ = objectWriter.close()
+		return
+	}
+
+	err = objectWriter.close()
+	if nil != err {
+		return
+	}
+
+	inodeTableEntryValueV1 = ilayout.InodeTableEntryValueV1Struct{
+		InodeHeadObjectNumber: objectWriter.objectNumber,
+		InodeHeadLength:       uint64(len(inodeHeadV1Buf)),
+	}
+
+	err = nil
+	return
+}
+
+func (converter *converterStruct) convertDirInode(dirInodeNumber uint64, converterInode *converterInodeStruct, objectWriter *objectWriterStruct, inodeHeadV1 *ilayout.InodeHeadV1Struct) (err error) {
+	var (
+		childInodeNumber uint64
+		childInodeType   uint8
+		dirEntry         inode.DirEntry
+		dirEntrySlice    []inode.DirEntry
+		directory        sortedmap.BPlusTree
+		ok               bool
+	)
+
+	directory = sortedmap.NewBPlusTree(
+		maxDirEntriesPerBPlusTreePage,
+		sortedmap.CompareString,
+		&bPlusTreeCallbacksStruct{
+			accountName:   converter.accountName,
+			containerName: converter.dstContainerName,
+			keyIsString:   true,
+			valueType:     bPlusTreeValueTypeDirectoryEntry,
+			objectWriter:  objectWriter,
+		},
+		nil)
+
+	ok, err = directory.Put(".", ilayout.DirectoryEntryValueV1Struct{
+		InodeNumber: dirInodeNumber,
+		InodeType:   ilayout.InodeTypeDir,
+	})
+	if (nil == err) && !ok {
+		err = fmt.Errorf("directory.Put(\".\",) returned !ok")
+	}
+	if nil != err {
+		return
+	}
+
+	ok, err = directory.Put("..", ilayout.DirectoryEntryValueV1Struct{
+		InodeNumber: converterInode.parentDirInodeNumber,
+		InodeType:   ilayout.InodeTypeDir,
+	})
+	if (nil == err) && !ok {
+		err = fmt.Errorf("directory.Put(\"..\",) returned !ok")
+	}
+	if nil != err {
+		return
+	}
+
+	dirEntrySlice, err = converter.readDir(dirInodeNumber)
+	if nil != err {
+		return
+	}
+
+	for _, dirEntry = range dirEntrySlice {
+		_, _, childInodeNumber = converter.headhunterVolumeHandle.SnapShotU64Decode(uint64(dirEntry.InodeNumber))
+
+		childInodeType, err = convertInodeType(dirEntry.Type)
+		if nil != err {
+			return
+		}
+
+		ok, err = directory.Put(dirEntry.Basename, ilayout.DirectoryEntryValueV1Struct{
+			InodeNumber: childInodeNumber,
+			InodeType:   childInodeType,
+		})
+		if (nil == err) && !ok {
+			err = fmt.Errorf("directory.Put(\"%s\",) returned !ok", dirEntry.Basename)
+		}
+		if nil != err {
+			return
+		}
+	}
+
+	inodeHeadV1.PayloadObjectNumber, inodeHeadV1.PayloadObjectOffset, inodeHeadV1.PayloadObjectLength, err = directory.Flush(false)
+	if nil != err {
+		return
+	}
+
+	inodeHeadV1.Layout = []ilayout.InodeHeadLayoutEntryV1Struct{
+		{
+			ObjectNumber:    objectWriter.objectNumber,
+			ObjectSize:      objectWriter.bytesPut,
+			BytesReferenced: objectWriter.bytesPut,
+		},
+	}
+
+	err = nil
+	return
+}
+
+// BytesRemaining is called by swiftclient.ObjectCopy() when copying a LogSegment.
+//
+func (converter *converterStruct) BytesRemaining(bytesRemaining uint64) (chunkSize uint64) {
+	if bytesRemaining > objectCopyChunkSize {
+		chunkSize = objectCopyChunkSize
+	} else {
+		chunkSize = bytesRemaining
+	}
+	return
+}
+
+// fetchLogSegmentObject returns the InodeHead Layout entry for the Object of the converted
+// volume holding the contents of the legacy LogSegment containerName/objectName. The first
+// reference by a given File Inode to a LogSegment either reuses it in place or copies it.
+//
+func (converter *converterStruct) fetchLogSegmentObject(fileInodeNumber uint64, containerName string, objectName string, layoutMap map[string]*ilayout.InodeHeadLayoutEntryV1Struct, layoutSlice *[]*ilayout.InodeHeadLayoutEntryV1Struct) (layout *ilayout.InodeHeadLayoutEntryV1Struct, err error) {
+	var (
+		alreadyReused   bool
+		objectNumber    uint64
+		objectNumberErr error
+		objectPath      string
+		objectSize      uint64
+		ok              bool
+		reuse           bool
+	)
+
+	objectPath = containerName + objectPathSeparator + objectName
+
+	layout, ok = layoutMap[objectPath]
+	if ok {
+		err = nil
+		return
+	}
+
+	objectSize, err = swiftclient.ObjectContentLength(converter.accountName, containerName, objectName)
+	if nil != err {
+		return
+	}
+
+	reuse = false
+
+	if containerName == converter.dstContainerName {
+		objectNumber, objectNumberErr = ilayout.GetObjectNumberFromString(objectName)
+		if (nil == objectNumberErr) && (ReuseObjectNumberFloor <= objectNumber) {
+			_, alreadyReused = converter.reusedLogSegmentMap[objectPath]
+			reuse = !alreadyReused
+		}
+	}
+
+	if reuse {
+		converter.reusedLogSegmentMap[objectPath] = fileInodeNumber
+		converter.report.ReusedObjectCount++
+		converter.report.ReusedObjectBytes += objectSize
+	} else {
+		objectNumber = converter.fetchNonce()
+
+		err = swiftclient.ObjectCopy(converter.accountName, containerName, objectName, converter.accountName, converter.dstContainerName, ilayout.GetObjectNameAsString(objectNumber), converter)
+		if nil != err {
+			return
+		}
+
+		converter.report.CopiedObjectCount++
+		converter.report.CopiedObjectBytes += objectSize
+	}
+
+	layout = &ilayout.InodeHeadLayoutEntryV1Struct{
+		ObjectNumber:    objectNumber,
+		ObjectSize:      objectSize,
+		BytesReferenced: 0,
+	}
+
+	layoutMap[objectPath] = layout
+	*layoutSlice = append(*layoutSlice, layout)
+
+	err = nil
+	return
+}
+
+func isAllZeroes(buf []byte) (allZeroes bool) {
+	var (
+		b byte
+	)
+
+	for _, b = range buf {
+		if 0 != b {
+			allZeroes = false
+			return
+		}
+	}
+
+	allZeroes = true
+	return
+}
+
+// rewriteExtent reads [fileOffset:fileOffset+length) of the SnapShot view of fileInodeNumber
+// and appends it to objectWriter adding the corresponding ExtentMap entries. Chunks that read
+// as all zeroes are left as holes.
+//
+func (converter *converterStruct) rewriteExtent(fileInodeNumber uint64, fileOffset uint64, length uint64, extentMap sortedmap.BPlusTree, objectWriter *objectWriterStruct) (err error) {
+	var (
+		buf          []byte
+		chunkLength  uint64
+		objectOffset uint64
+		ok           bool
+	)
+
+	for 0 < length {
+		if length > rewriteExtentChunkSize {
+			chunkLength = rewriteExtentChunkSize
+		} else {
+			chunkLength = length
+		}
+
+		buf, err = converter.inodeVolumeHandle.Read(converter.snapShotInodeNumber(fileInodeNumber), fileOffset, chunkLength, nil)
+		if nil != err {
+			return
+		}
+		if uint64(len(buf)) != chunkLength {
+			err = fmt.Errorf("Read(,0x%X,0x%X,) returned only 0x%X bytes", fileOffset, chunkLength, len(buf))
+			return
+		}
+
+		if !isAllZeroes(buf) {
+			objectOffset, err = objectWriter.write(buf)
+			if nil != err {
+				return
+			}
+
+			ok, err = extentMap.Put(fileOffset, ilayout.ExtentMapEntryValueV1Struct{
+				Length:       chunkLength,
+				ObjectNumber: objectWriter.objectNumber,
+				ObjectOffset: objectOffset,
+			})
+			if (nil == err) && !ok {
+				err = fmt.Errorf("extentMap.Put(0x%X,) returned !ok", fileOffset)
+			}
+			if nil != err {
+				return
+			}
+
+			converter.report.RewrittenExtentBytes += chunkLength
+		}
+
+		fileOffset += chunkLength
+		length -= chunkLength
+	}
+
+	err = nil
+	return
+}
+
+func (converter *converterStruct) convertFileInode(fileInodeNumber uint64, fileSize uint64, objectWriter *objectWriterStruct, inodeHeadV1 *ilayout.InodeHeadV1Struct) (err error) {
+	var (
+		extentMap      sortedmap.BPlusTree
+		extentMapChunk *inode.ExtentMapChunkStruct
+		extentMapEntry inode.ExtentMapEntryStruct
+		fileOffset     uint64
+		layout         *ilayout.InodeHeadLayoutEntryV1Struct
+		layoutMap      map[string]*ilayout.InodeHeadLayoutEntryV1Struct
+		layoutSlice    []*ilayout.InodeHeadLayoutEntryV1Struct
+		ok             bool
+	)
+
+	extentMap = sortedmap.NewBPlusTree(
+		maxExtentsPerBPlusTreePage,
+		sortedmap.CompareUint64,
+		&bPlusTreeCallbacksStruct{
+			accountName:   converter.accountName,
+			containerName: converter.dstContainerName,
+			keyIsString:   false,
+			valueType:     bPlusTreeValueTypeExtentMapEntry,
+			objectWriter:  objectWriter,
+		},
+		nil)
+
+	layoutMap = make(map[string]*ilayout.InodeHeadLayoutEntryV1Struct)
+	layoutSlice = make([]*ilayout.InodeHeadLayoutEntryV1Struct, 0)
+
+	if converter.headhunterVolumeHandle.IsEncrypted() {
+		// FetchExtentMapChunk() is not supported for encrypted volumes... so rewrite everything
+
+		err = converter.rewriteExtent(fileInodeNumber, 0, fileSize, extentMap, objectWriter)
+		if nil != err {
+			return
+		}
+	} else {
+		fileOffset = 0
+
+		for fileOffset < fileSize {
+			extentMapChunk, err = converter.inodeVolumeHandle.FetchExtentMapChunk(converter.snapShotInodeNumber(fileInodeNumber), fileOffset, extentMapChunkMaxEntries, 0)
+			if nil != err {
+				return
+			}
+
+			for _, extentMapEntry = range extentMapChunk.ExtentMapEntry {
+				if extentMapEntry.FileOffset < fileOffset {
+					// Already converted as part of the previous chunk
+					continue
+				}
+
+				if nil != extentMapEntry.Compression {
+					err = converter.rewriteExtent(fileInodeNumber, extentMapEntry.FileOffset, extentMapEntry.Length, extentMap, objectWriter)
+					if nil != err {
+						return
+					}
+
+					continue
+				}
+
+				layout, err = converter.fetchLogSegmentObject(fileInodeNumber, extentMapEntry.ContainerName, extentMapEntry.ObjectName, layoutMap, &layoutSlice)
+				if nil != err {
+					return
+				}
+
+				ok, err = extentMap.Put(extentMapEntry.FileOffset, ilayout.ExtentMapEntryValueV1Struct{
+					Length:       extentMapEntry.Length,
+					ObjectNumber: layout.ObjectNumber,
+					ObjectOffset: extentMapEntry.LogSegmentOffset,
+				})
+				if (nil == err) && !ok {
+					err = fmt.Errorf("extentMap.Put(0x%X,) returned !ok", extentMapEntry.FileOffset)
+				}
+				if nil != err {
+					return
+				}
+
+				layout.BytesReferenced += extentMapEntry.Length
+			}
+
+			if extentMapChunk.FileOffsetRangeEnd <= fileOffset {
+				err = fmt.Errorf("FetchExtentMapChunk(,0x%X,,) made no progress", fileOffset)
+				return
+			}
+
+			fileOffset = extentMapChunk.FileOffsetRangeEnd
+		}
+	}
+
+	inodeHeadV1.PayloadObjectNumber, inodeHeadV1.PayloadObjectOffset, inodeHeadV1.PayloadObjectLength, err = extentMap.Flush(false)
+	if nil != err {
+		return
+	}
+
+	inodeHeadV1.Layout = make([]ilayout.InodeHeadLayoutEntryV1Struct, 0, 1+len(layoutSlice))
+
+	inodeHeadV1.Layout = append(inodeHeadV1.Layout, ilayout.InodeHeadLayoutEntryV1Struct{
+		ObjectNumber:    objectWriter.objectNumber,
+		ObjectSize:      objectWriter.bytesPut,
+		BytesReferenced: objectWriter.bytesPut,
+	})
+
+	for _, layout = range layoutSlice {
+		inodeHeadV1.Layout = append(inodeHeadV1.Layout, *layout)
+	}
+
+	err = nil
+	return
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+// The pfs2ilayout program is the command line form invoking the pfs2ilayout package's ConvertVolume() function.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NVIDIA/proxyfs/pfs2ilayout"
+)
+
+func usage() {
+	fmt.Println("pfs2ilayout -?")
+	fmt.Println("   Prints this help text")
+	fmt.Println("pfs2ilayout [-q] VolumeNameToConvert DstContainerName ConfFile [ConfFileOverrides]*")
+	fmt.Println("   -q indicates that verification should skip comparing the contents of each File")
+	fmt.Println("  VolumeNameToConvert indicates which Volume in ConfFile is to be converted")
+	fmt.Println("      Note: VolumeNameToConvert must not be served by any proxyfsd during conversion")
+	fmt.Println("  DstContainerName specifies the Container (in the Volume's Account) to hold the converted Volume")
+	fmt.Println("      Note: LogSegments already in DstContainerName may be reused rather than copied")
+	fmt.Println("  ConfFile specifies the .conf file as also passed to proxyfsd et. al.")
+	fmt.Println("  ConfFileOverrides is an optional list of modifications to ConfFile to apply")
+}
+
+func main() {
+	var (
+		argsIndex      int
+		err            error
+		report         *pfs2ilayout.ReportStruct
+		verifyFileData bool
+	)
+
+	if (2 == len(os.Args)) && ("-?" == os.Args[1]) {
+		usage()
+		os.Exit(0)
+	}
+
+	argsIndex = 1
+	verifyFileData = true
+
+	if (2 <= len(os.Args)) && ("-q" == os.Args[1]) {
+		argsIndex++
+		verifyFileData = false
+	}
+
+	if (argsIndex + 3) > len(os.Args) {
+		usage()
+		os.Exit(1)
+	}
+
+	report, err = pfs2ilayout.ConvertVolume(os.Args[argsIndex], os.Args[argsIndex+1], verifyFileData, os.Args[argsIndex+2], os.Args[argsIndex+3:], os.Args)
+	if nil != err {
+		fmt.Fprintf(os.Stderr, "pfs2ilayout: ConvertVolume() returned error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Converted %s from SnapShot %s (ID %d)\n", os.Args[argsIndex], report.SnapShotName, report.SnapShotID)
+	fmt.Printf("  Inodes:           %d Dir, %d File, %d SymLink\n", report.DirInodeCount, report.FileInodeCount, report.SymLinkInodeCount)
+	fmt.Printf("  Reused Objects:   %d (%d bytes)\n", report.ReusedObjectCount, report.ReusedObjectBytes)
+	fmt.Printf("  Copied Objects:   %d (%d bytes)\n", report.CopiedObjectCount, report.CopiedObjectBytes)
+	fmt.Printf("  Rewritten Bytes:  %d\n", report.RewrittenExtentBytes)
+	fmt.Printf("  ReservedToNonce:  %016X\n", report.ReservedToNonce)
+	fmt.Printf("Note: SnapShot %s must be retained while the legacy Volume remains in service\n", report.SnapShotName)
+
+	os.Exit(0)
+}
@@ -0,0 +1,558 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package pfs2ilayout
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/headhunter"
+	"github.com/NVIDIA/proxyfs/ilayout"
+	"github.com/NVIDIA/proxyfs/inode"
+	"github.com/NVIDIA/proxyfs/swiftclient"
+)
+
+func fetchCheckPoint(volumeName string, dstContainerName string) (checkPointV1 *ilayout.CheckPointV1Struct, err error) {
+	var (
+		accountName            string
+		checkPointBuf          []byte
+		checkPointVersion      uint64
+		headhunterVolumeHandle headhunter.VolumeHandle
+	)
+
+	headhunterVolumeHandle, err = headhunter.FetchVolumeHandle(volumeName)
+	if nil != err {
+		return
+	}
+
+	accountName, _ = headhunterVolumeHandle.FetchAccountAndCheckpointContainerNames()
+
+	checkPointBuf, err = swiftclient.ObjectLoad(accountName, dstContainerName, ilayout.GetObjectNameAsString(ilayout.CheckPointObjectNumber))
+	if nil != err {
+		return
+	}
+
+	checkPointVersion, err = ilayout.UnmarshalCheckPointVersion(string(checkPointBuf))
+	if nil != err {
+		return
+	}
+	if ilayout.CheckPointVersionV1 != checkPointVersion {
+		err = fmt.Errorf("unsupported CheckPointVersion (%d)", checkPointVersion)
+		return
+	}
+
+	checkPointV1, err = ilayout.UnmarshalCheckPointV1(string(checkPointBuf))
+
+	return
+}
+
+func verify(volumeName string, dstContainerName string, snapShotID uint64, verifyFileData bool) (err error) {
+	var (
+		checkPointV1               *ilayout.CheckPointV1Struct
+		inodeHeadLayout            ilayout.InodeHeadLayoutEntryV1Struct
+		inodeHeadV1                *ilayout.InodeHeadV1Struct
+		inodeNumber                uint64
+		inodeObjectBytesReferenced uint64
+		inodeObjectCount           uint64
+		inodeObjectSize            uint64
+		inodeTable                 sortedmap.BPlusTree
+		inodeTableLen              int
+		superBlockV1               *ilayout.SuperBlockV1Struct
+		superBlockV1Buf            []byte
+		verifier                   *converterStruct
+	)
+
+	verifier = &converterStruct{
+		volumeName:       volumeName,
+		dstContainerName: dstContainerName,
+		snapShotID:       snapShotID,
+		inodeMap:         make(map[uint64]*converterInodeStruct),
+	}
+
+	verifier.inodeVolumeHandle, err = inode.FetchVolumeHandle(volumeName)
+	if nil != err {
+		return
+	}
+
+	verifier.headhunterVolumeHandle, err = headhunter.FetchVolumeHandle(volumeName)
+	if nil != err {
+		return
+	}
+
+	verifier.accountName, _ = verifier.headhunterVolumeHandle.FetchAccountAndCheckpointContainerNames()
+
+	// Locate the InodeTable via the CheckPoint and SuperBlock
+
+	checkPointV1, err = fetchCheckPoint(volumeName, dstContainerName)
+	if nil != err {
+		err = fmt.Errorf("unable to fetch CheckPoint: %v", err)
+		return
+	}
+
+	superBlockV1Buf, err = swiftclient.ObjectTail(verifier.accountName, dstContainerName, ilayout.GetObjectNameAsString(checkPointV1.SuperBlockObjectNumber), checkPointV1.SuperBlockLength)
+	if nil != err {
+		err = fmt.Errorf("unable to fetch SuperBlock: %v", err)
+		return
+	}
+
+	superBlockV1, err = ilayout.UnmarshalSuperBlockV1(superBlockV1Buf)
+	if nil != err {
+		err = fmt.Errorf("unable to unmarshal SuperBlock: %v", err)
+		return
+	}
+
+	inodeTable, err = sortedmap.OldBPlusTree(
+		superBlockV1.InodeTableRootObjectNumber,
+		superBlockV1.InodeTableRootObjectOffset,
+		superBlockV1.InodeTableRootObjectLength,
+		sortedmap.CompareUint64,
+		&bPlusTreeCallbacksStruct{
+			accountName:   verifier.accountName,
+			containerName: dstContainerName,
+			keyIsString:   false,
+			valueType:     bPlusTreeValueTypeInodeTableEntry,
+			objectWriter:  nil,
+		},
+		nil)
+	if nil != err {
+		err = fmt.Errorf("unable to load InodeTable: %v", err)
+		return
+	}
+
+	// Compare against the Inodes reachable in the SnapShot
+
+	err = verifier.discoverInodes()
+	if nil != err {
+		return
+	}
+
+	inodeTableLen, err = inodeTable.Len()
+	if nil != err {
+		return
+	}
+	if len(verifier.inodeMap) != inodeTableLen {
+		err = fmt.Errorf("InodeTable contains %d Inodes but SnapShot contains %d", inodeTableLen, len(verifier.inodeMap))
+		return
+	}
+
+	inodeObjectCount = 0
+	inodeObjectSize = 0
+	inodeObjectBytesReferenced = 0
+
+	for inodeNumber = range verifier.inodeMap {
+		inodeHeadV1, err = verifier.verifyInode(inodeTable, inodeNumber, verifyFileData)
+		if nil != err {
+			err = fmt.Errorf("Inode %016X: %v", inodeNumber, err)
+			return
+		}
+
+		for _, inodeHeadLayout = range inodeHeadV1.Layout {
+			if inodeHeadLayout.ObjectNumber > checkPointV1.ReservedToNonce {
+				if ReuseObjectNumberFloor > inodeHeadLayout.ObjectNumber {
+					err = fmt.Errorf("Inode %016X: Layout references Object %016X beyond ReservedToNonce", inodeNumber, inodeHeadLayout.ObjectNumber)
+					return
+				}
+			}
+
+			inodeObjectCount++
+			inodeObjectSize += inodeHeadLayout.ObjectSize
+			inodeObjectBytesReferenced += inodeHeadLayout.BytesReferenced
+		}
+	}
+
+	if (inodeObjectCount != superBlockV1.InodeObjectCount) || (inodeObjectSize != superBlockV1.InodeObjectSize) || (inodeObjectBytesReferenced != superBlockV1.InodeBytesReferenced) {
+		err = fmt.Errorf("SuperBlock Inode Object totals mismatch")
+		return
+	}
+
+	err = nil
+	return
+}
+
+func sortLinkTable(linkTable []ilayout.InodeLinkTableEntryStruct) {
+	sort.Slice(linkTable, func(i, j int) bool {
+		if linkTable[i].ParentDirInodeNumber == linkTable[j].ParentDirInodeNumber {
+			return linkTable[i].ParentDirEntryName < linkTable[j].ParentDirEntryName
+		}
+		return linkTable[i].ParentDirInodeNumber < linkTable[j].ParentDirInodeNumber
+	})
+}
+
+func (verifier *converterStruct) verifyInode(inodeTable sortedmap.BPlusTree, inodeNumber uint64, verifyFileData bool) (inodeHeadV1 *ilayout.InodeHeadV1Struct, err error) {
+	var (
+		expectedInode          *converterInodeStruct
+		inodeHeadV1Buf         []byte
+		inodeStreamTableEntry  ilayout.InodeStreamTableEntryStruct
+		inodeStreamValue       []byte
+		inodeTableEntryValue   sortedmap.Value
+		inodeTableEntryValueV1 ilayout.InodeTableEntryValueV1Struct
+		linkTableIndex         int
+		metadata               *inode.MetadataStruct
+		ok                     bool
+		snapShotInodeNumber    inode.InodeNumber
+		symlinkTarget          string
+	)
+
+	expectedInode = verifier.inodeMap[inodeNumber]
+	snapShotInodeNumber = verifier.snapShotInodeNumber(inodeNumber)
+
+	inodeTableEntryValue, ok, err = inodeTable.GetByKey(inodeNumber)
+	if nil != err {
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("missing from InodeTable")
+		return
+	}
+
+	inodeTableEntryValueV1, ok = inodeTableEntryValue.(ilayout.InodeTableEntryValueV1Struct)
+	if !ok {
+		err = fmt.Errorf("InodeTable entry not an InodeTableEntryValueV1Struct")
+		return
+	}
+
+	inodeHeadV1Buf, err = swiftclient.ObjectTail(verifier.accountName, verifier.dstContainerName, ilayout.GetObjectNameAsString(inodeTableEntryValueV1.InodeHeadObjectNumber), inodeTableEntryValueV1.InodeHeadLength)
+	if nil != err {
+		return
+	}
+
+	inodeHeadV1, err = ilayout.UnmarshalInodeHeadV1(inodeHeadV1Buf)
+	if nil != err {
+		return
+	}
+
+	metadata, err = verifier.inodeVolumeHandle.GetMetadata(snapShotInodeNumber)
+	if nil != err {
+		return
+	}
+
+	// Verify InodeHead fields
+
+	if (inodeNumber != inodeHeadV1.InodeNumber) || (expectedInode.inodeType != inodeHeadV1.InodeType) {
+		err = fmt.Errorf("InodeNumber/InodeType mismatch")
+		return
+	}
+
+	if (uint16(metadata.Mode)&ilayout.InodeModeMask != inodeHeadV1.Mode) || (uint64(metadata.UserID) != inodeHeadV1.UserID) || (uint64(metadata.GroupID) != inodeHeadV1.GroupID) {
+		err = fmt.Errorf("Mode/UserID/GroupID mismatch")
+		return
+	}
+
+	if !metadata.ModificationTime.Equal(inodeHeadV1.ModificationTime) || !metadata.AttrChangeTime.Equal(inodeHeadV1.StatusChangeTime) {
+		err = fmt.Errorf("ModificationTime/StatusChangeTime mismatch")
+		return
+	}
+
+	if len(expectedInode.linkTable) != len(inodeHeadV1.LinkTable) {
+		err = fmt.Errorf("LinkTable length mismatch (%d != %d)", len(inodeHeadV1.LinkTable), len(expectedInode.linkTable))
+		return
+	}
+
+	sortLinkTable(expectedInode.linkTable)
+	sortLinkTable(inodeHeadV1.LinkTable)
+
+	for linkTableIndex = range expectedInode.linkTable {
+		if expectedInode.linkTable[linkTableIndex] != inodeHeadV1.LinkTable[linkTableIndex] {
+			err = fmt.Errorf("LinkTable mismatch (%+v != %+v)", inodeHeadV1.LinkTable[linkTableIndex], expectedInode.linkTable[linkTableIndex])
+			return
+		}
+	}
+
+	if len(metadata.InodeStreamNameSlice) != len(inodeHeadV1.StreamTable) {
+		err = fmt.Errorf("StreamTable length mismatch")
+		return
+	}
+
+	for _, inodeStreamTableEntry = range inodeHeadV1.StreamTable {
+		inodeStreamValue, err = verifier.inodeVolumeHandle.GetStream(snapShotInodeNumber, inodeStreamTableEntry.Name)
+		if nil != err {
+			err = fmt.Errorf("Stream \"%s\" not found: %v", inodeStreamTableEntry.Name, err)
+			return
+		}
+		if !bytes.Equal(inodeStreamValue, inodeStreamTableEntry.Value) {
+			err = fmt.Errorf("Stream \"%s\" mismatch", inodeStreamTableEntry.Name)
+			return
+		}
+	}
+
+	// Verify type-specific contents
+
+	switch inodeHeadV1.InodeType {
+	case ilayout.InodeTypeDir:
+		err = verifier.verifyDirInode(inodeNumber, expectedInode, inodeHeadV1)
+	case ilayout.InodeTypeFile:
+		if metadata.Size != inodeHeadV1.Size {
+			err = fmt.Errorf("Size mismatch (0x%X != 0x%X)", inodeHeadV1.Size, metadata.Size)
+			return
+		}
+		err = verifier.verifyFileInode(inodeNumber, inodeHeadV1, verifyFileData)
+	case ilayout.InodeTypeSymLink:
+		symlinkTarget, err = verifier.inodeVolumeHandle.GetSymlink(snapShotInodeNumber)
+		if nil != err {
+			return
+		}
+		if symlinkTarget != inodeHeadV1.SymLinkTarget {
+			err = fmt.Errorf("SymLinkTarget mismatch (\"%s\" != \"%s\")", inodeHeadV1.SymLinkTarget, symlinkTarget)
+		}
+	}
+
+	return
+}
+
+func (verifier *converterStruct) verifyDirInode(dirInodeNumber uint64, expectedInode *converterInodeStruct, inodeHeadV1 *ilayout.InodeHeadV1Struct) (err error) {
+	var (
+		childInodeNumber      uint64
+		childInodeType        uint8
+		dirEntry              inode.DirEntry
+		dirEntrySlice         []inode.DirEntry
+		directory             sortedmap.BPlusTree
+		directoryEntryValue   sortedmap.Value
+		directoryEntryValueV1 ilayout.DirectoryEntryValueV1Struct
+		directoryLen          int
+		ok                    bool
+	)
+
+	directory, err = sortedmap.OldBPlusTree(
+		inodeHeadV1.PayloadObjectNumber,
+		inodeHeadV1.PayloadObjectOffset,
+		inodeHeadV1.PayloadObjectLength,
+		sortedmap.CompareString,
+		&bPlusTreeCallbacksStruct{
+			accountName:   verifier.accountName,
+			containerName: verifier.dstContainerName,
+			keyIsString:   true,
+			valueType:     bPlusTreeValueTypeDirectoryEntry,
+			objectWriter:  nil,
+		},
+		nil)
+	if nil != err {
+		return
+	}
+
+	dirEntrySlice, err = verifier.readDir(dirInodeNumber)
+	if nil != err {
+		return
+	}
+
+	directoryLen, err = directory.Len()
+	if nil != err {
+		return
+	}
+	if (2 + len(dirEntrySlice)) != directoryLen {
+		err = fmt.Errorf("Directory contains %d entries but SnapShot contains %d", directoryLen, 2+len(dirEntrySlice))
+		return
+	}
+
+	dirEntrySlice = append(dirEntrySlice,
+		inode.DirEntry{
+			InodeNumber: inode.InodeNumber(dirInodeNumber),
+			Basename:    ".",
+			Type:        inode.DirType,
+		},
+		inode.DirEntry{
+			InodeNumber: inode.InodeNumber(expectedInode.parentDirInodeNumber),
+			Basename:    "..",
+			Type:        inode.DirType,
+		})
+
+	for _, dirEntry = range dirEntrySlice {
+		_, _, childInodeNumber = verifier.headhunterVolumeHandle.SnapShotU64Decode(uint64(dirEntry.InodeNumber))
+
+		childInodeType, err = convertInodeType(dirEntry.Type)
+		if nil != err {
+			return
+		}
+
+		directoryEntryValue, ok, err = directory.GetByKey(dirEntry.Basename)
+		if nil != err {
+			return
+		}
+		if !ok {
+			err = fmt.Errorf("Directory missing \"%s\"", dirEntry.Basename)
+			return
+		}
+
+		directoryEntryValueV1, ok = directoryEntryValue.(ilayout.DirectoryEntryValueV1Struct)
+		if !ok {
+			err = fmt.Errorf("Directory entry \"%s\" not a DirectoryEntryValueV1Struct", dirEntry.Basename)
+			return
+		}
+
+		if (childInodeNumber != directoryEntryValueV1.InodeNumber) || (childInodeType != directoryEntryValueV1.InodeType) {
+			err = fmt.Errorf("Directory entry \"%s\" mismatch (%+v)", dirEntry.Basename, directoryEntryValueV1)
+			return
+		}
+	}
+
+	err = nil
+	return
+}
+
+// verifyFileData compares [fileOffset:fileOffset+length) of the SnapShot view of fileInodeNumber
+// with expectedBuf (or, if expectedBuf is nil, zeroes).
+//
+func (verifier *converterStruct) verifyFileData(fileInodeNumber uint64, fileOffset uint64, length uint64, expectedBuf []byte) (err error) {
+	var (
+		buf         []byte
+		chunkLength uint64
+		chunkOffset uint64
+	)
+
+	for chunkOffset = 0; chunkOffset < length; chunkOffset += chunkLength {
+		chunkLength = length - chunkOffset
+		if chunkLength > rewriteExtentChunkSize {
+			chunkLength = rewriteExtentChunkSize
+		}
+
+		buf, err = verifier.inodeVolumeHandle.Read(verifier.snapShotInodeNumber(fileInodeNumber), fileOffset+chunkOffset, chunkLength, nil)
+		if nil != err {
+			return
+		}
+
+		if nil == expectedBuf {
+			if (uint64(len(buf)) != chunkLength) || !isAllZeroes(buf) {
+				err = fmt.Errorf("hole at 0x%X should read as zeroes", fileOffset+chunkOffset)
+				return
+			}
+		} else {
+			if !bytes.Equal(buf, expectedBuf[chunkOffset:chunkOffset+chunkLength]) {
+				err = fmt.Errorf("data mismatch at 0x%X", fileOffset+chunkOffset)
+				return
+			}
+		}
+	}
+
+	err = nil
+	return
+}
+
+func (verifier *converterStruct) verifyFileInode(fileInodeNumber uint64, inodeHeadV1 *ilayout.InodeHeadV1Struct, verifyFileData bool) (err error) {
+	var (
+		bytesReferencedMap    map[uint64]uint64
+		extentBuf             []byte
+		extentMap             sortedmap.BPlusTree
+		extentMapEntryValue   sortedmap.Value
+		extentMapEntryValueV1 ilayout.ExtentMapEntryValueV1Struct
+		extentMapIndex        int
+		extentMapLen          int
+		fileOffset            uint64
+		fileOffsetAsKey       sortedmap.Key
+		inodeHeadLayout       ilayout.InodeHeadLayoutEntryV1Struct
+		nextFileOffset        uint64
+		ok                    bool
+		objectContentLength   uint64
+	)
+
+	extentMap, err = sortedmap.OldBPlusTree(
+		inodeHeadV1.PayloadObjectNumber,
+		inodeHeadV1.PayloadObjectOffset,
+		inodeHeadV1.PayloadObjectLength,
+		sortedmap.CompareUint64,
+		&bPlusTreeCallbacksStruct{
+			accountName:   verifier.accountName,
+			containerName: verifier.dstContainerName,
+			keyIsString:   false,
+			valueType:     bPlusTreeValueTypeExtentMapEntry,
+			objectWriter:  nil,
+		},
+		nil)
+	if nil != err {
+		return
+	}
+
+	if (0 == len(inodeHeadV1.Layout)) || (inodeHeadV1.PayloadObjectNumber != inodeHeadV1.Layout[0].ObjectNumber) {
+		err = fmt.Errorf("Layout does not begin with the Object holding the ExtentMap")
+		return
+	}
+
+	bytesReferencedMap = make(map[uint64]uint64)
+
+	for _, inodeHeadLayout = range inodeHeadV1.Layout[1:] {
+		bytesReferencedMap[inodeHeadLayout.ObjectNumber] = 0
+	}
+
+	extentMapLen, err = extentMap.Len()
+	if nil != err {
+		return
+	}
+
+	nextFileOffset = 0
+
+	for extentMapIndex = 0; extentMapIndex < extentMapLen; extentMapIndex++ {
+		fileOffsetAsKey, extentMapEntryValue, ok, err = extentMap.GetByIndex(extentMapIndex)
+		if nil != err {
+			return
+		}
+		if !ok {
+			err = fmt.Errorf("extentMap.GetByIndex(%d) returned !ok", extentMapIndex)
+			return
+		}
+
+		fileOffset = fileOffsetAsKey.(uint64)
+		extentMapEntryValueV1 = extentMapEntryValue.(ilayout.ExtentMapEntryValueV1Struct)
+
+		if (fileOffset < nextFileOffset) || ((fileOffset + extentMapEntryValueV1.Length) > inodeHeadV1.Size) {
+			err = fmt.Errorf("extent at 0x%X overlaps its predecessor or extends beyond Size", fileOffset)
+			return
+		}
+
+		if extentMapEntryValueV1.ObjectNumber != inodeHeadV1.PayloadObjectNumber {
+			_, ok = bytesReferencedMap[extentMapEntryValueV1.ObjectNumber]
+			if !ok {
+				err = fmt.Errorf("extent at 0x%X references Object %016X not in Layout", fileOffset, extentMapEntryValueV1.ObjectNumber)
+				return
+			}
+
+			bytesReferencedMap[extentMapEntryValueV1.ObjectNumber] += extentMapEntryValueV1.Length
+		}
+
+		if verifyFileData {
+			err = verifier.verifyFileData(fileInodeNumber, nextFileOffset, fileOffset-nextFileOffset, nil)
+			if nil != err {
+				return
+			}
+
+			extentBuf, err = swiftclient.ObjectGet(verifier.accountName, verifier.dstContainerName, ilayout.GetObjectNameAsString(extentMapEntryValueV1.ObjectNumber), extentMapEntryValueV1.ObjectOffset, extentMapEntryValueV1.Length)
+			if nil != err {
+				return
+			}
+
+			err = verifier.verifyFileData(fileInodeNumber, fileOffset, extentMapEntryValueV1.Length, extentBuf)
+			if nil != err {
+				return
+			}
+		}
+
+		nextFileOffset = fileOffset + extentMapEntryValueV1.Length
+	}
+
+	if verifyFileData {
+		err = verifier.verifyFileData(fileInodeNumber, nextFileOffset, inodeHeadV1.Size-nextFileOffset, nil)
+		if nil != err {
+			return
+		}
+	}
+
+	for _, inodeHeadLayout = range inodeHeadV1.Layout[1:] {
+		if bytesReferencedMap[inodeHeadLayout.ObjectNumber] != inodeHeadLayout.BytesReferenced {
+			err = fmt.Errorf("Layout BytesReferenced mismatch for Object %016X", inodeHeadLayout.ObjectNumber)
+			return
+		}
+
+		objectContentLength, err = swiftclient.ObjectContentLength(verifier.accountName, verifier.dstContainerName, ilayout.GetObjectNameAsString(inodeHeadLayout.ObjectNumber))
+		if nil != err {
+			return
+		}
+		if objectContentLength != inodeHeadLayout.ObjectSize {
+			err = fmt.Errorf("Layout ObjectSize mismatch for Object %016X", inodeHeadLayout.ObjectNumber)
+			return
+		}
+	}
+
+	err = nil
+	return
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package pfs2ilayout
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/transitions"
+	"github.com/NVIDIA/proxyfs/version"
+)
+
+func convertVolume(volumeName string, dstContainerName string, verifyFileData bool, confFile string, confStrings []string, execArgs []string) (report *ReportStruct, err error) {
+	var (
+		confMap conf.ConfMap
+		whoAmI  string
+	)
+
+	// Load confFile & confStrings (overrides)
+
+	confMap, err = conf.MakeConfMapFromFile(confFile)
+	if nil != err {
+		err = fmt.Errorf("failed to load config: %v", err)
+		return
+	}
+
+	err = confMap.UpdateFromStrings(confStrings)
+	if nil != err {
+		err = fmt.Errorf("failed to apply config overrides: %v", err)
+		return
+	}
+
+	// Upgrade confMap if necessary
+	// [should be removed once backwards compatibility is no longer required...]
+
+	err = transitions.UpgradeConfMapIfNeeded(confMap)
+	if nil != err {
+		err = fmt.Errorf("failed to upgrade config: %v", err)
+		return
+	}
+
+	// Update confMap to specify only volumeName
+
+	whoAmI, err = confMap.FetchOptionValueString("Cluster", "WhoAmI")
+	if nil != err {
+		return
+	}
+
+	err = confMap.UpdateFromStrings([]string{
+		"FSGlobals.VolumeGroupList=PFS2ILAYOUT",
+		"VolumeGroup:PFS2ILAYOUT.VolumeList=" + volumeName,
+		"VolumeGroup:PFS2ILAYOUT.VirtualIPAddr=",
+		"VolumeGroup:PFS2ILAYOUT.PrimaryPeer=" + whoAmI})
+	if nil != err {
+		err = fmt.Errorf("failed to retarget config at only %s: %v", volumeName, err)
+		return
+	}
+
+	err = transitions.Up(confMap)
+	if nil != err {
+		return
+	}
+
+	logger.Infof("pfs2ilayout is starting up (version %s) (PID %d); invoked as '%s'",
+		version.ProxyFSVersion, os.Getpid(), strings.Join(execArgs, "' '"))
+
+	report, err = Convert(volumeName, dstContainerName)
+	if nil != err {
+		_ = transitions.Down(confMap)
+		return
+	}
+
+	err = Verify(volumeName, dstContainerName, report.SnapShotID, verifyFileData)
+	if nil != err {
+		_ = transitions.Down(confMap)
+		err = fmt.Errorf("verification of %s failed: %v", dstContainerName, err)
+		return
+	}
+
+	err = transitions.Down(confMap)
+
+	return
+}
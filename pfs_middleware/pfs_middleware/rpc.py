@@ -24,6 +24,7 @@ allow_read_only = {
     "Server.RpcListXAttr",
     "Server.RpcLookup",
     "Server.RpcLookupPlus",
+    "Server.RpcLseek",
     "Server.RpcMountByAccountName",
     "Server.RpcPing",
     "Server.RpcReadSymlink",
@@ -43,6 +44,7 @@ allow_read_write = {
     "Server.RpcCreate",
     "Server.RpcDelete",
     "Server.RpcDestroy",
+    "Server.RpcFallocate",
     "Server.RpcFetchExtentMapChunk",
     "Server.RpcFlock",
     "Server.RpcFlush",
@@ -57,6 +59,7 @@ allow_read_write = {
     "Server.RpcLog",
     "Server.RpcLookup",
     "Server.RpcLookupPlus",
+    "Server.RpcLseek",
     "Server.RpcMiddlewareMkdir",
     "Server.RpcMiddlewarePost",
     "Server.RpcMkdir",
@@ -78,12 +81,16 @@ allow_read_write = {
     "Server.RpcRmdir",
     "Server.RpcSetTime",
     "Server.RpcSetXAttr",
+    "Server.RpcSnapShotClone",
     "Server.RpcSnapShotCreate",
     "Server.RpcSnapShotDelete",
+    "Server.RpcSnapShotDiff",
     "Server.RpcSnapShotListByID",
     "Server.RpcSnapShotListByName",
     "Server.RpcSnapShotListByTime",
     "Server.RpcSnapShotLookupByName",
+    "Server.RpcSnapShotRestore",
+    "Server.RpcSnapShotRollback",
     "Server.RpcStatVFS",
     "Server.RpcSymlink",
     "Server.RpcType",
@@ -0,0 +1,866 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"container/list"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/NVIDIA/sortedmap"
+
+	"github.com/NVIDIA/proxyfs/inode"
+	"github.com/NVIDIA/proxyfs/jrpcfs"
+)
+
+// When [Agent]CacheDirPath is set, PFSAgent maintains an on-disk cache beneath
+// it that survives restarts of PFSAgent:
+//
+//   <CacheDirPath>/identity                                 - the Volume the cache belongs to
+//   <CacheDirPath>/read/<LogSegmentNumber>.<CacheLineTag>    - a LogSegment Cache Line
+//   <CacheDirPath>/dirty/<Sequence>.<InodeNumber>            - a dirty LogSegment journal
+//
+// As LogSegment Numbers are only unique within a Volume, the identity file records
+// the VolumeName, AccountName, and the CRTime of the root directory (which changes
+// should the Volume be reformatted) of the Volume the cache was populated from. This
+// is verified following each mount. Upon a mismatch, the read cache lines are
+// discarded... unless dirty LogSegment journals are present, in which case PFSAgent
+// refuses to start rather than replay them into the wrong Volume.
+//
+// As LogSegments are never modified once written, read cache lines fetched from
+// Swift may be retained indefinitely (subject to [Agent]CacheDirReadCacheLineCount)
+// and are consulted prior to issuing a GET to Swift.
+//
+// Each chunkedPutContextStruct is journaled to its own dirty LogSegment file as
+// a sequence of records each consisting of a little-endian uint64 FileOffset,
+// a little-endian uint64 Length, and Length bytes of data. Each record is fsync'd
+// before the write it journals is acknowledged. Just prior to issuing RpcWrote,
+// a final record with a Length of diskCacheWroteRecordLength is appended whose
+// FileOffset field instead holds the length of the "<ContainerName>/<ObjectName>"
+// of the LogSegment that immediately follows. Once the LogSegment has been PUT and
+// ProxyFS informed via RpcWrote, the journal is removed. Any journals found upon
+// the next mount are replayed (in Sequence order) thru the normal write path before
+// the FUSE mount point is presented... except for those ending in a Wrote record
+// whose LogSegment is referenced by the Inode's ExtentMap (as only an applied
+// RpcWrote would have left it there).
+
+const (
+	diskCacheDirtyDirName       = "dirty"
+	diskCacheIdentityFileName   = "identity"
+	diskCacheReadDirName        = "read"
+	diskCacheRecordHeaderSize   = 16
+	diskCacheTempFileNamePrefix = ".tmp."
+	diskCacheWroteRecordLength  = ^uint64(0)
+)
+
+// diskCacheRecordStruct is a write recorded in a dirty LogSegment journal.
+type diskCacheRecordStruct struct {
+	fileOffset uint64
+	data       []byte
+}
+
+type diskCacheIdentityStruct struct {
+	VolumeName      string
+	AccountName     string
+	RootDirCRTimeNs uint64
+}
+
+func initializeDiskCache() {
+	var (
+		dirtyDirPath      string
+		err               error
+		fileInfo          os.FileInfo
+		fileInfoSlice     []os.FileInfo
+		key               logSegmentCacheElementKeyStruct
+		ok                bool
+		readDirPath       string
+		sequence          uint64
+		validFileInfoList []os.FileInfo
+	)
+
+	globals.diskCacheReadLineMap = make(map[logSegmentCacheElementKeyStruct]*list.Element)
+	globals.diskCacheReadLineLRU = list.New()
+	globals.diskCacheNextDirtySequence = 0
+
+	if "" == globals.config.CacheDirPath {
+		return
+	}
+
+	readDirPath = filepath.Join(globals.config.CacheDirPath, diskCacheReadDirName)
+	dirtyDirPath = filepath.Join(globals.config.CacheDirPath, diskCacheDirtyDirName)
+
+	err = os.MkdirAll(readDirPath, 0700)
+	if nil != err {
+		logFatalf("initializeDiskCache() unable to create %s: %v", readDirPath, err)
+	}
+	err = os.MkdirAll(dirtyDirPath, 0700)
+	if nil != err {
+		logFatalf("initializeDiskCache() unable to create %s: %v", dirtyDirPath, err)
+	}
+
+	// Reload the read cache LRU from the read cache lines left by a prior instance (oldest first)
+
+	fileInfoSlice, err = ioutil.ReadDir(readDirPath)
+	if nil != err {
+		logFatalf("initializeDiskCache() unable to read %s: %v", readDirPath, err)
+	}
+
+	validFileInfoList = make([]os.FileInfo, 0, len(fileInfoSlice))
+
+	for _, fileInfo = range fileInfoSlice {
+		_, ok = parseDiskCacheReadLineFileName(fileInfo.Name())
+		if ok {
+			validFileInfoList = append(validFileInfoList, fileInfo)
+		} else {
+			_ = os.Remove(filepath.Join(readDirPath, fileInfo.Name())) // Likely a partially written read cache line
+		}
+	}
+
+	sort.SliceStable(validFileInfoList, func(i int, j int) bool {
+		return validFileInfoList[i].ModTime().Before(validFileInfoList[j].ModTime())
+	})
+
+	for _, fileInfo = range validFileInfoList {
+		key, _ = parseDiskCacheReadLineFileName(fileInfo.Name())
+		globals.diskCacheReadLineMap[key] = globals.diskCacheReadLineLRU.PushBack(key)
+	}
+
+	evictDiskCacheReadLinesIfNecessary(0)
+
+	// Ensure dirty LogSegment journals we create are ordered after any left by a prior instance
+
+	fileInfoSlice, err = ioutil.ReadDir(dirtyDirPath)
+	if nil != err {
+		logFatalf("initializeDiskCache() unable to read %s: %v", dirtyDirPath, err)
+	}
+
+	for _, fileInfo = range fileInfoSlice {
+		sequence, _, ok = parseDiskCacheDirtyLogSegmentFileName(fileInfo.Name())
+		if ok && (sequence >= globals.diskCacheNextDirtySequence) {
+			globals.diskCacheNextDirtySequence = sequence + 1
+		}
+	}
+}
+
+func uninitializeDiskCache() {
+	globals.diskCacheReadLineMap = nil
+	globals.diskCacheReadLineLRU = nil
+	globals.diskCacheNextDirtySequence = 0
+}
+
+// verifyDiskCacheIdentity ensures the on-disk cache was populated from the Volume
+// just mounted (via accountName). Must be called prior to replayDiskCacheDirtyLogSegments().
+//
+func verifyDiskCacheIdentity(accountName string) {
+	var (
+		err            error
+		getStatReply   *jrpcfs.StatStruct
+		getStatRequest *jrpcfs.GetStatRequest
+	)
+
+	if "" == globals.config.CacheDirPath {
+		return
+	}
+
+	getStatRequest = &jrpcfs.GetStatRequest{
+		InodeHandle: jrpcfs.InodeHandle{
+			MountID:     globals.mountID,
+			InodeNumber: int64(inode.RootDirInodeNumber),
+		},
+	}
+
+	getStatReply = &jrpcfs.StatStruct{}
+
+	err = globals.retryRPCClient.Send("RpcGetStat", getStatRequest, getStatReply)
+	if nil != err {
+		logFatalf("verifyDiskCacheIdentity() unable to stat root directory of Volume %s: %v", globals.config.FUSEVolumeName, err)
+	}
+
+	err = checkDiskCacheIdentity(&diskCacheIdentityStruct{
+		VolumeName:      globals.config.FUSEVolumeName,
+		AccountName:     accountName,
+		RootDirCRTimeNs: getStatReply.CRTimeNs,
+	})
+	if nil != err {
+		logFatalf("verifyDiskCacheIdentity() failed: %v", err)
+	}
+}
+
+// checkDiskCacheIdentity compares identity to that recorded in the on-disk cache. If they
+// differ (or none was recorded), the read cache lines are discarded and identity recorded
+// in their place. An error is returned should dirty LogSegment journals be present as they
+// cannot safely be replayed into the Volume identified by identity.
+//
+func checkDiskCacheIdentity(identity *diskCacheIdentityStruct) (err error) {
+	var (
+		dirtyDirPath     string
+		fileInfo         os.FileInfo
+		fileInfoSlice    []os.FileInfo
+		identityBuf      []byte
+		identityFilePath string
+		ok               bool
+		priorIdentity    diskCacheIdentityStruct
+		readDirPath      string
+		tempFilePath     string
+	)
+
+	identityFilePath = filepath.Join(globals.config.CacheDirPath, diskCacheIdentityFileName)
+
+	identityBuf, err = ioutil.ReadFile(identityFilePath)
+	if nil == err {
+		err = json.Unmarshal(identityBuf, &priorIdentity)
+		if (nil == err) && (*identity == priorIdentity) {
+			return
+		}
+		logInfof("disk cache %s was populated from Volume %s (Account: %s)... discarding it", globals.config.CacheDirPath, priorIdentity.VolumeName, priorIdentity.AccountName)
+	} else if !os.IsNotExist(err) {
+		err = fmt.Errorf("unable to read %s: %v", identityFilePath, err)
+		return
+	}
+
+	dirtyDirPath = filepath.Join(globals.config.CacheDirPath, diskCacheDirtyDirName)
+
+	fileInfoSlice, err = ioutil.ReadDir(dirtyDirPath)
+	if nil != err {
+		err = fmt.Errorf("unable to read %s: %v", dirtyDirPath, err)
+		return
+	}
+
+	for _, fileInfo = range fileInfoSlice {
+		_, _, ok = parseDiskCacheDirtyLogSegmentFileName(fileInfo.Name())
+		if ok {
+			err = fmt.Errorf("dirty LogSegment journal %s in %s does not belong to Volume %s (Account: %s)", fileInfo.Name(), dirtyDirPath, identity.VolumeName, identity.AccountName)
+			return
+		}
+	}
+
+	readDirPath = filepath.Join(globals.config.CacheDirPath, diskCacheReadDirName)
+
+	globals.diskCacheMutex.Lock()
+
+	fileInfoSlice, err = ioutil.ReadDir(readDirPath)
+	if nil != err {
+		globals.diskCacheMutex.Unlock()
+		err = fmt.Errorf("unable to read %s: %v", readDirPath, err)
+		return
+	}
+
+	for _, fileInfo = range fileInfoSlice {
+		err = os.Remove(filepath.Join(readDirPath, fileInfo.Name()))
+		if nil != err {
+			globals.diskCacheMutex.Unlock()
+			err = fmt.Errorf("unable to remove LogSegment Cache Line %s: %v", fileInfo.Name(), err)
+			return
+		}
+	}
+
+	globals.diskCacheReadLineMap = make(map[logSegmentCacheElementKeyStruct]*list.Element)
+	globals.diskCacheReadLineLRU = list.New()
+
+	globals.diskCacheMutex.Unlock()
+
+	// Record identity via a temporary file and a rename so that it is never partially written
+
+	identityBuf, err = json.Marshal(identity)
+	if nil != err {
+		err = fmt.Errorf("unable to marshal identity: %v", err)
+		return
+	}
+
+	tempFilePath = filepath.Join(globals.config.CacheDirPath, diskCacheTempFileNamePrefix+diskCacheIdentityFileName)
+
+	err = ioutil.WriteFile(tempFilePath, identityBuf, 0600)
+	if nil != err {
+		_ = os.Remove(tempFilePath)
+		err = fmt.Errorf("unable to write %s: %v", tempFilePath, err)
+		return
+	}
+
+	err = os.Rename(tempFilePath, identityFilePath)
+	if nil != err {
+		_ = os.Remove(tempFilePath)
+		err = fmt.Errorf("unable to rename %s: %v", tempFilePath, err)
+		return
+	}
+
+	err = syncDiskCacheDir(globals.config.CacheDirPath)
+
+	return
+}
+
+// syncDiskCacheDir ensures the creation, removal, or renaming of files in dirPath are durable.
+//
+func syncDiskCacheDir(dirPath string) (err error) {
+	var (
+		dir *os.File
+	)
+
+	dir, err = os.Open(dirPath)
+	if nil != err {
+		err = fmt.Errorf("unable to open %s: %v", dirPath, err)
+		return
+	}
+
+	err = dir.Sync()
+	if nil != err {
+		_ = dir.Close()
+		err = fmt.Errorf("unable to sync %s: %v", dirPath, err)
+		return
+	}
+
+	err = dir.Close()
+	if nil != err {
+		err = fmt.Errorf("unable to close %s: %v", dirPath, err)
+	}
+
+	return
+}
+
+func diskCacheReadLineFileName(key logSegmentCacheElementKeyStruct) (fileName string) {
+	fileName = fmt.Sprintf("%016X.%016X", key.logSegmentNumber, key.cacheLineTag)
+	return
+}
+
+func parseDiskCacheReadLineFileName(fileName string) (key logSegmentCacheElementKeyStruct, ok bool) {
+	var (
+		err error
+	)
+
+	if (33 != len(fileName)) || strings.HasPrefix(fileName, diskCacheTempFileNamePrefix) {
+		ok = false
+		return
+	}
+
+	_, err = fmt.Sscanf(fileName, "%016X.%016X", &key.logSegmentNumber, &key.cacheLineTag)
+	ok = (nil == err)
+
+	return
+}
+
+func diskCacheDirtyLogSegmentFileName(sequence uint64, inodeNumber inode.InodeNumber) (fileName string) {
+	fileName = fmt.Sprintf("%016X.%016X", sequence, uint64(inodeNumber))
+	return
+}
+
+func parseDiskCacheDirtyLogSegmentFileName(fileName string) (sequence uint64, inodeNumber inode.InodeNumber, ok bool) {
+	var (
+		err              error
+		inodeNumberAsU64 uint64
+	)
+
+	if 33 != len(fileName) {
+		ok = false
+		return
+	}
+
+	_, err = fmt.Sscanf(fileName, "%016X.%016X", &sequence, &inodeNumberAsU64)
+	inodeNumber = inode.InodeNumber(inodeNumberAsU64)
+	ok = (nil == err)
+
+	return
+}
+
+// evictDiskCacheReadLinesIfNecessary removes the oldest on-disk LogSegment Cache Lines
+// until there is room for roomNeeded additional ones. Callers other than
+// initializeDiskCache() must hold globals.diskCacheMutex.
+//
+func evictDiskCacheReadLinesIfNecessary(roomNeeded uint64) {
+	var (
+		err        error
+		key        logSegmentCacheElementKeyStruct
+		lruElement *list.Element
+	)
+
+	for (uint64(globals.diskCacheReadLineLRU.Len()) + roomNeeded) > globals.config.CacheDirReadCacheLineCount {
+		lruElement = globals.diskCacheReadLineLRU.Front()
+		if nil == lruElement {
+			return
+		}
+		key = lruElement.Value.(logSegmentCacheElementKeyStruct)
+		_ = globals.diskCacheReadLineLRU.Remove(lruElement)
+		delete(globals.diskCacheReadLineMap, key)
+		err = os.Remove(filepath.Join(globals.config.CacheDirPath, diskCacheReadDirName, diskCacheReadLineFileName(key)))
+		if (nil != err) && !os.IsNotExist(err) {
+			logWarnf("evictDiskCacheReadLinesIfNecessary() unable to remove LogSegment Cache Line %016X.%016X: %v", key.logSegmentNumber, key.cacheLineTag, err)
+		}
+	}
+}
+
+// fetchDiskCacheReadLine returns the on-disk copy of the LogSegment Cache Line
+// identified by key if present.
+//
+func fetchDiskCacheReadLine(key logSegmentCacheElementKeyStruct) (buf []byte, ok bool) {
+	var (
+		err        error
+		lruElement *list.Element
+	)
+
+	if "" == globals.config.CacheDirPath {
+		ok = false
+		return
+	}
+
+	globals.diskCacheMutex.Lock()
+
+	lruElement, ok = globals.diskCacheReadLineMap[key]
+	if !ok {
+		globals.diskCacheMutex.Unlock()
+		_ = atomic.AddUint64(&globals.metrics.DiskCacheReadMisses, 1)
+		return
+	}
+
+	globals.diskCacheReadLineLRU.MoveToBack(lruElement)
+
+	buf, err = ioutil.ReadFile(filepath.Join(globals.config.CacheDirPath, diskCacheReadDirName, diskCacheReadLineFileName(key)))
+	if nil != err {
+		logWarnf("fetchDiskCacheReadLine() unable to read LogSegment Cache Line %016X.%016X: %v", key.logSegmentNumber, key.cacheLineTag, err)
+		_ = globals.diskCacheReadLineLRU.Remove(lruElement)
+		delete(globals.diskCacheReadLineMap, key)
+		globals.diskCacheMutex.Unlock()
+		_ = atomic.AddUint64(&globals.metrics.DiskCacheReadMisses, 1)
+		ok = false
+		return
+	}
+
+	globals.diskCacheMutex.Unlock()
+
+	_ = atomic.AddUint64(&globals.metrics.DiskCacheReadHits, 1)
+
+	return
+}
+
+// storeDiskCacheReadLine records buf as the on-disk copy of the LogSegment Cache
+// Line identified by key. The write is made via a temporary file and a rename
+// so that a partially written LogSegment Cache Line is never visible.
+//
+func storeDiskCacheReadLine(key logSegmentCacheElementKeyStruct, buf []byte) {
+	var (
+		err          error
+		fileName     string
+		ok           bool
+		readDirPath  string
+		tempFileName string
+	)
+
+	if ("" == globals.config.CacheDirPath) || (0 == globals.config.CacheDirReadCacheLineCount) {
+		return
+	}
+
+	globals.diskCacheMutex.Lock()
+	defer globals.diskCacheMutex.Unlock()
+
+	_, ok = globals.diskCacheReadLineMap[key]
+	if ok {
+		return
+	}
+
+	evictDiskCacheReadLinesIfNecessary(1)
+
+	readDirPath = filepath.Join(globals.config.CacheDirPath, diskCacheReadDirName)
+	fileName = diskCacheReadLineFileName(key)
+	tempFileName = diskCacheTempFileNamePrefix + fileName
+
+	err = ioutil.WriteFile(filepath.Join(readDirPath, tempFileName), buf, 0600)
+	if nil != err {
+		logWarnf("storeDiskCacheReadLine() unable to write LogSegment Cache Line %s: %v", fileName, err)
+		_ = os.Remove(filepath.Join(readDirPath, tempFileName))
+		return
+	}
+
+	err = os.Rename(filepath.Join(readDirPath, tempFileName), filepath.Join(readDirPath, fileName))
+	if nil != err {
+		logWarnf("storeDiskCacheReadLine() unable to rename LogSegment Cache Line %s: %v", fileName, err)
+		_ = os.Remove(filepath.Join(readDirPath, tempFileName))
+		return
+	}
+
+	globals.diskCacheReadLineMap[key] = globals.diskCacheReadLineLRU.PushBack(key)
+}
+
+// removeDiskCacheReadLine discards the on-disk copy of the LogSegment Cache Line
+// identified by key (e.g. because it failed verification).
+//
+func removeDiskCacheReadLine(key logSegmentCacheElementKeyStruct) {
+	var (
+		err        error
+		lruElement *list.Element
+		ok         bool
+	)
+
+	globals.diskCacheMutex.Lock()
+	defer globals.diskCacheMutex.Unlock()
+
+	lruElement, ok = globals.diskCacheReadLineMap[key]
+	if !ok {
+		return
+	}
+
+	_ = globals.diskCacheReadLineLRU.Remove(lruElement)
+	delete(globals.diskCacheReadLineMap, key)
+
+	err = os.Remove(filepath.Join(globals.config.CacheDirPath, diskCacheReadDirName, diskCacheReadLineFileName(key)))
+	if (nil != err) && !os.IsNotExist(err) {
+		logWarnf("removeDiskCacheReadLine() unable to remove LogSegment Cache Line %016X.%016X: %v", key.logSegmentNumber, key.cacheLineTag, err)
+	}
+}
+
+// openDiskCacheDirtyLogSegment creates the journal for a freshly created chunkedPutContext.
+//
+func (chunkedPutContext *chunkedPutContextStruct) openDiskCacheDirtyLogSegment() {
+	var (
+		err      error
+		fileName string
+	)
+
+	if "" == globals.config.CacheDirPath {
+		chunkedPutContext.diskCacheFile = nil
+		return
+	}
+
+	globals.diskCacheMutex.Lock()
+	fileName = diskCacheDirtyLogSegmentFileName(globals.diskCacheNextDirtySequence, chunkedPutContext.fileInode.InodeNumber)
+	globals.diskCacheNextDirtySequence++
+	globals.diskCacheMutex.Unlock()
+
+	chunkedPutContext.diskCacheFile, err = os.OpenFile(filepath.Join(globals.config.CacheDirPath, diskCacheDirtyDirName, fileName), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0600)
+	if nil != err {
+		logFatalf("(*chunkedPutContextStruct).openDiskCacheDirtyLogSegment() unable to create %s: %v", fileName, err)
+	}
+
+	err = syncDiskCacheDir(filepath.Join(globals.config.CacheDirPath, diskCacheDirtyDirName))
+	if nil != err {
+		logFatalf("(*chunkedPutContextStruct).openDiskCacheDirtyLogSegment() failed: %v", err)
+	}
+}
+
+// appendDiskCacheDirtyLogSegment journals a write of data at fileOffset. The record
+// is handed to the kernel in a single write and fsync'd prior to returning so that,
+// should PFSAgent (or the host) exit once the write has been acknowledged, it will
+// be found (complete) by replayDiskCacheDirtyLogSegments() on the next mount.
+//
+func (chunkedPutContext *chunkedPutContextStruct) appendDiskCacheDirtyLogSegment(fileOffset uint64, data []byte) {
+	var (
+		record []byte
+	)
+
+	if nil == chunkedPutContext.diskCacheFile {
+		return
+	}
+
+	record = make([]byte, diskCacheRecordHeaderSize, diskCacheRecordHeaderSize+len(data))
+	binary.LittleEndian.PutUint64(record[0:8], fileOffset)
+	binary.LittleEndian.PutUint64(record[8:16], uint64(len(data)))
+	record = append(record, data...)
+
+	chunkedPutContext.writeDiskCacheDirtyLogSegmentRecord(record)
+}
+
+// appendDiskCacheDirtyLogSegmentWrote journals that RpcWrote is about to be issued for
+// the LogSegment containerName/objectName. Should the journal survive,
+// replayDiskCacheDirtyLogSegments() will look for the LogSegment in the Inode's
+// ExtentMap to determine if RpcWrote was applied.
+//
+func (chunkedPutContext *chunkedPutContextStruct) appendDiskCacheDirtyLogSegmentWrote(containerName string, objectName string) {
+	var (
+		logSegmentPath string
+		record         []byte
+	)
+
+	if nil == chunkedPutContext.diskCacheFile {
+		return
+	}
+
+	logSegmentPath = containerName + "/" + objectName
+
+	record = make([]byte, diskCacheRecordHeaderSize, diskCacheRecordHeaderSize+len(logSegmentPath))
+	binary.LittleEndian.PutUint64(record[0:8], uint64(len(logSegmentPath)))
+	binary.LittleEndian.PutUint64(record[8:16], diskCacheWroteRecordLength)
+	record = append(record, logSegmentPath...)
+
+	chunkedPutContext.writeDiskCacheDirtyLogSegmentRecord(record)
+}
+
+func (chunkedPutContext *chunkedPutContextStruct) writeDiskCacheDirtyLogSegmentRecord(record []byte) {
+	var (
+		err error
+	)
+
+	_, err = chunkedPutContext.diskCacheFile.Write(record)
+	if nil != err {
+		logFatalf("(*chunkedPutContextStruct).writeDiskCacheDirtyLogSegmentRecord() unable to write to %s: %v", chunkedPutContext.diskCacheFile.Name(), err)
+	}
+
+	err = chunkedPutContext.diskCacheFile.Sync()
+	if nil != err {
+		logFatalf("(*chunkedPutContextStruct).writeDiskCacheDirtyLogSegmentRecord() unable to sync %s: %v", chunkedPutContext.diskCacheFile.Name(), err)
+	}
+}
+
+// closeDiskCacheDirtyLogSegment closes the journal of chunkedPutContext. If remove is
+// set, the journal is also removed as its contents are now safely recorded by ProxyFS.
+//
+func (chunkedPutContext *chunkedPutContextStruct) closeDiskCacheDirtyLogSegment(remove bool) {
+	var (
+		err      error
+		filePath string
+	)
+
+	if nil == chunkedPutContext.diskCacheFile {
+		return
+	}
+
+	filePath = chunkedPutContext.diskCacheFile.Name()
+
+	err = chunkedPutContext.diskCacheFile.Close()
+	if nil != err {
+		logWarnf("(*chunkedPutContextStruct).closeDiskCacheDirtyLogSegment() unable to close %s: %v", filePath, err)
+	}
+
+	chunkedPutContext.diskCacheFile = nil
+
+	if remove {
+		err = os.Remove(filePath)
+		if nil != err {
+			logWarnf("(*chunkedPutContextStruct).closeDiskCacheDirtyLogSegment() unable to remove %s: %v", filePath, err)
+		}
+	}
+}
+
+// replayDiskCacheDirtyLogSegments re-issues the writes recorded in any dirty LogSegment
+// journals left behind by a prior instance of PFSAgent. Each replayed write is itself
+// journaled anew, so a journal is only removed once all of its writes have been replayed.
+// A journal ending in a Wrote record is skipped if its LogSegment is referenced by the
+// Inode's ExtentMap (i.e. the RpcWrote was applied and only the removal of the journal
+// was missed). Should the Inode not be accessible, the journal is retained (and replay
+// of it will be attempted again on the next mount).
+//
+func replayDiskCacheDirtyLogSegments() {
+	var (
+		buf                      []byte
+		dirtyDirPath             string
+		err                      error
+		fileInfo                 os.FileInfo
+		fileInfoSlice            []os.FileInfo
+		fileInode                *fileInodeStruct
+		fileOffset               uint64
+		inodeNumber              inode.InodeNumber
+		length                   uint64
+		ok                       bool
+		record                   diskCacheRecordStruct
+		records                  []diskCacheRecordStruct
+		replayed                 bool
+		sequence                 uint64
+		wroteApplied             bool
+		wroteContainerName       string
+		wroteLogSegmentPath      string
+		wroteLogSegmentPathSplit []string
+		wroteObjectName          string
+		wroteRecorded            bool
+	)
+
+	if "" == globals.config.CacheDirPath {
+		return
+	}
+
+	dirtyDirPath = filepath.Join(globals.config.CacheDirPath, diskCacheDirtyDirName)
+
+	fileInfoSlice, err = ioutil.ReadDir(dirtyDirPath) // Sorted by name... and, hence, by sequence
+	if nil != err {
+		logFatalf("replayDiskCacheDirtyLogSegments() unable to read %s: %v", dirtyDirPath, err)
+	}
+
+	for _, fileInfo = range fileInfoSlice {
+		sequence, inodeNumber, ok = parseDiskCacheDirtyLogSegmentFileName(fileInfo.Name())
+		if !ok || (sequence >= globals.diskCacheNextDirtySequence) {
+			continue
+		}
+
+		buf, err = ioutil.ReadFile(filepath.Join(dirtyDirPath, fileInfo.Name()))
+		if nil != err {
+			logFatalf("replayDiskCacheDirtyLogSegments() unable to read %s: %v", fileInfo.Name(), err)
+		}
+
+		records = make([]diskCacheRecordStruct, 0)
+		wroteRecorded = false
+
+		for len(buf) > 0 {
+			if len(buf) < diskCacheRecordHeaderSize {
+				logWarnf("replayDiskCacheDirtyLogSegments() found truncated record header in %s", fileInfo.Name())
+				break
+			}
+
+			fileOffset = binary.LittleEndian.Uint64(buf[0:8])
+			length = binary.LittleEndian.Uint64(buf[8:16])
+
+			if diskCacheWroteRecordLength == length {
+				// fileOffset actually holds the length of the "<ContainerName>/<ObjectName>" that follows
+
+				if uint64(len(buf)-diskCacheRecordHeaderSize) < fileOffset {
+					logWarnf("replayDiskCacheDirtyLogSegments() found truncated Wrote record in %s", fileInfo.Name())
+					break
+				}
+
+				wroteLogSegmentPath = string(buf[diskCacheRecordHeaderSize : diskCacheRecordHeaderSize+fileOffset])
+				wroteLogSegmentPathSplit = strings.SplitN(wroteLogSegmentPath, "/", 2)
+				if 2 == len(wroteLogSegmentPathSplit) {
+					wroteRecorded = true
+					wroteContainerName = wroteLogSegmentPathSplit[0]
+					wroteObjectName = wroteLogSegmentPathSplit[1]
+				} else {
+					logWarnf("replayDiskCacheDirtyLogSegments() found malformed Wrote record in %s", fileInfo.Name())
+				}
+
+				buf = buf[diskCacheRecordHeaderSize+fileOffset:]
+				continue
+			}
+
+			if uint64(len(buf)-diskCacheRecordHeaderSize) < length {
+				logWarnf("replayDiskCacheDirtyLogSegments() found truncated record data in %s", fileInfo.Name())
+				break
+			}
+
+			records = append(records, diskCacheRecordStruct{fileOffset: fileOffset, data: buf[diskCacheRecordHeaderSize : diskCacheRecordHeaderSize+length]})
+			buf = buf[diskCacheRecordHeaderSize+length:]
+		}
+
+		fileInode = lockInodeWithExclusiveLease(inodeNumber)
+		if nil == fileInode {
+			logWarnf("replayDiskCacheDirtyLogSegments() unable to lock Inode 0x%016X... retaining %s", uint64(inodeNumber), fileInfo.Name())
+			continue
+		}
+
+		err = fileInode.ensureCachedStatPopulatedWhileLocked()
+		if nil != err {
+			logWarnf("replayDiskCacheDirtyLogSegments() unable to stat Inode 0x%016X (%v)... retaining %s", uint64(inodeNumber), err, fileInfo.Name())
+			fileInode.unlock(true)
+			continue
+		}
+
+		if wroteRecorded {
+			wroteApplied, err = fileInode.logSegmentReferencedWhileLocked(wroteContainerName, wroteObjectName, records)
+			if nil != err {
+				logWarnf("replayDiskCacheDirtyLogSegments() unable to fetch ExtentMap of Inode 0x%016X (%v)... retaining %s", uint64(inodeNumber), err, fileInfo.Name())
+				fileInode.unlock(true)
+				continue
+			}
+		} else {
+			wroteApplied = false
+		}
+
+		fileInode.unlock(false)
+
+		if wroteApplied {
+			logInfof("skipping dirty LogSegment %s for Inode 0x%016X as it has already been recorded by ProxyFS", fileInfo.Name(), uint64(inodeNumber))
+		} else {
+			logInfof("replaying dirty LogSegment %s (%d writes) for Inode 0x%016X", fileInfo.Name(), len(records), uint64(inodeNumber))
+
+			replayed = true
+
+			for _, record = range records {
+				fileInode = lockInodeWithExclusiveLease(inodeNumber)
+				if nil == fileInode {
+					logWarnf("replayDiskCacheDirtyLogSegments() unable to lock Inode 0x%016X... retaining %s", uint64(inodeNumber), fileInfo.Name())
+					replayed = false
+					break
+				}
+
+				err = fileInode.ensureCachedStatPopulatedWhileLocked()
+				if nil != err {
+					logWarnf("replayDiskCacheDirtyLogSegments() unable to stat Inode 0x%016X (%v)... retaining %s", uint64(inodeNumber), err, fileInfo.Name())
+					fileInode.unlock(true)
+					replayed = false
+					break
+				}
+
+				fileInode.doWriteWhileLocked(record.fileOffset, record.data)
+
+				fileInode.unlock(false)
+			}
+
+			if !replayed {
+				continue
+			}
+		}
+
+		err = os.Remove(filepath.Join(dirtyDirPath, fileInfo.Name()))
+		if nil != err {
+			logFatalf("replayDiskCacheDirtyLogSegments() unable to remove %s: %v", fileInfo.Name(), err)
+		}
+
+		_ = atomic.AddUint64(&globals.metrics.DiskCacheReplayedLogSegments, 1)
+	}
+}
+
+// logSegmentReferencedWhileLocked returns whether any extent of fileInode.extentMap
+// overlapping the writes journaled in records references the LogSegment
+// containerName/objectName. Note that later writes may have superceded some (but
+// not likely all) of the extents the LogSegment was originally recorded in.
+//
+func (fileInode *fileInodeStruct) logSegmentReferencedWhileLocked(containerName string, objectName string, records []diskCacheRecordStruct) (referenced bool, err error) {
+	var (
+		curExtent        *multiObjectExtentStruct
+		curExtentAsValue sortedmap.Value
+		curExtentIndex   int
+		ok               bool
+		record           diskCacheRecordStruct
+		recordEnd        uint64
+	)
+
+	for _, record = range records {
+		recordEnd = record.fileOffset + uint64(len(record.data))
+
+		err = fileInode.populateExtentMap(record.fileOffset, uint64(len(record.data)))
+		if nil != err {
+			return
+		}
+		if nil == fileInode.extentMap {
+			continue
+		}
+
+		curExtentIndex, _, err = fileInode.extentMap.BisectLeft(record.fileOffset)
+		if nil != err {
+			return
+		}
+		if curExtentIndex < 0 {
+			curExtentIndex = 0
+		}
+
+		for {
+			_, curExtentAsValue, ok, err = fileInode.extentMap.GetByIndex(curExtentIndex)
+			if nil != err {
+				return
+			}
+			if !ok {
+				break
+			}
+
+			curExtent, ok = curExtentAsValue.(*multiObjectExtentStruct)
+			if !ok {
+				logFatalf("logSegmentReferencedWhileLocked() found unexpected extent in fileInode.extentMap")
+			}
+
+			if curExtent.fileOffset >= recordEnd {
+				break
+			}
+
+			if (curExtent.containerName == containerName) && (curExtent.objectName == objectName) && ((curExtent.fileOffset + curExtent.length) > record.fileOffset) {
+				referenced = true
+				return
+			}
+
+			curExtentIndex++
+		}
+	}
+
+	referenced = false
+	err = nil
+	return
+}
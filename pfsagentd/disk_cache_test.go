@@ -0,0 +1,231 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/proxyfs/inode"
+)
+
+func TestDiskCache(t *testing.T) {
+	var (
+		buf               []byte
+		chunkedPutContext *chunkedPutContextStruct
+		err               error
+		fileInfoSlice     []os.FileInfo
+		identity          *diskCacheIdentityStruct
+		inodeNumber       inode.InodeNumber
+		key               [3]logSegmentCacheElementKeyStruct
+		ok                bool
+		sequence          uint64
+		testDir           string
+	)
+
+	testDir, err = ioutil.TempDir(os.TempDir(), "pfsagentd_disk_cache_test_")
+	if nil != err {
+		t.Fatalf("ioutil.TempDir() failed: %v", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(testDir)
+	}()
+
+	globals.config.CacheDirPath = testDir
+	globals.config.CacheDirReadCacheLineCount = 2
+	globals.metrics = &metricsStruct{}
+
+	initializeDiskCache()
+
+	key[0] = logSegmentCacheElementKeyStruct{logSegmentNumber: 0x11, cacheLineTag: 0}
+	key[1] = logSegmentCacheElementKeyStruct{logSegmentNumber: 0x11, cacheLineTag: 1}
+	key[2] = logSegmentCacheElementKeyStruct{logSegmentNumber: 0x22, cacheLineTag: 0}
+
+	// Verify read cache lines are stored, fetched, and evicted in LRU order
+
+	_, ok = fetchDiskCacheReadLine(key[0])
+	if ok {
+		t.Fatalf("fetchDiskCacheReadLine(key[0]) should have missed")
+	}
+
+	storeDiskCacheReadLine(key[0], []byte("Line0"))
+	storeDiskCacheReadLine(key[1], []byte("Line1"))
+
+	buf, ok = fetchDiskCacheReadLine(key[0])
+	if !ok || ("Line0" != string(buf)) {
+		t.Fatalf("fetchDiskCacheReadLine(key[0]) returned \"%s\", %v", string(buf), ok)
+	}
+
+	storeDiskCacheReadLine(key[2], []byte("Line2")) // Should evict key[1]
+
+	_, ok = fetchDiskCacheReadLine(key[1])
+	if ok {
+		t.Fatalf("fetchDiskCacheReadLine(key[1]) should have been evicted")
+	}
+
+	if (1 != globals.metrics.DiskCacheReadHits) || (2 != globals.metrics.DiskCacheReadMisses) {
+		t.Fatalf("unexpected DiskCacheReadHits (%d) and/or DiskCacheReadMisses (%d)", globals.metrics.DiskCacheReadHits, globals.metrics.DiskCacheReadMisses)
+	}
+
+	// Verify a read cache line (e.g. one failing verification) may be discarded
+
+	storeDiskCacheReadLine(key[1], []byte("Line1")) // Should evict key[0]
+
+	removeDiskCacheReadLine(key[1])
+
+	_, ok = fetchDiskCacheReadLine(key[1])
+	if ok {
+		t.Fatalf("fetchDiskCacheReadLine(key[1]) should have been removed")
+	}
+	_, err = os.Stat(filepath.Join(testDir, diskCacheReadDirName, diskCacheReadLineFileName(key[1])))
+	if !os.IsNotExist(err) {
+		t.Fatalf("removed LogSegment Cache Line should not exist (err: %v)", err)
+	}
+
+	// Verify a dirty LogSegment journal records each write
+
+	chunkedPutContext = &chunkedPutContextStruct{
+		fileInode: &fileInodeStruct{InodeNumber: inode.InodeNumber(0x33)},
+	}
+
+	chunkedPutContext.openDiskCacheDirtyLogSegment()
+	chunkedPutContext.appendDiskCacheDirtyLogSegment(0x100, []byte("ABC"))
+	chunkedPutContext.appendDiskCacheDirtyLogSegment(0x200, []byte("DE"))
+	chunkedPutContext.appendDiskCacheDirtyLogSegmentWrote("Container", "0000000000000044")
+	chunkedPutContext.closeDiskCacheDirtyLogSegment(false)
+
+	uninitializeDiskCache()
+
+	// Verify both read cache lines and the dirty LogSegment journal survive a restart
+
+	initializeDiskCache()
+
+	buf, ok = fetchDiskCacheReadLine(key[2])
+	if !ok || ("Line2" != string(buf)) {
+		t.Fatalf("fetchDiskCacheReadLine(key[2]) after restart returned \"%s\", %v", string(buf), ok)
+	}
+
+	if 1 != globals.diskCacheNextDirtySequence {
+		t.Fatalf("globals.diskCacheNextDirtySequence after restart should have been 1 (was %d)", globals.diskCacheNextDirtySequence)
+	}
+
+	fileInfoSlice, err = ioutil.ReadDir(filepath.Join(testDir, diskCacheDirtyDirName))
+	if nil != err {
+		t.Fatalf("ioutil.ReadDir() failed: %v", err)
+	}
+	if 1 != len(fileInfoSlice) {
+		t.Fatalf("expected a single dirty LogSegment journal (found %d)", len(fileInfoSlice))
+	}
+
+	sequence, inodeNumber, ok = parseDiskCacheDirtyLogSegmentFileName(fileInfoSlice[0].Name())
+	if !ok || (0 != sequence) || (inode.InodeNumber(0x33) != inodeNumber) {
+		t.Fatalf("parseDiskCacheDirtyLogSegmentFileName(\"%s\") returned %v, %v, %v", fileInfoSlice[0].Name(), sequence, inodeNumber, ok)
+	}
+
+	buf, err = ioutil.ReadFile(filepath.Join(testDir, diskCacheDirtyDirName, fileInfoSlice[0].Name()))
+	if nil != err {
+		t.Fatalf("ioutil.ReadFile() failed: %v", err)
+	}
+	if (3*diskCacheRecordHeaderSize + 5 + len("Container/0000000000000044")) != len(buf) {
+		t.Fatalf("dirty LogSegment journal has unexpected length: %d", len(buf))
+	}
+	if (0x100 != binary.LittleEndian.Uint64(buf[0:8])) || (3 != binary.LittleEndian.Uint64(buf[8:16])) || !bytes.Equal([]byte("ABC"), buf[16:19]) {
+		t.Fatalf("dirty LogSegment journal has unexpected first record")
+	}
+	if (0x200 != binary.LittleEndian.Uint64(buf[19:27])) || (2 != binary.LittleEndian.Uint64(buf[27:35])) || !bytes.Equal([]byte("DE"), buf[35:37]) {
+		t.Fatalf("dirty LogSegment journal has unexpected second record")
+	}
+	if (uint64(len("Container/0000000000000044")) != binary.LittleEndian.Uint64(buf[37:45])) || (diskCacheWroteRecordLength != binary.LittleEndian.Uint64(buf[45:53])) || ("Container/0000000000000044" != string(buf[53:])) {
+		t.Fatalf("dirty LogSegment journal has unexpected Wrote record")
+	}
+
+	// Verify a completed dirty LogSegment journal is removed
+
+	chunkedPutContext.openDiskCacheDirtyLogSegment()
+	chunkedPutContext.appendDiskCacheDirtyLogSegment(0x300, []byte("F"))
+	chunkedPutContext.closeDiskCacheDirtyLogSegment(true)
+
+	fileInfoSlice, err = ioutil.ReadDir(filepath.Join(testDir, diskCacheDirtyDirName))
+	if nil != err {
+		t.Fatalf("ioutil.ReadDir() failed: %v", err)
+	}
+	if 1 != len(fileInfoSlice) {
+		t.Fatalf("expected only the original dirty LogSegment journal (found %d)", len(fileInfoSlice))
+	}
+
+	// Verify a cache of unknown identity is refused while dirty LogSegment journals are present
+
+	identity = &diskCacheIdentityStruct{VolumeName: "TestVolume", AccountName: "TestAccount", RootDirCRTimeNs: 1}
+
+	err = checkDiskCacheIdentity(identity)
+	if nil == err {
+		t.Fatalf("checkDiskCacheIdentity() should have failed with a dirty LogSegment journal present")
+	}
+
+	_, ok = fetchDiskCacheReadLine(key[2])
+	if !ok {
+		t.Fatalf("fetchDiskCacheReadLine(key[2]) should have survived a failed checkDiskCacheIdentity()")
+	}
+
+	err = os.Remove(filepath.Join(testDir, diskCacheDirtyDirName, fileInfoSlice[0].Name()))
+	if nil != err {
+		t.Fatalf("os.Remove() failed: %v", err)
+	}
+
+	// Verify a cache of unknown identity has its read cache lines discarded and identity recorded
+
+	err = checkDiskCacheIdentity(identity)
+	if nil != err {
+		t.Fatalf("checkDiskCacheIdentity() failed: %v", err)
+	}
+
+	_, ok = fetchDiskCacheReadLine(key[2])
+	if ok {
+		t.Fatalf("fetchDiskCacheReadLine(key[2]) should have been discarded")
+	}
+
+	storeDiskCacheReadLine(key[0], []byte("Line0"))
+
+	// Verify a cache of the same identity is retained
+
+	err = checkDiskCacheIdentity(&diskCacheIdentityStruct{VolumeName: "TestVolume", AccountName: "TestAccount", RootDirCRTimeNs: 1})
+	if nil != err {
+		t.Fatalf("checkDiskCacheIdentity() failed: %v", err)
+	}
+
+	_, ok = fetchDiskCacheReadLine(key[0])
+	if !ok {
+		t.Fatalf("fetchDiskCacheReadLine(key[0]) should have been retained")
+	}
+
+	// Verify a cache populated prior to a reformat of the Volume has its read cache lines discarded
+
+	err = checkDiskCacheIdentity(&diskCacheIdentityStruct{VolumeName: "TestVolume", AccountName: "TestAccount", RootDirCRTimeNs: 2})
+	if nil != err {
+		t.Fatalf("checkDiskCacheIdentity() failed: %v", err)
+	}
+
+	_, ok = fetchDiskCacheReadLine(key[0])
+	if ok {
+		t.Fatalf("fetchDiskCacheReadLine(key[0]) should have been discarded")
+	}
+
+	fileInfoSlice, err = ioutil.ReadDir(filepath.Join(testDir, diskCacheReadDirName))
+	if nil != err {
+		t.Fatalf("ioutil.ReadDir() failed: %v", err)
+	}
+	if 0 != len(fileInfoSlice) {
+		t.Fatalf("expected no LogSegment Cache Lines (found %d)", len(fileInfoSlice))
+	}
+
+	uninitializeDiskCache()
+
+	globals.config.CacheDirPath = ""
+	globals.config.CacheDirReadCacheLineCount = 0
+	globals.metrics = nil
+}
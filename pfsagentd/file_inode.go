@@ -5,6 +5,7 @@ package main
 
 import (
 	"container/list"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,6 +19,8 @@ import (
 
 	"github.com/NVIDIA/proxyfs/inode"
 	"github.com/NVIDIA/proxyfs/jrpcfs"
+	"github.com/NVIDIA/proxyfs/keyring"
+	"github.com/NVIDIA/proxyfs/retryrpc"
 )
 
 func (fileInode *fileInodeStruct) doFlushIfNecessary() {
@@ -287,6 +290,20 @@ func (chunkedPutContext *chunkedPutContextStruct) performChunkedPut() {
 	chunkedPutContext.containerName = containerAndObjectNamesSplit[0]
 	chunkedPutContext.objectName = containerAndObjectNamesSplit[1]
 
+	if nil != globals.keyring {
+		chunkedPutContext.objectNumber, err = strconv.ParseUint(chunkedPutContext.objectName, 16, 64)
+		if nil != err {
+			logFatalf("*chunkedPutContextStruct.performChunkedPut() provisioned un-parseable objectName: \"%s\" (err: %v)", chunkedPutContext.objectName, err)
+		}
+
+		chunkedPutContext.compression = &inode.LogSegmentCompressionStruct{
+			Algorithm: inode.CompressionNone,
+			Encrypted: true,
+			Chunk:     make([]inode.LogSegmentCompressedChunkStruct, 0),
+		}
+		chunkedPutContext.sealedBuf = make([]byte, 0)
+	}
+
 	swiftStorageURL = fetchStorageURL()
 
 	chunkedPutRequest, err = http.NewRequest(http.MethodPut, swiftStorageURL+"/"+chunkedPutContext.containerName+"/"+chunkedPutContext.objectName, chunkedPutContext)
@@ -342,8 +359,12 @@ func (chunkedPutContext *chunkedPutContextStruct) complete() {
 		ObjectOffset:  make([]uint64, extentMapLen),
 		Length:        make([]uint64, extentMapLen),
 		WroteTimeNs:   uint64(time.Now().UnixNano()),
+		Checksums:     inode.NewLogSegmentChecksums(globals.config.ReadCacheLineSize),
+		Compression:   chunkedPutContext.compression,
 	}
 
+	wroteRequest.Checksums.Append(chunkedPutContext.buf)
+
 	for curExtentIndex = 0; curExtentIndex < extentMapLen; curExtentIndex++ {
 		_, curExtentAsValue, ok, err = chunkedPutContext.extentMap.GetByIndex(curExtentIndex)
 		if nil != err {
@@ -364,11 +385,17 @@ func (chunkedPutContext *chunkedPutContextStruct) complete() {
 			objectName:    chunkedPutContext.objectName,
 			objectOffset:  curExtentAsSingleObjectExtent.objectOffset,
 			length:        curExtentAsSingleObjectExtent.length,
+			compression:   chunkedPutContext.compression,
+			checksums:     wroteRequest.Checksums,
 		}
 
 		fileInode.updateExtentMap(curExtentAsMultiObjectExtent)
 	}
 
+	// Should we exit before the journal is removed, record how replay may detect RpcWrote was applied
+
+	chunkedPutContext.appendDiskCacheDirtyLogSegmentWrote(chunkedPutContext.containerName, chunkedPutContext.objectName)
+
 	wroteReply = &jrpcfs.WroteReply{}
 
 	err = globals.retryRPCClient.Send("RpcWrote", wroteRequest, wroteReply)
@@ -377,6 +404,10 @@ func (chunkedPutContext *chunkedPutContextStruct) complete() {
 		logWarnf("TODO (i.e. convert to logFatalf) *chunkedPutContextStruct.complete() failed Server.RpcWrote: %v", err)
 	}
 
+	// Now that ProxyFS has recorded the LogSegment, any journal of it may be discarded
+
+	chunkedPutContext.closeDiskCacheDirtyLogSegment(nil == err)
+
 	// Remove this chunkedPutContext from fileInode.chunkedPutList
 
 	_ = fileInode.chunkedPutList.Remove(chunkedPutContext.chunkedPutListElement)
@@ -390,6 +421,7 @@ func (chunkedPutContext *chunkedPutContextStruct) complete() {
 
 func (chunkedPutContext *chunkedPutContextStruct) Read(p []byte) (n int, err error) {
 	var (
+		sendBuf                []byte
 		wakeChanOpenOrNonEmpty bool
 	)
 
@@ -397,7 +429,14 @@ func (chunkedPutContext *chunkedPutContextStruct) Read(p []byte) (n int, err err
 
 	// grantedLock = chunkedPutContext.fileInode.getExclusiveLock()
 
-	n = len(chunkedPutContext.buf) - chunkedPutContext.pos
+	if nil == chunkedPutContext.compression {
+		sendBuf = chunkedPutContext.buf
+	} else {
+		chunkedPutContext.sealPendingChunk()
+		sendBuf = chunkedPutContext.sealedBuf
+	}
+
+	n = len(sendBuf) - chunkedPutContext.pos
 
 	if n < 0 {
 		logFatalf("*chunkedPutContextStruct.Read() called with pos past beyond len(chunkedPutContext.buf)")
@@ -410,7 +449,7 @@ func (chunkedPutContext *chunkedPutContextStruct) Read(p []byte) (n int, err err
 			n = len(p)
 		}
 
-		copy(p, chunkedPutContext.buf[chunkedPutContext.pos:chunkedPutContext.pos+n])
+		copy(p, sendBuf[chunkedPutContext.pos:chunkedPutContext.pos+n])
 
 		chunkedPutContext.pos += n
 
@@ -438,6 +477,36 @@ func (chunkedPutContext *chunkedPutContextStruct) Read(p []byte) (n int, err err
 	return
 }
 
+// sealPendingChunk seals the bytes appended to buf since the prior call as the next
+// chunk of the LogSegment. Once sealed, a chunk is never resealed so that a retried
+// Chunked PUT resends precisely the bytes described by compression.
+//
+func (chunkedPutContext *chunkedPutContextStruct) sealPendingChunk() {
+	var (
+		bufLen      int
+		err         error
+		sealedBuf   []byte
+		unsealedBuf []byte
+	)
+
+	bufLen = len(chunkedPutContext.buf)
+
+	if bufLen == chunkedPutContext.sealedLen {
+		return
+	}
+
+	unsealedBuf = chunkedPutContext.buf[chunkedPutContext.sealedLen:bufLen]
+
+	sealedBuf, err = globals.keyring.Seal(unsealedBuf, keyring.ObjectAdditionalData(chunkedPutContext.objectNumber, uint64(len(chunkedPutContext.sealedBuf))))
+	if nil != err {
+		logFatalf("*chunkedPutContextStruct.sealPendingChunk() unable to seal LogSegment %s/%s chunk: %v", chunkedPutContext.containerName, chunkedPutContext.objectName, err)
+	}
+
+	chunkedPutContext.compression.Append(uint64(len(unsealedBuf)), uint64(len(sealedBuf)))
+	chunkedPutContext.sealedBuf = append(chunkedPutContext.sealedBuf, sealedBuf...)
+	chunkedPutContext.sealedLen = bufLen
+}
+
 func (chunkedPutContext *chunkedPutContextStruct) Close() (err error) {
 	// Make sure Read() gets a chance to cleanly exit
 
@@ -580,7 +649,8 @@ Restart:
 }
 
 // populateExtentMapHelper fetches an ExtentMapChunk anchored by fileOffset and inserts
-// it into fileInode.extentMap using updateExtentMap().
+// it into fileInode.extentMap using updateExtentMap(). The ExtentMapChunk is streamed
+// a frame at a time so that a large one need not be held in memory all at once.
 //
 // If returned exhausted is true, there are no more extentMapEntry's to populate
 //
@@ -593,11 +663,14 @@ func (fileInode *fileInodeStruct) populateExtentMapHelper(fileOffset uint64) (ex
 		extentMapEntry             *inode.ExtentMapEntryStruct
 		extentMapLength            int
 		fetchExtentMapChunkReply   *jrpcfs.FetchExtentMapChunkReply
-		fetchExtentMapChunkRequest *jrpcfs.FetchExtentMapChunkRequest
+		fetchExtentMapChunkRequest *jrpcfs.FetchExtentMapChunkStreamRequest
+		fetchExtentMapChunkStream  *retryrpc.ClientStream
+		fileSize                   uint64
+		numExtentMapEntries        int
 		ok                         bool
 	)
 
-	fetchExtentMapChunkRequest = &jrpcfs.FetchExtentMapChunkRequest{
+	fetchExtentMapChunkRequest = &jrpcfs.FetchExtentMapChunkStreamRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
 			InodeNumber: int64(fileInode.InodeNumber),
@@ -607,71 +680,94 @@ func (fileInode *fileInodeStruct) populateExtentMapHelper(fileOffset uint64) (ex
 		MaxEntriesBeforeFileOffset: int64(globals.config.FetchExtentsBeforeFileOffset),
 	}
 
-	fetchExtentMapChunkReply = &jrpcfs.FetchExtentMapChunkReply{}
-
-	err = globals.retryRPCClient.Send("RpcFetchExtentMapChunk", fetchExtentMapChunkRequest, fetchExtentMapChunkReply)
+	fetchExtentMapChunkStream, err = globals.retryRPCClient.SendStream(context.Background(), "RpcFetchExtentMapChunkStream", fetchExtentMapChunkRequest)
 	if nil != err {
 		return
 	}
+	defer fetchExtentMapChunkStream.Close()
 
-	if 0 == len(fetchExtentMapChunkReply.ExtentMapEntry) {
-		exhausted = true
-		return
-	}
+	numExtentMapEntries = 0
 
-	exhausted = false
+	for {
+		fetchExtentMapChunkReply = &jrpcfs.FetchExtentMapChunkReply{}
+
+		err = fetchExtentMapChunkStream.Recv(fetchExtentMapChunkReply)
+		if io.EOF == err {
+			err = nil
+			break
+		}
+		if nil != err {
+			return
+		}
+
+		if 0 == len(fetchExtentMapChunkReply.ExtentMapEntry) {
+			continue
+		}
+
+		numExtentMapEntries += len(fetchExtentMapChunkReply.ExtentMapEntry)
+		fileSize = fetchExtentMapChunkReply.FileSize
+
+		curFileOffset = fetchExtentMapChunkReply.FileOffsetRangeStart
 
-	curFileOffset = fetchExtentMapChunkReply.FileOffsetRangeStart
+		for curExtentMapChunkIndex = range fetchExtentMapChunkReply.ExtentMapEntry {
+			extentMapEntry = &fetchExtentMapChunkReply.ExtentMapEntry[curExtentMapChunkIndex]
 
-	for curExtentMapChunkIndex = range fetchExtentMapChunkReply.ExtentMapEntry {
-		extentMapEntry = &fetchExtentMapChunkReply.ExtentMapEntry[curExtentMapChunkIndex]
+			if curFileOffset < extentMapEntry.FileOffset {
+				// We need to insert a preceeding zero-fill extent first
 
-		if curFileOffset < extentMapEntry.FileOffset {
-			// We need to insert a preceeding zero-fill extent first
+				curExtent = &multiObjectExtentStruct{
+					fileOffset:    curFileOffset,
+					containerName: "",
+					objectName:    "",
+					objectOffset:  0,
+					length:        extentMapEntry.FileOffset - curFileOffset,
+				}
+
+				fileInode.updateExtentMap(curExtent)
+			}
+
+			// Insert the actual extent
 
 			curExtent = &multiObjectExtentStruct{
-				fileOffset:    curFileOffset,
-				containerName: "",
-				objectName:    "",
-				objectOffset:  0,
-				length:        extentMapEntry.FileOffset - curFileOffset,
+				fileOffset:    extentMapEntry.FileOffset,
+				containerName: extentMapEntry.ContainerName,
+				objectName:    extentMapEntry.ObjectName,
+				objectOffset:  extentMapEntry.LogSegmentOffset,
+				length:        extentMapEntry.Length,
+				compression:   extentMapEntry.Compression,
+				checksums:     extentMapEntry.Checksums,
 			}
 
 			fileInode.updateExtentMap(curExtent)
-		}
-
-		// Insert the actual extent
 
-		curExtent = &multiObjectExtentStruct{
-			fileOffset:    extentMapEntry.FileOffset,
-			containerName: extentMapEntry.ContainerName,
-			objectName:    extentMapEntry.ObjectName,
-			objectOffset:  extentMapEntry.LogSegmentOffset,
-			length:        extentMapEntry.Length,
+			curFileOffset = extentMapEntry.FileOffset + extentMapEntry.Length
 		}
 
-		fileInode.updateExtentMap(curExtent)
+		if curFileOffset < fetchExtentMapChunkReply.FileOffsetRangeEnd {
+			// We need to insert a trailing  zero-fill extent
 
-		curFileOffset = extentMapEntry.FileOffset + extentMapEntry.Length
-	}
-
-	if curFileOffset < fetchExtentMapChunkReply.FileOffsetRangeEnd {
-		// We need to insert a trailing  zero-fill extent
+			curExtent = &multiObjectExtentStruct{
+				fileOffset:    curFileOffset,
+				containerName: "",
+				objectName:    "",
+				objectOffset:  0,
+				length:        fetchExtentMapChunkReply.FileOffsetRangeEnd - curFileOffset,
+			}
 
-		curExtent = &multiObjectExtentStruct{
-			fileOffset:    curFileOffset,
-			containerName: "",
-			objectName:    "",
-			objectOffset:  0,
-			length:        fetchExtentMapChunkReply.FileOffsetRangeEnd - curFileOffset,
+			_, err = fileInode.extentMap.Put(curExtent.fileOffset, curExtent)
+			if nil != err {
+				logFatalf("populateExtentMap() couldn't insert zero-fill extent [Case 2]: %v", err)
+			}
 		}
+	}
 
-		_, err = fileInode.extentMap.Put(curExtent.fileOffset, curExtent)
-		if nil != err {
-			logFatalf("populateExtentMap() couldn't insert zero-fill extent [Case 2]: %v", err)
-		}
+	if 0 == numExtentMapEntries {
+		exhausted = true
+		return
 	}
 
+	exhausted = false
+
 	// Finally, we need to trim, as necessary, excess extents
 
 	extentMapLength, err = fileInode.extentMap.Len()
@@ -699,13 +795,13 @@ func (fileInode *fileInodeStruct) populateExtentMapHelper(fileOffset uint64) (ex
 			logFatalf("populateExtentMap() couldn't get last extent [Case 3]")
 		}
 
-		if (curExtent.fileOffset + curExtent.length) <= fetchExtentMapChunkReply.FileSize {
+		if (curExtent.fileOffset + curExtent.length) <= fileSize {
 			// Last extent does not extend beyond FileSize... so we are done
 
 			return
 		}
 
-		if curExtent.fileOffset < fetchExtentMapChunkReply.FileSize {
+		if curExtent.fileOffset < fileSize {
 			// Last extent crossed FileSize boundary... truncate it and we are done
 
 			curExtent.length = fileInode.cachedStat.Size - curExtent.fileOffset
@@ -788,6 +884,8 @@ func (fileInode *fileInodeStruct) updateExtentMap(newExtent *multiObjectExtentSt
 					objectName:    prevExtent.objectName,
 					objectOffset:  prevExtent.objectOffset + prevExtentNewLength,
 					length:        prevExtent.length - prevExtentNewLength,
+					compression:   prevExtent.compression,
+					checksums:     prevExtent.checksums,
 				}
 
 				prevExtent.length = prevExtentNewLength
@@ -855,6 +953,8 @@ func (fileInode *fileInodeStruct) updateExtentMap(newExtent *multiObjectExtentSt
 				objectName:    curExtent.objectName,
 				objectOffset:  curExtent.objectOffset + curExtentLostLength,
 				length:        curExtent.length - curExtentLostLength,
+				compression:   curExtent.compression,
+				checksums:     curExtent.checksums,
 			}
 
 			ok, err = fileInode.extentMap.Put(splitExtent.fileOffset, splitExtent)
@@ -949,6 +1049,8 @@ func (fileInode *fileInodeStruct) getReadPlan(fileOffset uint64, length uint64)
 			objectName:    curMultiObjectExtent.objectName, // May be == ""
 			objectOffset:  curMultiObjectExtent.objectOffset + (curFileOffset - curMultiObjectExtent.fileOffset),
 			length:        curMultiObjectExtent.length - (curFileOffset - curMultiObjectExtent.fileOffset),
+			compression:   curMultiObjectExtent.compression,
+			checksums:     curMultiObjectExtent.checksums,
 		}
 
 		if remainingLength < multiObjectReadPlanStep.length {
@@ -1114,6 +1216,8 @@ func (chunkedPutContext *chunkedPutContextStruct) getReadPlanHelper(fileOffset u
 						objectName:    inReadPlanStepAsMultiObjectExtent.objectName,
 						objectOffset:  inReadPlanStepAsMultiObjectExtent.objectOffset + (curFileOffset - inReadPlanStepAsMultiObjectExtent.fileOffset),
 						length:        overlapExtentWithLink.fileOffset - curFileOffset,
+						compression:   inReadPlanStepAsMultiObjectExtent.compression,
+						checksums:     inReadPlanStepAsMultiObjectExtent.checksums,
 					}
 
 					outReadPlan = append(outReadPlan, outReadPlanStepAsMultiObjectExtent)
@@ -1153,6 +1257,8 @@ func (chunkedPutContext *chunkedPutContextStruct) getReadPlanHelper(fileOffset u
 					objectName:    inReadPlanStepAsMultiObjectExtent.objectName,
 					objectOffset:  inReadPlanStepAsMultiObjectExtent.objectOffset + (curFileOffset - inReadPlanStepAsMultiObjectExtent.fileOffset),
 					length:        remainingLength,
+					compression:   inReadPlanStepAsMultiObjectExtent.compression,
+					checksums:     inReadPlanStepAsMultiObjectExtent.checksums,
 				}
 
 				outReadPlan = append(outReadPlan, outReadPlanStepAsMultiObjectExtent)
@@ -1334,20 +1440,37 @@ func (chunkedPutContext *chunkedPutContextStruct) getReadPlanHelper(fileOffset u
 	}
 }
 
-func fetchLogSegmentCacheLine(containerName string, objectName string, offset uint64) (logSegmentCacheElement *logSegmentCacheElementStruct) {
+// fetchLogSegmentCacheLine returns the LogSegment Cache Line containing offset. Should
+// the LogSegment be compressed (or sealed), both offset and the LogSegment Cache Line
+// are logical (i.e. uncompressed and unsealed) and compression is used to fetch and
+// decompress (or open) the chunks covering the LogSegment Cache Line. Should the
+// LogSegment have checksums, the LogSegment Cache Line is verified before being
+// inserted in the LogSegment Cache (or the on-disk cache). A LogSegment Cache Line
+// failing verification is reported just as one that could not be fetched.
+//
+func fetchLogSegmentCacheLine(containerName string, objectName string, compression *inode.LogSegmentCompressionStruct, checksums *inode.LogSegmentChecksumsStruct, offset uint64) (logSegmentCacheElement *logSegmentCacheElementStruct) {
+	logSegmentCacheElement = fetchOrPrefetchLogSegmentCacheLine(containerName, objectName, compression, checksums, offset, false)
+	return
+}
+
+// fetchOrPrefetchLogSegmentCacheLine implements fetchLogSegmentCacheLine(). If prefetch
+// is set, the caller is read-ahead in which case nothing is done should the LogSegment
+// Cache Line already be present (or being fetched) and the new LogSegment Cache Line is
+// marked as prefetched so that its eventual use (or eviction unused) may be tracked.
+//
+func fetchOrPrefetchLogSegmentCacheLine(containerName string, objectName string, compression *inode.LogSegmentCompressionStruct, checksums *inode.LogSegmentChecksumsStruct, offset uint64, prefetch bool) (logSegmentCacheElement *logSegmentCacheElementStruct) {
 	var (
+		corruptOffset                           uint64
 		err                                     error
-		getRequest                              *http.Request
+		fetchPhysical                           func(physicalOffset uint64, physicalLength uint64) (physicalBuf []byte, err error)
 		logSegmentCacheElementGetEndime         time.Time
 		logSegmentCacheElementGetStartTime      time.Time
 		logSegmentCacheElementKey               logSegmentCacheElementKeyStruct
 		logSegmentCacheElementToEvict           *logSegmentCacheElementStruct
 		logSegmentCacheElementToEvictKey        logSegmentCacheElementKeyStruct
 		logSegmentCacheElementToEvictLRUElement *list.Element
-		logSegmentEnd                           uint64
 		logSegmentStart                         uint64
 		ok                                      bool
-		statusCode                              int
 		swiftStorageURL                         string
 		url                                     string
 	)
@@ -1369,11 +1492,24 @@ func fetchLogSegmentCacheLine(containerName string, objectName string, offset ui
 
 	logSegmentCacheElement, ok = globals.logSegmentCacheMap[logSegmentCacheElementKey]
 
+	if ok && prefetch {
+		// Already present (or being fetched)... so nothing to prefetch
+
+		globals.Unlock()
+
+		return
+	}
+
 	if ok {
 		// Found it... so indicate cache hit & move it to MRU end of LRU
 
 		_ = atomic.AddUint64(&globals.metrics.ReadCacheHits, 1)
 
+		if logSegmentCacheElement.prefetched {
+			logSegmentCacheElement.prefetched = false
+			_ = atomic.AddUint64(&globals.metrics.ReadAheadHits, 1)
+		}
+
 		globals.logSegmentCacheLRU.MoveToBack(logSegmentCacheElement.cacheLRUElement)
 
 		globals.Unlock()
@@ -1396,7 +1532,11 @@ func fetchLogSegmentCacheLine(containerName string, objectName string, offset ui
 
 	// Cache miss
 
-	_ = atomic.AddUint64(&globals.metrics.ReadCacheMisses, 1)
+	if prefetch {
+		_ = atomic.AddUint64(&globals.metrics.ReadAheadPrefetches, 1)
+	} else {
+		_ = atomic.AddUint64(&globals.metrics.ReadCacheMisses, 1)
+	}
 
 	// Make room for new LogSegment Cache Line if necessary
 
@@ -1408,6 +1548,9 @@ func fetchLogSegmentCacheLine(containerName string, objectName string, offset ui
 		if nil != err {
 			logFatalf("fetchLogSegmentCacheLine() evicting hit un-parseable objectName: \"%s\" (err: %v)", logSegmentCacheElementToEvict.objectName, err)
 		}
+		if logSegmentCacheElementToEvict.prefetched {
+			_ = atomic.AddUint64(&globals.metrics.ReadAheadWaste, 1)
+		}
 		delete(globals.logSegmentCacheMap, logSegmentCacheElementToEvictKey)
 		globals.logSegmentCacheLRU.Remove(logSegmentCacheElementToEvictLRUElement)
 	}
@@ -1419,6 +1562,7 @@ func fetchLogSegmentCacheLine(containerName string, objectName string, offset ui
 		containerName:  containerName,
 		objectName:     objectName,
 		startingOffset: logSegmentCacheElementKey.cacheLineTag * globals.config.ReadCacheLineSize,
+		prefetched:     prefetch,
 	}
 
 	logSegmentCacheElement.Add(1)
@@ -1430,27 +1574,69 @@ func fetchLogSegmentCacheLine(containerName string, objectName string, offset ui
 
 	globals.Unlock()
 
-	// Issue GET for it
+	// Check for it in the on-disk cache (if enabled) before issuing GET for it
 
-	swiftStorageURL = fetchStorageURL()
+	logSegmentStart = logSegmentCacheElementKey.cacheLineTag * globals.config.ReadCacheLineSize
 
-	url = swiftStorageURL + "/" + containerName + "/" + objectName
+	logSegmentCacheElement.buf, ok = fetchDiskCacheReadLine(logSegmentCacheElementKey)
+	if ok {
+		if nil != checksums {
+			corruptOffset, err = checksums.VerifyCovered(logSegmentStart, logSegmentCacheElement.buf)
+			if nil != err {
+				// Our on-disk copy is damaged... so discard it and fall through to issue a GET
 
-	logSegmentStart = logSegmentCacheElementKey.cacheLineTag * globals.config.ReadCacheLineSize
-	logSegmentEnd = logSegmentStart + globals.config.ReadCacheLineSize - 1
+				_ = atomic.AddUint64(&globals.metrics.ReadCacheChecksumMismatches, 1)
+				logWarnf("fetchLogSegmentCacheLine() on-disk copy of LogSegment %s/%s at Offset 0x%016X failed verification at Offset 0x%016X", containerName, objectName, logSegmentStart, corruptOffset)
+				removeDiskCacheReadLine(logSegmentCacheElementKey)
+				ok = false
+			}
+		}
+		if ok {
+			globals.Lock()
+			logSegmentCacheElement.state = logSegmentCacheElementStateGetSuccessful
+			globals.Unlock()
 
-	getRequest, err = http.NewRequest(http.MethodGet, url, nil)
-	if nil != err {
-		logFatalf("unable to create GET http.Request (,%s,): %v", url)
+			logSegmentCacheElement.Done()
+
+			return
+		}
 	}
 
-	getRequest.Header.Add("Range", fmt.Sprintf("bytes=%d-%d", logSegmentStart, logSegmentEnd))
+	// Issue GET for it
+
+	swiftStorageURL = fetchStorageURL()
+
+	url = swiftStorageURL + "/" + containerName + "/" + objectName
 
 	logSegmentCacheElementGetStartTime = time.Now()
 
-	_, logSegmentCacheElement.buf, ok, statusCode = doHTTPRequest(getRequest, http.StatusOK, http.StatusPartialContent)
-	if !ok {
-		logFatalf("fetchLogSegmentCacheLine() failed with unexpected statusCode: %v", statusCode)
+	if nil == compression {
+		logSegmentCacheElement.buf = fetchLogSegmentRange(url, logSegmentStart, globals.config.ReadCacheLineSize)
+		ok = true
+	} else {
+		fetchPhysical = func(physicalOffset uint64, physicalLength uint64) (physicalBuf []byte, err error) {
+			physicalBuf = fetchLogSegmentRange(url, physicalOffset, physicalLength)
+			err = nil
+			return
+		}
+		if compression.Encrypted {
+			logSegmentCacheElement.buf, err = compression.ReadSealed(logSegmentStart, globals.config.ReadCacheLineSize, fetchPhysical, openLogSegmentChunk(logSegmentCacheElementKey.logSegmentNumber))
+		} else {
+			logSegmentCacheElement.buf, err = compression.Read(logSegmentStart, globals.config.ReadCacheLineSize, fetchPhysical)
+		}
+		ok = (nil == err)
+		if !ok {
+			logWarnf("fetchLogSegmentCacheLine() unable to decompress or open LogSegment %s/%s at Offset 0x%016X: %v", containerName, objectName, logSegmentStart, err)
+		}
+	}
+
+	if ok && (nil != checksums) {
+		corruptOffset, err = checksums.VerifyCovered(logSegmentStart, logSegmentCacheElement.buf)
+		ok = (nil == err)
+		if !ok {
+			_ = atomic.AddUint64(&globals.metrics.ReadCacheChecksumMismatches, 1)
+			logErrorf("fetchLogSegmentCacheLine() LogSegment %s/%s at Offset 0x%016X failed verification at Offset 0x%016X", containerName, objectName, logSegmentStart, corruptOffset)
+		}
 	}
 
 	logSegmentCacheElementGetEndime = time.Now()
@@ -1461,6 +1647,8 @@ func fetchLogSegmentCacheLine(containerName string, objectName string, offset ui
 		logSegmentCacheElement.state = logSegmentCacheElementStateGetSuccessful
 
 		globals.stats.LogSegmentGetUsec.Add(uint64(logSegmentCacheElementGetEndime.Sub(logSegmentCacheElementGetStartTime) / time.Microsecond))
+
+		storeDiskCacheReadLine(logSegmentCacheElementKey, logSegmentCacheElement.buf)
 	} else {
 		logSegmentCacheElement.state = logSegmentCacheElementStateGetFailed
 
@@ -1479,6 +1667,49 @@ func fetchLogSegmentCacheLine(containerName string, objectName string, offset ui
 	return
 }
 
+// openLogSegmentChunk returns the func used to open each sealed chunk of the (encrypted)
+// LogSegment numbered objectNumber (i.e. as named in Swift).
+//
+func openLogSegmentChunk(objectNumber uint64) func(physicalOffset uint64, sealedBuf []byte) (buf []byte, err error) {
+	return func(physicalOffset uint64, sealedBuf []byte) (buf []byte, err error) {
+		if nil == globals.keyring {
+			err = fmt.Errorf("LogSegment %016X is sealed but no keyring is configured", objectNumber)
+			return
+		}
+
+		buf, err = globals.keyring.Open(sealedBuf, keyring.ObjectAdditionalData(objectNumber, physicalOffset))
+
+		return
+	}
+}
+
+// fetchLogSegmentRange issues a ranged GET of length bytes starting at offset of the
+// LogSegment at url. As with any ranged GET, fewer bytes are returned should the
+// range extend beyond the end of the LogSegment.
+//
+func fetchLogSegmentRange(url string, offset uint64, length uint64) (buf []byte) {
+	var (
+		err        error
+		getRequest *http.Request
+		ok         bool
+		statusCode int
+	)
+
+	getRequest, err = http.NewRequest(http.MethodGet, url, nil)
+	if nil != err {
+		logFatalf("unable to create GET http.Request (,%s,): %v", url, err)
+	}
+
+	getRequest.Header.Add("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	_, buf, ok, statusCode = doHTTPRequest(getRequest, http.StatusOK, http.StatusPartialContent)
+	if !ok {
+		logFatalf("fetchLogSegmentRange() failed with unexpected statusCode: %v", statusCode)
+	}
+
+	return
+}
+
 func (chunkedPutContext *chunkedPutContextStruct) mergeSingleObjectExtent(newExtent *singleObjectExtentStruct) {
 	var (
 		curExtent        *singleObjectExtentStruct
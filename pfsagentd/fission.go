@@ -5,8 +5,11 @@ package main
 
 import (
 	"container/list"
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
 	"sync/atomic"
 	"syscall"
@@ -14,10 +17,13 @@ import (
 
 	"github.com/NVIDIA/fission"
 	"github.com/NVIDIA/sortedmap"
+	"golang.org/x/sys/unix"
 
 	"github.com/NVIDIA/proxyfs/fs"
 	"github.com/NVIDIA/proxyfs/inode"
 	"github.com/NVIDIA/proxyfs/jrpcfs"
+	"github.com/NVIDIA/proxyfs/retryrpc"
+	"github.com/NVIDIA/proxyfs/tracing"
 )
 
 const (
@@ -25,6 +31,7 @@ const (
 
 	initOutFlagsMaskReadDirPlusDisabled = uint32(0) |
 		fission.InitFlagsAsyncRead |
+		fission.InitFlagsPosixLocks |
 		fission.InitFlagsFileOps |
 		fission.InitFlagsAtomicOTrunc |
 		fission.InitFlagsBigWrites |
@@ -151,10 +158,14 @@ func (dummy *globalsStruct) DoLookup(inHeader *fission.InHeader, lookupIn *fissi
 		lookupPlusRequest *jrpcfs.LookupPlusRequest
 		mTimeNSec         uint32
 		mTimeSec          uint64
+		span              *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoLookup_calls, 1)
 
+	span = startFUSESpan("DoLookup", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	lookupPlusRequest = &jrpcfs.LookupPlusRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
@@ -265,10 +276,14 @@ func (dummy *globalsStruct) DoGetAttr(inHeader *fission.InHeader, getAttrIn *fis
 		getStatRequest *jrpcfs.GetStatRequest
 		mTimeNSec      uint32
 		mTimeSec       uint64
+		span           *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoGetAttr_calls, 1)
 
+	span = startFUSESpan("DoGetAttr", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	fileInode = lockInodeWithSharedLease(inode.InodeNumber(inHeader.NodeID))
 
 	if nil == fileInode.cachedStat {
@@ -508,10 +523,14 @@ func (dummy *globalsStruct) DoSetAttr(inHeader *fission.InHeader, setAttrIn *fis
 		settingMTimeNow        bool
 		settingSize            bool
 		settingUID             bool
+		span                   *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoSetAttr_calls, 1)
 
+	span = startFUSESpan("DoSetAttr", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	if setAttrIn.Valid != (setAttrIn.Valid & (fission.SetAttrInValidMode | fission.SetAttrInValidUID | fission.SetAttrInValidGID | fission.SetAttrInValidSize | fission.SetAttrInValidATime | fission.SetAttrInValidMTime | fission.SetAttrInValidFH | fission.SetAttrInValidATimeNow | fission.SetAttrInValidMTimeNow | fission.SetAttrInValidLockOwner)) {
 		errno = syscall.ENOSYS
 		return
@@ -639,10 +658,14 @@ func (dummy *globalsStruct) DoReadLink(inHeader *fission.InHeader) (readLinkOut
 		err                error
 		readSymlinkReply   *jrpcfs.ReadSymlinkReply
 		readSymlinkRequest *jrpcfs.ReadSymlinkRequest
+		span               *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoReadLink_calls, 1)
 
+	span = startFUSESpan("DoReadLink", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	readSymlinkRequest = &jrpcfs.ReadSymlinkRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
@@ -677,12 +700,16 @@ func (dummy *globalsStruct) DoSymLink(inHeader *fission.InHeader, symLinkIn *fis
 		getStatRequest *jrpcfs.GetStatRequest
 		mTimeNSec      uint32
 		mTimeSec       uint64
+		span           *tracing.SpanStruct
 		symlinkReply   *jrpcfs.InodeReply
 		symlinkRequest *jrpcfs.SymlinkRequest
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoSymLink_calls, 1)
 
+	span = startFUSESpan("DoSymLink", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	symlinkRequest = &jrpcfs.SymlinkRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
@@ -773,10 +800,14 @@ func (dummy *globalsStruct) DoMkDir(inHeader *fission.InHeader, mkDirIn *fission
 		mkdirRequest   *jrpcfs.MkdirRequest
 		mTimeNSec      uint32
 		mTimeSec       uint64
+		span           *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoMkDir_calls, 1)
 
+	span = startFUSESpan("DoMkDir", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	mkdirRequest = &jrpcfs.MkdirRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
@@ -854,12 +885,16 @@ func (dummy *globalsStruct) DoUnlink(inHeader *fission.InHeader, unlinkIn *fissi
 		fileInode     *fileInodeStruct
 		lookupReply   *jrpcfs.InodeReply
 		lookupRequest *jrpcfs.LookupRequest
+		span          *tracing.SpanStruct
 		unlinkReply   *jrpcfs.Reply
 		unlinkRequest *jrpcfs.UnlinkRequest
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoUnlink_calls, 1)
 
+	span = startFUSESpan("DoUnlink", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	lookupRequest = &jrpcfs.LookupRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
@@ -917,12 +952,16 @@ func (dummy *globalsStruct) DoUnlink(inHeader *fission.InHeader, unlinkIn *fissi
 func (dummy *globalsStruct) DoRmDir(inHeader *fission.InHeader, rmDirIn *fission.RmDirIn) (errno syscall.Errno) {
 	var (
 		err           error
+		span          *tracing.SpanStruct
 		unlinkReply   *jrpcfs.Reply
 		unlinkRequest *jrpcfs.UnlinkRequest
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoRmDir_calls, 1)
 
+	span = startFUSESpan("DoRmDir", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	unlinkRequest = &jrpcfs.UnlinkRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
@@ -953,10 +992,14 @@ func (dummy *globalsStruct) DoRename(inHeader *fission.InHeader, renameIn *fissi
 		lookupRequest  *jrpcfs.LookupRequest
 		moveReply      *jrpcfs.MoveReply
 		moveRequest    *jrpcfs.MoveRequest
+		span           *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoRename_calls, 1)
 
+	span = startFUSESpan("DoRename", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	lookupRequest = &jrpcfs.LookupRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
@@ -1046,10 +1089,14 @@ func (dummy *globalsStruct) DoLink(inHeader *fission.InHeader, linkIn *fission.L
 		linkRequest    *jrpcfs.LinkRequest
 		mTimeNSec      uint32
 		mTimeSec       uint64
+		span           *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoLink_calls, 1)
 
+	span = startFUSESpan("DoLink", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	fileInode = lockInodeWithExclusiveLease(inode.InodeNumber(linkIn.OldNodeID))
 
 	fileInode.doFlushIfNecessary()
@@ -1139,10 +1186,14 @@ func (dummy *globalsStruct) DoOpen(inHeader *fission.InHeader, openIn *fission.O
 		getStatReply   *jrpcfs.StatStruct
 		getStatRequest *jrpcfs.GetStatRequest
 		ok             bool
+		span           *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoOpen_calls, 1)
 
+	span = startFUSESpan("DoOpen", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	if 0 != (openIn.Flags & fission.FOpenRequestTRUNC) {
 		fileInode = lockInodeWithExclusiveLease(inode.InodeNumber(inHeader.NodeID))
 		fileInode.doFlushIfNecessary()
@@ -1233,9 +1284,13 @@ func (dummy *globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.R
 		readPlanStepAsMultiObjectExtentStruct     *multiObjectExtentStruct
 		readPlanStepAsSingleObjectExtentWithLink  *singleObjectExtentWithLinkStruct
 		readPlanStepRemainingLength               uint64
+		span                                      *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoRead_calls, 1)
+
+	span = startFUSESpan("DoRead", inHeader)
+	defer endFUSESpan(span, &errno)
 	globals.stats.FUSEDoReadBytes.Add(uint64(readIn.Size))
 
 	fileInode = lockInodeWithSharedLease(inode.InodeNumber(inHeader.NodeID))
@@ -1333,9 +1388,9 @@ func (dummy *globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.R
 					readPlanStepRemainingLength = readPlanStepAsMultiObjectExtentStruct.length
 
 					for readPlanStepRemainingLength > 0 {
-						logSegmentCacheElement = fetchLogSegmentCacheLine(readPlanStepAsMultiObjectExtentStruct.containerName, readPlanStepAsMultiObjectExtentStruct.objectName, curObjectOffset)
+						logSegmentCacheElement = fetchLogSegmentCacheLine(readPlanStepAsMultiObjectExtentStruct.containerName, readPlanStepAsMultiObjectExtentStruct.objectName, readPlanStepAsMultiObjectExtentStruct.compression, readPlanStepAsMultiObjectExtentStruct.checksums, curObjectOffset)
 
-						if logSegmentCacheElementStateGetFailed == logSegmentCacheElement.state {
+						if (nil == logSegmentCacheElement) || (logSegmentCacheElementStateGetFailed == logSegmentCacheElement.state) {
 							fileInode.unlock(true)
 							errno = syscall.EIO
 							return
@@ -1378,6 +1433,8 @@ func (dummy *globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.R
 		}
 	}
 
+	fileInode.readAheadIfSequential(readIn.FH, readIn.Offset, uint64(len(readOut.Data)))
+
 	fileInode.unlock(false)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoRead_bytes, uint64(len(readOut.Data)))
@@ -1388,19 +1445,20 @@ func (dummy *globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.R
 
 func (dummy *globalsStruct) DoWrite(inHeader *fission.InHeader, writeIn *fission.WriteIn) (writeOut *fission.WriteOut, errno syscall.Errno) {
 	var (
-		actualOffset             uint64
-		chunkedPutContext        *chunkedPutContextStruct
-		chunkedPutContextElement *list.Element
-		err                      error
-		fhInodeNumber            uint64
-		fileInode                *fileInodeStruct
-		fOpenRequestFlags        uint32
-		ok                       bool
-		singleObjectExtent       *singleObjectExtentStruct
+		actualOffset      uint64
+		err               error
+		fhInodeNumber     uint64
+		fileInode         *fileInodeStruct
+		fOpenRequestFlags uint32
+		ok                bool
+		span              *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoWrite_calls, 1)
 
+	span = startFUSESpan("DoWrite", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	fileInode = lockInodeWithExclusiveLease(inode.InodeNumber(inHeader.NodeID))
 
 	globals.Lock()
@@ -1443,6 +1501,37 @@ func (dummy *globalsStruct) DoWrite(inHeader *fission.InHeader, writeIn *fission
 		actualOffset = fileInode.cachedStat.Size
 	}
 
+	fileInode.doWriteWhileLocked(actualOffset, writeIn.Data)
+
+	// grantedLock.release()
+
+	// fileInode.dereference()
+
+	fileInode.unlock(false)
+
+	writeOut = &fission.WriteOut{
+		Size:    uint32(len(writeIn.Data)),
+		Padding: 0,
+	}
+
+	_ = atomic.AddUint64(&globals.metrics.FUSE_DoWrite_bytes, uint64(writeOut.Size))
+	globals.stats.FUSEDoWriteBytes.Add(uint64(writeOut.Size))
+
+	errno = 0
+	return
+}
+
+// doWriteWhileLocked appends data destined for fileOffset to the open (or a freshly
+// opened) chunkedPutContext of fileInode. The caller must hold an Exclusive Lease
+// on fileInode and have ensured fileInode.cachedStat is populated.
+//
+func (fileInode *fileInodeStruct) doWriteWhileLocked(fileOffset uint64, data []byte) {
+	var (
+		chunkedPutContext        *chunkedPutContextStruct
+		chunkedPutContextElement *list.Element
+		singleObjectExtent       *singleObjectExtentStruct
+	)
+
 	// Grab quota to start a fresh chunkedPutContext before calling fileInode.getExclusiveLock()
 	// since, in the pathologic case where only fileInode has any outstanding chunkedPutContext's,
 	// they can only be complete()'d while holding fileInode.getExclusiveLock() and we would
@@ -1470,6 +1559,8 @@ func (dummy *globalsStruct) DoWrite(inHeader *fission.InHeader, writeIn *fission
 		chunkedPutContext.extentMap = sortedmap.NewLLRBTree(sortedmap.CompareUint64, chunkedPutContext)
 		chunkedPutContext.chunkedPutListElement = fileInode.chunkedPutList.PushBack(chunkedPutContext)
 
+		chunkedPutContext.openDiskCacheDirtyLogSegment()
+
 		// fileInode.reference()
 
 		pruneFileInodeDirtyListIfNecessary()
@@ -1509,16 +1600,22 @@ func (dummy *globalsStruct) DoWrite(inHeader *fission.InHeader, writeIn *fission
 			chunkedPutContext.extentMap = sortedmap.NewLLRBTree(sortedmap.CompareUint64, chunkedPutContext)
 			chunkedPutContext.chunkedPutListElement = fileInode.chunkedPutList.PushBack(chunkedPutContext)
 
+			chunkedPutContext.openDiskCacheDirtyLogSegment()
+
 			// fileInode.reference()
 
 			go chunkedPutContext.sendDaemon()
 		}
 	}
 
+	// Journal the write (if enabled) before it can be sent in the Chunked PUT
+
+	chunkedPutContext.appendDiskCacheDirtyLogSegment(fileOffset, data)
+
 	singleObjectExtent = &singleObjectExtentStruct{
-		fileOffset:   actualOffset,
+		fileOffset:   fileOffset,
 		objectOffset: uint64(len(chunkedPutContext.buf)),
-		length:       uint64(len(writeIn.Data)),
+		length:       uint64(len(data)),
 	}
 
 	chunkedPutContext.mergeSingleObjectExtent(singleObjectExtent)
@@ -1527,7 +1624,7 @@ func (dummy *globalsStruct) DoWrite(inHeader *fission.InHeader, writeIn *fission
 		fileInode.cachedStat.Size = singleObjectExtent.fileOffset + singleObjectExtent.length
 	}
 
-	chunkedPutContext.buf = append(chunkedPutContext.buf, writeIn.Data...)
+	chunkedPutContext.buf = append(chunkedPutContext.buf, data...)
 
 	select {
 	case chunkedPutContext.sendChan <- struct{}{}:
@@ -1541,36 +1638,28 @@ func (dummy *globalsStruct) DoWrite(inHeader *fission.InHeader, writeIn *fission
 		chunkedPutContext.state = chunkedPutContextStateClosing
 		close(chunkedPutContext.sendChan)
 	}
-
-	// grantedLock.release()
-
-	// fileInode.dereference()
-
-	fileInode.unlock(false)
-
-	writeOut = &fission.WriteOut{
-		Size:    uint32(len(writeIn.Data)),
-		Padding: 0,
-	}
-
-	_ = atomic.AddUint64(&globals.metrics.FUSE_DoWrite_bytes, uint64(writeOut.Size))
-	globals.stats.FUSEDoWriteBytes.Add(uint64(writeOut.Size))
-
-	errno = 0
-	return
 }
 
 func (dummy *globalsStruct) DoStatFS(inHeader *fission.InHeader) (statFSOut *fission.StatFSOut, errno syscall.Errno) {
 	var (
 		err            error
-		statVFSRequest *jrpcfs.StatVFSRequest
+		span           *tracing.SpanStruct
 		statVFSReply   *jrpcfs.StatVFS
+		statVFSRequest *jrpcfs.StatVFSRequest
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoStatFS_calls, 1)
 
+	span = startFUSESpan("DoStatFS", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	statVFSRequest = &jrpcfs.StatVFSRequest{
-		MountID: globals.mountID,
+		InodeHandle: jrpcfs.InodeHandle{
+			MountID:     globals.mountID,
+			InodeNumber: int64(inHeader.NodeID),
+		},
+		UserID:  int32(inHeader.UID),
+		GroupID: int32(inHeader.GID),
 	}
 
 	statVFSReply = &jrpcfs.StatVFS{}
@@ -1605,10 +1694,14 @@ func (dummy *globalsStruct) DoRelease(inHeader *fission.InHeader, releaseIn *fis
 		fhInodeNumber uint64
 		fhSet         fhSetType
 		ok            bool
+		span          *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoRelease_calls, 1)
 
+	span = startFUSESpan("DoRelease", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	globals.Lock()
 
 	_, ok = globals.fhToOpenRequestMap[releaseIn.FH]
@@ -1617,6 +1710,7 @@ func (dummy *globalsStruct) DoRelease(inHeader *fission.InHeader, releaseIn *fis
 	}
 
 	delete(globals.fhToOpenRequestMap, releaseIn.FH)
+	delete(globals.fhToReadAheadStateMap, releaseIn.FH)
 
 	fhInodeNumber, ok = globals.fhToInodeNumberMap[releaseIn.FH]
 	if !ok {
@@ -1657,10 +1751,14 @@ func (dummy *globalsStruct) DoFSync(inHeader *fission.InHeader, fSyncIn *fission
 		fhInodeNumber uint64
 		fileInode     *fileInodeStruct
 		ok            bool
+		span          *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoFSync_calls, 1)
 
+	span = startFUSESpan("DoFSync", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	globals.Lock()
 
 	_, ok = globals.fhToOpenRequestMap[fSyncIn.FH]
@@ -1696,12 +1794,17 @@ func (dummy *globalsStruct) DoFSync(inHeader *fission.InHeader, fSyncIn *fission
 func (dummy *globalsStruct) DoSetXAttr(inHeader *fission.InHeader, setXAttrIn *fission.SetXAttrIn) (errno syscall.Errno) {
 	var (
 		err             error
+		fileInode       *fileInodeStruct
 		setXAttrReply   *jrpcfs.Reply
 		setXAttrRequest *jrpcfs.SetXAttrRequest
+		span            *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoSetXAttr_calls, 1)
 
+	span = startFUSESpan("DoSetXAttr", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	if !globals.config.XAttrEnabled {
 		errno = syscall.ENOSYS
 		return
@@ -1715,14 +1818,33 @@ func (dummy *globalsStruct) DoSetXAttr(inHeader *fission.InHeader, setXAttrIn *f
 		AttrName:  string(setXAttrIn.Name[:]),
 		AttrValue: setXAttrIn.Data[:],
 		AttrFlags: fs.SetXAttrCreateOrReplace,
+		UserID:    int32(inHeader.UID),
+		GroupID:   int32(inHeader.GID),
 	}
 
 	setXAttrReply = &jrpcfs.Reply{}
 
-	err = globals.retryRPCClient.Send("RpcSetXAttr", setXAttrRequest, setXAttrReply)
-	if nil != err {
-		errno = convertErrToErrno(err, syscall.EIO)
-		return
+	if inode.PosixACLAccessStreamName == setXAttrRequest.AttrName {
+		// Setting an access ACL may also modify the Mode of the inode
+
+		fileInode = lockInodeWithExclusiveLease(inode.InodeNumber(inHeader.NodeID))
+
+		err = globals.retryRPCClient.Send("RpcSetXAttr", setXAttrRequest, setXAttrReply)
+		if nil != err {
+			fileInode.unlock(true)
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+
+		fileInode.cachedStat = nil
+
+		fileInode.unlock(false)
+	} else {
+		err = globals.retryRPCClient.Send("RpcSetXAttr", setXAttrRequest, setXAttrReply)
+		if nil != err {
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
 	}
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoSetXAttr_bytes, uint64(len(setXAttrIn.Data)))
@@ -1736,10 +1858,14 @@ func (dummy *globalsStruct) DoGetXAttr(inHeader *fission.InHeader, getXAttrIn *f
 		err             error
 		getXAttrReply   *jrpcfs.GetXAttrReply
 		getXAttrRequest *jrpcfs.GetXAttrRequest
+		span            *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoGetXAttr_calls, 1)
 
+	span = startFUSESpan("DoGetXAttr", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	if !globals.config.XAttrEnabled {
 		errno = syscall.ENOSYS
 		return
@@ -1793,6 +1919,7 @@ func (dummy *globalsStruct) DoListXAttr(inHeader *fission.InHeader, listXAttrIn
 		err              error
 		listXAttrReply   *jrpcfs.ListXAttrReply
 		listXAttrRequest *jrpcfs.ListXAttrRequest
+		span             *tracing.SpanStruct
 		totalSize        uint32
 		xAttrIndex       int
 		xAttrName        string
@@ -1800,6 +1927,9 @@ func (dummy *globalsStruct) DoListXAttr(inHeader *fission.InHeader, listXAttrIn
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoListXAttr_calls, 1)
 
+	span = startFUSESpan("DoListXAttr", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	if !globals.config.XAttrEnabled {
 		errno = syscall.ENOSYS
 		return
@@ -1857,10 +1987,14 @@ func (dummy *globalsStruct) DoRemoveXAttr(inHeader *fission.InHeader, removeXAtt
 		err                error
 		removeXAttrReply   *jrpcfs.Reply
 		removeXAttrRequest *jrpcfs.RemoveXAttrRequest
+		span               *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoRemoveXAttr_calls, 1)
 
+	span = startFUSESpan("DoRemoveXAttr", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	if !globals.config.XAttrEnabled {
 		errno = syscall.ENOSYS
 		return
@@ -1872,6 +2006,8 @@ func (dummy *globalsStruct) DoRemoveXAttr(inHeader *fission.InHeader, removeXAtt
 			InodeNumber: int64(inHeader.NodeID),
 		},
 		AttrName: string(removeXAttrIn.Name[:]),
+		UserID:   int32(inHeader.UID),
+		GroupID:  int32(inHeader.GID),
 	}
 
 	removeXAttrReply = &jrpcfs.Reply{}
@@ -1888,12 +2024,19 @@ func (dummy *globalsStruct) DoRemoveXAttr(inHeader *fission.InHeader, removeXAtt
 
 func (dummy *globalsStruct) DoFlush(inHeader *fission.InHeader, flushIn *fission.FlushIn) (errno syscall.Errno) {
 	var (
+		err           error
 		fhInodeNumber uint64
+		fileLock      *fission.FileLock
+		flockOwnerSet flockOwnerSetType
 		ok            bool
+		span          *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoFlush_calls, 1)
 
+	span = startFUSESpan("DoFlush", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	globals.Lock()
 
 	_, ok = globals.fhToOpenRequestMap[flushIn.FH]
@@ -1909,8 +2052,36 @@ func (dummy *globalsStruct) DoFlush(inHeader *fission.InHeader, flushIn *fission
 		logFatalf("DoFlush(NodeID=%v,FH=%v) called for FH associated with NodeID=%v", inHeader.NodeID, flushIn.FH, fhInodeNumber)
 	}
 
+	// POSIX requires that any locks held by this LockOwner be released upon close()
+
+	flockOwnerSet, ok = globals.inodeNumberToFLockOwnerMap[inHeader.NodeID]
+	if ok {
+		_, ok = flockOwnerSet[flushIn.LockOwner]
+		if ok {
+			delete(flockOwnerSet, flushIn.LockOwner)
+			if 0 == len(flockOwnerSet) {
+				delete(globals.inodeNumberToFLockOwnerMap, inHeader.NodeID)
+			}
+		}
+	}
+
 	globals.Unlock()
 
+	if ok {
+		fileLock = &fission.FileLock{
+			Start: 0,
+			End:   math.MaxInt64,
+			Type:  syscall.F_UNLCK,
+			PID:   inHeader.PID,
+		}
+
+		_, err = doFlock(inHeader.NodeID, flushIn.LockOwner, syscall.F_SETLK, fileLock)
+		if nil != err {
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
+		}
+	}
+
 	errno = 0
 	return
 }
@@ -1949,10 +2120,14 @@ func (dummy *globalsStruct) DoOpenDir(inHeader *fission.InHeader, openDirIn *fis
 		getStatReply   *jrpcfs.StatStruct
 		getStatRequest *jrpcfs.GetStatRequest
 		ok             bool
+		span           *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoOpenDir_calls, 1)
 
+	span = startFUSESpan("DoOpenDir", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	getStatRequest = &jrpcfs.GetStatRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
@@ -2001,22 +2176,27 @@ func (dummy *globalsStruct) DoOpenDir(inHeader *fission.InHeader, openDirIn *fis
 func (dummy *globalsStruct) DoReadDir(inHeader *fission.InHeader, readDirIn *fission.ReadDirIn) (readDirOut *fission.ReadDirOut, errno syscall.Errno) {
 	var (
 		curSize              uint32
+		dirEnt               fission.DirEnt
 		dirEntIndex          uint64
 		dirEntNameLenAligned uint32
-		dirEnt               fission.DirEnt
-		dirEntSize           uint32
 		dirEntry             *jrpcfs.DirEntry
+		dirEntSize           uint32
 		err                  error
 		fhInodeNumber        uint64
 		maxEntries           uint64
 		numEntries           uint64
 		ok                   bool
-		readdirByLocRequest  *jrpcfs.ReaddirByLocRequest
 		readdirReply         *jrpcfs.ReaddirReply
+		readdirStream        *retryrpc.ClientStream
+		readdirStreamRequest *jrpcfs.ReaddirByLocStreamRequest
+		span                 *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoReadDir_calls, 1)
 
+	span = startFUSESpan("DoReadDir", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	globals.Lock()
 
 	fhInodeNumber, ok = globals.fhToInodeNumberMap[readDirIn.FH]
@@ -2031,7 +2211,7 @@ func (dummy *globalsStruct) DoReadDir(inHeader *fission.InHeader, readDirIn *fis
 
 	maxEntries = (uint64(readDirIn.Size) + fission.DirEntFixedPortionSize + 1 - 1) / (fission.DirEntFixedPortionSize + 1)
 
-	readdirByLocRequest = &jrpcfs.ReaddirByLocRequest{
+	readdirStreamRequest = &jrpcfs.ReaddirByLocStreamRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
 			InodeNumber: int64(inHeader.NodeID),
@@ -2040,43 +2220,58 @@ func (dummy *globalsStruct) DoReadDir(inHeader *fission.InHeader, readDirIn *fis
 		PrevDirEntLocation: int64(readDirIn.Offset) - 1,
 	}
 
-	readdirReply = &jrpcfs.ReaddirReply{}
-
-	err = globals.retryRPCClient.Send("RpcReaddirByLoc", readdirByLocRequest, readdirReply)
+	readdirStream, err = globals.retryRPCClient.SendStream(context.Background(), "RpcReaddirByLocStream", readdirStreamRequest)
 	if nil != err {
 		errno = convertErrToErrno(err, syscall.EIO)
 		return
 	}
-
-	numEntries = uint64(len(readdirReply.DirEnts))
+	defer readdirStream.Close()
 
 	readDirOut = &fission.ReadDirOut{
-		DirEnt: make([]fission.DirEnt, 0, numEntries),
+		DirEnt: make([]fission.DirEnt, 0),
 	}
 
 	curSize = 0
 
-	for dirEntIndex = 0; dirEntIndex < numEntries; dirEntIndex++ {
-		dirEntry = &readdirReply.DirEnts[dirEntIndex]
+	// Consume frames until either the directory is exhausted or readDirIn.Size is reached
 
-		dirEntNameLenAligned = (uint32(len(dirEntry.Basename)) + (fission.DirEntAlignment - 1)) & ^uint32(fission.DirEntAlignment-1)
-		dirEntSize = fission.DirEntFixedPortionSize + dirEntNameLenAligned
+EscapeReadDirStream:
+	for {
+		readdirReply = &jrpcfs.ReaddirReply{}
 
-		if (curSize + dirEntSize) > readDirIn.Size {
-			break
+		err = readdirStream.Recv(readdirReply)
+		if io.EOF == err {
+			break EscapeReadDirStream
 		}
-
-		dirEnt = fission.DirEnt{
-			Ino:     uint64(dirEntry.InodeNumber),
-			Off:     uint64(dirEntry.NextDirLocation),
-			NameLen: uint32(len(dirEntry.Basename)), // unnecessary
-			Type:    uint32(dirEntry.FileType),
-			Name:    []byte(dirEntry.Basename),
+		if nil != err {
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
 		}
 
-		readDirOut.DirEnt = append(readDirOut.DirEnt, dirEnt)
+		numEntries = uint64(len(readdirReply.DirEnts))
+
+		for dirEntIndex = 0; dirEntIndex < numEntries; dirEntIndex++ {
+			dirEntry = &readdirReply.DirEnts[dirEntIndex]
+
+			dirEntNameLenAligned = (uint32(len(dirEntry.Basename)) + (fission.DirEntAlignment - 1)) & ^uint32(fission.DirEntAlignment-1)
+			dirEntSize = fission.DirEntFixedPortionSize + dirEntNameLenAligned
 
-		curSize += dirEntSize
+			if (curSize + dirEntSize) > readDirIn.Size {
+				break EscapeReadDirStream
+			}
+
+			dirEnt = fission.DirEnt{
+				Ino:     uint64(dirEntry.InodeNumber),
+				Off:     uint64(dirEntry.NextDirLocation),
+				NameLen: uint32(len(dirEntry.Basename)), // unnecessary
+				Type:    uint32(dirEntry.FileType),
+				Name:    []byte(dirEntry.Basename),
+			}
+
+			readDirOut.DirEnt = append(readDirOut.DirEnt, dirEnt)
+
+			curSize += dirEntSize
+		}
 	}
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoReadDir_entries, uint64(len(readDirOut.DirEnt)))
@@ -2090,10 +2285,14 @@ func (dummy *globalsStruct) DoReleaseDir(inHeader *fission.InHeader, releaseDirI
 		fhInodeNumber uint64
 		fhSet         fhSetType
 		ok            bool
+		span          *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoReleaseDir_calls, 1)
 
+	span = startFUSESpan("DoReleaseDir", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	globals.Lock()
 
 	fhInodeNumber, ok = globals.fhToInodeNumberMap[releaseDirIn.FH]
@@ -2134,10 +2333,14 @@ func (dummy *globalsStruct) DoFSyncDir(inHeader *fission.InHeader, fSyncDirIn *f
 	var (
 		fhInodeNumber uint64
 		ok            bool
+		span          *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoFSyncDir_calls, 1)
 
+	span = startFUSESpan("DoFSyncDir", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	globals.Lock()
 
 	fhInodeNumber, ok = globals.fhToInodeNumberMap[fSyncDirIn.FH]
@@ -2155,30 +2358,195 @@ func (dummy *globalsStruct) DoFSyncDir(inHeader *fission.InHeader, fSyncDirIn *f
 }
 
 func (dummy *globalsStruct) DoGetLK(inHeader *fission.InHeader, getLKIn *fission.GetLKIn) (getLKOut *fission.GetLKOut, errno syscall.Errno) {
+	var (
+		err        error
+		flockReply *jrpcfs.FlockReply
+		span       *tracing.SpanStruct
+	)
+
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoGetLK_calls, 1)
-	errno = syscall.ENOSYS
+
+	span = startFUSESpan("DoGetLK", inHeader)
+	defer endFUSESpan(span, &errno)
+
+	flockReply, err = doFlock(inHeader.NodeID, getLKIn.Owner, syscall.F_GETLK, &getLKIn.FileLock)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	getLKOut = &fission.GetLKOut{
+		FileLock: fileLockFromFlockReply(flockReply),
+	}
+
+	errno = 0
 	return
 }
+
 func (dummy *globalsStruct) DoSetLK(inHeader *fission.InHeader, setLKIn *fission.SetLKIn) (errno syscall.Errno) {
+	var (
+		span *tracing.SpanStruct
+	)
+
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoSetLK_calls, 1)
-	errno = syscall.ENOSYS
+
+	span = startFUSESpan("DoSetLK", inHeader)
+	defer endFUSESpan(span, &errno)
+
+	errno = doSetLK(inHeader.NodeID, setLKIn.Owner, &setLKIn.FileLock)
+
 	return
 }
+
 func (dummy *globalsStruct) DoSetLKW(inHeader *fission.InHeader, setLKWIn *fission.SetLKWIn) (errno syscall.Errno) {
+	var (
+		interruptChan chan struct{}
+		span          *tracing.SpanStruct
+	)
+
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoSetLKW_calls, 1)
-	errno = syscall.ENOSYS
+
+	span = startFUSESpan("DoSetLKW", inHeader)
+	defer endFUSESpan(span, &errno)
+
+	// As there is no way to block in ProxyFS awaiting a lock, simply retry until it is granted (or we are interrupted)
+
+	interruptChan = make(chan struct{})
+
+	globals.Lock()
+	globals.setLKWInterruptChanMap[inHeader.Unique] = interruptChan
+	globals.Unlock()
+
+	for {
+		errno = doSetLK(inHeader.NodeID, setLKWIn.Owner, &setLKWIn.FileLock)
+		if syscall.EAGAIN != errno {
+			break
+		}
+
+		_ = atomic.AddUint64(&globals.metrics.FUSE_DoSetLKW_retries, 1)
+
+		select {
+		case <-interruptChan:
+			errno = syscall.EINTR
+		case <-time.After(globals.config.FLockRetryDelay):
+		}
+
+		if syscall.EINTR == errno {
+			break
+		}
+	}
+
+	globals.Lock()
+	delete(globals.setLKWInterruptChanMap, inHeader.Unique)
+	globals.Unlock()
+
 	return
 }
 
-func (dummy *globalsStruct) DoAccess(inHeader *fission.InHeader, accessIn *fission.AccessIn) (errno syscall.Errno) {
+// doSetLK is used by both DoSetLK() & DoSetLKW() to issue a single (non-blocking) attempt
+// to set (or clear) a lock. Each lock owner that might hold a lock on an inode is tracked
+// so that DoFlush() knows to release them.
+//
+func doSetLK(inodeNumber uint64, owner uint64, fileLock *fission.FileLock) (errno syscall.Errno) {
 	var (
 		err           error
+		flockOwnerSet flockOwnerSetType
+		ok            bool
+	)
+
+	_, err = doFlock(inodeNumber, owner, syscall.F_SETLK, fileLock)
+	if nil != err {
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	if syscall.F_UNLCK != fileLock.Type {
+		globals.Lock()
+
+		flockOwnerSet, ok = globals.inodeNumberToFLockOwnerMap[inodeNumber]
+		if !ok {
+			flockOwnerSet = make(flockOwnerSetType)
+			globals.inodeNumberToFLockOwnerMap[inodeNumber] = flockOwnerSet
+		}
+
+		flockOwnerSet[owner] = struct{}{}
+
+		globals.Unlock()
+	}
+
+	errno = 0
+	return
+}
+
+// doFlock issues an RpcFlock converting fission.FileLock's {Start,End} (where End is
+// inclusive and math.MaxInt64 indicates EOF) to jrpcfs.FlockRequest's {Start,Len}
+// (where Len == 0 indicates EOF).
+//
+func doFlock(inodeNumber uint64, owner uint64, lockCmd int32, fileLock *fission.FileLock) (flockReply *jrpcfs.FlockReply, err error) {
+	var (
+		flockLen     uint64
+		flockRequest *jrpcfs.FlockRequest
+	)
+
+	if fileLock.End < fileLock.Start {
+		err = fmt.Errorf("errno: %d", syscall.EINVAL)
+		return
+	}
+
+	if math.MaxInt64 <= fileLock.End {
+		flockLen = 0
+	} else {
+		flockLen = fileLock.End - fileLock.Start + 1
+	}
+
+	flockRequest = &jrpcfs.FlockRequest{
+		InodeHandle: jrpcfs.InodeHandle{
+			MountID:     globals.mountID,
+			InodeNumber: int64(inodeNumber),
+		},
+		FlockCmd:    lockCmd,
+		FlockType:   int32(fileLock.Type),
+		FlockWhence: 0,
+		FlockStart:  fileLock.Start,
+		FlockLen:    flockLen,
+		FlockPid:    uint64(fileLock.PID),
+		FlockOwner:  owner,
+	}
+
+	flockReply = &jrpcfs.FlockReply{}
+
+	err = globals.retryRPCClient.Send("RpcFlock", flockRequest, flockReply)
+
+	return
+}
+
+func fileLockFromFlockReply(flockReply *jrpcfs.FlockReply) (fileLock fission.FileLock) {
+	fileLock.Start = flockReply.FlockStart
+	fileLock.Type = uint32(flockReply.FlockType)
+	fileLock.PID = uint32(flockReply.FlockPid)
+
+	if (0 == flockReply.FlockLen) || ((flockReply.FlockLen - 1) >= (math.MaxInt64 - flockReply.FlockStart)) {
+		fileLock.End = math.MaxInt64
+	} else {
+		fileLock.End = flockReply.FlockStart + flockReply.FlockLen - 1
+	}
+
+	return
+}
+
+func (dummy *globalsStruct) DoAccess(inHeader *fission.InHeader, accessIn *fission.AccessIn) (errno syscall.Errno) {
+	var (
 		accessReply   *jrpcfs.Reply
 		accessRequest *jrpcfs.AccessRequest
+		err           error
+		span          *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoAccess_calls, 1)
 
+	span = startFUSESpan("DoAccess", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	accessRequest = &jrpcfs.AccessRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
@@ -2205,10 +2573,10 @@ func (dummy *globalsStruct) DoCreate(inHeader *fission.InHeader, createIn *fissi
 	var (
 		aTimeNSec      uint32
 		aTimeSec       uint64
-		cTimeNSec      uint32
-		cTimeSec       uint64
 		createReply    *jrpcfs.InodeReply
 		createRequest  *jrpcfs.CreateRequest
+		cTimeNSec      uint32
+		cTimeSec       uint64
 		err            error
 		fhSet          fhSetType
 		getStatReply   *jrpcfs.StatStruct
@@ -2216,10 +2584,14 @@ func (dummy *globalsStruct) DoCreate(inHeader *fission.InHeader, createIn *fissi
 		mTimeNSec      uint32
 		mTimeSec       uint64
 		ok             bool
+		span           *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoCreate_calls, 1)
 
+	span = startFUSESpan("DoCreate", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	createRequest = &jrpcfs.CreateRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
@@ -2313,8 +2685,24 @@ func (dummy *globalsStruct) DoCreate(inHeader *fission.InHeader, createIn *fissi
 }
 
 func (dummy *globalsStruct) DoInterrupt(inHeader *fission.InHeader, interruptIn *fission.InterruptIn) {
+	var (
+		interruptChan chan struct{}
+		ok            bool
+	)
+
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoInterrupt_calls, 1)
-	return
+
+	// Only a DoSetLKW() awaiting a lock is interruptible
+
+	globals.Lock()
+
+	interruptChan, ok = globals.setLKWInterruptChanMap[interruptIn.Unique]
+	if ok {
+		delete(globals.setLKWInterruptChanMap, interruptIn.Unique)
+		close(interruptChan)
+	}
+
+	globals.Unlock()
 }
 
 func (dummy *globalsStruct) DoBMap(inHeader *fission.InHeader, bMapIn *fission.BMapIn) (bMapOut *fission.BMapOut, errno syscall.Errno) {
@@ -2342,37 +2730,122 @@ func (dummy *globalsStruct) DoBatchForget(inHeader *fission.InHeader, batchForge
 }
 
 func (dummy *globalsStruct) DoFAllocate(inHeader *fission.InHeader, fAllocateIn *fission.FAllocateIn) (errno syscall.Errno) {
+	var (
+		err              error
+		fallocateReply   *jrpcfs.Reply
+		fallocateRequest *jrpcfs.FallocateRequest
+		fhInodeNumber    uint64
+		fileInode        *fileInodeStruct
+		getStatReply     *jrpcfs.StatStruct
+		getStatRequest   *jrpcfs.GetStatRequest
+		ok               bool
+		span             *tracing.SpanStruct
+	)
+
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoFAllocate_calls, 1)
-	errno = syscall.ENOSYS
+
+	span = startFUSESpan("DoFAllocate", inHeader)
+	defer endFUSESpan(span, &errno)
+
+	globals.Lock()
+
+	fhInodeNumber, ok = globals.fhToInodeNumberMap[fAllocateIn.FH]
+	if !ok {
+		logFatalf("DoFAllocate(NodeID=%v,FH=%v) called for unknown FH (in fhToInodeNumberMap)", inHeader.NodeID, fAllocateIn.FH)
+	}
+	if fhInodeNumber != inHeader.NodeID {
+		logFatalf("DoFAllocate(NodeID=%v,FH=%v) called for FH associated with NodeID=%v", inHeader.NodeID, fAllocateIn.FH, fhInodeNumber)
+	}
+
+	globals.Unlock()
+
+	fileInode = lockInodeWithExclusiveLease(inode.InodeNumber(inHeader.NodeID))
+	if nil == fileInode {
+		logFatalf("DoFAllocate(NodeID=%v,FH=%v) called for non-FileInode", inHeader.NodeID, fAllocateIn.FH)
+	}
+
+	// Ensure ProxyFS has seen all prior writes before modifying the extent map
+
+	fileInode.doFlushIfNecessary()
+
+	fallocateRequest = &jrpcfs.FallocateRequest{
+		InodeHandle: jrpcfs.InodeHandle{
+			MountID:     globals.mountID,
+			InodeNumber: int64(inHeader.NodeID),
+		},
+		Mode:   fAllocateIn.Mode,
+		Offset: fAllocateIn.Offset,
+		Length: fAllocateIn.Length,
+	}
+
+	fallocateReply = &jrpcfs.Reply{}
+
+	err = globals.retryRPCClient.Send("RpcFallocate", fallocateRequest, fallocateReply)
+	if nil != err {
+		fileInode.unlock(true)
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	// Any cached extents may now describe punched (or zeroed) ranges
+
+	fileInode.extentMap = nil
+
+	getStatRequest = &jrpcfs.GetStatRequest{
+		InodeHandle: jrpcfs.InodeHandle{
+			MountID:     globals.mountID,
+			InodeNumber: int64(inHeader.NodeID),
+		},
+	}
+
+	getStatReply = &jrpcfs.StatStruct{}
+
+	err = globals.retryRPCClient.Send("RpcGetStat", getStatRequest, getStatReply)
+	if nil != err {
+		fileInode.unlock(true)
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	fileInode.cachedStat = getStatReply
+
+	fileInode.unlock(false)
+
+	errno = 0
 	return
 }
 
 func (dummy *globalsStruct) DoReadDirPlus(inHeader *fission.InHeader, readDirPlusIn *fission.ReadDirPlusIn) (readDirPlusOut *fission.ReadDirPlusOut, errno syscall.Errno) {
 	var (
-		aTimeNSec               uint32
-		aTimeSec                uint64
-		cTimeNSec               uint32
-		cTimeSec                uint64
-		curSize                 uint32
-		dirEntIndex             uint64
-		dirEntNameLenAligned    uint32
-		dirEntPlus              fission.DirEntPlus
-		dirEntPlusSize          uint32
-		dirEntry                *jrpcfs.DirEntry
-		err                     error
-		fhInodeNumber           uint64
-		mTimeNSec               uint32
-		mTimeSec                uint64
-		maxEntries              uint64
-		numEntries              uint64
-		ok                      bool
-		readdirPlusByLocRequest *jrpcfs.ReaddirPlusByLocRequest
-		readdirPlusReply        *jrpcfs.ReaddirPlusReply
-		statStruct              *jrpcfs.StatStruct
+		aTimeNSec                uint32
+		aTimeSec                 uint64
+		cTimeNSec                uint32
+		cTimeSec                 uint64
+		curSize                  uint32
+		dirEntIndex              uint64
+		dirEntNameLenAligned     uint32
+		dirEntPlus               fission.DirEntPlus
+		dirEntPlusSize           uint32
+		dirEntry                 *jrpcfs.DirEntry
+		err                      error
+		fhInodeNumber            uint64
+		maxEntries               uint64
+		mTimeNSec                uint32
+		mTimeSec                 uint64
+		numEntries               uint64
+		ok                       bool
+		readdirPlusReply         *jrpcfs.ReaddirPlusReply
+		readdirPlusStream        *retryrpc.ClientStream
+		readdirPlusStreamRequest *jrpcfs.ReaddirPlusByLocStreamRequest
+		span                     *tracing.SpanStruct
+		statStruct               *jrpcfs.StatStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoReadDirPlus_calls, 1)
 
+	span = startFUSESpan("DoReadDirPlus", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	globals.Lock()
 
 	fhInodeNumber, ok = globals.fhToInodeNumberMap[readDirPlusIn.FH]
@@ -2387,7 +2860,7 @@ func (dummy *globalsStruct) DoReadDirPlus(inHeader *fission.InHeader, readDirPlu
 
 	maxEntries = (uint64(readDirPlusIn.Size) + fission.DirEntPlusFixedPortionSize + 1 - 1) / (fission.DirEntPlusFixedPortionSize + 1)
 
-	readdirPlusByLocRequest = &jrpcfs.ReaddirPlusByLocRequest{
+	readdirPlusStreamRequest = &jrpcfs.ReaddirPlusByLocStreamRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
 			InodeNumber: int64(inHeader.NodeID),
@@ -2396,81 +2869,96 @@ func (dummy *globalsStruct) DoReadDirPlus(inHeader *fission.InHeader, readDirPlu
 		PrevDirEntLocation: int64(readDirPlusIn.Offset) - 1,
 	}
 
-	readdirPlusReply = &jrpcfs.ReaddirPlusReply{}
-
-	err = globals.retryRPCClient.Send("RpcReaddirPlusByLoc", readdirPlusByLocRequest, readdirPlusReply)
+	readdirPlusStream, err = globals.retryRPCClient.SendStream(context.Background(), "RpcReaddirPlusByLocStream", readdirPlusStreamRequest)
 	if nil != err {
 		errno = convertErrToErrno(err, syscall.EIO)
 		return
 	}
-
-	numEntries = uint64(len(readdirPlusReply.DirEnts))
-	if numEntries != uint64(len(readdirPlusReply.StatEnts)) {
-		logFatalf("DoReadDirPlus(NodeID=%v,FH=%v) fetched mismatched number of DirEnts (%v) & StatEnts (%v)", inHeader.NodeID, readDirPlusIn.FH, len(readdirPlusReply.DirEnts), len(readdirPlusReply.StatEnts))
-	}
+	defer readdirPlusStream.Close()
 
 	readDirPlusOut = &fission.ReadDirPlusOut{
-		DirEntPlus: make([]fission.DirEntPlus, 0, numEntries),
+		DirEntPlus: make([]fission.DirEntPlus, 0),
 	}
 
 	curSize = 0
 
-	for dirEntIndex = 0; dirEntIndex < numEntries; dirEntIndex++ {
-		dirEntry = &readdirPlusReply.DirEnts[dirEntIndex]
-		statStruct = &readdirPlusReply.StatEnts[dirEntIndex]
+	// Consume frames until either the directory is exhausted or readDirPlusIn.Size is reached
 
-		dirEntNameLenAligned = (uint32(len(dirEntry.Basename)) + (fission.DirEntAlignment - 1)) & ^uint32(fission.DirEntAlignment-1)
-		dirEntPlusSize = fission.DirEntPlusFixedPortionSize + dirEntNameLenAligned
+EscapeReadDirPlusStream:
+	for {
+		readdirPlusReply = &jrpcfs.ReaddirPlusReply{}
 
-		if (curSize + dirEntPlusSize) > readDirPlusIn.Size {
-			break
+		err = readdirPlusStream.Recv(readdirPlusReply)
+		if io.EOF == err {
+			break EscapeReadDirPlusStream
+		}
+		if nil != err {
+			errno = convertErrToErrno(err, syscall.EIO)
+			return
 		}
 
-		aTimeSec, aTimeNSec = nsToUnixTime(statStruct.ATimeNs)
-		mTimeSec, mTimeNSec = nsToUnixTime(statStruct.MTimeNs)
-		cTimeSec, cTimeNSec = nsToUnixTime(statStruct.CTimeNs)
-
-		dirEntPlus = fission.DirEntPlus{
-			EntryOut: fission.EntryOut{
-				NodeID:         uint64(dirEntry.InodeNumber),
-				Generation:     0,
-				EntryValidSec:  globals.entryValidSec,
-				AttrValidSec:   globals.attrValidSec,
-				EntryValidNSec: globals.entryValidNSec,
-				AttrValidNSec:  globals.attrValidNSec,
-				Attr: fission.Attr{
-					Ino:       uint64(dirEntry.InodeNumber),
-					Size:      statStruct.Size,
-					Blocks:    0, // fixAttrSizes() will compute this
-					ATimeSec:  aTimeSec,
-					MTimeSec:  mTimeSec,
-					CTimeSec:  cTimeSec,
-					ATimeNSec: aTimeNSec,
-					MTimeNSec: mTimeNSec,
-					CTimeNSec: cTimeNSec,
-					Mode:      statStruct.FileMode,
-					NLink:     uint32(statStruct.NumLinks),
-					UID:       statStruct.UserID,
-					GID:       statStruct.GroupID,
-					RDev:      0,
-					BlkSize:   0, // fixAttrSizes() will set this
-					Padding:   0,
-				},
-			},
-			DirEnt: fission.DirEnt{
-				Ino:     uint64(dirEntry.InodeNumber),
-				Off:     uint64(dirEntry.NextDirLocation),
-				NameLen: uint32(len(dirEntry.Basename)), // unnecessary
-				Type:    uint32(dirEntry.FileType),
-				Name:    []byte(dirEntry.Basename),
-			},
+		numEntries = uint64(len(readdirPlusReply.DirEnts))
+		if numEntries != uint64(len(readdirPlusReply.StatEnts)) {
+			logFatalf("DoReadDirPlus(NodeID=%v,FH=%v) fetched mismatched number of DirEnts (%v) & StatEnts (%v)", inHeader.NodeID, readDirPlusIn.FH, len(readdirPlusReply.DirEnts), len(readdirPlusReply.StatEnts))
 		}
 
-		fixAttrSizes(&dirEntPlus.EntryOut.Attr)
+		for dirEntIndex = 0; dirEntIndex < numEntries; dirEntIndex++ {
+			dirEntry = &readdirPlusReply.DirEnts[dirEntIndex]
+			statStruct = &readdirPlusReply.StatEnts[dirEntIndex]
+
+			dirEntNameLenAligned = (uint32(len(dirEntry.Basename)) + (fission.DirEntAlignment - 1)) & ^uint32(fission.DirEntAlignment-1)
+			dirEntPlusSize = fission.DirEntPlusFixedPortionSize + dirEntNameLenAligned
+
+			if (curSize + dirEntPlusSize) > readDirPlusIn.Size {
+				break EscapeReadDirPlusStream
+			}
+
+			aTimeSec, aTimeNSec = nsToUnixTime(statStruct.ATimeNs)
+			mTimeSec, mTimeNSec = nsToUnixTime(statStruct.MTimeNs)
+			cTimeSec, cTimeNSec = nsToUnixTime(statStruct.CTimeNs)
+
+			dirEntPlus = fission.DirEntPlus{
+				EntryOut: fission.EntryOut{
+					NodeID:         uint64(dirEntry.InodeNumber),
+					Generation:     0,
+					EntryValidSec:  globals.entryValidSec,
+					AttrValidSec:   globals.attrValidSec,
+					EntryValidNSec: globals.entryValidNSec,
+					AttrValidNSec:  globals.attrValidNSec,
+					Attr: fission.Attr{
+						Ino:       uint64(dirEntry.InodeNumber),
+						Size:      statStruct.Size,
+						Blocks:    0, // fixAttrSizes() will compute this
+						ATimeSec:  aTimeSec,
+						MTimeSec:  mTimeSec,
+						CTimeSec:  cTimeSec,
+						ATimeNSec: aTimeNSec,
+						MTimeNSec: mTimeNSec,
+						CTimeNSec: cTimeNSec,
+						Mode:      statStruct.FileMode,
+						NLink:     uint32(statStruct.NumLinks),
+						UID:       statStruct.UserID,
+						GID:       statStruct.GroupID,
+						RDev:      0,
+						BlkSize:   0, // fixAttrSizes() will set this
+						Padding:   0,
+					},
+				},
+				DirEnt: fission.DirEnt{
+					Ino:     uint64(dirEntry.InodeNumber),
+					Off:     uint64(dirEntry.NextDirLocation),
+					NameLen: uint32(len(dirEntry.Basename)), // unnecessary
+					Type:    uint32(dirEntry.FileType),
+					Name:    []byte(dirEntry.Basename),
+				},
+			}
 
-		readDirPlusOut.DirEntPlus = append(readDirPlusOut.DirEntPlus, dirEntPlus)
+			fixAttrSizes(&dirEntPlus.EntryOut.Attr)
 
-		curSize += dirEntPlusSize
+			readDirPlusOut.DirEntPlus = append(readDirPlusOut.DirEntPlus, dirEntPlus)
+
+			curSize += dirEntPlusSize
+		}
 	}
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoReadDirPlus_entries, uint64(len(readDirPlusOut.DirEntPlus)))
@@ -2489,10 +2977,14 @@ func (dummy *globalsStruct) DoRename2(inHeader *fission.InHeader, rename2In *fis
 		lookupRequest  *jrpcfs.LookupRequest
 		moveReply      *jrpcfs.MoveReply
 		moveRequest    *jrpcfs.MoveRequest
+		span           *tracing.SpanStruct
 	)
 
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoRename2_calls, 1)
 
+	span = startFUSESpan("DoRename2", inHeader)
+	defer endFUSESpan(span, &errno)
+
 	lookupRequest = &jrpcfs.LookupRequest{
 		InodeHandle: jrpcfs.InodeHandle{
 			MountID:     globals.mountID,
@@ -2569,7 +3061,73 @@ func (dummy *globalsStruct) DoRename2(inHeader *fission.InHeader, rename2In *fis
 }
 
 func (dummy *globalsStruct) DoLSeek(inHeader *fission.InHeader, lSeekIn *fission.LSeekIn) (lSeekOut *fission.LSeekOut, errno syscall.Errno) {
+	var (
+		err           error
+		fhInodeNumber uint64
+		fileInode     *fileInodeStruct
+		lseekReply    *jrpcfs.LseekReply
+		lseekRequest  *jrpcfs.LseekRequest
+		ok            bool
+		span          *tracing.SpanStruct
+	)
+
 	_ = atomic.AddUint64(&globals.metrics.FUSE_DoLSeek_calls, 1)
-	errno = syscall.ENOSYS
+
+	span = startFUSESpan("DoLSeek", inHeader)
+	defer endFUSESpan(span, &errno)
+
+	// Only SEEK_DATA & SEEK_HOLE are sent to us... others are handled by the kernel
+
+	if (unix.SEEK_DATA != lSeekIn.Whence) && (unix.SEEK_HOLE != lSeekIn.Whence) {
+		errno = syscall.EINVAL
+		return
+	}
+
+	globals.Lock()
+
+	fhInodeNumber, ok = globals.fhToInodeNumberMap[lSeekIn.FH]
+	if !ok {
+		logFatalf("DoLSeek(NodeID=%v,FH=%v) called for unknown FH (in fhToInodeNumberMap)", inHeader.NodeID, lSeekIn.FH)
+	}
+	if fhInodeNumber != inHeader.NodeID {
+		logFatalf("DoLSeek(NodeID=%v,FH=%v) called for FH associated with NodeID=%v", inHeader.NodeID, lSeekIn.FH, fhInodeNumber)
+	}
+
+	globals.Unlock()
+
+	fileInode = lockInodeWithSharedLease(inode.InodeNumber(inHeader.NodeID))
+	if nil == fileInode {
+		logFatalf("DoLSeek(NodeID=%v,FH=%v) called for non-FileInode", inHeader.NodeID, lSeekIn.FH)
+	}
+
+	// Holes are tracked by ProxyFS, so it must first see any outstanding writes
+
+	fileInode.doFlushIfNecessary()
+
+	lseekRequest = &jrpcfs.LseekRequest{
+		InodeHandle: jrpcfs.InodeHandle{
+			MountID:     globals.mountID,
+			InodeNumber: int64(inHeader.NodeID),
+		},
+		Offset: lSeekIn.Offset,
+		Whence: int(lSeekIn.Whence),
+	}
+
+	lseekReply = &jrpcfs.LseekReply{}
+
+	err = globals.retryRPCClient.Send("RpcLseek", lseekRequest, lseekReply)
+	if nil != err {
+		fileInode.unlock(false)
+		errno = convertErrToErrno(err, syscall.EIO)
+		return
+	}
+
+	fileInode.unlock(false)
+
+	lSeekOut = &fission.LSeekOut{
+		Offset: lseekReply.Offset,
+	}
+
+	errno = 0
 	return
 }
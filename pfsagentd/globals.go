@@ -24,7 +24,9 @@ import (
 	"github.com/NVIDIA/proxyfs/conf"
 	"github.com/NVIDIA/proxyfs/inode"
 	"github.com/NVIDIA/proxyfs/jrpcfs"
+	"github.com/NVIDIA/proxyfs/keyring"
 	"github.com/NVIDIA/proxyfs/retryrpc"
+	"github.com/NVIDIA/proxyfs/tracing"
 	"github.com/NVIDIA/proxyfs/utils"
 )
 
@@ -46,10 +48,15 @@ type configStruct struct {
 	FetchExtentsBeforeFileOffset uint64
 	ReadCacheLineSize            uint64 // Aligned chunk of a LogSegment
 	ReadCacheLineCount           uint64
+	CacheDirPath                 string // Unless starting with '/', relative to $CWD; == "" means disabled
+	CacheDirReadCacheLineCount   uint64
+	ReadAheadWindowMax           uint64 // == 0 means disabled
+	ReadAheadInFlightMax         uint64
 	LeaseRetryLimit              uint64
 	LeaseRetryDelay              time.Duration
 	LeaseRetryDelayVariance      uint8
 	LeaseRetryExpBackoff         float64
+	FLockRetryDelay              time.Duration
 	SharedLeaseLimit             uint64
 	ExclusiveLeaseLimit          uint64
 	ExtentMapEntryLimit          uint64
@@ -136,6 +143,8 @@ type multiObjectExtentStruct struct {
 	objectName    string // If == "", implies a zero-filled extent/ReadPlanStep
 	objectOffset  uint64
 	length        uint64
+	compression   *inode.LogSegmentCompressionStruct // If != nil, objectOffset must be mapped thru compression
+	checksums     *inode.LogSegmentChecksumsStruct   // If != nil, used to verify each LogSegment Cache Line fetched
 }
 
 const (
@@ -163,6 +172,14 @@ type chunkedPutContextStruct struct {
 	//                                            will be closed to indicate a flush is requested
 	inRead         bool //                      Set when in Read() as a hint to Close() to help Read() cleanly exit
 	flushRequested bool //                      Set to remember that a flush has been requested of *chunkedPutContextStruct.Read()
+	diskCacheFile  *os.File
+	//                                            If != nil, journal of writes to buf under [Agent]CacheDirPath
+	//                                            removed once complete()'d with ProxyFS
+	compression *inode.LogSegmentCompressionStruct
+	//                                            If != nil (i.e. volume is encrypted), chunk map of sealedBuf
+	sealedBuf    []byte //                      Sealed chunks of buf[:sealedLen] sent in place of buf (pos is then relative to sealedBuf)
+	sealedLen    int    //                      Bytes of buf sealed so far
+	objectNumber uint64 //                      objectName as a uint64 (used to seal each chunk)
 }
 
 type fileInodeStruct struct {
@@ -197,6 +214,8 @@ type fileInodeStruct struct {
 
 type fhSetType map[uint64]struct{}
 
+type flockOwnerSetType map[uint64]struct{}
+
 type logSegmentCacheElementStateType uint8
 
 const (
@@ -218,6 +237,12 @@ type logSegmentCacheElementStruct struct {
 	startingOffset  uint64
 	cacheLRUElement *list.Element // Element on globals.logSegmentCacheLRU
 	buf             []byte
+	prefetched      bool // Set if fetched by read-ahead and not yet read
+}
+
+type readAheadStateStruct struct {
+	nextOffset uint64 // Offset following the most recent DoRead() of this FH
+	window     uint64 // Bytes to read-ahead (0 if access not sequential)
 }
 
 type authPlugInControlStruct struct {
@@ -290,8 +315,19 @@ type metricsStruct struct {
 
 	FUSE_DoBatchForget_nodes uint64
 
-	ReadCacheHits   uint64
-	ReadCacheMisses uint64
+	FUSE_DoSetLKW_retries uint64
+
+	ReadCacheHits               uint64
+	ReadCacheMisses             uint64
+	ReadCacheChecksumMismatches uint64
+
+	DiskCacheReadHits            uint64
+	DiskCacheReadMisses          uint64
+	DiskCacheReplayedLogSegments uint64
+
+	ReadAheadPrefetches uint64
+	ReadAheadHits       uint64
+	ReadAheadWaste      uint64
 
 	LogSegmentPUTs        uint64
 	LogSegmentPUTReadHits uint64
@@ -349,22 +385,33 @@ type globalsStruct struct {
 	swiftAuthToken                  string          // Protected by swiftAuthWaitGroup
 	swiftStorageURL                 string          // Protected by swiftAuthWaitGroup
 	mountID                         jrpcfs.MountIDAsString
+	keyring                         *keyring.KeyringStruct // If != nil, LogSegments are sealed & opened with [Volume:<FUSEVolumeName>]'s keys
 	fissionErrChan                  chan error
 	fissionVolume                   fission.Volume
 	fuseConn                        *fuse.Conn
 	jrpcLastID                      uint64
 	fileInodeMap                    map[inode.InodeNumber]*fileInodeStruct
-	fileInodeDirtyList              *list.List           // LRU of fileInode's with non-empty chunkedPutList
-	fileInodeDirtyLogSegmentChan    chan struct{}        // Limits # of in-flight LogSegment Chunked PUTs
-	unleasedFileInodeCacheLRU       *list.List           // Front() is oldest fileInodeStruct.leaseListElement
-	sharedLeaseFileInodeCacheLRU    *list.List           // Front() is oldest fileInodeStruct.leaseListElement
-	exclusiveLeaseFileInodeCacheLRU *list.List           // Front() is oldest fileInodeStruct.leaseListElement
-	fhToOpenRequestMap              map[uint64]uint32    // Key == FH; Value == {Create|Open}In.Flags
-	fhToInodeNumberMap              map[uint64]uint64    // Key == FH; Value == InodeNumber
-	inodeNumberToFHMap              map[uint64]fhSetType // Key == InodeNumber; Value == set of FH's
-	lastFH                          uint64               // Valid FH's start at 1
+	fileInodeDirtyList              *list.List                   // LRU of fileInode's with non-empty chunkedPutList
+	fileInodeDirtyLogSegmentChan    chan struct{}                // Limits # of in-flight LogSegment Chunked PUTs
+	unleasedFileInodeCacheLRU       *list.List                   // Front() is oldest fileInodeStruct.leaseListElement
+	sharedLeaseFileInodeCacheLRU    *list.List                   // Front() is oldest fileInodeStruct.leaseListElement
+	exclusiveLeaseFileInodeCacheLRU *list.List                   // Front() is oldest fileInodeStruct.leaseListElement
+	fhToOpenRequestMap              map[uint64]uint32            // Key == FH; Value == {Create|Open}In.Flags
+	fhToInodeNumberMap              map[uint64]uint64            // Key == FH; Value == InodeNumber
+	inodeNumberToFHMap              map[uint64]fhSetType         // Key == InodeNumber; Value == set of FH's
+	lastFH                          uint64                       // Valid FH's start at 1
+	inodeNumberToFLockOwnerMap      map[uint64]flockOwnerSetType // Key == InodeNumber; Value == set of FUSE LockOwner's possibly holding locks
+	setLKWInterruptChanMap          map[uint64]chan struct{}     // Key == InHeader.Unique of each DoSetLKW() awaiting a lock
 	logSegmentCacheMap              map[logSegmentCacheElementKeyStruct]*logSegmentCacheElementStruct
 	logSegmentCacheLRU              *list.List // Front() is oldest logSegmentCacheElementStruct.cacheLRUElement
+	diskCacheMutex                  sync.Mutex // Protects the diskCache* fields that follow
+	diskCacheReadLineMap            map[logSegmentCacheElementKeyStruct]*list.Element
+	diskCacheReadLineLRU            *list.List // Front() is oldest on-disk LogSegment Cache Line (Value == logSegmentCacheElementKeyStruct)
+	diskCacheNextDirtySequence      uint64     // Used to name (and order) each chunkedPutContextStruct.diskCacheFile
+	readAheadWG                     sync.WaitGroup
+	fhToReadAheadStateMap           map[uint64]*readAheadStateStruct // Key == FH
+	readAheadInFlight               uint64                           // # of logSegmentCacheElementStruct's being prefetched
+	tracingConfig                   *tracing.ConfigStruct            // If != nil, tracing has been started
 	metrics                         *metricsStruct
 	stats                           *statsStruct
 }
@@ -397,6 +444,13 @@ func initializeGlobals(confMap conf.ConfMap) {
 		logFatal(err)
 	}
 
+	// An encrypted volume's keys are configured just as they are for ProxyFS
+
+	globals.keyring, err = keyring.NewKeyring(confMap, globals.config.FUSEVolumeName)
+	if nil != err {
+		logFatal(err)
+	}
+
 	globals.config.FUSEMountPointPath, err = confMap.FetchOptionValueString("Agent", "FUSEMountPointPath")
 	if nil != err {
 		logFatal(err)
@@ -499,6 +553,26 @@ func initializeGlobals(confMap conf.ConfMap) {
 		logFatal(err)
 	}
 
+	globals.config.CacheDirPath, err = confMap.FetchOptionValueString("Agent", "CacheDirPath")
+	if nil != err {
+		globals.config.CacheDirPath = "" // If not present, default to disabling the on-disk cache
+	}
+
+	globals.config.CacheDirReadCacheLineCount, err = confMap.FetchOptionValueUint64("Agent", "CacheDirReadCacheLineCount")
+	if nil != err {
+		globals.config.CacheDirReadCacheLineCount = globals.config.ReadCacheLineCount // If not present, default to ReadCacheLineCount
+	}
+
+	globals.config.ReadAheadWindowMax, err = confMap.FetchOptionValueUint64("Agent", "ReadAheadWindowMax")
+	if nil != err {
+		globals.config.ReadAheadWindowMax = 0 // If not present, default to disabling read-ahead
+	}
+
+	globals.config.ReadAheadInFlightMax, err = confMap.FetchOptionValueUint64("Agent", "ReadAheadInFlightMax")
+	if nil != err {
+		globals.config.ReadAheadInFlightMax = 8 // If not present, default to 8 concurrent read-ahead cache line fetches
+	}
+
 	globals.config.LeaseRetryLimit, err = confMap.FetchOptionValueUint64("Agent", "LeaseRetryLimit")
 	if nil != err {
 		logFatal(err)
@@ -527,6 +601,12 @@ func initializeGlobals(confMap conf.ConfMap) {
 		logFatal(err)
 	}
 
+	globals.config.FLockRetryDelay, err = confMap.FetchOptionValueDuration("Agent", "FLockRetryDelay")
+	if nil != err {
+		globals.config.FLockRetryDelay = 100 * time.Millisecond // If not present, default to retrying blocked FLock requests every 100ms
+		logInfof("[Agent]FLockRetryDelay not specified... defaulting to %v", globals.config.FLockRetryDelay)
+	}
+
 	globals.config.SharedLeaseLimit, err = confMap.FetchOptionValueUint64("Agent", "SharedLeaseLimit")
 	if nil != err {
 		logFatal(err)
@@ -749,21 +829,49 @@ func initializeGlobals(confMap conf.ConfMap) {
 	globals.fhToOpenRequestMap = make(map[uint64]uint32)
 	globals.fhToInodeNumberMap = make(map[uint64]uint64)
 	globals.inodeNumberToFHMap = make(map[uint64]fhSetType)
+	globals.fhToReadAheadStateMap = make(map[uint64]*readAheadStateStruct)
 
 	globals.lastFH = 0
 
+	globals.inodeNumberToFLockOwnerMap = make(map[uint64]flockOwnerSetType)
+	globals.setLKWInterruptChanMap = make(map[uint64]chan struct{})
+
 	globals.logSegmentCacheMap = make(map[logSegmentCacheElementKeyStruct]*logSegmentCacheElementStruct)
 	globals.logSegmentCacheLRU = list.New()
 
+	globals.readAheadInFlight = 0
+
 	globals.metrics = &metricsStruct{}
 	globals.stats = &statsStruct{}
 
-	bucketstats.Register("PFSAgent", "", globals.stats)
+	initializeDiskCache()
+
+	globals.tracingConfig, err = tracing.ParseConfMap(confMap)
+	if nil != err {
+		logFatal(err)
+	}
+	if nil != globals.tracingConfig {
+		err = tracing.Start(globals.tracingConfig)
+		if nil != err {
+			logFatal(err)
+		}
+	}
+
+	bucketstats.RegisterWithLabels("PFSAgent", "", map[string]string{"volume": globals.config.FUSEVolumeName}, globals.stats)
 }
 
 func uninitializeGlobals() {
 	bucketstats.UnRegister("PFSAgent", "")
 
+	globals.readAheadWG.Wait()
+
+	uninitializeDiskCache()
+
+	if nil != globals.tracingConfig {
+		_ = tracing.Stop()
+		globals.tracingConfig = nil
+	}
+
 	globals.logFile = nil
 	globals.retryRPCCACertPEM = nil
 	globals.retryRPCClient = nil
@@ -791,7 +899,10 @@ func uninitializeGlobals() {
 	globals.fhToOpenRequestMap = nil
 	globals.fhToInodeNumberMap = nil
 	globals.inodeNumberToFHMap = nil
+	globals.fhToReadAheadStateMap = nil
 	globals.lastFH = 0
+	globals.inodeNumberToFLockOwnerMap = nil
+	globals.setLKWInterruptChanMap = nil
 	globals.logSegmentCacheMap = nil
 	globals.logSegmentCacheLRU = nil
 	globals.metrics = nil
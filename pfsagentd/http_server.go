@@ -398,6 +398,10 @@ func serveGetOfMetrics(responseWriter http.ResponseWriter, request *http.Request
 		line = fmt.Sprintf(format, keyAsString, valueAsString)
 		_, _ = responseWriter.Write([]byte(line))
 	}
+
+	// Append bucketstats statistics (labeled with our FUSEVolumeName) as typed Prometheus metrics
+
+	_, _ = responseWriter.Write([]byte(bucketstats.SprintPrometheusStats("*", "*")))
 }
 
 func serveGetOfStats(responseWriter http.ResponseWriter, request *http.Request) {
@@ -6,6 +6,8 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/NVIDIA/proxyfs/tracing"
 )
 
 type jrpcRequestMethodAndIDStruct struct {
@@ -14,10 +16,11 @@ type jrpcRequestMethodAndIDStruct struct {
 }
 
 type jrpcRequestStruct struct {
-	JSONrpc string         `json:"jsonrpc"`
-	Method  string         `json:"method"`
-	ID      uint64         `json:"id"`
-	Params  [1]interface{} `json:"params"`
+	JSONrpc     string         `json:"jsonrpc"`
+	Method      string         `json:"method"`
+	ID          uint64         `json:"id"`
+	Params      [1]interface{} `json:"params"`
+	TraceParent string         `json:"traceparent,omitempty"`
 }
 
 type jrpcResponseIDStruct struct {
@@ -51,10 +54,11 @@ func jrpcMarshalRequest(requestMethod string, request interface{}) (requestID ui
 	globals.Unlock()
 
 	jrpcRequest = &jrpcRequestStruct{
-		JSONrpc: "2.0",
-		Method:  requestMethod,
-		ID:      requestID,
-		Params:  [1]interface{}{request},
+		JSONrpc:     "2.0",
+		Method:      requestMethod,
+		ID:          requestID,
+		Params:      [1]interface{}{request},
+		TraceParent: tracing.CurrentTraceParent(),
 	}
 
 	requestBuf, marshalErr = json.Marshal(jrpcRequest)
@@ -5,13 +5,23 @@ package main
 
 import (
 	"container/list"
+	"context"
 	"encoding/json"
 	"time"
 
 	"github.com/NVIDIA/proxyfs/inode"
 	"github.com/NVIDIA/proxyfs/jrpcfs"
+	"github.com/NVIDIA/proxyfs/retryrpc"
 )
 
+// sendLeaseRequest issues an RpcLease at retryrpc.PriorityHigh so that it is
+// not stuck behind a backlog of (e.g.) RpcWrote's at the server.
+//
+func sendLeaseRequest(leaseRequest *jrpcfs.LeaseRequest, leaseReply *jrpcfs.LeaseReply) (err error) {
+	err = globals.retryRPCClient.SendContext(retryrpc.WithPriority(context.Background(), retryrpc.PriorityHigh), "RpcLease", leaseRequest, leaseReply)
+	return
+}
+
 func (dummy *globalsStruct) Interrupt(rpcInterruptBuf []byte) {
 	var (
 		err          error
@@ -89,7 +99,7 @@ func (dummy *globalsStruct) Interrupt(rpcInterruptBuf []byte) {
 				_ = globals.exclusiveLeaseFileInodeCacheLRU.Remove(fileInode.leaseListElement)
 				fileInode.leaseListElement = globals.sharedLeaseFileInodeCacheLRU.PushBack(fileInode)
 				globals.Unlock()
-				// TODO - flush dirty state (chunkedPutList)
+				fileInode.doFlushIfNecessary()
 				// TODO - inform ProxyFS that we'd like to Demote our Exclusive Lease
 				// TODO - finally service any waiters that have arrived (or delete lockWaiters)
 			} else { // jrpcfs.RPCInterruptTypeRelease == rpcInterrupt.RPCInterruptType
@@ -97,7 +107,7 @@ func (dummy *globalsStruct) Interrupt(rpcInterruptBuf []byte) {
 				_ = globals.exclusiveLeaseFileInodeCacheLRU.Remove(fileInode.leaseListElement)
 				fileInode.leaseListElement = globals.unleasedFileInodeCacheLRU.PushBack(fileInode)
 				globals.Unlock()
-				// TODO - flush dirty state (chunkedPutList)
+				fileInode.doFlushIfNecessary()
 				// TODO - invalidate cached state (cachedStat and extentMap)
 				// TODO - inform ProxyFS that we'd like to Release our Exclusive Lease
 				// TODO - finally service any waiters that have arrived (or delete lockWaiters)
@@ -175,7 +185,7 @@ func lockInodeWithSharedLease(inodeNumber inode.InodeNumber) (fileInode *fileIno
 			leaseReply = &jrpcfs.LeaseReply{}
 
 			leaseRequestStartTime = time.Now()
-			err = globals.retryRPCClient.Send("RpcLease", leaseRequest, leaseReply)
+			err = sendLeaseRequest(leaseRequest, leaseReply)
 			if nil != err {
 				logFatalf("lockInodeWithSharedLease() unable to obtain Shared Lease [case 1] - retryRPCClient.Send() failed: %v", err)
 			}
@@ -304,7 +314,7 @@ func lockInodeWithSharedLease(inodeNumber inode.InodeNumber) (fileInode *fileIno
 		leaseReply = &jrpcfs.LeaseReply{}
 
 		leaseRequestStartTime = time.Now()
-		err = globals.retryRPCClient.Send("RpcLease", leaseRequest, leaseReply)
+		err = sendLeaseRequest(leaseRequest, leaseReply)
 		if nil != err {
 			logFatalf("lockInodeWithSharedLease() unable to obtain Shared Lease [case 3] - retryRPCClient.Send() failed: %v", err)
 		}
@@ -522,7 +532,7 @@ func lockInodeWithExclusiveLease(inodeNumber inode.InodeNumber) (fileInode *file
 			leaseReply = &jrpcfs.LeaseReply{}
 
 			leaseRequestStartTime = time.Now()
-			err = globals.retryRPCClient.Send("RpcLease", leaseRequest, leaseReply)
+			err = sendLeaseRequest(leaseRequest, leaseReply)
 			if nil != err {
 				logFatalf("lockInodeWithExclusiveLease() unable to obtain Exclusive Lease [case 1] - retryRPCClient.Send() failed: %v", err)
 			}
@@ -647,7 +657,7 @@ func lockInodeWithExclusiveLease(inodeNumber inode.InodeNumber) (fileInode *file
 		leaseReply = &jrpcfs.LeaseReply{}
 
 		leaseRequestStartTime = time.Now()
-		err = globals.retryRPCClient.Send("RpcLease", leaseRequest, leaseReply)
+		err = sendLeaseRequest(leaseRequest, leaseReply)
 		if nil != err {
 			logFatalf("lockInodeWithExclusiveLease() unable to obtain Exclusive Lease [case 3] - retryRPCClient.Send() failed: %v", err)
 		}
@@ -687,7 +697,7 @@ func lockInodeWithExclusiveLease(inodeNumber inode.InodeNumber) (fileInode *file
 		leaseReply = &jrpcfs.LeaseReply{}
 
 		leaseRequestStartTime = time.Now()
-		err = globals.retryRPCClient.Send("RpcLease", leaseRequest, leaseReply)
+		err = sendLeaseRequest(leaseRequest, leaseReply)
 		if nil != err {
 			logFatalf("lockInodeWithExclusiveLease() unable to obtain Exclusive Lease [case 5] - retryRPCClient.Send() failed: %v", err)
 		}
@@ -726,7 +736,7 @@ func lockInodeWithExclusiveLease(inodeNumber inode.InodeNumber) (fileInode *file
 			leaseReply = &jrpcfs.LeaseReply{}
 
 			leaseRequestStartTime = time.Now()
-			err = globals.retryRPCClient.Send("RpcLease", leaseRequest, leaseReply)
+			err = sendLeaseRequest(leaseRequest, leaseReply)
 			if nil != err {
 				logFatalf("lockInodeWithExclusiveLease() unable to obtain Exclusive Lease [case 6] - retryRPCClient.Send() failed: %v", err)
 			}
@@ -755,7 +765,7 @@ func lockInodeWithExclusiveLease(inodeNumber inode.InodeNumber) (fileInode *file
 				leaseReply = &jrpcfs.LeaseReply{}
 
 				leaseRequestStartTime = time.Now()
-				err = globals.retryRPCClient.Send("RpcLease", leaseRequest, leaseReply)
+				err = sendLeaseRequest(leaseRequest, leaseReply)
 				if nil != err {
 					logFatalf("lockInodeWithExclusiveLease() unable to obtain Exclusive Lease [case 7] - retryRPCClient.Send() failed: %v", err)
 				}
@@ -836,7 +846,9 @@ func (fileInode *fileInodeStruct) unlock(forceRelease bool) {
 
 				globals.Unlock()
 
-				// TODO - flush dirty state (chunkedPutList)
+				// Flush dirty state (chunkedPutList) before giving up our Exclusive Lease
+
+				fileInode.doFlushIfNecessary()
 
 				leaseRequest = &jrpcfs.LeaseRequest{
 					InodeHandle: jrpcfs.InodeHandle{
@@ -848,7 +860,7 @@ func (fileInode *fileInodeStruct) unlock(forceRelease bool) {
 				leaseReply = &jrpcfs.LeaseReply{}
 
 				leaseRequestStartTime = time.Now()
-				err = globals.retryRPCClient.Send("RpcLease", leaseRequest, leaseReply)
+				err = sendLeaseRequest(leaseRequest, leaseReply)
 				if nil != err {
 					logFatalf("(*fileInodeStruct).unlock() unable to Demote Exclusive Lease [case 1] - retryRPCClient.Send() failed: %v", err)
 				}
@@ -888,7 +900,7 @@ func (fileInode *fileInodeStruct) unlock(forceRelease bool) {
 				leaseReply = &jrpcfs.LeaseReply{}
 
 				leaseRequestStartTime = time.Now()
-				err = globals.retryRPCClient.Send("RpcLease", leaseRequest, leaseReply)
+				err = sendLeaseRequest(leaseRequest, leaseReply)
 				if nil != err {
 					logFatalf("(*fileInodeStruct).unlock() unable to Demote Exclusive Lease [case 3] - retryRPCClient.Send() failed: %v", err)
 				}
@@ -912,8 +924,11 @@ func (fileInode *fileInodeStruct) unlock(forceRelease bool) {
 
 				globals.Unlock()
 
+				// Flush dirty state (chunkedPutList) before giving up our Exclusive Lease
+
+				fileInode.doFlushIfNecessary()
+
 				// TODO - invalidate cached state (cachedStat and extentMap)
-				// TODO - flush dirty state (chunkedPutList)
 
 				leaseRequest = &jrpcfs.LeaseRequest{
 					InodeHandle: jrpcfs.InodeHandle{
@@ -925,7 +940,7 @@ func (fileInode *fileInodeStruct) unlock(forceRelease bool) {
 				leaseReply = &jrpcfs.LeaseReply{}
 
 				leaseRequestStartTime = time.Now()
-				err = globals.retryRPCClient.Send("RpcLease", leaseRequest, leaseReply)
+				err = sendLeaseRequest(leaseRequest, leaseReply)
 				if nil != err {
 					logFatalf("(*fileInodeStruct).unlock() unable to Demote Exclusive Lease [case 5] - retryRPCClient.Send() failed: %v", err)
 				}
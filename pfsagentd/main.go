@@ -61,6 +61,10 @@ func main() {
 
 	doMountProxyFS()
 
+	// Replay any writes journaled (but not flushed) by a prior instance
+
+	replayDiskCacheDirtyLogSegments()
+
 	// Start serving FUSE mount point
 
 	performMountFUSE()
@@ -0,0 +1,130 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"github.com/NVIDIA/proxyfs/inode"
+)
+
+// readAheadIfSequential tracks whether DoRead()'s via fh are sequential. If so, the
+// read-ahead window is grown (doubling from ReadCacheLineSize up to ReadAheadWindowMax)
+// and the LogSegment Cache Lines covering that window beyond the just completed DoRead()
+// are fetched in parallel in the background (up to ReadAheadInFlightMax at a time). Any
+// other DoRead() pattern collapses the read-ahead window.
+//
+// The caller must hold a lock on fileInode.
+//
+func (fileInode *fileInodeStruct) readAheadIfSequential(fh uint64, offset uint64, length uint64) {
+	var (
+		cacheLineOffset                       uint64
+		err                                   error
+		ok                                    bool
+		readAheadLength                       uint64
+		readAheadOffset                       uint64
+		readAheadState                        *readAheadStateStruct
+		readPlan                              []interface{}
+		readPlanStepAsInterface               interface{}
+		readPlanStepAsMultiObjectExtentStruct *multiObjectExtentStruct
+	)
+
+	if (0 == globals.config.ReadAheadWindowMax) || (0 == length) {
+		return
+	}
+
+	globals.Lock()
+
+	readAheadState, ok = globals.fhToReadAheadStateMap[fh]
+	if !ok {
+		readAheadState = &readAheadStateStruct{
+			nextOffset: 0,
+			window:     0,
+		}
+		globals.fhToReadAheadStateMap[fh] = readAheadState
+	}
+
+	if offset == readAheadState.nextOffset {
+		if 0 == readAheadState.window {
+			readAheadState.window = globals.config.ReadCacheLineSize
+		} else {
+			readAheadState.window *= 2
+		}
+		if readAheadState.window > globals.config.ReadAheadWindowMax {
+			readAheadState.window = globals.config.ReadAheadWindowMax
+		}
+	} else {
+		readAheadState.window = 0
+	}
+
+	readAheadState.nextOffset = offset + length
+
+	readAheadOffset = readAheadState.nextOffset
+	readAheadLength = readAheadState.window
+
+	globals.Unlock()
+
+	if (0 == readAheadLength) || (readAheadOffset >= fileInode.cachedStat.Size) {
+		return
+	}
+
+	if (readAheadOffset + readAheadLength) > fileInode.cachedStat.Size {
+		readAheadLength = fileInode.cachedStat.Size - readAheadOffset
+	}
+
+	err = fileInode.populateExtentMap(readAheadOffset, readAheadLength)
+	if nil != err {
+		return
+	}
+
+	readPlan, _ = fileInode.getReadPlan(readAheadOffset, readAheadLength)
+
+	for _, readPlanStepAsInterface = range readPlan {
+		readPlanStepAsMultiObjectExtentStruct, ok = readPlanStepAsInterface.(*multiObjectExtentStruct)
+		if !ok || ("" == readPlanStepAsMultiObjectExtentStruct.objectName) {
+			// Either zero-filled or not yet persisted by Swift... so nothing to read-ahead
+			continue
+		}
+
+		cacheLineOffset = readPlanStepAsMultiObjectExtentStruct.objectOffset - (readPlanStepAsMultiObjectExtentStruct.objectOffset % globals.config.ReadCacheLineSize)
+
+		for cacheLineOffset < (readPlanStepAsMultiObjectExtentStruct.objectOffset + readPlanStepAsMultiObjectExtentStruct.length) {
+			if !prefetchLogSegmentCacheLine(readPlanStepAsMultiObjectExtentStruct.containerName, readPlanStepAsMultiObjectExtentStruct.objectName, readPlanStepAsMultiObjectExtentStruct.compression, readPlanStepAsMultiObjectExtentStruct.checksums, cacheLineOffset) {
+				// At our cap... so abandon the balance of the read-ahead window
+				return
+			}
+
+			cacheLineOffset += globals.config.ReadCacheLineSize
+		}
+	}
+}
+
+// prefetchLogSegmentCacheLine launches a background fetch of the LogSegment Cache Line
+// containing offset unless ReadAheadInFlightMax such fetches are already underway.
+//
+func prefetchLogSegmentCacheLine(containerName string, objectName string, compression *inode.LogSegmentCompressionStruct, checksums *inode.LogSegmentChecksumsStruct, offset uint64) (launched bool) {
+	globals.Lock()
+
+	if globals.readAheadInFlight >= globals.config.ReadAheadInFlightMax {
+		globals.Unlock()
+		launched = false
+		return
+	}
+
+	globals.readAheadInFlight++
+	globals.readAheadWG.Add(1)
+
+	globals.Unlock()
+
+	go func() {
+		_ = fetchOrPrefetchLogSegmentCacheLine(containerName, objectName, compression, checksums, offset, true)
+
+		globals.Lock()
+		globals.readAheadInFlight--
+		globals.Unlock()
+
+		globals.readAheadWG.Done()
+	}()
+
+	launched = true
+	return
+}
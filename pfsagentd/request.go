@@ -19,6 +19,7 @@ import (
 
 	"github.com/NVIDIA/proxyfs/jrpcfs"
 	"github.com/NVIDIA/proxyfs/retryrpc"
+	"github.com/NVIDIA/proxyfs/tracing"
 	"github.com/NVIDIA/proxyfs/version"
 )
 
@@ -78,6 +79,11 @@ func doMountProxyFS() {
 
 	globals.mountID = mountReply.MountID
 
+	if mountReply.Encrypted != (nil != globals.keyring) {
+		logFatalf("Volume %s (Account: %s) encrypted == %v but [Volume:%s]EncryptionKeyProvider configured == %v", globals.config.FUSEVolumeName, accountName, mountReply.Encrypted, globals.config.FUSEVolumeName, nil != globals.keyring)
+	}
+
+	verifyDiskCacheIdentity(accountName)
 }
 
 func doUnmountProxyFS() {
@@ -114,10 +120,16 @@ func doJRPCRequest(jrpcMethod string, jrpcParam interface{}, jrpcResult interfac
 		jrpcResponse    []byte
 		marshalErr      error
 		ok              bool
+		span            *tracing.SpanStruct
 		swiftStorageURL string
 		unmarshalErr    error
 	)
 
+	span = tracing.StartSpan("jrpc/"+jrpcMethod, tracing.SpanKindClient)
+	defer func() {
+		span.EndWithError(err)
+	}()
+
 	jrpcRequestID, jrpcRequest, marshalErr = jrpcMarshalRequest(jrpcMethod, jrpcParam)
 	if nil != marshalErr {
 		logFatalf("unable to marshal request (jrpcMethod=%s jrpcParam=%v): %#v", jrpcMethod, jrpcParam, marshalErr)
@@ -100,10 +100,12 @@ func testSetup(t *testing.T) {
 		"Agent.FetchExtentsBeforeFileOffset=0",
 		"Agent.ReadCacheLineSize=1048576",
 		"Agent.ReadCacheLineCount=1000",
+		"Agent.CacheDirPath=",
 		"Agent.LeaseRetryLimit=10",
 		"Agent.LeaseRetryDelay=10ms",
 		"Agent.LeaseRetryDelayVariance=25",
 		"Agent.LeaseRetryExpBackoff=1.4",
+		"Agent.FLockRetryDelay=10ms",
 		"Agent.SharedLeaseLimit=1000",
 		"Agent.ExclusiveLeaseLimit=100",
 		"Agent.ExtentMapEntryLimit=1048576",
@@ -0,0 +1,33 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"syscall"
+
+	"github.com/NVIDIA/fission"
+
+	"github.com/NVIDIA/proxyfs/tracing"
+)
+
+// startFUSESpan starts the Span covering a FUSE upcall. Any jrpcfs or retryrpc
+// request made while servicing the upcall (on the same goroutine) will carry
+// this Span's trace context to ProxyFS.
+//
+func startFUSESpan(opName string, inHeader *fission.InHeader) (span *tracing.SpanStruct) {
+	span = tracing.StartSpan("fuse/"+opName, tracing.SpanKindServer)
+	span.SetAttribute("fuse.node_id", inHeader.NodeID)
+	span.SetAttribute("fuse.unique", inHeader.Unique)
+	return
+}
+
+// endFUSESpan ends a Span started by startFUSESpan(). It is intended to be
+// deferred such that the upcall's final errno is recorded.
+//
+func endFUSESpan(span *tracing.SpanStruct, errno *syscall.Errno) {
+	if 0 != *errno {
+		span.SetError(*errno)
+	}
+	span.End()
+}
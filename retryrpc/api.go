@@ -8,7 +8,9 @@ package retryrpc
 //
 // NOTE: This package does handle cases where the server process dies.  There
 // are still gaps where a server may complete an RPC and die before returning
-// a response.
+// a response unless ServerConfig.JournalPath is set.  Even then, a server that
+// dies after the RPC returns but before its reply is journaled will call the
+// RPC again when it is retransmitted.
 
 import (
 	"bytes"
@@ -56,8 +58,11 @@ type Server struct {
 	deadlineIO           time.Duration
 	keepAlivePeriod      time.Duration
 	completedDoneWG      sync.WaitGroup
-	logger               *log.Logger // If nil, defaults to log.New()
-	dontStartTrimmers    bool        // Used for testing
+	logger               *log.Logger       // If nil, defaults to log.New()
+	dontStartTrimmers    bool              // Used for testing
+	normalRPCSlots       chan struct{}     // If non-nil, limits concurrently executing PriorityNormal RPCs
+	journalPath          string            // If non-empty, path of journal of completed requests
+	journal              *completedJournal // nil unless journalPath != ""
 }
 
 // ServerConfig is used to configure a retryrpc Server
@@ -69,6 +74,8 @@ type ServerConfig struct {
 	DeadlineIO        time.Duration   // How long I/Os on sockets wait even if idle
 	KeepAlivePeriod   time.Duration   // How frequently a KEEPALIVE is sent
 	TLSCertificate    tls.Certificate // TLS Certificate to present to Clients (or tls.Certificate{} if using TCP)
+	MaxConcurrentRPCs int             // Max PriorityNormal RPCs executed at once (0 means no limit... so Priority only orders Client writes) - PriorityHigh RPCs, and streams awaiting credit, never wait
+	JournalPath       string          // If non-empty, completed RPCs are journaled here so they survive a Server restart
 	Logger            *log.Logger     // If nil, defaults to log.New()
 	dontStartTrimmers bool            // Used for testing
 }
@@ -84,6 +91,7 @@ func NewServer(config *ServerConfig) *Server {
 		keepAlivePeriod:   config.KeepAlivePeriod,
 		dontStartTrimmers: config.dontStartTrimmers,
 		logger:            config.Logger,
+		journalPath:       config.JournalPath,
 		tlsCertificate:    config.TLSCertificate}
 	if server.logger == nil {
 		var logBuf bytes.Buffer
@@ -93,6 +101,9 @@ func NewServer(config *ServerConfig) *Server {
 	server.perClientInfo = make(map[uint64]*clientInfo)
 	server.completedTickerDone = make(chan bool)
 	server.connections = list.New()
+	if config.MaxConcurrentRPCs > 0 {
+		server.normalRPCSlots = make(chan struct{}, config.MaxConcurrentRPCs)
+	}
 
 	return server
 }
@@ -108,6 +119,13 @@ func (server *Server) Register(retrySvr interface{}) (err error) {
 func (server *Server) Start() (err error) {
 	hostPortStr := net.JoinHostPort(server.dnsOrIpaddr, fmt.Sprintf("%d", server.port))
 
+	// Recover completed requests (if journaling) before any client can retransmit
+	err = server.startJournal()
+	if nil != err {
+		err = fmt.Errorf("startJournal() failed: %v", err)
+		return
+	}
+
 	tlsConfig := &tls.Config{
 		Certificates: []tls.Certificate{server.tlsCertificate},
 	}
@@ -206,6 +224,15 @@ func (server *Server) SendCallback(clientID uint64, msg []byte) {
 func (server *Server) Close() {
 	server.Lock()
 	server.halting = true
+
+	// Wake up streaming RPCs waiting for their client to consume frames
+	for _, ci := range server.perClientInfo {
+		ci.Lock()
+		for _, stream := range ci.activeStream {
+			stream.cancel()
+		}
+		ci.Unlock()
+	}
 	server.Unlock()
 
 	if len(server.tlsCertificate.Certificate) == 0 {
@@ -234,6 +261,8 @@ func (server *Server) Close() {
 	server.completedTickerDone <- true
 	server.completedDoneWG.Wait()
 
+	server.stopJournal()
+
 	// Cleanup bucketstats so that unit tests can run
 	for _, ci := range server.perClientInfo {
 		ci.Lock()
@@ -310,10 +339,13 @@ type Client struct {
 	// or to be sent to server.  Key is assigned from currentRequestID
 	highestConsecutive requestID // Highest requestID that can be
 	// trimmed
-	bt          *btree.BTree   // btree of requestID's acked
-	goroutineWG sync.WaitGroup // Used to track outstanding goroutines
-	logger      *log.Logger    // If nil, defaults to log.New()
-	stats       clientSideStatsInfo
+	bt           *btree.BTree   // btree of requestID's acked
+	goroutineWG  sync.WaitGroup // Used to track outstanding goroutines
+	logger       *log.Logger    // If nil, defaults to log.New()
+	streamWindow uint32         // Frames of a stream the server may send ahead of Recv()
+	stats        clientSideStatsInfo
+	sendQueue    [PriorityHigh + 1]*list.List // Of *queuedSendStruct's... PriorityHigh's written first
+	sending      bool                         // Set while a goroutine is draining sendQueue
 }
 
 // ClientCallbacks contains the methods required when supporting
@@ -333,6 +365,7 @@ type ClientConfig struct {
 	Callbacks                interface{}   // Structure implementing ClientCallbacks
 	DeadlineIO               time.Duration // How long I/Os on sockets wait even if idle
 	KeepAlivePeriod          time.Duration // How frequently a KEEPALIVE is sent
+	StreamWindow             uint32        // Frames of a stream the Server may send ahead of Recv() (0 means DefaultStreamWindow)
 	Logger                   *log.Logger   // If nil, defaults to log.New()
 }
 
@@ -358,6 +391,11 @@ func NewClient(config *ClientConfig) (client *Client, err error) {
 		keepAlivePeriod: config.KeepAlivePeriod,
 		deadlineIO:      config.DeadlineIO,
 		logger:          config.Logger,
+		streamWindow:    config.StreamWindow,
+	}
+
+	if client.streamWindow == 0 {
+		client.streamWindow = DefaultStreamWindow
 	}
 
 	if client.logger == nil {
@@ -367,6 +405,9 @@ func NewClient(config *ClientConfig) (client *Client, err error) {
 
 	client.outstandingRequest = make(map[requestID]*reqCtx)
 	client.bt = btree.New(2)
+	for priority := range client.sendQueue {
+		client.sendQueue[priority] = list.New()
+	}
 
 	if config.RootCAx509CertificatePEM == nil {
 		client.connection.useTLS = false
@@ -387,10 +428,57 @@ func NewClient(config *ClientConfig) (client *Client, err error) {
 	return client, err
 }
 
+// Priority is the class of an RPC sent with SendContext()
+type Priority uint8
+
+const (
+	// PriorityNormal is the Priority of RPCs sent with Send() or
+	// with a context not passed through WithPriority()
+	PriorityNormal Priority = iota
+	// PriorityHigh RPCs (e.g. leases) are never queued behind PriorityNormal
+	// RPCs (e.g. flushes) - neither by the Client waiting to write them to
+	// the socket nor by a Server with a MaxConcurrentRPCs limit
+	PriorityHigh
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of parent that causes SendContext() to send
+// the RPC with the specified Priority
+func WithPriority(parent context.Context, priority Priority) context.Context {
+	return context.WithValue(parent, priorityKey{}, priority)
+}
+
 // Send the request and block until it has completed
 func (client *Client) Send(method string, request interface{}, reply interface{}) (err error) {
 
-	return client.send(method, request, reply)
+	return client.send(context.Background(), method, request, reply)
+}
+
+// SendContext sends the request and blocks until it has completed or ctx
+// is done.
+//
+// If ctx has a deadline, it is passed along to the server which will not
+// call the method once the deadline has passed.  If ctx is done before the
+// reply arrives, the server is asked to cancel the request and ctx.Err()
+// is returned.  In that case, the method may or may not have been called.
+//
+// NOTE: The deadline is sent as an absolute time so the clocks of the client
+// and server are assumed to be reasonably synchronized.
+func (client *Client) SendContext(ctx context.Context, method string, request interface{}, reply interface{}) (err error) {
+
+	return client.send(ctx, method, request, reply)
+}
+
+// SendStream sends the request for a streaming RPC (i.e. a method whose reply
+// argument is a *ServerStream) and returns the ClientStream used to receive
+// its frames.
+//
+// ctx is handled as with SendContext() for the life of the stream.  The
+// caller must either call Recv() until it returns an error or call Close().
+func (client *Client) SendStream(ctx context.Context, method string, request interface{}) (stream *ClientStream, err error) {
+
+	return client.sendStream(ctx, method, request)
 }
 
 // GetMyUniqueID returns the unique ID of the client
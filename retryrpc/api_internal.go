@@ -7,6 +7,7 @@ package retryrpc
 
 import (
 	"container/list"
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
@@ -28,8 +29,9 @@ const (
 )
 
 const (
-	currentRetryVersion = 1
-	bucketStatsPkgName  = "proxyfs.retryrpc"
+	currentRetryVersion   = 1
+	bucketStatsPkgName    = "proxyfs.retryrpc"
+	tracingSpanNamePrefix = "retryrpc/"
 )
 
 type requestID uint64
@@ -54,6 +56,11 @@ type statsInfo struct {
 	RPCattempted           bucketstats.Total           // Number of RPCs attempted - may be completed or in process
 	RPCcompleted           bucketstats.Total           // Number of RPCs which completed - incremented after call returns
 	RPCretried             bucketstats.Total           // Number of RPCs which were just pulled from completed list
+	RPCabandoned           bucketstats.Total           // Number of RPCs not called since cancelled or past their deadline
+	RPCcancelsReceived     bucketstats.Total           // Number of Cancel messages received
+	StreamsStarted         bucketstats.Total           // Number of streaming RPCs started
+	StreamsResumed         bucketstats.Total           // Number of streaming RPCs resumed after client reconnected
+	StreamFramesSent       bucketstats.Total           // Number of streaming RPC frames sent (not counting resends)
 	PerMethodStats         map[string]*methodStats     // Per method bucketstats
 }
 
@@ -61,18 +68,22 @@ type statsInfo struct {
 // such as completed requests, etc
 type clientInfo struct {
 	sync.Mutex
-	cCtx                     *connCtx                      // Current connCtx for client
-	myUniqueID               uint64                        // Unique ID of this client
-	completedRequest         map[requestID]*completedEntry // Key: "RequestID"
-	completedRequestLRU      *list.List                    // LRU used to remove completed request in ticker
-	highestReplySeen         requestID                     // Highest consectutive requestID client has seen
-	previousHighestReplySeen requestID                     // Previous highest consectutive requestID client has seen
+	cCtx                     *connCtx                         // Current connCtx for client
+	myUniqueID               uint64                           // Unique ID of this client
+	completedRequest         map[requestID]*completedEntry    // Key: "RequestID"
+	completedRequestLRU      *list.List                       // LRU used to remove completed request in ticker
+	highestReplySeen         requestID                        // Highest consectutive requestID client has seen
+	previousHighestReplySeen requestID                        // Previous highest consectutive requestID client has seen
+	activeRequest            map[requestID]context.CancelFunc // Key: "RequestID" of requests being processed
+	cancelledRequest         map[requestID]time.Time          // Key: "RequestID" of Cancels which arrived before their request
+	activeStream             map[requestID]*ServerStream      // Key: "RequestID" of streams whose frames are not all ACKed
 	stats                    statsInfo
 }
 
 type completedEntry struct {
 	reply   *ioReply
 	lruElem *list.Element
+	durable chan struct{} // If non-nil, closed once reply has been journaled
 }
 
 // connCtx tracks a conn which has been accepted.
@@ -92,7 +103,9 @@ type connCtx struct {
 // as well as the request type and reply type arguments
 type methodArgs struct {
 	methodPtr    *reflect.Method
+	passContext  bool
 	passClientID bool
+	stream       bool
 	request      reflect.Type
 	reply        reflect.Type
 }
@@ -125,6 +138,14 @@ const (
 	// PassID is the message sent by the client to identify itself to server.
 	// This is used when we are retransmitting.
 	PassID
+	// Cancel is the message sent by the client when it is no longer waiting
+	// for the reply to an RPC.
+	Cancel
+	// StreamFrame is a frame of a streaming RPC sent from server to client
+	StreamFrame
+	// StreamAck is the message sent by the client as it consumes the frames
+	// of a streaming RPC.
+	StreamAck
 )
 
 // ioHeader is the header sent on the socket
@@ -174,15 +195,36 @@ type reqCtx struct {
 	ioreq     *ioRequest // Wrapped request passed to Send()
 	rpcReply  interface{}
 	answer    chan replyCtx
-	genNum    uint64    // Generation number of socket when request sent
-	startTime time.Time // Time Send() called sendToServer()
+	genNum    uint64        // Generation number of socket when request sent
+	startTime time.Time     // Time Send() called sendToServer()
+	queued    bool          // Set once sendToServer() has put request on outstandingRequest
+	abandoned bool          // Set if send() returned before request was queued
+	stream    *ClientStream // Non-nil if request is a streaming RPC
+	priority  Priority      // Selects the Client.sendQueue the request waits in
+}
+
+// queuedSendStruct is a request (or, if control, a Cancel or StreamAck) waiting
+// in a Client.sendQueue to be written to the connection of generation genNum
+type queuedSendStruct struct {
+	crID      requestID
+	ioreq     *ioRequest
+	control   bool      // Set if not an RPC (so not tracked in outstandingRequest)
+	genNum    uint64    // Generation number of socket when queued
+	startTime time.Time // Time queued
 }
 
 // jsonRequest is used to marshal an RPC request in/out of JSON
 type jsonRequest struct {
-	MyUniqueID       uint64         `json:"myuniqueid"`       // ID of client
-	RequestID        requestID      `json:"requestid"`        // ID of this request
-	HighestReplySeen requestID      `json:"highestReplySeen"` // Used to trim completedRequests on server
+	MyUniqueID       uint64         `json:"myuniqueid"`            // ID of client
+	RequestID        requestID      `json:"requestid"`             // ID of this request
+	HighestReplySeen requestID      `json:"highestReplySeen"`      // Used to trim completedRequests on server
+	TraceParent      string         `json:"traceparent,omitempty"` // Trace context (if any) of the client's Span for this request
+	Deadline         int64          `json:"deadline,omitempty"`    // If non-zero, UnixNano() after which server should not call method
+	Priority         Priority       `json:"priority,omitempty"`
+	Stream           bool           `json:"stream,omitempty"`      // Set if request is a streaming RPC
+	Window           uint32         `json:"window,omitempty"`      // Frames of a stream server may send ahead of StreamAck
+	ResumeSeq        uint64         `json:"resumeseq,omitempty"`   // Frames of a stream already received by client
+	StreamAcked      uint64         `json:"streamacked,omitempty"` // Frames of a stream already consumed by client
 	Method           string         `json:"method"`
 	Params           [1]interface{} `json:"params"`
 }
@@ -195,6 +237,29 @@ type jsonReply struct {
 	Result     interface{} `json:"result"`
 }
 
+// jsonCancel is used to marshal a Cancel of an RPC request in/out of JSON
+type jsonCancel struct {
+	MyUniqueID uint64    `json:"myuniqueid"` // ID of client
+	RequestID  requestID `json:"requestid"`  // ID of request to cancel
+}
+
+// jsonStreamFrame is used to marshal a frame of a streaming RPC in/out of JSON
+type jsonStreamFrame struct {
+	MyUniqueID uint64      `json:"myuniqueid"`       // ID of client
+	RequestID  requestID   `json:"requestid"`        // ID of the streaming request
+	Seq        uint64      `json:"seq"`              // Frames are numbered from 0
+	EOF        bool        `json:"eof,omitempty"`    // Set in final frame sent after method returns
+	ErrStr     string      `json:"errstr,omitempty"` // Only in final frame
+	Result     interface{} `json:"result,omitempty"` // Not in final frame
+}
+
+// jsonStreamAck is used to marshal a StreamAck in/out of JSON
+type jsonStreamAck struct {
+	MyUniqueID uint64    `json:"myuniqueid"` // ID of client
+	RequestID  requestID `json:"requestid"`  // ID of the streaming request
+	Seq        uint64    `json:"seq"`        // Number of frames consumed
+}
+
 // svrRequest is used with jsonRequest when we unmarshal the
 // parameters passed in an RPC.  This is how we get the rpcReply
 // structure specific to the RPC
@@ -221,6 +286,34 @@ func buildIoRequest(jReq *jsonRequest) (ioreq *ioRequest, err error) {
 	return
 }
 
+func buildCancelRequest(jCancel *jsonCancel) (ioreq *ioRequest, err error) {
+	ioreq = &ioRequest{}
+	ioreq.JReq, err = json.Marshal(*jCancel)
+	if err != nil {
+		return nil, err
+	}
+	ioreq.Hdr.Len = uint32(len(ioreq.JReq))
+	ioreq.Hdr.Protocol = uint16(JSON)
+	ioreq.Hdr.Version = currentRetryVersion
+	ioreq.Hdr.Type = Cancel
+	ioreq.Hdr.Magic = headerMagic
+	return
+}
+
+func buildStreamAckRequest(jAck *jsonStreamAck) (ioreq *ioRequest, err error) {
+	ioreq = &ioRequest{}
+	ioreq.JReq, err = json.Marshal(*jAck)
+	if err != nil {
+		return nil, err
+	}
+	ioreq.Hdr.Len = uint32(len(ioreq.JReq))
+	ioreq.Hdr.Protocol = uint16(JSON)
+	ioreq.Hdr.Version = currentRetryVersion
+	ioreq.Hdr.Type = StreamAck
+	ioreq.Hdr.Magic = headerMagic
+	return
+}
+
 func setupHdrReply(ioreply *ioReply, t MsgType) {
 	ioreply.Hdr.Len = uint32(len(ioreply.JResult))
 	ioreply.Hdr.Protocol = uint16(JSON)
@@ -4,6 +4,7 @@
 package retryrpc
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/binary"
 	"encoding/json"
@@ -16,6 +17,7 @@ import (
 	"github.com/google/btree"
 
 	"github.com/NVIDIA/proxyfs/bucketstats"
+	"github.com/NVIDIA/proxyfs/tracing"
 )
 
 const (
@@ -38,6 +40,8 @@ type clientSideStatsInfo struct {
 	SeesIOAndSignalsChannel bucketstats.BucketLog2Round // Tracks time after returnReply() -> getIO and reply channel
 	TimeSendRPCUsec         bucketstats.BucketLog2Round // Tracks time when Send() called and returned
 	SendToServer            bucketstats.BucketLog2Round // Tracks time takes to send message to server
+	SendAbandoned           bucketstats.Total           // Number of times Send returned since its context was done
+	StreamFramesReceived    bucketstats.Total           // Number of streaming RPC frames received (not counting duplicates)
 }
 
 // TODO - what if RPC was completed on Server1 and before response,
@@ -47,21 +51,72 @@ type clientSideStatsInfo struct {
 
 //
 // Send algorithm is:
-// 1. Build ctx including channel for reply struct
-// 2. Call goroutine to do marshalling and sending of
-//    request to server
-// 3. Wait on channel in reply struct for result
-// 4. readResponses goroutine will read response on socket
-//    and call a goroutine to do unmarshalling and notification
-func (client *Client) send(method string, rpcRequest interface{}, rpcReply interface{}) (err error) {
+//  1. Build ctx including channel for reply struct
+//  2. Call goroutine to do marshalling and sending of
+//     request to server
+//  3. Wait on channel in reply struct for result (or for ctx to be done)
+//  4. readResponses goroutine will read response on socket
+//     and call a goroutine to do unmarshalling and notification
+func (client *Client) send(ctx context.Context, method string, rpcRequest interface{}, rpcReply interface{}) (err error) {
 	var (
-		connectionRetryCount int
-		connectionRetryDelay time.Duration
-		crID                 requestID
+		answer replyCtx
 	)
 
 	client.stats.SendCalled.Add(1)
 
+	jreq := client.prepareRequest(ctx, method, rpcRequest)
+	crID := jreq.RequestID
+
+	// Pass our Span's trace context (if tracing) along to the server
+	span := tracing.StartSpan(tracingSpanNamePrefix+method, tracing.SpanKindClient)
+	jreq.TraceParent = span.TraceParent()
+	span.SetAttribute("retryrpc.request_id", uint64(crID))
+
+	// Setup ioreq to write structure on socket to server
+	ioreq, err := buildIoRequest(jreq)
+	if err != nil {
+		client.logger.Fatalf("Client buildIoRequest returned err: %v", err)
+		return err
+	}
+
+	// Create context to wait result and to handle retransmits
+	rCtx := &reqCtx{ioreq: ioreq, rpcReply: &rpcReply, startTime: time.Now(), priority: jreq.Priority}
+	rCtx.answer = make(chan replyCtx, 1)
+
+	// Send request to server.
+	//
+	// We use a goroutine to be consistent with the way sendToServer() is called
+	// in the retransmit() case.
+	client.goroutineWG.Add(1)
+	go client.sendToServer(crID, rCtx, true)
+
+	// Now wait for response
+	select {
+	case answer = <-rCtx.answer:
+	case <-ctx.Done():
+		if client.abandonRequest(crID, rCtx) {
+			answer.err = ctx.Err()
+		} else {
+			// notifyReply() already claimed the request so the answer is coming
+			answer = <-rCtx.answer
+		}
+	}
+	client.stats.TimeSendRPCUsec.Add(uint64(time.Duration(time.Since(rCtx.startTime).Microseconds())))
+
+	span.EndWithError(answer.err)
+
+	return answer.err
+}
+
+// prepareRequest dials the server (if this is the first request) and returns
+// the jsonRequest for method with the next requestID assigned.
+func (client *Client) prepareRequest(ctx context.Context, method string, rpcRequest interface{}) (jreq *jsonRequest) {
+	var (
+		connectionRetryCount int
+		connectionRetryDelay time.Duration
+		err                  error
+	)
+
 	client.Lock()
 	if client.connection.state == INITIAL {
 
@@ -94,9 +149,15 @@ func (client *Client) send(method string, rpcRequest interface{}, rpcReply inter
 	}
 
 	// Put request data into structure to be be marshaled into JSON
-	jreq := jsonRequest{Method: method, HighestReplySeen: client.highestConsecutive}
+	jreq = &jsonRequest{Method: method, HighestReplySeen: client.highestConsecutive}
 	jreq.Params[0] = rpcRequest
 	jreq.MyUniqueID = client.myUniqueID
+	if deadline, ok := ctx.Deadline(); ok {
+		jreq.Deadline = deadline.UnixNano()
+	}
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		jreq.Priority = priority
+	}
 
 	if client.halting {
 		client.Unlock()
@@ -104,33 +165,72 @@ func (client *Client) send(method string, rpcRequest interface{}, rpcReply inter
 		return
 	}
 	client.currentRequestID++
-	crID = client.currentRequestID
-	jreq.RequestID = crID
+	jreq.RequestID = client.currentRequestID
 	client.Unlock()
 
-	// Setup ioreq to write structure on socket to server
-	ioreq, err := buildIoRequest(&jreq)
+	return
+}
+
+// abandonRequest is called when the context passed to send() is done before
+// the reply arrived.
+//
+// Returns false if notifyReply() has already removed the request from
+// outstandingRequest (so the reply must still be received).  Otherwise, the
+// request will no longer be (re)sent and, if it may already have been sent,
+// the server is asked to cancel it.
+func (client *Client) abandonRequest(crID requestID, rCtx *reqCtx) (abandoned bool) {
+	client.Lock()
+	if !rCtx.queued {
+		// sendToServer() has not run yet - tell it not to bother
+		rCtx.abandoned = true
+	} else {
+		_, ok := client.outstandingRequest[crID]
+		if !ok {
+			client.Unlock()
+			return false
+		}
+		delete(client.outstandingRequest, crID)
+
+		client.goroutineWG.Add(1)
+		go client.sendCancel(crID)
+	}
+	client.Unlock()
+
+	client.stats.SendAbandoned.Add(1)
+
+	// We will never see a reply so treat the requestID as done
+	go client.updateHighestConsecutiveNum(crID)
+
+	return true
+}
+
+// sendCancel tells the server we are no longer waiting for crID.
+func (client *Client) sendCancel(crID requestID) {
+	defer client.goroutineWG.Done()
+
+	ioreq, err := buildCancelRequest(&jsonCancel{MyUniqueID: client.myUniqueID, RequestID: crID})
 	if err != nil {
-		client.logger.Fatalf("Client buildIoRequest returned err: %v", err)
-		return err
+		client.logger.Fatalf("Client buildCancelRequest returned err: %v", err)
+		return
 	}
 
-	// Create context to wait result and to handle retransmits
-	ctx := &reqCtx{ioreq: ioreq, rpcReply: &rpcReply, startTime: time.Now()}
-	ctx.answer = make(chan replyCtx)
+	client.writeControl(ioreq)
+}
 
-	// Send request to server.
-	//
-	// We use a goroutine to be consistent with the way sendToServer() is called
-	// in the retransmit() case.
-	client.goroutineWG.Add(1)
-	go client.sendToServer(crID, ctx, true)
+// writeControl writes a Cancel or StreamAck to the server.
+//
+// These are "best effort" - if one cannot be written it is dropped since
+// the server will eventually trim the request anyway.
+func (client *Client) writeControl(ioreq *ioRequest) {
+	client.Lock()
 
-	// Now wait for response
-	answer := <-ctx.answer
-	client.stats.TimeSendRPCUsec.Add(uint64(time.Duration(time.Since(ctx.startTime).Microseconds())))
+	if client.connection.state != CONNECTED {
+		client.Unlock()
+		return
+	}
 
-	return answer.err
+	// These should not wait behind RPCs (e.g. flushes) queued ahead of them
+	client.queueSend(PriorityHigh, &queuedSendStruct{ioreq: ioreq, control: true})
 }
 
 // sendToServer packages the request and marshals it before
@@ -141,10 +241,6 @@ func (client *Client) send(method string, rpcRequest interface{}, rpcReply inter
 func (client *Client) sendToServer(crID requestID, ctx *reqCtx, queue bool) {
 	defer client.goroutineWG.Done()
 
-	startTime := time.Now()
-
-	// Now send the request to the server.
-	// We need to GRAB THE MUTEX HERE TO SERIALIZE WRITES on socket
 	client.Lock()
 
 	// Keep track of requests we are sending so we can resend them later
@@ -156,7 +252,16 @@ func (client *Client) sendToServer(crID requestID, ctx *reqCtx, queue bool) {
 	//
 	// Don't queue the request if we are retransmitting....
 	if queue {
+		if ctx.abandoned {
+			// send() has already returned
+			client.Unlock()
+			return
+		}
 		client.outstandingRequest[crID] = ctx
+		ctx.queued = true
+	} else if ctx.stream != nil {
+		// Tell the server which frames of the stream we already have
+		ctx.ioreq = ctx.stream.resumeRequest()
 	}
 
 	// Record generation number of connection.  It is used during
@@ -176,39 +281,110 @@ func (client *Client) sendToServer(crID requestID, ctx *reqCtx, queue bool) {
 		return
 	}
 
-	// Send header
-	client.connection.SetDeadline(time.Now().Add(client.deadlineIO))
-	err := binary.Write(client.connection.ioWriter(), binary.BigEndian, ctx.ioreq.Hdr)
-	if err != nil {
-		genNum := ctx.genNum
-		client.Unlock()
+	// Writes on the socket are serialized by queueSend() such that the
+	// request is written after those of higher Priority already waiting
+	client.queueSend(ctx.priority, &queuedSendStruct{crID: crID, ioreq: ctx.ioreq})
+}
 
-		// Just return - the retransmit code will start another
-		// sendToServer() goroutine
-		client.retransmit(genNum)
+// queueSend appends qS to the sendQueue of the specified Priority. Unless
+// another goroutine is already doing so, the calling goroutine then writes
+// the requests in every sendQueue to the socket - always taking the next one
+// from the highest Priority sendQueue that is not empty - until they are empty.
+//
+// Requests are written without holding the client lock so that requests
+// arriving meanwhile (of any Priority) need not wait for the write in
+// progress to be queued.
+//
+// NOTE: Client lock is held on entry and released on return
+func (client *Client) queueSend(priority Priority, qS *queuedSendStruct) {
+	qS.genNum = client.connection.genNum
+	qS.startTime = time.Now()
+	client.sendQueue[priority].PushBack(qS)
+
+	if client.sending {
+		// The goroutine draining the sendQueues will write it
+		client.Unlock()
 		return
 	}
+	client.sending = true
 
-	// Send JSON request
-	client.connection.SetDeadline(time.Now().Add(client.deadlineIO))
-	bytesWritten, writeErr := client.connection.Write(ctx.ioreq.JReq)
-
-	if (bytesWritten != len(ctx.ioreq.JReq)) || (writeErr != nil) {
-		/* TODO - log message?
-		fmt.Printf("CLIENT: PARTIAL Write! bytesWritten is: %v len(ctx.ioreq.JReq): %v writeErr: %v\n",
-			bytesWritten, len(ctx.ioreq.JReq), writeErr)
-		*/
-		genNum := ctx.genNum
+	for {
+		qS = client.nextQueuedSend()
+		if qS == nil {
+			client.sending = false
+			client.Unlock()
+			return
+		}
+
+		// retransmit() may close the connection while we write to it...
+		// which simply causes the write to fail
+		nC := client.connection.castToNetConn()
 		client.Unlock()
 
-		// Just return - the retransmit code will start another
-		// sendToServer() goroutine
-		client.retransmit(genNum)
+		err := writeQueuedSend(nC, client.deadlineIO, qS)
+		if err == nil {
+			if !qS.control {
+				client.stats.SendToServer.Add(uint64(time.Duration(time.Since(qS.startTime).Microseconds())))
+			}
+		} else if !qS.control {
+			// retransmit() will resend every request on outstandingRequest
+			// (including those still waiting in a sendQueue) once reconnected
+			client.retransmit(qS.genNum)
+		}
+
+		client.Lock()
+	}
+}
+
+// nextQueuedSend removes and returns the request to be written next (or nil
+// if the sendQueues are empty). Requests queued for a prior connection have
+// been resent by retransmit() and requests abandoned by send() need not be
+// sent so both are discarded.
+//
+// NOTE: Client lock is held
+func (client *Client) nextQueuedSend() (qS *queuedSendStruct) {
+	for priority := len(client.sendQueue) - 1; priority >= 0; priority-- {
+		sendQueue := client.sendQueue[priority]
+		for sendQueue.Len() > 0 {
+			qS = sendQueue.Remove(sendQueue.Front()).(*queuedSendStruct)
+			if (client.connection.state != CONNECTED) || (qS.genNum != client.connection.genNum) {
+				continue
+			}
+			if !qS.control {
+				_, ok := client.outstandingRequest[qS.crID]
+				if !ok {
+					continue
+				}
+			}
+			return
+		}
+	}
+
+	qS = nil
+	return
+}
+
+// writeQueuedSend writes the header and JSON of qS on nC
+func writeQueuedSend(nC net.Conn, deadlineIO time.Duration, qS *queuedSendStruct) (err error) {
+	// Send header
+	nC.SetDeadline(time.Now().Add(deadlineIO))
+	err = binary.Write(nC, binary.BigEndian, qS.ioreq.Hdr)
+	if err != nil {
 		return
 	}
 
-	client.Unlock()
-	client.stats.SendToServer.Add(uint64(time.Duration(time.Since(startTime).Microseconds())))
+	// Send JSON request
+	nC.SetDeadline(time.Now().Add(deadlineIO))
+	bytesWritten, writeErr := nC.Write(qS.ioreq.JReq)
+	if writeErr != nil {
+		err = writeErr
+		return
+	}
+	if bytesWritten != len(qS.ioreq.JReq) {
+		err = fmt.Errorf("partial write of request (%v of %v bytes)", bytesWritten, len(qS.ioreq.JReq))
+	}
+
+	return
 }
 
 func (client *Client) notifyReply(buf []byte, genNum uint64, recvResponse time.Time) {
@@ -286,17 +462,27 @@ func (client *Client) notifyReply(buf []byte, genNum uint64, recvResponse time.T
 
 	// Give reply to blocked send() - most developers test for nil err so
 	// only set it if there is an error
-	r := replyCtx{}
-	if jReply.ErrStr != "" {
-		r.err = fmt.Errorf("%v", jReply.ErrStr)
-	}
-	ctx.answer <- r
+	ctx.answer <- replyCtx{err: errFromErrStr(jReply.ErrStr)}
 	client.stats.SeesIOAndSignalsChannel.Add(uint64(time.Duration(time.Since(recvResponse).Microseconds())))
 
 	// Fork off a goroutine to update highestConsecutiveNum
 	go client.updateHighestConsecutiveNum(crID)
 }
 
+// errFromErrStr returns the error (if any) returned by the server's method
+func errFromErrStr(errStr string) (err error) {
+	switch errStr {
+	case "":
+	case context.DeadlineExceeded.Error():
+		err = context.DeadlineExceeded
+	case context.Canceled.Error():
+		err = context.Canceled
+	default:
+		err = fmt.Errorf("%v", errStr)
+	}
+	return
+}
+
 // readReplies is a goroutine dedicated to reading responses from the server.
 //
 // As soon as it reads a complete response, it launches a goroutine to process
@@ -358,6 +544,16 @@ func (client *Client) readReplies(nC net.Conn, callingGenNum uint64) {
 			go client.notifyReply(buf, callingGenNum, recvResponse)
 			client.stats.ReplyCalled.Add(1)
 
+		case StreamFrame:
+			// Frames are handed to their ClientStream here so they stay in order
+			err := client.receiveFrame(buf)
+			if err != nil {
+				// Assume read garbage on socket - close the socket and reconnect
+				client.logger.Printf("receiveFrame failed to unmarshal buf: %+v err: %v\n", string(buf), err)
+				client.retransmit(callingGenNum)
+				return
+			}
+
 		case Upcall:
 
 			// Spawn off goroutine to call callback
@@ -5,7 +5,9 @@ package retryrpc
 
 import (
 	"container/list"
+	"context"
 	"strconv"
+	"time"
 
 	"github.com/NVIDIA/proxyfs/bucketstats"
 )
@@ -14,7 +16,7 @@ import (
 // track clients and initialize clientInfo.
 
 func (ci *clientInfo) isEmpty() bool {
-	return len(ci.completedRequest) == 0
+	return (len(ci.completedRequest) == 0) && (len(ci.activeStream) == 0)
 }
 
 func (ci *clientInfo) completedCnt() int {
@@ -29,6 +31,9 @@ func initClientInfo(cCtx *connCtx, newUniqueID uint64, server *Server) (ci *clie
 	ci = &clientInfo{cCtx: cCtx, myUniqueID: newUniqueID}
 	ci.completedRequest = make(map[requestID]*completedEntry)
 	ci.completedRequestLRU = list.New()
+	ci.activeRequest = make(map[requestID]context.CancelFunc)
+	ci.cancelledRequest = make(map[requestID]time.Time)
+	ci.activeStream = make(map[requestID]*ServerStream)
 	ci.stats.PerMethodStats = make(map[string]*methodStats)
 
 	idAsStr := strconv.FormatInt(int64(newUniqueID), 10)
@@ -43,6 +48,57 @@ func initClientInfo(cCtx *connCtx, newUniqueID uint64, server *Server) (ci *clie
 	return
 }
 
+// startRequest returns the context passed to the method called for jReq
+// along with its CancelFunc.  The context is done if the client cancels
+// the request or the request's deadline passes.
+//
+// NOTE: We assume ci lock is held
+func (ci *clientInfo) startRequest(jReq *jsonRequest) (ctx context.Context, cancel context.CancelFunc) {
+	if jReq.Deadline != 0 {
+		ctx, cancel = context.WithDeadline(context.Background(), time.Unix(0, jReq.Deadline))
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	ci.activeRequest[jReq.RequestID] = cancel
+
+	// The Cancel may have been processed before the request
+	_, ok := ci.cancelledRequest[jReq.RequestID]
+	if ok {
+		delete(ci.cancelledRequest, jReq.RequestID)
+		cancel()
+	}
+	return
+}
+
+// endRequest stops tracking a request started with startRequest()
+//
+// NOTE: We assume ci lock is held
+func (ci *clientInfo) endRequest(rID requestID) {
+	delete(ci.activeRequest, rID)
+}
+
+// cancelRequest cancels rID if it is being processed.  If it has not arrived
+// yet, remember the Cancel so the request is not processed when it does.
+func (ci *clientInfo) cancelRequest(rID requestID) {
+	ci.Lock()
+	stream, streaming := ci.activeStream[rID]
+	if streaming {
+		// The client will not consume (or ACK) any more frames
+		delete(ci.activeStream, rID)
+		stream.cancel()
+	}
+	cancel, ok := ci.activeRequest[rID]
+	if ok {
+		cancel()
+	} else if !streaming {
+		_, ok = ci.completedRequest[rID]
+		if !ok {
+			ci.cancelledRequest[rID] = time.Now()
+		}
+	}
+	ci.Unlock()
+}
+
 // Bump bucketstats for this method
 func (ci *clientInfo) setMethodStats(method string, deltaTime uint64) {
 	ms := ci.stats.PerMethodStats[method]
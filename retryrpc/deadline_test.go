@@ -0,0 +1,250 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package retryrpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDeadlineServer is a struct with pointer receivers implementing RpcTestWait() and RpcTestCount()
+type TestDeadlineServer struct {
+	started  chan struct{} // RpcTestWait() signals it has been called
+	release  chan struct{} // RpcTestWait() returns once this is closed...
+	ctxErr   chan error    // ...or its context is done, sending ctx.Err() here
+	countCnt uint64        // Number of times RpcTestCount() was called
+}
+
+// TestDeadlineReq is the request object for RpcTestWait() and RpcTestCount()
+type TestDeadlineReq struct {
+	Message string
+}
+
+// TestDeadlineReply is the response object for RpcTestWait() and RpcTestCount()
+type TestDeadlineReply struct {
+	Message string
+}
+
+// RpcTestWait blocks until released or the request is cancelled
+func (s *TestDeadlineServer) RpcTestWait(ctx context.Context, in *TestDeadlineReq, reply *TestDeadlineReply) (err error) {
+	s.started <- struct{}{}
+	select {
+	case <-s.release:
+		reply.Message = "released"
+	case <-ctx.Done():
+		err = ctx.Err()
+		s.ctxErr <- err
+	}
+	return
+}
+
+// RpcTestCount counts the number of times it has been called
+func (s *TestDeadlineServer) RpcTestCount(clientID uint64, in *TestDeadlineReq, reply *TestDeadlineReply) (err error) {
+	atomic.AddUint64(&s.countCnt, 1)
+	reply.Message = in.Message
+	return nil
+}
+
+func newTestDeadlineServer() *TestDeadlineServer {
+	return &TestDeadlineServer{
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+		ctxErr:  make(chan error, 1),
+	}
+}
+
+func getNewDeadlineServerAndClient(t *testing.T, myServer *TestDeadlineServer, maxConcurrentRPCs int) (rrSvr *Server, rrClnt *Client) {
+	assert := assert.New(t)
+
+	rrSvr = NewServer(&ServerConfig{
+		LongTrim:          10 * time.Second,
+		ShortTrim:         100 * time.Millisecond,
+		DNSOrIPAddr:       testIPAddr,
+		Port:              testPort,
+		DeadlineIO:        60 * time.Second,
+		KeepAlivePeriod:   60 * time.Second,
+		MaxConcurrentRPCs: maxConcurrentRPCs,
+		Logger:            newLogger(),
+	})
+	assert.NotNil(rrSvr)
+
+	err := rrSvr.Register(myServer)
+	assert.Nil(err)
+	assert.Equal(2, len(rrSvr.svrMap))
+	assert.True(rrSvr.svrMap["RpcTestWait"].passContext)
+	assert.False(rrSvr.svrMap["RpcTestWait"].passClientID)
+	assert.False(rrSvr.svrMap["RpcTestCount"].passContext)
+	assert.True(rrSvr.svrMap["RpcTestCount"].passClientID)
+
+	startErr := rrSvr.Start()
+	assert.Nil(startErr, "startErr is not nil")
+
+	rrSvr.Run()
+
+	rrClnt, err = NewClient(&ClientConfig{
+		DNSOrIPAddr:     testIPAddr,
+		Port:            testPort,
+		DeadlineIO:      60 * time.Second,
+		KeepAlivePeriod: 60 * time.Second,
+		Logger:          newLogger(),
+	})
+	assert.NotNil(rrClnt)
+	assert.Nil(err)
+
+	// Make sure we are connected before timing anything
+	err = rrClnt.Send("RpcTestCount", &TestDeadlineReq{Message: "connect"}, &TestDeadlineReply{})
+	assert.Nil(err)
+
+	return
+}
+
+// Test that an RPC whose deadline passes is abandoned by both client and server
+func TestSendContextDeadline(t *testing.T) {
+	assert := assert.New(t)
+
+	myServer := newTestDeadlineServer()
+	rrSvr, rrClnt := getNewDeadlineServerAndClient(t, myServer, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	reply := &TestDeadlineReply{}
+	err := rrClnt.SendContext(ctx, "RpcTestWait", &TestDeadlineReq{Message: "deadline"}, reply)
+	assert.Equal(context.DeadlineExceeded, err)
+	assert.Equal("", reply.Message)
+
+	<-myServer.started
+	assert.Equal(context.DeadlineExceeded, <-myServer.ctxErr)
+
+	// Client should still be usable
+	reply = &TestDeadlineReply{}
+	err = rrClnt.SendContext(context.Background(), "RpcTestCount", &TestDeadlineReq{Message: "after"}, reply)
+	assert.Nil(err)
+	assert.Equal("after", reply.Message)
+
+	rrClnt.Close()
+	rrSvr.Close()
+}
+
+// Test that cancelling the context of an RPC sends a Cancel the server honors
+func TestSendContextCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	myServer := newTestDeadlineServer()
+	rrSvr, rrClnt := getNewDeadlineServerAndClient(t, myServer, 0)
+
+	// No deadline - so only the Cancel can wake up RpcTestWait()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-myServer.started
+		cancel()
+	}()
+
+	err := rrClnt.SendContext(ctx, "RpcTestWait", &TestDeadlineReq{Message: "cancel"}, &TestDeadlineReply{})
+	assert.Equal(context.Canceled, err)
+	assert.Equal(context.Canceled, <-myServer.ctxErr)
+
+	// The cancelled request should no longer be outstanding
+	assert.Nil(rrClnt.Send("RpcTestCount", &TestDeadlineReq{Message: "after"}, &TestDeadlineReply{}))
+	rrClnt.Lock()
+	assert.Equal(0, len(rrClnt.outstandingRequest))
+	rrClnt.Unlock()
+
+	rrClnt.Close()
+	rrSvr.Close()
+}
+
+// Test that PriorityHigh RPCs do not wait behind PriorityNormal RPCs and that
+// PriorityNormal RPCs whose deadline passes while waiting are never called
+func TestSendContextPriority(t *testing.T) {
+	assert := assert.New(t)
+
+	myServer := newTestDeadlineServer()
+	rrSvr, rrClnt := getNewDeadlineServerAndClient(t, myServer, 1)
+
+	// Occupy the only PriorityNormal slot
+	waitDone := make(chan error)
+	go func() {
+		waitDone <- rrClnt.Send("RpcTestWait", &TestDeadlineReq{Message: "bulk"}, &TestDeadlineReply{})
+	}()
+	<-myServer.started
+
+	// A PriorityNormal RPC times out waiting for the slot...
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := rrClnt.SendContext(ctx, "RpcTestCount", &TestDeadlineReq{Message: "normal"}, &TestDeadlineReply{})
+	assert.Equal(context.DeadlineExceeded, err)
+
+	// ...while a PriorityHigh RPC does not wait
+	reply := &TestDeadlineReply{}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = rrClnt.SendContext(WithPriority(ctx, PriorityHigh), "RpcTestCount", &TestDeadlineReq{Message: "high"}, reply)
+	assert.Nil(err)
+	assert.Equal("high", reply.Message)
+
+	close(myServer.release)
+	assert.Nil(<-waitDone)
+
+	// Only the "connect" and PriorityHigh RPCs should have called RpcTestCount()
+	assert.Equal(uint64(2), atomic.LoadUint64(&myServer.countCnt))
+
+	rrSvr.Lock()
+	ci := rrSvr.perClientInfo[rrClnt.GetMyUniqueID()]
+	rrSvr.Unlock()
+	for i := 0; (ci.stats.RPCabandoned.TotalGet() == 0) && (i < 100); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(uint64(1), ci.stats.RPCabandoned.TotalGet())
+
+	rrClnt.Close()
+	rrSvr.Close()
+}
+
+// Test that the Client writes queued PriorityHigh requests before those of
+// PriorityNormal and discards requests that need not be written
+func TestNextQueuedSend(t *testing.T) {
+	assert := assert.New(t)
+
+	rrClnt, err := NewClient(&ClientConfig{DNSOrIPAddr: testIPAddr, Port: testPort, Logger: newLogger()})
+	if !assert.Nil(err) {
+		return
+	}
+
+	rrClnt.connection.state = CONNECTED
+	rrClnt.connection.genNum = 2
+
+	queue := func(priority Priority, crID requestID, control bool, genNum uint64) {
+		rrClnt.sendQueue[priority].PushBack(&queuedSendStruct{crID: crID, control: control, genNum: genNum})
+		if !control {
+			rrClnt.outstandingRequest[crID] = &reqCtx{priority: priority}
+		}
+	}
+
+	queue(PriorityNormal, 1, false, 2)
+	queue(PriorityNormal, 2, false, 2) // Abandoned below
+	queue(PriorityNormal, 3, false, 1) // Queued for a prior connection
+	queue(PriorityNormal, 4, false, 2)
+	queue(PriorityHigh, 5, false, 2)
+	queue(PriorityHigh, 0, true, 2)
+	queue(PriorityHigh, 6, false, 2)
+
+	delete(rrClnt.outstandingRequest, 2)
+
+	var order []requestID
+	for qS := rrClnt.nextQueuedSend(); qS != nil; qS = rrClnt.nextQueuedSend() {
+		order = append(order, qS.crID)
+	}
+	assert.Equal([]requestID{5, 0, 6, 1, 4}, order)
+
+	// Nothing is written unless CONNECTED
+	queue(PriorityHigh, 7, false, 2)
+	rrClnt.connection.state = RETRANSMITTING
+	assert.Nil(rrClnt.nextQueuedSend())
+	assert.Equal(0, rrClnt.sendQueue[PriorityHigh].Len())
+}
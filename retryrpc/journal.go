@@ -0,0 +1,334 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package retryrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// This file contains the optional journal of completed requests.
+//
+// Without it, a Server that completes an RPC and then dies before returning
+// the reply will call the RPC a second time when the client retransmits it
+// to the restarted Server.  With it, the restarted Server replays the journal
+// in Start() and simply returns the saved reply.
+//
+// The journal is a file of JSON records, one per line:
+//
+//   {"k":"nonce","c":<clientIDNonce>}
+//   {"k":"client","c":<clientID>}
+//   {"k":"ack","c":<clientID>,"h":<highestReplySeen>}
+//   {"k":"completed","c":<clientID>,"r":<requestID>,"t":<UnixNano>,"reply":<jsonReply>}
+//
+// Records are appended (and the file fsync'd) before the reply (or new client
+// ID) is written to the client.  Each time the LongTrim ticker fires, the file
+// is rewritten with only the records still needed so that its size remains
+// bounded by what is in completedRequest.
+
+const (
+	journalRecordNonce     = "nonce"
+	journalRecordClient    = "client"
+	journalRecordAck       = "ack"
+	journalRecordCompleted = "completed"
+)
+
+type journalRecord struct {
+	Kind             string          `json:"k"`
+	ClientID         uint64          `json:"c"`
+	RequestID        requestID       `json:"r,omitempty"`
+	TimeCompleted    int64           `json:"t,omitempty"` // UnixNano() when request completed
+	HighestReplySeen requestID       `json:"h,omitempty"`
+	Reply            json.RawMessage `json:"reply,omitempty"`
+}
+
+// completedJournal is the open journal file
+//
+// Lock ordering is Server lock (if held) before completedJournal lock
+// before clientInfo lock.
+type completedJournal struct {
+	sync.Mutex
+	path string
+	file *os.File // Opened O_APPEND
+}
+
+// startJournal replays the journal (if any) into perClientInfo and then
+// rewrites it so that new records may be appended.
+//
+// NOTE: Must be called after Register() since replayed clients need svrMap
+func (server *Server) startJournal() (err error) {
+	if server.journalPath == "" {
+		return
+	}
+
+	server.Lock()
+	defer server.Unlock()
+
+	err = server.replayJournal()
+	if err != nil {
+		return
+	}
+
+	server.journal = &completedJournal{path: server.journalPath}
+	err = server.compactJournal()
+	if err != nil {
+		server.journal = nil
+	}
+	return
+}
+
+// stopJournal closes the journal file.  The file itself is kept so that
+// a future Server can replay it.
+func (server *Server) stopJournal() {
+	j := server.journal
+	if j == nil {
+		return
+	}
+
+	j.Lock()
+	if j.file != nil {
+		_ = j.file.Close()
+		j.file = nil
+	}
+	j.Unlock()
+}
+
+// replayJournal rebuilds perClientInfo from the journal.
+//
+// A torn record at the end of the journal (i.e. the Server died while
+// appending it) is ignored.  Since the record was not completely written
+// the reply was never sent to the client.
+//
+// NOTE: We assume Server Lock is held
+func (server *Server) replayJournal() (err error) {
+	file, err := os.Open(server.journalPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	now := time.Now()
+	numRecords := 0
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil {
+			if (readErr != io.EOF) || (len(line) != 0) {
+				server.logger.Printf("Journal replay ignoring torn record at end of %v\n", server.journalPath)
+			}
+			break
+		}
+
+		rec := journalRecord{}
+		err = json.Unmarshal(line, &rec)
+		if err != nil {
+			server.logger.Printf("Journal replay ignoring invalid record: %v err: %v\n", string(line), err)
+			err = nil
+			break
+		}
+		numRecords++
+
+		switch rec.Kind {
+		case journalRecordNonce:
+			if rec.ClientID > server.clientIDNonce {
+				server.clientIDNonce = rec.ClientID
+			}
+		case journalRecordClient:
+			_ = server.replayClientInfo(rec.ClientID)
+		case journalRecordAck:
+			ci := server.replayClientInfo(rec.ClientID)
+			if rec.HighestReplySeen > ci.highestReplySeen {
+				ci.highestReplySeen = rec.HighestReplySeen
+				for rID, ce := range ci.completedRequest {
+					if rID <= ci.highestReplySeen {
+						ci.completedRequestLRU.Remove(ce.lruElem)
+						delete(ci.completedRequest, rID)
+					}
+				}
+				ci.previousHighestReplySeen = ci.highestReplySeen
+			}
+		case journalRecordCompleted:
+			ci := server.replayClientInfo(rec.ClientID)
+			timeCompleted := time.Unix(0, rec.TimeCompleted)
+			if rec.RequestID <= ci.highestReplySeen {
+				continue // Already ACKed by client
+			}
+			if timeCompleted.Add(server.completedLongTTL).Before(now) {
+				continue // Would already have been trimmed
+			}
+			if _, ok := ci.completedRequest[rec.RequestID]; ok {
+				continue // Appended again after a compaction
+			}
+			ce := &completedEntry{reply: &ioReply{JResult: []byte(rec.Reply)}}
+			ci.completedRequest[rec.RequestID] = ce
+			ce.lruElem = ci.completedRequestLRU.PushBack(completedLRUEntry{requestID: rec.RequestID, timeCompleted: timeCompleted})
+			ci.stats.TrimAddCompleted.Add(1)
+		default:
+			server.logger.Printf("Journal replay ignoring record of unknown kind: %v\n", string(line))
+		}
+	}
+
+	server.logger.Printf("Journal replay of %v records from %v found %v clients\n", numRecords, server.journalPath, len(server.perClientInfo))
+	return
+}
+
+// replayClientInfo returns the clientInfo for clientID, creating it if
+// necessary.  A created clientInfo looks like that of a client whose
+// connection has been lost so it is ready for the client to reconnect.
+//
+// NOTE: We assume Server Lock is held
+func (server *Server) replayClientInfo(clientID uint64) (ci *clientInfo) {
+	ci, ok := server.perClientInfo[clientID]
+	if !ok {
+		cCtx := &connCtx{serviceClientExited: true}
+		cCtx.cond = sync.NewCond(&cCtx.Mutex)
+		ci = initClientInfo(cCtx, clientID, server)
+		cCtx.ci = ci
+		server.perClientInfo[clientID] = ci
+	}
+	if clientID > server.clientIDNonce {
+		server.clientIDNonce = clientID
+	}
+	return
+}
+
+// compactJournal rewrites the journal with the records needed to recreate
+// the current perClientInfo and reopens it for appending.
+//
+// NOTE: We assume Server Lock is held
+func (server *Server) compactJournal() (err error) {
+	j := server.journal
+	if j == nil {
+		return
+	}
+
+	j.Lock()
+	defer j.Unlock()
+
+	tmpPath := j.path + ".tmp"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+
+	w := bufio.NewWriter(tmpFile)
+	enc := json.NewEncoder(w)
+
+	// The nonce is kept even if its clientInfo is not so that client IDs are never reused
+	err = enc.Encode(&journalRecord{Kind: journalRecordNonce, ClientID: server.clientIDNonce})
+
+	for clientID, ci := range server.perClientInfo {
+		if err != nil {
+			break
+		}
+
+		ci.Lock()
+		err = enc.Encode(&journalRecord{Kind: journalRecordClient, ClientID: clientID})
+		if (err == nil) && (ci.highestReplySeen != 0) {
+			err = enc.Encode(&journalRecord{Kind: journalRecordAck, ClientID: clientID, HighestReplySeen: ci.highestReplySeen})
+		}
+		for e := ci.completedRequestLRU.Front(); (err == nil) && (e != nil); e = e.Next() {
+			lruEntry := e.Value.(completedLRUEntry)
+			ce := ci.completedRequest[lruEntry.requestID]
+			err = enc.Encode(&journalRecord{
+				Kind:          journalRecordCompleted,
+				ClientID:      clientID,
+				RequestID:     lruEntry.requestID,
+				TimeCompleted: lruEntry.timeCompleted.UnixNano(),
+				Reply:         json.RawMessage(ce.reply.JResult),
+			})
+		}
+		ci.Unlock()
+	}
+
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, j.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return
+	}
+
+	// Make sure the rename itself is durable
+	dir, err := os.Open(filepath.Dir(j.path))
+	if err == nil {
+		err = dir.Sync()
+		_ = dir.Close()
+	}
+
+	// Even if that failed, j.path now names the compacted journal so
+	// further appends must go there rather than to the replaced file
+	if j.file != nil {
+		_ = j.file.Close()
+	}
+	var openErr error
+	j.file, openErr = os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err == nil {
+		err = openErr
+	}
+	return
+}
+
+// appendJournal durably appends rec to the journal (if any).
+//
+// A failure is logged but otherwise ignored since the Server can still
+// function (just without surviving a restart).
+func (server *Server) appendJournal(rec *journalRecord) {
+	j := server.journal
+	if j == nil {
+		return
+	}
+
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		server.logger.Fatalf("Unable to marshal journal record: %+v err: %v", rec, err)
+	}
+	buf = append(buf, '\n')
+
+	j.Lock()
+	if j.file != nil {
+		_, err = j.file.Write(buf)
+		if err == nil {
+			err = j.file.Sync()
+		}
+	}
+	j.Unlock()
+
+	if err != nil {
+		server.logger.Printf("appendJournal() of %v record failed: %v\n", rec.Kind, err)
+	}
+}
+
+// journalCompleted appends the completion of rID to the journal (if any).
+func (server *Server) journalCompleted(clientID uint64, rID requestID, timeCompleted time.Time, ior *ioReply) {
+	if server.journal == nil {
+		return
+	}
+	server.appendJournal(&journalRecord{
+		Kind:          journalRecordCompleted,
+		ClientID:      clientID,
+		RequestID:     rID,
+		TimeCompleted: timeCompleted.UnixNano(),
+		Reply:         json.RawMessage(ior.JResult),
+	})
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package retryrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getNewJournalServer(t *testing.T, myServer *TestDeadlineServer, journalPath string) (rrSvr *Server) {
+	assert := assert.New(t)
+
+	rrSvr = NewServer(&ServerConfig{
+		LongTrim:          10 * time.Second,
+		ShortTrim:         100 * time.Millisecond,
+		DNSOrIPAddr:       testIPAddr,
+		Port:              testPort,
+		DeadlineIO:        60 * time.Second,
+		KeepAlivePeriod:   60 * time.Second,
+		JournalPath:       journalPath,
+		Logger:            newLogger(),
+		dontStartTrimmers: true,
+	})
+	assert.NotNil(rrSvr)
+
+	err := rrSvr.Register(myServer)
+	assert.Nil(err)
+
+	err = rrSvr.Start()
+	assert.Nil(err)
+
+	rrSvr.Run()
+
+	return
+}
+
+func getNewJournalClient(t *testing.T) (rrClnt *Client) {
+	assert := assert.New(t)
+
+	rrClnt, err := NewClient(&ClientConfig{
+		DNSOrIPAddr:     testIPAddr,
+		Port:            testPort,
+		DeadlineIO:      60 * time.Second,
+		KeepAlivePeriod: 60 * time.Second,
+		Logger:          newLogger(),
+	})
+	assert.NotNil(rrClnt)
+	assert.Nil(err)
+
+	return
+}
+
+// Test that completed requests survive a Server restart when journaling
+func TestJournal(t *testing.T) {
+	assert := assert.New(t)
+
+	testDir, err := ioutil.TempDir("", "ProxyFS_test_retryrpc_")
+	assert.Nil(err)
+	defer os.RemoveAll(testDir)
+
+	journalPath := filepath.Join(testDir, "retryrpc.journal")
+	myServer := newTestDeadlineServer()
+
+	// Complete some requests - with the trimmers stopped, none are ACK trimmed
+	rrSvr := getNewJournalServer(t, myServer, journalPath)
+	rrClnt := getNewJournalClient(t)
+	for i := 1; i <= 3; i++ {
+		reply := &TestDeadlineReply{}
+		err = rrClnt.Send("RpcTestCount", &TestDeadlineReq{Message: fmt.Sprintf("request %d", i)}, reply)
+		assert.Nil(err)
+	}
+	clientID := rrClnt.GetMyUniqueID()
+	rrClnt.Close()
+	rrSvr.Close()
+	assert.Equal(uint64(3), atomic.LoadUint64(&myServer.countCnt))
+
+	// Simulate the loss of the last record's trailing newline
+	journalFile, err := os.OpenFile(journalPath, os.O_APPEND|os.O_WRONLY, 0600)
+	assert.Nil(err)
+	_, err = journalFile.Write([]byte(`{"k":"completed","c":`))
+	assert.Nil(err)
+	journalFile.Close()
+
+	// "Restart" the Server - which should recover the client and its completed requests
+	rrSvr = getNewJournalServer(t, myServer, journalPath)
+	assert.Equal(3, rrSvr.CompletedCnt())
+	assert.Equal(clientID, rrSvr.clientIDNonce)
+	ci, ok := rrSvr.perClientInfo[clientID]
+	assert.True(ok)
+
+	// Retransmit request 2 as if its reply had been lost
+	jReq := jsonRequest{MyUniqueID: clientID, RequestID: 2, HighestReplySeen: 1, Method: "RpcTestCount"}
+	jReq.Params[0] = &TestDeadlineReq{Message: "retransmit"}
+	buf, err := json.Marshal(&jReq)
+	assert.Nil(err)
+
+	svrConn, clntConn := net.Pipe()
+	cCtx := &connCtx{conn: svrConn}
+	cCtx.cond = sync.NewCond(&cCtx.Mutex)
+	cCtx.activeRPCsWG.Add(1)
+	rrSvr.goroutineWG.Add(1)
+	go rrSvr.processRequest(ci, cCtx, buf)
+
+	replyBuf, msgType, err := getIO(0, 10*time.Second, clntConn)
+	assert.Nil(err)
+	assert.Equal(RPC, msgType)
+	reply := &TestDeadlineReply{}
+	err = json.Unmarshal(replyBuf, &svrResponse{Result: reply})
+	assert.Nil(err)
+	assert.Equal("request 2", reply.Message)
+	assert.Equal(uint64(3), atomic.LoadUint64(&myServer.countCnt))
+	cCtx.activeRPCsWG.Wait()
+	svrConn.Close()
+	clntConn.Close()
+
+	// A new client must not be given the ID of the recovered client
+	rrClnt = getNewJournalClient(t)
+	err = rrClnt.Send("RpcTestCount", &TestDeadlineReq{Message: "new client"}, &TestDeadlineReply{})
+	assert.Nil(err)
+	assert.Equal(clientID+1, rrClnt.GetMyUniqueID())
+	rrClnt.Close()
+
+	// Once the recovered client's replies are ACKed, trimmed and the journal
+	// compacted, only the newer client should be recovered next time
+	rrSvr.Lock()
+	ci.Lock()
+	ci.highestReplySeen = 3
+	ci.Unlock()
+	rrSvr.Unlock()
+	rrSvr.trimCompleted(time.Now(), false)
+	assert.Equal(1, rrSvr.CompletedCnt())
+	rrSvr.trimCompleted(time.Now(), true)
+	rrSvr.Close()
+
+	rrSvr = getNewJournalServer(t, myServer, journalPath)
+	assert.Equal(1, rrSvr.CompletedCnt())
+	assert.Equal(clientID+1, rrSvr.clientIDNonce)
+	_, ok = rrSvr.perClientInfo[clientID]
+	assert.False(ok)
+	rrSvr.Close()
+}
@@ -5,6 +5,7 @@ package retryrpc
 
 import (
 	"container/list"
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
@@ -15,6 +16,8 @@ import (
 	"time"
 
 	"golang.org/x/sys/unix"
+
+	"github.com/NVIDIA/proxyfs/tracing"
 )
 
 // TODO - test if Register has been called???
@@ -125,6 +128,13 @@ func (server *Server) processRequest(ci *clientInfo, myConnCtx *connCtx, buf []b
 	}
 	ci.stats.RPCattempted.Add(1)
 
+	if jReq.Stream {
+		ci.Unlock()
+		server.processStreamRequest(ci, myConnCtx, &jReq, buf)
+		myConnCtx.activeRPCsWG.Done()
+		return
+	}
+
 	// First check if we already completed this request by looking at
 	// completed queue.
 	var localIOR ioReply
@@ -138,7 +148,14 @@ func (server *Server) processRequest(ci *clientInfo, myConnCtx *connCtx, buf []b
 		ci.stats.RPCretried.Add(1)
 		ci.Unlock()
 
+		// The reply may not yet be durable... wait until it is
+		if ce.durable != nil {
+			<-ce.durable
+		}
+
 	} else {
+		// Track the request so that a Cancel from the client can find it
+		rpcCtx, cancel := ci.startRequest(&jReq)
 		ci.Unlock()
 
 		// Call the RPC and return the results.
@@ -147,21 +164,27 @@ func (server *Server) processRequest(ci *clientInfo, myConnCtx *connCtx, buf []b
 		// be unmarshaled again to retrieve the parameters specific to
 		// the RPC.
 		startRPC := time.Now()
-		ior := server.callRPCAndFormatReply(buf, ci, &jReq)
+		ior := server.callRPCAndFormatReply(rpcCtx, buf, ci, &jReq)
 		ci.stats.CallWrapRPCUsec.Add(uint64(time.Since(startRPC).Microseconds()))
 		ci.stats.RPCcompleted.Add(1)
+		cancel()
 
 		// We had to drop the lock before calling the RPC since it
 		// could block.
 		ci.Lock()
+		ci.endRequest(rID)
 		dur := time.Since(startRPC)
 		if dur > ci.stats.longestRPC {
 			ci.stats.longestRPCMethod = jReq.Method
 			ci.stats.longestRPC = dur
 		}
 
-		// Update completed queue
+		// Update completed queue (marking the entry as not yet durable
+		// such that a retransmit awaits journalCompleted() below)
 		ce := &completedEntry{reply: ior}
+		if server.journal != nil {
+			ce.durable = make(chan struct{})
+		}
 		ci.completedRequest[rID] = ce
 		ci.stats.TrimAddCompleted.Add(1)
 		setupHdrReply(ce.reply, RPC)
@@ -176,6 +199,12 @@ func (server *Server) processRequest(ci *clientInfo, myConnCtx *connCtx, buf []b
 		le := ci.completedRequestLRU.PushBack(lruEntry)
 		ce.lruElem = le
 		ci.Unlock()
+
+		// The reply must be durable before the client can see it
+		server.journalCompleted(ci.myUniqueID, rID, lruEntry.timeCompleted, ior)
+		if ce.durable != nil {
+			close(ce.durable)
+		}
 	}
 
 	// Write results on socket back to client...
@@ -202,12 +231,12 @@ func (server *Server) processRequest(ci *clientInfo, myConnCtx *connCtx, buf []b
 // This avoids race conditions when there are cascading retransmits.
 // The algorithm is:
 //
-// 1. Client sends UniqueID to server when the connection is reestablished.
-// 2. After accepting new socket, the server waits for the UniqueID from
-//    the client.
-// 3. If this is a client returning on a new socket, the server blocks
-//    until all outstanding RPCs and related goroutines have completed for the
-//    client on the previous connection.
+//  1. Client sends UniqueID to server when the connection is reestablished.
+//  2. After accepting new socket, the server waits for the UniqueID from
+//     the client.
+//  3. If this is a client returning on a new socket, the server blocks
+//     until all outstanding RPCs and related goroutines have completed for the
+//     client on the previous connection.
 func (server *Server) getClientIDAndWait(cCtx *connCtx) (ci *clientInfo, err error) {
 	buf, msgType, getErr := getIO(uint64(0), server.deadlineIO, cCtx.conn)
 	if getErr != nil {
@@ -286,6 +315,9 @@ func (server *Server) getClientIDAndWait(cCtx *connCtx) (ci *clientInfo, err err
 		cCtx.ci = ci
 		cCtx.Unlock()
 
+		// Remember the client (and that its ID has been used) before telling it its ID
+		server.appendJournal(&journalRecord{Kind: journalRecordClient, ClientID: newUniqueID})
+
 		// Build reply and send back to client
 		var e error
 		localIOR.JResult, e = json.Marshal(newUniqueID)
@@ -327,6 +359,22 @@ func (server *Server) serviceClient(ci *clientInfo, cCtx *connCtx) {
 			continue
 		}
 
+		if msgType == Cancel {
+			// Cheap enough to handle without a goroutine
+			server.Unlock()
+			server.cancelRequest(ci, buf)
+			continue
+		}
+
+		if msgType == StreamAck {
+			// The stream may be busy writing a frame to the client so
+			// do not hold up reading the next request
+			server.goroutineWG.Add(1)
+			server.Unlock()
+			go server.ackStream(ci, buf)
+			continue
+		}
+
 		if msgType != RPC {
 			server.logger.Fatalf("serviceClient() received invalid msgType: %v", msgType)
 			continue
@@ -347,13 +395,63 @@ func (server *Server) serviceClient(ci *clientInfo, cCtx *connCtx) {
 	}
 }
 
+// cancelRequest handles a Cancel sent by the client for one of its requests.
+func (server *Server) cancelRequest(ci *clientInfo, buf []byte) {
+	jCancel := jsonCancel{}
+	err := json.Unmarshal(buf, &jCancel)
+	if err != nil {
+		server.logger.Printf("Unmarshal of Cancel buf failed with err: %v\n", err)
+		return
+	}
+
+	ci.stats.RPCcancelsReceived.Add(1)
+	ci.cancelRequest(jCancel.RequestID)
+}
+
+// admitRPC waits (if necessary) until a PriorityNormal RPC may be called.
+// PriorityHigh RPCs never wait behind PriorityNormal RPCs.
+//
+// If ctx is done before the RPC may be called, ctx.Err() is returned and
+// releaseRPC() must not be called.
+func (server *Server) admitRPC(ctx context.Context, priority Priority) (err error) {
+	err = ctx.Err()
+	if err != nil {
+		return
+	}
+
+	if (priority >= PriorityHigh) || (server.normalRPCSlots == nil) {
+		return
+	}
+
+	select {
+	case server.normalRPCSlots <- struct{}{}:
+	case <-ctx.Done():
+		err = ctx.Err()
+		return
+	}
+
+	// Both cases of the select may have been ready
+	err = ctx.Err()
+	if err != nil {
+		<-server.normalRPCSlots
+	}
+	return
+}
+
+// releaseRPC is called once an RPC admitted by admitRPC() returns.
+func (server *Server) releaseRPC(priority Priority) {
+	if (priority < PriorityHigh) && (server.normalRPCSlots != nil) {
+		<-server.normalRPCSlots
+	}
+}
+
 // callRPCAndMarshal calls the RPC and returns results to requestor
-func (server *Server) callRPCAndFormatReply(buf []byte, ci *clientInfo, jReq *jsonRequest) (ior *ioReply) {
+//
+// The RPC is not called if ctx is done (i.e. the client cancelled the request
+// or its deadline passed) before it gets the chance to run.
+func (server *Server) callRPCAndFormatReply(ctx context.Context, buf []byte, ci *clientInfo, jReq *jsonRequest) (ior *ioReply) {
 	var (
-		err          error
-		returnValues []reflect.Value
-		typOfReq     reflect.Type
-		dummyReq     interface{}
+		err error
 	)
 
 	// Setup the reply structure with common fields
@@ -363,46 +461,25 @@ func (server *Server) callRPCAndFormatReply(buf []byte, ci *clientInfo, jReq *js
 
 	ma := server.svrMap[jReq.Method]
 	if ma != nil {
-
-		// Another unmarshal of buf to find the parameters specific to
-		// this RPC
-		typOfReq = ma.request.Elem()
-		dummyReq = reflect.New(typOfReq).Interface()
-
-		sReq := svrRequest{}
-		sReq.Params[0] = dummyReq
-		err = json.Unmarshal(buf, &sReq)
-		if err != nil {
-			server.logger.Fatalf("Unmarshal sReq: %+v err: %v", sReq, err)
-			return
-		}
-		req := reflect.ValueOf(dummyReq)
-		cid := reflect.ValueOf(ci.myUniqueID)
-
-		// Create the reply structure
-		typOfReply := ma.reply.Elem()
-		myReply := reflect.New(typOfReply)
-
-		// Call the method
-		function := ma.methodPtr.Func
-		t := time.Now()
-		if ma.passClientID {
-			returnValues = function.Call([]reflect.Value{server.receiver, cid, req, myReply})
+		if ma.stream {
+			jReply.ErrStr = fmt.Sprintf("retryrpc: %v is a streaming RPC", jReq.Method)
+		} else if err = server.admitRPC(ctx, jReq.Priority); err != nil {
+			// Client no longer cares about the result
+			ci.stats.RPCabandoned.Add(1)
+			jReply.ErrStr = err.Error()
 		} else {
-			returnValues = function.Call([]reflect.Value{server.receiver, req, myReply})
-		}
-		ci.setMethodStats(jReq.Method, uint64(time.Since(t).Microseconds()))
+			// Create the reply structure
+			typOfReply := ma.reply.Elem()
+			myReply := reflect.New(typOfReply)
 
-		// The return value for the method is an error.
-		errInter := returnValues[0].Interface()
-		if errInter == nil {
-			jReply.Result = myReply.Elem().Interface()
-		} else {
-			e, ok := errInter.(error)
-			if !ok {
-				server.logger.Fatalf("Call returnValues invalid cast errInter: %+v", errInter)
+			err = server.callMethod(ctx, buf, ci, jReq, ma, myReply)
+			server.releaseRPC(jReq.Priority)
+
+			if err == nil {
+				jReply.Result = myReply.Elem().Interface()
+			} else {
+				jReply.ErrStr = err.Error()
 			}
-			jReply.ErrStr = e.Error()
 		}
 	} else {
 		// TODO - figure out if this is the correct error
@@ -420,6 +497,64 @@ func (server *Server) callRPCAndFormatReply(buf []byte, ci *clientInfo, jReq *js
 	return
 }
 
+// callMethod unmarshals the parameters of the RPC from buf and calls the
+// method with reply as its last argument.  The error returned by the method
+// is returned.
+func (server *Server) callMethod(ctx context.Context, buf []byte, ci *clientInfo, jReq *jsonRequest, ma *methodArgs, reply reflect.Value) (err error) {
+	var (
+		args         []reflect.Value
+		returnValues []reflect.Value
+		typOfReq     reflect.Type
+		dummyReq     interface{}
+	)
+
+	// Another unmarshal of buf to find the parameters specific to
+	// this RPC
+	typOfReq = ma.request.Elem()
+	dummyReq = reflect.New(typOfReq).Interface()
+
+	sReq := svrRequest{}
+	sReq.Params[0] = dummyReq
+	err = json.Unmarshal(buf, &sReq)
+	if err != nil {
+		server.logger.Fatalf("Unmarshal sReq: %+v err: %v", sReq, err)
+		return
+	}
+	req := reflect.ValueOf(dummyReq)
+	cid := reflect.ValueOf(ci.myUniqueID)
+
+	// Call the method within a Span (if tracing) parented by the client's Span
+	span := tracing.StartSpanFromTraceParent(tracingSpanNamePrefix+jReq.Method, tracing.SpanKindServer, jReq.TraceParent)
+	span.SetAttribute("retryrpc.client_id", ci.myUniqueID)
+	span.SetAttribute("retryrpc.request_id", uint64(jReq.RequestID))
+	function := ma.methodPtr.Func
+	args = []reflect.Value{server.receiver}
+	if ma.passContext {
+		args = append(args, reflect.ValueOf(ctx))
+	}
+	if ma.passClientID {
+		args = append(args, cid)
+	}
+	args = append(args, req, reply)
+	t := time.Now()
+	returnValues = function.Call(args)
+	ci.setMethodStats(jReq.Method, uint64(time.Since(t).Microseconds()))
+
+	// The return value for the method is an error.
+	errInter := returnValues[0].Interface()
+	if errInter != nil {
+		e, ok := errInter.(error)
+		if !ok {
+			server.logger.Fatalf("Call returnValues invalid cast errInter: %+v", errInter)
+		}
+		err = e
+		span.SetError(e)
+	}
+	span.End()
+
+	return
+}
+
 func (server *Server) returnResults(ior *ioReply, cCtx *connCtx) {
 
 	// Now write the response back to the client
@@ -498,6 +633,12 @@ func (server *Server) trimCompleted(t time.Time, long bool) {
 			ci.cCtx.Unlock()
 			ci.Unlock()
 		}
+
+		// Rewrite the journal (if any) so it only holds what is left
+		err := server.compactJournal()
+		if err != nil {
+			server.logger.Printf("compactJournal() failed: %v\n", err)
+		}
 		server.logger.Printf("Trimmed completed RetryRpcs - Total: %v\n", totalItems)
 	} else {
 		for k, ci := range server.perClientInfo {
@@ -515,6 +656,8 @@ func (server *Server) trimCompleted(t time.Time, long bool) {
 func (server *Server) trimAClientBasedACK(uniqueID uint64, ci *clientInfo) (numItems int) {
 
 	ci.Lock()
+	highestReplySeen := ci.highestReplySeen
+	acked := ci.highestReplySeen > ci.previousHighestReplySeen
 
 	// Remove from completedRequest completedRequestLRU
 	for h := ci.previousHighestReplySeen + 1; h <= ci.highestReplySeen; h++ {
@@ -529,7 +672,20 @@ func (server *Server) trimAClientBasedACK(uniqueID uint64, ci *clientInfo) (numI
 
 	// Keep track of how far we have trimmed for next run
 	ci.previousHighestReplySeen = ci.highestReplySeen
+
+	// Streams are normally removed once their final frame is ACKed
+	// but the StreamAck may have been lost
+	for rID, stream := range ci.activeStream {
+		if rID <= ci.highestReplySeen {
+			delete(ci.activeStream, rID)
+			stream.cancel()
+		}
+	}
 	ci.Unlock()
+
+	if acked {
+		server.appendJournal(&journalRecord{Kind: journalRecordAck, ClientID: uniqueID, HighestReplySeen: highestReplySeen})
+	}
 	return
 }
 
@@ -555,6 +711,22 @@ func (server *Server) trimTLLBased(ci *clientInfo, t time.Time) (numItems int) {
 			break
 		}
 	}
+
+	// Also forget Cancels whose request never arrived
+	for rID, cancelTime := range ci.cancelledRequest {
+		if cancelTime.Add(server.completedLongTTL).Before(t) {
+			delete(ci.cancelledRequest, rID)
+		}
+	}
+
+	// And streams the client has stopped consuming
+	for rID, stream := range ci.activeStream {
+		if stream.idleSince().Add(server.completedLongTTL).Before(t) {
+			delete(ci.activeStream, rID)
+			stream.cancel()
+			numItems++
+		}
+	}
 	s := ci.stats
 	server.logger.Printf("ID: %v largestReplySize: %v largestReplySizeMethod: %v longest RPC: %v longest RPC Method: %v\n",
 		ci.myUniqueID, s.largestReplySize, s.largestReplySizeMethod, s.longestRPC, s.longestRPCMethod)
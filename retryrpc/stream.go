@@ -0,0 +1,588 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package retryrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/NVIDIA/proxyfs/tracing"
+)
+
+// This file contains streaming RPCs.
+//
+// A streaming RPC is a method whose reply argument is a *ServerStream.
+// Rather than filling in a single reply, the method calls ServerStream.Send()
+// with each frame and the client consumes them one at a time with
+// ClientStream.Recv().  This way neither side buffers a huge reply (e.g. a
+// large directory) all at once.
+//
+// Flow control is credit based.  The request carries the number of frames
+// (the window) the client is willing to have received but not consumed, and
+// the client sends a StreamAck with the number of frames consumed every half
+// window.  ServerStream.Send() blocks while a window's worth of frames are
+// not ACKed.  The final frame, sent once the method returns and carrying its
+// error (if any), needs no credit.
+//
+// A Server with a MaxConcurrentRPCs limit counts a PriorityNormal stream
+// against it only while the method is running... Send() gives up the slot
+// while waiting for credit and waits for one again before sending.  This way,
+// clients slow to consume their streams do not hold up other RPCs.
+//
+// The server keeps the frames not yet ACKed.  When the client reconnects, it
+// retransmits the request with the number of frames it has received
+// (ResumeSeq) and the server resends only the frames following those rather
+// than calling the method again.
+//
+// Streams are not journaled so a stream that is in progress when the Server
+// restarts ends with an error.
+
+const (
+	// DefaultStreamWindow is the number of frames of a stream the Server may
+	// send ahead of ClientStream.Recv() if ClientConfig.StreamWindow is 0
+	DefaultStreamWindow = 16
+)
+
+var errStreamClosed = fmt.Errorf("retryrpc: stream closed")
+
+// ServerStream is passed to a streaming RPC in place of a reply structure
+type ServerStream struct {
+	sync.Mutex
+	cond       *sync.Cond
+	ctx        context.Context    // Done if the stream is cancelled, trimmed or the Server is closing
+	cancel     context.CancelFunc //
+	server     *Server            //
+	stats      *statsInfo         // Stats of the client
+	myUniqueID uint64             // ID of client
+	requestID  requestID          // ID of the streaming request
+	cCtx       *connCtx           // Connection frames are currently written on
+	window     uint64             // Frames which may be sent but not ACKed (not counting final frame)
+	priority   Priority           // Priority of the streaming request
+	holdsSlot  bool               // Set while the method holds a Server.normalRPCSlots slot
+	nextSeq    uint64             // Seq of next frame sent
+	ackedSeq   uint64             // Number of frames ACKed by client
+	unacked    []*ioReply         // Frames [ackedSeq, nextSeq) kept to resend after a reconnect
+	done       bool               // Set once final frame has been sent
+	lastActive time.Time          // Used to trim streams the client has stopped consuming
+}
+
+// ClientStream is returned by SendStream() and receives the frames of a
+// streaming RPC
+//
+// NOTE: A ClientStream is not safe for use by multiple goroutines at once.
+type ClientStream struct {
+	client   *Client
+	ctx      context.Context
+	crID     requestID
+	rCtx     *reqCtx
+	jreq     *jsonRequest          // Marshaled again (with ResumeSeq) on retransmit
+	span     *tracing.SpanStruct   //
+	frames   chan *jsonStreamFrame // Frames received but not consumed by Recv()
+	ackEvery uint64                // Number of frames consumed between StreamAcks
+	nextSeq  uint64                // Frames received - protected by client lock
+	consumed uint64                // Frames consumed by Recv() - protected by client lock
+	ackedSeq uint64                // Frames consumed as of last StreamAck - protected by client lock
+	finished bool                  // Set once Recv() has returned err (or Close() called)
+	err      error                 // Returned by Recv() once finished
+}
+
+// Send sends frame to the client.  If the client has not consumed the frames
+// already sent, Send blocks until it has.
+//
+// If the stream is cancelled (or its deadline passes) before frame can be
+// sent, the context's error is returned and the method should return.
+func (stream *ServerStream) Send(frame interface{}) (err error) {
+	if stream.server == nil {
+		// e.g. the method was called via net/rpc which knows nothing of streams
+		err = fmt.Errorf("retryrpc: ServerStream not created by a retryrpc Server")
+		return
+	}
+
+	stream.Lock()
+	defer stream.Unlock()
+
+	for {
+		err = stream.ctx.Err()
+		if err != nil {
+			return
+		}
+		if stream.nextSeq-stream.ackedSeq < stream.window {
+			break
+		}
+		if stream.holdsSlot {
+			// Let other RPCs run while the client catches up
+			stream.server.releaseRPC(stream.priority)
+			stream.holdsSlot = false
+		}
+		stream.cond.Wait()
+	}
+
+	if !stream.holdsSlot {
+		// Wait for a slot without holding the stream lock as ack() needs it
+		stream.Unlock()
+		err = stream.server.admitRPC(stream.ctx, stream.priority)
+		stream.Lock()
+		if err != nil {
+			return
+		}
+		if stream.holdsSlot {
+			// Another Send() of this stream got a slot first
+			stream.server.releaseRPC(stream.priority)
+		}
+		stream.holdsSlot = true
+	}
+
+	stream.sendFrame(&jsonStreamFrame{Result: frame})
+	return
+}
+
+// releaseSlot gives up the slot (if any) held by the method once it returns
+func (stream *ServerStream) releaseSlot() {
+	stream.Lock()
+	if stream.holdsSlot {
+		stream.server.releaseRPC(stream.priority)
+		stream.holdsSlot = false
+	}
+	stream.Unlock()
+}
+
+// sendFrame writes the next frame of the stream to the client and keeps it
+// until it is ACKed.
+//
+// NOTE: We assume stream lock is held
+func (stream *ServerStream) sendFrame(jFrame *jsonStreamFrame) {
+	jFrame.MyUniqueID = stream.myUniqueID
+	jFrame.RequestID = stream.requestID
+	jFrame.Seq = stream.nextSeq
+
+	ior := &ioReply{}
+	var err error
+	ior.JResult, err = json.Marshal(jFrame)
+	if err != nil {
+		stream.server.logger.Fatalf("Unable to marshal jFrame: %+v err: %v", jFrame, err)
+	}
+	setupHdrReply(ior, StreamFrame)
+
+	stream.unacked = append(stream.unacked, ior)
+	stream.nextSeq++
+	stream.lastActive = time.Now()
+	stream.stats.StreamFramesSent.Add(1)
+
+	// If the write fails, the frame is resent once the client reconnects
+	stream.server.returnResults(ior, stream.cCtx)
+}
+
+// finish sends the final frame once the method has returned
+func (stream *ServerStream) finish(errStr string) {
+	stream.Lock()
+	stream.sendFrame(&jsonStreamFrame{EOF: true, ErrStr: errStr})
+	stream.done = true
+	stream.Unlock()
+}
+
+// ack records that the client has consumed seq frames.  Returns true once
+// the client has consumed the final frame.
+func (stream *ServerStream) ack(seq uint64) (complete bool) {
+	stream.Lock()
+	stream.ackLocked(seq)
+	complete = stream.done && (stream.ackedSeq == stream.nextSeq)
+	stream.Unlock()
+	return
+}
+
+// NOTE: We assume stream lock is held
+func (stream *ServerStream) ackLocked(seq uint64) {
+	if (seq <= stream.ackedSeq) || (seq > stream.nextSeq) {
+		return
+	}
+
+	numAcked := seq - stream.ackedSeq
+	for i := uint64(0); i < numAcked; i++ {
+		stream.unacked[i] = nil
+	}
+	stream.unacked = stream.unacked[numAcked:]
+	stream.ackedSeq = seq
+	stream.lastActive = time.Now()
+
+	// Send() may be waiting for credit
+	stream.cond.Broadcast()
+}
+
+// resume switches the stream to the client's new connection and resends the
+// frames the client did not receive.
+func (stream *ServerStream) resume(cCtx *connCtx, resumeSeq uint64, acked uint64) {
+	stream.Lock()
+	stream.cCtx = cCtx
+	stream.ackLocked(acked)
+	stream.lastActive = time.Now()
+	for i, ior := range stream.unacked {
+		if stream.ackedSeq+uint64(i) >= resumeSeq {
+			stream.server.returnResults(ior, cCtx)
+		}
+	}
+	stream.stats.StreamsResumed.Add(1)
+	stream.Unlock()
+}
+
+// idleSince returns the last time a frame was sent or ACKed
+func (stream *ServerStream) idleSince() (lastActive time.Time) {
+	stream.Lock()
+	lastActive = stream.lastActive
+	stream.Unlock()
+	return
+}
+
+// processStreamRequest starts the streaming RPC jReq or, if the client is
+// retransmitting it after reconnecting, resumes it.
+func (server *Server) processStreamRequest(ci *clientInfo, cCtx *connCtx, jReq *jsonRequest, buf []byte) {
+	rID := jReq.RequestID
+	ma := server.svrMap[jReq.Method]
+
+	// The Server lock is held so that Close() either sees the stream or
+	// we see halting
+	server.Lock()
+	if server.halting {
+		server.Unlock()
+		return
+	}
+
+	ci.Lock()
+	stream, ok := ci.activeStream[rID]
+	if ok {
+		ci.Unlock()
+		server.Unlock()
+		stream.resume(cCtx, jReq.ResumeSeq, jReq.StreamAcked)
+		return
+	}
+
+	var errStr string
+	switch {
+	case ma == nil:
+		// Method does not exist
+		errStr = fmt.Sprintf("errno: %d", unix.ENOENT)
+	case !ma.stream:
+		errStr = fmt.Sprintf("retryrpc: %v is not a streaming RPC", jReq.Method)
+	case jReq.ResumeSeq != 0:
+		// The stream was trimmed (or the Server restarted) so the
+		// frames following those the client has cannot be recreated
+		errStr = "retryrpc: stream lost"
+	}
+	if errStr != "" {
+		ci.Unlock()
+		server.Unlock()
+		server.returnStreamError(cCtx, jReq, errStr)
+		return
+	}
+
+	// Track the request so that a Cancel from the client can find it
+	rpcCtx, cancel := ci.startRequest(jReq)
+	stream = &ServerStream{
+		ctx:        rpcCtx,
+		cancel:     cancel,
+		server:     server,
+		stats:      &ci.stats,
+		myUniqueID: ci.myUniqueID,
+		requestID:  rID,
+		cCtx:       cCtx,
+		window:     uint64(jReq.Window),
+		priority:   jReq.Priority,
+		lastActive: time.Now(),
+	}
+	stream.cond = sync.NewCond(&stream.Mutex)
+	if stream.window == 0 {
+		stream.window = 1
+	}
+	ci.activeStream[rID] = stream
+	ci.stats.StreamsStarted.Add(1)
+	ci.Unlock()
+
+	// The method may run for as long as the client takes to consume the
+	// frames so it does not hold up a reconnecting client like other RPCs
+	server.goroutineWG.Add(1)
+	server.Unlock()
+	go server.runStream(ci, stream, jReq, ma, buf)
+}
+
+// runStream calls the method of a streaming RPC and then sends the final frame
+func (server *Server) runStream(ci *clientInfo, stream *ServerStream, jReq *jsonRequest, ma *methodArgs, buf []byte) {
+	var (
+		errStr string
+	)
+
+	defer server.goroutineWG.Done()
+
+	// Wake up Send() if the stream is cancelled while it waits for credit
+	go func() {
+		<-stream.ctx.Done()
+		stream.Lock()
+		stream.cond.Broadcast()
+		stream.Unlock()
+	}()
+
+	startRPC := time.Now()
+	err := server.admitRPC(stream.ctx, jReq.Priority)
+	if err != nil {
+		// Client no longer cares about the result
+		ci.stats.RPCabandoned.Add(1)
+		errStr = err.Error()
+	} else {
+		stream.Lock()
+		stream.holdsSlot = true
+		stream.Unlock()
+		err = server.callMethod(stream.ctx, buf, ci, jReq, ma, reflect.ValueOf(stream))
+		stream.releaseSlot()
+		if err != nil {
+			errStr = err.Error()
+		}
+	}
+	ci.stats.CallWrapRPCUsec.Add(uint64(time.Since(startRPC).Microseconds()))
+	ci.stats.RPCcompleted.Add(1)
+
+	stream.finish(errStr)
+	stream.cancel()
+
+	ci.Lock()
+	ci.endRequest(jReq.RequestID)
+	ci.Unlock()
+}
+
+// returnStreamError sends a final frame with errStr for a streaming request
+// which is not being tracked
+func (server *Server) returnStreamError(cCtx *connCtx, jReq *jsonRequest, errStr string) {
+	jFrame := &jsonStreamFrame{MyUniqueID: jReq.MyUniqueID, RequestID: jReq.RequestID, Seq: jReq.ResumeSeq, EOF: true, ErrStr: errStr}
+
+	ior := &ioReply{}
+	var err error
+	ior.JResult, err = json.Marshal(jFrame)
+	if err != nil {
+		server.logger.Fatalf("Unable to marshal jFrame: %+v err: %v", jFrame, err)
+	}
+	setupHdrReply(ior, StreamFrame)
+
+	server.returnResults(ior, cCtx)
+}
+
+// ackStream handles a StreamAck sent by the client as it consumes frames.
+func (server *Server) ackStream(ci *clientInfo, buf []byte) {
+	defer server.goroutineWG.Done()
+
+	jAck := jsonStreamAck{}
+	err := json.Unmarshal(buf, &jAck)
+	if err != nil {
+		server.logger.Printf("Unmarshal of StreamAck buf failed with err: %v\n", err)
+		return
+	}
+
+	ci.Lock()
+	stream, ok := ci.activeStream[jAck.RequestID]
+	ci.Unlock()
+	if !ok {
+		return
+	}
+
+	if stream.ack(jAck.Seq) {
+		// Client has consumed the final frame - nothing left to resend
+		ci.Lock()
+		if ci.activeStream[jAck.RequestID] == stream {
+			delete(ci.activeStream, jAck.RequestID)
+		}
+		ci.Unlock()
+	}
+}
+
+// sendStream sends the request for a streaming RPC and returns without
+// waiting for any frames.
+func (client *Client) sendStream(ctx context.Context, method string, rpcRequest interface{}) (stream *ClientStream, err error) {
+	err = ctx.Err()
+	if err != nil {
+		return
+	}
+
+	client.stats.SendCalled.Add(1)
+
+	jreq := client.prepareRequest(ctx, method, rpcRequest)
+	jreq.Stream = true
+	jreq.Window = client.streamWindow
+	crID := jreq.RequestID
+
+	// The Span ends in Recv() or Close() - possibly on another goroutine
+	span := tracing.StartDetachedSpan(tracingSpanNamePrefix+method, tracing.SpanKindClient, tracing.CurrentTraceParent())
+	jreq.TraceParent = span.TraceParent()
+	span.SetAttribute("retryrpc.request_id", uint64(crID))
+
+	// Setup ioreq to write structure on socket to server
+	ioreq, err := buildIoRequest(jreq)
+	if err != nil {
+		client.logger.Fatalf("Client buildIoRequest returned err: %v", err)
+		return
+	}
+
+	stream = &ClientStream{
+		client:   client,
+		ctx:      ctx,
+		crID:     crID,
+		jreq:     jreq,
+		span:     span,
+		frames:   make(chan *jsonStreamFrame, jreq.Window+1),
+		ackEvery: uint64(jreq.Window / 2),
+	}
+	if stream.ackEvery == 0 {
+		stream.ackEvery = 1
+	}
+	stream.rCtx = &reqCtx{ioreq: ioreq, startTime: time.Now(), stream: stream, priority: jreq.Priority}
+
+	client.goroutineWG.Add(1)
+	go client.sendToServer(crID, stream.rCtx, true)
+
+	return
+}
+
+// resumeRequest returns the request to retransmit after reconnecting so
+// that the server only resends frames we have not received.
+//
+// NOTE: Client lock is held
+func (stream *ClientStream) resumeRequest() (ioreq *ioRequest) {
+	stream.jreq.ResumeSeq = stream.nextSeq
+	stream.jreq.StreamAcked = stream.consumed
+	stream.ackedSeq = stream.consumed
+
+	ioreq, err := buildIoRequest(stream.jreq)
+	if err != nil {
+		stream.client.logger.Fatalf("Client buildIoRequest returned err: %v", err)
+	}
+	return
+}
+
+// receiveFrame hands a frame read by readReplies() to its ClientStream.
+//
+// Frames for a stream which has ended or that have already been received
+// (i.e. resent after a reconnect) are dropped.
+func (client *Client) receiveFrame(buf []byte) (err error) {
+	result := json.RawMessage{}
+	jFrame := &jsonStreamFrame{Result: &result}
+	err = json.Unmarshal(buf, jFrame)
+	if err != nil {
+		return
+	}
+
+	client.Lock()
+	rCtx, ok := client.outstandingRequest[jFrame.RequestID]
+	if !ok || (rCtx.stream == nil) || (jFrame.Seq != rCtx.stream.nextSeq) {
+		client.Unlock()
+		return
+	}
+	stream := rCtx.stream
+
+	// The server never sends more than a window ahead of Recv() so this
+	// does not block readReplies()
+	select {
+	case stream.frames <- jFrame:
+	default:
+		client.Unlock()
+		client.logger.Printf("receiveFrame dropped frame %v of requestID: %v exceeding window\n", jFrame.Seq, jFrame.RequestID)
+		return
+	}
+	stream.nextSeq++
+	if jFrame.EOF {
+		delete(client.outstandingRequest, jFrame.RequestID)
+	}
+	client.Unlock()
+
+	client.stats.StreamFramesReceived.Add(1)
+
+	if jFrame.EOF {
+		// Fork off a goroutine to update highestConsecutiveNum
+		go client.updateHighestConsecutiveNum(jFrame.RequestID)
+	}
+	return
+}
+
+// Recv unmarshals the next frame of the stream into frame.
+//
+// Once all frames have been received, io.EOF is returned if the method
+// returned nil.  Otherwise, the error returned by the method is returned.
+// If the context passed to SendStream() is done first, the server is asked
+// to cancel the stream and ctx.Err() is returned.
+func (stream *ClientStream) Recv(frame interface{}) (err error) {
+	var (
+		jFrame *jsonStreamFrame
+	)
+
+	if stream.finished {
+		return stream.err
+	}
+
+	select {
+	case jFrame = <-stream.frames:
+	case <-stream.ctx.Done():
+		stream.end(stream.ctx.Err(), true)
+		return stream.err
+	}
+
+	// Give the server more credit every ackEvery frames
+	client := stream.client
+	client.Lock()
+	stream.consumed++
+	consumed := stream.consumed
+	sendAck := jFrame.EOF || (consumed-stream.ackedSeq >= stream.ackEvery)
+	if sendAck {
+		stream.ackedSeq = consumed
+	}
+	client.Unlock()
+
+	if sendAck {
+		ioreq, ackErr := buildStreamAckRequest(&jsonStreamAck{MyUniqueID: client.myUniqueID, RequestID: stream.crID, Seq: consumed})
+		if ackErr != nil {
+			client.logger.Fatalf("Client buildStreamAckRequest returned err: %v", ackErr)
+		}
+		client.writeControl(ioreq)
+	}
+
+	if jFrame.EOF {
+		err = errFromErrStr(jFrame.ErrStr)
+		if err == nil {
+			err = io.EOF
+		}
+		stream.end(err, false)
+		return
+	}
+
+	result := *jFrame.Result.(*json.RawMessage)
+	if len(result) != 0 {
+		err = json.Unmarshal(result, frame)
+	}
+	return
+}
+
+// Close ends the stream.  If the final frame has not been received, the
+// server is asked to cancel the stream.
+func (stream *ClientStream) Close() {
+	if stream.finished {
+		return
+	}
+	stream.end(errStreamClosed, true)
+}
+
+// end is called once Recv() will not return any more frames
+func (stream *ClientStream) end(err error, abandon bool) {
+	if abandon {
+		_ = stream.client.abandonRequest(stream.crID, stream.rCtx)
+	}
+	stream.finished = true
+	stream.err = err
+
+	stream.client.stats.TimeSendRPCUsec.Add(uint64(time.Duration(time.Since(stream.rCtx.startTime).Microseconds())))
+	if (err == io.EOF) || (err == errStreamClosed) {
+		stream.span.End()
+	} else {
+		stream.span.EndWithError(err)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package retryrpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStreamServer is a struct with pointer receivers implementing RpcTestStream() and RpcTestPing()
+type TestStreamServer struct {
+	calls  uint64     // Number of times RpcTestStream() was called
+	sent   uint64     // Number of frames RpcTestStream() has sent
+	ctxErr chan error // Error returned by Send() if the stream is cancelled
+}
+
+// TestStreamReq is the request object for RpcTestStream() and RpcTestPing()
+type TestStreamReq struct {
+	NumFrames int
+	Fail      bool // If set, RpcTestStream() returns an error after sending its frames
+}
+
+// TestStreamFrame is the frame sent by RpcTestStream()
+type TestStreamFrame struct {
+	Index int
+}
+
+// TestStreamReply is the response object for RpcTestPing()
+type TestStreamReply struct {
+	Message string
+}
+
+// RpcTestStream sends NumFrames frames
+func (s *TestStreamServer) RpcTestStream(in *TestStreamReq, stream *ServerStream) (err error) {
+	atomic.AddUint64(&s.calls, 1)
+	for i := 0; i < in.NumFrames; i++ {
+		err = stream.Send(&TestStreamFrame{Index: i})
+		if err != nil {
+			s.ctxErr <- err
+			return
+		}
+		atomic.AddUint64(&s.sent, 1)
+	}
+	if in.Fail {
+		err = fmt.Errorf("failed after %v frames", in.NumFrames)
+	}
+	return
+}
+
+// RpcTestPing is not a streaming RPC
+func (s *TestStreamServer) RpcTestPing(in *TestStreamReq, reply *TestStreamReply) (err error) {
+	reply.Message = "pong"
+	return nil
+}
+
+func getNewStreamServerAndClient(t *testing.T, myServer *TestStreamServer, streamWindow uint32, maxConcurrentRPCs int) (rrSvr *Server, rrClnt *Client) {
+	assert := assert.New(t)
+
+	rrSvr = NewServer(&ServerConfig{
+		LongTrim:          10 * time.Second,
+		ShortTrim:         100 * time.Millisecond,
+		DNSOrIPAddr:       testIPAddr,
+		Port:              testPort,
+		DeadlineIO:        60 * time.Second,
+		KeepAlivePeriod:   60 * time.Second,
+		MaxConcurrentRPCs: maxConcurrentRPCs,
+		Logger:            newLogger(),
+	})
+	assert.NotNil(rrSvr)
+
+	err := rrSvr.Register(myServer)
+	assert.Nil(err)
+	assert.Equal(2, len(rrSvr.svrMap))
+	assert.True(rrSvr.svrMap["RpcTestStream"].stream)
+	assert.False(rrSvr.svrMap["RpcTestPing"].stream)
+
+	startErr := rrSvr.Start()
+	assert.Nil(startErr, "startErr is not nil")
+
+	rrSvr.Run()
+
+	rrClnt, err = NewClient(&ClientConfig{
+		DNSOrIPAddr:     testIPAddr,
+		Port:            testPort,
+		DeadlineIO:      60 * time.Second,
+		KeepAlivePeriod: 60 * time.Second,
+		StreamWindow:    streamWindow,
+		Logger:          newLogger(),
+	})
+	assert.NotNil(rrClnt)
+	assert.Nil(err)
+
+	return
+}
+
+// recvFrames receives frames until Recv() returns an error, checking they arrive in order
+func recvFrames(t *testing.T, stream *ClientStream, firstIndex int) (numFrames int, err error) {
+	assert := assert.New(t)
+
+	for {
+		frame := &TestStreamFrame{}
+		err = stream.Recv(frame)
+		if err != nil {
+			return
+		}
+		assert.Equal(firstIndex+numFrames, frame.Index)
+		numFrames++
+	}
+}
+
+// Test that frames are received in order followed by the method's error (if any)
+func TestStream(t *testing.T) {
+	assert := assert.New(t)
+
+	myServer := &TestStreamServer{ctxErr: make(chan error, 1)}
+	rrSvr, rrClnt := getNewStreamServerAndClient(t, myServer, 4, 0)
+
+	stream, err := rrClnt.SendStream(context.Background(), "RpcTestStream", &TestStreamReq{NumFrames: 100})
+	assert.Nil(err)
+	numFrames, err := recvFrames(t, stream, 0)
+	assert.Equal(100, numFrames)
+	assert.Equal(io.EOF, err)
+	assert.Equal(io.EOF, stream.Recv(&TestStreamFrame{}))
+
+	stream, err = rrClnt.SendStream(context.Background(), "RpcTestStream", &TestStreamReq{NumFrames: 3, Fail: true})
+	assert.Nil(err)
+	numFrames, err = recvFrames(t, stream, 0)
+	assert.Equal(3, numFrames)
+	assert.Equal("failed after 3 frames", err.Error())
+
+	// Streaming and non-streaming RPCs cannot be mixed up
+	stream, err = rrClnt.SendStream(context.Background(), "RpcTestPing", &TestStreamReq{})
+	assert.Nil(err)
+	assert.NotNil(stream.Recv(&TestStreamFrame{}))
+	assert.NotNil(rrClnt.Send("RpcTestStream", &TestStreamReq{NumFrames: 1}, &TestStreamReply{}))
+
+	reply := &TestStreamReply{}
+	assert.Nil(rrClnt.Send("RpcTestPing", &TestStreamReq{}, reply))
+	assert.Equal("pong", reply.Message)
+
+	// Once ACKed, the server should forget the streams
+	ci := rrSvr.perClientInfo[rrClnt.GetMyUniqueID()]
+	for i := 0; i < 100; i++ {
+		ci.Lock()
+		numStreams := len(ci.activeStream)
+		ci.Unlock()
+		if numStreams == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	ci.Lock()
+	assert.Equal(0, len(ci.activeStream))
+	ci.Unlock()
+
+	rrClnt.Close()
+	rrSvr.Close()
+}
+
+// Test that the server sends no more than the window ahead of Recv() and
+// that closing the stream cancels the method
+func TestStreamWindowAndClose(t *testing.T) {
+	assert := assert.New(t)
+
+	myServer := &TestStreamServer{ctxErr: make(chan error, 1)}
+	rrSvr, rrClnt := getNewStreamServerAndClient(t, myServer, 4, 0)
+
+	stream, err := rrClnt.SendStream(context.Background(), "RpcTestStream", &TestStreamReq{NumFrames: 100})
+	assert.Nil(err)
+
+	// Without Recv(), Send() must block once the window is full
+	for i := 0; (atomic.LoadUint64(&myServer.sent) < 4) && (i < 100); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(uint64(4), atomic.LoadUint64(&myServer.sent))
+
+	// Consuming half the window lets the server send more
+	numFrames := 0
+	for ; numFrames < 2; numFrames++ {
+		frame := &TestStreamFrame{}
+		assert.Nil(stream.Recv(frame))
+		assert.Equal(numFrames, frame.Index)
+	}
+	for i := 0; (atomic.LoadUint64(&myServer.sent) < 6) && (i < 100); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(uint64(6), atomic.LoadUint64(&myServer.sent))
+
+	stream.Close()
+	assert.Equal(context.Canceled, <-myServer.ctxErr)
+	assert.Equal(errStreamClosed, stream.Recv(&TestStreamFrame{}))
+
+	// Client should still be usable
+	reply := &TestStreamReply{}
+	assert.Nil(rrClnt.Send("RpcTestPing", &TestStreamReq{}, reply))
+	assert.Equal("pong", reply.Message)
+	rrClnt.Lock()
+	assert.Equal(0, len(rrClnt.outstandingRequest))
+	rrClnt.Unlock()
+
+	rrClnt.Close()
+	rrSvr.Close()
+}
+
+// Test that a stream waiting for the client to consume its frames does not
+// hold up other RPCs of a Server with a MaxConcurrentRPCs limit
+func TestStreamReleasesSlotWhileWaiting(t *testing.T) {
+	assert := assert.New(t)
+
+	myServer := &TestStreamServer{ctxErr: make(chan error, 1)}
+	rrSvr, rrClnt := getNewStreamServerAndClient(t, myServer, 4, 1)
+
+	stream, err := rrClnt.SendStream(context.Background(), "RpcTestStream", &TestStreamReq{NumFrames: 10})
+	assert.Nil(err)
+
+	// Without Recv(), Send() blocks once the window is full
+	for i := 0; (atomic.LoadUint64(&myServer.sent) < 4) && (i < 100); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(uint64(4), atomic.LoadUint64(&myServer.sent))
+
+	// ...yet the sole slot is available to other RPCs
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	reply := &TestStreamReply{}
+	assert.Nil(rrClnt.SendContext(ctx, "RpcTestPing", &TestStreamReq{}, reply))
+	assert.Equal("pong", reply.Message)
+	cancel()
+
+	// Once consumed, the stream gets the slot back to finish
+	numFrames, err := recvFrames(t, stream, 0)
+	assert.Equal(10, numFrames)
+	assert.Equal(io.EOF, err)
+	assert.Equal(0, len(rrSvr.normalRPCSlots))
+
+	rrClnt.Close()
+	rrSvr.Close()
+}
+
+// Test that a stream resumes without calling the method again after the
+// connection is lost
+func TestStreamResume(t *testing.T) {
+	assert := assert.New(t)
+
+	myServer := &TestStreamServer{ctxErr: make(chan error, 1)}
+	rrSvr, rrClnt := getNewStreamServerAndClient(t, myServer, 8, 0)
+
+	stream, err := rrClnt.SendStream(context.Background(), "RpcTestStream", &TestStreamReq{NumFrames: 50})
+	assert.Nil(err)
+
+	numFrames := 0
+	for ; numFrames < 10; numFrames++ {
+		frame := &TestStreamFrame{}
+		assert.Nil(stream.Recv(frame))
+		assert.Equal(numFrames, frame.Index)
+	}
+
+	rrSvr.CloseClientConn()
+
+	moreFrames, err := recvFrames(t, stream, numFrames)
+	assert.Equal(50, numFrames+moreFrames)
+	assert.Equal(io.EOF, err)
+	assert.Equal(uint64(1), atomic.LoadUint64(&myServer.calls))
+
+	ci := rrSvr.perClientInfo[rrClnt.GetMyUniqueID()]
+	assert.Equal(uint64(1), ci.stats.StreamsResumed.TotalGet())
+
+	rrClnt.Close()
+	rrSvr.Close()
+}
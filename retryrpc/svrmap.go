@@ -4,6 +4,7 @@
 package retryrpc
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"unicode"
@@ -11,7 +12,9 @@ import (
 )
 
 var (
-	typeOfError = reflect.TypeOf((*error)(nil)).Elem()
+	typeOfContext      = reflect.TypeOf((*context.Context)(nil)).Elem()
+	typeOfError        = reflect.TypeOf((*error)(nil)).Elem()
+	typeOfServerStream = reflect.TypeOf((*ServerStream)(nil))
 )
 
 // Find all methods for the type which can be exported.
@@ -26,15 +29,27 @@ func (server *Server) buildSvrMap(typ reflect.Type) {
 		// Just like net/rpc, we have these requirements on methods:
 		// - must be exported
 		// - needs three ins: receiver, *args, *reply
+		//   (optionally with a context.Context and/or uint64 clientID
+		//   following the receiver)
 		// - reply has to be a pointer and must be exported
+		//   (a *ServerStream reply makes the method a streaming RPC)
 		// - method can only return one value of type error
 		if method.PkgPath != "" {
 			continue
 		}
 
-		// Will have 3 arguments if pass request/reply
-		// Will have 4 arguments if expect clientID and request/reply
-		if (mType.NumIn() != 3) && (mType.NumIn() != 4) {
+		// The receiver may be followed by a context.Context which is done
+		// if the client cancels the request or its deadline passes
+		firstIn := 1
+		passContext := false
+		if (mType.NumIn() > firstIn) && (mType.In(firstIn) == typeOfContext) {
+			passContext = true
+			firstIn++
+		}
+
+		// Will have 2 more arguments if pass request/reply
+		// Will have 3 more arguments if expect clientID and request/reply
+		if (mType.NumIn() != firstIn+2) && (mType.NumIn() != firstIn+3) {
 			continue
 		}
 
@@ -47,48 +62,37 @@ func (server *Server) buildSvrMap(typ reflect.Type) {
 			continue
 		}
 
-		// We save off the request type so we know how to unmarshal the request.
-		// We use the reply type to allocate the reply struct and marshal the response.
-		if mType.NumIn() == 3 {
-			argType := mType.In(1)
-			if !isExportedOrBuiltinType(argType) {
-				continue
-			}
-			replyType := mType.In(2)
-			if replyType.Kind() != reflect.Ptr {
-				continue
-			}
-
-			if !isExportedOrBuiltinType(replyType) {
-				continue
-			}
-
-			ma := methodArgs{methodPtr: &method, passClientID: false, request: argType, reply: replyType}
-			server.svrMap[mName] = &ma
-		} else if mType.NumIn() == 4 {
-			argType := mType.In(2)
-			if !isExportedOrBuiltinType(argType) {
-				continue
-			}
-
+		passClientID := false
+		if mType.NumIn() == firstIn+3 {
 			// Check if first argument is uint64 for clientID
-			clientIDType := mType.In(1)
+			clientIDType := mType.In(firstIn)
 			if clientIDType.Kind() != reflect.Uint64 {
 				continue
 			}
+			passClientID = true
+			firstIn++
+		}
 
-			replyType := mType.In(3)
-			if replyType.Kind() != reflect.Ptr {
-				continue
-			}
+		// We save off the request type so we know how to unmarshal the request.
+		// We use the reply type to allocate the reply struct and marshal the response.
+		argType := mType.In(firstIn)
+		if !isExportedOrBuiltinType(argType) {
+			continue
+		}
 
-			if !isExportedOrBuiltinType(replyType) {
-				continue
-			}
+		replyType := mType.In(firstIn + 1)
+		if replyType.Kind() != reflect.Ptr {
+			continue
+		}
 
-			ma := methodArgs{methodPtr: &method, passClientID: true, request: argType, reply: replyType}
-			server.svrMap[mName] = &ma
+		if !isExportedOrBuiltinType(replyType) {
+			continue
 		}
+
+		stream := replyType == typeOfServerStream
+
+		ma := methodArgs{methodPtr: &method, passContext: passContext, passClientID: passClientID, stream: stream, request: argType, reply: replyType}
+		server.svrMap[mName] = &ma
 	}
 }
 
@@ -0,0 +1,200 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package s3client provides a minimal client for S3-compatible object stores.
+//
+// Only the operations needed to hold a ProxyFS volume are provided: bucket
+// creation, deletion, and listing plus object PUT (including multipart uploads),
+// GET (including byte ranges), HEAD, DELETE, and COPY. Requests are signed using
+// AWS Signature Version 4 and always use path-style addressing (i.e. URLs of the
+// form <Endpoint>/<bucket>/<key>) so that any S3-compatible endpoint may be used.
+//
+// Object metadata is exchanged as a map from the (lower case) user metadata name
+// to its value... the "X-Amz-Meta-" prefix is neither expected nor returned.
+//
+// Retries are the responsibility of the caller. Any non-2xx response is returned
+// as an *ErrorStruct.
+//
+package s3client
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ClientConfig is used to configure a Client.
+//
+type ClientConfig struct {
+	Endpoint        string        // e.g. "http://127.0.0.1:9000" (no trailing "/")
+	Region          string        // e.g. "us-east-1"
+	AccessKeyID     string        //
+	SecretAccessKey string        //
+	Timeout         time.Duration // Applied to each request
+}
+
+// Client is used to issue requests to an S3-compatible object store.
+//
+type Client struct {
+	config     ClientConfig
+	httpClient *http.Client
+}
+
+// ErrorStruct is returned for any non-2xx response. Code and Message are taken
+// from the S3 error response body, if any.
+//
+type ErrorStruct struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (errorStruct *ErrorStruct) Error() string {
+	return fmt.Sprintf("S3 StatusCode %d Code %s Message %s", errorStruct.StatusCode, errorStruct.Code, errorStruct.Message)
+}
+
+// IsNotFound returns whether err reports a missing bucket, object, or upload.
+//
+func IsNotFound(err error) (isNotFound bool) {
+	var (
+		errorStruct *ErrorStruct
+		ok          bool
+	)
+
+	errorStruct, ok = err.(*ErrorStruct)
+	isNotFound = ok && (http.StatusNotFound == errorStruct.StatusCode)
+
+	return
+}
+
+// NewClient returns a Client configured according to config.
+//
+func NewClient(config *ClientConfig) (client *Client, err error) {
+	client, err = newClient(config)
+	return
+}
+
+// CreateBucket creates the named bucket.
+//
+func (client *Client) CreateBucket(bucketName string) (err error) {
+	err = client.createBucket(bucketName)
+	return
+}
+
+// DeleteBucket deletes the named (empty) bucket.
+//
+func (client *Client) DeleteBucket(bucketName string) (err error) {
+	err = client.deleteBucket(bucketName)
+	return
+}
+
+// HeadBucket returns nil if the named bucket exists (and is accessible).
+//
+func (client *Client) HeadBucket(bucketName string) (err error) {
+	err = client.headBucket(bucketName)
+	return
+}
+
+// ListObjects returns, in order, the keys in the named bucket starting with prefix.
+// If delimiter is not "", keys containing delimiter after prefix are instead rolled
+// up into commonPrefixes. All pages of the listing are fetched.
+//
+func (client *Client) ListObjects(bucketName string, prefix string, delimiter string) (keys []string, commonPrefixes []string, err error) {
+	keys, commonPrefixes, err = client.listObjects(bucketName, prefix, delimiter)
+	return
+}
+
+// PutObject creates (or replaces) the named object.
+//
+func (client *Client) PutObject(bucketName string, key string, metadata map[string]string, body []byte) (err error) {
+	err = client.putObject(bucketName, key, metadata, body)
+	return
+}
+
+// GetObject returns the metadata and contents of the named object.
+//
+func (client *Client) GetObject(bucketName string, key string) (metadata map[string]string, body []byte, err error) {
+	metadata, body, err = client.getObject(bucketName, key, "")
+	return
+}
+
+// GetObjectRange returns length bytes of the named object starting at offset.
+//
+func (client *Client) GetObjectRange(bucketName string, key string, offset uint64, length uint64) (body []byte, err error) {
+	if 0 == length {
+		body = make([]byte, 0)
+		err = nil
+		return
+	}
+
+	_, body, err = client.getObject(bucketName, key, fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	return
+}
+
+// GetObjectTail returns the trailing length bytes of the named object.
+//
+func (client *Client) GetObjectTail(bucketName string, key string, length uint64) (body []byte, err error) {
+	if 0 == length {
+		body = make([]byte, 0)
+		err = nil
+		return
+	}
+
+	_, body, err = client.getObject(bucketName, key, fmt.Sprintf("bytes=-%d", length))
+	return
+}
+
+// HeadObject returns the metadata and length of the named object.
+//
+func (client *Client) HeadObject(bucketName string, key string) (metadata map[string]string, contentLength uint64, err error) {
+	metadata, contentLength, err = client.headObject(bucketName, key)
+	return
+}
+
+// DeleteObject deletes the named object. Note that, per S3 semantics, deleting
+// a non-existent object succeeds.
+//
+func (client *Client) DeleteObject(bucketName string, key string) (err error) {
+	err = client.deleteObject(bucketName, key)
+	return
+}
+
+// CopyObject copies an object within the store. If metadata is nil, the source
+// object's metadata is copied. Otherwise, the destination object's metadata is
+// replaced by metadata (as is needed to update the metadata of an object "in place").
+//
+func (client *Client) CopyObject(srcBucketName string, srcKey string, dstBucketName string, dstKey string, metadata map[string]string) (err error) {
+	err = client.copyObject(srcBucketName, srcKey, dstBucketName, dstKey, metadata)
+	return
+}
+
+// CreateMultipartUpload initiates a multipart upload of the named object.
+//
+func (client *Client) CreateMultipartUpload(bucketName string, key string, metadata map[string]string) (uploadID string, err error) {
+	uploadID, err = client.createMultipartUpload(bucketName, key, metadata)
+	return
+}
+
+// UploadPart uploads the partNumber'd (starting at 1) part of a multipart upload.
+// All but the last part must be at least the minimum part size of the store
+// (5 MiB for Amazon S3). The returned eTag must be passed to CompleteMultipartUpload().
+//
+func (client *Client) UploadPart(bucketName string, key string, uploadID string, partNumber uint32, body []byte) (eTag string, err error) {
+	eTag, err = client.uploadPart(bucketName, key, uploadID, partNumber, body)
+	return
+}
+
+// CompleteMultipartUpload assembles the uploaded parts (whose eTags are supplied
+// in part number order) into the named object.
+//
+func (client *Client) CompleteMultipartUpload(bucketName string, key string, uploadID string, eTags []string) (err error) {
+	err = client.completeMultipartUpload(bucketName, key, uploadID, eTags)
+	return
+}
+
+// AbortMultipartUpload discards a multipart upload and any parts uploaded thus far.
+//
+func (client *Client) AbortMultipartUpload(bucketName string, key string, uploadID string) (err error) {
+	err = client.abortMultipartUpload(bucketName, key, uploadID)
+	return
+}
@@ -0,0 +1,492 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package s3client
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRegion   = "us-east-1"
+	metadataPrefix  = "X-Amz-Meta-"
+	xmlErrorElement = "<Error>"
+)
+
+type listBucketResultStruct struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+type initiateMultipartUploadResultStruct struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	UploadID string   `xml:"UploadId"`
+}
+
+type completedPartStruct struct {
+	PartNumber uint32 `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUploadStruct struct {
+	XMLName xml.Name              `xml:"CompleteMultipartUpload"`
+	Parts   []completedPartStruct `xml:"Part"`
+}
+
+type createBucketConfigurationStruct struct {
+	XMLName            xml.Name `xml:"CreateBucketConfiguration"`
+	LocationConstraint string   `xml:"LocationConstraint"`
+}
+
+type errorResponseStruct struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+func newClient(config *ClientConfig) (client *Client, err error) {
+	var (
+		endpointURL *url.URL
+	)
+
+	endpointURL, err = url.Parse(config.Endpoint)
+	if nil != err {
+		err = fmt.Errorf("url.Parse(config.Endpoint==\"%s\") failed: %v", config.Endpoint, err)
+		return
+	}
+	if ("http" != endpointURL.Scheme) && ("https" != endpointURL.Scheme) {
+		err = fmt.Errorf("config.Endpoint==\"%s\" must be an http:// or https:// URL", config.Endpoint)
+		return
+	}
+
+	client = &Client{
+		config: *config,
+		httpClient: &http.Client{
+			Timeout: config.Timeout,
+		},
+	}
+
+	client.config.Endpoint = strings.TrimSuffix(client.config.Endpoint, "/")
+
+	if "" == client.config.Region {
+		client.config.Region = defaultRegion
+	}
+
+	err = nil
+	return
+}
+
+// encodeQuery produces a query string encoded identically to the way signRequest()
+// will canonicalize it (url.Values.Encode() would, e.g., encode ' ' as '+').
+//
+func encodeQuery(query url.Values) (rawQuery string) {
+	var (
+		key        string
+		keys       []string
+		pairs      []string
+		queryValue string
+	)
+
+	keys = make([]string, 0, len(query))
+	for key = range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs = make([]string, 0, len(keys))
+	for _, key = range keys {
+		for _, queryValue = range query[key] {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(queryValue, true))
+		}
+	}
+
+	rawQuery = strings.Join(pairs, "&")
+
+	return
+}
+
+// doRequest issues a signed request. Any non-2xx response is returned as an *ErrorStruct.
+//
+func (client *Client) doRequest(method string, bucketName string, key string, query url.Values, headers map[string]string, body []byte) (responseHeaders http.Header, responseBody []byte, err error) {
+	var (
+		headerName   string
+		headerValue  string
+		httpRequest  *http.Request
+		httpResponse *http.Response
+		payloadHash  string
+		requestPath  string
+		requestURL   string
+	)
+
+	requestPath = "/" + bucketName
+	if "" != key {
+		requestPath += "/" + key
+	}
+
+	requestURL = client.config.Endpoint + uriEncode(requestPath, false)
+	if 0 < len(query) {
+		requestURL += "?" + encodeQuery(query)
+	}
+
+	httpRequest, err = http.NewRequest(method, requestURL, bytes.NewReader(body))
+	if nil != err {
+		return
+	}
+
+	for headerName, headerValue = range headers {
+		httpRequest.Header.Set(headerName, headerValue)
+	}
+
+	if 0 == len(body) {
+		payloadHash = emptyPayloadSHA256
+	} else {
+		payloadHash = payloadSHA256(body)
+	}
+
+	signRequest(httpRequest, payloadHash, client.config.AccessKeyID, client.config.SecretAccessKey, client.config.Region, time.Now())
+
+	httpResponse, err = client.httpClient.Do(httpRequest)
+	if nil != err {
+		return
+	}
+
+	responseBody, err = ioutil.ReadAll(httpResponse.Body)
+	_ = httpResponse.Body.Close()
+	if nil != err {
+		return
+	}
+
+	responseHeaders = httpResponse.Header
+
+	if (200 <= httpResponse.StatusCode) && (299 >= httpResponse.StatusCode) {
+		err = nil
+	} else {
+		err = newErrorStruct(httpResponse.StatusCode, responseBody)
+	}
+
+	return
+}
+
+func newErrorStruct(statusCode int, responseBody []byte) (errorStruct *ErrorStruct) {
+	var (
+		errorResponse errorResponseStruct
+	)
+
+	errorStruct = &ErrorStruct{
+		StatusCode: statusCode,
+		Code:       http.StatusText(statusCode),
+		Message:    "",
+	}
+
+	if (0 < len(responseBody)) && (nil == xml.Unmarshal(responseBody, &errorResponse)) {
+		errorStruct.Code = errorResponse.Code
+		errorStruct.Message = errorResponse.Message
+	}
+
+	return
+}
+
+// checkEmbeddedError examines the body of a successful CopyObject or
+// CompleteMultipartUpload response that, per S3 semantics, may nonetheless
+// report an error that occurred after the 200 OK status was sent.
+//
+func checkEmbeddedError(responseBody []byte) (err error) {
+	var (
+		errorResponse errorResponseStruct
+	)
+
+	if bytes.Contains(responseBody, []byte(xmlErrorElement)) && (nil == xml.Unmarshal(responseBody, &errorResponse)) {
+		err = &ErrorStruct{
+			StatusCode: http.StatusInternalServerError,
+			Code:       errorResponse.Code,
+			Message:    errorResponse.Message,
+		}
+	} else {
+		err = nil
+	}
+
+	return
+}
+
+func metadataToHeaders(metadata map[string]string, headers map[string]string) {
+	var (
+		name  string
+		value string
+	)
+
+	for name, value = range metadata {
+		headers[metadataPrefix+name] = value
+	}
+}
+
+func headersToMetadata(headers http.Header) (metadata map[string]string) {
+	var (
+		headerName   string
+		headerValues []string
+	)
+
+	metadata = make(map[string]string)
+
+	for headerName, headerValues = range headers {
+		headerName = http.CanonicalHeaderKey(headerName)
+		if strings.HasPrefix(headerName, metadataPrefix) && (0 < len(headerValues)) {
+			metadata[strings.ToLower(headerName[len(metadataPrefix):])] = headerValues[0]
+		}
+	}
+
+	return
+}
+
+func (client *Client) createBucket(bucketName string) (err error) {
+	var (
+		body []byte
+	)
+
+	if defaultRegion != client.config.Region {
+		body, err = xml.Marshal(&createBucketConfigurationStruct{LocationConstraint: client.config.Region})
+		if nil != err {
+			return
+		}
+	}
+
+	_, _, err = client.doRequest(http.MethodPut, bucketName, "", nil, nil, body)
+
+	return
+}
+
+func (client *Client) deleteBucket(bucketName string) (err error) {
+	_, _, err = client.doRequest(http.MethodDelete, bucketName, "", nil, nil, nil)
+	return
+}
+
+func (client *Client) headBucket(bucketName string) (err error) {
+	_, _, err = client.doRequest(http.MethodHead, bucketName, "", nil, nil, nil)
+	return
+}
+
+func (client *Client) listObjects(bucketName string, prefix string, delimiter string) (keys []string, commonPrefixes []string, err error) {
+	var (
+		listBucketResult listBucketResultStruct
+		query            url.Values
+		responseBody     []byte
+	)
+
+	keys = make([]string, 0)
+	commonPrefixes = make([]string, 0)
+
+	query = url.Values{}
+	query.Set("list-type", "2")
+	if "" != prefix {
+		query.Set("prefix", prefix)
+	}
+	if "" != delimiter {
+		query.Set("delimiter", delimiter)
+	}
+
+	for {
+		_, responseBody, err = client.doRequest(http.MethodGet, bucketName, "", query, nil, nil)
+		if nil != err {
+			return
+		}
+
+		listBucketResult = listBucketResultStruct{}
+
+		err = xml.Unmarshal(responseBody, &listBucketResult)
+		if nil != err {
+			return
+		}
+
+		for _, contents := range listBucketResult.Contents {
+			keys = append(keys, contents.Key)
+		}
+		for _, commonPrefix := range listBucketResult.CommonPrefixes {
+			commonPrefixes = append(commonPrefixes, commonPrefix.Prefix)
+		}
+
+		if !listBucketResult.IsTruncated {
+			err = nil
+			return
+		}
+
+		query.Set("continuation-token", listBucketResult.NextContinuationToken)
+	}
+}
+
+func (client *Client) putObject(bucketName string, key string, metadata map[string]string, body []byte) (err error) {
+	var (
+		headers = make(map[string]string)
+	)
+
+	metadataToHeaders(metadata, headers)
+
+	_, _, err = client.doRequest(http.MethodPut, bucketName, key, nil, headers, body)
+
+	return
+}
+
+func (client *Client) getObject(bucketName string, key string, rangeHeaderValue string) (metadata map[string]string, body []byte, err error) {
+	var (
+		headers         map[string]string
+		responseHeaders http.Header
+	)
+
+	if "" != rangeHeaderValue {
+		headers = map[string]string{"Range": rangeHeaderValue}
+	}
+
+	responseHeaders, body, err = client.doRequest(http.MethodGet, bucketName, key, nil, headers, nil)
+	if nil != err {
+		return
+	}
+
+	metadata = headersToMetadata(responseHeaders)
+
+	return
+}
+
+func (client *Client) headObject(bucketName string, key string) (metadata map[string]string, contentLength uint64, err error) {
+	var (
+		responseHeaders http.Header
+	)
+
+	responseHeaders, _, err = client.doRequest(http.MethodHead, bucketName, key, nil, nil, nil)
+	if nil != err {
+		return
+	}
+
+	metadata = headersToMetadata(responseHeaders)
+
+	contentLength, err = strconv.ParseUint(responseHeaders.Get("Content-Length"), 10, 64)
+	if nil != err {
+		err = fmt.Errorf("HEAD %s/%s returned unparseable Content-Length \"%s\"", bucketName, key, responseHeaders.Get("Content-Length"))
+		return
+	}
+
+	return
+}
+
+func (client *Client) deleteObject(bucketName string, key string) (err error) {
+	_, _, err = client.doRequest(http.MethodDelete, bucketName, key, nil, nil, nil)
+	return
+}
+
+func (client *Client) copyObject(srcBucketName string, srcKey string, dstBucketName string, dstKey string, metadata map[string]string) (err error) {
+	var (
+		headers      = make(map[string]string)
+		responseBody []byte
+	)
+
+	headers["X-Amz-Copy-Source"] = uriEncode("/"+srcBucketName+"/"+srcKey, false)
+
+	if nil == metadata {
+		headers["X-Amz-Metadata-Directive"] = "COPY"
+	} else {
+		headers["X-Amz-Metadata-Directive"] = "REPLACE"
+		metadataToHeaders(metadata, headers)
+	}
+
+	_, responseBody, err = client.doRequest(http.MethodPut, dstBucketName, dstKey, nil, headers, nil)
+	if nil != err {
+		return
+	}
+
+	err = checkEmbeddedError(responseBody)
+
+	return
+}
+
+func (client *Client) createMultipartUpload(bucketName string, key string, metadata map[string]string) (uploadID string, err error) {
+	var (
+		headers                       = make(map[string]string)
+		initiateMultipartUploadResult initiateMultipartUploadResultStruct
+		responseBody                  []byte
+	)
+
+	metadataToHeaders(metadata, headers)
+
+	_, responseBody, err = client.doRequest(http.MethodPost, bucketName, key, url.Values{"uploads": []string{""}}, headers, nil)
+	if nil != err {
+		return
+	}
+
+	err = xml.Unmarshal(responseBody, &initiateMultipartUploadResult)
+	if nil != err {
+		return
+	}
+
+	uploadID = initiateMultipartUploadResult.UploadID
+
+	return
+}
+
+func (client *Client) uploadPart(bucketName string, key string, uploadID string, partNumber uint32, body []byte) (eTag string, err error) {
+	var (
+		query           url.Values
+		responseHeaders http.Header
+	)
+
+	query = url.Values{}
+	query.Set("partNumber", strconv.FormatUint(uint64(partNumber), 10))
+	query.Set("uploadId", uploadID)
+
+	responseHeaders, _, err = client.doRequest(http.MethodPut, bucketName, key, query, nil, body)
+	if nil != err {
+		return
+	}
+
+	eTag = responseHeaders.Get("ETag")
+
+	return
+}
+
+func (client *Client) completeMultipartUpload(bucketName string, key string, uploadID string, eTags []string) (err error) {
+	var (
+		body                    []byte
+		completeMultipartUpload completeMultipartUploadStruct
+		eTag                    string
+		eTagIndex               int
+		responseBody            []byte
+	)
+
+	completeMultipartUpload.Parts = make([]completedPartStruct, len(eTags))
+
+	for eTagIndex, eTag = range eTags {
+		completeMultipartUpload.Parts[eTagIndex].PartNumber = uint32(eTagIndex + 1)
+		completeMultipartUpload.Parts[eTagIndex].ETag = eTag
+	}
+
+	body, err = xml.Marshal(&completeMultipartUpload)
+	if nil != err {
+		return
+	}
+
+	_, responseBody, err = client.doRequest(http.MethodPost, bucketName, key, url.Values{"uploadId": []string{uploadID}}, nil, body)
+	if nil != err {
+		return
+	}
+
+	err = checkEmbeddedError(responseBody)
+
+	return
+}
+
+func (client *Client) abortMultipartUpload(bucketName string, key string, uploadID string) (err error) {
+	_, _, err = client.doRequest(http.MethodDelete, bucketName, key, url.Values{"uploadId": []string{uploadID}}, nil, nil)
+	return
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package s3client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4 request signing as described at:
+//
+//   https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
+//
+// Signed headers are Host, Content-MD5, Content-Type, Range, and all X-Amz-* headers.
+
+const (
+	signAlgorithm       = "AWS4-HMAC-SHA256"
+	signDateFormat      = "20060102"
+	signTimeFormat      = "20060102T150405Z"
+	signService         = "s3"
+	signTerminator      = "aws4_request"
+	emptyPayloadSHA256  = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	unsignedPayloadHash = "UNSIGNED-PAYLOAD"
+)
+
+func payloadSHA256(payload []byte) (payloadHash string) {
+	var (
+		sum [sha256.Size]byte
+	)
+
+	sum = sha256.Sum256(payload)
+	payloadHash = hex.EncodeToString(sum[:])
+
+	return
+}
+
+func hmacSHA256(key []byte, data string) (mac []byte) {
+	var (
+		h = hmac.New(sha256.New, key)
+	)
+
+	_, _ = h.Write([]byte(data))
+	mac = h.Sum(nil)
+
+	return
+}
+
+// uriEncode percent-encodes all but the unreserved characters of s. If
+// encodeSlash is false, '/' is also left as is (as required for the path).
+//
+func uriEncode(s string, encodeSlash bool) (encoded string) {
+	var (
+		b       byte
+		builder strings.Builder
+		i       int
+	)
+
+	for i = 0; i < len(s); i++ {
+		b = s[i]
+		if (('A' <= b) && (b <= 'Z')) || (('a' <= b) && (b <= 'z')) || (('0' <= b) && (b <= '9')) || ('-' == b) || ('_' == b) || ('.' == b) || ('~' == b) || (('/' == b) && !encodeSlash) {
+			builder.WriteByte(b)
+		} else {
+			builder.WriteByte('%')
+			builder.WriteByte("0123456789ABCDEF"[b>>4])
+			builder.WriteByte("0123456789ABCDEF"[b&0xF])
+		}
+	}
+
+	encoded = builder.String()
+
+	return
+}
+
+func canonicalQueryString(request *http.Request) (canonicalQuery string) {
+	var (
+		key        string
+		keys       []string
+		pairs      []string
+		queryValue string
+		query      = request.URL.Query()
+	)
+
+	keys = make([]string, 0, len(query))
+	for key = range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs = make([]string, 0, len(keys))
+	for _, key = range keys {
+		for _, queryValue = range query[key] {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(queryValue, true))
+		}
+	}
+
+	canonicalQuery = strings.Join(pairs, "&")
+
+	return
+}
+
+func isSignedHeader(lowerCaseHeaderName string) (signed bool) {
+	switch lowerCaseHeaderName {
+	case "content-md5", "content-type", "range":
+		signed = true
+	default:
+		signed = strings.HasPrefix(lowerCaseHeaderName, "x-amz-")
+	}
+
+	return
+}
+
+// signRequest adds the X-Amz-Date, X-Amz-Content-Sha256, and Authorization
+// headers to request. The request's URL.Path must already be in the form
+// to be sent (i.e. not yet percent-encoded).
+//
+func signRequest(request *http.Request, payloadHash string, accessKeyID string, secretAccessKey string, region string, now time.Time) {
+	var (
+		amzDate          string
+		canonicalHeaders strings.Builder
+		canonicalRequest string
+		credentialScope  string
+		headerName       string
+		headerNames      []string
+		headerValues     map[string]string
+		host             string
+		signature        string
+		signedHeaders    string
+		signingKey       []byte
+		stringToSign     string
+		values           []string
+	)
+
+	now = now.UTC()
+	amzDate = now.Format(signTimeFormat)
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host = request.Host
+	if "" == host {
+		host = request.URL.Host
+	}
+
+	headerValues = make(map[string]string)
+	headerValues["host"] = host
+	headerNames = []string{"host"}
+
+	for headerName, values = range request.Header {
+		headerName = strings.ToLower(headerName)
+		if isSignedHeader(headerName) {
+			headerValues[headerName] = strings.TrimSpace(strings.Join(values, ","))
+			headerNames = append(headerNames, headerName)
+		}
+	}
+
+	sort.Strings(headerNames)
+
+	for _, headerName = range headerNames {
+		canonicalHeaders.WriteString(headerName)
+		canonicalHeaders.WriteByte(':')
+		canonicalHeaders.WriteString(headerValues[headerName])
+		canonicalHeaders.WriteByte('\n')
+	}
+
+	signedHeaders = strings.Join(headerNames, ";")
+
+	canonicalRequest = request.Method + "\n" +
+		uriEncode(request.URL.Path, false) + "\n" +
+		canonicalQueryString(request) + "\n" +
+		canonicalHeaders.String() + "\n" +
+		signedHeaders + "\n" +
+		payloadHash
+
+	credentialScope = now.Format(signDateFormat) + "/" + region + "/" + signService + "/" + signTerminator
+
+	stringToSign = signAlgorithm + "\n" +
+		amzDate + "\n" +
+		credentialScope + "\n" +
+		payloadSHA256([]byte(canonicalRequest))
+
+	signingKey = hmacSHA256([]byte("AWS4"+secretAccessKey), now.Format(signDateFormat))
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, signService)
+	signingKey = hmacSHA256(signingKey, signTerminator)
+
+	signature = hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", signAlgorithm+" Credential="+accessKeyID+"/"+credentialScope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
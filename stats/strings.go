@@ -32,6 +32,9 @@ var (
 	FileWritebackMissOps         = "proxyfs.inode.file.writeback.miss.operations"
 	FileReadcacheHitOps          = "proxyfs.inode.file.readcache.hit.operations"
 	FileReadcacheMissOps         = "proxyfs.inode.file.readcache.miss.operations"
+	FileReadaheadPrefetchOps     = "proxyfs.inode.file.readahead.prefetch.operations"
+	FileReadaheadHitOps          = "proxyfs.inode.file.readahead.hit.operations"
+	FileReadaheadWasteOps        = "proxyfs.inode.file.readahead.waste.operations"
 	FileReadOps                  = "proxyfs.inode.file.read.operations"
 	FileReadOps4K                = "proxyfs.inode.file.read.operations.size-up-to-4KB"
 	FileReadOps8K                = "proxyfs.inode.file.read.operations.size-4KB-to-8KB"
@@ -30,13 +30,14 @@ func accountDeleteWithRetry(accountName string) (err error) {
 		retryObj *RetryCtrl = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
 		opname   string     = fmt.Sprintf("swiftclient.accountDelete(\"%v\")", accountName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftAccountDeleteRetryOps,
 			retrySuccessCnt:   &stats.SwiftAccountDeleteRetrySuccessOps,
-			clientRequestTime: &globals.AccountDeleteUsec,
-			clientFailureCnt:  &globals.AccountDeleteFailure,
-			swiftRequestTime:  &globals.SwiftAccountDeleteUsec,
-			swiftRetryOps:     &globals.SwiftAccountDeleteRetryOps,
+			clientRequestTime: &volumeStats.AccountDeleteUsec,
+			clientFailureCnt:  &volumeStats.AccountDeleteFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountDeleteUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountDeleteRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -105,16 +106,18 @@ func accountGetWithRetry(accountName string) (headers map[string][]string, conta
 		statnm             requestStatistics
 		toAddContainerList []string
 		toAddHeaders       map[string][]string
+		volumeStats        *volumeStatsStruct
 	)
 
 	retryObj = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
+	volumeStats = fetchVolumeStats(accountName)
 	statnm = requestStatistics{
 		retryCnt:          &stats.SwiftAccountGetRetryOps,
 		retrySuccessCnt:   &stats.SwiftAccountGetRetrySuccessOps,
-		clientRequestTime: &globals.AccountGetUsec,
-		clientFailureCnt:  &globals.AccountGetFailure,
-		swiftRequestTime:  &globals.SwiftAccountGetUsec,
-		swiftRetryOps:     &globals.SwiftAccountGetRetryOps,
+		clientRequestTime: &volumeStats.AccountGetUsec,
+		clientFailureCnt:  &volumeStats.AccountGetFailure,
+		swiftRequestTime:  &volumeStats.SwiftAccountGetUsec,
+		swiftRetryOps:     &volumeStats.SwiftAccountGetRetryOps,
 	}
 
 	request := func() (bool, error) {
@@ -220,13 +223,14 @@ func accountHeadWithRetry(accountName string) (map[string][]string, error) {
 		retryObj *RetryCtrl = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
 		opname   string     = fmt.Sprintf("swiftclient.accountHead(\"%v\")", accountName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftAccountHeadRetryOps,
 			retrySuccessCnt:   &stats.SwiftAccountHeadRetrySuccessOps,
-			clientRequestTime: &globals.AccountHeadUsec,
-			clientFailureCnt:  &globals.AccountHeadFailure,
-			swiftRequestTime:  &globals.SwiftAccountHeadUsec,
-			swiftRetryOps:     &globals.SwiftAccountHeadRetryOps,
+			clientRequestTime: &volumeStats.AccountHeadUsec,
+			clientFailureCnt:  &volumeStats.AccountHeadFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountHeadUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountHeadRetryOps,
 		}
 	)
 	err := retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -295,14 +299,15 @@ func accountPostWithRetry(accountName string, requestHeaders map[string][]string
 	var (
 		retryObj *RetryCtrl = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
 
-		opname string            = fmt.Sprintf("swiftclient.accountPost(\"%v\")", accountName)
-		statnm requestStatistics = requestStatistics{
+		opname      string             = fmt.Sprintf("swiftclient.accountPost(\"%v\")", accountName)
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftAccountPostRetryOps,
 			retrySuccessCnt:   &stats.SwiftAccountPostRetrySuccessOps,
-			clientRequestTime: &globals.AccountPostUsec,
-			clientFailureCnt:  &globals.AccountPostFailure,
-			swiftRequestTime:  &globals.SwiftAccountPostUsec,
-			swiftRetryOps:     &globals.SwiftAccountPostRetryOps,
+			clientRequestTime: &volumeStats.AccountPostUsec,
+			clientFailureCnt:  &volumeStats.AccountPostFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountPostUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountPostRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -392,13 +397,14 @@ func accountPutWithRetry(accountName string, requestHeaders map[string][]string)
 		retryObj *RetryCtrl = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
 		opname   string     = fmt.Sprintf("swiftclient.accountPut(\"%v\")", accountName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftAccountPutRetryOps,
 			retrySuccessCnt:   &stats.SwiftAccountPutRetrySuccessOps,
-			clientRequestTime: &globals.AccountPutUsec,
-			clientFailureCnt:  &globals.AccountPutFailure,
-			swiftRequestTime:  &globals.SwiftAccountPutUsec,
-			swiftRetryOps:     &globals.SwiftAccountPutRetryOps,
+			clientRequestTime: &volumeStats.AccountPutUsec,
+			clientFailureCnt:  &volumeStats.AccountPutFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountPutUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountPutRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -64,107 +64,174 @@ func SetStarvationCallbackFunc(starvationCallback StarvationCallbackFunc) {
 	globals.starvationCallback = starvationCallback
 }
 
+// AccountBackend returns the name (e.g. BackendNameSwift) of the object store backend holding the named Account.
+func AccountBackend(accountName string) (backendName string) {
+	backendName = fetchBackend(accountName).name()
+	return
+}
+
 // AccountDelete invokes HTTP DELETE on the named Swift Account.
 func AccountDelete(accountName string) (err error) {
-	return accountDeleteWithRetry(accountName)
+	span := startSpan("AccountDelete", accountName, "", "")
+	err = fetchBackend(accountName).accountDelete(accountName)
+	span.EndWithError(err)
+	return
 }
 
 // AccountGet invokes HTTP GET on the named Swift Account.
 func AccountGet(accountName string) (headers map[string][]string, containerList []string, err error) {
-	return accountGetWithRetry(accountName)
+	span := startSpan("AccountGet", accountName, "", "")
+	headers, containerList, err = fetchBackend(accountName).accountGet(accountName)
+	span.EndWithError(err)
+	return
 }
 
 // AccountHead invokes HTTP HEAD on the named Swift Account.
 func AccountHead(accountName string) (headers map[string][]string, err error) {
-	return accountHeadWithRetry(accountName)
+	span := startSpan("AccountHead", accountName, "", "")
+	headers, err = fetchBackend(accountName).accountHead(accountName)
+	span.EndWithError(err)
+	return
 }
 
 // AccountPost invokes HTTP POST on the named Swift Account.
 func AccountPost(accountName string, headers map[string][]string) (err error) {
-	return accountPostWithRetry(accountName, headers)
+	span := startSpan("AccountPost", accountName, "", "")
+	err = fetchBackend(accountName).accountPost(accountName, headers)
+	span.EndWithError(err)
+	return
 }
 
 // AccountPut invokes HTTP PUT on the named Swift Account.
 func AccountPut(accountName string, headers map[string][]string) (err error) {
-	return accountPutWithRetry(accountName, headers)
+	span := startSpan("AccountPut", accountName, "", "")
+	err = fetchBackend(accountName).accountPut(accountName, headers)
+	span.EndWithError(err)
+	return
 }
 
 // ContainerDelete invokes HTTP DELETE on the named Swift Container.
 func ContainerDelete(accountName string, containerName string) (err error) {
-	return containerDeleteWithRetry(accountName, containerName)
+	span := startSpan("ContainerDelete", accountName, containerName, "")
+	err = fetchBackend(accountName).containerDelete(accountName, containerName)
+	span.EndWithError(err)
+	return
 }
 
 // ContainerGet invokes HTTP GET on the named Swift Container.
 func ContainerGet(accountName string, containerName string) (headers map[string][]string, objectList []string, err error) {
-	return containerGetWithRetry(accountName, containerName)
+	span := startSpan("ContainerGet", accountName, containerName, "")
+	headers, objectList, err = fetchBackend(accountName).containerGet(accountName, containerName)
+	span.EndWithError(err)
+	return
 }
 
 // ContainerHead invokes HTTP HEAD on the named Swift Container.
 func ContainerHead(accountName string, containerName string) (headers map[string][]string, err error) {
-	return containerHeadWithRetry(accountName, containerName)
+	span := startSpan("ContainerHead", accountName, containerName, "")
+	headers, err = fetchBackend(accountName).containerHead(accountName, containerName)
+	span.EndWithError(err)
+	return
 }
 
 // ContainerPost invokes HTTP POST on the named Swift Container.
 func ContainerPost(accountName string, containerName string, headers map[string][]string) (err error) {
-	return containerPostWithRetry(accountName, containerName, headers)
+	span := startSpan("ContainerPost", accountName, containerName, "")
+	err = fetchBackend(accountName).containerPost(accountName, containerName, headers)
+	span.EndWithError(err)
+	return
 }
 
 // ContainerPut invokes HTTP PUT on the named Swift Container.
 func ContainerPut(accountName string, containerName string, headers map[string][]string) (err error) {
-	return containerPutWithRetry(accountName, containerName, headers)
+	span := startSpan("ContainerPut", accountName, containerName, "")
+	err = fetchBackend(accountName).containerPut(accountName, containerName, headers)
+	span.EndWithError(err)
+	return
 }
 
 // ObjectContentLength invokes HTTP HEAD on the named Swift Object and returns value of Content-Length Header.
 func ObjectContentLength(accountName string, containerName string, objectName string) (length uint64, err error) {
-	return objectContentLengthWithRetry(accountName, containerName, objectName)
+	span := startSpan("ObjectContentLength", accountName, containerName, objectName)
+	length, err = fetchBackend(accountName).objectContentLength(accountName, containerName, objectName)
+	span.EndWithError(err)
+	return
 }
 
 // ObjectCopy asynchronously creates a copy of the named Swift Object Source called the named Swift Object Destination.
+// The Source and Destination may reside in Accounts held by different object store backends.
 func ObjectCopy(srcAccountName string, srcContainerName string, srcObjectName string, dstAccountName string, dstContainerName string, dstObjectName string, chunkedCopyContext ChunkedCopyContext) (err error) {
-	return objectCopy(srcAccountName, srcContainerName, srcObjectName, dstAccountName, dstContainerName, dstObjectName, chunkedCopyContext)
+	span := startSpan("ObjectCopy", srcAccountName, srcContainerName, srcObjectName)
+	err = objectCopy(srcAccountName, srcContainerName, srcObjectName, dstAccountName, dstContainerName, dstObjectName, chunkedCopyContext)
+	span.EndWithError(err)
+	return
 }
 
 // ObjectDelete invokes HTTP DELETE on the named Swift Object.
 func ObjectDelete(accountName string, containerName string, objectName string, operationOptions OperationOptions) (err error) {
-	return objectDelete(accountName, containerName, objectName, operationOptions)
+	span := startSpan("ObjectDelete", accountName, containerName, objectName)
+	err = fetchBackend(accountName).objectDelete(accountName, containerName, objectName, operationOptions)
+	span.EndWithError(err)
+	return
 }
 
 // ObjectFetchChunkedPutContext provisions a context to use for an HTTP PUT using "chunked" Transfer-Encoding on the named Swift Object.
 // The useReserveForVolumeName string argument should be set to "" if normal chunked connection pool is to be used.
 // If a reserved connection is to be used, note that there is only one per useReserveForVolumeName allowed at a time.
 func ObjectFetchChunkedPutContext(accountName string, containerName string, objectName string, useReserveForVolumeName string) (chunkedPutContext ChunkedPutContext, err error) {
-	return objectFetchChunkedPutContextWithRetry(accountName, containerName, objectName, useReserveForVolumeName)
+	span := startSpan("ObjectFetchChunkedPutContext", accountName, containerName, objectName)
+	chunkedPutContext, err = fetchBackend(accountName).objectFetchChunkedPutContext(accountName, containerName, objectName, useReserveForVolumeName)
+	span.EndWithError(err)
+	return
 }
 
 // ObjectGet invokes HTTP GET on the named Swift Object for the specified byte range.
 func ObjectGet(accountName string, containerName string, objectName string, offset uint64, length uint64) (buf []byte, err error) {
-	return objectGetWithRetry(accountName, containerName, objectName, offset, length)
+	span := startSpan("ObjectGet", accountName, containerName, objectName)
+	buf, err = fetchBackend(accountName).objectGet(accountName, containerName, objectName, offset, length)
+	span.EndWithError(err)
+	return
 }
 
 // ObjectHead invokes HTTP HEAD on the named Swift Object.
 func ObjectHead(accountName string, containerName string, objectName string) (headers map[string][]string, err error) {
-	return objectHeadWithRetry(accountName, containerName, objectName)
+	span := startSpan("ObjectHead", accountName, containerName, objectName)
+	headers, err = fetchBackend(accountName).objectHead(accountName, containerName, objectName)
+	span.EndWithError(err)
+	return
 }
 
 // ObjectLoad invokes HTTP GET on the named Swift Object for the entire object.
 func ObjectLoad(accountName string, containerName string, objectName string) (buf []byte, err error) {
-	return objectLoadWithRetry(accountName, containerName, objectName)
+	span := startSpan("ObjectLoad", accountName, containerName, objectName)
+	buf, err = fetchBackend(accountName).objectLoad(accountName, containerName, objectName)
+	span.EndWithError(err)
+	return
 }
 
 // ObjectPost invokes HTTP POST on the named Swift Object.
 func ObjectPost(accountName string, containerName string, objectName string, headers map[string][]string) (err error) {
-	return objectPostWithRetry(accountName, containerName, objectName, headers)
+	span := startSpan("ObjectPost", accountName, containerName, objectName)
+	err = fetchBackend(accountName).objectPost(accountName, containerName, objectName, headers)
+	span.EndWithError(err)
+	return
 }
 
 // ObjectRead invokes HTTP GET on the named Swift Object at the specified offset filling in the specified byte slice.
 // Note that the byte slice must already have the desired length even though those bytes will be overwritten.
 func ObjectRead(accountName string, containerName string, objectName string, offset uint64, buf []byte) (len uint64, err error) {
-	return objectReadWithRetry(accountName, containerName, objectName, offset, buf)
+	span := startSpan("ObjectRead", accountName, containerName, objectName)
+	len, err = fetchBackend(accountName).objectRead(accountName, containerName, objectName, offset, buf)
+	span.EndWithError(err)
+	return
 }
 
 // ObjectTail invokes HTTP GET on the named Swift Object with a byte range selecting the specified length of trailing bytes.
 func ObjectTail(accountName string, containerName string, objectName string, length uint64) (buf []byte, err error) {
-	return objectTailWithRetry(accountName, containerName, objectName, length)
+	span := startSpan("ObjectTail", accountName, containerName, objectName)
+	buf, err = fetchBackend(accountName).objectTail(accountName, containerName, objectName, length)
+	span.EndWithError(err)
+	return
 }
 
 // Number of chunked connections that are idle
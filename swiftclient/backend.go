@@ -0,0 +1,239 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+// Object Store Backend selection
+
+package swiftclient
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/logger"
+)
+
+// backendIf is implemented by each supported object store. Callers of the
+// package API always name things in terms of Swift Accounts, Containers, and
+// Objects... it is up to each backend to map these onto its own data model.
+//
+type backendIf interface {
+	name() (backendName string)
+	accountDelete(accountName string) (err error)
+	accountGet(accountName string) (headers map[string][]string, containerList []string, err error)
+	accountHead(accountName string) (headers map[string][]string, err error)
+	accountPost(accountName string, headers map[string][]string) (err error)
+	accountPut(accountName string, headers map[string][]string) (err error)
+	containerDelete(accountName string, containerName string) (err error)
+	containerGet(accountName string, containerName string) (headers map[string][]string, objectList []string, err error)
+	containerHead(accountName string, containerName string) (headers map[string][]string, err error)
+	containerPost(accountName string, containerName string, headers map[string][]string) (err error)
+	containerPut(accountName string, containerName string, headers map[string][]string) (err error)
+	objectContentLength(accountName string, containerName string, objectName string) (length uint64, err error)
+	objectDelete(accountName string, containerName string, objectName string, operationOptions OperationOptions) (err error)
+	objectFetchChunkedPutContext(accountName string, containerName string, objectName string, useReserveForVolumeName string) (chunkedPutContext ChunkedPutContext, err error)
+	objectGet(accountName string, containerName string, objectName string, offset uint64, length uint64) (buf []byte, err error)
+	objectHead(accountName string, containerName string, objectName string) (headers map[string][]string, err error)
+	objectLoad(accountName string, containerName string, objectName string) (buf []byte, err error)
+	objectPost(accountName string, containerName string, objectName string, headers map[string][]string) (err error)
+	objectRead(accountName string, containerName string, objectName string, offset uint64, buf []byte) (len uint64, err error)
+	objectTail(accountName string, containerName string, objectName string, length uint64) (buf []byte, err error)
+}
+
+// Names of the supported object store backends (see AccountBackend())
+const (
+	BackendNamePosix = "posix"
+	BackendNameS3    = "s3"
+	BackendNameSwift = "swift"
+)
+
+// swiftBackendStruct is the backend used for any Account not mapped to another
+// backend. It simply invokes the Swift-specific implementation.
+//
+type swiftBackendStruct struct{}
+
+var swiftBackend swiftBackendStruct
+
+// fetchBackend returns the backend holding the named Account.
+//
+func fetchBackend(accountName string) (backend backendIf) {
+	var (
+		ok bool
+	)
+
+	globals.backendMapMutex.Lock()
+	backend, ok = globals.backendMap[accountName]
+	globals.backendMapMutex.Unlock()
+
+	if !ok {
+		backend = &swiftBackend
+	}
+
+	return
+}
+
+// configureBackends (re)computes the mapping of Accounts to backends from the
+// ObjectStoreBackend setting of every [Volume:<VolumeName>] section of confMap.
+// Note that this includes volumes not (yet) part of any VolumeGroup as tools
+// like mkproxyfs access a volume's Account without serving it.
+//
+func configureBackends(confMap conf.ConfMap) (err error) {
+	var (
+		accountName string
+		backend     backendIf
+		backendMap  map[string]backendIf
+		backendName string
+		ok          bool
+		sectionName string
+	)
+
+	backendMap = make(map[string]backendIf)
+
+	for sectionName = range confMap {
+		if !strings.HasPrefix(sectionName, "Volume:") {
+			continue
+		}
+
+		backendName, err = confMap.FetchOptionValueString(sectionName, "ObjectStoreBackend")
+		if nil != err {
+			backendName = BackendNameSwift // If not present, default to Swift
+		}
+
+		if BackendNameSwift == backendName {
+			continue
+		}
+
+		accountName, err = confMap.FetchOptionValueString(sectionName, "AccountName")
+		if nil != err {
+			return
+		}
+		_, ok = backendMap[accountName]
+		if ok {
+			err = fmt.Errorf("%s.AccountName (%s) shared with another non-Swift backed volume", sectionName, accountName)
+			return
+		}
+
+		switch backendName {
+		case BackendNamePosix:
+			backend, err = newPosixBackend(confMap, sectionName, accountName)
+		case BackendNameS3:
+			backend, err = newS3Backend(confMap, sectionName, accountName)
+		default:
+			err = fmt.Errorf("%s.ObjectStoreBackend (%s) must be one of \"%s\", \"%s\", or \"%s\"", sectionName, backendName, BackendNameSwift, BackendNameS3, BackendNamePosix)
+		}
+		if nil != err {
+			return
+		}
+
+		logger.Infof("%s.AccountName (%s) mapped to %s backend", sectionName, accountName, backendName)
+
+		backendMap[accountName] = backend
+	}
+
+	globals.backendMapMutex.Lock()
+	globals.backendMap = backendMap
+	globals.backendMapMutex.Unlock()
+
+	err = nil
+	return
+}
+
+// backendRequestWithRetry performs request via RetryCtrl.RequestWithRetry() using
+// the retry settings for Object operations if isObjectOp is set or those for
+// Account and Container operations otherwise.
+//
+func backendRequestWithRetry(isObjectOp bool, opname string, statnm *requestStatistics, request func() (bool, error)) (err error) {
+	var (
+		retryObj *RetryCtrl
+	)
+
+	if isObjectOp {
+		retryObj = NewRetryCtrl(globals.retryLimitObject, globals.retryDelayObject, globals.retryExpBackoffObject)
+	} else {
+		retryObj = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
+	}
+
+	err = retryObj.RequestWithRetry(request, &opname, statnm)
+
+	return
+}
+
+func (swiftBackend *swiftBackendStruct) name() (backendName string) {
+	return BackendNameSwift
+}
+
+func (swiftBackend *swiftBackendStruct) accountDelete(accountName string) (err error) {
+	return accountDeleteWithRetry(accountName)
+}
+
+func (swiftBackend *swiftBackendStruct) accountGet(accountName string) (headers map[string][]string, containerList []string, err error) {
+	return accountGetWithRetry(accountName)
+}
+
+func (swiftBackend *swiftBackendStruct) accountHead(accountName string) (headers map[string][]string, err error) {
+	return accountHeadWithRetry(accountName)
+}
+
+func (swiftBackend *swiftBackendStruct) accountPost(accountName string, headers map[string][]string) (err error) {
+	return accountPostWithRetry(accountName, headers)
+}
+
+func (swiftBackend *swiftBackendStruct) accountPut(accountName string, headers map[string][]string) (err error) {
+	return accountPutWithRetry(accountName, headers)
+}
+
+func (swiftBackend *swiftBackendStruct) containerDelete(accountName string, containerName string) (err error) {
+	return containerDeleteWithRetry(accountName, containerName)
+}
+
+func (swiftBackend *swiftBackendStruct) containerGet(accountName string, containerName string) (headers map[string][]string, objectList []string, err error) {
+	return containerGetWithRetry(accountName, containerName)
+}
+
+func (swiftBackend *swiftBackendStruct) containerHead(accountName string, containerName string) (headers map[string][]string, err error) {
+	return containerHeadWithRetry(accountName, containerName)
+}
+
+func (swiftBackend *swiftBackendStruct) containerPost(accountName string, containerName string, headers map[string][]string) (err error) {
+	return containerPostWithRetry(accountName, containerName, headers)
+}
+
+func (swiftBackend *swiftBackendStruct) containerPut(accountName string, containerName string, headers map[string][]string) (err error) {
+	return containerPutWithRetry(accountName, containerName, headers)
+}
+
+func (swiftBackend *swiftBackendStruct) objectContentLength(accountName string, containerName string, objectName string) (length uint64, err error) {
+	return objectContentLengthWithRetry(accountName, containerName, objectName)
+}
+
+func (swiftBackend *swiftBackendStruct) objectDelete(accountName string, containerName string, objectName string, operationOptions OperationOptions) (err error) {
+	return objectDelete(accountName, containerName, objectName, operationOptions)
+}
+
+func (swiftBackend *swiftBackendStruct) objectFetchChunkedPutContext(accountName string, containerName string, objectName string, useReserveForVolumeName string) (chunkedPutContext ChunkedPutContext, err error) {
+	return objectFetchChunkedPutContextWithRetry(accountName, containerName, objectName, useReserveForVolumeName)
+}
+
+func (swiftBackend *swiftBackendStruct) objectGet(accountName string, containerName string, objectName string, offset uint64, length uint64) (buf []byte, err error) {
+	return objectGetWithRetry(accountName, containerName, objectName, offset, length)
+}
+
+func (swiftBackend *swiftBackendStruct) objectHead(accountName string, containerName string, objectName string) (headers map[string][]string, err error) {
+	return objectHeadWithRetry(accountName, containerName, objectName)
+}
+
+func (swiftBackend *swiftBackendStruct) objectLoad(accountName string, containerName string, objectName string) (buf []byte, err error) {
+	return objectLoadWithRetry(accountName, containerName, objectName)
+}
+
+func (swiftBackend *swiftBackendStruct) objectPost(accountName string, containerName string, objectName string, headers map[string][]string) (err error) {
+	return objectPostWithRetry(accountName, containerName, objectName, headers)
+}
+
+func (swiftBackend *swiftBackendStruct) objectRead(accountName string, containerName string, objectName string, offset uint64, buf []byte) (len uint64, err error) {
+	return objectReadWithRetry(accountName, containerName, objectName, offset, buf)
+}
+
+func (swiftBackend *swiftBackendStruct) objectTail(accountName string, containerName string, objectName string, length uint64) (buf []byte, err error) {
+	return objectTailWithRetry(accountName, containerName, objectName, length)
+}
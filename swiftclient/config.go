@@ -87,40 +87,60 @@ type globalsStruct struct {
 	chaosCloseChunkFailureRate      uint64 // set only during testing
 	chaosOpenConnectionFailureRate  uint32 // set only during testing
 
-	// statistics for requests to swiftclient
-	AccountDeleteUsec              bucketstats.BucketLog2Round     // bucketized by time
-	AccountGetUsec                 bucketstats.BucketLog2Round     // bucketized by time
-	AccountHeadUsec                bucketstats.BucketLog2Round     // bucketized by time
-	AccountPostUsec                bucketstats.BucketLog2Round     // bucketized by time
-	AccountPutUsec                 bucketstats.BucketLog2Round     // bucketized by time
-	ContainerDeleteUsec            bucketstats.BucketLog2Round     // bucketized by time
-	ContainerGetUsec               bucketstats.BucketLog2Round     // bucketized by time
-	ContainerHeadUsec              bucketstats.BucketLog2Round     // bucketized by time
-	ContainerPostUsec              bucketstats.BucketLog2Round     // bucketized by time
-	ContainerPutUsec               bucketstats.BucketLog2Round     // bucketized by time
-	ObjectContentLengthUsec        bucketstats.BucketLog2Round     // bucketized by time
-	ObjectCopyUsec                 bucketstats.BucketLog2Round     // bucketized by time
-	ObjectDeleteUsec               bucketstats.BucketLog2Round     // bucketized by time
-	ObjectGetUsec                  bucketstats.BucketLog2Round     // bucketized by time
-	ObjectGetBytes                 bucketstats.BucketLog2Round     // bucketized by byte count
-	ObjectHeadUsec                 bucketstats.BucketLog2Round     // bucketized by time
-	ObjectLoadUsec                 bucketstats.BucketLog2Round     // bucketized by time
-	ObjectLoadBytes                bucketstats.BucketLog2Round     // bucketized by byte count
-	ObjectPostUsec                 bucketstats.BucketLog2Round     // bucketized by time
-	ObjectReadUsec                 bucketstats.BucketLog2Round     // bucketized by time
-	ObjectReadBytes                bucketstats.BucketLog2Round     // bucketized by byte count
-	ObjectTailUsec                 bucketstats.BucketLog2Round     // bucketized by time
-	ObjectTailBytes                bucketstats.BucketLog2Round     // bucketized by byte count
+	// object store backends for Accounts not held in Swift
+	backendMap      map[string]backendIf // Key: AccountName
+	backendMapMutex trackedlock.Mutex
+
+	// statistics for the Account of each served volume
+	volumeStatsMap      map[string]*volumeStatsStruct // Key: AccountName
+	volumeStatsMapMutex trackedlock.RWMutex
+
+	// aggregate statistics for the Accounts of every volume (served or not)
+	volumeStatsStruct
+
+	// statistics for the connection pools
 	ObjectNonChunkedFreeConnection bucketstats.BucketLogRoot2Round // free non-chunked connections (at acquire time)
-	ObjectPutCtxtFetchUsec         bucketstats.BucketLog2Round     // bucketized by time
 	ObjectPutCtxtFreeConnection    bucketstats.BucketLogRoot2Round // free chunked put connections (at acquite time)
-	ObjectPutCtxtBytesPut          bucketstats.Total               // number of calls to BytesPut() query
-	ObjectPutCtxtCloseUsec         bucketstats.BucketLog2Round     // bucketized by time
-	ObjectPutCtxtReadBytes         bucketstats.BucketLog2Round     // bucketized by bytes read
-	ObjectPutCtxtSendChunkUsec     bucketstats.BucketLog2Round     // bucketized by time
-	ObjectPutCtxtSendChunkBytes    bucketstats.BucketLog2Round     // bucketized by byte count
-	ObjectPutCtxtBytes             bucketstats.BucketLog2Round     // bucketized by total bytes put
-	ObjectPutCtxtFetchToCloseUsec  bucketstats.BucketLog2Round     // Fetch returns to Close called time
+}
+
+// volumeStatsStruct holds the statistics of requests to the Account of a volume. Each served
+// volume's are registered with package bucketstats as group volumeName with a "volume" label
+// and are also accumulated in those of globals (registered, as before, as group "").
+type volumeStatsStruct struct {
+	volumeName string
+
+	// statistics for requests to swiftclient
+	AccountDeleteUsec             bucketstats.BucketLog2Round // bucketized by time
+	AccountGetUsec                bucketstats.BucketLog2Round // bucketized by time
+	AccountHeadUsec               bucketstats.BucketLog2Round // bucketized by time
+	AccountPostUsec               bucketstats.BucketLog2Round // bucketized by time
+	AccountPutUsec                bucketstats.BucketLog2Round // bucketized by time
+	ContainerDeleteUsec           bucketstats.BucketLog2Round // bucketized by time
+	ContainerGetUsec              bucketstats.BucketLog2Round // bucketized by time
+	ContainerHeadUsec             bucketstats.BucketLog2Round // bucketized by time
+	ContainerPostUsec             bucketstats.BucketLog2Round // bucketized by time
+	ContainerPutUsec              bucketstats.BucketLog2Round // bucketized by time
+	ObjectContentLengthUsec       bucketstats.BucketLog2Round // bucketized by time
+	ObjectCopyUsec                bucketstats.BucketLog2Round // bucketized by time
+	ObjectDeleteUsec              bucketstats.BucketLog2Round // bucketized by time
+	ObjectGetUsec                 bucketstats.BucketLog2Round // bucketized by time
+	ObjectGetBytes                bucketstats.BucketLog2Round // bucketized by byte count
+	ObjectHeadUsec                bucketstats.BucketLog2Round // bucketized by time
+	ObjectLoadUsec                bucketstats.BucketLog2Round // bucketized by time
+	ObjectLoadBytes               bucketstats.BucketLog2Round // bucketized by byte count
+	ObjectPostUsec                bucketstats.BucketLog2Round // bucketized by time
+	ObjectReadUsec                bucketstats.BucketLog2Round // bucketized by time
+	ObjectReadBytes               bucketstats.BucketLog2Round // bucketized by byte count
+	ObjectTailUsec                bucketstats.BucketLog2Round // bucketized by time
+	ObjectTailBytes               bucketstats.BucketLog2Round // bucketized by byte count
+	ObjectPutCtxtFetchUsec        bucketstats.BucketLog2Round // bucketized by time
+	ObjectPutCtxtBytesPut         bucketstats.Total           // number of calls to BytesPut() query
+	ObjectPutCtxtCloseUsec        bucketstats.BucketLog2Round // bucketized by time
+	ObjectPutCtxtReadBytes        bucketstats.BucketLog2Round // bucketized by bytes read
+	ObjectPutCtxtSendChunkUsec    bucketstats.BucketLog2Round // bucketized by time
+	ObjectPutCtxtSendChunkBytes   bucketstats.BucketLog2Round // bucketized by byte count
+	ObjectPutCtxtBytes            bucketstats.BucketLog2Round // bucketized by total bytes put
+	ObjectPutCtxtFetchToCloseUsec bucketstats.BucketLog2Round // Fetch returns to Close called time
 
 	// client request failures
 	AccountDeleteFailure       bucketstats.Total
@@ -245,6 +265,9 @@ func (dummy *globalsStruct) reloadConfig(confMap conf.ConfMap) (err error) {
 	}
 	logger.Infof("SwiftClient.ChecksumChunkedPutChunks %s\n", checksums)
 
+	// (re)map each volume's Account to the object store backend holding it
+	err = configureBackends(confMap)
+
 	return
 }
 
@@ -257,9 +280,12 @@ func (dummy *globalsStruct) Up(confMap conf.ConfMap) (err error) {
 		nonChunkedConnectionPoolSize uint16
 	)
 
-	// register the bucketstats statistics tracked here
+	// register the bucketstats statistics tracked here (those of each
+	// served volume are registered by ServeVolume())
 	bucketstats.Register("proxyfs.swiftclient", "", &globals)
 
+	globals.volumeStatsMap = make(map[string]*volumeStatsStruct)
+
 	noAuthIPAddr, err = confMap.FetchOptionValueString("SwiftClient", "NoAuthIPAddr")
 	if nil != err {
 		noAuthIPAddr = "127.0.0.1" // TODO: Eventually just return
@@ -354,10 +380,51 @@ func (dummy *globalsStruct) VolumeDestroyed(confMap conf.ConfMap, volumeName str
 	return nil
 }
 func (dummy *globalsStruct) ServeVolume(confMap conf.ConfMap, volumeName string) (err error) {
-	return nil
+	var (
+		accountName string
+		ok          bool
+		volumeStats *volumeStatsStruct
+	)
+
+	accountName, err = confMap.FetchOptionValueString("Volume:"+volumeName, "AccountName")
+	if nil != err {
+		return
+	}
+
+	globals.volumeStatsMapMutex.Lock()
+	defer globals.volumeStatsMapMutex.Unlock()
+
+	volumeStats, ok = globals.volumeStatsMap[accountName]
+	if ok {
+		err = fmt.Errorf("swiftclient.ServeVolume() called for Volume (%s) sharing AccountName (%s) with served Volume (%s)", volumeName, accountName, volumeStats.volumeName)
+		return
+	}
+
+	volumeStats = &volumeStatsStruct{volumeName: volumeName}
+	bucketstats.RegisterWithLabelsAndAggregate("proxyfs.swiftclient", volumeName, map[string]string{"volume": volumeName}, volumeStats, &globals)
+
+	globals.volumeStatsMap[accountName] = volumeStats
+
+	return
 }
 func (dummy *globalsStruct) UnserveVolume(confMap conf.ConfMap, volumeName string) (err error) {
-	return nil
+	var (
+		accountName string
+		volumeStats *volumeStatsStruct
+	)
+
+	globals.volumeStatsMapMutex.Lock()
+	defer globals.volumeStatsMapMutex.Unlock()
+
+	for accountName, volumeStats = range globals.volumeStatsMap {
+		if volumeName == volumeStats.volumeName {
+			bucketstats.UnRegister("proxyfs.swiftclient", volumeName)
+			delete(globals.volumeStatsMap, accountName)
+			return
+		}
+	}
+
+	return
 }
 func (dummy *globalsStruct) VolumeToBeUnserved(confMap conf.ConfMap, volumeName string) (err error) {
 	return nil
@@ -385,3 +452,21 @@ func (dummy *globalsStruct) Down(confMap conf.ConfMap) (err error) {
 	err = nil
 	return
 }
+
+// fetchVolumeStats returns the statistics of the served volume whose Account is accountName
+// (or, if there is no such volume, the aggregate statistics in globals).
+func fetchVolumeStats(accountName string) (volumeStats *volumeStatsStruct) {
+	var (
+		ok bool
+	)
+
+	globals.volumeStatsMapMutex.RLock()
+	volumeStats, ok = globals.volumeStatsMap[accountName]
+	globals.volumeStatsMapMutex.RUnlock()
+
+	if !ok {
+		volumeStats = &globals.volumeStatsStruct
+	}
+
+	return
+}
@@ -30,13 +30,14 @@ func containerDeleteWithRetry(accountName string, containerName string) (err err
 		retryObj *RetryCtrl = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
 		opname   string     = fmt.Sprintf("swiftclient.containerDelete(\"%v/%v\")", accountName, containerName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftContainerDeleteRetryOps,
 			retrySuccessCnt:   &stats.SwiftContainerDeleteRetrySuccessOps,
-			clientRequestTime: &globals.ContainerDeleteUsec,
-			clientFailureCnt:  &globals.ContainerDeleteFailure,
-			swiftRequestTime:  &globals.SwiftContainerDeleteUsec,
-			swiftRetryOps:     &globals.SwiftContainerDeleteRetryOps,
+			clientRequestTime: &volumeStats.ContainerDeleteUsec,
+			clientFailureCnt:  &volumeStats.ContainerDeleteFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerDeleteUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerDeleteRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -105,16 +106,18 @@ func containerGetWithRetry(accountName string, containerName string) (headers ma
 		statnm          requestStatistics
 		toAddHeaders    map[string][]string
 		toAddObjectList []string
+		volumeStats     *volumeStatsStruct
 	)
 
 	retryObj = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
+	volumeStats = fetchVolumeStats(accountName)
 	statnm = requestStatistics{
 		retryCnt:          &stats.SwiftContainerGetRetryOps,
 		retrySuccessCnt:   &stats.SwiftContainerGetRetrySuccessOps,
-		clientRequestTime: &globals.ContainerGetUsec,
-		clientFailureCnt:  &globals.ContainerGetFailure,
-		swiftRequestTime:  &globals.SwiftContainerGetUsec,
-		swiftRetryOps:     &globals.SwiftContainerGetRetryOps,
+		clientRequestTime: &volumeStats.ContainerGetUsec,
+		clientFailureCnt:  &volumeStats.ContainerGetFailure,
+		swiftRequestTime:  &volumeStats.SwiftContainerGetUsec,
+		swiftRetryOps:     &volumeStats.SwiftContainerGetRetryOps,
 	}
 
 	request := func() (bool, error) {
@@ -221,13 +224,14 @@ func containerHeadWithRetry(accountName string, containerName string) (map[strin
 		retryObj *RetryCtrl = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
 		opname   string     = fmt.Sprintf("swiftclient.containerHead(\"%v/%v\")", accountName, containerName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftContainerHeadRetryOps,
 			retrySuccessCnt:   &stats.SwiftContainerHeadRetrySuccessOps,
-			clientRequestTime: &globals.ContainerHeadUsec,
-			clientFailureCnt:  &globals.ContainerHeadFailure,
-			swiftRequestTime:  &globals.SwiftContainerHeadUsec,
-			swiftRetryOps:     &globals.SwiftContainerHeadRetryOps,
+			clientRequestTime: &volumeStats.ContainerHeadUsec,
+			clientFailureCnt:  &volumeStats.ContainerHeadFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerHeadUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerHeadRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -297,13 +301,14 @@ func containerPostWithRetry(accountName string, containerName string, requestHea
 		retryObj *RetryCtrl = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
 		opname   string     = fmt.Sprintf("swiftclient.containerPost(\"%v/%v\")", accountName, containerName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftContainerPostRetryOps,
 			retrySuccessCnt:   &stats.SwiftContainerPostRetrySuccessOps,
-			clientRequestTime: &globals.ContainerPostUsec,
-			clientFailureCnt:  &globals.ContainerPostFailure,
-			swiftRequestTime:  &globals.SwiftContainerPostUsec,
-			swiftRetryOps:     &globals.SwiftContainerPostRetryOps,
+			clientRequestTime: &volumeStats.ContainerPostUsec,
+			clientFailureCnt:  &volumeStats.ContainerPostFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerPostUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerPostRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -393,13 +398,14 @@ func containerPutWithRetry(accountName string, containerName string, requestHead
 		retryObj *RetryCtrl = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
 		opname   string     = fmt.Sprintf("swiftclient.containerPut(\"%v/%v\")", accountName, containerName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftContainerPutRetryOps,
 			retrySuccessCnt:   &stats.SwiftContainerPutRetrySuccessOps,
-			clientRequestTime: &globals.ContainerPutUsec,
-			clientFailureCnt:  &globals.ContainerPutFailure,
-			swiftRequestTime:  &globals.SwiftContainerPutUsec,
-			swiftRetryOps:     &globals.SwiftContainerPutRetryOps,
+			clientRequestTime: &volumeStats.ContainerPutUsec,
+			clientFailureCnt:  &volumeStats.ContainerPutFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerPutUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerPutRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
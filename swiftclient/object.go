@@ -44,13 +44,14 @@ func objectContentLengthWithRetry(accountName string, containerName string, obje
 		opname string = fmt.Sprintf("swiftclient.objectContentLength(\"%v/%v/%v\")",
 			accountName, containerName, objectName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftObjContentLengthRetryOps,
 			retrySuccessCnt:   &stats.SwiftObjContentLengthRetrySuccessOps,
-			clientRequestTime: &globals.ObjectContentLengthUsec,
-			clientFailureCnt:  &globals.ObjectContentLengthFailure,
-			swiftRequestTime:  &globals.SwiftObjectContentLengthUsec,
-			swiftRetryOps:     &globals.SwiftObjectContentLengthRetryOps,
+			clientRequestTime: &volumeStats.ObjectContentLengthUsec,
+			clientFailureCnt:  &volumeStats.ObjectContentLengthFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectContentLengthUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectContentLengthRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -130,15 +131,15 @@ func objectCopy(srcAccountName string, srcContainerName string, srcObjectName st
 	clientReqTime = time.Now()
 	defer func() {
 		elapsedUsec := time.Since(clientReqTime).Nanoseconds() / time.Microsecond.Nanoseconds()
-		globals.ObjectCopyUsec.Add(uint64(elapsedUsec))
+		fetchVolumeStats(dstAccountName).ObjectCopyUsec.Add(uint64(elapsedUsec))
 	}()
 
-	srcObjectSize, err = objectContentLengthWithRetry(srcAccountName, srcContainerName, srcObjectName)
+	srcObjectSize, err = ObjectContentLength(srcAccountName, srcContainerName, srcObjectName)
 	if nil != err {
 		return
 	}
 
-	dstChunkedPutContext, err = objectFetchChunkedPutContextWithRetry(dstAccountName, dstContainerName, dstObjectName, "")
+	dstChunkedPutContext, err = ObjectFetchChunkedPutContext(dstAccountName, dstContainerName, dstObjectName, "")
 	if nil != err {
 		return
 	}
@@ -153,9 +154,9 @@ func objectCopy(srcAccountName string, srcContainerName string, srcObjectName st
 		if (srcObjectPosition + chunkSize) > srcObjectSize {
 			chunkSize = srcObjectSize - srcObjectPosition
 
-			chunk, err = objectTailWithRetry(srcAccountName, srcContainerName, srcObjectName, chunkSize)
+			chunk, err = ObjectTail(srcAccountName, srcContainerName, srcObjectName, chunkSize)
 		} else {
-			chunk, err = objectGetWithRetry(srcAccountName, srcContainerName, srcObjectName, srcObjectPosition, chunkSize)
+			chunk, err = ObjectGet(srcAccountName, srcContainerName, srcObjectName, srcObjectPosition, chunkSize)
 		}
 
 		srcObjectPosition += chunkSize
@@ -193,13 +194,14 @@ func objectDelete(accountName string, containerName string, objectName string, o
 			opname string = fmt.Sprintf(
 				"swiftclient.objectDeleteOneTime(\"%v/%v/%v\")", accountName, containerName, objectName)
 
-			statnm requestStatistics = requestStatistics{
+			volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+			statnm      requestStatistics  = requestStatistics{
 				retryCnt:          &stats.SwiftObjDeleteRetryOps,
 				retrySuccessCnt:   &stats.SwiftObjDeleteRetrySuccessOps,
-				clientRequestTime: &globals.ObjectDeleteUsec,
-				clientFailureCnt:  &globals.ObjectDeleteFailure,
-				swiftRequestTime:  &globals.SwiftObjectDeleteUsec,
-				swiftRetryOps:     &globals.SwiftObjectDeleteRetryOps,
+				clientRequestTime: &volumeStats.ObjectDeleteUsec,
+				clientFailureCnt:  &volumeStats.ObjectDeleteFailure,
+				swiftRequestTime:  &volumeStats.SwiftObjectDeleteUsec,
+				swiftRetryOps:     &volumeStats.SwiftObjectDeleteRetryOps,
 			}
 		)
 		err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -280,13 +282,14 @@ func objectGetWithRetry(accountName string, containerName string, objectName str
 		opname string = fmt.Sprintf(
 			"swiftclient.objectGet(\"%v/%v/%v\")", accountName, containerName, objectName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftObjGetRetryOps,
 			retrySuccessCnt:   &stats.SwiftObjGetRetrySuccessOps,
-			clientRequestTime: &globals.ObjectGetUsec,
-			clientFailureCnt:  &globals.ObjectGetFailure,
-			swiftRequestTime:  &globals.SwiftObjectGetUsec,
-			swiftRetryOps:     &globals.SwiftObjectGetRetryOps,
+			clientRequestTime: &volumeStats.ObjectGetUsec,
+			clientFailureCnt:  &volumeStats.ObjectGetFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectGetUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectGetRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -382,7 +385,7 @@ func objectGet(accountName string, containerName string, objectName string, offs
 	releaseNonChunkedConnection(connection, parseConnection(headers))
 
 	stats.IncrementOperationsAndBucketedBytes(stats.SwiftObjGet, uint64(len(buf)))
-	globals.ObjectGetBytes.Add(uint64(len(buf)))
+	fetchVolumeStats(accountName).ObjectGetBytes.Add(uint64(len(buf)))
 	return
 }
 
@@ -407,13 +410,14 @@ func objectHeadWithRetry(accountName string, containerName string, objectName st
 		opname string = fmt.Sprintf(
 			"swiftclient.objectHead(\"%v/%v/%v\")", accountName, containerName, objectName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftObjHeadRetryOps,
 			retrySuccessCnt:   &stats.SwiftObjHeadRetrySuccessOps,
-			clientRequestTime: &globals.ObjectHeadUsec,
-			clientFailureCnt:  &globals.ObjectHeadFailure,
-			swiftRequestTime:  &globals.SwiftObjectHeadUsec,
-			swiftRetryOps:     &globals.SwiftObjectHeadRetryOps,
+			clientRequestTime: &volumeStats.ObjectHeadUsec,
+			clientFailureCnt:  &volumeStats.ObjectHeadFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectHeadUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectHeadRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -489,13 +493,14 @@ func objectLoadWithRetry(accountName string, containerName string, objectName st
 		opname string = fmt.Sprintf(
 			"swiftclient.objectLoad(\"%v/%v/%v\")", accountName, containerName, objectName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftObjLoadRetryOps,
 			retrySuccessCnt:   &stats.SwiftObjLoadRetrySuccessOps,
-			clientRequestTime: &globals.ObjectLoadUsec,
-			clientFailureCnt:  &globals.ObjectLoadFailure,
-			swiftRequestTime:  &globals.SwiftObjectLoadUsec,
-			swiftRetryOps:     &globals.SwiftObjectLoadRetryOps,
+			clientRequestTime: &volumeStats.ObjectLoadUsec,
+			clientFailureCnt:  &volumeStats.ObjectLoadFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectLoadUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectLoadRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -588,7 +593,7 @@ func objectLoad(accountName string, containerName string, objectName string) (bu
 	releaseNonChunkedConnection(connection, parseConnection(headers))
 
 	stats.IncrementOperationsAndBucketedBytes(stats.SwiftObjLoad, uint64(len(buf)))
-	globals.ObjectLoadBytes.Add(uint64(len(buf)))
+	fetchVolumeStats(accountName).ObjectLoadBytes.Add(uint64(len(buf)))
 	return
 }
 
@@ -607,13 +612,14 @@ func objectPostWithRetry(accountName string, containerName string, objectName st
 		retryObj *RetryCtrl = NewRetryCtrl(globals.retryLimit, globals.retryDelay, globals.retryExpBackoff)
 		opname   string     = fmt.Sprintf("swiftclient.objectPost(\"%v/%v/%v\")", accountName, containerName, objectName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftObjPostRetryOps,
 			retrySuccessCnt:   &stats.SwiftObjPostRetrySuccessOps,
-			clientRequestTime: &globals.ObjectPostUsec,
-			clientFailureCnt:  &globals.ObjectPostFailure,
-			swiftRequestTime:  &globals.SwiftObjectPostUsec,
-			swiftRetryOps:     &globals.SwiftObjectPostRetryOps,
+			clientRequestTime: &volumeStats.ObjectPostUsec,
+			clientFailureCnt:  &volumeStats.ObjectPostFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectPostUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectPostRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -709,13 +715,14 @@ func objectReadWithRetry(accountName string, containerName string, objectName st
 		opname string = fmt.Sprintf(
 			"swiftclient.objectRead(\"%v/%v/%v\", offset=0x%016X, len(buf)=0x%016X)", accountName, containerName, objectName, offset, cap(buf))
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftObjReadRetryOps,
 			retrySuccessCnt:   &stats.SwiftObjReadRetrySuccessOps,
-			clientRequestTime: &globals.ObjectReadUsec,
-			clientFailureCnt:  &globals.ObjectReadFailure,
-			swiftRequestTime:  &globals.SwiftObjectReadUsec,
-			swiftRetryOps:     &globals.SwiftObjectReadRetryOps,
+			clientRequestTime: &volumeStats.ObjectReadUsec,
+			clientFailureCnt:  &volumeStats.ObjectReadFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectReadUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectReadRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -816,7 +823,7 @@ func objectRead(accountName string, containerName string, objectName string, off
 	releaseNonChunkedConnection(connection, parseConnection(headers))
 
 	stats.IncrementOperationsAndBucketedBytes(stats.SwiftObjRead, len)
-	globals.ObjectReadBytes.Add(len)
+	fetchVolumeStats(accountName).ObjectReadBytes.Add(len)
 	return
 }
 
@@ -843,13 +850,14 @@ func objectTailWithRetry(accountName string, containerName string, objectName st
 		opname string = fmt.Sprintf(
 			"swiftclient.objectTail(\"%v/%v/%v\")", accountName, containerName, objectName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftObjTailRetryOps,
 			retrySuccessCnt:   &stats.SwiftObjTailRetrySuccessOps,
-			clientRequestTime: &globals.ObjectTailUsec,
-			clientFailureCnt:  &globals.ObjectTailFailure,
-			swiftRequestTime:  &globals.SwiftObjectTailUsec,
-			swiftRetryOps:     &globals.SwiftObjectTailRetryOps,
+			clientRequestTime: &volumeStats.ObjectTailUsec,
+			clientFailureCnt:  &volumeStats.ObjectTailFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectTailUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectTailRetryOps,
 		}
 	)
 	err = retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -945,7 +953,7 @@ func objectTail(accountName string, containerName string, objectName string, len
 	releaseNonChunkedConnection(connection, parseConnection(headers))
 
 	stats.IncrementOperationsAndBytes(stats.SwiftObjTail, uint64(len(buf)))
-	globals.ObjectTailBytes.Add(uint64(len(buf)))
+	fetchVolumeStats(accountName).ObjectTailBytes.Add(uint64(len(buf)))
 	return
 }
 
@@ -1021,13 +1029,14 @@ func objectFetchChunkedPutContextWithRetry(accountName string, containerName str
 		opname string = fmt.Sprintf(
 			"swiftclient.objectFetchChunkedPutContext(\"%v/%v/%v\")", accountName, containerName, objectName)
 
-		statnm requestStatistics = requestStatistics{
+		volumeStats *volumeStatsStruct = fetchVolumeStats(accountName)
+		statnm      requestStatistics  = requestStatistics{
 			retryCnt:          &stats.SwiftObjFetchPutCtxtRetryOps,
 			retrySuccessCnt:   &stats.SwiftObjFetchPutCtxtRetrySuccessOps,
-			clientRequestTime: &globals.ObjectPutCtxtFetchUsec,
-			clientFailureCnt:  &globals.ObjectPutCtxtFetchFailure,
-			swiftRequestTime:  &globals.SwiftObjectPutCtxtFetchUsec,
-			swiftRetryOps:     &globals.SwiftObjectPutCtxtFetchRetryOps,
+			clientRequestTime: &volumeStats.ObjectPutCtxtFetchUsec,
+			clientFailureCnt:  &volumeStats.ObjectPutCtxtFetchFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectPutCtxtFetchUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectPutCtxtFetchRetryOps,
 		}
 	)
 	err := retryObj.RequestWithRetry(request, &opname, &statnm)
@@ -1104,13 +1113,14 @@ func (chunkedPutContext *chunkedPutContextStruct) BytesPut() (bytesPut uint64, e
 	chunkedPutContext.Unlock()
 
 	stats.IncrementOperations(&stats.SwiftObjPutCtxBytesPutOps)
-	globals.ObjectPutCtxtBytesPut.Add(1)
+	fetchVolumeStats(chunkedPutContext.accountName).ObjectPutCtxtBytesPut.Add(1)
 
 	err = nil
 	return
 }
 
 func (chunkedPutContext *chunkedPutContextStruct) Close() (err error) {
+	volumeStats := fetchVolumeStats(chunkedPutContext.accountName)
 
 	// track the amount of time the client spent holding the connection --
 	// this includes time spent in SendChunk(), but not time spent in
@@ -1118,7 +1128,7 @@ func (chunkedPutContext *chunkedPutContextStruct) Close() (err error) {
 	// in Close()
 	fetchToCloseUsec := time.Since(chunkedPutContext.fetchTime).Nanoseconds() /
 		time.Microsecond.Nanoseconds()
-	globals.ObjectPutCtxtFetchToCloseUsec.Add(uint64(fetchToCloseUsec))
+	volumeStats.ObjectPutCtxtFetchToCloseUsec.Add(uint64(fetchToCloseUsec))
 
 	chunkedPutContext.validateChunkChecksums()
 
@@ -1157,10 +1167,10 @@ func (chunkedPutContext *chunkedPutContextStruct) Close() (err error) {
 		statnm   requestStatistics = requestStatistics{
 			retryCnt:          &stats.SwiftObjPutCtxtCloseRetryOps,
 			retrySuccessCnt:   &stats.SwiftObjPutCtxtCloseRetrySuccessOps,
-			clientRequestTime: &globals.ObjectPutCtxtCloseUsec,
-			clientFailureCnt:  &globals.ObjectPutCtxtCloseFailure,
-			swiftRequestTime:  &globals.SwiftObjectPutCtxtCloseUsec,
-			swiftRetryOps:     &globals.SwiftObjectPutCtxtCloseRetryOps,
+			clientRequestTime: &volumeStats.ObjectPutCtxtCloseUsec,
+			clientFailureCnt:  &volumeStats.ObjectPutCtxtCloseFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectPutCtxtCloseUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectPutCtxtCloseRetryOps,
 		}
 	)
 	retryObj = NewRetryCtrl(globals.retryLimitObject, globals.retryDelayObject, globals.retryExpBackoffObject)
@@ -1251,7 +1261,7 @@ func (chunkedPutContext *chunkedPutContextStruct) closeHelper() (err error) {
 	chunkedPutContext.stillOpen = parseConnection(headers)
 
 	stats.IncrementOperations(&stats.SwiftObjPutCtxCloseOps)
-	globals.ObjectPutCtxtBytes.Add(chunkedPutContext.bytesPut)
+	fetchVolumeStats(chunkedPutContext.accountName).ObjectPutCtxtBytes.Add(chunkedPutContext.bytesPut)
 
 	return
 }
@@ -1378,7 +1388,7 @@ func (chunkedPutContext *chunkedPutContextStruct) Read(offset uint64, length uin
 	chunkedPutContext.Unlock()
 
 	stats.IncrementOperationsAndBucketedBytes(stats.SwiftObjPutCtxRead, length)
-	globals.ObjectPutCtxtReadBytes.Add(length)
+	fetchVolumeStats(chunkedPutContext.accountName).ObjectPutCtxtReadBytes.Add(length)
 
 	err = nil
 	return
@@ -1630,6 +1640,7 @@ var (
 // error, SendChunk() also cleans up the TCP connection.
 //
 func (chunkedPutContext *chunkedPutContextStruct) SendChunk(buf []byte) (err error) {
+	volumeStats := fetchVolumeStats(chunkedPutContext.accountName)
 
 	clientReqTime := time.Now()
 
@@ -1739,8 +1750,8 @@ func (chunkedPutContext *chunkedPutContextStruct) SendChunk(buf []byte) (err err
 
 	stats.IncrementOperationsAndBucketedBytes(stats.SwiftObjPutCtxSendChunk, uint64(len(buf)))
 	elapsedUsec := time.Since(clientReqTime).Nanoseconds() / time.Microsecond.Nanoseconds()
-	globals.ObjectPutCtxtSendChunkUsec.Add(uint64(elapsedUsec))
-	globals.ObjectPutCtxtSendChunkBytes.Add(uint64(len(buf)))
+	volumeStats.ObjectPutCtxtSendChunkUsec.Add(uint64(elapsedUsec))
+	volumeStats.ObjectPutCtxtSendChunkBytes.Add(uint64(len(buf)))
 
 	return
 }
@@ -0,0 +1,1326 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+// Local File System ("posix") Object Store Backend
+//
+// Each Account mapped to a posix backend is held in a directory beneath PosixPath.
+// Each Container in that Account is held in a subdirectory of the Account's directory
+// and each Object by a file in that Container's directory. Names are URL path-escaped
+// (with any leading '.' escaped as well) so that a name may contain '/' and so that
+// no Account, Container, or Object may be mistaken for one of the following files:
+//
+//   .headers           - the headers of the Account or Container holding it
+//   .headers.<Object>  - the headers of <Object> (if any were POST'd)
+//   .tmp.*             - a file being written
+//
+// Every file is first written to a .tmp.* file, fsync'd, then renamed into place
+// and, finally, the directory holding it is fsync'd. Hence, Objects PUT via a
+// ChunkedPutContext only become visible (and durable) upon a successful Close().
+//
+// As the local file system is not expected to recover from an error on its own,
+// errors are not retried.
+
+package swiftclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/stats"
+	"github.com/NVIDIA/proxyfs/trackedlock"
+)
+
+const (
+	posixHeadersFileName     = ".headers"
+	posixObjectHeadersPrefix = ".headers."
+	posixTempFilePrefix      = ".tmp."
+
+	posixDirPerm = os.FileMode(0755)
+)
+
+type posixBackendStruct struct {
+	trackedlock.Mutex        // serializes updates to Account and Container directories and to .headers* files
+	accountName       string //
+	posixPath         string //
+	accountPath       string // posixPath/<AccountName>
+}
+
+type posixChunkedPutContextStruct struct {
+	trackedlock.Mutex
+	posixBackend  *posixBackendStruct
+	containerName string
+	objectName    string
+	objectPath    string
+	file          *os.File // the .tmp.* file being written
+	active        bool
+	err           error
+	bytesPut      uint64
+	fetchTime     time.Time
+}
+
+func newPosixBackend(confMap conf.ConfMap, sectionName string, accountName string) (backend backendIf, err error) {
+	var (
+		fileInfo     os.FileInfo
+		posixBackend *posixBackendStruct
+	)
+
+	posixBackend = &posixBackendStruct{
+		accountName: accountName,
+	}
+
+	posixBackend.posixPath, err = confMap.FetchOptionValueString(sectionName, "PosixPath")
+	if nil != err {
+		return
+	}
+
+	fileInfo, err = os.Stat(posixBackend.posixPath)
+	if nil != err {
+		err = fmt.Errorf("%s.PosixPath (%s) not accessible: %v", sectionName, posixBackend.posixPath, err)
+		return
+	}
+	if !fileInfo.IsDir() {
+		err = fmt.Errorf("%s.PosixPath (%s) is not a directory", sectionName, posixBackend.posixPath)
+		return
+	}
+
+	posixBackend.accountPath = filepath.Join(posixBackend.posixPath, posixEncodeName(accountName))
+
+	backend = posixBackend
+
+	err = nil
+	return
+}
+
+func posixEncodeName(name string) (encodedName string) {
+	encodedName = url.PathEscape(name)
+	if strings.HasPrefix(encodedName, ".") {
+		encodedName = "%2E" + encodedName[1:]
+	}
+	return
+}
+
+// posixError converts statusCode into the form of error returned by the Swift backend.
+//
+func posixError(statusCode int, opname string, format string, args ...interface{}) (retriable bool, err error) {
+	var (
+		fsErr blunder.FsError
+	)
+
+	_, fsErr = httpStatusIsError(statusCode)
+	err = blunder.NewError(fsErr, "%s returned HTTP StatusCode %d: %s", opname, statusCode, fmt.Sprintf(format, args...))
+	err = blunder.AddHTTPCode(err, statusCode)
+	if http.StatusNotFound != statusCode {
+		logger.WarnfWithError(err, "%s got bad status", opname)
+	}
+
+	retriable = false
+
+	return
+}
+
+// posixOSError converts an error returned by package os (et. al.) into the form of
+// error returned by the Swift backend. A missing file or directory is reported as
+// http.StatusNotFound... any other error as http.StatusInternalServerError.
+//
+func posixOSError(osErr error, opname string) (retriable bool, err error) {
+	if os.IsNotExist(osErr) {
+		retriable, err = posixError(http.StatusNotFound, opname, "%v", osErr)
+	} else {
+		retriable, err = posixError(http.StatusInternalServerError, opname, "%v", osErr)
+	}
+	return
+}
+
+// posixSyncDir fsync's the directory at dirPath such that any entries created,
+// renamed, or removed in it are durable.
+//
+func posixSyncDir(dirPath string) (err error) {
+	var (
+		dir *os.File
+	)
+
+	dir, err = os.Open(dirPath)
+	if nil != err {
+		return
+	}
+
+	err = dir.Sync()
+	if nil != err {
+		_ = dir.Close()
+		return
+	}
+
+	err = dir.Close()
+
+	return
+}
+
+// posixWriteFile durably replaces the contents of dirPath/fileName with buf.
+//
+func posixWriteFile(dirPath string, fileName string, buf []byte) (err error) {
+	var (
+		file *os.File
+	)
+
+	file, err = ioutil.TempFile(dirPath, posixTempFilePrefix)
+	if nil != err {
+		return
+	}
+
+	_, err = file.Write(buf)
+	if nil == err {
+		err = file.Sync()
+	}
+	if nil != err {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return
+	}
+
+	err = file.Close()
+	if nil != err {
+		_ = os.Remove(file.Name())
+		return
+	}
+
+	err = os.Rename(file.Name(), filepath.Join(dirPath, fileName))
+	if nil != err {
+		_ = os.Remove(file.Name())
+		return
+	}
+
+	err = posixSyncDir(dirPath)
+
+	return
+}
+
+// posixRemoveFile durably removes dirPath/fileName. If missingOK is set, it is
+// not an error for dirPath/fileName to not exist.
+//
+func posixRemoveFile(dirPath string, fileName string, missingOK bool) (err error) {
+	err = os.Remove(filepath.Join(dirPath, fileName))
+	if nil != err {
+		if missingOK && os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	err = posixSyncDir(dirPath)
+
+	return
+}
+
+// posixReadHeaders returns the headers recorded in dirPath/fileName... or no
+// headers if that file does not exist.
+//
+func posixReadHeaders(dirPath string, fileName string) (headers map[string][]string, err error) {
+	var (
+		buf []byte
+	)
+
+	headers = make(map[string][]string)
+
+	buf, err = ioutil.ReadFile(filepath.Join(dirPath, fileName))
+	if nil != err {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	err = json.Unmarshal(buf, &headers)
+
+	return
+}
+
+// posixUpdateHeaders merges updates into the headers recorded in dirPath/fileName.
+// As in Swift, a header with an empty value removes that header.
+//
+func posixUpdateHeaders(dirPath string, fileName string, updates map[string][]string) (err error) {
+	var (
+		buf          []byte
+		headerName   string
+		headerValues []string
+		headers      map[string][]string
+	)
+
+	headers, err = posixReadHeaders(dirPath, fileName)
+	if nil != err {
+		return
+	}
+
+	for headerName, headerValues = range updates {
+		if (0 == len(headerValues)) || ("" == strings.Join(headerValues, "")) {
+			delete(headers, http.CanonicalHeaderKey(headerName))
+		} else {
+			headers[http.CanonicalHeaderKey(headerName)] = headerValues
+		}
+	}
+
+	if 0 == len(headers) {
+		err = posixRemoveFile(dirPath, fileName, true)
+		return
+	}
+
+	buf, err = json.Marshal(headers)
+	if nil != err {
+		return
+	}
+
+	err = posixWriteFile(dirPath, fileName, buf)
+
+	return
+}
+
+// posixList returns the sorted (decoded) names of the subdirectories of dirPath
+// if wantDirs is set or the names of the files in dirPath otherwise.
+//
+func posixList(dirPath string, wantDirs bool) (names []string, err error) {
+	var (
+		fileInfo  os.FileInfo
+		fileInfos []os.FileInfo
+		name      string
+	)
+
+	fileInfos, err = ioutil.ReadDir(dirPath)
+	if nil != err {
+		return
+	}
+
+	names = make([]string, 0, len(fileInfos))
+
+	for _, fileInfo = range fileInfos {
+		if strings.HasPrefix(fileInfo.Name(), ".") || (fileInfo.IsDir() != wantDirs) {
+			continue
+		}
+		name, err = url.PathUnescape(fileInfo.Name())
+		if nil != err {
+			return
+		}
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return
+}
+
+// posixStatDir returns an error unless dirPath is an existing directory.
+//
+func posixStatDir(dirPath string) (err error) {
+	var (
+		fileInfo os.FileInfo
+	)
+
+	fileInfo, err = os.Stat(dirPath)
+	if (nil == err) && !fileInfo.IsDir() {
+		err = fmt.Errorf("%s is not a directory", dirPath)
+	}
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) containerPath(containerName string) (containerPath string) {
+	containerPath = filepath.Join(posixBackend.accountPath, posixEncodeName(containerName))
+	return
+}
+
+func (posixBackend *posixBackendStruct) objectPath(containerName string, objectName string) (objectPath string) {
+	objectPath = filepath.Join(posixBackend.containerPath(containerName), posixEncodeName(objectName))
+	return
+}
+
+// ensureDir creates (and makes durable) the directory at dirPath if it does not
+// yet exist much like Swift will auto-create an Account. Called with posixBackend locked.
+//
+func (posixBackend *posixBackendStruct) ensureDir(dirPath string) (err error) {
+	err = os.Mkdir(dirPath, posixDirPerm)
+	if nil != err {
+		if os.IsExist(err) {
+			err = posixStatDir(dirPath)
+		}
+		return
+	}
+
+	err = posixSyncDir(filepath.Dir(dirPath))
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) name() (backendName string) {
+	return BackendNamePosix
+}
+
+func (posixBackend *posixBackendStruct) accountDelete(accountName string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixAccountDelete(\"%v\")", accountName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftAccountDeleteRetryOps,
+			retrySuccessCnt:   &stats.SwiftAccountDeleteRetrySuccessOps,
+			clientRequestTime: &volumeStats.AccountDeleteUsec,
+			clientFailureCnt:  &volumeStats.AccountDeleteFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountDeleteUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountDeleteRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			containerList []string
+			err           error
+		)
+
+		posixBackend.Lock()
+		defer posixBackend.Unlock()
+
+		containerList, err = posixList(posixBackend.accountPath, true)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		if 0 != len(containerList) {
+			return posixError(http.StatusConflict, opname, "Account not empty")
+		}
+		err = posixRemoveFile(posixBackend.accountPath, posixHeadersFileName, true)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		err = posixRemoveFile(posixBackend.posixPath, posixEncodeName(accountName), false)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) accountGet(accountName string) (headers map[string][]string, containerList []string, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixAccountGet(\"%v\")", accountName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftAccountGetRetryOps,
+			retrySuccessCnt:   &stats.SwiftAccountGetRetrySuccessOps,
+			clientRequestTime: &volumeStats.AccountGetUsec,
+			clientFailureCnt:  &volumeStats.AccountGetFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountGetUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountGetRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+
+		containerList, err = posixList(posixBackend.accountPath, true)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		headers, err = posixReadHeaders(posixBackend.accountPath, posixHeadersFileName)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) accountHead(accountName string) (headers map[string][]string, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixAccountHead(\"%v\")", accountName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftAccountHeadRetryOps,
+			retrySuccessCnt:   &stats.SwiftAccountHeadRetrySuccessOps,
+			clientRequestTime: &volumeStats.AccountHeadUsec,
+			clientFailureCnt:  &volumeStats.AccountHeadFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountHeadUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountHeadRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+
+		err = posixStatDir(posixBackend.accountPath)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		headers, err = posixReadHeaders(posixBackend.accountPath, posixHeadersFileName)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) accountPost(accountName string, headers map[string][]string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixAccountPost(\"%v\")", accountName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftAccountPostRetryOps,
+			retrySuccessCnt:   &stats.SwiftAccountPostRetrySuccessOps,
+			clientRequestTime: &volumeStats.AccountPostUsec,
+			clientFailureCnt:  &volumeStats.AccountPostFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountPostUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountPostRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+
+		posixBackend.Lock()
+		defer posixBackend.Unlock()
+
+		err = posixStatDir(posixBackend.accountPath)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		err = posixUpdateHeaders(posixBackend.accountPath, posixHeadersFileName, headers)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) accountPut(accountName string, headers map[string][]string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixAccountPut(\"%v\")", accountName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftAccountPutRetryOps,
+			retrySuccessCnt:   &stats.SwiftAccountPutRetrySuccessOps,
+			clientRequestTime: &volumeStats.AccountPutUsec,
+			clientFailureCnt:  &volumeStats.AccountPutFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountPutUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountPutRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+
+		posixBackend.Lock()
+		defer posixBackend.Unlock()
+
+		err = posixBackend.ensureDir(posixBackend.accountPath)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		err = posixUpdateHeaders(posixBackend.accountPath, posixHeadersFileName, headers)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) containerDelete(accountName string, containerName string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixContainerDelete(\"%v/%v\")", accountName, containerName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftContainerDeleteRetryOps,
+			retrySuccessCnt:   &stats.SwiftContainerDeleteRetrySuccessOps,
+			clientRequestTime: &volumeStats.ContainerDeleteUsec,
+			clientFailureCnt:  &volumeStats.ContainerDeleteFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerDeleteUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerDeleteRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			containerPath = posixBackend.containerPath(containerName)
+			err           error
+			objectList    []string
+		)
+
+		posixBackend.Lock()
+		defer posixBackend.Unlock()
+
+		objectList, err = posixList(containerPath, false)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		if 0 != len(objectList) {
+			return posixError(http.StatusConflict, opname, "Container not empty")
+		}
+		err = posixRemoveFile(containerPath, posixHeadersFileName, true)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		err = posixRemoveFile(posixBackend.accountPath, posixEncodeName(containerName), false)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) containerGet(accountName string, containerName string) (headers map[string][]string, objectList []string, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixContainerGet(\"%v/%v\")", accountName, containerName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftContainerGetRetryOps,
+			retrySuccessCnt:   &stats.SwiftContainerGetRetrySuccessOps,
+			clientRequestTime: &volumeStats.ContainerGetUsec,
+			clientFailureCnt:  &volumeStats.ContainerGetFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerGetUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerGetRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+
+		objectList, err = posixList(posixBackend.containerPath(containerName), false)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		headers, err = posixReadHeaders(posixBackend.containerPath(containerName), posixHeadersFileName)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) containerHead(accountName string, containerName string) (headers map[string][]string, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixContainerHead(\"%v/%v\")", accountName, containerName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftContainerHeadRetryOps,
+			retrySuccessCnt:   &stats.SwiftContainerHeadRetrySuccessOps,
+			clientRequestTime: &volumeStats.ContainerHeadUsec,
+			clientFailureCnt:  &volumeStats.ContainerHeadFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerHeadUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerHeadRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+
+		err = posixStatDir(posixBackend.containerPath(containerName))
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		headers, err = posixReadHeaders(posixBackend.containerPath(containerName), posixHeadersFileName)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) containerPost(accountName string, containerName string, headers map[string][]string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixContainerPost(\"%v/%v\")", accountName, containerName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftContainerPostRetryOps,
+			retrySuccessCnt:   &stats.SwiftContainerPostRetrySuccessOps,
+			clientRequestTime: &volumeStats.ContainerPostUsec,
+			clientFailureCnt:  &volumeStats.ContainerPostFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerPostUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerPostRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+
+		posixBackend.Lock()
+		defer posixBackend.Unlock()
+
+		err = posixStatDir(posixBackend.containerPath(containerName))
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		err = posixUpdateHeaders(posixBackend.containerPath(containerName), posixHeadersFileName, headers)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) containerPut(accountName string, containerName string, headers map[string][]string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixContainerPut(\"%v/%v\")", accountName, containerName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftContainerPutRetryOps,
+			retrySuccessCnt:   &stats.SwiftContainerPutRetrySuccessOps,
+			clientRequestTime: &volumeStats.ContainerPutUsec,
+			clientFailureCnt:  &volumeStats.ContainerPutFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerPutUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerPutRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+
+		posixBackend.Lock()
+		defer posixBackend.Unlock()
+
+		err = posixBackend.ensureDir(posixBackend.accountPath)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		err = posixBackend.ensureDir(posixBackend.containerPath(containerName))
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		err = posixUpdateHeaders(posixBackend.containerPath(containerName), posixHeadersFileName, headers)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) objectContentLength(accountName string, containerName string, objectName string) (length uint64, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixObjectContentLength(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjContentLengthRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjContentLengthRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectContentLengthUsec,
+			clientFailureCnt:  &volumeStats.ObjectContentLengthFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectContentLengthUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectContentLengthRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err      error
+			fileInfo os.FileInfo
+		)
+
+		fileInfo, err = os.Stat(posixBackend.objectPath(containerName, objectName))
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		length = uint64(fileInfo.Size())
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) objectDelete(accountName string, containerName string, objectName string, operationOptions OperationOptions) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixObjectDelete(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjDeleteRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjDeleteRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectDeleteUsec,
+			clientFailureCnt:  &volumeStats.ObjectDeleteFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectDeleteUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectDeleteRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			containerPath = posixBackend.containerPath(containerName)
+			err           error
+		)
+
+		err = posixRemoveFile(containerPath, posixEncodeName(objectName), false)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		posixBackend.Lock()
+		err = posixRemoveFile(containerPath, posixObjectHeadersPrefix+posixEncodeName(objectName), true)
+		posixBackend.Unlock()
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	if (operationOptions & SkipRetry) == SkipRetry {
+		_, err = request()
+	} else {
+		err = backendRequestWithRetry(true, opname, &statnm, request)
+	}
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) objectFetchChunkedPutContext(accountName string, containerName string, objectName string, useReserveForVolumeName string) (chunkedPutContext ChunkedPutContext, err error) {
+	var (
+		file   *os.File
+		opname = fmt.Sprintf("swiftclient.posixObjectFetchChunkedPutContext(\"%v/%v/%v\")", accountName, containerName, objectName)
+	)
+
+	// Note that there are no connections to reserve
+
+	file, err = ioutil.TempFile(posixBackend.containerPath(containerName), posixTempFilePrefix)
+	if nil != err {
+		_, err = posixOSError(err, opname)
+		return
+	}
+
+	chunkedPutContext = &posixChunkedPutContextStruct{
+		posixBackend:  posixBackend,
+		containerName: containerName,
+		objectName:    objectName,
+		objectPath:    posixBackend.objectPath(containerName, objectName),
+		file:          file,
+		active:        true,
+		err:           nil,
+		bytesPut:      0,
+		fetchTime:     time.Now(),
+	}
+
+	stats.IncrementOperations(&stats.SwiftObjPutCtxFetchOps)
+
+	err = nil
+	return
+}
+
+// posixReadAt returns up to length bytes of the Object at objectPath starting
+// at offset. As with a Swift Range GET, it is an error for offset to be at or
+// beyond the end of the Object.
+//
+func posixReadAt(objectPath string, offset uint64, length uint64, opname string) (buf []byte, retriable bool, err error) {
+	var (
+		file     *os.File
+		fileInfo os.FileInfo
+		readLen  int
+	)
+
+	file, err = os.Open(objectPath)
+	if nil != err {
+		retriable, err = posixOSError(err, opname)
+		return
+	}
+	defer file.Close()
+
+	fileInfo, err = file.Stat()
+	if nil != err {
+		retriable, err = posixOSError(err, opname)
+		return
+	}
+
+	if offset >= uint64(fileInfo.Size()) {
+		retriable, err = posixError(http.StatusRequestedRangeNotSatisfiable, opname, "offset %d beyond Object length %d", offset, fileInfo.Size())
+		return
+	}
+
+	if (offset + length) > uint64(fileInfo.Size()) {
+		length = uint64(fileInfo.Size()) - offset
+	}
+
+	buf = make([]byte, length)
+
+	readLen, err = file.ReadAt(buf, int64(offset))
+	if (nil != err) && (io.EOF != err) {
+		retriable, err = posixOSError(err, opname)
+		return
+	}
+
+	buf = buf[:readLen]
+
+	err = nil
+	return
+}
+
+func (posixBackend *posixBackendStruct) objectGet(accountName string, containerName string, objectName string, offset uint64, length uint64) (buf []byte, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixObjectGet(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjGetRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjGetRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectGetUsec,
+			clientFailureCnt:  &volumeStats.ObjectGetFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectGetUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectGetRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err       error
+			retriable bool
+		)
+
+		buf, retriable, err = posixReadAt(posixBackend.objectPath(containerName, objectName), offset, length, opname)
+
+		return retriable, err
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+	if nil == err {
+		volumeStats.ObjectGetBytes.Add(uint64(len(buf)))
+	}
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) objectHead(accountName string, containerName string, objectName string) (headers map[string][]string, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixObjectHead(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjHeadRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjHeadRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectHeadUsec,
+			clientFailureCnt:  &volumeStats.ObjectHeadFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectHeadUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectHeadRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err      error
+			fileInfo os.FileInfo
+		)
+
+		fileInfo, err = os.Stat(posixBackend.objectPath(containerName, objectName))
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		headers, err = posixReadHeaders(posixBackend.containerPath(containerName), posixObjectHeadersPrefix+posixEncodeName(objectName))
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		headers["Content-Length"] = []string{strconv.FormatInt(fileInfo.Size(), 10)}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) objectLoad(accountName string, containerName string, objectName string) (buf []byte, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixObjectLoad(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjLoadRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjLoadRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectLoadUsec,
+			clientFailureCnt:  &volumeStats.ObjectLoadFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectLoadUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectLoadRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+		buf, err = ioutil.ReadFile(posixBackend.objectPath(containerName, objectName))
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+	if nil == err {
+		volumeStats.ObjectLoadBytes.Add(uint64(len(buf)))
+	}
+
+	return
+}
+
+// objectPost replaces the headers of the named Object.
+//
+func (posixBackend *posixBackendStruct) objectPost(accountName string, containerName string, objectName string, headers map[string][]string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixObjectPost(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjPostRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjPostRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectPostUsec,
+			clientFailureCnt:  &volumeStats.ObjectPostFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectPostUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectPostRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			containerPath   = posixBackend.containerPath(containerName)
+			err             error
+			headersFileName = posixObjectHeadersPrefix + posixEncodeName(objectName)
+		)
+
+		posixBackend.Lock()
+		defer posixBackend.Unlock()
+
+		_, err = os.Stat(posixBackend.objectPath(containerName, objectName))
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		err = posixRemoveFile(containerPath, headersFileName, true)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		err = posixUpdateHeaders(containerPath, headersFileName, headers)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) objectRead(accountName string, containerName string, objectName string, offset uint64, buf []byte) (readLen uint64, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixObjectRead(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjReadRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjReadRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectReadUsec,
+			clientFailureCnt:  &volumeStats.ObjectReadFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectReadUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectReadRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			body      []byte
+			err       error
+			retriable bool
+		)
+
+		body, retriable, err = posixReadAt(posixBackend.objectPath(containerName, objectName), offset, uint64(len(buf)), opname)
+		if nil != err {
+			return retriable, err
+		}
+
+		readLen = uint64(copy(buf, body))
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+	if nil == err {
+		volumeStats.ObjectReadBytes.Add(readLen)
+	}
+
+	return
+}
+
+func (posixBackend *posixBackendStruct) objectTail(accountName string, containerName string, objectName string, length uint64) (buf []byte, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.posixObjectTail(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjTailRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjTailRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectTailUsec,
+			clientFailureCnt:  &volumeStats.ObjectTailFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectTailUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectTailRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err       error
+			fileInfo  os.FileInfo
+			offset    uint64
+			retriable bool
+		)
+
+		fileInfo, err = os.Stat(posixBackend.objectPath(containerName, objectName))
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		if 0 == fileInfo.Size() {
+			buf = make([]byte, 0)
+			return false, nil
+		}
+
+		if length < uint64(fileInfo.Size()) {
+			offset = uint64(fileInfo.Size()) - length
+		} else {
+			offset = 0
+		}
+
+		buf, retriable, err = posixReadAt(posixBackend.objectPath(containerName, objectName), offset, length, opname)
+		if nil != err {
+			return retriable, err
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+	if nil == err {
+		volumeStats.ObjectTailBytes.Add(uint64(len(buf)))
+	}
+
+	return
+}
+
+func (chunkedPutContext *posixChunkedPutContextStruct) opname(op string) (opname string) {
+	opname = fmt.Sprintf("swiftclient.posixChunkedPutContext.%s(\"%v/%v/%v\")", op, chunkedPutContext.posixBackend.accountName, chunkedPutContext.containerName, chunkedPutContext.objectName)
+	return
+}
+
+func (chunkedPutContext *posixChunkedPutContextStruct) Active() (active bool) {
+	chunkedPutContext.Lock()
+	active = chunkedPutContext.active
+	chunkedPutContext.Unlock()
+
+	stats.IncrementOperations(&stats.SwiftObjPutCtxActiveOps)
+
+	return
+}
+
+func (chunkedPutContext *posixChunkedPutContextStruct) BytesPut() (bytesPut uint64, err error) {
+	chunkedPutContext.Lock()
+	bytesPut = chunkedPutContext.bytesPut
+	chunkedPutContext.Unlock()
+
+	stats.IncrementOperations(&stats.SwiftObjPutCtxBytesPutOps)
+	fetchVolumeStats(chunkedPutContext.posixBackend.accountName).ObjectPutCtxtBytesPut.Add(1)
+
+	err = nil
+	return
+}
+
+func (chunkedPutContext *posixChunkedPutContextStruct) Close() (err error) {
+	var (
+		opname      = chunkedPutContext.opname("Close")
+		volumeStats = fetchVolumeStats(chunkedPutContext.posixBackend.accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjPutCtxtCloseRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjPutCtxtCloseRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectPutCtxtCloseUsec,
+			clientFailureCnt:  &volumeStats.ObjectPutCtxtCloseFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectPutCtxtCloseUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectPutCtxtCloseRetryOps,
+		}
+		posixBackend = chunkedPutContext.posixBackend
+	)
+
+	chunkedPutContext.Lock()
+	defer chunkedPutContext.Unlock()
+
+	volumeStats.ObjectPutCtxtFetchToCloseUsec.Add(uint64(time.Since(chunkedPutContext.fetchTime) / time.Microsecond))
+
+	if !chunkedPutContext.active {
+		err = blunder.NewError(blunder.BadHTTPPutError, "called while inactive")
+		logger.PanicfWithError(err, "%s logic error", opname)
+		return
+	}
+
+	chunkedPutContext.active = false
+
+	if nil != chunkedPutContext.err {
+		_ = chunkedPutContext.file.Close()
+		_ = os.Remove(chunkedPutContext.file.Name())
+		err = chunkedPutContext.err
+		return
+	}
+
+	request := func() (bool, error) {
+		var err error
+
+		err = chunkedPutContext.file.Sync()
+		if nil != err {
+			_ = chunkedPutContext.file.Close()
+			return posixOSError(err, opname)
+		}
+		err = chunkedPutContext.file.Close()
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		// As in Swift, PUT'ing an Object discards any headers POST'd to its predecessor
+
+		posixBackend.Lock()
+		defer posixBackend.Unlock()
+
+		err = os.Rename(chunkedPutContext.file.Name(), chunkedPutContext.objectPath)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		err = posixRemoveFile(filepath.Dir(chunkedPutContext.objectPath), posixObjectHeadersPrefix+filepath.Base(chunkedPutContext.objectPath), true)
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+		err = posixSyncDir(filepath.Dir(chunkedPutContext.objectPath))
+		if nil != err {
+			return posixOSError(err, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+	if nil != err {
+		_ = os.Remove(chunkedPutContext.file.Name())
+		chunkedPutContext.err = err
+		return
+	}
+
+	volumeStats.ObjectPutCtxtBytes.Add(chunkedPutContext.bytesPut)
+	stats.IncrementOperations(&stats.SwiftObjPutCtxCloseOps)
+
+	return
+}
+
+func (chunkedPutContext *posixChunkedPutContextStruct) Read(offset uint64, length uint64) (buf []byte, err error) {
+	chunkedPutContext.Lock()
+	defer chunkedPutContext.Unlock()
+
+	if (offset + length) > chunkedPutContext.bytesPut {
+		err = blunder.NewError(blunder.BadHTTPGetError, "swiftclient.posixChunkedPutContext.Read() called for invalid range")
+		logger.ErrorfWithError(err, "%s called for invalid range", chunkedPutContext.opname("Read"))
+		return
+	}
+
+	buf = make([]byte, length)
+
+	_, err = chunkedPutContext.file.ReadAt(buf, int64(offset))
+	if nil != err {
+		err = blunder.NewError(blunder.BadHTTPGetError, "swiftclient.posixChunkedPutContext.Read() failed: %v", err)
+		logger.ErrorfWithError(err, "%s failed", chunkedPutContext.opname("Read"))
+		return
+	}
+
+	fetchVolumeStats(chunkedPutContext.posixBackend.accountName).ObjectPutCtxtReadBytes.Add(length)
+	stats.IncrementOperations(&stats.SwiftObjPutCtxReadOps)
+
+	return
+}
+
+func (chunkedPutContext *posixChunkedPutContextStruct) SendChunk(buf []byte) (err error) {
+	var (
+		clientReqTime = time.Now()
+		volumeStats   = fetchVolumeStats(chunkedPutContext.posixBackend.accountName)
+	)
+
+	chunkedPutContext.Lock()
+	defer chunkedPutContext.Unlock()
+
+	if !chunkedPutContext.active {
+		err = blunder.NewError(blunder.BadHTTPPutError, "called while inactive")
+		logger.PanicfWithError(err, "%s logic error", chunkedPutContext.opname("SendChunk"))
+		return
+	}
+
+	if 0 == len(buf) {
+		err = blunder.NewError(blunder.BadHTTPPutError, "called with zero-length buf")
+		logger.PanicfWithError(err, "%s logic error", chunkedPutContext.opname("SendChunk"))
+		return
+	}
+
+	if nil != chunkedPutContext.err {
+		err = chunkedPutContext.err
+		return
+	}
+
+	_, err = chunkedPutContext.file.Write(buf)
+	if nil != err {
+		_, err = posixOSError(err, chunkedPutContext.opname("SendChunk"))
+		chunkedPutContext.err = err
+		return
+	}
+
+	chunkedPutContext.bytesPut += uint64(len(buf))
+
+	volumeStats.ObjectPutCtxtSendChunkUsec.Add(uint64(time.Since(clientReqTime) / time.Microsecond))
+	volumeStats.ObjectPutCtxtSendChunkBytes.Add(uint64(len(buf)))
+	stats.IncrementOperations(&stats.SwiftObjPutCtxSendChunkOps)
+
+	return
+}
@@ -0,0 +1,327 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package swiftclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/transitions"
+)
+
+func TestPosixBackend(t *testing.T) {
+	var (
+		accountName         = "AUTH_posixtest"
+		buf                 []byte
+		bytesPut            uint64
+		chunkedPutContext   ChunkedPutContext
+		confMap             conf.ConfMap
+		confStrings         []string
+		containerList       []string
+		contentLength       uint64
+		err                 error
+		expectedObjectBytes []byte
+		headers             map[string][]string
+		objectList          []string
+		ok                  bool
+		posixPath           string
+		readLen             uint64
+	)
+
+	posixPath, err = ioutil.TempDir("", "swiftclient_posix_test")
+	if nil != err {
+		t.Fatalf("ioutil.TempDir() failed: %v", err)
+	}
+
+	confStrings = []string{
+		"TrackedLock.LockHoldTimeLimit=0s",
+		"TrackedLock.LockCheckPeriod=0s",
+
+		"Stats.IPAddr=localhost",
+		"Stats.UDPPort=52184",
+		"Stats.BufferLength=100",
+		"Stats.MaxLatency=1s",
+
+		"SwiftClient.NoAuthIPAddr=127.0.0.1",
+		"SwiftClient.NoAuthTCPPort=9999",
+		"SwiftClient.Timeout=10s",
+		"SwiftClient.RetryLimit=3",
+		"SwiftClient.RetryLimitObject=3",
+		"SwiftClient.RetryDelay=25ms",
+		"SwiftClient.RetryDelayObject=25ms",
+		"SwiftClient.RetryExpBackoff=1.2",
+		"SwiftClient.RetryExpBackoffObject=2.0",
+		"SwiftClient.ChunkedConnectionPoolSize=1",
+		"SwiftClient.NonChunkedConnectionPoolSize=1",
+
+		"Cluster.WhoAmI=Peer0",
+
+		"Peer:Peer0.ReadCacheQuotaFraction=0.20",
+
+		"FSGlobals.VolumeGroupList=",
+		"FSGlobals.EtcdEnabled=false",
+
+		"Logging.LogFilePath=/dev/null",
+		"Logging.LogToConsole=false",
+
+		"Volume:TestVolume0.AccountName=" + accountName,
+		"Volume:TestVolume0.ObjectStoreBackend=posix",
+		"Volume:TestVolume0.PosixPath=" + posixPath,
+	}
+
+	confMap, err = conf.MakeConfMapFromStrings(confStrings)
+	if nil != err {
+		t.Fatalf("conf.MakeConfMapFromStrings() failed: %v", err)
+	}
+
+	err = transitions.Up(confMap)
+	if nil != err {
+		t.Fatalf("transitions.Up() failed: %v", err)
+	}
+
+	_, ok = fetchBackend(accountName).(*posixBackendStruct)
+	if !ok {
+		t.Fatalf("fetchBackend(accountName) should have returned a posix backend")
+	}
+
+	// Account and Container operations (including names needing escaping)
+
+	_, err = AccountHead(accountName)
+	if http.StatusNotFound != blunder.HTTPCode(err) {
+		t.Fatalf("AccountHead() of missing Account should have returned 404 but returned: %v", err)
+	}
+
+	err = AccountPut(accountName, map[string][]string{"X-Account-Meta-Dog": {"Spike"}})
+	if nil != err {
+		t.Fatalf("AccountPut() failed: %v", err)
+	}
+	err = ContainerPut(accountName, ".TestContainer", map[string][]string{"X-Container-Meta-Cat": {"Dinah"}})
+	if nil != err {
+		t.Fatalf("ContainerPut() failed: %v", err)
+	}
+	err = ContainerPost(accountName, ".TestContainer", map[string][]string{"X-Container-Meta-Mouse": {"Jerry"}})
+	if nil != err {
+		t.Fatalf("ContainerPost() failed: %v", err)
+	}
+	err = ContainerPost(accountName, ".TestContainer", map[string][]string{"X-Container-Meta-Cat": {""}})
+	if nil != err {
+		t.Fatalf("ContainerPost() failed: %v", err)
+	}
+	headers, err = ContainerHead(accountName, ".TestContainer")
+	if (nil != err) || !reflect.DeepEqual(headers, map[string][]string{"X-Container-Meta-Mouse": {"Jerry"}}) {
+		t.Fatalf("ContainerHead() returned %v and %v", headers, err)
+	}
+	err = ContainerPost(accountName, "MissingContainer", map[string][]string{"X-Container-Meta-Cat": {"Dinah"}})
+	if !blunder.Is(err, blunder.NotFoundError) {
+		t.Fatalf("ContainerPost() of missing Container should have returned NotFoundError but returned: %v", err)
+	}
+	headers, containerList, err = AccountGet(accountName)
+	if (nil != err) || !reflect.DeepEqual(headers, map[string][]string{"X-Account-Meta-Dog": {"Spike"}}) || !reflect.DeepEqual(containerList, []string{".TestContainer"}) {
+		t.Fatalf("AccountGet() returned %v, %v, and %v", headers, containerList, err)
+	}
+
+	// Chunked PUTs
+
+	chunkedPutContext, err = ObjectFetchChunkedPutContext(accountName, ".TestContainer", "FooBar", "")
+	if nil != err {
+		t.Fatalf("ObjectFetchChunkedPutContext() failed: %v", err)
+	}
+
+	expectedObjectBytes = make([]byte, 0)
+
+	for _, chunk := range []string{"01234", "56789", "abcde", "fghij", "klmno"} {
+		err = chunkedPutContext.SendChunk([]byte(chunk))
+		if nil != err {
+			t.Fatalf("SendChunk() failed: %v", err)
+		}
+		expectedObjectBytes = append(expectedObjectBytes, []byte(chunk)...)
+	}
+
+	bytesPut, err = chunkedPutContext.BytesPut()
+	if (nil != err) || (25 != bytesPut) {
+		t.Fatalf("BytesPut() returned %d and %v", bytesPut, err)
+	}
+	buf, err = chunkedPutContext.Read(3, 10)
+	if (nil != err) || ("3456789abc" != string(buf)) {
+		t.Fatalf("Read() returned \"%s\" and %v", string(buf), err)
+	}
+	_, err = chunkedPutContext.Read(20, 10)
+	if nil == err {
+		t.Fatalf("Read() beyond BytesPut() should have failed")
+	}
+
+	_, err = ObjectHead(accountName, ".TestContainer", "FooBar")
+	if !blunder.Is(err, blunder.NotFoundError) {
+		t.Fatalf("ObjectHead() prior to Close() should have returned NotFoundError but returned: %v", err)
+	}
+	_, objectList, err = ContainerGet(accountName, ".TestContainer")
+	if (nil != err) || (0 != len(objectList)) {
+		t.Fatalf("ContainerGet() prior to Close() returned %v and %v", objectList, err)
+	}
+
+	err = chunkedPutContext.Close()
+	if nil != err {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if chunkedPutContext.Active() {
+		t.Fatalf("Active() should have returned false after Close()")
+	}
+
+	chunkedPutContext, err = ObjectFetchChunkedPutContext(accountName, ".TestContainer", "dir/Small", "")
+	if nil != err {
+		t.Fatalf("ObjectFetchChunkedPutContext() failed: %v", err)
+	}
+	err = chunkedPutContext.SendChunk([]byte("xyz"))
+	if nil != err {
+		t.Fatalf("SendChunk() failed: %v", err)
+	}
+	err = chunkedPutContext.Close()
+	if nil != err {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	_, err = ObjectFetchChunkedPutContext(accountName, "MissingContainer", "FooBar", "")
+	if !blunder.Is(err, blunder.NotFoundError) {
+		t.Fatalf("ObjectFetchChunkedPutContext() into missing Container should have returned NotFoundError but returned: %v", err)
+	}
+
+	// Restart... verifying everything above persisted
+
+	err = transitions.Down(confMap)
+	if nil != err {
+		t.Fatalf("transitions.Down() failed: %v", err)
+	}
+	err = transitions.Up(confMap)
+	if nil != err {
+		t.Fatalf("transitions.Up() failed: %v", err)
+	}
+
+	// Object reads
+
+	buf, err = ObjectLoad(accountName, ".TestContainer", "FooBar")
+	if (nil != err) || !bytes.Equal(expectedObjectBytes, buf) {
+		t.Fatalf("ObjectLoad() returned \"%s\" and %v", string(buf), err)
+	}
+	buf, err = ObjectGet(accountName, ".TestContainer", "FooBar", 7, 4)
+	if (nil != err) || ("789a" != string(buf)) {
+		t.Fatalf("ObjectGet() returned \"%s\" and %v", string(buf), err)
+	}
+	buf, err = ObjectGet(accountName, ".TestContainer", "FooBar", 23, 4)
+	if (nil != err) || ("no" != string(buf)) {
+		t.Fatalf("ObjectGet() spanning the end of the Object returned \"%s\" and %v", string(buf), err)
+	}
+	_, err = ObjectGet(accountName, ".TestContainer", "FooBar", 25, 4)
+	if http.StatusRequestedRangeNotSatisfiable != blunder.HTTPCode(err) {
+		t.Fatalf("ObjectGet() beyond the end of the Object should have returned 416 but returned: %v", err)
+	}
+	buf = make([]byte, 5)
+	readLen, err = ObjectRead(accountName, ".TestContainer", "FooBar", 20, buf)
+	if (nil != err) || (5 != readLen) || ("klmno" != string(buf)) {
+		t.Fatalf("ObjectRead() returned %d, \"%s\", and %v", readLen, string(buf), err)
+	}
+	buf, err = ObjectTail(accountName, ".TestContainer", "FooBar", 3)
+	if (nil != err) || ("mno" != string(buf)) {
+		t.Fatalf("ObjectTail() returned \"%s\" and %v", string(buf), err)
+	}
+	buf, err = ObjectTail(accountName, ".TestContainer", "dir/Small", 10)
+	if (nil != err) || ("xyz" != string(buf)) {
+		t.Fatalf("ObjectTail() longer than the Object returned \"%s\" and %v", string(buf), err)
+	}
+	contentLength, err = ObjectContentLength(accountName, ".TestContainer", "dir/Small")
+	if (nil != err) || (3 != contentLength) {
+		t.Fatalf("ObjectContentLength() returned %d and %v", contentLength, err)
+	}
+
+	err = ObjectPost(accountName, ".TestContainer", "FooBar", map[string][]string{"X-Object-Meta-Owl": {"Hedwig"}})
+	if nil != err {
+		t.Fatalf("ObjectPost() failed: %v", err)
+	}
+	headers, err = ObjectHead(accountName, ".TestContainer", "FooBar")
+	if (nil != err) || !reflect.DeepEqual(headers, map[string][]string{"X-Object-Meta-Owl": {"Hedwig"}, "Content-Length": {"25"}}) {
+		t.Fatalf("ObjectHead() returned %v and %v", headers, err)
+	}
+	err = ObjectPost(accountName, ".TestContainer", "Missing", map[string][]string{"X-Object-Meta-Owl": {"Hedwig"}})
+	if !blunder.Is(err, blunder.NotFoundError) {
+		t.Fatalf("ObjectPost() of missing Object should have returned NotFoundError but returned: %v", err)
+	}
+
+	_, objectList, err = ContainerGet(accountName, ".TestContainer")
+	if (nil != err) || !reflect.DeepEqual(objectList, []string{"FooBar", "dir/Small"}) {
+		t.Fatalf("ContainerGet() returned %v and %v", objectList, err)
+	}
+
+	// ObjectCopy within the Account
+
+	err = ObjectCopy(accountName, ".TestContainer", "FooBar", accountName, ".TestContainer", "FooBarCopy", &testObjectCopyCallbackStruct{chunkSize: 7})
+	if nil != err {
+		t.Fatalf("ObjectCopy() failed: %v", err)
+	}
+	buf, err = ObjectLoad(accountName, ".TestContainer", "FooBarCopy")
+	if (nil != err) || !bytes.Equal(expectedObjectBytes, buf) {
+		t.Fatalf("ObjectLoad() of copied Object returned \"%s\" and %v", string(buf), err)
+	}
+
+	// Deletion (including of non-empty Containers and Accounts)
+
+	err = ContainerDelete(accountName, ".TestContainer")
+	if http.StatusConflict != blunder.HTTPCode(err) {
+		t.Fatalf("ContainerDelete() of non-empty Container should have returned 409 but returned: %v", err)
+	}
+	err = AccountDelete(accountName)
+	if http.StatusConflict != blunder.HTTPCode(err) {
+		t.Fatalf("AccountDelete() of non-empty Account should have returned 409 but returned: %v", err)
+	}
+
+	for _, objectName := range []string{"FooBar", "FooBarCopy", "dir/Small"} {
+		err = ObjectDelete(accountName, ".TestContainer", objectName, 0)
+		if nil != err {
+			t.Fatalf("ObjectDelete() failed: %v", err)
+		}
+	}
+	err = ObjectDelete(accountName, ".TestContainer", "FooBar", SkipRetry)
+	if !blunder.Is(err, blunder.NotFoundError) {
+		t.Fatalf("ObjectDelete() of deleted Object should have returned NotFoundError but returned: %v", err)
+	}
+
+	err = ContainerDelete(accountName, ".TestContainer")
+	if nil != err {
+		t.Fatalf("ContainerDelete() failed: %v", err)
+	}
+	err = AccountDelete(accountName)
+	if nil != err {
+		t.Fatalf("AccountDelete() failed: %v", err)
+	}
+
+	_, err = AccountHead(accountName)
+	if !blunder.Is(err, blunder.NotFoundError) {
+		t.Fatalf("AccountHead() of deleted Account should have returned NotFoundError but returned: %v", err)
+	}
+
+	err = transitions.Down(confMap)
+	if nil != err {
+		t.Fatalf("transitions.Down() failed: %v", err)
+	}
+
+	// Verify a missing PosixPath is rejected
+
+	err = confMap.UpdateFromString("Volume:TestVolume0.PosixPath=" + posixPath + "/missing")
+	if nil != err {
+		t.Fatalf("confMap.UpdateFromString() failed: %v", err)
+	}
+	err = configureBackends(confMap)
+	if nil == err {
+		t.Fatalf("configureBackends() should have failed for a missing PosixPath")
+	}
+
+	err = os.RemoveAll(posixPath)
+	if nil != err {
+		t.Fatalf("os.RemoveAll(posixPath) failed: %v", err)
+	}
+}
@@ -0,0 +1,1166 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+// S3-compatible Object Store Backend
+//
+// Each Account mapped to an S3 backend is held in a single bucket. Each Container
+// in that Account is held as the set of keys prefixed by "<ContainerName>/" and
+// each Object by the key "<ContainerName>/<ObjectName>". Since S3 has no notion
+// of an (empty) directory, the existence of a Container (along with its headers)
+// is recorded in a marker object keyed by "<ContainerName>/". The headers of the
+// Account itself are similarly recorded in a marker object (whose key contains
+// no "/" so as not to be mistaken for a Container).
+//
+// Swift headers are stored as S3 user metadata. As S3 user metadata names are
+// case-insensitive, header names are returned in http.CanonicalHeaderKey() form.
+// As in Swift, a header with an empty value removes that header.
+//
+// Objects PUT via ChunkedPutContext's are buffered until S3PartSize bytes have been
+// sent at which point a multipart upload is started. Hence, smaller Objects result
+// in a single PUT upon Close().
+
+package swiftclient
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/blunder"
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/s3client"
+	"github.com/NVIDIA/proxyfs/stats"
+	"github.com/NVIDIA/proxyfs/trackedlock"
+)
+
+const (
+	s3AccountMarkerKey = "account"
+
+	s3DefaultPartSize = uint64(8 * 1024 * 1024)
+	s3DefaultTimeout  = 60 * time.Second
+)
+
+type s3BackendStruct struct {
+	accountName string
+	bucketName  string
+	client      *s3client.Client
+	partSize    uint64
+}
+
+type s3ChunkedPutContextStruct struct {
+	trackedlock.Mutex
+	s3Backend     *s3BackendStruct
+	containerName string
+	objectName    string
+	key           string
+	active        bool
+	err           error
+	buf           []byte   // every byte sent thus far (retained to satisfy Read())
+	uploadID      string   // set once the first part is uploaded
+	uploadedBytes uint64   // leading bytes of buf already uploaded as parts
+	eTags         []string // of each uploaded part
+	fetchTime     time.Time
+}
+
+func newS3Backend(confMap conf.ConfMap, sectionName string, accountName string) (backend backendIf, err error) {
+	var (
+		clientConfig s3client.ClientConfig
+		s3Backend    *s3BackendStruct
+	)
+
+	s3Backend = &s3BackendStruct{
+		accountName: accountName,
+	}
+
+	clientConfig.Endpoint, err = confMap.FetchOptionValueString(sectionName, "S3Endpoint")
+	if nil != err {
+		return
+	}
+	clientConfig.Region, err = confMap.FetchOptionValueString(sectionName, "S3Region")
+	if nil != err {
+		clientConfig.Region = "" // If not present, default to s3client's default
+	}
+	clientConfig.AccessKeyID, err = confMap.FetchOptionValueString(sectionName, "S3AccessKeyID")
+	if nil != err {
+		return
+	}
+	clientConfig.SecretAccessKey, err = confMap.FetchOptionValueString(sectionName, "S3SecretAccessKey")
+	if nil != err {
+		return
+	}
+	clientConfig.Timeout, err = confMap.FetchOptionValueDuration(sectionName, "S3Timeout")
+	if nil != err {
+		clientConfig.Timeout = s3DefaultTimeout // If not present, default to 60s
+	}
+
+	s3Backend.bucketName, err = confMap.FetchOptionValueString(sectionName, "S3Bucket")
+	if nil != err {
+		return
+	}
+	s3Backend.partSize, err = confMap.FetchOptionValueUint64(sectionName, "S3PartSize")
+	if nil != err {
+		s3Backend.partSize = s3DefaultPartSize // If not present, default to 8MiB
+	}
+	if 0 == s3Backend.partSize {
+		err = fmt.Errorf("%s.S3PartSize must be non-zero", sectionName)
+		return
+	}
+
+	s3Backend.client, err = s3client.NewClient(&clientConfig)
+	if nil != err {
+		return
+	}
+
+	backend = s3Backend
+
+	err = nil
+	return
+}
+
+// s3Error converts an error returned by package s3client into the form returned
+// by the Swift backend. Errors not reported by S3 itself (e.g. connection failures),
+// S3 server errors, and throttling are deemed retriable.
+//
+func s3Error(s3Err error, transportFsErr blunder.FsError, opname string) (retriable bool, err error) {
+	var (
+		errorStruct *s3client.ErrorStruct
+		fsErr       blunder.FsError
+		ok          bool
+	)
+
+	errorStruct, ok = s3Err.(*s3client.ErrorStruct)
+	if !ok {
+		err = blunder.AddError(fmt.Errorf("%s failed: %v", opname, s3Err), transportFsErr)
+		logger.WarnfWithError(err, "%s got s3client error", opname)
+		retriable = true
+		return
+	}
+
+	_, fsErr = httpStatusIsError(errorStruct.StatusCode)
+	err = blunder.NewError(fsErr, "%s returned HTTP StatusCode %d Code %s Message %s", opname, errorStruct.StatusCode, errorStruct.Code, errorStruct.Message)
+	err = blunder.AddHTTPCode(err, errorStruct.StatusCode)
+	if http.StatusNotFound != errorStruct.StatusCode {
+		logger.WarnfWithError(err, "%s got bad status", opname)
+	}
+
+	retriable = (http.StatusInternalServerError <= errorStruct.StatusCode) || (http.StatusRequestTimeout == errorStruct.StatusCode) || (http.StatusTooManyRequests == errorStruct.StatusCode)
+
+	return
+}
+
+func s3ConflictError(opname string, message string) (retriable bool, err error) {
+	retriable, err = s3Error(&s3client.ErrorStruct{StatusCode: http.StatusConflict, Code: "Conflict", Message: message}, blunder.BadHTTPDeleteError, opname)
+	return
+}
+
+func s3MergeHeadersIntoMetadata(metadata map[string]string, headers map[string][]string) {
+	var (
+		headerName   string
+		headerValues []string
+	)
+
+	for headerName, headerValues = range headers {
+		if (0 == len(headerValues)) || ("" == strings.Join(headerValues, "")) {
+			delete(metadata, strings.ToLower(headerName))
+		} else {
+			metadata[strings.ToLower(headerName)] = strings.Join(headerValues, ",")
+		}
+	}
+}
+
+func s3MetadataToHeaders(metadata map[string]string) (headers map[string][]string) {
+	var (
+		metadataName  string
+		metadataValue string
+	)
+
+	headers = make(map[string][]string)
+
+	for metadataName, metadataValue = range metadata {
+		headers[http.CanonicalHeaderKey(metadataName)] = []string{metadataValue}
+	}
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) containerKey(containerName string) (key string) {
+	key = containerName + "/"
+	return
+}
+
+func (s3Backend *s3BackendStruct) objectKey(containerName string, objectName string) (key string) {
+	key = containerName + "/" + objectName
+	return
+}
+
+// ensureBucket creates the bucket if it does not yet exist much like Swift will
+// auto-create an Account.
+//
+func (s3Backend *s3BackendStruct) ensureBucket() (err error) {
+	err = s3Backend.client.HeadBucket(s3Backend.bucketName)
+	if s3client.IsNotFound(err) {
+		err = s3Backend.client.CreateBucket(s3Backend.bucketName)
+	}
+	return
+}
+
+// accountMetadata returns the metadata of the Account marker object (if any).
+//
+func (s3Backend *s3BackendStruct) accountMetadata() (metadata map[string]string, err error) {
+	metadata, _, err = s3Backend.client.HeadObject(s3Backend.bucketName, s3AccountMarkerKey)
+	if s3client.IsNotFound(err) {
+		metadata = make(map[string]string)
+		err = s3Backend.client.HeadBucket(s3Backend.bucketName)
+	}
+	return
+}
+
+// markerMetadata returns the metadata of the marker object at key or, if the marker
+// object does not exist and missingOK is set, an empty metadata map.
+//
+func (s3Backend *s3BackendStruct) markerMetadata(key string, missingOK bool) (metadata map[string]string, err error) {
+	metadata, _, err = s3Backend.client.HeadObject(s3Backend.bucketName, key)
+	if missingOK && s3client.IsNotFound(err) {
+		metadata = make(map[string]string)
+		err = nil
+	}
+	return
+}
+
+func (s3Backend *s3BackendStruct) name() (backendName string) {
+	return BackendNameS3
+}
+
+func (s3Backend *s3BackendStruct) accountDelete(accountName string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3AccountDelete(\"%v\")", accountName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftAccountDeleteRetryOps,
+			retrySuccessCnt:   &stats.SwiftAccountDeleteRetrySuccessOps,
+			clientRequestTime: &volumeStats.AccountDeleteUsec,
+			clientFailureCnt:  &volumeStats.AccountDeleteFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountDeleteUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountDeleteRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			commonPrefixes []string
+			err            error
+		)
+
+		_, commonPrefixes, err = s3Backend.client.ListObjects(s3Backend.bucketName, "", "/")
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPDeleteError, opname)
+		}
+		if 0 != len(commonPrefixes) {
+			return s3ConflictError(opname, "Account not empty")
+		}
+		err = s3Backend.client.DeleteObject(s3Backend.bucketName, s3AccountMarkerKey)
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPDeleteError, opname)
+		}
+		err = s3Backend.client.DeleteBucket(s3Backend.bucketName)
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPDeleteError, opname)
+		}
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) accountGet(accountName string) (headers map[string][]string, containerList []string, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3AccountGet(\"%v\")", accountName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftAccountGetRetryOps,
+			retrySuccessCnt:   &stats.SwiftAccountGetRetrySuccessOps,
+			clientRequestTime: &volumeStats.AccountGetUsec,
+			clientFailureCnt:  &volumeStats.AccountGetFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountGetUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountGetRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			commonPrefix   string
+			commonPrefixes []string
+			err            error
+			metadata       map[string]string
+		)
+
+		metadata, err = s3Backend.accountMetadata()
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPGetError, opname)
+		}
+		_, commonPrefixes, err = s3Backend.client.ListObjects(s3Backend.bucketName, "", "/")
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPGetError, opname)
+		}
+
+		headers = s3MetadataToHeaders(metadata)
+		containerList = make([]string, 0, len(commonPrefixes))
+		for _, commonPrefix = range commonPrefixes {
+			containerList = append(containerList, strings.TrimSuffix(commonPrefix, "/"))
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) accountHead(accountName string) (headers map[string][]string, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3AccountHead(\"%v\")", accountName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftAccountHeadRetryOps,
+			retrySuccessCnt:   &stats.SwiftAccountHeadRetrySuccessOps,
+			clientRequestTime: &volumeStats.AccountHeadUsec,
+			clientFailureCnt:  &volumeStats.AccountHeadFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountHeadUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountHeadRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err      error
+			metadata map[string]string
+		)
+
+		metadata, err = s3Backend.accountMetadata()
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPHeadError, opname)
+		}
+
+		headers = s3MetadataToHeaders(metadata)
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) accountPost(accountName string, headers map[string][]string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3AccountPost(\"%v\")", accountName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftAccountPostRetryOps,
+			retrySuccessCnt:   &stats.SwiftAccountPostRetrySuccessOps,
+			clientRequestTime: &volumeStats.AccountPostUsec,
+			clientFailureCnt:  &volumeStats.AccountPostFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountPostUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountPostRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err      error
+			metadata map[string]string
+		)
+
+		metadata, err = s3Backend.accountMetadata()
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+		s3MergeHeadersIntoMetadata(metadata, headers)
+		err = s3Backend.client.PutObject(s3Backend.bucketName, s3AccountMarkerKey, metadata, []byte{})
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) accountPut(accountName string, headers map[string][]string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3AccountPut(\"%v\")", accountName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftAccountPutRetryOps,
+			retrySuccessCnt:   &stats.SwiftAccountPutRetrySuccessOps,
+			clientRequestTime: &volumeStats.AccountPutUsec,
+			clientFailureCnt:  &volumeStats.AccountPutFailure,
+			swiftRequestTime:  &volumeStats.SwiftAccountPutUsec,
+			swiftRetryOps:     &volumeStats.SwiftAccountPutRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err      error
+			metadata map[string]string
+		)
+
+		err = s3Backend.ensureBucket()
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+		metadata, err = s3Backend.accountMetadata()
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+		s3MergeHeadersIntoMetadata(metadata, headers)
+		err = s3Backend.client.PutObject(s3Backend.bucketName, s3AccountMarkerKey, metadata, []byte{})
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) containerDelete(accountName string, containerName string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ContainerDelete(\"%v/%v\")", accountName, containerName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftContainerDeleteRetryOps,
+			retrySuccessCnt:   &stats.SwiftContainerDeleteRetrySuccessOps,
+			clientRequestTime: &volumeStats.ContainerDeleteUsec,
+			clientFailureCnt:  &volumeStats.ContainerDeleteFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerDeleteUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerDeleteRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err  error
+			keys []string
+		)
+
+		_, err = s3Backend.markerMetadata(s3Backend.containerKey(containerName), false)
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPDeleteError, opname)
+		}
+		keys, _, err = s3Backend.client.ListObjects(s3Backend.bucketName, s3Backend.containerKey(containerName), "")
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPDeleteError, opname)
+		}
+		if (1 < len(keys)) || ((1 == len(keys)) && (s3Backend.containerKey(containerName) != keys[0])) {
+			return s3ConflictError(opname, "Container not empty")
+		}
+		err = s3Backend.client.DeleteObject(s3Backend.bucketName, s3Backend.containerKey(containerName))
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPDeleteError, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) containerGet(accountName string, containerName string) (headers map[string][]string, objectList []string, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ContainerGet(\"%v/%v\")", accountName, containerName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftContainerGetRetryOps,
+			retrySuccessCnt:   &stats.SwiftContainerGetRetrySuccessOps,
+			clientRequestTime: &volumeStats.ContainerGetUsec,
+			clientFailureCnt:  &volumeStats.ContainerGetFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerGetUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerGetRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err      error
+			key      string
+			keys     []string
+			metadata map[string]string
+		)
+
+		metadata, err = s3Backend.markerMetadata(s3Backend.containerKey(containerName), false)
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPGetError, opname)
+		}
+		keys, _, err = s3Backend.client.ListObjects(s3Backend.bucketName, s3Backend.containerKey(containerName), "")
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPGetError, opname)
+		}
+
+		headers = s3MetadataToHeaders(metadata)
+		objectList = make([]string, 0, len(keys))
+		for _, key = range keys {
+			if s3Backend.containerKey(containerName) != key {
+				objectList = append(objectList, strings.TrimPrefix(key, s3Backend.containerKey(containerName)))
+			}
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) containerHead(accountName string, containerName string) (headers map[string][]string, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ContainerHead(\"%v/%v\")", accountName, containerName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftContainerHeadRetryOps,
+			retrySuccessCnt:   &stats.SwiftContainerHeadRetrySuccessOps,
+			clientRequestTime: &volumeStats.ContainerHeadUsec,
+			clientFailureCnt:  &volumeStats.ContainerHeadFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerHeadUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerHeadRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err      error
+			metadata map[string]string
+		)
+
+		metadata, err = s3Backend.markerMetadata(s3Backend.containerKey(containerName), false)
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPHeadError, opname)
+		}
+
+		headers = s3MetadataToHeaders(metadata)
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) containerPost(accountName string, containerName string, headers map[string][]string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ContainerPost(\"%v/%v\")", accountName, containerName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftContainerPostRetryOps,
+			retrySuccessCnt:   &stats.SwiftContainerPostRetrySuccessOps,
+			clientRequestTime: &volumeStats.ContainerPostUsec,
+			clientFailureCnt:  &volumeStats.ContainerPostFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerPostUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerPostRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err      error
+			metadata map[string]string
+		)
+
+		metadata, err = s3Backend.markerMetadata(s3Backend.containerKey(containerName), false)
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+		s3MergeHeadersIntoMetadata(metadata, headers)
+		err = s3Backend.client.PutObject(s3Backend.bucketName, s3Backend.containerKey(containerName), metadata, []byte{})
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) containerPut(accountName string, containerName string, headers map[string][]string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ContainerPut(\"%v/%v\")", accountName, containerName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftContainerPutRetryOps,
+			retrySuccessCnt:   &stats.SwiftContainerPutRetrySuccessOps,
+			clientRequestTime: &volumeStats.ContainerPutUsec,
+			clientFailureCnt:  &volumeStats.ContainerPutFailure,
+			swiftRequestTime:  &volumeStats.SwiftContainerPutUsec,
+			swiftRetryOps:     &volumeStats.SwiftContainerPutRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err      error
+			metadata map[string]string
+		)
+
+		err = s3Backend.ensureBucket()
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+		metadata, err = s3Backend.markerMetadata(s3Backend.containerKey(containerName), true)
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+		s3MergeHeadersIntoMetadata(metadata, headers)
+		err = s3Backend.client.PutObject(s3Backend.bucketName, s3Backend.containerKey(containerName), metadata, []byte{})
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(false, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) objectContentLength(accountName string, containerName string, objectName string) (length uint64, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ObjectContentLength(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjContentLengthRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjContentLengthRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectContentLengthUsec,
+			clientFailureCnt:  &volumeStats.ObjectContentLengthFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectContentLengthUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectContentLengthRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+		_, length, err = s3Backend.client.HeadObject(s3Backend.bucketName, s3Backend.objectKey(containerName, objectName))
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPHeadError, opname)
+		}
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+
+	return
+}
+
+// objectDelete deletes the named Object. Note that, unlike Swift, S3 reports
+// success when deleting a non-existent Object.
+//
+func (s3Backend *s3BackendStruct) objectDelete(accountName string, containerName string, objectName string, operationOptions OperationOptions) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ObjectDelete(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjDeleteRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjDeleteRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectDeleteUsec,
+			clientFailureCnt:  &volumeStats.ObjectDeleteFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectDeleteUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectDeleteRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+		err = s3Backend.client.DeleteObject(s3Backend.bucketName, s3Backend.objectKey(containerName, objectName))
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPDeleteError, opname)
+		}
+		return false, nil
+	}
+
+	if (operationOptions & SkipRetry) == SkipRetry {
+		_, err = request()
+	} else {
+		err = backendRequestWithRetry(true, opname, &statnm, request)
+	}
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) objectFetchChunkedPutContext(accountName string, containerName string, objectName string, useReserveForVolumeName string) (chunkedPutContext ChunkedPutContext, err error) {
+	// Note that there are no connections to reserve (and no request to issue
+	// until either S3PartSize bytes have been sent or Close() is called)
+
+	chunkedPutContext = &s3ChunkedPutContextStruct{
+		s3Backend:     s3Backend,
+		containerName: containerName,
+		objectName:    objectName,
+		key:           s3Backend.objectKey(containerName, objectName),
+		active:        true,
+		err:           nil,
+		buf:           make([]byte, 0),
+		uploadID:      "",
+		uploadedBytes: 0,
+		eTags:         make([]string, 0),
+		fetchTime:     time.Now(),
+	}
+
+	stats.IncrementOperations(&stats.SwiftObjPutCtxFetchOps)
+
+	err = nil
+	return
+}
+
+func (s3Backend *s3BackendStruct) objectGet(accountName string, containerName string, objectName string, offset uint64, length uint64) (buf []byte, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ObjectGet(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjGetRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjGetRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectGetUsec,
+			clientFailureCnt:  &volumeStats.ObjectGetFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectGetUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectGetRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+		buf, err = s3Backend.client.GetObjectRange(s3Backend.bucketName, s3Backend.objectKey(containerName, objectName), offset, length)
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPGetError, opname)
+		}
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+	if nil == err {
+		volumeStats.ObjectGetBytes.Add(uint64(len(buf)))
+	}
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) objectHead(accountName string, containerName string, objectName string) (headers map[string][]string, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ObjectHead(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjHeadRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjHeadRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectHeadUsec,
+			clientFailureCnt:  &volumeStats.ObjectHeadFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectHeadUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectHeadRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			contentLength uint64
+			err           error
+			metadata      map[string]string
+		)
+
+		metadata, contentLength, err = s3Backend.client.HeadObject(s3Backend.bucketName, s3Backend.objectKey(containerName, objectName))
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPHeadError, opname)
+		}
+
+		headers = s3MetadataToHeaders(metadata)
+		headers["Content-Length"] = []string{strconv.FormatUint(contentLength, 10)}
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) objectLoad(accountName string, containerName string, objectName string) (buf []byte, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ObjectLoad(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjLoadRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjLoadRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectLoadUsec,
+			clientFailureCnt:  &volumeStats.ObjectLoadFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectLoadUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectLoadRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+		_, buf, err = s3Backend.client.GetObject(s3Backend.bucketName, s3Backend.objectKey(containerName, objectName))
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPGetError, opname)
+		}
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+	if nil == err {
+		volumeStats.ObjectLoadBytes.Add(uint64(len(buf)))
+	}
+
+	return
+}
+
+// objectPost replaces the headers of the named Object. As S3 object metadata is
+// immutable, this is accomplished by copying the Object onto itself.
+//
+func (s3Backend *s3BackendStruct) objectPost(accountName string, containerName string, objectName string, headers map[string][]string) (err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ObjectPost(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjPostRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjPostRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectPostUsec,
+			clientFailureCnt:  &volumeStats.ObjectPostFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectPostUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectPostRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			err      error
+			key      = s3Backend.objectKey(containerName, objectName)
+			metadata = make(map[string]string)
+		)
+
+		s3MergeHeadersIntoMetadata(metadata, headers)
+		err = s3Backend.client.CopyObject(s3Backend.bucketName, key, s3Backend.bucketName, key, metadata)
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) objectRead(accountName string, containerName string, objectName string, offset uint64, buf []byte) (readLen uint64, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ObjectRead(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjReadRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjReadRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectReadUsec,
+			clientFailureCnt:  &volumeStats.ObjectReadFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectReadUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectReadRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var (
+			body []byte
+			err  error
+		)
+
+		body, err = s3Backend.client.GetObjectRange(s3Backend.bucketName, s3Backend.objectKey(containerName, objectName), offset, uint64(len(buf)))
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPGetError, opname)
+		}
+
+		readLen = uint64(copy(buf, body))
+
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+	if nil == err {
+		volumeStats.ObjectReadBytes.Add(readLen)
+	}
+
+	return
+}
+
+func (s3Backend *s3BackendStruct) objectTail(accountName string, containerName string, objectName string, length uint64) (buf []byte, err error) {
+	var (
+		opname      = fmt.Sprintf("swiftclient.s3ObjectTail(\"%v/%v/%v\")", accountName, containerName, objectName)
+		volumeStats = fetchVolumeStats(accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjTailRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjTailRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectTailUsec,
+			clientFailureCnt:  &volumeStats.ObjectTailFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectTailUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectTailRetryOps,
+		}
+	)
+
+	request := func() (bool, error) {
+		var err error
+		buf, err = s3Backend.client.GetObjectTail(s3Backend.bucketName, s3Backend.objectKey(containerName, objectName), length)
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPGetError, opname)
+		}
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+	if nil == err {
+		volumeStats.ObjectTailBytes.Add(uint64(len(buf)))
+	}
+
+	return
+}
+
+func (chunkedPutContext *s3ChunkedPutContextStruct) opname(op string) (opname string) {
+	opname = fmt.Sprintf("swiftclient.s3ChunkedPutContext.%s(\"%v/%v/%v\")", op, chunkedPutContext.s3Backend.accountName, chunkedPutContext.containerName, chunkedPutContext.objectName)
+	return
+}
+
+// uploadPart uploads the next part of a multipart upload (first initiating the
+// multipart upload if necessary). Called with chunkedPutContext locked.
+//
+func (chunkedPutContext *s3ChunkedPutContextStruct) uploadPart(part []byte) (err error) {
+	var (
+		eTag        string
+		opname      = chunkedPutContext.opname("uploadPart")
+		volumeStats = fetchVolumeStats(chunkedPutContext.s3Backend.accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjPutCtxtCloseRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjPutCtxtCloseRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectPutCtxtCloseUsec,
+			clientFailureCnt:  &volumeStats.ObjectPutCtxtCloseFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectPutCtxtCloseUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectPutCtxtCloseRetryOps,
+		}
+		s3Backend = chunkedPutContext.s3Backend
+	)
+
+	request := func() (bool, error) {
+		var err error
+		if "" == chunkedPutContext.uploadID {
+			chunkedPutContext.uploadID, err = s3Backend.client.CreateMultipartUpload(s3Backend.bucketName, chunkedPutContext.key, nil)
+			if nil != err {
+				return s3Error(err, blunder.BadHTTPPutError, opname)
+			}
+		}
+		eTag, err = s3Backend.client.UploadPart(s3Backend.bucketName, chunkedPutContext.key, chunkedPutContext.uploadID, uint32(len(chunkedPutContext.eTags)+1), part)
+		if nil != err {
+			return s3Error(err, blunder.BadHTTPPutError, opname)
+		}
+		return false, nil
+	}
+
+	err = backendRequestWithRetry(true, opname, &statnm, request)
+	if nil != err {
+		return
+	}
+
+	chunkedPutContext.eTags = append(chunkedPutContext.eTags, eTag)
+	chunkedPutContext.uploadedBytes += uint64(len(part))
+
+	return
+}
+
+// abort discards any multipart upload in progress. Called with chunkedPutContext locked.
+//
+func (chunkedPutContext *s3ChunkedPutContextStruct) abort() {
+	var (
+		err       error
+		s3Backend = chunkedPutContext.s3Backend
+	)
+
+	if "" != chunkedPutContext.uploadID {
+		err = s3Backend.client.AbortMultipartUpload(s3Backend.bucketName, chunkedPutContext.key, chunkedPutContext.uploadID)
+		if nil != err {
+			logger.WarnfWithError(err, "%s failed", chunkedPutContext.opname("abort"))
+		}
+		chunkedPutContext.uploadID = ""
+	}
+}
+
+func (chunkedPutContext *s3ChunkedPutContextStruct) Active() (active bool) {
+	chunkedPutContext.Lock()
+	active = chunkedPutContext.active
+	chunkedPutContext.Unlock()
+
+	stats.IncrementOperations(&stats.SwiftObjPutCtxActiveOps)
+
+	return
+}
+
+func (chunkedPutContext *s3ChunkedPutContextStruct) BytesPut() (bytesPut uint64, err error) {
+	chunkedPutContext.Lock()
+	bytesPut = uint64(len(chunkedPutContext.buf))
+	chunkedPutContext.Unlock()
+
+	stats.IncrementOperations(&stats.SwiftObjPutCtxBytesPutOps)
+	fetchVolumeStats(chunkedPutContext.s3Backend.accountName).ObjectPutCtxtBytesPut.Add(1)
+
+	err = nil
+	return
+}
+
+func (chunkedPutContext *s3ChunkedPutContextStruct) Close() (err error) {
+	var (
+		opname      = chunkedPutContext.opname("Close")
+		volumeStats = fetchVolumeStats(chunkedPutContext.s3Backend.accountName)
+		statnm      = requestStatistics{
+			retryCnt:          &stats.SwiftObjPutCtxtCloseRetryOps,
+			retrySuccessCnt:   &stats.SwiftObjPutCtxtCloseRetrySuccessOps,
+			clientRequestTime: &volumeStats.ObjectPutCtxtCloseUsec,
+			clientFailureCnt:  &volumeStats.ObjectPutCtxtCloseFailure,
+			swiftRequestTime:  &volumeStats.SwiftObjectPutCtxtCloseUsec,
+			swiftRetryOps:     &volumeStats.SwiftObjectPutCtxtCloseRetryOps,
+		}
+		s3Backend = chunkedPutContext.s3Backend
+	)
+
+	chunkedPutContext.Lock()
+	defer chunkedPutContext.Unlock()
+
+	volumeStats.ObjectPutCtxtFetchToCloseUsec.Add(uint64(time.Since(chunkedPutContext.fetchTime) / time.Microsecond))
+
+	if !chunkedPutContext.active {
+		err = blunder.NewError(blunder.BadHTTPPutError, "called while inactive")
+		logger.PanicfWithError(err, "%s logic error", opname)
+		return
+	}
+
+	chunkedPutContext.active = false
+
+	if nil != chunkedPutContext.err {
+		chunkedPutContext.abort()
+		err = chunkedPutContext.err
+		return
+	}
+
+	if "" == chunkedPutContext.uploadID {
+		request := func() (bool, error) {
+			var err error
+			err = s3Backend.client.PutObject(s3Backend.bucketName, chunkedPutContext.key, nil, chunkedPutContext.buf)
+			if nil != err {
+				return s3Error(err, blunder.BadHTTPPutError, opname)
+			}
+			return false, nil
+		}
+
+		err = backendRequestWithRetry(true, opname, &statnm, request)
+	} else {
+		if chunkedPutContext.uploadedBytes < uint64(len(chunkedPutContext.buf)) {
+			err = chunkedPutContext.uploadPart(chunkedPutContext.buf[chunkedPutContext.uploadedBytes:])
+		}
+
+		if nil == err {
+			request := func() (bool, error) {
+				var err error
+				err = s3Backend.client.CompleteMultipartUpload(s3Backend.bucketName, chunkedPutContext.key, chunkedPutContext.uploadID, chunkedPutContext.eTags)
+				if nil != err {
+					return s3Error(err, blunder.BadHTTPPutError, opname)
+				}
+				return false, nil
+			}
+
+			err = backendRequestWithRetry(true, opname, &statnm, request)
+		}
+
+		if nil != err {
+			chunkedPutContext.abort()
+		}
+	}
+
+	if nil != err {
+		chunkedPutContext.err = err
+		return
+	}
+
+	volumeStats.ObjectPutCtxtBytes.Add(uint64(len(chunkedPutContext.buf)))
+	stats.IncrementOperations(&stats.SwiftObjPutCtxCloseOps)
+
+	return
+}
+
+func (chunkedPutContext *s3ChunkedPutContextStruct) Read(offset uint64, length uint64) (buf []byte, err error) {
+	chunkedPutContext.Lock()
+	defer chunkedPutContext.Unlock()
+
+	if (offset + length) > uint64(len(chunkedPutContext.buf)) {
+		err = blunder.NewError(blunder.BadHTTPGetError, "swiftclient.s3ChunkedPutContext.Read() called for invalid range")
+		logger.ErrorfWithError(err, "%s called for invalid range", chunkedPutContext.opname("Read"))
+		return
+	}
+
+	buf = make([]byte, length)
+	copy(buf, chunkedPutContext.buf[offset:offset+length])
+
+	fetchVolumeStats(chunkedPutContext.s3Backend.accountName).ObjectPutCtxtReadBytes.Add(length)
+	stats.IncrementOperations(&stats.SwiftObjPutCtxReadOps)
+
+	return
+}
+
+func (chunkedPutContext *s3ChunkedPutContextStruct) SendChunk(buf []byte) (err error) {
+	var (
+		clientReqTime = time.Now()
+		volumeStats   = fetchVolumeStats(chunkedPutContext.s3Backend.accountName)
+	)
+
+	chunkedPutContext.Lock()
+	defer chunkedPutContext.Unlock()
+
+	if !chunkedPutContext.active {
+		err = blunder.NewError(blunder.BadHTTPPutError, "called while inactive")
+		logger.PanicfWithError(err, "%s logic error", chunkedPutContext.opname("SendChunk"))
+		return
+	}
+
+	if 0 == len(buf) {
+		err = blunder.NewError(blunder.BadHTTPPutError, "called with zero-length buf")
+		logger.PanicfWithError(err, "%s logic error", chunkedPutContext.opname("SendChunk"))
+		return
+	}
+
+	if nil != chunkedPutContext.err {
+		err = chunkedPutContext.err
+		return
+	}
+
+	chunkedPutContext.buf = append(chunkedPutContext.buf, buf...)
+
+	for (uint64(len(chunkedPutContext.buf)) - chunkedPutContext.uploadedBytes) >= chunkedPutContext.s3Backend.partSize {
+		err = chunkedPutContext.uploadPart(chunkedPutContext.buf[chunkedPutContext.uploadedBytes : chunkedPutContext.uploadedBytes+chunkedPutContext.s3Backend.partSize])
+		if nil != err {
+			chunkedPutContext.err = err
+			return
+		}
+	}
+
+	volumeStats.ObjectPutCtxtSendChunkUsec.Add(uint64(time.Since(clientReqTime) / time.Microsecond))
+	volumeStats.ObjectPutCtxtSendChunkBytes.Add(uint64(len(buf)))
+	stats.IncrementOperations(&stats.SwiftObjPutCtxSendChunkOps)
+
+	return
+}
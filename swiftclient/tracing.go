@@ -0,0 +1,23 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package swiftclient
+
+import (
+	"github.com/NVIDIA/proxyfs/tracing"
+)
+
+// startSpan starts the Span covering an API call naming the given Account,
+// Container, and Object (the latter two may be "").
+//
+func startSpan(opName string, accountName string, containerName string, objectName string) (span *tracing.SpanStruct) {
+	span = tracing.StartSpan("swiftclient."+opName, tracing.SpanKindClient)
+	span.SetAttribute("swift.account", accountName)
+	if "" != containerName {
+		span.SetAttribute("swift.container", containerName)
+	}
+	if "" != objectName {
+		span.SetAttribute("swift.object", objectName)
+	}
+	return
+}
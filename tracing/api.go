@@ -0,0 +1,228 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tracing provides distributed tracing of requests as they flow from
+// pfsagentd through retryrpc (or the jrpcfs JSON-RPC server) into fs and
+// swiftclient. Completed Spans are exported in OTLP/JSON format to a local file
+// and/or an OTLP/HTTP collector endpoint.
+//
+// Trace context is carried between processes in the W3C "traceparent" format
+// (e.g. "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01").
+//
+// Within a process, each goroutine has (at most) a single "current" Span. A Span
+// started by StartSpan() becomes a child of the calling goroutine's current Span
+// (or the root of a new Trace if there is none) and becomes the goroutine's new
+// current Span until End() is called. This avoids threading a context through
+// every fs and inode API. Note that work handed off to another goroutine will
+// not be associated with the Trace unless that goroutine explicitly starts a
+// Span via StartSpanFromTraceParent().
+//
+// Sampling decisions are made at the root of each Trace according to the
+// configured SampleRatio. Spans whose parent was not sampled are not recorded
+// but still propagate their (unsampled) trace context such that downstream
+// processes make the same decision.
+//
+// To configure tracing, the following section is used:
+//
+//	[Tracing]
+//	Enabled:          true
+//	ServiceName:      proxyfsd                            # defaults to the program name
+//	SampleRatio:      0.01                                # defaults to 1.0 (trace everything)
+//	ExportFilePath:   /var/log/proxyfsd/traces.json       # OTLP/JSON, one ExportTraceServiceRequest per line
+//	ExportEndpoint:   http://127.0.0.1:4318/v1/traces     # OTLP/HTTP collector accepting JSON
+//	ExportInterval:   5s                                  # defaults to 5s
+//	ExportBatchSize:  512                                 # defaults to 512
+//	ExportQueueDepth: 4096                                # defaults to 4096 (Spans beyond this are dropped)
+//
+// At least one of ExportFilePath or ExportEndpoint must be specified. If the
+// [Tracing] section is missing or Enabled is false, tracing is disabled and
+// all of the functions below are (nearly) free.
+//
+// All methods of *SpanStruct may be safely invoked on a nil *SpanStruct.
+//
+package tracing
+
+import (
+	"time"
+
+	"github.com/NVIDIA/proxyfs/conf"
+)
+
+// SpanKind values are as specified by OTLP.
+//
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1 // e.g. an fs API call
+	SpanKindServer   SpanKind = 2 // e.g. an RPC being served or a FUSE upcall
+	SpanKindClient   SpanKind = 3 // e.g. an RPC being sent or a Swift request
+)
+
+// SpanStruct records a single timed operation within a Trace.
+//
+type SpanStruct struct {
+	name          string
+	kind          SpanKind
+	traceID       [16]byte
+	spanID        [8]byte
+	parentSpanID  [8]byte // All zeroes if this is the root of the Trace
+	sampled       bool    // If false, Span is not recorded (but trace context is still propagated)
+	startTime     time.Time
+	endTime       time.Time
+	attributes    []attributeStruct
+	statusMessage string // If non-empty, Span ended in error
+	goID          uint64 // Goroutine for which this Span is (or was) current (0 if detached)
+	prevSpan      *SpanStruct
+	ended         bool
+}
+
+// ConfigStruct specifies how tracing is to be performed. It is normally
+// obtained from a call to ParseConfMap().
+//
+type ConfigStruct struct {
+	ServiceName      string
+	SampleRatio      float64
+	ExportFilePath   string
+	ExportEndpoint   string
+	ExportInterval   time.Duration
+	ExportBatchSize  uint64
+	ExportQueueDepth uint64
+}
+
+// ParseConfMap returns the tracing configuration specified in the [Tracing]
+// section of confMap. If tracing is not enabled, config will be nil.
+//
+// Programs (e.g. proxyfsd) using package transitions need not call this
+// function (nor Start() or Stop()) as this package is registered with package
+// transitions.
+//
+func ParseConfMap(confMap conf.ConfMap) (config *ConfigStruct, err error) {
+	config, err = parseConfMap(confMap)
+	return
+}
+
+// Start enables tracing (and Span export) as specified by config.
+//
+func Start(config *ConfigStruct) (err error) {
+	err = start(config)
+	return
+}
+
+// Stop disables tracing after exporting any remaining completed Spans.
+//
+func Stop() (err error) {
+	err = stop()
+	return
+}
+
+// StartSpan starts a Span that is a child of the calling goroutine's current
+// Span (if any) and makes it the calling goroutine's current Span. If tracing
+// is not enabled, nil is returned.
+//
+func StartSpan(name string, kind SpanKind) (span *SpanStruct) {
+	span = startSpan(name, kind, nil)
+	return
+}
+
+// StartSpanFromTraceParent starts a Span that is a child of the (typically
+// remote) Span described by traceParent and makes it the calling goroutine's
+// current Span. If traceParent is empty (or cannot be parsed), this behaves
+// like StartSpan(). If tracing is not enabled, nil is returned.
+//
+func StartSpanFromTraceParent(name string, kind SpanKind, traceParent string) (span *SpanStruct) {
+	var (
+		err           error
+		parentContext *spanContextStruct
+	)
+
+	if "" != traceParent {
+		parentContext, err = parseTraceParent(traceParent)
+		if nil != err {
+			parentContext = nil
+		}
+	}
+
+	span = startSpan(name, kind, parentContext)
+
+	return
+}
+
+// StartDetachedSpan starts a Span that is a child of the (typically remote) Span
+// described by traceParent (or the root of a new Trace if traceParent is empty)
+// but that does not become any goroutine's current Span. This is useful when a
+// Span is to be started and ended on different goroutines. If tracing is not
+// enabled, nil is returned.
+//
+func StartDetachedSpan(name string, kind SpanKind, traceParent string) (span *SpanStruct) {
+	var (
+		err           error
+		parentContext *spanContextStruct
+	)
+
+	if "" != traceParent {
+		parentContext, err = parseTraceParent(traceParent)
+		if nil != err {
+			parentContext = nil
+		}
+	}
+
+	span = startDetachedSpan(name, kind, parentContext)
+
+	return
+}
+
+// CurrentTraceParent returns the traceparent of the calling goroutine's current
+// Span or "" if there is none.
+//
+func CurrentTraceParent() (traceParent string) {
+	traceParent = currentSpan().TraceParent()
+	return
+}
+
+// TraceParent returns the traceparent to be sent to a remote process such that
+// its Spans become children of span.
+//
+func (span *SpanStruct) TraceParent() (traceParent string) {
+	if nil == span {
+		traceParent = ""
+	} else {
+		traceParent = span.traceParent()
+	}
+	return
+}
+
+// SetAttribute records an attribute of span. Supported value types are string,
+// bool, float64, and the signed and unsigned integer types. Values of any other
+// type are recorded via fmt.Sprintf("%v").
+//
+func (span *SpanStruct) SetAttribute(key string, value interface{}) {
+	if (nil != span) && span.sampled {
+		span.attributes = append(span.attributes, attributeStruct{key: key, value: value})
+	}
+}
+
+// SetError records that span failed with err (if non-nil).
+//
+func (span *SpanStruct) SetError(err error) {
+	if (nil != span) && (nil != err) {
+		span.statusMessage = err.Error()
+	}
+}
+
+// End completes span, restoring the calling goroutine's prior current Span,
+// and queues span for export (if it was sampled).
+//
+func (span *SpanStruct) End() {
+	if nil != span {
+		span.end()
+	}
+}
+
+// EndWithError is a convenience function equivalent to SetError(err) followed by End().
+//
+func (span *SpanStruct) EndWithError(err error) {
+	if nil != span {
+		span.SetError(err)
+		span.end()
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/utils"
+)
+
+const (
+	traceParentVersion     = "00"
+	traceParentSampledFlag = 0x01
+	traceParentFieldCount  = 4
+	detachedGoID           = 0 // Goroutine IDs start at 1
+)
+
+type spanContextStruct struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+}
+
+type attributeStruct struct {
+	key   string
+	value interface{}
+}
+
+type globalsStruct struct {
+	sync.Mutex
+	enabled           uint32 // Atomically accessed to avoid taking the Mutex when tracing is disabled
+	config            *ConfigStruct
+	sampleThreshold   uint64                 // Root Spans are sampled if the low 63 bits of their TraceID are below this
+	rand              *rand.Rand             // Protected by Mutex
+	currentSpanMap    map[uint64]*SpanStruct // Key: goroutine ID
+	spanChan          chan *SpanStruct       // Completed & sampled Spans awaiting export
+	exporterStopChan  chan struct{}
+	exporterDoneChan  chan struct{}
+	exportedSpanCount uint64
+	droppedSpanCount  uint64
+}
+
+var globals globalsStruct
+
+func isEnabled() (enabled bool) {
+	enabled = (0 != atomic.LoadUint32(&globals.enabled))
+	return
+}
+
+func parseTraceParent(traceParent string) (spanContext *spanContextStruct, err error) {
+	var (
+		flags       []byte
+		spanIDBuf   []byte
+		traceIDBuf  []byte
+		fieldSlice  []string
+		allZeroes   bool
+		traceIDByte byte
+	)
+
+	fieldSlice = strings.Split(traceParent, "-")
+	if (traceParentFieldCount != len(fieldSlice)) || (traceParentVersion != fieldSlice[0]) {
+		err = fmt.Errorf("traceparent \"%s\" not understood", traceParent)
+		return
+	}
+
+	traceIDBuf, err = hex.DecodeString(fieldSlice[1])
+	if (nil != err) || (16 != len(traceIDBuf)) {
+		err = fmt.Errorf("traceparent \"%s\" contains invalid trace-id", traceParent)
+		return
+	}
+
+	spanIDBuf, err = hex.DecodeString(fieldSlice[2])
+	if (nil != err) || (8 != len(spanIDBuf)) {
+		err = fmt.Errorf("traceparent \"%s\" contains invalid parent-id", traceParent)
+		return
+	}
+
+	flags, err = hex.DecodeString(fieldSlice[3])
+	if (nil != err) || (1 != len(flags)) {
+		err = fmt.Errorf("traceparent \"%s\" contains invalid trace-flags", traceParent)
+		return
+	}
+
+	allZeroes = true
+	for _, traceIDByte = range traceIDBuf {
+		if 0 != traceIDByte {
+			allZeroes = false
+			break
+		}
+	}
+	if allZeroes {
+		err = fmt.Errorf("traceparent \"%s\" contains all zeroes trace-id", traceParent)
+		return
+	}
+
+	spanContext = &spanContextStruct{
+		sampled: (traceParentSampledFlag == (flags[0] & traceParentSampledFlag)),
+	}
+
+	copy(spanContext.traceID[:], traceIDBuf)
+	copy(spanContext.spanID[:], spanIDBuf)
+
+	err = nil
+	return
+}
+
+func (span *SpanStruct) traceParent() (traceParent string) {
+	var (
+		flags byte
+	)
+
+	if span.sampled {
+		flags = traceParentSampledFlag
+	} else {
+		flags = 0
+	}
+
+	traceParent = fmt.Sprintf("%s-%s-%s-%02x", traceParentVersion, hex.EncodeToString(span.traceID[:]), hex.EncodeToString(span.spanID[:]), flags)
+
+	return
+}
+
+// computeSampleThreshold converts sampleRatio into a threshold to compare
+// against the low 63 bits of a TraceID (as do other OTel implementations).
+//
+func computeSampleThreshold(sampleRatio float64) (sampleThreshold uint64) {
+	if sampleRatio >= 1.0 {
+		sampleThreshold = math.MaxUint64
+	} else if sampleRatio <= 0.0 {
+		sampleThreshold = 0
+	} else {
+		sampleThreshold = uint64(sampleRatio * float64(uint64(1)<<63))
+	}
+	return
+}
+
+func currentSpan() (span *SpanStruct) {
+	if !isEnabled() {
+		span = nil
+		return
+	}
+
+	span = fetchCurrentSpan(utils.GetGoId())
+
+	return
+}
+
+func fetchCurrentSpan(goID uint64) (span *SpanStruct) {
+	globals.Lock()
+	if nil == globals.currentSpanMap {
+		span = nil
+	} else {
+		span = globals.currentSpanMap[goID]
+	}
+	globals.Unlock()
+
+	return
+}
+
+func startSpan(name string, kind SpanKind, parentContext *spanContextStruct) (span *SpanStruct) {
+	if !isEnabled() {
+		span = nil
+		return
+	}
+
+	span = startSpanForGoID(name, kind, parentContext, utils.GetGoId())
+
+	return
+}
+
+func startDetachedSpan(name string, kind SpanKind, parentContext *spanContextStruct) (span *SpanStruct) {
+	if !isEnabled() {
+		span = nil
+		return
+	}
+
+	span = startSpanForGoID(name, kind, parentContext, detachedGoID)
+
+	return
+}
+
+// startSpanForGoID starts a Span that will become the current Span of goroutine goID
+// unless goID == detachedGoID.
+//
+func startSpanForGoID(name string, kind SpanKind, parentContext *spanContextStruct, goID uint64) (span *SpanStruct) {
+	span = &SpanStruct{
+		name:      name,
+		kind:      kind,
+		startTime: time.Now(),
+		goID:      goID,
+	}
+
+	globals.Lock()
+
+	if nil == globals.rand {
+		// Tracing was disabled since our caller checked isEnabled()
+
+		globals.Unlock()
+		span = nil
+		return
+	}
+
+	if detachedGoID == goID {
+		span.prevSpan = nil
+	} else {
+		span.prevSpan = globals.currentSpanMap[goID]
+	}
+
+	if nil == parentContext {
+		if nil == span.prevSpan {
+			// Start a new Trace subject to sampling
+
+			_, _ = globals.rand.Read(span.traceID[:])
+			span.sampled = ((binary.BigEndian.Uint64(span.traceID[8:]) >> 1) < globals.sampleThreshold)
+		} else {
+			span.traceID = span.prevSpan.traceID
+			span.parentSpanID = span.prevSpan.spanID
+			span.sampled = span.prevSpan.sampled
+		}
+	} else {
+		span.traceID = parentContext.traceID
+		span.parentSpanID = parentContext.spanID
+		span.sampled = parentContext.sampled
+	}
+
+	_, _ = globals.rand.Read(span.spanID[:])
+
+	if detachedGoID != goID {
+		globals.currentSpanMap[goID] = span
+	}
+
+	globals.Unlock()
+
+	return
+}
+
+func (span *SpanStruct) end() {
+	globals.Lock()
+
+	if span.ended {
+		globals.Unlock()
+		return
+	}
+
+	span.ended = true
+	span.endTime = time.Now()
+
+	if (detachedGoID != span.goID) && (nil != globals.currentSpanMap) {
+		if span == globals.currentSpanMap[span.goID] {
+			if nil == span.prevSpan {
+				delete(globals.currentSpanMap, span.goID)
+			} else {
+				globals.currentSpanMap[span.goID] = span.prevSpan
+			}
+		}
+	}
+
+	span.prevSpan = nil
+
+	if span.sampled && (nil != globals.spanChan) {
+		select {
+		case globals.spanChan <- span:
+		default:
+			globals.droppedSpanCount++
+		}
+	}
+
+	globals.Unlock()
+}
@@ -0,0 +1,334 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testStart(t *testing.T, exportFilePath string, sampleRatio float64) {
+	var (
+		err error
+	)
+
+	err = Start(&ConfigStruct{
+		ServiceName:      "tracing.test",
+		SampleRatio:      sampleRatio,
+		ExportFilePath:   exportFilePath,
+		ExportEndpoint:   "",
+		ExportInterval:   time.Second,
+		ExportBatchSize:  2,
+		ExportQueueDepth: 100,
+	})
+	if nil != err {
+		t.Fatalf("Start() failed: %v", err)
+	}
+}
+
+func testFetchExportedSpans(t *testing.T, exportFilePath string) (otlpSpanSlice []*otlpSpanStruct) {
+	var (
+		err          error
+		exportFile   *os.File
+		otlpSpan     *otlpSpanStruct
+		scanner      *bufio.Scanner
+		tracesData   *otlpTracesDataStruct
+		resourceSpan *otlpResourceSpansStruct
+		scopeSpan    *otlpScopeSpansStruct
+	)
+
+	otlpSpanSlice = make([]*otlpSpanStruct, 0)
+
+	exportFile, err = os.Open(exportFilePath)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return
+		}
+		t.Fatalf("os.Open() failed: %v", err)
+	}
+	defer exportFile.Close()
+
+	scanner = bufio.NewScanner(exportFile)
+
+	for scanner.Scan() {
+		tracesData = &otlpTracesDataStruct{}
+		err = json.Unmarshal(scanner.Bytes(), tracesData)
+		if nil != err {
+			t.Fatalf("json.Unmarshal() of exported line failed: %v", err)
+		}
+		for _, resourceSpan = range tracesData.ResourceSpans {
+			if (0 == len(resourceSpan.Resource.Attributes)) || ("service.name" != resourceSpan.Resource.Attributes[0].Key) || ("tracing.test" != *resourceSpan.Resource.Attributes[0].Value.StringValue) {
+				t.Fatalf("exported Resource missing service.name")
+			}
+			for _, scopeSpan = range resourceSpan.ScopeSpans {
+				for _, otlpSpan = range scopeSpan.Spans {
+					otlpSpanSlice = append(otlpSpanSlice, otlpSpan)
+				}
+			}
+		}
+	}
+
+	err = scanner.Err()
+	if nil != err {
+		t.Fatalf("scanner.Err() returned: %v", err)
+	}
+
+	return
+}
+
+func TestTraceParent(t *testing.T) {
+	var (
+		err         error
+		span        *SpanStruct
+		spanContext *spanContextStruct
+		traceParent string
+	)
+
+	for _, traceParent = range []string{
+		"",
+		"01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331",
+		"00-0af7651916cd43dd8448eb211c80319-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b716920333-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-1",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+	} {
+		_, err = parseTraceParent(traceParent)
+		if nil == err {
+			t.Fatalf("parseTraceParent(\"%s\") should have failed", traceParent)
+		}
+	}
+
+	for _, traceParent = range []string{
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00",
+	} {
+		spanContext, err = parseTraceParent(traceParent)
+		if nil != err {
+			t.Fatalf("parseTraceParent(\"%s\") failed: %v", traceParent, err)
+		}
+
+		span = &SpanStruct{
+			traceID: spanContext.traceID,
+			spanID:  spanContext.spanID,
+			sampled: spanContext.sampled,
+		}
+
+		if traceParent != span.TraceParent() {
+			t.Fatalf("TraceParent() returned \"%s\" (expected \"%s\")", span.TraceParent(), traceParent)
+		}
+	}
+
+	// Tracing is not enabled, so the following should all be (nil-safe) no-ops
+
+	span = StartSpan("disabled", SpanKindInternal)
+	if nil != span {
+		t.Fatalf("StartSpan() should have returned nil when tracing is disabled")
+	}
+
+	span.SetAttribute("key", "value")
+	span.EndWithError(fmt.Errorf("error"))
+
+	if "" != CurrentTraceParent() {
+		t.Fatalf("CurrentTraceParent() should have returned \"\" when tracing is disabled")
+	}
+}
+
+func TestSpans(t *testing.T) {
+	var (
+		childSpan      *SpanStruct
+		detachedSpan   *SpanStruct
+		err            error
+		exportFilePath string
+		otlpSpan       *otlpSpanStruct
+		otlpSpanMap    map[string]*otlpSpanStruct
+		otlpSpanSlice  []*otlpSpanStruct
+		remoteSpan     *SpanStruct
+		remoteWG       sync.WaitGroup
+		rootSpan       *SpanStruct
+		testDir        string
+	)
+
+	testDir, err = ioutil.TempDir("", "ProxyFS_test_tracing_")
+	if nil != err {
+		t.Fatalf("ioutil.TempDir() failed: %v", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(testDir)
+	}()
+
+	exportFilePath = filepath.Join(testDir, "traces.json")
+
+	testStart(t, exportFilePath, 1.0)
+
+	err = Start(&ConfigStruct{})
+	if nil == err {
+		t.Fatalf("Start() should have failed when already started")
+	}
+
+	rootSpan = StartSpan("root", SpanKindServer)
+	rootSpan.SetAttribute("uint64", uint64(1))
+	rootSpan.SetAttribute("string", "two")
+	rootSpan.SetAttribute("bool", true)
+
+	if rootSpan.TraceParent() != CurrentTraceParent() {
+		t.Fatalf("CurrentTraceParent() should have returned rootSpan's traceparent")
+	}
+
+	childSpan = StartSpan("child", SpanKindClient)
+
+	if childSpan.TraceParent() != CurrentTraceParent() {
+		t.Fatalf("CurrentTraceParent() should have returned childSpan's traceparent")
+	}
+
+	// Simulate propagation to a remote process (i.e. a different goroutine)
+
+	remoteWG.Add(1)
+	go func(traceParent string) {
+		if "" != CurrentTraceParent() {
+			t.Errorf("CurrentTraceParent() should have returned \"\" in a new goroutine")
+		}
+		remoteSpan = StartSpanFromTraceParent("remote", SpanKindServer, traceParent)
+		remoteSpan.EndWithError(fmt.Errorf("remote failure"))
+		remoteWG.Done()
+	}(childSpan.TraceParent())
+	remoteWG.Wait()
+
+	childSpan.End()
+
+	// A detached Span should not become (nor disturb) the current Span
+
+	detachedSpan = StartDetachedSpan("detached", SpanKindServer, "")
+	if rootSpan.TraceParent() != CurrentTraceParent() {
+		t.Fatalf("CurrentTraceParent() should have returned rootSpan's traceparent after StartDetachedSpan()")
+	}
+	detachedSpan.End()
+
+	if rootSpan.TraceParent() != CurrentTraceParent() {
+		t.Fatalf("CurrentTraceParent() should have returned rootSpan's traceparent after childSpan.End()")
+	}
+
+	rootSpan.End()
+	rootSpan.End() // Should be a no-op
+
+	if "" != CurrentTraceParent() {
+		t.Fatalf("CurrentTraceParent() should have returned \"\" after rootSpan.End()")
+	}
+
+	err = Stop()
+	if nil != err {
+		t.Fatalf("Stop() failed: %v", err)
+	}
+
+	err = Stop()
+	if nil == err {
+		t.Fatalf("Stop() should have failed when not started")
+	}
+
+	otlpSpanSlice = testFetchExportedSpans(t, exportFilePath)
+	if 4 != len(otlpSpanSlice) {
+		t.Fatalf("expected 4 exported Spans (got %d)", len(otlpSpanSlice))
+	}
+
+	otlpSpanMap = make(map[string]*otlpSpanStruct)
+	for _, otlpSpan = range otlpSpanSlice {
+		otlpSpanMap[otlpSpan.Name] = otlpSpan
+	}
+
+	if ("" != otlpSpanMap["root"].ParentSpanID) || (3 != len(otlpSpanMap["root"].Attributes)) || (SpanKindServer != otlpSpanMap["root"].Kind) {
+		t.Fatalf("unexpected root Span: %+v", otlpSpanMap["root"])
+	}
+	if "1" != *otlpSpanMap["root"].Attributes[0].Value.IntValue {
+		t.Fatalf("unexpected root Span uint64 attribute")
+	}
+	if (otlpSpanMap["root"].TraceID != otlpSpanMap["child"].TraceID) || (otlpSpanMap["root"].SpanID != otlpSpanMap["child"].ParentSpanID) {
+		t.Fatalf("child Span not a child of root Span")
+	}
+	if (otlpSpanMap["child"].TraceID != otlpSpanMap["remote"].TraceID) || (otlpSpanMap["child"].SpanID != otlpSpanMap["remote"].ParentSpanID) {
+		t.Fatalf("remote Span not a child of child Span")
+	}
+	if (otlpStatusCodeError != otlpSpanMap["remote"].Status.Code) || ("remote failure" != otlpSpanMap["remote"].Status.Message) {
+		t.Fatalf("remote Span should have recorded an error status")
+	}
+	if ("" != otlpSpanMap["detached"].ParentSpanID) || (otlpSpanMap["root"].TraceID == otlpSpanMap["detached"].TraceID) {
+		t.Fatalf("detached Span should have started a new Trace")
+	}
+	if otlpStatusCodeUnset != otlpSpanMap["root"].Status.Code {
+		t.Fatalf("root Span should not have recorded an error status")
+	}
+}
+
+func TestSampling(t *testing.T) {
+	var (
+		childSpan      *SpanStruct
+		err            error
+		exportFilePath string
+		remoteSpan     *SpanStruct
+		rootSpan       *SpanStruct
+		testDir        string
+	)
+
+	testDir, err = ioutil.TempDir("", "ProxyFS_test_tracing_")
+	if nil != err {
+		t.Fatalf("ioutil.TempDir() failed: %v", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(testDir)
+	}()
+
+	exportFilePath = filepath.Join(testDir, "traces.json")
+
+	testStart(t, exportFilePath, 0.0)
+
+	// With a SampleRatio of 0.0, no new Trace is sampled...
+
+	rootSpan = StartSpan("unsampledRoot", SpanKindServer)
+	if nil == rootSpan {
+		t.Fatalf("StartSpan() should have returned a (non-recording) Span")
+	}
+	if !strings.HasSuffix(rootSpan.TraceParent(), "-00") {
+		t.Fatalf("unsampled Span's traceparent (\"%s\") should have trace-flags 00", rootSpan.TraceParent())
+	}
+
+	// ...nor any of its children...
+
+	childSpan = StartSpan("unsampledChild", SpanKindInternal)
+	if childSpan.TraceParent() == rootSpan.TraceParent() {
+		t.Fatalf("child Span should have its own span-id")
+	}
+	if !strings.HasSuffix(childSpan.TraceParent(), "-00") {
+		t.Fatalf("child of unsampled Span should not be sampled")
+	}
+	childSpan.End()
+	rootSpan.End()
+
+	// ...but a remote parent's sampling decision is honored
+
+	remoteSpan = StartSpanFromTraceParent("sampledRemote", SpanKindServer, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	remoteSpan.End()
+
+	err = Stop()
+	if nil != err {
+		t.Fatalf("Stop() failed: %v", err)
+	}
+
+	if 1 != len(testFetchExportedSpans(t, exportFilePath)) {
+		t.Fatalf("expected only the Span with a sampled remote parent to be exported")
+	}
+
+	if 0 != computeSampleThreshold(0.0) {
+		t.Fatalf("computeSampleThreshold(0.0) should return 0")
+	}
+	if (uint64(1) << 62) != computeSampleThreshold(0.5) {
+		t.Fatalf("computeSampleThreshold(0.5) should return 1<<62")
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/conf"
+	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/transitions"
+)
+
+const (
+	defaultSampleRatio      = 1.0
+	defaultExportInterval   = 5 * time.Second
+	defaultExportBatchSize  = 512
+	defaultExportQueueDepth = 4096
+)
+
+func init() {
+	transitions.Register("tracing", &globals)
+}
+
+func parseConfMap(confMap conf.ConfMap) (config *ConfigStruct, err error) {
+	var (
+		enabled bool
+	)
+
+	enabled, err = confMap.FetchOptionValueBool("Tracing", "Enabled")
+	if (nil != err) || !enabled {
+		// Treat a missing [Tracing] section (or Enabled key) as tracing disabled
+
+		config = nil
+		err = nil
+		return
+	}
+
+	config = &ConfigStruct{}
+
+	config.ServiceName, err = confMap.FetchOptionValueString("Tracing", "ServiceName")
+	if (nil != err) || ("" == config.ServiceName) {
+		config.ServiceName = filepath.Base(os.Args[0])
+	}
+
+	config.SampleRatio, err = confMap.FetchOptionValueFloat64("Tracing", "SampleRatio")
+	if nil != err {
+		config.SampleRatio = defaultSampleRatio
+	}
+	if (config.SampleRatio < 0.0) || (config.SampleRatio > 1.0) {
+		err = fmt.Errorf("[Tracing]SampleRatio (%v) must be between 0.0 and 1.0", config.SampleRatio)
+		return
+	}
+
+	config.ExportFilePath, err = confMap.FetchOptionValueString("Tracing", "ExportFilePath")
+	if nil != err {
+		config.ExportFilePath = ""
+	}
+
+	config.ExportEndpoint, err = confMap.FetchOptionValueString("Tracing", "ExportEndpoint")
+	if nil != err {
+		config.ExportEndpoint = ""
+	}
+
+	if ("" == config.ExportFilePath) && ("" == config.ExportEndpoint) {
+		err = fmt.Errorf("[Tracing]ExportFilePath and/or [Tracing]ExportEndpoint must be specified")
+		return
+	}
+
+	config.ExportInterval, err = confMap.FetchOptionValueDuration("Tracing", "ExportInterval")
+	if (nil != err) || (0 == config.ExportInterval) {
+		config.ExportInterval = defaultExportInterval
+	}
+
+	config.ExportBatchSize, err = confMap.FetchOptionValueUint64("Tracing", "ExportBatchSize")
+	if (nil != err) || (0 == config.ExportBatchSize) {
+		config.ExportBatchSize = defaultExportBatchSize
+	}
+
+	config.ExportQueueDepth, err = confMap.FetchOptionValueUint64("Tracing", "ExportQueueDepth")
+	if (nil != err) || (0 == config.ExportQueueDepth) {
+		config.ExportQueueDepth = defaultExportQueueDepth
+	}
+
+	err = nil
+	return
+}
+
+func start(config *ConfigStruct) (err error) {
+	globals.Lock()
+
+	if nil != globals.config {
+		globals.Unlock()
+		err = fmt.Errorf("tracing already started")
+		return
+	}
+
+	globals.config = config
+	globals.sampleThreshold = computeSampleThreshold(config.SampleRatio)
+	globals.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	globals.currentSpanMap = make(map[uint64]*SpanStruct)
+	globals.spanChan = make(chan *SpanStruct, config.ExportQueueDepth)
+	globals.exporterStopChan = make(chan struct{})
+	globals.exporterDoneChan = make(chan struct{})
+	globals.exportedSpanCount = 0
+	globals.droppedSpanCount = 0
+
+	go exporter(config, globals.spanChan, globals.exporterStopChan, globals.exporterDoneChan)
+
+	atomic.StoreUint32(&globals.enabled, 1)
+
+	globals.Unlock()
+
+	logger.Infof("tracing enabled (ServiceName: %s SampleRatio: %v ExportFilePath: \"%s\" ExportEndpoint: \"%s\")", config.ServiceName, config.SampleRatio, config.ExportFilePath, config.ExportEndpoint)
+
+	err = nil
+	return
+}
+
+func stop() (err error) {
+	var (
+		droppedSpanCount  uint64
+		exportedSpanCount uint64
+		exporterDoneChan  chan struct{}
+	)
+
+	globals.Lock()
+
+	if nil == globals.config {
+		globals.Unlock()
+		err = fmt.Errorf("tracing not started")
+		return
+	}
+
+	atomic.StoreUint32(&globals.enabled, 0)
+
+	// Spans still in progress will not be queued for export once spanChan is nil
+
+	globals.spanChan = nil
+	globals.currentSpanMap = nil
+	globals.rand = nil
+	globals.config = nil
+
+	close(globals.exporterStopChan)
+	exporterDoneChan = globals.exporterDoneChan
+
+	globals.exporterStopChan = nil
+	globals.exporterDoneChan = nil
+
+	globals.Unlock()
+
+	<-exporterDoneChan
+
+	globals.Lock()
+	droppedSpanCount = globals.droppedSpanCount
+	exportedSpanCount = globals.exportedSpanCount
+	globals.Unlock()
+
+	logger.Infof("tracing disabled (%d Spans exported, %d Spans dropped)", exportedSpanCount, droppedSpanCount)
+
+	err = nil
+	return
+}
+
+func (dummy *globalsStruct) Up(confMap conf.ConfMap) (err error) {
+	var (
+		config *ConfigStruct
+	)
+
+	config, err = parseConfMap(confMap)
+	if (nil != err) || (nil == config) {
+		return
+	}
+
+	err = start(config)
+
+	return
+}
+
+func (dummy *globalsStruct) VolumeGroupCreated(confMap conf.ConfMap, volumeGroupName string, activePeer string, virtualIPAddr string) (err error) {
+	return nil
+}
+func (dummy *globalsStruct) VolumeGroupMoved(confMap conf.ConfMap, volumeGroupName string, activePeer string, virtualIPAddr string) (err error) {
+	return nil
+}
+func (dummy *globalsStruct) VolumeGroupDestroyed(confMap conf.ConfMap, volumeGroupName string) (err error) {
+	return nil
+}
+func (dummy *globalsStruct) VolumeCreated(confMap conf.ConfMap, volumeName string, volumeGroupName string) (err error) {
+	return nil
+}
+func (dummy *globalsStruct) VolumeMoved(confMap conf.ConfMap, volumeName string, volumeGroupName string) (err error) {
+	return nil
+}
+func (dummy *globalsStruct) VolumeDestroyed(confMap conf.ConfMap, volumeName string) (err error) {
+	return nil
+}
+func (dummy *globalsStruct) ServeVolume(confMap conf.ConfMap, volumeName string) (err error) {
+	return nil
+}
+func (dummy *globalsStruct) UnserveVolume(confMap conf.ConfMap, volumeName string) (err error) {
+	return nil
+}
+func (dummy *globalsStruct) VolumeToBeUnserved(confMap conf.ConfMap, volumeName string) (err error) {
+	return nil
+}
+func (dummy *globalsStruct) SignaledStart(confMap conf.ConfMap) (err error) {
+	return nil
+}
+
+// SignaledFinish applies any change to the [Tracing] section (e.g. SampleRatio)
+// by restarting tracing.
+//
+func (dummy *globalsStruct) SignaledFinish(confMap conf.ConfMap) (err error) {
+	var (
+		config *ConfigStruct
+	)
+
+	config, err = parseConfMap(confMap)
+	if nil != err {
+		return
+	}
+
+	if isEnabled() {
+		err = stop()
+		if nil != err {
+			return
+		}
+	}
+
+	if nil != config {
+		err = start(config)
+	}
+
+	return
+}
+
+func (dummy *globalsStruct) Down(confMap conf.ConfMap) (err error) {
+	if isEnabled() {
+		err = stop()
+	} else {
+		err = nil
+	}
+
+	return
+}
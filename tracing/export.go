@@ -0,0 +1,321 @@
+// Copyright (c) 2015-2021, NVIDIA CORPORATION.
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/NVIDIA/proxyfs/logger"
+	"github.com/NVIDIA/proxyfs/version"
+)
+
+const (
+	otlpScopeName             = "github.com/NVIDIA/proxyfs"
+	otlpStatusCodeUnset       = 0
+	otlpStatusCodeError       = 2
+	exportFilePerm            = 0644
+	exportEndpointContentType = "application/json"
+)
+
+// The following structs marshal (via encoding/json) to the OTLP/JSON encoding
+// of an ExportTraceServiceRequest message.
+
+type otlpTracesDataStruct struct {
+	ResourceSpans []*otlpResourceSpansStruct `json:"resourceSpans"`
+}
+
+type otlpResourceSpansStruct struct {
+	Resource   otlpResourceStruct      `json:"resource"`
+	ScopeSpans []*otlpScopeSpansStruct `json:"scopeSpans"`
+}
+
+type otlpResourceStruct struct {
+	Attributes []*otlpKeyValueStruct `json:"attributes"`
+}
+
+type otlpScopeSpansStruct struct {
+	Scope otlpScopeStruct   `json:"scope"`
+	Spans []*otlpSpanStruct `json:"spans"`
+}
+
+type otlpScopeStruct struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpSpanStruct struct {
+	TraceID           string                `json:"traceId"`
+	SpanID            string                `json:"spanId"`
+	ParentSpanID      string                `json:"parentSpanId,omitempty"`
+	Name              string                `json:"name"`
+	Kind              SpanKind              `json:"kind"`
+	StartTimeUnixNano string                `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string                `json:"endTimeUnixNano"`
+	Attributes        []*otlpKeyValueStruct `json:"attributes,omitempty"`
+	Status            otlpStatusStruct      `json:"status"`
+}
+
+type otlpKeyValueStruct struct {
+	Key   string             `json:"key"`
+	Value otlpAnyValueStruct `json:"value"`
+}
+
+type otlpAnyValueStruct struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // OTLP/JSON encodes 64-bit integers as strings
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpStatusStruct struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func newOTLPKeyValue(key string, value interface{}) (keyValue *otlpKeyValueStruct) {
+	var (
+		boolValue   bool
+		doubleValue float64
+		intValue    string
+		stringValue string
+	)
+
+	keyValue = &otlpKeyValueStruct{Key: key}
+
+	switch v := value.(type) {
+	case string:
+		stringValue = v
+		keyValue.Value.StringValue = &stringValue
+	case bool:
+		boolValue = v
+		keyValue.Value.BoolValue = &boolValue
+	case float64:
+		doubleValue = v
+		keyValue.Value.DoubleValue = &doubleValue
+	case int:
+		intValue = strconv.FormatInt(int64(v), 10)
+		keyValue.Value.IntValue = &intValue
+	case int8:
+		intValue = strconv.FormatInt(int64(v), 10)
+		keyValue.Value.IntValue = &intValue
+	case int16:
+		intValue = strconv.FormatInt(int64(v), 10)
+		keyValue.Value.IntValue = &intValue
+	case int32:
+		intValue = strconv.FormatInt(int64(v), 10)
+		keyValue.Value.IntValue = &intValue
+	case int64:
+		intValue = strconv.FormatInt(v, 10)
+		keyValue.Value.IntValue = &intValue
+	case uint:
+		intValue = strconv.FormatUint(uint64(v), 10)
+		keyValue.Value.IntValue = &intValue
+	case uint8:
+		intValue = strconv.FormatUint(uint64(v), 10)
+		keyValue.Value.IntValue = &intValue
+	case uint16:
+		intValue = strconv.FormatUint(uint64(v), 10)
+		keyValue.Value.IntValue = &intValue
+	case uint32:
+		intValue = strconv.FormatUint(uint64(v), 10)
+		keyValue.Value.IntValue = &intValue
+	case uint64:
+		intValue = strconv.FormatUint(v, 10)
+		keyValue.Value.IntValue = &intValue
+	default:
+		stringValue = fmt.Sprintf("%v", v)
+		keyValue.Value.StringValue = &stringValue
+	}
+
+	return
+}
+
+func newOTLPSpan(span *SpanStruct) (otlpSpan *otlpSpanStruct) {
+	var (
+		attribute attributeStruct
+		zeroID    [8]byte
+	)
+
+	otlpSpan = &otlpSpanStruct{
+		TraceID:           hex.EncodeToString(span.traceID[:]),
+		SpanID:            hex.EncodeToString(span.spanID[:]),
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: strconv.FormatInt(span.startTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.endTime.UnixNano(), 10),
+		Attributes:        make([]*otlpKeyValueStruct, 0, len(span.attributes)),
+	}
+
+	if zeroID != span.parentSpanID {
+		otlpSpan.ParentSpanID = hex.EncodeToString(span.parentSpanID[:])
+	}
+
+	for _, attribute = range span.attributes {
+		otlpSpan.Attributes = append(otlpSpan.Attributes, newOTLPKeyValue(attribute.key, attribute.value))
+	}
+
+	if "" == span.statusMessage {
+		otlpSpan.Status.Code = otlpStatusCodeUnset
+	} else {
+		otlpSpan.Status.Code = otlpStatusCodeError
+		otlpSpan.Status.Message = span.statusMessage
+	}
+
+	return
+}
+
+// marshalSpans returns the OTLP/JSON encoding of an ExportTraceServiceRequest holding spanSlice.
+//
+func marshalSpans(config *ConfigStruct, spanSlice []*SpanStruct) (buf []byte, err error) {
+	var (
+		hostName      string
+		otlpSpanSlice []*otlpSpanStruct
+		span          *SpanStruct
+		tracesData    *otlpTracesDataStruct
+	)
+
+	otlpSpanSlice = make([]*otlpSpanStruct, 0, len(spanSlice))
+
+	for _, span = range spanSlice {
+		otlpSpanSlice = append(otlpSpanSlice, newOTLPSpan(span))
+	}
+
+	hostName, err = os.Hostname()
+	if nil != err {
+		hostName = ""
+	}
+
+	tracesData = &otlpTracesDataStruct{
+		ResourceSpans: []*otlpResourceSpansStruct{
+			{
+				Resource: otlpResourceStruct{
+					Attributes: []*otlpKeyValueStruct{
+						newOTLPKeyValue("service.name", config.ServiceName),
+						newOTLPKeyValue("service.version", version.ProxyFSVersion),
+						newOTLPKeyValue("host.name", hostName),
+						newOTLPKeyValue("process.pid", os.Getpid()),
+					},
+				},
+				ScopeSpans: []*otlpScopeSpansStruct{
+					{
+						Scope: otlpScopeStruct{
+							Name:    otlpScopeName,
+							Version: version.ProxyFSVersion,
+						},
+						Spans: otlpSpanSlice,
+					},
+				},
+			},
+		},
+	}
+
+	buf, err = json.Marshal(tracesData)
+
+	return
+}
+
+func exportSpans(config *ConfigStruct, httpClient *http.Client, spanSlice []*SpanStruct) {
+	var (
+		buf          []byte
+		err          error
+		exportFile   *os.File
+		httpResponse *http.Response
+	)
+
+	if 0 == len(spanSlice) {
+		return
+	}
+
+	buf, err = marshalSpans(config, spanSlice)
+	if nil != err {
+		logger.Warnf("tracing: unable to marshal %d Spans: %v", len(spanSlice), err)
+		return
+	}
+
+	if "" != config.ExportFilePath {
+		exportFile, err = os.OpenFile(config.ExportFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, exportFilePerm)
+		if nil == err {
+			_, err = exportFile.Write(append(buf, '\n'))
+			if nil == err {
+				err = exportFile.Close()
+			} else {
+				_ = exportFile.Close()
+			}
+		}
+		if nil != err {
+			logger.Warnf("tracing: unable to export %d Spans to \"%s\": %v", len(spanSlice), config.ExportFilePath, err)
+		}
+	}
+
+	if "" != config.ExportEndpoint {
+		httpResponse, err = httpClient.Post(config.ExportEndpoint, exportEndpointContentType, bytes.NewReader(buf))
+		if nil == err {
+			_ = httpResponse.Body.Close()
+			if (httpResponse.StatusCode < http.StatusOK) || (httpResponse.StatusCode >= http.StatusMultipleChoices) {
+				err = fmt.Errorf("received %s", httpResponse.Status)
+			}
+		}
+		if nil != err {
+			logger.Warnf("tracing: unable to export %d Spans to \"%s\": %v", len(spanSlice), config.ExportEndpoint, err)
+		}
+	}
+
+	globals.Lock()
+	globals.exportedSpanCount += uint64(len(spanSlice))
+	globals.Unlock()
+}
+
+// exporter batches completed Spans arriving on spanChan exporting them when
+// either ExportBatchSize have accumulated or ExportInterval has elapsed. Upon
+// being signaled via stopChan, any remaining Spans are exported before exiting.
+//
+func exporter(config *ConfigStruct, spanChan chan *SpanStruct, stopChan chan struct{}, doneChan chan struct{}) {
+	var (
+		httpClient *http.Client
+		span       *SpanStruct
+		spanSlice  []*SpanStruct
+		ticker     *time.Ticker
+	)
+
+	httpClient = &http.Client{
+		Timeout: config.ExportInterval,
+	}
+
+	spanSlice = make([]*SpanStruct, 0, config.ExportBatchSize)
+
+	ticker = time.NewTicker(config.ExportInterval)
+
+	for {
+		select {
+		case span = <-spanChan:
+			spanSlice = append(spanSlice, span)
+			if uint64(len(spanSlice)) >= config.ExportBatchSize {
+				exportSpans(config, httpClient, spanSlice)
+				spanSlice = make([]*SpanStruct, 0, config.ExportBatchSize)
+			}
+		case <-ticker.C:
+			exportSpans(config, httpClient, spanSlice)
+			spanSlice = make([]*SpanStruct, 0, config.ExportBatchSize)
+		case <-stopChan:
+			ticker.Stop()
+			for {
+				select {
+				case span = <-spanChan:
+					spanSlice = append(spanSlice, span)
+				default:
+					exportSpans(config, httpClient, spanSlice)
+					close(doneChan)
+					return
+				}
+			}
+		}
+	}
+}